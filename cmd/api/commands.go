@@ -0,0 +1,305 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/google/uuid"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/app"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/staffinvitation"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/roles"
+)
+
+// defaultCommandTimeout bounds a maintenance command unless --timeout is
+// given.
+const defaultCommandTimeout = 2 * time.Minute
+
+// command is a maintenance subcommand, `ucms-api <group> <name> [args]`. It
+// runs against the database of the configuration, next to the serving API.
+type command struct {
+	group   string
+	name    string
+	usage   string
+	summary string
+	// parse reads the arguments after the name, so that a typo is reported
+	// before connecting to the database.
+	parse func(fs *flag.FlagSet, args []string) (action, error)
+}
+
+// action runs a parsed command and prints its result summary to out.
+type action func(ctx context.Context, m *app.Maintenance, out io.Writer) error
+
+var commands = []command{
+	{
+		group:   "user",
+		name:    "promote",
+		usage:   "<barcode> --role=<role>",
+		summary: "raise the global role of a user: student, aitusa or staff",
+		parse:   parsePromoteUser,
+	},
+	{
+		group:   "invitation",
+		name:    "revoke",
+		usage:   "<id>",
+		summary: "delete a staff invitation, whoever created it",
+		parse:   parseRevokeInvitation,
+	},
+	{
+		group:   "registration",
+		name:    "purge",
+		usage:   "--older-than=<age>",
+		summary: "delete the unfinished registrations older than age, e.g. 30d or 12h",
+		parse:   parsePurgeRegistrations,
+	},
+	{
+		group:   "mail",
+		name:    "requeue-dead-letters",
+		usage:   "",
+		summary: "send the emails of the poison queue again",
+		parse:   parseRequeueDeadLetters,
+	},
+}
+
+// isCommand reports whether args name a maintenance command group rather
+// than the flags of the server.
+func isCommand(args []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+	for _, c := range commands {
+		if c.group == args[0] {
+			return true
+		}
+	}
+	return false
+}
+
+// runCommand runs the maintenance command of args and returns the exit code:
+// 0 on success, 1 when the command fails and 2 on a usage error.
+func runCommand(args []string, lookup func(string) (string, bool), stdout, stderr io.Writer) int {
+	c, ok := findCommand(args)
+	if !ok {
+		printUsage(stderr)
+		return 2
+	}
+
+	fs := flag.NewFlagSet(c.group+" "+c.name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: ucms-api %s %s %s\n", c.group, c.name, c.usage)
+		fs.PrintDefaults()
+	}
+	timeout := fs.Duration("timeout", defaultCommandTimeout, "how long the command may run")
+	run, err := c.parse(fs, args[2:])
+	if err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			fs.Usage()
+		}
+		return 2
+	}
+	if *timeout <= 0 {
+		fmt.Fprintf(stderr, "Error: --timeout must be positive, got %s\n", *timeout)
+		return 2
+	}
+
+	config, err := loadConfig(lookup)
+	if err != nil {
+		fmt.Fprintf(stderr, "Invalid configuration: %v\n", err)
+		return 1
+	}
+	applyProcessSettings(config)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	ctx, cancel := context.WithTimeout(ctx, *timeout)
+	defer cancel()
+
+	m, err := app.NewMaintenance(ctx, config)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	defer m.Close()
+
+	if err := run(ctx, m, stdout); err != nil {
+		fmt.Fprintf(stderr, "Error: %s %s failed: %v\n", c.group, c.name, err)
+		return 1
+	}
+	return 0
+}
+
+func findCommand(args []string) (command, bool) {
+	if len(args) < 2 {
+		return command{}, false
+	}
+	for _, c := range commands {
+		if c.group == args[0] && c.name == args[1] {
+			return c, true
+		}
+	}
+	return command{}, false
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: ucms-api [--drain-timeout=<duration>]")
+	fmt.Fprintln(w, "       ucms-api <command> [args] [--timeout=<duration>]")
+	fmt.Fprintln(w, "\nCommands:")
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %s %s %s\n", c.group, c.name, c.usage)
+		fmt.Fprintf(w, "      %s\n", c.summary)
+	}
+}
+
+// parseArgs parses the flags of fs wherever they are among args, and
+// returns the positional arguments.
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+func parsePromoteUser(fs *flag.FlagSet, args []string) (action, error) {
+	role := fs.String("role", "", "the new role: student, aitusa or staff")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return nil, err
+	}
+	if len(positional) != 1 {
+		return nil, errors.New("expected the barcode of the user")
+	}
+	if *role == "" {
+		return nil, errors.New("--role is required")
+	}
+	newRole, err := roles.Parse(*role)
+	if err != nil {
+		return nil, err
+	}
+	barcode, err := user.NewBarcode(positional[0])
+	if err != nil {
+		return nil, err
+	}
+
+	return func(ctx context.Context, m *app.Maintenance, out io.Writer) error {
+		res, err := m.PromoteUser(ctx, barcode, newRole)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Promoted user %s (%s) from %s to %s\n", res.Username, barcode, res.FromRole, res.ToRole)
+		return nil
+	}, nil
+}
+
+func parseRevokeInvitation(fs *flag.FlagSet, args []string) (action, error) {
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return nil, err
+	}
+	if len(positional) != 1 {
+		return nil, errors.New("expected the id of the invitation")
+	}
+	uid, err := uuid.Parse(positional[0])
+	if err != nil {
+		return nil, fmt.Errorf("invalid invitation id %q: %w", positional[0], err)
+	}
+	id := staffinvitation.ID(uid)
+
+	return func(ctx context.Context, m *app.Maintenance, out io.Writer) error {
+		if err := m.RevokeInvitation(ctx, id); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Revoked staff invitation %s\n", id)
+		return nil
+	}, nil
+}
+
+func parsePurgeRegistrations(fs *flag.FlagSet, args []string) (action, error) {
+	olderThan := fs.String("older-than", "", "the age of the registrations to delete, e.g. 30d or 12h")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return nil, err
+	}
+	if len(positional) != 0 {
+		return nil, fmt.Errorf("unexpected arguments %q", positional)
+	}
+	if *olderThan == "" {
+		return nil, errors.New("--older-than is required")
+	}
+	age, err := parseAge(*olderThan)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(ctx context.Context, m *app.Maintenance, out io.Writer) error {
+		res, err := m.PurgeRegistrations(ctx, age)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Deleted %d unfinished registrations created before %s\n",
+			res.Deleted, res.Cutoff.Format(time.RFC3339))
+		return nil
+	}, nil
+}
+
+func parseRequeueDeadLetters(fs *flag.FlagSet, args []string) (action, error) {
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return nil, err
+	}
+	if len(positional) != 0 {
+		return nil, fmt.Errorf("unexpected arguments %q", positional)
+	}
+
+	return func(ctx context.Context, m *app.Maintenance, out io.Writer) error {
+		res, err := m.RequeueMailDeadLetters(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Requeued %d mail dead letters, %d failed again\n", res.Redelivered, res.Failed)
+		if res.Failed > 0 {
+			return fmt.Errorf("%d dead letters failed again and stay in the poison queue, see the log", res.Failed)
+		}
+		return nil
+	}, nil
+}
+
+// parseAge parses a positive duration, in days with the d suffix, e.g. 30d,
+// or in the time.ParseDuration format.
+func parseAge(s string) (time.Duration, error) {
+	var age time.Duration
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("invalid age %q: %w", s, err)
+		}
+		age = time.Duration(n) * 24 * time.Hour
+	} else {
+		var err error
+		if age, err = time.ParseDuration(s); err != nil {
+			return 0, fmt.Errorf("invalid age %q: %w", s, err)
+		}
+	}
+	if age <= 0 {
+		return 0, fmt.Errorf("the age must be positive, got %q", s)
+	}
+	return age, nil
+}
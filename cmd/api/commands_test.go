@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAge(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{in: "30d", want: 30 * 24 * time.Hour},
+		{in: "1d", want: 24 * time.Hour},
+		{in: "12h", want: 12 * time.Hour},
+		{in: "90m", want: 90 * time.Minute},
+		{in: "0d", wantErr: true},
+		{in: "-1d", wantErr: true},
+		{in: "d", wantErr: true},
+		{in: "30", wantErr: true},
+		{in: "thirty days", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := parseAge(tt.in)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestIsCommand(t *testing.T) {
+	assert.True(t, isCommand([]string{"user", "promote"}))
+	assert.True(t, isCommand([]string{"mail"}))
+	assert.False(t, isCommand(nil))
+	assert.False(t, isCommand([]string{"--drain-timeout=5s"}))
+}
+
+// TestRunCommand_UsageErrors covers the errors reported before the
+// configuration is loaded, the commands themselves are tested against the
+// database in tests/system.
+func TestRunCommand_UsageErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{name: "unknown command", args: []string{"user", "delete"}, want: "Commands:"},
+		{name: "group only", args: []string{"mail"}, want: "requeue-dead-letters"},
+		{name: "unknown role", args: []string{"user", "promote", "230001", "--role=admin"}, want: `unknown role "admin"`},
+		{name: "missing role", args: []string{"user", "promote", "230001"}, want: "--role is required"},
+		{name: "missing barcode", args: []string{"user", "promote", "--role=staff"}, want: "expected the barcode"},
+		{name: "invalid invitation id", args: []string{"invitation", "revoke", "42"}, want: `invalid invitation id "42"`},
+		{name: "missing age", args: []string{"registration", "purge"}, want: "--older-than is required"},
+		{name: "invalid age", args: []string{"registration", "purge", "--older-than=30x"}, want: `invalid age "30x"`},
+		{name: "unexpected argument", args: []string{"mail", "requeue-dead-letters", "now"}, want: "unexpected arguments"},
+		{name: "invalid timeout", args: []string{"mail", "requeue-dead-letters", "--timeout=0s"}, want: "--timeout must be positive"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			lookup := func(string) (string, bool) {
+				t.Fatal("the configuration is loaded after a usage error")
+				return "", false
+			}
+
+			code := runCommand(tt.args, lookup, &stdout, &stderr)
+
+			assert.Equal(t, 2, code)
+			assert.Contains(t, stderr.String(), tt.want)
+			assert.Empty(t, stdout.String())
+		})
+	}
+}
+
+func TestRunCommand_InvalidConfig(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	lookup := func(key string) (string, bool) {
+		if key == "DRAIN_TIMEOUT" {
+			return "soon", true
+		}
+		return "", false
+	}
+
+	code := runCommand([]string{"registration", "purge", "--older-than=30d"}, lookup, &stdout, &stderr)
+
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr.String(), "Invalid configuration")
+}
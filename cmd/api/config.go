@@ -0,0 +1,222 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/app"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/emails"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/env"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelsdk"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/retention"
+)
+
+// loadConfig reads the configuration in three layers: the defaults, the YAML
+// file named by CONFIG_FILE, then the environment variables that are set.
+// The secrets can be given as files as well, e.g. PG_DSN_FILE, see
+// env.Vars.GetSecret.
+func loadConfig(lookup func(string) (string, bool)) (*app.Config, error) {
+	vars := env.NewVars(lookup)
+	cfg := app.DefaultConfig()
+
+	// The redaction default depends on the mode, it applies unless the file
+	// sets redact_pii.
+	redactPIISet := false
+	if path := vars.GetString("CONFIG_FILE", ""); path != "" {
+		var err error
+		if redactPIISet, err = decodeConfigFile(path, cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	cfg.Mode = vars.GetMode("MODE", cfg.Mode)
+	cfg.Port = vars.GetString("PORT", cfg.Port)
+	cfg.ReusePort = vars.GetBool("REUSE_PORT", cfg.ReusePort)
+	cfg.DrainTimeout = vars.GetDuration("DRAIN_TIMEOUT", cfg.DrainTimeout)
+	cfg.TLS.CertFile = vars.GetString("TLS_CERT_FILE", cfg.TLS.CertFile)
+	cfg.TLS.KeyFile = vars.GetString("TLS_KEY_FILE", cfg.TLS.KeyFile)
+	cfg.TLS.RedirectPort = vars.GetString("TLS_REDIRECT_PORT", cfg.TLS.RedirectPort)
+	cfg.Admin.Host = vars.GetString("ADMIN_HOST", cfg.Admin.Host)
+	cfg.Admin.Port = vars.GetString("ADMIN_PORT", cfg.Admin.Port)
+	cfg.PgDSN = vars.GetSecret("PG_DSN", cfg.PgDSN)
+	cfg.DBStartupMaxWait = vars.GetDuration("DB_STARTUP_MAX_WAIT", cfg.DBStartupMaxWait)
+	cfg.LogPath = vars.GetString("LOG_PATH", cfg.LogPath)
+	cfg.AccessTokenSecretKey = vars.GetSecret("ACCESS_TOKEN_SECRET", cfg.AccessTokenSecretKey)
+	cfg.RefreshTokenSecretKey = vars.GetSecret("REFRESH_TOKEN_SECRET", cfg.RefreshTokenSecretKey)
+	vars.GetText(vars.Alias("STAFF_INVITATION_LINK_URL", "STAFF_INVITATION_BASE_URL"), &cfg.StaffInvitationLinkURL)
+	vars.GetText(vars.Alias("ACCEPT_INVITATION_PAGE_URL", "STAFF_INVITATION_PAGE_URL"), &cfg.AcceptInvitationPageURL)
+	cfg.InvitationTokenSecretKey = vars.GetSecret("INVITATION_TOKEN_SECRET", cfg.InvitationTokenSecretKey)
+
+	cfg.TokenTTL.Access = vars.GetDuration("ACCESS_TOKEN_TTL", cfg.TokenTTL.Access)
+	cfg.TokenTTL.Refresh = vars.GetDuration("REFRESH_TOKEN_TTL", cfg.TokenTTL.Refresh)
+	cfg.TokenTTL.Invitation = vars.GetDuration("INVITATION_TOKEN_TTL", cfg.TokenTTL.Invitation)
+
+	cfg.Service.Namespace = vars.GetString("SERVICE_NAMESPACE", cfg.Service.Namespace)
+	cfg.Service.Name = vars.GetString("SERVICE_NAME", cfg.Service.Name)
+	cfg.Service.Version = vars.GetString("SERVICE_VERSION", cfg.Service.Version)
+	cfg.Service.InstanceId = vars.GetString("SERVICE_INSTANCE_ID", cfg.Service.InstanceId)
+	cfg.Service.CampusID = vars.GetString("CAMPUS_ID", cfg.Service.CampusID)
+
+	if !redactPIISet {
+		cfg.RedactPII = otelx.RedactionEnabledFor(cfg.Mode)
+	}
+	cfg.RedactPII = vars.GetBool("REDACT_PII", cfg.RedactPII)
+	cfg.EmailPolicy.LowercaseLocal = vars.GetBool("EMAIL_LOWERCASE_LOCAL_PART", cfg.EmailPolicy.LowercaseLocal)
+	vars.GetText("BARCODE_STUDENT_PROFILE", &cfg.BarcodeProfiles.Student)
+	vars.GetText("BARCODE_STAFF_PROFILE", &cfg.BarcodeProfiles.Staff)
+	cfg.SlowThresholds.Handler = vars.GetMillis("SLOW_HANDLER_THRESHOLD_MS", cfg.SlowThresholds.Handler)
+	cfg.SlowThresholds.Query = vars.GetMillis("SLOW_QUERY_THRESHOLD_MS", cfg.SlowThresholds.Query)
+
+	cfg.S3.Endpoint = vars.GetString("S3_ENDPOINT", cfg.S3.Endpoint)
+	cfg.S3.AccessKey = vars.GetSecret("S3_ACCESS_KEY", cfg.S3.AccessKey)
+	cfg.S3.SecretKey = vars.GetSecret("S3_SECRET_KEY", cfg.S3.SecretKey)
+	cfg.S3.Bucket = vars.GetString("S3_BUCKET", cfg.S3.Bucket)
+	cfg.S3.Region = vars.GetString("S3_REGION", cfg.S3.Region)
+	cfg.S3.BaseURL = vars.GetString("S3_BASE_URL", cfg.S3.BaseURL)
+	cfg.S3.UsePathStyle = vars.GetBool("S3_USE_PATH_STYLE", cfg.S3.UsePathStyle)
+	cfg.S3.BootstrapBucket = vars.GetBool("S3_BOOTSTRAP_BUCKET", cfg.S3.BootstrapBucket)
+	cfg.S3.TraceFullKeys = vars.GetBool("S3_TRACE_FULL_KEYS", cfg.S3.TraceFullKeys)
+
+	cfg.Storage.Backend = vars.GetString("STORAGE_BACKEND", cfg.Storage.Backend)
+	cfg.Storage.FSRoot = vars.GetString("FS_STORAGE_ROOT", cfg.Storage.FSRoot)
+	cfg.Storage.FSBaseURL = vars.GetString("FS_STORAGE_BASE_URL", cfg.Storage.FSBaseURL)
+	cfg.Storage.FSSigningKey = vars.GetSecret("FS_STORAGE_SIGNING_KEY", cfg.Storage.FSSigningKey)
+	cfg.Storage.URLStrategy = vars.GetString("STORAGE_URL_STRATEGY", cfg.Storage.URLStrategy)
+	cfg.Storage.SignedURLExpiry = vars.GetDuration("STORAGE_SIGNED_URL_EXPIRY", cfg.Storage.SignedURLExpiry)
+	cfg.Storage.CDNBaseURL = vars.GetString("CDN_BASE_URL", cfg.Storage.CDNBaseURL)
+	cfg.Storage.CDNKeyPairID = vars.GetString("CDN_KEY_PAIR_ID", cfg.Storage.CDNKeyPairID)
+	cfg.Storage.CDNPrivateKeyPath = vars.GetString("CDN_PRIVATE_KEY_PATH", cfg.Storage.CDNPrivateKeyPath)
+	cfg.Storage.VerifyAvatars = vars.GetBool("AVATAR_VERIFY_OBJECTS", cfg.Storage.VerifyAvatars)
+	cfg.Storage.VerifyTTL = vars.GetDuration("AVATAR_VERIFY_TTL", cfg.Storage.VerifyTTL)
+
+	cfg.AvatarGC.Interval = vars.GetDuration("AVATAR_GC_INTERVAL", cfg.AvatarGC.Interval)
+	cfg.AvatarGC.GracePeriod = vars.GetDuration("AVATAR_GC_GRACE_PERIOD", cfg.AvatarGC.GracePeriod)
+	cfg.AvatarGC.DryRun = vars.GetBool("AVATAR_GC_DRY_RUN", cfg.AvatarGC.DryRun)
+	cfg.WeeklyReport.Enabled = vars.GetBool("WEEKLY_REPORT_ENABLED", cfg.WeeklyReport.Enabled)
+	cfg.WeeklyReport.At = vars.GetDuration("WEEKLY_REPORT_AT", cfg.WeeklyReport.At)
+	cfg.Retention.Interval = vars.GetDuration("RETENTION_INTERVAL", cfg.Retention.Interval)
+	// RETENTION_<CATEGORY>_DAYS and _MODE, e.g. RETENTION_AUTH_AUDIT_DAYS.
+	for _, c := range retention.Categories {
+		prefix := "RETENTION_" + strings.ToUpper(string(c))
+		p := cfg.Retention.Policies[c]
+		p.Days = vars.GetInt(prefix+"_DAYS", p.Days)
+		vars.GetText(prefix+"_MODE", &p.Mode)
+		if p == (retention.Policy{}) {
+			continue
+		}
+		if cfg.Retention.Policies == nil {
+			cfg.Retention.Policies = make(retention.Policies)
+		}
+		cfg.Retention.Policies[c] = p
+	}
+
+	cfg.Scanner.Backend = vars.GetString("SCANNER_BACKEND", cfg.Scanner.Backend)
+	cfg.Scanner.ClamAVAddr = vars.GetString("CLAMAV_ADDR", cfg.Scanner.ClamAVAddr)
+	cfg.Scanner.Timeout = vars.GetDuration("SCANNER_TIMEOUT", cfg.Scanner.Timeout)
+	cfg.Scanner.FailOpen = vars.GetBool("SCANNER_FAIL_OPEN", cfg.Scanner.FailOpen)
+
+	cfg.BreachCheck.URL = vars.GetString("PASSWORD_BREACH_CHECK_URL", cfg.BreachCheck.URL)
+	cfg.BreachCheck.Timeout = vars.GetDuration("PASSWORD_BREACH_CHECK_TIMEOUT", cfg.BreachCheck.Timeout)
+
+	if initialStaffEmail := vars.GetString("INITIAL_STAFF_EMAIL", ""); initialStaffEmail != "" {
+		email, err := emails.New(initialStaffEmail)
+		if err != nil {
+			return nil, fmt.Errorf("invalid INITIAL_STAFF_EMAIL %q: %w", initialStaffEmail, err)
+		}
+		initialStaffBarcode := vars.GetString("INITIAL_STAFF_BARCODE", "000000")
+		barcode, err := user.NewBarcode(initialStaffBarcode)
+		if err != nil {
+			return nil, fmt.Errorf("invalid INITIAL_STAFF_BARCODE %q: %w", initialStaffBarcode, err)
+		}
+		cfg.InitialStaff = &user.CreateInitialStaffArgs{
+			Username:  vars.GetString("INITIAL_STAFF_USERNAME", "admin"),
+			Email:     email,
+			Password:  vars.GetSecret("INITIAL_STAFF_PASSWORD", "StrongP@ssw0rd"),
+			Barcode:   barcode,
+			FirstName: vars.GetString("INITIAL_STAFF_FIRST_NAME", "Admin"),
+			LastName:  vars.GetString("INITIAL_STAFF_LAST_NAME", "User"),
+		}
+		cfg.InitialStaffRotatePassword = vars.GetBool("INITIAL_STAFF_ROTATE_PASSWORD", false)
+	}
+
+	// The exporter headers usually carry an authorization token.
+	otelHeaders := vars.GetSecret("OTEL_EXPORTER_OTLP_HEADERS", "")
+	otelConfig, err := otelsdk.LoadConfig(func(key string) string {
+		if key == "OTEL_EXPORTER_OTLP_HEADERS" {
+			return otelHeaders
+		}
+		value, _ := lookup(key)
+		return value
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid OpenTelemetry configuration: %w", err)
+	}
+	cfg.OTel = otelConfig
+
+	for _, deprecated := range vars.Deprecated() {
+		slog.Warn("Deprecated environment variable, rename it",
+			"variable", deprecated.Key, "replacement", deprecated.Replacement)
+	}
+
+	// Every variable has a default for now, Require reports the invalid
+	// values read above.
+	if err := vars.Require(); err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// applyFlags applies the command line flags over cfg:
+//
+//	--drain-timeout  overrides DRAIN_TIMEOUT, e.g. for a deploy that can not wait 30s
+func applyFlags(cfg *app.Config, args []string) error {
+	fs := flag.NewFlagSet("api", flag.ContinueOnError)
+	drainTimeout := fs.Duration("drain-timeout", cfg.DrainTimeout,
+		"how long the shutdown waits for the requests in flight, overrides DRAIN_TIMEOUT")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *drainTimeout <= 0 {
+		return fmt.Errorf("--drain-timeout must be positive, got %s", *drainTimeout)
+	}
+	cfg.DrainTimeout = *drainTimeout
+	return nil
+}
+
+// decodeConfigFile decodes the YAML file at path over cfg, the keys it omits
+// keep their value. Unknown keys are an error, so that a typo does not
+// silently leave the default in place. It reports whether the file sets
+// redact_pii.
+func decodeConfigFile(path string, cfg *app.Config) (redactPIISet bool, err error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return false, fmt.Errorf("read config file: %w", err)
+	}
+
+	dec := yaml.NewDecoder(bytes.NewReader(content))
+	dec.KnownFields(true)
+	if err := dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return false, fmt.Errorf("decode config file %s: %w", path, err)
+	}
+
+	var set struct {
+		RedactPII *bool `yaml:"redact_pii"`
+	}
+	if err := yaml.Unmarshal(content, &set); err != nil {
+		return false, fmt.Errorf("decode config file %s: %w", path, err)
+	}
+	return set.RedactPII != nil, nil
+}
@@ -0,0 +1,327 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/app"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/env"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/retention"
+)
+
+func lookupMap(m map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		v, ok := m[key]
+		return v, ok
+	}
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoadConfig_Defaults(t *testing.T) {
+	cfg, err := loadConfig(lookupMap(nil))
+	require.NoError(t, err)
+
+	assert.Equal(t, env.Dev, cfg.Mode)
+	assert.Equal(t, "8080", cfg.Port)
+	assert.Equal(t, "ucms-api", cfg.Service.Name)
+	assert.Equal(t, 15*time.Minute, cfg.TokenTTL.Invitation)
+	assert.Nil(t, cfg.InitialStaff)
+	assert.Equal(t, app.AdminConfig{Host: "127.0.0.1"}, cfg.Admin, "the ops routes stay on the public router")
+	assert.Equal(t, time.Minute, cfg.DBStartupMaxWait)
+}
+
+func TestLoadConfig_DBStartupMaxWait(t *testing.T) {
+	cfg, err := loadConfig(lookupMap(map[string]string{"DB_STARTUP_MAX_WAIT": "0s"}))
+	require.NoError(t, err)
+	assert.Zero(t, cfg.DBStartupMaxWait, "0 connects once")
+
+	_, err = loadConfig(lookupMap(map[string]string{"DB_STARTUP_MAX_WAIT": "-1s"}))
+	assert.ErrorContains(t, err, "DB_STARTUP_MAX_WAIT must not be negative")
+}
+
+func TestLoadConfig_Admin(t *testing.T) {
+	cfg, err := loadConfig(lookupMap(map[string]string{"ADMIN_PORT": "9090"}))
+	require.NoError(t, err)
+	assert.Equal(t, app.AdminConfig{Host: "127.0.0.1", Port: "9090"}, cfg.Admin)
+
+	_, err = loadConfig(lookupMap(map[string]string{"ADMIN_PORT": "8080"}))
+	assert.ErrorContains(t, err, "ADMIN_PORT 8080 must differ from PORT")
+}
+
+func TestLoadConfig_Precedence(t *testing.T) {
+	path := writeFile(t, "config.yaml", `
+mode: prod
+port: "9090"
+pg_dsn: postgres://file:file@db:5432/ucms
+service:
+  name: ucms-file
+  campus_id: south
+s3:
+  bucket: file-bucket
+  use_path_style: false
+token_ttl:
+  access: 5m
+slow_thresholds:
+  query: 2s
+`)
+
+	cfg, err := loadConfig(lookupMap(map[string]string{
+		"CONFIG_FILE":  path,
+		"PORT":         "7070",
+		"SERVICE_NAME": "ucms-env",
+		"S3_BUCKET":    "",
+	}))
+	require.NoError(t, err)
+
+	// the environment overrides the file
+	assert.Equal(t, "7070", cfg.Port)
+	assert.Equal(t, "ucms-env", cfg.Service.Name)
+	// the file overrides the defaults
+	assert.Equal(t, env.Prod, cfg.Mode)
+	assert.Equal(t, "postgres://file:file@db:5432/ucms", cfg.PgDSN)
+	assert.Equal(t, "south", cfg.Service.CampusID)
+	assert.Equal(t, "file-bucket", cfg.S3.Bucket, "empty variables do not override the file")
+	assert.False(t, cfg.S3.UsePathStyle)
+	assert.Equal(t, 5*time.Minute, cfg.TokenTTL.Access)
+	assert.Equal(t, 2*time.Second, cfg.SlowThresholds.Query)
+	// the keys the file omits keep the defaults
+	assert.Equal(t, "ucms", cfg.Service.Namespace)
+	assert.Equal(t, "us-east-1", cfg.S3.Region)
+	assert.True(t, cfg.RedactPII, "prod redacts by default")
+}
+
+func TestLoadConfig_RedactPIIFromFile(t *testing.T) {
+	path := writeFile(t, "config.yaml", "mode: prod\nredact_pii: false\n")
+
+	cfg, err := loadConfig(lookupMap(map[string]string{"CONFIG_FILE": path}))
+	require.NoError(t, err)
+	assert.False(t, cfg.RedactPII)
+
+	cfg, err = loadConfig(lookupMap(map[string]string{"CONFIG_FILE": path, "REDACT_PII": "true"}))
+	require.NoError(t, err)
+	assert.True(t, cfg.RedactPII)
+}
+
+func TestLoadConfig_InvalidFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{name: "unknown key", content: "port: \"8080\"\nprot: \"9090\"\n", wantErr: "field prot not found"},
+		{name: "unknown nested key", content: "s3:\n  buckett: avatars\n", wantErr: "field buckett not found"},
+		{name: "env only key", content: "otel:\n  enabled: false\n", wantErr: "field otel not found"},
+		{name: "invalid duration", content: "token_ttl:\n  access: soon\n", wantErr: "decode config file"},
+		{name: "invalid mode", content: "mode: staging\n", wantErr: `invalid mode "staging"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeFile(t, "config.yaml", tt.content)
+			_, err := loadConfig(lookupMap(map[string]string{"CONFIG_FILE": path}))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+
+	t.Run("missing file", func(t *testing.T) {
+		_, err := loadConfig(lookupMap(map[string]string{"CONFIG_FILE": filepath.Join(t.TempDir(), "missing.yaml")}))
+		assert.ErrorContains(t, err, "read config file")
+	})
+
+	t.Run("empty file", func(t *testing.T) {
+		path := writeFile(t, "config.yaml", "")
+		cfg, err := loadConfig(lookupMap(map[string]string{"CONFIG_FILE": path}))
+		require.NoError(t, err)
+		assert.Equal(t, "8080", cfg.Port)
+	})
+}
+
+func TestLoadConfig_SecretFiles(t *testing.T) {
+	configPath := writeFile(t, "config.yaml", "access_token_secret: from-config\ns3:\n  secret_key: from-config\n")
+
+	cfg, err := loadConfig(lookupMap(map[string]string{
+		"CONFIG_FILE":                     configPath,
+		"PG_DSN_FILE":                     writeFile(t, "pg_dsn", "postgres://u:p@db/ucms\n"),
+		"ACCESS_TOKEN_SECRET_FILE":        writeFile(t, "access", "access-from-file\n"),
+		"REFRESH_TOKEN_SECRET_FILE":       writeFile(t, "refresh", "refresh-from-file"),
+		"INVITATION_TOKEN_SECRET_FILE":    writeFile(t, "invitation", "invitation-from-file"),
+		"S3_ACCESS_KEY_FILE":              writeFile(t, "s3_access", "s3-access-from-file"),
+		"S3_SECRET_KEY_FILE":              writeFile(t, "s3_secret", "s3-secret-from-file"),
+		"INITIAL_STAFF_EMAIL":             "admin@test.com",
+		"INITIAL_STAFF_PASSWORD_FILE":     writeFile(t, "staff_password", "P@ssw0rdFromFile"),
+		"INITIAL_STAFF_ROTATE_PASSWORD":   "true",
+		"OTEL_EXPORTER_OTLP_HEADERS_FILE": writeFile(t, "otel_headers", "authorization=Bearer%20token"),
+	}))
+	require.NoError(t, err)
+
+	assert.Equal(t, "postgres://u:p@db/ucms", cfg.PgDSN)
+	assert.Equal(t, "access-from-file", cfg.AccessTokenSecretKey, "secret files override the config file")
+	assert.Equal(t, "refresh-from-file", cfg.RefreshTokenSecretKey)
+	assert.Equal(t, "invitation-from-file", cfg.InvitationTokenSecretKey)
+	assert.Equal(t, "s3-access-from-file", cfg.S3.AccessKey)
+	assert.Equal(t, "s3-secret-from-file", cfg.S3.SecretKey)
+	require.NotNil(t, cfg.InitialStaff)
+	assert.Equal(t, "P@ssw0rdFromFile", cfg.InitialStaff.Password)
+	assert.True(t, cfg.InitialStaffRotatePassword)
+	assert.Equal(t, map[string]string{"authorization": "Bearer token"}, cfg.OTel.Headers)
+
+	t.Run("unreadable secret file", func(t *testing.T) {
+		_, err := loadConfig(lookupMap(map[string]string{
+			"PG_DSN_FILE": filepath.Join(t.TempDir(), "missing"),
+		}))
+		assert.ErrorContains(t, err, "PG_DSN_FILE")
+	})
+}
+
+func TestLoadConfig_InvitationURLs(t *testing.T) {
+	t.Run("trailing slashes are dropped", func(t *testing.T) {
+		path := writeFile(t, "config.yaml", "staff_invitation_link_url: https://ucms.kz/invitations/accept/\n")
+		cfg, err := loadConfig(lookupMap(map[string]string{
+			"CONFIG_FILE":                path,
+			"ACCEPT_INVITATION_PAGE_URL": "https://ucms.kz/staff/accept/",
+		}))
+		require.NoError(t, err)
+		assert.Equal(t, "https://ucms.kz/invitations/accept", cfg.StaffInvitationLinkURL.String())
+		assert.Equal(t, "https://ucms.kz/staff/accept", cfg.AcceptInvitationPageURL.String())
+	})
+
+	t.Run("deprecated variables are still read", func(t *testing.T) {
+		cfg, err := loadConfig(lookupMap(map[string]string{
+			"STAFF_INVITATION_BASE_URL": "https://old.ucms.kz/invitations",
+			"STAFF_INVITATION_PAGE_URL": "https://old.ucms.kz/accept",
+		}))
+		require.NoError(t, err)
+		assert.Equal(t, "https://old.ucms.kz/invitations", cfg.StaffInvitationLinkURL.String())
+		assert.Equal(t, "https://old.ucms.kz/accept", cfg.AcceptInvitationPageURL.String())
+	})
+
+	t.Run("new variables win over the deprecated ones", func(t *testing.T) {
+		cfg, err := loadConfig(lookupMap(map[string]string{
+			"STAFF_INVITATION_LINK_URL": "https://new.ucms.kz/invitations",
+			"STAFF_INVITATION_BASE_URL": "https://old.ucms.kz/invitations",
+		}))
+		require.NoError(t, err)
+		assert.Equal(t, "https://new.ucms.kz/invitations", cfg.StaffInvitationLinkURL.String())
+	})
+
+	invalid := []struct {
+		name string
+		vars map[string]string
+		file string
+	}{
+		{name: "relative", vars: map[string]string{"STAFF_INVITATION_LINK_URL": "/invitations/accept"}},
+		{name: "other scheme", vars: map[string]string{"ACCEPT_INVITATION_PAGE_URL": "ftp://ucms.kz/accept"}},
+		{name: "deprecated variable", vars: map[string]string{"STAFF_INVITATION_PAGE_URL": "ucms.kz/accept"}},
+		{name: "config file", file: "accept_invitation_page_url: javascript:alert(1)\n"},
+		{name: "empty in the config file", file: "staff_invitation_link_url: \"\"\n"},
+	}
+	for _, tt := range invalid {
+		t.Run("invalid "+tt.name, func(t *testing.T) {
+			vars := map[string]string{}
+			for k, v := range tt.vars {
+				vars[k] = v
+			}
+			if tt.file != "" {
+				vars["CONFIG_FILE"] = writeFile(t, "config.yaml", tt.file)
+			}
+			_, err := loadConfig(lookupMap(vars))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestLoadConfig_TLS(t *testing.T) {
+	cfg, err := loadConfig(lookupMap(nil))
+	require.NoError(t, err)
+	assert.False(t, cfg.TLS.Enabled())
+
+	path := writeFile(t, "config.yaml", "tls:\n  cert_file: /etc/ucms/cert.pem\n  key_file: /etc/ucms/key.pem\n")
+	cfg, err = loadConfig(lookupMap(map[string]string{"CONFIG_FILE": path, "TLS_REDIRECT_PORT": "8081"}))
+	require.NoError(t, err)
+	assert.True(t, cfg.TLS.Enabled())
+	assert.Equal(t, "/etc/ucms/cert.pem", cfg.TLS.CertFile)
+	assert.Equal(t, "8081", cfg.TLS.RedirectPort)
+
+	invalid := []struct {
+		name    string
+		vars    map[string]string
+		wantErr string
+	}{
+		{name: "cert without key", vars: map[string]string{"TLS_CERT_FILE": "cert.pem"}, wantErr: "must be set together"},
+		{name: "key without cert", vars: map[string]string{"TLS_KEY_FILE": "key.pem"}, wantErr: "must be set together"},
+		{name: "redirect without tls", vars: map[string]string{"TLS_REDIRECT_PORT": "8081"}, wantErr: "TLS_REDIRECT_PORT requires"},
+		{
+			name:    "redirect on the server port",
+			vars:    map[string]string{"TLS_CERT_FILE": "cert.pem", "TLS_KEY_FILE": "key.pem", "TLS_REDIRECT_PORT": "8080"},
+			wantErr: "must differ from PORT",
+		},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := loadConfig(lookupMap(tt.vars))
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestLoadConfig_Drain(t *testing.T) {
+	cfg, err := loadConfig(lookupMap(map[string]string{"REUSE_PORT": "true", "DRAIN_TIMEOUT": "45s"}))
+	require.NoError(t, err)
+	assert.True(t, cfg.ReusePort)
+	assert.Equal(t, 45*time.Second, cfg.DrainTimeout)
+
+	require.NoError(t, applyFlags(cfg, nil))
+	assert.Equal(t, 45*time.Second, cfg.DrainTimeout, "the variable applies without the flag")
+
+	require.NoError(t, applyFlags(cfg, []string{"--drain-timeout", "5s"}))
+	assert.Equal(t, 5*time.Second, cfg.DrainTimeout, "the flag overrides the variable")
+
+	assert.ErrorContains(t, applyFlags(cfg, []string{"--drain-timeout=0s"}), "--drain-timeout must be positive")
+
+	_, err = loadConfig(lookupMap(map[string]string{"DRAIN_TIMEOUT": "0s"}))
+	assert.ErrorContains(t, err, "DRAIN_TIMEOUT must be positive")
+}
+
+func TestLoadConfig_Retention(t *testing.T) {
+	cfg, err := loadConfig(lookupMap(nil))
+	require.NoError(t, err)
+	assert.Empty(t, cfg.Retention.Policies, "the rows are kept forever by default")
+
+	path := writeFile(t, "config.yaml", "retention:\n  policies:\n    error_inbox:\n      days: 30\n    auth_audit:\n      days: 365\n      mode: archive\n")
+	cfg, err = loadConfig(lookupMap(map[string]string{
+		"CONFIG_FILE":               path,
+		"RETENTION_INTERVAL":        "6h",
+		"RETENTION_AUTH_AUDIT_DAYS": "180",
+		"RETENTION_MAIL_LOG_DAYS":   "90",
+		"RETENTION_MAIL_LOG_MODE":   "archive",
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, 6*time.Hour, cfg.Retention.Interval)
+	assert.Equal(t, retention.Policies{
+		retention.CategoryAuthAudit:  {Days: 180, Mode: retention.ModeArchive},
+		retention.CategoryMailLog:    {Days: 90, Mode: retention.ModeArchive},
+		retention.CategoryErrorInbox: {Days: 30},
+	}, cfg.Retention.Policies)
+
+	_, err = loadConfig(lookupMap(map[string]string{"RETENTION_ERROR_INBOX_MODE": "move"}))
+	assert.ErrorContains(t, err, "RETENTION_ERROR_INBOX_MODE")
+
+	_, err = loadConfig(lookupMap(map[string]string{"RETENTION_ERROR_INBOX_DAYS": "-1"}))
+	assert.ErrorContains(t, err, "must not be negative")
+
+	path = writeFile(t, "config.yaml", "retention:\n  policies:\n    sessions:\n      days: 1\n")
+	_, err = loadConfig(lookupMap(map[string]string{"CONFIG_FILE": path}))
+	assert.ErrorContains(t, err, `unknown retention category "sessions"`)
+}
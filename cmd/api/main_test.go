@@ -0,0 +1,22 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/app"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/env"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelsdk"
+)
+
+func TestNewResource_Campus(t *testing.T) {
+	res := newResource(&app.Config{
+		Mode:    env.Test,
+		Service: app.ServiceConfig{Name: "ucms-api", CampusID: "north"},
+	})
+
+	v, ok := res.Set().Value(otelsdk.CampusKey)
+	assert.True(t, ok)
+	assert.Equal(t, "north", v.AsString())
+}
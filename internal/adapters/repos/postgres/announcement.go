@@ -0,0 +1,306 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/announcement"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/group"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/env"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/postgres"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/watermillx"
+)
+
+type AnnouncementRepo struct {
+	tracer  trace.Tracer
+	pool    *pgxpool.Pool
+	wlogger watermill.LoggerAdapter
+	clock   clock.Clock
+}
+
+// NewAnnouncementRepo creates a new AnnouncementRepo.
+// It also sets default tracer and logger if they are nil.
+//
+//	WARNING: panics if pool is nil
+func NewAnnouncementRepo(pool *pgxpool.Pool, t trace.Tracer, l *slog.Logger) *AnnouncementRepo {
+	if pool == nil {
+		panic("pgxpool.Pool is required")
+	}
+	if t == nil {
+		t = tracer
+	}
+	if l == nil {
+		l = logger
+	}
+
+	return &AnnouncementRepo{
+		tracer:  t,
+		pool:    pool,
+		wlogger: watermillx.NewOTelFilteredSlogLogger(l, env.Current().SlogLevel()),
+	}
+}
+
+// WithClock sets the clock the loaded announcements are rehydrated with,
+// clock.Real by default.
+func (r *AnnouncementRepo) WithClock(c clock.Clock) *AnnouncementRepo {
+	r.clock = c
+	return r
+}
+
+const announcementColumns = `id, author_id, title, body, target_group_ids, send_email, created_at, unpublished_at, unpublished_by`
+
+func (r *AnnouncementRepo) SaveAnnouncement(ctx context.Context, a *announcement.Announcement) error {
+	const op = "postgres.AnnouncementRepo.SaveAnnouncement"
+	ctx, span := r.tracer.Start(ctx, "AnnouncementRepo.SaveAnnouncement")
+	defer span.End()
+	span.SetAttributes(attribute.String("announcement.id", a.ID().String()))
+
+	dto := AnnouncementToDTO(a)
+	err := postgres.WithTx(ctx, r.pool, func(ctx context.Context, tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO announcements (`+announcementColumns+`)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9);
+		`, dto.ID, dto.AuthorID, dto.Title, dto.Body, dto.TargetGroupIDs, dto.SendEmail,
+			dto.CreatedAt, dto.UnpublishedAt, dto.UnpublishedBy)
+		if err != nil {
+			otelx.RecordSpanError(span, err, "failed to insert announcement")
+			return translateError(err, op)
+		}
+
+		if events := a.GetUncommittedEvents(); len(events) > 0 {
+			if err := saveVersion(ctx, tx, "announcements", dto.ID, a); err != nil {
+				otelx.RecordSpanError(span, err, "failed to save version")
+				return errorx.Wrap(err, op)
+			}
+			if err := watermillx.Publish(ctx, tx, r.wlogger, events...); err != nil {
+				otelx.RecordSpanError(span, err, "failed to publish events")
+				return errorx.Wrap(err, op)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to execute transaction")
+		return err
+	}
+	a.CommitEvents()
+
+	return nil
+}
+
+// UpdateAnnouncement locks the announcement id, runs fn on it and saves it
+// with the events it recorded.
+func (r *AnnouncementRepo) UpdateAnnouncement(
+	ctx context.Context,
+	id announcement.ID,
+	fn func(context.Context, *announcement.Announcement) error,
+) error {
+	const op = "postgres.AnnouncementRepo.UpdateAnnouncement"
+	ctx, span := r.tracer.Start(ctx, "AnnouncementRepo.UpdateAnnouncement")
+	defer span.End()
+	span.SetAttributes(attribute.String("announcement.id", id.String()))
+	if fn == nil {
+		otelx.RecordSpanError(span, ErrNilFunc, "update function cannot be nil")
+		return ErrNilFunc
+	}
+
+	var updated *announcement.Announcement
+	err := postgres.WithTx(ctx, r.pool, func(ctx context.Context, tx pgx.Tx) error {
+		dto, err := scanAnnouncement(tx.QueryRow(ctx, `
+			SELECT `+announcementColumns+`, version
+			FROM announcements
+			WHERE id = $1
+			FOR UPDATE;
+		`, id))
+		if err != nil {
+			otelx.RecordSpanError(span, err, "failed to get announcement")
+			if errors.Is(err, pgx.ErrNoRows) {
+				return errorx.NewNotFound().WithCause(err, op)
+			}
+			return errorx.Wrap(err, op)
+		}
+
+		updated = AnnouncementToDomain(dto, r.clock)
+		if err := fn(ctx, updated); err != nil {
+			otelx.RecordSpanError(span, err, "update function returned an error")
+			return errorx.Wrap(err, op)
+		}
+
+		dto = AnnouncementToDTO(updated)
+		_, err = tx.Exec(ctx, `
+			UPDATE announcements SET unpublished_at = $2, unpublished_by = $3
+			WHERE id = $1;
+		`, dto.ID, dto.UnpublishedAt, dto.UnpublishedBy)
+		if err != nil {
+			otelx.RecordSpanError(span, err, "failed to update announcement")
+			return translateError(err, op)
+		}
+
+		if events := updated.GetUncommittedEvents(); len(events) > 0 {
+			if err := saveVersion(ctx, tx, "announcements", dto.ID, updated); err != nil {
+				otelx.RecordSpanError(span, err, "failed to save version")
+				return errorx.Wrap(err, op)
+			}
+			if err := watermillx.Publish(ctx, tx, r.wlogger, events...); err != nil {
+				otelx.RecordSpanError(span, err, "failed to publish events")
+				return errorx.Wrap(err, op)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to execute transaction")
+		return err
+	}
+	updated.CommitEvents()
+
+	return nil
+}
+
+func (r *AnnouncementRepo) GetAnnouncement(ctx context.Context, id announcement.ID) (*announcement.Announcement, error) {
+	const op = "postgres.AnnouncementRepo.GetAnnouncement"
+	ctx, span := r.tracer.Start(ctx, "AnnouncementRepo.GetAnnouncement")
+	defer span.End()
+	span.SetAttributes(attribute.String("announcement.id", id.String()))
+
+	dto, err := scanAnnouncement(r.pool.QueryRow(ctx, `
+		SELECT `+announcementColumns+`, version
+		FROM announcements
+		WHERE id = $1;
+	`, id))
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to get announcement")
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errorx.NewNotFound().WithCause(err, op)
+		}
+		return nil, errorx.Wrap(err, op)
+	}
+
+	return AnnouncementToDomain(dto, r.clock), nil
+}
+
+// ListAnnouncements returns a page of the announcements params selects, the
+// newest first.
+func (r *AnnouncementRepo) ListAnnouncements(
+	ctx context.Context,
+	params announcement.ListParams,
+) ([]*announcement.Announcement, error) {
+	const op = "postgres.AnnouncementRepo.ListAnnouncements"
+	ctx, span := r.tracer.Start(ctx, "AnnouncementRepo.ListAnnouncements")
+	defer span.End()
+	otelx.SetSpanAttrs(span, map[string]any{
+		"params.group_id": params.GroupID.String(),
+		"params.all":      params.All,
+		"params.limit":    params.Limit,
+		"params.offset":   params.Offset,
+	})
+
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+announcementColumns+`, version
+		FROM announcements
+		WHERE $1 OR (
+			unpublished_at IS NULL
+			AND (cardinality(target_group_ids) = 0 OR $2 = ANY (target_group_ids))
+		)
+		ORDER BY created_at DESC, id
+		LIMIT $3 OFFSET $4;
+	`, params.All, uuid.UUID(params.GroupID), params.Limit, params.Offset)
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to list announcements")
+		return nil, errorx.Wrap(err, op)
+	}
+	defer rows.Close()
+
+	var announcements []*announcement.Announcement
+	for rows.Next() {
+		dto, err := scanAnnouncement(rows)
+		if err != nil {
+			otelx.RecordSpanError(span, err, "failed to scan announcement")
+			return nil, errorx.Wrap(err, op)
+		}
+		announcements = append(announcements, AnnouncementToDomain(dto, r.clock))
+	}
+	if err := rows.Err(); err != nil {
+		otelx.RecordSpanError(span, err, "failed to iterate announcements")
+		return nil, errorx.Wrap(err, op)
+	}
+
+	return announcements, nil
+}
+
+// ListAnnouncementRecipients returns the students of groupIDs, all students
+// when there are none. The graduated and the expelled students are left
+// out.
+func (r *AnnouncementRepo) ListAnnouncementRecipients(
+	ctx context.Context,
+	groupIDs []group.ID,
+) ([]announcement.Recipient, error) {
+	const op = "postgres.AnnouncementRepo.ListAnnouncementRecipients"
+	ctx, span := r.tracer.Start(ctx, "AnnouncementRepo.ListAnnouncementRecipients")
+	defer span.End()
+	span.SetAttributes(attribute.Int("params.groups", len(groupIDs)))
+
+	ids := make([]uuid.UUID, len(groupIDs))
+	for i, id := range groupIDs {
+		ids[i] = uuid.UUID(id)
+	}
+	rows, err := r.pool.Query(ctx, `
+		SELECT u.id, u.email
+		FROM students s
+		JOIN users u ON u.id = s.user_id
+		WHERE (cardinality($1::uuid[]) = 0 OR s.group_id = ANY ($1))
+			AND s.enrollment_status IN ('enrolled', 'academic_leave')
+		ORDER BY u.id;
+	`, ids)
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to list announcement recipients")
+		return nil, errorx.Wrap(err, op)
+	}
+	defer rows.Close()
+
+	var recipients []announcement.Recipient
+	for rows.Next() {
+		var id uuid.UUID
+		var email string
+		if err := rows.Scan(&id, &email); err != nil {
+			otelx.RecordSpanError(span, err, "failed to scan announcement recipient")
+			return nil, errorx.Wrap(err, op)
+		}
+		recipients = append(recipients, announcement.Recipient{UserID: user.ID(id), Email: email})
+	}
+	if err := rows.Err(); err != nil {
+		otelx.RecordSpanError(span, err, "failed to iterate announcement recipients")
+		return nil, errorx.Wrap(err, op)
+	}
+
+	return recipients, nil
+}
+
+func scanAnnouncement(row pgx.Row) (AnnouncementDTO, error) {
+	var dto AnnouncementDTO
+	err := row.Scan(
+		&dto.ID,
+		&dto.AuthorID,
+		&dto.Title,
+		&dto.Body,
+		&dto.TargetGroupIDs,
+		&dto.SendEmail,
+		&dto.CreatedAt,
+		&dto.UnpublishedAt,
+		&dto.UnpublishedBy,
+		&dto.Version,
+	)
+	return dto, err
+}
@@ -0,0 +1,18 @@
+package postgres
+
+import (
+	"context"
+
+	"gitlab.com/ucmsv2/ucms-backend/pkg/ctxs"
+)
+
+// campusScope returns the campus the reads made with ctx are restricted to,
+// to be matched with "($n::text IS NULL OR campus_id = $n)". A nil scope
+// matches every campus.
+func campusScope(ctx context.Context) *string {
+	id, ok := ctxs.CampusScopeFromCtx(ctx)
+	if !ok {
+		return nil
+	}
+	return &id
+}
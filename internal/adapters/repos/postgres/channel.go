@@ -0,0 +1,65 @@
+package postgres
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+)
+
+// channelListenRetry is the pause before listening again on a lost
+// connection.
+const channelListenRetry = time.Second
+
+// listenChannel passes the payloads notified on channel by every instance to
+// handle until ctx is done. It holds a connection of pool for as long and
+// listens again on a new one when the connection is lost, the payloads
+// notified in between are missed.
+func listenChannel(ctx context.Context, pool *pgxpool.Pool, l *slog.Logger, channel string, handle func(ctx context.Context, payload string)) {
+	for {
+		err := listenChannelOnce(ctx, pool, l, channel, handle)
+		if ctx.Err() != nil {
+			return
+		}
+		l.WarnContext(ctx, "lost the channel, listening again",
+			slog.String("channel", channel),
+			slog.String("error", err.Error()),
+			slog.Duration("retry_in", channelListenRetry))
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(channelListenRetry):
+		}
+	}
+}
+
+func listenChannelOnce(ctx context.Context, pool *pgxpool.Pool, l *slog.Logger, channel string, handle func(ctx context.Context, payload string)) error {
+	const op = "postgres.listenChannelOnce"
+	conn, err := pool.Acquire(ctx)
+	if err != nil {
+		return errorx.Wrap(err, op)
+	}
+	// A connection closed by the canceled wait is dropped by the pool, a
+	// healthy one goes back without the channel.
+	defer func() {
+		_, _ = conn.Exec(context.WithoutCancel(ctx), `UNLISTEN *;`)
+		conn.Release()
+	}()
+
+	if _, err := conn.Exec(ctx, `LISTEN `+channel+`;`); err != nil {
+		return errorx.Wrap(err, op)
+	}
+	l.DebugContext(ctx, "listening", slog.String("channel", channel))
+
+	for {
+		msg, err := conn.Conn().WaitForNotification(ctx)
+		if err != nil {
+			return errorx.Wrap(err, op)
+		}
+		handle(ctx, msg.Payload)
+	}
+}
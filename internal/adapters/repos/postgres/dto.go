@@ -5,48 +5,74 @@ import (
 
 	"github.com/google/uuid"
 
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/announcement"
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/group"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/notification"
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/registration"
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/staffinvitation"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/term"
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/avatars"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/emails"
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/majors"
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/roles"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
 )
 
 type UserDTO struct {
-	ID             uuid.UUID
-	Barcode        string
-	Username       string
-	RoleID         int
+	ID       uuid.UUID
+	Barcode  string
+	Username string
+	RoleID   int
+	// OtherRoles are the names of the roles held beside RoleID, loaded
+	// where the access tokens are minted only, see otherRolesColumn.
+	OtherRoles     []string
 	FirstName      string
 	LastName       string
-	Email          string
+	Email          emails.Email
 	AvatarSource   string
 	AvatarExternal string
 	AvatarS3Key    string
 	Passhash       []byte
-	CreatedAt      time.Time
-	UpdatedAt      time.Time
+	// PreviousPasshashes is never nil, the column is not null.
+	PreviousPasshashes [][]byte
+	// The TOS columns are null until the user accepts the terms of service.
+	TOSVersion    *string
+	TOSAcceptedAt *time.Time
+	TOSAcceptedIP *string
+	EmailVerified bool
+	// LastSeenAt is null for the users never seen, it is only written by
+	// UserRepo.TouchLastSeen.
+	LastSeenAt *time.Time
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	// Version is the count of the events recorded by the user, its staff or
+	// student aggregate included, see saveVersion.
+	Version int
 }
 
 type StudentDTO struct {
-	ID      uuid.UUID
-	GroupID uuid.UUID
+	ID               uuid.UUID
+	GroupID          uuid.UUID
+	EnrollmentStatus string
+	LeaveUntil       *time.Time
+	ExpelReason      string
 }
 
 type StaffDTO struct {
-	ID uuid.UUID
+	ID         uuid.UUID
+	Department string
+	Position   string
 }
 
 type GlobalRoleDTO struct {
 	ID   int16
-	Name string
+	Name roles.Global
 }
 
 type RegistrationDTO struct {
 	ID               uuid.UUID
-	Email            string
+	Email            emails.Email
 	Status           string
 	VerificationCode string
 	CodeAttempts     int16
@@ -54,15 +80,19 @@ type RegistrationDTO struct {
 	ResendTimeout    time.Time
 	CreatedAt        time.Time
 	UpdatedAt        time.Time
+	Version          int
 }
 
 type GroupDTO struct {
-	ID        uuid.UUID
-	Name      string
-	Major     string
-	Year      string
-	CreatedAt time.Time
-	UpdatedAt time.Time
+	ID                 uuid.UUID
+	Name               string
+	Major              string
+	Year               string
+	CreatedAt          time.Time
+	UpdatedAt          time.Time
+	EnrollmentOpensAt  *time.Time
+	EnrollmentClosesAt *time.Time
+	TermID             *uuid.UUID
 }
 
 type StaffInvitationDTO struct {
@@ -72,9 +102,37 @@ type StaffInvitationDTO struct {
 	RecipientsEmail []string
 	ValidFrom       *time.Time
 	ValidUntil      *time.Time
+	Department      string
+	Position        string
 	CreatedAt       time.Time
 	UpdatedAt       time.Time
 	DeletedAt       *time.Time
+	Version         int
+}
+
+type NotificationDTO struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	EventID   uuid.UUID
+	Type      string
+	Title     string
+	Body      string
+	Link      string
+	ReadAt    *time.Time
+	CreatedAt time.Time
+}
+
+type AnnouncementDTO struct {
+	ID             uuid.UUID
+	AuthorID       uuid.UUID
+	Title          string
+	Body           string
+	TargetGroupIDs []uuid.UUID
+	SendEmail      bool
+	CreatedAt      time.Time
+	UnpublishedAt  *time.Time
+	UnpublishedBy  *uuid.UUID
+	Version        int
 }
 
 func DomainToRegistrationDTO(r *registration.Registration) RegistrationDTO {
@@ -91,8 +149,10 @@ func DomainToRegistrationDTO(r *registration.Registration) RegistrationDTO {
 	}
 }
 
-func RegistrationToDomain(dto RegistrationDTO) *registration.Registration {
-	return registration.Rehydrate(registration.RehydrateArgs{
+// RegistrationToDomain rehydrates the registration with clk, a nil clk is
+// clock.Real.
+func RegistrationToDomain(dto RegistrationDTO, clk clock.Clock) *registration.Registration {
+	r := registration.Rehydrate(registration.RehydrateArgs{
 		ID:               registration.ID(dto.ID),
 		Email:            dto.Email,
 		Status:           registration.Status(dto.Status),
@@ -102,87 +162,194 @@ func RegistrationToDomain(dto RegistrationDTO) *registration.Registration {
 		ResendTimeout:    dto.ResendTimeout,
 		CreatedAt:        dto.CreatedAt,
 		UpdatedAt:        dto.UpdatedAt,
+		Clock:            clk,
 	})
+	r.SetVersion(dto.Version)
+	return r
 }
 
 func DomainToUserDTO(u *user.User) UserDTO {
 	return UserDTO{
-		ID:             uuid.UUID(u.ID()),
-		Barcode:        string(u.Barcode()),
-		Username:       u.Username(),
-		RoleID:         0,
-		FirstName:      u.FirstName(),
-		LastName:       u.LastName(),
-		Email:          u.Email(),
-		AvatarSource:   u.Avatar().Source.String(),
-		AvatarExternal: u.Avatar().External,
-		AvatarS3Key:    u.Avatar().S3Key,
-		Passhash:       u.PassHash(),
-		CreatedAt:      u.CreatedAt(),
-		UpdatedAt:      u.UpdatedAt(),
+		ID:                 uuid.UUID(u.ID()),
+		Barcode:            string(u.Barcode()),
+		Username:           u.Username(),
+		RoleID:             0,
+		FirstName:          u.FirstName(),
+		LastName:           u.LastName(),
+		Email:              u.Email(),
+		AvatarSource:       u.Avatar().Source.String(),
+		AvatarExternal:     u.Avatar().External,
+		AvatarS3Key:        u.Avatar().S3Key,
+		Passhash:           u.PassHash(),
+		PreviousPasshashes: append([][]byte{}, u.PassHistory()...),
+		TOSVersion:         nullableString(u.TOS().Version),
+		TOSAcceptedAt:      nullableTime(u.TOS().AcceptedAt),
+		TOSAcceptedIP:      nullableString(u.TOS().IP),
+		EmailVerified:      u.EmailVerified(),
+		CreatedAt:          u.CreatedAt(),
+		UpdatedAt:          u.UpdatedAt(),
+	}
+}
+
+func (dto UserDTO) tos() user.TOSAcceptance {
+	var a user.TOSAcceptance
+	if dto.TOSVersion != nil {
+		a.Version = *dto.TOSVersion
+	}
+	if dto.TOSAcceptedAt != nil {
+		a.AcceptedAt = *dto.TOSAcceptedAt
+	}
+	if dto.TOSAcceptedIP != nil {
+		a.IP = *dto.TOSAcceptedIP
+	}
+	return a
+}
+
+func (dto UserDTO) lastSeenAt() time.Time {
+	if dto.LastSeenAt == nil {
+		return time.Time{}
 	}
+	return *dto.LastSeenAt
+}
+
+func nullableString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+func nullableTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
 }
 
 func UserToDomain(dto UserDTO, roleDTO GlobalRoleDTO) *user.User {
-	return user.RehydrateUser(user.RehydrateUserArgs{
-		ID:        user.ID(dto.ID),
-		Barcode:   user.Barcode(dto.Barcode),
-		Username:  dto.Username,
-		FirstName: dto.FirstName,
-		LastName:  dto.LastName,
-		Role:      roles.Global(roleDTO.Name),
+	u := user.RehydrateUser(userRehydrateArgs(dto, roleDTO))
+	u.SetVersion(dto.Version)
+	return u
+}
+
+func userRehydrateArgs(dto UserDTO, roleDTO GlobalRoleDTO) user.RehydrateUserArgs {
+	return user.RehydrateUserArgs{
+		ID:         user.ID(dto.ID),
+		Barcode:    user.Barcode(dto.Barcode),
+		Username:   dto.Username,
+		FirstName:  dto.FirstName,
+		LastName:   dto.LastName,
+		Role:       roleDTO.Name,
+		OtherRoles: dto.otherRoles(),
 		Avatar: avatars.Avatar{
 			Source:   avatars.SourceFromString(dto.AvatarSource),
 			S3Key:    dto.AvatarS3Key,
 			External: dto.AvatarExternal,
 		},
-		Email:     dto.Email,
-		PassHash:  dto.Passhash,
-		CreatedAt: dto.CreatedAt,
-		UpdatedAt: dto.UpdatedAt,
-	})
+		Email:         dto.Email,
+		PassHash:      dto.Passhash,
+		PassHistory:   dto.PreviousPasshashes,
+		TOS:           dto.tos(),
+		EmailVerified: dto.EmailVerified,
+		LastSeenAt:    dto.lastSeenAt(),
+		CreatedAt:     dto.CreatedAt,
+		UpdatedAt:     dto.UpdatedAt,
+	}
+}
+
+func (dto UserDTO) otherRoles() roles.Set {
+	rs := make([]roles.Global, len(dto.OtherRoles))
+	for i, name := range dto.OtherRoles {
+		rs[i] = roles.Global(name)
+	}
+	return roles.NewSet(rs...)
+}
+
+// EmailVerificationDTO is the pending code of a user, the columns are null
+// when there is none.
+type EmailVerificationDTO struct {
+	Code      *string
+	Attempts  *int16
+	ExpiresAt *time.Time
+	ResendAt  *time.Time
+}
+
+// UserWithEmailVerificationToDomain rehydrates the user with their pending
+// email verification code.
+func UserWithEmailVerificationToDomain(dto UserDTO, roleDTO GlobalRoleDTO, v EmailVerificationDTO) *user.User {
+	args := userRehydrateArgs(dto, roleDTO)
+	if v.Code != nil && v.Attempts != nil && v.ExpiresAt != nil && v.ResendAt != nil {
+		args.EmailVerification = &user.EmailVerification{
+			Code:      *v.Code,
+			Attempts:  int8(*v.Attempts),
+			ExpiresAt: *v.ExpiresAt,
+			ResendAt:  *v.ResendAt,
+		}
+	}
+	u := user.RehydrateUser(args)
+	u.SetVersion(dto.Version)
+	return u
 }
 
 func StudentToDomain(userDTO UserDTO, roleDTO GlobalRoleDTO, studentDTO StudentDTO) *user.Student {
-	return user.RehydrateStudent(user.RehydrateStudentArgs{
+	s := user.RehydrateStudent(user.RehydrateStudentArgs{
 		RehydrateUserArgs: user.RehydrateUserArgs{
 			ID:        user.ID(userDTO.ID),
 			Barcode:   user.Barcode(userDTO.Barcode),
 			Username:  userDTO.Username,
 			FirstName: userDTO.FirstName,
 			LastName:  userDTO.LastName,
-			Role:      roles.Global(roleDTO.Name),
+			Role:      roleDTO.Name,
 			Avatar: avatars.Avatar{
 				Source:   avatars.SourceFromString(userDTO.AvatarSource),
 				S3Key:    userDTO.AvatarS3Key,
 				External: userDTO.AvatarExternal,
 			},
-			Email:     userDTO.Email,
-			PassHash:  userDTO.Passhash,
-			CreatedAt: userDTO.CreatedAt,
-			UpdatedAt: userDTO.UpdatedAt,
+			Email:         userDTO.Email,
+			PassHash:      userDTO.Passhash,
+			PassHistory:   userDTO.PreviousPasshashes,
+			TOS:           userDTO.tos(),
+			EmailVerified: userDTO.EmailVerified,
+			LastSeenAt:    userDTO.lastSeenAt(),
+			CreatedAt:     userDTO.CreatedAt,
+			UpdatedAt:     userDTO.UpdatedAt,
 		},
-		GroupID: group.ID(studentDTO.GroupID),
+		GroupID:          group.ID(studentDTO.GroupID),
+		EnrollmentStatus: user.EnrollmentStatus(studentDTO.EnrollmentStatus),
+		LeaveUntil:       studentDTO.LeaveUntil,
+		ExpelReason:      studentDTO.ExpelReason,
 	})
+	// The student shares the version of its user row.
+	s.SetVersion(userDTO.Version)
+	s.User().SetVersion(userDTO.Version)
+	return s
 }
 
 func DomainToGroupDTO(g *group.Group) GroupDTO {
 	return GroupDTO{
-		ID:        uuid.UUID(g.ID()),
-		Name:      g.Name(),
-		Major:     g.Major().String(),
-		Year:      g.Year(),
-		CreatedAt: g.CreatedAt(),
-		UpdatedAt: g.UpdatedAt(),
+		ID:                 uuid.UUID(g.ID()),
+		Name:               g.Name(),
+		Major:              g.Major().String(),
+		Year:               g.Year(),
+		CreatedAt:          g.CreatedAt(),
+		UpdatedAt:          g.UpdatedAt(),
+		EnrollmentOpensAt:  g.EnrollmentOpensAt(),
+		EnrollmentClosesAt: g.EnrollmentClosesAt(),
+		TermID:             (*uuid.UUID)(g.TermID()),
 	}
 }
 
 func GroupToDomain(dto GroupDTO) *group.Group {
 	return group.Rehydrate(group.RehydrateArgs{
-		ID:    group.ID(dto.ID),
-		Name:  dto.Name,
-		Major: majors.Major(dto.Major),
-		Year:  dto.Year,
+		ID:                 group.ID(dto.ID),
+		Name:               dto.Name,
+		Major:              majors.Major(dto.Major),
+		Year:               dto.Year,
+		CreatedAt:          dto.CreatedAt,
+		UpdatedAt:          dto.UpdatedAt,
+		EnrollmentOpensAt:  dto.EnrollmentOpensAt,
+		EnrollmentClosesAt: dto.EnrollmentClosesAt,
+		TermID:             (*term.ID)(dto.TermID),
 	})
 }
 
@@ -194,44 +361,143 @@ func DomainToStaffInvitationDTO(i *staffinvitation.StaffInvitation) StaffInvitat
 		RecipientsEmail: i.RecipientsEmail(),
 		ValidFrom:       i.ValidFrom(),
 		ValidUntil:      i.ValidUntil(),
+		Department:      i.Department(),
+		Position:        i.Position(),
 		CreatedAt:       i.CreatedAt(),
 		UpdatedAt:       i.UpdatedAt(),
 		DeletedAt:       i.DeletedAt(),
 	}
 }
 
-func StaffInvitationToDomain(dto StaffInvitationDTO) *staffinvitation.StaffInvitation {
-	return staffinvitation.Rehydrate(staffinvitation.RehydrateArgs{
+// StaffInvitationToDomain rehydrates the invitation with clk, a nil clk is
+// clock.Real.
+func StaffInvitationToDomain(dto StaffInvitationDTO, clk clock.Clock) *staffinvitation.StaffInvitation {
+	i := staffinvitation.Rehydrate(staffinvitation.RehydrateArgs{
 		ID:              staffinvitation.ID(dto.ID),
 		CreatorID:       user.ID(dto.CreatorID),
 		Code:            dto.Code,
 		RecipientsEmail: dto.RecipientsEmail,
 		ValidFrom:       dto.ValidFrom,
 		ValidUntil:      dto.ValidUntil,
+		Department:      dto.Department,
+		Position:        dto.Position,
 		CreatedAt:       dto.CreatedAt,
 		UpdatedAt:       dto.UpdatedAt,
 		DeletedAt:       dto.DeletedAt,
+		Clock:           clk,
 	})
+	i.SetVersion(dto.Version)
+	return i
 }
 
 func StaffToDomain(userDTO UserDTO, roleDTO GlobalRoleDTO, staffDTO StaffDTO) *user.Staff {
-	return user.RehydrateStaff(user.RehydrateStaffArgs{
+	s := user.RehydrateStaff(user.RehydrateStaffArgs{
 		RehydrateUserArgs: user.RehydrateUserArgs{
 			ID:        user.ID(userDTO.ID),
 			Barcode:   user.Barcode(userDTO.Barcode),
 			Username:  userDTO.Username,
 			FirstName: userDTO.FirstName,
 			LastName:  userDTO.LastName,
-			Role:      roles.Global(roleDTO.Name),
+			Role:      roleDTO.Name,
 			Avatar: avatars.Avatar{
 				Source:   avatars.SourceFromString(userDTO.AvatarSource),
 				S3Key:    userDTO.AvatarS3Key,
 				External: userDTO.AvatarExternal,
 			},
-			Email:     userDTO.Email,
-			PassHash:  userDTO.Passhash,
-			CreatedAt: userDTO.CreatedAt,
-			UpdatedAt: userDTO.UpdatedAt,
+			Email:         userDTO.Email,
+			PassHash:      userDTO.Passhash,
+			PassHistory:   userDTO.PreviousPasshashes,
+			TOS:           userDTO.tos(),
+			EmailVerified: userDTO.EmailVerified,
+			LastSeenAt:    userDTO.lastSeenAt(),
+			CreatedAt:     userDTO.CreatedAt,
+			UpdatedAt:     userDTO.UpdatedAt,
 		},
+		Department: staffDTO.Department,
+		Position:   staffDTO.Position,
+	})
+	// The staff member shares the version of its user row.
+	s.SetVersion(userDTO.Version)
+	s.User().SetVersion(userDTO.Version)
+	return s
+}
+
+func NotificationToDTO(n *notification.Notification) NotificationDTO {
+	return NotificationDTO{
+		ID:        uuid.UUID(n.ID()),
+		UserID:    uuid.UUID(n.UserID()),
+		EventID:   n.EventID(),
+		Type:      n.Type().String(),
+		Title:     n.Title(),
+		Body:      n.Body(),
+		Link:      n.Link(),
+		ReadAt:    n.ReadAt(),
+		CreatedAt: n.CreatedAt(),
+	}
+}
+
+func NotificationToDomain(dto NotificationDTO, clk clock.Clock) *notification.Notification {
+	return notification.Rehydrate(notification.RehydrateArgs{
+		ID:        notification.ID(dto.ID),
+		UserID:    user.ID(dto.UserID),
+		EventID:   dto.EventID,
+		Type:      notification.Type(dto.Type),
+		Title:     dto.Title,
+		Body:      dto.Body,
+		Link:      dto.Link,
+		ReadAt:    dto.ReadAt,
+		CreatedAt: dto.CreatedAt,
+		Clock:     clk,
+	})
+}
+
+func AnnouncementToDTO(a *announcement.Announcement) AnnouncementDTO {
+	groupIDs := make([]uuid.UUID, len(a.GroupIDs()))
+	for i, id := range a.GroupIDs() {
+		groupIDs[i] = uuid.UUID(id)
+	}
+	var unpublishedBy *uuid.UUID
+	if by := a.UnpublishedBy(); by != nil {
+		id := uuid.UUID(*by)
+		unpublishedBy = &id
+	}
+
+	return AnnouncementDTO{
+		ID:             uuid.UUID(a.ID()),
+		AuthorID:       uuid.UUID(a.AuthorID()),
+		Title:          a.Title(),
+		Body:           a.Body(),
+		TargetGroupIDs: groupIDs,
+		SendEmail:      a.SendEmail(),
+		CreatedAt:      a.CreatedAt(),
+		UnpublishedAt:  a.UnpublishedAt(),
+		UnpublishedBy:  unpublishedBy,
+	}
+}
+
+func AnnouncementToDomain(dto AnnouncementDTO, clk clock.Clock) *announcement.Announcement {
+	groupIDs := make([]group.ID, len(dto.TargetGroupIDs))
+	for i, id := range dto.TargetGroupIDs {
+		groupIDs[i] = group.ID(id)
+	}
+	var unpublishedBy *user.ID
+	if dto.UnpublishedBy != nil {
+		id := user.ID(*dto.UnpublishedBy)
+		unpublishedBy = &id
+	}
+
+	a := announcement.Rehydrate(announcement.RehydrateArgs{
+		ID:            announcement.ID(dto.ID),
+		AuthorID:      user.ID(dto.AuthorID),
+		Title:         dto.Title,
+		Body:          dto.Body,
+		GroupIDs:      groupIDs,
+		SendEmail:     dto.SendEmail,
+		CreatedAt:     dto.CreatedAt,
+		UnpublishedAt: dto.UnpublishedAt,
+		UnpublishedBy: unpublishedBy,
+		Clock:         clk,
 	})
+	a.SetVersion(dto.Version)
+	return a
 }
@@ -0,0 +1,142 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/postgres"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/watermillx"
+)
+
+// UpdateEmailVerification applies fn to the user loaded with their pending
+// email verification code, then saves whether the email is verified and the
+// code. The persistable errors of fn are saved as well, e.g. the counted
+// failed attempts.
+func (r *UserRepo) UpdateEmailVerification(
+	ctx context.Context,
+	id user.ID,
+	fn func(ctx context.Context, u *user.User) error,
+) error {
+	const op = "postgres.UserRepo.UpdateEmailVerification"
+	ctx, span := r.tracer.Start(ctx, "UserRepo.UpdateEmailVerification")
+	defer span.End()
+	if fn == nil {
+		otelx.RecordSpanError(span, ErrNilFunc, "update function cannot be nil")
+		return ErrNilFunc
+	}
+
+	err := postgres.WithTx(ctx, r.pool, func(ctx context.Context, tx pgx.Tx) error {
+		query := `
+        SELECT  u.id, u.barcode, u.username, u.role_id,
+                u.first_name, u.last_name,
+                u.avatar_source, u.avatar_external, u.avatar_s3_key,
+                u.email, u.pass_hash, u.previous_pass_hashes,
+                u.tos_version, u.tos_accepted_at, u.tos_accepted_ip, u.email_verified, u.version, u.last_seen_at, u.created_at, u.updated_at,
+                gr.id, gr.name,
+                ev.code, ev.attempts, ev.expires_at, ev.resend_at
+        FROM users u
+            JOIN global_roles gr ON u.role_id = gr.id
+            LEFT JOIN email_verifications ev ON ev.user_id = u.id
+        WHERE u.id = $1
+        FOR UPDATE OF u;
+    `
+
+		var dto UserDTO
+		var roleDTO GlobalRoleDTO
+		var verificationDTO EmailVerificationDTO
+		err := tx.QueryRow(ctx, query, id).
+			Scan(
+				&dto.ID, &dto.Barcode, &dto.Username, &dto.RoleID,
+				&dto.FirstName, &dto.LastName,
+				&dto.AvatarSource, &dto.AvatarExternal, &dto.AvatarS3Key,
+				&dto.Email, &dto.Passhash, &dto.PreviousPasshashes,
+				&dto.TOSVersion, &dto.TOSAcceptedAt, &dto.TOSAcceptedIP, &dto.EmailVerified, &dto.Version, &dto.LastSeenAt, &dto.CreatedAt, &dto.UpdatedAt,
+				&roleDTO.ID, &roleDTO.Name,
+				&verificationDTO.Code, &verificationDTO.Attempts, &verificationDTO.ExpiresAt, &verificationDTO.ResendAt,
+			)
+		if err != nil {
+			otelx.RecordSpanError(span, err, "failed to get user by id")
+			if errors.Is(err, pgx.ErrNoRows) {
+				return errorx.NewNotFound().WithCause(err, op)
+			}
+			return errorx.Wrap(err, op)
+		}
+
+		u := UserWithEmailVerificationToDomain(dto, roleDTO, verificationDTO)
+
+		fnerr := fn(ctx, u)
+		if fnerr != nil && !errorx.IsPersistable(fnerr) {
+			otelx.RecordSpanError(span, fnerr, "update function returned an error and cannot continue")
+			return errorx.Wrap(fnerr, op)
+		}
+
+		_, err = tx.Exec(ctx, `UPDATE users SET email_verified = $2, updated_at = $3 WHERE id = $1;`,
+			dto.ID, u.EmailVerified(), u.UpdatedAt())
+		if err != nil {
+			otelx.RecordSpanError(span, err, "failed to update user")
+			return errorx.Wrap(err, op)
+		}
+
+		if v := u.EmailVerification(); v != nil {
+			_, err = tx.Exec(ctx, `
+			INSERT INTO email_verifications (user_id, code, attempts, expires_at, resend_at)
+			VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (user_id) DO UPDATE
+			SET code = EXCLUDED.code, attempts = EXCLUDED.attempts,
+				expires_at = EXCLUDED.expires_at, resend_at = EXCLUDED.resend_at;
+			`, dto.ID, v.Code, v.Attempts, v.ExpiresAt, v.ResendAt)
+		} else {
+			_, err = tx.Exec(ctx, `DELETE FROM email_verifications WHERE user_id = $1;`, dto.ID)
+		}
+		if err != nil {
+			otelx.RecordSpanError(span, err, "failed to save email verification")
+			return errorx.Wrap(err, op)
+		}
+
+		events := u.GetUncommittedEvents()
+		if len(events) > 0 {
+			if err := saveVersion(ctx, tx, "users", dto.ID, u); err != nil {
+				otelx.RecordSpanError(span, err, "failed to save version")
+				return errorx.Wrap(err, op)
+			}
+			if err := watermillx.Publish(ctx, tx, r.wlogger, events...); err != nil {
+				otelx.RecordSpanError(span, err, "failed to publish events")
+				return errorx.Wrap(err, op)
+			}
+		}
+
+		if fnerr != nil && errorx.IsPersistable(fnerr) {
+			otelx.RecordSpanError(span, fnerr, "update function returned an error but is allowed to continue")
+			return errorx.Wrap(fnerr, op)
+		}
+
+		return nil
+	})
+	if err != nil {
+		otelx.RecordSpanError(span, err, "transaction to update email verification failed")
+		return err
+	}
+
+	return nil
+}
+
+// IsEmailVerified reports whether the user confirmed their email.
+func (r *UserRepo) IsEmailVerified(ctx context.Context, id user.ID) (bool, error) {
+	const op = "postgres.UserRepo.IsEmailVerified"
+	ctx, span := r.tracer.Start(ctx, "UserRepo.IsEmailVerified")
+	defer span.End()
+
+	var verified bool
+	err := r.pool.QueryRow(ctx, `SELECT email_verified FROM users WHERE id = $1;`, id).Scan(&verified)
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to get email verified")
+		return false, translateError(err, op)
+	}
+
+	return verified, nil
+}
@@ -2,9 +2,58 @@ package postgres
 
 import (
 	"errors"
+
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/i18nx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/postgres"
 )
 
 var (
 	ErrNoRowsAffected = errors.New("no rows affected")
 	ErrNilFunc        = errors.New("update function cannot be nil")
 )
+
+// constraintErrors are the errors of the constraints of the schema, a
+// concurrent request that wins the race on a unique column gets the same
+// error as the one that checked first.
+var constraintErrors = postgres.ConstraintErrors{
+	"users_email_key": func() *errorx.I18nError {
+		return errorx.NewDuplicateEntry().WithKey(i18nx.KeyEmailNotAvailable)
+	},
+	"users_username_key": func() *errorx.I18nError {
+		return errorx.NewDuplicateEntry().WithKey(i18nx.KeyUsernameNotAvailable)
+	},
+	"users_barcode_key": func() *errorx.I18nError {
+		return errorx.NewDuplicateEntry().WithKey(i18nx.KeyBarcodeNotAvailable)
+	},
+	"registrations_email_key": func() *errorx.I18nError {
+		return errorx.NewDuplicateEntry().WithKey(i18nx.KeyEmailNotAvailable)
+	},
+	"students_group_id_fkey": func() *errorx.I18nError {
+		return errorx.NewResourceNotFound(i18nx.FieldGroup)
+	},
+	"staff_invitations_creator_id_fkey": func() *errorx.I18nError {
+		return errorx.NewNotFound()
+	},
+	"tos_versions_pkey": func() *errorx.I18nError {
+		return errorx.NewDuplicateEntry()
+	},
+	"users_tos_version_fkey": func() *errorx.I18nError {
+		return errorx.NewResourceNotFound(i18nx.FieldTOSVersion)
+	},
+	"groups_term_id_fkey": func() *errorx.I18nError {
+		return errorx.NewResourceNotFound(i18nx.FieldTerm)
+	},
+	"terms_code_key": func() *errorx.I18nError {
+		return errorx.NewDuplicateEntry().WithDetails("a term with this code already exists")
+	},
+	// The writes check the overlaps first, see TermRepo, the constraint is
+	// the last resort.
+	"terms_no_overlap": errorx.NewTermOverlap,
+}
+
+// translateError classifies pgx errors with postgres.TranslateError and the
+// constraints of the schema.
+func translateError(err error, op string) error {
+	return postgres.TranslateError(err, op, constraintErrors)
+}
@@ -0,0 +1,175 @@
+package postgres
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorinbox"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx"
+)
+
+type ErrorEventRepo struct {
+	tracer trace.Tracer
+	logger *slog.Logger
+	pool   *pgxpool.Pool
+}
+
+// NewErrorEventRepo creates a new ErrorEventRepo.
+// It also sets default tracer and logger if they are nil.
+//
+//	WARNING; panics if pool is nil
+func NewErrorEventRepo(pool *pgxpool.Pool, t trace.Tracer, l *slog.Logger) *ErrorEventRepo {
+	if pool == nil {
+		panic("pgxpool.Pool is required")
+	}
+	if t == nil {
+		t = tracer
+	}
+	if l == nil {
+		l = logger
+	}
+
+	return &ErrorEventRepo{
+		tracer: t,
+		logger: l,
+		pool:   pool,
+	}
+}
+
+// UpsertErrorEvents adds the counts of events to the stored errors. A
+// resolved error that happens again is reopened.
+func (r *ErrorEventRepo) UpsertErrorEvents(ctx context.Context, events []errorinbox.ErrorEvent) error {
+	const op = "postgres.ErrorEventRepo.UpsertErrorEvents"
+	ctx, span := r.tracer.Start(ctx, "ErrorEventRepo.UpsertErrorEvents")
+	defer span.End()
+	span.SetAttributes(attribute.Int("error_events.count", len(events)))
+
+	query := `
+		INSERT INTO error_events (signature, type, message, frames, route, sample_correlation_id, count, status, first_seen, last_seen)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (signature) DO UPDATE SET
+			count = error_events.count + EXCLUDED.count,
+			last_seen = GREATEST(error_events.last_seen, EXCLUDED.last_seen),
+			route = EXCLUDED.route,
+			sample_correlation_id = COALESCE(NULLIF(EXCLUDED.sample_correlation_id, ''), error_events.sample_correlation_id),
+			status = CASE WHEN error_events.status = 'resolved' THEN 'open' ELSE error_events.status END,
+			updated_at = now();
+	`
+
+	batch := &pgx.Batch{}
+	for _, e := range events {
+		batch.Queue(query,
+			e.Signature,
+			e.Type,
+			e.Message,
+			e.Frames,
+			e.Route,
+			e.CorrelationID,
+			e.Count,
+			string(e.Status),
+			e.FirstSeen,
+			e.LastSeen,
+		)
+	}
+
+	if err := r.pool.SendBatch(ctx, batch).Close(); err != nil {
+		otelx.RecordSpanError(span, err, "failed to upsert error events")
+		return errorx.Wrap(err, op)
+	}
+
+	return nil
+}
+
+// ListErrorEvents returns the errors with one of the statuses, the most
+// recently seen first.
+func (r *ErrorEventRepo) ListErrorEvents(ctx context.Context, params errorinbox.ListParams) ([]errorinbox.ErrorEvent, error) {
+	const op = "postgres.ErrorEventRepo.ListErrorEvents"
+	ctx, span := r.tracer.Start(ctx, "ErrorEventRepo.ListErrorEvents")
+	defer span.End()
+
+	statuses := make([]string, len(params.Statuses))
+	for i, s := range params.Statuses {
+		statuses[i] = string(s)
+	}
+	otelx.SetSpanAttrs(span, map[string]any{
+		"params.statuses": statuses,
+		"params.limit":    params.Limit,
+	})
+
+	rows, err := r.pool.Query(ctx, `
+		SELECT signature, type, message, frames, route, sample_correlation_id, count, status, first_seen, last_seen
+		FROM error_events
+		WHERE status = ANY($1)
+		ORDER BY last_seen DESC
+		LIMIT $2;
+	`, statuses, params.Limit)
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to list error events")
+		return nil, errorx.Wrap(err, op)
+	}
+	defer rows.Close()
+
+	var events []errorinbox.ErrorEvent
+	for rows.Next() {
+		var (
+			e      errorinbox.ErrorEvent
+			status string
+		)
+		err := rows.Scan(
+			&e.Signature,
+			&e.Type,
+			&e.Message,
+			&e.Frames,
+			&e.Route,
+			&e.CorrelationID,
+			&e.Count,
+			&status,
+			&e.FirstSeen,
+			&e.LastSeen,
+		)
+		if err != nil {
+			otelx.RecordSpanError(span, err, "failed to scan error event")
+			return nil, errorx.Wrap(err, op)
+		}
+		e.Status = errorinbox.Status(status)
+		events = append(events, e)
+	}
+	if err := rows.Err(); err != nil {
+		otelx.RecordSpanError(span, err, "failed to iterate error events")
+		return nil, errorx.Wrap(err, op)
+	}
+
+	return events, nil
+}
+
+// SetErrorEventStatus resolves, mutes or reopens the error with signature.
+func (r *ErrorEventRepo) SetErrorEventStatus(ctx context.Context, signature string, status errorinbox.Status) error {
+	const op = "postgres.ErrorEventRepo.SetErrorEventStatus"
+	ctx, span := r.tracer.Start(ctx, "ErrorEventRepo.SetErrorEventStatus")
+	defer span.End()
+	span.SetAttributes(
+		attribute.String("error_event.signature", signature),
+		attribute.String("error_event.status", string(status)),
+	)
+
+	res, err := r.pool.Exec(ctx, `
+		UPDATE error_events SET status = $2, updated_at = now()
+		WHERE signature = $1;
+	`, signature, string(status))
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to update error event status")
+		return errorx.Wrap(err, op)
+	}
+	if res.RowsAffected() == 0 {
+		otelx.RecordSpanError(span, ErrNoRowsAffected, "error event not found")
+		return errorx.NewNotFound().WithCause(ErrNoRowsAffected, op)
+	}
+
+	return nil
+}
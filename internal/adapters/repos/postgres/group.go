@@ -8,12 +8,15 @@ import (
 	"github.com/ThreeDotsLabs/watermill"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
 
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/group"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/ctxs"
 	"gitlab.com/ucmsv2/ucms-backend/pkg/env"
 	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
 	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/postgres"
 	"gitlab.com/ucmsv2/ucms-backend/pkg/watermillx"
 )
 
@@ -53,19 +56,22 @@ func (r *GroupRepo) GetGroupByID(ctx context.Context, groupID group.ID) (*group.
 	defer span.End()
 
 	query := `
-        SELECT id, name, year, major, created_at, updated_at
+        SELECT id, name, year, major, created_at, updated_at, enrollment_opens_at, enrollment_closes_at, term_id
         FROM groups
-        WHERE id = $1;
+        WHERE id = $1 AND ($2::text IS NULL OR campus_id = $2);
     `
 
 	var dto GroupDTO
-	err := r.pool.QueryRow(ctx, query, groupID).Scan(
+	err := r.pool.QueryRow(ctx, query, groupID, campusScope(ctx)).Scan(
 		&dto.ID,
 		&dto.Name,
 		&dto.Year,
 		&dto.Major,
 		&dto.CreatedAt,
 		&dto.UpdatedAt,
+		&dto.EnrollmentOpensAt,
+		&dto.EnrollmentClosesAt,
+		&dto.TermID,
 	)
 	if err != nil {
 		otelx.RecordSpanError(span, err, "failed to execute query")
@@ -86,14 +92,15 @@ func (r *GroupRepo) SaveGroup(ctx context.Context, g *group.Group) error {
 	dto := DomainToGroupDTO(g)
 
 	query := `
-		INSERT INTO groups (id, name, year, major, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6);
+		INSERT INTO groups (id, name, year, major, created_at, updated_at, enrollment_opens_at, enrollment_closes_at, term_id, campus_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10);
 	`
 
-	res, err := r.pool.Exec(ctx, query, dto.ID, dto.Name, dto.Year, dto.Major, dto.CreatedAt, dto.UpdatedAt)
+	res, err := r.pool.Exec(ctx, query, dto.ID, dto.Name, dto.Year, dto.Major, dto.CreatedAt, dto.UpdatedAt,
+		dto.EnrollmentOpensAt, dto.EnrollmentClosesAt, dto.TermID, ctxs.CampusFromCtx(ctx))
 	if err != nil {
 		otelx.RecordSpanError(span, err, "failed to execute query")
-		return errorx.Wrap(err, op)
+		return translateError(err, op)
 	}
 	if res.RowsAffected() == 0 {
 		return errorx.Wrap(ErrNoRowsAffected, op)
@@ -101,3 +108,89 @@ func (r *GroupRepo) SaveGroup(ctx context.Context, g *group.Group) error {
 
 	return nil
 }
+
+// UpdateGroup locks the group and saves the changes fn makes to it, fn
+// failing leaves the group as it was.
+func (r *GroupRepo) UpdateGroup(ctx context.Context, id group.ID, fn func(ctx context.Context, g *group.Group) error) error {
+	const op = "postgres.GroupRepo.UpdateGroup"
+	ctx, span := r.tracer.Start(ctx, "GroupRepo.UpdateGroup",
+		trace.WithAttributes(attribute.String("group.id", id.String())),
+	)
+	defer span.End()
+	if fn == nil {
+		otelx.RecordSpanError(span, ErrNilFunc, "update function cannot be nil")
+		return ErrNilFunc
+	}
+
+	err := postgres.WithTx(ctx, r.pool, func(ctx context.Context, tx pgx.Tx) error {
+		var dto GroupDTO
+		err := tx.QueryRow(ctx, `
+        SELECT id, name, year, major, created_at, updated_at, enrollment_opens_at, enrollment_closes_at, term_id
+        FROM groups
+        WHERE id = $1 AND ($2::text IS NULL OR campus_id = $2)
+        FOR UPDATE;
+    `, id, campusScope(ctx)).Scan(&dto.ID, &dto.Name, &dto.Year, &dto.Major, &dto.CreatedAt, &dto.UpdatedAt,
+			&dto.EnrollmentOpensAt, &dto.EnrollmentClosesAt, &dto.TermID)
+		if err != nil {
+			otelx.RecordSpanError(span, err, "failed to get group by id")
+			return translateError(err, op)
+		}
+
+		g := GroupToDomain(dto)
+		if err := fn(ctx, g); err != nil {
+			otelx.RecordSpanError(span, err, "update function returned an error")
+			return errorx.Wrap(err, op)
+		}
+
+		dto = DomainToGroupDTO(g)
+		_, err = tx.Exec(ctx, `
+        UPDATE groups
+        SET name = $2, year = $3, major = $4, updated_at = $5, enrollment_opens_at = $6, enrollment_closes_at = $7,
+            term_id = $8
+        WHERE id = $1;
+    `, dto.ID, dto.Name, dto.Year, dto.Major, dto.UpdatedAt, dto.EnrollmentOpensAt, dto.EnrollmentClosesAt, dto.TermID)
+		if err != nil {
+			otelx.RecordSpanError(span, err, "failed to update group")
+			return translateError(err, op)
+		}
+		return nil
+	})
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to execute transaction")
+		return err
+	}
+
+	return nil
+}
+
+// ListGroups returns the groups of the campus in scope ordered by name, all
+// of them when no campus is in scope.
+func (r *GroupRepo) ListGroups(ctx context.Context) ([]*group.Group, error) {
+	const op = "postgres.GroupRepo.ListGroups"
+	ctx, span := r.tracer.Start(ctx, "GroupRepo.ListGroups")
+	defer span.End()
+
+	rows, err := r.pool.Query(ctx, `
+        SELECT id, name, year, major, created_at, updated_at, enrollment_opens_at, enrollment_closes_at, term_id
+        FROM groups
+        WHERE ($1::text IS NULL OR campus_id = $1)
+        ORDER BY name, id;
+    `, campusScope(ctx))
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to list groups")
+		return nil, errorx.Wrap(err, op)
+	}
+
+	groups, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*group.Group, error) {
+		var dto GroupDTO
+		err := row.Scan(&dto.ID, &dto.Name, &dto.Year, &dto.Major, &dto.CreatedAt, &dto.UpdatedAt,
+			&dto.EnrollmentOpensAt, &dto.EnrollmentClosesAt, &dto.TermID)
+		return GroupToDomain(dto), err
+	})
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to scan groups")
+		return nil, errorx.Wrap(err, op)
+	}
+
+	return groups, nil
+}
@@ -0,0 +1,113 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/group"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx"
+)
+
+// GroupChannel is the LISTEN/NOTIFY channel of the groups whose members
+// changed, its payload is the JSON array of their IDs.
+const GroupChannel = "group_changes"
+
+// RefreshGroupSummary counts the members of the group into its summary. It
+// counts them again on every call, so a redelivered event changes nothing,
+// and it does nothing when the group was deleted meanwhile.
+func (r *GroupRepo) RefreshGroupSummary(ctx context.Context, groupID group.ID) error {
+	const op = "postgres.GroupRepo.RefreshGroupSummary"
+	ctx, span := r.tracer.Start(ctx, "GroupRepo.RefreshGroupSummary",
+		trace.WithAttributes(attribute.String("group.id", groupID.String())))
+	defer span.End()
+
+	_, err := r.pool.Exec(ctx, `
+        INSERT INTO group_summaries (group_id, member_count, refreshed_at)
+        SELECT g.id, (SELECT count(*) FROM students s WHERE s.group_id = g.id), now()
+        FROM groups g
+        WHERE g.id = $1
+        ON CONFLICT (group_id) DO UPDATE
+        SET member_count = EXCLUDED.member_count, refreshed_at = EXCLUDED.refreshed_at;
+    `, groupID)
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to refresh group summary")
+		return errorx.Wrap(err, op)
+	}
+
+	return nil
+}
+
+// GroupFeed announces the groups whose members changed to every instance
+// through GroupChannel, so that they drop what they cached of them.
+type GroupFeed struct {
+	tracer trace.Tracer
+	logger *slog.Logger
+	pool   *pgxpool.Pool
+}
+
+// NewGroupFeed creates a new GroupFeed.
+// It also sets default tracer and logger if they are nil.
+//
+//	WARNING: panics if pool is nil
+func NewGroupFeed(pool *pgxpool.Pool, t trace.Tracer, l *slog.Logger) *GroupFeed {
+	if pool == nil {
+		panic("pgxpool.Pool is required")
+	}
+	if t == nil {
+		t = tracer
+	}
+	if l == nil {
+		l = logger
+	}
+
+	return &GroupFeed{
+		tracer: t,
+		logger: l,
+		pool:   pool,
+	}
+}
+
+// PublishGroupsChanged notifies the listeners of every instance, this one
+// included, of the groups.
+func (f *GroupFeed) PublishGroupsChanged(ctx context.Context, groupIDs ...group.ID) error {
+	const op = "postgres.GroupFeed.PublishGroupsChanged"
+	ctx, span := f.tracer.Start(ctx, "GroupFeed.PublishGroupsChanged")
+	defer span.End()
+	span.SetAttributes(attribute.Int("groups.count", len(groupIDs)))
+
+	payload, err := json.Marshal(groupIDs)
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to marshal group payload")
+		return errorx.Wrap(err, op)
+	}
+
+	_, err = f.pool.Exec(ctx, `SELECT pg_notify($1, $2);`, GroupChannel, string(payload))
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to notify")
+		return errorx.Wrap(err, op)
+	}
+
+	return nil
+}
+
+// Listen passes the groups published by every instance to evict until ctx is
+// done, see listenChannel. The groups published while the connection is lost
+// are missed, the caches expire them.
+func (f *GroupFeed) Listen(ctx context.Context, evict func(groupIDs ...group.ID)) {
+	listenChannel(ctx, f.pool, f.logger, GroupChannel, func(ctx context.Context, msg string) {
+		var groupIDs []group.ID
+		if err := json.Unmarshal([]byte(msg), &groupIDs); err != nil {
+			f.logger.ErrorContext(ctx, "invalid group payload",
+				slog.String("payload", msg),
+				slog.String("error", err.Error()))
+			return
+		}
+		evict(groupIDs...)
+	})
+}
@@ -0,0 +1,70 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/attribute"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx"
+)
+
+func (r *UserRepo) SaveImpersonation(ctx context.Context, imp *user.Impersonation) error {
+	const op = "postgres.UserRepo.SaveImpersonation"
+	ctx, span := r.tracer.Start(ctx, "UserRepo.SaveImpersonation")
+	defer span.End()
+	span.SetAttributes(
+		attribute.String("impersonation.id", imp.ID().String()),
+		attribute.String("impersonation.actor_id", imp.ActorID().String()),
+		attribute.String("impersonation.target_id", imp.TargetID().String()),
+	)
+
+	_, err := r.pool.Exec(ctx, `
+		INSERT INTO impersonations (id, actor_id, target_id, target_role, ip, started_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7);
+	`, imp.ID(), uuid.UUID(imp.ActorID()), uuid.UUID(imp.TargetID()), imp.TargetRole().String(),
+		imp.IP(), imp.StartedAt(), imp.ExpiresAt())
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to insert impersonation")
+		return translateError(err, op)
+	}
+
+	return nil
+}
+
+// ListImpersonations lists the impersonation sessions, the latest first.
+func (r *UserRepo) ListImpersonations(ctx context.Context, limit, offset int) ([]*user.Impersonation, error) {
+	const op = "postgres.UserRepo.ListImpersonations"
+	ctx, span := r.tracer.Start(ctx, "UserRepo.ListImpersonations")
+	defer span.End()
+
+	rows, err := r.pool.Query(ctx, `
+		SELECT id, actor_id, target_id, target_role, ip, started_at, expires_at
+		FROM impersonations
+		ORDER BY started_at DESC, id
+		LIMIT $1 OFFSET $2;
+	`, limit, offset)
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to query impersonations")
+		return nil, errorx.Wrap(err, op)
+	}
+	imps, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*user.Impersonation, error) {
+		var (
+			args              user.RehydrateImpersonationArgs
+			actorID, targetID uuid.UUID
+		)
+		err := row.Scan(&args.ID, &actorID, &targetID, &args.TargetRole, &args.IP, &args.StartedAt, &args.ExpiresAt)
+		args.ActorID = user.ID(actorID)
+		args.TargetID = user.ID(targetID)
+		return user.RehydrateImpersonation(args), err
+	})
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to scan impersonations")
+		return nil, errorx.Wrap(err, op)
+	}
+
+	return imps, nil
+}
@@ -0,0 +1,180 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/incident"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/postgres"
+)
+
+// IncidentRepo stores the incidents of the status page.
+type IncidentRepo struct {
+	tracer trace.Tracer
+	pool   *pgxpool.Pool
+	clock  clock.Clock
+}
+
+// NewIncidentRepo creates a new IncidentRepo.
+//
+//	WARNING: panics if pool is nil
+func NewIncidentRepo(pool *pgxpool.Pool, t trace.Tracer) *IncidentRepo {
+	if pool == nil {
+		panic("pgxpool.Pool is required")
+	}
+	if t == nil {
+		t = tracer
+	}
+
+	return &IncidentRepo{
+		tracer: t,
+		pool:   pool,
+	}
+}
+
+// WithClock sets the clock the loaded incidents are rehydrated with,
+// clock.Real by default.
+func (r *IncidentRepo) WithClock(c clock.Clock) *IncidentRepo {
+	r.clock = c
+	return r
+}
+
+const incidentColumns = `id, title, severity, started_at, resolved_at, notes, created_by, created_at, updated_at`
+
+func (r *IncidentRepo) SaveIncident(ctx context.Context, i *incident.Incident) error {
+	const op = "postgres.IncidentRepo.SaveIncident"
+	ctx, span := r.tracer.Start(ctx, "IncidentRepo.SaveIncident")
+	defer span.End()
+	span.SetAttributes(attribute.String("incident.id", i.ID().String()))
+
+	_, err := r.pool.Exec(ctx, `
+		INSERT INTO incidents (`+incidentColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9);
+	`, uuid.UUID(i.ID()), i.Title(), i.Severity(), i.StartedAt(), i.ResolvedAt(), i.Notes(),
+		uuid.UUID(i.CreatedBy()), i.CreatedAt(), i.UpdatedAt())
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to insert incident")
+		return translateError(err, op)
+	}
+
+	return nil
+}
+
+// UpdateIncident locks the incident id, runs fn on it and saves it.
+func (r *IncidentRepo) UpdateIncident(
+	ctx context.Context,
+	id incident.ID,
+	fn func(context.Context, *incident.Incident) error,
+) error {
+	const op = "postgres.IncidentRepo.UpdateIncident"
+	ctx, span := r.tracer.Start(ctx, "IncidentRepo.UpdateIncident")
+	defer span.End()
+	span.SetAttributes(attribute.String("incident.id", id.String()))
+	if fn == nil {
+		otelx.RecordSpanError(span, ErrNilFunc, "update function cannot be nil")
+		return ErrNilFunc
+	}
+
+	return postgres.WithTx(ctx, r.pool, func(ctx context.Context, tx pgx.Tx) error {
+		i, err := r.scanIncident(tx.QueryRow(ctx, `
+			SELECT `+incidentColumns+`
+			FROM incidents
+			WHERE id = $1
+			FOR UPDATE;
+		`, uuid.UUID(id)))
+		if err != nil {
+			otelx.RecordSpanError(span, err, "failed to get incident")
+			if errors.Is(err, pgx.ErrNoRows) {
+				return errorx.NewNotFound().WithCause(err, op)
+			}
+			return errorx.Wrap(err, op)
+		}
+
+		if err := fn(ctx, i); err != nil {
+			otelx.RecordSpanError(span, err, "update function returned an error")
+			return errorx.Wrap(err, op)
+		}
+
+		_, err = tx.Exec(ctx, `
+			UPDATE incidents
+			SET title = $2, severity = $3, started_at = $4, resolved_at = $5, notes = $6, updated_at = $7
+			WHERE id = $1;
+		`, uuid.UUID(i.ID()), i.Title(), i.Severity(), i.StartedAt(), i.ResolvedAt(), i.Notes(), i.UpdatedAt())
+		if err != nil {
+			otelx.RecordSpanError(span, err, "failed to update incident")
+			return translateError(err, op)
+		}
+		return nil
+	})
+}
+
+// ListRecentIncidents returns the incidents ongoing or resolved since since,
+// the latest started first, at most limit.
+func (r *IncidentRepo) ListRecentIncidents(ctx context.Context, since time.Time, limit int) ([]*incident.Incident, error) {
+	const op = "postgres.IncidentRepo.ListRecentIncidents"
+	ctx, span := r.tracer.Start(ctx, "IncidentRepo.ListRecentIncidents")
+	defer span.End()
+
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+incidentColumns+`
+		FROM incidents
+		WHERE resolved_at IS NULL OR resolved_at >= $1
+		ORDER BY started_at DESC, created_at DESC
+		LIMIT $2;
+	`, since, limit)
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to query incidents")
+		return nil, errorx.Wrap(err, op)
+	}
+	defer rows.Close()
+
+	var incidents []*incident.Incident
+	for rows.Next() {
+		i, err := r.scanIncident(rows)
+		if err != nil {
+			otelx.RecordSpanError(span, err, "failed to scan incident")
+			return nil, errorx.Wrap(err, op)
+		}
+		incidents = append(incidents, i)
+	}
+	if err := rows.Err(); err != nil {
+		otelx.RecordSpanError(span, err, "failed to iterate incidents")
+		return nil, errorx.Wrap(err, op)
+	}
+
+	return incidents, nil
+}
+
+func (r *IncidentRepo) scanIncident(row pgx.Row) (*incident.Incident, error) {
+	var (
+		args          = incident.RehydrateArgs{Clock: r.clock}
+		id, createdBy uuid.UUID
+	)
+	err := row.Scan(
+		&id,
+		&args.Title,
+		&args.Severity,
+		&args.StartedAt,
+		&args.ResolvedAt,
+		&args.Notes,
+		&createdBy,
+		&args.CreatedAt,
+		&args.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	args.ID, args.CreatedBy = incident.ID(id), user.ID(createdBy)
+	return incident.Rehydrate(args), nil
+}
@@ -0,0 +1,91 @@
+package postgres
+
+import (
+	"context"
+	"hash/fnv"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx"
+)
+
+// jobUnlockTimeout bounds the release of a job lock, the run context may be
+// done by then.
+const jobUnlockTimeout = 5 * time.Second
+
+// JobLocker takes the session level advisory locks that keep a background
+// job from running on two instances at once. A lock holds a connection of
+// the pool until it is released.
+type JobLocker struct {
+	tracer trace.Tracer
+	pool   *pgxpool.Pool
+}
+
+// NewJobLocker creates a new JobLocker.
+//
+//	WARNING: panics if pool is nil
+func NewJobLocker(pool *pgxpool.Pool, t trace.Tracer) *JobLocker {
+	if pool == nil {
+		panic("pgxpool.Pool is required")
+	}
+	if t == nil {
+		t = tracer
+	}
+
+	return &JobLocker{
+		tracer: t,
+		pool:   pool,
+	}
+}
+
+// TryLock takes the advisory lock of the job name unless another session
+// holds it.
+func (l *JobLocker) TryLock(ctx context.Context, name string) (func(), bool, error) {
+	const op = "postgres.JobLocker.TryLock"
+	ctx, span := l.tracer.Start(ctx, "JobLocker.TryLock", trace.WithAttributes(
+		attribute.String("job.name", name),
+	))
+	defer span.End()
+
+	conn, err := l.pool.Acquire(ctx)
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to acquire connection")
+		return nil, false, errorx.Wrap(err, op)
+	}
+
+	key := jobLockKey(name)
+	var locked bool
+	if err := conn.QueryRow(ctx, `SELECT pg_try_advisory_lock($1);`, key).Scan(&locked); err != nil {
+		conn.Release()
+		otelx.RecordSpanError(span, err, "failed to take the job lock")
+		return nil, false, errorx.Wrap(err, op)
+	}
+	span.SetAttributes(attribute.Bool("job.locked", locked))
+	if !locked {
+		conn.Release()
+		return nil, false, nil
+	}
+
+	unlock := func() {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), jobUnlockTimeout)
+		defer cancel()
+		if _, err := conn.Exec(ctx, `SELECT pg_advisory_unlock($1);`, key); err != nil {
+			// The lock lives as long as the session, closing the connection
+			// releases it and the pool drops the closed connection.
+			_ = conn.Conn().Close(ctx)
+		}
+		conn.Release()
+	}
+	return unlock, true, nil
+}
+
+// jobLockKey maps a job name to the key of its advisory lock.
+func jobLockKey(name string) int64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte("ucms.job." + name))
+	return int64(h.Sum64())
+}
@@ -0,0 +1,95 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/attribute"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/lastseen"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx"
+)
+
+// TouchLastSeen sets the last seen time of the users, except where the
+// stored one is younger than minInterval, and returns the number of users
+// written.
+func (r *UserRepo) TouchLastSeen(ctx context.Context, seen []lastseen.Seen, minInterval time.Duration) (int64, error) {
+	const op = "postgres.UserRepo.TouchLastSeen"
+	ctx, span := r.tracer.Start(ctx, "UserRepo.TouchLastSeen")
+	defer span.End()
+	span.SetAttributes(attribute.Int("last_seen.count", len(seen)))
+
+	ids := make([]uuid.UUID, len(seen))
+	ats := make([]time.Time, len(seen))
+	for i, s := range seen {
+		ids[i], ats[i] = uuid.UUID(s.UserID), s.At
+	}
+
+	res, err := r.pool.Exec(ctx, `
+        UPDATE users u SET last_seen_at = s.at
+        FROM unnest($1::uuid[], $2::timestamptz[]) AS s(id, at)
+        WHERE u.id = s.id
+          AND (u.last_seen_at IS NULL OR u.last_seen_at <= s.at - make_interval(secs => $3));
+    `, ids, ats, minInterval.Seconds())
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to touch last seen")
+		return 0, errorx.Wrap(err, op)
+	}
+
+	return res.RowsAffected(), nil
+}
+
+// ListInactiveUsers returns a page of the users not seen since
+// params.Since, those never seen first, then the longest unseen.
+func (r *UserRepo) ListInactiveUsers(ctx context.Context, params user.InactiveParams) ([]*user.User, error) {
+	const op = "postgres.UserRepo.ListInactiveUsers"
+	ctx, span := r.tracer.Start(ctx, "UserRepo.ListInactiveUsers")
+	defer span.End()
+	otelx.SetSpanAttrs(span, map[string]any{
+		"params.since":  params.Since,
+		"params.limit":  params.Limit,
+		"params.offset": params.Offset,
+	})
+
+	rows, err := r.pool.Query(ctx, `
+        SELECT  u.id, u.barcode, u.username, u.role_id,
+                u.first_name, u.last_name,
+                u.avatar_source, u.avatar_external, u.avatar_s3_key,
+                u.email, u.pass_hash, u.previous_pass_hashes,
+                u.tos_version, u.tos_accepted_at, u.tos_accepted_ip, u.email_verified, u.version, u.last_seen_at, u.created_at, u.updated_at,
+                gr.id, gr.name
+        FROM users u JOIN global_roles gr ON u.role_id = gr.id
+        WHERE (u.last_seen_at IS NULL OR u.last_seen_at < $1)
+          AND ($4::text IS NULL OR u.campus_id = $4)
+        ORDER BY u.last_seen_at NULLS FIRST, u.id
+        LIMIT $2 OFFSET $3;
+    `, params.Since, params.Limit, params.Offset, campusScope(ctx))
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to list inactive users")
+		return nil, errorx.Wrap(err, op)
+	}
+
+	users, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*user.User, error) {
+		var dto UserDTO
+		var roleDTO GlobalRoleDTO
+		err := row.Scan(
+			&dto.ID, &dto.Barcode, &dto.Username, &dto.RoleID,
+			&dto.FirstName, &dto.LastName,
+			&dto.AvatarSource, &dto.AvatarExternal, &dto.AvatarS3Key,
+			&dto.Email, &dto.Passhash, &dto.PreviousPasshashes,
+			&dto.TOSVersion, &dto.TOSAcceptedAt, &dto.TOSAcceptedIP, &dto.EmailVerified, &dto.Version, &dto.LastSeenAt, &dto.CreatedAt, &dto.UpdatedAt,
+			&roleDTO.ID, &roleDTO.Name,
+		)
+		return UserToDomain(dto, roleDTO), err
+	})
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to scan inactive users")
+		return nil, errorx.Wrap(err, op)
+	}
+
+	return users, nil
+}
@@ -0,0 +1,324 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/notification"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/postgres"
+)
+
+type NotificationRepo struct {
+	tracer trace.Tracer
+	logger *slog.Logger
+	pool   *pgxpool.Pool
+	clock  clock.Clock
+}
+
+// NewNotificationRepo creates a new NotificationRepo.
+// It also sets default tracer and logger if they are nil.
+//
+//	WARNING: panics if pool is nil
+func NewNotificationRepo(pool *pgxpool.Pool, t trace.Tracer, l *slog.Logger) *NotificationRepo {
+	if pool == nil {
+		panic("pgxpool.Pool is required")
+	}
+	if t == nil {
+		t = tracer
+	}
+	if l == nil {
+		l = logger
+	}
+
+	return &NotificationRepo{
+		tracer: t,
+		logger: l,
+		pool:   pool,
+	}
+}
+
+// WithClock sets the clock the loaded notifications are rehydrated with,
+// clock.Real by default.
+func (r *NotificationRepo) WithClock(c clock.Clock) *NotificationRepo {
+	r.clock = c
+	return r
+}
+
+const notificationColumns = `id, user_id, event_id, type, title, body, link, read_at, created_at`
+
+// SaveNotification saves n unless its user already has the notification of
+// its event, a redelivered event then saves nothing. It reports whether n
+// was saved.
+func (r *NotificationRepo) SaveNotification(ctx context.Context, n *notification.Notification) (bool, error) {
+	const op = "postgres.NotificationRepo.SaveNotification"
+	ctx, span := r.tracer.Start(ctx, "NotificationRepo.SaveNotification")
+	defer span.End()
+	span.SetAttributes(
+		attribute.String("notification.user_id", n.UserID().String()),
+		attribute.String("notification.event_id", n.EventID().String()),
+	)
+
+	dto := NotificationToDTO(n)
+	res, err := r.pool.Exec(ctx, `
+		INSERT INTO notifications (`+notificationColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (user_id, event_id) DO NOTHING;
+	`, dto.ID, dto.UserID, dto.EventID, dto.Type, dto.Title, dto.Body, dto.Link, dto.ReadAt, dto.CreatedAt)
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to insert notification")
+		return false, errorx.Wrap(err, op)
+	}
+	if res.RowsAffected() == 0 {
+		span.AddEvent("notification of the event already exists")
+		return false, nil
+	}
+
+	return true, nil
+}
+
+// GetNotification returns the notification id of userID, the notifications
+// of the other users are not found.
+func (r *NotificationRepo) GetNotification(ctx context.Context, userID user.ID, id notification.ID) (*notification.Notification, error) {
+	const op = "postgres.NotificationRepo.GetNotification"
+	ctx, span := r.tracer.Start(ctx, "NotificationRepo.GetNotification")
+	defer span.End()
+	span.SetAttributes(
+		attribute.String("user.id", userID.String()),
+		attribute.String("notification.id", id.String()),
+	)
+
+	dto, err := scanNotification(r.pool.QueryRow(ctx, `
+		SELECT `+notificationColumns+`
+		FROM notifications
+		WHERE id = $1 AND user_id = $2;
+	`, id, userID))
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to get notification")
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errorx.NewNotFound().WithCause(err, op)
+		}
+		return nil, errorx.Wrap(err, op)
+	}
+
+	return NotificationToDomain(dto, r.clock), nil
+}
+
+// ListNotifications returns a page of the notifications of userID, the
+// newest first.
+func (r *NotificationRepo) ListNotifications(
+	ctx context.Context,
+	userID user.ID,
+	params notification.ListParams,
+) ([]*notification.Notification, error) {
+	const op = "postgres.NotificationRepo.ListNotifications"
+	ctx, span := r.tracer.Start(ctx, "NotificationRepo.ListNotifications")
+	defer span.End()
+	otelx.SetSpanAttrs(span, map[string]any{
+		"user.id":            userID.String(),
+		"params.unread_only": params.UnreadOnly,
+		"params.limit":       params.Limit,
+		"params.offset":      params.Offset,
+	})
+
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+notificationColumns+`
+		FROM notifications
+		WHERE user_id = $1 AND (NOT $2 OR read_at IS NULL)
+		ORDER BY created_at DESC, id
+		LIMIT $3 OFFSET $4;
+	`, userID, params.UnreadOnly, params.Limit, params.Offset)
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to list notifications")
+		return nil, errorx.Wrap(err, op)
+	}
+	defer rows.Close()
+
+	var notifications []*notification.Notification
+	for rows.Next() {
+		dto, err := scanNotification(rows)
+		if err != nil {
+			otelx.RecordSpanError(span, err, "failed to scan notification")
+			return nil, errorx.Wrap(err, op)
+		}
+		notifications = append(notifications, NotificationToDomain(dto, r.clock))
+	}
+	if err := rows.Err(); err != nil {
+		otelx.RecordSpanError(span, err, "failed to iterate notifications")
+		return nil, errorx.Wrap(err, op)
+	}
+
+	return notifications, nil
+}
+
+// ListNotificationsAfter returns up to limit notifications of userID created
+// after the notification after, the oldest first. An unknown after returns
+// none.
+func (r *NotificationRepo) ListNotificationsAfter(
+	ctx context.Context,
+	userID user.ID,
+	after notification.ID,
+	limit int,
+) ([]*notification.Notification, error) {
+	const op = "postgres.NotificationRepo.ListNotificationsAfter"
+	ctx, span := r.tracer.Start(ctx, "NotificationRepo.ListNotificationsAfter")
+	defer span.End()
+	otelx.SetSpanAttrs(span, map[string]any{
+		"user.id":      userID.String(),
+		"params.after": after.String(),
+		"params.limit": limit,
+	})
+
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+notificationColumns+`
+		FROM notifications n
+		WHERE n.user_id = $1 AND (n.created_at, n.id) > (
+			SELECT a.created_at, a.id FROM notifications a WHERE a.id = $2 AND a.user_id = $1
+		)
+		ORDER BY n.created_at, n.id
+		LIMIT $3;
+	`, userID, after, limit)
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to list notifications")
+		return nil, errorx.Wrap(err, op)
+	}
+	defer rows.Close()
+
+	var notifications []*notification.Notification
+	for rows.Next() {
+		dto, err := scanNotification(rows)
+		if err != nil {
+			otelx.RecordSpanError(span, err, "failed to scan notification")
+			return nil, errorx.Wrap(err, op)
+		}
+		notifications = append(notifications, NotificationToDomain(dto, r.clock))
+	}
+	if err := rows.Err(); err != nil {
+		otelx.RecordSpanError(span, err, "failed to iterate notifications")
+		return nil, errorx.Wrap(err, op)
+	}
+
+	return notifications, nil
+}
+
+// CountUnreadNotifications returns the number of notifications userID has
+// not read.
+func (r *NotificationRepo) CountUnreadNotifications(ctx context.Context, userID user.ID) (int, error) {
+	const op = "postgres.NotificationRepo.CountUnreadNotifications"
+	ctx, span := r.tracer.Start(ctx, "NotificationRepo.CountUnreadNotifications")
+	defer span.End()
+	span.SetAttributes(attribute.String("user.id", userID.String()))
+
+	var count int
+	err := r.pool.QueryRow(ctx, `
+		SELECT count(*) FROM notifications WHERE user_id = $1 AND read_at IS NULL;
+	`, userID).Scan(&count)
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to count unread notifications")
+		return 0, errorx.Wrap(err, op)
+	}
+
+	return count, nil
+}
+
+// UpdateNotification locks the notification id of userID, runs fn on it and
+// saves it. The notifications of the other users are not found.
+func (r *NotificationRepo) UpdateNotification(
+	ctx context.Context,
+	userID user.ID,
+	id notification.ID,
+	fn func(ctx context.Context, n *notification.Notification) error,
+) error {
+	const op = "postgres.NotificationRepo.UpdateNotification"
+	ctx, span := r.tracer.Start(ctx, "NotificationRepo.UpdateNotification")
+	defer span.End()
+	span.SetAttributes(
+		attribute.String("user.id", userID.String()),
+		attribute.String("notification.id", id.String()),
+	)
+	if fn == nil {
+		otelx.RecordSpanError(span, ErrNilFunc, "update function cannot be nil")
+		return ErrNilFunc
+	}
+
+	err := postgres.WithTx(ctx, r.pool, func(ctx context.Context, tx pgx.Tx) error {
+		dto, err := scanNotification(tx.QueryRow(ctx, `
+			SELECT `+notificationColumns+`
+			FROM notifications
+			WHERE id = $1 AND user_id = $2
+			FOR UPDATE;
+		`, id, userID))
+		if err != nil {
+			otelx.RecordSpanError(span, err, "failed to get notification")
+			if errors.Is(err, pgx.ErrNoRows) {
+				return errorx.NewNotFound().WithCause(err, op)
+			}
+			return errorx.Wrap(err, op)
+		}
+
+		n := NotificationToDomain(dto, r.clock)
+		if err := fn(ctx, n); err != nil {
+			otelx.RecordSpanError(span, err, "update function returned an error")
+			return errorx.Wrap(err, op)
+		}
+
+		_, err = tx.Exec(ctx, `UPDATE notifications SET read_at = $2 WHERE id = $1;`, id, n.ReadAt())
+		if err != nil {
+			otelx.RecordSpanError(span, err, "failed to update notification")
+			return errorx.Wrap(err, op)
+		}
+		return nil
+	})
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to execute transaction")
+		return err
+	}
+
+	return nil
+}
+
+// MarkAllNotificationsRead marks the unread notifications of userID as read
+// at readAt and returns how many there were.
+func (r *NotificationRepo) MarkAllNotificationsRead(ctx context.Context, userID user.ID, readAt time.Time) (int, error) {
+	const op = "postgres.NotificationRepo.MarkAllNotificationsRead"
+	ctx, span := r.tracer.Start(ctx, "NotificationRepo.MarkAllNotificationsRead")
+	defer span.End()
+	span.SetAttributes(attribute.String("user.id", userID.String()))
+
+	res, err := r.pool.Exec(ctx, `
+		UPDATE notifications SET read_at = $2
+		WHERE user_id = $1 AND read_at IS NULL;
+	`, userID, readAt)
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to mark notifications as read")
+		return 0, errorx.Wrap(err, op)
+	}
+
+	return int(res.RowsAffected()), nil
+}
+
+func scanNotification(row pgx.Row) (NotificationDTO, error) {
+	var dto NotificationDTO
+	err := row.Scan(
+		&dto.ID,
+		&dto.UserID,
+		&dto.EventID,
+		&dto.Type,
+		&dto.Title,
+		&dto.Body,
+		&dto.Link,
+		&dto.ReadAt,
+		&dto.CreatedAt,
+	)
+	return dto, err
+}
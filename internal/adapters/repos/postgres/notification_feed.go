@@ -0,0 +1,105 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/notification"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx"
+)
+
+// NotificationChannel is the LISTEN/NOTIFY channel of the new notifications.
+const NotificationChannel = "notifications"
+
+// notificationPayload is the payload of NotificationChannel. It carries the
+// IDs only, the notifications may be longer than a NOTIFY payload can be.
+type notificationPayload struct {
+	UserID user.ID         `json:"user_id"`
+	ID     notification.ID `json:"id"`
+}
+
+// NotificationFeed announces the new notifications to every instance through
+// NotificationChannel.
+type NotificationFeed struct {
+	tracer trace.Tracer
+	logger *slog.Logger
+	pool   *pgxpool.Pool
+}
+
+// NewNotificationFeed creates a new NotificationFeed.
+// It also sets default tracer and logger if they are nil.
+//
+//	WARNING: panics if pool is nil
+func NewNotificationFeed(pool *pgxpool.Pool, t trace.Tracer, l *slog.Logger) *NotificationFeed {
+	if pool == nil {
+		panic("pgxpool.Pool is required")
+	}
+	if t == nil {
+		t = tracer
+	}
+	if l == nil {
+		l = logger
+	}
+
+	return &NotificationFeed{
+		tracer: t,
+		logger: l,
+		pool:   pool,
+	}
+}
+
+// PublishNotification notifies the listeners of every instance, this one
+// included, of n.
+func (f *NotificationFeed) PublishNotification(ctx context.Context, n *notification.Notification) error {
+	const op = "postgres.NotificationFeed.PublishNotification"
+	ctx, span := f.tracer.Start(ctx, "NotificationFeed.PublishNotification")
+	defer span.End()
+	span.SetAttributes(
+		attribute.String("user.id", n.UserID().String()),
+		attribute.String("notification.id", n.ID().String()),
+	)
+
+	payload, err := json.Marshal(notificationPayload{UserID: n.UserID(), ID: n.ID()})
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to marshal notification payload")
+		return errorx.Wrap(err, op)
+	}
+
+	_, err = f.pool.Exec(ctx, `SELECT pg_notify($1, $2);`, NotificationChannel, string(payload))
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to notify")
+		return errorx.Wrap(err, op)
+	}
+
+	return nil
+}
+
+// Listen passes the notifications published by every instance to notify
+// until ctx is done. It holds a connection of the pool for as long and
+// listens again on a new one when the connection is lost, the notifications
+// published in between are missed, the streams resume them from the
+// database.
+func (f *NotificationFeed) Listen(ctx context.Context, notify func(ctx context.Context, userID user.ID, id notification.ID) error) {
+	listenChannel(ctx, f.pool, f.logger, NotificationChannel, func(ctx context.Context, msg string) {
+		var payload notificationPayload
+		if err := json.Unmarshal([]byte(msg), &payload); err != nil {
+			f.logger.ErrorContext(ctx, "invalid notification payload",
+				slog.String("payload", msg),
+				slog.String("error", err.Error()))
+			return
+		}
+		if err := notify(ctx, payload.UserID, payload.ID); err != nil {
+			f.logger.ErrorContext(ctx, "failed to relay notification",
+				slog.String("user.id", payload.UserID.String()),
+				slog.String("notification.id", payload.ID.String()),
+				slog.String("error", err.Error()))
+		}
+	})
+}
@@ -0,0 +1,97 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/attribute"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/postgres"
+)
+
+// pendingUploadTTLSeconds is user.PendingUploadTTL for the SQL intervals.
+var pendingUploadTTLSeconds = user.PendingUploadTTL.Seconds()
+
+// CreatePendingUpload returns the token of an upload of the avatar object key
+// by the user, to be consumed once the object is stored. It is taken under
+// the lock of the key, the object is not removed from under it, see
+// ReleaseAvatarRef. The expired tokens of the user are dropped.
+func (r *UserRepo) CreatePendingUpload(ctx context.Context, userID user.ID, key string) (uuid.UUID, error) {
+	const op = "postgres.UserRepo.CreatePendingUpload"
+	ctx, span := r.tracer.Start(ctx, "UserRepo.CreatePendingUpload")
+	defer span.End()
+	span.SetAttributes(
+		attribute.String("user.id", userID.String()),
+		attribute.String("avatar.s3_key", key),
+	)
+
+	token := uuid.New()
+	err := postgres.WithTx(ctx, r.pool, func(ctx context.Context, tx pgx.Tx) error {
+		if err := lockAvatarKey(ctx, tx, key); err != nil {
+			otelx.RecordSpanError(span, err, "failed to lock avatar key")
+			return errorx.Wrap(err, op)
+		}
+
+		_, err := tx.Exec(ctx, `
+			DELETE FROM pending_uploads
+			WHERE user_id = $1 AND created_at <= now() - $2 * interval '1 second';
+		`, userID, pendingUploadTTLSeconds)
+		if err != nil {
+			otelx.RecordSpanError(span, err, "failed to delete expired pending uploads")
+			return errorx.Wrap(err, op)
+		}
+
+		_, err = tx.Exec(ctx, `
+			INSERT INTO pending_uploads (id, user_id, s3_key) VALUES ($1, $2, $3);
+		`, token, userID, key)
+		if err != nil {
+			otelx.RecordSpanError(span, err, "failed to insert pending upload")
+			return errorx.Wrap(err, op)
+		}
+		return nil
+	})
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return token, nil
+}
+
+// ConsumePendingUpload removes the token of the upload of key by the user.
+// It fails with user.ErrUploadExpired when the token expired or was consumed
+// already.
+func (r *UserRepo) ConsumePendingUpload(ctx context.Context, token uuid.UUID, userID user.ID, key string) error {
+	const op = "postgres.UserRepo.ConsumePendingUpload"
+	ctx, span := r.tracer.Start(ctx, "UserRepo.ConsumePendingUpload")
+	defer span.End()
+	span.SetAttributes(
+		attribute.String("user.id", userID.String()),
+		attribute.String("avatar.s3_key", key),
+	)
+
+	err := postgres.WithTx(ctx, r.pool, func(ctx context.Context, tx pgx.Tx) error {
+		res, err := tx.Exec(ctx, `
+			DELETE FROM pending_uploads
+			WHERE id = $1 AND user_id = $2 AND s3_key = $3
+				AND created_at > now() - $4 * interval '1 second';
+		`, token, userID, key, pendingUploadTTLSeconds)
+		if err != nil {
+			otelx.RecordSpanError(span, err, "failed to delete pending upload")
+			return errorx.Wrap(err, op)
+		}
+		if res.RowsAffected() == 0 {
+			otelx.RecordSpanError(span, user.ErrUploadExpired, "pending upload expired")
+			return errorx.Wrap(user.ErrUploadExpired, op)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
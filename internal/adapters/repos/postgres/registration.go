@@ -2,8 +2,8 @@ package postgres
 
 import (
 	"context"
-	"errors"
 	"log/slog"
+	"time"
 
 	"github.com/ThreeDotsLabs/watermill"
 	"github.com/google/uuid"
@@ -12,6 +12,9 @@ import (
 	"go.opentelemetry.io/otel/trace"
 
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/registration"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/emails"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/ctxs"
 	"gitlab.com/ucmsv2/ucms-backend/pkg/env"
 	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
 	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx"
@@ -24,6 +27,7 @@ type RegistrationRepo struct {
 	logger  *slog.Logger
 	pool    *pgxpool.Pool
 	wlogger watermill.LoggerAdapter
+	clock   clock.Clock
 }
 
 // NewRegistrationRepo creates a new instance of RegistrationRepo.
@@ -49,32 +53,36 @@ func NewRegistrationRepo(pool *pgxpool.Pool, t trace.Tracer, l *slog.Logger) *Re
 	}
 }
 
-func (r *RegistrationRepo) GetRegistrationByEmail(ctx context.Context, email string) (*registration.Registration, error) {
+// WithClock sets the clock the loaded registrations are rehydrated with,
+// clock.Real by default.
+func (r *RegistrationRepo) WithClock(c clock.Clock) *RegistrationRepo {
+	r.clock = c
+	return r
+}
+
+func (r *RegistrationRepo) GetRegistrationByEmail(ctx context.Context, email emails.Email) (*registration.Registration, error) {
 	const op = "postgres.RegistrationRepo.GetRegistrationByEmail"
 	ctx, span := r.tracer.Start(ctx, "RegistrationRepo.GetRegistrationByEmail")
 	defer span.End()
 
 	query := `
-        SELECT id, email, status, verification_code, code_attempts, code_expires_at, resend_timeout, created_at, updated_at
+        SELECT id, email, status, verification_code, code_attempts, code_expires_at, resend_timeout, created_at, updated_at, version
         FROM registrations
-        WHERE email = $1;
+        WHERE email = $1 AND ($2::text IS NULL OR campus_id = $2);
     `
 
 	var dto RegistrationDTO
-	err := r.pool.QueryRow(ctx, query, email).Scan(
+	err := r.pool.QueryRow(ctx, query, email, campusScope(ctx)).Scan(
 		&dto.ID, &dto.Email, &dto.Status,
 		&dto.VerificationCode, &dto.CodeAttempts, &dto.CodeExpiresAt,
-		&dto.ResendTimeout, &dto.CreatedAt, &dto.UpdatedAt,
+		&dto.ResendTimeout, &dto.CreatedAt, &dto.UpdatedAt, &dto.Version,
 	)
 	if err != nil {
 		otelx.RecordSpanError(span, err, "failed to get registration by email")
-		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, errorx.NewNotFound().WithCause(err, op)
-		}
-		return nil, errorx.Wrap(err, op)
+		return nil, translateError(err, op)
 	}
 
-	return RegistrationToDomain(dto), nil
+	return RegistrationToDomain(dto, r.clock), nil
 }
 
 func (re *RegistrationRepo) GetRegistrationByID(ctx context.Context, id registration.ID) (*registration.Registration, error) {
@@ -83,26 +91,61 @@ func (re *RegistrationRepo) GetRegistrationByID(ctx context.Context, id registra
 	defer span.End()
 
 	query := `
-		SELECT id, email, status, verification_code, code_attempts, code_expires_at, resend_timeout, created_at, updated_at
+		SELECT id, email, status, verification_code, code_attempts, code_expires_at, resend_timeout, created_at, updated_at, version
 		FROM registrations
-		WHERE id = $1;
+		WHERE id = $1 AND ($2::text IS NULL OR campus_id = $2);
 	`
 
 	var dto RegistrationDTO
-	err := re.pool.QueryRow(ctx, query, uuid.UUID(id)).Scan(
+	err := re.pool.QueryRow(ctx, query, uuid.UUID(id), campusScope(ctx)).Scan(
 		&dto.ID, &dto.Email, &dto.Status,
 		&dto.VerificationCode, &dto.CodeAttempts, &dto.CodeExpiresAt,
-		&dto.ResendTimeout, &dto.CreatedAt, &dto.UpdatedAt,
+		&dto.ResendTimeout, &dto.CreatedAt, &dto.UpdatedAt, &dto.Version,
 	)
 	if err != nil {
 		otelx.RecordSpanError(span, err, "failed to get registration by id")
-		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, errorx.NewNotFound().WithCause(err, op)
-		}
-		return nil, errorx.Wrap(err, op)
+		return nil, translateError(err, op)
 	}
 
-	return RegistrationToDomain(dto), nil
+	return RegistrationToDomain(dto, re.clock), nil
+}
+
+// CountPendingRegistrations returns the number of registrations that were
+// started but not completed yet, verified ones included.
+func (re *RegistrationRepo) CountPendingRegistrations(ctx context.Context) (int64, error) {
+	const op = "postgres.RegistrationRepo.CountPendingRegistrations"
+	ctx, span := re.tracer.Start(ctx, "RegistrationRepo.CountPendingRegistrations")
+	defer span.End()
+
+	var count int64
+	err := re.pool.QueryRow(ctx, `
+		SELECT count(*) FROM registrations WHERE status = ANY($1);
+	`, []string{registration.StatusPending.String(), registration.StatusVerified.String()}).Scan(&count)
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to count pending registrations")
+		return 0, errorx.Wrap(err, op)
+	}
+
+	return count, nil
+}
+
+// DeleteUnfinishedRegistrations deletes the registrations created before
+// cutoff that were not completed, and returns how many were deleted.
+func (re *RegistrationRepo) DeleteUnfinishedRegistrations(ctx context.Context, cutoff time.Time) (int64, error) {
+	const op = "postgres.RegistrationRepo.DeleteUnfinishedRegistrations"
+	ctx, span := re.tracer.Start(ctx, "RegistrationRepo.DeleteUnfinishedRegistrations")
+	defer span.End()
+
+	res, err := re.pool.Exec(ctx, `
+		DELETE FROM registrations
+		WHERE status <> $1 AND created_at < $2 AND ($3::text IS NULL OR campus_id = $3);
+	`, registration.StatusCompleted.String(), cutoff, campusScope(ctx))
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to delete unfinished registrations")
+		return 0, errorx.Wrap(err, op)
+	}
+
+	return res.RowsAffected(), nil
 }
 
 func (re *RegistrationRepo) SaveRegistration(ctx context.Context, r *registration.Registration) error {
@@ -113,8 +156,8 @@ func (re *RegistrationRepo) SaveRegistration(ctx context.Context, r *registratio
 	dto := DomainToRegistrationDTO(r)
 
 	query := `
-        INSERT INTO registrations (id, email, status, verification_code, code_attempts, code_expires_at, resend_timeout, created_at, updated_at)
-        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+        INSERT INTO registrations (id, email, status, verification_code, code_attempts, code_expires_at, resend_timeout, created_at, updated_at, campus_id)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
     `
 
 	err := postgres.WithTx(ctx, re.pool, func(ctx context.Context, tx pgx.Tx) error {
@@ -122,10 +165,11 @@ func (re *RegistrationRepo) SaveRegistration(ctx context.Context, r *registratio
 			dto.ID, dto.Email, dto.Status,
 			dto.VerificationCode, dto.CodeAttempts, dto.CodeExpiresAt,
 			dto.ResendTimeout, dto.CreatedAt, dto.UpdatedAt,
+			ctxs.CampusFromCtx(ctx),
 		)
 		if err != nil {
 			otelx.RecordSpanError(span, err, "failed to insert registration")
-			return errorx.Wrap(err, op)
+			return translateError(err, op)
 		}
 		if res.RowsAffected() == 0 {
 			otelx.RecordSpanError(span, ErrNoRowsAffected, "no rows affected when inserting registration")
@@ -133,6 +177,10 @@ func (re *RegistrationRepo) SaveRegistration(ctx context.Context, r *registratio
 		}
 
 		if events := r.GetUncommittedEvents(); len(events) > 0 {
+			if err := saveVersion(ctx, tx, "registrations", dto.ID, r); err != nil {
+				otelx.RecordSpanError(span, err, "failed to save version")
+				return errorx.Wrap(err, op)
+			}
 			if err := watermillx.Publish(ctx, tx, re.wlogger, events...); err != nil {
 				otelx.RecordSpanError(span, err, "failed to publish events")
 				return errorx.Wrap(err, op)
@@ -144,6 +192,7 @@ func (re *RegistrationRepo) SaveRegistration(ctx context.Context, r *registratio
 		otelx.RecordSpanError(span, err, "transaction to save registration failed")
 		return err
 	}
+	r.CommitEvents()
 
 	return nil
 }
@@ -162,7 +211,7 @@ func (re *RegistrationRepo) UpdateRegistration(
 	}
 
 	selectquery := `
-        SELECT id, email, status, verification_code, code_attempts, code_expires_at, resend_timeout, created_at, updated_at
+        SELECT id, email, status, verification_code, code_attempts, code_expires_at, resend_timeout, created_at, updated_at, version
         FROM registrations
         WHERE id = $1
         FOR UPDATE;
@@ -180,17 +229,14 @@ func (re *RegistrationRepo) UpdateRegistration(
 		err := tx.QueryRow(ctx, selectquery, uuid.UUID(id)).Scan(
 			&dto.ID, &dto.Email, &dto.Status,
 			&dto.VerificationCode, &dto.CodeAttempts, &dto.CodeExpiresAt,
-			&dto.ResendTimeout, &dto.CreatedAt, &dto.UpdatedAt,
+			&dto.ResendTimeout, &dto.CreatedAt, &dto.UpdatedAt, &dto.Version,
 		)
 		if err != nil {
 			otelx.RecordSpanError(span, err, "failed to get registration for update")
-			if errors.Is(err, pgx.ErrNoRows) {
-				return errorx.NewNotFound().WithCause(err, op)
-			}
-			return errorx.Wrap(err, op)
+			return translateError(err, op)
 		}
 
-		reg := RegistrationToDomain(dto)
+		reg := RegistrationToDomain(dto, re.clock)
 
 		fnerr := fn(ctx, reg)
 		if fnerr != nil && !errorx.IsPersistable(fnerr) {
@@ -216,6 +262,10 @@ func (re *RegistrationRepo) UpdateRegistration(
 
 		events := reg.GetUncommittedEvents()
 		if len(events) > 0 {
+			if err := saveVersion(ctx, tx, "registrations", dto.ID, reg); err != nil {
+				otelx.RecordSpanError(span, err, "failed to save version")
+				return errorx.Wrap(err, op)
+			}
 			if err := watermillx.Publish(ctx, tx, re.wlogger, events...); err != nil {
 				otelx.RecordSpanError(span, err, "failed to publish events")
 				return errorx.Wrap(err, op)
@@ -238,7 +288,7 @@ func (re *RegistrationRepo) UpdateRegistration(
 
 func (re *RegistrationRepo) UpdateRegistrationByEmail(
 	ctx context.Context,
-	email string,
+	email emails.Email,
 	fn func(ctx context.Context, r *registration.Registration) error,
 ) error {
 	const op = "postgres.RegistrationRepo.UpdateRegistrationByEmail"
@@ -250,7 +300,7 @@ func (re *RegistrationRepo) UpdateRegistrationByEmail(
 	}
 
 	selectquery := `
-        SELECT id, email, status, verification_code, code_attempts, code_expires_at, resend_timeout, created_at, updated_at
+        SELECT id, email, status, verification_code, code_attempts, code_expires_at, resend_timeout, created_at, updated_at, version
         FROM registrations
         WHERE email = $1
         FOR UPDATE;
@@ -268,17 +318,14 @@ func (re *RegistrationRepo) UpdateRegistrationByEmail(
 		err := tx.QueryRow(ctx, selectquery, email).Scan(
 			&dto.ID, &dto.Email, &dto.Status,
 			&dto.VerificationCode, &dto.CodeAttempts, &dto.CodeExpiresAt,
-			&dto.ResendTimeout, &dto.CreatedAt, &dto.UpdatedAt,
+			&dto.ResendTimeout, &dto.CreatedAt, &dto.UpdatedAt, &dto.Version,
 		)
 		if err != nil {
 			otelx.RecordSpanError(span, err, "failed to get registration for update")
-			if errors.Is(err, pgx.ErrNoRows) {
-				return errorx.NewNotFound().WithCause(err, op)
-			}
-			return errorx.Wrap(err, op)
+			return translateError(err, op)
 		}
 
-		reg := RegistrationToDomain(dto)
+		reg := RegistrationToDomain(dto, re.clock)
 
 		fnerr := fn(ctx, reg)
 		if fnerr != nil && !errorx.IsPersistable(fnerr) {
@@ -303,6 +350,10 @@ func (re *RegistrationRepo) UpdateRegistrationByEmail(
 
 		events := reg.GetUncommittedEvents()
 		if len(events) > 0 {
+			if err := saveVersion(ctx, tx, "registrations", dto.ID, reg); err != nil {
+				otelx.RecordSpanError(span, err, "failed to save version")
+				return errorx.Wrap(err, op)
+			}
 			if err := watermillx.Publish(ctx, tx, re.wlogger, events...); err != nil {
 				otelx.RecordSpanError(span, err, "failed to publish events")
 				return errorx.Wrap(err, op)
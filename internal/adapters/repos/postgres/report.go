@@ -0,0 +1,238 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/registration"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/report"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/roles"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/watermillx"
+)
+
+// ReportRepo aggregates the reports over the other tables and stores the
+// generated ones.
+type ReportRepo struct {
+	tracer trace.Tracer
+	pool   *pgxpool.Pool
+}
+
+// NewReportRepo creates a new ReportRepo.
+//
+//	WARNING: panics if pool is nil
+func NewReportRepo(pool *pgxpool.Pool, t trace.Tracer) *ReportRepo {
+	if pool == nil {
+		panic("pgxpool.Pool is required")
+	}
+	if t == nil {
+		t = tracer
+	}
+
+	return &ReportRepo{
+		tracer: t,
+		pool:   pool,
+	}
+}
+
+// CountProvisioning counts the accounts provisioned in [from, to).
+func (r *ReportRepo) CountProvisioning(ctx context.Context, from, to time.Time) (report.Provisioning, error) {
+	const op = "postgres.ReportRepo.CountProvisioning"
+	ctx, span := r.tracer.Start(ctx, "ReportRepo.CountProvisioning")
+	defer span.End()
+
+	var p report.Provisioning
+	err := r.pool.QueryRow(ctx, `
+		SELECT
+			(SELECT count(*) FROM registrations
+			 WHERE created_at >= $1 AND created_at < $2),
+			(SELECT count(*) FROM registrations
+			 WHERE created_at >= $1 AND created_at < $2 AND status = $3),
+			(SELECT count(*) FROM staffs s JOIN users u ON u.id = s.user_id
+			 WHERE u.created_at >= $1 AND u.created_at < $2),
+			(SELECT count(*) FROM students
+			 WHERE enrollment_changed_at >= $1 AND enrollment_changed_at < $2
+			   AND enrollment_status = ANY($4));
+	`, from, to, registration.StatusCompleted.String(),
+		[]string{user.AcademicLeave.String(), user.Expelled.String()},
+	).Scan(&p.Registrations, &p.CompletedRegistrations, &p.NewStaff, &p.SuspendedAccounts)
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to count provisioning")
+		return report.Provisioning{}, errorx.Wrap(err, op)
+	}
+
+	return p, nil
+}
+
+// ListReportRecipients lists the staff, they receive the reports.
+func (r *ReportRepo) ListReportRecipients(ctx context.Context) ([]report.Recipient, error) {
+	const op = "postgres.ReportRepo.ListReportRecipients"
+	ctx, span := r.tracer.Start(ctx, "ReportRepo.ListReportRecipients")
+	defer span.End()
+
+	rows, err := r.pool.Query(ctx, `
+		SELECT u.id, u.email, u.first_name
+		FROM users u
+		JOIN global_roles gr ON u.role_id = gr.id
+		WHERE gr.name = $1
+		ORDER BY u.email;
+	`, roles.Staff.String())
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to query report recipients")
+		return nil, errorx.Wrap(err, op)
+	}
+	recipients, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (report.Recipient, error) {
+		var id uuid.UUID
+		var rc report.Recipient
+		err := row.Scan(&id, &rc.Email, &rc.FirstName)
+		rc.UserID = user.ID(id)
+		return rc, err
+	})
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to scan report recipients")
+		return nil, errorx.Wrap(err, op)
+	}
+
+	return recipients, nil
+}
+
+// SaveWeeklyReport stores w unless the report of its week is stored already,
+// and reports whether it did.
+func (r *ReportRepo) SaveWeeklyReport(ctx context.Context, w *report.Weekly) (bool, error) {
+	const op = "postgres.ReportRepo.SaveWeeklyReport"
+	ctx, span := r.tracer.Start(ctx, "ReportRepo.SaveWeeklyReport")
+	defer span.End()
+	span.SetAttributes(attribute.String("report.week_start", w.WeekStart.Format(time.DateOnly)))
+
+	data, err := json.Marshal(w)
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to marshal weekly report")
+		return false, errorx.Wrap(err, op)
+	}
+
+	res, err := r.pool.Exec(ctx, `
+		INSERT INTO weekly_reports (week_start, report, generated_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (week_start) DO NOTHING;
+	`, w.WeekStart, data, w.GeneratedAt)
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to insert weekly report")
+		return false, errorx.Wrap(err, op)
+	}
+
+	return res.RowsAffected() == 1, nil
+}
+
+// GetWeeklyReport returns the report of the week starting at weekStart.
+func (r *ReportRepo) GetWeeklyReport(ctx context.Context, weekStart time.Time) (*report.Weekly, error) {
+	const op = "postgres.ReportRepo.GetWeeklyReport"
+	ctx, span := r.tracer.Start(ctx, "ReportRepo.GetWeeklyReport")
+	defer span.End()
+	span.SetAttributes(attribute.String("report.week_start", weekStart.Format(time.DateOnly)))
+
+	var data []byte
+	err := r.pool.QueryRow(ctx, `SELECT report FROM weekly_reports WHERE week_start = $1;`, weekStart).Scan(&data)
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to get weekly report")
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errorx.NewNotFound().WithCause(err, op)
+		}
+		return nil, errorx.Wrap(err, op)
+	}
+
+	var w report.Weekly
+	if err := json.Unmarshal(data, &w); err != nil {
+		otelx.RecordSpanError(span, err, "failed to unmarshal weekly report")
+		return nil, errorx.Wrap(err, op)
+	}
+
+	return &w, nil
+}
+
+// CountRegistrationsByStatus returns the number of registrations started in
+// [from, to) per status, statuses without registrations are left out.
+func (r *ReportRepo) CountRegistrationsByStatus(ctx context.Context, from, to time.Time) (map[registration.Status]int64, error) {
+	const op = "postgres.ReportRepo.CountRegistrationsByStatus"
+	ctx, span := r.tracer.Start(ctx, "ReportRepo.CountRegistrationsByStatus")
+	defer span.End()
+
+	rows, err := r.pool.Query(ctx, `
+		SELECT status, count(*)
+		FROM registrations
+		WHERE created_at >= $1 AND created_at < $2
+		GROUP BY status;
+	`, from, to)
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to count registrations by status")
+		return nil, errorx.Wrap(err, op)
+	}
+	defer rows.Close()
+
+	counts := make(map[registration.Status]int64)
+	for rows.Next() {
+		var (
+			status registration.Status
+			count  int64
+		)
+		if err := rows.Scan(&status, &count); err != nil {
+			otelx.RecordSpanError(span, err, "failed to scan registration count")
+			return nil, errorx.Wrap(err, op)
+		}
+		counts[status] = count
+	}
+	if err := rows.Err(); err != nil {
+		otelx.RecordSpanError(span, err, "failed to iterate registration counts")
+		return nil, errorx.Wrap(err, op)
+	}
+
+	return counts, nil
+}
+
+// CountActiveInvitations counts the staff invitations that can be accepted
+// at now and their recipients.
+func (r *ReportRepo) CountActiveInvitations(ctx context.Context, now time.Time) (report.Invitations, error) {
+	const op = "postgres.ReportRepo.CountActiveInvitations"
+	ctx, span := r.tracer.Start(ctx, "ReportRepo.CountActiveInvitations")
+	defer span.End()
+
+	var inv report.Invitations
+	err := r.pool.QueryRow(ctx, `
+		SELECT count(*), coalesce(sum(coalesce(cardinality(recipients_email), 0)), 0)
+		FROM staff_invitations
+		WHERE deleted_at IS NULL
+		  AND (valid_from IS NULL OR valid_from <= $1)
+		  AND (valid_until IS NULL OR valid_until > $1);
+	`, now).Scan(&inv.Active, &inv.OutstandingRecipients)
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to count active invitations")
+		return report.Invitations{}, errorx.Wrap(err, op)
+	}
+
+	return inv, nil
+}
+
+// CountDeadLetters counts the dead letters poisoned since since by the
+// handlers named handlers, see watermillx.CountDeadLetters.
+func (r *ReportRepo) CountDeadLetters(ctx context.Context, handlers []string, since time.Time) (int64, error) {
+	const op = "postgres.ReportRepo.CountDeadLetters"
+	ctx, span := r.tracer.Start(ctx, "ReportRepo.CountDeadLetters")
+	defer span.End()
+
+	count, err := watermillx.CountDeadLetters(ctx, r.pool, handlers, since)
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to count dead letters")
+		return 0, errorx.Wrap(err, op)
+	}
+
+	return count, nil
+}
@@ -0,0 +1,208 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/retention"
+)
+
+// retentionTable is a table the retention job removes rows from, its
+// primary key and the time its rows expire after.
+type retentionTable struct {
+	key  string
+	time string
+}
+
+// retentionTables are the tables of the retention categories, see
+// retention.Category.Tables. The names are interpolated in the queries, only
+// the ones listed here are accepted.
+var retentionTables = map[string]retentionTable{
+	"impersonations":        {key: "id", time: "started_at"},
+	"staff_bootstrap_audit": {key: "id", time: "created_at"},
+	"error_events":          {key: "signature", time: "last_seen"},
+}
+
+// RetentionRepo reads and deletes the rows past their retention window and
+// keeps the manifests of the archives, see retention.Archiver.
+type RetentionRepo struct {
+	tracer trace.Tracer
+	pool   *pgxpool.Pool
+}
+
+// NewRetentionRepo creates a new RetentionRepo.
+//
+//	WARNING: panics if pool is nil
+func NewRetentionRepo(pool *pgxpool.Pool, t trace.Tracer) *RetentionRepo {
+	if pool == nil {
+		panic("pgxpool.Pool is required")
+	}
+	if t == nil {
+		t = tracer
+	}
+
+	return &RetentionRepo{
+		tracer: t,
+		pool:   pool,
+	}
+}
+
+func (r *RetentionRepo) table(name string) (retentionTable, error) {
+	t, ok := retentionTables[name]
+	if !ok {
+		return retentionTable{}, fmt.Errorf("table %q has no retention", name)
+	}
+	return t, nil
+}
+
+// ListExpiredRows pages the rows of table older than cutoff by their key as
+// text, the order of the key type does not matter to the pages.
+func (r *RetentionRepo) ListExpiredRows(ctx context.Context, table string, cutoff time.Time, after string, limit int) ([]retention.Row, error) {
+	const op = "postgres.RetentionRepo.ListExpiredRows"
+	ctx, span := r.tracer.Start(ctx, "RetentionRepo.ListExpiredRows", trace.WithAttributes(
+		attribute.String("retention.table", table),
+		attribute.Int("retention.limit", limit),
+	))
+	defer span.End()
+
+	t, err := r.table(table)
+	if err != nil {
+		otelx.RecordSpanError(span, err, "unknown table")
+		return nil, errorx.Wrap(err, op)
+	}
+
+	rows, err := r.pool.Query(ctx, fmt.Sprintf(`
+		SELECT t.%[2]s::text COLLATE "C", row_to_json(t)::text
+		FROM %[1]s t
+		WHERE t.%[3]s < $1 AND t.%[2]s::text COLLATE "C" > $2
+		ORDER BY 1
+		LIMIT $3;
+	`, table, t.key, t.time), cutoff, after, limit)
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to list expired rows")
+		return nil, errorx.Wrap(err, op)
+	}
+	defer rows.Close()
+
+	var expired []retention.Row
+	for rows.Next() {
+		var key, data string
+		if err := rows.Scan(&key, &data); err != nil {
+			otelx.RecordSpanError(span, err, "failed to scan expired row")
+			return nil, errorx.Wrap(err, op)
+		}
+		expired = append(expired, retention.Row{Key: key, Data: []byte(data)})
+	}
+	if err := rows.Err(); err != nil {
+		otelx.RecordSpanError(span, err, "failed to iterate expired rows")
+		return nil, errorx.Wrap(err, op)
+	}
+
+	return expired, nil
+}
+
+// DeleteExpiredRows deletes up to limit rows of table older than cutoff, the
+// statement is a transaction of its own.
+func (r *RetentionRepo) DeleteExpiredRows(ctx context.Context, table string, cutoff time.Time, limit int) (int64, error) {
+	const op = "postgres.RetentionRepo.DeleteExpiredRows"
+	ctx, span := r.tracer.Start(ctx, "RetentionRepo.DeleteExpiredRows", trace.WithAttributes(
+		attribute.String("retention.table", table),
+		attribute.Int("retention.limit", limit),
+	))
+	defer span.End()
+
+	t, err := r.table(table)
+	if err != nil {
+		otelx.RecordSpanError(span, err, "unknown table")
+		return 0, errorx.Wrap(err, op)
+	}
+
+	res, err := r.pool.Exec(ctx, fmt.Sprintf(`
+		DELETE FROM %[1]s
+		WHERE %[2]s IN (
+			SELECT %[2]s FROM %[1]s
+			WHERE %[3]s < $1
+			LIMIT $2
+		);
+	`, table, t.key, t.time), cutoff, limit)
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to delete expired rows")
+		return 0, errorx.Wrap(err, op)
+	}
+	span.SetAttributes(attribute.Int64("retention.deleted", res.RowsAffected()))
+
+	return res.RowsAffected(), nil
+}
+
+func (r *RetentionRepo) SaveArchive(ctx context.Context, a retention.Archive) error {
+	const op = "postgres.RetentionRepo.SaveArchive"
+	ctx, span := r.tracer.Start(ctx, "RetentionRepo.SaveArchive", trace.WithAttributes(
+		attribute.String("archive.id", a.ID.String()),
+		attribute.String("archive.key", a.Key),
+	))
+	defer span.End()
+
+	_, err := r.pool.Exec(ctx, `
+		INSERT INTO archive_manifests (id, category, table_name, object_key, row_count, size_bytes, cutoff, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8);
+	`, a.ID, string(a.Category), a.Table, a.Key, a.Rows, a.Size, a.Cutoff, a.CreatedAt)
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to insert archive manifest")
+		return translateError(err, op)
+	}
+
+	return nil
+}
+
+// ListArchives lists the archive manifests, the latest first.
+func (r *RetentionRepo) ListArchives(ctx context.Context, params retention.ListParams) ([]retention.Archive, error) {
+	const op = "postgres.RetentionRepo.ListArchives"
+	ctx, span := r.tracer.Start(ctx, "RetentionRepo.ListArchives")
+	defer span.End()
+	otelx.SetSpanAttrs(span, map[string]any{
+		"params.table":  params.Table,
+		"params.limit":  params.Limit,
+		"params.offset": params.Offset,
+	})
+
+	rows, err := r.pool.Query(ctx, `
+		SELECT id, category, table_name, object_key, row_count, size_bytes, cutoff, created_at
+		FROM archive_manifests
+		WHERE $1 = '' OR table_name = $1
+		ORDER BY created_at DESC, object_key DESC
+		LIMIT $2 OFFSET $3;
+	`, params.Table, params.Limit, params.Offset)
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to list archive manifests")
+		return nil, errorx.Wrap(err, op)
+	}
+	defer rows.Close()
+
+	var archives []retention.Archive
+	for rows.Next() {
+		var (
+			a        retention.Archive
+			category string
+		)
+		err := rows.Scan(&a.ID, &category, &a.Table, &a.Key, &a.Rows, &a.Size, &a.Cutoff, &a.CreatedAt)
+		if err != nil {
+			otelx.RecordSpanError(span, err, "failed to scan archive manifest")
+			return nil, errorx.Wrap(err, op)
+		}
+		a.Category = retention.Category(category)
+		archives = append(archives, a)
+	}
+	if err := rows.Err(); err != nil {
+		otelx.RecordSpanError(span, err, "failed to iterate archive manifests")
+		return nil, errorx.Wrap(err, op)
+	}
+
+	return archives, nil
+}
@@ -0,0 +1,128 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/schemaversion"
+)
+
+type SchemaVersionRepo struct {
+	tracer trace.Tracer
+	logger *slog.Logger
+	pool   *pgxpool.Pool
+}
+
+// NewSchemaVersionRepo creates a new SchemaVersionRepo.
+// It also sets default tracer and logger if they are nil.
+//
+//	WARNING; panics if pool is nil
+func NewSchemaVersionRepo(pool *pgxpool.Pool, t trace.Tracer, l *slog.Logger) *SchemaVersionRepo {
+	if pool == nil {
+		panic("pgxpool.Pool is required")
+	}
+	if t == nil {
+		t = tracer
+	}
+	if l == nil {
+		l = logger
+	}
+
+	return &SchemaVersionRepo{
+		tracer: t,
+		logger: l,
+		pool:   pool,
+	}
+}
+
+// AppliedVersion reads the version golang-migrate recorded in
+// schema_migrations, 0 before the first migration.
+func (r *SchemaVersionRepo) AppliedVersion(ctx context.Context) (uint, bool, error) {
+	const op = "postgres.SchemaVersionRepo.AppliedVersion"
+	ctx, span := r.tracer.Start(ctx, "SchemaVersionRepo.AppliedVersion")
+	defer span.End()
+
+	var (
+		version int64
+		dirty   bool
+	)
+	err := r.pool.QueryRow(ctx, `SELECT version, dirty FROM schema_migrations LIMIT 1;`).Scan(&version, &dirty)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, false, nil
+	}
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to read schema version")
+		return 0, false, errorx.Wrap(err, op)
+	}
+	span.SetAttributes(attribute.Int64("schema.version", version), attribute.Bool("schema.dirty", dirty))
+
+	return uint(version), dirty, nil
+}
+
+// RecordMigrations adds the migrations to the manifest, a later release
+// may mark a recorded migration breaking.
+func (r *SchemaVersionRepo) RecordMigrations(ctx context.Context, migrations []schemaversion.Migration) error {
+	const op = "postgres.SchemaVersionRepo.RecordMigrations"
+	ctx, span := r.tracer.Start(ctx, "SchemaVersionRepo.RecordMigrations")
+	defer span.End()
+	span.SetAttributes(attribute.Int("migrations.count", len(migrations)))
+
+	query := `
+		INSERT INTO schema_manifest (version, name, breaking)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (version) DO UPDATE SET
+			name = EXCLUDED.name,
+			breaking = schema_manifest.breaking OR EXCLUDED.breaking;
+	`
+
+	batch := &pgx.Batch{}
+	for _, m := range migrations {
+		batch.Queue(query, int64(m.Version), m.Name, m.Breaking)
+	}
+
+	if err := r.pool.SendBatch(ctx, batch).Close(); err != nil {
+		otelx.RecordSpanError(span, err, "failed to record migrations")
+		return errorx.Wrap(err, op)
+	}
+
+	return nil
+}
+
+// FirstBreakingAfter returns the oldest breaking migration of the manifest
+// newer than version.
+func (r *SchemaVersionRepo) FirstBreakingAfter(ctx context.Context, version uint) (schemaversion.Migration, bool, error) {
+	const op = "postgres.SchemaVersionRepo.FirstBreakingAfter"
+	ctx, span := r.tracer.Start(ctx, "SchemaVersionRepo.FirstBreakingAfter")
+	defer span.End()
+	span.SetAttributes(attribute.Int64("schema.version", int64(version)))
+
+	var (
+		m        schemaversion.Migration
+		mVersion int64
+	)
+	err := r.pool.QueryRow(ctx, `
+		SELECT version, name, breaking
+		FROM schema_manifest
+		WHERE version > $1 AND breaking
+		ORDER BY version
+		LIMIT 1;
+	`, int64(version)).Scan(&mVersion, &m.Name, &m.Breaking)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return schemaversion.Migration{}, false, nil
+	}
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to read schema manifest")
+		return schemaversion.Migration{}, false, errorx.Wrap(err, op)
+	}
+	m.Version = uint(mVersion)
+
+	return m, true, nil
+}
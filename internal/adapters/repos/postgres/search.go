@@ -0,0 +1,245 @@
+package postgres
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/group"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/staffinvitation"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx"
+)
+
+// likeEscaper escapes the wildcards of a LIKE pattern, the backslash is the
+// default escape character.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// searchPatterns returns the LIKE patterns of the values containing q and of
+// those starting with it.
+func searchPatterns(q string) (contains, prefix string) {
+	escaped := likeEscaper.Replace(q)
+	return "%" + escaped + "%", escaped + "%"
+}
+
+// SearchUsers returns up to limit users whose barcode, username, email or
+// name contain q, the exact barcode and username matches first, then the
+// prefix matches.
+func (r *UserRepo) SearchUsers(ctx context.Context, q string, limit int) ([]*user.User, error) {
+	const op = "postgres.UserRepo.SearchUsers"
+	ctx, span := r.tracer.Start(ctx, "UserRepo.SearchUsers")
+	defer span.End()
+
+	contains, prefix := searchPatterns(q)
+	rows, err := r.pool.Query(ctx, `
+        SELECT  u.id, u.barcode, u.username, u.role_id,
+                u.first_name, u.last_name,
+                u.avatar_source, u.avatar_external, u.avatar_s3_key,
+                u.email, u.pass_hash, u.previous_pass_hashes,
+                u.tos_version, u.tos_accepted_at, u.tos_accepted_ip, u.email_verified, u.version, u.last_seen_at, u.created_at, u.updated_at,
+                gr.id, gr.name
+        FROM users u JOIN global_roles gr ON u.role_id = gr.id
+        WHERE (u.barcode ILIKE $2 OR u.username ILIKE $2 OR u.email ILIKE $2
+               OR u.first_name || ' ' || u.last_name ILIKE $2)
+          AND ($5::text IS NULL OR u.campus_id = $5)
+        ORDER BY CASE
+                     WHEN upper(u.barcode) = upper($1) OR lower(u.username) = lower($1) THEN 0
+                     WHEN u.barcode ILIKE $3 OR u.username ILIKE $3 OR u.email ILIKE $3
+                          OR u.first_name ILIKE $3 OR u.last_name ILIKE $3 THEN 1
+                     ELSE 2
+                 END, u.last_name, u.first_name, u.id
+        LIMIT $4;
+    `, q, contains, prefix, limit, campusScope(ctx))
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to search users")
+		return nil, errorx.Wrap(err, op)
+	}
+
+	users, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*user.User, error) {
+		var dto UserDTO
+		var roleDTO GlobalRoleDTO
+		err := row.Scan(
+			&dto.ID, &dto.Barcode, &dto.Username, &dto.RoleID,
+			&dto.FirstName, &dto.LastName,
+			&dto.AvatarSource, &dto.AvatarExternal, &dto.AvatarS3Key,
+			&dto.Email, &dto.Passhash, &dto.PreviousPasshashes,
+			&dto.TOSVersion, &dto.TOSAcceptedAt, &dto.TOSAcceptedIP, &dto.EmailVerified, &dto.Version, &dto.LastSeenAt, &dto.CreatedAt, &dto.UpdatedAt,
+			&roleDTO.ID, &roleDTO.Name,
+		)
+		return UserToDomain(dto, roleDTO), err
+	})
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to scan users")
+		return nil, errorx.Wrap(err, op)
+	}
+
+	return users, nil
+}
+
+// SearchGroups returns up to limit groups whose name or major contain q,
+// the exact name matches first, then the prefix matches.
+func (r *GroupRepo) SearchGroups(ctx context.Context, q string, limit int) ([]*group.Group, error) {
+	const op = "postgres.GroupRepo.SearchGroups"
+	ctx, span := r.tracer.Start(ctx, "GroupRepo.SearchGroups")
+	defer span.End()
+
+	contains, prefix := searchPatterns(q)
+	rows, err := r.pool.Query(ctx, `
+        SELECT id, name, year, major, created_at, updated_at, enrollment_opens_at, enrollment_closes_at, term_id
+        FROM groups
+        WHERE (name ILIKE $2 OR major ILIKE $2)
+          AND ($5::text IS NULL OR campus_id = $5)
+        ORDER BY CASE
+                     WHEN lower(name) = lower($1) THEN 0
+                     WHEN name ILIKE $3 OR major ILIKE $3 THEN 1
+                     ELSE 2
+                 END, name, id
+        LIMIT $4;
+    `, q, contains, prefix, limit, campusScope(ctx))
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to search groups")
+		return nil, errorx.Wrap(err, op)
+	}
+
+	groups, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*group.Group, error) {
+		var dto GroupDTO
+		err := row.Scan(&dto.ID, &dto.Name, &dto.Year, &dto.Major, &dto.CreatedAt, &dto.UpdatedAt,
+			&dto.EnrollmentOpensAt, &dto.EnrollmentClosesAt, &dto.TermID)
+		return GroupToDomain(dto), err
+	})
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to scan groups")
+		return nil, errorx.Wrap(err, op)
+	}
+
+	return groups, nil
+}
+
+// SearchStaffInvitations returns up to limit invitations that are not
+// deleted whose code, recipients or department contain q, the exact code
+// match first, then the prefix matches, the latest first.
+func (r *StaffInvitationRepo) SearchStaffInvitations(
+	ctx context.Context,
+	q string,
+	limit int,
+) ([]*staffinvitation.StaffInvitation, error) {
+	const op = "postgres.StaffInvitationRepo.SearchStaffInvitations"
+	ctx, span := r.tracer.Start(ctx, "StaffInvitationRepo.SearchStaffInvitations")
+	defer span.End()
+
+	contains, prefix := searchPatterns(q)
+	rows, err := r.pool.Query(ctx, `
+        SELECT id, creator_id, code, recipients_email, valid_from, valid_until, department, position, created_at, updated_at, deleted_at
+        FROM staff_invitations
+        WHERE deleted_at IS NULL
+          AND (code ILIKE $2 OR department ILIKE $2
+               OR EXISTS (SELECT 1 FROM unnest(recipients_email) AS e WHERE e ILIKE $2))
+        ORDER BY CASE
+                     WHEN lower(code) = lower($1) THEN 0
+                     WHEN code ILIKE $3
+                          OR EXISTS (SELECT 1 FROM unnest(recipients_email) AS e WHERE e ILIKE $3) THEN 1
+                     ELSE 2
+                 END, created_at DESC, id
+        LIMIT $4;
+    `, q, contains, prefix, limit)
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to search staff invitations")
+		return nil, errorx.Wrap(err, op)
+	}
+
+	invitations, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*staffinvitation.StaffInvitation, error) {
+		var dto StaffInvitationDTO
+		err := row.Scan(
+			&dto.ID, &dto.CreatorID, &dto.Code,
+			&dto.RecipientsEmail, &dto.ValidFrom, &dto.ValidUntil, &dto.Department, &dto.Position,
+			&dto.CreatedAt, &dto.UpdatedAt, &dto.DeletedAt,
+		)
+		return StaffInvitationToDomain(dto, r.clock), err
+	})
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to scan staff invitations")
+		return nil, errorx.Wrap(err, op)
+	}
+
+	return invitations, nil
+}
+
+// ListUsers returns a page of the users ordered by last name, first name and
+// id, those containing params.Query only when it is set. params.EnrollmentStatus
+// and params.Department keep the students with the status and the staff members
+// of the department when they are set. The page starts after params.After when
+// it is set, at params.Offset otherwise.
+func (r *UserRepo) ListUsers(ctx context.Context, params user.ListParams) ([]*user.User, error) {
+	const op = "postgres.UserRepo.ListUsers"
+	ctx, span := r.tracer.Start(ctx, "UserRepo.ListUsers")
+	defer span.End()
+
+	var contains *string
+	if params.Query != "" {
+		pattern, _ := searchPatterns(params.Query)
+		contains = &pattern
+	}
+	var afterLast, afterFirst, afterID *string
+	offset := params.Offset
+	if params.After != nil {
+		afterLast, afterFirst = &params.After.LastName, &params.After.FirstName
+		id := params.After.ID.String()
+		afterID = &id
+		offset = 0
+	}
+	var status, department *string
+	if params.EnrollmentStatus != "" {
+		s := params.EnrollmentStatus.String()
+		status = &s
+	}
+	if params.Department != "" {
+		department = &params.Department
+	}
+
+	rows, err := r.pool.Query(ctx, `
+        SELECT  u.id, u.barcode, u.username, u.role_id,
+                u.first_name, u.last_name,
+                u.avatar_source, u.avatar_external, u.avatar_s3_key,
+                u.email, u.pass_hash, u.previous_pass_hashes,
+                u.tos_version, u.tos_accepted_at, u.tos_accepted_ip, u.email_verified, u.version, u.last_seen_at, u.created_at, u.updated_at,
+                gr.id, gr.name
+        FROM users u JOIN global_roles gr ON u.role_id = gr.id
+        WHERE ($1::text IS NULL
+               OR u.barcode ILIKE $1 OR u.username ILIKE $1 OR u.email ILIKE $1
+               OR u.first_name || ' ' || u.last_name ILIKE $1)
+          AND ($2::text IS NULL OR (u.last_name, u.first_name, u.id) > ($2, $3, $4::uuid))
+          AND ($7::text IS NULL OR u.campus_id = $7)
+          AND ($8::text IS NULL OR EXISTS (
+                SELECT 1 FROM students s WHERE s.user_id = u.id AND s.enrollment_status = $8))
+          AND ($9::text IS NULL OR EXISTS (
+                SELECT 1 FROM staffs st WHERE st.user_id = u.id AND st.department = $9))
+        ORDER BY u.last_name, u.first_name, u.id
+        LIMIT $5 OFFSET $6;
+    `, contains, afterLast, afterFirst, afterID, params.Limit, offset, campusScope(ctx), status, department)
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to list users")
+		return nil, errorx.Wrap(err, op)
+	}
+
+	users, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*user.User, error) {
+		var dto UserDTO
+		var roleDTO GlobalRoleDTO
+		err := row.Scan(
+			&dto.ID, &dto.Barcode, &dto.Username, &dto.RoleID,
+			&dto.FirstName, &dto.LastName,
+			&dto.AvatarSource, &dto.AvatarExternal, &dto.AvatarS3Key,
+			&dto.Email, &dto.Passhash, &dto.PreviousPasshashes,
+			&dto.TOSVersion, &dto.TOSAcceptedAt, &dto.TOSAcceptedIP, &dto.EmailVerified, &dto.Version, &dto.LastSeenAt, &dto.CreatedAt, &dto.UpdatedAt,
+			&roleDTO.ID, &roleDTO.Name,
+		)
+		return UserToDomain(dto, roleDTO), err
+	})
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to scan users")
+		return nil, errorx.Wrap(err, op)
+	}
+
+	return users, nil
+}
@@ -14,6 +14,8 @@ import (
 
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/staffinvitation"
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/emails"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/ctxs"
 	"gitlab.com/ucmsv2/ucms-backend/pkg/env"
 	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
 	"gitlab.com/ucmsv2/ucms-backend/pkg/logging"
@@ -66,60 +68,197 @@ func (r *StaffRepo) HasAnyStaff(ctx context.Context) (bool, error) {
 }
 
 func (r *StaffRepo) SaveStaff(ctx context.Context, staff *user.Staff) error {
+	const op = "postgres.StaffRepo.SaveStaff"
 	ctx, span := r.tracer.Start(ctx, "StaffRepo.SaveStaff")
 	defer span.End()
 
-	return postgres.WithTx(ctx, r.pool, func(ctx context.Context, tx pgx.Tx) error {
-		dto := DomainToUserDTO(staff.User())
-		res, err := tx.Exec(ctx, insertUserQuery,
-			dto.ID,
-			dto.Barcode,
-			dto.Username,
-			staff.User().Role().String(),
-			dto.Email,
-			dto.FirstName,
-			dto.LastName,
-			dto.AvatarSource,
-			dto.AvatarExternal,
-			dto.AvatarS3Key,
-			dto.Passhash,
-			dto.CreatedAt,
-			dto.UpdatedAt,
-		)
+	err := postgres.WithTx(ctx, r.pool, func(ctx context.Context, tx pgx.Tx) error {
+		return insertStaff(ctx, tx, r.wlogger, staff, op)
+	})
+	if err != nil {
+		return err
+	}
+	staff.CommitEvents()
+
+	return nil
+}
+
+// LinkStaffAccount locks the user with email, runs fn on it and saves the
+// staff fn returns: the role of the user, the roles held beside it, the
+// terms of service accepted and the staff row, with the events of the staff.
+func (r *StaffRepo) LinkStaffAccount(
+	ctx context.Context,
+	email emails.Email,
+	fn func(ctx context.Context, u *user.User) (*user.Staff, error),
+) error {
+	const op = "postgres.StaffRepo.LinkStaffAccount"
+	ctx, span := r.tracer.Start(ctx, "StaffRepo.LinkStaffAccount",
+		trace.WithAttributes(attribute.String("user.email", logging.RedactEmail(email))),
+	)
+	defer span.End()
+	if fn == nil {
+		otelx.RecordSpanError(span, ErrNilFunc, "update function cannot be nil")
+		return ErrNilFunc
+	}
+
+	var staff *user.Staff
+	err := postgres.WithTx(ctx, r.pool, func(ctx context.Context, tx pgx.Tx) error {
+		query := `
+        SELECT  u.id, u.barcode, u.username, u.role_id,
+                u.first_name, u.last_name,
+                u.avatar_source, u.avatar_external, u.avatar_s3_key,
+                u.email, u.pass_hash, u.previous_pass_hashes,
+                u.tos_version, u.tos_accepted_at, u.tos_accepted_ip, u.email_verified, u.version, u.last_seen_at, u.created_at, u.updated_at,
+                gr.id, gr.name, ` + otherRolesColumn + `
+        FROM users u JOIN global_roles gr ON u.role_id = gr.id
+        WHERE u.email = $1 AND ($2::text IS NULL OR u.campus_id = $2)
+        FOR UPDATE OF u;
+    `
+		var dto UserDTO
+		var roleDTO GlobalRoleDTO
+		err := tx.QueryRow(ctx, query, email, campusScope(ctx)).
+			Scan(
+				&dto.ID, &dto.Barcode, &dto.Username, &dto.RoleID,
+				&dto.FirstName, &dto.LastName,
+				&dto.AvatarSource, &dto.AvatarExternal, &dto.AvatarS3Key,
+				&dto.Email, &dto.Passhash, &dto.PreviousPasshashes,
+				&dto.TOSVersion, &dto.TOSAcceptedAt, &dto.TOSAcceptedIP, &dto.EmailVerified, &dto.Version, &dto.LastSeenAt, &dto.CreatedAt, &dto.UpdatedAt,
+				&roleDTO.ID, &roleDTO.Name, &dto.OtherRoles,
+			)
 		if err != nil {
-			otelx.RecordSpanError(span, err, "failed to insert user")
-			return err
+			otelx.RecordSpanError(span, err, "failed to get user by email")
+			if errors.Is(err, pgx.ErrNoRows) {
+				return errorx.NewNotFound().WithCause(err, op)
+			}
+			return errorx.Wrap(err, op)
 		}
-		if res.RowsAffected() == 0 {
-			err := fmt.Errorf("no rows affected while inserting user: %w", ErrNoRowsAffected)
-			otelx.RecordSpanError(span, err, "no rows affected while inserting user")
-			return err
+
+		staff, err = fn(ctx, UserToDomain(dto, roleDTO))
+		if err != nil {
+			otelx.RecordSpanError(span, err, "update function returned an error")
+			return errorx.Wrap(err, op)
 		}
+		u := staff.User()
+		dto = DomainToUserDTO(u)
 
-		insertStaffQuery := `
-            INSERT INTO staffs (user_id)
-            VALUES ($1);
-        `
-		res, err = tx.Exec(ctx, insertStaffQuery, dto.ID)
+		_, err = tx.Exec(ctx, `
+		UPDATE users
+		SET role_id = (SELECT id FROM global_roles WHERE name = $2), updated_at = $3,
+			tos_version = $4, tos_accepted_at = $5, tos_accepted_ip = $6, email_verified = $7
+		WHERE id = $1;
+		`, dto.ID, u.Role().String(), dto.UpdatedAt, dto.TOSVersion, dto.TOSAcceptedAt, dto.TOSAcceptedIP, dto.EmailVerified)
 		if err != nil {
-			otelx.RecordSpanError(span, err, "failed to insert staff")
-			return err
+			otelx.RecordSpanError(span, err, "failed to update user")
+			return translateError(err, op)
 		}
-		if res.RowsAffected() == 0 {
-			err := fmt.Errorf("no rows affected while inserting staff: %w", ErrNoRowsAffected)
-			otelx.RecordSpanError(span, err, "no rows affected while inserting staff")
-			return err
+
+		_, err = tx.Exec(ctx, `
+		INSERT INTO user_roles (user_id, role_id, granted_at)
+		SELECT $1, gr.id, $3 FROM global_roles gr WHERE gr.name = ANY($2)
+		ON CONFLICT (user_id, role_id) DO NOTHING;
+		`, dto.ID, u.OtherRoles().Strings(), dto.UpdatedAt)
+		if err != nil {
+			otelx.RecordSpanError(span, err, "failed to insert user roles")
+			return translateError(err, op)
+		}
+
+		_, err = tx.Exec(ctx, `
+            INSERT INTO staffs (user_id, department, position)
+            VALUES ($1, $2, $3);
+        `, dto.ID, staff.Department(), staff.Position())
+		if err != nil {
+			otelx.RecordSpanError(span, err, "failed to insert staff")
+			return translateError(err, op)
 		}
 
-		events := staff.GetUncommittedEvents()
-		if len(events) > 0 {
+		if events := staff.GetUncommittedEvents(); len(events) > 0 {
+			if err := saveVersion(ctx, tx, "users", dto.ID, staff); err != nil {
+				otelx.RecordSpanError(span, err, "failed to save version")
+				return errorx.Wrap(err, op)
+			}
 			if err := watermillx.Publish(ctx, tx, r.wlogger, events...); err != nil {
 				otelx.RecordSpanError(span, err, "failed to publish events")
-				return err
+				return errorx.Wrap(err, op)
 			}
 		}
 		return nil
 	})
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to execute transaction")
+		return err
+	}
+	staff.CommitEvents()
+
+	return nil
+}
+
+// insertStaff inserts the user and the staff rows of staff and publishes its
+// events within tx.
+func insertStaff(ctx context.Context, tx pgx.Tx, wlogger watermill.LoggerAdapter, staff *user.Staff, op string) error {
+	span := trace.SpanFromContext(ctx)
+
+	dto := DomainToUserDTO(staff.User())
+	res, err := tx.Exec(ctx, insertUserQuery,
+		dto.ID,
+		dto.Barcode,
+		dto.Username,
+		staff.User().Role().String(),
+		dto.Email,
+		dto.FirstName,
+		dto.LastName,
+		dto.AvatarSource,
+		dto.AvatarExternal,
+		dto.AvatarS3Key,
+		dto.Passhash,
+		dto.CreatedAt,
+		dto.UpdatedAt,
+		ctxs.CampusFromCtx(ctx),
+		dto.TOSVersion,
+		dto.TOSAcceptedAt,
+		dto.TOSAcceptedIP,
+		dto.EmailVerified,
+	)
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to insert user")
+		return translateError(err, op)
+	}
+	if res.RowsAffected() == 0 {
+		err := fmt.Errorf("no rows affected while inserting user: %w", ErrNoRowsAffected)
+		otelx.RecordSpanError(span, err, "no rows affected while inserting user")
+		return err
+	}
+	if err := syncAvatarRefs(ctx, tx, "", avatarRefKey(dto)); err != nil {
+		otelx.RecordSpanError(span, err, "failed to update avatar references")
+		return err
+	}
+
+	insertStaffQuery := `
+            INSERT INTO staffs (user_id, department, position)
+            VALUES ($1, $2, $3);
+        `
+	res, err = tx.Exec(ctx, insertStaffQuery, dto.ID, staff.Department(), staff.Position())
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to insert staff")
+		return translateError(err, op)
+	}
+	if res.RowsAffected() == 0 {
+		err := fmt.Errorf("no rows affected while inserting staff: %w", ErrNoRowsAffected)
+		otelx.RecordSpanError(span, err, "no rows affected while inserting staff")
+		return err
+	}
+
+	events := staff.GetUncommittedEvents()
+	if len(events) > 0 {
+		if err := saveVersion(ctx, tx, "users", dto.ID, staff); err != nil {
+			otelx.RecordSpanError(span, err, "failed to save version")
+			return err
+		}
+		if err := watermillx.Publish(ctx, tx, wlogger, events...); err != nil {
+			otelx.RecordSpanError(span, err, "failed to publish events")
+			return err
+		}
+	}
+	return nil
 }
 
 func (r *StaffRepo) GetStaffByID(ctx context.Context, id user.ID) (*user.Staff, error) {
@@ -133,36 +272,125 @@ func (r *StaffRepo) GetStaffByID(ctx context.Context, id user.ID) (*user.Staff,
         SELECT  s.user_id, u.id, u.barcode, u.username, 
 				u.role_id, u.first_name, u.last_name, 
 				u.avatar_source, u.avatar_external, u.avatar_s3_key,
-                u.email, u.pass_hash, u.created_at, u.updated_at,
-                gr.id, gr.name
+                u.email, u.pass_hash, u.previous_pass_hashes,
+                u.tos_version, u.tos_accepted_at, u.tos_accepted_ip, u.email_verified, u.version, u.last_seen_at, u.created_at, u.updated_at,
+                gr.id, gr.name, s.department, s.position
         FROM staffs s
         JOIN users u ON s.user_id = u.id
         JOIN global_roles gr ON u.role_id = gr.id
-        WHERE s.user_id = $1;
+        WHERE s.user_id = $1 AND ($2::text IS NULL OR u.campus_id = $2);
     `
 
 	var userDTO UserDTO
 	var roleDTO GlobalRoleDTO
 	var staffDTO StaffDTO
-	err := r.pool.QueryRow(ctx, query, id).Scan(
+	err := r.pool.QueryRow(ctx, query, id, campusScope(ctx)).Scan(
 		&staffDTO.ID, &userDTO.ID, &userDTO.Barcode, &userDTO.Username,
 		&userDTO.RoleID, &userDTO.FirstName, &userDTO.LastName,
 		&userDTO.AvatarSource, &userDTO.AvatarExternal, &userDTO.AvatarS3Key,
-		&userDTO.Email, &userDTO.Passhash, &userDTO.CreatedAt, &userDTO.UpdatedAt,
-		&roleDTO.ID, &roleDTO.Name,
+		&userDTO.Email, &userDTO.Passhash, &userDTO.PreviousPasshashes,
+		&userDTO.TOSVersion, &userDTO.TOSAcceptedAt, &userDTO.TOSAcceptedIP, &userDTO.EmailVerified, &userDTO.Version, &userDTO.LastSeenAt, &userDTO.CreatedAt, &userDTO.UpdatedAt,
+		&roleDTO.ID, &roleDTO.Name, &staffDTO.Department, &staffDTO.Position,
 	)
 	if err != nil {
 		otelx.RecordSpanError(span, err, "failed to get staff by id")
-		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, errorx.NewNotFound().WithCause(err, op)
-		}
-		return nil, errorx.Wrap(err, op)
+		return nil, translateError(err, op)
 	}
 
 	return StaffToDomain(userDTO, roleDTO, staffDTO), nil
 }
 
-func (r *StaffRepo) GetStaffByEmail(ctx context.Context, email string) (*user.Staff, error) {
+// UpdateStaff locks the staff with id, runs fn on it and saves its
+// department and position with the events fn recorded.
+func (r *StaffRepo) UpdateStaff(
+	ctx context.Context,
+	id user.ID,
+	fn func(ctx context.Context, staff *user.Staff) error,
+) error {
+	const op = "postgres.StaffRepo.UpdateStaff"
+	ctx, span := r.tracer.Start(ctx, "StaffRepo.UpdateStaff",
+		trace.WithAttributes(attribute.String("user.id", id.String())),
+	)
+	defer span.End()
+	if fn == nil {
+		otelx.RecordSpanError(span, ErrNilFunc, "update function cannot be nil")
+		return ErrNilFunc
+	}
+
+	err := postgres.WithTx(ctx, r.pool, func(ctx context.Context, tx pgx.Tx) error {
+		query := `
+        SELECT  s.user_id, u.id, u.barcode, u.username,
+				u.role_id, u.first_name, u.last_name,
+				u.avatar_source, u.avatar_external, u.avatar_s3_key,
+                u.email, u.pass_hash, u.previous_pass_hashes,
+                u.tos_version, u.tos_accepted_at, u.tos_accepted_ip, u.email_verified, u.version, u.last_seen_at, u.created_at, u.updated_at,
+                gr.id, gr.name, s.department, s.position
+        FROM staffs s
+        JOIN users u ON s.user_id = u.id
+        JOIN global_roles gr ON u.role_id = gr.id
+        WHERE s.user_id = $1 AND ($2::text IS NULL OR u.campus_id = $2)
+        FOR UPDATE OF u, s;
+    `
+		var userDTO UserDTO
+		var roleDTO GlobalRoleDTO
+		var staffDTO StaffDTO
+		err := tx.QueryRow(ctx, query, id, campusScope(ctx)).Scan(
+			&staffDTO.ID, &userDTO.ID, &userDTO.Barcode, &userDTO.Username,
+			&userDTO.RoleID, &userDTO.FirstName, &userDTO.LastName,
+			&userDTO.AvatarSource, &userDTO.AvatarExternal, &userDTO.AvatarS3Key,
+			&userDTO.Email, &userDTO.Passhash, &userDTO.PreviousPasshashes,
+			&userDTO.TOSVersion, &userDTO.TOSAcceptedAt, &userDTO.TOSAcceptedIP, &userDTO.EmailVerified, &userDTO.Version, &userDTO.LastSeenAt, &userDTO.CreatedAt, &userDTO.UpdatedAt,
+			&roleDTO.ID, &roleDTO.Name, &staffDTO.Department, &staffDTO.Position,
+		)
+		if err != nil {
+			otelx.RecordSpanError(span, err, "failed to get staff by id")
+			return translateError(err, op)
+		}
+
+		staff := StaffToDomain(userDTO, roleDTO, staffDTO)
+		if err := fn(ctx, staff); err != nil {
+			otelx.RecordSpanError(span, err, "update function returned an error")
+			return errorx.Wrap(err, op)
+		}
+
+		res, err := tx.Exec(ctx, `
+        UPDATE staffs SET department = $2, position = $3 WHERE user_id = $1;
+        `, userDTO.ID, staff.Department(), staff.Position())
+		if err != nil {
+			otelx.RecordSpanError(span, err, "failed to update staff")
+			return translateError(err, op)
+		}
+		if res.RowsAffected() == 0 {
+			otelx.RecordSpanError(span, ErrNoRowsAffected, "no rows affected while updating staff")
+			return errorx.Wrap(ErrNoRowsAffected, op)
+		}
+		_, err = tx.Exec(ctx, `UPDATE users SET updated_at = $2 WHERE id = $1;`, userDTO.ID, staff.User().UpdatedAt())
+		if err != nil {
+			otelx.RecordSpanError(span, err, "failed to update user")
+			return translateError(err, op)
+		}
+
+		if events := staff.GetUncommittedEvents(); len(events) > 0 {
+			if err := saveVersion(ctx, tx, "users", userDTO.ID, staff); err != nil {
+				otelx.RecordSpanError(span, err, "failed to save version")
+				return errorx.Wrap(err, op)
+			}
+			if err := watermillx.Publish(ctx, tx, r.wlogger, events...); err != nil {
+				otelx.RecordSpanError(span, err, "failed to publish events")
+				return errorx.Wrap(err, op)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to execute transaction")
+		return err
+	}
+
+	return nil
+}
+
+func (r *StaffRepo) GetStaffByEmail(ctx context.Context, email emails.Email) (*user.Staff, error) {
 	const op = "postgres.StaffRepo.GetStaffByEmail"
 	ctx, span := r.tracer.Start(ctx, "StaffRepo.GetStaffByEmail",
 		trace.WithAttributes(attribute.String("user.email", logging.RedactEmail(email))),
@@ -173,30 +401,29 @@ func (r *StaffRepo) GetStaffByEmail(ctx context.Context, email string) (*user.St
         SELECT 	s.user_id, u.id, u.barcode, u.username, 
 				u.role_id, u.first_name, u.last_name, 
 				u.avatar_source, u.avatar_external, u.avatar_s3_key,
-                u.email, u.pass_hash, u.created_at, u.updated_at,
-                gr.id, gr.name
+                u.email, u.pass_hash, u.previous_pass_hashes,
+                u.tos_version, u.tos_accepted_at, u.tos_accepted_ip, u.email_verified, u.version, u.last_seen_at, u.created_at, u.updated_at,
+                gr.id, gr.name, s.department, s.position
         FROM staffs s
         JOIN users u ON s.user_id = u.id
         JOIN global_roles gr ON u.role_id = gr.id
-        WHERE u.email = $1;
+        WHERE u.email = $1 AND ($2::text IS NULL OR u.campus_id = $2);
     `
 
 	var userDTO UserDTO
 	var roleDTO GlobalRoleDTO
 	var staffDTO StaffDTO
-	err := r.pool.QueryRow(ctx, query, email).Scan(
+	err := r.pool.QueryRow(ctx, query, email, campusScope(ctx)).Scan(
 		&staffDTO.ID, &userDTO.ID, &userDTO.Barcode, &userDTO.Username,
 		&userDTO.RoleID, &userDTO.FirstName, &userDTO.LastName,
 		&userDTO.AvatarSource, &userDTO.AvatarExternal, &userDTO.AvatarS3Key,
-		&userDTO.Email, &userDTO.Passhash, &userDTO.CreatedAt, &userDTO.UpdatedAt,
-		&roleDTO.ID, &roleDTO.Name,
+		&userDTO.Email, &userDTO.Passhash, &userDTO.PreviousPasshashes,
+		&userDTO.TOSVersion, &userDTO.TOSAcceptedAt, &userDTO.TOSAcceptedIP, &userDTO.EmailVerified, &userDTO.Version, &userDTO.LastSeenAt, &userDTO.CreatedAt, &userDTO.UpdatedAt,
+		&roleDTO.ID, &roleDTO.Name, &staffDTO.Department, &staffDTO.Position,
 	)
 	if err != nil {
 		otelx.RecordSpanError(span, err, "failed to get staff by email")
-		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, errorx.NewNotFound().WithCause(err, op)
-		}
-		return nil, errorx.Wrap(err, op)
+		return nil, translateError(err, op)
 	}
 
 	return StaffToDomain(userDTO, roleDTO, staffDTO), nil
@@ -213,8 +440,9 @@ func (r *StaffRepo) GetCreatorByInvitationID(ctx context.Context, id staffinvita
         SELECT s.user_id, u.id, u.barcode, u.username, 
 				u.role_id, u.first_name, u.last_name, 
 				u.avatar_source, u.avatar_external, u.avatar_s3_key,
-                u.email, u.pass_hash, u.created_at, u.updated_at,
-                gr.id, gr.name
+                u.email, u.pass_hash, u.previous_pass_hashes,
+                u.tos_version, u.tos_accepted_at, u.tos_accepted_ip, u.email_verified, u.version, u.last_seen_at, u.created_at, u.updated_at,
+                gr.id, gr.name, s.department, s.position
         FROM staff_invitations si
         JOIN staffs s ON si.creator_id = s.user_id
         JOIN users u ON s.user_id = u.id
@@ -229,15 +457,13 @@ func (r *StaffRepo) GetCreatorByInvitationID(ctx context.Context, id staffinvita
 		&staffDTO.ID, &userDTO.ID, &userDTO.Barcode, &userDTO.Username,
 		&userDTO.RoleID, &userDTO.FirstName, &userDTO.LastName,
 		&userDTO.AvatarSource, &userDTO.AvatarExternal, &userDTO.AvatarS3Key,
-		&userDTO.Email, &userDTO.Passhash, &userDTO.CreatedAt, &userDTO.UpdatedAt,
-		&roleDTO.ID, &roleDTO.Name,
+		&userDTO.Email, &userDTO.Passhash, &userDTO.PreviousPasshashes,
+		&userDTO.TOSVersion, &userDTO.TOSAcceptedAt, &userDTO.TOSAcceptedIP, &userDTO.EmailVerified, &userDTO.Version, &userDTO.LastSeenAt, &userDTO.CreatedAt, &userDTO.UpdatedAt,
+		&roleDTO.ID, &roleDTO.Name, &staffDTO.Department, &staffDTO.Position,
 	)
 	if err != nil {
 		otelx.RecordSpanError(span, err, "failed to get creator by invitation id")
-		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, errorx.NewNotFound().WithCause(err, op)
-		}
-		return nil, errorx.Wrap(err, op)
+		return nil, translateError(err, op)
 	}
 
 	return StaffToDomain(userDTO, roleDTO, staffDTO), nil
@@ -245,7 +471,7 @@ func (r *StaffRepo) GetCreatorByInvitationID(ctx context.Context, id staffinvita
 
 func (st *StaffRepo) IsStaffExists(
 	ctx context.Context,
-	email string,
+	email emails.Email,
 	username string,
 	barcode user.Barcode,
 ) (emailExists bool, usernameExists bool, barcodeExists bool, err error) {
@@ -256,7 +482,7 @@ func (st *StaffRepo) IsStaffExists(
 		trace.WithAttributes(
 			attribute.String("user.email", logging.RedactEmail(email)),
 			attribute.String("user.username", logging.RedactUsername(username)),
-			attribute.String("user.barcode", barcode.String()),
+			attribute.String("user.barcode", otelx.Redact("user.barcode", barcode.String())),
 		),
 	)
 	defer span.End()
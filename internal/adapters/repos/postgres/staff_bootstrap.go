@@ -0,0 +1,128 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/emails"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/postgres"
+)
+
+// staffBootstrapLockKey is the transaction level advisory lock that the
+// instances starting together take in turn to bootstrap the initial staff.
+const staffBootstrapLockKey int64 = 0x75636d73_73746166 // "ucmsstaf"
+
+// BootstrapInitialStaff calls fn with the staff of email, nil when there is
+// none, and whether any staff exist, then saves what fn did: the staff it
+// returns is inserted for user.BootstrapCreated and its password hash is
+// updated for user.BootstrapPasswordRotated, both are recorded in
+// staff_bootstrap_audit with instanceID. The whole is serialized across
+// instances by an advisory lock.
+func (r *StaffRepo) BootstrapInitialStaff(
+	ctx context.Context,
+	email emails.Email,
+	instanceID string,
+	fn func(ctx context.Context, existing *user.Staff, hasStaff bool) (*user.Staff, user.BootstrapAction, error),
+) error {
+	const op = "postgres.StaffRepo.BootstrapInitialStaff"
+	ctx, span := r.tracer.Start(ctx, "StaffRepo.BootstrapInitialStaff")
+	defer span.End()
+	if fn == nil {
+		otelx.RecordSpanError(span, ErrNilFunc, "bootstrap function cannot be nil")
+		return ErrNilFunc
+	}
+
+	return postgres.WithTx(ctx, r.pool, func(ctx context.Context, tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock($1);`, staffBootstrapLockKey); err != nil {
+			otelx.RecordSpanError(span, err, "failed to take the staff bootstrap lock")
+			return errorx.Wrap(err, op)
+		}
+
+		var hasStaff bool
+		if err := tx.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM staffs);`).Scan(&hasStaff); err != nil {
+			otelx.RecordSpanError(span, err, "failed to check if any staff exists")
+			return errorx.Wrap(err, op)
+		}
+
+		existing, err := getStaffByEmailForUpdate(ctx, tx, email)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			otelx.RecordSpanError(span, err, "failed to get staff by email")
+			return errorx.Wrap(err, op)
+		}
+
+		staff, action, err := fn(ctx, existing, hasStaff)
+		if err != nil {
+			otelx.RecordSpanError(span, err, "bootstrap function returned an error")
+			return errorx.Wrap(err, op)
+		}
+
+		switch action {
+		case user.BootstrapCreated:
+			if err := insertStaff(ctx, tx, r.wlogger, staff, op); err != nil {
+				return err
+			}
+		case user.BootstrapPasswordRotated:
+			dto := DomainToUserDTO(staff.User())
+			res, err := tx.Exec(ctx, `UPDATE users SET pass_hash = $2, previous_pass_hashes = $3, updated_at = $4 WHERE id = $1;`,
+				dto.ID, dto.Passhash, dto.PreviousPasshashes, dto.UpdatedAt,
+			)
+			if err != nil {
+				otelx.RecordSpanError(span, err, "failed to update password")
+				return translateError(err, op)
+			}
+			if res.RowsAffected() == 0 {
+				otelx.RecordSpanError(span, ErrNoRowsAffected, "no rows affected while updating password")
+				return errorx.Wrap(ErrNoRowsAffected, op)
+			}
+		default:
+			return nil
+		}
+
+		_, err = tx.Exec(ctx, `
+            INSERT INTO staff_bootstrap_audit (user_id, action, instance_id)
+            VALUES ($1, $2, $3);
+        `, DomainToUserDTO(staff.User()).ID, string(action), instanceID)
+		if err != nil {
+			otelx.RecordSpanError(span, err, "failed to insert staff bootstrap audit")
+			return translateError(err, op)
+		}
+		return nil
+	})
+}
+
+func getStaffByEmailForUpdate(ctx context.Context, tx pgx.Tx, email emails.Email) (*user.Staff, error) {
+	query := `
+        SELECT 	s.user_id, u.id, u.barcode, u.username,
+				u.role_id, u.first_name, u.last_name,
+				u.avatar_source, u.avatar_external, u.avatar_s3_key,
+                u.email, u.pass_hash, u.previous_pass_hashes,
+                u.tos_version, u.tos_accepted_at, u.tos_accepted_ip, u.email_verified, u.version, u.last_seen_at, u.created_at, u.updated_at,
+                gr.id, gr.name, s.department, s.position
+        FROM staffs s
+        JOIN users u ON s.user_id = u.id
+        JOIN global_roles gr ON u.role_id = gr.id
+        WHERE u.email = $1
+        FOR UPDATE OF u;
+    `
+
+	var userDTO UserDTO
+	var roleDTO GlobalRoleDTO
+	var staffDTO StaffDTO
+	err := tx.QueryRow(ctx, query, email).Scan(
+		&staffDTO.ID, &userDTO.ID, &userDTO.Barcode, &userDTO.Username,
+		&userDTO.RoleID, &userDTO.FirstName, &userDTO.LastName,
+		&userDTO.AvatarSource, &userDTO.AvatarExternal, &userDTO.AvatarS3Key,
+		&userDTO.Email, &userDTO.Passhash, &userDTO.PreviousPasshashes,
+		&userDTO.TOSVersion, &userDTO.TOSAcceptedAt, &userDTO.TOSAcceptedIP, &userDTO.EmailVerified, &userDTO.Version, &userDTO.LastSeenAt, &userDTO.CreatedAt, &userDTO.UpdatedAt,
+		&roleDTO.ID, &roleDTO.Name, &staffDTO.Department, &staffDTO.Position,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return StaffToDomain(userDTO, roleDTO, staffDTO), nil
+}
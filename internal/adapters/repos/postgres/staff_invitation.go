@@ -2,8 +2,8 @@ package postgres
 
 import (
 	"context"
-	"errors"
 	"log/slog"
+	"time"
 
 	"github.com/ThreeDotsLabs/watermill"
 	"github.com/jackc/pgx/v5"
@@ -12,6 +12,7 @@ import (
 
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/staffinvitation"
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
 	"gitlab.com/ucmsv2/ucms-backend/pkg/env"
 	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
 	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx"
@@ -23,6 +24,7 @@ type StaffInvitationRepo struct {
 	tracer  trace.Tracer
 	pool    *pgxpool.Pool
 	wlogger watermill.LoggerAdapter
+	clock   clock.Clock
 }
 
 // NewStaffInvitationRepo creates a new StaffInvitationRepo.
@@ -47,6 +49,13 @@ func NewStaffInvitationRepo(pool *pgxpool.Pool, t trace.Tracer, l *slog.Logger)
 	}
 }
 
+// WithClock sets the clock the loaded invitations are rehydrated with,
+// clock.Real by default.
+func (r *StaffInvitationRepo) WithClock(c clock.Clock) *StaffInvitationRepo {
+	r.clock = c
+	return r
+}
+
 func (r *StaffInvitationRepo) SaveStaffInvitation(ctx context.Context, invitation *staffinvitation.StaffInvitation) error {
 	const op = "postgres.StaffInvitationRepo.SaveStaffInvitation"
 	ctx, span := r.tracer.Start(ctx, "StaffInvitationRepo.SaveStaffInvitation")
@@ -55,24 +64,26 @@ func (r *StaffInvitationRepo) SaveStaffInvitation(ctx context.Context, invitatio
 	dto := DomainToStaffInvitationDTO(invitation)
 
 	query := `
-        INSERT INTO staff_invitations (id, creator_id, code, recipients_email, valid_from, valid_until, created_at, updated_at)
-        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+        INSERT INTO staff_invitations (id, creator_id, code, recipients_email, valid_from, valid_until, department, position, created_at, updated_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
     `
 
 	err := postgres.WithTx(ctx, r.pool, func(ctx context.Context, tx pgx.Tx) error {
-		res, err := r.pool.Exec(ctx, query,
+		res, err := tx.Exec(ctx, query,
 			dto.ID,
 			dto.CreatorID,
 			dto.Code,
 			dto.RecipientsEmail,
 			dto.ValidFrom,
 			dto.ValidUntil,
+			dto.Department,
+			dto.Position,
 			dto.CreatedAt,
 			dto.UpdatedAt,
 		)
 		if err != nil {
 			otelx.RecordSpanError(span, err, "failed to execute insert query")
-			return errorx.Wrap(err, op)
+			return translateError(err, op)
 		}
 		if res.RowsAffected() == 0 {
 			otelx.RecordSpanError(span, ErrNoRowsAffected, "no rows affected when inserting staff invitation")
@@ -80,6 +91,10 @@ func (r *StaffInvitationRepo) SaveStaffInvitation(ctx context.Context, invitatio
 		}
 
 		if events := invitation.GetUncommittedEvents(); len(events) > 0 {
+			if err := saveVersion(ctx, tx, "staff_invitations", dto.ID, invitation); err != nil {
+				otelx.RecordSpanError(span, err, "failed to save version")
+				return errorx.Wrap(err, op)
+			}
 			if err := watermillx.Publish(ctx, tx, r.wlogger, events...); err != nil {
 				otelx.RecordSpanError(span, err, "failed to publish events")
 				return errorx.Wrap(err, op)
@@ -92,6 +107,7 @@ func (r *StaffInvitationRepo) SaveStaffInvitation(ctx context.Context, invitatio
 		otelx.RecordSpanError(span, err, "failed to execute transaction")
 		return err
 	}
+	invitation.CommitEvents()
 
 	return nil
 }
@@ -110,7 +126,7 @@ func (r *StaffInvitationRepo) UpdateStaffInvitation(
 	}
 
 	selectquery := `
-        SELECT id, creator_id, code, recipients_email, valid_from, valid_until, created_at, updated_at, deleted_at
+        SELECT id, creator_id, code, recipients_email, valid_from, valid_until, department, position, created_at, updated_at, deleted_at, version
         FROM staff_invitations
         WHERE id = $1
         FOR UPDATE;
@@ -125,18 +141,15 @@ func (r *StaffInvitationRepo) UpdateStaffInvitation(
 		var dto StaffInvitationDTO
 		err := tx.QueryRow(ctx, selectquery, id).Scan(
 			&dto.ID, &dto.CreatorID, &dto.Code, &dto.RecipientsEmail,
-			&dto.ValidFrom, &dto.ValidUntil, &dto.CreatedAt,
-			&dto.UpdatedAt, &dto.DeletedAt,
+			&dto.ValidFrom, &dto.ValidUntil, &dto.Department, &dto.Position, &dto.CreatedAt,
+			&dto.UpdatedAt, &dto.DeletedAt, &dto.Version,
 		)
 		if err != nil {
-			if errors.Is(err, pgx.ErrNoRows) {
-				return errorx.NewNotFound().WithCause(err, op)
-			}
 			otelx.RecordSpanError(span, err, "failed to select staff invitation")
-			return errorx.Wrap(err, op)
+			return translateError(err, op)
 		}
 
-		invitation := StaffInvitationToDomain(dto)
+		invitation := StaffInvitationToDomain(dto, r.clock)
 
 		fnerr := fn(ctx, invitation)
 		if fnerr != nil && !errorx.IsPersistable(fnerr) {
@@ -157,7 +170,7 @@ func (r *StaffInvitationRepo) UpdateStaffInvitation(
 		)
 		if err != nil {
 			otelx.RecordSpanError(span, err, "failed to execute update query")
-			return errorx.Wrap(err, op)
+			return translateError(err, op)
 		}
 		if res.RowsAffected() == 0 {
 			otelx.RecordSpanError(span, ErrNoRowsAffected, "no rows affected when updating staff invitation")
@@ -165,6 +178,10 @@ func (r *StaffInvitationRepo) UpdateStaffInvitation(
 		}
 
 		if events := invitation.GetUncommittedEvents(); len(events) > 0 {
+			if err := saveVersion(ctx, tx, "staff_invitations", dto.ID, invitation); err != nil {
+				otelx.RecordSpanError(span, err, "failed to save version")
+				return errorx.Wrap(err, op)
+			}
 			if err := watermillx.Publish(ctx, tx, r.wlogger, events...); err != nil {
 				otelx.RecordSpanError(span, err, "failed to publish events")
 				return errorx.Wrap(err, op)
@@ -192,7 +209,7 @@ func (r *StaffInvitationRepo) GetStaffInvitationByID(ctx context.Context, id sta
 	defer span.End()
 
 	query := `
-        SELECT id, creator_id, code, recipients_email, valid_from, valid_until, created_at, updated_at, deleted_at
+        SELECT id, creator_id, code, recipients_email, valid_from, valid_until, department, position, created_at, updated_at, deleted_at, version
         FROM staff_invitations
         WHERE id = $1;
     `
@@ -200,18 +217,15 @@ func (r *StaffInvitationRepo) GetStaffInvitationByID(ctx context.Context, id sta
 	var dto StaffInvitationDTO
 	err := r.pool.QueryRow(ctx, query, id).Scan(
 		&dto.ID, &dto.CreatorID, &dto.Code,
-		&dto.RecipientsEmail, &dto.ValidFrom, &dto.ValidUntil,
-		&dto.CreatedAt, &dto.UpdatedAt, &dto.DeletedAt,
+		&dto.RecipientsEmail, &dto.ValidFrom, &dto.ValidUntil, &dto.Department, &dto.Position,
+		&dto.CreatedAt, &dto.UpdatedAt, &dto.DeletedAt, &dto.Version,
 	)
 	if err != nil {
 		otelx.RecordSpanError(span, err, "failed to execute select query")
-		if err == pgx.ErrNoRows {
-			return nil, errorx.NewNotFound().WithCause(err, op)
-		}
-		return nil, errorx.Wrap(err, op)
+		return nil, translateError(err, op)
 	}
 
-	invitation := StaffInvitationToDomain(dto)
+	invitation := StaffInvitationToDomain(dto, r.clock)
 	return invitation, nil
 }
 
@@ -221,7 +235,7 @@ func (r *StaffInvitationRepo) GetStaffInvitationByCode(ctx context.Context, code
 	defer span.End()
 
 	query := `
-        SELECT id, creator_id, code, recipients_email, valid_from, valid_until, created_at, updated_at, deleted_at
+        SELECT id, creator_id, code, recipients_email, valid_from, valid_until, department, position, created_at, updated_at, deleted_at, version
         FROM staff_invitations
         WHERE code = $1;
     `
@@ -229,18 +243,15 @@ func (r *StaffInvitationRepo) GetStaffInvitationByCode(ctx context.Context, code
 	var dto StaffInvitationDTO
 	err := r.pool.QueryRow(ctx, query, code).Scan(
 		&dto.ID, &dto.CreatorID, &dto.Code,
-		&dto.RecipientsEmail, &dto.ValidFrom, &dto.ValidUntil,
-		&dto.CreatedAt, &dto.UpdatedAt, &dto.DeletedAt,
+		&dto.RecipientsEmail, &dto.ValidFrom, &dto.ValidUntil, &dto.Department, &dto.Position,
+		&dto.CreatedAt, &dto.UpdatedAt, &dto.DeletedAt, &dto.Version,
 	)
 	if err != nil {
 		otelx.RecordSpanError(span, err, "failed to execute select query")
-		if err == pgx.ErrNoRows {
-			return nil, errorx.NewNotFound().WithCause(err, op)
-		}
-		return nil, errorx.Wrap(err, op)
+		return nil, translateError(err, op)
 	}
 
-	invitation := StaffInvitationToDomain(dto)
+	invitation := StaffInvitationToDomain(dto, r.clock)
 	return invitation, nil
 }
 
@@ -253,7 +264,7 @@ func (r *StaffInvitationRepo) GetLatestStaffInvitationByCreatorID(
 	defer span.End()
 
 	query := `
-        SELECT id, creator_id, code, recipients_email, valid_from, valid_until, created_at, updated_at, deleted_at
+        SELECT id, creator_id, code, recipients_email, valid_from, valid_until, department, position, created_at, updated_at, deleted_at, version
         FROM staff_invitations
         WHERE creator_id = $1
         ORDER BY created_at DESC
@@ -263,17 +274,53 @@ func (r *StaffInvitationRepo) GetLatestStaffInvitationByCreatorID(
 	var dto StaffInvitationDTO
 	err := r.pool.QueryRow(ctx, query, creatorID).Scan(
 		&dto.ID, &dto.CreatorID, &dto.Code,
-		&dto.RecipientsEmail, &dto.ValidFrom, &dto.ValidUntil,
-		&dto.CreatedAt, &dto.UpdatedAt, &dto.DeletedAt,
+		&dto.RecipientsEmail, &dto.ValidFrom, &dto.ValidUntil, &dto.Department, &dto.Position,
+		&dto.CreatedAt, &dto.UpdatedAt, &dto.DeletedAt, &dto.Version,
 	)
 	if err != nil {
 		otelx.RecordSpanError(span, err, "failed to execute select query")
-		if err == pgx.ErrNoRows {
-			return nil, errorx.NewNotFound().WithCause(err, op)
-		}
-		return nil, errorx.Wrap(err, op)
+		return nil, translateError(err, op)
 	}
 
-	invitation := StaffInvitationToDomain(dto)
+	invitation := StaffInvitationToDomain(dto, r.clock)
 	return invitation, nil
 }
+
+// pendingRecipientsLockClass namespaces the advisory locks of the creators,
+// see CountPendingRecipients.
+const pendingRecipientsLockClass = 0x696e7669 // "invi"
+
+// CountPendingRecipients counts the recipients of the invitations of the
+// creator that are neither deleted nor expired at now. It runs in the
+// transaction of ctx, if any, so that the invitations saved with it count,
+// and takes the lock of the creator until it ends: the invitations of a
+// creator are counted and saved by one transaction at a time.
+func (r *StaffInvitationRepo) CountPendingRecipients(ctx context.Context, creatorID user.ID, now time.Time) (int, error) {
+	const op = "postgres.StaffInvitationRepo.CountPendingRecipients"
+	ctx, span := r.tracer.Start(ctx, "StaffInvitationRepo.CountPendingRecipients")
+	defer span.End()
+
+	query := `
+        SELECT coalesce(sum(cardinality(recipients_email)), 0)
+        FROM staff_invitations
+        WHERE creator_id = $1
+          AND deleted_at IS NULL
+          AND (valid_until IS NULL OR valid_until > $2);
+    `
+
+	var count int
+	err := postgres.WithTx(ctx, r.pool, func(ctx context.Context, tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock($1, hashtext($2));`,
+			pendingRecipientsLockClass, creatorID.String())
+		if err != nil {
+			return err
+		}
+		return tx.QueryRow(ctx, query, creatorID, now).Scan(&count)
+	})
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to count pending recipients")
+		return 0, translateError(err, op)
+	}
+
+	return count, nil
+}
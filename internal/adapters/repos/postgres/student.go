@@ -6,11 +6,14 @@ import (
 	"log/slog"
 
 	"github.com/ThreeDotsLabs/watermill"
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"go.opentelemetry.io/otel/trace"
 
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/emails"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/ctxs"
 	"gitlab.com/ucmsv2/ucms-backend/pkg/env"
 	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
 	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx"
@@ -53,24 +56,26 @@ func (st *StudentRepo) GetStudentByID(ctx context.Context, id user.ID) (*user.St
         SELECT  u.id, u.barcode, u.username, u.role_id,
                 u.first_name, u.last_name, 
 				u.avatar_source, u.avatar_external, u.avatar_s3_key,
-                u.email, u.pass_hash, u.created_at, u.updated_at,
+                u.email, u.pass_hash, u.previous_pass_hashes,
+                u.tos_version, u.tos_accepted_at, u.tos_accepted_ip, u.email_verified, u.version, u.last_seen_at, u.created_at, u.updated_at,
                 gr.id, gr.name,
-                s.group_id
+                s.group_id, s.enrollment_status, s.leave_until, coalesce(s.expel_reason, '')
         FROM users u
         JOIN global_roles gr ON u.role_id = gr.id
         JOIN students s ON u.id = s.user_id
-        WHERE u.id = $1;
+        WHERE u.id = $1 AND ($2::text IS NULL OR u.campus_id = $2);
     `
 	var dto UserDTO
 	var roleDTO GlobalRoleDTO
 	var studentDTO StudentDTO
-	err := st.pool.QueryRow(ctx, query, id).Scan(
+	err := st.pool.QueryRow(ctx, query, id, campusScope(ctx)).Scan(
 		&dto.ID, &dto.Barcode, &dto.Username, &dto.RoleID,
 		&dto.FirstName, &dto.LastName,
 		&dto.AvatarSource, &dto.AvatarExternal, &dto.AvatarS3Key,
-		&dto.Email, &dto.Passhash, &dto.CreatedAt, &dto.UpdatedAt,
+		&dto.Email, &dto.Passhash, &dto.PreviousPasshashes,
+		&dto.TOSVersion, &dto.TOSAcceptedAt, &dto.TOSAcceptedIP, &dto.EmailVerified, &dto.Version, &dto.LastSeenAt, &dto.CreatedAt, &dto.UpdatedAt,
 		&dto.RoleID, &roleDTO.Name,
-		&studentDTO.GroupID,
+		&studentDTO.GroupID, &studentDTO.EnrollmentStatus, &studentDTO.LeaveUntil, &studentDTO.ExpelReason,
 	)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -83,7 +88,7 @@ func (st *StudentRepo) GetStudentByID(ctx context.Context, id user.ID) (*user.St
 	return StudentToDomain(dto, roleDTO, studentDTO), nil
 }
 
-func (st *StudentRepo) GetStudentByEmail(ctx context.Context, email string) (*user.Student, error) {
+func (st *StudentRepo) GetStudentByEmail(ctx context.Context, email emails.Email) (*user.Student, error) {
 	const op = "postgres.StudentRepo.GetStudentByEmail"
 	ctx, span := st.tracer.Start(ctx, "StudentRepo.GetStudentByEmail")
 	defer span.End()
@@ -92,24 +97,26 @@ func (st *StudentRepo) GetStudentByEmail(ctx context.Context, email string) (*us
         SELECT  u.id, u.barcode, u.username, u.role_id,
                 u.first_name, u.last_name, 
 				u.avatar_source, u.avatar_external, u.avatar_s3_key,
-                u.email, u.pass_hash, u.created_at, u.updated_at,
+                u.email, u.pass_hash, u.previous_pass_hashes,
+                u.tos_version, u.tos_accepted_at, u.tos_accepted_ip, u.email_verified, u.version, u.last_seen_at, u.created_at, u.updated_at,
                 gr.id, gr.name,
-                s.group_id
+                s.group_id, s.enrollment_status, s.leave_until, coalesce(s.expel_reason, '')
         FROM users u
         JOIN global_roles gr ON u.role_id = gr.id
         JOIN students s ON u.id = s.user_id
-        WHERE u.email = $1;
+        WHERE u.email = $1 AND ($2::text IS NULL OR u.campus_id = $2);
     `
 	var dto UserDTO
 	var roleDTO GlobalRoleDTO
 	var studentDTO StudentDTO
-	err := st.pool.QueryRow(ctx, query, email).Scan(
+	err := st.pool.QueryRow(ctx, query, email, campusScope(ctx)).Scan(
 		&dto.ID, &dto.Barcode, &dto.Username, &dto.RoleID,
 		&dto.FirstName, &dto.LastName,
 		&dto.AvatarSource, &dto.AvatarExternal, &dto.AvatarS3Key,
-		&dto.Email, &dto.Passhash, &dto.CreatedAt, &dto.UpdatedAt,
+		&dto.Email, &dto.Passhash, &dto.PreviousPasshashes,
+		&dto.TOSVersion, &dto.TOSAcceptedAt, &dto.TOSAcceptedIP, &dto.EmailVerified, &dto.Version, &dto.LastSeenAt, &dto.CreatedAt, &dto.UpdatedAt,
 		&dto.RoleID, &roleDTO.Name,
-		&studentDTO.GroupID,
+		&studentDTO.GroupID, &studentDTO.EnrollmentStatus, &studentDTO.LeaveUntil, &studentDTO.ExpelReason,
 	)
 	if err != nil {
 		otelx.RecordSpanError(span, err, "failed to get student by email")
@@ -143,6 +150,11 @@ func (st *StudentRepo) SaveStudent(ctx context.Context, student *user.Student) e
 			dto.Passhash,
 			dto.CreatedAt,
 			dto.UpdatedAt,
+			ctxs.CampusFromCtx(ctx),
+			dto.TOSVersion,
+			dto.TOSAcceptedAt,
+			dto.TOSAcceptedIP,
+			dto.EmailVerified,
 		)
 		if err != nil {
 			otelx.RecordSpanError(span, err, "failed to insert user")
@@ -152,6 +164,10 @@ func (st *StudentRepo) SaveStudent(ctx context.Context, student *user.Student) e
 			otelx.RecordSpanError(span, err, "no rows affected while inserting user")
 			return errorx.Wrap(ErrNoRowsAffected, op)
 		}
+		if err := syncAvatarRefs(ctx, tx, "", avatarRefKey(dto)); err != nil {
+			otelx.RecordSpanError(span, err, "failed to update avatar references")
+			return errorx.Wrap(err, op)
+		}
 
 		insertStudentQuery := `
             INSERT INTO students (user_id, group_id, created_at, updated_at)
@@ -174,6 +190,116 @@ func (st *StudentRepo) SaveStudent(ctx context.Context, student *user.Student) e
 
 		events := student.GetUncommittedEvents()
 		if len(events) > 0 {
+			if err := saveVersion(ctx, tx, "users", dto.ID, student); err != nil {
+				otelx.RecordSpanError(span, err, "failed to save version")
+				return errorx.Wrap(err, op)
+			}
+			if err := watermillx.Publish(ctx, tx, st.wlogger, events...); err != nil {
+				otelx.RecordSpanError(span, err, "failed to publish events")
+				return errorx.Wrap(err, op)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to execute transaction")
+		return err
+	}
+	student.CommitEvents()
+
+	return nil
+}
+
+// UpdateStudentByBarcode locks the student with barcode, runs fn on it and
+// saves its enrollment and group with the events fn recorded.
+func (st *StudentRepo) UpdateStudentByBarcode(
+	ctx context.Context,
+	barcode user.Barcode,
+	fn func(ctx context.Context, student *user.Student) error,
+) error {
+	const op = "postgres.StudentRepo.UpdateStudentByBarcode"
+	ctx, span := st.tracer.Start(ctx, "StudentRepo.UpdateStudentByBarcode")
+	defer span.End()
+	if fn == nil {
+		otelx.RecordSpanError(span, ErrNilFunc, "update function cannot be nil")
+		return ErrNilFunc
+	}
+
+	err := postgres.WithTx(ctx, st.pool, func(ctx context.Context, tx pgx.Tx) error {
+		query := `
+        SELECT  u.id, u.barcode, u.username, u.role_id,
+                u.first_name, u.last_name,
+				u.avatar_source, u.avatar_external, u.avatar_s3_key,
+                u.email, u.pass_hash, u.previous_pass_hashes,
+                u.tos_version, u.tos_accepted_at, u.tos_accepted_ip, u.email_verified, u.version, u.last_seen_at, u.created_at, u.updated_at,
+                gr.id, gr.name,
+                s.group_id, s.enrollment_status, s.leave_until, coalesce(s.expel_reason, '')
+        FROM users u
+        JOIN global_roles gr ON u.role_id = gr.id
+        JOIN students s ON u.id = s.user_id
+        WHERE u.barcode = $1 AND ($2::text IS NULL OR u.campus_id = $2)
+        FOR UPDATE OF u, s;
+    `
+		var dto UserDTO
+		var roleDTO GlobalRoleDTO
+		var studentDTO StudentDTO
+		err := tx.QueryRow(ctx, query, barcode, campusScope(ctx)).Scan(
+			&dto.ID, &dto.Barcode, &dto.Username, &dto.RoleID,
+			&dto.FirstName, &dto.LastName,
+			&dto.AvatarSource, &dto.AvatarExternal, &dto.AvatarS3Key,
+			&dto.Email, &dto.Passhash, &dto.PreviousPasshashes,
+			&dto.TOSVersion, &dto.TOSAcceptedAt, &dto.TOSAcceptedIP, &dto.EmailVerified, &dto.Version, &dto.LastSeenAt, &dto.CreatedAt, &dto.UpdatedAt,
+			&dto.RoleID, &roleDTO.Name,
+			&studentDTO.GroupID, &studentDTO.EnrollmentStatus, &studentDTO.LeaveUntil, &studentDTO.ExpelReason,
+		)
+		if err != nil {
+			otelx.RecordSpanError(span, err, "failed to get student by barcode")
+			if errors.Is(err, pgx.ErrNoRows) {
+				return errorx.NewNotFound().WithCause(err, op)
+			}
+			return errorx.Wrap(err, op)
+		}
+
+		student := StudentToDomain(dto, roleDTO, studentDTO)
+		if err := fn(ctx, student); err != nil {
+			otelx.RecordSpanError(span, err, "update function returned an error")
+			return errorx.Wrap(err, op)
+		}
+
+		res, err := tx.Exec(ctx, `
+        UPDATE students
+        SET enrollment_status = $2, leave_until = $3, expel_reason = nullif($4, ''), updated_at = $5,
+            group_id = $6,
+            enrollment_changed_at = CASE WHEN enrollment_status <> $2 THEN $5 ELSE enrollment_changed_at END
+        WHERE user_id = $1;
+        `,
+			dto.ID,
+			student.EnrollmentStatus().String(),
+			student.LeaveUntil(),
+			student.ExpelReason(),
+			student.User().UpdatedAt(),
+			uuid.UUID(student.GroupID()),
+		)
+		if err != nil {
+			otelx.RecordSpanError(span, err, "failed to update student")
+			return errorx.Wrap(err, op)
+		}
+		if res.RowsAffected() == 0 {
+			otelx.RecordSpanError(span, ErrNoRowsAffected, "no rows affected while updating student")
+			return errorx.Wrap(ErrNoRowsAffected, op)
+		}
+		_, err = tx.Exec(ctx, `UPDATE users SET updated_at = $2 WHERE id = $1;`, dto.ID, student.User().UpdatedAt())
+		if err != nil {
+			otelx.RecordSpanError(span, err, "failed to update user")
+			return errorx.Wrap(err, op)
+		}
+
+		events := student.GetUncommittedEvents()
+		if len(events) > 0 {
+			if err := saveVersion(ctx, tx, "users", dto.ID, student); err != nil {
+				otelx.RecordSpanError(span, err, "failed to save version")
+				return errorx.Wrap(err, op)
+			}
 			if err := watermillx.Publish(ctx, tx, st.wlogger, events...); err != nil {
 				otelx.RecordSpanError(span, err, "failed to publish events")
 				return errorx.Wrap(err, op)
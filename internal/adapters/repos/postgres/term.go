@@ -0,0 +1,268 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/term"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/i18nx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/postgres"
+)
+
+// TermRepo stores the academic terms. The writes check the term against the
+// terms it overlaps with term.Term.CheckOverlaps, with the table locked so
+// that two writes can not both pass the check.
+type TermRepo struct {
+	tracer trace.Tracer
+	pool   *pgxpool.Pool
+	clock  clock.Clock
+}
+
+// NewTermRepo creates a new TermRepo.
+//
+//	WARNING: panics if pool is nil
+func NewTermRepo(pool *pgxpool.Pool, t trace.Tracer) *TermRepo {
+	if pool == nil {
+		panic("pgxpool.Pool is required")
+	}
+	if t == nil {
+		t = tracer
+	}
+
+	return &TermRepo{
+		tracer: t,
+		pool:   pool,
+	}
+}
+
+// WithClock sets the clock the loaded terms are rehydrated with, clock.Real
+// by default.
+func (r *TermRepo) WithClock(c clock.Clock) *TermRepo {
+	r.clock = c
+	return r
+}
+
+const termColumns = `id, code, name, starts_at, ends_at, created_at, updated_at`
+
+// SaveTerm inserts t unless it overlaps a stored term, term.ErrOverlap then.
+func (r *TermRepo) SaveTerm(ctx context.Context, t *term.Term) error {
+	const op = "postgres.TermRepo.SaveTerm"
+	ctx, span := r.tracer.Start(ctx, "TermRepo.SaveTerm")
+	defer span.End()
+	span.SetAttributes(attribute.String("term.id", t.ID().String()), attribute.String("term.code", t.Code()))
+
+	return postgres.WithTx(ctx, r.pool, func(ctx context.Context, tx pgx.Tx) error {
+		if err := r.checkOverlaps(ctx, tx, t); err != nil {
+			otelx.RecordSpanError(span, err, "term overlaps")
+			return errorx.Wrap(err, op)
+		}
+
+		_, err := tx.Exec(ctx, `
+			INSERT INTO terms (`+termColumns+`)
+			VALUES ($1, $2, $3, $4, $5, $6, $7);
+		`, uuid.UUID(t.ID()), t.Code(), t.Name(), t.StartsAt(), t.EndsAt(), t.CreatedAt(), t.UpdatedAt())
+		if err != nil {
+			otelx.RecordSpanError(span, err, "failed to insert term")
+			return translateError(err, op)
+		}
+		return nil
+	})
+}
+
+// UpdateTerm locks the term id, runs fn on it and saves it unless it
+// overlaps another term, term.ErrOverlap then.
+func (r *TermRepo) UpdateTerm(ctx context.Context, id term.ID, fn func(context.Context, *term.Term) error) error {
+	const op = "postgres.TermRepo.UpdateTerm"
+	ctx, span := r.tracer.Start(ctx, "TermRepo.UpdateTerm")
+	defer span.End()
+	span.SetAttributes(attribute.String("term.id", id.String()))
+	if fn == nil {
+		otelx.RecordSpanError(span, ErrNilFunc, "update function cannot be nil")
+		return ErrNilFunc
+	}
+
+	return postgres.WithTx(ctx, r.pool, func(ctx context.Context, tx pgx.Tx) error {
+		if err := lockTerms(ctx, tx); err != nil {
+			otelx.RecordSpanError(span, err, "failed to lock terms")
+			return errorx.Wrap(err, op)
+		}
+		t, err := r.scanTerm(tx.QueryRow(ctx, `SELECT `+termColumns+` FROM terms WHERE id = $1;`, uuid.UUID(id)))
+		if err != nil {
+			otelx.RecordSpanError(span, err, "failed to get term")
+			if errors.Is(err, pgx.ErrNoRows) {
+				return errorx.NewResourceNotFound(i18nx.FieldTerm).WithCause(err, op)
+			}
+			return errorx.Wrap(err, op)
+		}
+
+		if err := fn(ctx, t); err != nil {
+			otelx.RecordSpanError(span, err, "update function returned an error")
+			return errorx.Wrap(err, op)
+		}
+		if err := r.checkOverlaps(ctx, tx, t); err != nil {
+			otelx.RecordSpanError(span, err, "term overlaps")
+			return errorx.Wrap(err, op)
+		}
+
+		_, err = tx.Exec(ctx, `
+			UPDATE terms
+			SET code = $2, name = $3, starts_at = $4, ends_at = $5, updated_at = $6
+			WHERE id = $1;
+		`, uuid.UUID(t.ID()), t.Code(), t.Name(), t.StartsAt(), t.EndsAt(), t.UpdatedAt())
+		if err != nil {
+			otelx.RecordSpanError(span, err, "failed to update term")
+			return translateError(err, op)
+		}
+		return nil
+	})
+}
+
+// DeleteTerm deletes the term id. A term groups study in is kept, a conflict
+// error then, the groups are moved to another term first.
+func (r *TermRepo) DeleteTerm(ctx context.Context, id term.ID) error {
+	const op = "postgres.TermRepo.DeleteTerm"
+	ctx, span := r.tracer.Start(ctx, "TermRepo.DeleteTerm")
+	defer span.End()
+	span.SetAttributes(attribute.String("term.id", id.String()))
+
+	return postgres.WithTx(ctx, r.pool, func(ctx context.Context, tx pgx.Tx) error {
+		var groups int64
+		err := tx.QueryRow(ctx, `SELECT count(*) FROM groups WHERE term_id = $1;`, uuid.UUID(id)).Scan(&groups)
+		if err != nil {
+			otelx.RecordSpanError(span, err, "failed to count groups of term")
+			return errorx.Wrap(err, op)
+		}
+		if groups > 0 {
+			return errorx.NewConflict().WithDetails("the term has groups, move them to another term first").WithOp(op)
+		}
+
+		res, err := tx.Exec(ctx, `DELETE FROM terms WHERE id = $1;`, uuid.UUID(id))
+		if err != nil {
+			otelx.RecordSpanError(span, err, "failed to delete term")
+			return translateError(err, op)
+		}
+		if res.RowsAffected() == 0 {
+			return errorx.NewResourceNotFound(i18nx.FieldTerm).WithCause(ErrNoRowsAffected, op)
+		}
+		return nil
+	})
+}
+
+func (r *TermRepo) GetTermByID(ctx context.Context, id term.ID) (*term.Term, error) {
+	const op = "postgres.TermRepo.GetTermByID"
+	ctx, span := r.tracer.Start(ctx, "TermRepo.GetTermByID")
+	defer span.End()
+	span.SetAttributes(attribute.String("term.id", id.String()))
+
+	t, err := r.scanTerm(r.pool.QueryRow(ctx, `SELECT `+termColumns+` FROM terms WHERE id = $1;`, uuid.UUID(id)))
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to get term")
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errorx.NewResourceNotFound(i18nx.FieldTerm).WithCause(err, op)
+		}
+		return nil, errorx.Wrap(err, op)
+	}
+
+	return t, nil
+}
+
+// GetTermAt returns the term containing at, a not found error when at is
+// between the terms, see term.Current.
+func (r *TermRepo) GetTermAt(ctx context.Context, at time.Time) (*term.Term, error) {
+	const op = "postgres.TermRepo.GetTermAt"
+	ctx, span := r.tracer.Start(ctx, "TermRepo.GetTermAt")
+	defer span.End()
+
+	t, err := r.scanTerm(r.pool.QueryRow(ctx, `
+		SELECT `+termColumns+`
+		FROM terms
+		WHERE starts_at <= $1 AND ends_at > $1;
+	`, at))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errorx.NewResourceNotFound(i18nx.FieldTerm).WithCause(err, op)
+		}
+		otelx.RecordSpanError(span, err, "failed to get term")
+		return nil, errorx.Wrap(err, op)
+	}
+
+	return t, nil
+}
+
+// ListTerms returns all the terms, the earliest first.
+func (r *TermRepo) ListTerms(ctx context.Context) ([]*term.Term, error) {
+	const op = "postgres.TermRepo.ListTerms"
+	ctx, span := r.tracer.Start(ctx, "TermRepo.ListTerms")
+	defer span.End()
+
+	rows, err := r.pool.Query(ctx, `SELECT `+termColumns+` FROM terms ORDER BY starts_at;`)
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to query terms")
+		return nil, errorx.Wrap(err, op)
+	}
+	terms, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*term.Term, error) {
+		return r.scanTerm(row)
+	})
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to scan terms")
+		return nil, errorx.Wrap(err, op)
+	}
+
+	return terms, nil
+}
+
+// checkOverlaps locks the terms and checks t against the ones it overlaps.
+func (r *TermRepo) checkOverlaps(ctx context.Context, tx pgx.Tx, t *term.Term) error {
+	if err := lockTerms(ctx, tx); err != nil {
+		return err
+	}
+
+	rows, err := tx.Query(ctx, `
+		SELECT `+termColumns+`
+		FROM terms
+		WHERE starts_at < $2 AND ends_at > $1
+		ORDER BY starts_at;
+	`, t.StartsAt(), t.EndsAt())
+	if err != nil {
+		return err
+	}
+	overlapping, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*term.Term, error) {
+		return r.scanTerm(row)
+	})
+	if err != nil {
+		return err
+	}
+
+	return t.CheckOverlaps(overlapping)
+}
+
+// lockTerms blocks the concurrent writes of the terms until tx ends, the
+// reads go on. The writes are rare, the overlap check needs them one at a
+// time.
+func lockTerms(ctx context.Context, tx pgx.Tx) error {
+	_, err := tx.Exec(ctx, `LOCK TABLE terms IN SHARE ROW EXCLUSIVE MODE;`)
+	return err
+}
+
+func (r *TermRepo) scanTerm(row pgx.Row) (*term.Term, error) {
+	var (
+		args = term.RehydrateArgs{Clock: r.clock}
+		id   uuid.UUID
+	)
+	err := row.Scan(&id, &args.Code, &args.Name, &args.StartsAt, &args.EndsAt, &args.CreatedAt, &args.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	args.ID = term.ID(id)
+	return term.Rehydrate(args), nil
+}
@@ -0,0 +1,159 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/tos"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/i18nx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx"
+)
+
+// TOSRepo stores the versions of the terms of service, the acceptances are
+// stored with the users by UserRepo.
+type TOSRepo struct {
+	tracer trace.Tracer
+	pool   *pgxpool.Pool
+}
+
+// NewTOSRepo creates a new TOSRepo.
+//
+//	WARNING: panics if pool is nil
+func NewTOSRepo(pool *pgxpool.Pool, t trace.Tracer) *TOSRepo {
+	if pool == nil {
+		panic("pgxpool.Pool is required")
+	}
+	if t == nil {
+		t = tracer
+	}
+
+	return &TOSRepo{
+		tracer: t,
+		pool:   pool,
+	}
+}
+
+const tosVersionColumns = `version, document_url, published_at, created_at`
+
+func (r *TOSRepo) SaveTOSVersion(ctx context.Context, v *tos.Version) error {
+	const op = "postgres.TOSRepo.SaveTOSVersion"
+	ctx, span := r.tracer.Start(ctx, "TOSRepo.SaveTOSVersion")
+	defer span.End()
+	span.SetAttributes(attribute.String("tos.version", v.Version()))
+
+	_, err := r.pool.Exec(ctx, `
+		INSERT INTO tos_versions (`+tosVersionColumns+`)
+		VALUES ($1, $2, $3, $4);
+	`, v.Version(), v.DocumentURL(), v.PublishedAt(), v.CreatedAt())
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to insert tos version")
+		return translateError(err, op)
+	}
+
+	return nil
+}
+
+// ListTOSVersions returns the versions, the latest published first.
+func (r *TOSRepo) ListTOSVersions(ctx context.Context) ([]*tos.Version, error) {
+	const op = "postgres.TOSRepo.ListTOSVersions"
+	ctx, span := r.tracer.Start(ctx, "TOSRepo.ListTOSVersions")
+	defer span.End()
+
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+tosVersionColumns+`
+		FROM tos_versions
+		ORDER BY published_at DESC, created_at DESC;
+	`)
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to query tos versions")
+		return nil, errorx.Wrap(err, op)
+	}
+	defer rows.Close()
+
+	var versions []*tos.Version
+	for rows.Next() {
+		v, err := scanTOSVersion(rows)
+		if err != nil {
+			otelx.RecordSpanError(span, err, "failed to scan tos version")
+			return nil, errorx.Wrap(err, op)
+		}
+		versions = append(versions, v)
+	}
+	if err := rows.Err(); err != nil {
+		otelx.RecordSpanError(span, err, "failed to iterate tos versions")
+		return nil, errorx.Wrap(err, op)
+	}
+
+	return versions, nil
+}
+
+// GetCurrentTOSVersion returns the latest version published by now.
+func (r *TOSRepo) GetCurrentTOSVersion(ctx context.Context, now time.Time) (*tos.Version, error) {
+	const op = "postgres.TOSRepo.GetCurrentTOSVersion"
+	ctx, span := r.tracer.Start(ctx, "TOSRepo.GetCurrentTOSVersion")
+	defer span.End()
+
+	v, err := scanTOSVersion(r.pool.QueryRow(ctx, `
+		SELECT `+tosVersionColumns+`
+		FROM tos_versions
+		WHERE published_at <= $1
+		ORDER BY published_at DESC, created_at DESC
+		LIMIT 1;
+	`, now))
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to get current tos version")
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errorx.NewResourceNotFound(i18nx.FieldTOSVersion).WithCause(err, op)
+		}
+		return nil, errorx.Wrap(err, op)
+	}
+
+	return v, nil
+}
+
+// RequiresTOSReconsent reports whether the user has not accepted the latest
+// version published by now. Nobody does while no version is published.
+func (r *TOSRepo) RequiresTOSReconsent(ctx context.Context, userID user.ID, now time.Time) (bool, error) {
+	const op = "postgres.TOSRepo.RequiresTOSReconsent"
+	ctx, span := r.tracer.Start(ctx, "TOSRepo.RequiresTOSReconsent")
+	defer span.End()
+	span.SetAttributes(attribute.String("user.id", userID.String()))
+
+	var required bool
+	err := r.pool.QueryRow(ctx, `
+		SELECT u.tos_version IS DISTINCT FROM latest.version
+		FROM users u
+		LEFT JOIN LATERAL (
+			SELECT version FROM tos_versions
+			WHERE published_at <= $2
+			ORDER BY published_at DESC, created_at DESC
+			LIMIT 1
+		) AS latest ON true
+		WHERE u.id = $1 AND latest.version IS NOT NULL;
+	`, userID, now).Scan(&required)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to check tos acceptance")
+		return false, errorx.Wrap(err, op)
+	}
+
+	return required, nil
+}
+
+func scanTOSVersion(row pgx.Row) (*tos.Version, error) {
+	var args tos.RehydrateArgs
+	if err := row.Scan(&args.Version, &args.DocumentURL, &args.PublishedAt, &args.CreatedAt); err != nil {
+		return nil, err
+	}
+	return tos.Rehydrate(args), nil
+}
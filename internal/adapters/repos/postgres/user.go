@@ -8,17 +8,28 @@ import (
 	"github.com/ThreeDotsLabs/watermill"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
 
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/avatars"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/emails"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/roles"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/ctxs"
 	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
 	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx"
 	"gitlab.com/ucmsv2/ucms-backend/pkg/postgres"
 	"gitlab.com/ucmsv2/ucms-backend/pkg/watermillx"
 )
 
-const insertUserQuery = ` INSERT INTO users (id, barcode, username, role_id, email, first_name, last_name, avatar_source, avatar_external, avatar_s3_key, pass_hash, created_at, updated_at)
-    VALUES ($1, $2, $3, (SELECT id FROM global_roles WHERE name = $4), $5, $6, $7, $8, $9, $10, $11, $12, $13);`
+const insertUserQuery = ` INSERT INTO users (id, barcode, username, role_id, email, first_name, last_name, avatar_source, avatar_external, avatar_s3_key, pass_hash, created_at, updated_at, campus_id, tos_version, tos_accepted_at, tos_accepted_ip, email_verified)
+    VALUES ($1, $2, $3, (SELECT id FROM global_roles WHERE name = $4), $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18);`
+
+// otherRolesColumn selects the names of the roles the user u holds beside
+// its role_id, see user.User.Roles.
+const otherRolesColumn = `ARRAY(
+                    SELECT ogr.name FROM user_roles ur JOIN global_roles ogr ON ur.role_id = ogr.id
+                    WHERE ur.user_id = u.id ORDER BY ogr.id)`
 
 type UserRepo struct {
 	tracer  trace.Tracer
@@ -69,18 +80,31 @@ func (r *UserRepo) SaveUser(ctx context.Context, u *user.User) error {
 			dto.Passhash,
 			dto.CreatedAt,
 			dto.UpdatedAt,
+			ctxs.CampusFromCtx(ctx),
+			dto.TOSVersion,
+			dto.TOSAcceptedAt,
+			dto.TOSAcceptedIP,
+			dto.EmailVerified,
 		)
 		if err != nil {
 			otelx.RecordSpanError(span, err, "failed to insert user")
-			return errorx.Wrap(err, op)
+			return translateError(err, op)
 		}
 		if res.RowsAffected() == 0 {
 			otelx.RecordSpanError(span, err, "no rows affected while inserting user")
 			return errorx.Wrap(ErrNoRowsAffected, op)
 		}
+		if err := syncAvatarRefs(ctx, tx, "", avatarRefKey(dto)); err != nil {
+			otelx.RecordSpanError(span, err, "failed to update avatar references")
+			return errorx.Wrap(err, op)
+		}
 
 		events := u.GetUncommittedEvents()
 		if len(events) > 0 {
+			if err := saveVersion(ctx, tx, "users", dto.ID, u); err != nil {
+				otelx.RecordSpanError(span, err, "failed to save version")
+				return errorx.Wrap(err, op)
+			}
 			if err := watermillx.Publish(ctx, tx, r.wlogger, events...); err != nil {
 				otelx.RecordSpanError(span, err, "failed to publish events")
 				return errorx.Wrap(err, op)
@@ -92,6 +116,7 @@ func (r *UserRepo) SaveUser(ctx context.Context, u *user.User) error {
 		otelx.RecordSpanError(span, err, "failed to execute transaction")
 		return err
 	}
+	u.CommitEvents()
 
 	return nil
 }
@@ -113,20 +138,25 @@ func (r *UserRepo) UpdateUser(
         SELECT  u.id, u.barcode, u.username, u.role_id,
                 u.first_name, u.last_name, 
 				u.avatar_source, u.avatar_external, u.avatar_s3_key,
-                u.email, u.pass_hash, u.created_at, u.updated_at,
+                u.email, u.pass_hash, u.previous_pass_hashes,
+                u.tos_version, u.tos_accepted_at, u.tos_accepted_ip, u.email_verified, u.version, u.last_seen_at, u.created_at, u.updated_at,
                 gr.id, gr.name
         FROM users u JOIN global_roles gr ON u.role_id = gr.id
-        WHERE u.id = $1;
+        WHERE u.id = $1
+        FOR UPDATE OF u;
     `
 
+		// The row is locked so that concurrent updates of the user release
+		// its old avatar reference once.
 		var dto UserDTO
 		var roleDTO GlobalRoleDTO
-		err := r.pool.QueryRow(ctx, query, id).
+		err := tx.QueryRow(ctx, query, id).
 			Scan(
 				&dto.ID, &dto.Barcode, &dto.Username, &dto.RoleID,
 				&dto.FirstName, &dto.LastName,
 				&dto.AvatarSource, &dto.AvatarExternal, &dto.AvatarS3Key,
-				&dto.Email, &dto.Passhash, &dto.CreatedAt, &dto.UpdatedAt,
+				&dto.Email, &dto.Passhash, &dto.PreviousPasshashes,
+				&dto.TOSVersion, &dto.TOSAcceptedAt, &dto.TOSAcceptedIP, &dto.EmailVerified, &dto.Version, &dto.LastSeenAt, &dto.CreatedAt, &dto.UpdatedAt,
 				&roleDTO.ID, &roleDTO.Name,
 			)
 		if err != nil {
@@ -138,6 +168,7 @@ func (r *UserRepo) UpdateUser(
 		}
 
 		u := UserToDomain(dto, roleDTO)
+		oldAvatarKey := avatarRefKey(dto)
 
 		fnerr := fn(ctx, u)
 		if fnerr != nil && !errorx.IsPersistable(fnerr) {
@@ -152,7 +183,8 @@ func (r *UserRepo) UpdateUser(
 		SET barcode = $2, username = $3, role_id = (SELECT id FROM global_roles WHERE name = $4),
 			first_name = $5, last_name = $6,
 			avatar_source = $7, avatar_external = $8, avatar_s3_key = $9,
-			email = $10, pass_hash = $11, updated_at = $12
+			email = $10, pass_hash = $11, previous_pass_hashes = $12, updated_at = $13,
+			tos_version = $14, tos_accepted_at = $15, tos_accepted_ip = $16, email_verified = $17
 		WHERE id = $1;
 		`
 
@@ -168,7 +200,12 @@ func (r *UserRepo) UpdateUser(
 			dto.AvatarS3Key,
 			dto.Email,
 			dto.Passhash,
+			dto.PreviousPasshashes,
 			dto.UpdatedAt,
+			dto.TOSVersion,
+			dto.TOSAcceptedAt,
+			dto.TOSAcceptedIP,
+			dto.EmailVerified,
 		)
 		if err != nil {
 			otelx.RecordSpanError(span, err, "failed to update user")
@@ -178,9 +215,17 @@ func (r *UserRepo) UpdateUser(
 			otelx.RecordSpanError(span, err, "no rows affected while updating user")
 			return errorx.Wrap(ErrNoRowsAffected, op)
 		}
+		if err := syncAvatarRefs(ctx, tx, oldAvatarKey, avatarRefKey(dto)); err != nil {
+			otelx.RecordSpanError(span, err, "failed to update avatar references")
+			return errorx.Wrap(err, op)
+		}
 
 		events := u.GetUncommittedEvents()
 		if len(events) > 0 {
+			if err := saveVersion(ctx, tx, "users", dto.ID, u); err != nil {
+				otelx.RecordSpanError(span, err, "failed to save version")
+				return errorx.Wrap(err, op)
+			}
 			if err := watermillx.Publish(ctx, tx, r.wlogger, events...); err != nil {
 				otelx.RecordSpanError(span, err, "failed to publish events")
 				return errorx.Wrap(err, op)
@@ -211,21 +256,23 @@ func (r *UserRepo) GetUserByID(ctx context.Context, id user.ID) (*user.User, err
         SELECT  u.id, u.barcode, u.username, u.role_id,
                 u.first_name, u.last_name, 
 				u.avatar_source, u.avatar_external, u.avatar_s3_key,
-                u.email, u.pass_hash, u.created_at, u.updated_at,
-                gr.id, gr.name
+                u.email, u.pass_hash, u.previous_pass_hashes,
+                u.tos_version, u.tos_accepted_at, u.tos_accepted_ip, u.email_verified, u.version, u.last_seen_at, u.created_at, u.updated_at,
+                gr.id, gr.name, ` + otherRolesColumn + `
         FROM users u JOIN global_roles gr ON u.role_id = gr.id
-        WHERE u.id = $1;
+        WHERE u.id = $1 AND ($2::text IS NULL OR u.campus_id = $2);
     `
 
 	var dto UserDTO
 	var roleDTO GlobalRoleDTO
-	err := r.pool.QueryRow(ctx, query, id).
+	err := r.pool.QueryRow(ctx, query, id, campusScope(ctx)).
 		Scan(
 			&dto.ID, &dto.Barcode, &dto.Username, &dto.RoleID,
 			&dto.FirstName, &dto.LastName,
 			&dto.AvatarSource, &dto.AvatarExternal, &dto.AvatarS3Key,
-			&dto.Email, &dto.Passhash, &dto.CreatedAt, &dto.UpdatedAt,
-			&roleDTO.ID, &roleDTO.Name,
+			&dto.Email, &dto.Passhash, &dto.PreviousPasshashes,
+			&dto.TOSVersion, &dto.TOSAcceptedAt, &dto.TOSAcceptedIP, &dto.EmailVerified, &dto.Version, &dto.LastSeenAt, &dto.CreatedAt, &dto.UpdatedAt,
+			&roleDTO.ID, &roleDTO.Name, &dto.OtherRoles,
 		)
 	if err != nil {
 		otelx.RecordSpanError(span, err, "failed to get user by id")
@@ -238,7 +285,7 @@ func (r *UserRepo) GetUserByID(ctx context.Context, id user.ID) (*user.User, err
 	return UserToDomain(dto, roleDTO), nil
 }
 
-func (r *UserRepo) GetUserByEmail(ctx context.Context, email string) (*user.User, error) {
+func (r *UserRepo) GetUserByEmail(ctx context.Context, email emails.Email) (*user.User, error) {
 	ctx, span := r.tracer.Start(ctx, "UserRepo.GetUserByEmail")
 	defer span.End()
 
@@ -246,21 +293,23 @@ func (r *UserRepo) GetUserByEmail(ctx context.Context, email string) (*user.User
         SELECT  u.id, u.barcode, u.username, u.role_id, 
                 u.first_name, u.last_name, 
 				u.avatar_source, u.avatar_external, u.avatar_s3_key,
-                u.email, u.pass_hash, u.created_at, u.updated_at,
-                gr.id, gr.name
+                u.email, u.pass_hash, u.previous_pass_hashes,
+                u.tos_version, u.tos_accepted_at, u.tos_accepted_ip, u.email_verified, u.version, u.last_seen_at, u.created_at, u.updated_at,
+                gr.id, gr.name, ` + otherRolesColumn + `
         FROM users u JOIN global_roles gr ON u.role_id = gr.id
-        WHERE email = $1;
+        WHERE u.email = $1 AND ($2::text IS NULL OR u.campus_id = $2);
     `
 
 	var dto UserDTO
 	var roleDTO GlobalRoleDTO
-	err := r.pool.QueryRow(ctx, query, email).
+	err := r.pool.QueryRow(ctx, query, email, campusScope(ctx)).
 		Scan(
 			&dto.ID, &dto.Barcode, &dto.Username, &dto.RoleID,
 			&dto.FirstName, &dto.LastName,
 			&dto.AvatarSource, &dto.AvatarExternal, &dto.AvatarS3Key,
-			&dto.Email, &dto.Passhash, &dto.CreatedAt, &dto.UpdatedAt,
-			&roleDTO.ID, &roleDTO.Name,
+			&dto.Email, &dto.Passhash, &dto.PreviousPasshashes,
+			&dto.TOSVersion, &dto.TOSAcceptedAt, &dto.TOSAcceptedIP, &dto.EmailVerified, &dto.Version, &dto.LastSeenAt, &dto.CreatedAt, &dto.UpdatedAt,
+			&roleDTO.ID, &roleDTO.Name, &dto.OtherRoles,
 		)
 	if err != nil {
 		otelx.RecordSpanError(span, err, "failed to get user by email")
@@ -282,21 +331,23 @@ func (r *UserRepo) GetUserByBarcode(ctx context.Context, barcode user.Barcode) (
         SELECT  u.id, u.barcode, u.username, u.role_id,
                 u.first_name, u.last_name, 
 				u.avatar_source, u.avatar_external, u.avatar_s3_key,
-                u.email, u.pass_hash, u.created_at, u.updated_at,
-                gr.id, gr.name
+                u.email, u.pass_hash, u.previous_pass_hashes,
+                u.tos_version, u.tos_accepted_at, u.tos_accepted_ip, u.email_verified, u.version, u.last_seen_at, u.created_at, u.updated_at,
+                gr.id, gr.name, ` + otherRolesColumn + `
         FROM users u JOIN global_roles gr ON u.role_id = gr.id
-        WHERE u.barcode = $1;
+        WHERE u.barcode = $1 AND ($2::text IS NULL OR u.campus_id = $2);
     `
 
 	var dto UserDTO
 	var roleDTO GlobalRoleDTO
-	err := r.pool.QueryRow(ctx, query, barcode).
+	err := r.pool.QueryRow(ctx, query, barcode, campusScope(ctx)).
 		Scan(
 			&dto.ID, &dto.Barcode, &dto.Username, &dto.RoleID,
 			&dto.FirstName, &dto.LastName,
 			&dto.AvatarSource, &dto.AvatarExternal, &dto.AvatarS3Key,
-			&dto.Email, &dto.Passhash, &dto.CreatedAt, &dto.UpdatedAt,
-			&roleDTO.ID, &roleDTO.Name,
+			&dto.Email, &dto.Passhash, &dto.PreviousPasshashes,
+			&dto.TOSVersion, &dto.TOSAcceptedAt, &dto.TOSAcceptedIP, &dto.EmailVerified, &dto.Version, &dto.LastSeenAt, &dto.CreatedAt, &dto.UpdatedAt,
+			&roleDTO.ID, &roleDTO.Name, &dto.OtherRoles,
 		)
 	if err != nil {
 		otelx.RecordSpanError(span, err, "failed to get user by barcode")
@@ -309,9 +360,98 @@ func (r *UserRepo) GetUserByBarcode(ctx context.Context, barcode user.Barcode) (
 	return UserToDomain(dto, roleDTO), nil
 }
 
+// PromoteUser loads the user with barcode, applies fn and saves the new role.
+// A user promoted to staff gets the staff record as well.
+func (r *UserRepo) PromoteUser(
+	ctx context.Context,
+	barcode user.Barcode,
+	fn func(ctx context.Context, u *user.User) error,
+) error {
+	const op = "postgres.UserRepo.PromoteUser"
+	ctx, span := r.tracer.Start(ctx, "UserRepo.PromoteUser")
+	defer span.End()
+	if fn == nil {
+		otelx.RecordSpanError(span, ErrNilFunc, "update function cannot be nil")
+		return ErrNilFunc
+	}
+
+	err := postgres.WithTx(ctx, r.pool, func(ctx context.Context, tx pgx.Tx) error {
+		query := `
+        SELECT  u.id, u.barcode, u.username, u.role_id,
+                u.first_name, u.last_name,
+                u.avatar_source, u.avatar_external, u.avatar_s3_key,
+                u.email, u.pass_hash, u.previous_pass_hashes,
+                u.tos_version, u.tos_accepted_at, u.tos_accepted_ip, u.email_verified, u.version, u.last_seen_at, u.created_at, u.updated_at,
+                gr.id, gr.name
+        FROM users u JOIN global_roles gr ON u.role_id = gr.id
+        WHERE u.barcode = $1 AND ($2::text IS NULL OR u.campus_id = $2)
+        FOR UPDATE OF u;
+    `
+
+		var dto UserDTO
+		var roleDTO GlobalRoleDTO
+		err := tx.QueryRow(ctx, query, barcode, campusScope(ctx)).
+			Scan(
+				&dto.ID, &dto.Barcode, &dto.Username, &dto.RoleID,
+				&dto.FirstName, &dto.LastName,
+				&dto.AvatarSource, &dto.AvatarExternal, &dto.AvatarS3Key,
+				&dto.Email, &dto.Passhash, &dto.PreviousPasshashes,
+				&dto.TOSVersion, &dto.TOSAcceptedAt, &dto.TOSAcceptedIP, &dto.EmailVerified, &dto.Version, &dto.LastSeenAt, &dto.CreatedAt, &dto.UpdatedAt,
+				&roleDTO.ID, &roleDTO.Name,
+			)
+		if err != nil {
+			otelx.RecordSpanError(span, err, "failed to get user by barcode")
+			if errors.Is(err, pgx.ErrNoRows) {
+				return errorx.NewNotFound().WithCause(err, op)
+			}
+			return errorx.Wrap(err, op)
+		}
+
+		u := UserToDomain(dto, roleDTO)
+		if err := fn(ctx, u); err != nil {
+			otelx.RecordSpanError(span, err, "update function returned an error")
+			return errorx.Wrap(err, op)
+		}
+
+		res, err := tx.Exec(ctx, `
+		UPDATE users
+		SET role_id = (SELECT id FROM global_roles WHERE name = $2), updated_at = $3
+		WHERE id = $1;
+		`, dto.ID, u.Role().String(), u.UpdatedAt())
+		if err != nil {
+			otelx.RecordSpanError(span, err, "failed to update user role")
+			return errorx.Wrap(err, op)
+		}
+		if res.RowsAffected() == 0 {
+			otelx.RecordSpanError(span, ErrNoRowsAffected, "no rows affected while updating user role")
+			return errorx.Wrap(ErrNoRowsAffected, op)
+		}
+
+		if u.Role() == roles.Staff {
+			_, err := tx.Exec(ctx, `
+            INSERT INTO staffs (user_id)
+            VALUES ($1)
+            ON CONFLICT (user_id) DO NOTHING;
+        `, dto.ID)
+			if err != nil {
+				otelx.RecordSpanError(span, err, "failed to insert staff")
+				return errorx.Wrap(err, op)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		otelx.RecordSpanError(span, err, "transaction to promote user failed")
+		return err
+	}
+
+	return nil
+}
+
 func (r *UserRepo) IsUserExists(
 	ctx context.Context,
-	email, username string,
+	email emails.Email, username string,
 	barcode user.Barcode,
 ) (emailExists, usernameExists, barcodeExists bool, err error) {
 	const op = "postgres.UserRepo.IsUserExists"
@@ -333,3 +473,251 @@ func (r *UserRepo) IsUserExists(
 
 	return emailExists, usernameExists, barcodeExists, nil
 }
+
+// CountUsersByRole returns the number of users per global role, roles
+// without users are reported as zero.
+func (r *UserRepo) CountUsersByRole(ctx context.Context) (map[roles.Global]int64, error) {
+	const op = "postgres.UserRepo.CountUsersByRole"
+	ctx, span := r.tracer.Start(ctx, "UserRepo.CountUsersByRole")
+	defer span.End()
+
+	rows, err := r.pool.Query(ctx, `
+		SELECT gr.name, count(u.id)
+		FROM global_roles gr
+		LEFT JOIN users u ON u.role_id = gr.id
+		GROUP BY gr.name;
+	`)
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to count users by role")
+		return nil, errorx.Wrap(err, op)
+	}
+	defer rows.Close()
+
+	counts := make(map[roles.Global]int64)
+	for rows.Next() {
+		var (
+			role  roles.Global
+			count int64
+		)
+		if err := rows.Scan(&role, &count); err != nil {
+			otelx.RecordSpanError(span, err, "failed to scan user count")
+			return nil, errorx.Wrap(err, op)
+		}
+		counts[role] = count
+	}
+	if err := rows.Err(); err != nil {
+		otelx.RecordSpanError(span, err, "failed to iterate user counts")
+		return nil, errorx.Wrap(err, op)
+	}
+
+	return counts, nil
+}
+
+// FilterReferencedAvatarKeys returns the subset of keys that are still the
+// current S3 avatar of some user or have a pending upload, see
+// CreatePendingUpload.
+func (r *UserRepo) FilterReferencedAvatarKeys(ctx context.Context, keys []string) (map[string]struct{}, error) {
+	const op = "postgres.UserRepo.FilterReferencedAvatarKeys"
+	ctx, span := r.tracer.Start(ctx, "UserRepo.FilterReferencedAvatarKeys")
+	defer span.End()
+
+	referenced := make(map[string]struct{}, len(keys))
+	if len(keys) == 0 {
+		return referenced, nil
+	}
+
+	rows, err := r.pool.Query(ctx, `
+		SELECT avatar_s3_key FROM users
+		WHERE avatar_source = $1 AND avatar_s3_key = ANY($2)
+		UNION
+		SELECT s3_key FROM pending_uploads
+		WHERE s3_key = ANY($2) AND created_at > now() - $3 * interval '1 second';
+	`, avatars.SourceS3.String(), keys, pendingUploadTTLSeconds)
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to query referenced avatar keys")
+		return nil, errorx.Wrap(err, op)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			otelx.RecordSpanError(span, err, "failed to scan avatar key")
+			return nil, errorx.Wrap(err, op)
+		}
+		referenced[key] = struct{}{}
+	}
+	if err := rows.Err(); err != nil {
+		otelx.RecordSpanError(span, err, "failed to iterate avatar keys")
+		return nil, errorx.Wrap(err, op)
+	}
+
+	return referenced, nil
+}
+
+// ListAvatarReferences returns up to limit users pointing to an S3 avatar,
+// ordered by their id and starting after the user after, uuid.Nil for the
+// first page.
+func (r *UserRepo) ListAvatarReferences(ctx context.Context, after user.ID, limit int) ([]user.AvatarReference, error) {
+	const op = "postgres.UserRepo.ListAvatarReferences"
+	ctx, span := r.tracer.Start(ctx, "UserRepo.ListAvatarReferences")
+	defer span.End()
+
+	rows, err := r.pool.Query(ctx, `
+		SELECT id, avatar_s3_key FROM users
+		WHERE avatar_source = $1 AND avatar_s3_key <> '' AND id > $2
+		ORDER BY id
+		LIMIT $3;
+	`, avatars.SourceS3.String(), after, limit)
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to query avatar references")
+		return nil, errorx.Wrap(err, op)
+	}
+	defer rows.Close()
+
+	var refs []user.AvatarReference
+	for rows.Next() {
+		var ref user.AvatarReference
+		if err := rows.Scan(&ref.UserID, &ref.S3Key); err != nil {
+			otelx.RecordSpanError(span, err, "failed to scan avatar reference")
+			return nil, errorx.Wrap(err, op)
+		}
+		refs = append(refs, ref)
+	}
+	if err := rows.Err(); err != nil {
+		otelx.RecordSpanError(span, err, "failed to iterate avatar references")
+		return nil, errorx.Wrap(err, op)
+	}
+
+	return refs, nil
+}
+
+// PublishAvatarMissing publishes e on its own, the read that found the avatar
+// missing changes nothing.
+func (r *UserRepo) PublishAvatarMissing(ctx context.Context, e *user.UserAvatarMissing) error {
+	const op = "postgres.UserRepo.PublishAvatarMissing"
+	ctx, span := r.tracer.Start(ctx, "UserRepo.PublishAvatarMissing")
+	defer span.End()
+	span.SetAttributes(
+		attribute.String("user.id", e.UserID.String()),
+		attribute.String("avatar.s3_key", e.Avatar.S3Key),
+	)
+
+	err := postgres.WithTx(ctx, r.pool, func(ctx context.Context, tx pgx.Tx) error {
+		return watermillx.Publish(ctx, tx, r.wlogger, e)
+	})
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to publish avatar missing event")
+		return errorx.Wrap(err, op)
+	}
+
+	return nil
+}
+
+// avatarLockClass namespaces the advisory locks of the avatar keys, see
+// lockAvatarKey.
+const avatarLockClass = 0x61766174 // "avat"
+
+// lockAvatarKey takes the transaction scoped lock of the avatar key. Taking a
+// reference to a key and removing its unreferenced object both hold it, so
+// an object is never removed from under a reference being taken.
+func lockAvatarKey(ctx context.Context, tx pgx.Tx, key string) error {
+	_, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock($1, hashtext($2));`, avatarLockClass, key)
+	return err
+}
+
+// ReleaseAvatarRef calls remove once no user references the avatar object
+// key anymore and no upload of it is pending, and reports whether it did.
+// remove runs under the lock of the key, so no reference or upload is taken
+// meanwhile; the drained counter row is removed after it succeeds.
+func (r *UserRepo) ReleaseAvatarRef(ctx context.Context, key string, remove func(context.Context) error) (bool, error) {
+	const op = "postgres.UserRepo.ReleaseAvatarRef"
+	ctx, span := r.tracer.Start(ctx, "UserRepo.ReleaseAvatarRef")
+	defer span.End()
+	span.SetAttributes(attribute.String("avatar.s3_key", key))
+	if remove == nil {
+		otelx.RecordSpanError(span, ErrNilFunc, "remove function cannot be nil")
+		return false, ErrNilFunc
+	}
+
+	var removed bool
+	err := postgres.WithTx(ctx, r.pool, func(ctx context.Context, tx pgx.Tx) error {
+		if err := lockAvatarKey(ctx, tx, key); err != nil {
+			otelx.RecordSpanError(span, err, "failed to lock avatar key")
+			return errorx.Wrap(err, op)
+		}
+
+		var referenced bool
+		err := tx.QueryRow(ctx, `
+			SELECT EXISTS (SELECT 1 FROM avatar_refs WHERE s3_key = $1 AND ref_count > 0)
+				OR EXISTS (
+					SELECT 1 FROM pending_uploads
+					WHERE s3_key = $1 AND created_at > now() - $2 * interval '1 second'
+				);
+		`, key, pendingUploadTTLSeconds).Scan(&referenced)
+		if err != nil {
+			otelx.RecordSpanError(span, err, "failed to check avatar references")
+			return errorx.Wrap(err, op)
+		}
+		if referenced {
+			return nil
+		}
+
+		if err := remove(ctx); err != nil {
+			otelx.RecordSpanError(span, err, "failed to remove avatar object")
+			return err
+		}
+		if _, err := tx.Exec(ctx, `DELETE FROM avatar_refs WHERE s3_key = $1 AND ref_count = 0;`, key); err != nil {
+			otelx.RecordSpanError(span, err, "failed to delete avatar references")
+			return errorx.Wrap(err, op)
+		}
+		removed = true
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return removed, nil
+}
+
+// avatarRefKey returns the storage key the user holds a reference to, if any.
+func avatarRefKey(dto UserDTO) string {
+	if dto.AvatarSource != avatars.SourceS3.String() {
+		return ""
+	}
+	return dto.AvatarS3Key
+}
+
+// syncAvatarRefs moves a user's avatar reference from oldKey to newKey.
+// Empty keys mean no reference.
+func syncAvatarRefs(ctx context.Context, tx pgx.Tx, oldKey, newKey string) error {
+	if oldKey == newKey {
+		return nil
+	}
+
+	if oldKey != "" {
+		_, err := tx.Exec(ctx, `
+			UPDATE avatar_refs SET ref_count = ref_count - 1, updated_at = now()
+			WHERE s3_key = $1 AND ref_count > 0;
+		`, oldKey)
+		if err != nil {
+			return err
+		}
+	}
+
+	if newKey != "" {
+		if err := lockAvatarKey(ctx, tx, newKey); err != nil {
+			return err
+		}
+		_, err := tx.Exec(ctx, `
+			INSERT INTO avatar_refs (s3_key, ref_count) VALUES ($1, 1)
+			ON CONFLICT (s3_key) DO UPDATE SET ref_count = avatar_refs.ref_count + 1, updated_at = now();
+		`, newKey)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,113 @@
+package postgres
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/validationstats"
+)
+
+type ValidationFailureRepo struct {
+	tracer trace.Tracer
+	logger *slog.Logger
+	pool   *pgxpool.Pool
+}
+
+// NewValidationFailureRepo creates a new ValidationFailureRepo.
+// It also sets default tracer and logger if they are nil.
+//
+//	WARNING; panics if pool is nil
+func NewValidationFailureRepo(pool *pgxpool.Pool, t trace.Tracer, l *slog.Logger) *ValidationFailureRepo {
+	if pool == nil {
+		panic("pgxpool.Pool is required")
+	}
+	if t == nil {
+		t = tracer
+	}
+	if l == nil {
+		l = logger
+	}
+
+	return &ValidationFailureRepo{
+		tracer: t,
+		logger: l,
+		pool:   pool,
+	}
+}
+
+// AddValidationFailures adds the counts of failures to the stored counters.
+func (r *ValidationFailureRepo) AddValidationFailures(ctx context.Context, failures []validationstats.Failure) error {
+	const op = "postgres.ValidationFailureRepo.AddValidationFailures"
+	ctx, span := r.tracer.Start(ctx, "ValidationFailureRepo.AddValidationFailures")
+	defer span.End()
+	span.SetAttributes(attribute.Int("validation_failures.count", len(failures)))
+
+	query := `
+		INSERT INTO validation_failures (day, route, field, count)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (day, route, field) DO UPDATE SET
+			count = validation_failures.count + EXCLUDED.count;
+	`
+
+	batch := &pgx.Batch{}
+	for _, f := range failures {
+		batch.Queue(query, f.Day, f.Route, f.Field, f.Count)
+	}
+
+	if err := r.pool.SendBatch(ctx, batch).Close(); err != nil {
+		otelx.RecordSpanError(span, err, "failed to add validation failures")
+		return errorx.Wrap(err, op)
+	}
+
+	return nil
+}
+
+// ListValidationStats sums the failures by route and field since
+// params.Since, the most failing fields first.
+func (r *ValidationFailureRepo) ListValidationStats(ctx context.Context, params validationstats.StatsParams) ([]validationstats.FieldStat, error) {
+	const op = "postgres.ValidationFailureRepo.ListValidationStats"
+	ctx, span := r.tracer.Start(ctx, "ValidationFailureRepo.ListValidationStats")
+	defer span.End()
+	otelx.SetSpanAttrs(span, map[string]any{
+		"params.route": params.Route,
+		"params.since": params.Since,
+		"params.limit": params.Limit,
+	})
+
+	rows, err := r.pool.Query(ctx, `
+		SELECT route, field, sum(count)::bigint
+		FROM validation_failures
+		WHERE day >= $1::date AND ($2 = '' OR route = $2)
+		GROUP BY route, field
+		ORDER BY 3 DESC, route, field
+		LIMIT $3;
+	`, params.Since, params.Route, params.Limit)
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to list validation stats")
+		return nil, errorx.Wrap(err, op)
+	}
+	defer rows.Close()
+
+	var stats []validationstats.FieldStat
+	for rows.Next() {
+		var s validationstats.FieldStat
+		if err := rows.Scan(&s.Route, &s.Field, &s.Count); err != nil {
+			otelx.RecordSpanError(span, err, "failed to scan validation stat")
+			return nil, errorx.Wrap(err, op)
+		}
+		stats = append(stats, s)
+	}
+	if err := rows.Err(); err != nil {
+		otelx.RecordSpanError(span, err, "failed to iterate validation stats")
+		return nil, errorx.Wrap(err, op)
+	}
+
+	return stats, nil
+}
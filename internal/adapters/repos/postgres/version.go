@@ -0,0 +1,47 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/event"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+)
+
+// ErrVersionConflict is an aggregate saved with events while another save
+// of it committed events since it was read, both stamped theirs with the
+// same versions.
+var ErrVersionConflict = errorx.NewConflict().WithDetails("the record was changed concurrently, retry")
+
+// versioned is an aggregate recording events, see event.Recorder.
+type versioned interface {
+	Version() int
+	GetUncommittedEvents() []event.Event
+}
+
+// saveVersion adds the uncommitted events of agg to the version stored in
+// the version column of table, in the transaction publishing them. agg must
+// have been read at the stored version, the rows of the aggregates read for
+// an update are locked so it only fails when another table of the aggregate
+// was locked instead, e.g. the staffs row of a staff member.
+func saveVersion(ctx context.Context, tx pgx.Tx, table string, id uuid.UUID, agg versioned) error {
+	n := len(agg.GetUncommittedEvents())
+	if n == 0 {
+		return nil
+	}
+
+	res, err := tx.Exec(ctx, fmt.Sprintf(`
+		UPDATE %s SET version = version + $3
+		WHERE id = $1 AND version = $2;
+	`, table), id, agg.Version(), n)
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return ErrVersionConflict
+	}
+	return nil
+}
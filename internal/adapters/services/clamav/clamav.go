@@ -0,0 +1,150 @@
+package clamav
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/storagex"
+)
+
+var tracer = otel.Tracer("ucms/internal/adapters/services/clamav")
+
+const (
+	// chunkSize must stay below clamd's StreamMaxLength, which defaults to 25 MB.
+	chunkSize = 64 * 1024
+
+	replyOK    = "OK"
+	replyFound = " FOUND"
+	replyError = " ERROR"
+)
+
+// Client talks to clamd over TCP using the INSTREAM command.
+// See https://docs.clamav.net/manual/Usage/Scanning.html#clamd
+type Client struct {
+	addr   string
+	tracer trace.Tracer
+	dialer net.Dialer
+}
+
+// NewClient returns a client for the clamd listening on addr, e.g. localhost:3310.
+func NewClient(addr string) *Client {
+	return &Client{addr: addr, tracer: tracer}
+}
+
+// Scan streams the content to clamd and reports its verdict. The context
+// bounds the whole exchange, including the time clamd needs to answer.
+func (c *Client) Scan(ctx context.Context, content io.Reader) (storagex.ScanResult, error) {
+	const op = "clamav.Client.Scan"
+	ctx, span := c.tracer.Start(ctx, "clamav.Client.Scan")
+	defer span.End()
+
+	reply, err := c.command(ctx, "zINSTREAM\x00", content)
+	if err != nil {
+		otelx.RecordSpanError(span, err, "clamd scan failed")
+		return storagex.ScanResult{}, errorx.Wrap(err, op)
+	}
+
+	// Replies look like "stream: OK" or "stream: Eicar-Signature FOUND".
+	verdict := strings.TrimPrefix(reply, "stream: ")
+	switch {
+	case verdict == replyOK:
+		return storagex.ScanResult{}, nil
+	case strings.HasSuffix(verdict, replyFound):
+		signature := strings.TrimSuffix(verdict, replyFound)
+		span.SetAttributes(attribute.String("clamav.signature", signature))
+		return storagex.ScanResult{Infected: true, Signature: signature}, nil
+	default:
+		err := fmt.Errorf("unexpected clamd reply %q", reply)
+		otelx.RecordSpanError(span, err, "clamd scan failed")
+		return storagex.ScanResult{}, errorx.Wrap(err, op)
+	}
+}
+
+// Ping checks that clamd is reachable.
+func (c *Client) Ping(ctx context.Context) error {
+	const op = "clamav.Client.Ping"
+	reply, err := c.command(ctx, "zPING\x00", nil)
+	if err != nil {
+		return errorx.Wrap(err, op)
+	}
+	if reply != "PONG" {
+		return errorx.Wrap(fmt.Errorf("unexpected clamd reply %q", reply), op)
+	}
+	return nil
+}
+
+func (c *Client) command(ctx context.Context, cmd string, stream io.Reader) (string, error) {
+	conn, err := c.dialer.DialContext(ctx, "tcp", c.addr)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+
+	// Unblock reads and writes once the context is done.
+	stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+	defer stop()
+
+	if _, err := io.WriteString(conn, cmd); err != nil {
+		return "", contextErr(ctx, err)
+	}
+	if stream != nil {
+		if err := writeChunks(conn, stream); err != nil {
+			return "", contextErr(ctx, err)
+		}
+	}
+
+	reply, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil && !(errors.Is(err, io.EOF) && reply != "") {
+		return "", contextErr(ctx, err)
+	}
+	reply = strings.TrimRight(reply, "\x00\n")
+	if strings.HasSuffix(reply, replyError) {
+		return "", fmt.Errorf("clamd error: %s", reply)
+	}
+
+	return reply, nil
+}
+
+// writeChunks sends the stream as length prefixed chunks terminated by an
+// empty chunk.
+func writeChunks(w io.Writer, r io.Reader) error {
+	buf := make([]byte, 4+chunkSize)
+	for {
+		n, err := io.ReadFull(r, buf[4:])
+		if n > 0 {
+			binary.BigEndian.PutUint32(buf[:4], uint32(n))
+			if _, werr := w.Write(buf[:4+n]); werr != nil {
+				return werr
+			}
+		}
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err := w.Write([]byte{0, 0, 0, 0})
+	return err
+}
+
+// contextErr prefers the context error over the error of the closed connection.
+func contextErr(ctx context.Context, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+	return err
+}
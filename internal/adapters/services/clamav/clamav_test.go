@@ -0,0 +1,65 @@
+package clamav
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/ucmsv2/ucms-backend/tests/mocks"
+)
+
+func TestClient_Scan(t *testing.T) {
+	t.Parallel()
+	server := mocks.NewClamdServer(t)
+	c := NewClient(server.Addr())
+
+	res, err := c.Scan(t.Context(), strings.NewReader("just a picture"))
+	require.NoError(t, err)
+	assert.False(t, res.Infected)
+
+	// Larger than a chunk, so the signature spans several INSTREAM chunks.
+	payload := append(bytes.Repeat([]byte{'a'}, chunkSize-10), []byte(mocks.EICAR)...)
+	res, err = c.Scan(t.Context(), bytes.NewReader(payload))
+	require.NoError(t, err)
+	assert.True(t, res.Infected)
+	assert.Equal(t, mocks.EICARSignature, res.Signature)
+
+	assert.Equal(t, 2, server.Scans())
+}
+
+func TestClient_ScanTimeout(t *testing.T) {
+	t.Parallel()
+	server := mocks.NewClamdServer(t)
+	server.SetHang(true)
+	c := NewClient(server.Addr())
+
+	ctx, cancel := context.WithTimeout(t.Context(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := c.Scan(ctx, strings.NewReader("just a picture"))
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 5*time.Second, "scan should be bounded by the context")
+}
+
+func TestClient_Unavailable(t *testing.T) {
+	t.Parallel()
+	server := mocks.NewClamdServer(t)
+	addr := server.Addr()
+	server.Close()
+
+	_, err := NewClient(addr).Scan(t.Context(), strings.NewReader("just a picture"))
+	assert.Error(t, err)
+}
+
+func TestClient_Ping(t *testing.T) {
+	t.Parallel()
+	server := mocks.NewClamdServer(t)
+
+	assert.NoError(t, NewClient(server.Addr()).Ping(t.Context()))
+}
@@ -0,0 +1,324 @@
+package fs
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/storagex"
+)
+
+const (
+	dirPerm  = 0o750
+	filePerm = 0o640
+
+	metaSuffix = ".meta.json"
+)
+
+// Storage keeps objects on the local filesystem. It is meant for single VM
+// deployments where running MinIO is not worth it.
+//
+// Keys are never used as paths directly: an object is stored under
+// <root>/<h[0:2]>/<h[2:4]>/<h> where h is the hex encoded SHA-256 of the key,
+// next to a JSON sidecar holding its metadata.
+type Storage struct {
+	root    string
+	baseURL string
+	// signingKey signs the URLs of PresignGet, see WithSigningKey.
+	signingKey []byte
+	now        func() time.Time
+}
+
+type metadata struct {
+	Key          string    `json:"key"`
+	ContentType  string    `json:"content_type"`
+	Size         int64     `json:"size"`
+	LastModified time.Time `json:"last_modified"`
+}
+
+// NewStorage creates the root directory if needed. baseURL is the public
+// prefix the files are served under, e.g. http://localhost:8080/v1/files.
+func NewStorage(root, baseURL string) (*Storage, error) {
+	const op = "fs.NewStorage"
+	if root == "" {
+		return nil, errorx.Wrap(errors.New("storage root is required"), op)
+	}
+
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		return nil, errorx.Wrap(err, op)
+	}
+	if err := os.MkdirAll(abs, dirPerm); err != nil {
+		return nil, errorx.Wrap(err, op)
+	}
+
+	return &Storage{
+		root:    abs,
+		baseURL: strings.TrimRight(baseURL, "/"),
+		now:     time.Now,
+	}, nil
+}
+
+func (s *Storage) UploadFile(ctx context.Context, key string, file io.Reader, contentType string) error {
+	const op = "fs.Storage.UploadFile"
+	path, err := s.path(key)
+	if err != nil {
+		return errorx.Wrap(err, op)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), dirPerm); err != nil {
+		return errorx.Wrap(err, op)
+	}
+
+	size, err := writeAtomic(path, func(w io.Writer) (int64, error) {
+		return io.Copy(w, &ctxReader{ctx: ctx, r: file})
+	})
+	if err != nil {
+		return errorx.Wrap(err, op)
+	}
+
+	meta, err := json.Marshal(metadata{
+		Key:          key,
+		ContentType:  contentType,
+		Size:         size,
+		LastModified: time.Now().UTC(),
+	})
+	if err != nil {
+		return errorx.Wrap(err, op)
+	}
+	_, err = writeAtomic(path+metaSuffix, func(w io.Writer) (int64, error) {
+		n, err := w.Write(meta)
+		return int64(n), err
+	})
+	return errorx.Wrap(err, op)
+}
+
+func (s *Storage) DeleteFile(ctx context.Context, key string) error {
+	const op = "fs.Storage.DeleteFile"
+	path, err := s.path(key)
+	if err != nil {
+		return errorx.Wrap(err, op)
+	}
+
+	// Mirror S3 semantics: deleting a missing object is not an error.
+	for _, p := range []string{path, path + metaSuffix} {
+		if err := os.Remove(p); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return errorx.Wrap(err, op)
+		}
+	}
+
+	return nil
+}
+
+func (s *Storage) GetObject(ctx context.Context, key string) ([]byte, error) {
+	const op = "fs.Storage.GetObject"
+	body, _, err := s.OpenObject(ctx, key)
+	if err != nil {
+		return nil, errorx.Wrap(err, op)
+	}
+	defer func() {
+		if cerr := body.Close(); cerr != nil {
+			slog.Warn("failed to close file", slog.String("error", cerr.Error()))
+		}
+	}()
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, errorx.Wrap(err, op)
+	}
+
+	return data, nil
+}
+
+// OpenObject returns the object content and its metadata. The caller must close the body.
+func (s *Storage) OpenObject(ctx context.Context, key string) (io.ReadCloser, storagex.ObjectInfo, error) {
+	const op = "fs.Storage.OpenObject"
+	info, err := s.HeadObject(ctx, key)
+	if err != nil {
+		return nil, storagex.ObjectInfo{}, errorx.Wrap(err, op)
+	}
+
+	path, err := s.path(key)
+	if err != nil {
+		return nil, storagex.ObjectInfo{}, errorx.Wrap(err, op)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, storagex.ObjectInfo{}, errorx.Wrap(storagex.ErrObjectNotFound, op)
+		}
+		return nil, storagex.ObjectInfo{}, errorx.Wrap(err, op)
+	}
+
+	return f, info, nil
+}
+
+func (s *Storage) HeadObject(ctx context.Context, key string) (storagex.ObjectInfo, error) {
+	const op = "fs.Storage.HeadObject"
+	path, err := s.path(key)
+	if err != nil {
+		return storagex.ObjectInfo{}, errorx.Wrap(err, op)
+	}
+
+	raw, err := os.ReadFile(path + metaSuffix)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return storagex.ObjectInfo{}, errorx.Wrap(storagex.ErrObjectNotFound, op)
+		}
+		return storagex.ObjectInfo{}, errorx.Wrap(err, op)
+	}
+
+	var meta metadata
+	if err := json.Unmarshal(raw, &meta); err != nil {
+		return storagex.ObjectInfo{}, errorx.Wrap(err, op)
+	}
+	if meta.Key != key {
+		// SHA-256 collision or tampered sidecar, either way not the requested object.
+		return storagex.ObjectInfo{}, errorx.Wrap(storagex.ErrObjectNotFound, op)
+	}
+
+	return storagex.ObjectInfo{
+		Key:          meta.Key,
+		ContentType:  meta.ContentType,
+		Size:         meta.Size,
+		LastModified: meta.LastModified,
+	}, nil
+}
+
+// ListObjects returns up to limit objects whose key starts with prefix, in
+// key order. The returned token is the last key of the page and is empty
+// when there are no more objects.
+//
+// The layout is hashed, so every call walks the whole tree. That is fine for
+// the small deployments this backend is meant for.
+func (s *Storage) ListObjects(ctx context.Context, prefix, token string, limit int) ([]storagex.ObjectInfo, string, error) {
+	const op = "fs.Storage.ListObjects"
+	if limit <= 0 {
+		limit = 1000
+	}
+
+	var objects []storagex.ObjectInfo
+	err := filepath.WalkDir(s.root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.HasSuffix(path, metaSuffix) {
+			return nil
+		}
+
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil // deleted while walking
+			}
+			return err
+		}
+		var meta metadata
+		if err := json.Unmarshal(raw, &meta); err != nil {
+			slog.WarnContext(ctx, "skipping corrupted metadata file", slog.String("path", path), slog.String("error", err.Error()))
+			return nil
+		}
+		if !strings.HasPrefix(meta.Key, prefix) || meta.Key <= token {
+			return nil
+		}
+
+		objects = append(objects, storagex.ObjectInfo{
+			Key:          meta.Key,
+			ContentType:  meta.ContentType,
+			Size:         meta.Size,
+			LastModified: meta.LastModified,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, "", errorx.Wrap(err, op)
+	}
+
+	slices.SortFunc(objects, func(a, b storagex.ObjectInfo) int {
+		return strings.Compare(a.Key, b.Key)
+	})
+	if len(objects) <= limit {
+		return objects, "", nil
+	}
+
+	objects = objects[:limit]
+	return objects, objects[limit-1].Key, nil
+}
+
+// URL returns the public URL the object is served under.
+func (s *Storage) URL(key string) string {
+	return s.baseURL + "/" + key
+}
+
+func (s *Storage) Root() string {
+	return s.root
+}
+
+func (s *Storage) path(key string) (string, error) {
+	if err := storagex.ValidateKey(key); err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256([]byte(key))
+	name := hex.EncodeToString(sum[:])
+	path := filepath.Join(s.root, name[0:2], name[2:4], name)
+
+	// Defense in depth, the hashed name can not contain separators.
+	if !strings.HasPrefix(path, s.root+string(filepath.Separator)) {
+		return "", storagex.ErrInvalidKey
+	}
+
+	return path, nil
+}
+
+// writeAtomic writes into a temporary file in the target directory and renames
+// it into place, so readers never observe a partially written object.
+func writeAtomic(path string, write func(io.Writer) (int64, error)) (int64, error) {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = os.Remove(tmp.Name())
+	}()
+
+	n, err := write(tmp)
+	if err != nil {
+		_ = tmp.Close()
+		return 0, err
+	}
+	if err := tmp.Chmod(filePerm); err != nil {
+		_ = tmp.Close()
+		return 0, err
+	}
+	if err := tmp.Close(); err != nil {
+		return 0, err
+	}
+
+	return n, os.Rename(tmp.Name(), path)
+}
+
+type ctxReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r *ctxReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
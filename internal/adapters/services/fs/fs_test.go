@@ -0,0 +1,178 @@
+package fs
+
+import (
+	"bytes"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/storagex"
+)
+
+func newTestStorage(t *testing.T) *Storage {
+	t.Helper()
+
+	s, err := NewStorage(t.TempDir(), "http://localhost:8080/v1/files/")
+	require.NoError(t, err)
+	return s
+}
+
+func TestStorage_RoundTrip(t *testing.T) {
+	s := newTestStorage(t)
+	key := "avatars/user-1/1700000000000"
+	content := []byte("not really a jpeg")
+
+	err := s.UploadFile(t.Context(), key, bytes.NewReader(content), "image/jpeg")
+	require.NoError(t, err)
+
+	info, err := s.HeadObject(t.Context(), key)
+	require.NoError(t, err)
+	assert.Equal(t, key, info.Key)
+	assert.Equal(t, "image/jpeg", info.ContentType)
+	assert.Equal(t, int64(len(content)), info.Size)
+	assert.False(t, info.LastModified.IsZero())
+
+	data, err := s.GetObject(t.Context(), key)
+	require.NoError(t, err)
+	assert.Equal(t, content, data)
+
+	body, info, err := s.OpenObject(t.Context(), key)
+	require.NoError(t, err)
+	got, err := io.ReadAll(body)
+	require.NoError(t, err)
+	require.NoError(t, body.Close())
+	assert.Equal(t, content, got)
+	assert.Equal(t, "image/jpeg", info.ContentType)
+
+	assert.Equal(t, "http://localhost:8080/v1/files/"+key, s.URL(key))
+
+	require.NoError(t, s.DeleteFile(t.Context(), key))
+	_, err = s.HeadObject(t.Context(), key)
+	assert.True(t, errorx.IsNotFound(err), "expected not found after delete, got %v", err)
+
+	// Deleting twice is fine, the same as with S3.
+	require.NoError(t, s.DeleteFile(t.Context(), key))
+}
+
+func TestStorage_ShardedLayout(t *testing.T) {
+	s := newTestStorage(t)
+	key := "avatars/user-1/1"
+
+	require.NoError(t, s.UploadFile(t.Context(), key, strings.NewReader("data"), "image/png"))
+
+	path, err := s.path(key)
+	require.NoError(t, err)
+	rel, err := filepath.Rel(s.Root(), path)
+	require.NoError(t, err)
+
+	parts := strings.Split(rel, string(filepath.Separator))
+	require.Len(t, parts, 3)
+	assert.Len(t, parts[0], 2)
+	assert.Len(t, parts[1], 2)
+	assert.True(t, strings.HasPrefix(parts[2], parts[0]+parts[1]))
+
+	_, err = os.Stat(path + metaSuffix)
+	assert.NoError(t, err, "metadata sidecar should exist")
+}
+
+func TestStorage_Overwrite(t *testing.T) {
+	s := newTestStorage(t)
+	key := "avatars/user-1/1"
+
+	require.NoError(t, s.UploadFile(t.Context(), key, strings.NewReader("first"), "image/png"))
+	require.NoError(t, s.UploadFile(t.Context(), key, strings.NewReader("second!"), "image/webp"))
+
+	data, err := s.GetObject(t.Context(), key)
+	require.NoError(t, err)
+	assert.Equal(t, "second!", string(data))
+
+	info, err := s.HeadObject(t.Context(), key)
+	require.NoError(t, err)
+	assert.Equal(t, "image/webp", info.ContentType)
+	assert.Equal(t, int64(7), info.Size)
+}
+
+func TestStorage_NotFound(t *testing.T) {
+	s := newTestStorage(t)
+
+	_, err := s.GetObject(t.Context(), "avatars/missing")
+	assert.True(t, errorx.IsNotFound(err))
+
+	_, _, err = s.OpenObject(t.Context(), "avatars/missing")
+	assert.True(t, errorx.IsNotFound(err))
+}
+
+func TestStorage_RejectsUnsafeKeys(t *testing.T) {
+	s := newTestStorage(t)
+
+	keys := []string{
+		"",
+		"../../etc/passwd",
+		"avatars/../../etc/passwd",
+		"/etc/passwd",
+		"avatars/./user",
+		"avatars//user",
+		"avatars/",
+		`..\..\windows\win.ini`,
+		"avatars/user\x00.jpg",
+		"avatars/user\n1",
+		strings.Repeat("a", storagex.MaxKeyLength+1),
+	}
+
+	for _, key := range keys {
+		t.Run(key, func(t *testing.T) {
+			err := s.UploadFile(t.Context(), key, strings.NewReader("data"), "text/plain")
+			require.ErrorIs(t, err, storagex.ErrInvalidKey)
+
+			_, err = s.GetObject(t.Context(), key)
+			require.ErrorIs(t, err, storagex.ErrInvalidKey)
+
+			err = s.DeleteFile(t.Context(), key)
+			require.ErrorIs(t, err, storagex.ErrInvalidKey)
+		})
+	}
+
+	entries, err := os.ReadDir(s.Root())
+	require.NoError(t, err)
+	assert.Empty(t, entries, "nothing should be written for rejected keys")
+}
+
+func TestStorage_PresignGet(t *testing.T) {
+	s := newTestStorage(t)
+	key := "archives/error_events/2025-03-10.ndjson.gz"
+
+	_, err := s.PresignGet(t.Context(), key, time.Minute)
+	require.Error(t, err, "presigning needs a signing key")
+
+	s.WithSigningKey([]byte("test-signing-key"))
+	raw, err := s.PresignGet(t.Context(), key, time.Minute)
+	require.NoError(t, err)
+	u, err := url.Parse(raw)
+	require.NoError(t, err)
+	assert.Equal(t, "/v1/files/"+key, u.Path)
+	require.NoError(t, s.VerifySignature(key, u.Query()))
+
+	assert.ErrorIs(t, s.VerifySignature("archives/impersonations/2025-03-10.ndjson.gz", u.Query()), ErrInvalidSignature,
+		"the signature is bound to the key")
+
+	tampered := u.Query()
+	tampered.Set("expires", strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10))
+	assert.ErrorIs(t, s.VerifySignature(key, tampered), ErrInvalidSignature, "the signature is bound to the expiry")
+
+	assert.ErrorIs(t, s.VerifySignature(key, url.Values{}), ErrInvalidSignature)
+
+	expired, err := s.PresignGet(t.Context(), key, -time.Second)
+	require.NoError(t, err)
+	u, err = url.Parse(expired)
+	require.NoError(t, err)
+	assert.ErrorIs(t, s.VerifySignature(key, u.Query()), ErrInvalidSignature)
+}
@@ -0,0 +1,116 @@
+package hibp
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/httpclient"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx"
+)
+
+var tracer = otel.Tracer("ucms/internal/adapters/services/hibp")
+
+const (
+	// DefaultBaseURL is the Pwned Passwords API.
+	DefaultBaseURL = "https://api.pwnedpasswords.com"
+	// DefaultTimeout bounds a range request, the sign up is not held up
+	// by a slow API.
+	DefaultTimeout = 800 * time.Millisecond
+
+	prefixLen = 5
+	// maxResponseSize caps a range response, a few hundred suffixes and the
+	// padding take some 40KB.
+	maxResponseSize = 1 << 20
+)
+
+// Client checks passwords against a Pwned Passwords style range API with
+// k-anonymity: only the first 5 hex characters of the SHA-1 of a password are
+// sent, the matching suffixes are compared locally.
+// See https://haveibeenpwned.com/API/v3#PwnedPasswords
+type Client struct {
+	baseURL string
+	http    *http.Client
+	tracer  trace.Tracer
+}
+
+type ClientArgs struct {
+	// BaseURL defaults to DefaultBaseURL.
+	BaseURL string
+	// Timeout defaults to DefaultTimeout.
+	Timeout time.Duration
+}
+
+func NewClient(args ClientArgs) *Client {
+	if args.BaseURL == "" {
+		args.BaseURL = DefaultBaseURL
+	}
+	if args.Timeout <= 0 {
+		args.Timeout = DefaultTimeout
+	}
+
+	return &Client{
+		baseURL: strings.TrimRight(args.BaseURL, "/"),
+		http:    httpclient.New("hibp", httpclient.WithTimeout(args.Timeout), httpclient.WithMaxResponseSize(maxResponseSize)),
+		tracer:  tracer,
+	}
+}
+
+// IsBreached reports whether password appears in the breaches known to the
+// API. The errors are returned as they are, the user.PasswordPolicy fails
+// open on them.
+func (c *Client) IsBreached(ctx context.Context, password string) (bool, error) {
+	const op = "hibp.Client.IsBreached"
+	ctx, span := c.tracer.Start(ctx, "hibp.Client.IsBreached")
+	defer span.End()
+
+	// The range API is keyed by SHA-1, it is not used for storage.
+	sum := sha1.Sum([]byte(password))
+	digest := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := digest[:prefixLen], digest[prefixLen:]
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/range/"+prefix, nil)
+	if err != nil {
+		return false, errorx.Wrap(err, op)
+	}
+	// The padding hides the number of suffixes of the prefix from observers.
+	req.Header.Set("Add-Padding", "true")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		otelx.RecordSpanError(span, err, "range request failed")
+		return false, errorx.Wrap(err, op)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		err := fmt.Errorf("unexpected status %d", resp.StatusCode)
+		otelx.RecordSpanError(span, err, "range request failed")
+		return false, errorx.Wrap(err, op)
+	}
+
+	// Lines look like "0018A45C4D1DEF81644B54AB7F969B88D65:21", the padding
+	// lines have a count of 0.
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		hashSuffix, count, ok := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		if ok && strings.EqualFold(hashSuffix, suffix) && count != "0" {
+			return true, nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		otelx.RecordSpanError(span, err, "failed to read range response")
+		return false, errorx.Wrap(err, op)
+	}
+
+	return false, nil
+}
@@ -0,0 +1,82 @@
+package hibp
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// The SHA-1 of "P@ssw0rd" is 21BD12DC183F740EE76F27B78EB39C8AD972A757.
+const (
+	breachedPrefix = "21BD1"
+	breachedSuffix = "2DC183F740EE76F27B78EB39C8AD972A757"
+)
+
+func newRangeServer(t *testing.T, handler http.HandlerFunc) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestClient_IsBreached(t *testing.T) {
+	t.Parallel()
+	var gotPath, gotPadding string
+	server := newRangeServer(t, func(w http.ResponseWriter, r *http.Request) {
+		gotPath, gotPadding = r.URL.Path, r.Header.Get("Add-Padding")
+		fmt.Fprintf(w, "0018A45C4D1DEF81644B54AB7F969B88D65:21\r\n%s:3\r\nFFFFF45C4D1DEF81644B54AB7F969B88D65:0\r\n", breachedSuffix)
+	})
+	c := NewClient(ClientArgs{BaseURL: server.URL})
+
+	breached, err := c.IsBreached(t.Context(), "P@ssw0rd")
+	require.NoError(t, err)
+	assert.True(t, breached)
+	assert.Equal(t, "/range/"+breachedPrefix, gotPath, "only the prefix is sent")
+	assert.Equal(t, "true", gotPadding)
+
+	breached, err = c.IsBreached(t.Context(), "An0ther!Passw0rd")
+	require.NoError(t, err)
+	assert.False(t, breached)
+}
+
+func TestClient_IsBreached_PaddingIsNotABreach(t *testing.T) {
+	t.Parallel()
+	server := newRangeServer(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s:0\r\n", breachedSuffix)
+	})
+
+	breached, err := NewClient(ClientArgs{BaseURL: server.URL}).IsBreached(t.Context(), "P@ssw0rd")
+	require.NoError(t, err)
+	assert.False(t, breached)
+}
+
+func TestClient_IsBreached_Timeout(t *testing.T) {
+	t.Parallel()
+	server := newRangeServer(t, func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	})
+	c := NewClient(ClientArgs{BaseURL: server.URL, Timeout: 50 * time.Millisecond})
+
+	start := time.Now()
+	_, err := c.IsBreached(t.Context(), "P@ssw0rd")
+	assert.Error(t, err)
+	assert.Less(t, time.Since(start), 2*time.Second, "the request should be bounded by the timeout")
+}
+
+func TestClient_IsBreached_UnexpectedStatus(t *testing.T) {
+	t.Parallel()
+	server := newRangeServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+
+	_, err := NewClient(ClientArgs{BaseURL: server.URL}).IsBreached(t.Context(), "P@ssw0rd")
+	assert.ErrorContains(t, err, "unexpected status 429")
+}
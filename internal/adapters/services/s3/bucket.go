@@ -0,0 +1,233 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	awsv2 "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/smithy-go"
+	"github.com/google/uuid"
+
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/storagex"
+)
+
+const (
+	// TmpLifecycleRuleID identifies the rule expiring objects under storagex.TmpKeyPrefix.
+	TmpLifecycleRuleID = "ucms-expire-tmp"
+	tmpExpirationDays  = 1
+
+	selfTestPrefix = storagex.TmpKeyPrefix + "selftest/"
+)
+
+type EnsureBucketOptions struct {
+	// Bootstrap creates the bucket when it is missing and applies the
+	// lifecycle rules. Disable it when the credentials are not allowed to
+	// manage the bucket, the bucket then has to exist already.
+	Bootstrap bool
+}
+
+// BucketInfo describes the bucket as observed after EnsureBucket.
+type BucketInfo struct {
+	Name    string
+	Region  string
+	Created bool
+	// Policy is the bucket policy document, empty when there is none or it can not be read.
+	Policy string
+	// TmpExpirationDays is the expiration of the tmp/ lifecycle rule, 0 when the rule is missing.
+	TmpExpirationDays int32
+}
+
+// EnsureBucket makes sure the bucket exists and, with Bootstrap enabled,
+// that temporary objects expire. It is meant to be called once at startup.
+func (c *Client) EnsureBucket(ctx context.Context, opts EnsureBucketOptions) (_ BucketInfo, err error) {
+	const op = "s3.Client.EnsureBucket"
+	ctx, o := c.startOperation(ctx, "EnsureBucket", "")
+	defer func() { o.end(ctx, err) }()
+	info := BucketInfo{Name: c.bucket}
+
+	exists, err := c.bucketExists(ctx)
+	if err != nil {
+		return info, errorx.Wrap(err, op)
+	}
+	if !exists {
+		if !opts.Bootstrap {
+			return info, errorx.Wrap(fmt.Errorf("bucket %q does not exist and bootstrap is disabled", c.bucket), op)
+		}
+		if err := c.createBucket(ctx); err != nil {
+			return info, errorx.Wrap(err, op)
+		}
+		info.Created = true
+	}
+
+	if opts.Bootstrap {
+		if err := c.ensureTmpLifecycleRule(ctx); err != nil {
+			return info, errorx.Wrap(err, op)
+		}
+	}
+
+	if info.Region, err = c.bucketRegion(ctx); err != nil {
+		return info, errorx.Wrap(err, op)
+	}
+	// Reading the policy and lifecycle is informational only, restricted
+	// credentials may not be allowed to.
+	info.Policy, _ = c.bucketPolicy(ctx)
+	if rules, err := c.lifecycleRules(ctx); err == nil {
+		for _, rule := range rules {
+			if aws.StringValue(rule.ID) == TmpLifecycleRuleID && rule.Expiration != nil {
+				info.TmpExpirationDays = aws.Int32Value(rule.Expiration.Days)
+			}
+		}
+	}
+
+	return info, nil
+}
+
+// SelfTest writes, reads back and deletes a small object to verify the
+// credentials have read/write access to the bucket.
+func (c *Client) SelfTest(ctx context.Context) (err error) {
+	const op = "s3.Client.SelfTest"
+	ctx, o := c.startOperation(ctx, "SelfTest", "")
+	defer func() { o.end(ctx, err) }()
+	key := selfTestPrefix + uuid.NewString()
+	payload := []byte("ucms storage self-test " + key)
+
+	if err := c.UploadFile(ctx, key, bytes.NewReader(payload), "text/plain"); err != nil {
+		return errorx.Wrap(fmt.Errorf("write: %w", err), op)
+	}
+	// Remove the object even if reading it back fails, the tmp/ lifecycle
+	// rule is only a safety net.
+	defer func() { _ = c.DeleteFile(context.WithoutCancel(ctx), key) }()
+
+	body, _, err := c.OpenObject(ctx, key)
+	if err != nil {
+		return errorx.Wrap(fmt.Errorf("read: %w", err), op)
+	}
+	defer body.Close()
+
+	got, err := io.ReadAll(body)
+	if err != nil {
+		return errorx.Wrap(fmt.Errorf("read: %w", err), op)
+	}
+	if !bytes.Equal(got, payload) {
+		return errorx.Wrap(errors.New("read back content does not match"), op)
+	}
+
+	if err := c.DeleteFile(ctx, key); err != nil {
+		return errorx.Wrap(fmt.Errorf("delete: %w", err), op)
+	}
+
+	return nil
+}
+
+func (c *Client) bucketExists(ctx context.Context) (bool, error) {
+	_, err := c.s3Client.HeadBucket(ctx, &s3.HeadBucketInput{Bucket: aws.String(c.bucket)})
+	if err == nil {
+		return true, nil
+	}
+
+	var notFound *types.NotFound
+	var noSuchBucket *types.NoSuchBucket
+	if errors.As(err, &notFound) || errors.As(err, &noSuchBucket) {
+		return false, nil
+	}
+	return false, err
+}
+
+func (c *Client) createBucket(ctx context.Context) error {
+	input := &s3.CreateBucketInput{Bucket: aws.String(c.bucket)}
+	// us-east-1 is the default location and must not be sent as a constraint.
+	if c.region != "" && c.region != "us-east-1" {
+		input.CreateBucketConfiguration = &types.CreateBucketConfiguration{
+			LocationConstraint: types.BucketLocationConstraint(c.region),
+		}
+	}
+
+	_, err := c.s3Client.CreateBucket(ctx, input)
+	var owned *types.BucketAlreadyOwnedByYou
+	if errors.As(err, &owned) {
+		// Another instance created it concurrently.
+		return nil
+	}
+	return err
+}
+
+// ensureTmpLifecycleRule adds or updates our rule while keeping the rules
+// that were configured outside of the application.
+func (c *Client) ensureTmpLifecycleRule(ctx context.Context) error {
+	rules, err := c.lifecycleRules(ctx)
+	if err != nil {
+		return err
+	}
+
+	rule := types.LifecycleRule{
+		ID:         aws.String(TmpLifecycleRuleID),
+		Status:     types.ExpirationStatusEnabled,
+		Filter:     &types.LifecycleRuleFilter{Prefix: aws.String(storagex.TmpKeyPrefix)},
+		Expiration: &types.LifecycleExpiration{Days: awsv2.Int32(tmpExpirationDays)},
+	}
+
+	merged := make([]types.LifecycleRule, 0, len(rules)+1)
+	for _, r := range rules {
+		if aws.StringValue(r.ID) != TmpLifecycleRuleID {
+			merged = append(merged, r)
+		}
+	}
+	merged = append(merged, rule)
+
+	_, err = c.s3Client.PutBucketLifecycleConfiguration(ctx, &s3.PutBucketLifecycleConfigurationInput{
+		Bucket:                 aws.String(c.bucket),
+		LifecycleConfiguration: &types.BucketLifecycleConfiguration{Rules: merged},
+	})
+	return err
+}
+
+func (c *Client) lifecycleRules(ctx context.Context) ([]types.LifecycleRule, error) {
+	out, err := c.s3Client.GetBucketLifecycleConfiguration(ctx, &s3.GetBucketLifecycleConfigurationInput{
+		Bucket: aws.String(c.bucket),
+	})
+	if err != nil {
+		if apiErrorCode(err) == "NoSuchLifecycleConfiguration" {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return out.Rules, nil
+}
+
+func (c *Client) bucketRegion(ctx context.Context) (string, error) {
+	out, err := c.s3Client.GetBucketLocation(ctx, &s3.GetBucketLocationInput{Bucket: aws.String(c.bucket)})
+	if err != nil {
+		return "", err
+	}
+	if out.LocationConstraint == "" {
+		return "us-east-1", nil
+	}
+	return string(out.LocationConstraint), nil
+}
+
+func (c *Client) bucketPolicy(ctx context.Context) (string, error) {
+	out, err := c.s3Client.GetBucketPolicy(ctx, &s3.GetBucketPolicyInput{Bucket: aws.String(c.bucket)})
+	if err != nil {
+		if apiErrorCode(err) == "NoSuchBucketPolicy" {
+			return "", nil
+		}
+		return "", err
+	}
+	return strings.TrimSpace(aws.StringValue(out.Policy)), nil
+}
+
+func apiErrorCode(err error) string {
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.ErrorCode()
+	}
+	return ""
+}
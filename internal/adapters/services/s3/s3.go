@@ -2,20 +2,31 @@ package s3
 
 import (
 	"context"
+	"errors"
 	"io"
 	"log/slog"
+	"strings"
+	"time"
 
+	awsv2 "github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 	"github.com/aws/aws-sdk-go/aws"
+	"go.opentelemetry.io/otel/attribute"
 
 	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/storagex"
 )
 
 type Client struct {
 	s3Client *s3.Client
 	bucket   string
+	region   string
+	baseURL  string
+
+	telemetry telemetry
 }
 
 func NewClient(ctx context.Context, endpoint, accessKey, secretKey, bucket, region string) (*Client, error) {
@@ -33,13 +44,21 @@ func NewClient(ctx context.Context, endpoint, accessKey, secretKey, bucket, regi
 		s3Client: s3.NewFromConfig(cfg, func(o *s3.Options) {
 			o.UsePathStyle = true // Required for MinIO
 		}),
-		bucket: bucket,
+		bucket:    bucket,
+		region:    region,
+		telemetry: newTelemetry(TelemetryOptions{}),
 	}, nil
 }
 
-func (c *Client) UploadFile(ctx context.Context, key string, file io.Reader, contentType string) error {
+func (c *Client) UploadFile(ctx context.Context, key string, file io.Reader, contentType string) (err error) {
 	const op = "s3.Client.UploadFile"
-	_, err := c.s3Client.PutObject(ctx, &s3.PutObjectInput{
+	ctx, o := c.startOperation(ctx, "PutObject", key)
+	defer func() { o.end(ctx, err) }()
+	if l, ok := file.(interface{ Len() int }); ok {
+		o.setBytes(int64(l.Len()))
+	}
+
+	_, err = c.s3Client.PutObject(ctx, &s3.PutObjectInput{
 		Bucket:      aws.String(c.bucket),
 		Key:         aws.String(key),
 		Body:        file,
@@ -50,17 +69,23 @@ func (c *Client) UploadFile(ctx context.Context, key string, file io.Reader, con
 	return errorx.Wrap(err, op)
 }
 
-func (c *Client) DeleteFile(ctx context.Context, key string) error {
+func (c *Client) DeleteFile(ctx context.Context, key string) (err error) {
 	const op = "s3.Client.DeleteFile"
-	_, err := c.s3Client.DeleteObject(ctx, &s3.DeleteObjectInput{
+	ctx, o := c.startOperation(ctx, "DeleteObject", key)
+	defer func() { o.end(ctx, err) }()
+
+	_, err = c.s3Client.DeleteObject(ctx, &s3.DeleteObjectInput{
 		Bucket: aws.String(c.bucket),
 		Key:    aws.String(key),
 	})
 	return errorx.Wrap(err, op)
 }
 
-func (c *Client) GetObject(ctx context.Context, key string) ([]byte, error) {
+func (c *Client) GetObject(ctx context.Context, key string) (_ []byte, err error) {
 	const op = "s3.Client.GetObject"
+	ctx, o := c.startOperation(ctx, "GetObject", key)
+	defer func() { o.end(ctx, err) }()
+
 	output, err := c.s3Client.GetObject(ctx, &s3.GetObjectInput{
 		Bucket: aws.String(c.bucket),
 		Key:    aws.String(key),
@@ -79,13 +104,142 @@ func (c *Client) GetObject(ctx context.Context, key string) ([]byte, error) {
 	if err != nil {
 		return nil, errorx.Wrap(err, op)
 	}
+	o.setBytes(int64(len(data)))
 
 	return data, nil
 }
 
-func (c *Client) CreateBucket(ctx context.Context) error {
+// OpenObject returns the object body and its metadata. The caller must close the body.
+// The span ends once the object is opened, reading the body is not part of it.
+func (c *Client) OpenObject(ctx context.Context, key string) (_ io.ReadCloser, _ storagex.ObjectInfo, err error) {
+	const op = "s3.Client.OpenObject"
+	ctx, o := c.startOperation(ctx, "GetObject", key)
+	defer func() { o.end(ctx, err) }()
+
+	output, err := c.s3Client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(c.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, storagex.ObjectInfo{}, errorx.Wrap(classifyNotFound(err), op)
+	}
+	o.setBytes(aws.Int64Value(output.ContentLength))
+
+	return output.Body, storagex.ObjectInfo{
+		Key:          key,
+		ContentType:  aws.StringValue(output.ContentType),
+		Size:         aws.Int64Value(output.ContentLength),
+		LastModified: aws.TimeValue(output.LastModified),
+	}, nil
+}
+
+func (c *Client) HeadObject(ctx context.Context, key string) (_ storagex.ObjectInfo, err error) {
+	const op = "s3.Client.HeadObject"
+	ctx, o := c.startOperation(ctx, "HeadObject", key)
+	defer func() { o.end(ctx, err) }()
+
+	output, err := c.s3Client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(c.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return storagex.ObjectInfo{}, errorx.Wrap(classifyNotFound(err), op)
+	}
+	o.setBytes(aws.Int64Value(output.ContentLength))
+
+	return storagex.ObjectInfo{
+		Key:          key,
+		ContentType:  aws.StringValue(output.ContentType),
+		Size:         aws.Int64Value(output.ContentLength),
+		LastModified: aws.TimeValue(output.LastModified),
+	}, nil
+}
+
+// ListObjects returns up to limit objects under prefix, and the token to
+// pass for the next page. The token is empty on the last page.
+func (c *Client) ListObjects(ctx context.Context, prefix, token string, limit int) (_ []storagex.ObjectInfo, _ string, err error) {
+	const op = "s3.Client.ListObjects"
+	ctx, o := c.startOperation(ctx, "ListObjectsV2", "")
+	defer func() { o.end(ctx, err) }()
+	o.span.SetAttributes(attribute.String("s3.prefix", prefix))
+
+	input := &s3.ListObjectsV2Input{
+		Bucket:  aws.String(c.bucket),
+		Prefix:  aws.String(prefix),
+		MaxKeys: awsv2.Int32(int32(limit)),
+	}
+	if token != "" {
+		input.ContinuationToken = aws.String(token)
+	}
+
+	output, err := c.s3Client.ListObjectsV2(ctx, input)
+	if err != nil {
+		return nil, "", errorx.Wrap(err, op)
+	}
+
+	objects := make([]storagex.ObjectInfo, 0, len(output.Contents))
+	for _, obj := range output.Contents {
+		objects = append(objects, storagex.ObjectInfo{
+			Key:          aws.StringValue(obj.Key),
+			Size:         aws.Int64Value(obj.Size),
+			LastModified: aws.TimeValue(obj.LastModified),
+		})
+	}
+
+	o.span.SetAttributes(attribute.Int("s3.objects", len(objects)))
+
+	var next string
+	if output.IsTruncated != nil && *output.IsTruncated {
+		next = aws.StringValue(output.NextContinuationToken)
+	}
+
+	return objects, next, nil
+}
+
+// PresignGet returns a GET URL for the object that is valid for expiry,
+// for buckets without anonymous read access.
+func (c *Client) PresignGet(ctx context.Context, key string, expiry time.Duration) (_ string, err error) {
+	const op = "s3.Client.PresignGet"
+	ctx, o := c.startOperation(ctx, "PresignGetObject", key)
+	defer func() { o.end(ctx, err) }()
+
+	req, err := s3.NewPresignClient(c.s3Client).PresignGetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(c.bucket),
+		Key:    aws.String(key),
+	}, s3.WithPresignExpires(expiry))
+	if err != nil {
+		return "", errorx.Wrap(err, op)
+	}
+
+	return req.URL, nil
+}
+
+// WithBaseURL sets the public prefix used by URL, e.g. http://localhost:9000/ucms-avatars.
+func (c *Client) WithBaseURL(baseURL string) *Client {
+	c.baseURL = strings.TrimRight(baseURL, "/")
+	return c
+}
+
+// URL returns the public URL of the object.
+func (c *Client) URL(key string) string {
+	return c.baseURL + "/" + key
+}
+
+func classifyNotFound(err error) error {
+	var noSuchKey *types.NoSuchKey
+	var notFound *types.NotFound
+	if errors.As(err, &noSuchKey) || errors.As(err, &notFound) {
+		return storagex.ErrObjectNotFound
+	}
+	return err
+}
+
+func (c *Client) CreateBucket(ctx context.Context) (err error) {
 	const op = "s3.CreateBucket"
-	_, err := c.s3Client.CreateBucket(ctx, &s3.CreateBucketInput{
+	ctx, o := c.startOperation(ctx, "CreateBucket", "")
+	defer func() { o.end(ctx, err) }()
+
+	_, err = c.s3Client.CreateBucket(ctx, &s3.CreateBucketInput{
 		Bucket: aws.String(c.bucket),
 	})
 	if err != nil {
@@ -0,0 +1,200 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/storagex"
+)
+
+var (
+	tracer = otel.Tracer("ucms/internal/adapters/services/s3")
+	meter  = otel.Meter("ucms/internal/adapters/services/s3")
+)
+
+// Error classes recorded in the error.type attribute.
+const (
+	ErrorTypeNotFound  = "not_found"
+	ErrorTypeThrottled = "throttled"
+	ErrorTypeTimeout   = "timeout"
+	ErrorTypeCanceled  = "canceled"
+	ErrorTypeOther     = "other"
+)
+
+type TelemetryOptions struct {
+	// Tracer and Meter default to the global providers.
+	Tracer trace.Tracer
+	Meter  metric.Meter
+	// RecordFullKeys records the full object key on spans. By default only
+	// the key prefix is recorded, keys may contain user identifiers.
+	RecordFullKeys bool
+}
+
+type telemetry struct {
+	tracer         trace.Tracer
+	duration       metric.Float64Histogram
+	recordFullKeys bool
+}
+
+func newTelemetry(opts TelemetryOptions) telemetry {
+	if opts.Tracer == nil {
+		opts.Tracer = tracer
+	}
+	if opts.Meter == nil {
+		opts.Meter = meter
+	}
+
+	duration, err := opts.Meter.Float64Histogram("ucms.s3.operation.duration",
+		metric.WithDescription("Duration of S3 operations"),
+		metric.WithUnit("s"),
+	)
+	if err != nil {
+		slog.Warn("failed to create s3 duration histogram", slog.String("error", err.Error()))
+	}
+
+	return telemetry{
+		tracer:         opts.Tracer,
+		duration:       duration,
+		recordFullKeys: opts.RecordFullKeys,
+	}
+}
+
+// WithTelemetry replaces the tracer and meter used to instrument the client.
+func (c *Client) WithTelemetry(opts TelemetryOptions) *Client {
+	c.telemetry = newTelemetry(opts)
+	return c
+}
+
+// operation is a single instrumented S3 call.
+type operation struct {
+	telemetry telemetry
+	span      trace.Span
+	name      string
+	bucket    string
+	start     time.Time
+}
+
+// startOperation starts the span of an S3 call, key may be empty for
+// bucket level operations.
+func (c *Client) startOperation(ctx context.Context, name, key string) (context.Context, *operation) {
+	attrs := map[string]any{
+		"s3.operation": name,
+		"s3.bucket":    c.bucket,
+	}
+	if key != "" {
+		attrs["s3.key_prefix"] = keyPrefix(key)
+		if c.telemetry.recordFullKeys {
+			attrs["s3.key"] = key
+		}
+	}
+
+	ctx, span := c.telemetry.tracer.Start(ctx, "s3."+name, trace.WithSpanKind(trace.SpanKindClient))
+	otelx.SetSpanAttrs(span, attrs)
+
+	return ctx, &operation{
+		telemetry: c.telemetry,
+		span:      span,
+		name:      name,
+		bucket:    c.bucket,
+		start:     time.Now(),
+	}
+}
+
+// setBytes records the number of bytes transferred, negative values are unknown.
+func (o *operation) setBytes(n int64) {
+	if n >= 0 {
+		o.span.SetAttributes(attribute.Int64("s3.bytes", n))
+	}
+}
+
+// end finishes the span and records the duration. Not found is an expected
+// outcome for lookups, it is classified but does not mark the span as failed.
+func (o *operation) end(ctx context.Context, err error) {
+	outcome := "ok"
+	if err != nil {
+		outcome = ClassifyError(err)
+		o.span.SetAttributes(attribute.String("error.type", outcome))
+		if outcome != ErrorTypeNotFound {
+			otelx.RecordSpanError(o.span, err, "s3 "+o.name+" failed")
+		}
+	} else {
+		o.span.SetStatus(codes.Ok, "")
+	}
+	o.span.End()
+
+	if o.telemetry.duration != nil {
+		o.telemetry.duration.Record(ctx, time.Since(o.start).Seconds(), metric.WithAttributes(
+			attribute.String("s3.operation", o.name),
+			attribute.String("s3.bucket", o.bucket),
+			attribute.String("outcome", outcome),
+		))
+	}
+}
+
+var throttlingCodes = map[string]bool{
+	"SlowDown":                 true,
+	"Throttling":               true,
+	"ThrottlingException":      true,
+	"RequestLimitExceeded":     true,
+	"TooManyRequests":          true,
+	"RequestThrottled":         true,
+	"TooManyRequestsException": true,
+}
+
+// ClassifyError maps an S3 error to one of the ErrorType constants.
+func ClassifyError(err error) string {
+	if errors.Is(classifyNotFound(err), storagex.ErrObjectNotFound) {
+		return ErrorTypeNotFound
+	}
+	switch code := apiErrorCode(err); {
+	case code == "NoSuchBucket" || code == "NotFound" || code == "NoSuchKey":
+		return ErrorTypeNotFound
+	case throttlingCodes[code]:
+		return ErrorTypeThrottled
+	}
+
+	var respErr *awshttp.ResponseError
+	if errors.As(err, &respErr) {
+		switch respErr.HTTPStatusCode() {
+		case http.StatusNotFound:
+			return ErrorTypeNotFound
+		case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+			return ErrorTypeThrottled
+		}
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ErrorTypeTimeout
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return ErrorTypeTimeout
+	}
+	if errors.Is(err, context.Canceled) {
+		return ErrorTypeCanceled
+	}
+
+	return ErrorTypeOther
+}
+
+// keyPrefix returns the key up to and including the last slash, e.g.
+// "avatars/" for "avatars/<hash>".
+func keyPrefix(key string) string {
+	if i := strings.LastIndexByte(key, '/'); i >= 0 {
+		return key[:i+1]
+	}
+	return ""
+}
@@ -0,0 +1,167 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/smithy-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+)
+
+type instrumentedClient struct {
+	*Client
+	spans   *tracetest.InMemoryExporter
+	metrics *sdkmetric.ManualReader
+}
+
+// newInstrumentedClient points a client at a fake S3 server that stores
+// PUT objects in memory and answers 404 for everything else.
+func newInstrumentedClient(t *testing.T, opts TelemetryOptions) *instrumentedClient {
+	t.Helper()
+
+	objects := map[string][]byte{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPut:
+			var buf bytes.Buffer
+			_, _ = buf.ReadFrom(r.Body)
+			objects[r.URL.Path] = buf.Bytes()
+			w.Header().Set("ETag", `"etag"`)
+		case http.MethodHead:
+			if body, ok := objects[r.URL.Path]; ok {
+				w.Header().Set("Content-Length", fmt.Sprint(len(body)))
+				return
+			}
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	client, err := NewClient(t.Context(), srv.URL, "access", "secret", "bucket", "us-east-1")
+	require.NoError(t, err)
+
+	spans := tracetest.NewInMemoryExporter()
+	metrics := sdkmetric.NewManualReader()
+	opts.Tracer = sdktrace.NewTracerProvider(sdktrace.WithSyncer(spans)).Tracer("test")
+	opts.Meter = sdkmetric.NewMeterProvider(sdkmetric.WithReader(metrics)).Meter("test")
+
+	return &instrumentedClient{
+		Client:  client.WithTelemetry(opts),
+		spans:   spans,
+		metrics: metrics,
+	}
+}
+
+func spanAttrs(s tracetest.SpanStub) map[attribute.Key]attribute.Value {
+	attrs := make(map[attribute.Key]attribute.Value, len(s.Attributes))
+	for _, a := range s.Attributes {
+		attrs[a.Key] = a.Value
+	}
+	return attrs
+}
+
+func TestClient_Telemetry_Success(t *testing.T) {
+	c := newInstrumentedClient(t, TelemetryOptions{})
+
+	err := c.UploadFile(t.Context(), "avatars/abc123", bytes.NewReader([]byte("avatar")), "image/png")
+	require.NoError(t, err)
+
+	spans := c.spans.GetSpans()
+	require.Len(t, spans, 1)
+	span := spans[0]
+	assert.Equal(t, "s3.PutObject", span.Name)
+	assert.Equal(t, codes.Ok, span.Status.Code)
+
+	attrs := spanAttrs(span)
+	assert.Equal(t, "PutObject", attrs["s3.operation"].AsString())
+	assert.Equal(t, "bucket", attrs["s3.bucket"].AsString())
+	assert.Equal(t, "avatars/", attrs["s3.key_prefix"].AsString())
+	assert.Equal(t, int64(6), attrs["s3.bytes"].AsInt64())
+	assert.NotContains(t, attrs, attribute.Key("s3.key"), "full keys are not recorded by default")
+	assert.NotContains(t, attrs, attribute.Key("error.type"))
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, c.metrics.Collect(context.Background(), &rm))
+	require.Len(t, rm.ScopeMetrics, 1)
+	require.Len(t, rm.ScopeMetrics[0].Metrics, 1)
+	m := rm.ScopeMetrics[0].Metrics[0]
+	assert.Equal(t, "ucms.s3.operation.duration", m.Name)
+	hist, ok := m.Data.(metricdata.Histogram[float64])
+	require.True(t, ok)
+	require.Len(t, hist.DataPoints, 1)
+	assert.Equal(t, uint64(1), hist.DataPoints[0].Count)
+	op, _ := hist.DataPoints[0].Attributes.Value("s3.operation")
+	outcome, _ := hist.DataPoints[0].Attributes.Value("outcome")
+	assert.Equal(t, "PutObject", op.AsString())
+	assert.Equal(t, "ok", outcome.AsString())
+}
+
+func TestClient_Telemetry_NotFound(t *testing.T) {
+	c := newInstrumentedClient(t, TelemetryOptions{RecordFullKeys: true})
+
+	_, err := c.HeadObject(t.Context(), "avatars/missing")
+	require.True(t, errorx.IsNotFound(err), "got %v", err)
+
+	spans := c.spans.GetSpans()
+	require.Len(t, spans, 1)
+	attrs := spanAttrs(spans[0])
+	assert.Equal(t, "s3.HeadObject", spans[0].Name)
+	assert.Equal(t, ErrorTypeNotFound, attrs["error.type"].AsString())
+	assert.Equal(t, "avatars/missing", attrs["s3.key"].AsString())
+	assert.NotEqual(t, codes.Error, spans[0].Status.Code, "a missing object is an expected outcome")
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, c.metrics.Collect(context.Background(), &rm))
+	hist := rm.ScopeMetrics[0].Metrics[0].Data.(metricdata.Histogram[float64])
+	outcome, _ := hist.DataPoints[0].Attributes.Value("outcome")
+	assert.Equal(t, ErrorTypeNotFound, outcome.AsString())
+}
+
+func TestClient_Telemetry_ChildSpans(t *testing.T) {
+	c := newInstrumentedClient(t, TelemetryOptions{})
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(c.spans))
+	ctx, parent := tp.Tracer("test").Start(t.Context(), "parent")
+
+	require.NoError(t, c.UploadFile(ctx, "avatars/abc", bytes.NewReader([]byte("x")), "image/png"))
+	parent.End()
+
+	spans := c.spans.GetSpans()
+	require.Len(t, spans, 2)
+	assert.Equal(t, spans[1].SpanContext.SpanID(), spans[0].Parent.SpanID(), "s3 span should be a child of the caller span")
+}
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{name: "throttled", err: &smithy.GenericAPIError{Code: "SlowDown"}, want: ErrorTypeThrottled},
+		{name: "no such bucket", err: &smithy.GenericAPIError{Code: "NoSuchBucket"}, want: ErrorTypeNotFound},
+		{name: "deadline", err: fmt.Errorf("put: %w", context.DeadlineExceeded), want: ErrorTypeTimeout},
+		{name: "canceled", err: context.Canceled, want: ErrorTypeCanceled},
+		{name: "other", err: errors.New("boom"), want: ErrorTypeOther},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ClassifyError(tt.err))
+		})
+	}
+}
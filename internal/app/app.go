@@ -0,0 +1,418 @@
+// Package app wires the API: the database, the repositories, the
+// applications and their HTTP and event ports. cmd/api and the integration
+// test framework both build it with New, the tests replace the
+// infrastructure with the Options.
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	staffcmd "gitlab.com/ucmsv2/ucms-backend/internal/application/staff/cmd"
+	"gitlab.com/ucmsv2/ucms-backend/internal/application/stats"
+	"gitlab.com/ucmsv2/ucms-backend/internal/jobs"
+	httpport "gitlab.com/ucmsv2/ucms-backend/internal/ports/http"
+	watermillport "gitlab.com/ucmsv2/ucms-backend/internal/ports/watermill"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorinbox"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/healthx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/lastseen"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/listenx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/schemaversion"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/tlsx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/validationstats"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/watermillx"
+)
+
+// App is the wired API. New builds it without starting anything, Run starts
+// the background work and the listeners and Shutdown stops them.
+type App struct {
+	Config         *Config
+	Pool           *pgxpool.Pool
+	Repos          *Repositories
+	Infrastructure *Infrastructure
+	Apps           *Applications
+	HTTPPort       *httpport.Port
+	// Router is the public router, AdminRouter the ops router of the admin
+	// listener, nil unless Config.Admin.Port is set.
+	Router      chi.Router
+	AdminRouter chi.Router
+	// EventRouter delivers the events to the handlers of the Apps, Run
+	// starts it.
+	EventRouter *message.Router
+	// ErrorRecorder records the 5xx responses, Run starts writing them.
+	ErrorRecorder *errorinbox.Recorder
+	// ValidationRecorder counts the request fields failing validation, Run
+	// starts writing them.
+	ValidationRecorder *validationstats.Recorder
+	// LastSeen records the time of the authenticated requests of the users,
+	// Run starts writing them.
+	LastSeen *lastseen.Tracker
+	// Schema compares the schema with the embedded migrations, Run checks it
+	// periodically.
+	Schema *schemaversion.Checker
+	// Jobs runs the background jobs, Run starts it.
+	Jobs *jobs.Runner
+	// Health probes the components of the status page, Run starts it.
+	Health *healthx.Monitor
+
+	logger   *slog.Logger
+	ownsPool bool
+
+	ready          chan struct{}
+	servers        []*http.Server
+	listeners      []net.Listener
+	stopBackground context.CancelFunc
+	background     sync.WaitGroup
+}
+
+// New builds the App from cfg: it connects to and migrates the database,
+// sets up the storage, the repositories, the applications and the ports.
+// Nothing runs until Run.
+func New(ctx context.Context, cfg *Config, opts ...Option) (*App, error) {
+	o := options{logger: slog.Default()}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	a := &App{
+		Config: cfg,
+		logger: o.logger,
+		ready:  make(chan struct{}),
+	}
+
+	a.Pool = o.pool
+	if a.Pool == nil {
+		pool, err := setupDatabase(ctx, cfg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to setup database: %w", err)
+		}
+		a.Pool = pool
+		a.ownsPool = true
+	}
+
+	infra, err := setupInfrastructure(ctx, cfg, o)
+	if err != nil {
+		a.closePool()
+		return nil, err
+	}
+	a.Infrastructure = infra
+
+	a.Repos = setupRepositories(a.Pool, infra.Clock)
+	setupAvatarVerification(ctx, cfg.Storage, infra, a.Repos, a.logger)
+
+	a.Schema, err = setupSchema(ctx, a.Repos, infra, a.logger)
+	if err != nil {
+		a.closePool()
+		return nil, err
+	}
+
+	if err := stats.RegisterGauges(stats.GaugesArgs{
+		Users:         a.Repos.User,
+		Registrations: a.Repos.Registration,
+	}); err != nil {
+		a.logger.WarnContext(ctx, "Failed to register business gauges", "error", err)
+	}
+
+	wlogger := watermillx.NewOTelFilteredSlogLogger(a.logger, cfg.Mode.SlogLevel())
+	a.EventRouter = o.eventRouter
+	if a.EventRouter == nil {
+		a.EventRouter, err = message.NewRouter(message.RouterConfig{}, wlogger)
+		if err != nil {
+			a.closePool()
+			return nil, fmt.Errorf("failed to create watermill router: %w", err)
+		}
+	}
+	if err := watermillx.InitializeEventSchema(ctx, a.Pool, wlogger); err != nil {
+		a.closePool()
+		return nil, fmt.Errorf("failed to initialize event schema: %w", err)
+	}
+
+	a.Health = setupHealth(a.Pool, a.Repos, infra, a.EventRouter, o)
+	a.Apps, err = setupApplications(cfg, a.Repos, infra, a.Health, o)
+	if err != nil {
+		a.closePool()
+		return nil, err
+	}
+	a.Jobs, err = setupJobs(cfg, a.Apps, a.Repos, infra, a.Pool)
+	if err != nil {
+		a.closePool()
+		return nil, fmt.Errorf("failed to set up background jobs: %w", err)
+	}
+
+	newPort := watermillport.NewPort
+	if o.testEvents {
+		newPort = watermillport.NewPortForTest
+	}
+	wmport, err := newPort(a.EventRouter, a.Pool, wlogger)
+	if err != nil {
+		a.closePool()
+		return nil, fmt.Errorf("failed to create Watermill port: %w", err)
+	}
+	if err := wmport.Run(ctx, watermillport.AppEventHandlers{
+		Registration: a.Apps.Registration.Event,
+		Mail:         a.Apps.Mail.Event,
+		Student:      a.Apps.Student.Event,
+		User:         a.Apps.User.Event,
+		Notification: a.Apps.Notification.Event,
+	}); err != nil {
+		a.closePool()
+		return nil, fmt.Errorf("failed to run Watermill port: %w", err)
+	}
+
+	a.ErrorRecorder = errorinbox.NewRecorder(errorinbox.RecorderArgs{Store: a.Repos.ErrorEvent})
+	a.ValidationRecorder = validationstats.NewRecorder(validationstats.RecorderArgs{
+		Store: a.Repos.ValidationFailure,
+		Clock: infra.Clock,
+	})
+	a.LastSeen = lastseen.NewTracker(lastseen.TrackerArgs{
+		Store: a.Repos.User,
+		Clock: infra.Clock,
+	})
+	a.HTTPPort = setupHTTPPort(cfg, a.Apps, infra, a.Repos, a.ErrorRecorder, a.ValidationRecorder, a.LastSeen, a.Schema, a.Jobs, a.Pool)
+	a.Router = setupRouter(cfg, a.HTTPPort)
+	if cfg.Admin.Port != "" {
+		a.AdminRouter = a.HTTPPort.RouteOps(nil)
+	}
+
+	return a, nil
+}
+
+// Run starts the event processing, bootstraps the initial staff, starts the
+// background jobs and serves the listeners of the Config. It returns when a
+// listener fails or ctx is done, Shutdown stops the rest.
+func (a *App) Run(ctx context.Context) error {
+	cfg := a.Config
+
+	if err := a.listen(ctx); err != nil {
+		return err
+	}
+
+	bgCtx, stop := context.WithCancel(context.WithoutCancel(ctx))
+	a.stopBackground = stop
+
+	a.goBackground(func() {
+		if err := a.EventRouter.Run(bgCtx); err != nil {
+			a.logger.ErrorContext(bgCtx, "Event router stopped", "error", err)
+		}
+	})
+	select {
+	case <-a.EventRouter.Running():
+	case <-ctx.Done():
+		return nil
+	}
+
+	if cfg.InitialStaff != nil {
+		err := a.Apps.Staff.Command.BootstrapInitialStaff.Handle(ctx, staffcmd.BootstrapInitialStaff{
+			Staff:          *cfg.InitialStaff,
+			RotatePassword: cfg.InitialStaffRotatePassword,
+			InstanceID:     cfg.Service.InstanceId,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to bootstrap initial staff user: %w", err)
+		}
+	} else {
+		a.logger.InfoContext(ctx, "Skipping initial staff user bootstrap, INITIAL_STAFF_EMAIL is not set")
+	}
+
+	a.goBackground(func() { a.Jobs.Run(bgCtx) })
+	a.goBackground(func() { a.Health.Run(bgCtx) })
+	a.goBackground(func() { a.Schema.Run(bgCtx) })
+	a.goBackground(func() { a.ListenNotifications(bgCtx) })
+	a.goBackground(func() { a.ListenGroupChanges(bgCtx) })
+	// Run flushes the errors of the last requests before returning.
+	a.goBackground(func() { a.ErrorRecorder.Run(bgCtx) })
+	a.goBackground(func() { a.ValidationRecorder.Run(bgCtx) })
+	a.goBackground(func() { a.LastSeen.Run(bgCtx) })
+
+	if cfg.TLS.Enabled() {
+		certs, err := tlsx.NewReloader(cfg.TLS.CertFile, cfg.TLS.KeyFile)
+		if err != nil {
+			return err
+		}
+		a.servers[0].TLSConfig = tlsx.ServerConfig(certs.GetCertificate)
+
+		// Renewed certificates are picked up on SIGHUP, e.g. from a certbot
+		// deploy hook.
+		reload := make(chan os.Signal, 1)
+		signal.Notify(reload, syscall.SIGHUP)
+		a.goBackground(func() {
+			defer signal.Stop(reload)
+			certs.ReloadOn(bgCtx, reload)
+		})
+	}
+
+	errs := make(chan error, len(a.servers))
+	for i, srv := range a.servers {
+		ln := a.listeners[i]
+		go func() {
+			a.logger.InfoContext(ctx, "Starting HTTP server", "addr", ln.Addr().String(), "tls", srv.TLSConfig != nil)
+			var err error
+			if srv.TLSConfig != nil {
+				// The certificate comes from TLSConfig.GetCertificate.
+				err = srv.ServeTLS(ln, "", "")
+			} else {
+				err = srv.Serve(ln)
+			}
+			if err != nil && !errors.Is(err, http.ErrServerClosed) {
+				errs <- fmt.Errorf("HTTP server %s: %w", srv.Addr, err)
+			}
+		}()
+	}
+	close(a.ready)
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+		return nil
+	}
+}
+
+// Ready is closed once Run serves the listeners.
+func (a *App) Ready() <-chan struct{} {
+	return a.ready
+}
+
+// Addr is the address of the public listener, set once Run listens.
+func (a *App) Addr() net.Addr {
+	if len(a.listeners) == 0 {
+		return nil
+	}
+	return a.listeners[0].Addr()
+}
+
+// AdminAddr is the address of the admin listener, nil without one.
+func (a *App) AdminAddr() net.Addr {
+	if a.AdminRouter == nil || len(a.listeners) == 0 {
+		return nil
+	}
+	return a.listeners[len(a.listeners)-1].Addr()
+}
+
+// Shutdown stops accepting connections and drains the requests in flight
+// until ctx is done, the connections still open then are closed. It then
+// stops the background work and closes the pool New opened.
+func (a *App) Shutdown(ctx context.Context) error {
+	var errs []error
+	for _, srv := range a.servers {
+		if err := srv.Shutdown(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("shutdown HTTP server %s: %w", srv.Addr, err))
+			_ = srv.Close()
+		}
+	}
+	// The listeners Run returned before serving are not closed by the
+	// servers.
+	for _, ln := range a.listeners {
+		_ = ln.Close()
+	}
+	if a.stopBackground != nil {
+		a.stopBackground()
+	}
+	if err := a.EventRouter.Close(); err != nil {
+		errs = append(errs, fmt.Errorf("close event router: %w", err))
+	}
+	a.background.Wait()
+	a.closePool()
+	return errors.Join(errs...)
+}
+
+// listen binds the public listener first, then the redirect and the admin
+// listeners of the Config. The sockets passed by systemd socket activation
+// are taken in the same order, the listeners left over are bound, with
+// SO_REUSEPORT when Config.ReusePort is set.
+func (a *App) listen(ctx context.Context) error {
+	cfg := a.Config
+	servers := []*http.Server{{
+		Addr:         ":" + cfg.Port,
+		Handler:      a.Router,
+		ReadTimeout:  15 * time.Second,
+		WriteTimeout: 15 * time.Second,
+		IdleTimeout:  60 * time.Second,
+	}}
+	if cfg.TLS.Enabled() && cfg.TLS.RedirectPort != "" {
+		servers = append(servers, &http.Server{
+			Addr:              ":" + cfg.TLS.RedirectPort,
+			Handler:           tlsx.RedirectHandler(cfg.Port),
+			ReadHeaderTimeout: 5 * time.Second,
+			IdleTimeout:       60 * time.Second,
+		})
+	}
+	if a.AdminRouter != nil {
+		servers = append(servers, &http.Server{
+			Addr:              net.JoinHostPort(cfg.Admin.Host, cfg.Admin.Port),
+			Handler:           a.AdminRouter,
+			ReadHeaderTimeout: 15 * time.Second,
+			// No WriteTimeout, the CPU profile and the trace stream for as
+			// long as they are asked for.
+			IdleTimeout: 60 * time.Second,
+		})
+	}
+
+	inherited, err := listenx.Inherited()
+	if err != nil {
+		return err
+	}
+	if len(inherited) > len(servers) {
+		a.logger.WarnContext(ctx, "More sockets passed than listeners, closing the extra ones",
+			"sockets", len(inherited), "listeners", len(servers))
+		for _, ln := range inherited[len(servers):] {
+			_ = ln.Close()
+		}
+		inherited = inherited[:len(servers)]
+	}
+	a.listeners = inherited
+
+	for _, srv := range servers[len(inherited):] {
+		ln, err := listenx.Listen(ctx, srv.Addr, listenx.Options{ReusePort: cfg.ReusePort})
+		if err != nil {
+			for _, l := range a.listeners {
+				_ = l.Close()
+			}
+			a.listeners = nil
+			return fmt.Errorf("listen on %s: %w", srv.Addr, err)
+		}
+		a.listeners = append(a.listeners, ln)
+	}
+	a.servers = servers
+	return nil
+}
+
+// ListenNotifications delivers the notifications published by every instance
+// to the streams open on this one until ctx is done. Run starts it.
+func (a *App) ListenNotifications(ctx context.Context) {
+	a.Repos.NotificationFeed.Listen(ctx, a.Apps.Notification.Hub.Notify)
+}
+
+// ListenGroupChanges drops the groups whose members changed on any instance
+// from the caches of this one until ctx is done. Run starts it.
+func (a *App) ListenGroupChanges(ctx context.Context) {
+	a.Repos.GroupFeed.Listen(ctx, a.Apps.Student.Query.GetGroup.Invalidate)
+}
+
+func (a *App) goBackground(fn func()) {
+	a.background.Add(1)
+	go func() {
+		defer a.background.Done()
+		fn()
+	}()
+}
+
+func (a *App) closePool() {
+	if a.ownsPool && a.Pool != nil {
+		a.Pool.Close()
+		a.Pool = nil
+	}
+}
@@ -0,0 +1,45 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+)
+
+func TestConfig_Masked(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.PgDSN = "postgres://user:hunter2@db:5432/ucms?sslmode=disable"
+	cfg.AccessTokenSecretKey = "access"
+	cfg.S3.SecretKey = "minio-secret"
+	cfg.InitialStaff = &user.CreateInitialStaffArgs{Email: "admin@test.com", Password: "StrongP@ssw0rd"}
+	cfg.OTel.Headers = map[string]string{"authorization": "token"}
+
+	masked := cfg.Masked()
+	assert.Equal(t, "postgres://user:xxxxx@db:5432/ucms?sslmode=disable", masked.PgDSN)
+	assert.Equal(t, maskedSecret, masked.AccessTokenSecretKey)
+	assert.Equal(t, maskedSecret, masked.S3.SecretKey)
+	assert.Equal(t, maskedSecret, masked.InitialStaff.Password)
+	assert.Equal(t, maskedSecret, masked.OTel.Headers["authorization"])
+	assert.Equal(t, "8080", masked.Port)
+
+	assert.Equal(t, "access", cfg.AccessTokenSecretKey, "masking does not change the config")
+	assert.NotEqual(t, maskedSecret, cfg.InitialStaff.Password)
+	assert.Equal(t, "token", cfg.OTel.Headers["authorization"])
+
+	assert.Equal(t, maskedSecret, maskDSN("host=db user=ucms password=hunter2"))
+}
+
+func TestIsLoopback(t *testing.T) {
+	for host, want := range map[string]bool{
+		"127.0.0.1": true,
+		"::1":       true,
+		"localhost": true,
+		"":          false,
+		"0.0.0.0":   false,
+		"10.0.0.5":  false,
+	} {
+		assert.Equal(t, want, isLoopback(host), host)
+	}
+}
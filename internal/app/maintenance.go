@@ -0,0 +1,120 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	regcmd "gitlab.com/ucmsv2/ucms-backend/internal/application/registration/cmd"
+	staffcmd "gitlab.com/ucmsv2/ucms-backend/internal/application/staff/cmd"
+	usercmd "gitlab.com/ucmsv2/ucms-backend/internal/application/user/cmd"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/staffinvitation"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/roles"
+	watermillport "gitlab.com/ucmsv2/ucms-backend/internal/ports/watermill"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/watermillx"
+)
+
+// Maintenance runs the one-off operations of the ucms-api subcommands. It is
+// the database and the repositories of the App without the storage, the
+// listeners and the event router, each operation builds the handler it
+// needs.
+type Maintenance struct {
+	Config *Config
+	Pool   *pgxpool.Pool
+	Repos  *Repositories
+
+	o        options
+	clock    clock.Clock
+	ownsPool bool
+}
+
+// NewMaintenance connects to and migrates the database of cfg, or uses the
+// pool of WithPool. WithLogger, WithMailSender, AllowMockMailSender and
+// WithClock apply as well, the other Options are ignored.
+func NewMaintenance(ctx context.Context, cfg *Config, opts ...Option) (*Maintenance, error) {
+	o := options{logger: slog.Default()}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	m := &Maintenance{Config: cfg, o: o, Pool: o.pool}
+	if m.Pool == nil {
+		pool, err := setupDatabase(ctx, cfg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to setup database: %w", err)
+		}
+		m.Pool = pool
+		m.ownsPool = true
+	}
+
+	if o.clock != nil {
+		m.clock = o.clock
+	} else {
+		m.clock, _ = setupClock(cfg.Mode)
+	}
+	m.Repos = setupRepositories(m.Pool, m.clock)
+
+	return m, nil
+}
+
+// Close closes the pool unless it was given with WithPool.
+func (m *Maintenance) Close() {
+	if m.ownsPool && m.Pool != nil {
+		m.Pool.Close()
+		m.Pool = nil
+	}
+}
+
+// PromoteUser raises the global role of the user with barcode to role.
+func (m *Maintenance) PromoteUser(ctx context.Context, barcode user.Barcode, role roles.Global) (*usercmd.PromoteUserResult, error) {
+	h := usercmd.NewPromoteUserHandler(usercmd.PromoteUserHandlerArgs{
+		Logger:   m.o.logger,
+		UserRepo: m.Repos.User,
+	})
+	return h.Handle(ctx, usercmd.PromoteUser{Barcode: barcode, Role: role})
+}
+
+// RevokeInvitation deletes the staff invitation id, whoever created it.
+func (m *Maintenance) RevokeInvitation(ctx context.Context, id staffinvitation.ID) error {
+	h := otelx.InstrumentCommand[staffcmd.RevokeInvitation](
+		"RevokeInvitationHandler.Handle",
+		staffcmd.NewRevokeInvitationHandler(staffcmd.RevokeInvitationHandlerArgs{
+			Logger:              m.o.logger,
+			StaffInvitationRepo: m.Repos.StaffInvitation,
+		}),
+	)
+	return h.Handle(ctx, staffcmd.RevokeInvitation{InvitationID: id})
+}
+
+// PurgeRegistrations deletes the registrations started more than olderThan
+// ago that were never completed.
+func (m *Maintenance) PurgeRegistrations(ctx context.Context, olderThan time.Duration) (*regcmd.PurgeRegistrationsResult, error) {
+	h := regcmd.NewPurgeRegistrationsHandler(regcmd.PurgeRegistrationsHandlerArgs{
+		Logger: m.o.logger,
+		Repo:   m.Repos.Registration,
+		Clock:  m.clock,
+	})
+	return h.Handle(ctx, regcmd.PurgeRegistrations{OlderThan: olderThan})
+}
+
+// RequeueMailDeadLetters sends the emails whose handling was poisoned again,
+// see watermillport.RequeueMailDeadLetters.
+func (m *Maintenance) RequeueMailDeadLetters(ctx context.Context) (watermillx.RedeliverResult, error) {
+	// The poison queue table is created by the first start of the API.
+	wlogger := watermillx.NewOTelFilteredSlogLogger(m.o.logger, m.Config.Mode.SlogLevel())
+	if err := watermillx.InitializeEventSchema(ctx, m.Pool, wlogger); err != nil {
+		return watermillx.RedeliverResult{}, fmt.Errorf("failed to initialize event schema: %w", err)
+	}
+
+	mailApp, err := setupMail(m.Config, m.Repos, setupMailSender(m.o), m.o)
+	if err != nil {
+		return watermillx.RedeliverResult{}, err
+	}
+	return watermillport.RequeueMailDeadLetters(ctx, m.Pool, mailApp.Event)
+}
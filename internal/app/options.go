@@ -0,0 +1,80 @@
+package app
+
+import (
+	"log/slog"
+
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	mailevent "gitlab.com/ucmsv2/ucms-backend/internal/application/mail/event"
+	userapp "gitlab.com/ucmsv2/ucms-backend/internal/application/user"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
+)
+
+// Option replaces a part of the infrastructure New builds from the Config,
+// e.g. with the fakes of the integration tests.
+type Option func(*options)
+
+type options struct {
+	logger      *slog.Logger
+	pool        *pgxpool.Pool
+	mailSender  mailevent.MailSender
+	mockMail    bool
+	clock       clock.Settable
+	storage     *storageOverride
+	eventRouter *message.Router
+	testEvents  bool
+}
+
+type storageOverride struct {
+	storage userapp.AvatarStorage
+	baseURL string
+}
+
+// WithLogger sets the logger of the wiring and of the applications that take
+// one, slog.Default by default.
+func WithLogger(l *slog.Logger) Option {
+	return func(o *options) { o.logger = l }
+}
+
+// WithPool uses pool instead of connecting to Config.PgDSN. The pool is
+// neither migrated nor closed by Shutdown, it belongs to the caller.
+func WithPool(pool *pgxpool.Pool) Option {
+	return func(o *options) { o.pool = pool }
+}
+
+// WithMailSender sets where the emails go.
+func WithMailSender(s mailevent.MailSender) Option {
+	return func(o *options) { o.mailSender = s }
+}
+
+// AllowMockMailSender lets the mail sender be a mock outside the modes
+// allowing env.CapFakeMail, for the tests starting the app in those modes.
+func AllowMockMailSender() Option {
+	return func(o *options) { o.mockMail = true }
+}
+
+// WithClock sets the clock of the registrations and invitations, it is moved
+// by POST /v1/dev/clock outside production as well.
+func WithClock(c clock.Settable) Option {
+	return func(o *options) { o.clock = c }
+}
+
+// WithStorage stores the avatars in storage, publicly served under baseURL,
+// instead of the backend of Config.Storage. storage may be nil for the apps
+// that do not upload.
+func WithStorage(storage userapp.AvatarStorage, baseURL string) Option {
+	return func(o *options) { o.storage = &storageOverride{storage: storage, baseURL: baseURL} }
+}
+
+// WithEventRouter processes the events on router, e.g. one with the logging
+// middlewares of the tests, instead of a new one.
+func WithEventRouter(router *message.Router) Option {
+	return func(o *options) { o.eventRouter = router }
+}
+
+// WithTestEventProcessors subscribes the event handlers the way the tests
+// need, see watermillport.NewPortForTest.
+func WithTestEventProcessors() Option {
+	return func(o *options) { o.testEvents = true }
+}
@@ -0,0 +1,855 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/go-chi/chi/v5"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	ucmsv2 "gitlab.com/ucmsv2/ucms-backend"
+	"gitlab.com/ucmsv2/ucms-backend/internal/adapters/repos/postgres"
+	"gitlab.com/ucmsv2/ucms-backend/internal/adapters/services/clamav"
+	"gitlab.com/ucmsv2/ucms-backend/internal/adapters/services/fs"
+	"gitlab.com/ucmsv2/ucms-backend/internal/adapters/services/hibp"
+	"gitlab.com/ucmsv2/ucms-backend/internal/adapters/services/s3"
+	announcementapp "gitlab.com/ucmsv2/ucms-backend/internal/application/announcement"
+	authapp "gitlab.com/ucmsv2/ucms-backend/internal/application/auth"
+	"gitlab.com/ucmsv2/ucms-backend/internal/application/mail"
+	mailevent "gitlab.com/ucmsv2/ucms-backend/internal/application/mail/event"
+	notificationapp "gitlab.com/ucmsv2/ucms-backend/internal/application/notification"
+	"gitlab.com/ucmsv2/ucms-backend/internal/application/registration"
+	reportapp "gitlab.com/ucmsv2/ucms-backend/internal/application/report"
+	reportcmd "gitlab.com/ucmsv2/ucms-backend/internal/application/report/cmd"
+	searchapp "gitlab.com/ucmsv2/ucms-backend/internal/application/search"
+	staffapp "gitlab.com/ucmsv2/ucms-backend/internal/application/staff"
+	statusapp "gitlab.com/ucmsv2/ucms-backend/internal/application/status"
+	studentapp "gitlab.com/ucmsv2/ucms-backend/internal/application/student"
+	termapp "gitlab.com/ucmsv2/ucms-backend/internal/application/term"
+	tosapp "gitlab.com/ucmsv2/ucms-backend/internal/application/tos"
+	userapp "gitlab.com/ucmsv2/ucms-backend/internal/application/user"
+	usercmd "gitlab.com/ucmsv2/ucms-backend/internal/application/user/cmd"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/internal/jobs"
+	httpport "gitlab.com/ucmsv2/ucms-backend/internal/ports/http"
+	watermillport "gitlab.com/ucmsv2/ucms-backend/internal/ports/watermill"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/buildinfo"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/env"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorinbox"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/healthx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/lastseen"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/pagination"
+	pgpkg "gitlab.com/ucmsv2/ucms-backend/pkg/postgres"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/retention"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/schemaversion"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/storagex"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/validationstats"
+	"gitlab.com/ucmsv2/ucms-backend/tests/mocks"
+)
+
+// Applications holds all the application dependencies
+type Applications struct {
+	Registration *registration.App
+	Mail         *mail.App
+	Student      *studentapp.App
+	Staff        *staffapp.App
+	Auth         *authapp.App
+	User         *userapp.App
+	Notification *notificationapp.App
+	Announcement *announcementapp.App
+	TOS          *tosapp.App
+	Report       *reportapp.App
+	Search       *searchapp.App
+	Status       *statusapp.App
+	Term         *termapp.App
+}
+
+// setupDatabase connects to and migrates the database, retrying for
+// Config.DBStartupMaxWait while it is not up yet.
+func setupDatabase(ctx context.Context, config *Config) (*pgxpool.Pool, error) {
+	// A malformed DSN does not get better with retries.
+	if _, err := pgxpool.ParseConfig(config.PgDSN); err != nil {
+		return nil, fmt.Errorf("failed to parse pgdsn: %w", err)
+	}
+
+	migrateDSN := strings.Replace(config.PgDSN, "postgres://", "pgx://", 1)
+
+	var pool *pgxpool.Pool
+	err := pgpkg.Retry(ctx, config.DBStartupMaxWait, func(ctx context.Context) error {
+		p, err := pgpkg.NewPgxPool(ctx, config.PgDSN, config.Mode)
+		if err != nil {
+			return fmt.Errorf("failed to create database pool: %w", err)
+		}
+
+		// Run migrations
+		if err := pgpkg.Migrate(migrateDSN, &ucmsv2.Migrations); err != nil {
+			p.Close()
+			return fmt.Errorf("failed to run migrations: %w", err)
+		}
+
+		pool = p
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	warnMigrationConflicts(ctx, pool)
+
+	return pool, nil
+}
+
+// warnMigrationConflicts reports the rows the normalizing migrations could not
+// rewrite because their normalized value is taken. They are unreachable by
+// the normalized lookups until the staff merge or rename them.
+func warnMigrationConflicts(ctx context.Context, pool *pgxpool.Pool) {
+	var count int
+	err := pool.QueryRow(ctx, `SELECT count(*) FROM migration_conflicts`).Scan(&count)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to count the migration conflicts", "error", err)
+		return
+	}
+	if count > 0 {
+		slog.WarnContext(ctx, "Rows left unnormalized by the migrations, see the migration_conflicts table", "count", count)
+	}
+}
+
+// setupSchema records the embedded migrations in the schema manifest and
+// checks the schema once. A failed check is only logged, the periodic checks
+// retry it.
+func setupSchema(ctx context.Context, repos *Repositories, infrastructure *Infrastructure, l *slog.Logger) (*schemaversion.Checker, error) {
+	migrations, err := schemaversion.Embedded(ucmsv2.Migrations, "migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the embedded migrations: %w", err)
+	}
+	checker := schemaversion.NewChecker(schemaversion.CheckerArgs{
+		Store:      repos.SchemaVersion,
+		Migrations: migrations,
+		Logger:     l,
+		Clock:      infrastructure.Clock,
+	})
+	if err := checker.Record(ctx); err != nil {
+		l.WarnContext(ctx, "Failed to check the schema version", "error", err)
+	}
+	return checker, nil
+}
+
+type Repositories struct {
+	PgxPool           *pgxpool.Pool
+	User              *postgres.UserRepo
+	Registration      *postgres.RegistrationRepo
+	Student           *postgres.StudentRepo
+	Staff             *postgres.StaffRepo
+	StaffInvitation   *postgres.StaffInvitationRepo
+	Group             *postgres.GroupRepo
+	ErrorEvent        *postgres.ErrorEventRepo
+	ValidationFailure *postgres.ValidationFailureRepo
+	Notification      *postgres.NotificationRepo
+	Announcement      *postgres.AnnouncementRepo
+	TOS               *postgres.TOSRepo
+	Report            *postgres.ReportRepo
+	Incident          *postgres.IncidentRepo
+	Term              *postgres.TermRepo
+	SchemaVersion     *postgres.SchemaVersionRepo
+	Retention         *postgres.RetentionRepo
+	// NotificationFeed carries the new notifications to the streams of
+	// every instance, App.ListenNotifications receives them.
+	NotificationFeed *postgres.NotificationFeed
+	// GroupFeed carries the groups whose members changed to the caches of
+	// every instance, App.ListenGroupChanges receives them.
+	GroupFeed *postgres.GroupFeed
+}
+
+func setupRepositories(pool *pgxpool.Pool, clk clock.Clock) *Repositories {
+	return &Repositories{
+		PgxPool:           pool,
+		User:              postgres.NewUserRepo(pool, nil, nil),
+		Registration:      postgres.NewRegistrationRepo(pool, nil, nil).WithClock(clk),
+		Student:           postgres.NewStudentRepo(pool, nil, nil),
+		Staff:             postgres.NewStaffRepo(pool, nil, nil),
+		StaffInvitation:   postgres.NewStaffInvitationRepo(pool, nil, nil).WithClock(clk),
+		Group:             postgres.NewGroupRepo(pool, nil, nil),
+		ErrorEvent:        postgres.NewErrorEventRepo(pool, nil, nil),
+		ValidationFailure: postgres.NewValidationFailureRepo(pool, nil, nil),
+		Notification:      postgres.NewNotificationRepo(pool, nil, nil).WithClock(clk),
+		NotificationFeed:  postgres.NewNotificationFeed(pool, nil, nil),
+		GroupFeed:         postgres.NewGroupFeed(pool, nil, nil),
+		Announcement:      postgres.NewAnnouncementRepo(pool, nil, nil).WithClock(clk),
+		TOS:               postgres.NewTOSRepo(pool, nil),
+		Report:            postgres.NewReportRepo(pool, nil),
+		Incident:          postgres.NewIncidentRepo(pool, nil).WithClock(clk),
+		Term:              postgres.NewTermRepo(pool, nil).WithClock(clk),
+		SchemaVersion:     postgres.NewSchemaVersionRepo(pool, nil, nil),
+		Retention:         postgres.NewRetentionRepo(pool, nil),
+	}
+}
+
+type Infrastructure struct {
+	// Storage is nil only when WithStorage replaces it with nil.
+	Storage userapp.AvatarStorage
+	// FileStorage is set only for the fs backend, files are then served by the API itself.
+	FileStorage *fs.Storage
+	// StorageSelfTest is set only for the s3 backend, the instance is not
+	// ready while the credentials can not write to the bucket.
+	StorageSelfTest *storagex.SelfTestReadiness
+	// StorageBaseURL is the public prefix of the stored objects.
+	StorageBaseURL string
+	// AvatarURLs builds avatar URLs for responses according to STORAGE_URL_STRATEGY.
+	AvatarURLs *user.AvatarURLBuilder
+	// ArchiveURLs signs the download URLs of the retention archives, nil
+	// when the storage can not sign them.
+	ArchiveURLs *storagex.URLBuilder
+	// UploadScanner checks uploads for malware according to SCANNER_BACKEND.
+	UploadScanner *storagex.UploadScanner
+	// PasswordPolicy checks the new passwords, against the breach API of
+	// PASSWORD_BREACH_CHECK_URL when it is set.
+	PasswordPolicy *user.PasswordPolicy
+	// Clock is the time of the registrations and invitations. Outside
+	// production it is DevClock, which POST /v1/dev/clock moves.
+	Clock    clock.Clock
+	DevClock clock.Settable
+}
+
+func setupInfrastructure(ctx context.Context, config *Config, o options) (*Infrastructure, error) {
+	infra, presigner, err := setupStorage(ctx, config, o.storage)
+	if err != nil {
+		return nil, err
+	}
+
+	urlBuilder, err := setupURLBuilder(config.Storage, infra.StorageBaseURL, presigner)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set up storage URL builder: %w", err)
+	}
+	o.logger.InfoContext(ctx, "Using storage URL strategy", "strategy", urlBuilder.Strategy())
+	infra.AvatarURLs = user.NewAvatarURLBuilder(urlBuilder)
+
+	infra.ArchiveURLs, err = setupArchiveURLs(config.Storage, infra, presigner)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set up archive URL builder: %w", err)
+	}
+
+	infra.UploadScanner, err = setupUploadScanner(ctx, o.logger, config.Scanner)
+	if err != nil {
+		return nil, err
+	}
+	infra.PasswordPolicy = setupPasswordPolicy(ctx, o.logger, config.BreachCheck)
+
+	if o.clock != nil {
+		infra.Clock, infra.DevClock = o.clock, o.clock
+	} else {
+		infra.Clock, infra.DevClock = setupClock(config.Mode)
+	}
+
+	return infra, nil
+}
+
+func setupStorage(ctx context.Context, config *Config, override *storageOverride) (*Infrastructure, storagex.Presigner, error) {
+	if override != nil {
+		return &Infrastructure{
+			Storage:        override.storage,
+			StorageBaseURL: override.baseURL,
+		}, nil, nil
+	}
+
+	switch config.Storage.Backend {
+	case StorageBackendFS:
+		fsStorage, err := fs.NewStorage(config.Storage.FSRoot, config.Storage.FSBaseURL)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to set up filesystem storage: %w", err)
+		}
+		fsStorage.WithSigningKey([]byte(config.Storage.FSSigningKey))
+		slog.InfoContext(ctx, "Using filesystem storage", "root", fsStorage.Root())
+
+		return &Infrastructure{
+			Storage:        fsStorage,
+			FileStorage:    fsStorage,
+			StorageBaseURL: config.Storage.FSBaseURL,
+		}, nil, nil
+	case StorageBackendS3:
+		s3Storage, err := s3.NewClient(ctx, config.S3.Endpoint, config.S3.AccessKey, config.S3.SecretKey, config.S3.Bucket, config.S3.Region)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to set up S3 storage: %w", err)
+		}
+		s3Storage.WithTelemetry(s3.TelemetryOptions{RecordFullKeys: config.S3.TraceFullKeys})
+
+		bucket, err := s3Storage.EnsureBucket(ctx, s3.EnsureBucketOptions{Bootstrap: config.S3.BootstrapBucket})
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to ensure S3 bucket %s: %w", config.S3.Bucket, err)
+		}
+		slog.InfoContext(ctx, "Using S3 storage",
+			"bucket", bucket.Name,
+			"region", bucket.Region,
+			"created", bucket.Created,
+			"tmp_expiration_days", bucket.TmpExpirationDays,
+			"policy", bucket.Policy)
+
+		// The API is not ready to serve uploads without storage access, the
+		// readiness fails until the storage probe passes the self-test again.
+		selfTest := storagex.NewSelfTestReadiness(s3Storage)
+		if err := selfTest.Check(ctx); err != nil {
+			slog.ErrorContext(ctx, "S3 storage self-test failed, the instance is not ready", "error", err)
+		}
+
+		return &Infrastructure{
+			Storage:         s3Storage.WithBaseURL(config.S3.BaseURL),
+			StorageSelfTest: selfTest,
+			StorageBaseURL:  config.S3.BaseURL,
+		}, s3Storage, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown storage backend %q, expected %q or %q",
+			config.Storage.Backend, StorageBackendS3, StorageBackendFS)
+	}
+}
+
+// setupClock returns the clock of the application. In the modes allowing
+// env.CapDevClock it can be moved through POST /v1/dev/clock to test the
+// cooldowns and expirations by hand, so it is returned as the dev clock as
+// well.
+func setupClock(mode env.Mode) (clock.Clock, clock.Settable) {
+	if !mode.Allows(env.CapDevClock) {
+		return clock.Real, nil
+	}
+	offset := clock.NewOffset()
+	return offset, offset
+}
+
+func setupPasswordPolicy(ctx context.Context, logger *slog.Logger, config BreachCheckConfig) *user.PasswordPolicy {
+	if config.URL == "" {
+		logger.InfoContext(ctx, "Password breach check is disabled")
+		return user.NewPasswordPolicy(user.PasswordPolicyArgs{})
+	}
+
+	logger.InfoContext(ctx, "Using password breach check", "url", config.URL, "timeout", config.Timeout)
+	return user.NewPasswordPolicy(user.PasswordPolicyArgs{
+		BreachChecker: hibp.NewClient(hibp.ClientArgs{
+			BaseURL: config.URL,
+			Timeout: config.Timeout,
+		}),
+		BreachCheckTimeout: config.Timeout,
+	})
+}
+
+func setupUploadScanner(ctx context.Context, logger *slog.Logger, config ScannerConfig) (*storagex.UploadScanner, error) {
+	var scanner storagex.Scanner
+	switch config.Backend {
+	case ScannerBackendNone, "":
+		logger.WarnContext(ctx, "Upload malware scanning is disabled")
+		scanner = storagex.NoopScanner{}
+	case ScannerBackendClamAV:
+		client := clamav.NewClient(config.ClamAVAddr)
+		// An unreachable clamd is not fatal, uploads then follow SCANNER_FAIL_OPEN.
+		if err := client.Ping(ctx); err != nil {
+			logger.WarnContext(ctx, "ClamAV is not reachable", "addr", config.ClamAVAddr, "error", err)
+		}
+		logger.InfoContext(ctx, "Using ClamAV upload scanning",
+			"addr", config.ClamAVAddr,
+			"timeout", config.Timeout,
+			"fail_open", config.FailOpen)
+		scanner = client
+	default:
+		return nil, fmt.Errorf("unknown scanner backend %q, expected %q or %q",
+			config.Backend, ScannerBackendNone, ScannerBackendClamAV)
+	}
+
+	return storagex.NewUploadScanner(scanner, storagex.UploadScannerConfig{
+		Timeout:  config.Timeout,
+		FailOpen: config.FailOpen,
+	}), nil
+}
+
+// setupAvatarVerification makes the avatar URLs check that the S3 avatars
+// exist when AVATAR_VERIFY_OBJECTS is set, the missing ones are published
+// by the user repo.
+func setupAvatarVerification(ctx context.Context, config StorageConfig, infra *Infrastructure, repos *Repositories, logger *slog.Logger) {
+	if !config.VerifyAvatars || infra.Storage == nil {
+		return
+	}
+	logger.InfoContext(ctx, "Verifying avatar objects", "ttl", config.VerifyTTL)
+	infra.AvatarURLs.VerifyObjects(storagex.NewExistenceCache(storagex.ExistenceCacheConfig{
+		Objects: infra.Storage,
+		TTL:     config.VerifyTTL,
+	}), repos.User)
+}
+
+func setupURLBuilder(config StorageConfig, publicBaseURL string, presigner storagex.Presigner) (*storagex.URLBuilder, error) {
+	strategy, err := storagex.ParseURLStrategy(config.URLStrategy)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := storagex.URLBuilderConfig{
+		Strategy:        strategy,
+		PublicBaseURL:   publicBaseURL,
+		Presigner:       presigner, // nil for the fs backend, which can not sign
+		SignedURLExpiry: config.SignedURLExpiry,
+		CDNBaseURL:      config.CDNBaseURL,
+	}
+	if strategy == storagex.URLStrategyCDN && config.CDNKeyPairID != "" {
+		pemKey, err := os.ReadFile(config.CDNPrivateKeyPath)
+		if err != nil {
+			return nil, fmt.Errorf("read cdn private key: %w", err)
+		}
+		signer, err := storagex.NewCloudFrontSigner(config.CDNKeyPairID, pemKey)
+		if err != nil {
+			return nil, err
+		}
+		cfg.CDNSigner = signer
+	}
+
+	return storagex.NewURLBuilder(cfg)
+}
+
+// setupArchiveURLs signs the URLs of the retention archives whatever
+// STORAGE_URL_STRATEGY is, the archives are private. The fs backend signs
+// them itself, the files handler checks the signature.
+func setupArchiveURLs(config StorageConfig, infra *Infrastructure, presigner storagex.Presigner) (*storagex.URLBuilder, error) {
+	if infra.FileStorage != nil {
+		presigner = infra.FileStorage
+	}
+	if presigner == nil {
+		// The storage of WithStorage.
+		presigner, _ = infra.Storage.(storagex.Presigner)
+	}
+	if presigner == nil {
+		return nil, nil
+	}
+
+	return storagex.NewURLBuilder(storagex.URLBuilderConfig{
+		Strategy:        storagex.URLStrategySigned,
+		Presigner:       presigner,
+		SignedURLExpiry: config.SignedURLExpiry,
+	})
+}
+
+func setupApplications(
+	config *Config,
+	repos *Repositories,
+	infrastructure *Infrastructure,
+	health *healthx.Monitor,
+	o options,
+) (*Applications, error) {
+	regApp := registration.NewApp(registration.Args{
+		Mode:           config.Mode,
+		Clock:          infrastructure.Clock,
+		Repo:           repos.Registration,
+		Purger:         repos.Registration,
+		UserGetter:     repos.User,
+		GroupGetter:    repos.Group,
+		StudentSaver:   repos.Student,
+		PgxPool:        repos.PgxPool,
+		PasswordPolicy: infrastructure.PasswordPolicy,
+		TOSVersions:    repos.TOS,
+	})
+
+	studentApp := studentapp.NewApp(studentapp.Args{
+		Logger:      o.logger,
+		PgxPool:     repos.PgxPool,
+		StudentRepo: repos.Student,
+		GroupGetter: repos.Group,
+		GroupRepo:   repos.Group,
+		GroupLister: repos.Group,
+		AvatarURLs:  infrastructure.AvatarURLs,
+		Clock:       infrastructure.Clock,
+
+		GroupSummaries: repos.Group,
+		GroupPublisher: repos.GroupFeed,
+	})
+
+	staffApp := staffapp.NewApp(staffapp.Args{
+		StaffInvitationRepo: repos.StaffInvitation,
+		StaffRepo:           repos.Staff,
+		PasswordPolicy:      infrastructure.PasswordPolicy,
+		TOSVersions:         repos.TOS,
+		Clock:               infrastructure.Clock,
+		AvatarURLs:          infrastructure.AvatarURLs,
+		UnreadCounter:       repos.Notification,
+	})
+
+	userApp := userapp.NewApp(userapp.Args{
+		S3BaseURL:           infrastructure.StorageBaseURL,
+		AvatarStorage:       infrastructure.Storage,
+		UserRepo:            repos.User,
+		UploadScanner:       infrastructure.UploadScanner,
+		AvatarGCGracePeriod: config.AvatarGC.GracePeriod,
+	})
+
+	authApp := authapp.NewApp(authapp.Args{
+		Logger:         o.logger,
+		UserGetter:     repos.User,
+		LoginPolicy:    authapp.NewEnrollmentPolicy(repos.Student),
+		Impersonations: repos.User,
+		Clock:          infrastructure.Clock,
+		EmailVerifier: authapp.EmailVerifierFunc(func(ctx context.Context, id user.ID) error {
+			return userApp.Command.RequestEmailVerification.Handle(ctx, usercmd.RequestEmailVerification{UserID: id})
+		}),
+		AccessTokenSecretKey:    config.AccessTokenSecretKey,
+		RefreshTokenSecretKey:   config.RefreshTokenSecretKey,
+		AccessTokenlExpDuration: &config.TokenTTL.Access,
+		RefreshTokenExpDuration: &config.TokenTTL.Refresh,
+	})
+
+	notificationApp := notificationapp.NewApp(notificationapp.Args{
+		Logger:                  o.logger,
+		NotificationRepo:        repos.Notification,
+		InvitationCreatorGetter: repos.Staff,
+		GroupGetter:             repos.Group,
+		RecipientLister:         repos.Announcement,
+		Publisher:               repos.NotificationFeed,
+		Clock:                   infrastructure.Clock,
+	})
+
+	announcementApp := announcementapp.NewApp(announcementapp.Args{
+		Logger:           o.logger,
+		AnnouncementRepo: repos.Announcement,
+		GroupGetter:      repos.Group,
+		StudentGetter:    repos.Student,
+		Clock:            infrastructure.Clock,
+	})
+
+	mailSender := setupMailSender(o)
+	mailApp, err := setupMail(config, repos, mailSender, o)
+	if err != nil {
+		return nil, err
+	}
+
+	reportApp := reportapp.NewApp(reportapp.Args{
+		Logger:       o.logger,
+		ReportRepo:   repos.Report,
+		Users:        repos.User,
+		Terms:        repos.Term,
+		MailSender:   mailSender,
+		MailHandlers: watermillport.MailHandlerNames(),
+		Clock:        infrastructure.Clock,
+	})
+
+	searchApp := searchapp.NewApp(searchapp.Args{
+		Logger:        o.logger,
+		Users:         repos.User,
+		Groups:        repos.Group,
+		Invitations:   repos.StaffInvitation,
+		UserLister:    repos.User,
+		InactiveUsers: repos.User,
+		Cursors:       pagination.NewCodec([]byte(config.AccessTokenSecretKey)),
+		Clock:         infrastructure.Clock,
+	})
+
+	tosApp := tosapp.NewApp(tosapp.Args{
+		Logger:   o.logger,
+		TOSRepo:  repos.TOS,
+		UserRepo: repos.User,
+		Clock:    infrastructure.Clock,
+	})
+
+	statusApp := statusapp.NewApp(statusapp.Args{
+		Logger:       o.logger,
+		IncidentRepo: repos.Incident,
+		Health:       health,
+		Clock:        infrastructure.Clock,
+	})
+
+	termApp := termapp.NewApp(termapp.Args{
+		Logger:   o.logger,
+		TermRepo: repos.Term,
+		Clock:    infrastructure.Clock,
+	})
+
+	return &Applications{
+		Registration: regApp,
+		Mail:         mailApp,
+		Student:      studentApp,
+		Staff:        staffApp,
+		Auth:         authApp,
+		User:         userApp,
+		Notification: notificationApp,
+		Announcement: announcementApp,
+		TOS:          tosApp,
+		Report:       reportApp,
+		Search:       searchApp,
+		Status:       statusApp,
+		Term:         termApp,
+	}, nil
+}
+
+// mailFailureWindow is how far back the dead letters of the mail handlers
+// degrade the mail component, a poisoned email stops counting after it.
+const mailFailureWindow = 15 * time.Minute
+
+// setupHealth sets up the readiness checks behind the components of the
+// status page. The api component is the process answering, it has no probe.
+func setupHealth(
+	pool *pgxpool.Pool,
+	repos *Repositories,
+	infrastructure *Infrastructure,
+	eventRouter *message.Router,
+	o options,
+) *healthx.Monitor {
+	clk := infrastructure.Clock
+	var storage func(context.Context) error
+	if infrastructure.Storage != nil {
+		storage = func(ctx context.Context) error {
+			if infrastructure.StorageSelfTest != nil {
+				if err := infrastructure.StorageSelfTest.Probe(ctx); err != nil {
+					return err
+				}
+			}
+			_, _, err := infrastructure.Storage.ListObjects(ctx, "", "", 1)
+			return err
+		}
+	}
+
+	return healthx.NewMonitor(healthx.MonitorArgs{
+		Logger: o.logger,
+		Clock:  clk,
+		Checks: []healthx.Check{
+			{Name: "api"},
+			{Name: "database", Probe: pool.Ping},
+			{Name: "storage", Probe: storage},
+			{Name: "mail", Probe: func(ctx context.Context) error {
+				since := clock.Or(clk).Now().Add(-mailFailureWindow)
+				count, err := repos.Report.CountDeadLetters(ctx, watermillport.MailHandlerNames(), since)
+				if err != nil {
+					return err
+				}
+				if count > 0 {
+					return fmt.Errorf("%d emails failed in the last %s", count, mailFailureWindow)
+				}
+				return nil
+			}},
+			{Name: "events", Probe: func(context.Context) error {
+				if !eventRouter.IsRunning() || eventRouter.IsClosed() {
+					return errors.New("event router is not running")
+				}
+				return nil
+			}},
+		},
+	})
+}
+
+func setupMailSender(o options) mailevent.MailSender {
+	if o.mailSender != nil {
+		return o.mailSender
+	}
+	// There is no SMTP sender yet, setupMail refuses the mock outside the
+	// modes allowing fake mail.
+	return mocks.NewMockMailSender()
+}
+
+func setupMail(config *Config, repos *Repositories, mailSender mailevent.MailSender, o options) (*mail.App, error) {
+	mailApp, err := mail.NewApp(mail.Args{
+		Mailsender:              mailSender,
+		StaffInvitationLinkURL:  config.StaffInvitationLinkURL,
+		InvitationCreatorGetter: repos.Staff,
+		RecipientLister:         repos.Announcement,
+		AllowMockSender:         o.mockMail || config.Mode.Allows(env.CapFakeMail),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to set up mail in the %s mode: %w", config.Mode.String(), err)
+	}
+	return mailApp, nil
+}
+
+// Names of the background jobs, served on /v1/staffs/system/jobs.
+const (
+	jobAvatarGC     = "avatar-gc"
+	jobWeeklyReport = "weekly-report"
+	jobRetention    = "retention"
+)
+
+// jobJitter spreads the runs of the instances started together.
+const jobJitter = time.Minute
+
+// setupJobs registers the background jobs enabled by config, App.Run runs
+// them.
+func setupJobs(config *Config, apps *Applications, repos *Repositories, infra *Infrastructure, pool *pgxpool.Pool) (*jobs.Runner, error) {
+	runner := jobs.NewRunner(jobs.RunnerArgs{
+		Locker: postgres.NewJobLocker(pool, nil),
+	})
+
+	if config.AvatarGC.Interval > 0 {
+		gc := apps.User.Command.CollectOrphanedAvatars
+		err := runner.Register(jobs.Job{
+			Name:     jobAvatarGC,
+			Schedule: jobs.Every(config.AvatarGC.Interval),
+			Jitter:   jobJitter,
+			Run: func(ctx context.Context) error {
+				_, err := gc.Handle(ctx, usercmd.CollectOrphanedAvatars{DryRun: config.AvatarGC.DryRun})
+				return err
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if config.WeeklyReport.Enabled {
+		generate := apps.Report.Command.GenerateWeeklyReport
+		err := runner.Register(jobs.Job{
+			Name:     jobWeeklyReport,
+			Schedule: jobs.Weekly{At: config.WeeklyReport.At},
+			Jitter:   jobJitter,
+			// A run missed while no instance was up is caught up on start,
+			// the report of a week is generated once however often this runs.
+			RunOnStart: true,
+			Run: func(ctx context.Context) error {
+				_, err := generate.Handle(ctx, reportcmd.GenerateWeeklyReport{})
+				return err
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if config.Retention.Interval > 0 && config.Retention.Policies.Enabled() {
+		archiver, err := retention.NewArchiver(retention.ArchiverArgs{
+			Store:    repos.Retention,
+			Storage:  infra.Storage,
+			Policies: config.Retention.Policies,
+			Clock:    infra.Clock,
+		})
+		if err != nil {
+			return nil, err
+		}
+		err = runner.Register(jobs.Job{
+			Name:     jobRetention,
+			Schedule: jobs.Every(config.Retention.Interval),
+			Jitter:   jobJitter,
+			Run: func(ctx context.Context) error {
+				_, err := archiver.Run(ctx)
+				return err
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return runner, nil
+}
+
+func setupHTTPPort(
+	config *Config,
+	apps *Applications,
+	infrastructure *Infrastructure,
+	repos *Repositories,
+	errorRecorder *errorinbox.Recorder,
+	validationRecorder *validationstats.Recorder,
+	lastSeen *lastseen.Tracker,
+	schema *schemaversion.Checker,
+	runner *jobs.Runner,
+	pool *pgxpool.Pool,
+) *httpport.Port {
+	httpArgs := httpport.Args{
+		ServiceName:             config.Service.Name,
+		RegistrationApp:         apps.Registration,
+		AuthApp:                 apps.Auth,
+		StudentApp:              apps.Student,
+		StaffApp:                apps.Staff,
+		UserApp:                 apps.User,
+		NotificationApp:         apps.Notification,
+		AnnouncementApp:         apps.Announcement,
+		TOSApp:                  apps.TOS,
+		ReportApp:               apps.Report,
+		SearchApp:               apps.Search,
+		StatusApp:               apps.Status,
+		TermApp:                 apps.Term,
+		Secret:                  []byte(config.AccessTokenSecretKey),
+		CookieDomain:            config.CookieDomain,
+		AcceptInvitationPageURL: config.AcceptInvitationPageURL,
+		InvitationTokenAlg:      jwt.SigningMethodHS256,
+		InvitationTokenKey:      config.InvitationTokenSecretKey,
+		InvitationTokenExp:      config.TokenTTL.Invitation,
+		AccessTokenExp:          config.TokenTTL.Access,
+		Schema:                  schema,
+		BuildInfo: buildinfo.Info{
+			Version:   config.Service.Version,
+			Commit:    config.Service.Commit,
+			BuildDate: config.Service.BuildDate,
+		},
+		ErrorRecorder:      errorRecorder,
+		ErrorEvents:        repos.ErrorEvent,
+		ValidationRecorder: validationRecorder,
+		ValidationStats:    repos.ValidationFailure,
+		LastSeen:           lastSeen,
+		Jobs:               runner,
+		Clock:              infrastructure.Clock,
+		DevClock:           infrastructure.DevClock,
+		TLS:                config.TLS.Enabled(),
+		OpsListener:        config.Admin.Port != "",
+		OpsLoopback:        isLoopback(config.Admin.Host),
+		Mode:               config.Mode,
+		UnitOfWork:         pgpkg.NewUnitOfWork(pool),
+	}
+	if infrastructure.FileStorage != nil {
+		httpArgs.FileStorage = infrastructure.FileStorage
+	}
+	if infrastructure.StorageSelfTest != nil {
+		httpArgs.Storage = infrastructure.StorageSelfTest
+	}
+	if infrastructure.ArchiveURLs != nil {
+		httpArgs.Archives = repos.Retention
+		httpArgs.ArchiveURLs = infrastructure.ArchiveURLs
+	}
+	return httpport.NewPort(httpArgs)
+}
+
+// isLoopback reports whether the listeners bound to host only accept local
+// connections, e.g. 127.0.0.1, ::1 or localhost. An empty host binds every
+// interface.
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func setupRouter(config *Config, httpPort *httpport.Port) chi.Router {
+	router := chi.NewRouter()
+
+	if config.Mode.Allows(env.CapPermissiveCORS) {
+		router.Use(func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				origin := r.Header.Get("Origin")
+
+				allowedOrigins := map[string]bool{
+					"http://localhost:3000": true,
+					"http://localhost:5173": true, // Vite default
+					"http://127.0.0.1:3000": true,
+					"http://127.0.0.1:5173": true,
+					"*":                     true, // Allow all origins in development
+					"null":                  true, // Handle null origin for file:// requests
+				}
+
+				if allowedOrigins[origin] {
+					w.Header().Set("Access-Control-Allow-Origin", origin)
+				} else if origin == "" {
+					// For same-origin requests or when opening HTML file directly
+					w.Header().Set("Access-Control-Allow-Origin", "http://localhost:8080")
+				}
+
+				w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+				w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, Idempotency-Key, Accept-Language")
+				w.Header().Set("Access-Control-Allow-Credentials", "true") // ← THIS IS CRUCIAL!
+
+				if r.Method == "OPTIONS" {
+					w.WriteHeader(http.StatusOK)
+					return
+				}
+
+				next.ServeHTTP(w, r)
+			})
+		})
+	}
+
+	return httpPort.Route(router)
+}
@@ -0,0 +1,163 @@
+package announcementquery
+
+import (
+	"context"
+	"log/slog"
+
+	"go.opentelemetry.io/contrib/bridges/otelslog"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/announcement"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/roles"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/httpx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx"
+)
+
+var (
+	tracer = otel.Tracer("ucms/internal/application/announcement/query")
+	logger = otelslog.NewLogger("ucms/internal/application/announcement/query")
+)
+
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+type AnnouncementLister interface {
+	ListAnnouncements(ctx context.Context, params announcement.ListParams) ([]*announcement.Announcement, error)
+}
+
+type StudentGetter interface {
+	GetStudentByID(ctx context.Context, id user.ID) (*user.Student, error)
+}
+
+// ListAnnouncements lists the announcements the user sees, the newest first.
+// The students see the published ones targeting their group or all students,
+// the staff see every one of them to moderate.
+type ListAnnouncements struct {
+	UserID user.ID
+	Role   roles.Global
+	// Page starts at 1.
+	Page int
+	// PageSize defaults to DefaultPageSize and is capped at MaxPageSize.
+	PageSize int
+}
+
+type AnnouncementResponse struct {
+	ID       string   `json:"id"`
+	AuthorID string   `json:"author_id"`
+	Title    string   `json:"title"`
+	Body     string   `json:"body"`
+	GroupIDs []string `json:"group_ids"`
+	// Published is false for the announcements the staff unpublished, the
+	// students never see those.
+	Published     bool        `json:"published"`
+	UnpublishedAt *httpx.Time `json:"unpublished_at,omitempty"`
+	CreatedAt     httpx.Time  `json:"created_at"`
+}
+
+func NewAnnouncementResponse(a *announcement.Announcement) AnnouncementResponse {
+	res := AnnouncementResponse{
+		ID:            a.ID().String(),
+		AuthorID:      a.AuthorID().String(),
+		Title:         a.Title(),
+		Body:          a.Body(),
+		GroupIDs:      make([]string, len(a.GroupIDs())),
+		Published:     a.IsPublished(),
+		UnpublishedAt: httpx.NewTimePtr(a.UnpublishedAt()),
+		CreatedAt:     httpx.NewTime(a.CreatedAt()),
+	}
+	for i, id := range a.GroupIDs() {
+		res.GroupIDs[i] = id.String()
+	}
+	return res
+}
+
+type ListAnnouncementsResponse struct {
+	Announcements []AnnouncementResponse `json:"announcements"`
+}
+
+type ListAnnouncementsHandler struct {
+	tracer   trace.Tracer
+	logger   *slog.Logger
+	repo     AnnouncementLister
+	students StudentGetter
+}
+
+type ListAnnouncementsHandlerArgs struct {
+	Tracer           trace.Tracer
+	Logger           *slog.Logger
+	AnnouncementRepo AnnouncementLister
+	StudentGetter    StudentGetter
+}
+
+func NewListAnnouncementsHandler(args ListAnnouncementsHandlerArgs) *ListAnnouncementsHandler {
+	if args.Tracer == nil {
+		args.Tracer = tracer
+	}
+	if args.Logger == nil {
+		args.Logger = logger
+	}
+
+	return &ListAnnouncementsHandler{
+		tracer:   args.Tracer,
+		logger:   args.Logger,
+		repo:     args.AnnouncementRepo,
+		students: args.StudentGetter,
+	}
+}
+
+func (h *ListAnnouncementsHandler) Handle(ctx context.Context, query ListAnnouncements) (*ListAnnouncementsResponse, error) {
+	const op = "announcementquery.ListAnnouncementsHandler.Handle"
+	ctx, span := h.tracer.Start(ctx, "ListAnnouncementsHandler.Handle")
+	defer span.End()
+	otelx.SetSpanAttrs(span, map[string]any{
+		"user.id":         query.UserID.String(),
+		"user.role":       query.Role.String(),
+		"query.page":      query.Page,
+		"query.page_size": query.PageSize,
+	})
+
+	if query.Page < 1 {
+		query.Page = 1
+	}
+	if query.PageSize <= 0 {
+		query.PageSize = DefaultPageSize
+	}
+	query.PageSize = min(query.PageSize, MaxPageSize)
+
+	params := announcement.ListParams{
+		All:    query.Role.IsStaffLike(),
+		Limit:  query.PageSize,
+		Offset: (query.Page - 1) * query.PageSize,
+	}
+	if !params.All {
+		student, err := h.students.GetStudentByID(ctx, query.UserID)
+		switch {
+		case errorx.IsNotFound(err):
+			// Without a group the user sees the announcements to all
+			// students only.
+		case err != nil:
+			otelx.RecordSpanError(span, err, "failed to get student by id")
+			return nil, errorx.Wrap(err, op)
+		default:
+			params.GroupID = student.GroupID()
+		}
+	}
+
+	announcements, err := h.repo.ListAnnouncements(ctx, params)
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to list announcements")
+		return nil, errorx.Wrap(err, op)
+	}
+
+	res := ListAnnouncementsResponse{Announcements: make([]AnnouncementResponse, len(announcements))}
+	for i, a := range announcements {
+		res.Announcements[i] = NewAnnouncementResponse(a)
+	}
+
+	return &res, nil
+}
@@ -0,0 +1,72 @@
+package announcementapp
+
+import (
+	"log/slog"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/application/announcement/announcementquery"
+	"gitlab.com/ucmsv2/ucms-backend/internal/application/announcement/cmd"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx"
+)
+
+type App struct {
+	Command Command
+	Query   Query
+}
+
+type Command struct {
+	CreateAnnouncement    otelx.Handler[cmd.CreateAnnouncement]
+	UnpublishAnnouncement otelx.Handler[cmd.UnpublishAnnouncement]
+}
+
+type Query struct {
+	ListAnnouncements *announcementquery.ListAnnouncementsHandler
+}
+
+type AnnouncementRepo interface {
+	cmd.AnnouncementRepo
+	announcementquery.AnnouncementLister
+}
+
+type Args struct {
+	Tracer           trace.Tracer
+	Logger           *slog.Logger
+	AnnouncementRepo AnnouncementRepo
+	GroupGetter      cmd.GroupGetter
+	StudentGetter    announcementquery.StudentGetter
+	// Clock defaults to clock.Real.
+	Clock clock.Clock
+}
+
+func NewApp(args Args) *App {
+	return &App{
+		Command: Command{
+			CreateAnnouncement: otelx.InstrumentCommand[cmd.CreateAnnouncement](
+				"CreateAnnouncementHandler.Handle",
+				cmd.NewCreateAnnouncementHandler(cmd.CreateAnnouncementHandlerArgs{
+					Logger:           args.Logger,
+					AnnouncementRepo: args.AnnouncementRepo,
+					GroupGetter:      args.GroupGetter,
+					Clock:            args.Clock,
+				}),
+			),
+			UnpublishAnnouncement: otelx.InstrumentCommand[cmd.UnpublishAnnouncement](
+				"UnpublishAnnouncementHandler.Handle",
+				cmd.NewUnpublishAnnouncementHandler(cmd.UnpublishAnnouncementHandlerArgs{
+					Logger:           args.Logger,
+					AnnouncementRepo: args.AnnouncementRepo,
+				}),
+			),
+		},
+		Query: Query{
+			ListAnnouncements: announcementquery.NewListAnnouncementsHandler(announcementquery.ListAnnouncementsHandlerArgs{
+				Tracer:           args.Tracer,
+				Logger:           args.Logger,
+				AnnouncementRepo: args.AnnouncementRepo,
+				StudentGetter:    args.StudentGetter,
+			}),
+		},
+	}
+}
@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"context"
+	"log/slog"
+
+	"go.opentelemetry.io/contrib/bridges/otelslog"
+	"go.opentelemetry.io/otel/trace"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/announcement"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/group"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/i18nx"
+)
+
+var logger = otelslog.NewLogger("ucms/internal/application/announcement/cmd")
+
+type AnnouncementRepo interface {
+	SaveAnnouncement(ctx context.Context, a *announcement.Announcement) error
+	UpdateAnnouncement(ctx context.Context, id announcement.ID, fn func(context.Context, *announcement.Announcement) error) error
+}
+
+type GroupGetter interface {
+	GetGroupByID(ctx context.Context, id group.ID) (*group.Group, error)
+}
+
+// CreateAnnouncement publishes an announcement of the student association.
+type CreateAnnouncement struct {
+	AuthorID user.ID
+	Title    string
+	Body     string
+	// GroupIDs are the target groups, none targets all students.
+	GroupIDs  []group.ID
+	SendEmail bool
+}
+
+func (c CreateAnnouncement) SpanAttrs() map[string]any {
+	return map[string]any{
+		"author_id":    c.AuthorID.String(),
+		"groups_count": len(c.GroupIDs),
+		"send_email":   c.SendEmail,
+	}
+}
+
+type CreateAnnouncementHandler struct {
+	logger *slog.Logger
+	repo   AnnouncementRepo
+	groups GroupGetter
+	clock  clock.Clock
+}
+
+type CreateAnnouncementHandlerArgs struct {
+	Logger           *slog.Logger
+	AnnouncementRepo AnnouncementRepo
+	GroupGetter      GroupGetter
+	// Clock defaults to clock.Real.
+	Clock clock.Clock
+}
+
+func NewCreateAnnouncementHandler(args CreateAnnouncementHandlerArgs) *CreateAnnouncementHandler {
+	h := &CreateAnnouncementHandler{
+		logger: args.Logger,
+		repo:   args.AnnouncementRepo,
+		groups: args.GroupGetter,
+		clock:  args.Clock,
+	}
+
+	if h.logger == nil {
+		h.logger = logger
+	}
+
+	return h
+}
+
+func (h *CreateAnnouncementHandler) Handle(ctx context.Context, cmd CreateAnnouncement) error {
+	const op = "cmd.CreateAnnouncementHandler.Handle"
+	span := trace.SpanFromContext(ctx)
+
+	a, err := announcement.New(announcement.CreateArgs{
+		AuthorID:  cmd.AuthorID,
+		Title:     cmd.Title,
+		Body:      cmd.Body,
+		GroupIDs:  cmd.GroupIDs,
+		SendEmail: cmd.SendEmail,
+		Clock:     h.clock,
+	})
+	if err != nil {
+		span.AddEvent("failed to create new announcement")
+		return errorx.Wrap(err, op)
+	}
+
+	for _, groupID := range a.GroupIDs() {
+		_, err := h.groups.GetGroupByID(ctx, groupID)
+		if err != nil {
+			span.AddEvent("failed to get group by ID")
+			if errorx.IsNotFound(err) {
+				return errorx.NewResourceNotFound(i18nx.FieldGroup).WithCause(err, op)
+			}
+			return errorx.Wrap(err, op)
+		}
+	}
+
+	err = h.repo.SaveAnnouncement(ctx, a)
+	if err != nil {
+		span.AddEvent("failed to save announcement")
+		return errorx.Wrap(err, op)
+	}
+
+	h.logger.InfoContext(ctx, "announcement published",
+		slog.String("announcement_id", a.ID().String()),
+		slog.String("author_id", cmd.AuthorID.String()),
+		slog.Int("groups_count", len(a.GroupIDs())))
+
+	return nil
+}
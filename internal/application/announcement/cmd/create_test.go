@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/announcement"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/group"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/builders"
+	"gitlab.com/ucmsv2/ucms-backend/tests/mocks"
+)
+
+func TestCreateAnnouncementHandler(t *testing.T) {
+	repo := mocks.NewAnnouncementRepo()
+	groups := mocks.NewGroupRepo()
+	target := builders.NewGroupBuilder().WithID(group.NewID()).WithName("SE-2402").Build()
+	groups.SeedGroup(t, target)
+	h := NewCreateAnnouncementHandler(CreateAnnouncementHandlerArgs{AnnouncementRepo: repo, GroupGetter: groups})
+	authorID := user.NewID()
+
+	err := h.Handle(t.Context(), CreateAnnouncement{
+		AuthorID:  authorID,
+		Title:     "Spring fest",
+		Body:      "See you on Friday in the main hall.",
+		GroupIDs:  []group.ID{target.ID()},
+		SendEmail: true,
+	})
+	require.NoError(t, err)
+
+	got, err := repo.ListAnnouncements(t.Context(), announcement.ListParams{All: true, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	assert.Equal(t, authorID, got[0].AuthorID())
+	assert.Equal(t, []group.ID{target.ID()}, got[0].GroupIDs())
+	assert.True(t, got[0].SendEmail())
+
+	published := mocks.RequireEventExists(t, repo.EventRepo, &announcement.Published{})
+	assert.Equal(t, got[0].ID(), published.AnnouncementID)
+}
+
+func TestCreateAnnouncementHandler_UnknownGroup(t *testing.T) {
+	repo := mocks.NewAnnouncementRepo()
+	h := NewCreateAnnouncementHandler(CreateAnnouncementHandlerArgs{
+		AnnouncementRepo: repo,
+		GroupGetter:      mocks.NewGroupRepo(),
+	})
+
+	err := h.Handle(t.Context(), CreateAnnouncement{
+		AuthorID: user.NewID(),
+		Title:    "Spring fest",
+		Body:     "See you on Friday in the main hall.",
+		GroupIDs: []group.ID{group.NewID()},
+	})
+	var i18nErr *errorx.I18nError
+	require.ErrorAs(t, err, &i18nErr)
+	assert.Equal(t, errorx.CodeNotFound, i18nErr.Code)
+	repo.AssertEventCount(t, 0)
+}
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"context"
+	"log/slog"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/announcement"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+)
+
+// UnpublishAnnouncement hides an announcement from the students, the staff
+// moderate the announcements with it.
+type UnpublishAnnouncement struct {
+	StaffID        user.ID
+	AnnouncementID announcement.ID
+}
+
+func (c UnpublishAnnouncement) SpanAttrs() map[string]any {
+	return map[string]any{
+		"staff_id":        c.StaffID.String(),
+		"announcement_id": c.AnnouncementID.String(),
+	}
+}
+
+type UnpublishAnnouncementHandler struct {
+	logger *slog.Logger
+	repo   AnnouncementRepo
+}
+
+type UnpublishAnnouncementHandlerArgs struct {
+	Logger           *slog.Logger
+	AnnouncementRepo AnnouncementRepo
+}
+
+func NewUnpublishAnnouncementHandler(args UnpublishAnnouncementHandlerArgs) *UnpublishAnnouncementHandler {
+	h := &UnpublishAnnouncementHandler{
+		logger: args.Logger,
+		repo:   args.AnnouncementRepo,
+	}
+
+	if h.logger == nil {
+		h.logger = logger
+	}
+
+	return h
+}
+
+func (h *UnpublishAnnouncementHandler) Handle(ctx context.Context, cmd UnpublishAnnouncement) error {
+	const op = "cmd.UnpublishAnnouncementHandler.Handle"
+	span := trace.SpanFromContext(ctx)
+
+	err := h.repo.UpdateAnnouncement(ctx, cmd.AnnouncementID, func(_ context.Context, a *announcement.Announcement) error {
+		a.Unpublish(cmd.StaffID)
+		return nil
+	})
+	if err != nil {
+		span.AddEvent("failed to unpublish announcement")
+		return errorx.Wrap(err, op)
+	}
+
+	h.logger.InfoContext(ctx, "announcement unpublished",
+		slog.String("announcement_id", cmd.AnnouncementID.String()),
+		slog.String("staff_id", cmd.StaffID.String()))
+
+	return nil
+}
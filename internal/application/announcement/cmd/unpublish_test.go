@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/announcement"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/fixtures"
+	"gitlab.com/ucmsv2/ucms-backend/tests/mocks"
+)
+
+func TestUnpublishAnnouncementHandler(t *testing.T) {
+	repo := mocks.NewAnnouncementRepo()
+	a, err := announcement.New(announcement.CreateArgs{
+		AuthorID: user.NewID(),
+		Title:    "Spring fest",
+		Body:     "See you on Friday in the main hall.",
+	})
+	require.NoError(t, err)
+	repo.SeedAnnouncement(t, a)
+	h := NewUnpublishAnnouncementHandler(UnpublishAnnouncementHandlerArgs{AnnouncementRepo: repo})
+
+	err = h.Handle(t.Context(), UnpublishAnnouncement{StaffID: fixtures.TestStaff.ID, AnnouncementID: a.ID()})
+	require.NoError(t, err)
+
+	got, err := repo.GetAnnouncement(t.Context(), a.ID())
+	require.NoError(t, err)
+	assert.False(t, got.IsPublished())
+	require.NotNil(t, got.UnpublishedBy())
+	assert.Equal(t, fixtures.TestStaff.ID, *got.UnpublishedBy())
+	mocks.RequireEventExists(t, repo.EventRepo, &announcement.Unpublished{})
+
+	visible, err := repo.ListAnnouncements(t.Context(), announcement.ListParams{Limit: 10})
+	require.NoError(t, err)
+	assert.Empty(t, visible)
+}
+
+func TestUnpublishAnnouncementHandler_Unknown(t *testing.T) {
+	h := NewUnpublishAnnouncementHandler(UnpublishAnnouncementHandlerArgs{AnnouncementRepo: mocks.NewAnnouncementRepo()})
+
+	err := h.Handle(t.Context(), UnpublishAnnouncement{StaffID: fixtures.TestStaff.ID, AnnouncementID: announcement.NewID()})
+	var i18nErr *errorx.I18nError
+	require.ErrorAs(t, err, &i18nErr)
+	assert.Equal(t, errorx.CodeNotFound, i18nErr.Code)
+}
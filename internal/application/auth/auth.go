@@ -17,6 +17,8 @@ import (
 	"go.opentelemetry.io/otel/trace"
 
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/emails"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
 	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
 	"gitlab.com/ucmsv2/ucms-backend/pkg/i18nx"
 	"gitlab.com/ucmsv2/ucms-backend/pkg/logging"
@@ -42,13 +44,32 @@ var ErrWrongEmailOrBarcodeOrPassword = errorx.NewUnauthorized().WithKey(i18nx.Ke
 type UserGetter interface {
 	GetUserByID(ctx context.Context, id user.ID) (*user.User, error)
 	GetUserByBarcode(ctx context.Context, barcode user.Barcode) (*user.User, error)
-	GetUserByEmail(ctx context.Context, email string) (*user.User, error)
+	GetUserByEmail(ctx context.Context, email emails.Email) (*user.User, error)
+}
+
+// EmailVerifier mails the email verification code to a user, see
+// user.User.RequestEmailVerification.
+type EmailVerifier interface {
+	RequestEmailVerification(ctx context.Context, id user.ID) error
+}
+
+// EmailVerifierFunc adapts a function to EmailVerifier.
+type EmailVerifierFunc func(ctx context.Context, id user.ID) error
+
+func (f EmailVerifierFunc) RequestEmailVerification(ctx context.Context, id user.ID) error {
+	return f(ctx, id)
 }
 
 type App struct {
 	tracer     trace.Tracer
 	logger     *slog.Logger
 	usergetter UserGetter
+	policy     LoginPolicy
+	// emailVerifier is nil when no code is mailed on login.
+	emailVerifier EmailVerifier
+	// impersonations is nil when the impersonation is not available.
+	impersonations ImpersonationRepo
+	clock          clock.Clock
 
 	accessTokenExpDuration  time.Duration
 	refreshTokenExpDuration time.Duration
@@ -61,6 +82,16 @@ type Args struct {
 	Tracer     trace.Tracer
 	Logger     *slog.Logger
 	UserGetter UserGetter
+	// LoginPolicy defaults to letting in every user with valid credentials.
+	LoginPolicy LoginPolicy
+	// Impersonations records the impersonation sessions, nil leaves the
+	// impersonation unavailable.
+	Impersonations ImpersonationRepo
+	// EmailVerifier is asked to mail a code to the users logging in without
+	// a verified email, nil mails none.
+	EmailVerifier EmailVerifier
+	// Clock defaults to clock.Real.
+	Clock clock.Clock
 
 	AccessTokenSecretKey    string
 	RefreshTokenSecretKey   string
@@ -70,9 +101,13 @@ type Args struct {
 
 func NewApp(args Args) *App {
 	app := &App{
-		tracer:     tracer,
-		logger:     logger,
-		usergetter: args.UserGetter,
+		tracer:         tracer,
+		logger:         logger,
+		usergetter:     args.UserGetter,
+		policy:         args.LoginPolicy,
+		impersonations: args.Impersonations,
+		emailVerifier:  args.EmailVerifier,
+		clock:          args.Clock,
 
 		accessTokenExpDuration:  AccessTokenExpDuration,
 		refreshTokenExpDuration: RefreshTokenExpDuration,
@@ -131,10 +166,22 @@ func (a *App) LoginHandle(ctx context.Context, cmd Login) (LoginResponse, error)
 	)
 	if cmd.IsEmail {
 		span.SetAttributes(attribute.String("user.email", logging.RedactEmail(cmd.EmailOrBarcode)))
-		u, err = a.usergetter.GetUserByEmail(ctx, cmd.EmailOrBarcode)
+		var email emails.Email
+		email, err = emails.New(cmd.EmailOrBarcode)
+		if err != nil {
+			otelx.RecordSpanError(span, err, "invalid email")
+			return LoginResponse{}, ErrWrongEmailOrBarcodeOrPassword.WithCause(err, op)
+		}
+		u, err = a.usergetter.GetUserByEmail(ctx, email)
 	} else {
 		span.SetAttributes(attribute.String("user.Barcode", cmd.EmailOrBarcode))
-		u, err = a.usergetter.GetUserByBarcode(ctx, user.Barcode(cmd.EmailOrBarcode))
+		var barcode user.Barcode
+		barcode, err = user.NewBarcode(cmd.EmailOrBarcode)
+		if err != nil {
+			otelx.RecordSpanError(span, err, "invalid barcode")
+			return LoginResponse{}, ErrWrongEmailOrBarcodeOrPassword.WithCause(err, op)
+		}
+		u, err = a.usergetter.GetUserByBarcode(ctx, barcode)
 	}
 	if err != nil {
 		otelx.RecordSpanError(span, err, "failed to get user by email or barcode")
@@ -149,14 +196,20 @@ func (a *App) LoginHandle(ctx context.Context, cmd Login) (LoginResponse, error)
 		otelx.RecordSpanError(span, err, "failed to compare user password")
 		return LoginResponse{}, ErrWrongEmailOrBarcodeOrPassword.WithCause(err, op)
 	}
+	if err := a.checkPolicy(ctx, u); err != nil {
+		otelx.RecordSpanError(span, err, "login policy denied user")
+		return LoginResponse{}, errorx.Wrap(err, op)
+	}
+	a.requestEmailVerification(ctx, u)
 
 	accessToken := jwt.NewWithClaims(a.signingMethod, jwt.MapClaims{
-		"iss":       ISS,
-		"sub":       UserSubject,
-		"exp":       time.Now().Add(a.accessTokenExpDuration).Unix(),
-		"iat":       time.Now().Unix(),
-		"uid":       u.ID().String(),
-		"user_role": u.Role().String(),
+		"iss":        ISS,
+		"sub":        UserSubject,
+		"exp":        time.Now().Add(a.accessTokenExpDuration).Unix(),
+		"iat":        time.Now().Unix(),
+		"uid":        u.ID().String(),
+		"user_role":  u.Role().String(),
+		"user_roles": u.Roles().Strings(),
 	})
 	refreshToken := jwt.NewWithClaims(a.signingMethod, jwt.MapClaims{
 		"iss":   ISS,
@@ -189,6 +242,9 @@ func (a *App) LoginHandle(ctx context.Context, cmd Login) (LoginResponse, error)
 
 type Refresh struct {
 	RefreshToken string
+	// AccessToken is the access token being replaced, if any. The
+	// impersonation sessions are not refreshed, see ImpersonateHandle.
+	AccessToken string
 }
 
 func (a *App) RefreshHandle(ctx context.Context, cmd Refresh) (LoginResponse, error) {
@@ -204,6 +260,12 @@ func (a *App) RefreshHandle(ctx context.Context, cmd Refresh) (LoginResponse, er
 	)
 	defer span.End()
 
+	if a.isImpersonationToken(cmd.AccessToken) {
+		err := errors.New("impersonation sessions are not refreshed")
+		otelx.RecordSpanError(span, err, "refresh of an impersonation session")
+		return LoginResponse{}, errorx.NewInvalidCredentials().WithCause(err, op)
+	}
+
 	refreshToken, err := jwt.Parse(
 		cmd.RefreshToken,
 		func(t *jwt.Token) (any, error) { return a.refreshTokenSecretKey, nil },
@@ -253,14 +315,19 @@ func (a *App) RefreshHandle(ctx context.Context, cmd Refresh) (LoginResponse, er
 		otelx.RecordSpanError(span, err, "failed to get user by id from refresh token claims")
 		return LoginResponse{}, errorx.NewInternalError().WithCause(err, op)
 	}
+	if err := a.checkPolicy(ctx, u); err != nil {
+		otelx.RecordSpanError(span, err, "login policy denied user")
+		return LoginResponse{}, errorx.Wrap(err, op)
+	}
 
 	accessToken := jwt.NewWithClaims(a.signingMethod, jwt.MapClaims{
-		"iss":       ISS,
-		"sub":       UserSubject,
-		"exp":       time.Now().Add(a.accessTokenExpDuration).Unix(),
-		"iat":       time.Now().Unix(),
-		"uid":       u.ID().String(),
-		"user_role": u.Role().String(),
+		"iss":        ISS,
+		"sub":        UserSubject,
+		"exp":        time.Now().Add(a.accessTokenExpDuration).Unix(),
+		"iat":        time.Now().Unix(),
+		"uid":        u.ID().String(),
+		"user_role":  u.Role().String(),
+		"user_roles": u.Roles().Strings(),
 	})
 
 	accessjwt, err := accessToken.SignedString(a.accessTokenSecretKey)
@@ -277,6 +344,28 @@ func (a *App) RefreshHandle(ctx context.Context, cmd Refresh) (LoginResponse, er
 	}, nil
 }
 
+func (a *App) checkPolicy(ctx context.Context, u *user.User) error {
+	if a.policy == nil {
+		return nil
+	}
+	return a.policy.CanLogIn(ctx, u)
+}
+
+// requestEmailVerification mails a code to the user when their email is not
+// verified. The login goes on when it fails, the user is only kept out of
+// the endpoints that need the verification and gets a code on the next login.
+func (a *App) requestEmailVerification(ctx context.Context, u *user.User) {
+	if a.emailVerifier == nil || u.EmailVerified() {
+		return
+	}
+	if err := a.emailVerifier.RequestEmailVerification(ctx, u.ID()); err != nil {
+		otelx.RecordSpanError(trace.SpanFromContext(ctx), err, "failed to request email verification")
+		a.logger.WarnContext(ctx, "failed to request email verification",
+			slog.String("user.id", u.ID().String()),
+			slog.String("error", err.Error()))
+	}
+}
+
 type JWTTokenAssertion struct {
 	token    string
 	jwttoken *jwt.Token
@@ -375,8 +464,37 @@ func (a *JWTTokenAssertion) AssertUID(expected string) *JWTTokenAssertion {
 	return a
 }
 
+// AssertActor checks the actor claim of an impersonation token, an empty
+// expected checks that there is none.
+func (a *JWTTokenAssertion) AssertActor(expected string) *JWTTokenAssertion {
+	a.t.Helper()
+	act, ok := a.claims[ActorClaim]
+	if expected == "" {
+		assert.False(a.t, ok, "unexpected actor claim: %v", act)
+		return a
+	}
+	require.True(a.t, ok, "actor claim not found")
+	actor, ok := act.(map[string]any)
+	require.True(a.t, ok, "actor claim must be an object, got %T", act)
+	assert.Equal(a.t, expected, actor["uid"])
+	return a
+}
+
 func (a *JWTTokenAssertion) AssertUserRole(expected string) *JWTTokenAssertion {
 	a.t.Helper()
 	assert.Equal(a.t, a.claims["user_role"], expected)
 	return a
 }
+
+// AssertUserRoles checks the roles claim, in the order of roles.Set.
+func (a *JWTTokenAssertion) AssertUserRoles(expected ...string) *JWTTokenAssertion {
+	a.t.Helper()
+	got, ok := a.claims["user_roles"].([]any)
+	require.True(a.t, ok, "user_roles claim must be an array, got %T", a.claims["user_roles"])
+	want := make([]any, len(expected))
+	for i, role := range expected {
+		want[i] = role
+	}
+	assert.Equal(a.t, want, got)
+	return a
+}
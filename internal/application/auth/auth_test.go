@@ -1,13 +1,19 @@
 package authapp_test
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"testing"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	authapp "gitlab.com/ucmsv2/ucms-backend/internal/application/auth"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
 	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
 	"gitlab.com/ucmsv2/ucms-backend/tests/integration/builders"
 	"gitlab.com/ucmsv2/ucms-backend/tests/integration/fixtures"
@@ -82,7 +88,7 @@ func TestLoginHandle_HappyPath(t *testing.T) {
 
 	t.Run("with email", func(t *testing.T) {
 		res, err := s.App.LoginHandle(t.Context(), authapp.Login{
-			EmailOrBarcode: u.Email(),
+			EmailOrBarcode: u.Email().String(),
 			IsEmail:        true,
 			Password:       password,
 		})
@@ -107,6 +113,19 @@ func TestLoginHandle_HappyPath(t *testing.T) {
 		require.NotEmpty(t, res.RefreshToken)
 		s.assertRefreshToken(t, res.RefreshToken, u.ID().String())
 	})
+
+	t.Run("with mixed case barcode", func(t *testing.T) {
+		lettered := builders.NewUserBuilder().WithBarcode("ABC123").WithPassword(password).Build()
+		s.MockUserRepo.SeedUser(t, lettered)
+
+		res, err := s.App.LoginHandle(t.Context(), authapp.Login{
+			EmailOrBarcode: "aBc123",
+			IsEmail:        false,
+			Password:       password,
+		})
+		require.NoError(t, err)
+		s.assertAccessToken(t, res.AccessToken, lettered.ID().String(), lettered.Role().String())
+	})
 }
 
 func TestLoginHandle_FailPath(t *testing.T) {
@@ -152,7 +171,7 @@ func TestLoginHandle_FailPath(t *testing.T) {
 		{
 			name: "valid email, but IsEmail is false",
 			cmd: authapp.Login{
-				EmailOrBarcode: u.Email(),
+				EmailOrBarcode: u.Email().String(),
 				IsEmail:        false,
 				Password:       password,
 			},
@@ -170,7 +189,7 @@ func TestLoginHandle_FailPath(t *testing.T) {
 		{
 			name: "invalid password, but valid email",
 			cmd: authapp.Login{
-				EmailOrBarcode: u.Email(),
+				EmailOrBarcode: u.Email().String(),
 				IsEmail:        true,
 				Password:       wrongPassword,
 			},
@@ -206,7 +225,7 @@ func TestLoginHandle_FailPath(t *testing.T) {
 		{
 			name: "empty password",
 			cmd: authapp.Login{
-				EmailOrBarcode: u.Email(),
+				EmailOrBarcode: u.Email().String(),
 				IsEmail:        true,
 				Password:       "",
 			},
@@ -247,7 +266,7 @@ func TestRefreshHandle_HappyPath(t *testing.T) {
 	s.MockUserRepo.SeedUser(t, u)
 
 	loginRes, err := s.App.LoginHandle(t.Context(), authapp.Login{
-		EmailOrBarcode: u.Email(),
+		EmailOrBarcode: u.Email().String(),
 		IsEmail:        true,
 		Password:       password,
 	})
@@ -351,3 +370,191 @@ func TestRefreshHandle_FailPath(t *testing.T) {
 		})
 	}
 }
+
+func TestLoginHandle_EnrollmentPolicy(t *testing.T) {
+	t.Parallel()
+
+	userRepo := mocks.NewUserRepo()
+	studentRepo := mocks.NewStudentRepo()
+	app := authapp.NewApp(authapp.Args{
+		UserGetter:            userRepo,
+		LoginPolicy:           authapp.NewEnrollmentPolicy(studentRepo),
+		AccessTokenSecretKey:  fixtures.AccessTokenSecretKey,
+		RefreshTokenSecretKey: fixtures.RefreshTokenSecretKey,
+	})
+	password := fixtures.TestStudent.Password
+	leaveUntil := time.Now().AddDate(0, 6, 0)
+
+	tests := []struct {
+		name       string
+		status     user.EnrollmentStatus
+		leaveUntil *time.Time
+		wantCode   errorx.Code
+	}{
+		{name: "enrolled", status: user.Enrolled},
+		{name: "on academic leave", status: user.AcademicLeave, leaveUntil: &leaveUntil},
+		{name: "graduated", status: user.Graduated},
+		{name: "expelled", status: user.Expelled, wantCode: errorx.CodeStudentExpelled},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			student := builders.NewStudentBuilder().
+				WithID(user.ID(uuid.New())).
+				WithEmail(fmt.Sprintf("student%d@example.com", i)).
+				WithBarcode(user.Barcode(fmt.Sprintf("STU10%d", i))).
+				WithPassword(password).
+				WithEnrollment(tt.status, tt.leaveUntil, "").
+				Build()
+			studentRepo.SeedStudent(t, student)
+			userRepo.SeedUser(t, student.User())
+
+			res, err := app.LoginHandle(t.Context(), authapp.Login{
+				EmailOrBarcode: student.User().Barcode().String(),
+				Password:       password,
+			})
+			if tt.wantCode == "" {
+				require.NoError(t, err)
+				assert.NotEmpty(t, res.AccessToken)
+				return
+			}
+			var i18nErr *errorx.I18nError
+			require.ErrorAs(t, err, &i18nErr)
+			assert.Equal(t, tt.wantCode, i18nErr.Code)
+			assert.Equal(t, 403, i18nErr.HTTPStatusCode())
+		})
+	}
+
+	t.Run("staff are not checked", func(t *testing.T) {
+		staff := builders.NewUserBuilder().AsStaff().WithPassword(password).Build()
+		userRepo.SeedUser(t, staff)
+
+		_, err := app.LoginHandle(t.Context(), authapp.Login{
+			EmailOrBarcode: staff.Email().String(),
+			IsEmail:        true,
+			Password:       password,
+		})
+		require.NoError(t, err)
+	})
+}
+
+func TestLoginHandle_EmailVerification(t *testing.T) {
+	t.Parallel()
+
+	userRepo := mocks.NewUserRepo()
+	var requested []user.ID
+	verifierErr := error(nil)
+	app := authapp.NewApp(authapp.Args{
+		UserGetter: userRepo,
+		EmailVerifier: authapp.EmailVerifierFunc(func(_ context.Context, id user.ID) error {
+			requested = append(requested, id)
+			return verifierErr
+		}),
+		AccessTokenSecretKey:  fixtures.AccessTokenSecretKey,
+		RefreshTokenSecretKey: fixtures.RefreshTokenSecretKey,
+	})
+	password := fixtures.TestStaff.Password
+	login := func(t *testing.T, u *user.User) {
+		t.Helper()
+		res, err := app.LoginHandle(t.Context(), authapp.Login{
+			EmailOrBarcode: u.Email().String(),
+			IsEmail:        true,
+			Password:       password,
+		})
+		require.NoError(t, err)
+		assert.NotEmpty(t, res.AccessToken)
+	}
+
+	verified := builders.NewUserBuilder().AsStaff().WithPassword(password).Build()
+	userRepo.SeedUser(t, verified)
+	login(t, verified)
+	assert.Empty(t, requested)
+
+	unverified := builders.NewUserBuilder().AsStaff().WithPassword(password).WithEmailUnverified().Build()
+	userRepo.SeedUser(t, unverified)
+	login(t, unverified)
+	assert.Equal(t, []user.ID{unverified.ID()}, requested)
+
+	// The login does not depend on the mail.
+	verifierErr = errors.New("mail is down")
+	login(t, unverified)
+	assert.Len(t, requested, 2)
+}
+
+func TestImpersonateHandle(t *testing.T) {
+	t.Parallel()
+
+	userRepo := mocks.NewUserRepo()
+	clk := clock.NewFake(time.Now().Add(time.Hour).Truncate(time.Second))
+	app := authapp.NewApp(authapp.Args{
+		UserGetter:            userRepo,
+		Impersonations:        userRepo,
+		Clock:                 clk,
+		AccessTokenSecretKey:  fixtures.AccessTokenSecretKey,
+		RefreshTokenSecretKey: fixtures.RefreshTokenSecretKey,
+	})
+	admin := builders.NewUserBuilder().AsStaff().WithBarcode(fixtures.TestStaff.Barcode).Build()
+	otherAdmin := builders.NewUserBuilder().AsStaff().WithBarcode(fixtures.TestStaff2.Barcode).Build()
+	student := builders.NewUserBuilder().WithBarcode(fixtures.TestStudent.Barcode).Build()
+	for _, u := range []*user.User{admin, otherAdmin, student} {
+		userRepo.SeedUser(t, u)
+	}
+
+	res, err := app.ImpersonateHandle(t.Context(), authapp.Impersonate{
+		ActorID:       admin.ID(),
+		TargetBarcode: student.Barcode().String(),
+		IP:            "203.0.113.7",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, student.ID(), res.TargetID)
+	assert.Equal(t, user.ImpersonationTTL, res.AccessTokenExp)
+
+	authapp.NewJWTTokenAssertion(t, res.AccessToken, []byte(fixtures.AccessTokenSecretKey)).
+		AssertValid().
+		AssertISS(authapp.ISS).
+		AssertSub(authapp.UserSubject).
+		AssertExp(clk.Now().Add(user.ImpersonationTTL)).
+		AssertUID(student.ID().String()).
+		AssertUserRole(student.Role().String()).
+		AssertActor(admin.ID().String()).
+		AssertJTINotEmpty()
+
+	imps, err := app.ListImpersonationsHandle(t.Context(), authapp.ListImpersonations{})
+	require.NoError(t, err)
+	require.Len(t, imps, 1)
+	assert.Equal(t, admin.ID().String(), imps[0].ActorID)
+	assert.Equal(t, student.ID().String(), imps[0].TargetID)
+	assert.Equal(t, "203.0.113.7", imps[0].IP)
+
+	t.Run("staff target", func(t *testing.T) {
+		_, err := app.ImpersonateHandle(t.Context(), authapp.Impersonate{
+			ActorID:       admin.ID(),
+			TargetBarcode: otherAdmin.Barcode().String(),
+		})
+		assert.True(t, errorx.IsCode(err, errorx.CodeForbidden), "expected forbidden, got: %v", err)
+
+		imps, err := app.ListImpersonationsHandle(t.Context(), authapp.ListImpersonations{})
+		require.NoError(t, err)
+		assert.Len(t, imps, 1, "a denied impersonation is not recorded")
+	})
+
+	t.Run("refresh is disabled", func(t *testing.T) {
+		login, err := app.LoginHandle(t.Context(), authapp.Login{
+			EmailOrBarcode: admin.Barcode().String(),
+			Password:       fixtures.TestStudent.Password,
+		})
+		require.NoError(t, err)
+
+		_, err = app.RefreshHandle(t.Context(), authapp.Refresh{
+			RefreshToken: login.RefreshToken,
+			AccessToken:  res.AccessToken,
+		})
+		assert.True(t, errorx.IsCode(err, errorx.CodeInvalidCredentials), "expected invalid credentials, got: %v", err)
+
+		_, err = app.RefreshHandle(t.Context(), authapp.Refresh{
+			RefreshToken: login.RefreshToken,
+			AccessToken:  login.AccessToken,
+		})
+		assert.NoError(t, err)
+	})
+}
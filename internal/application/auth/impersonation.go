@@ -0,0 +1,200 @@
+package authapp
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/httpx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx"
+)
+
+// ActorClaim is the access token claim of the staff member impersonating the
+// user of the token, {"uid": "<staff id>"}. It is the act claim of RFC 8693.
+const ActorClaim = "act"
+
+const (
+	DefaultImpersonationsPageSize = 50
+	MaxImpersonationsPageSize     = 200
+)
+
+type ImpersonationRepo interface {
+	SaveImpersonation(ctx context.Context, imp *user.Impersonation) error
+	// ListImpersonations lists the sessions, the latest first.
+	ListImpersonations(ctx context.Context, limit, offset int) ([]*user.Impersonation, error)
+}
+
+type Impersonate struct {
+	ActorID       user.ID
+	TargetBarcode string
+	IP            string
+}
+
+type ImpersonateResponse struct {
+	AccessToken    string
+	AccessTokenExp time.Duration
+	TargetID       user.ID
+}
+
+// ImpersonateHandle starts a session of the staff member acting as the user
+// of the barcode and returns its access token. The token carries the actor in
+// ActorClaim and expires after user.ImpersonationTTL, there is no refresh
+// token for it.
+func (a *App) ImpersonateHandle(ctx context.Context, cmd Impersonate) (ImpersonateResponse, error) {
+	const op = "authapp.App.ImpersonateHandle"
+	ctx, span := a.tracer.Start(ctx, "App.ImpersonateHandle", trace.WithAttributes(
+		attribute.String("actor.id", cmd.ActorID.String()),
+		attribute.String("target.barcode", cmd.TargetBarcode),
+	))
+	defer span.End()
+
+	if a.impersonations == nil {
+		err := errors.New("impersonation repository is not configured")
+		otelx.RecordSpanError(span, err, "impersonation is not available")
+		return ImpersonateResponse{}, errorx.NewServiceUnavailable().WithCause(err, op)
+	}
+
+	barcode, err := user.NewBarcode(cmd.TargetBarcode)
+	if err != nil {
+		otelx.RecordSpanError(span, err, "invalid barcode")
+		return ImpersonateResponse{}, errorx.Wrap(err, op)
+	}
+	actor, err := a.usergetter.GetUserByID(ctx, cmd.ActorID)
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to get actor")
+		return ImpersonateResponse{}, errorx.Wrap(err, op)
+	}
+	target, err := a.usergetter.GetUserByBarcode(ctx, barcode)
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to get target")
+		return ImpersonateResponse{}, errorx.Wrap(err, op)
+	}
+
+	imp, err := user.NewImpersonation(actor, target, cmd.IP, clock.Or(a.clock).Now())
+	if err != nil {
+		otelx.RecordSpanError(span, err, "impersonation denied")
+		return ImpersonateResponse{}, errorx.Wrap(err, op)
+	}
+	span.SetAttributes(attribute.String("target.id", target.ID().String()))
+
+	// The session is recorded before its token exists, a token is never out
+	// without its audit.
+	if err := a.impersonations.SaveImpersonation(ctx, imp); err != nil {
+		otelx.RecordSpanError(span, err, "failed to save impersonation")
+		return ImpersonateResponse{}, errorx.Wrap(err, op)
+	}
+
+	accessToken := jwt.NewWithClaims(a.signingMethod, jwt.MapClaims{
+		"iss":        ISS,
+		"sub":        UserSubject,
+		"exp":        imp.ExpiresAt().Unix(),
+		"iat":        imp.StartedAt().Unix(),
+		"jti":        imp.ID().String(),
+		"uid":        target.ID().String(),
+		"user_role":  target.Role().String(),
+		"user_roles": target.Roles().Strings(),
+		ActorClaim:   map[string]any{"uid": actor.ID().String()},
+	})
+	accessjwt, err := accessToken.SignedString(a.accessTokenSecretKey)
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to sign access token")
+		return ImpersonateResponse{}, errorx.Wrap(err, op)
+	}
+
+	a.logger.InfoContext(ctx, "impersonation started",
+		"actor_id", actor.ID().String(),
+		"target_id", target.ID().String(),
+		"impersonation_id", imp.ID().String())
+
+	return ImpersonateResponse{
+		AccessToken:    accessjwt,
+		AccessTokenExp: user.ImpersonationTTL,
+		TargetID:       target.ID(),
+	}, nil
+}
+
+type ImpersonationResponse struct {
+	ID         string     `json:"id"`
+	ActorID    string     `json:"actor_id"`
+	TargetID   string     `json:"target_id"`
+	TargetRole string     `json:"target_role"`
+	IP         string     `json:"ip"`
+	StartedAt  httpx.Time `json:"started_at"`
+	ExpiresAt  httpx.Time `json:"expires_at"`
+}
+
+type ListImpersonations struct {
+	// Page starts at 1.
+	Page int
+	// PageSize defaults to DefaultImpersonationsPageSize and is capped at
+	// MaxImpersonationsPageSize.
+	PageSize int
+}
+
+// ListImpersonationsHandle lists the impersonation sessions, the latest first.
+func (a *App) ListImpersonationsHandle(ctx context.Context, query ListImpersonations) ([]ImpersonationResponse, error) {
+	const op = "authapp.App.ListImpersonationsHandle"
+	ctx, span := a.tracer.Start(ctx, "App.ListImpersonationsHandle")
+	defer span.End()
+
+	if a.impersonations == nil {
+		err := errors.New("impersonation repository is not configured")
+		otelx.RecordSpanError(span, err, "impersonation is not available")
+		return nil, errorx.NewServiceUnavailable().WithCause(err, op)
+	}
+
+	if query.Page < 1 {
+		query.Page = 1
+	}
+	if query.PageSize <= 0 {
+		query.PageSize = DefaultImpersonationsPageSize
+	}
+	query.PageSize = min(query.PageSize, MaxImpersonationsPageSize)
+
+	imps, err := a.impersonations.ListImpersonations(ctx, query.PageSize, (query.Page-1)*query.PageSize)
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to list impersonations")
+		return nil, errorx.Wrap(err, op)
+	}
+
+	res := make([]ImpersonationResponse, len(imps))
+	for i, imp := range imps {
+		res[i] = ImpersonationResponse{
+			ID:         imp.ID().String(),
+			ActorID:    imp.ActorID().String(),
+			TargetID:   imp.TargetID().String(),
+			TargetRole: imp.TargetRole().String(),
+			IP:         imp.IP(),
+			StartedAt:  httpx.NewTime(imp.StartedAt()),
+			ExpiresAt:  httpx.NewTime(imp.ExpiresAt()),
+		}
+	}
+	return res, nil
+}
+
+// isImpersonationToken reports whether token is an impersonation access
+// token, expired or not.
+func (a *App) isImpersonationToken(token string) bool {
+	if token == "" {
+		return false
+	}
+	parsed, err := jwt.Parse(token, func(t *jwt.Token) (any, error) {
+		return a.accessTokenSecretKey, nil
+	}, jwt.WithValidMethods([]string{a.signingMethod.Alg()}), jwt.WithoutClaimsValidation())
+	if err != nil {
+		return false
+	}
+	claims, ok := parsed.Claims.(jwt.MapClaims)
+	if !ok {
+		return false
+	}
+	_, ok = claims[ActorClaim]
+	return ok
+}
@@ -0,0 +1,47 @@
+package authapp
+
+import (
+	"context"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+)
+
+// LoginPolicy decides whether a user who proved their identity gets tokens.
+// It is asked on login and on refresh.
+type LoginPolicy interface {
+	CanLogIn(ctx context.Context, u *user.User) error
+}
+
+type StudentGetter interface {
+	GetStudentByID(ctx context.Context, id user.ID) (*user.Student, error)
+}
+
+// EnrollmentPolicy keeps the expelled students out, see user.Student.CanLogIn.
+type EnrollmentPolicy struct {
+	students StudentGetter
+}
+
+func NewEnrollmentPolicy(students StudentGetter) *EnrollmentPolicy {
+	if students == nil {
+		panic("students getter is required")
+	}
+	return &EnrollmentPolicy{students: students}
+}
+
+func (p *EnrollmentPolicy) CanLogIn(ctx context.Context, u *user.User) error {
+	const op = "authapp.EnrollmentPolicy.CanLogIn"
+	if !u.Role().IsStudentLike() {
+		return nil
+	}
+
+	student, err := p.students.GetStudentByID(ctx, u.ID())
+	if errorx.IsNotFound(err) {
+		return nil // The user has no student record to check.
+	}
+	if err != nil {
+		return errorx.Wrap(err, op)
+	}
+
+	return student.CanLogIn()
+}
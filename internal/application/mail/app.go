@@ -1,25 +1,91 @@
 package mail
 
 import (
+	"context"
+	"errors"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+
 	mailevent "gitlab.com/ucmsv2/ucms-backend/internal/application/mail/event"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx/metrics"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/urlx"
+)
+
+// Kinds of the mail senders reported on the ucms.mail.sender_kind gauge.
+const (
+	// SenderKindMock keeps the emails in memory, see Args.AllowMockSender.
+	SenderKindMock = "mock"
+	// SenderKindUnknown is the kind of the senders not reporting one.
+	SenderKindUnknown = "unknown"
 )
 
+// ErrMockSender is returned by NewApp for a mock sender it is not allowed
+// to use, the emails would silently go nowhere.
+var ErrMockSender = errors.New("the mail sender is a mock that delivers nothing, configure a real sender or run in a mode allowing fake mail")
+
+// SenderKinder is implemented by the mail senders reporting their kind.
+type SenderKinder interface {
+	SenderKind() string
+}
+
+// SenderKind returns the kind of s, SenderKindUnknown when it does not report
+// one.
+func SenderKind(s mailevent.MailSender) string {
+	if k, ok := s.(SenderKinder); ok {
+		return k.SenderKind()
+	}
+	return SenderKindUnknown
+}
+
 type App struct {
 	Event *mailevent.MailEventHandler
 }
 
 type Args struct {
 	Mailsender              mailevent.MailSender
-	StaffInvitationBaseURL  string
+	StaffInvitationLinkURL  urlx.URL
 	InvitationCreatorGetter mailevent.InvitationCreatorGetter
+	RecipientLister         mailevent.AnnouncementRecipientLister
+	// AllowMockSender lets Mailsender be a mock, only the tests and the
+	// modes allowing env.CapFakeMail set it.
+	AllowMockSender bool
+	// Metrics defaults to metrics.Default.
+	Metrics *metrics.Registry
 }
 
-func NewApp(args Args) *App {
+// NewApp creates the mail App and reports the kind of its sender on the
+// ucms.mail.sender_kind gauge. It returns ErrMockSender for a mock sender
+// unless args.AllowMockSender is set.
+func NewApp(args Args) (*App, error) {
+	const op = "mail.NewApp"
+	kind := SenderKind(args.Mailsender)
+	if kind == SenderKindMock && !args.AllowMockSender {
+		return nil, errorx.Wrap(ErrMockSender, op)
+	}
+	if args.Metrics == nil {
+		args.Metrics = metrics.Default()
+	}
+
+	attrs := metric.WithAttributes(attribute.String(metrics.AttrSenderKind, kind))
+	err := args.Metrics.RegisterInt64Gauge(metrics.MailSenderKind,
+		func(_ context.Context, o metric.Int64Observer) error {
+			o.Observe(1, attrs)
+			return nil
+		},
+		metric.WithDescription("Kind of the mail sender of the instance, always 1"),
+	)
+	if err != nil {
+		return nil, errorx.Wrap(err, op)
+	}
+
 	return &App{
 		Event: mailevent.NewMailEventHandler(mailevent.MailEventHandlerArgs{
 			Mailsender:              args.Mailsender,
-			StaffInvitationBaseURL:  args.StaffInvitationBaseURL,
+			StaffInvitationLinkURL:  args.StaffInvitationLinkURL,
 			InvitationCreatorGetter: args.InvitationCreatorGetter,
+			RecipientLister:         args.RecipientLister,
 		}),
-	}
+	}, nil
 }
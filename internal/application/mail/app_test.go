@@ -0,0 +1,30 @@
+package mail
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/metric/noop"
+
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx/metrics"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/urlx"
+	"gitlab.com/ucmsv2/ucms-backend/tests/mocks"
+)
+
+func TestNewApp_MockSender(t *testing.T) {
+	args := Args{
+		Mailsender:             mocks.NewMockMailSender(),
+		StaffInvitationLinkURL: urlx.MustParse("http://localhost:3000/invitations/staff"),
+		Metrics:                metrics.NewRegistry(noop.NewMeterProvider().Meter("test")),
+	}
+	assert.Equal(t, SenderKindMock, SenderKind(args.Mailsender))
+
+	_, err := NewApp(args)
+	require.ErrorIs(t, err, ErrMockSender)
+
+	args.AllowMockSender = true
+	app, err := NewApp(args)
+	require.NoError(t, err)
+	assert.NotNil(t, app.Event)
+}
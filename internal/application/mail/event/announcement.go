@@ -0,0 +1,65 @@
+package mailevent
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/announcement"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/mails"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/logging"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx"
+)
+
+const AnnouncementSubjectPrefix = "AITUSA: "
+
+// HandleAnnouncementPublished mails the announcement to its students when its
+// author asked for it, the notification center delivers it either way.
+func (h *MailEventHandler) HandleAnnouncementPublished(ctx context.Context, e *announcement.Published) error {
+	if e == nil || !e.SendEmail {
+		return nil
+	}
+	const op = "mailevent.MailEventHandler.HandleAnnouncementPublished"
+	ctx, span := h.tracer.Start(ctx, "MailEventHandler.HandleAnnouncementPublished",
+		trace.WithAttributes(
+			attribute.String("announcement.id", e.AnnouncementID.String()),
+			attribute.Int("announcement.groups_count", len(e.GroupIDs)),
+		),
+	)
+	defer span.End()
+
+	l := h.logger.With(
+		slog.String("event", "AnnouncementPublished"),
+		slog.String("announcement.id", e.AnnouncementID.String()),
+	)
+
+	recipients, err := h.recipientLister.ListAnnouncementRecipients(ctx, e.GroupIDs)
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to list announcement recipients")
+		l.ErrorContext(ctx, "failed to list announcement recipients", slog.String("error", err.Error()))
+		return errorx.Wrap(err, op)
+	}
+	span.SetAttributes(attribute.Int("announcement.recipients_count", len(recipients)))
+
+	for _, recipient := range recipients {
+		payload := mails.Payload{
+			To:      recipient.Email,
+			Subject: AnnouncementSubjectPrefix + e.Title,
+			Body:    fmt.Sprintf("%s\n\n%s\n\nBest regards,\nAITUSA", e.Title, e.Body),
+		}
+		if err := h.mailsender.SendMail(ctx, payload); err != nil {
+			otelx.RecordSpanError(span, err, "failed to send announcement email")
+			l.ErrorContext(ctx, "failed to send announcement email",
+				slog.String("email", logging.RedactEmail(recipient.Email)),
+				slog.String("error", err.Error()),
+			)
+			// Continue sending emails to other recipients even if one fails
+		}
+	}
+
+	return nil
+}
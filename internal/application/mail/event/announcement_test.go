@@ -0,0 +1,46 @@
+package mailevent
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/announcement"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/event"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/group"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/urlx"
+	"gitlab.com/ucmsv2/ucms-backend/tests/mocks"
+)
+
+func TestHandleAnnouncementPublished(t *testing.T) {
+	mailsender := mocks.NewMockMailSender()
+	recipients := mocks.NewAnnouncementRepo()
+	target := group.NewID()
+	recipients.SeedRecipient(target, announcement.Recipient{UserID: user.NewID(), Email: "student@test.com"})
+	recipients.SeedRecipient(group.NewID(), announcement.Recipient{UserID: user.NewID(), Email: "other@test.com"})
+	h := NewMailEventHandler(MailEventHandlerArgs{
+		Mailsender:             mailsender,
+		StaffInvitationLinkURL: urlx.MustParse("https://ucms.kz/invitations/accept/"),
+		RecipientLister:        recipients,
+	})
+
+	e := &announcement.Published{
+		Header:         event.NewEventHeader(),
+		AnnouncementID: announcement.NewID(),
+		Title:          "Spring fest",
+		Body:           "See you on Friday in the main hall.",
+		GroupIDs:       []group.ID{target},
+	}
+	require.NoError(t, h.HandleAnnouncementPublished(t.Context(), e))
+	assert.Empty(t, mailsender.MailsTo("student@test.com"), "without send_email")
+
+	e.SendEmail = true
+	require.NoError(t, h.HandleAnnouncementPublished(t.Context(), e))
+	sent := mailsender.MailsTo("student@test.com")
+	require.Len(t, sent, 1)
+	assert.Equal(t, AnnouncementSubjectPrefix+"Spring fest", sent[0].Subject)
+	assert.Contains(t, sent[0].Body, "main hall")
+	assert.Empty(t, mailsender.MailsTo("other@test.com"))
+}
@@ -0,0 +1,59 @@
+package mailevent
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/ARUMANDESU/validation"
+	"github.com/ARUMANDESU/validation/is"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/mails"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/logging"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx"
+)
+
+const EmailVerificationRequestedSubject = "Confirm Your Email"
+
+func (h *MailEventHandler) HandleEmailVerificationRequested(ctx context.Context, e *user.EmailVerificationRequested) error {
+	const op = "mailevent.MailEventHandler.HandleEmailVerificationRequested"
+
+	l := h.logger.With(slog.String("event", "EmailVerificationRequested"), slog.String("user.id", e.UserID.String()))
+	ctx, span := h.tracer.Start(
+		ctx,
+		"MailEventHandler.HandleEmailVerificationRequested",
+		trace.WithAttributes(
+			attribute.String("event.user.id", e.UserID.String()),
+			attribute.String("event.user.email", logging.RedactEmail(e.Email)),
+		),
+	)
+	defer span.End()
+
+	err := validation.ValidateStruct(e,
+		validation.Field(&e.Email, validation.Required, is.EmailFormat),
+		validation.Field(&e.VerificationCode, validation.Required),
+	)
+	if err != nil {
+		otelx.RecordSpanError(span, err, "validation failed")
+		l.ErrorContext(ctx, "validation failed", slog.Any("error", err))
+		return errorx.Wrap(err, op)
+	}
+
+	payload := mails.Payload{
+		To:      e.Email.String(),
+		Subject: EmailVerificationRequestedSubject,
+		Body: fmt.Sprintf("Hello %s,\n\nConfirm your email with the code: %s\nIt expires in %d minutes.",
+			e.FirstName, e.VerificationCode, int(user.EmailVerificationExpiresAt.Minutes())),
+	}
+	if err := h.mailsender.SendMail(ctx, payload); err != nil {
+		otelx.RecordSpanError(span, err, "failed to send email verification code")
+		l.ErrorContext(ctx, "Failed to send email verification code", slog.Any("error", err))
+		return errorx.Wrap(err, op)
+	}
+
+	return nil
+}
@@ -8,9 +8,12 @@ import (
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/trace"
 
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/announcement"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/group"
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/staffinvitation"
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/mails"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/urlx"
 )
 
 var (
@@ -22,6 +25,12 @@ type InvitationCreatorGetter interface {
 	GetCreatorByInvitationID(ctx context.Context, id staffinvitation.ID) (*user.Staff, error)
 }
 
+// AnnouncementRecipientLister lists the students of the target groups, of
+// every group when none is given.
+type AnnouncementRecipientLister interface {
+	ListAnnouncementRecipients(ctx context.Context, groupIDs []group.ID) ([]announcement.Recipient, error)
+}
+
 type MailSender interface {
 	SendMail(ctx context.Context, payload mails.Payload) error
 }
@@ -30,19 +39,26 @@ type MailEventHandler struct {
 	tracer                  trace.Tracer
 	logger                  *slog.Logger
 	mailsender              MailSender
-	staffInvitationBaseURL  string
+	staffInvitationLinkURL  urlx.URL
 	invitationCreatorGetter InvitationCreatorGetter
+	recipientLister         AnnouncementRecipientLister
 }
 
 type MailEventHandlerArgs struct {
-	Tracer                  trace.Tracer
-	Logger                  *slog.Logger
-	StaffInvitationBaseURL  string
+	Tracer trace.Tracer
+	Logger *slog.Logger
+	// StaffInvitationLinkURL is the page the invitation emails link to, the
+	// code is appended to its path and the email to its query.
+	StaffInvitationLinkURL  urlx.URL
 	Mailsender              MailSender
 	InvitationCreatorGetter InvitationCreatorGetter
+	RecipientLister         AnnouncementRecipientLister
 }
 
 func NewMailEventHandler(args MailEventHandlerArgs) *MailEventHandler {
+	if args.StaffInvitationLinkURL.IsZero() {
+		panic("staff invitation link url is required")
+	}
 	if args.Tracer == nil {
 		args.Tracer = tracer
 	}
@@ -53,8 +69,9 @@ func NewMailEventHandler(args MailEventHandlerArgs) *MailEventHandler {
 	return &MailEventHandler{
 		tracer:                  args.Tracer,
 		logger:                  args.Logger,
-		staffInvitationBaseURL:  args.StaffInvitationBaseURL,
+		staffInvitationLinkURL:  args.StaffInvitationLinkURL,
 		mailsender:              args.Mailsender,
 		invitationCreatorGetter: args.InvitationCreatorGetter,
+		recipientLister:         args.RecipientLister,
 	}
 }
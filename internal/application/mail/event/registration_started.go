@@ -26,8 +26,6 @@ func (h *MailEventHandler) HandleRegistrationStarted(ctx context.Context, e *reg
 	ctx, span := h.tracer.Start(
 		ctx,
 		"MailEventHandler.HandleRegistrationStarted",
-		trace.WithNewRoot(),
-		trace.WithLinks(trace.LinkFromContext(e.Extract())),
 		trace.WithAttributes(
 			attribute.String("event.registration.id", e.RegistrationID.String()),
 			attribute.String("event.registration.email", logging.RedactEmail(e.Email)),
@@ -46,7 +44,7 @@ func (h *MailEventHandler) HandleRegistrationStarted(ctx context.Context, e *reg
 	}
 
 	payload := mails.Payload{
-		To:      e.Email,
+		To:      e.Email.String(),
 		Subject: RegistrationStartedSubject,
 		Body:    fmt.Sprintf("Your email verification code is: %s", e.VerificationCode),
 	}
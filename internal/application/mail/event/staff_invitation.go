@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
-	"net/url"
 
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
@@ -26,8 +25,6 @@ func (h *MailEventHandler) HandleStaffInvitationCreated(ctx context.Context, e *
 		return nil
 	}
 	ctx, span := h.tracer.Start(ctx, "MailEventHandler.HandleStaffInvitationCreated",
-		trace.WithNewRoot(),
-		trace.WithLinks(trace.LinkFromContext(e.Extract())),
 		trace.WithAttributes(
 			attribute.String("invitation.id", e.StaffInvitationID.String()),
 			attribute.Int("invitation.recipients_email_count", len(e.RecipientsEmail)),
@@ -65,8 +62,6 @@ func (h *MailEventHandler) HandleStaffInvitationRecipientsUpdated(ctx context.Co
 		return nil
 	}
 	ctx, span := h.tracer.Start(ctx, "MailEventHandler.HandleStaffInvitationRecipientsUpdated",
-		trace.WithNewRoot(),
-		trace.WithLinks(trace.LinkFromContext(e.Extract())),
 		trace.WithAttributes(
 			attribute.String("invitation.id", e.StaffInvitationID.String()),
 			attribute.Int("invitation.new_recipients_email_count", len(e.NewRecipientsEmail)),
@@ -108,8 +103,6 @@ func (h *MailEventHandler) HandleStaffInvitationAccepted(ctx context.Context, e
 	}
 	const op = "event.MailEventHandler.HandleStaffInvitationAccepted"
 	ctx, span := h.tracer.Start(ctx, "MailEventHandler.HandleStaffInvitationAccepted",
-		trace.WithNewRoot(),
-		trace.WithLinks(trace.LinkFromContext(e.Extract())),
 		trace.WithAttributes(
 			attribute.String("staff.id", e.StaffID.String()),
 			attribute.String("staff.email", logging.RedactEmail(e.Email)),
@@ -124,12 +117,16 @@ func (h *MailEventHandler) HandleStaffInvitationAccepted(ctx context.Context, e
 		slog.String("invitation.id", e.InvitationID.String()),
 	)
 
+	created := "Your account has been successfully created."
+	if e.Linked {
+		created = "Your student account now has staff access as well."
+	}
 	newStaffWelcomePayload := mails.Payload{
-		To:      e.Email,
+		To:      e.Email.String(),
 		Subject: "Welcome to the Staff Team",
 		Body: fmt.Sprintf(
-			"Hello,\n\nWelcome to the staff team! Your account has been successfully created.\n\nYou can log in using your email: %s\n\nBest regards,\nThe Team",
-			e.Email,
+			"Hello,\n\nWelcome to the staff team! %s\n\nYou can log in using your email: %s\n\nBest regards,\nThe Team",
+			created, e.Email,
 		),
 	}
 
@@ -151,7 +148,7 @@ func (h *MailEventHandler) HandleStaffInvitationAccepted(ctx context.Context, e
 	}
 
 	notificationPayload := mails.Payload{
-		To:      creator.User().Email(),
+		To:      creator.User().Email().String(),
 		Subject: "Staff Invitation Accepted",
 		Body: fmt.Sprintf(
 			"Hello,\n\nThe staff invitation you sent has been accepted by %s %s (%s).\n\nBest regards,\nThe Team",
@@ -177,10 +174,8 @@ func (h *MailEventHandler) sendStaffInvitationEmail(ctx context.Context, email,
 		To:      email,
 		Subject: StaffInvitationSubject,
 		Body: fmt.Sprintf(
-			"You have been invited to join as staff. Please use the following link to accept the invitation:\n\n%s/%s?email=%s",
-			h.staffInvitationBaseURL,
-			code,
-			url.QueryEscape(email),
+			"You have been invited to join as staff. Please use the following link to accept the invitation:\n\n%s",
+			h.staffInvitationLinkURL.WithPath(code).WithQuery("email", email),
 		),
 	}
 	if err := h.mailsender.SendMail(ctx, payload); err != nil {
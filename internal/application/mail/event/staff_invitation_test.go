@@ -0,0 +1,42 @@
+package mailevent
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/staffinvitation"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/urlx"
+	"gitlab.com/ucmsv2/ucms-backend/tests/mocks"
+)
+
+func TestHandleStaffInvitationCreated_Link(t *testing.T) {
+	mailsender := mocks.NewMockMailSender()
+	h := NewMailEventHandler(MailEventHandlerArgs{
+		Mailsender:             mailsender,
+		StaffInvitationLinkURL: urlx.MustParse("https://ucms.kz/invitations/accept/"),
+	})
+
+	err := h.HandleStaffInvitationCreated(t.Context(), &staffinvitation.Created{
+		StaffInvitationID: staffinvitation.NewID(),
+		Code:              "ABC123",
+		RecipientsEmail:   []string{"first.last+staff@test.com", "o'brien@test.com"},
+	})
+	require.NoError(t, err)
+
+	sent := mailsender.MailsTo("first.last+staff@test.com")
+	require.Len(t, sent, 1)
+	assert.Contains(t, sent[0].Body, "https://ucms.kz/invitations/accept/ABC123?email=first.last%2Bstaff%40test.com")
+	assert.NotContains(t, sent[0].Body, "accept//")
+
+	sent = mailsender.MailsTo("o'brien@test.com")
+	require.Len(t, sent, 1)
+	assert.Contains(t, sent[0].Body, "https://ucms.kz/invitations/accept/ABC123?email=o%27brien%40test.com")
+}
+
+func TestNewMailEventHandler_RequiresLinkURL(t *testing.T) {
+	assert.Panics(t, func() {
+		NewMailEventHandler(MailEventHandlerArgs{Mailsender: mocks.NewMockMailSender()})
+	})
+}
@@ -25,10 +25,8 @@ func (h *MailEventHandler) HandleStudentRegistered(ctx context.Context, e *user.
 	}
 	const op = "mailevent.MailEventHandler.HandleStudentRegistered"
 	ctx, span := h.tracer.Start(ctx, "MailEventHandler.HandleStudentRegistered",
-		trace.WithNewRoot(),
-		trace.WithLinks(trace.LinkFromContext(e.Extract())),
 		trace.WithAttributes(
-			attribute.String("student.barcode", e.StudentBarcode.String()),
+			attribute.String("student.barcode", otelx.Redact("student.barcode", e.StudentBarcode.String())),
 			attribute.String("student.email", logging.RedactEmail(e.Email)),
 			attribute.String("student.group.id", e.GroupID.String())),
 	)
@@ -48,7 +46,7 @@ func (h *MailEventHandler) HandleStudentRegistered(ctx context.Context, e *user.
 	}
 
 	payload := mails.Payload{
-		To:      e.Email,
+		To:      e.Email.String(),
 		Subject: WelcomeSubject,
 		Body: fmt.Sprintf(
 			"Hello %s %s,\n\nWelcome to UCMS! Your registration is successful.\n\nBest regards,\nUCMS Team",
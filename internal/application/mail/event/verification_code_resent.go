@@ -32,8 +32,6 @@ func (h *MailEventHandler) HandleVerificationCodeResent(ctx context.Context, e *
 	ctx, span := h.tracer.Start(
 		ctx,
 		"MailEventHandler.HandleVerificationCodeResent",
-		trace.WithNewRoot(),
-		trace.WithLinks(trace.LinkFromContext(e.Extract())),
 		trace.WithAttributes(
 			attribute.String("event.registration.id", e.RegistrationID.String()),
 			attribute.String("event.registration.email", logging.RedactEmail(e.Email)),
@@ -42,7 +40,7 @@ func (h *MailEventHandler) HandleVerificationCodeResent(ctx context.Context, e *
 	defer span.End()
 
 	l.DebugContext(ctx, "Handling VerificationCodeResent event by mail application",
-		slog.String("email", e.Email),
+		slog.String("email", e.Email.String()),
 		slog.String("verification_code", e.VerificationCode),
 	)
 
@@ -56,7 +54,7 @@ func (h *MailEventHandler) HandleVerificationCodeResent(ctx context.Context, e *
 	}
 
 	if err := h.mailsender.SendMail(ctx, mails.Payload{
-		To:      e.Email,
+		To:      e.Email.String(),
 		Subject: VerificationCodeResentSubject,
 		Body:    fmt.Sprintf("Your verification code has been resent: %s", e.VerificationCode),
 	}); err != nil {
@@ -0,0 +1,99 @@
+package notificationapp
+
+import (
+	"log/slog"
+
+	notificationcmd "gitlab.com/ucmsv2/ucms-backend/internal/application/notification/cmd"
+	notificationevent "gitlab.com/ucmsv2/ucms-backend/internal/application/notification/event"
+	"gitlab.com/ucmsv2/ucms-backend/internal/application/notification/feed"
+	"gitlab.com/ucmsv2/ucms-backend/internal/application/notification/notificationquery"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx"
+)
+
+type App struct {
+	Command Command
+	Query   Query
+	Event   *notificationevent.NotificationEventHandler
+	// Hub holds the open streams of this instance, the Publisher of every
+	// instance notifies it.
+	Hub *feed.Hub
+}
+
+type Command struct {
+	MarkRead    otelx.Handler[notificationcmd.MarkRead]
+	MarkAllRead otelx.Handler[notificationcmd.MarkAllRead]
+}
+
+type Query struct {
+	ListNotifications *notificationquery.ListNotificationsHandler
+	OpenStream        *notificationquery.OpenStreamHandler
+}
+
+type NotificationRepo interface {
+	notificationcmd.NotificationRepo
+	notificationquery.NotificationLister
+	notificationevent.NotificationSaver
+	notificationquery.StreamNotificationRepo
+	feed.NotificationGetter
+}
+
+type Args struct {
+	Logger                  *slog.Logger
+	NotificationRepo        NotificationRepo
+	InvitationCreatorGetter notificationevent.InvitationCreatorGetter
+	GroupGetter             notificationevent.GroupGetter
+	RecipientLister         notificationevent.AnnouncementRecipientLister
+	// Publisher announces the new notifications to the Hubs of every
+	// instance, defaults to the Hub of this one.
+	Publisher feed.Publisher
+	// Clock defaults to clock.Real.
+	Clock clock.Clock
+}
+
+func NewApp(args Args) *App {
+	hub := feed.NewHub(args.NotificationRepo, args.Logger)
+	if args.Publisher == nil {
+		args.Publisher = hub
+	}
+
+	return &App{
+		Command: Command{
+			MarkRead: otelx.InstrumentCommand[notificationcmd.MarkRead](
+				"MarkReadHandler.Handle",
+				notificationcmd.NewMarkReadHandler(notificationcmd.MarkReadHandlerArgs{
+					NotificationRepo: args.NotificationRepo,
+				}),
+			),
+			MarkAllRead: otelx.InstrumentCommand[notificationcmd.MarkAllRead](
+				"MarkAllReadHandler.Handle",
+				notificationcmd.NewMarkAllReadHandler(notificationcmd.MarkAllReadHandlerArgs{
+					Logger:           args.Logger,
+					NotificationRepo: args.NotificationRepo,
+					Clock:            args.Clock,
+				}),
+			),
+		},
+		Query: Query{
+			ListNotifications: notificationquery.NewListNotificationsHandler(notificationquery.ListNotificationsHandlerArgs{
+				Logger:           args.Logger,
+				NotificationRepo: args.NotificationRepo,
+			}),
+			OpenStream: notificationquery.NewOpenStreamHandler(notificationquery.OpenStreamHandlerArgs{
+				Logger:           args.Logger,
+				NotificationRepo: args.NotificationRepo,
+				Hub:              hub,
+			}),
+		},
+		Event: notificationevent.NewNotificationEventHandler(notificationevent.NotificationEventHandlerArgs{
+			Logger:                  args.Logger,
+			NotificationRepo:        args.NotificationRepo,
+			InvitationCreatorGetter: args.InvitationCreatorGetter,
+			GroupGetter:             args.GroupGetter,
+			RecipientLister:         args.RecipientLister,
+			Publisher:               args.Publisher,
+			Clock:                   args.Clock,
+		}),
+		Hub: hub,
+	}
+}
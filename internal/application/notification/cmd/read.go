@@ -0,0 +1,123 @@
+package notificationcmd
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"go.opentelemetry.io/contrib/bridges/otelslog"
+	"go.opentelemetry.io/otel/trace"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/notification"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+)
+
+var logger = otelslog.NewLogger("ucms/internal/application/notification/cmd")
+
+type NotificationRepo interface {
+	UpdateNotification(
+		ctx context.Context,
+		userID user.ID,
+		id notification.ID,
+		fn func(context.Context, *notification.Notification) error,
+	) error
+	MarkAllNotificationsRead(ctx context.Context, userID user.ID, readAt time.Time) (int, error)
+}
+
+// MarkRead marks a notification of the user as read, the notifications of
+// the other users are not found.
+type MarkRead struct {
+	UserID         user.ID
+	NotificationID notification.ID
+}
+
+func (c MarkRead) SpanAttrs() map[string]any {
+	return map[string]any{
+		"user_id":         c.UserID.String(),
+		"notification_id": c.NotificationID.String(),
+	}
+}
+
+type MarkReadHandler struct {
+	repo NotificationRepo
+}
+
+type MarkReadHandlerArgs struct {
+	NotificationRepo NotificationRepo
+}
+
+func NewMarkReadHandler(args MarkReadHandlerArgs) *MarkReadHandler {
+	return &MarkReadHandler{repo: args.NotificationRepo}
+}
+
+func (h *MarkReadHandler) Handle(ctx context.Context, cmd MarkRead) error {
+	const op = "notificationcmd.MarkReadHandler.Handle"
+	span := trace.SpanFromContext(ctx)
+
+	err := h.repo.UpdateNotification(ctx, cmd.UserID, cmd.NotificationID,
+		func(_ context.Context, n *notification.Notification) error {
+			n.MarkRead()
+			return nil
+		})
+	if err != nil {
+		span.AddEvent("failed to mark notification as read")
+		return errorx.Wrap(err, op)
+	}
+
+	return nil
+}
+
+// MarkAllRead marks every unread notification of the user as read.
+type MarkAllRead struct {
+	UserID user.ID
+}
+
+func (c MarkAllRead) SpanAttrs() map[string]any {
+	return map[string]any{"user_id": c.UserID.String()}
+}
+
+type MarkAllReadHandler struct {
+	logger *slog.Logger
+	repo   NotificationRepo
+	clock  clock.Clock
+}
+
+type MarkAllReadHandlerArgs struct {
+	Logger           *slog.Logger
+	NotificationRepo NotificationRepo
+	// Clock defaults to clock.Real.
+	Clock clock.Clock
+}
+
+func NewMarkAllReadHandler(args MarkAllReadHandlerArgs) *MarkAllReadHandler {
+	h := &MarkAllReadHandler{
+		logger: args.Logger,
+		repo:   args.NotificationRepo,
+		clock:  clock.Or(args.Clock),
+	}
+
+	if h.logger == nil {
+		h.logger = logger
+	}
+
+	return h
+}
+
+func (h *MarkAllReadHandler) Handle(ctx context.Context, cmd MarkAllRead) error {
+	const op = "notificationcmd.MarkAllReadHandler.Handle"
+	span := trace.SpanFromContext(ctx)
+
+	marked, err := h.repo.MarkAllNotificationsRead(ctx, cmd.UserID, h.clock.Now().UTC())
+	if err != nil {
+		span.AddEvent("failed to mark notifications as read")
+		return errorx.Wrap(err, op)
+	}
+
+	h.logger.DebugContext(ctx, "notifications marked as read",
+		slog.String("user_id", cmd.UserID.String()),
+		slog.Int("count", marked))
+
+	return nil
+}
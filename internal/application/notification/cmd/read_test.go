@@ -0,0 +1,83 @@
+package notificationcmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/notification"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/tests/mocks"
+)
+
+var readNow = time.Date(2026, time.March, 2, 9, 0, 0, 0, time.UTC)
+
+func seedNotification(t *testing.T, repo *mocks.NotificationRepo, userID user.ID) *notification.Notification {
+	t.Helper()
+	n, err := notification.New(notification.CreateArgs{
+		UserID:  userID,
+		EventID: uuid.New(),
+		Type:    notification.TypeGroupChanged,
+		Title:   "You were moved to SE-2402",
+	})
+	require.NoError(t, err)
+	repo.SeedNotification(t, n)
+	return n
+}
+
+func unreadCount(t *testing.T, repo *mocks.NotificationRepo, userID user.ID) int {
+	t.Helper()
+	count, err := repo.CountUnreadNotifications(t.Context(), userID)
+	require.NoError(t, err)
+	return count
+}
+
+func TestMarkReadHandler(t *testing.T) {
+	repo := mocks.NewNotificationRepo()
+	userID := user.NewID()
+	n := seedNotification(t, repo, userID)
+	seedNotification(t, repo, userID)
+	h := NewMarkReadHandler(MarkReadHandlerArgs{NotificationRepo: repo})
+
+	require.NoError(t, h.Handle(t.Context(), MarkRead{UserID: userID, NotificationID: n.ID()}))
+	assert.Equal(t, 1, unreadCount(t, repo, userID))
+
+	require.NoError(t, h.Handle(t.Context(), MarkRead{UserID: userID, NotificationID: n.ID()}), "read again")
+	assert.Equal(t, 1, unreadCount(t, repo, userID))
+}
+
+func TestMarkReadHandler_OtherUser(t *testing.T) {
+	repo := mocks.NewNotificationRepo()
+	owner := user.NewID()
+	n := seedNotification(t, repo, owner)
+	h := NewMarkReadHandler(MarkReadHandlerArgs{NotificationRepo: repo})
+
+	err := h.Handle(t.Context(), MarkRead{UserID: user.NewID(), NotificationID: n.ID()})
+	assert.True(t, errorx.IsNotFound(err), "got %v", err)
+	assert.Equal(t, 1, unreadCount(t, repo, owner))
+}
+
+func TestMarkAllReadHandler(t *testing.T) {
+	repo := mocks.NewNotificationRepo()
+	userID, other := user.NewID(), user.NewID()
+	seedNotification(t, repo, userID)
+	seedNotification(t, repo, userID)
+	seedNotification(t, repo, other)
+	h := NewMarkAllReadHandler(MarkAllReadHandlerArgs{NotificationRepo: repo, Clock: clock.NewFake(readNow)})
+
+	require.NoError(t, h.Handle(t.Context(), MarkAllRead{UserID: userID}))
+	assert.Equal(t, 0, unreadCount(t, repo, userID))
+	assert.Equal(t, 1, unreadCount(t, repo, other))
+
+	got, err := repo.ListNotifications(t.Context(), userID, notification.ListParams{Limit: 10})
+	require.NoError(t, err)
+	for _, n := range got {
+		require.NotNil(t, n.ReadAt())
+		assert.Equal(t, readNow, *n.ReadAt())
+	}
+}
@@ -0,0 +1,294 @@
+package notificationevent
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"unicode/utf8"
+
+	"go.opentelemetry.io/contrib/bridges/otelslog"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/announcement"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/group"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/notification"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/staffinvitation"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/sanitizex"
+)
+
+var (
+	tracer = otel.Tracer("ucms/application/notification/event")
+	logger = otelslog.NewLogger("ucms/application/notification/event")
+)
+
+// The pages of the client the notifications open.
+const (
+	LinkStaffInvitations = "/staff/invitations"
+	LinkProfile          = "/profile"
+	LinkAnnouncements    = "/announcements"
+)
+
+type NotificationSaver interface {
+	// SaveNotification reports whether n was saved, false when its event was
+	// already notified.
+	SaveNotification(ctx context.Context, n *notification.Notification) (bool, error)
+}
+
+// Publisher announces the saved notifications to the open streams.
+type Publisher interface {
+	PublishNotification(ctx context.Context, n *notification.Notification) error
+}
+
+type InvitationCreatorGetter interface {
+	GetCreatorByInvitationID(ctx context.Context, id staffinvitation.ID) (*user.Staff, error)
+}
+
+// AnnouncementRecipientLister lists the students of the target groups, of
+// every group when none is given.
+type AnnouncementRecipientLister interface {
+	ListAnnouncementRecipients(ctx context.Context, groupIDs []group.ID) ([]announcement.Recipient, error)
+}
+
+type GroupGetter interface {
+	GetGroupByID(ctx context.Context, id group.ID) (*group.Group, error)
+}
+
+// NotificationEventHandler turns the events the users care about into their
+// notifications. The notifications carry the ID of their event, so a
+// redelivered event does not notify twice.
+type NotificationEventHandler struct {
+	tracer                  trace.Tracer
+	logger                  *slog.Logger
+	notifications           NotificationSaver
+	invitationCreatorGetter InvitationCreatorGetter
+	groupGetter             GroupGetter
+	recipientLister         AnnouncementRecipientLister
+	publisher               Publisher
+	clock                   clock.Clock
+}
+
+type NotificationEventHandlerArgs struct {
+	Tracer                  trace.Tracer
+	Logger                  *slog.Logger
+	NotificationRepo        NotificationSaver
+	InvitationCreatorGetter InvitationCreatorGetter
+	GroupGetter             GroupGetter
+	RecipientLister         AnnouncementRecipientLister
+	// Publisher is optional, without it the notifications reach the users
+	// on their next poll only.
+	Publisher Publisher
+	// Clock defaults to clock.Real.
+	Clock clock.Clock
+}
+
+func NewNotificationEventHandler(args NotificationEventHandlerArgs) *NotificationEventHandler {
+	if args.Tracer == nil {
+		args.Tracer = tracer
+	}
+	if args.Logger == nil {
+		args.Logger = logger
+	}
+
+	return &NotificationEventHandler{
+		tracer:                  args.Tracer,
+		logger:                  args.Logger,
+		notifications:           args.NotificationRepo,
+		invitationCreatorGetter: args.InvitationCreatorGetter,
+		groupGetter:             args.GroupGetter,
+		recipientLister:         args.RecipientLister,
+		publisher:               args.Publisher,
+		clock:                   args.Clock,
+	}
+}
+
+// HandleStaffInvitationAccepted notifies the creator of the invitation.
+func (h *NotificationEventHandler) HandleStaffInvitationAccepted(ctx context.Context, e *user.StaffInvitationAccepted) error {
+	if e == nil {
+		return nil
+	}
+	const op = "notificationevent.NotificationEventHandler.HandleStaffInvitationAccepted"
+	ctx, span := h.tracer.Start(ctx, "NotificationEventHandler.HandleStaffInvitationAccepted",
+		trace.WithAttributes(
+			attribute.String("event.id", e.EventID.String()),
+			attribute.String("staff.id", e.StaffID.String()),
+			attribute.String("invitation.id", e.InvitationID.String()),
+		),
+	)
+	defer span.End()
+
+	creator, err := h.invitationCreatorGetter.GetCreatorByInvitationID(ctx, staffinvitation.ID(e.InvitationID))
+	if errorx.IsNotFound(err) {
+		h.logger.WarnContext(ctx, "creator of the accepted invitation not found, nobody to notify",
+			slog.String("invitation.id", e.InvitationID.String()))
+		return nil
+	}
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to get invitation creator")
+		return errorx.Wrap(err, op)
+	}
+
+	err = h.notify(ctx, notification.CreateArgs{
+		UserID:  creator.User().ID(),
+		EventID: e.EventID,
+		Type:    notification.TypeInvitationAccepted,
+		Title:   "Staff invitation accepted",
+		Body:    fmt.Sprintf("%s %s accepted your staff invitation.", e.FirstName, e.LastName),
+		Link:    LinkStaffInvitations,
+	})
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to notify invitation creator")
+		return errorx.Wrap(err, op)
+	}
+
+	return nil
+}
+
+// HandleStudentGroupChanged notifies the student of their new group.
+func (h *NotificationEventHandler) HandleStudentGroupChanged(ctx context.Context, e *user.StudentGroupChanged) error {
+	if e == nil {
+		return nil
+	}
+	const op = "notificationevent.NotificationEventHandler.HandleStudentGroupChanged"
+	ctx, span := h.tracer.Start(ctx, "NotificationEventHandler.HandleStudentGroupChanged",
+		trace.WithAttributes(
+			attribute.String("event.id", e.EventID.String()),
+			attribute.String("student.id", e.StudentID.String()),
+			attribute.String("group.id", e.To.String()),
+		),
+	)
+	defer span.End()
+
+	g, err := h.groupGetter.GetGroupByID(ctx, e.To)
+	if errorx.IsNotFound(err) {
+		h.logger.WarnContext(ctx, "new group of the student not found, skipping the notification",
+			slog.String("student.id", e.StudentID.String()),
+			slog.String("group.id", e.To.String()))
+		return nil
+	}
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to get group")
+		return errorx.Wrap(err, op)
+	}
+
+	err = h.notify(ctx, notification.CreateArgs{
+		UserID:  e.StudentID,
+		EventID: e.EventID,
+		Type:    notification.TypeGroupChanged,
+		Title:   fmt.Sprintf("You were moved to %s", g.Name()),
+		Body:    fmt.Sprintf("Your group is now %s.", g.Name()),
+		Link:    LinkProfile,
+	})
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to notify student")
+		return errorx.Wrap(err, op)
+	}
+
+	return nil
+}
+
+// HandleAnnouncementPublished notifies the students the announcement targets.
+// A redelivered event notifies the students missed the first time only.
+func (h *NotificationEventHandler) HandleAnnouncementPublished(ctx context.Context, e *announcement.Published) error {
+	if e == nil {
+		return nil
+	}
+	const op = "notificationevent.NotificationEventHandler.HandleAnnouncementPublished"
+	ctx, span := h.tracer.Start(ctx, "NotificationEventHandler.HandleAnnouncementPublished",
+		trace.WithAttributes(
+			attribute.String("event.id", e.EventID.String()),
+			attribute.String("announcement.id", e.AnnouncementID.String()),
+			attribute.Int("announcement.groups_count", len(e.GroupIDs)),
+		),
+	)
+	defer span.End()
+
+	recipients, err := h.recipientLister.ListAnnouncementRecipients(ctx, e.GroupIDs)
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to list announcement recipients")
+		return errorx.Wrap(err, op)
+	}
+	span.SetAttributes(attribute.Int("announcement.recipients_count", len(recipients)))
+
+	body := e.Body
+	if utf8.RuneCountInString(body) > notification.MaxBodyLen {
+		body = sanitizex.TruncateRunes(body, notification.MaxBodyLen-1) + "…"
+	}
+	for _, recipient := range recipients {
+		err = h.notify(ctx, notification.CreateArgs{
+			UserID:  recipient.UserID,
+			EventID: e.EventID,
+			Type:    notification.TypeAnnouncement,
+			Title:   e.Title,
+			Body:    body,
+			Link:    LinkAnnouncements,
+		})
+		if err != nil {
+			otelx.RecordSpanError(span, err, "failed to notify student")
+			return errorx.Wrap(err, op)
+		}
+	}
+
+	return nil
+}
+
+// HandleUserAvatarRejected tells the user that their avatar upload failed the
+// malware scan and was not saved.
+func (h *NotificationEventHandler) HandleUserAvatarRejected(ctx context.Context, e *user.UserAvatarRejected) error {
+	if e == nil {
+		return nil
+	}
+	const op = "notificationevent.NotificationEventHandler.HandleUserAvatarRejected"
+	ctx, span := h.tracer.Start(ctx, "NotificationEventHandler.HandleUserAvatarRejected",
+		trace.WithAttributes(
+			attribute.String("event.id", e.EventID.String()),
+			attribute.String("user.id", e.UserID.String()),
+		),
+	)
+	defer span.End()
+
+	body := "The image you uploaded failed the malware scan and was not saved."
+	if e.Filename != "" {
+		body = fmt.Sprintf("The image %q you uploaded failed the malware scan and was not saved.", e.Filename)
+	}
+	err := h.notify(ctx, notification.CreateArgs{
+		UserID:  e.UserID,
+		EventID: e.EventID,
+		Type:    notification.TypeAvatarRejected,
+		Title:   "Your avatar was rejected",
+		Body:    body,
+		Link:    LinkProfile,
+	})
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to notify user")
+		return errorx.Wrap(err, op)
+	}
+
+	return nil
+}
+
+func (h *NotificationEventHandler) notify(ctx context.Context, args notification.CreateArgs) error {
+	args.Clock = h.clock
+	n, err := notification.New(args)
+	if err != nil {
+		return err
+	}
+	saved, err := h.notifications.SaveNotification(ctx, n)
+	if err != nil || !saved || h.publisher == nil {
+		return err
+	}
+
+	// The notification is saved, the streams resume it from the database if
+	// the announcement is lost.
+	if err := h.publisher.PublishNotification(ctx, n); err != nil {
+		h.logger.WarnContext(ctx, "failed to publish notification",
+			slog.String("notification.id", n.ID().String()),
+			slog.String("error", err.Error()))
+	}
+	return nil
+}
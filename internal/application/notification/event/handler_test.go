@@ -0,0 +1,235 @@
+package notificationevent
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/announcement"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/event"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/group"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/notification"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/staffinvitation"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/builders"
+	"gitlab.com/ucmsv2/ucms-backend/tests/mocks"
+)
+
+var notifyNow = time.Date(2026, time.March, 2, 9, 0, 0, 0, time.UTC)
+
+type creatorGetter map[staffinvitation.ID]*user.Staff
+
+func (g creatorGetter) GetCreatorByInvitationID(_ context.Context, id staffinvitation.ID) (*user.Staff, error) {
+	if creator, ok := g[id]; ok {
+		return creator, nil
+	}
+	return nil, errorx.NewNotFound()
+}
+
+func listAll(t *testing.T, repo *mocks.NotificationRepo, userID user.ID) []*notification.Notification {
+	t.Helper()
+	got, err := repo.ListNotifications(t.Context(), userID, notification.ListParams{Limit: 100})
+	require.NoError(t, err)
+	return got
+}
+
+func TestHandleStudentGroupChanged(t *testing.T) {
+	repo := mocks.NewNotificationRepo()
+	groups := mocks.NewGroupRepo()
+	target := builders.NewGroupBuilder().WithID(group.NewID()).WithName("SE-2402").Build()
+	groups.SeedGroup(t, target)
+	h := NewNotificationEventHandler(NotificationEventHandlerArgs{
+		NotificationRepo: repo,
+		GroupGetter:      groups,
+		Clock:            clock.NewFake(notifyNow),
+	})
+
+	studentID := user.NewID()
+	e := &user.StudentGroupChanged{
+		Header:    event.NewEventHeader(),
+		StudentID: studentID,
+		To:        target.ID(),
+	}
+	require.NoError(t, h.HandleStudentGroupChanged(t.Context(), e))
+
+	got := listAll(t, repo, studentID)
+	require.Len(t, got, 1)
+	assert.Equal(t, e.EventID, got[0].EventID())
+	assert.Equal(t, notification.TypeGroupChanged, got[0].Type())
+	assert.Contains(t, got[0].Title(), "SE-2402")
+	assert.Equal(t, LinkProfile, got[0].Link())
+	assert.Equal(t, notifyNow, got[0].CreatedAt())
+	assert.False(t, got[0].IsRead())
+
+	t.Run("redelivery", func(t *testing.T) {
+		require.NoError(t, h.HandleStudentGroupChanged(t.Context(), e))
+		assert.Len(t, listAll(t, repo, studentID), 1)
+	})
+
+	t.Run("unknown group", func(t *testing.T) {
+		other := user.NewID()
+		err := h.HandleStudentGroupChanged(t.Context(), &user.StudentGroupChanged{
+			Header:    event.NewEventHeader(),
+			StudentID: other,
+			To:        group.NewID(),
+		})
+		require.NoError(t, err)
+		assert.Empty(t, listAll(t, repo, other))
+	})
+}
+
+func TestHandleStaffInvitationAccepted(t *testing.T) {
+	repo := mocks.NewNotificationRepo()
+	creator := builders.NewStaffBuilder().Build()
+	invitationID := staffinvitation.NewID()
+	h := NewNotificationEventHandler(NotificationEventHandlerArgs{
+		NotificationRepo:        repo,
+		InvitationCreatorGetter: creatorGetter{invitationID: creator},
+	})
+
+	e := &user.StaffInvitationAccepted{
+		Header:       event.NewEventHeader(),
+		StaffID:      user.NewID(),
+		FirstName:    "Aigerim",
+		LastName:     "Sadykova",
+		InvitationID: uuid.UUID(invitationID),
+	}
+	require.NoError(t, h.HandleStaffInvitationAccepted(t.Context(), e))
+	require.NoError(t, h.HandleStaffInvitationAccepted(t.Context(), e), "redelivery")
+
+	got := listAll(t, repo, creator.User().ID())
+	require.Len(t, got, 1)
+	assert.Equal(t, notification.TypeInvitationAccepted, got[0].Type())
+	assert.Contains(t, got[0].Body(), "Aigerim Sadykova")
+
+	t.Run("creator not found", func(t *testing.T) {
+		err := h.HandleStaffInvitationAccepted(t.Context(), &user.StaffInvitationAccepted{
+			Header:       event.NewEventHeader(),
+			InvitationID: uuid.New(),
+		})
+		require.NoError(t, err)
+	})
+}
+
+func TestHandleAnnouncementPublished(t *testing.T) {
+	repo := mocks.NewNotificationRepo()
+	recipients := mocks.NewAnnouncementRepo()
+	target, other := group.NewID(), group.NewID()
+	inTarget := announcement.Recipient{UserID: user.NewID(), Email: "target@test.com"}
+	inOther := announcement.Recipient{UserID: user.NewID(), Email: "other@test.com"}
+	recipients.SeedRecipient(target, inTarget)
+	recipients.SeedRecipient(other, inOther)
+	h := NewNotificationEventHandler(NotificationEventHandlerArgs{
+		NotificationRepo: repo,
+		RecipientLister:  recipients,
+	})
+
+	e := &announcement.Published{
+		Header:         event.NewEventHeader(),
+		AnnouncementID: announcement.NewID(),
+		Title:          "Spring fest",
+		Body:           strings.Repeat("a", notification.MaxBodyLen+10),
+		GroupIDs:       []group.ID{target},
+	}
+	require.NoError(t, h.HandleAnnouncementPublished(t.Context(), e))
+	require.NoError(t, h.HandleAnnouncementPublished(t.Context(), e), "redelivery")
+
+	got := listAll(t, repo, inTarget.UserID)
+	require.Len(t, got, 1)
+	assert.Equal(t, notification.TypeAnnouncement, got[0].Type())
+	assert.Equal(t, "Spring fest", got[0].Title())
+	assert.Equal(t, LinkAnnouncements, got[0].Link())
+	assert.Len(t, []rune(got[0].Body()), notification.MaxBodyLen)
+	assert.Empty(t, listAll(t, repo, inOther.UserID))
+
+	t.Run("all students", func(t *testing.T) {
+		e := &announcement.Published{
+			Header:         event.NewEventHeader(),
+			AnnouncementID: announcement.NewID(),
+			Title:          "Library hours",
+			Body:           "The library closes at 8 pm.",
+		}
+		require.NoError(t, h.HandleAnnouncementPublished(t.Context(), e))
+		assert.Len(t, listAll(t, repo, inTarget.UserID), 2)
+		assert.Len(t, listAll(t, repo, inOther.UserID), 1)
+	})
+}
+
+type publisher struct {
+	published []*notification.Notification
+	err       error
+}
+
+func (p *publisher) PublishNotification(_ context.Context, n *notification.Notification) error {
+	p.published = append(p.published, n)
+	return p.err
+}
+
+func TestHandleUserAvatarRejected(t *testing.T) {
+	repo := mocks.NewNotificationRepo()
+	h := NewNotificationEventHandler(NotificationEventHandlerArgs{
+		NotificationRepo: repo,
+		Clock:            clock.NewFake(notifyNow),
+	})
+
+	userID := user.NewID()
+	e := &user.UserAvatarRejected{
+		Header:    event.NewEventHeader(),
+		UserID:    userID,
+		Filename:  "avatar.jpg",
+		Signature: "Eicar-Test-Signature",
+	}
+	require.NoError(t, h.HandleUserAvatarRejected(t.Context(), e))
+	require.NoError(t, h.HandleUserAvatarRejected(t.Context(), e))
+
+	got := listAll(t, repo, userID)
+	require.Len(t, got, 1, "a redelivered event should not notify twice")
+	assert.Equal(t, notification.TypeAvatarRejected, got[0].Type())
+	assert.Contains(t, got[0].Body(), "avatar.jpg")
+	assert.NotContains(t, got[0].Body(), e.Signature, "the scanner details stay in the audit log")
+	assert.Equal(t, LinkProfile, got[0].Link())
+}
+
+func TestNotificationEventHandler_Publishes(t *testing.T) {
+	groups := mocks.NewGroupRepo()
+	target := builders.NewGroupBuilder().WithID(group.NewID()).Build()
+	groups.SeedGroup(t, target)
+	e := &user.StudentGroupChanged{
+		Header:    event.NewEventHeader(),
+		StudentID: user.NewID(),
+		To:        target.ID(),
+	}
+
+	t.Run("once per saved notification", func(t *testing.T) {
+		pub := &publisher{}
+		h := NewNotificationEventHandler(NotificationEventHandlerArgs{
+			NotificationRepo: mocks.NewNotificationRepo(),
+			GroupGetter:      groups,
+			Publisher:        pub,
+		})
+
+		require.NoError(t, h.HandleStudentGroupChanged(t.Context(), e))
+		require.NoError(t, h.HandleStudentGroupChanged(t.Context(), e))
+		require.Len(t, pub.published, 1)
+		assert.Equal(t, e.EventID, pub.published[0].EventID())
+	})
+
+	t.Run("failed publish keeps the notification", func(t *testing.T) {
+		repo := mocks.NewNotificationRepo()
+		h := NewNotificationEventHandler(NotificationEventHandlerArgs{
+			NotificationRepo: repo,
+			GroupGetter:      groups,
+			Publisher:        &publisher{err: assert.AnError},
+		})
+
+		require.NoError(t, h.HandleStudentGroupChanged(t.Context(), e))
+		assert.Len(t, listAll(t, repo, e.StudentID), 1)
+	})
+}
@@ -0,0 +1,145 @@
+// Package feed delivers the new notifications to the streams the users keep
+// open. The Hub holds the subscriptions of this instance, the notifications
+// reach it through a Publisher shared by every instance, e.g. the
+// LISTEN/NOTIFY channel of postgres.NotificationFeed.
+package feed
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+
+	"go.opentelemetry.io/contrib/bridges/otelslog"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/notification"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+)
+
+var logger = otelslog.NewLogger("ucms/internal/application/notification/feed")
+
+// SubscriptionBuffer is the number of notifications a subscription holds
+// before its reader is considered gone.
+const SubscriptionBuffer = 16
+
+// Publisher announces a saved notification to the Hubs of every instance.
+type Publisher interface {
+	PublishNotification(ctx context.Context, n *notification.Notification) error
+}
+
+type NotificationGetter interface {
+	GetNotification(ctx context.Context, userID user.ID, id notification.ID) (*notification.Notification, error)
+}
+
+// Subscription receives the new notifications of a user. C is closed by
+// Close and when the subscriber falls SubscriptionBuffer notifications
+// behind, the stream then ends and the client resumes from its last event.
+type Subscription struct {
+	C <-chan *notification.Notification
+
+	c      chan *notification.Notification
+	hub    *Hub
+	userID user.ID
+	once   sync.Once
+}
+
+// Close unsubscribes, closing it again does nothing.
+func (s *Subscription) Close() {
+	s.hub.remove(s)
+}
+
+// Hub fans the notifications out to the subscriptions of their users.
+type Hub struct {
+	logger        *slog.Logger
+	notifications NotificationGetter
+
+	mu   sync.Mutex
+	subs map[user.ID]map[*Subscription]struct{}
+}
+
+func NewHub(notifications NotificationGetter, l *slog.Logger) *Hub {
+	if l == nil {
+		l = logger
+	}
+	return &Hub{
+		logger:        l,
+		notifications: notifications,
+		subs:          make(map[user.ID]map[*Subscription]struct{}),
+	}
+}
+
+func (h *Hub) Subscribe(userID user.ID) *Subscription {
+	c := make(chan *notification.Notification, SubscriptionBuffer)
+	sub := &Subscription{C: c, c: c, hub: h, userID: userID}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.subs[userID] == nil {
+		h.subs[userID] = make(map[*Subscription]struct{})
+	}
+	h.subs[userID][sub] = struct{}{}
+	return sub
+}
+
+// Subscribers returns the number of open subscriptions of userID.
+func (h *Hub) Subscribers(userID user.ID) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.subs[userID])
+}
+
+// Deliver hands n to the subscriptions of its user.
+func (h *Hub) Deliver(n *notification.Notification) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for sub := range h.subs[n.UserID()] {
+		select {
+		case sub.c <- n:
+		default:
+			h.logger.Warn("notification subscriber is too slow, closing the subscription",
+				slog.String("user.id", n.UserID().String()))
+			h.removeLocked(sub)
+		}
+	}
+}
+
+// Notify loads the notification id of userID and delivers it, when the user
+// has subscriptions on this instance. It is the receiving end of the
+// Publisher.
+func (h *Hub) Notify(ctx context.Context, userID user.ID, id notification.ID) error {
+	const op = "feed.Hub.Notify"
+	if h.Subscribers(userID) == 0 {
+		return nil
+	}
+
+	n, err := h.notifications.GetNotification(ctx, userID, id)
+	if err != nil {
+		return errorx.Wrap(err, op)
+	}
+	h.Deliver(n)
+	return nil
+}
+
+// PublishNotification delivers n to this instance only, it is the Publisher
+// of a single instance and of the tests.
+func (h *Hub) PublishNotification(_ context.Context, n *notification.Notification) error {
+	h.Deliver(n)
+	return nil
+}
+
+func (h *Hub) remove(sub *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.removeLocked(sub)
+}
+
+func (h *Hub) removeLocked(sub *Subscription) {
+	sub.once.Do(func() {
+		delete(h.subs[sub.userID], sub)
+		if len(h.subs[sub.userID]) == 0 {
+			delete(h.subs, sub.userID)
+		}
+		close(sub.c)
+	})
+}
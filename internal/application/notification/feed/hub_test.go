@@ -0,0 +1,99 @@
+package feed
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/event"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/notification"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/tests/mocks"
+)
+
+func newNotification(t *testing.T, userID user.ID) *notification.Notification {
+	t.Helper()
+	n, err := notification.New(notification.CreateArgs{
+		UserID:  userID,
+		EventID: event.NewEventHeader().EventID,
+		Type:    notification.TypeGroupChanged,
+		Title:   "You were moved to SE-2402",
+	})
+	require.NoError(t, err)
+	return n
+}
+
+func TestHub_Deliver(t *testing.T) {
+	hub := NewHub(mocks.NewNotificationRepo(), nil)
+	userID := user.NewID()
+	first, second := hub.Subscribe(userID), hub.Subscribe(userID)
+	other := hub.Subscribe(user.NewID())
+	defer first.Close()
+	defer second.Close()
+	defer other.Close()
+
+	n := newNotification(t, userID)
+	hub.Deliver(n)
+
+	assert.Equal(t, n, <-first.C)
+	assert.Equal(t, n, <-second.C)
+	assert.Empty(t, other.C)
+}
+
+func TestHub_SlowSubscriber(t *testing.T) {
+	hub := NewHub(mocks.NewNotificationRepo(), nil)
+	userID := user.NewID()
+	sub := hub.Subscribe(userID)
+
+	for range SubscriptionBuffer + 1 {
+		hub.Deliver(newNotification(t, userID))
+	}
+
+	assert.Zero(t, hub.Subscribers(userID))
+	received := 0
+	for range sub.C {
+		received++
+	}
+	assert.Equal(t, SubscriptionBuffer, received)
+	sub.Close() // Closing a dropped subscription does nothing.
+}
+
+func TestHub_Close(t *testing.T) {
+	hub := NewHub(mocks.NewNotificationRepo(), nil)
+	userID := user.NewID()
+	sub := hub.Subscribe(userID)
+	require.Equal(t, 1, hub.Subscribers(userID))
+
+	sub.Close()
+	sub.Close()
+
+	assert.Zero(t, hub.Subscribers(userID))
+	_, open := <-sub.C
+	assert.False(t, open)
+}
+
+func TestHub_Notify(t *testing.T) {
+	repo := mocks.NewNotificationRepo()
+	hub := NewHub(repo, nil)
+	userID := user.NewID()
+	n := newNotification(t, userID)
+	repo.SeedNotification(t, n)
+
+	t.Run("without subscribers", func(t *testing.T) {
+		assert.NoError(t, hub.Notify(t.Context(), userID, notification.NewID()))
+	})
+
+	sub := hub.Subscribe(userID)
+	defer sub.Close()
+
+	require.NoError(t, hub.Notify(t.Context(), userID, n.ID()))
+	got := <-sub.C
+	assert.Equal(t, n.ID(), got.ID())
+
+	t.Run("unknown notification", func(t *testing.T) {
+		err := hub.Notify(t.Context(), userID, notification.NewID())
+		assert.True(t, errorx.IsNotFound(err))
+	})
+}
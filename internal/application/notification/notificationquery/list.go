@@ -0,0 +1,146 @@
+package notificationquery
+
+import (
+	"context"
+	"log/slog"
+
+	"go.opentelemetry.io/contrib/bridges/otelslog"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/notification"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/httpx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx"
+)
+
+var (
+	tracer = otel.Tracer("ucms/internal/application/notification/query")
+	logger = otelslog.NewLogger("ucms/internal/application/notification/query")
+)
+
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+type NotificationLister interface {
+	ListNotifications(ctx context.Context, userID user.ID, params notification.ListParams) ([]*notification.Notification, error)
+	UnreadCounter
+}
+
+type UnreadCounter interface {
+	CountUnreadNotifications(ctx context.Context, userID user.ID) (int, error)
+}
+
+type ListNotifications struct {
+	UserID     user.ID
+	UnreadOnly bool
+	// Page starts at 1.
+	Page int
+	// PageSize defaults to DefaultPageSize and is capped at MaxPageSize.
+	PageSize int
+}
+
+type NotificationResponse struct {
+	ID        string      `json:"id"`
+	Type      string      `json:"type"`
+	Title     string      `json:"title"`
+	Body      string      `json:"body"`
+	Link      string      `json:"link"`
+	ReadAt    *httpx.Time `json:"read_at"`
+	CreatedAt httpx.Time  `json:"created_at"`
+}
+
+func NewNotificationResponse(n *notification.Notification) NotificationResponse {
+	return NotificationResponse{
+		ID:        n.ID().String(),
+		Type:      n.Type().String(),
+		Title:     n.Title(),
+		Body:      n.Body(),
+		Link:      n.Link(),
+		ReadAt:    httpx.NewTimePtr(n.ReadAt()),
+		CreatedAt: httpx.NewTime(n.CreatedAt()),
+	}
+}
+
+type ListNotificationsResponse struct {
+	Notifications []NotificationResponse `json:"notifications"`
+	// UnreadCount counts every unread notification of the user, not only the
+	// ones of the page.
+	UnreadCount int `json:"unread_count"`
+}
+
+type ListNotificationsHandler struct {
+	tracer trace.Tracer
+	logger *slog.Logger
+	repo   NotificationLister
+}
+
+type ListNotificationsHandlerArgs struct {
+	Tracer           trace.Tracer
+	Logger           *slog.Logger
+	NotificationRepo NotificationLister
+}
+
+func NewListNotificationsHandler(args ListNotificationsHandlerArgs) *ListNotificationsHandler {
+	if args.Tracer == nil {
+		args.Tracer = tracer
+	}
+	if args.Logger == nil {
+		args.Logger = logger
+	}
+
+	return &ListNotificationsHandler{
+		tracer: args.Tracer,
+		logger: args.Logger,
+		repo:   args.NotificationRepo,
+	}
+}
+
+func (h *ListNotificationsHandler) Handle(ctx context.Context, query ListNotifications) (*ListNotificationsResponse, error) {
+	const op = "notificationquery.ListNotificationsHandler.Handle"
+	ctx, span := h.tracer.Start(ctx, "ListNotificationsHandler.Handle")
+	defer span.End()
+	otelx.SetSpanAttrs(span, map[string]any{
+		"user.id":           query.UserID.String(),
+		"query.unread_only": query.UnreadOnly,
+		"query.page":        query.Page,
+		"query.page_size":   query.PageSize,
+	})
+
+	if query.Page < 1 {
+		query.Page = 1
+	}
+	if query.PageSize <= 0 {
+		query.PageSize = DefaultPageSize
+	}
+	query.PageSize = min(query.PageSize, MaxPageSize)
+
+	notifications, err := h.repo.ListNotifications(ctx, query.UserID, notification.ListParams{
+		UnreadOnly: query.UnreadOnly,
+		Limit:      query.PageSize,
+		Offset:     (query.Page - 1) * query.PageSize,
+	})
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to list notifications")
+		return nil, errorx.Wrap(err, op)
+	}
+
+	unread, err := h.repo.CountUnreadNotifications(ctx, query.UserID)
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to count unread notifications")
+		return nil, errorx.Wrap(err, op)
+	}
+
+	res := ListNotificationsResponse{
+		Notifications: make([]NotificationResponse, len(notifications)),
+		UnreadCount:   unread,
+	}
+	for i, n := range notifications {
+		res.Notifications[i] = NewNotificationResponse(n)
+	}
+
+	return &res, nil
+}
@@ -0,0 +1,127 @@
+package notificationquery
+
+import (
+	"context"
+	"log/slog"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/application/notification/feed"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/notification"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx"
+)
+
+type StreamNotificationRepo interface {
+	UnreadCounter
+	ListNotificationsAfter(ctx context.Context, userID user.ID, after notification.ID, limit int) ([]*notification.Notification, error)
+}
+
+type Subscriber interface {
+	Subscribe(userID user.ID) *feed.Subscription
+}
+
+type OpenStream struct {
+	UserID user.ID
+	// LastEventID is the last notification the client received, the stream
+	// resumes the ones created after it. Nil resumes none.
+	LastEventID *notification.ID
+}
+
+// Stream is the open notification stream of a user. It must be closed.
+type Stream struct {
+	UnreadCount int
+	// Missed are the notifications created after OpenStream.LastEventID, the
+	// oldest first and at most MaxPageSize of them.
+	Missed []NotificationResponse
+	// C receives the notifications created after the stream opened. It is
+	// closed when the stream falls behind, the client then resumes from its
+	// last event.
+	C <-chan *notification.Notification
+
+	sub    *feed.Subscription
+	missed map[notification.ID]struct{}
+}
+
+// Resumed reports whether n was already sent among Missed, the
+// notifications created while the stream opened may be both.
+func (s *Stream) Resumed(n *notification.Notification) bool {
+	_, ok := s.missed[n.ID()]
+	return ok
+}
+
+func (s *Stream) Close() {
+	s.sub.Close()
+}
+
+type OpenStreamHandler struct {
+	tracer trace.Tracer
+	logger *slog.Logger
+	repo   StreamNotificationRepo
+	hub    Subscriber
+}
+
+type OpenStreamHandlerArgs struct {
+	Tracer           trace.Tracer
+	Logger           *slog.Logger
+	NotificationRepo StreamNotificationRepo
+	Hub              Subscriber
+}
+
+func NewOpenStreamHandler(args OpenStreamHandlerArgs) *OpenStreamHandler {
+	if args.Tracer == nil {
+		args.Tracer = tracer
+	}
+	if args.Logger == nil {
+		args.Logger = logger
+	}
+
+	return &OpenStreamHandler{
+		tracer: args.Tracer,
+		logger: args.Logger,
+		repo:   args.NotificationRepo,
+		hub:    args.Hub,
+	}
+}
+
+func (h *OpenStreamHandler) Handle(ctx context.Context, query OpenStream) (*Stream, error) {
+	const op = "notificationquery.OpenStreamHandler.Handle"
+	ctx, span := h.tracer.Start(ctx, "OpenStreamHandler.Handle")
+	defer span.End()
+	otelx.SetSpanAttrs(span, map[string]any{
+		"user.id":      query.UserID.String(),
+		"query.resume": query.LastEventID != nil,
+	})
+
+	// Subscribing first, the notifications created while the rest is read
+	// are not lost.
+	sub := h.hub.Subscribe(query.UserID)
+	stream := &Stream{C: sub.C, sub: sub}
+
+	var err error
+	stream.UnreadCount, err = h.repo.CountUnreadNotifications(ctx, query.UserID)
+	if err != nil {
+		sub.Close()
+		otelx.RecordSpanError(span, err, "failed to count unread notifications")
+		return nil, errorx.Wrap(err, op)
+	}
+
+	if query.LastEventID != nil {
+		missed, err := h.repo.ListNotificationsAfter(ctx, query.UserID, *query.LastEventID, MaxPageSize)
+		if err != nil {
+			sub.Close()
+			otelx.RecordSpanError(span, err, "failed to list missed notifications")
+			return nil, errorx.Wrap(err, op)
+		}
+
+		stream.Missed = make([]NotificationResponse, len(missed))
+		stream.missed = make(map[notification.ID]struct{}, len(missed))
+		for i, n := range missed {
+			stream.Missed[i] = NewNotificationResponse(n)
+			stream.missed[n.ID()] = struct{}{}
+		}
+	}
+
+	return stream, nil
+}
@@ -6,7 +6,12 @@ import (
 	"gitlab.com/ucmsv2/ucms-backend/internal/application/registration/cmd"
 	"gitlab.com/ucmsv2/ucms-backend/internal/application/registration/event"
 	"gitlab.com/ucmsv2/ucms-backend/internal/application/registration/query"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/tos"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
 	"gitlab.com/ucmsv2/ucms-backend/pkg/env"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/singleflightx"
 )
 
 type App struct {
@@ -16,10 +21,12 @@ type App struct {
 }
 
 type Command struct {
-	Verify          *cmd.VerifyHandler
-	StartStudent    *cmd.StartStudentHandler
-	StudentComplete *cmd.StudentCompleteHandler
-	ResendCode      *cmd.ResendCodeHandler
+	Verify          otelx.Handler[cmd.Verify]
+	StartStudent    otelx.Handler[cmd.StartStudent]
+	StudentComplete otelx.Handler[cmd.StudentComplete]
+	ResendCode      otelx.Handler[cmd.ResendCode]
+	// PurgeRegistrations is run by the operators, it is not routed.
+	PurgeRegistrations *cmd.PurgeRegistrationsHandler
 }
 
 type Event struct {
@@ -35,32 +42,69 @@ type Query struct {
 type Args struct {
 	Mode         env.Mode
 	Repo         cmd.Repo
+	Purger       cmd.RegistrationPurger
 	UserGetter   cmd.UserGetter
 	GroupGetter  cmd.GroupGetter
 	StudentSaver cmd.StudentSaver
 	PgxPool      *pgxpool.Pool
+	// PasswordPolicy defaults to a policy without breach check.
+	PasswordPolicy *user.PasswordPolicy
+	// TOSVersions is optional, without it no terms of service are recorded.
+	TOSVersions tos.CurrentGetter
+	// Clock defaults to clock.Real.
+	Clock clock.Clock
 }
 
 func NewApp(args Args) *App {
 	return &App{
 		Command: Command{
-			StartStudent: cmd.NewStartStudentHandler(cmd.StartStudentHandlerArgs{
-				Mode:       args.Mode,
-				Repo:       args.Repo,
-				UserGetter: args.UserGetter,
-			}),
-			Verify: cmd.NewVerifyHandler(cmd.VerifyHandlerArgs{
-				RegistrationRepo: args.Repo,
-			}),
-			StudentComplete: cmd.NewStudentCompleteHandler(cmd.StudentCompleteHandlerArgs{
-				UserGetter:       args.UserGetter,
-				RegistrationRepo: args.Repo,
-				GroupGetter:      args.GroupGetter,
-				StudentSaver:     args.StudentSaver,
-			}),
-			ResendCode: cmd.NewResendCodeHandler(cmd.ResendCodeHandlerArgs{
-				Repo:       args.Repo,
-				UserGetter: args.UserGetter,
+			StartStudent: otelx.InstrumentCommand[cmd.StartStudent](
+				"StartStudentHandler.Handle",
+				// A double submitted start would fail with the resend
+				// cooldown, it gets the result of the first instead.
+				singleflightx.Command(
+					singleflightx.NewGroup("registration.start", nil),
+					cmd.StartStudent.FlightKey,
+					cmd.NewStartStudentHandler(cmd.StartStudentHandlerArgs{
+						Mode:       args.Mode,
+						Repo:       args.Repo,
+						UserGetter: args.UserGetter,
+						Clock:      args.Clock,
+					}),
+				),
+			),
+			Verify: otelx.InstrumentCommand[cmd.Verify](
+				"VerifyHandler.Handle",
+				cmd.NewVerifyHandler(cmd.VerifyHandlerArgs{
+					RegistrationRepo: args.Repo,
+				}),
+			),
+			StudentComplete: otelx.InstrumentCommand[cmd.StudentComplete](
+				"StudentCompleteHandler.Handle",
+				cmd.NewStudentCompleteHandler(cmd.StudentCompleteHandlerArgs{
+					UserGetter:       args.UserGetter,
+					RegistrationRepo: args.Repo,
+					GroupGetter:      args.GroupGetter,
+					StudentSaver:     args.StudentSaver,
+					PasswordPolicy:   args.PasswordPolicy,
+					TOSVersions:      args.TOSVersions,
+					Clock:            args.Clock,
+				}),
+			),
+			ResendCode: otelx.InstrumentCommand[cmd.ResendCode](
+				"ResendCodeHandler.Handle",
+				singleflightx.Command(
+					singleflightx.NewGroup("registration.resend_code", nil),
+					cmd.ResendCode.FlightKey,
+					cmd.NewResendCodeHandler(cmd.ResendCodeHandlerArgs{
+						Repo:       args.Repo,
+						UserGetter: args.UserGetter,
+					}),
+				),
+			),
+			PurgeRegistrations: cmd.NewPurgeRegistrationsHandler(cmd.PurgeRegistrationsHandlerArgs{
+				Repo:  args.Purger,
+				Clock: args.Clock,
 			}),
 		},
 		Event: Event{
@@ -0,0 +1,116 @@
+package registration
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/application/registration/cmd"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/registration"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/emails"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/env"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/fixtures"
+	"gitlab.com/ucmsv2/ucms-backend/tests/mocks"
+)
+
+// codeCountingRepo counts the verification codes generated, a saved
+// registration or a resent code, and saves slowly so that the concurrent
+// starts overlap.
+type codeCountingRepo struct {
+	*mocks.RegistrationRepo
+	generated atomic.Int32
+}
+
+func (r *codeCountingRepo) SaveRegistration(ctx context.Context, reg *registration.Registration) error {
+	time.Sleep(100 * time.Millisecond)
+	err := r.RegistrationRepo.SaveRegistration(ctx, reg)
+	if err == nil {
+		r.generated.Add(1)
+	}
+	return err
+}
+
+func (r *codeCountingRepo) UpdateRegistration(
+	ctx context.Context,
+	id registration.ID,
+	fn func(context.Context, *registration.Registration) error,
+) error {
+	err := r.RegistrationRepo.UpdateRegistration(ctx, id, fn)
+	if err == nil {
+		r.generated.Add(1)
+	}
+	return err
+}
+
+func TestApp_ConcurrentStarts_GenerateOneCode(t *testing.T) {
+	t.Parallel()
+
+	repo := &codeCountingRepo{RegistrationRepo: mocks.NewRegistrationRepo()}
+	app := NewApp(Args{
+		Mode:       env.Test,
+		Repo:       repo,
+		UserGetter: mocks.NewUserRepo(),
+	})
+	email := fixtures.ValidStudentEmail
+
+	const starts = 10
+	var wg sync.WaitGroup
+	errs := make([]error, starts)
+	start := make(chan struct{})
+	for i := range starts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			errs[i] = app.Command.StartStudent.Handle(t.Context(), cmd.StartStudent{Email: emails.Email(email)})
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	for _, err := range errs {
+		assert.NoError(t, err, "the starts share the result of the first")
+	}
+	assert.Equal(t, int32(1), repo.generated.Load())
+	reg, err := repo.GetRegistrationByEmail(t.Context(), emails.Email(email))
+	require.NoError(t, err)
+	registration.NewRegistrationAssertion(reg).AssertStatus(t, registration.StatusPending)
+	repo.AssertEventCount(t, 1)
+	mocks.RequireEventExists(t, repo.EventRepo, &registration.RegistrationStarted{})
+}
+
+func TestApp_ConcurrentStarts_OtherEmails(t *testing.T) {
+	t.Parallel()
+
+	repo := &codeCountingRepo{RegistrationRepo: mocks.NewRegistrationRepo()}
+	app := NewApp(Args{
+		Mode:       env.Test,
+		Repo:       repo,
+		UserGetter: mocks.NewUserRepo(),
+	})
+	addrs := []string{fixtures.ValidStudentEmail, fixtures.ValidStudent2Email}
+
+	var wg sync.WaitGroup
+	errs := make([]error, len(addrs))
+	for i, email := range addrs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = app.Command.StartStudent.Handle(t.Context(), cmd.StartStudent{Email: emails.Email(email)})
+		}()
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		require.NoError(t, err)
+	}
+	assert.Equal(t, int32(2), repo.generated.Load(), "the starts of other emails are not coalesced")
+	for _, email := range addrs {
+		repo.AssertRegistrationExistsByEmail(t, email)
+	}
+}
@@ -6,19 +6,20 @@ import (
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/group"
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/registration"
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/emails"
 )
 
 type Repo interface {
-	GetRegistrationByEmail(ctx context.Context, email string) (*registration.Registration, error)
+	GetRegistrationByEmail(ctx context.Context, email emails.Email) (*registration.Registration, error)
 	SaveRegistration(ctx context.Context, r *registration.Registration) error
 	UpdateRegistration(ctx context.Context, id registration.ID, fn func(context.Context, *registration.Registration) error) error
-	UpdateRegistrationByEmail(ctx context.Context, email string, fn func(context.Context, *registration.Registration) error) error
+	UpdateRegistrationByEmail(ctx context.Context, email emails.Email, fn func(context.Context, *registration.Registration) error) error
 }
 
 type UserGetter interface {
-	GetUserByEmail(ctx context.Context, email string) (*user.User, error)
+	GetUserByEmail(ctx context.Context, email emails.Email) (*user.User, error)
 	GetUserByBarcode(ctx context.Context, barcode user.Barcode) (*user.User, error)
-	IsUserExists(ctx context.Context, email, username string, barcode user.Barcode) (emailExists, usernameExists, barcodeExists bool, err error)
+	IsUserExists(ctx context.Context, email emails.Email, username string, barcode user.Barcode) (emailExists, usernameExists, barcodeExists bool, err error)
 }
 
 type GroupGetter interface {
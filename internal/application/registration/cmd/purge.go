@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+)
+
+type RegistrationPurger interface {
+	DeleteUnfinishedRegistrations(ctx context.Context, cutoff time.Time) (int64, error)
+}
+
+// PurgeRegistrations deletes the registrations that were started more than
+// OlderThan ago and never completed, it is run by an operator with
+// `ucms-api registration purge`. The completed ones are kept.
+type PurgeRegistrations struct {
+	OlderThan time.Duration
+}
+
+type PurgeRegistrationsResult struct {
+	Cutoff  time.Time
+	Deleted int64
+}
+
+type PurgeRegistrationsHandler struct {
+	logger *slog.Logger
+	repo   RegistrationPurger
+	clock  clock.Clock
+}
+
+type PurgeRegistrationsHandlerArgs struct {
+	Logger *slog.Logger
+	Repo   RegistrationPurger
+	// Clock defaults to clock.Real.
+	Clock clock.Clock
+}
+
+func NewPurgeRegistrationsHandler(args PurgeRegistrationsHandlerArgs) *PurgeRegistrationsHandler {
+	if args.Logger == nil {
+		args.Logger = logger
+	}
+
+	return &PurgeRegistrationsHandler{
+		logger: args.Logger,
+		repo:   args.Repo,
+		clock:  clock.Or(args.Clock),
+	}
+}
+
+func (h *PurgeRegistrationsHandler) Handle(ctx context.Context, cmd PurgeRegistrations) (*PurgeRegistrationsResult, error) {
+	const op = "cmd.PurgeRegistrationsHandler.Handle"
+	span := trace.SpanFromContext(ctx)
+
+	if cmd.OlderThan <= 0 {
+		return nil, errorx.NewInvalidRequest().WithCause(
+			fmt.Errorf("the age must be positive, got %s", cmd.OlderThan), op)
+	}
+
+	res := &PurgeRegistrationsResult{Cutoff: h.clock.Now().UTC().Add(-cmd.OlderThan)}
+	deleted, err := h.repo.DeleteUnfinishedRegistrations(ctx, res.Cutoff)
+	if err != nil {
+		span.AddEvent("failed to delete unfinished registrations")
+		return nil, errorx.Wrap(err, op)
+	}
+	res.Deleted = deleted
+
+	h.logger.InfoContext(ctx, "Unfinished registrations purged",
+		"cutoff", res.Cutoff, "deleted", res.Deleted)
+	return res, nil
+}
@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/registration"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/builders"
+	"gitlab.com/ucmsv2/ucms-backend/tests/mocks"
+)
+
+func TestPurgeRegistrationsHandler(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 9, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("deletes the old unfinished registrations", func(t *testing.T) {
+		t.Parallel()
+		repo := mocks.NewRegistrationRepo()
+		stale := builders.NewRegistrationBuilder().WithEmail("stale@test.com").
+			WithCreatedAt(now.Add(-31 * 24 * time.Hour)).Build()
+		staleVerified := builders.NewRegistrationBuilder().WithEmail("stale-verified@test.com").
+			WithStatus(registration.StatusVerified).WithCreatedAt(now.Add(-40 * 24 * time.Hour)).Build()
+		completed := builders.NewRegistrationBuilder().WithEmail("completed@test.com").
+			WithStatus(registration.StatusCompleted).WithCreatedAt(now.Add(-60 * 24 * time.Hour)).Build()
+		recent := builders.NewRegistrationBuilder().WithEmail("recent@test.com").
+			WithCreatedAt(now.Add(-time.Hour)).Build()
+		for _, reg := range []*registration.Registration{stale, staleVerified, completed, recent} {
+			repo.SeedRegistration(t, reg)
+		}
+		h := NewPurgeRegistrationsHandler(PurgeRegistrationsHandlerArgs{Repo: repo, Clock: clock.NewFake(now)})
+
+		res, err := h.Handle(t.Context(), PurgeRegistrations{OlderThan: 30 * 24 * time.Hour})
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), res.Deleted)
+		assert.Equal(t, now.Add(-30*24*time.Hour), res.Cutoff)
+
+		repo.AssertRegistrationNotExistsByEmail(t, stale.Email().String())
+		repo.AssertRegistrationNotExistsByEmail(t, staleVerified.Email().String())
+		repo.AssertRegistrationExistsByEmail(t, completed.Email().String())
+		repo.AssertRegistrationExistsByEmail(t, recent.Email().String())
+	})
+
+	t.Run("non-positive age", func(t *testing.T) {
+		t.Parallel()
+		h := NewPurgeRegistrationsHandler(PurgeRegistrationsHandlerArgs{Repo: mocks.NewRegistrationRepo()})
+
+		_, err := h.Handle(t.Context(), PurgeRegistrations{OlderThan: 0})
+		assert.True(t, errorx.IsCode(err, errorx.CodeInvalid), "unexpected error: %v", err)
+	})
+}
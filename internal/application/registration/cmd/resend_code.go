@@ -4,43 +4,48 @@ import (
 	"context"
 	"log/slog"
 
-	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
 
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/registration"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/emails"
 	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
-	"gitlab.com/ucmsv2/ucms-backend/pkg/logging"
 	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx"
 )
 
 type ResendCode struct {
-	Email string
+	Email emails.Email
+}
+
+func (c ResendCode) SpanAttrs() map[string]any {
+	return map[string]any{
+		"email": c.Email.String(),
+	}
+}
+
+// FlightKey identifies the concurrent resends of an email, they are handled
+// once, see singleflightx.
+func (c ResendCode) FlightKey() string {
+	return c.Email.String()
 }
 
 type ResendCodeHandler struct {
-	tracer     trace.Tracer
 	logger     *slog.Logger
 	repo       Repo
 	usergetter UserGetter
 }
 
 type ResendCodeHandlerArgs struct {
-	Tracer     trace.Tracer
 	Logger     *slog.Logger
 	Repo       Repo
 	UserGetter UserGetter
 }
 
 func NewResendCodeHandler(args ResendCodeHandlerArgs) *ResendCodeHandler {
-	if args.Tracer == nil {
-		args.Tracer = tracer
-	}
 	if args.Logger == nil {
 		args.Logger = logger
 	}
 
 	return &ResendCodeHandler{
-		tracer:     args.Tracer,
 		logger:     args.Logger,
 		repo:       args.Repo,
 		usergetter: args.UserGetter,
@@ -49,19 +54,15 @@ func NewResendCodeHandler(args ResendCodeHandlerArgs) *ResendCodeHandler {
 
 func (h *ResendCodeHandler) Handle(ctx context.Context, cmd ResendCode) error {
 	const op = "cmd.ResendCodeHandler.Handle"
-	ctx, span := h.tracer.Start(ctx, "ResendCodeHandler.Handle",
-		trace.WithAttributes(
-			attribute.String("email", logging.RedactEmail(cmd.Email)),
-		))
-	defer span.End()
+	span := trace.SpanFromContext(ctx)
 
 	user, err := h.usergetter.GetUserByEmail(ctx, cmd.Email)
 	if err != nil && !errorx.IsNotFound(err) {
-		otelx.RecordSpanError(span, err, "failed to get user by email")
+		span.AddEvent("failed to get user by email")
 		return errorx.Wrap(err, op)
 	}
 	if user != nil {
-		otelx.RecordSpanError(span, ErrEmailNotAvailable, "user already exists with this email")
+		span.AddEvent("user already exists with this email")
 		return errorx.Wrap(ErrEmailNotAvailable, op)
 	}
 	span.AddEvent("user not found, proceeding to resend code")
@@ -81,7 +82,7 @@ func (h *ResendCodeHandler) Handle(ctx context.Context, cmd ResendCode) error {
 		return nil
 	})
 	if err != nil {
-		otelx.RecordSpanError(span, err, "failed to update registration by email")
+		span.AddEvent("failed to update registration by email")
 		return errorx.Wrap(err, op)
 	}
 
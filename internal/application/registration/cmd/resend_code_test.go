@@ -7,6 +7,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/registration"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/emails"
 	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
 	"gitlab.com/ucmsv2/ucms-backend/tests/integration/builders"
 	"gitlab.com/ucmsv2/ucms-backend/tests/mocks"
@@ -56,7 +57,7 @@ func TestResendCodeHandler_HappyPath(t *testing.T) {
 		err := s.Handler.Handle(t.Context(), cmd)
 		require.NoError(t, err)
 
-		s.MockRepo.AssertRegistrationExistsByEmail(t, reg.Email()).
+		s.MockRepo.AssertRegistrationExistsByEmail(t, reg.Email().String()).
 			AssertStatus(t, registration.StatusPending).
 			AssertCodeAttempts(t, 0).
 			AssertResendNotAvailable(t).
@@ -66,7 +67,7 @@ func TestResendCodeHandler_HappyPath(t *testing.T) {
 		e := mocks.RequireEventExists(t, s.MockRepo.EventRepo, &registration.VerificationCodeResent{})
 		registration.NewVerificationCodeSentAssertion(e).
 			AssertRegistrationID(t, reg.ID()).
-			AssertEmail(t, reg.Email()).
+			AssertEmail(t, reg.Email().String()).
 			AssertVerificationCode(t, reg.VerificationCode())
 	})
 
@@ -88,7 +89,7 @@ func TestResendCodeHandler_HappyPath(t *testing.T) {
 		err := s.Handler.Handle(t.Context(), cmd)
 		require.NoError(t, err)
 
-		s.MockRepo.AssertRegistrationExistsByEmail(t, reg.Email()).
+		s.MockRepo.AssertRegistrationExistsByEmail(t, reg.Email().String()).
 			AssertStatus(t, registration.StatusPending).
 			AssertCodeAttempts(t, 0).
 			AssertResendNotAvailable(t).
@@ -98,7 +99,7 @@ func TestResendCodeHandler_HappyPath(t *testing.T) {
 		e := mocks.RequireEventExists(t, s.MockRepo.EventRepo, &registration.VerificationCodeResent{})
 		registration.NewVerificationCodeSentAssertion(e).
 			AssertRegistrationID(t, reg.ID()).
-			AssertEmail(t, reg.Email()).
+			AssertEmail(t, reg.Email().String()).
 			AssertVerificationCode(t, reg.VerificationCode())
 	})
 
@@ -114,13 +115,13 @@ func TestResendCodeHandler_HappyPath(t *testing.T) {
 		s.MockRepo.SeedRegistration(t, reg)
 
 		cmd := ResendCode{
-			Email: email,
+			Email: emails.Email(email),
 		}
 
 		err := s.Handler.Handle(t.Context(), cmd)
 		require.NoError(t, err)
 
-		s.MockRepo.AssertRegistrationExistsByEmail(t, reg.Email()).
+		s.MockRepo.AssertRegistrationExistsByEmail(t, reg.Email().String()).
 			AssertStatus(t, registration.StatusPending).
 			AssertCodeAttempts(t, 0).
 			AssertResendNotAvailable(t).
@@ -130,7 +131,7 @@ func TestResendCodeHandler_HappyPath(t *testing.T) {
 		e := mocks.RequireEventExists(t, s.MockRepo.EventRepo, &registration.VerificationCodeResent{})
 		registration.NewVerificationCodeSentAssertion(e).
 			AssertRegistrationID(t, reg.ID()).
-			AssertEmail(t, reg.Email()).
+			AssertEmail(t, reg.Email().String()).
 			AssertVerificationCode(t, reg.VerificationCode())
 	})
 }
@@ -148,7 +149,7 @@ func TestResendCodeHandler_ErrorCases(t *testing.T) {
 		s.MockUserRepo.SeedUser(t, existingUser)
 
 		cmd := ResendCode{
-			Email: email,
+			Email: emails.Email(email),
 		}
 
 		err := s.Handler.Handle(t.Context(), cmd)
@@ -160,7 +161,7 @@ func TestResendCodeHandler_ErrorCases(t *testing.T) {
 		email := "nonexistent@test.com"
 
 		cmd := ResendCode{
-			Email: email,
+			Email: emails.Email(email),
 		}
 
 		err := s.Handler.Handle(t.Context(), cmd)
@@ -177,7 +178,7 @@ func TestResendCodeHandler_ErrorCases(t *testing.T) {
 		s.MockRepo.SeedRegistration(t, reg)
 
 		cmd := ResendCode{
-			Email: email,
+			Email: emails.Email(email),
 		}
 
 		err := s.Handler.Handle(t.Context(), cmd)
@@ -195,7 +196,7 @@ func TestResendCodeHandler_ErrorCases(t *testing.T) {
 		s.MockRepo.SeedRegistration(t, reg)
 
 		cmd := ResendCode{
-			Email: email,
+			Email: emails.Email(email),
 		}
 
 		err := s.Handler.Handle(t.Context(), cmd)
@@ -229,7 +230,7 @@ func TestResendCodeHandler_EdgeCases(t *testing.T) {
 		s.MockRepo.SeedRegistration(t, reg)
 
 		cmd := ResendCode{
-			Email: email,
+			Email: emails.Email(email),
 		}
 
 		err := s.Handler.Handle(t.Context(), cmd)
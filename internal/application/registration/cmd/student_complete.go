@@ -4,15 +4,18 @@ import (
 	"context"
 	"log/slog"
 
-	"go.opentelemetry.io/otel/attribute"
+	"github.com/ARUMANDESU/validation"
+	"go.opentelemetry.io/otel/metric"
 	"go.opentelemetry.io/otel/trace"
 
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/group"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/tos"
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/emails"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
 	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
 	"gitlab.com/ucmsv2/ucms-backend/pkg/i18nx"
-	"gitlab.com/ucmsv2/ucms-backend/pkg/logging"
-	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx/metrics"
 )
 
 var (
@@ -21,7 +24,7 @@ var (
 )
 
 type StudentComplete struct {
-	Email            string
+	Email            emails.Email
 	VerificationCode string
 	Barcode          user.Barcode
 	Username         string
@@ -29,57 +32,84 @@ type StudentComplete struct {
 	LastName         string
 	Password         string
 	GroupID          group.ID
+	// AcceptTOS is the consent to the current terms of service, required
+	// once a version is published.
+	AcceptTOS bool
+	// IP is the address the terms of service were accepted from.
+	IP string
+}
+
+func (c StudentComplete) SpanAttrs() map[string]any {
+	return map[string]any{
+		"student.email":   c.Email.String(),
+		"student.barcode": c.Barcode.String(),
+		"group.id":        c.GroupID.String(),
+		"accept_tos":      c.AcceptTOS,
+	}
 }
 
 type StudentCompleteHandler struct {
-	tracer       trace.Tracer
-	logger       *slog.Logger
-	usergetter   UserGetter
-	groupgetter  GroupGetter
-	regRepo      Repo
-	studentSaver StudentSaver
+	logger         *slog.Logger
+	usergetter     UserGetter
+	groupgetter    GroupGetter
+	regRepo        Repo
+	studentSaver   StudentSaver
+	passwordPolicy *user.PasswordPolicy
+	tosVersions    tos.CurrentGetter
+	completed      metric.Int64Counter
+	clock          clock.Clock
 }
 
 type StudentCompleteHandlerArgs struct {
-	Trace            trace.Tracer
 	Logger           *slog.Logger
 	UserGetter       UserGetter
 	GroupGetter      GroupGetter
 	RegistrationRepo Repo
 	StudentSaver     StudentSaver
+	// PasswordPolicy defaults to a policy without breach check.
+	PasswordPolicy *user.PasswordPolicy
+	// TOSVersions is optional, without it no terms of service are recorded.
+	TOSVersions tos.CurrentGetter
+	// Clock defaults to clock.Real.
+	Clock clock.Clock
+	// Metrics defaults to metrics.Default().
+	Metrics *metrics.Registry
 }
 
 func NewStudentCompleteHandler(args StudentCompleteHandlerArgs) *StudentCompleteHandler {
-	if args.Trace == nil {
-		args.Trace = tracer
-	}
 	if args.Logger == nil {
 		args.Logger = logger
 	}
+	if args.Metrics == nil {
+		args.Metrics = metrics.Default()
+	}
+	if args.PasswordPolicy == nil {
+		args.PasswordPolicy = user.NewPasswordPolicy(user.PasswordPolicyArgs{})
+	}
 
 	return &StudentCompleteHandler{
-		tracer:       args.Trace,
-		logger:       args.Logger,
-		usergetter:   args.UserGetter,
-		groupgetter:  args.GroupGetter,
-		regRepo:      args.RegistrationRepo,
-		studentSaver: args.StudentSaver,
+		logger:         args.Logger,
+		usergetter:     args.UserGetter,
+		groupgetter:    args.GroupGetter,
+		regRepo:        args.RegistrationRepo,
+		studentSaver:   args.StudentSaver,
+		passwordPolicy: args.PasswordPolicy,
+		tosVersions:    args.TOSVersions,
+		completed: args.Metrics.Int64Counter(metrics.RegistrationCompleted,
+			metric.WithDescription("Number of completed student registrations"),
+			metric.WithUnit("{registration}"),
+		),
+		clock: args.Clock,
 	}
 }
 
 func (h *StudentCompleteHandler) Handle(ctx context.Context, cmd StudentComplete) error {
 	const op = "cmd.StudentCompleteHandler.Handle"
-	ctx, span := h.tracer.Start(ctx, "StudentCompleteHandler.Handle",
-		trace.WithAttributes(
-			attribute.String("student.email", logging.RedactEmail(cmd.Email)),
-			attribute.String("student.barcode", cmd.Barcode.String()),
-			attribute.String("group.id", cmd.GroupID.String()),
-		))
-	defer span.End()
+	span := trace.SpanFromContext(ctx)
 
 	emailExists, usernameExists, barcodeExists, err := h.usergetter.IsUserExists(ctx, cmd.Email, cmd.Username, cmd.Barcode)
 	if err != nil {
-		otelx.RecordSpanError(span, err, "failed to check if user exists")
+		span.AddEvent("failed to check if user exists")
 		return errorx.Wrap(err, op)
 	}
 	if emailExists || usernameExists || barcodeExists {
@@ -93,33 +123,56 @@ func (h *StudentCompleteHandler) Handle(ctx context.Context, cmd StudentComplete
 		if barcodeExists {
 			errs = append(errs, ErrBarcodeNotAvailable)
 		}
-		otelx.RecordSpanError(span, errs, "validation error: user already exists")
+		span.AddEvent("validation error: user already exists")
 		return errorx.Wrap(errs, op)
 	}
 
-	_, err = h.groupgetter.GetGroupByID(ctx, group.ID(cmd.GroupID))
+	g, err := h.groupgetter.GetGroupByID(ctx, group.ID(cmd.GroupID))
 	if err != nil {
-		otelx.RecordSpanError(span, err, "failed to get group by ID")
+		span.AddEvent("failed to get group by ID")
 		if errorx.IsNotFound(err) {
 			return errorx.NewResourceNotFound(i18nx.FieldGroup).WithCause(err, op)
 		}
 		return errorx.Wrap(err, op)
 	}
+	if err := g.CheckEnrollmentOpen(clock.Or(h.clock).Now()); err != nil {
+		span.AddEvent("group enrollment closed")
+		return errorx.Wrap(err, op)
+	}
 
 	reg, err := h.regRepo.GetRegistrationByEmail(ctx, cmd.Email)
 	if err != nil {
-		otelx.RecordSpanError(span, err, "failed to get registration by email")
+		span.AddEvent("failed to get registration by email")
 		return errorx.Wrap(err, op)
 	}
 
 	err = reg.CheckCode(cmd.VerificationCode)
 	if err != nil {
-		otelx.RecordSpanError(span, err, "failed to verify code")
+		span.AddEvent("failed to verify code")
 		return errorx.Wrap(err, op)
 	}
 
+	if err := h.passwordPolicy.Check(ctx, cmd.Password); err != nil {
+		span.AddEvent("password rejected by the password policy")
+		return errorx.Wrap(err, op)
+	}
+
+	terms, err := tos.Current(ctx, h.tosVersions, clock.Or(h.clock).Now())
+	if err != nil {
+		span.AddEvent("failed to get current tos version")
+		return errorx.Wrap(err, op)
+	}
+	consent := user.TOSConsent{}
+	if terms != nil {
+		if !cmd.AcceptTOS {
+			span.AddEvent("terms of service not accepted")
+			return errorx.Wrap(validation.Errors{i18nx.FieldAcceptTOS: validation.ErrRequired}, op)
+		}
+		consent = user.TOSConsent{Version: terms.Version(), IP: cmd.IP}
+	}
+
 	student, err := user.RegisterStudent(user.RegisterStudentArgs{
-		Barcode:        user.Barcode(cmd.Barcode),
+		Barcode:        cmd.Barcode,
 		Username:       cmd.Username,
 		RegistrationID: reg.ID(),
 		FirstName:      cmd.FirstName,
@@ -127,17 +180,20 @@ func (h *StudentCompleteHandler) Handle(ctx context.Context, cmd StudentComplete
 		Email:          cmd.Email,
 		Password:       cmd.Password,
 		GroupID:        cmd.GroupID,
+		TOS:            consent,
+		Clock:          h.clock,
 	})
 	if err != nil {
-		otelx.RecordSpanError(span, err, "failed to register student")
+		span.AddEvent("failed to register student")
 		return errorx.Wrap(err, op)
 	}
 
 	err = h.studentSaver.SaveStudent(ctx, student)
 	if err != nil {
-		otelx.RecordSpanError(span, err, "failed to save student")
+		span.AddEvent("failed to save student")
 		return errorx.Wrap(err, op)
 	}
+	h.completed.Add(ctx, 1)
 
 	return nil
 }
@@ -6,12 +6,16 @@ import (
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
 
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/group"
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/registration"
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/emails"
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/roles"
 	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx/metrics"
 	"gitlab.com/ucmsv2/ucms-backend/tests/integration/builders"
 	"gitlab.com/ucmsv2/ucms-backend/tests/integration/fixtures"
 	"gitlab.com/ucmsv2/ucms-backend/tests/mocks"
@@ -23,6 +27,7 @@ type StudentCompleteSuite struct {
 	MockRegistration *mocks.RegistrationRepo
 	MockGroup        *mocks.GroupRepo
 	MockStudent      *mocks.StudentRepo
+	Metrics          *sdkmetric.ManualReader
 }
 
 func NewStudentCompleteSuite(t *testing.T) *StudentCompleteSuite {
@@ -30,6 +35,7 @@ func NewStudentCompleteSuite(t *testing.T) *StudentCompleteSuite {
 	mockRegistration := mocks.NewRegistrationRepo()
 	mockGroup := mocks.NewGroupRepo()
 	mockStudent := mocks.NewStudentRepo()
+	reader := sdkmetric.NewManualReader()
 
 	// Seed a group for the test
 	group := builders.NewGroupBuilder().Build()
@@ -40,6 +46,7 @@ func NewStudentCompleteSuite(t *testing.T) *StudentCompleteSuite {
 		RegistrationRepo: mockRegistration,
 		GroupGetter:      mockGroup,
 		StudentSaver:     mockStudent,
+		Metrics:          metrics.NewRegistry(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)).Meter("test")),
 	})
 
 	return &StudentCompleteSuite{
@@ -48,6 +55,7 @@ func NewStudentCompleteSuite(t *testing.T) *StudentCompleteSuite {
 		MockRegistration: mockRegistration,
 		MockGroup:        mockGroup,
 		MockStudent:      mockStudent,
+		Metrics:          reader,
 	}
 }
 
@@ -63,7 +71,7 @@ func TestStudentCompleteHandler_HappyPath(t *testing.T) {
 		s.MockRegistration.SeedRegistration(t, reg)
 
 		err := s.Handler.Handle(t.Context(), StudentComplete{
-			Email:            fixtures.TestStudent.Email,
+			Email:            emails.Email(fixtures.TestStudent.Email),
 			VerificationCode: reg.VerificationCode(),
 			Barcode:          fixtures.TestStudent.Barcode,
 			Username:         fixtures.TestStudent.Username,
@@ -96,6 +104,53 @@ func TestStudentCompleteHandler_HappyPath(t *testing.T) {
 	})
 }
 
+// completedCount returns the value of the completed registrations counter.
+func (s *StudentCompleteSuite) completedCount(t *testing.T) int64 {
+	t.Helper()
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, s.Metrics.Collect(t.Context(), &rm))
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name == metrics.RegistrationCompleted {
+				var total int64
+				for _, dp := range m.Data.(metricdata.Sum[int64]).DataPoints {
+					total += dp.Value
+				}
+				return total
+			}
+		}
+	}
+	return 0
+}
+
+func TestStudentCompleteHandler_CountsCompletedRegistrations(t *testing.T) {
+	t.Parallel()
+
+	s := NewStudentCompleteSuite(t)
+	reg := builders.NewRegistrationBuilder().
+		WithEmail(fixtures.ValidStudentEmail).
+		WithStatus(registration.StatusVerified).
+		Build()
+	s.MockRegistration.SeedRegistration(t, reg)
+
+	cmd := StudentComplete{
+		Email:            emails.Email(fixtures.TestStudent.Email),
+		VerificationCode: reg.VerificationCode(),
+		Barcode:          fixtures.TestStudent.Barcode,
+		Username:         fixtures.TestStudent.Username,
+		FirstName:        fixtures.TestStudent.FirstName,
+		LastName:         fixtures.TestStudent.LastName,
+		Password:         fixtures.TestStudent.Password,
+		GroupID:          fixtures.TestStudent.GroupID,
+	}
+	require.NoError(t, s.Handler.Handle(t.Context(), cmd))
+	assert.Equal(t, int64(1), s.completedCount(t))
+
+	cmd.VerificationCode = "WRONG1"
+	require.Error(t, s.Handler.Handle(t.Context(), cmd))
+	assert.Equal(t, int64(1), s.completedCount(t), "failed commands should not be counted")
+}
+
 func TestStudentCompleteHandler_UserAlreadyExists_ShouldFail(t *testing.T) {
 	t.Parallel()
 
@@ -107,7 +162,7 @@ func TestStudentCompleteHandler_UserAlreadyExists_ShouldFail(t *testing.T) {
 			Build()
 		s.MockRegistration.SeedRegistration(t, reg)
 		err := s.Handler.Handle(t.Context(), StudentComplete{
-			Email:            fixtures.TestStudent.Email,
+			Email:            emails.Email(fixtures.TestStudent.Email),
 			VerificationCode: reg.VerificationCode(),
 			Barcode:          fixtures.TestStudent.Barcode,
 			Username:         fixtures.TestStudent.Username,
@@ -145,7 +200,7 @@ func TestStudentCompleteHandler_UserAlreadyExists_ShouldFail(t *testing.T) {
 		s.MockUser.SeedUser(t, u)
 
 		err := s.Handler.Handle(t.Context(), StudentComplete{
-			Email:            fixtures.TestStudent.Email,
+			Email:            emails.Email(fixtures.TestStudent.Email),
 			VerificationCode: fixtures.ValidVerificationCode,
 			Barcode:          u.Barcode(),
 			Username:         fixtures.TestStudent.Username,
@@ -186,7 +241,7 @@ func TestStudentCompleteHandler_UserAlreadyExists_ShouldFail(t *testing.T) {
 			Build()
 		s.MockRegistration.SeedRegistration(t, reg)
 		err := s.Handler.Handle(t.Context(), StudentComplete{
-			Email:            fixtures.TestStudent.Email,
+			Email:            emails.Email(fixtures.TestStudent.Email),
 			VerificationCode: reg.VerificationCode(),
 			Barcode:          fixtures.TestStudent.Barcode,
 			Username:         fixtures.TestStudent.Username,
@@ -213,7 +268,7 @@ func TestStudentCompleteHandler_Verified(t *testing.T) {
 		s.MockRegistration.SeedRegistration(t, reg)
 
 		err := s.Handler.Handle(t.Context(), StudentComplete{
-			Email:            fixtures.TestStudent.Email,
+			Email:            emails.Email(fixtures.TestStudent.Email),
 			VerificationCode: fixtures.InvalidVerificationCode,
 			Barcode:          fixtures.TestStudent.Barcode,
 			Username:         fixtures.TestStudent.Username,
@@ -238,7 +293,7 @@ func TestStudentCompleteHandler_AlreadyCompleted_ShouldFail(t *testing.T) {
 	s.MockRegistration.SeedRegistration(t, reg)
 
 	err := s.Handler.Handle(t.Context(), StudentComplete{
-		Email:            fixtures.TestStudent.Email,
+		Email:            emails.Email(fixtures.TestStudent.Email),
 		VerificationCode: reg.VerificationCode(),
 		Barcode:          fixtures.TestStudent.Barcode,
 		Username:         fixtures.TestStudent.Username,
@@ -262,7 +317,7 @@ func TestStudentCompleteHandler_Pending_InvalidVerificationCode_ShouldFail(t *te
 	s.MockRegistration.SeedRegistration(t, reg)
 
 	err := s.Handler.Handle(t.Context(), StudentComplete{
-		Email:            fixtures.TestStudent.Email,
+		Email:            emails.Email(fixtures.TestStudent.Email),
 		VerificationCode: fixtures.InvalidVerificationCode,
 		Barcode:          fixtures.TestStudent.Barcode,
 		Username:         fixtures.TestStudent.Username,
@@ -281,7 +336,7 @@ func TestStudentCompleteHandler_RegistrationNotFound_ShouldFail(t *testing.T) {
 	s := NewStudentCompleteSuite(t)
 
 	err := s.Handler.Handle(t.Context(), StudentComplete{
-		Email:            fixtures.TestStudent.Email,
+		Email:            emails.Email(fixtures.TestStudent.Email),
 		VerificationCode: fixtures.ValidVerificationCode,
 		Barcode:          fixtures.TestStudent.Barcode,
 		Username:         fixtures.TestStudent.Username,
@@ -5,115 +5,138 @@ import (
 	"log/slog"
 
 	"go.opentelemetry.io/contrib/bridges/otelslog"
-	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
 
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/registration"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/emails"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
 	"gitlab.com/ucmsv2/ucms-backend/pkg/env"
 	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
 	"gitlab.com/ucmsv2/ucms-backend/pkg/i18nx"
-	"gitlab.com/ucmsv2/ucms-backend/pkg/logging"
-	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx"
 )
 
 var ErrEmailNotAvailable = errorx.NewDuplicateEntry().WithKey(i18nx.KeyEmailNotAvailable)
 
-var (
-	tracer = otel.Tracer("ucms/application/registration/cmd")
-	logger = otelslog.NewLogger("ucms/application/registration/cmd")
-)
+var logger = otelslog.NewLogger("ucms/application/registration/cmd")
 
 type StartStudent struct {
-	Email string
+	Email emails.Email
+}
+
+func (c StartStudent) SpanAttrs() map[string]any {
+	return map[string]any{
+		"student.email": c.Email.String(),
+	}
+}
+
+// FlightKey identifies the concurrent starts of an email, they are handled
+// once, see singleflightx.
+func (c StartStudent) FlightKey() string {
+	return c.Email.String()
 }
 
 type StartStudentHandler struct {
-	tracer     trace.Tracer
 	logger     *slog.Logger
 	mode       env.Mode
 	repo       Repo
 	usergetter UserGetter
+	clock      clock.Clock
 }
 
 type StartStudentHandlerArgs struct {
-	Tracer     trace.Tracer
 	Logger     *slog.Logger
 	Mode       env.Mode
 	Repo       Repo
 	UserGetter UserGetter
+	// Clock defaults to clock.Real.
+	Clock clock.Clock
 }
 
 func NewStartStudentHandler(args StartStudentHandlerArgs) *StartStudentHandler {
-	if args.Tracer == nil {
-		args.Tracer = tracer
-	}
 	if args.Logger == nil {
 		args.Logger = logger
 	}
 
 	return &StartStudentHandler{
-		tracer:     args.Tracer,
 		logger:     args.Logger,
 		mode:       args.Mode,
 		repo:       args.Repo,
 		usergetter: args.UserGetter,
+		clock:      args.Clock,
 	}
 }
 
+// Handle starts the registration of cmd.Email. Starting again, e.g. after the
+// student refreshed the page, continues the pending registration: the code is
+// resent as the resend endpoint does, and it fails with the resend cooldown
+// until it has passed. A completed registration makes the email unavailable.
 func (h *StartStudentHandler) Handle(ctx context.Context, cmd StartStudent) error {
 	const op = "cmd.StartStudentHandler.Handle"
-	ctx, span := h.tracer.Start(
-		ctx,
-		"StartStudentHandler.Handle",
-		trace.WithAttributes(attribute.String("student.email", logging.RedactEmail(cmd.Email))),
-	)
-	defer span.End()
+	span := trace.SpanFromContext(ctx)
 
 	user, err := h.usergetter.GetUserByEmail(ctx, cmd.Email)
 	if err != nil && !errorx.IsNotFound(err) {
-		otelx.RecordSpanError(span, err, "failed to get user by email")
+		span.AddEvent("failed to get user by email")
 		return errorx.Wrap(err, op)
 	}
 	if user != nil {
-		otelx.RecordSpanError(span, ErrEmailNotAvailable, "user already exists with this email")
+		span.AddEvent("user already exists with this email")
 		return errorx.Wrap(ErrEmailNotAvailable, op)
 	}
 	span.AddEvent("user not found, proceeding with registration")
 
 	reg, err := h.repo.GetRegistrationByEmail(ctx, cmd.Email)
 	if err != nil && !errorx.IsNotFound(err) {
-		otelx.RecordSpanError(span, err, "failed to get registration by email")
+		span.AddEvent("failed to get registration by email")
+		return errorx.Wrap(err, op)
+	}
+	if err == nil {
+		return h.resend(ctx, reg)
+	}
+
+	reg, err = registration.NewRegistration(cmd.Email, h.mode, h.clock)
+	if err != nil {
+		span.AddEvent("failed to create new registration")
 		return errorx.Wrap(err, op)
 	}
-	if errorx.IsNotFound(err) {
-		reg, err = registration.NewRegistration(cmd.Email, h.mode)
-		if err != nil {
-			otelx.RecordSpanError(span, err, "failed to create new registration")
-			return errorx.Wrap(err, op)
-		}
 
-		err = h.repo.SaveRegistration(ctx, reg)
+	err = h.repo.SaveRegistration(ctx, reg)
+	if errorx.IsDuplicateEntry(err) {
+		// A concurrent start saved the registration first, this one continues
+		// it like a second start would.
+		span.AddEvent("registration started concurrently")
+		reg, err = h.repo.GetRegistrationByEmail(ctx, cmd.Email)
 		if err != nil {
-			otelx.RecordSpanError(span, err, "failed to save new registration")
 			return errorx.Wrap(err, op)
 		}
-		span.AddEvent("registration saved successfully",
-			trace.WithAttributes(
-				attribute.String("registration.id", reg.ID().String()),
-				attribute.String("registration.status", reg.Status().String()),
-			),
-		)
-
-		return nil
+		return h.resend(ctx, reg)
 	}
+	if err != nil {
+		span.AddEvent("failed to save new registration")
+		return errorx.Wrap(err, op)
+	}
+	span.AddEvent("registration saved successfully",
+		trace.WithAttributes(
+			attribute.String("registration.id", reg.ID().String()),
+			attribute.String("registration.status", reg.Status().String()),
+		),
+	)
+
+	return nil
+}
+
+// resend resends the code of the existing registration reg.
+func (h *StartStudentHandler) resend(ctx context.Context, reg *registration.Registration) error {
+	const op = "cmd.StartStudentHandler.resend"
+	span := trace.SpanFromContext(ctx)
 
 	if reg.IsCompleted() {
-		otelx.RecordSpanError(span, ErrEmailNotAvailable, "registration already completed with this email")
+		span.AddEvent("registration already completed with this email")
 		return errorx.Wrap(ErrEmailNotAvailable, op)
 	}
 
-	err = h.repo.UpdateRegistration(ctx, reg.ID(), func(ctx context.Context, r *registration.Registration) error {
+	err := h.repo.UpdateRegistration(ctx, reg.ID(), func(ctx context.Context, r *registration.Registration) error {
 		err := r.ResendCode()
 		if err != nil {
 			trace.SpanFromContext(ctx).AddEvent("resend verification code failed")
@@ -123,9 +146,10 @@ func (h *StartStudentHandler) Handle(ctx context.Context, cmd StartStudent) erro
 		return nil
 	})
 	if err != nil {
-		otelx.RecordSpanError(span, err, "failed to resend code for existing registration")
+		span.AddEvent("failed to resend code for existing registration")
 		return errorx.Wrap(err, op)
 	}
+	span.AddEvent("verification code resent for existing registration")
 
 	return nil
 }
@@ -1,13 +1,16 @@
 package cmd
 
 import (
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/registration"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/emails"
 	"gitlab.com/ucmsv2/ucms-backend/pkg/env"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
 	"gitlab.com/ucmsv2/ucms-backend/tests/integration/builders"
 	"gitlab.com/ucmsv2/ucms-backend/tests/integration/fixtures"
 	"gitlab.com/ucmsv2/ucms-backend/tests/mocks"
@@ -43,7 +46,7 @@ func TestStartStudentHandler_HappyPath(t *testing.T) {
 	s := NewStudentStartTestSuite(t)
 	email := fixtures.ValidStudentEmail
 
-	err := s.Handler.Handle(t.Context(), StartStudent{Email: email})
+	err := s.Handler.Handle(t.Context(), StartStudent{Email: emails.Email(email)})
 	require.NoError(t, err)
 
 	s.MockRepo.
@@ -56,11 +59,11 @@ func TestStartStudentHandler_HappyPath(t *testing.T) {
 	e := mocks.RequireEventExists(t, s.MockRepo.EventRepo, &registration.RegistrationStarted{})
 	require.NotNil(t, e)
 
-	reg, err := s.MockRepo.GetRegistrationByEmail(t.Context(), email)
+	reg, err := s.MockRepo.GetRegistrationByEmail(t.Context(), emails.Email(email))
 	require.NoError(t, err)
 
 	assert.Equal(t, reg.ID(), e.RegistrationID)
-	assert.Equal(t, email, e.Email)
+	assert.Equal(t, email, e.Email.String())
 	assert.Equal(t, reg.VerificationCode(), e.VerificationCode)
 }
 
@@ -74,7 +77,7 @@ func TestStartStudentHandler_UserAlreadyExists_MustReturnError(t *testing.T) {
 	require.Error(t, err)
 	// assert.ErrorIs(t, err, apperr.ErrConflict)
 
-	s.MockRepo.AssertRegistrationNotExistsByEmail(t, u.Email())
+	s.MockRepo.AssertRegistrationNotExistsByEmail(t, u.Email().String())
 }
 
 func TestStartStudentHandler_RegistrationCompleted_MustReturnError(t *testing.T) {
@@ -88,7 +91,7 @@ func TestStartStudentHandler_RegistrationCompleted_MustReturnError(t *testing.T)
 		Build()
 	s.MockRepo.SeedRegistration(t, reg)
 
-	err := s.Handler.Handle(t.Context(), StartStudent{Email: email})
+	err := s.Handler.Handle(t.Context(), StartStudent{Email: emails.Email(email)})
 	require.Error(t, err)
 	// assert.ErrorIs(t, err, apperr.ErrConflict)
 
@@ -130,8 +133,12 @@ func TestStartStudentHandler_RegistrationAlreadyExists(t *testing.T) {
 					Build()
 				s.MockRepo.SeedRegistration(t, reg)
 
-				err := s.Handler.Handle(t.Context(), StartStudent{Email: email})
-				require.Error(t, err)
+				err := s.Handler.Handle(t.Context(), StartStudent{Email: emails.Email(email)})
+				require.ErrorIs(t, err, registration.ErrWaitUntilResend)
+				retry, ok := errorx.RetryOf(err)
+				require.True(t, ok, "the cooldown should tell when to retry")
+				assert.Equal(t, reg.ResendTimeout(), retry.RetryAt())
+				s.MockRepo.AssertEventCount(t, 0)
 			})
 
 			t.Run("resend timeout is expired, should resend verification code", func(t *testing.T) {
@@ -144,7 +151,7 @@ func TestStartStudentHandler_RegistrationAlreadyExists(t *testing.T) {
 					Build()
 				s.MockRepo.SeedRegistration(t, reg)
 
-				err := s.Handler.Handle(t.Context(), StartStudent{Email: email})
+				err := s.Handler.Handle(t.Context(), StartStudent{Email: emails.Email(email)})
 				require.NoError(t, err)
 
 				s.MockRepo.
@@ -157,10 +164,10 @@ func TestStartStudentHandler_RegistrationAlreadyExists(t *testing.T) {
 				e := mocks.RequireEventExists(t, s.MockRepo.EventRepo, &registration.VerificationCodeResent{})
 				require.NotNil(t, e)
 
-				reg, err = s.MockRepo.GetRegistrationByEmail(t.Context(), email)
+				reg, err = s.MockRepo.GetRegistrationByEmail(t.Context(), emails.Email(email))
 				require.NoError(t, err)
 				assert.Equal(t, reg.ID(), e.RegistrationID)
-				assert.Equal(t, email, e.Email)
+				assert.Equal(t, email, e.Email.String())
 				assert.Equal(t, reg.VerificationCode(), e.VerificationCode)
 			})
 		})
@@ -178,7 +185,7 @@ func TestStartStudentHandler_RegistrationAlreadyExists_StatusExpired(t *testing.
 		Build()
 	s.MockRepo.SeedRegistration(t, reg)
 
-	err := s.Handler.Handle(t.Context(), StartStudent{Email: email})
+	err := s.Handler.Handle(t.Context(), StartStudent{Email: emails.Email(email)})
 	require.Error(t, err)
 	// assert.ErrorIs(t, err, apperr.ErrConflict)
 
@@ -187,3 +194,42 @@ func TestStartStudentHandler_RegistrationAlreadyExists_StatusExpired(t *testing.
 		AssertEmail(t, email).
 		AssertVerificationCodeNotEmpty(t)
 }
+
+// concurrentStartRepo misses the registration on the first lookup, as when a
+// concurrent start saves it between the lookup and the save.
+type concurrentStartRepo struct {
+	*mocks.RegistrationRepo
+	missed bool
+}
+
+func (r *concurrentStartRepo) GetRegistrationByEmail(ctx context.Context, email emails.Email) (*registration.Registration, error) {
+	if !r.missed {
+		r.missed = true
+		return nil, errorx.NewNotFound()
+	}
+	return r.RegistrationRepo.GetRegistrationByEmail(ctx, email)
+}
+
+func TestStartStudentHandler_ConcurrentStart_ResendsCode(t *testing.T) {
+	t.Parallel()
+
+	mockRepo := mocks.NewRegistrationRepo()
+	email := fixtures.ValidStudentEmail
+	reg := builders.NewRegistrationBuilder().
+		WithEmail(email).
+		WithResendAvailable().
+		Build()
+	mockRepo.SeedRegistration(t, reg)
+	handler := NewStartStudentHandler(StartStudentHandlerArgs{
+		Mode:       env.Test,
+		Repo:       &concurrentStartRepo{RegistrationRepo: mockRepo},
+		UserGetter: mocks.NewUserRepo(),
+	})
+
+	err := handler.Handle(t.Context(), StartStudent{Email: emails.Email(email)})
+	require.NoError(t, err)
+
+	mockRepo.AssertEventCount(t, 1)
+	e := mocks.RequireEventExists(t, mockRepo.EventRepo, &registration.VerificationCodeResent{})
+	assert.Equal(t, reg.ID(), e.RegistrationID)
+}
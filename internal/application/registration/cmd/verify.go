@@ -5,44 +5,42 @@ import (
 	"log/slog"
 	"net/http"
 
-	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
 
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/registration"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/emails"
 	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
-	"gitlab.com/ucmsv2/ucms-backend/pkg/logging"
-	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx"
 )
 
 var ErrOKAlreadyVerified = errorx.NewAlreadyProcessed().WithHTTPCode(http.StatusOK)
 
 type Verify struct {
-	Email string
+	Email emails.Email
 	Code  string
 }
 
+func (c Verify) SpanAttrs() map[string]any {
+	return map[string]any{
+		"email": c.Email.String(),
+	}
+}
+
 type VerifyHandler struct {
-	tracer trace.Tracer
 	logger *slog.Logger
 	repo   Repo
 }
 
 type VerifyHandlerArgs struct {
-	Tracer           trace.Tracer
 	Logger           *slog.Logger
 	RegistrationRepo Repo
 }
 
 func NewVerifyHandler(args VerifyHandlerArgs) *VerifyHandler {
-	if args.Tracer == nil {
-		args.Tracer = tracer
-	}
 	if args.Logger == nil {
 		args.Logger = logger
 	}
 
 	return &VerifyHandler{
-		tracer: args.Tracer,
 		logger: args.Logger,
 		repo:   args.RegistrationRepo,
 	}
@@ -50,10 +48,7 @@ func NewVerifyHandler(args VerifyHandlerArgs) *VerifyHandler {
 
 func (h *VerifyHandler) Handle(ctx context.Context, cmd Verify) error {
 	const op = "cmd.VerifyHandler.Handle"
-	ctx, span := h.tracer.Start(ctx, "VerifyHandler.Handle",
-		trace.WithAttributes(attribute.String("email", logging.RedactEmail(cmd.Email))),
-	)
-	defer span.End()
+	span := trace.SpanFromContext(ctx)
 
 	err := h.repo.UpdateRegistrationByEmail(ctx, cmd.Email, func(ctx context.Context, r *registration.Registration) error {
 		span := trace.SpanFromContext(ctx)
@@ -71,7 +66,7 @@ func (h *VerifyHandler) Handle(ctx context.Context, cmd Verify) error {
 		return nil
 	})
 	if err != nil {
-		otelx.RecordSpanError(span, err, "failed to update registration by email")
+		span.AddEvent("failed to update registration by email")
 		return errorx.Wrap(err, op)
 	}
 
@@ -7,6 +7,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/registration"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/emails"
 	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
 	"gitlab.com/ucmsv2/ucms-backend/tests/integration/builders"
 	"gitlab.com/ucmsv2/ucms-backend/tests/integration/fixtures"
@@ -44,7 +45,7 @@ func TestVerifyHandler_HappyPath(t *testing.T) {
 	require.NoError(t, err)
 
 	s.MockRepo.
-		AssertRegistrationExistsByEmail(t, reg.Email()).
+		AssertRegistrationExistsByEmail(t, reg.Email().String()).
 		AssertStatus(t, registration.StatusVerified)
 
 	s.MockRepo.AssertEventCount(t, 1)
@@ -83,7 +84,7 @@ func TestVerifyHandler_InvalidEmail_ShouldReturnError(t *testing.T) {
 	s.MockRepo.SeedRegistration(t, reg)
 
 	err := s.Handler.Handle(t.Context(), Verify{
-		Email: email2,
+		Email: emails.Email(email2),
 		Code:  reg.VerificationCode(),
 	})
 	require.Error(t, err)
@@ -104,7 +105,7 @@ func TestVerifyHandler_InvalidCode_ShouldReturnError(t *testing.T) {
 	require.Error(t, err)
 	assert.ErrorIs(t, err, registration.ErrPersistentVerificationCodeMismatch)
 
-	s.MockRepo.AssertRegistrationExistsByEmail(t, reg.Email()).
+	s.MockRepo.AssertRegistrationExistsByEmail(t, reg.Email().String()).
 		AssertStatus(t, registration.StatusPending).
 		AssertCodeAttempts(t, 1).
 		AssertVerificationCodeNotEmpty(t)
@@ -129,7 +130,7 @@ func TestVerifyHandler_InvalidCode_TooManyAttempts_ShouldReturnError(t *testing.
 	require.Error(t, err)
 	assert.ErrorIs(t, err, registration.ErrPersistentTooManyAttempts)
 
-	s.MockRepo.AssertRegistrationExistsByEmail(t, reg.Email()).
+	s.MockRepo.AssertRegistrationExistsByEmail(t, reg.Email().String()).
 		AssertStatus(t, registration.StatusExpired).
 		AssertCodeAttempts(t, registration.MaxVerificationCodeAttempts+1).
 		AssertVerificationCodeNotEmpty(t)
@@ -66,7 +66,7 @@ func (h *RegistrationCompletedHandler) StudentHandle(ctx context.Context, e *use
 	)
 	ctx, span := h.tracer.Start(ctx, "RegistrationCompletedHandler.StudentHandle",
 		trace.WithAttributes(
-			attribute.String("student.barcode", e.StudentBarcode.String()),
+			attribute.String("student.barcode", otelx.Redact("student.barcode", e.StudentBarcode.String())),
 			attribute.String("registration.id", e.RegistrationID.String()),
 			attribute.String("student.email", logging.RedactEmail(e.Email)),
 		))
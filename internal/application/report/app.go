@@ -0,0 +1,78 @@
+package reportapp
+
+import (
+	"log/slog"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/application/report/cmd"
+	"gitlab.com/ucmsv2/ucms-backend/internal/application/report/reportquery"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
+)
+
+type App struct {
+	Command Command
+	Query   Query
+}
+
+type Command struct {
+	GenerateWeeklyReport *cmd.GenerateWeeklyReportHandler
+}
+
+type Query struct {
+	Weekly    *reportquery.WeeklyHandler
+	Dashboard *reportquery.DashboardHandler
+}
+
+type ReportRepo interface {
+	cmd.WeeklyReportRepo
+	reportquery.WeeklyReportGetter
+	reportquery.DashboardCounter
+}
+
+type Args struct {
+	Tracer     trace.Tracer
+	Logger     *slog.Logger
+	ReportRepo ReportRepo
+	Users      reportquery.UserCounter
+	// Terms scopes the reports by term, they are not without it.
+	Terms      reportquery.TermGetter
+	MailSender cmd.MailSender
+	// MailHandlers are the names of the event handlers sending the emails,
+	// see reportquery.DashboardHandlerArgs.
+	MailHandlers []string
+	// Clock defaults to clock.Real.
+	Clock clock.Clock
+}
+
+func NewApp(args Args) *App {
+	return &App{
+		Command: Command{
+			GenerateWeeklyReport: cmd.NewGenerateWeeklyReportHandler(cmd.GenerateWeeklyReportHandlerArgs{
+				Tracer:     args.Tracer,
+				Logger:     args.Logger,
+				ReportRepo: args.ReportRepo,
+				MailSender: args.MailSender,
+				Terms:      args.Terms,
+				Clock:      args.Clock,
+			}),
+		},
+		Query: Query{
+			Weekly: reportquery.NewWeeklyHandler(reportquery.WeeklyHandlerArgs{
+				Tracer:     args.Tracer,
+				Logger:     args.Logger,
+				ReportRepo: args.ReportRepo,
+				Clock:      args.Clock,
+			}),
+			Dashboard: reportquery.NewDashboardHandler(reportquery.DashboardHandlerArgs{
+				Tracer:       args.Tracer,
+				Logger:       args.Logger,
+				Users:        args.Users,
+				Counter:      args.ReportRepo,
+				Terms:        args.Terms,
+				MailHandlers: args.MailHandlers,
+				Clock:        args.Clock,
+			}),
+		},
+	}
+}
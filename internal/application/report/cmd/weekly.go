@@ -0,0 +1,231 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"time"
+
+	"go.opentelemetry.io/contrib/bridges/otelslog"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/report"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/term"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/mails"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/logging"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx"
+)
+
+var (
+	tracer = otel.Tracer("ucms/internal/application/report/cmd")
+	logger = otelslog.NewLogger("ucms/internal/application/report/cmd")
+)
+
+const WeeklySubjectPrefix = "UCMS weekly provisioning report: "
+
+//go:embed weekly.html
+var weeklyHTML string
+
+var weeklyTemplate = template.Must(template.New("weekly").Funcs(template.FuncMap{
+	"date":    func(t time.Time) string { return t.Format(time.DateOnly) },
+	"percent": formatPercent,
+}).Parse(weeklyHTML))
+
+type WeeklyReportRepo interface {
+	CountProvisioning(ctx context.Context, from, to time.Time) (report.Provisioning, error)
+	ListReportRecipients(ctx context.Context) ([]report.Recipient, error)
+	SaveWeeklyReport(ctx context.Context, w *report.Weekly) (bool, error)
+}
+
+type MailSender interface {
+	SendMail(ctx context.Context, payload mails.Payload) error
+}
+
+// GenerateWeeklyReport generates the provisioning report of the week
+// WeekStart is in and mails it to the staff. The report of a week is
+// generated once, a later run of the same week does nothing.
+type GenerateWeeklyReport struct {
+	// WeekStart defaults to the last week that is over.
+	WeekStart time.Time
+}
+
+type GenerateWeeklyReportResult struct {
+	Report *report.Weekly
+	// Generated is false when the report of the week was generated already.
+	Generated bool
+	Mailed    int
+	Failed    int
+}
+
+type GenerateWeeklyReportHandler struct {
+	tracer trace.Tracer
+	logger *slog.Logger
+	repo   WeeklyReportRepo
+	mail   MailSender
+	terms  term.CurrentGetter
+	clock  clock.Clock
+}
+
+type GenerateWeeklyReportHandlerArgs struct {
+	Tracer     trace.Tracer
+	Logger     *slog.Logger
+	ReportRepo WeeklyReportRepo
+	MailSender MailSender
+	// Terms resolves the term the week starts in, the report then counts
+	// the term to date as well. Optional.
+	Terms term.CurrentGetter
+	// Clock defaults to clock.Real.
+	Clock clock.Clock
+}
+
+func NewGenerateWeeklyReportHandler(args GenerateWeeklyReportHandlerArgs) *GenerateWeeklyReportHandler {
+	if args.Tracer == nil {
+		args.Tracer = tracer
+	}
+	if args.Logger == nil {
+		args.Logger = logger
+	}
+
+	return &GenerateWeeklyReportHandler{
+		tracer: args.Tracer,
+		logger: args.Logger,
+		repo:   args.ReportRepo,
+		mail:   args.MailSender,
+		terms:  args.Terms,
+		clock:  args.Clock,
+	}
+}
+
+func (h *GenerateWeeklyReportHandler) Handle(ctx context.Context, cmd GenerateWeeklyReport) (*GenerateWeeklyReportResult, error) {
+	const op = "cmd.GenerateWeeklyReportHandler.Handle"
+	now := clock.Or(h.clock).Now()
+	weekStart := cmd.WeekStart
+	if weekStart.IsZero() {
+		weekStart = report.WeekStart(now).Add(-report.Week)
+	}
+	weekStart = report.WeekStart(weekStart)
+
+	ctx, span := h.tracer.Start(ctx, "GenerateWeeklyReportHandler.Handle", trace.WithAttributes(
+		attribute.String("report.week_start", weekStart.Format(time.DateOnly)),
+	))
+	defer span.End()
+
+	p, err := h.repo.CountProvisioning(ctx, weekStart, weekStart.Add(report.Week))
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to count provisioning")
+		return nil, errorx.Wrap(err, op)
+	}
+	w := report.NewWeekly(weekStart, p, now)
+
+	t, err := term.Current(ctx, h.terms, weekStart)
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to resolve term")
+		return nil, errorx.Wrap(err, op)
+	}
+	if t != nil {
+		to := w.WeekEnd
+		if t.EndsAt().Before(to) {
+			to = t.EndsAt()
+		}
+		tp, err := h.repo.CountProvisioning(ctx, t.StartsAt(), to)
+		if err != nil {
+			otelx.RecordSpanError(span, err, "failed to count term provisioning")
+			return nil, errorx.Wrap(err, op)
+		}
+		w.Term = report.NewTermProvisioning(t, to, tp)
+		span.SetAttributes(attribute.String("report.term", t.Code()))
+	}
+
+	// The stored report is the claim of this run, the other instances
+	// running the schedule skip the week.
+	saved, err := h.repo.SaveWeeklyReport(ctx, w)
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to save weekly report")
+		return nil, errorx.Wrap(err, op)
+	}
+	res := &GenerateWeeklyReportResult{Report: w, Generated: saved}
+	if !saved {
+		span.AddEvent("weekly report generated already")
+		return res, nil
+	}
+
+	recipients, err := h.repo.ListReportRecipients(ctx)
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to list report recipients")
+		return nil, errorx.Wrap(err, op)
+	}
+
+	for _, rc := range recipients {
+		payload, err := weeklyPayload(rc, w)
+		if err != nil {
+			otelx.RecordSpanError(span, err, "failed to render weekly report")
+			return nil, errorx.Wrap(err, op)
+		}
+		if err := h.mail.SendMail(ctx, payload); err != nil {
+			res.Failed++
+			h.logger.ErrorContext(ctx, "failed to send weekly report",
+				slog.String("email", logging.RedactEmail(rc.Email)),
+				slog.String("error", err.Error()))
+			// Continue sending to the other recipients even if one fails
+			continue
+		}
+		res.Mailed++
+	}
+
+	span.SetAttributes(attribute.Int("report.mailed", res.Mailed), attribute.Int("report.failed", res.Failed))
+	h.logger.InfoContext(ctx, "weekly report generated",
+		slog.String("week_start", weekStart.Format(time.DateOnly)),
+		slog.Int("mailed", res.Mailed),
+		slog.Int("failed", res.Failed))
+
+	return res, nil
+}
+
+func weeklyPayload(rc report.Recipient, w *report.Weekly) (mails.Payload, error) {
+	var html bytes.Buffer
+	err := weeklyTemplate.Execute(&html, struct {
+		FirstName string
+		Report    *report.Weekly
+	}{rc.FirstName, w})
+	if err != nil {
+		return mails.Payload{}, err
+	}
+
+	body := fmt.Sprintf(
+		"Hello %s,\n\nAccount provisioning from %s to %s:\n\n"+
+			"New registrations: %d\nCompleted registrations: %d\nCompletion rate: %s\n"+
+			"New staff: %d\nSuspended accounts: %d\n",
+		rc.FirstName, w.WeekStart.Format(time.DateOnly), w.WeekEnd.Format(time.DateOnly),
+		w.Registrations, w.CompletedRegistrations, formatPercent(w.CompletionRate),
+		w.NewStaff, w.SuspendedAccounts,
+	)
+	if t := w.Term; t != nil {
+		body += fmt.Sprintf(
+			"\nTerm %s from %s to %s:\n\n"+
+				"New registrations: %d\nCompleted registrations: %d\nCompletion rate: %s\n"+
+				"New staff: %d\nSuspended accounts: %d\n",
+			t.Term.Code, t.Term.StartsAt.Format(time.DateOnly), t.To.Format(time.DateOnly),
+			t.Registrations, t.CompletedRegistrations, formatPercent(t.CompletionRate),
+			t.NewStaff, t.SuspendedAccounts,
+		)
+	}
+	body += "\nBest regards,\nUCMS"
+
+	return mails.Payload{
+		To:      rc.Email,
+		Subject: WeeklySubjectPrefix + w.WeekStart.Format(time.DateOnly),
+		Body:    body,
+		HTML:    html.String(),
+	}, nil
+}
+
+func formatPercent(rate float64) string {
+	return fmt.Sprintf("%.1f%%", rate*100)
+}
@@ -0,0 +1,342 @@
+package reportquery
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/registration"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/report"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/term"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/roles"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/httpx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx"
+)
+
+const (
+	// DashboardTimeout is the deadline the queries of a dashboard share.
+	DashboardTimeout = 2 * time.Second
+	// DashboardCacheTTL is how long a dashboard is served from memory.
+	DashboardCacheTTL = 30 * time.Second
+	// RegistrationsWindow is the window of the registrations section outside
+	// the terms.
+	RegistrationsWindow = report.Week
+	// MailFailuresWindow is the window of the mail failures section.
+	MailFailuresWindow = 24 * time.Hour
+)
+
+type UserCounter interface {
+	CountUsersByRole(ctx context.Context) (map[roles.Global]int64, error)
+}
+
+type DashboardCounter interface {
+	CountRegistrationsByStatus(ctx context.Context, from, to time.Time) (map[registration.Status]int64, error)
+	CountActiveInvitations(ctx context.Context, now time.Time) (report.Invitations, error)
+	CountDeadLetters(ctx context.Context, handlers []string, since time.Time) (int64, error)
+}
+
+// TermGetter resolves the term a dashboard is filtered by.
+type TermGetter interface {
+	term.CurrentGetter
+	GetTermByID(ctx context.Context, id term.ID) (*term.Term, error)
+}
+
+// DashboardFilter selects the term of the dashboard, the current one when
+// TermID is zero.
+type DashboardFilter struct {
+	TermID term.ID
+}
+
+// Dashboard is the summary of the home page of the admin UI. A section whose
+// query failed has its Error set and its counts left zero.
+type Dashboard struct {
+	// Term is the term the registrations are counted in, null when the
+	// dashboard is not filtered by a term, e.g. between the terms.
+	Term          *DashboardTerm       `json:"term"`
+	Users         UsersSection         `json:"users"`
+	Registrations RegistrationsSection `json:"registrations"`
+	Invitations   InvitationsSection   `json:"invitations"`
+	MailFailures  CountSection         `json:"mail_failures"`
+	DeadLetters   CountSection         `json:"dead_letters"`
+	// Partial is set when a section failed.
+	Partial     bool       `json:"partial"`
+	GeneratedAt httpx.Time `json:"generated_at"`
+}
+
+// UsersSection counts all the users by global role.
+type UsersSection struct {
+	Total  int64            `json:"total"`
+	ByRole map[string]int64 `json:"by_role"`
+	Error  string           `json:"error,omitempty"`
+}
+
+type DashboardTerm struct {
+	ID       string     `json:"id"`
+	Code     string     `json:"code"`
+	Name     string     `json:"name"`
+	StartsAt httpx.Time `json:"starts_at"`
+	EndsAt   httpx.Time `json:"ends_at"`
+}
+
+// RegistrationsSection counts the registrations started from Since until
+// Until by status, the term of the dashboard or the last RegistrationsWindow
+// outside the terms.
+type RegistrationsSection struct {
+	Since    httpx.Time       `json:"since"`
+	Until    httpx.Time       `json:"until"`
+	Total    int64            `json:"total"`
+	ByStatus map[string]int64 `json:"by_status"`
+	Error    string           `json:"error,omitempty"`
+}
+
+type InvitationsSection struct {
+	report.Invitations
+	Error string `json:"error,omitempty"`
+}
+
+// CountSection counts the dead letters since Since, since ever when it is
+// null.
+type CountSection struct {
+	Since *httpx.Time `json:"since"`
+	Count int64       `json:"count"`
+	Error string      `json:"error,omitempty"`
+}
+
+// DashboardHandler assembles the Dashboard from the counts of the users,
+// registrations, invitations and dead letters.
+type DashboardHandler struct {
+	tracer       trace.Tracer
+	logger       *slog.Logger
+	users        UserCounter
+	counter      DashboardCounter
+	terms        TermGetter
+	mailHandlers []string
+	clock        clock.Clock
+	timeout      time.Duration
+	ttl          time.Duration
+
+	mu sync.Mutex
+	// cached are the dashboards by the term of their filter.
+	cached map[term.ID]*Dashboard
+}
+
+type DashboardHandlerArgs struct {
+	Tracer  trace.Tracer
+	Logger  *slog.Logger
+	Users   UserCounter
+	Counter DashboardCounter
+	// Terms filters the dashboards by term, without it they never are.
+	Terms TermGetter
+	// MailHandlers are the names of the event handlers sending the emails,
+	// their dead letters are the mail failures.
+	MailHandlers []string
+	// Clock defaults to clock.Real.
+	Clock clock.Clock
+	// Timeout defaults to DashboardTimeout.
+	Timeout time.Duration
+	// CacheTTL defaults to DashboardCacheTTL.
+	CacheTTL time.Duration
+}
+
+func NewDashboardHandler(args DashboardHandlerArgs) *DashboardHandler {
+	if args.Tracer == nil {
+		args.Tracer = tracer
+	}
+	if args.Logger == nil {
+		args.Logger = logger
+	}
+	if args.Timeout <= 0 {
+		args.Timeout = DashboardTimeout
+	}
+	if args.CacheTTL <= 0 {
+		args.CacheTTL = DashboardCacheTTL
+	}
+
+	return &DashboardHandler{
+		tracer:       args.Tracer,
+		logger:       args.Logger,
+		users:        args.Users,
+		counter:      args.Counter,
+		terms:        args.Terms,
+		mailHandlers: args.MailHandlers,
+		clock:        args.Clock,
+		timeout:      args.Timeout,
+		ttl:          args.CacheTTL,
+		cached:       make(map[term.ID]*Dashboard),
+	}
+}
+
+// Get returns the dashboard of the term of f, from memory when the last one
+// is younger than the cache TTL. The sections are queried concurrently, a
+// failed one is marked and the dashboard is partial, it fails only when every
+// section failed. The partial dashboards are not cached.
+func (h *DashboardHandler) Get(ctx context.Context, f DashboardFilter) (*Dashboard, error) {
+	const op = "reportquery.DashboardHandler.Get"
+	ctx, span := h.tracer.Start(ctx, "DashboardHandler.Get")
+	defer span.End()
+
+	now := clock.Or(h.clock).Now().UTC()
+	h.mu.Lock()
+	cached := h.cached[f.TermID]
+	h.mu.Unlock()
+	if cached != nil && now.Sub(cached.GeneratedAt.Time) < h.ttl {
+		span.SetAttributes(attribute.Bool("dashboard.cached", true))
+		return cached, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	// An unknown term asked for fails the dashboard, the current term that
+	// failed to resolve fails only the registrations counted in it.
+	t, termErr := h.term(ctx, f, now)
+	if termErr != nil && f.TermID != (term.ID{}) {
+		otelx.RecordSpanError(span, termErr, "failed to get term")
+		return nil, errorx.Wrap(termErr, op)
+	}
+
+	var (
+		res = Dashboard{GeneratedAt: httpx.NewTime(now)}
+		wg  sync.WaitGroup
+		mu  sync.Mutex
+		// errs are the errors of the sections, in the order they failed.
+		errs []error
+	)
+	section := func(name string, errField *string, fn func() error) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := fn(); err != nil {
+				*errField = sectionError(err)
+				mu.Lock()
+				defer mu.Unlock()
+				errs = append(errs, err)
+				h.logger.WarnContext(ctx, "dashboard section failed",
+					slog.String("section", name),
+					slog.String("error", err.Error()))
+			}
+		}()
+	}
+
+	section("users", &res.Users.Error, func() error {
+		counts, err := h.users.CountUsersByRole(ctx)
+		if err != nil {
+			return err
+		}
+		res.Users.ByRole = make(map[string]int64, len(counts))
+		for role, n := range counts {
+			res.Users.ByRole[role.String()] = n
+			res.Users.Total += n
+		}
+		return nil
+	})
+
+	registrationsSince, registrationsUntil := now.Add(-RegistrationsWindow), now
+	if t != nil {
+		res.Term = newDashboardTerm(t)
+		registrationsSince = t.StartsAt()
+		if t.EndsAt().Before(registrationsUntil) {
+			registrationsUntil = t.EndsAt()
+		}
+		if registrationsUntil.Before(registrationsSince) {
+			registrationsUntil = registrationsSince
+		}
+		span.SetAttributes(attribute.String("dashboard.term", t.Code()))
+	}
+	res.Registrations.Since = httpx.NewTime(registrationsSince)
+	res.Registrations.Until = httpx.NewTime(registrationsUntil)
+	section("registrations", &res.Registrations.Error, func() error {
+		if termErr != nil {
+			return termErr
+		}
+		counts, err := h.counter.CountRegistrationsByStatus(ctx, registrationsSince, registrationsUntil)
+		if err != nil {
+			return err
+		}
+		res.Registrations.ByStatus = make(map[string]int64, len(counts))
+		for status, n := range counts {
+			res.Registrations.ByStatus[status.String()] = n
+			res.Registrations.Total += n
+		}
+		return nil
+	})
+
+	section("invitations", &res.Invitations.Error, func() error {
+		inv, err := h.counter.CountActiveInvitations(ctx, now)
+		res.Invitations.Invitations = inv
+		return err
+	})
+
+	mailSince := now.Add(-MailFailuresWindow)
+	res.MailFailures.Since = httpx.NewTimePtr(&mailSince)
+	section("mail_failures", &res.MailFailures.Error, func() error {
+		n, err := h.counter.CountDeadLetters(ctx, h.mailHandlers, mailSince)
+		res.MailFailures.Count = n
+		return err
+	})
+
+	section("dead_letters", &res.DeadLetters.Error, func() error {
+		n, err := h.counter.CountDeadLetters(ctx, nil, time.Time{})
+		res.DeadLetters.Count = n
+		return err
+	})
+	wg.Wait()
+
+	if len(errs) > 0 {
+		err := errors.Join(errs...)
+		otelx.RecordSpanError(span, err, "dashboard section failed")
+		if len(errs) == dashboardSections {
+			return nil, errorx.Wrap(err, op)
+		}
+		res.Partial = true
+	}
+	span.SetAttributes(attribute.Bool("dashboard.partial", res.Partial))
+
+	if !res.Partial {
+		h.mu.Lock()
+		h.cached[f.TermID] = &res
+		h.mu.Unlock()
+	}
+
+	return &res, nil
+}
+
+// term returns the term of f, the one now is in when f has none. Without a
+// TermGetter the dashboards are not filtered.
+func (h *DashboardHandler) term(ctx context.Context, f DashboardFilter, now time.Time) (*term.Term, error) {
+	if h.terms == nil {
+		return nil, nil
+	}
+	if f.TermID != (term.ID{}) {
+		return h.terms.GetTermByID(ctx, f.TermID)
+	}
+	return term.Current(ctx, h.terms, now)
+}
+
+func newDashboardTerm(t *term.Term) *DashboardTerm {
+	return &DashboardTerm{
+		ID:       t.ID().String(),
+		Code:     t.Code(),
+		Name:     t.Name(),
+		StartsAt: httpx.NewTime(t.StartsAt()),
+		EndsAt:   httpx.NewTime(t.EndsAt()),
+	}
+}
+
+// dashboardSections is the number of sections of a Dashboard.
+const dashboardSections = 5
+
+// sectionError is the error of a failed section, the causes stay in the logs.
+func sectionError(err error) string {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return "timed out"
+	}
+	return "failed to load"
+}
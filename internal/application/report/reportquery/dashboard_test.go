@@ -0,0 +1,273 @@
+package reportquery
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/registration"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/report"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/term"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/roles"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/i18nx"
+)
+
+// fakeCounter returns fixed counts, or the error of the method failing.
+type fakeCounter struct {
+	mu      sync.Mutex
+	calls   int
+	failing map[string]error
+	// block makes the registrations wait for the deadline.
+	block bool
+	// from and to are the window of the last registrations count.
+	from, to time.Time
+}
+
+func (f *fakeCounter) fail(method string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls++
+	return f.failing[method]
+}
+
+func (f *fakeCounter) CountUsersByRole(context.Context) (map[roles.Global]int64, error) {
+	if err := f.fail("users"); err != nil {
+		return nil, err
+	}
+	return map[roles.Global]int64{roles.Student: 3, roles.Staff: 2, roles.AITUSA: 0}, nil
+}
+
+func (f *fakeCounter) CountRegistrationsByStatus(ctx context.Context, from, to time.Time) (map[registration.Status]int64, error) {
+	f.mu.Lock()
+	f.from, f.to = from, to
+	f.mu.Unlock()
+	if f.block {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	if err := f.fail("registrations"); err != nil {
+		return nil, err
+	}
+	return map[registration.Status]int64{registration.StatusPending: 4, registration.StatusCompleted: 1}, nil
+}
+
+func (f *fakeCounter) CountActiveInvitations(context.Context, time.Time) (report.Invitations, error) {
+	if err := f.fail("invitations"); err != nil {
+		return report.Invitations{}, err
+	}
+	return report.Invitations{Active: 2, OutstandingRecipients: 5}, nil
+}
+
+func (f *fakeCounter) CountDeadLetters(_ context.Context, handlers []string, _ time.Time) (int64, error) {
+	if err := f.fail("dead_letters"); err != nil {
+		return 0, err
+	}
+	if len(handlers) > 0 {
+		return 1, nil
+	}
+	return 6, nil
+}
+
+func newDashboardHandler(f *fakeCounter, c clock.Clock) *DashboardHandler {
+	return NewDashboardHandler(DashboardHandlerArgs{
+		Users:        f,
+		Counter:      f,
+		MailHandlers: []string{"MailOnRegistrationStarted"},
+		Clock:        c,
+		Timeout:      50 * time.Millisecond,
+	})
+}
+
+func TestDashboardHandler_Get(t *testing.T) {
+	now := time.Date(2026, 5, 6, 12, 0, 0, 0, time.UTC)
+	f := &fakeCounter{}
+	h := newDashboardHandler(f, clock.NewFake(now))
+
+	res, err := h.Get(t.Context(), DashboardFilter{})
+	require.NoError(t, err)
+	assert.False(t, res.Partial)
+	assert.Equal(t, int64(5), res.Users.Total)
+	assert.Equal(t, int64(3), res.Users.ByRole["student"])
+	assert.Equal(t, int64(5), res.Registrations.Total)
+	assert.Equal(t, int64(4), res.Registrations.ByStatus["pending"])
+	assert.Equal(t, now.Add(-7*24*time.Hour), res.Registrations.Since.Time)
+	assert.Equal(t, report.Invitations{Active: 2, OutstandingRecipients: 5}, res.Invitations.Invitations)
+	assert.Equal(t, int64(1), res.MailFailures.Count)
+	assert.Equal(t, now.Add(-24*time.Hour), res.MailFailures.Since.Time)
+	assert.Equal(t, int64(6), res.DeadLetters.Count)
+	assert.Nil(t, res.DeadLetters.Since)
+}
+
+func TestDashboardHandler_Cache(t *testing.T) {
+	c := clock.NewFake(time.Date(2026, 5, 6, 12, 0, 0, 0, time.UTC))
+	f := &fakeCounter{}
+	h := newDashboardHandler(f, c)
+
+	first, err := h.Get(t.Context(), DashboardFilter{})
+	require.NoError(t, err)
+	calls := f.calls
+
+	c.Advance(DashboardCacheTTL - time.Second)
+	cached, err := h.Get(t.Context(), DashboardFilter{})
+	require.NoError(t, err)
+	assert.Same(t, first, cached)
+	assert.Equal(t, calls, f.calls, "the cached dashboard is not queried again")
+
+	c.Advance(time.Second)
+	fresh, err := h.Get(t.Context(), DashboardFilter{})
+	require.NoError(t, err)
+	assert.NotSame(t, first, fresh)
+	assert.Equal(t, 2*calls, f.calls)
+}
+
+func TestDashboardHandler_PartialFailure(t *testing.T) {
+	f := &fakeCounter{failing: map[string]error{"invitations": errors.New("connection reset")}}
+	h := newDashboardHandler(f, nil)
+
+	res, err := h.Get(t.Context(), DashboardFilter{})
+	require.NoError(t, err)
+	assert.True(t, res.Partial)
+	assert.Equal(t, "failed to load", res.Invitations.Error)
+	assert.Empty(t, res.Users.Error)
+	assert.Equal(t, int64(5), res.Users.Total, "the other sections are still there")
+
+	data, err := json.Marshal(res)
+	require.NoError(t, err)
+	var body struct {
+		Users       map[string]any `json:"users"`
+		Invitations map[string]any `json:"invitations"`
+	}
+	require.NoError(t, json.Unmarshal(data, &body))
+	assert.Equal(t, map[string]any{"active": 0.0, "outstanding_recipients": 0.0, "error": "failed to load"}, body.Invitations)
+	assert.NotContains(t, body.Users, "error")
+
+	calls := f.calls
+	_, err = h.Get(t.Context(), DashboardFilter{})
+	require.NoError(t, err)
+	assert.Greater(t, f.calls, calls, "a partial dashboard is not cached")
+}
+
+func TestDashboardHandler_Timeout(t *testing.T) {
+	f := &fakeCounter{block: true}
+	h := newDashboardHandler(f, nil)
+
+	res, err := h.Get(t.Context(), DashboardFilter{})
+	require.NoError(t, err)
+	assert.True(t, res.Partial)
+	assert.Equal(t, "timed out", res.Registrations.Error)
+	assert.Empty(t, res.DeadLetters.Error)
+}
+
+func TestDashboardHandler_AllFailed(t *testing.T) {
+	fail := errors.New("connection refused")
+	f := &fakeCounter{failing: map[string]error{
+		"users":         fail,
+		"registrations": fail,
+		"invitations":   fail,
+		"dead_letters":  fail,
+	}}
+	h := newDashboardHandler(f, nil)
+
+	_, err := h.Get(t.Context(), DashboardFilter{})
+	assert.ErrorIs(t, err, fail)
+}
+
+// fakeTerms holds the terms of the dashboards, or fails with err.
+type fakeTerms struct {
+	terms []*term.Term
+	err   error
+}
+
+func (f fakeTerms) GetTermAt(_ context.Context, at time.Time) (*term.Term, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	for _, t := range f.terms {
+		if t.Contains(at) {
+			return t, nil
+		}
+	}
+	return nil, errorx.NewResourceNotFound(i18nx.FieldTerm)
+}
+
+func (f fakeTerms) GetTermByID(_ context.Context, id term.ID) (*term.Term, error) {
+	for _, t := range f.terms {
+		if t.ID() == id {
+			return t, nil
+		}
+	}
+	return nil, errorx.NewResourceNotFound(i18nx.FieldTerm)
+}
+
+func TestDashboardHandler_Term(t *testing.T) {
+	now := time.Date(2026, 5, 6, 12, 0, 0, 0, time.UTC)
+	newTerm := func(code string, start, end time.Time) *term.Term {
+		tm, err := term.New(term.CreateArgs{ID: term.NewID(), Code: code, Name: code, StartsAt: start, EndsAt: end})
+		require.NoError(t, err)
+		return tm
+	}
+	fall := newTerm("2025-Fall", time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	spring := newTerm("2026-Spring", time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC), time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC))
+
+	newHandler := func(f *fakeCounter, terms fakeTerms) *DashboardHandler {
+		return NewDashboardHandler(DashboardHandlerArgs{
+			Users:   f,
+			Counter: f,
+			Terms:   terms,
+			Clock:   clock.NewFake(now),
+			Timeout: 50 * time.Millisecond,
+		})
+	}
+
+	t.Run("current term", func(t *testing.T) {
+		f := &fakeCounter{}
+		res, err := newHandler(f, fakeTerms{terms: []*term.Term{fall, spring}}).Get(t.Context(), DashboardFilter{})
+		require.NoError(t, err)
+		require.NotNil(t, res.Term)
+		assert.Equal(t, "2026-Spring", res.Term.Code)
+		assert.Equal(t, spring.StartsAt(), f.from)
+		assert.Equal(t, now, f.to, "the term is counted up to now")
+		assert.Equal(t, spring.StartsAt(), res.Registrations.Since.Time)
+	})
+
+	t.Run("past term", func(t *testing.T) {
+		f := &fakeCounter{}
+		res, err := newHandler(f, fakeTerms{terms: []*term.Term{fall, spring}}).Get(t.Context(), DashboardFilter{TermID: fall.ID()})
+		require.NoError(t, err)
+		require.NotNil(t, res.Term)
+		assert.Equal(t, "2025-Fall", res.Term.Code)
+		assert.Equal(t, fall.StartsAt(), f.from)
+		assert.Equal(t, fall.EndsAt(), f.to)
+		assert.Equal(t, fall.EndsAt(), res.Registrations.Until.Time)
+	})
+
+	t.Run("between terms", func(t *testing.T) {
+		f := &fakeCounter{}
+		res, err := newHandler(f, fakeTerms{terms: []*term.Term{fall}}).Get(t.Context(), DashboardFilter{})
+		require.NoError(t, err)
+		assert.Nil(t, res.Term)
+		assert.Equal(t, now.Add(-RegistrationsWindow), f.from)
+		assert.Equal(t, now, f.to)
+	})
+
+	t.Run("unknown term", func(t *testing.T) {
+		_, err := newHandler(&fakeCounter{}, fakeTerms{}).Get(t.Context(), DashboardFilter{TermID: term.NewID()})
+		assert.True(t, errorx.IsNotFound(err))
+	})
+
+	t.Run("current term failed", func(t *testing.T) {
+		res, err := newHandler(&fakeCounter{}, fakeTerms{err: errors.New("connection reset")}).Get(t.Context(), DashboardFilter{})
+		require.NoError(t, err)
+		assert.True(t, res.Partial)
+		assert.Equal(t, "failed to load", res.Registrations.Error)
+		assert.Empty(t, res.Users.Error)
+	})
+}
@@ -0,0 +1,80 @@
+package reportquery
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"go.opentelemetry.io/contrib/bridges/otelslog"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/report"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx"
+)
+
+var (
+	tracer = otel.Tracer("ucms/internal/application/report/query")
+	logger = otelslog.NewLogger("ucms/internal/application/report/query")
+)
+
+type WeeklyReportGetter interface {
+	GetWeeklyReport(ctx context.Context, weekStart time.Time) (*report.Weekly, error)
+}
+
+// WeeklyHandler reads the generated weekly reports.
+type WeeklyHandler struct {
+	tracer trace.Tracer
+	logger *slog.Logger
+	repo   WeeklyReportGetter
+	clock  clock.Clock
+}
+
+type WeeklyHandlerArgs struct {
+	Tracer     trace.Tracer
+	Logger     *slog.Logger
+	ReportRepo WeeklyReportGetter
+	// Clock defaults to clock.Real.
+	Clock clock.Clock
+}
+
+func NewWeeklyHandler(args WeeklyHandlerArgs) *WeeklyHandler {
+	if args.Tracer == nil {
+		args.Tracer = tracer
+	}
+	if args.Logger == nil {
+		args.Logger = logger
+	}
+
+	return &WeeklyHandler{
+		tracer: args.Tracer,
+		logger: args.Logger,
+		repo:   args.ReportRepo,
+		clock:  args.Clock,
+	}
+}
+
+// Get returns the report of the week day is in, of the last week that is
+// over when day is zero. It is not found until the report is generated.
+func (h *WeeklyHandler) Get(ctx context.Context, day time.Time) (*report.Weekly, error) {
+	const op = "reportquery.WeeklyHandler.Get"
+	weekStart := report.WeekStart(day)
+	if day.IsZero() {
+		weekStart = report.WeekStart(clock.Or(h.clock).Now()).Add(-report.Week)
+	}
+	ctx, span := h.tracer.Start(ctx, "WeeklyHandler.Get", trace.WithAttributes(
+		attribute.String("report.week_start", weekStart.Format(time.DateOnly)),
+	))
+	defer span.End()
+
+	w, err := h.repo.GetWeeklyReport(ctx, weekStart)
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to get weekly report")
+		return nil, errorx.Wrap(err, op)
+	}
+
+	return w, nil
+}
@@ -0,0 +1,62 @@
+package searchapp
+
+import (
+	"log/slog"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/application/search/searchquery"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/pagination"
+)
+
+type App struct {
+	Query Query
+}
+
+type Query struct {
+	Search            *searchquery.SearchHandler
+	ListUsers         *searchquery.ListUsersHandler
+	ListInactiveUsers *searchquery.ListInactiveUsersHandler
+}
+
+type Args struct {
+	Tracer      trace.Tracer
+	Logger      *slog.Logger
+	Users       searchquery.UserSearcher
+	Groups      searchquery.GroupSearcher
+	Invitations searchquery.InvitationSearcher
+	UserLister  searchquery.UserLister
+	// InactiveUsers lists the users not seen for a while.
+	InactiveUsers searchquery.InactiveUserLister
+	// Cursors signs the cursors of the user listing.
+	Cursors *pagination.Codec
+	// Clock defaults to clock.Real.
+	Clock clock.Clock
+}
+
+func NewApp(args Args) *App {
+	return &App{
+		Query: Query{
+			Search: searchquery.NewSearchHandler(searchquery.SearchHandlerArgs{
+				Tracer:      args.Tracer,
+				Logger:      args.Logger,
+				Users:       args.Users,
+				Groups:      args.Groups,
+				Invitations: args.Invitations,
+			}),
+			ListUsers: searchquery.NewListUsersHandler(searchquery.ListUsersHandlerArgs{
+				Tracer:  args.Tracer,
+				Logger:  args.Logger,
+				Users:   args.UserLister,
+				Cursors: args.Cursors,
+			}),
+			ListInactiveUsers: searchquery.NewListInactiveUsersHandler(searchquery.ListInactiveUsersHandlerArgs{
+				Tracer: args.Tracer,
+				Logger: args.Logger,
+				Users:  args.InactiveUsers,
+				Clock:  args.Clock,
+			}),
+		},
+	}
+}
@@ -0,0 +1,121 @@
+package searchquery
+
+import (
+	"context"
+	"log/slog"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/httpx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/pagination"
+)
+
+const (
+	DefaultInactiveDays = 90
+	MaxInactiveDays     = 3650
+)
+
+type InactiveUserLister interface {
+	ListInactiveUsers(ctx context.Context, params user.InactiveParams) ([]*user.User, error)
+}
+
+type ListInactiveUsers struct {
+	// Days the users have not been seen for, defaults to
+	// DefaultInactiveDays.
+	Days int
+	// Page starts at 1.
+	Page int
+	// PageSize defaults to DefaultPageSize and is capped at MaxPageSize.
+	PageSize int
+}
+
+type ListInactiveUsersResponse struct {
+	Users []UserHit `json:"users"`
+	// Since is the time the users have not been seen since.
+	Since httpx.Time      `json:"since"`
+	Meta  pagination.Meta `json:"meta"`
+}
+
+// ListInactiveUsersHandler pages through the accounts not used for a number
+// of days, those never used included, for the staff to clean them up.
+type ListInactiveUsersHandler struct {
+	tracer trace.Tracer
+	logger *slog.Logger
+	users  InactiveUserLister
+	clock  clock.Clock
+}
+
+type ListInactiveUsersHandlerArgs struct {
+	Tracer trace.Tracer
+	Logger *slog.Logger
+	Users  InactiveUserLister
+	// Clock defaults to clock.Real.
+	Clock clock.Clock
+}
+
+func NewListInactiveUsersHandler(args ListInactiveUsersHandlerArgs) *ListInactiveUsersHandler {
+	if args.Tracer == nil {
+		args.Tracer = tracer
+	}
+	if args.Logger == nil {
+		args.Logger = logger
+	}
+
+	return &ListInactiveUsersHandler{
+		tracer: args.Tracer,
+		logger: args.Logger,
+		users:  args.Users,
+		clock:  clock.Or(args.Clock),
+	}
+}
+
+// Handle returns a page of the users not seen for query.Days, those never
+// seen first. The last seen times are accurate to lastseen.MinInterval.
+func (h *ListInactiveUsersHandler) Handle(ctx context.Context, query ListInactiveUsers) (*ListInactiveUsersResponse, error) {
+	const op = "searchquery.ListInactiveUsersHandler.Handle"
+	ctx, span := h.tracer.Start(ctx, "ListInactiveUsersHandler.Handle")
+	defer span.End()
+
+	if query.Days <= 0 {
+		query.Days = DefaultInactiveDays
+	}
+	query.Days = min(query.Days, MaxInactiveDays)
+	if query.Page < 1 {
+		query.Page = 1
+	}
+	if query.PageSize <= 0 {
+		query.PageSize = DefaultPageSize
+	}
+	query.PageSize = min(query.PageSize, MaxPageSize)
+	otelx.SetSpanAttrs(span, map[string]any{
+		"query.days":      query.Days,
+		"query.page":      query.Page,
+		"query.page_size": query.PageSize,
+	})
+
+	since := h.clock.Now().UTC().AddDate(0, 0, -query.Days)
+	users, err := h.users.ListInactiveUsers(ctx, user.InactiveParams{
+		Since:  since,
+		Limit:  query.PageSize,
+		Offset: (query.Page - 1) * query.PageSize,
+	})
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to list inactive users")
+		return nil, errorx.Wrap(err, op)
+	}
+
+	res := ListInactiveUsersResponse{
+		Users: make([]UserHit, len(users)),
+		Since: httpx.NewTime(since),
+		Meta:  pagination.Meta{Page: query.Page, PageSize: query.PageSize},
+	}
+	for i, u := range users {
+		res.Users[i] = newUserHit(u)
+	}
+
+	return &res, nil
+}
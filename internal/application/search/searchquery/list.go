@@ -0,0 +1,166 @@
+package searchquery
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/pagination"
+)
+
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+type UserLister interface {
+	ListUsers(ctx context.Context, params user.ListParams) ([]*user.User, error)
+}
+
+type ListUsers struct {
+	// Query keeps the users containing it, empty lists all of them.
+	Query string
+	// EnrollmentStatus keeps the students with the status, empty keeps all
+	// of the users.
+	EnrollmentStatus user.EnrollmentStatus
+	// Department keeps the staff members of the department, empty keeps all
+	// of the users.
+	Department string
+	// Cursor resumes after the page that returned it, Page is then ignored.
+	Cursor string
+	// Page starts at 1, it pages by offset when there is no Cursor.
+	Page int
+	// PageSize defaults to DefaultPageSize and is capped at MaxPageSize.
+	PageSize int
+}
+
+type ListUsersResponse struct {
+	Users []UserHit       `json:"users"`
+	Meta  pagination.Meta `json:"meta"`
+}
+
+// ListUsersHandler pages through the users for the staff, by keyset cursor
+// or by offset.
+type ListUsersHandler struct {
+	tracer  trace.Tracer
+	logger  *slog.Logger
+	users   UserLister
+	cursors *pagination.Codec
+}
+
+type ListUsersHandlerArgs struct {
+	Tracer  trace.Tracer
+	Logger  *slog.Logger
+	Users   UserLister
+	Cursors *pagination.Codec
+}
+
+func NewListUsersHandler(args ListUsersHandlerArgs) *ListUsersHandler {
+	if args.Tracer == nil {
+		args.Tracer = tracer
+	}
+	if args.Logger == nil {
+		args.Logger = logger
+	}
+
+	return &ListUsersHandler{
+		tracer:  args.Tracer,
+		logger:  args.Logger,
+		users:   args.Users,
+		cursors: args.Cursors,
+	}
+}
+
+// Handle returns a page of the users. The next cursor is set when a page may
+// follow, in both modes, so that a client paging by offset can switch to the
+// cursors.
+func (h *ListUsersHandler) Handle(ctx context.Context, query ListUsers) (*ListUsersResponse, error) {
+	const op = "searchquery.ListUsersHandler.Handle"
+	ctx, span := h.tracer.Start(ctx, "ListUsersHandler.Handle")
+	defer span.End()
+
+	query.Query = strings.TrimSpace(query.Query)
+	query.Department = strings.TrimSpace(query.Department)
+	if n := utf8.RuneCountInString(query.Query); n > MaxQueryLen || (n > 0 && n < MinQueryLen) {
+		return nil, errorx.NewInvalidRequest().WithDetails("the query must be 2 to 100 characters long").WithOp(op)
+	}
+	if query.Page < 1 {
+		query.Page = 1
+	}
+	if query.PageSize <= 0 {
+		query.PageSize = DefaultPageSize
+	}
+	query.PageSize = min(query.PageSize, MaxPageSize)
+	otelx.SetSpanAttrs(span, map[string]any{
+		"query.query_len":  len(query.Query),
+		"query.status":     query.EnrollmentStatus.String(),
+		"query.department": query.Department,
+		"query.cursor":     query.Cursor != "",
+		"query.page":       query.Page,
+		"query.page_size":  query.PageSize,
+	})
+
+	params := user.ListParams{
+		Query:            query.Query,
+		EnrollmentStatus: query.EnrollmentStatus,
+		Department:       query.Department,
+		// One more row tells whether a next page follows.
+		Limit:  query.PageSize + 1,
+		Offset: (query.Page - 1) * query.PageSize,
+	}
+	meta := pagination.Meta{Page: query.Page, PageSize: query.PageSize}
+	if query.Cursor != "" {
+		after, err := h.decode(query.Cursor)
+		if err != nil {
+			otelx.RecordSpanError(span, err, "invalid cursor")
+			return nil, errorx.Wrap(err, op)
+		}
+		params.After = &after
+		meta.Page = 0
+	}
+
+	users, err := h.users.ListUsers(ctx, params)
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to list users")
+		return nil, errorx.Wrap(err, op)
+	}
+	if len(users) > query.PageSize {
+		users = users[:query.PageSize]
+		meta.NextCursor = h.encode(users[len(users)-1].Key())
+	}
+
+	res := ListUsersResponse{Users: make([]UserHit, len(users)), Meta: meta}
+	for i, u := range users {
+		res.Users[i] = newUserHit(u)
+	}
+
+	return &res, nil
+}
+
+func (h *ListUsersHandler) encode(key user.ListKey) string {
+	return h.cursors.Encode(pagination.Cursor{
+		Keys: []string{key.LastName, key.FirstName},
+		ID:   key.ID.String(),
+	})
+}
+
+func (h *ListUsersHandler) decode(token string) (user.ListKey, error) {
+	const op = "searchquery.ListUsersHandler.decode"
+	cur, err := h.cursors.Decode(token, 2)
+	if err != nil {
+		return user.ListKey{}, err
+	}
+	id, err := uuid.Parse(cur.ID)
+	if err != nil {
+		return user.ListKey{}, fmt.Errorf("%s: %w: %w", op, pagination.ErrInvalidCursor, err)
+	}
+	return user.ListKey{LastName: cur.Keys[0], FirstName: cur.Keys[1], ID: user.ID(id)}, nil
+}
@@ -0,0 +1,122 @@
+package searchquery
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/pagination"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/builders"
+)
+
+// fakeLister pages its users like the repository, by key or by offset.
+type fakeLister struct {
+	users  []*user.User
+	params []user.ListParams
+}
+
+func (f *fakeLister) ListUsers(_ context.Context, params user.ListParams) ([]*user.User, error) {
+	f.params = append(f.params, params)
+	rest := f.users[min(params.Offset, len(f.users)):]
+	if params.After != nil {
+		i, _ := slices.BinarySearchFunc(f.users, *params.After, func(u *user.User, k user.ListKey) int {
+			return compareKeys(u.Key(), k)
+		})
+		if i < len(f.users) && compareKeys(f.users[i].Key(), *params.After) == 0 {
+			i++
+		}
+		rest = f.users[i:]
+	}
+	return rest[:min(params.Limit, len(rest))], nil
+}
+
+func compareKeys(a, b user.ListKey) int {
+	return cmp.Or(cmp.Compare(a.LastName, b.LastName), cmp.Compare(a.FirstName, b.FirstName), cmp.Compare(a.ID.String(), b.ID.String()))
+}
+
+func newListedUsers(n int) []*user.User {
+	users := make([]*user.User, n)
+	for i := range users {
+		users[i] = builders.NewUserBuilder().WithName("Anna", "Smith").Build()
+	}
+	slices.SortFunc(users, func(a, b *user.User) int { return compareKeys(a.Key(), b.Key()) })
+	return users
+}
+
+func TestListUsersHandler(t *testing.T) {
+	codec := pagination.NewCodec([]byte("secret"))
+	lister := &fakeLister{users: newListedUsers(7)}
+	h := NewListUsersHandler(ListUsersHandlerArgs{Users: lister, Cursors: codec})
+
+	t.Run("walks the cursors", func(t *testing.T) {
+		var ids []string
+		cursor := ""
+		for {
+			res, err := h.Handle(t.Context(), ListUsers{Cursor: cursor, PageSize: 3})
+			require.NoError(t, err)
+			if cursor != "" {
+				assert.Zero(t, res.Meta.Page, "the cursor mode has no page number")
+			}
+			for _, u := range res.Users {
+				ids = append(ids, u.ID)
+			}
+			if res.Meta.NextCursor == "" {
+				break
+			}
+			cursor = res.Meta.NextCursor
+		}
+
+		want := make([]string, len(lister.users))
+		for i, u := range lister.users {
+			want[i] = u.ID().String()
+		}
+		assert.Equal(t, want, ids, "every user once, in order")
+	})
+
+	t.Run("pages by offset", func(t *testing.T) {
+		res, err := h.Handle(t.Context(), ListUsers{Page: 3, PageSize: 3})
+		require.NoError(t, err)
+		require.Len(t, res.Users, 1)
+		assert.Equal(t, lister.users[6].ID().String(), res.Users[0].ID)
+		assert.Equal(t, 3, res.Meta.Page)
+		assert.Empty(t, res.Meta.NextCursor, "the last page has no next cursor")
+	})
+
+	t.Run("defaults and caps the page size", func(t *testing.T) {
+		lister.params = nil
+		_, err := h.Handle(t.Context(), ListUsers{})
+		require.NoError(t, err)
+		_, err = h.Handle(t.Context(), ListUsers{PageSize: MaxPageSize + 1})
+		require.NoError(t, err)
+		require.Len(t, lister.params, 2)
+		assert.Equal(t, DefaultPageSize+1, lister.params[0].Limit, "one more row tells whether a page follows")
+		assert.Equal(t, MaxPageSize+1, lister.params[1].Limit)
+	})
+
+	t.Run("forwards the filters", func(t *testing.T) {
+		lister.params = nil
+		_, err := h.Handle(t.Context(), ListUsers{EnrollmentStatus: user.Graduated, Department: " Registrar "})
+		require.NoError(t, err)
+		require.Len(t, lister.params, 1)
+		assert.Equal(t, user.Graduated, lister.params[0].EnrollmentStatus)
+		assert.Equal(t, "Registrar", lister.params[0].Department)
+	})
+
+	t.Run("rejects an invalid cursor", func(t *testing.T) {
+		forged := codec.Encode(pagination.Cursor{Keys: []string{"Smith", "Anna"}, ID: "not-a-uuid"})
+		for _, cursor := range []string{"garbage", forged, pagination.NewCodec([]byte("other")).Encode(pagination.Cursor{Keys: []string{"a", "b"}, ID: lister.users[0].ID().String()})} {
+			_, err := h.Handle(t.Context(), ListUsers{Cursor: cursor})
+			assert.ErrorIs(t, err, pagination.ErrInvalidCursor)
+		}
+	})
+
+	t.Run("rejects a one character query", func(t *testing.T) {
+		_, err := h.Handle(t.Context(), ListUsers{Query: "a"})
+		assert.Error(t, err)
+	})
+}
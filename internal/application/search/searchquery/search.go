@@ -0,0 +1,356 @@
+package searchquery
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"go.opentelemetry.io/contrib/bridges/otelslog"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/group"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/staffinvitation"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/httpx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx"
+)
+
+var (
+	tracer = otel.Tracer("ucms/internal/application/search/query")
+	logger = otelslog.NewLogger("ucms/internal/application/search/query")
+)
+
+const (
+	MinQueryLen  = 2
+	MaxQueryLen  = 100
+	DefaultLimit = 5
+	MaxLimit     = 20
+	// DefaultTimeout is the deadline the searches of a query share.
+	DefaultTimeout = 2 * time.Second
+)
+
+// Type is a bucket of the search results.
+type Type string
+
+const (
+	TypeUsers       Type = "users"
+	TypeGroups      Type = "groups"
+	TypeInvitations Type = "invitations"
+)
+
+// Types are the types searched when none are asked for.
+var Types = []Type{TypeUsers, TypeGroups, TypeInvitations}
+
+type UserSearcher interface {
+	SearchUsers(ctx context.Context, q string, limit int) ([]*user.User, error)
+}
+
+type GroupSearcher interface {
+	SearchGroups(ctx context.Context, q string, limit int) ([]*group.Group, error)
+}
+
+type InvitationSearcher interface {
+	SearchStaffInvitations(ctx context.Context, q string, limit int) ([]*staffinvitation.StaffInvitation, error)
+}
+
+type Search struct {
+	Query string
+	// Types defaults to Types.
+	Types []Type
+	// Limit caps every bucket, defaults to DefaultLimit and is capped at
+	// MaxLimit.
+	Limit int
+}
+
+type UserHit struct {
+	ID        string `json:"id"`
+	Barcode   string `json:"barcode"`
+	Username  string `json:"username"`
+	Email     string `json:"email"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	Role      string `json:"role"`
+	// LastSeenAt is null for the users never seen.
+	LastSeenAt *httpx.Time `json:"last_seen_at"`
+}
+
+func newUserHit(u *user.User) UserHit {
+	hit := UserHit{
+		ID:        u.ID().String(),
+		Barcode:   u.Barcode().String(),
+		Username:  u.Username(),
+		Email:     u.Email().String(),
+		FirstName: u.FirstName(),
+		LastName:  u.LastName(),
+		Role:      u.Role().String(),
+	}
+	if seen := u.LastSeenAt(); !seen.IsZero() {
+		t := httpx.NewTime(seen)
+		hit.LastSeenAt = &t
+	}
+	return hit
+}
+
+type GroupHit struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Major string `json:"major"`
+	Year  string `json:"year"`
+}
+
+type InvitationHit struct {
+	ID              string      `json:"id"`
+	Code            string      `json:"code"`
+	RecipientsEmail []string    `json:"recipients_email"`
+	Department      string      `json:"department"`
+	Position        string      `json:"position"`
+	ValidFrom       *httpx.Time `json:"valid_from"`
+	ValidUntil      *httpx.Time `json:"valid_until"`
+	CreatedAt       httpx.Time  `json:"created_at"`
+}
+
+// SearchResponse has a bucket per searched type, the buckets of the types
+// that were not searched are null.
+type SearchResponse struct {
+	Users       []UserHit       `json:"users"`
+	Groups      []GroupHit      `json:"groups"`
+	Invitations []InvitationHit `json:"invitations"`
+	// Partial is set when the search of a type failed, Failed lists them and
+	// their buckets are null.
+	Partial bool   `json:"partial"`
+	Failed  []Type `json:"failed,omitempty"`
+}
+
+// SearchHandler looks up a query in the users, groups and staff invitations
+// at once, for the typeahead of the staff.
+type SearchHandler struct {
+	tracer      trace.Tracer
+	logger      *slog.Logger
+	users       UserSearcher
+	groups      GroupSearcher
+	invitations InvitationSearcher
+	timeout     time.Duration
+}
+
+type SearchHandlerArgs struct {
+	Tracer      trace.Tracer
+	Logger      *slog.Logger
+	Users       UserSearcher
+	Groups      GroupSearcher
+	Invitations InvitationSearcher
+	// Timeout defaults to DefaultTimeout.
+	Timeout time.Duration
+}
+
+func NewSearchHandler(args SearchHandlerArgs) *SearchHandler {
+	if args.Tracer == nil {
+		args.Tracer = tracer
+	}
+	if args.Logger == nil {
+		args.Logger = logger
+	}
+	if args.Timeout <= 0 {
+		args.Timeout = DefaultTimeout
+	}
+
+	return &SearchHandler{
+		tracer:      args.Tracer,
+		logger:      args.Logger,
+		users:       args.Users,
+		groups:      args.Groups,
+		invitations: args.Invitations,
+		timeout:     args.Timeout,
+	}
+}
+
+// Handle searches the types of query concurrently. A failed type leaves its
+// bucket out and marks the response partial, the search fails only when
+// every type failed.
+func (h *SearchHandler) Handle(ctx context.Context, query Search) (*SearchResponse, error) {
+	const op = "searchquery.SearchHandler.Handle"
+	query.Query = strings.TrimSpace(query.Query)
+	if n := utf8.RuneCountInString(query.Query); n < MinQueryLen || n > MaxQueryLen {
+		return nil, errorx.NewInvalidRequest().WithDetails("the query must be 2 to 100 characters long").WithOp(op)
+	}
+	if len(query.Types) == 0 {
+		query.Types = Types
+	}
+	if query.Limit <= 0 {
+		query.Limit = DefaultLimit
+	}
+	query.Limit = min(query.Limit, MaxLimit)
+
+	ctx, span := h.tracer.Start(ctx, "SearchHandler.Handle", trace.WithAttributes(
+		attribute.Int("search.query_len", len(query.Query)),
+		attribute.Int("search.limit", query.Limit),
+	))
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	var (
+		res  SearchResponse
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+	search := func(t Type, fn func() error) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := fn(); err != nil {
+				mu.Lock()
+				defer mu.Unlock()
+				res.Failed = append(res.Failed, t)
+				errs = append(errs, err)
+				h.logger.WarnContext(ctx, "search failed",
+					slog.String("type", string(t)),
+					slog.String("error", err.Error()))
+			}
+		}()
+	}
+
+	if slices.Contains(query.Types, TypeUsers) {
+		search(TypeUsers, func() error {
+			users, err := h.users.SearchUsers(ctx, query.Query, query.Limit)
+			if err != nil {
+				return err
+			}
+			res.Users = userHits(query.Query, users, query.Limit)
+			return nil
+		})
+	}
+	if slices.Contains(query.Types, TypeGroups) {
+		search(TypeGroups, func() error {
+			groups, err := h.groups.SearchGroups(ctx, query.Query, query.Limit)
+			if err != nil {
+				return err
+			}
+			res.Groups = groupHits(query.Query, groups, query.Limit)
+			return nil
+		})
+	}
+	if slices.Contains(query.Types, TypeInvitations) {
+		search(TypeInvitations, func() error {
+			invitations, err := h.invitations.SearchStaffInvitations(ctx, query.Query, query.Limit)
+			if err != nil {
+				return err
+			}
+			res.Invitations = invitationHits(query.Query, invitations, query.Limit)
+			return nil
+		})
+	}
+	wg.Wait()
+
+	if len(errs) > 0 {
+		err := errors.Join(errs...)
+		otelx.RecordSpanError(span, err, "search failed")
+		if len(errs) == len(query.Types) {
+			return nil, errorx.Wrap(err, op)
+		}
+		res.Partial = true
+		// The types in the order of Types, not of the failures.
+		slices.SortFunc(res.Failed, func(a, b Type) int {
+			return slices.Index(Types, a) - slices.Index(Types, b)
+		})
+	}
+	span.SetAttributes(attribute.Bool("search.partial", res.Partial))
+
+	return &res, nil
+}
+
+// Relevance ranks of a hit, the lower first.
+const (
+	rankExact = iota
+	rankPrefix
+	rankContains
+)
+
+// rank returns rankExact when a value of exact is q, rankPrefix when a value
+// of prefix starts with q, ignoring the case.
+func rank(q string, exact, prefix []string) int {
+	q = strings.ToLower(q)
+	for _, v := range exact {
+		if strings.ToLower(v) == q {
+			return rankExact
+		}
+	}
+	for _, v := range prefix {
+		if strings.HasPrefix(strings.ToLower(v), q) {
+			return rankPrefix
+		}
+	}
+	return rankContains
+}
+
+// byRank orders hits by their rank, stable so that the order of the
+// repository breaks the ties, and caps them at limit.
+func byRank[T any](hits []T, ranks []int, limit int) []T {
+	idx := make([]int, len(hits))
+	for i := range idx {
+		idx[i] = i
+	}
+	slices.SortStableFunc(idx, func(a, b int) int { return ranks[a] - ranks[b] })
+
+	sorted := make([]T, 0, min(len(hits), limit))
+	for _, i := range idx[:min(len(idx), limit)] {
+		sorted = append(sorted, hits[i])
+	}
+	return sorted
+}
+
+func userHits(q string, users []*user.User, limit int) []UserHit {
+	hits := make([]UserHit, len(users))
+	ranks := make([]int, len(users))
+	for i, u := range users {
+		hits[i] = newUserHit(u)
+		ranks[i] = rank(q,
+			[]string{hits[i].Barcode, hits[i].Username},
+			[]string{hits[i].Barcode, hits[i].Username, hits[i].Email, hits[i].FirstName, hits[i].LastName},
+		)
+	}
+	return byRank(hits, ranks, limit)
+}
+
+func groupHits(q string, groups []*group.Group, limit int) []GroupHit {
+	hits := make([]GroupHit, len(groups))
+	ranks := make([]int, len(groups))
+	for i, g := range groups {
+		hits[i] = GroupHit{
+			ID:    g.ID().String(),
+			Name:  g.Name(),
+			Major: g.Major().String(),
+			Year:  g.Year(),
+		}
+		ranks[i] = rank(q, []string{hits[i].Name}, []string{hits[i].Name, hits[i].Major})
+	}
+	return byRank(hits, ranks, limit)
+}
+
+func invitationHits(q string, invitations []*staffinvitation.StaffInvitation, limit int) []InvitationHit {
+	hits := make([]InvitationHit, len(invitations))
+	ranks := make([]int, len(invitations))
+	for i, inv := range invitations {
+		hits[i] = InvitationHit{
+			ID:              inv.ID().String(),
+			Code:            inv.Code(),
+			RecipientsEmail: inv.RecipientsEmail(),
+			Department:      inv.Department(),
+			Position:        inv.Position(),
+			ValidFrom:       httpx.NewTimePtr(inv.ValidFrom()),
+			ValidUntil:      httpx.NewTimePtr(inv.ValidUntil()),
+			CreatedAt:       httpx.NewTime(inv.CreatedAt()),
+		}
+		ranks[i] = rank(q, []string{hits[i].Code}, append([]string{hits[i].Code}, hits[i].RecipientsEmail...))
+	}
+	return byRank(hits, ranks, limit)
+}
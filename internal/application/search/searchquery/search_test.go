@@ -0,0 +1,99 @@
+package searchquery
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/group"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/staffinvitation"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/builders"
+)
+
+// fakeSearcher returns its rows in the order given, or err.
+type fakeSearcher struct {
+	users       []*user.User
+	groups      []*group.Group
+	invitations []*staffinvitation.StaffInvitation
+	err         error
+	limits      []int
+}
+
+func (f *fakeSearcher) SearchUsers(_ context.Context, _ string, limit int) ([]*user.User, error) {
+	f.limits = append(f.limits, limit)
+	return f.users, f.err
+}
+
+func (f *fakeSearcher) SearchGroups(_ context.Context, _ string, limit int) ([]*group.Group, error) {
+	return f.groups, f.err
+}
+
+func (f *fakeSearcher) SearchStaffInvitations(_ context.Context, _ string, limit int) ([]*staffinvitation.StaffInvitation, error) {
+	return f.invitations, f.err
+}
+
+func newHandler(users, groups, invitations *fakeSearcher) *SearchHandler {
+	return NewSearchHandler(SearchHandlerArgs{Users: users, Groups: groups, Invitations: invitations})
+}
+
+func TestSearchHandler_Buckets(t *testing.T) {
+	partialName := builders.NewUserBuilder().WithBarcode("ZX1001").WithUsername("annabel").WithName("Anna", "Smith").Build()
+	exact := builders.NewUserBuilder().WithBarcode("ANNA01").WithUsername("anna").WithName("Maria", "Lee").Build()
+	users := &fakeSearcher{users: []*user.User{partialName, exact}}
+	groups := &fakeSearcher{groups: []*group.Group{
+		builders.NewGroupBuilder().WithName("SE-2301").Build(),
+		builders.NewGroupBuilder().WithName("SE-23").Build(),
+	}}
+	invitations := &fakeSearcher{invitations: []*staffinvitation.StaffInvitation{
+		builders.NewStaffInvitationBuilder().WithCode("other").WithRecipientsEmail([]string{"anna@example.com"}).Build(),
+	}}
+	h := newHandler(users, groups, invitations)
+
+	res, err := h.Handle(t.Context(), Search{Query: " anna "})
+	require.NoError(t, err)
+	assert.False(t, res.Partial)
+	require.Len(t, res.Users, 2)
+	assert.Equal(t, exact.ID().String(), res.Users[0].ID, "the exact username match comes first")
+	assert.Equal(t, partialName.ID().String(), res.Users[1].ID)
+	assert.Len(t, res.Groups, 2)
+	require.Len(t, res.Invitations, 1)
+	assert.Equal(t, []int{DefaultLimit}, users.limits)
+
+	res, err = h.Handle(t.Context(), Search{Query: "se-23", Types: []Type{TypeGroups}, Limit: 1})
+	require.NoError(t, err)
+	require.Len(t, res.Groups, 1, "the bucket is capped at the limit")
+	assert.Equal(t, "SE-23", res.Groups[0].Name, "the exact name match comes first")
+	assert.Nil(t, res.Users, "the types not asked for are not searched")
+	assert.Nil(t, res.Invitations)
+}
+
+func TestSearchHandler_PartialFailure(t *testing.T) {
+	failing := &fakeSearcher{err: errors.New("connection reset")}
+	users := &fakeSearcher{users: []*user.User{builders.NewUserBuilder().WithUsername("anna").Build()}}
+	h := newHandler(users, failing, failing)
+
+	res, err := h.Handle(t.Context(), Search{Query: "anna"})
+	require.NoError(t, err)
+	assert.True(t, res.Partial)
+	assert.Equal(t, []Type{TypeGroups, TypeInvitations}, res.Failed)
+	assert.Len(t, res.Users, 1, "the other buckets are returned")
+	assert.Nil(t, res.Groups)
+
+	h = newHandler(failing, failing, failing)
+	_, err = h.Handle(t.Context(), Search{Query: "anna"})
+	assert.Error(t, err, "the search fails when every type failed")
+}
+
+func TestSearchHandler_ShortQuery(t *testing.T) {
+	h := newHandler(&fakeSearcher{}, &fakeSearcher{}, &fakeSearcher{})
+
+	for _, q := range []string{"", "a", "  b  "} {
+		_, err := h.Handle(t.Context(), Search{Query: q})
+		assert.Equal(t, errorx.CodeInvalid, errorx.CodeOf(err), q)
+	}
+}
@@ -1,6 +1,14 @@
 package staffapp
 
-import "gitlab.com/ucmsv2/ucms-backend/internal/application/staff/cmd"
+import (
+	"gitlab.com/ucmsv2/ucms-backend/internal/application/staff/cmd"
+	"gitlab.com/ucmsv2/ucms-backend/internal/application/staff/staffquery"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/tos"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/singleflightx"
+)
 
 type App struct {
 	Command Command
@@ -8,46 +16,127 @@ type App struct {
 }
 
 type Command struct {
-	CreateInvitation           *cmd.CreateInvitationHandler
-	UpdateInvitationRecipients *cmd.UpdateInvitationRecipientsHandler
-	UpdateInvitationValidity   *cmd.UpdateInvitationValidityHandler
-	DeleteInvitation           *cmd.DeleteInvitationHandler
-	ValidateInvitation         *cmd.ValidateInvitationHandler
-	AcceptInvitation           *cmd.AcceptInvitationHandler
+	CreateInvitation           otelx.Handler[cmd.CreateInvitation]
+	BulkCreateInvitations      *cmd.BulkCreateInvitationsHandler
+	UpdateInvitationRecipients otelx.Handler[cmd.UpdateInvitationRecipients]
+	UpdateInvitationValidity   otelx.Handler[cmd.UpdateInvitationValidity]
+	DeleteInvitation           otelx.Handler[cmd.DeleteInvitation]
+	RevokeInvitation           otelx.Handler[cmd.RevokeInvitation]
+	ValidateInvitation         otelx.Handler[cmd.ValidateInvitation]
+	AcceptInvitation           otelx.Handler[cmd.AcceptInvitation]
+	BootstrapInitialStaff      otelx.Handler[cmd.BootstrapInitialStaff]
+	UpdateProfile              otelx.Handler[cmd.UpdateProfile]
 }
 
-type Query struct{}
+type Query struct {
+	GetStaff *staffquery.GetStaffHandler
+}
+
+type StaffRepo interface {
+	cmd.StaffRepo
+	staffquery.StaffGetter
+}
 
 type Args struct {
 	StaffInvitationRepo cmd.StaffInvitationRepo
-	StaffRepo           cmd.StaffRepo
+	StaffRepo           StaffRepo
+	// PasswordPolicy defaults to a policy without breach check.
+	PasswordPolicy *user.PasswordPolicy
+	// TOSVersions is optional, without it no terms of service are recorded.
+	TOSVersions tos.CurrentGetter
+	// Clock defaults to clock.Real.
+	Clock clock.Clock
+	// AvatarURLs builds the avatar urls of the profiles, nil leaves them
+	// empty.
+	AvatarURLs *user.AvatarURLBuilder
+	// UnreadCounter counts the unread notifications of the profiles, nil
+	// leaves the count at 0.
+	UnreadCounter staffquery.UnreadCounter
 }
 
 func NewApp(args Args) *App {
 	return &App{
 		Command: Command{
-			CreateInvitation: cmd.NewCreateInvitationHandler(
-				cmd.CreateInvitationHandlerArgs{StaffInvitationRepo: args.StaffInvitationRepo},
+			CreateInvitation: otelx.InstrumentCommand[cmd.CreateInvitation](
+				"CreateInvitationHandler.Handle",
+				// A double submitted form creates one invitation.
+				singleflightx.Command(
+					singleflightx.NewGroup("staff.create_invitation", nil),
+					cmd.CreateInvitation.FlightKey,
+					cmd.NewCreateInvitationHandler(
+						cmd.CreateInvitationHandlerArgs{
+							StaffInvitationRepo: args.StaffInvitationRepo,
+							Clock:               args.Clock,
+						},
+					),
+				),
+			),
+			BulkCreateInvitations: cmd.NewBulkCreateInvitationsHandler(cmd.BulkCreateInvitationsHandlerArgs{
+				StaffInvitationRepo: args.StaffInvitationRepo,
+				Clock:               args.Clock,
+			}),
+			UpdateInvitationRecipients: otelx.InstrumentCommand[cmd.UpdateInvitationRecipients](
+				"UpdateInvitationRecipientsHandler.Handle",
+				cmd.NewUpdateInvitationRecipientsHandler(
+					cmd.UpdateInvitationRecipientsHandlerArgs{StaffInvitationRepo: args.StaffInvitationRepo},
+				),
 			),
-			UpdateInvitationRecipients: cmd.NewUpdateInvitationRecipientsHandler(
-				cmd.UpdateInvitationRecipientsHandlerArgs{StaffInvitationRepo: args.StaffInvitationRepo},
+			UpdateInvitationValidity: otelx.InstrumentCommand[cmd.UpdateInvitationValidity](
+				"UpdateInvitationValidityHandler.Handle",
+				cmd.NewUpdateInvitationValidityHandler(
+					cmd.UpdateInvitationValidityHandlerArgs{StaffInvitationRepo: args.StaffInvitationRepo},
+				),
 			),
-			UpdateInvitationValidity: cmd.NewUpdateInvitationValidityHandler(
-				cmd.UpdateInvitationValidityHandlerArgs{StaffInvitationRepo: args.StaffInvitationRepo},
+			DeleteInvitation: otelx.InstrumentCommand[cmd.DeleteInvitation](
+				"DeleteInvitationHandler.Handle",
+				cmd.NewDeleteInvitationHandler(
+					cmd.DeleteInvitationHandlerArgs{StaffInvitationRepo: args.StaffInvitationRepo},
+				),
 			),
-			DeleteInvitation: cmd.NewDeleteInvitationHandler(
-				cmd.DeleteInvitationHandlerArgs{StaffInvitationRepo: args.StaffInvitationRepo},
+			RevokeInvitation: otelx.InstrumentCommand[cmd.RevokeInvitation](
+				"RevokeInvitationHandler.Handle",
+				cmd.NewRevokeInvitationHandler(
+					cmd.RevokeInvitationHandlerArgs{StaffInvitationRepo: args.StaffInvitationRepo},
+				),
 			),
-			ValidateInvitation: cmd.NewValidateInvitationHandler(
-				cmd.ValidateInvitationHandlerArgs{StaffInvitationRepo: args.StaffInvitationRepo},
+			ValidateInvitation: otelx.InstrumentCommand[cmd.ValidateInvitation](
+				"ValidateInvitationHandler.Handle",
+				cmd.NewValidateInvitationHandler(
+					cmd.ValidateInvitationHandlerArgs{StaffInvitationRepo: args.StaffInvitationRepo},
+				),
 			),
-			AcceptInvitation: cmd.NewAcceptInvitationHandler(
-				cmd.AcceptInvitationHandlerArgs{
-					StaffInvitationRepo: args.StaffInvitationRepo,
-					StaffRepo:           args.StaffRepo,
-				},
+			AcceptInvitation: otelx.InstrumentCommand[cmd.AcceptInvitation](
+				"AcceptInvitationHandler.Handle",
+				cmd.NewAcceptInvitationHandler(
+					cmd.AcceptInvitationHandlerArgs{
+						StaffInvitationRepo: args.StaffInvitationRepo,
+						StaffRepo:           args.StaffRepo,
+						PasswordPolicy:      args.PasswordPolicy,
+						TOSVersions:         args.TOSVersions,
+						Clock:               args.Clock,
+					},
+				),
 			),
+			BootstrapInitialStaff: otelx.InstrumentCommand[cmd.BootstrapInitialStaff](
+				"BootstrapInitialStaffHandler.Handle",
+				cmd.NewBootstrapInitialStaffHandler(
+					cmd.BootstrapInitialStaffHandlerArgs{
+						InitialStaffRepo: args.StaffRepo,
+						PasswordPolicy:   args.PasswordPolicy,
+					},
+				),
+			),
+			UpdateProfile: otelx.InstrumentCommand[cmd.UpdateProfile](
+				"UpdateProfileHandler.Handle",
+				cmd.NewUpdateProfileHandler(cmd.UpdateProfileHandlerArgs{StaffRepo: args.StaffRepo}),
+			),
+		},
+		Query: Query{
+			GetStaff: staffquery.NewGetStaffHandler(staffquery.GetStaffHandlerArgs{
+				Staff:         args.StaffRepo,
+				AvatarURLs:    args.AvatarURLs,
+				UnreadCounter: args.UnreadCounter,
+			}),
 		},
-		Query: Query{},
 	}
 }
@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"context"
+	"log/slog"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/emails"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/logging"
+)
+
+type InitialStaffRepo interface {
+	BootstrapInitialStaff(
+		ctx context.Context,
+		email emails.Email,
+		instanceID string,
+		fn func(ctx context.Context, existing *user.Staff, hasStaff bool) (*user.Staff, user.BootstrapAction, error),
+	) error
+}
+
+// BootstrapInitialStaff creates the initial staff at startup when there is no
+// staff yet. Running it again changes nothing unless RotatePassword is set,
+// then the password of the initial staff is reset to Staff.Password.
+type BootstrapInitialStaff struct {
+	Staff          user.CreateInitialStaffArgs
+	RotatePassword bool
+	// InstanceID is recorded with the changes, it tells which instance made
+	// them.
+	InstanceID string
+}
+
+func (c BootstrapInitialStaff) SpanAttrs() map[string]any {
+	return map[string]any{
+		"email":           logging.RedactEmail(c.Staff.Email),
+		"rotate_password": c.RotatePassword,
+		"instance_id":     c.InstanceID,
+	}
+}
+
+type BootstrapInitialStaffHandler struct {
+	logger         *slog.Logger
+	repo           InitialStaffRepo
+	passwordPolicy *user.PasswordPolicy
+}
+
+type BootstrapInitialStaffHandlerArgs struct {
+	Logger           *slog.Logger
+	InitialStaffRepo InitialStaffRepo
+	// PasswordPolicy checks the rotated passwords, it defaults to a policy
+	// without breach check.
+	PasswordPolicy *user.PasswordPolicy
+}
+
+func NewBootstrapInitialStaffHandler(args BootstrapInitialStaffHandlerArgs) *BootstrapInitialStaffHandler {
+	if args.InitialStaffRepo == nil {
+		panic("InitialStaffRepo cannot be nil")
+	}
+
+	if args.PasswordPolicy == nil {
+		args.PasswordPolicy = user.NewPasswordPolicy(user.PasswordPolicyArgs{})
+	}
+
+	h := &BootstrapInitialStaffHandler{
+		logger:         args.Logger,
+		repo:           args.InitialStaffRepo,
+		passwordPolicy: args.PasswordPolicy,
+	}
+
+	if h.logger == nil {
+		h.logger = logger
+	}
+
+	return h
+}
+
+func (h *BootstrapInitialStaffHandler) Handle(ctx context.Context, cmd BootstrapInitialStaff) error {
+	const op = "cmd.BootstrapInitialStaffHandler.Handle"
+	span := trace.SpanFromContext(ctx)
+
+	var action user.BootstrapAction
+	err := h.repo.BootstrapInitialStaff(ctx, cmd.Staff.Email, cmd.InstanceID,
+		func(ctx context.Context, existing *user.Staff, hasStaff bool) (*user.Staff, user.BootstrapAction, error) {
+			action = user.BootstrapSkipped
+			switch {
+			case existing != nil && cmd.RotatePassword:
+				if existing.User().ComparePassword(cmd.Staff.Password) == nil {
+					return nil, action, nil
+				}
+				if err := existing.User().ChangePassword(ctx, h.passwordPolicy, cmd.Staff.Password); err != nil {
+					return nil, "", err
+				}
+				action = user.BootstrapPasswordRotated
+				return existing, action, nil
+			case existing != nil || hasStaff:
+				return nil, action, nil
+			}
+
+			staff, err := user.CreateInitialStaff(cmd.Staff)
+			if err != nil {
+				return nil, "", err
+			}
+			action = user.BootstrapCreated
+			return staff, action, nil
+		},
+	)
+	if err != nil {
+		span.AddEvent("failed to bootstrap initial staff")
+		return errorx.Wrap(err, op)
+	}
+
+	h.logger.InfoContext(ctx, "Initial staff bootstrapped",
+		"action", string(action),
+		"email", logging.RedactEmail(cmd.Staff.Email),
+		"rotate_password", cmd.RotatePassword,
+	)
+	return nil
+}
@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/builders"
+	"gitlab.com/ucmsv2/ucms-backend/tests/mocks"
+)
+
+func TestBootstrapInitialStaffHandler(t *testing.T) {
+	newArgs := func() user.CreateInitialStaffArgs {
+		return builders.NewStaffBuilder().WithPassword("FirstP@ssw0rd").BuildCreateInitialStaffArgs()
+	}
+
+	t.Run("creates the initial staff once", func(t *testing.T) {
+		repo := mocks.NewStaffRepo()
+		h := NewBootstrapInitialStaffHandler(BootstrapInitialStaffHandlerArgs{InitialStaffRepo: repo})
+		args := newArgs()
+
+		require.NoError(t, h.Handle(t.Context(), BootstrapInitialStaff{Staff: args, InstanceID: "instance-1"}))
+		require.NoError(t, h.Handle(t.Context(), BootstrapInitialStaff{Staff: args, InstanceID: "instance-2"}))
+
+		assert.Equal(t, 1, repo.Count())
+		records := repo.BootstrapRecords()
+		require.Len(t, records, 1)
+		assert.Equal(t, user.BootstrapCreated, records[0].Action)
+		assert.Equal(t, "instance-1", records[0].InstanceID)
+	})
+
+	t.Run("keeps the password without rotation", func(t *testing.T) {
+		repo := mocks.NewStaffRepo()
+		h := NewBootstrapInitialStaffHandler(BootstrapInitialStaffHandlerArgs{InitialStaffRepo: repo})
+		args := newArgs()
+		require.NoError(t, h.Handle(t.Context(), BootstrapInitialStaff{Staff: args}))
+
+		args.Password = "SecondP@ssw0rd"
+		require.NoError(t, h.Handle(t.Context(), BootstrapInitialStaff{Staff: args}))
+
+		staff, err := repo.GetStaffByEmail(t.Context(), args.Email)
+		require.NoError(t, err)
+		assert.NoError(t, staff.User().ComparePassword("FirstP@ssw0rd"))
+		assert.Len(t, repo.BootstrapRecords(), 1)
+	})
+
+	t.Run("rotates the password", func(t *testing.T) {
+		repo := mocks.NewStaffRepo()
+		h := NewBootstrapInitialStaffHandler(BootstrapInitialStaffHandlerArgs{InitialStaffRepo: repo})
+		args := newArgs()
+		require.NoError(t, h.Handle(t.Context(), BootstrapInitialStaff{Staff: args}))
+
+		args.Password = "SecondP@ssw0rd"
+		require.NoError(t, h.Handle(t.Context(), BootstrapInitialStaff{Staff: args, RotatePassword: true}))
+		require.NoError(t, h.Handle(t.Context(), BootstrapInitialStaff{Staff: args, RotatePassword: true}))
+
+		staff, err := repo.GetStaffByEmail(t.Context(), args.Email)
+		require.NoError(t, err)
+		assert.NoError(t, staff.User().ComparePassword("SecondP@ssw0rd"))
+		records := repo.BootstrapRecords()
+		require.Len(t, records, 2, "rotating to the same password is skipped")
+		assert.Equal(t, user.BootstrapPasswordRotated, records[1].Action)
+	})
+
+	t.Run("weak rotated password", func(t *testing.T) {
+		repo := mocks.NewStaffRepo()
+		h := NewBootstrapInitialStaffHandler(BootstrapInitialStaffHandlerArgs{InitialStaffRepo: repo})
+		args := newArgs()
+		require.NoError(t, h.Handle(t.Context(), BootstrapInitialStaff{Staff: args}))
+
+		args.Password = "weak"
+		assert.Error(t, h.Handle(t.Context(), BootstrapInitialStaff{Staff: args, RotatePassword: true}))
+
+		staff, err := repo.GetStaffByEmail(t.Context(), args.Email)
+		require.NoError(t, err)
+		assert.NoError(t, staff.User().ComparePassword("FirstP@ssw0rd"))
+	})
+
+	t.Run("skips when other staff exist", func(t *testing.T) {
+		repo := mocks.NewStaffRepo()
+		repo.SeedStaff(t, builders.NewStaffBuilder().WithEmail("other@test.com").WithUsername("other").WithBarcode("OTHER1").Build())
+		h := NewBootstrapInitialStaffHandler(BootstrapInitialStaffHandlerArgs{InitialStaffRepo: repo})
+
+		require.NoError(t, h.Handle(t.Context(), BootstrapInitialStaff{Staff: newArgs()}))
+
+		assert.Equal(t, 1, repo.Count())
+		assert.Empty(t, repo.BootstrapRecords())
+	})
+
+	t.Run("never logs the password", func(t *testing.T) {
+		var buf bytes.Buffer
+		h := NewBootstrapInitialStaffHandler(BootstrapInitialStaffHandlerArgs{
+			Logger:           slog.New(slog.NewTextHandler(&buf, nil)),
+			InitialStaffRepo: mocks.NewStaffRepo(),
+		})
+		args := newArgs()
+
+		require.NoError(t, h.Handle(t.Context(), BootstrapInitialStaff{Staff: args, RotatePassword: true}))
+		assert.Contains(t, buf.String(), "action=created")
+		assert.NotContains(t, buf.String(), args.Password)
+		assert.NotContains(t, buf.String(), args.Email)
+	})
+}
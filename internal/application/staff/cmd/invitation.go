@@ -2,26 +2,29 @@ package cmd
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
 	"log/slog"
 	"time"
 
+	"github.com/ARUMANDESU/validation"
 	"github.com/google/uuid"
 	"go.opentelemetry.io/contrib/bridges/otelslog"
-	"go.opentelemetry.io/otel"
-	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
 	"go.opentelemetry.io/otel/trace"
 
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/staffinvitation"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/tos"
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/emails"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
 	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
 	"gitlab.com/ucmsv2/ucms-backend/pkg/i18nx"
-	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx/metrics"
 )
 
-var (
-	tracer = otel.Tracer("ucms/internal/application/staff/cmd")
-	logger = otelslog.NewLogger("ucms/internal/application/staff/cmd")
-)
+var logger = otelslog.NewLogger("ucms/internal/application/staff/cmd")
 
 var (
 	ErrEmailNotAvailable    = errorx.NewDuplicateEntry().WithKey(i18nx.KeyEmailNotAvailable)
@@ -33,16 +36,27 @@ type StaffInvitationRepo interface {
 	SaveStaffInvitation(ctx context.Context, invitation *staffinvitation.StaffInvitation) error
 	UpdateStaffInvitation(ctx context.Context, id staffinvitation.ID, fn func(context.Context, *staffinvitation.StaffInvitation) error) error
 	GetStaffInvitationByCode(ctx context.Context, code string) (*staffinvitation.StaffInvitation, error)
+	// CountPendingRecipients counts the recipients of the invitations of the
+	// creator neither deleted nor expired at now. The creator is locked until
+	// the transaction of ctx ends, the handlers run in the transaction of the
+	// request so that the count and the save are not interleaved with those
+	// of a concurrent request of the creator.
+	CountPendingRecipients(ctx context.Context, creatorID user.ID, now time.Time) (int, error)
 }
 
 type StaffRepo interface {
 	IsStaffExists(
 		ctx context.Context,
-		email string,
+		email emails.Email,
 		username string,
 		barcode user.Barcode,
 	) (emailExists bool, usernameExists bool, barcodeExists bool, err error)
 	SaveStaff(ctx context.Context, staff *user.Staff) error
+	// LinkStaffAccount locks the user with email, runs fn on it and saves the
+	// staff it returns: the roles of the user and the staff record.
+	LinkStaffAccount(ctx context.Context, email emails.Email, fn func(context.Context, *user.User) (*user.Staff, error)) error
+	StaffUpdater
+	InitialStaffRepo
 }
 
 type CreateInvitation struct {
@@ -50,30 +64,55 @@ type CreateInvitation struct {
 	RecipientsEmail []string
 	ValidFrom       *time.Time
 	ValidUntil      *time.Time
+	Department      string
+	Position        string
+}
+
+func (c CreateInvitation) SpanAttrs() map[string]any {
+	return map[string]any{
+		"creator_id":       c.CreatorID.String(),
+		"recipients_count": len(c.RecipientsEmail),
+	}
+}
+
+// FlightKey identifies the identical invitations of a creator, the concurrent
+// ones are created once, see singleflightx.
+func (c CreateInvitation) FlightKey() string {
+	h := sha256.New()
+	for _, email := range c.RecipientsEmail {
+		fmt.Fprintf(h, "%q,", email)
+	}
+	for _, t := range []*time.Time{c.ValidFrom, c.ValidUntil} {
+		if t == nil {
+			fmt.Fprint(h, "-,")
+			continue
+		}
+		fmt.Fprintf(h, "%s,", t.UTC().Format(time.RFC3339Nano))
+	}
+	fmt.Fprintf(h, "%q,%q", c.Department, c.Position)
+	return c.CreatorID.String() + ":" + hex.EncodeToString(h.Sum(nil))
 }
 
 type CreateInvitationHandler struct {
-	tracer trace.Tracer
 	logger *slog.Logger
 	repo   StaffInvitationRepo
+	clock  clock.Clock
 }
 
 type CreateInvitationHandlerArgs struct {
-	Tracer              trace.Tracer
 	Logger              *slog.Logger
 	StaffInvitationRepo StaffInvitationRepo
+	// Clock defaults to clock.Real.
+	Clock clock.Clock
 }
 
 func NewCreateInvitationHandler(args CreateInvitationHandlerArgs) *CreateInvitationHandler {
 	h := &CreateInvitationHandler{
-		tracer: args.Tracer,
 		logger: args.Logger,
 		repo:   args.StaffInvitationRepo,
+		clock:  args.Clock,
 	}
 
-	if h.tracer == nil {
-		h.tracer = tracer
-	}
 	if h.logger == nil {
 		h.logger = logger
 	}
@@ -83,26 +122,35 @@ func NewCreateInvitationHandler(args CreateInvitationHandlerArgs) *CreateInvitat
 
 func (h *CreateInvitationHandler) Handle(ctx context.Context, cmd CreateInvitation) error {
 	const op = "cmd.CreateInvitationHandler.Handle"
-	ctx, span := h.tracer.Start(ctx, "CreateInvitationHandler.Handle", trace.WithAttributes(
-		attribute.String("creator_id", cmd.CreatorID.String()),
-		attribute.Int("recipients_count", len(cmd.RecipientsEmail)),
-	))
-	defer span.End()
+	span := trace.SpanFromContext(ctx)
 
 	invitation, err := staffinvitation.NewStaffInvitation(staffinvitation.CreateArgs{
 		RecipientsEmail: cmd.RecipientsEmail,
 		CreatorID:       cmd.CreatorID,
 		ValidFrom:       cmd.ValidFrom,
 		ValidUntil:      cmd.ValidUntil,
+		Department:      cmd.Department,
+		Position:        cmd.Position,
+		Clock:           h.clock,
 	})
 	if err != nil {
-		otelx.RecordSpanError(span, err, "failed to create new staff invitation")
+		span.AddEvent("failed to create new staff invitation")
+		return errorx.Wrap(err, op)
+	}
+
+	pending, err := h.repo.CountPendingRecipients(ctx, cmd.CreatorID, clock.Or(h.clock).Now())
+	if err != nil {
+		span.AddEvent("failed to count pending recipients")
+		return errorx.Wrap(err, op)
+	}
+	if err := staffinvitation.CheckPendingRecipients(pending, len(invitation.RecipientsEmail())); err != nil {
+		span.AddEvent("pending recipients limit exceeded")
 		return errorx.Wrap(err, op)
 	}
 
 	err = h.repo.SaveStaffInvitation(ctx, invitation)
 	if err != nil {
-		otelx.RecordSpanError(span, err, "failed to save staff invitation")
+		span.AddEvent("failed to save staff invitation")
 		return errorx.Wrap(err, op)
 	}
 
@@ -115,28 +163,30 @@ type UpdateInvitationRecipients struct {
 	RecipientsEmail []string
 }
 
+func (c UpdateInvitationRecipients) SpanAttrs() map[string]any {
+	return map[string]any{
+		"invitation_id":    c.InvitationID.String(),
+		"creator_id":       c.CreatorID.String(),
+		"recipients_count": len(c.RecipientsEmail),
+	}
+}
+
 type UpdateInvitationRecipientsHandler struct {
-	tracer trace.Tracer
 	logger *slog.Logger
 	repo   StaffInvitationRepo
 }
 
 type UpdateInvitationRecipientsHandlerArgs struct {
-	Tracer              trace.Tracer
 	Logger              *slog.Logger
 	StaffInvitationRepo StaffInvitationRepo
 }
 
 func NewUpdateInvitationRecipientsHandler(args UpdateInvitationRecipientsHandlerArgs) *UpdateInvitationRecipientsHandler {
 	h := &UpdateInvitationRecipientsHandler{
-		tracer: args.Tracer,
 		logger: args.Logger,
 		repo:   args.StaffInvitationRepo,
 	}
 
-	if h.tracer == nil {
-		h.tracer = tracer
-	}
 	if h.logger == nil {
 		h.logger = logger
 	}
@@ -146,12 +196,7 @@ func NewUpdateInvitationRecipientsHandler(args UpdateInvitationRecipientsHandler
 
 func (h *UpdateInvitationRecipientsHandler) Handle(ctx context.Context, cmd UpdateInvitationRecipients) error {
 	const op = "cmd.UpdateInvitationRecipientsHandler.Handle"
-	ctx, span := h.tracer.Start(ctx, "UpdateInvitationRecipientsHandler.Handle", trace.WithAttributes(
-		attribute.String("invitation_id", cmd.InvitationID.String()),
-		attribute.String("creator_id", cmd.CreatorID.String()),
-		attribute.Int("recipients_count", len(cmd.RecipientsEmail)),
-	))
-	defer span.End()
+	span := trace.SpanFromContext(ctx)
 
 	err := h.repo.UpdateStaffInvitation(ctx, cmd.InvitationID, func(ctx context.Context, si *staffinvitation.StaffInvitation) error {
 		if err := si.UpdateRecipients(cmd.CreatorID, cmd.RecipientsEmail); err != nil {
@@ -162,7 +207,7 @@ func (h *UpdateInvitationRecipientsHandler) Handle(ctx context.Context, cmd Upda
 		return nil
 	})
 	if err != nil {
-		otelx.RecordSpanError(span, err, "failed to update staff invitation")
+		span.AddEvent("failed to update staff invitation")
 		return errorx.Wrap(err, op)
 	}
 
@@ -176,28 +221,29 @@ type UpdateInvitationValidity struct {
 	ValidUntil   *time.Time
 }
 
+func (c UpdateInvitationValidity) SpanAttrs() map[string]any {
+	return map[string]any{
+		"invitation_id": c.InvitationID.String(),
+		"creator_id":    c.CreatorID.String(),
+	}
+}
+
 type UpdateInvitationValidityHandler struct {
-	tracer trace.Tracer
 	logger *slog.Logger
 	repo   StaffInvitationRepo
 }
 
 type UpdateInvitationValidityHandlerArgs struct {
-	Tracer              trace.Tracer
 	Logger              *slog.Logger
 	StaffInvitationRepo StaffInvitationRepo
 }
 
 func NewUpdateInvitationValidityHandler(args UpdateInvitationValidityHandlerArgs) *UpdateInvitationValidityHandler {
 	h := &UpdateInvitationValidityHandler{
-		tracer: args.Tracer,
 		logger: args.Logger,
 		repo:   args.StaffInvitationRepo,
 	}
 
-	if h.tracer == nil {
-		h.tracer = tracer
-	}
 	if h.logger == nil {
 		h.logger = logger
 	}
@@ -207,11 +253,7 @@ func NewUpdateInvitationValidityHandler(args UpdateInvitationValidityHandlerArgs
 
 func (h *UpdateInvitationValidityHandler) Handle(ctx context.Context, cmd UpdateInvitationValidity) error {
 	const op = "cmd.UpdateInvitationValidityHandler.Handle"
-	ctx, span := h.tracer.Start(ctx, "UpdateInvitationValidityHandler.Handle", trace.WithAttributes(
-		attribute.String("invitation_id", cmd.InvitationID.String()),
-		attribute.String("creator_id", cmd.CreatorID.String()),
-	))
-	defer span.End()
+	span := trace.SpanFromContext(ctx)
 
 	err := h.repo.UpdateStaffInvitation(ctx, cmd.InvitationID, func(ctx context.Context, si *staffinvitation.StaffInvitation) error {
 		if err := si.UpdateValidity(cmd.CreatorID, cmd.ValidFrom, cmd.ValidUntil); err != nil {
@@ -222,7 +264,7 @@ func (h *UpdateInvitationValidityHandler) Handle(ctx context.Context, cmd Update
 		return nil
 	})
 	if err != nil {
-		otelx.RecordSpanError(span, err, "failed to update staff invitation validity")
+		span.AddEvent("failed to update staff invitation validity")
 		return errorx.Wrap(err, op)
 	}
 
@@ -234,28 +276,29 @@ type DeleteInvitation struct {
 	InvitationID staffinvitation.ID
 }
 
+func (c DeleteInvitation) SpanAttrs() map[string]any {
+	return map[string]any{
+		"invitation_id": c.InvitationID.String(),
+		"creator_id":    c.CreatorID.String(),
+	}
+}
+
 type DeleteInvitationHandler struct {
-	tracer trace.Tracer
 	logger *slog.Logger
 	repo   StaffInvitationRepo
 }
 
 type DeleteInvitationHandlerArgs struct {
-	Tracer              trace.Tracer
 	Logger              *slog.Logger
 	StaffInvitationRepo StaffInvitationRepo
 }
 
 func NewDeleteInvitationHandler(args DeleteInvitationHandlerArgs) *DeleteInvitationHandler {
 	h := &DeleteInvitationHandler{
-		tracer: args.Tracer,
 		logger: args.Logger,
 		repo:   args.StaffInvitationRepo,
 	}
 
-	if h.tracer == nil {
-		h.tracer = tracer
-	}
 	if h.logger == nil {
 		h.logger = logger
 	}
@@ -265,11 +308,7 @@ func NewDeleteInvitationHandler(args DeleteInvitationHandlerArgs) *DeleteInvitat
 
 func (h *DeleteInvitationHandler) Handle(ctx context.Context, cmd DeleteInvitation) error {
 	const op = "cmd.DeleteInvitationHandler.Handle"
-	ctx, span := h.tracer.Start(ctx, "DeleteInvitationHandler.Handle", trace.WithAttributes(
-		attribute.String("invitation_id", cmd.InvitationID.String()),
-		attribute.String("creator_id", cmd.CreatorID.String()),
-	))
-	defer span.End()
+	span := trace.SpanFromContext(ctx)
 
 	err := h.repo.UpdateStaffInvitation(ctx, cmd.InvitationID, func(ctx context.Context, si *staffinvitation.StaffInvitation) error {
 		if err := si.MarkDeleted(cmd.CreatorID); err != nil {
@@ -280,10 +319,62 @@ func (h *DeleteInvitationHandler) Handle(ctx context.Context, cmd DeleteInvitati
 		return nil
 	})
 	if err != nil {
-		otelx.RecordSpanError(span, err, "failed to delete staff invitation")
+		span.AddEvent("failed to delete staff invitation")
+		return errorx.Wrap(err, op)
+	}
+
+	return nil
+}
+
+// RevokeInvitation deletes an invitation on behalf of an operator, whoever
+// created it.
+type RevokeInvitation struct {
+	InvitationID staffinvitation.ID
+}
+
+func (c RevokeInvitation) SpanAttrs() map[string]any {
+	return map[string]any{
+		"invitation_id": c.InvitationID.String(),
+	}
+}
+
+type RevokeInvitationHandler struct {
+	logger *slog.Logger
+	repo   StaffInvitationRepo
+}
+
+type RevokeInvitationHandlerArgs struct {
+	Logger              *slog.Logger
+	StaffInvitationRepo StaffInvitationRepo
+}
+
+func NewRevokeInvitationHandler(args RevokeInvitationHandlerArgs) *RevokeInvitationHandler {
+	h := &RevokeInvitationHandler{
+		logger: args.Logger,
+		repo:   args.StaffInvitationRepo,
+	}
+
+	if h.logger == nil {
+		h.logger = logger
+	}
+
+	return h
+}
+
+func (h *RevokeInvitationHandler) Handle(ctx context.Context, cmd RevokeInvitation) error {
+	const op = "cmd.RevokeInvitationHandler.Handle"
+	span := trace.SpanFromContext(ctx)
+
+	err := h.repo.UpdateStaffInvitation(ctx, cmd.InvitationID, func(ctx context.Context, si *staffinvitation.StaffInvitation) error {
+		si.Revoke()
+		return nil
+	})
+	if err != nil {
+		span.AddEvent("failed to revoke staff invitation")
 		return errorx.Wrap(err, op)
 	}
 
+	h.logger.InfoContext(ctx, "Staff invitation revoked", "invitation_id", cmd.InvitationID.String())
 	return nil
 }
 
@@ -292,28 +383,29 @@ type ValidateInvitation struct {
 	Email          string
 }
 
+func (c ValidateInvitation) SpanAttrs() map[string]any {
+	return map[string]any{
+		"invitation_code": c.InvitationCode,
+		"email":           c.Email,
+	}
+}
+
 type ValidateInvitationHandler struct {
-	tracer trace.Tracer
 	logger *slog.Logger
 	repo   StaffInvitationRepo
 }
 
 type ValidateInvitationHandlerArgs struct {
-	Tracer              trace.Tracer
 	Logger              *slog.Logger
 	StaffInvitationRepo StaffInvitationRepo
 }
 
 func NewValidateInvitationHandler(args ValidateInvitationHandlerArgs) *ValidateInvitationHandler {
 	h := &ValidateInvitationHandler{
-		tracer: args.Tracer,
 		logger: args.Logger,
 		repo:   args.StaffInvitationRepo,
 	}
 
-	if h.tracer == nil {
-		h.tracer = tracer
-	}
 	if h.logger == nil {
 		h.logger = logger
 	}
@@ -323,15 +415,11 @@ func NewValidateInvitationHandler(args ValidateInvitationHandlerArgs) *ValidateI
 
 func (h *ValidateInvitationHandler) Handle(ctx context.Context, cmd ValidateInvitation) error {
 	const op = "cmd.ValidateInvitationHandler.Handle"
-	ctx, span := h.tracer.Start(ctx, "ValidateInvitationHandler.Handle", trace.WithAttributes(
-		attribute.String("invitation_code", cmd.InvitationCode),
-		attribute.String("email", cmd.Email),
-	))
-	defer span.End()
+	span := trace.SpanFromContext(ctx)
 
 	invitation, err := h.repo.GetStaffInvitationByCode(ctx, cmd.InvitationCode)
 	if err != nil {
-		otelx.RecordSpanError(span, err, "failed to get staff invitation by code")
+		span.AddEvent("failed to get staff invitation by code")
 		if errorx.IsNotFound(err) {
 			return staffinvitation.ErrNotFoundOrDeleted.WithCause(err, op)
 		}
@@ -339,7 +427,7 @@ func (h *ValidateInvitationHandler) Handle(ctx context.Context, cmd ValidateInvi
 	}
 
 	if err := invitation.ValidateInvitationAccess(cmd.Email, cmd.InvitationCode); err != nil {
-		otelx.RecordSpanError(span, err, "invitation validation failed")
+		span.AddEvent("invitation validation failed")
 		return errorx.Wrap(err, op)
 	}
 
@@ -348,39 +436,79 @@ func (h *ValidateInvitationHandler) Handle(ctx context.Context, cmd ValidateInvi
 
 type AcceptInvitation struct {
 	InvitationCode string
-	Email          string
+	Email          emails.Email
 	Barcode        user.Barcode
 	Username       string
 	Password       string
 	FirstName      string
 	LastName       string
+	// AcceptTOS is the consent to the current terms of service, required
+	// once a version is published.
+	AcceptTOS bool
+	// IP is the address the terms of service were accepted from.
+	IP string
+	// LinkExistingAccount accepts the invitation with the student account of
+	// Email instead of a new one, Password is then its current password. The
+	// other fields are kept from the account, see user.LinkStaffAccount.
+	LinkExistingAccount bool
+}
+
+func (c AcceptInvitation) SpanAttrs() map[string]any {
+	return map[string]any{
+		"invitation_code":       c.InvitationCode,
+		"email":                 c.Email.String(),
+		"barcode":               c.Barcode.String(),
+		"username":              c.Username,
+		"accept_tos":            c.AcceptTOS,
+		"link_existing_account": c.LinkExistingAccount,
+	}
 }
 
 type AcceptInvitationHandler struct {
-	tracer    trace.Tracer
-	logger    *slog.Logger
-	repo      StaffInvitationRepo
-	staffRepo StaffRepo
+	logger         *slog.Logger
+	repo           StaffInvitationRepo
+	staffRepo      StaffRepo
+	passwordPolicy *user.PasswordPolicy
+	tosVersions    tos.CurrentGetter
+	accepted       metric.Int64Counter
+	clock          clock.Clock
 }
 
 type AcceptInvitationHandlerArgs struct {
-	Tracer              trace.Tracer
 	Logger              *slog.Logger
 	StaffInvitationRepo StaffInvitationRepo
 	StaffRepo           StaffRepo
+	// PasswordPolicy defaults to a policy without breach check.
+	PasswordPolicy *user.PasswordPolicy
+	// TOSVersions is optional, without it no terms of service are recorded.
+	TOSVersions tos.CurrentGetter
+	// Clock defaults to clock.Real.
+	Clock clock.Clock
+	// Metrics defaults to metrics.Default().
+	Metrics *metrics.Registry
 }
 
 func NewAcceptInvitationHandler(args AcceptInvitationHandlerArgs) *AcceptInvitationHandler {
-	h := &AcceptInvitationHandler{
-		tracer:    args.Tracer,
-		logger:    args.Logger,
-		repo:      args.StaffInvitationRepo,
-		staffRepo: args.StaffRepo,
+	if args.Metrics == nil {
+		args.Metrics = metrics.Default()
+	}
+	if args.PasswordPolicy == nil {
+		args.PasswordPolicy = user.NewPasswordPolicy(user.PasswordPolicyArgs{})
 	}
 
-	if h.tracer == nil {
-		h.tracer = tracer
+	h := &AcceptInvitationHandler{
+		logger:         args.Logger,
+		repo:           args.StaffInvitationRepo,
+		staffRepo:      args.StaffRepo,
+		passwordPolicy: args.PasswordPolicy,
+		tosVersions:    args.TOSVersions,
+		accepted: args.Metrics.Int64Counter(metrics.StaffInvitationAccepted,
+			metric.WithDescription("Number of accepted staff invitations"),
+			metric.WithUnit("{invitation}"),
+		),
+		clock: args.Clock,
 	}
+
 	if h.logger == nil {
 		h.logger = logger
 	}
@@ -390,31 +518,29 @@ func NewAcceptInvitationHandler(args AcceptInvitationHandlerArgs) *AcceptInvitat
 
 func (h *AcceptInvitationHandler) Handle(ctx context.Context, cmd AcceptInvitation) error {
 	const op = "cmd.AcceptInvitationHandler.Handle"
-	ctx, span := h.tracer.Start(ctx, "AcceptInvitationHandler.Handle", trace.WithAttributes(
-		attribute.String("invitation_code", cmd.InvitationCode),
-		attribute.String("email", cmd.Email),
-		attribute.String("barcode", cmd.Barcode.String()),
-		attribute.String("username", cmd.Username),
-	))
-	defer span.End()
+	span := trace.SpanFromContext(ctx)
 
 	invitation, err := h.repo.GetStaffInvitationByCode(ctx, cmd.InvitationCode)
 	if err != nil {
-		otelx.RecordSpanError(span, err, "failed to get staff invitation by code")
+		span.AddEvent("failed to get staff invitation by code")
 		if errorx.IsNotFound(err) {
 			return staffinvitation.ErrNotFoundOrDeleted.WithCause(err, op)
 		}
 		return errorx.Wrap(err, op)
 	}
 
-	if err := invitation.ValidateInvitationAccess(cmd.Email, cmd.InvitationCode); err != nil {
-		otelx.RecordSpanError(span, err, "invitation validation failed")
+	if err := invitation.ValidateInvitationAccess(cmd.Email.String(), cmd.InvitationCode); err != nil {
+		span.AddEvent("invitation validation failed")
 		return errorx.Wrap(err, op)
 	}
 
+	if cmd.LinkExistingAccount {
+		return h.link(ctx, cmd, invitation)
+	}
+
 	emailExists, usernameExists, barcodeExists, err := h.staffRepo.IsStaffExists(ctx, cmd.Email, cmd.Username, cmd.Barcode)
 	if err != nil {
-		otelx.RecordSpanError(span, err, "failed to check if staff exists")
+		span.AddEvent("failed to check if staff exists")
 		return errorx.Wrap(err, op)
 	}
 
@@ -429,10 +555,20 @@ func (h *AcceptInvitationHandler) Handle(ctx context.Context, cmd AcceptInvitati
 		if barcodeExists {
 			errs = append(errs, ErrBarcodeNotAvailable)
 		}
-		otelx.RecordSpanError(span, errs, "validation error: user already exists")
+		span.AddEvent("validation error: user already exists")
 		return errorx.Wrap(errs, op)
 	}
 
+	if err := h.passwordPolicy.Check(ctx, cmd.Password); err != nil {
+		span.AddEvent("password rejected by the password policy")
+		return errorx.Wrap(err, op)
+	}
+
+	consent, err := h.consent(ctx, cmd)
+	if err != nil {
+		return errorx.Wrap(err, op)
+	}
+
 	staff, err := user.AcceptStaffInvitation(user.AcceptStaffInvitationArgs{
 		Email:        cmd.Email,
 		Barcode:      cmd.Barcode,
@@ -441,17 +577,99 @@ func (h *AcceptInvitationHandler) Handle(ctx context.Context, cmd AcceptInvitati
 		FirstName:    cmd.FirstName,
 		LastName:     cmd.LastName,
 		InvitationID: uuid.UUID(invitation.ID()),
+		Department:   invitation.Department(),
+		Position:     invitation.Position(),
+		TOS:          consent,
+		Clock:        h.clock,
 	})
 	if err != nil {
-		otelx.RecordSpanError(span, err, "failed to create staff")
+		span.AddEvent("failed to create staff")
+		return errorx.Wrap(err, op)
+	}
+
+	// The two writes land together only within the transaction of the
+	// request, see middlewares.Transactional.
+	err = h.repo.UpdateStaffInvitation(ctx, invitation.ID(), func(_ context.Context, inv *staffinvitation.StaffInvitation) error {
+		return inv.Accept(cmd.Email.String())
+	})
+	if err != nil {
+		span.AddEvent("failed to accept staff invitation")
 		return errorx.Wrap(err, op)
 	}
 
 	err = h.staffRepo.SaveStaff(ctx, staff)
 	if err != nil {
-		otelx.RecordSpanError(span, err, "failed to save staff")
+		span.AddEvent("failed to save staff")
 		return errorx.Wrap(err, op)
 	}
+	h.accepted.Add(ctx, 1)
 
 	return nil
 }
+
+// link accepts the invitation with the student account of the email, the
+// password of the account proves it is the invitee's.
+func (h *AcceptInvitationHandler) link(ctx context.Context, cmd AcceptInvitation, invitation *staffinvitation.StaffInvitation) error {
+	const op = "cmd.AcceptInvitationHandler.link"
+	span := trace.SpanFromContext(ctx)
+
+	consent, err := h.consent(ctx, cmd)
+	if err != nil {
+		return errorx.Wrap(err, op)
+	}
+
+	err = h.staffRepo.LinkStaffAccount(ctx, cmd.Email, func(_ context.Context, u *user.User) (*user.Staff, error) {
+		return user.LinkStaffAccount(u, user.LinkStaffAccountArgs{
+			Password:     cmd.Password,
+			InvitationID: uuid.UUID(invitation.ID()),
+			Department:   invitation.Department(),
+			Position:     invitation.Position(),
+			TOS:          consent,
+		})
+	})
+	if err != nil {
+		span.AddEvent("failed to link student account")
+		if errorx.IsNotFound(err) {
+			// Nothing tells whether the email has an account.
+			return errorx.NewInvalidCredentials().WithCause(err, op)
+		}
+		return errorx.Wrap(err, op)
+	}
+
+	// The two writes land together only within the transaction of the
+	// request, see middlewares.Transactional.
+	err = h.repo.UpdateStaffInvitation(ctx, invitation.ID(), func(_ context.Context, inv *staffinvitation.StaffInvitation) error {
+		return inv.Accept(cmd.Email.String())
+	})
+	if err != nil {
+		span.AddEvent("failed to accept staff invitation")
+		return errorx.Wrap(err, op)
+	}
+	h.accepted.Add(ctx, 1)
+
+	h.logger.InfoContext(ctx, "staff invitation accepted with a student account",
+		slog.String("invitation.id", invitation.ID().String()))
+
+	return nil
+}
+
+// consent returns the consent to the current terms of service, required once
+// a version is published.
+func (h *AcceptInvitationHandler) consent(ctx context.Context, cmd AcceptInvitation) (user.TOSConsent, error) {
+	const op = "cmd.AcceptInvitationHandler.consent"
+	span := trace.SpanFromContext(ctx)
+
+	terms, err := tos.Current(ctx, h.tosVersions, clock.Or(h.clock).Now())
+	if err != nil {
+		span.AddEvent("failed to get current tos version")
+		return user.TOSConsent{}, errorx.Wrap(err, op)
+	}
+	if terms == nil {
+		return user.TOSConsent{}, nil
+	}
+	if !cmd.AcceptTOS {
+		span.AddEvent("terms of service not accepted")
+		return user.TOSConsent{}, errorx.Wrap(validation.Errors{i18nx.FieldAcceptTOS: validation.ErrRequired}, op)
+	}
+	return user.TOSConsent{Version: terms.Version(), IP: cmd.IP}, nil
+}
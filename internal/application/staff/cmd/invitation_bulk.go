@@ -0,0 +1,156 @@
+package cmd
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/staffinvitation"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx"
+)
+
+var tracer = otel.Tracer("ucms/internal/application/staff/cmd")
+
+// BulkCreateInvitations invites the recipients of several specs at once. The
+// recipients of a spec are split into invitations of at most
+// staffinvitation.MaxEmails, all sharing the validity window. Either every
+// invitation is saved or none, within the transaction of the request, see
+// middlewares.Transactional.
+type BulkCreateInvitations struct {
+	CreatorID  user.ID
+	Specs      []InvitationSpec
+	ValidFrom  *time.Time
+	ValidUntil *time.Time
+}
+
+// InvitationSpec is the recipients of a bulk invitation and the department
+// and position they join.
+type InvitationSpec struct {
+	RecipientsEmail []string
+	Department      string
+	Position        string
+}
+
+func (c BulkCreateInvitations) recipientsCount() int {
+	var n int
+	for _, spec := range c.Specs {
+		n += len(spec.RecipientsEmail)
+	}
+	return n
+}
+
+// BulkInvitation is an invitation created for a chunk of the recipients of
+// the spec at index Spec.
+type BulkInvitation struct {
+	ID              staffinvitation.ID
+	Spec            int
+	RecipientsCount int
+}
+
+type BulkCreateInvitationsResult struct {
+	Invitations     []BulkInvitation
+	RecipientsCount int
+}
+
+type BulkCreateInvitationsHandler struct {
+	tracer trace.Tracer
+	logger *slog.Logger
+	repo   StaffInvitationRepo
+	clock  clock.Clock
+}
+
+type BulkCreateInvitationsHandlerArgs struct {
+	Tracer              trace.Tracer
+	Logger              *slog.Logger
+	StaffInvitationRepo StaffInvitationRepo
+	// Clock defaults to clock.Real.
+	Clock clock.Clock
+}
+
+func NewBulkCreateInvitationsHandler(args BulkCreateInvitationsHandlerArgs) *BulkCreateInvitationsHandler {
+	if args.Tracer == nil {
+		args.Tracer = tracer
+	}
+	if args.Logger == nil {
+		args.Logger = logger
+	}
+
+	return &BulkCreateInvitationsHandler{
+		tracer: args.Tracer,
+		logger: args.Logger,
+		repo:   args.StaffInvitationRepo,
+		clock:  clock.Or(args.Clock),
+	}
+}
+
+// Handle builds all the invitations and checks the pending recipients limit
+// before saving the first one, an invalid spec or a limit exceeded creates
+// nothing.
+func (h *BulkCreateInvitationsHandler) Handle(ctx context.Context, cmd BulkCreateInvitations) (*BulkCreateInvitationsResult, error) {
+	const op = "cmd.BulkCreateInvitationsHandler.Handle"
+	ctx, span := h.tracer.Start(ctx, "BulkCreateInvitationsHandler.Handle", trace.WithAttributes(
+		attribute.String("creator_id", cmd.CreatorID.String()),
+		attribute.Int("specs_count", len(cmd.Specs)),
+		attribute.Int("recipients_count", cmd.recipientsCount()),
+	))
+	defer span.End()
+
+	res := &BulkCreateInvitationsResult{}
+	var invitations []*staffinvitation.StaffInvitation
+	for i, spec := range cmd.Specs {
+		for chunk := range slices.Chunk(spec.RecipientsEmail, staffinvitation.MaxEmails) {
+			invitation, err := staffinvitation.NewStaffInvitation(staffinvitation.CreateArgs{
+				RecipientsEmail: chunk,
+				CreatorID:       cmd.CreatorID,
+				ValidFrom:       cmd.ValidFrom,
+				ValidUntil:      cmd.ValidUntil,
+				Department:      spec.Department,
+				Position:        spec.Position,
+				Clock:           h.clock,
+			})
+			if err != nil {
+				otelx.RecordSpanError(span, err, "failed to create new staff invitation")
+				return nil, errorx.Wrap(err, op)
+			}
+			invitations = append(invitations, invitation)
+			res.Invitations = append(res.Invitations, BulkInvitation{
+				ID:              invitation.ID(),
+				Spec:            i,
+				RecipientsCount: len(chunk),
+			})
+			res.RecipientsCount += len(chunk)
+		}
+	}
+
+	pending, err := h.repo.CountPendingRecipients(ctx, cmd.CreatorID, h.clock.Now())
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to count pending recipients")
+		return nil, errorx.Wrap(err, op)
+	}
+	if err := staffinvitation.CheckPendingRecipients(pending, res.RecipientsCount); err != nil {
+		otelx.RecordSpanError(span, err, "pending recipients limit exceeded")
+		return nil, errorx.Wrap(err, op)
+	}
+
+	for _, invitation := range invitations {
+		if err := h.repo.SaveStaffInvitation(ctx, invitation); err != nil {
+			otelx.RecordSpanError(span, err, "failed to save staff invitation")
+			return nil, errorx.Wrap(err, op)
+		}
+	}
+
+	h.logger.InfoContext(ctx, "Staff invitations created in bulk",
+		"creator_id", cmd.CreatorID.String(),
+		"invitations_count", len(res.Invitations),
+		"recipients_count", res.RecipientsCount,
+	)
+	return res, nil
+}
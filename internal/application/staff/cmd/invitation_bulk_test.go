@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/staffinvitation"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/builders"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/fixtures"
+	"gitlab.com/ucmsv2/ucms-backend/tests/mocks"
+)
+
+func bulkRecipients(prefix string, n int) []string {
+	emails := make([]string, n)
+	for i := range emails {
+		emails[i] = fmt.Sprintf("%s%03d@test.com", prefix, i)
+	}
+	return emails
+}
+
+func TestBulkCreateInvitationsHandler_ChunksRecipients(t *testing.T) {
+	clk := clock.NewFake(time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC))
+	repo := mocks.NewStaffInvitationRepo()
+	h := NewBulkCreateInvitationsHandler(BulkCreateInvitationsHandlerArgs{StaffInvitationRepo: repo, Clock: clk})
+	validUntil := clk.Now().Add(7 * 24 * time.Hour)
+
+	res, err := h.Handle(t.Context(), BulkCreateInvitations{
+		CreatorID: fixtures.TestStaff.ID,
+		Specs: []InvitationSpec{
+			{RecipientsEmail: bulkRecipients("math", 230), Department: "Mathematics", Position: "Lecturer"},
+			{RecipientsEmail: bulkRecipients("lab", 3), Department: "Physics"},
+		},
+		ValidUntil: &validUntil,
+	})
+	require.NoError(t, err)
+
+	// 230 recipients are 9 full invitations and one of 5.
+	require.Len(t, res.Invitations, 11)
+	assert.Equal(t, 233, res.RecipientsCount)
+	for i, inv := range res.Invitations[:9] {
+		assert.Equal(t, BulkInvitation{ID: inv.ID, Spec: 0, RecipientsCount: staffinvitation.MaxEmails}, inv, "chunk %d", i)
+	}
+	assert.Equal(t, 0, res.Invitations[9].Spec)
+	assert.Equal(t, 5, res.Invitations[9].RecipientsCount)
+	assert.Equal(t, 1, res.Invitations[10].Spec)
+	assert.Equal(t, 3, res.Invitations[10].RecipientsCount)
+
+	var saved []string
+	for _, inv := range res.Invitations {
+		invitation, err := repo.GetStaffInvitationByID(t.Context(), inv.ID)
+		require.NoError(t, err)
+		staffinvitation.NewAssertion(t, invitation).
+			AssertCreatorID(fixtures.TestStaff.ID).
+			AssertValidUntil(&validUntil)
+		saved = append(saved, invitation.RecipientsEmail()...)
+	}
+	assert.ElementsMatch(t, append(bulkRecipients("math", 230), bulkRecipients("lab", 3)...), saved)
+	assert.Len(t, repo.Events(), 11, "every invitation is mailed through its created event")
+}
+
+func TestBulkCreateInvitationsHandler_CreatesNothingOnFailure(t *testing.T) {
+	clk := clock.NewFake(time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC))
+
+	t.Run("pending recipients limit", func(t *testing.T) {
+		repo := mocks.NewStaffInvitationRepo()
+		repo.SeedStaffInvitation(t, builders.NewStaffInvitationBuilder().
+			WithCreatorID(fixtures.TestStaff.ID).
+			WithRecipientsEmail(bulkRecipients("pending", staffinvitation.MaxPendingRecipients-10)).
+			Build())
+		expired := clk.Now().Add(-time.Hour)
+		repo.SeedStaffInvitation(t, builders.NewStaffInvitationBuilder().
+			WithCreatorID(fixtures.TestStaff.ID).
+			WithRecipientsEmail(bulkRecipients("expired", 100)).
+			WithValidUntil(&expired).
+			Build())
+		h := NewBulkCreateInvitationsHandler(BulkCreateInvitationsHandlerArgs{StaffInvitationRepo: repo, Clock: clk})
+
+		_, err := h.Handle(t.Context(), BulkCreateInvitations{
+			CreatorID: fixtures.TestStaff.ID,
+			Specs:     []InvitationSpec{{RecipientsEmail: bulkRecipients("new", 11)}},
+		})
+		require.ErrorIs(t, err, staffinvitation.ErrPendingRecipientsExceeded)
+		assert.Len(t, repo.StaffInvitationsByCreatorID(fixtures.TestStaff.ID), 2)
+
+		res, err := h.Handle(t.Context(), BulkCreateInvitations{
+			CreatorID: fixtures.TestStaff.ID,
+			Specs:     []InvitationSpec{{RecipientsEmail: bulkRecipients("new", 10)}},
+		})
+		require.NoError(t, err, "expired invitations do not count")
+		assert.Equal(t, 10, res.RecipientsCount)
+	})
+
+	t.Run("invalid spec", func(t *testing.T) {
+		repo := mocks.NewStaffInvitationRepo()
+		h := NewBulkCreateInvitationsHandler(BulkCreateInvitationsHandlerArgs{StaffInvitationRepo: repo, Clock: clk})
+
+		_, err := h.Handle(t.Context(), BulkCreateInvitations{
+			CreatorID: fixtures.TestStaff.ID,
+			Specs: []InvitationSpec{
+				{RecipientsEmail: bulkRecipients("ok", 30)},
+				{RecipientsEmail: []string{"not-an-email"}},
+			},
+		})
+		require.Error(t, err)
+		assert.Empty(t, repo.StaffInvitationsByCreatorID(fixtures.TestStaff.ID))
+		assert.Empty(t, repo.Events())
+	})
+}
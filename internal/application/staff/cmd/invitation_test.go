@@ -0,0 +1,229 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/event"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/staffinvitation"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/emails"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/roles"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx/metrics"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/builders"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/fixtures"
+	"gitlab.com/ucmsv2/ucms-backend/tests/mocks"
+)
+
+func acceptedCount(t *testing.T, reader *sdkmetric.ManualReader) int64 {
+	t.Helper()
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(t.Context(), &rm))
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name == metrics.StaffInvitationAccepted {
+				var total int64
+				for _, dp := range m.Data.(metricdata.Sum[int64]).DataPoints {
+					total += dp.Value
+				}
+				return total
+			}
+		}
+	}
+	return 0
+}
+
+func TestAcceptInvitationHandler_CountsAcceptedInvitations(t *testing.T) {
+	invitation := builders.NewStaffInvitationBuilder().Build()
+	invitationRepo := mocks.NewStaffInvitationRepo()
+	invitationRepo.SeedStaffInvitation(t, invitation)
+	staffRepo := mocks.NewStaffRepo()
+	reader := sdkmetric.NewManualReader()
+	h := NewAcceptInvitationHandler(AcceptInvitationHandlerArgs{
+		StaffInvitationRepo: invitationRepo,
+		StaffRepo:           staffRepo,
+		Metrics:             metrics.NewRegistry(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)).Meter("test")),
+	})
+
+	cmd := AcceptInvitation{
+		InvitationCode: invitation.Code(),
+		Email:          emails.Email(fixtures.TestStaff2.Email),
+		Barcode:        fixtures.TestStaff2.Barcode,
+		Username:       fixtures.TestStaff2.Username,
+		Password:       fixtures.TestStaff2.Password,
+		FirstName:      fixtures.TestStaff2.FirstName,
+		LastName:       fixtures.TestStaff2.LastName,
+	}
+	require.NoError(t, h.Handle(t.Context(), cmd))
+	staffRepo.RequireStaffByEmail(t, fixtures.TestStaff2.Email)
+	assert.Equal(t, int64(1), acceptedCount(t, reader))
+	invitationRepo.RequireStaffInvitationByID(t, invitation.ID()).AssertRecipient(fixtures.TestStaff2.Email, false)
+
+	err := h.Handle(t.Context(), cmd)
+	require.ErrorIs(t, err, staffinvitation.ErrInvalidInvitation)
+	assert.Equal(t, int64(1), acceptedCount(t, reader), "failed commands should not be counted")
+}
+
+func TestAcceptInvitationHandler_LinkExistingAccount(t *testing.T) {
+	setup := func(t *testing.T) (*AcceptInvitationHandler, *mocks.StaffInvitationRepo, *mocks.StaffRepo, *staffinvitation.StaffInvitation) {
+		invitation := builders.NewStaffInvitationBuilder().Build()
+		invitationRepo := mocks.NewStaffInvitationRepo()
+		invitationRepo.SeedStaffInvitation(t, invitation)
+		staffRepo := mocks.NewStaffRepo()
+		h := NewAcceptInvitationHandler(AcceptInvitationHandlerArgs{
+			StaffInvitationRepo: invitationRepo,
+			StaffRepo:           staffRepo,
+		})
+		return h, invitationRepo, staffRepo, invitation
+	}
+	link := func(invitation *staffinvitation.StaffInvitation, password string) AcceptInvitation {
+		return AcceptInvitation{
+			InvitationCode:      invitation.Code(),
+			Email:               emails.Email(fixtures.TestStaff2.Email),
+			Password:            password,
+			LinkExistingAccount: true,
+		}
+	}
+
+	t.Run("links the student account", func(t *testing.T) {
+		h, invitationRepo, staffRepo, invitation := setup(t)
+		student := builders.NewUserBuilder().AsStudent().WithEmail(fixtures.TestStaff2.Email).Build()
+		staffRepo.SeedUser(t, student)
+
+		require.NoError(t, h.Handle(t.Context(), link(invitation, fixtures.TestStudent.Password)))
+
+		staff, err := staffRepo.GetStaffByEmail(t.Context(), emails.Email(fixtures.TestStaff2.Email))
+		require.NoError(t, err)
+		assert.Equal(t, student.ID(), staff.User().ID())
+		assert.Equal(t, student.Barcode(), staff.User().Barcode())
+		assert.Equal(t, roles.NewSet(roles.Student, roles.Staff), staff.User().Roles())
+		invitationRepo.RequireStaffInvitationByID(t, invitation.ID()).AssertRecipient(fixtures.TestStaff2.Email, false)
+		event.AssertEvents(t, staffRepo.Events(),
+			event.OfType[*user.StaffInvitationAccepted](),
+			event.OfType(func(e *user.RoleGranted) bool { return e.UserID == student.ID() && e.Role == roles.Staff }),
+		)
+	})
+
+	t.Run("wrong password", func(t *testing.T) {
+		h, invitationRepo, staffRepo, invitation := setup(t)
+		staffRepo.SeedUser(t, builders.NewUserBuilder().AsStudent().WithEmail(fixtures.TestStaff2.Email).Build())
+
+		err := h.Handle(t.Context(), link(invitation, "wrong"+fixtures.TestStudent.Password))
+		assert.True(t, errorx.IsCode(err, errorx.CodeInvalidCredentials), "unexpected error: %v", err)
+		assert.Zero(t, staffRepo.Count())
+		invitationRepo.RequireStaffInvitationByID(t, invitation.ID()).AssertRecipient(fixtures.TestStaff2.Email, true)
+	})
+
+	t.Run("no account", func(t *testing.T) {
+		h, _, staffRepo, invitation := setup(t)
+
+		err := h.Handle(t.Context(), link(invitation, fixtures.TestStudent.Password))
+		assert.True(t, errorx.IsCode(err, errorx.CodeInvalidCredentials), "unexpected error: %v", err)
+		assert.Zero(t, staffRepo.Count())
+	})
+}
+
+func TestCreateInvitationHandler_SavesInvitation(t *testing.T) {
+	clk := clock.NewFake(time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC))
+	repo := mocks.NewStaffInvitationRepo()
+	h := NewCreateInvitationHandler(CreateInvitationHandlerArgs{
+		StaffInvitationRepo: repo,
+		Clock:               clk,
+	})
+
+	recipients := []string{fixtures.TestStaff2.Email}
+	err := h.Handle(t.Context(), CreateInvitation{
+		CreatorID:       fixtures.TestStaff.ID,
+		RecipientsEmail: recipients,
+	})
+	require.NoError(t, err)
+
+	invitation, err := repo.GetLatestStaffInvitationByCreatorID(t.Context(), fixtures.TestStaff.ID)
+	require.NoError(t, err)
+	staffinvitation.NewAssertion(t, invitation).
+		AssertCodeNotEmpty().
+		AssertRecipientsEmail(recipients).
+		AssertCreatedAt(clk.Now()).
+		AssertDeleted(false)
+	require.Len(t, repo.Events(), 1)
+	assert.IsType(t, &staffinvitation.Created{}, repo.Events()[0])
+}
+
+func TestUpdateInvitationRecipientsHandler(t *testing.T) {
+	invitation := builders.NewStaffInvitationBuilder().Build()
+	repo := mocks.NewStaffInvitationRepo()
+	repo.SeedStaffInvitation(t, invitation)
+	h := NewUpdateInvitationRecipientsHandler(UpdateInvitationRecipientsHandlerArgs{StaffInvitationRepo: repo})
+
+	err := h.Handle(t.Context(), UpdateInvitationRecipients{
+		CreatorID:       fixtures.TestStaff2.ID,
+		InvitationID:    invitation.ID(),
+		RecipientsEmail: []string{"other@test.com"},
+	})
+	require.ErrorIs(t, err, staffinvitation.ErrForbidden)
+	repo.RequireStaffInvitationByID(t, invitation.ID()).AssertRecipientsEmail(invitation.RecipientsEmail())
+
+	recipients := []string{fixtures.TestStaff2.Email, "other@test.com"}
+	err = h.Handle(t.Context(), UpdateInvitationRecipients{
+		CreatorID:       invitation.CreatorID(),
+		InvitationID:    invitation.ID(),
+		RecipientsEmail: recipients,
+	})
+	require.NoError(t, err)
+	repo.RequireStaffInvitationByID(t, invitation.ID()).AssertRecipientsEmail(recipients)
+}
+
+func TestDeleteInvitationHandler_InvalidatesInvitation(t *testing.T) {
+	invitation := builders.NewStaffInvitationBuilder().Build()
+	repo := mocks.NewStaffInvitationRepo()
+	repo.SeedStaffInvitation(t, invitation)
+	deleteHandler := NewDeleteInvitationHandler(DeleteInvitationHandlerArgs{StaffInvitationRepo: repo})
+	validateHandler := NewValidateInvitationHandler(ValidateInvitationHandlerArgs{StaffInvitationRepo: repo})
+
+	validate := ValidateInvitation{InvitationCode: invitation.Code(), Email: fixtures.TestStaff2.Email}
+	require.NoError(t, validateHandler.Handle(t.Context(), validate))
+
+	err := deleteHandler.Handle(t.Context(), DeleteInvitation{
+		CreatorID:    invitation.CreatorID(),
+		InvitationID: invitation.ID(),
+	})
+	require.NoError(t, err)
+
+	repo.RequireStaffInvitationByID(t, invitation.ID()).AssertDeleted(true)
+	err = validateHandler.Handle(t.Context(), validate)
+	require.ErrorIs(t, err, staffinvitation.ErrNotFoundOrDeleted)
+}
+
+func TestRevokeInvitationHandler(t *testing.T) {
+	t.Run("invitation of another staff", func(t *testing.T) {
+		invitation := builders.NewStaffInvitationBuilder().WithCreatorID(fixtures.TestStaff2.ID).Build()
+		repo := mocks.NewStaffInvitationRepo()
+		repo.SeedStaffInvitation(t, invitation)
+		h := NewRevokeInvitationHandler(RevokeInvitationHandlerArgs{StaffInvitationRepo: repo})
+
+		require.NoError(t, h.Handle(t.Context(), RevokeInvitation{InvitationID: invitation.ID()}))
+
+		repo.RequireStaffInvitationByID(t, invitation.ID()).AssertDeleted(true)
+	})
+
+	t.Run("unknown invitation", func(t *testing.T) {
+		h := NewRevokeInvitationHandler(RevokeInvitationHandlerArgs{StaffInvitationRepo: mocks.NewStaffInvitationRepo()})
+
+		err := h.Handle(t.Context(), RevokeInvitation{InvitationID: staffinvitation.NewID()})
+		assert.True(t, errorx.IsNotFound(err), "expected not found, got %v", err)
+	})
+}
+
+func TestValidateInvitationHandler_UnknownCode(t *testing.T) {
+	h := NewValidateInvitationHandler(ValidateInvitationHandlerArgs{StaffInvitationRepo: mocks.NewStaffInvitationRepo()})
+
+	err := h.Handle(t.Context(), ValidateInvitation{InvitationCode: "unknown", Email: fixtures.TestStaff2.Email})
+	assert.True(t, errorx.IsNotFound(err), "expected not found, got %v", err)
+}
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"context"
+	"log/slog"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/optional"
+)
+
+type StaffUpdater interface {
+	UpdateStaff(ctx context.Context, id user.ID, fn func(context.Context, *user.Staff) error) error
+}
+
+// UpdateProfile changes the department and the position of a staff member.
+// A field that is not set is kept, a null or empty one is cleared.
+type UpdateProfile struct {
+	StaffID    user.ID
+	Department optional.Field[string]
+	Position   optional.Field[string]
+}
+
+func (c UpdateProfile) SpanAttrs() map[string]any {
+	return map[string]any{
+		"staff_id":           c.StaffID.String(),
+		"department_changed": c.Department.Set,
+		"position_changed":   c.Position.Set,
+	}
+}
+
+type UpdateProfileHandler struct {
+	logger *slog.Logger
+	repo   StaffUpdater
+}
+
+type UpdateProfileHandlerArgs struct {
+	Logger    *slog.Logger
+	StaffRepo StaffUpdater
+}
+
+func NewUpdateProfileHandler(args UpdateProfileHandlerArgs) *UpdateProfileHandler {
+	h := &UpdateProfileHandler{
+		logger: args.Logger,
+		repo:   args.StaffRepo,
+	}
+
+	if h.logger == nil {
+		h.logger = logger
+	}
+
+	return h
+}
+
+func (h *UpdateProfileHandler) Handle(ctx context.Context, cmd UpdateProfile) error {
+	const op = "cmd.UpdateProfileHandler.Handle"
+	span := trace.SpanFromContext(ctx)
+	if !cmd.Department.Set && !cmd.Position.Set {
+		span.AddEvent("empty patch, nothing to update")
+		return nil
+	}
+
+	err := h.repo.UpdateStaff(ctx, cmd.StaffID, func(ctx context.Context, staff *user.Staff) error {
+		return staff.UpdateDepartmentAndPosition(
+			cmd.Department.Apply(staff.Department()),
+			cmd.Position.Apply(staff.Position()),
+		)
+	})
+	if err != nil {
+		span.AddEvent("failed to update staff profile")
+		return errorx.Wrap(err, op)
+	}
+
+	return nil
+}
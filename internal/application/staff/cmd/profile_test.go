@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/optional"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/builders"
+	"gitlab.com/ucmsv2/ucms-backend/tests/mocks"
+)
+
+func TestUpdateProfileHandler(t *testing.T) {
+	repo := mocks.NewStaffRepo()
+	staff := builders.NewStaffBuilder().Build()
+	repo.SeedStaff(t, staff)
+	h := NewUpdateProfileHandler(UpdateProfileHandlerArgs{StaffRepo: repo})
+
+	department, position := "Registrar's Office", "Coordinator"
+	require.NoError(t, h.Handle(t.Context(), UpdateProfile{
+		StaffID:    staff.User().ID(),
+		Department: optional.SetTo(department),
+		Position:   optional.SetTo(position),
+	}))
+
+	newPosition := "Head"
+	require.NoError(t, h.Handle(t.Context(), UpdateProfile{StaffID: staff.User().ID(), Position: optional.SetTo(newPosition)}))
+
+	got, err := repo.GetStaffByID(t.Context(), staff.User().ID())
+	require.NoError(t, err)
+	assert.Equal(t, department, got.Department(), "a department left out is kept")
+	assert.Equal(t, newPosition, got.Position())
+
+	require.NoError(t, h.Handle(t.Context(), UpdateProfile{StaffID: staff.User().ID(), Department: optional.Clear[string]()}))
+
+	got, err = repo.GetStaffByID(t.Context(), staff.User().ID())
+	require.NoError(t, err)
+	assert.Empty(t, got.Department(), "a null department is cleared")
+	assert.Equal(t, newPosition, got.Position())
+}
+
+func TestUpdateProfileHandler_NoOpPatch_RecordsNoEvent(t *testing.T) {
+	repo := mocks.NewStaffRepo()
+	staff := builders.NewStaffBuilder().Build()
+	repo.SeedStaff(t, staff)
+	h := NewUpdateProfileHandler(UpdateProfileHandlerArgs{StaffRepo: repo})
+
+	department := "Registrar's Office"
+	require.NoError(t, h.Handle(t.Context(), UpdateProfile{StaffID: staff.User().ID(), Department: optional.SetTo(department)}))
+	repo.AssertEventCount(t, 1)
+
+	require.NoError(t, h.Handle(t.Context(), UpdateProfile{StaffID: staff.User().ID()}))
+	require.NoError(t, h.Handle(t.Context(), UpdateProfile{StaffID: staff.User().ID(), Department: optional.SetTo(department)}))
+	repo.AssertEventCount(t, 1)
+}
+
+func TestUpdateProfileHandler_UnknownStaff(t *testing.T) {
+	h := NewUpdateProfileHandler(UpdateProfileHandlerArgs{StaffRepo: mocks.NewStaffRepo()})
+
+	err := h.Handle(t.Context(), UpdateProfile{
+		StaffID:    builders.NewStaffBuilder().Build().User().ID(),
+		Department: optional.SetTo("Registrar's Office"),
+	})
+	var i18nErr *errorx.I18nError
+	require.ErrorAs(t, err, &i18nErr)
+	assert.Equal(t, errorx.CodeNotFound, i18nErr.Code)
+}
@@ -0,0 +1,136 @@
+package staffquery
+
+import (
+	"context"
+	"log/slog"
+
+	"go.opentelemetry.io/contrib/bridges/otelslog"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/httpx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx"
+)
+
+var (
+	tracer = otel.Tracer("ucms/internal/application/staff/query")
+	logger = otelslog.NewLogger("ucms/internal/application/staff/query")
+)
+
+type StaffGetter interface {
+	GetStaffByID(ctx context.Context, id user.ID) (*user.Staff, error)
+}
+
+// UnreadCounter counts the unread notifications of a user.
+type UnreadCounter interface {
+	CountUnreadNotifications(ctx context.Context, userID user.ID) (int, error)
+}
+
+type GetStaff struct {
+	ID user.ID `json:"id"`
+}
+
+type GetStaffResponse struct {
+	ID           string     `json:"id"`
+	Barcode      string     `json:"barcode"`
+	Username     string     `json:"username"`
+	AvatarURL    string     `json:"avatar_url"`
+	Email        string     `json:"email"`
+	FirstName    string     `json:"first_name"`
+	LastName     string     `json:"last_name"`
+	Role         string     `json:"role"`
+	Department   string     `json:"department"`
+	Position     string     `json:"position"`
+	RegisteredAt httpx.Time `json:"registered_at"`
+	// LastSeenAt is the last recorded request of the staff member, up to
+	// lastseen.MinInterval old, null until the first one is recorded.
+	LastSeenAt *httpx.Time `json:"last_seen_at"`
+	// UnreadNotifications is the number on the bell of the client.
+	UnreadNotifications int `json:"unread_notifications"`
+}
+
+type GetStaffHandler struct {
+	tracer     trace.Tracer
+	logger     *slog.Logger
+	staff      StaffGetter
+	avatarURLs *user.AvatarURLBuilder
+	unread     UnreadCounter
+}
+
+type GetStaffHandlerArgs struct {
+	Tracer     trace.Tracer
+	Logger     *slog.Logger
+	Staff      StaffGetter
+	AvatarURLs *user.AvatarURLBuilder
+	// UnreadCounter is optional, without it the unread count is 0.
+	UnreadCounter UnreadCounter
+}
+
+func NewGetStaffHandler(args GetStaffHandlerArgs) *GetStaffHandler {
+	if args.Tracer == nil {
+		args.Tracer = tracer
+	}
+	if args.Logger == nil {
+		args.Logger = logger
+	}
+
+	return &GetStaffHandler{
+		tracer:     args.Tracer,
+		logger:     args.Logger,
+		staff:      args.Staff,
+		avatarURLs: args.AvatarURLs,
+		unread:     args.UnreadCounter,
+	}
+}
+
+func (h *GetStaffHandler) Handle(ctx context.Context, query GetStaff) (*GetStaffResponse, error) {
+	const op = "staffquery.GetStaffHandler.Handle"
+	ctx, span := h.tracer.Start(ctx, "GetStaffHandler.Handle",
+		trace.WithAttributes(attribute.String("staff.id", query.ID.String())),
+	)
+	defer span.End()
+
+	staff, err := h.staff.GetStaffByID(ctx, query.ID)
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to get staff by id")
+		return nil, errorx.Wrap(err, op)
+	}
+
+	u := staff.User()
+	res := GetStaffResponse{
+		ID:           u.ID().String(),
+		Barcode:      u.Barcode().String(),
+		Username:     u.Username(),
+		Email:        u.Email().String(),
+		FirstName:    u.FirstName(),
+		LastName:     u.LastName(),
+		Role:         u.Role().String(),
+		Department:   staff.Department(),
+		Position:     staff.Position(),
+		RegisteredAt: httpx.NewTime(u.CreatedAt()),
+	}
+	if seen := u.LastSeenAt(); !seen.IsZero() {
+		t := httpx.NewTime(seen)
+		res.LastSeenAt = &t
+	}
+	if h.avatarURLs != nil {
+		res.AvatarURL, err = h.avatarURLs.UserAvatarURL(ctx, u.ID(), u.Avatar())
+		if err != nil {
+			otelx.RecordSpanError(span, err, "failed to build avatar url")
+			return nil, errorx.Wrap(err, op)
+		}
+	}
+
+	if h.unread != nil {
+		res.UnreadNotifications, err = h.unread.CountUnreadNotifications(ctx, u.ID())
+		if err != nil {
+			otelx.RecordSpanError(span, err, "failed to count unread notifications")
+			return nil, errorx.Wrap(err, op)
+		}
+	}
+
+	return &res, nil
+}
@@ -0,0 +1,80 @@
+// Package stats reports business gauges backed by repository count queries.
+package stats
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/roles"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx/metrics"
+)
+
+type UserCounter interface {
+	CountUsersByRole(ctx context.Context) (map[roles.Global]int64, error)
+}
+
+type RegistrationCounter interface {
+	CountPendingRegistrations(ctx context.Context) (int64, error)
+}
+
+type GaugesArgs struct {
+	// Metrics defaults to metrics.Default().
+	Metrics       *metrics.Registry
+	Users         UserCounter
+	Registrations RegistrationCounter
+	// CacheTTL is how long counts are reused between collections, defaults
+	// to metrics.DefaultCacheTTL.
+	CacheTTL time.Duration
+}
+
+// RegisterGauges registers the users by role and pending registrations
+// gauges. Counts are cached, so collections hit the database at most once per
+// CacheTTL.
+func RegisterGauges(args GaugesArgs) error {
+	const op = "stats.RegisterGauges"
+	if args.Metrics == nil {
+		args.Metrics = metrics.Default()
+	}
+
+	users := metrics.NewCached(args.CacheTTL, args.Users.CountUsersByRole)
+	err := args.Metrics.RegisterInt64Gauge(metrics.UsersByRole,
+		func(ctx context.Context, o metric.Int64Observer) error {
+			counts, err := users.Get(ctx)
+			if err != nil {
+				return err
+			}
+			for role, count := range counts {
+				o.Observe(count, metric.WithAttributes(attribute.String(metrics.AttrRole, role.String())))
+			}
+			return nil
+		},
+		metric.WithDescription("Number of users by global role"),
+		metric.WithUnit("{user}"),
+	)
+	if err != nil {
+		return errorx.Wrap(err, op)
+	}
+
+	pending := metrics.NewCached(args.CacheTTL, args.Registrations.CountPendingRegistrations)
+	err = args.Metrics.RegisterInt64Gauge(metrics.RegistrationsPending,
+		func(ctx context.Context, o metric.Int64Observer) error {
+			count, err := pending.Get(ctx)
+			if err != nil {
+				return err
+			}
+			o.Observe(count)
+			return nil
+		},
+		metric.WithDescription("Number of started but not completed registrations"),
+		metric.WithUnit("{registration}"),
+	)
+	if err != nil {
+		return errorx.Wrap(err, op)
+	}
+
+	return nil
+}
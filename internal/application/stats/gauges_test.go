@@ -0,0 +1,100 @@
+package stats
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/registration"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/roles"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx/metrics"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/builders"
+	"gitlab.com/ucmsv2/ucms-backend/tests/mocks"
+)
+
+type countingRegistrations struct {
+	*mocks.RegistrationRepo
+	calls int
+}
+
+func (r *countingRegistrations) CountPendingRegistrations(ctx context.Context) (int64, error) {
+	r.calls++
+	return r.RegistrationRepo.CountPendingRegistrations(ctx)
+}
+
+func collectGauges(t *testing.T, reader *sdkmetric.ManualReader) map[string]metricdata.Gauge[int64] {
+	t.Helper()
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	gauges := make(map[string]metricdata.Gauge[int64])
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if g, ok := m.Data.(metricdata.Gauge[int64]); ok {
+				gauges[m.Name] = g
+			}
+		}
+	}
+	return gauges
+}
+
+func TestRegisterGauges(t *testing.T) {
+	users := mocks.NewUserRepo()
+	seed := map[roles.Global]int{roles.Student: 3, roles.Staff: 2, roles.AITUSA: 1}
+	n := 0
+	for role, count := range seed {
+		for range count {
+			n++
+			users.SeedUser(t, builders.NewUserBuilder().
+				WithBarcode(user.Barcode(fmt.Sprintf("900%03d", n))).
+				WithRole(role).
+				Build())
+		}
+	}
+
+	registrations := &countingRegistrations{RegistrationRepo: mocks.NewRegistrationRepo()}
+	for i, status := range []registration.Status{
+		registration.StatusPending,
+		registration.StatusPending,
+		registration.StatusVerified,
+		registration.StatusCompleted,
+		registration.StatusExpired,
+	} {
+		registrations.SeedRegistration(t, builders.NewRegistrationBuilder().
+			WithEmail(fmt.Sprintf("reg%d@example.com", i)).
+			WithStatus(status).
+			Build())
+	}
+
+	reader := sdkmetric.NewManualReader()
+	reg := metrics.NewRegistry(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)).Meter("test"))
+	require.NoError(t, RegisterGauges(GaugesArgs{
+		Metrics:       reg,
+		Users:         users,
+		Registrations: registrations,
+	}))
+
+	gauges := collectGauges(t, reader)
+
+	byRole := make(map[string]int64)
+	for _, dp := range gauges[metrics.UsersByRole].DataPoints {
+		role, _ := dp.Attributes.Value(metrics.AttrRole)
+		byRole[role.AsString()] = dp.Value
+	}
+	assert.Equal(t, map[string]int64{"student": 3, "staff": 2, "aitusa": 1}, byRole)
+
+	pending := gauges[metrics.RegistrationsPending].DataPoints
+	require.Len(t, pending, 1)
+	assert.Equal(t, int64(3), pending[0].Value, "pending and verified registrations are pending")
+
+	// Counts are cached, collecting again does not hit the repository.
+	registrations.SeedRegistration(t, builders.NewRegistrationBuilder().WithEmail("late@example.com").Build())
+	gauges = collectGauges(t, reader)
+	assert.Equal(t, int64(3), gauges[metrics.RegistrationsPending].DataPoints[0].Value)
+	assert.Equal(t, 1, registrations.calls)
+}
@@ -0,0 +1,87 @@
+package statusapp
+
+import (
+	"context"
+	"log/slog"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/application/status/cmd"
+	"gitlab.com/ucmsv2/ucms-backend/internal/application/status/statusquery"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx"
+)
+
+type App struct {
+	Command Command
+	Query   Query
+}
+
+type Command struct {
+	CreateIncident otelx.Handler[cmd.CreateIncident]
+	UpdateIncident otelx.Handler[cmd.UpdateIncident]
+}
+
+type Query struct {
+	Status *statusquery.StatusHandler
+}
+
+type IncidentRepo interface {
+	cmd.IncidentRepo
+	statusquery.IncidentLister
+}
+
+type Args struct {
+	Tracer       trace.Tracer
+	Logger       *slog.Logger
+	IncidentRepo IncidentRepo
+	// Health are the components of the page, see healthx.Monitor.
+	Health statusquery.Components
+	// Clock defaults to clock.Real.
+	Clock clock.Clock
+}
+
+func NewApp(args Args) *App {
+	status := statusquery.NewStatusHandler(statusquery.StatusHandlerArgs{
+		Tracer:     args.Tracer,
+		Logger:     args.Logger,
+		Incidents:  args.IncidentRepo,
+		Components: args.Health,
+		Clock:      args.Clock,
+	})
+
+	return &App{
+		Command: Command{
+			CreateIncident: otelx.InstrumentCommand[cmd.CreateIncident](
+				"CreateIncidentHandler.Handle",
+				invalidates(status, cmd.NewCreateIncidentHandler(cmd.CreateIncidentHandlerArgs{
+					Logger:       args.Logger,
+					IncidentRepo: args.IncidentRepo,
+					Clock:        args.Clock,
+				})),
+			),
+			UpdateIncident: otelx.InstrumentCommand[cmd.UpdateIncident](
+				"UpdateIncidentHandler.Handle",
+				invalidates(status, cmd.NewUpdateIncidentHandler(cmd.UpdateIncidentHandlerArgs{
+					Logger:       args.Logger,
+					IncidentRepo: args.IncidentRepo,
+				})),
+			),
+		},
+		Query: Query{
+			Status: status,
+		},
+	}
+}
+
+// invalidates drops the cached incidents of the status once h succeeds, so
+// the staff see their change on the page right away.
+func invalidates[T any](status *statusquery.StatusHandler, h otelx.Handler[T]) otelx.Handler[T] {
+	return otelx.HandlerFunc[T](func(ctx context.Context, c T) error {
+		if err := h.Handle(ctx, c); err != nil {
+			return err
+		}
+		status.Invalidate()
+		return nil
+	})
+}
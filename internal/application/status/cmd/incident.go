@@ -0,0 +1,178 @@
+package cmd
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"go.opentelemetry.io/contrib/bridges/otelslog"
+	"go.opentelemetry.io/otel/trace"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/incident"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+)
+
+var logger = otelslog.NewLogger("ucms/internal/application/status/cmd")
+
+type IncidentRepo interface {
+	SaveIncident(ctx context.Context, i *incident.Incident) error
+	UpdateIncident(ctx context.Context, id incident.ID, fn func(context.Context, *incident.Incident) error) error
+}
+
+// CreateIncident reports an incident on the status page.
+type CreateIncident struct {
+	// ID is generated by the caller, so it can answer with it.
+	ID       incident.ID
+	StaffID  user.ID
+	Title    string
+	Severity incident.Severity
+	// StartedAt defaults to now.
+	StartedAt time.Time
+	// ResolvedAt reports an incident already over.
+	ResolvedAt *time.Time
+	Note       string
+}
+
+func (c CreateIncident) SpanAttrs() map[string]any {
+	return map[string]any{
+		"staff_id":          c.StaffID.String(),
+		"incident.id":       c.ID.String(),
+		"incident.severity": string(c.Severity),
+	}
+}
+
+type CreateIncidentHandler struct {
+	logger *slog.Logger
+	repo   IncidentRepo
+	clock  clock.Clock
+}
+
+type CreateIncidentHandlerArgs struct {
+	Logger       *slog.Logger
+	IncidentRepo IncidentRepo
+	// Clock defaults to clock.Real.
+	Clock clock.Clock
+}
+
+func NewCreateIncidentHandler(args CreateIncidentHandlerArgs) *CreateIncidentHandler {
+	h := &CreateIncidentHandler{
+		logger: args.Logger,
+		repo:   args.IncidentRepo,
+		clock:  args.Clock,
+	}
+
+	if h.logger == nil {
+		h.logger = logger
+	}
+
+	return h
+}
+
+func (h *CreateIncidentHandler) Handle(ctx context.Context, cmd CreateIncident) error {
+	const op = "cmd.CreateIncidentHandler.Handle"
+	span := trace.SpanFromContext(ctx)
+
+	i, err := incident.New(incident.CreateArgs{
+		ID:         cmd.ID,
+		CreatedBy:  cmd.StaffID,
+		Title:      cmd.Title,
+		Severity:   cmd.Severity,
+		StartedAt:  cmd.StartedAt,
+		ResolvedAt: cmd.ResolvedAt,
+		Note:       cmd.Note,
+		Clock:      h.clock,
+	})
+	if err != nil {
+		span.AddEvent("invalid incident")
+		return errorx.Wrap(err, op)
+	}
+
+	if err := h.repo.SaveIncident(ctx, i); err != nil {
+		span.AddEvent("failed to save incident")
+		return errorx.Wrap(err, op)
+	}
+
+	h.logger.InfoContext(ctx, "incident reported",
+		slog.String("incident.id", i.ID().String()),
+		slog.String("incident.severity", string(i.Severity())),
+		slog.String("staff_id", cmd.StaffID.String()))
+
+	return nil
+}
+
+// UpdateIncident replaces the fields of an incident and posts its note, a
+// ResolvedAt resolves it and a nil one reopens it.
+type UpdateIncident struct {
+	StaffID  user.ID
+	ID       incident.ID
+	Title    string
+	Severity incident.Severity
+	// StartedAt keeps the current start when zero.
+	StartedAt  time.Time
+	ResolvedAt *time.Time
+	Note       string
+}
+
+func (c UpdateIncident) SpanAttrs() map[string]any {
+	return map[string]any{
+		"staff_id":          c.StaffID.String(),
+		"incident.id":       c.ID.String(),
+		"incident.severity": string(c.Severity),
+		"incident.resolved": c.ResolvedAt != nil,
+	}
+}
+
+type UpdateIncidentHandler struct {
+	logger *slog.Logger
+	repo   IncidentRepo
+}
+
+type UpdateIncidentHandlerArgs struct {
+	Logger       *slog.Logger
+	IncidentRepo IncidentRepo
+}
+
+func NewUpdateIncidentHandler(args UpdateIncidentHandlerArgs) *UpdateIncidentHandler {
+	h := &UpdateIncidentHandler{
+		logger: args.Logger,
+		repo:   args.IncidentRepo,
+	}
+
+	if h.logger == nil {
+		h.logger = logger
+	}
+
+	return h
+}
+
+func (h *UpdateIncidentHandler) Handle(ctx context.Context, cmd UpdateIncident) error {
+	const op = "cmd.UpdateIncidentHandler.Handle"
+	span := trace.SpanFromContext(ctx)
+
+	err := h.repo.UpdateIncident(ctx, cmd.ID, func(_ context.Context, i *incident.Incident) error {
+		startedAt := cmd.StartedAt
+		if startedAt.IsZero() {
+			startedAt = i.StartedAt()
+		}
+		return i.Update(incident.UpdateArgs{
+			Title:      cmd.Title,
+			Severity:   cmd.Severity,
+			StartedAt:  startedAt,
+			ResolvedAt: cmd.ResolvedAt,
+			Note:       cmd.Note,
+		})
+	})
+	if err != nil {
+		span.AddEvent("failed to update incident")
+		return errorx.Wrap(err, op)
+	}
+
+	h.logger.InfoContext(ctx, "incident updated",
+		slog.String("incident.id", cmd.ID.String()),
+		slog.Bool("incident.resolved", cmd.ResolvedAt != nil),
+		slog.String("staff_id", cmd.StaffID.String()))
+
+	return nil
+}
@@ -0,0 +1,201 @@
+package statusquery
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/contrib/bridges/otelslog"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/incident"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/healthx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/httpx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx"
+)
+
+var (
+	tracer = otel.Tracer("ucms/internal/application/status/query")
+	logger = otelslog.NewLogger("ucms/internal/application/status/query")
+)
+
+const (
+	// IncidentsWindow is how long a resolved incident stays on the page, the
+	// ongoing ones stay until resolved.
+	IncidentsWindow = 14 * 24 * time.Hour
+	// MaxIncidents caps the incidents on the page.
+	MaxIncidents = 20
+	// IncidentsCacheTTL is how long the incidents are served from memory.
+	// The changes made on this instance drop them right away, the ones made
+	// on another are seen within it.
+	IncidentsCacheTTL = 30 * time.Second
+)
+
+type IncidentLister interface {
+	ListRecentIncidents(ctx context.Context, since time.Time, limit int) ([]*incident.Incident, error)
+}
+
+// Components are the components of the page and the start of the process,
+// see healthx.Monitor.
+type Components interface {
+	Components() []healthx.Component
+	StartedAt() time.Time
+}
+
+// Status is the data of the public status page. It exposes the coarse state
+// of the components only, never the errors behind it.
+type Status struct {
+	// State is the worst state of the components.
+	State      healthx.State       `json:"state"`
+	Components []ComponentResponse `json:"components"`
+	StartedAt  httpx.Time          `json:"started_at"`
+	// UptimeSeconds is the uptime of the instance answering.
+	UptimeSeconds int64              `json:"uptime_seconds"`
+	Incidents     []IncidentResponse `json:"incidents"`
+}
+
+type ComponentResponse struct {
+	Name  string        `json:"name"`
+	State healthx.State `json:"state"`
+	Since httpx.Time    `json:"since"`
+}
+
+type IncidentResponse struct {
+	ID         incident.ID       `json:"id"`
+	Title      string            `json:"title"`
+	Severity   incident.Severity `json:"severity"`
+	StartedAt  httpx.Time        `json:"started_at"`
+	ResolvedAt *httpx.Time       `json:"resolved_at"`
+	Notes      []NoteResponse    `json:"notes"`
+}
+
+type NoteResponse struct {
+	Text string     `json:"text"`
+	At   httpx.Time `json:"at"`
+}
+
+func NewIncidentResponse(i *incident.Incident) IncidentResponse {
+	notes := i.Notes()
+	res := IncidentResponse{
+		ID:         i.ID(),
+		Title:      i.Title(),
+		Severity:   i.Severity(),
+		StartedAt:  httpx.NewTime(i.StartedAt()),
+		ResolvedAt: httpx.NewTimePtr(i.ResolvedAt()),
+		Notes:      make([]NoteResponse, len(notes)),
+	}
+	for j, n := range notes {
+		res.Notes[j] = NoteResponse{Text: n.Text, At: httpx.NewTime(n.At)}
+	}
+	return res
+}
+
+// StatusHandler assembles the Status from the components and the recent
+// incidents.
+type StatusHandler struct {
+	tracer     trace.Tracer
+	logger     *slog.Logger
+	incidents  IncidentLister
+	components Components
+	clock      clock.Clock
+	ttl        time.Duration
+
+	mu       sync.Mutex
+	cached   []IncidentResponse
+	cachedAt time.Time
+}
+
+type StatusHandlerArgs struct {
+	Tracer     trace.Tracer
+	Logger     *slog.Logger
+	Incidents  IncidentLister
+	Components Components
+	// Clock defaults to clock.Real.
+	Clock clock.Clock
+	// CacheTTL defaults to IncidentsCacheTTL.
+	CacheTTL time.Duration
+}
+
+func NewStatusHandler(args StatusHandlerArgs) *StatusHandler {
+	if args.Tracer == nil {
+		args.Tracer = tracer
+	}
+	if args.Logger == nil {
+		args.Logger = logger
+	}
+	if args.CacheTTL <= 0 {
+		args.CacheTTL = IncidentsCacheTTL
+	}
+
+	return &StatusHandler{
+		tracer:     args.Tracer,
+		logger:     args.Logger,
+		incidents:  args.Incidents,
+		components: args.Components,
+		clock:      args.Clock,
+		ttl:        args.CacheTTL,
+	}
+}
+
+// Get returns the status. The components are read from memory, the
+// incidents from the cache while it is younger than its TTL.
+func (h *StatusHandler) Get(ctx context.Context) (*Status, error) {
+	const op = "statusquery.StatusHandler.Get"
+	ctx, span := h.tracer.Start(ctx, "StatusHandler.Get")
+	defer span.End()
+
+	now := clock.Or(h.clock).Now().UTC()
+	incidents, err := h.recentIncidents(ctx, now)
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to list incidents")
+		return nil, errorx.Wrap(err, op)
+	}
+
+	components := h.components.Components()
+	res := Status{
+		Components: make([]ComponentResponse, len(components)),
+		StartedAt:  httpx.NewTime(h.components.StartedAt()),
+		Incidents:  incidents,
+	}
+	states := make([]healthx.State, len(components))
+	for i, c := range components {
+		res.Components[i] = ComponentResponse{Name: c.Name, State: c.State, Since: httpx.NewTime(c.Since)}
+		states[i] = c.State
+	}
+	res.State = healthx.Worst(states...)
+	res.UptimeSeconds = int64(now.Sub(res.StartedAt.Time).Seconds())
+	span.SetAttributes(attribute.String("status.state", string(res.State)))
+
+	return &res, nil
+}
+
+func (h *StatusHandler) recentIncidents(ctx context.Context, now time.Time) ([]IncidentResponse, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.cached != nil && now.Sub(h.cachedAt) < h.ttl {
+		return h.cached, nil
+	}
+
+	incidents, err := h.incidents.ListRecentIncidents(ctx, now.Add(-IncidentsWindow), MaxIncidents)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]IncidentResponse, len(incidents))
+	for i, inc := range incidents {
+		res[i] = NewIncidentResponse(inc)
+	}
+	h.cached, h.cachedAt = res, now
+	return res, nil
+}
+
+// Invalidate drops the cached incidents, the next Get lists them again.
+func (h *StatusHandler) Invalidate() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.cached = nil
+}
@@ -0,0 +1,134 @@
+package statusquery
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/incident"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/healthx"
+)
+
+var statusNow = time.Date(2026, time.May, 6, 12, 0, 0, 0, time.UTC)
+
+// fakeIncidents returns its incidents, counting the calls.
+type fakeIncidents struct {
+	mu        sync.Mutex
+	calls     int
+	incidents []*incident.Incident
+}
+
+func (f *fakeIncidents) ListRecentIncidents(context.Context, time.Time, int) ([]*incident.Incident, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls++
+	return f.incidents, nil
+}
+
+func (f *fakeIncidents) set(incidents ...*incident.Incident) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.incidents = incidents
+}
+
+// storage is a storage probe failing while failing is set.
+type storage struct {
+	mu      sync.Mutex
+	failing bool
+}
+
+func (s *storage) probe(context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.failing {
+		return errors.New("bucket unreachable")
+	}
+	return nil
+}
+
+func newIncident(title string, startedAt time.Time) *incident.Incident {
+	return incident.Rehydrate(incident.RehydrateArgs{
+		ID:        incident.NewID(),
+		Title:     title,
+		Severity:  incident.SeverityMajor,
+		StartedAt: startedAt,
+		Notes:     []incident.Note{},
+	})
+}
+
+func TestStatusHandler_StorageFailureDegrades(t *testing.T) {
+	t.Parallel()
+	clk := clock.NewFake(statusNow)
+	s := &storage{}
+	monitor := healthx.NewMonitor(healthx.MonitorArgs{
+		Clock: clk,
+		Checks: []healthx.Check{
+			{Name: "api"},
+			{Name: "storage", Probe: s.probe},
+		},
+		DegradedAfter: 3,
+	})
+	h := NewStatusHandler(StatusHandlerArgs{Incidents: &fakeIncidents{}, Components: monitor, Clock: clk})
+
+	clk.Advance(time.Hour)
+	status, err := h.Get(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, healthx.Operational, status.State)
+	assert.Equal(t, int64(3600), status.UptimeSeconds)
+	assert.Empty(t, status.Incidents)
+
+	s.mu.Lock()
+	s.failing = true
+	s.mu.Unlock()
+	for range 3 {
+		monitor.ProbeOnce(t.Context())
+	}
+
+	status, err = h.Get(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, healthx.Degraded, status.State)
+	assert.Equal(t, []ComponentResponse{
+		{Name: "api", State: healthx.Operational, Since: status.StartedAt},
+		{Name: "storage", State: healthx.Degraded, Since: status.Components[1].Since},
+	}, status.Components)
+	assert.Equal(t, clk.Now(), status.Components[1].Since.Time)
+}
+
+func TestStatusHandler_IncidentsCache(t *testing.T) {
+	t.Parallel()
+	clk := clock.NewFake(statusNow)
+	incidents := &fakeIncidents{}
+	incidents.set(newIncident("Login slow", statusNow.Add(-time.Hour)))
+	h := NewStatusHandler(StatusHandlerArgs{
+		Incidents:  incidents,
+		Components: healthx.NewMonitor(healthx.MonitorArgs{Clock: clk}),
+		Clock:      clk,
+	})
+
+	status, err := h.Get(t.Context())
+	require.NoError(t, err)
+	require.Len(t, status.Incidents, 1)
+
+	incidents.set(newIncident("Uploads failing", statusNow), newIncident("Login slow", statusNow.Add(-time.Hour)))
+	status, err = h.Get(t.Context())
+	require.NoError(t, err)
+	assert.Len(t, status.Incidents, 1, "served from the cache")
+	assert.Equal(t, 1, incidents.calls)
+
+	h.Invalidate()
+	status, err = h.Get(t.Context())
+	require.NoError(t, err)
+	require.Len(t, status.Incidents, 2)
+	assert.Equal(t, "Uploads failing", status.Incidents[0].Title)
+
+	clk.Advance(IncidentsCacheTTL)
+	_, err = h.Get(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, 3, incidents.calls, "listed again once the cache expired")
+}
@@ -6,34 +6,126 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 	"go.opentelemetry.io/otel/trace"
 
+	"gitlab.com/ucmsv2/ucms-backend/internal/application/student/cmd"
+	studentevent "gitlab.com/ucmsv2/ucms-backend/internal/application/student/event"
 	"gitlab.com/ucmsv2/ucms-backend/internal/application/student/studentquery"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx"
 )
 
 type App struct {
-	Event Event
-	Query Query
+	Command Command
+	Event   Event
+	Query   Query
 }
 
-type Event struct{}
+type Command struct {
+	ChangeEnrollmentStatus otelx.Handler[cmd.ChangeEnrollmentStatus]
+	TransferGroup          otelx.Handler[cmd.TransferGroup]
+	CreateGroup            otelx.Handler[cmd.CreateGroup]
+	UpdateGroup            otelx.Handler[cmd.UpdateGroup]
+}
+
+type Event struct {
+	GroupSummary *studentevent.GroupSummaryHandler
+}
 
 type Query struct {
 	GetStudent *studentquery.GetStudentHandler
+	GetGroup   *studentquery.GetGroupHandler
+	ListGroups *studentquery.ListGroupsHandler
 }
 
 type Args struct {
-	PgxPool *pgxpool.Pool
-	Tracer  trace.Tracer
-	Logger  *slog.Logger
+	PgxPool     *pgxpool.Pool
+	StudentRepo cmd.StudentRepo
+	GroupGetter cmd.GroupGetter
+	GroupRepo   cmd.GroupRepo
+	GroupLister studentquery.GroupLister
+	Tracer      trace.Tracer
+	Logger      *slog.Logger
+	AvatarURLs  *user.AvatarURLBuilder
+	// Clock is the time of the enrollment windows of the groups, defaults to
+	// clock.Real.
+	Clock clock.Clock
+	// GroupSummaries and GroupPublisher keep the member counts of the groups
+	// in step with the student events.
+	GroupSummaries studentevent.GroupSummaryRefresher
+	GroupPublisher studentevent.GroupChangePublisher
 }
 
 func NewApp(args Args) *App {
+	getGroup := studentquery.NewGetGroupHandler(studentquery.GetGroupHandlerArgs{
+		Tracer: args.Tracer,
+		Logger: args.Logger,
+		Pool:   args.PgxPool,
+	})
+
 	return &App{
-		Event: Event{},
+		Command: Command{
+			ChangeEnrollmentStatus: otelx.InstrumentCommand[cmd.ChangeEnrollmentStatus](
+				"ChangeEnrollmentStatusHandler.Handle",
+				cmd.NewChangeEnrollmentStatusHandler(
+					cmd.ChangeEnrollmentStatusHandlerArgs{
+						Logger:      args.Logger,
+						StudentRepo: args.StudentRepo,
+					},
+				),
+			),
+			TransferGroup: otelx.InstrumentCommand[cmd.TransferGroup](
+				"TransferGroupHandler.Handle",
+				cmd.NewTransferGroupHandler(
+					cmd.TransferGroupHandlerArgs{
+						Logger:      args.Logger,
+						StudentRepo: args.StudentRepo,
+						GroupGetter: args.GroupGetter,
+						Clock:       args.Clock,
+					},
+				),
+			),
+			CreateGroup: otelx.InstrumentCommand[cmd.CreateGroup](
+				"CreateGroupHandler.Handle",
+				cmd.NewCreateGroupHandler(
+					cmd.CreateGroupHandlerArgs{
+						Logger:    args.Logger,
+						GroupRepo: args.GroupRepo,
+					},
+				),
+			),
+			UpdateGroup: otelx.InstrumentCommand[cmd.UpdateGroup](
+				"UpdateGroupHandler.Handle",
+				cmd.NewUpdateGroupHandler(
+					cmd.UpdateGroupHandlerArgs{
+						Logger:    args.Logger,
+						GroupRepo: args.GroupRepo,
+						Cache:     getGroup,
+						Clock:     args.Clock,
+					},
+				),
+			),
+		},
+		Event: Event{
+			GroupSummary: studentevent.NewGroupSummaryHandler(studentevent.GroupSummaryHandlerArgs{
+				Tracer:    args.Tracer,
+				Logger:    args.Logger,
+				Summaries: args.GroupSummaries,
+				Publisher: args.GroupPublisher,
+			}),
+		},
 		Query: Query{
 			GetStudent: studentquery.NewGetStudentHandler(studentquery.GetStudentHandlerArgs{
+				Tracer:     args.Tracer,
+				Logger:     args.Logger,
+				Pool:       args.PgxPool,
+				AvatarURLs: args.AvatarURLs,
+			}),
+			GetGroup: getGroup,
+			ListGroups: studentquery.NewListGroupsHandler(studentquery.ListGroupsHandlerArgs{
 				Tracer: args.Tracer,
 				Logger: args.Logger,
-				Pool:   args.PgxPool,
+				Groups: args.GroupLister,
+				Clock:  args.Clock,
 			}),
 		},
 	}
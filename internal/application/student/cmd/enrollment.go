@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/ARUMANDESU/validation"
+	"go.opentelemetry.io/contrib/bridges/otelslog"
+	"go.opentelemetry.io/otel/trace"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+)
+
+var logger = otelslog.NewLogger("ucms/internal/application/student/cmd")
+
+type StudentRepo interface {
+	UpdateStudentByBarcode(ctx context.Context, barcode user.Barcode, fn func(context.Context, *user.Student) error) error
+}
+
+type ChangeEnrollmentStatus struct {
+	StaffID user.ID
+	Barcode user.Barcode
+	Status  user.EnrollmentStatus
+	// LeaveUntil is required for user.AcademicLeave.
+	LeaveUntil *time.Time
+	// Reason is required for user.Expelled.
+	Reason string
+}
+
+func (c ChangeEnrollmentStatus) SpanAttrs() map[string]any {
+	return map[string]any{
+		"staff_id": c.StaffID.String(),
+		"barcode":  c.Barcode.String(),
+		"status":   c.Status.String(),
+	}
+}
+
+type ChangeEnrollmentStatusHandler struct {
+	logger *slog.Logger
+	repo   StudentRepo
+}
+
+type ChangeEnrollmentStatusHandlerArgs struct {
+	Logger      *slog.Logger
+	StudentRepo StudentRepo
+}
+
+func NewChangeEnrollmentStatusHandler(args ChangeEnrollmentStatusHandlerArgs) *ChangeEnrollmentStatusHandler {
+	h := &ChangeEnrollmentStatusHandler{
+		logger: args.Logger,
+		repo:   args.StudentRepo,
+	}
+
+	if h.logger == nil {
+		h.logger = logger
+	}
+
+	return h
+}
+
+func (h *ChangeEnrollmentStatusHandler) Handle(ctx context.Context, cmd ChangeEnrollmentStatus) error {
+	const op = "cmd.ChangeEnrollmentStatusHandler.Handle"
+	span := trace.SpanFromContext(ctx)
+
+	if cmd.Status == user.AcademicLeave && cmd.LeaveUntil == nil {
+		return errorx.Wrap(validation.Errors{"leave_until": validation.ErrRequired}, op)
+	}
+
+	err := h.repo.UpdateStudentByBarcode(ctx, cmd.Barcode, func(ctx context.Context, s *user.Student) error {
+		switch cmd.Status {
+		case user.AcademicLeave:
+			return s.TakeLeave(cmd.StaffID, *cmd.LeaveUntil)
+		case user.Enrolled:
+			return s.Return(cmd.StaffID)
+		case user.Graduated:
+			return s.Graduate(cmd.StaffID)
+		case user.Expelled:
+			return s.Expel(cmd.StaffID, cmd.Reason)
+		default:
+			_, err := user.ParseEnrollmentStatus(cmd.Status.String())
+			return err
+		}
+	})
+	if err != nil {
+		span.AddEvent("failed to change student enrollment status")
+		return errorx.Wrap(err, op)
+	}
+
+	h.logger.InfoContext(ctx, "student enrollment status changed",
+		slog.String("barcode", cmd.Barcode.String()),
+		slog.String("status", cmd.Status.String()),
+		slog.String("staff_id", cmd.StaffID.String()))
+
+	return nil
+}
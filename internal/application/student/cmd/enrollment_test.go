@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ARUMANDESU/validation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/builders"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/fixtures"
+	"gitlab.com/ucmsv2/ucms-backend/tests/mocks"
+)
+
+func TestChangeEnrollmentStatusHandler(t *testing.T) {
+	until := time.Now().AddDate(0, 6, 0).UTC().Truncate(time.Second)
+
+	tests := []struct {
+		name     string
+		from     user.EnrollmentStatus
+		cmd      ChangeEnrollmentStatus
+		want     user.EnrollmentStatus
+		wantCode errorx.Code
+	}{
+		{
+			name: "take leave",
+			from: user.Enrolled,
+			cmd:  ChangeEnrollmentStatus{Status: user.AcademicLeave, LeaveUntil: &until},
+			want: user.AcademicLeave,
+		},
+		{name: "return", from: user.AcademicLeave, cmd: ChangeEnrollmentStatus{Status: user.Enrolled}, want: user.Enrolled},
+		{name: "graduate", from: user.Enrolled, cmd: ChangeEnrollmentStatus{Status: user.Graduated}, want: user.Graduated},
+		{
+			name: "expel",
+			from: user.Enrolled,
+			cmd:  ChangeEnrollmentStatus{Status: user.Expelled, Reason: "academic misconduct"},
+			want: user.Expelled,
+		},
+		{
+			name:     "graduated takes leave",
+			from:     user.Graduated,
+			cmd:      ChangeEnrollmentStatus{Status: user.AcademicLeave, LeaveUntil: &until},
+			want:     user.Graduated,
+			wantCode: errorx.CodeBusinessRuleViolation,
+		},
+		{
+			name:     "unknown status",
+			from:     user.Enrolled,
+			cmd:      ChangeEnrollmentStatus{Status: "dropped"},
+			want:     user.Enrolled,
+			wantCode: errorx.CodeInvalid,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := mocks.NewStudentRepo()
+			var leaveUntil *time.Time
+			if tt.from == user.AcademicLeave {
+				leaveUntil = &until
+			}
+			student := builders.NewStudentBuilder().WithEnrollment(tt.from, leaveUntil, "").Build()
+			repo.SeedStudent(t, student)
+			h := NewChangeEnrollmentStatusHandler(ChangeEnrollmentStatusHandlerArgs{StudentRepo: repo})
+
+			tt.cmd.StaffID = fixtures.TestStaff.ID
+			tt.cmd.Barcode = student.User().Barcode()
+			err := h.Handle(t.Context(), tt.cmd)
+			if tt.wantCode != "" {
+				var i18nErr *errorx.I18nError
+				require.ErrorAs(t, err, &i18nErr)
+				assert.Equal(t, tt.wantCode, i18nErr.Code)
+			} else {
+				require.NoError(t, err)
+			}
+
+			got, err := repo.GetStudentByBarcode(t.Context(), student.User().Barcode())
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got.EnrollmentStatus())
+		})
+	}
+}
+
+func TestChangeEnrollmentStatusHandler_LeaveWithoutUntil(t *testing.T) {
+	repo := mocks.NewStudentRepo()
+	student := builders.NewStudentBuilder().Build()
+	repo.SeedStudent(t, student)
+	h := NewChangeEnrollmentStatusHandler(ChangeEnrollmentStatusHandlerArgs{StudentRepo: repo})
+
+	err := h.Handle(t.Context(), ChangeEnrollmentStatus{
+		StaffID: fixtures.TestStaff.ID,
+		Barcode: student.User().Barcode(),
+		Status:  user.AcademicLeave,
+	})
+	var verrs validation.Errors
+	require.ErrorAs(t, err, &verrs)
+	assert.Contains(t, verrs, "leave_until")
+}
+
+func TestChangeEnrollmentStatusHandler_UnknownStudent(t *testing.T) {
+	h := NewChangeEnrollmentStatusHandler(ChangeEnrollmentStatusHandlerArgs{StudentRepo: mocks.NewStudentRepo()})
+
+	err := h.Handle(t.Context(), ChangeEnrollmentStatus{Barcode: "STU999", Status: user.Graduated})
+	var i18nErr *errorx.I18nError
+	require.ErrorAs(t, err, &i18nErr)
+	assert.Equal(t, errorx.CodeNotFound, i18nErr.Code)
+}
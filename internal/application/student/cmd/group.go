@@ -0,0 +1,307 @@
+package cmd
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/group"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/term"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/majors"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/i18nx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/optional"
+)
+
+type GroupGetter interface {
+	GetGroupByID(ctx context.Context, id group.ID) (*group.Group, error)
+}
+
+type GroupUpdater interface {
+	UpdateGroup(ctx context.Context, id group.ID, fn func(context.Context, *group.Group) error) error
+}
+
+// GroupRepo creates and changes the groups, a group saved with a term that
+// does not exist fails with a not found error.
+type GroupRepo interface {
+	GroupUpdater
+	SaveGroup(ctx context.Context, g *group.Group) error
+}
+
+// GroupCache drops the groups changed by UpdateGroup from the reads, see
+// studentquery.GetGroupHandler.
+type GroupCache interface {
+	Invalidate(groupIDs ...group.ID)
+}
+
+// TransferGroup moves a student to another group.
+type TransferGroup struct {
+	StaffID user.ID
+	Barcode user.Barcode
+	GroupID group.ID
+}
+
+func (c TransferGroup) SpanAttrs() map[string]any {
+	return map[string]any{
+		"staff_id": c.StaffID.String(),
+		"barcode":  c.Barcode.String(),
+		"group_id": c.GroupID.String(),
+	}
+}
+
+type TransferGroupHandler struct {
+	logger *slog.Logger
+	repo   StudentRepo
+	groups GroupGetter
+	clock  clock.Clock
+}
+
+type TransferGroupHandlerArgs struct {
+	Logger      *slog.Logger
+	StudentRepo StudentRepo
+	GroupGetter GroupGetter
+	// Clock is the time the enrollment window of the group is checked at,
+	// defaults to clock.Real.
+	Clock clock.Clock
+}
+
+func NewTransferGroupHandler(args TransferGroupHandlerArgs) *TransferGroupHandler {
+	h := &TransferGroupHandler{
+		logger: args.Logger,
+		repo:   args.StudentRepo,
+		groups: args.GroupGetter,
+		clock:  clock.Or(args.Clock),
+	}
+
+	if h.logger == nil {
+		h.logger = logger
+	}
+
+	return h
+}
+
+func (h *TransferGroupHandler) Handle(ctx context.Context, cmd TransferGroup) error {
+	const op = "cmd.TransferGroupHandler.Handle"
+	span := trace.SpanFromContext(ctx)
+
+	g, err := h.groups.GetGroupByID(ctx, cmd.GroupID)
+	if err != nil {
+		span.AddEvent("failed to get group by ID")
+		if errorx.IsNotFound(err) {
+			return errorx.NewResourceNotFound(i18nx.FieldGroup).WithCause(err, op)
+		}
+		return errorx.Wrap(err, op)
+	}
+	if err := g.CheckEnrollmentOpen(h.clock.Now()); err != nil {
+		span.AddEvent("group enrollment closed")
+		return errorx.Wrap(err, op)
+	}
+
+	err = h.repo.UpdateStudentByBarcode(ctx, cmd.Barcode, func(ctx context.Context, s *user.Student) error {
+		return s.TransferToGroup(cmd.StaffID, cmd.GroupID)
+	})
+	if err != nil {
+		span.AddEvent("failed to transfer student to group")
+		return errorx.Wrap(err, op)
+	}
+
+	h.logger.InfoContext(ctx, "student transferred to group",
+		slog.String("barcode", cmd.Barcode.String()),
+		slog.String("group_id", cmd.GroupID.String()),
+		slog.String("staff_id", cmd.StaffID.String()))
+
+	return nil
+}
+
+// CreateGroup adds a group, in the term TermID unless it is nil.
+type CreateGroup struct {
+	// ID is generated by the caller, so it can answer with it.
+	ID      group.ID
+	StaffID user.ID
+	Name    string
+	Year    string
+	Major   majors.Major
+	TermID  *term.ID
+}
+
+func (c CreateGroup) SpanAttrs() map[string]any {
+	attrs := map[string]any{
+		"staff_id": c.StaffID.String(),
+		"group_id": c.ID.String(),
+	}
+	if c.TermID != nil {
+		attrs["term_id"] = c.TermID.String()
+	}
+	return attrs
+}
+
+type CreateGroupHandler struct {
+	logger *slog.Logger
+	repo   GroupRepo
+}
+
+type CreateGroupHandlerArgs struct {
+	Logger    *slog.Logger
+	GroupRepo GroupRepo
+}
+
+func NewCreateGroupHandler(args CreateGroupHandlerArgs) *CreateGroupHandler {
+	h := &CreateGroupHandler{
+		logger: args.Logger,
+		repo:   args.GroupRepo,
+	}
+
+	if h.logger == nil {
+		h.logger = logger
+	}
+
+	return h
+}
+
+func (h *CreateGroupHandler) Handle(ctx context.Context, cmd CreateGroup) error {
+	const op = "cmd.CreateGroupHandler.Handle"
+	span := trace.SpanFromContext(ctx)
+
+	g, err := group.NewGroup(cmd.Name, cmd.Year, cmd.Major)
+	if err != nil {
+		span.AddEvent("invalid group")
+		return errorx.Wrap(err, op)
+	}
+	g = group.Rehydrate(group.RehydrateArgs{
+		ID:        cmd.ID,
+		Name:      g.Name(),
+		Major:     g.Major(),
+		Year:      g.Year(),
+		CreatedAt: g.CreatedAt(),
+		UpdatedAt: g.UpdatedAt(),
+		TermID:    cmd.TermID,
+	})
+
+	if err := h.repo.SaveGroup(ctx, g); err != nil {
+		span.AddEvent("failed to save group")
+		return errorx.Wrap(err, op)
+	}
+
+	h.logger.InfoContext(ctx, "group created",
+		slog.String("group_id", g.ID().String()),
+		slog.String("staff_id", cmd.StaffID.String()))
+
+	return nil
+}
+
+// UpdateGroup changes the name, the year, the major, the enrollment window
+// and the term of a group. A field that is not set is kept. The name, the
+// year and the major are required, a null one fails validation, a null end
+// of the enrollment window leaves that side open. A new term rolls the group
+// over to it, a null one leaves the group without a term.
+type UpdateGroup struct {
+	StaffID            user.ID
+	GroupID            group.ID
+	Name               optional.Field[string]
+	Year               optional.Field[string]
+	Major              optional.Field[majors.Major]
+	EnrollmentOpensAt  optional.Field[*time.Time]
+	EnrollmentClosesAt optional.Field[*time.Time]
+	TermID             optional.Field[*term.ID]
+}
+
+func (c UpdateGroup) SpanAttrs() map[string]any {
+	return map[string]any{
+		"staff_id":                     c.StaffID.String(),
+		"group_id":                     c.GroupID.String(),
+		"name_changed":                 c.Name.Set,
+		"year_changed":                 c.Year.Set,
+		"major_changed":                c.Major.Set,
+		"enrollment_opens_at_changed":  c.EnrollmentOpensAt.Set,
+		"enrollment_closes_at_changed": c.EnrollmentClosesAt.Set,
+		"term_id_changed":              c.TermID.Set,
+	}
+}
+
+type UpdateGroupHandler struct {
+	logger *slog.Logger
+	repo   GroupUpdater
+	cache  GroupCache
+	clock  clock.Clock
+}
+
+type UpdateGroupHandlerArgs struct {
+	Logger    *slog.Logger
+	GroupRepo GroupUpdater
+	// Cache is optional.
+	Cache GroupCache
+	// Clock is the time the enrollment window must be in the future of,
+	// defaults to clock.Real.
+	Clock clock.Clock
+}
+
+func NewUpdateGroupHandler(args UpdateGroupHandlerArgs) *UpdateGroupHandler {
+	h := &UpdateGroupHandler{
+		logger: args.Logger,
+		repo:   args.GroupRepo,
+		cache:  args.Cache,
+		clock:  clock.Or(args.Clock),
+	}
+
+	if h.logger == nil {
+		h.logger = logger
+	}
+
+	return h
+}
+
+func (h *UpdateGroupHandler) Handle(ctx context.Context, cmd UpdateGroup) error {
+	const op = "cmd.UpdateGroupHandler.Handle"
+	span := trace.SpanFromContext(ctx)
+	if !cmd.Name.Set && !cmd.Year.Set && !cmd.Major.Set && !cmd.EnrollmentOpensAt.Set && !cmd.EnrollmentClosesAt.Set &&
+		!cmd.TermID.Set {
+		span.AddEvent("empty patch, nothing to update")
+		return nil
+	}
+
+	var changed bool
+	err := h.repo.UpdateGroup(ctx, cmd.GroupID, func(ctx context.Context, g *group.Group) error {
+		updated, err := g.Update(
+			cmd.Name.Apply(g.Name()),
+			cmd.Year.Apply(g.Year()),
+			cmd.Major.Apply(g.Major()),
+		)
+		if err != nil {
+			return err
+		}
+		windowChanged, err := g.SetEnrollmentWindow(
+			cmd.EnrollmentOpensAt.Apply(g.EnrollmentOpensAt()),
+			cmd.EnrollmentClosesAt.Apply(g.EnrollmentClosesAt()),
+			h.clock.Now(),
+		)
+		if err != nil {
+			return err
+		}
+		termChanged := g.SetTerm(cmd.TermID.Apply(g.TermID()))
+		changed = updated || windowChanged || termChanged
+		return nil
+	})
+	if err != nil {
+		span.AddEvent("failed to update group")
+		if errorx.IsNotFound(err) && !errorx.IsResourceNotFound(err, i18nx.FieldTerm) {
+			return errorx.NewResourceNotFound(i18nx.FieldGroup).WithCause(err, op)
+		}
+		return errorx.Wrap(err, op)
+	}
+	if !changed {
+		return nil
+	}
+	if h.cache != nil {
+		h.cache.Invalidate(cmd.GroupID)
+	}
+
+	h.logger.InfoContext(ctx, "group updated",
+		slog.String("group_id", cmd.GroupID.String()),
+		slog.String("staff_id", cmd.StaffID.String()))
+
+	return nil
+}
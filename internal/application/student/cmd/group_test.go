@@ -0,0 +1,233 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/group"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/term"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/majors"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/optional"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/builders"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/fixtures"
+	"gitlab.com/ucmsv2/ucms-backend/tests/mocks"
+)
+
+func TestTransferGroupHandler(t *testing.T) {
+	students := mocks.NewStudentRepo()
+	groups := mocks.NewGroupRepo()
+	student := builders.NewStudentBuilder().Build()
+	students.SeedStudent(t, student)
+	target := builders.NewGroupBuilder().WithID(group.NewID()).WithName("SE-2402").Build()
+	groups.SeedGroup(t, target)
+	h := NewTransferGroupHandler(TransferGroupHandlerArgs{StudentRepo: students, GroupGetter: groups})
+
+	err := h.Handle(t.Context(), TransferGroup{
+		StaffID: fixtures.TestStaff.ID,
+		Barcode: student.User().Barcode(),
+		GroupID: target.ID(),
+	})
+	require.NoError(t, err)
+
+	got, err := students.GetStudentByBarcode(t.Context(), student.User().Barcode())
+	require.NoError(t, err)
+	assert.Equal(t, target.ID(), got.GroupID())
+}
+
+func TestTransferGroupHandler_UnknownGroup(t *testing.T) {
+	students := mocks.NewStudentRepo()
+	student := builders.NewStudentBuilder().Build()
+	students.SeedStudent(t, student)
+	h := NewTransferGroupHandler(TransferGroupHandlerArgs{StudentRepo: students, GroupGetter: mocks.NewGroupRepo()})
+
+	err := h.Handle(t.Context(), TransferGroup{
+		StaffID: fixtures.TestStaff.ID,
+		Barcode: student.User().Barcode(),
+		GroupID: group.NewID(),
+	})
+	var i18nErr *errorx.I18nError
+	require.ErrorAs(t, err, &i18nErr)
+	assert.Equal(t, errorx.CodeNotFound, i18nErr.Code)
+
+	got, err := students.GetStudentByBarcode(t.Context(), student.User().Barcode())
+	require.NoError(t, err)
+	assert.Equal(t, student.GroupID(), got.GroupID())
+}
+
+type invalidatedGroups []group.ID
+
+func (c *invalidatedGroups) Invalidate(groupIDs ...group.ID) {
+	*c = append(*c, groupIDs...)
+}
+
+func TestUpdateGroupHandler(t *testing.T) {
+	groups := mocks.NewGroupRepo()
+	g := builders.NewGroupBuilder().WithID(group.NewID()).WithName("SE-2401").Build()
+	groups.SeedGroup(t, g)
+	var cache invalidatedGroups
+	h := NewUpdateGroupHandler(UpdateGroupHandlerArgs{GroupRepo: groups, Cache: &cache})
+
+	err := h.Handle(t.Context(), UpdateGroup{
+		StaffID: fixtures.TestStaff.ID,
+		GroupID: g.ID(),
+		Name:    optional.SetTo("SE-2402"),
+	})
+	require.NoError(t, err)
+
+	got, err := groups.GetGroupByID(t.Context(), g.ID())
+	require.NoError(t, err)
+	assert.Equal(t, "SE-2402", got.Name())
+	assert.Equal(t, g.Year(), got.Year(), "a field left out is kept")
+	assert.Equal(t, g.Major(), got.Major(), "a field left out is kept")
+	assert.Equal(t, invalidatedGroups{g.ID()}, cache)
+}
+
+func TestUpdateGroupHandler_NoOpPatch(t *testing.T) {
+	groups := mocks.NewGroupRepo()
+	g := builders.NewGroupBuilder().WithID(group.NewID()).Build()
+	groups.SeedGroup(t, g)
+	var cache invalidatedGroups
+	h := NewUpdateGroupHandler(UpdateGroupHandlerArgs{GroupRepo: groups, Cache: &cache})
+
+	require.NoError(t, h.Handle(t.Context(), UpdateGroup{GroupID: g.ID()}))
+	require.NoError(t, h.Handle(t.Context(), UpdateGroup{GroupID: g.ID(), Name: optional.SetTo(g.Name())}))
+
+	got, err := groups.GetGroupByID(t.Context(), g.ID())
+	require.NoError(t, err)
+	assert.Equal(t, g.UpdatedAt(), got.UpdatedAt())
+	assert.Empty(t, cache, "an unchanged group stays cached")
+}
+
+func TestUpdateGroupHandler_NullName(t *testing.T) {
+	groups := mocks.NewGroupRepo()
+	g := builders.NewGroupBuilder().WithID(group.NewID()).Build()
+	groups.SeedGroup(t, g)
+	h := NewUpdateGroupHandler(UpdateGroupHandlerArgs{GroupRepo: groups})
+
+	err := h.Handle(t.Context(), UpdateGroup{GroupID: g.ID(), Name: optional.Clear[string]()})
+	require.Error(t, err)
+
+	got, err := groups.GetGroupByID(t.Context(), g.ID())
+	require.NoError(t, err)
+	assert.Equal(t, g.Name(), got.Name())
+}
+
+func TestUpdateGroupHandler_UnknownGroup(t *testing.T) {
+	h := NewUpdateGroupHandler(UpdateGroupHandlerArgs{GroupRepo: mocks.NewGroupRepo()})
+
+	err := h.Handle(t.Context(), UpdateGroup{GroupID: group.NewID(), Year: optional.SetTo("25")})
+	var i18nErr *errorx.I18nError
+	require.ErrorAs(t, err, &i18nErr)
+	assert.Equal(t, errorx.CodeNotFound, i18nErr.Code)
+}
+
+func TestTransferGroupHandler_EnrollmentClosed(t *testing.T) {
+	students := mocks.NewStudentRepo()
+	groups := mocks.NewGroupRepo()
+	student := builders.NewStudentBuilder().Build()
+	students.SeedStudent(t, student)
+	clk := clock.NewFake(time.Date(2026, time.August, 3, 9, 0, 0, 0, time.UTC))
+	opensAt := clk.Now().Add(time.Hour)
+	target := builders.NewGroupBuilder().WithID(group.NewID()).WithName("SE-2402").
+		WithEnrollmentWindow(&opensAt, nil).Build()
+	groups.SeedGroup(t, target)
+	h := NewTransferGroupHandler(TransferGroupHandlerArgs{StudentRepo: students, GroupGetter: groups, Clock: clk})
+	transfer := TransferGroup{
+		StaffID: fixtures.TestStaff.ID,
+		Barcode: student.User().Barcode(),
+		GroupID: target.ID(),
+	}
+
+	err := h.Handle(t.Context(), transfer)
+	require.ErrorIs(t, err, group.ErrEnrollmentClosed)
+	got, err := students.GetStudentByBarcode(t.Context(), student.User().Barcode())
+	require.NoError(t, err)
+	assert.Equal(t, student.GroupID(), got.GroupID())
+
+	clk.Advance(time.Hour)
+	require.NoError(t, h.Handle(t.Context(), transfer))
+}
+
+func TestUpdateGroupHandler_EnrollmentWindow(t *testing.T) {
+	groups := mocks.NewGroupRepo()
+	g := builders.NewGroupBuilder().WithID(group.NewID()).Build()
+	groups.SeedGroup(t, g)
+	clk := clock.NewFake(time.Date(2026, time.August, 3, 9, 0, 0, 0, time.UTC))
+	var cache invalidatedGroups
+	h := NewUpdateGroupHandler(UpdateGroupHandlerArgs{GroupRepo: groups, Cache: &cache, Clock: clk})
+	opensAt, closesAt := clk.Now().Add(time.Hour), clk.Now().Add(24*time.Hour)
+
+	err := h.Handle(t.Context(), UpdateGroup{
+		GroupID:            g.ID(),
+		EnrollmentOpensAt:  optional.SetTo(&opensAt),
+		EnrollmentClosesAt: optional.SetTo(&closesAt),
+	})
+	require.NoError(t, err)
+	got, err := groups.GetGroupByID(t.Context(), g.ID())
+	require.NoError(t, err)
+	require.NotNil(t, got.EnrollmentOpensAt())
+	assert.Equal(t, opensAt, *got.EnrollmentOpensAt())
+	assert.Equal(t, g.Name(), got.Name(), "a field left out is kept")
+	assert.Equal(t, invalidatedGroups{g.ID()}, cache)
+
+	err = h.Handle(t.Context(), UpdateGroup{GroupID: g.ID(), EnrollmentOpensAt: optional.Clear[*time.Time]()})
+	require.NoError(t, err)
+	got, err = groups.GetGroupByID(t.Context(), g.ID())
+	require.NoError(t, err)
+	assert.Nil(t, got.EnrollmentOpensAt(), "a null end leaves that side open")
+	require.NotNil(t, got.EnrollmentClosesAt())
+	assert.Equal(t, closesAt, *got.EnrollmentClosesAt())
+}
+
+func TestCreateGroupHandler(t *testing.T) {
+	groups := mocks.NewGroupRepo()
+	h := NewCreateGroupHandler(CreateGroupHandlerArgs{GroupRepo: groups})
+	termID := term.NewID()
+
+	id := group.NewID()
+	err := h.Handle(t.Context(), CreateGroup{
+		ID:      id,
+		StaffID: fixtures.TestStaff.ID,
+		Name:    "SE-2501",
+		Year:    "25",
+		Major:   majors.SE,
+		TermID:  &termID,
+	})
+	require.NoError(t, err)
+
+	got, err := groups.GetGroupByID(t.Context(), id)
+	require.NoError(t, err)
+	assert.Equal(t, "SE-2501", got.Name())
+	require.NotNil(t, got.TermID())
+	assert.Equal(t, termID, *got.TermID())
+
+	err = h.Handle(t.Context(), CreateGroup{ID: group.NewID(), Name: "SE-2502", Year: "year", Major: majors.SE})
+	require.Error(t, err)
+}
+
+func TestUpdateGroupHandler_Rollover(t *testing.T) {
+	groups := mocks.NewGroupRepo()
+	fall, spring := term.NewID(), term.NewID()
+	g := builders.NewGroupBuilder().WithID(group.NewID()).WithTermID(fall).Build()
+	groups.SeedGroup(t, g)
+	var cache invalidatedGroups
+	h := NewUpdateGroupHandler(UpdateGroupHandlerArgs{GroupRepo: groups, Cache: &cache})
+
+	require.NoError(t, h.Handle(t.Context(), UpdateGroup{GroupID: g.ID(), TermID: optional.SetTo(&spring)}))
+	got, err := groups.GetGroupByID(t.Context(), g.ID())
+	require.NoError(t, err)
+	require.NotNil(t, got.TermID())
+	assert.Equal(t, spring, *got.TermID())
+	assert.Equal(t, g.Name(), got.Name(), "a field left out is kept")
+	assert.Equal(t, invalidatedGroups{g.ID()}, cache)
+
+	require.NoError(t, h.Handle(t.Context(), UpdateGroup{GroupID: g.ID(), TermID: optional.Clear[*term.ID]()}))
+	got, err = groups.GetGroupByID(t.Context(), g.ID())
+	require.NoError(t, err)
+	assert.Nil(t, got.TermID(), "a null term leaves the group without one")
+}
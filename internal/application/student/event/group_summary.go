@@ -0,0 +1,136 @@
+package studentevent
+
+import (
+	"context"
+	"log/slog"
+
+	"go.opentelemetry.io/contrib/bridges/otelslog"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/group"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx"
+)
+
+var (
+	tracer = otel.Tracer("ucms/internal/application/student/event")
+	logger = otelslog.NewLogger("ucms/internal/application/student/event")
+)
+
+// GroupSummaryRefresher counts the members of a group into its summary, a
+// deleted group is left alone.
+type GroupSummaryRefresher interface {
+	RefreshGroupSummary(ctx context.Context, groupID group.ID) error
+}
+
+// GroupChangePublisher tells every instance to drop what it cached of the
+// groups.
+type GroupChangePublisher interface {
+	PublishGroupsChanged(ctx context.Context, groupIDs ...group.ID) error
+}
+
+// GroupSummaryHandler keeps the group summaries in step with the students
+// registering and moving between groups.
+type GroupSummaryHandler struct {
+	tracer    trace.Tracer
+	logger    *slog.Logger
+	summaries GroupSummaryRefresher
+	publisher GroupChangePublisher
+}
+
+type GroupSummaryHandlerArgs struct {
+	Tracer    trace.Tracer
+	Logger    *slog.Logger
+	Summaries GroupSummaryRefresher
+	Publisher GroupChangePublisher
+}
+
+func NewGroupSummaryHandler(args GroupSummaryHandlerArgs) *GroupSummaryHandler {
+	if args.Tracer == nil {
+		args.Tracer = tracer
+	}
+	if args.Logger == nil {
+		args.Logger = logger
+	}
+
+	return &GroupSummaryHandler{
+		tracer:    args.Tracer,
+		logger:    args.Logger,
+		summaries: args.Summaries,
+		publisher: args.Publisher,
+	}
+}
+
+// HandleStudentGroupChanged refreshes the summaries of the group the student
+// left and of the one they joined.
+func (h *GroupSummaryHandler) HandleStudentGroupChanged(ctx context.Context, e *user.StudentGroupChanged) error {
+	if e == nil {
+		return nil
+	}
+	const op = "studentevent.GroupSummaryHandler.HandleStudentGroupChanged"
+	ctx, span := h.tracer.Start(ctx, "GroupSummaryHandler.HandleStudentGroupChanged",
+		trace.WithAttributes(
+			attribute.String("event.id", e.EventID.String()),
+			attribute.String("student.id", e.StudentID.String()),
+			attribute.String("group.from", e.From.String()),
+			attribute.String("group.to", e.To.String()),
+		),
+	)
+	defer span.End()
+
+	groups := []group.ID{e.To}
+	if e.From != (group.ID{}) {
+		groups = append(groups, e.From)
+	}
+	if err := h.refresh(ctx, groups...); err != nil {
+		otelx.RecordSpanError(span, err, "failed to refresh group summaries")
+		return errorx.Wrap(err, op)
+	}
+
+	return nil
+}
+
+// HandleStudentRegistered refreshes the summary of the group of the new
+// student.
+func (h *GroupSummaryHandler) HandleStudentRegistered(ctx context.Context, e *user.StudentRegistered) error {
+	if e == nil {
+		return nil
+	}
+	const op = "studentevent.GroupSummaryHandler.HandleStudentRegistered"
+	ctx, span := h.tracer.Start(ctx, "GroupSummaryHandler.HandleStudentRegistered",
+		trace.WithAttributes(
+			attribute.String("event.id", e.EventID.String()),
+			attribute.String("student.id", e.StudentID.String()),
+			attribute.String("group.id", e.GroupID.String()),
+		),
+	)
+	defer span.End()
+
+	if err := h.refresh(ctx, e.GroupID); err != nil {
+		otelx.RecordSpanError(span, err, "failed to refresh group summary")
+		return errorx.Wrap(err, op)
+	}
+
+	return nil
+}
+
+// refresh counts the members of the groups again, then drops the groups from
+// the caches. Dropping them first would let a read cache the old count again
+// before the refresh.
+func (h *GroupSummaryHandler) refresh(ctx context.Context, groupIDs ...group.ID) error {
+	for _, id := range groupIDs {
+		if err := h.summaries.RefreshGroupSummary(ctx, id); err != nil {
+			return err
+		}
+	}
+	if err := h.publisher.PublishGroupsChanged(ctx, groupIDs...); err != nil {
+		// The caches expire the groups, the summaries are right already.
+		h.logger.WarnContext(ctx, "failed to publish the changed groups",
+			slog.Any("groups", groupIDs),
+			slog.String("error", err.Error()))
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package studentevent
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/event"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/group"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+)
+
+// fakeGroups records the refreshes and the publications in their order.
+type fakeGroups struct {
+	calls      []string
+	refreshed  []group.ID
+	published  []group.ID
+	refreshErr error
+	publishErr error
+}
+
+func (f *fakeGroups) RefreshGroupSummary(_ context.Context, groupID group.ID) error {
+	f.calls = append(f.calls, "refresh")
+	if f.refreshErr != nil {
+		return f.refreshErr
+	}
+	f.refreshed = append(f.refreshed, groupID)
+	return nil
+}
+
+func (f *fakeGroups) PublishGroupsChanged(_ context.Context, groupIDs ...group.ID) error {
+	f.calls = append(f.calls, "publish")
+	f.published = append(f.published, groupIDs...)
+	return f.publishErr
+}
+
+func newGroupSummaryHandler(groups *fakeGroups) *GroupSummaryHandler {
+	return NewGroupSummaryHandler(GroupSummaryHandlerArgs{Summaries: groups, Publisher: groups})
+}
+
+func TestGroupSummaryHandler_GroupChanged(t *testing.T) {
+	from, to := group.NewID(), group.NewID()
+	e := &user.StudentGroupChanged{Header: event.NewEventHeader(), StudentID: user.NewID(), From: from, To: to}
+
+	t.Run("refreshes both groups before dropping them from the caches", func(t *testing.T) {
+		groups := &fakeGroups{}
+		require.NoError(t, newGroupSummaryHandler(groups).HandleStudentGroupChanged(t.Context(), e))
+
+		assert.ElementsMatch(t, []group.ID{from, to}, groups.refreshed)
+		assert.ElementsMatch(t, []group.ID{from, to}, groups.published)
+		assert.Equal(t, []string{"refresh", "refresh", "publish"}, groups.calls)
+	})
+
+	t.Run("is idempotent", func(t *testing.T) {
+		groups := &fakeGroups{}
+		h := newGroupSummaryHandler(groups)
+		require.NoError(t, h.HandleStudentGroupChanged(t.Context(), e))
+		require.NoError(t, h.HandleStudentGroupChanged(t.Context(), e))
+
+		assert.ElementsMatch(t, []group.ID{from, to, from, to}, groups.refreshed, "a redelivery counts the members again")
+	})
+
+	t.Run("a failed refresh is redelivered", func(t *testing.T) {
+		groups := &fakeGroups{refreshErr: errors.New("connection reset")}
+		err := newGroupSummaryHandler(groups).HandleStudentGroupChanged(t.Context(), e)
+
+		require.Error(t, err)
+		assert.Empty(t, groups.published)
+	})
+
+	t.Run("a failed publication is not", func(t *testing.T) {
+		groups := &fakeGroups{publishErr: errors.New("connection reset")}
+		err := newGroupSummaryHandler(groups).HandleStudentGroupChanged(t.Context(), e)
+
+		assert.NoError(t, err, "the caches expire the groups")
+	})
+}
+
+func TestGroupSummaryHandler_StudentRegistered(t *testing.T) {
+	groups := &fakeGroups{}
+	groupID := group.NewID()
+
+	err := newGroupSummaryHandler(groups).HandleStudentRegistered(t.Context(), &user.StudentRegistered{
+		Header:    event.NewEventHeader(),
+		StudentID: user.NewID(),
+		GroupID:   groupID,
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, []group.ID{groupID}, groups.refreshed)
+	assert.Equal(t, []group.ID{groupID}, groups.published)
+}
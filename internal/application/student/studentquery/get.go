@@ -14,6 +14,7 @@ import (
 	"go.opentelemetry.io/otel/trace"
 
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/avatars"
 	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
 	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx"
 )
@@ -43,18 +44,26 @@ type GetStudentResponse struct {
 		Year  string `json:"year"`
 	} `json:"group"`
 	RegisteredAt time.Time `json:"registered_at"`
+	// EnrollmentStatus lets the clients tell the students on leave and the
+	// graduates apart, LeaveUntil is set for the students on leave.
+	EnrollmentStatus string     `json:"enrollment_status"`
+	LeaveUntil       *time.Time `json:"leave_until"`
+	// UnreadNotifications is the number on the bell of the client.
+	UnreadNotifications int `json:"unread_notifications"`
 }
 
 type GetStudentHandler struct {
-	tracer trace.Tracer
-	logger *slog.Logger
-	pool   *pgxpool.Pool
+	tracer     trace.Tracer
+	logger     *slog.Logger
+	pool       *pgxpool.Pool
+	avatarURLs *user.AvatarURLBuilder
 }
 
 type GetStudentHandlerArgs struct {
-	Tracer trace.Tracer
-	Logger *slog.Logger
-	Pool   *pgxpool.Pool
+	Tracer     trace.Tracer
+	Logger     *slog.Logger
+	Pool       *pgxpool.Pool
+	AvatarURLs *user.AvatarURLBuilder
 }
 
 func NewGetStudentHandler(args GetStudentHandlerArgs) *GetStudentHandler {
@@ -66,9 +75,10 @@ func NewGetStudentHandler(args GetStudentHandlerArgs) *GetStudentHandler {
 	}
 
 	return &GetStudentHandler{
-		tracer: args.Tracer,
-		logger: args.Logger,
-		pool:   args.Pool,
+		tracer:     args.Tracer,
+		logger:     args.Logger,
+		pool:       args.Pool,
+		avatarURLs: args.AvatarURLs,
 	}
 }
 
@@ -80,16 +90,22 @@ func (h *GetStudentHandler) Handle(ctx context.Context, query GetStudent) (*GetS
 	defer span.End()
 
 	var res GetStudentResponse
+	var avatarSource string
+	var avatar avatars.Avatar
 	err := h.pool.QueryRow(ctx, `
-        SELECT u.id, u.barcode, u.email, u.first_name, u.last_name, u.avatar_url, u.created_at, 
-            gr.name, g.id, g.major, g.name, g.year
+        SELECT u.id, u.barcode, u.email, u.first_name, u.last_name,
+            u.avatar_source, u.avatar_external, u.avatar_s3_key, u.created_at,
+            gr.name, g.id, g.major, g.name, g.year,
+            s.enrollment_status, s.leave_until,
+            (SELECT count(*) FROM notifications n WHERE n.user_id = u.id AND n.read_at IS NULL)
         FROM students s JOIN users u ON s.user_id = u.id
         JOIN groups g ON s.group_id = g.id
         JOIN global_roles gr ON u.role_id = gr.id
         WHERE u.id = $1
     `, query.ID).Scan(
-		&res.ID, &res.Barcode, &res.Email, &res.FirstName, &res.LastName, &res.AvatarURL,
-		&res.RegisteredAt, &res.Role, &res.Group.ID, &res.Group.Major, &res.Group.Name, &res.Group.Year,
+		&res.ID, &res.Barcode, &res.Email, &res.FirstName, &res.LastName,
+		&avatarSource, &avatar.External, &avatar.S3Key, &res.RegisteredAt, &res.Role, &res.Group.ID, &res.Group.Major, &res.Group.Name, &res.Group.Year,
+		&res.EnrollmentStatus, &res.LeaveUntil, &res.UnreadNotifications,
 	)
 	if err != nil {
 		otelx.RecordSpanError(span, err, "failed to get student by id")
@@ -99,5 +115,12 @@ func (h *GetStudentHandler) Handle(ctx context.Context, query GetStudent) (*GetS
 		return nil, errorx.Wrap(err, op)
 	}
 
+	avatar.Source = avatars.SourceFromString(avatarSource)
+	res.AvatarURL, err = h.avatarURLs.UserAvatarURL(ctx, query.ID, avatar)
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to build avatar url")
+		return nil, errorx.Wrap(err, op)
+	}
+
 	return &res, nil
 }
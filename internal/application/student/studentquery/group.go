@@ -0,0 +1,153 @@
+package studentquery
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/group"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/ctxs"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx"
+)
+
+// DefaultGroupCacheTTL bounds how long a group read from the cache may be
+// stale when its invalidation was missed.
+const DefaultGroupCacheTTL = time.Minute
+
+type GetGroup struct {
+	ID group.ID
+}
+
+type GetGroupResponse struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Major string `json:"major"`
+	Year  string `json:"year"`
+	// MemberCount comes from the group summaries, it follows the transfers
+	// and registrations within the eventual consistency of their events.
+	MemberCount int `json:"member_count"`
+	// EnrollmentOpensAt and EnrollmentClosesAt bound when the students may
+	// join the group, a null end leaves that side open.
+	EnrollmentOpensAt  *time.Time `json:"enrollment_opens_at"`
+	EnrollmentClosesAt *time.Time `json:"enrollment_closes_at"`
+	// TermID is the term the group studies in, null when it has none.
+	TermID *string `json:"term_id"`
+}
+
+// GetGroupHandler gets a group with its member count. The groups are cached
+// in the process, Invalidate drops the ones whose members changed.
+type GetGroupHandler struct {
+	tracer trace.Tracer
+	logger *slog.Logger
+	pool   *pgxpool.Pool
+	ttl    time.Duration
+
+	mu     sync.Mutex
+	cached map[group.ID]cachedGroup
+}
+
+type cachedGroup struct {
+	res     GetGroupResponse
+	expires time.Time
+}
+
+type GetGroupHandlerArgs struct {
+	Tracer trace.Tracer
+	Logger *slog.Logger
+	Pool   *pgxpool.Pool
+	// CacheTTL defaults to DefaultGroupCacheTTL.
+	CacheTTL time.Duration
+}
+
+func NewGetGroupHandler(args GetGroupHandlerArgs) *GetGroupHandler {
+	if args.Tracer == nil {
+		args.Tracer = tracer
+	}
+	if args.Logger == nil {
+		args.Logger = logger
+	}
+	if args.CacheTTL <= 0 {
+		args.CacheTTL = DefaultGroupCacheTTL
+	}
+
+	return &GetGroupHandler{
+		tracer: args.Tracer,
+		logger: args.Logger,
+		pool:   args.Pool,
+		ttl:    args.CacheTTL,
+		cached: make(map[group.ID]cachedGroup),
+	}
+}
+
+func (h *GetGroupHandler) Handle(ctx context.Context, query GetGroup) (*GetGroupResponse, error) {
+	const op = "studentquery.GetGroupHandler.Handle"
+	ctx, span := h.tracer.Start(ctx, "GetGroupHandler.Handle",
+		trace.WithAttributes(attribute.String("group.id", query.ID.String())),
+	)
+	defer span.End()
+
+	// A campus scoped read must neither be served from nor fill the cache of
+	// the unscoped ones.
+	var scope *string
+	if id, ok := ctxs.CampusScopeFromCtx(ctx); ok {
+		scope = &id
+	} else if res, ok := h.lookup(query.ID); ok {
+		span.SetAttributes(attribute.Bool("cache.hit", true))
+		return &res, nil
+	}
+
+	var res GetGroupResponse
+	err := h.pool.QueryRow(ctx, `
+        SELECT g.id, g.name, g.major, g.year, coalesce(gs.member_count, 0),
+               g.enrollment_opens_at, g.enrollment_closes_at, g.term_id
+        FROM groups g LEFT JOIN group_summaries gs ON gs.group_id = g.id
+        WHERE g.id = $1 AND ($2::text IS NULL OR g.campus_id = $2)
+    `, query.ID, scope).Scan(&res.ID, &res.Name, &res.Major, &res.Year, &res.MemberCount,
+		&res.EnrollmentOpensAt, &res.EnrollmentClosesAt, &res.TermID)
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to get group by id")
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errorx.NewNotFound().WithCause(err, op)
+		}
+		return nil, errorx.Wrap(err, op)
+	}
+
+	if scope == nil {
+		h.store(query.ID, res)
+	}
+	return &res, nil
+}
+
+// Invalidate drops the groups from the cache.
+func (h *GetGroupHandler) Invalidate(groupIDs ...group.ID) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, id := range groupIDs {
+		delete(h.cached, id)
+	}
+}
+
+func (h *GetGroupHandler) lookup(id group.ID) (GetGroupResponse, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	c, ok := h.cached[id]
+	if !ok || time.Now().After(c.expires) {
+		delete(h.cached, id)
+		return GetGroupResponse{}, false
+	}
+	return c.res, true
+}
+
+func (h *GetGroupHandler) store(id group.ID, res GetGroupResponse) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.cached[id] = cachedGroup{res: res, expires: time.Now().Add(h.ttl)}
+}
@@ -0,0 +1,99 @@
+package studentquery
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/group"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx"
+)
+
+type GroupLister interface {
+	ListGroups(ctx context.Context) ([]*group.Group, error)
+}
+
+type ListGroups struct{}
+
+type GroupListItem struct {
+	ID                 string     `json:"id"`
+	Name               string     `json:"name"`
+	Major              string     `json:"major"`
+	Year               string     `json:"year"`
+	EnrollmentOpensAt  *time.Time `json:"enrollment_opens_at"`
+	EnrollmentClosesAt *time.Time `json:"enrollment_closes_at"`
+	// EnrollmentOpen tells whether a student can join the group now, by
+	// registering or by a transfer.
+	EnrollmentOpen bool `json:"enrollment_open"`
+}
+
+type ListGroupsResponse struct {
+	Groups []GroupListItem `json:"groups"`
+}
+
+// ListGroupsHandler lists the groups the registering students choose from.
+type ListGroupsHandler struct {
+	tracer trace.Tracer
+	logger *slog.Logger
+	groups GroupLister
+	clock  clock.Clock
+}
+
+type ListGroupsHandlerArgs struct {
+	Tracer trace.Tracer
+	Logger *slog.Logger
+	Groups GroupLister
+	// Clock is the time the enrollment windows are checked at, defaults to
+	// clock.Real.
+	Clock clock.Clock
+}
+
+func NewListGroupsHandler(args ListGroupsHandlerArgs) *ListGroupsHandler {
+	if args.Tracer == nil {
+		args.Tracer = tracer
+	}
+	if args.Logger == nil {
+		args.Logger = logger
+	}
+
+	return &ListGroupsHandler{
+		tracer: args.Tracer,
+		logger: args.Logger,
+		groups: args.Groups,
+		clock:  clock.Or(args.Clock),
+	}
+}
+
+func (h *ListGroupsHandler) Handle(ctx context.Context, _ ListGroups) (*ListGroupsResponse, error) {
+	const op = "studentquery.ListGroupsHandler.Handle"
+	ctx, span := h.tracer.Start(ctx, "ListGroupsHandler.Handle")
+	defer span.End()
+
+	groups, err := h.groups.ListGroups(ctx)
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to list groups")
+		return nil, errorx.Wrap(err, op)
+	}
+	span.SetAttributes(attribute.Int("groups.count", len(groups)))
+
+	now := h.clock.Now()
+	res := ListGroupsResponse{Groups: make([]GroupListItem, len(groups))}
+	for i, g := range groups {
+		res.Groups[i] = GroupListItem{
+			ID:                 g.ID().String(),
+			Name:               g.Name(),
+			Major:              g.Major().String(),
+			Year:               g.Year(),
+			EnrollmentOpensAt:  g.EnrollmentOpensAt(),
+			EnrollmentClosesAt: g.EnrollmentClosesAt(),
+			EnrollmentOpen:     g.EnrollmentOpen(now),
+		}
+	}
+
+	return &res, nil
+}
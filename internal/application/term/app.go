@@ -0,0 +1,77 @@
+package termapp
+
+import (
+	"log/slog"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/application/term/cmd"
+	"gitlab.com/ucmsv2/ucms-backend/internal/application/term/termquery"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx"
+)
+
+type App struct {
+	Command Command
+	Query   Query
+}
+
+type Command struct {
+	CreateTerm otelx.Handler[cmd.CreateTerm]
+	UpdateTerm otelx.Handler[cmd.UpdateTerm]
+	DeleteTerm otelx.Handler[cmd.DeleteTerm]
+}
+
+type Query struct {
+	Term *termquery.TermHandler
+}
+
+type TermRepo interface {
+	cmd.TermRepo
+	termquery.TermReader
+}
+
+type Args struct {
+	Tracer   trace.Tracer
+	Logger   *slog.Logger
+	TermRepo TermRepo
+	// Clock defaults to clock.Real.
+	Clock clock.Clock
+}
+
+func NewApp(args Args) *App {
+	return &App{
+		Command: Command{
+			CreateTerm: otelx.InstrumentCommand[cmd.CreateTerm](
+				"CreateTermHandler.Handle",
+				cmd.NewCreateTermHandler(cmd.CreateTermHandlerArgs{
+					Logger:   args.Logger,
+					TermRepo: args.TermRepo,
+					Clock:    args.Clock,
+				}),
+			),
+			UpdateTerm: otelx.InstrumentCommand[cmd.UpdateTerm](
+				"UpdateTermHandler.Handle",
+				cmd.NewUpdateTermHandler(cmd.UpdateTermHandlerArgs{
+					Logger:   args.Logger,
+					TermRepo: args.TermRepo,
+				}),
+			),
+			DeleteTerm: otelx.InstrumentCommand[cmd.DeleteTerm](
+				"DeleteTermHandler.Handle",
+				cmd.NewDeleteTermHandler(cmd.DeleteTermHandlerArgs{
+					Logger:   args.Logger,
+					TermRepo: args.TermRepo,
+				}),
+			),
+		},
+		Query: Query{
+			Term: termquery.NewTermHandler(termquery.TermHandlerArgs{
+				Tracer:   args.Tracer,
+				Logger:   args.Logger,
+				TermRepo: args.TermRepo,
+				Clock:    args.Clock,
+			}),
+		},
+	}
+}
@@ -0,0 +1,232 @@
+package cmd
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"go.opentelemetry.io/contrib/bridges/otelslog"
+	"go.opentelemetry.io/otel/trace"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/term"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/optional"
+)
+
+var logger = otelslog.NewLogger("ucms/internal/application/term/cmd")
+
+// TermRepo stores the terms, the writes fail with term.ErrOverlap when the
+// term overlaps another one.
+type TermRepo interface {
+	SaveTerm(ctx context.Context, t *term.Term) error
+	UpdateTerm(ctx context.Context, id term.ID, fn func(context.Context, *term.Term) error) error
+	DeleteTerm(ctx context.Context, id term.ID) error
+}
+
+// CreateTerm adds an academic term, it can not overlap the other terms.
+type CreateTerm struct {
+	// ID is generated by the caller, so it can answer with it.
+	ID       term.ID
+	StaffID  user.ID
+	Code     string
+	Name     string
+	StartsAt time.Time
+	EndsAt   time.Time
+}
+
+func (c CreateTerm) SpanAttrs() map[string]any {
+	return map[string]any{
+		"staff_id":  c.StaffID.String(),
+		"term.id":   c.ID.String(),
+		"term.code": c.Code,
+	}
+}
+
+type CreateTermHandler struct {
+	logger *slog.Logger
+	repo   TermRepo
+	clock  clock.Clock
+}
+
+type CreateTermHandlerArgs struct {
+	Logger   *slog.Logger
+	TermRepo TermRepo
+	// Clock defaults to clock.Real.
+	Clock clock.Clock
+}
+
+func NewCreateTermHandler(args CreateTermHandlerArgs) *CreateTermHandler {
+	h := &CreateTermHandler{
+		logger: args.Logger,
+		repo:   args.TermRepo,
+		clock:  args.Clock,
+	}
+
+	if h.logger == nil {
+		h.logger = logger
+	}
+
+	return h
+}
+
+func (h *CreateTermHandler) Handle(ctx context.Context, cmd CreateTerm) error {
+	const op = "cmd.CreateTermHandler.Handle"
+	span := trace.SpanFromContext(ctx)
+
+	t, err := term.New(term.CreateArgs{
+		ID:       cmd.ID,
+		Code:     cmd.Code,
+		Name:     cmd.Name,
+		StartsAt: cmd.StartsAt,
+		EndsAt:   cmd.EndsAt,
+		Clock:    h.clock,
+	})
+	if err != nil {
+		span.AddEvent("invalid term")
+		return errorx.Wrap(err, op)
+	}
+
+	if err := h.repo.SaveTerm(ctx, t); err != nil {
+		span.AddEvent("failed to save term")
+		return errorx.Wrap(err, op)
+	}
+
+	h.logger.InfoContext(ctx, "term created",
+		slog.String("term.id", t.ID().String()),
+		slog.String("term.code", t.Code()),
+		slog.String("staff_id", cmd.StaffID.String()))
+
+	return nil
+}
+
+// UpdateTerm changes the fields of a term, a field that is not set is kept.
+// The fields are required, a null one fails validation.
+type UpdateTerm struct {
+	StaffID  user.ID
+	ID       term.ID
+	Code     optional.Field[string]
+	Name     optional.Field[string]
+	StartsAt optional.Field[time.Time]
+	EndsAt   optional.Field[time.Time]
+}
+
+func (c UpdateTerm) SpanAttrs() map[string]any {
+	return map[string]any{
+		"staff_id":          c.StaffID.String(),
+		"term.id":           c.ID.String(),
+		"code_changed":      c.Code.Set,
+		"name_changed":      c.Name.Set,
+		"starts_at_changed": c.StartsAt.Set,
+		"ends_at_changed":   c.EndsAt.Set,
+	}
+}
+
+type UpdateTermHandler struct {
+	logger *slog.Logger
+	repo   TermRepo
+}
+
+type UpdateTermHandlerArgs struct {
+	Logger   *slog.Logger
+	TermRepo TermRepo
+}
+
+func NewUpdateTermHandler(args UpdateTermHandlerArgs) *UpdateTermHandler {
+	h := &UpdateTermHandler{
+		logger: args.Logger,
+		repo:   args.TermRepo,
+	}
+
+	if h.logger == nil {
+		h.logger = logger
+	}
+
+	return h
+}
+
+func (h *UpdateTermHandler) Handle(ctx context.Context, cmd UpdateTerm) error {
+	const op = "cmd.UpdateTermHandler.Handle"
+	span := trace.SpanFromContext(ctx)
+	if !cmd.Code.Set && !cmd.Name.Set && !cmd.StartsAt.Set && !cmd.EndsAt.Set {
+		span.AddEvent("empty patch, nothing to update")
+		return nil
+	}
+
+	var changed bool
+	err := h.repo.UpdateTerm(ctx, cmd.ID, func(_ context.Context, t *term.Term) error {
+		var err error
+		changed, err = t.Update(term.UpdateArgs{
+			Code:     cmd.Code.Apply(t.Code()),
+			Name:     cmd.Name.Apply(t.Name()),
+			StartsAt: cmd.StartsAt.Apply(t.StartsAt()),
+			EndsAt:   cmd.EndsAt.Apply(t.EndsAt()),
+		})
+		return err
+	})
+	if err != nil {
+		span.AddEvent("failed to update term")
+		return errorx.Wrap(err, op)
+	}
+
+	if changed {
+		h.logger.InfoContext(ctx, "term updated",
+			slog.String("term.id", cmd.ID.String()),
+			slog.String("staff_id", cmd.StaffID.String()))
+	}
+
+	return nil
+}
+
+// DeleteTerm deletes a term no group studies in.
+type DeleteTerm struct {
+	StaffID user.ID
+	ID      term.ID
+}
+
+func (c DeleteTerm) SpanAttrs() map[string]any {
+	return map[string]any{
+		"staff_id": c.StaffID.String(),
+		"term.id":  c.ID.String(),
+	}
+}
+
+type DeleteTermHandler struct {
+	logger *slog.Logger
+	repo   TermRepo
+}
+
+type DeleteTermHandlerArgs struct {
+	Logger   *slog.Logger
+	TermRepo TermRepo
+}
+
+func NewDeleteTermHandler(args DeleteTermHandlerArgs) *DeleteTermHandler {
+	h := &DeleteTermHandler{
+		logger: args.Logger,
+		repo:   args.TermRepo,
+	}
+
+	if h.logger == nil {
+		h.logger = logger
+	}
+
+	return h
+}
+
+func (h *DeleteTermHandler) Handle(ctx context.Context, cmd DeleteTerm) error {
+	const op = "cmd.DeleteTermHandler.Handle"
+	span := trace.SpanFromContext(ctx)
+
+	if err := h.repo.DeleteTerm(ctx, cmd.ID); err != nil {
+		span.AddEvent("failed to delete term")
+		return errorx.Wrap(err, op)
+	}
+
+	h.logger.InfoContext(ctx, "term deleted",
+		slog.String("term.id", cmd.ID.String()),
+		slog.String("staff_id", cmd.StaffID.String()))
+
+	return nil
+}
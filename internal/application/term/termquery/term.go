@@ -0,0 +1,144 @@
+package termquery
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"go.opentelemetry.io/contrib/bridges/otelslog"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/term"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/httpx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/i18nx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx"
+)
+
+var (
+	tracer = otel.Tracer("ucms/internal/application/term/query")
+	logger = otelslog.NewLogger("ucms/internal/application/term/query")
+)
+
+type TermReader interface {
+	term.CurrentGetter
+	GetTermByID(ctx context.Context, id term.ID) (*term.Term, error)
+	ListTerms(ctx context.Context) ([]*term.Term, error)
+}
+
+// TermResponse is a term of the API, Current tells whether now is in it.
+type TermResponse struct {
+	ID        string     `json:"id"`
+	Code      string     `json:"code"`
+	Name      string     `json:"name"`
+	StartsAt  httpx.Time `json:"starts_at"`
+	EndsAt    httpx.Time `json:"ends_at"`
+	Current   bool       `json:"current"`
+	CreatedAt httpx.Time `json:"created_at"`
+	UpdatedAt httpx.Time `json:"updated_at"`
+}
+
+func newTermResponse(t *term.Term, now time.Time) TermResponse {
+	return TermResponse{
+		ID:        t.ID().String(),
+		Code:      t.Code(),
+		Name:      t.Name(),
+		StartsAt:  httpx.NewTime(t.StartsAt()),
+		EndsAt:    httpx.NewTime(t.EndsAt()),
+		Current:   t.Contains(now),
+		CreatedAt: httpx.NewTime(t.CreatedAt()),
+		UpdatedAt: httpx.NewTime(t.UpdatedAt()),
+	}
+}
+
+// TermHandler reads the terms.
+type TermHandler struct {
+	tracer trace.Tracer
+	logger *slog.Logger
+	repo   TermReader
+	clock  clock.Clock
+}
+
+type TermHandlerArgs struct {
+	Tracer   trace.Tracer
+	Logger   *slog.Logger
+	TermRepo TermReader
+	// Clock decides the current term, defaults to clock.Real.
+	Clock clock.Clock
+}
+
+func NewTermHandler(args TermHandlerArgs) *TermHandler {
+	if args.Tracer == nil {
+		args.Tracer = tracer
+	}
+	if args.Logger == nil {
+		args.Logger = logger
+	}
+
+	return &TermHandler{
+		tracer: args.Tracer,
+		logger: args.Logger,
+		repo:   args.TermRepo,
+		clock:  args.Clock,
+	}
+}
+
+// List returns all the terms, the earliest first.
+func (h *TermHandler) List(ctx context.Context) ([]TermResponse, error) {
+	const op = "termquery.TermHandler.List"
+	ctx, span := h.tracer.Start(ctx, "TermHandler.List")
+	defer span.End()
+
+	terms, err := h.repo.ListTerms(ctx)
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to list terms")
+		return nil, errorx.Wrap(err, op)
+	}
+
+	now := clock.Or(h.clock).Now()
+	res := make([]TermResponse, 0, len(terms))
+	for _, t := range terms {
+		res = append(res, newTermResponse(t, now))
+	}
+	return res, nil
+}
+
+func (h *TermHandler) Get(ctx context.Context, id term.ID) (*TermResponse, error) {
+	const op = "termquery.TermHandler.Get"
+	ctx, span := h.tracer.Start(ctx, "TermHandler.Get", trace.WithAttributes(
+		attribute.String("term.id", id.String()),
+	))
+	defer span.End()
+
+	t, err := h.repo.GetTermByID(ctx, id)
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to get term")
+		return nil, errorx.Wrap(err, op)
+	}
+
+	res := newTermResponse(t, clock.Or(h.clock).Now())
+	return &res, nil
+}
+
+// Current returns the term now is in, not found between the terms.
+func (h *TermHandler) Current(ctx context.Context) (*TermResponse, error) {
+	const op = "termquery.TermHandler.Current"
+	ctx, span := h.tracer.Start(ctx, "TermHandler.Current")
+	defer span.End()
+
+	now := clock.Or(h.clock).Now()
+	t, err := term.Current(ctx, h.repo, now)
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to get current term")
+		return nil, errorx.Wrap(err, op)
+	}
+	if t == nil {
+		return nil, errorx.NewResourceNotFound(i18nx.FieldTerm).WithOp(op)
+	}
+
+	res := newTermResponse(t, now)
+	return &res, nil
+}
@@ -0,0 +1,81 @@
+package tosapp
+
+import (
+	"log/slog"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/application/tos/cmd"
+	"gitlab.com/ucmsv2/ucms-backend/internal/application/tos/tosquery"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx"
+)
+
+type App struct {
+	Command Command
+	Query   Query
+}
+
+type Command struct {
+	PublishVersion otelx.Handler[cmd.PublishVersion]
+	AcceptTOS      otelx.Handler[cmd.AcceptTOS]
+}
+
+type Query struct {
+	Version *tosquery.VersionHandler
+	Consent *tosquery.ConsentHandler
+}
+
+type TOSRepo interface {
+	cmd.VersionSaver
+	cmd.CurrentVersionGetter
+	tosquery.VersionGetter
+	tosquery.ConsentChecker
+}
+
+type Args struct {
+	Tracer   trace.Tracer
+	Logger   *slog.Logger
+	TOSRepo  TOSRepo
+	UserRepo cmd.UserRepo
+	// Clock defaults to clock.Real.
+	Clock clock.Clock
+}
+
+func NewApp(args Args) *App {
+	return &App{
+		Command: Command{
+			PublishVersion: otelx.InstrumentCommand[cmd.PublishVersion](
+				"PublishVersionHandler.Handle",
+				cmd.NewPublishVersionHandler(cmd.PublishVersionHandlerArgs{
+					Logger:  args.Logger,
+					TOSRepo: args.TOSRepo,
+					Clock:   args.Clock,
+				}),
+			),
+			AcceptTOS: otelx.InstrumentCommand[cmd.AcceptTOS](
+				"AcceptTOSHandler.Handle",
+				cmd.NewAcceptTOSHandler(cmd.AcceptTOSHandlerArgs{
+					Logger:   args.Logger,
+					TOSRepo:  args.TOSRepo,
+					UserRepo: args.UserRepo,
+					Clock:    args.Clock,
+				}),
+			),
+		},
+		Query: Query{
+			Version: tosquery.NewVersionHandler(tosquery.VersionHandlerArgs{
+				Tracer:  args.Tracer,
+				Logger:  args.Logger,
+				TOSRepo: args.TOSRepo,
+				Clock:   args.Clock,
+			}),
+			Consent: tosquery.NewConsentHandler(tosquery.ConsentHandlerArgs{
+				Tracer:  args.Tracer,
+				Logger:  args.Logger,
+				TOSRepo: args.TOSRepo,
+				Clock:   args.Clock,
+			}),
+		},
+	}
+}
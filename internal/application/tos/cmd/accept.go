@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/tos"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+)
+
+type CurrentVersionGetter interface {
+	GetCurrentTOSVersion(ctx context.Context, now time.Time) (*tos.Version, error)
+}
+
+type UserRepo interface {
+	UpdateUser(ctx context.Context, id user.ID, fn func(context.Context, *user.User) error) error
+}
+
+// AcceptTOS records that the user accepted the current terms of service.
+type AcceptTOS struct {
+	UserID user.ID
+	// IP is the address the user accepted from.
+	IP string
+}
+
+func (c AcceptTOS) SpanAttrs() map[string]any {
+	return map[string]any{
+		"user_id": c.UserID.String(),
+	}
+}
+
+type AcceptTOSHandler struct {
+	logger   *slog.Logger
+	versions CurrentVersionGetter
+	users    UserRepo
+	clock    clock.Clock
+}
+
+type AcceptTOSHandlerArgs struct {
+	Logger   *slog.Logger
+	TOSRepo  CurrentVersionGetter
+	UserRepo UserRepo
+	// Clock defaults to clock.Real.
+	Clock clock.Clock
+}
+
+func NewAcceptTOSHandler(args AcceptTOSHandlerArgs) *AcceptTOSHandler {
+	h := &AcceptTOSHandler{
+		logger:   args.Logger,
+		versions: args.TOSRepo,
+		users:    args.UserRepo,
+		clock:    args.Clock,
+	}
+
+	if h.logger == nil {
+		h.logger = logger
+	}
+
+	return h
+}
+
+func (h *AcceptTOSHandler) Handle(ctx context.Context, cmd AcceptTOS) error {
+	const op = "cmd.AcceptTOSHandler.Handle"
+	span := trace.SpanFromContext(ctx)
+
+	current, err := h.versions.GetCurrentTOSVersion(ctx, clock.Or(h.clock).Now())
+	if err != nil {
+		span.AddEvent("failed to get current tos version")
+		return errorx.Wrap(err, op)
+	}
+
+	err = h.users.UpdateUser(ctx, cmd.UserID, func(_ context.Context, u *user.User) error {
+		return u.AcceptTOS(current.Version(), cmd.IP)
+	})
+	if err != nil {
+		span.AddEvent("failed to accept tos")
+		return errorx.Wrap(err, op)
+	}
+
+	h.logger.InfoContext(ctx, "terms of service accepted",
+		slog.String("tos.version", current.Version()),
+		slog.String("user_id", cmd.UserID.String()))
+
+	return nil
+}
@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"go.opentelemetry.io/contrib/bridges/otelslog"
+	"go.opentelemetry.io/otel/trace"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/tos"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+)
+
+var logger = otelslog.NewLogger("ucms/internal/application/tos/cmd")
+
+type VersionSaver interface {
+	SaveTOSVersion(ctx context.Context, v *tos.Version) error
+}
+
+// PublishVersion publishes a version of the terms of service, the users have
+// to accept it once it takes effect.
+type PublishVersion struct {
+	StaffID     user.ID
+	Version     string
+	DocumentURL string
+	// PublishedAt defaults to now, a later time schedules the version.
+	PublishedAt time.Time
+}
+
+func (c PublishVersion) SpanAttrs() map[string]any {
+	return map[string]any{
+		"staff_id":     c.StaffID.String(),
+		"tos.version":  c.Version,
+		"published_at": c.PublishedAt,
+	}
+}
+
+type PublishVersionHandler struct {
+	logger *slog.Logger
+	repo   VersionSaver
+	clock  clock.Clock
+}
+
+type PublishVersionHandlerArgs struct {
+	Logger  *slog.Logger
+	TOSRepo VersionSaver
+	// Clock defaults to clock.Real.
+	Clock clock.Clock
+}
+
+func NewPublishVersionHandler(args PublishVersionHandlerArgs) *PublishVersionHandler {
+	h := &PublishVersionHandler{
+		logger: args.Logger,
+		repo:   args.TOSRepo,
+		clock:  args.Clock,
+	}
+
+	if h.logger == nil {
+		h.logger = logger
+	}
+
+	return h
+}
+
+func (h *PublishVersionHandler) Handle(ctx context.Context, cmd PublishVersion) error {
+	const op = "cmd.PublishVersionHandler.Handle"
+	span := trace.SpanFromContext(ctx)
+
+	v, err := tos.NewVersion(tos.CreateArgs{
+		Version:     cmd.Version,
+		DocumentURL: cmd.DocumentURL,
+		PublishedAt: cmd.PublishedAt,
+		Clock:       h.clock,
+	})
+	if err != nil {
+		span.AddEvent("invalid tos version")
+		return errorx.Wrap(err, op)
+	}
+
+	if err := h.repo.SaveTOSVersion(ctx, v); err != nil {
+		span.AddEvent("failed to save tos version")
+		return errorx.Wrap(err, op)
+	}
+
+	h.logger.InfoContext(ctx, "terms of service version published",
+		slog.String("tos.version", v.Version()),
+		slog.Time("published_at", v.PublishedAt()),
+		slog.String("staff_id", cmd.StaffID.String()))
+
+	return nil
+}
@@ -0,0 +1,74 @@
+package tosquery
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/tos"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx"
+)
+
+type ConsentChecker interface {
+	RequiresTOSReconsent(ctx context.Context, userID user.ID, now time.Time) (bool, error)
+}
+
+// ConsentHandler gates the users who have not accepted the current terms of
+// service, the HTTP port asks it on every authenticated request.
+type ConsentHandler struct {
+	tracer trace.Tracer
+	logger *slog.Logger
+	repo   ConsentChecker
+	clock  clock.Clock
+}
+
+type ConsentHandlerArgs struct {
+	Tracer  trace.Tracer
+	Logger  *slog.Logger
+	TOSRepo ConsentChecker
+	// Clock defaults to clock.Real.
+	Clock clock.Clock
+}
+
+func NewConsentHandler(args ConsentHandlerArgs) *ConsentHandler {
+	if args.Tracer == nil {
+		args.Tracer = tracer
+	}
+	if args.Logger == nil {
+		args.Logger = logger
+	}
+
+	return &ConsentHandler{
+		tracer: args.Tracer,
+		logger: args.Logger,
+		repo:   args.TOSRepo,
+		clock:  args.Clock,
+	}
+}
+
+// CheckTOSConsent returns tos.ErrReconsentRequired when the user has not
+// accepted the current version.
+func (h *ConsentHandler) CheckTOSConsent(ctx context.Context, userID user.ID) error {
+	const op = "tosquery.ConsentHandler.CheckTOSConsent"
+	ctx, span := h.tracer.Start(ctx, "ConsentHandler.CheckTOSConsent",
+		trace.WithAttributes(attribute.String("user.id", userID.String())))
+	defer span.End()
+
+	required, err := h.repo.RequiresTOSReconsent(ctx, userID, clock.Or(h.clock).Now())
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to check tos consent")
+		return errorx.Wrap(err, op)
+	}
+	if required {
+		span.AddEvent("tos reconsent required")
+		return errorx.Wrap(tos.ErrReconsentRequired, op)
+	}
+
+	return nil
+}
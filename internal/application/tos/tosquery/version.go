@@ -0,0 +1,110 @@
+package tosquery
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"go.opentelemetry.io/contrib/bridges/otelslog"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/tos"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/httpx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx"
+)
+
+var (
+	tracer = otel.Tracer("ucms/internal/application/tos/query")
+	logger = otelslog.NewLogger("ucms/internal/application/tos/query")
+)
+
+type VersionGetter interface {
+	GetCurrentTOSVersion(ctx context.Context, now time.Time) (*tos.Version, error)
+	ListTOSVersions(ctx context.Context) ([]*tos.Version, error)
+}
+
+type VersionResponse struct {
+	Version     string     `json:"version"`
+	DocumentURL string     `json:"document_url"`
+	PublishedAt httpx.Time `json:"published_at"`
+}
+
+func NewVersionResponse(v *tos.Version) VersionResponse {
+	return VersionResponse{
+		Version:     v.Version(),
+		DocumentURL: v.DocumentURL(),
+		PublishedAt: httpx.NewTime(v.PublishedAt()),
+	}
+}
+
+// VersionHandler reads the versions of the terms of service, the current
+// one for the registration forms and all of them for the staff.
+type VersionHandler struct {
+	tracer trace.Tracer
+	logger *slog.Logger
+	repo   VersionGetter
+	clock  clock.Clock
+}
+
+type VersionHandlerArgs struct {
+	Tracer  trace.Tracer
+	Logger  *slog.Logger
+	TOSRepo VersionGetter
+	// Clock defaults to clock.Real.
+	Clock clock.Clock
+}
+
+func NewVersionHandler(args VersionHandlerArgs) *VersionHandler {
+	if args.Tracer == nil {
+		args.Tracer = tracer
+	}
+	if args.Logger == nil {
+		args.Logger = logger
+	}
+
+	return &VersionHandler{
+		tracer: args.Tracer,
+		logger: args.Logger,
+		repo:   args.TOSRepo,
+		clock:  args.Clock,
+	}
+}
+
+// Current returns the version in effect, not found while none is published.
+func (h *VersionHandler) Current(ctx context.Context) (*VersionResponse, error) {
+	const op = "tosquery.VersionHandler.Current"
+	ctx, span := h.tracer.Start(ctx, "VersionHandler.Current")
+	defer span.End()
+
+	v, err := h.repo.GetCurrentTOSVersion(ctx, clock.Or(h.clock).Now())
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to get current tos version")
+		return nil, errorx.Wrap(err, op)
+	}
+
+	res := NewVersionResponse(v)
+	return &res, nil
+}
+
+// List returns every version, the latest published first. The scheduled
+// versions are included.
+func (h *VersionHandler) List(ctx context.Context) ([]VersionResponse, error) {
+	const op = "tosquery.VersionHandler.List"
+	ctx, span := h.tracer.Start(ctx, "VersionHandler.List")
+	defer span.End()
+
+	versions, err := h.repo.ListTOSVersions(ctx)
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to list tos versions")
+		return nil, errorx.Wrap(err, op)
+	}
+
+	res := make([]VersionResponse, len(versions))
+	for i, v := range versions {
+		res[i] = NewVersionResponse(v)
+	}
+	return res, nil
+}
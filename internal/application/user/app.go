@@ -1,9 +1,13 @@
 package userapp
 
 import (
+	"time"
+
 	usercmd "gitlab.com/ucmsv2/ucms-backend/internal/application/user/cmd"
 	userevent "gitlab.com/ucmsv2/ucms-backend/internal/application/user/event"
+	"gitlab.com/ucmsv2/ucms-backend/internal/application/user/userquery"
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/storagex"
 )
 
 type App struct {
@@ -13,37 +17,93 @@ type App struct {
 }
 
 type Command struct {
-	UpdateAvatar *usercmd.UpdateAvatarHandler
-	DeleteAvatar *usercmd.DeleteAvatarHandler
+	UpdateAvatar           *usercmd.UpdateAvatarHandler
+	DeleteAvatar           *usercmd.DeleteAvatarHandler
+	CollectOrphanedAvatars *usercmd.CollectOrphanedAvatarsHandler
+	PromoteUser            *usercmd.PromoteUserHandler
+	// RequestEmailVerification and VerifyEmail confirm the email of the
+	// initial staff, see user.User.RequestEmailVerification.
+	RequestEmailVerification *usercmd.RequestEmailVerificationHandler
+	VerifyEmail              *usercmd.VerifyEmailHandler
 }
 
 type Event struct {
 	AvatarUpdated *userevent.AvatarUpdatedHandler
+	AvatarMissing *userevent.AvatarMissingHandler
 }
 
-type Query struct{}
+type Query struct {
+	ExportData        *userquery.ExportDataHandler
+	EmailVerification *userquery.EmailVerificationHandler
+}
+
+type AvatarStorage interface {
+	usercmd.AvatarStorage
+	usercmd.AvatarObjectStorage
+	storagex.ObjectOpener
+}
+
+type UserRepo interface {
+	usercmd.UserRepo
+	usercmd.PendingUploadRepo
+	usercmd.AvatarGCRepo
+	usercmd.UserPromoter
+	usercmd.EmailVerificationRepo
+	userevent.AvatarRefReleaser
+	userquery.UserGetter
+	userquery.EmailVerifiedChecker
+}
 
 type Args struct {
 	S3BaseURL     string
-	AvatarStorage usercmd.AvatarStorage
-	UserRepo      usercmd.UserRepo
+	AvatarStorage AvatarStorage
+	UserRepo      UserRepo
+	// UploadScanner checks uploads for malware, nil accepts everything.
+	UploadScanner *storagex.UploadScanner
+	// AvatarGCGracePeriod defaults to usercmd.DefaultAvatarGCGracePeriod.
+	AvatarGCGracePeriod time.Duration
 }
 
 func NewApp(args Args) *App {
 	return &App{
 		Command: Command{
 			UpdateAvatar: usercmd.NewUpdateAvatarHandler(usercmd.UpdateAvatarHandlerArgs{
-				AvatarDomainService: &user.AvatarService{},
+				AvatarDomainService: user.NewAvatarService(args.S3BaseURL),
 				Storage:             args.AvatarStorage,
 				UserRepo:            args.UserRepo,
+				Uploads:             args.UserRepo,
+				Scanner:             args.UploadScanner,
 			}),
 			DeleteAvatar: usercmd.NewDeleteAvatarHandler(usercmd.DeleteAVatarHandlerArgs{
 				UserRepo: args.UserRepo,
 			}),
+			CollectOrphanedAvatars: usercmd.NewCollectOrphanedAvatarsHandler(usercmd.CollectOrphanedAvatarsHandlerArgs{
+				Storage:     args.AvatarStorage,
+				UserRepo:    args.UserRepo,
+				GracePeriod: args.AvatarGCGracePeriod,
+			}),
+			PromoteUser: usercmd.NewPromoteUserHandler(usercmd.PromoteUserHandlerArgs{
+				UserRepo: args.UserRepo,
+			}),
+			RequestEmailVerification: usercmd.NewRequestEmailVerificationHandler(usercmd.RequestEmailVerificationHandlerArgs{
+				UserRepo: args.UserRepo,
+			}),
+			VerifyEmail: usercmd.NewVerifyEmailHandler(usercmd.VerifyEmailHandlerArgs{
+				UserRepo: args.UserRepo,
+			}),
 		},
 		Event: Event{
-			AvatarUpdated: userevent.NewAvatarUpdatedHandler(args.AvatarStorage),
+			AvatarUpdated: userevent.NewAvatarUpdatedHandler(args.AvatarStorage, args.UserRepo),
+			AvatarMissing: userevent.NewAvatarMissingHandler(args.AvatarStorage, args.UserRepo),
+		},
+		Query: Query{
+			ExportData: userquery.NewExportDataHandler(userquery.ExportDataHandlerArgs{
+				UserRepo: args.UserRepo,
+				Storage:  args.AvatarStorage,
+			}),
+			EmailVerification: userquery.NewEmailVerificationHandler(userquery.EmailVerificationHandlerArgs{
+				UserRepo: args.UserRepo,
+			}),
 		},
-		Query: Query{},
 	}
 }
@@ -0,0 +1,316 @@
+package usercmd
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"go.opentelemetry.io/contrib/bridges/otelslog"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/storagex"
+)
+
+const (
+	DefaultAvatarGCGracePeriod = 24 * time.Hour
+	defaultAvatarGCPageSize    = 500
+)
+
+var (
+	meter  = otel.Meter("ucms/internal/application/user/cmd")
+	logger = otelslog.NewLogger("ucms/internal/application/user/cmd")
+)
+
+type AvatarObjectStorage interface {
+	ListObjects(ctx context.Context, prefix, token string, limit int) ([]storagex.ObjectInfo, string, error)
+	HeadObject(ctx context.Context, key string) (storagex.ObjectInfo, error)
+	DeleteFile(ctx context.Context, key string) error
+}
+
+type AvatarReferenceChecker interface {
+	FilterReferencedAvatarKeys(ctx context.Context, keys []string) (map[string]struct{}, error)
+	// ReleaseAvatarRef calls remove under the lock of the key unless the key
+	// was referenced meanwhile, the same as when an avatar is replaced.
+	ReleaseAvatarRef(ctx context.Context, key string, remove func(context.Context) error) (bool, error)
+}
+
+// AvatarReferenceRepairer clears the references to the avatar objects that
+// are gone.
+type AvatarReferenceRepairer interface {
+	ListAvatarReferences(ctx context.Context, after user.ID, limit int) ([]user.AvatarReference, error)
+	UserRepo
+}
+
+type AvatarGCRepo interface {
+	AvatarReferenceChecker
+	AvatarReferenceRepairer
+}
+
+type CollectOrphanedAvatars struct {
+	// DryRun only reports the orphans without deleting them.
+	DryRun bool
+}
+
+type CollectOrphanedAvatarsResult struct {
+	Scanned  int
+	Orphaned []string
+	Deleted  int
+	Failed   int
+	// Referenced counts the orphans that were referenced again before they
+	// could be deleted, they are kept.
+	Referenced int
+
+	// Checked counts the avatar references checked, Dangling are the users
+	// whose avatar object is gone and Repaired those whose reference was
+	// cleared.
+	Checked  int
+	Dangling []user.ID
+	Repaired int
+}
+
+// CollectOrphanedAvatarsHandler removes avatar objects no user points to anymore,
+// e.g. replaced avatars whose cleanup event failed, then repairs the other
+// way round the users pointing to an object that is gone, e.g. deleted from
+// the bucket by hand.
+//
+// Objects younger than the grace period are never touched: the upload happens
+// before the user row is updated, so a fresh object may not be referenced yet.
+// The references need no grace period for the same reason.
+type CollectOrphanedAvatarsHandler struct {
+	tracer      trace.Tracer
+	logger      *slog.Logger
+	storage     AvatarObjectStorage
+	repo        AvatarGCRepo
+	gracePeriod time.Duration
+	pageSize    int
+	now         func() time.Time
+	deleted     metric.Int64Counter
+}
+
+type CollectOrphanedAvatarsHandlerArgs struct {
+	Tracer      trace.Tracer
+	Logger      *slog.Logger
+	Storage     AvatarObjectStorage
+	UserRepo    AvatarGCRepo
+	GracePeriod time.Duration
+	PageSize    int
+	Now         func() time.Time
+}
+
+func NewCollectOrphanedAvatarsHandler(args CollectOrphanedAvatarsHandlerArgs) *CollectOrphanedAvatarsHandler {
+	if args.Tracer == nil {
+		args.Tracer = tracer
+	}
+	if args.Logger == nil {
+		args.Logger = logger
+	}
+	if args.GracePeriod <= 0 {
+		args.GracePeriod = DefaultAvatarGCGracePeriod
+	}
+	if args.PageSize <= 0 {
+		args.PageSize = defaultAvatarGCPageSize
+	}
+	if args.Now == nil {
+		args.Now = time.Now
+	}
+
+	deleted, err := meter.Int64Counter("ucms.avatar.gc.deleted",
+		metric.WithDescription("Number of orphaned avatar objects removed by the garbage collector"),
+		metric.WithUnit("{object}"),
+	)
+	if err != nil {
+		args.Logger.Warn("failed to create avatar gc counter", slog.String("error", err.Error()))
+	}
+
+	return &CollectOrphanedAvatarsHandler{
+		tracer:      args.Tracer,
+		logger:      args.Logger,
+		storage:     args.Storage,
+		repo:        args.UserRepo,
+		gracePeriod: args.GracePeriod,
+		pageSize:    args.PageSize,
+		now:         args.Now,
+		deleted:     deleted,
+	}
+}
+
+func (h *CollectOrphanedAvatarsHandler) Handle(ctx context.Context, cmd CollectOrphanedAvatars) (*CollectOrphanedAvatarsResult, error) {
+	const op = "usercmd.CollectOrphanedAvatarsHandler.Handle"
+	ctx, span := h.tracer.Start(ctx, "CollectOrphanedAvatarsHandler.Handle", trace.WithAttributes(
+		attribute.Bool("gc.dry_run", cmd.DryRun),
+		attribute.String("gc.grace_period", h.gracePeriod.String()),
+	))
+	defer span.End()
+
+	res := &CollectOrphanedAvatarsResult{}
+	cutoff := h.now().Add(-h.gracePeriod)
+
+	var token string
+	for {
+		objects, next, err := h.storage.ListObjects(ctx, user.AvatarKeyPrefix, token, h.pageSize)
+		if err != nil {
+			otelx.RecordSpanError(span, err, "failed to list avatar objects")
+			return res, errorx.Wrap(err, op)
+		}
+		res.Scanned += len(objects)
+
+		candidates := make([]string, 0, len(objects))
+		for _, obj := range objects {
+			if obj.LastModified.After(cutoff) {
+				continue
+			}
+			candidates = append(candidates, obj.Key)
+		}
+
+		referenced, err := h.repo.FilterReferencedAvatarKeys(ctx, candidates)
+		if err != nil {
+			otelx.RecordSpanError(span, err, "failed to check avatar references")
+			return res, errorx.Wrap(err, op)
+		}
+
+		for _, key := range candidates {
+			if _, ok := referenced[key]; ok {
+				continue
+			}
+			res.Orphaned = append(res.Orphaned, key)
+			if cmd.DryRun {
+				h.logger.InfoContext(ctx, "orphaned avatar found (dry run)", slog.String("key", key))
+				continue
+			}
+
+			// The objects are shared by content hash, a user may take the key
+			// again while it is checked. The deletion holds the lock of the
+			// key and checks the references again under it.
+			removed, err := h.repo.ReleaseAvatarRef(ctx, key, func(ctx context.Context) error {
+				return h.storage.DeleteFile(ctx, key)
+			})
+			if err != nil {
+				res.Failed++
+				h.logger.WarnContext(ctx, "failed to delete orphaned avatar",
+					slog.String("key", key),
+					slog.String("error", err.Error()))
+				continue
+			}
+			if !removed {
+				res.Referenced++
+				h.logger.InfoContext(ctx, "orphaned avatar referenced again, kept", slog.String("key", key))
+				continue
+			}
+			res.Deleted++
+			if h.deleted != nil {
+				h.deleted.Add(ctx, 1)
+			}
+			h.logger.InfoContext(ctx, "deleted orphaned avatar", slog.String("key", key))
+		}
+
+		if next == "" {
+			break
+		}
+		token = next
+	}
+
+	if err := h.repairReferences(ctx, cmd, res); err != nil {
+		otelx.RecordSpanError(span, err, "failed to repair avatar references")
+		return res, errorx.Wrap(err, op)
+	}
+
+	span.SetAttributes(
+		attribute.Int("gc.scanned", res.Scanned),
+		attribute.Int("gc.orphaned", len(res.Orphaned)),
+		attribute.Int("gc.deleted", res.Deleted),
+		attribute.Int("gc.failed", res.Failed),
+		attribute.Int("gc.referenced", res.Referenced),
+		attribute.Int("gc.checked", res.Checked),
+		attribute.Int("gc.dangling", len(res.Dangling)),
+		attribute.Int("gc.repaired", res.Repaired),
+	)
+	h.logger.InfoContext(ctx, "avatar garbage collection finished",
+		slog.Bool("dry_run", cmd.DryRun),
+		slog.Int("scanned", res.Scanned),
+		slog.Int("orphaned", len(res.Orphaned)),
+		slog.Int("deleted", res.Deleted),
+		slog.Int("failed", res.Failed),
+		slog.Int("referenced", res.Referenced),
+		slog.Int("checked", res.Checked),
+		slog.Int("dangling", len(res.Dangling)),
+		slog.Int("repaired", res.Repaired))
+
+	return res, nil
+}
+
+// repairReferences clears the S3 avatars of the users whose object is gone.
+// Each key is checked once, the users sharing it are repaired together.
+func (h *CollectOrphanedAvatarsHandler) repairReferences(ctx context.Context, cmd CollectOrphanedAvatars, res *CollectOrphanedAvatarsResult) error {
+	exists := make(map[string]bool)
+	var after user.ID
+	for {
+		refs, err := h.repo.ListAvatarReferences(ctx, after, h.pageSize)
+		if err != nil {
+			return err
+		}
+		res.Checked += len(refs)
+
+		for _, ref := range refs {
+			found, checked := exists[ref.S3Key]
+			if !checked {
+				_, err := h.storage.HeadObject(ctx, ref.S3Key)
+				switch {
+				case err == nil:
+					found = true
+				case errors.Is(err, storagex.ErrObjectNotFound):
+					found = false
+				default:
+					res.Failed++
+					h.logger.WarnContext(ctx, "failed to check avatar object",
+						slog.String("key", ref.S3Key),
+						slog.String("error", err.Error()))
+					continue
+				}
+				exists[ref.S3Key] = found
+			}
+			if found {
+				continue
+			}
+
+			res.Dangling = append(res.Dangling, ref.UserID)
+			if cmd.DryRun {
+				h.logger.InfoContext(ctx, "dangling avatar reference found (dry run)",
+					slog.String("user_id", ref.UserID.String()),
+					slog.String("key", ref.S3Key))
+				continue
+			}
+
+			var cleared bool
+			err := h.repo.UpdateUser(ctx, ref.UserID, func(_ context.Context, u *user.User) error {
+				cleared = u.ClearMissingAvatar(ref.S3Key)
+				return nil
+			})
+			if err != nil {
+				res.Failed++
+				h.logger.WarnContext(ctx, "failed to clear dangling avatar reference",
+					slog.String("user_id", ref.UserID.String()),
+					slog.String("key", ref.S3Key),
+					slog.String("error", err.Error()))
+				continue
+			}
+			if cleared {
+				res.Repaired++
+				h.logger.InfoContext(ctx, "cleared dangling avatar reference",
+					slog.String("user_id", ref.UserID.String()),
+					slog.String("key", ref.S3Key))
+			}
+		}
+
+		if len(refs) < h.pageSize {
+			return nil
+		}
+		after = refs[len(refs)-1].UserID
+	}
+}
@@ -0,0 +1,197 @@
+package usercmd
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/adapters/services/fs"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/builders"
+	"gitlab.com/ucmsv2/ucms-backend/tests/mocks"
+)
+
+type avatarGCTestSuite struct {
+	storage    *fs.Storage
+	repo       *mocks.UserRepo
+	referenced []string
+	orphan     string
+}
+
+func newAvatarGCTestSuite(t *testing.T) *avatarGCTestSuite {
+	t.Helper()
+
+	storage, err := fs.NewStorage(t.TempDir(), fsBaseURL)
+	require.NoError(t, err)
+	repo := mocks.NewUserRepo()
+
+	s := &avatarGCTestSuite{storage: storage, repo: repo}
+	for range 2 {
+		key := user.AvatarKeyPrefix + user.NewID().String() + "/1"
+		repo.SeedUser(t, builders.NewUserBuilder().WithS3Avatar(key).Build())
+		require.NoError(t, storage.UploadFile(t.Context(), key, strings.NewReader("referenced"), "image/png"))
+		s.referenced = append(s.referenced, key)
+	}
+
+	s.orphan = user.AvatarKeyPrefix + user.NewID().String() + "/1"
+	require.NoError(t, storage.UploadFile(t.Context(), s.orphan, strings.NewReader("orphan"), "image/png"))
+
+	// Objects outside of the avatars prefix are not ours to collect.
+	require.NoError(t, storage.UploadFile(t.Context(), "other/file", strings.NewReader("other"), "text/plain"))
+
+	return s
+}
+
+func (s *avatarGCTestSuite) handler(now time.Time) *CollectOrphanedAvatarsHandler {
+	return NewCollectOrphanedAvatarsHandler(CollectOrphanedAvatarsHandlerArgs{
+		Storage:  s.storage,
+		UserRepo: s.repo,
+		PageSize: 1, // exercise pagination
+		Now:      func() time.Time { return now },
+	})
+}
+
+func TestCollectOrphanedAvatarsHandler_DeletesOnlyOrphans(t *testing.T) {
+	t.Parallel()
+	s := newAvatarGCTestSuite(t)
+
+	res, err := s.handler(time.Now().Add(48*time.Hour)).Handle(t.Context(), CollectOrphanedAvatars{})
+	require.NoError(t, err)
+
+	assert.Equal(t, 3, res.Scanned)
+	assert.Equal(t, []string{s.orphan}, res.Orphaned)
+	assert.Equal(t, 1, res.Deleted)
+	assert.Zero(t, res.Failed)
+
+	_, err = s.storage.HeadObject(t.Context(), s.orphan)
+	assert.Error(t, err, "orphan should be deleted")
+	for _, key := range s.referenced {
+		_, err := s.storage.HeadObject(t.Context(), key)
+		assert.NoError(t, err, "referenced avatar %s should survive", key)
+	}
+	_, err = s.storage.HeadObject(t.Context(), "other/file")
+	assert.NoError(t, err)
+}
+
+func TestCollectOrphanedAvatarsHandler_DryRun(t *testing.T) {
+	t.Parallel()
+	s := newAvatarGCTestSuite(t)
+
+	res, err := s.handler(time.Now().Add(48*time.Hour)).Handle(t.Context(), CollectOrphanedAvatars{DryRun: true})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{s.orphan}, res.Orphaned)
+	assert.Zero(t, res.Deleted)
+
+	_, err = s.storage.HeadObject(t.Context(), s.orphan)
+	assert.NoError(t, err, "dry run must not delete anything")
+}
+
+func TestCollectOrphanedAvatarsHandler_GracePeriod(t *testing.T) {
+	t.Parallel()
+	s := newAvatarGCTestSuite(t)
+
+	res, err := s.handler(time.Now()).Handle(t.Context(), CollectOrphanedAvatars{})
+	require.NoError(t, err)
+
+	assert.Empty(t, res.Orphaned, "fresh objects are within the grace period")
+	_, err = s.storage.HeadObject(t.Context(), s.orphan)
+	assert.NoError(t, err)
+}
+
+func TestCollectOrphanedAvatarsHandler_KeepsPendingUploads(t *testing.T) {
+	t.Parallel()
+	s := newAvatarGCTestSuite(t)
+	_, err := s.repo.CreatePendingUpload(t.Context(), user.NewID(), s.orphan)
+	require.NoError(t, err)
+
+	res, err := s.handler(time.Now().Add(48*time.Hour)).Handle(t.Context(), CollectOrphanedAvatars{})
+	require.NoError(t, err)
+
+	assert.Empty(t, res.Orphaned, "the object of a pending upload is not an orphan")
+	_, err = s.storage.HeadObject(t.Context(), s.orphan)
+	assert.NoError(t, err)
+
+	s.repo.ExpirePendingUploads()
+	res, err = s.handler(time.Now().Add(48*time.Hour)).Handle(t.Context(), CollectOrphanedAvatars{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{s.orphan}, res.Orphaned, "the object of an expired upload is collected")
+}
+
+func TestCollectOrphanedAvatarsHandler_RepairsDanglingReferences(t *testing.T) {
+	t.Parallel()
+	s := newAvatarGCTestSuite(t)
+	gone := s.referenced[0]
+	require.NoError(t, s.storage.DeleteFile(t.Context(), gone))
+	owner := builders.NewUserBuilder().WithS3Avatar(gone).Build()
+	s.repo.SeedUser(t, owner)
+
+	res, err := s.handler(time.Now()).Handle(t.Context(), CollectOrphanedAvatars{DryRun: true})
+	require.NoError(t, err)
+	assert.Equal(t, 3, res.Checked)
+	assert.Len(t, res.Dangling, 2, "both users of the object are reported")
+	assert.Zero(t, res.Repaired)
+	kept, err := s.repo.GetUserByID(t.Context(), owner.ID())
+	require.NoError(t, err)
+	assert.Equal(t, gone, kept.Avatar().S3Key, "dry run must not clear anything")
+
+	res, err = s.handler(time.Now()).Handle(t.Context(), CollectOrphanedAvatars{})
+	require.NoError(t, err)
+	assert.Len(t, res.Dangling, 2)
+	assert.Equal(t, 2, res.Repaired)
+	assert.Zero(t, res.Failed)
+	assert.Contains(t, res.Dangling, owner.ID())
+
+	updated, err := s.repo.GetUserByID(t.Context(), owner.ID())
+	require.NoError(t, err)
+	assert.True(t, updated.Avatar().IsZero(), "the reference to the missing object is cleared")
+	referenced, err := s.repo.FilterReferencedAvatarKeys(t.Context(), s.referenced)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]struct{}{s.referenced[1]: {}}, referenced, "the existing avatar is kept")
+
+	res, err = s.handler(time.Now()).Handle(t.Context(), CollectOrphanedAvatars{})
+	require.NoError(t, err)
+	assert.Equal(t, 1, res.Checked)
+	assert.Empty(t, res.Dangling)
+}
+
+// racingRepo references the orphan right after the sweep found it
+// unreferenced, as a user uploading the same image concurrently.
+type racingRepo struct {
+	*mocks.UserRepo
+	t      *testing.T
+	orphan string
+}
+
+func (r *racingRepo) FilterReferencedAvatarKeys(ctx context.Context, keys []string) (map[string]struct{}, error) {
+	referenced, err := r.UserRepo.FilterReferencedAvatarKeys(ctx, keys)
+	if slices.Contains(keys, r.orphan) {
+		r.SeedUser(r.t, builders.NewUserBuilder().WithS3Avatar(r.orphan).Build())
+	}
+	return referenced, err
+}
+
+func TestCollectOrphanedAvatarsHandler_ReferencedMeanwhile(t *testing.T) {
+	t.Parallel()
+	s := newAvatarGCTestSuite(t)
+
+	res, err := NewCollectOrphanedAvatarsHandler(CollectOrphanedAvatarsHandlerArgs{
+		Storage:  s.storage,
+		UserRepo: &racingRepo{UserRepo: s.repo, t: t, orphan: s.orphan},
+		PageSize: 1,
+		Now:      func() time.Time { return time.Now().Add(48 * time.Hour) },
+	}).Handle(t.Context(), CollectOrphanedAvatars{})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{s.orphan}, res.Orphaned)
+	assert.Zero(t, res.Deleted)
+	assert.Equal(t, 1, res.Referenced)
+	assert.Empty(t, res.Dangling, "the new reference is not repaired away")
+	_, err = s.storage.HeadObject(t.Context(), s.orphan)
+	assert.NoError(t, err, "the object referenced meanwhile must survive")
+}
@@ -0,0 +1,128 @@
+package usercmd
+
+import (
+	"context"
+	"log/slog"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx"
+)
+
+type EmailVerificationRepo interface {
+	UpdateEmailVerification(ctx context.Context, id user.ID, fn func(context.Context, *user.User) error) error
+}
+
+// RequestEmailVerification mails a code to a user who did not verify their
+// email yet, see user.User.RequestEmailVerification. It is sent on login.
+type RequestEmailVerification struct {
+	UserID user.ID
+}
+
+type RequestEmailVerificationHandler struct {
+	tracer trace.Tracer
+	logger *slog.Logger
+	repo   EmailVerificationRepo
+}
+
+type RequestEmailVerificationHandlerArgs struct {
+	Tracer   trace.Tracer
+	Logger   *slog.Logger
+	UserRepo EmailVerificationRepo
+}
+
+func NewRequestEmailVerificationHandler(args RequestEmailVerificationHandlerArgs) *RequestEmailVerificationHandler {
+	if args.Tracer == nil {
+		args.Tracer = tracer
+	}
+	if args.Logger == nil {
+		args.Logger = logger
+	}
+
+	return &RequestEmailVerificationHandler{
+		tracer: args.Tracer,
+		logger: args.Logger,
+		repo:   args.UserRepo,
+	}
+}
+
+func (h *RequestEmailVerificationHandler) Handle(ctx context.Context, cmd RequestEmailVerification) error {
+	const op = "usercmd.RequestEmailVerificationHandler.Handle"
+	ctx, span := h.tracer.Start(ctx, "RequestEmailVerificationHandler.Handle", trace.WithAttributes(
+		attribute.String("user.id", cmd.UserID.String()),
+	))
+	defer span.End()
+
+	sent := false
+	err := h.repo.UpdateEmailVerification(ctx, cmd.UserID, func(_ context.Context, u *user.User) error {
+		if err := u.RequestEmailVerification(); err != nil {
+			return err
+		}
+		sent = len(u.GetUncommittedEvents()) > 0
+		return nil
+	})
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to request email verification")
+		return errorx.Wrap(err, op)
+	}
+
+	if sent {
+		h.logger.InfoContext(ctx, "email verification code sent", slog.String("user.id", cmd.UserID.String()))
+	}
+	return nil
+}
+
+// VerifyEmail confirms the email of the user with the code mailed to them.
+type VerifyEmail struct {
+	UserID user.ID
+	Code   string
+}
+
+type VerifyEmailHandler struct {
+	tracer trace.Tracer
+	logger *slog.Logger
+	repo   EmailVerificationRepo
+}
+
+type VerifyEmailHandlerArgs struct {
+	Tracer   trace.Tracer
+	Logger   *slog.Logger
+	UserRepo EmailVerificationRepo
+}
+
+func NewVerifyEmailHandler(args VerifyEmailHandlerArgs) *VerifyEmailHandler {
+	if args.Tracer == nil {
+		args.Tracer = tracer
+	}
+	if args.Logger == nil {
+		args.Logger = logger
+	}
+
+	return &VerifyEmailHandler{
+		tracer: args.Tracer,
+		logger: args.Logger,
+		repo:   args.UserRepo,
+	}
+}
+
+func (h *VerifyEmailHandler) Handle(ctx context.Context, cmd VerifyEmail) error {
+	const op = "usercmd.VerifyEmailHandler.Handle"
+	ctx, span := h.tracer.Start(ctx, "VerifyEmailHandler.Handle", trace.WithAttributes(
+		attribute.String("user.id", cmd.UserID.String()),
+	))
+	defer span.End()
+
+	err := h.repo.UpdateEmailVerification(ctx, cmd.UserID, func(_ context.Context, u *user.User) error {
+		return u.VerifyEmail(cmd.Code)
+	})
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to verify email")
+		return errorx.Wrap(err, op)
+	}
+
+	h.logger.InfoContext(ctx, "email verified", slog.String("user.id", cmd.UserID.String()))
+	return nil
+}
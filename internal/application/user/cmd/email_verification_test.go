@@ -0,0 +1,40 @@
+package usercmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/event"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/builders"
+	"gitlab.com/ucmsv2/ucms-backend/tests/mocks"
+)
+
+func TestEmailVerificationHandlers(t *testing.T) {
+	t.Parallel()
+	u := builders.NewUserBuilder().AsStaff().WithEmailUnverified().Build()
+	repo := mocks.NewUserRepo()
+	repo.SeedUser(t, u)
+	request := NewRequestEmailVerificationHandler(RequestEmailVerificationHandlerArgs{UserRepo: repo})
+	verify := NewVerifyEmailHandler(VerifyEmailHandlerArgs{UserRepo: repo})
+
+	require.NoError(t, request.Handle(t.Context(), RequestEmailVerification{UserID: u.ID()}))
+	stored, err := repo.GetUserByID(t.Context(), u.ID())
+	require.NoError(t, err)
+	e := event.AssertSingleEvent[*user.EmailVerificationRequested](t, stored.GetUncommittedEvents())
+
+	err = verify.Handle(t.Context(), VerifyEmail{UserID: u.ID(), Code: "wrong"})
+	assert.True(t, errorx.IsCode(err, errorx.CodeValidationFailed), "unexpected error: %v", err)
+	stored, err = repo.GetUserByID(t.Context(), u.ID())
+	require.NoError(t, err)
+	require.NotNil(t, stored.EmailVerification())
+	assert.Equal(t, int8(1), stored.EmailVerification().Attempts, "the failed attempt is saved")
+
+	require.NoError(t, verify.Handle(t.Context(), VerifyEmail{UserID: u.ID(), Code: e.VerificationCode}))
+	verified, err := repo.IsEmailVerified(t.Context(), u.ID())
+	require.NoError(t, err)
+	assert.True(t, verified)
+}
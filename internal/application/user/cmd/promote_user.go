@@ -0,0 +1,87 @@
+package usercmd
+
+import (
+	"context"
+	"log/slog"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/roles"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx"
+)
+
+type UserPromoter interface {
+	PromoteUser(ctx context.Context, barcode user.Barcode, fn func(context.Context, *user.User) error) error
+}
+
+// PromoteUser raises the global role of a user, see user.User.Promote. It is
+// run by an operator with `ucms-api user promote`.
+type PromoteUser struct {
+	Barcode user.Barcode
+	Role    roles.Global
+}
+
+type PromoteUserResult struct {
+	UserID   user.ID
+	Username string
+	FromRole roles.Global
+	ToRole   roles.Global
+}
+
+type PromoteUserHandler struct {
+	tracer trace.Tracer
+	logger *slog.Logger
+	repo   UserPromoter
+}
+
+type PromoteUserHandlerArgs struct {
+	Tracer   trace.Tracer
+	Logger   *slog.Logger
+	UserRepo UserPromoter
+}
+
+func NewPromoteUserHandler(args PromoteUserHandlerArgs) *PromoteUserHandler {
+	if args.Tracer == nil {
+		args.Tracer = tracer
+	}
+	if args.Logger == nil {
+		args.Logger = logger
+	}
+
+	return &PromoteUserHandler{
+		tracer: args.Tracer,
+		logger: args.Logger,
+		repo:   args.UserRepo,
+	}
+}
+
+func (h *PromoteUserHandler) Handle(ctx context.Context, cmd PromoteUser) (*PromoteUserResult, error) {
+	const op = "usercmd.PromoteUserHandler.Handle"
+	ctx, span := h.tracer.Start(ctx, "PromoteUserHandler.Handle", trace.WithAttributes(
+		attribute.String("user.barcode", cmd.Barcode.String()),
+		attribute.String("user.role", cmd.Role.String()),
+	))
+	defer span.End()
+
+	res := &PromoteUserResult{ToRole: cmd.Role}
+	err := h.repo.PromoteUser(ctx, cmd.Barcode, func(ctx context.Context, u *user.User) error {
+		res.UserID = u.ID()
+		res.Username = u.Username()
+		res.FromRole = u.Role()
+		return u.Promote(cmd.Role)
+	})
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to promote user")
+		return nil, errorx.Wrap(err, op)
+	}
+
+	h.logger.InfoContext(ctx, "User promoted",
+		"user_id", res.UserID.String(),
+		"from_role", res.FromRole.String(),
+		"to_role", res.ToRole.String(),
+	)
+	return res, nil
+}
@@ -0,0 +1,58 @@
+package usercmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/roles"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/builders"
+	"gitlab.com/ucmsv2/ucms-backend/tests/mocks"
+)
+
+func TestPromoteUserHandler(t *testing.T) {
+	t.Parallel()
+
+	t.Run("student to aitusa", func(t *testing.T) {
+		t.Parallel()
+		u := builders.NewUserBuilder().WithRole(roles.Student).Build()
+		repo := mocks.NewUserRepo()
+		repo.SeedUser(t, u)
+		h := NewPromoteUserHandler(PromoteUserHandlerArgs{UserRepo: repo})
+
+		res, err := h.Handle(t.Context(), PromoteUser{Barcode: u.Barcode(), Role: roles.AITUSA})
+		require.NoError(t, err)
+		assert.Equal(t, u.ID(), res.UserID)
+		assert.Equal(t, roles.Student, res.FromRole)
+		assert.Equal(t, roles.AITUSA, res.ToRole)
+
+		stored, err := repo.GetUserByBarcode(t.Context(), u.Barcode())
+		require.NoError(t, err)
+		assert.Equal(t, roles.AITUSA, stored.Role())
+	})
+
+	t.Run("unknown role", func(t *testing.T) {
+		t.Parallel()
+		u := builders.NewUserBuilder().WithRole(roles.Student).Build()
+		repo := mocks.NewUserRepo()
+		repo.SeedUser(t, u)
+		h := NewPromoteUserHandler(PromoteUserHandlerArgs{UserRepo: repo})
+
+		_, err := h.Handle(t.Context(), PromoteUser{Barcode: u.Barcode(), Role: roles.Global("admin")})
+		assert.True(t, errorx.IsCode(err, errorx.CodeInvalid), "unexpected error: %v", err)
+
+		stored, err := repo.GetUserByBarcode(t.Context(), u.Barcode())
+		require.NoError(t, err)
+		assert.Equal(t, roles.Student, stored.Role())
+	})
+
+	t.Run("unknown user", func(t *testing.T) {
+		t.Parallel()
+		h := NewPromoteUserHandler(PromoteUserHandlerArgs{UserRepo: mocks.NewUserRepo()})
+
+		_, err := h.Handle(t.Context(), PromoteUser{Barcode: "unknown", Role: roles.Staff})
+		assert.True(t, errorx.IsNotFound(err), "expected not found, got %v", err)
+	})
+}
@@ -1,16 +1,22 @@
 package usercmd
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"io"
+	"log/slog"
 
+	"github.com/google/uuid"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
 
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
 	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/imagex"
 	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/storagex"
 )
 
 const (
@@ -22,44 +28,75 @@ var tracer = otel.Tracer("ucms/internal/application/user/cmd")
 type AvatarStorage interface {
 	UploadFile(ctx context.Context, key string, file io.Reader, contentType string) error
 	DeleteFile(ctx context.Context, key string) error
+	HeadObject(ctx context.Context, key string) (storagex.ObjectInfo, error)
 }
 
 type UserRepo interface {
 	UpdateUser(ctx context.Context, id user.ID, updateFn func(context.Context, *user.User) error) error
 }
 
+// PendingUploadRepo keeps the token of an avatar upload from before its
+// object is stored until it is accepted, the garbage collection keeps the
+// objects of the pending uploads.
+type PendingUploadRepo interface {
+	CreatePendingUpload(ctx context.Context, userID user.ID, key string) (uuid.UUID, error)
+	// ConsumePendingUpload fails with user.ErrUploadExpired once the token
+	// expired or was consumed.
+	ConsumePendingUpload(ctx context.Context, token uuid.UUID, userID user.ID, key string) error
+}
+
 type UpdateAvatar struct {
 	UserID      user.ID
 	File        io.Reader
 	Size        int64
 	ContentType string
 	Filename    string
+	// ContentMD5 is the optional base64 encoded MD5 of the file (Content-MD5 header).
+	ContentMD5 string
+	// ContentSHA256 is the optional hex encoded SHA-256 of the file.
+	ContentSHA256 string
 }
 
 type UpdateAvatarHandler struct {
 	tracer        trace.Tracer
+	logger        *slog.Logger
 	avatarService *user.AvatarService
 	storage       AvatarStorage
 	repo          UserRepo
+	uploads       PendingUploadRepo
+	scanner       *storagex.UploadScanner
 }
 
 type UpdateAvatarHandlerArgs struct {
 	Tracer              trace.Tracer
+	Logger              *slog.Logger
 	AvatarDomainService *user.AvatarService
 	Storage             AvatarStorage
 	UserRepo            UserRepo
+	Uploads             PendingUploadRepo
+	// Scanner checks uploads for malware, nil accepts everything.
+	Scanner *storagex.UploadScanner
 }
 
 func NewUpdateAvatarHandler(args UpdateAvatarHandlerArgs) *UpdateAvatarHandler {
 	if args.Tracer == nil {
 		args.Tracer = tracer
 	}
+	if args.Logger == nil {
+		args.Logger = logger
+	}
+	if args.Scanner == nil {
+		args.Scanner = storagex.NewUploadScanner(storagex.NoopScanner{}, storagex.UploadScannerConfig{})
+	}
 
 	return &UpdateAvatarHandler{
 		tracer:        args.Tracer,
+		logger:        args.Logger,
 		avatarService: args.AvatarDomainService,
 		storage:       args.Storage,
 		repo:          args.UserRepo,
+		uploads:       args.Uploads,
+		scanner:       args.Scanner,
 	}
 }
 
@@ -78,16 +115,88 @@ func (h *UpdateAvatarHandler) Handle(ctx context.Context, cmd *UpdateAvatar) err
 		return errorx.Wrap(err, op)
 	}
 
-	newS3Key := h.avatarService.GenerateS3Key(cmd.UserID)
+	content, err := io.ReadAll(io.LimitReader(cmd.File, MaxAvatarSize+1))
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to read avatar file")
+		return errorx.Wrap(err, op)
+	}
+	if err := h.avatarService.ValidateAvatarFile(cmd.ContentType, int64(len(content))); err != nil {
+		otelx.RecordSpanError(span, err, "invalid avatar file content")
+		return errorx.Wrap(err, op)
+	}
+	if err := h.avatarService.VerifyChecksum(content, cmd.ContentMD5, cmd.ContentSHA256); err != nil {
+		otelx.RecordSpanError(span, err, "avatar checksum mismatch")
+		return errorx.Wrap(err, op)
+	}
+
+	avatar, err := h.avatarService.ProcessAvatar(cmd.ContentType, content)
+	if err != nil {
+		otelx.RecordSpanError(span, err, "invalid avatar image")
+		return errorx.Wrap(err, op)
+	}
+	span.AddEvent("processed avatar image", trace.WithAttributes(
+		attribute.Int("image.width", avatar.Width),
+		attribute.Int("image.height", avatar.Height),
+		attribute.Bool("image.reencoded", avatar.Reencoded),
+	))
+
+	// The key is derived from the processed image, uploads that only differ
+	// in their metadata share an object.
+	newS3Key := h.avatarService.GenerateS3Key(avatar.Content)
 	span.AddEvent("generated new S3 key", trace.WithAttributes(attribute.String("s3.key", newS3Key)))
 
-	if err := h.storage.UploadFile(ctx, newS3Key, cmd.File, cmd.ContentType); err != nil {
-		otelx.RecordSpanError(span, err, "failed to upload avatar to storage")
+	// Scan the original upload before anything is stored, so a rejected
+	// upload leaves nothing behind.
+	scan, err := h.scanner.Check(ctx, bytes.NewReader(content))
+	switch {
+	case errors.Is(err, storagex.ErrInfected):
+		otelx.RecordSpanError(span, err, "infected avatar upload")
+		h.logger.WarnContext(ctx, "rejected infected upload",
+			slog.String("audit.event", "upload.infected"),
+			slog.String("user.id", cmd.UserID.String()),
+			slog.String("file.filename", cmd.Filename),
+			slog.String("file.key", newS3Key),
+			slog.String("scan.signature", scan.Signature))
+
+		// The rejection is persisted along with its event, the user is told
+		// about it even if this response is lost.
+		infected := err
+		err = h.repo.UpdateUser(ctx, cmd.UserID, func(ctx context.Context, u *user.User) error {
+			if err := u.RejectAvatar(cmd.Filename, scan.Signature); err != nil {
+				return err
+			}
+			return errorx.NewPersistable(infected)
+		})
+		if err != nil && !errors.Is(err, storagex.ErrInfected) {
+			otelx.RecordSpanError(span, err, "failed to record avatar rejection")
+			return errorx.Wrap(err, op)
+		}
+		return errorx.Wrap(infected, op)
+	case err != nil:
+		otelx.RecordSpanError(span, err, "failed to scan avatar")
+		return errorx.Wrap(err, op)
+	case scan.Skipped:
+		h.logger.WarnContext(ctx, "malware scanner unavailable, accepting upload unscanned",
+			slog.String("user.id", cmd.UserID.String()),
+			slog.String("file.key", newS3Key))
+	}
+
+	// The token keeps the object from the garbage collection while it is
+	// stored, the upload is only accepted while it is valid.
+	token, err := h.uploads.CreatePendingUpload(ctx, cmd.UserID, newS3Key)
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to create pending upload")
+		return errorx.Wrap(err, op)
+	}
+	if err := h.ensureStored(ctx, newS3Key, avatar); err != nil {
+		return errorx.Wrap(err, op)
+	}
+	if err := h.uploads.ConsumePendingUpload(ctx, token, cmd.UserID, newS3Key); err != nil {
+		otelx.RecordSpanError(span, err, "pending upload not accepted")
 		return errorx.Wrap(err, op)
 	}
-	span.AddEvent("uploaded new avatar to storage", trace.WithAttributes(attribute.String("s3.key", newS3Key)))
 
-	err := h.repo.UpdateUser(ctx, cmd.UserID, func(ctx context.Context, u *user.User) error {
+	err = h.repo.UpdateUser(ctx, cmd.UserID, func(ctx context.Context, u *user.User) error {
 		if err := u.SetAvatarFromS3(newS3Key); err != nil {
 			return errorx.Wrap(err, op)
 		}
@@ -98,5 +207,35 @@ func (h *UpdateAvatarHandler) Handle(ctx context.Context, cmd *UpdateAvatar) err
 		return errorx.Wrap(err, op)
 	}
 
+	// A shared object skipped above may have been deleted by the release of
+	// its last reference after the token was consumed and before our
+	// reference was committed. Nothing deletes it once
+	// our reference is committed, so checking again is enough.
+	if err := h.ensureStored(ctx, newS3Key, avatar); err != nil {
+		return errorx.Wrap(err, op)
+	}
+
+	return nil
+}
+
+// ensureStored uploads the avatar unless its key is already stored. The key
+// is the content hash, so an existing object already holds exactly these bytes.
+func (h *UpdateAvatarHandler) ensureStored(ctx context.Context, key string, avatar *imagex.Result) error {
+	span := trace.SpanFromContext(ctx)
+
+	_, err := h.storage.HeadObject(ctx, key)
+	switch {
+	case err == nil:
+		span.AddEvent("avatar already stored, skipping upload", trace.WithAttributes(attribute.String("s3.key", key)))
+	case errorx.IsNotFound(err):
+		if err := h.storage.UploadFile(ctx, key, bytes.NewReader(avatar.Content), avatar.Format.ContentType()); err != nil {
+			otelx.RecordSpanError(span, err, "failed to upload avatar to storage")
+			return err
+		}
+		span.AddEvent("uploaded new avatar to storage", trace.WithAttributes(attribute.String("s3.key", key)))
+	default:
+		otelx.RecordSpanError(span, err, "failed to check existing avatar in storage")
+		return err
+	}
 	return nil
 }
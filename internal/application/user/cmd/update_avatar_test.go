@@ -0,0 +1,463 @@
+package usercmd
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/adapters/services/clamav"
+	"gitlab.com/ucmsv2/ucms-backend/internal/adapters/services/fs"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/event"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/avatars"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/i18nx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/storagex"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/builders"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/fixtures"
+	"gitlab.com/ucmsv2/ucms-backend/tests/mocks"
+)
+
+const fsBaseURL = "http://localhost:8080/v1/files"
+
+func TestUpdateAvatarHandler_FSStorage(t *testing.T) {
+	t.Parallel()
+
+	storage, err := fs.NewStorage(t.TempDir(), fsBaseURL)
+	require.NoError(t, err)
+	repo := mocks.NewUserRepo()
+	handler := NewUpdateAvatarHandler(UpdateAvatarHandlerArgs{
+		AvatarDomainService: user.NewAvatarService(fsBaseURL),
+		Storage:             storage,
+		UserRepo:            repo,
+		Uploads:             repo,
+	})
+
+	u := builders.NewUserBuilder().WithEmptyAvatar().Build()
+	repo.SeedUser(t, u)
+
+	err = handler.Handle(t.Context(), &UpdateAvatar{
+		UserID:      u.ID(),
+		File:        bytes.NewReader(fixtures.ValidJPEGAvatar),
+		Size:        int64(len(fixtures.ValidJPEGAvatar)),
+		ContentType: "image/jpeg",
+		Filename:    "avatar.jpg",
+	})
+	require.NoError(t, err)
+
+	updated, err := repo.GetUserByID(t.Context(), u.ID())
+	require.NoError(t, err)
+	require.Equal(t, avatars.SourceS3, updated.Avatar().Source)
+
+	key := updated.Avatar().S3Key
+	data, err := storage.GetObject(t.Context(), key)
+	require.NoError(t, err)
+	processed, err := user.NewAvatarService(fsBaseURL).ProcessAvatar("image/jpeg", fixtures.ValidJPEGAvatar)
+	require.NoError(t, err)
+	assert.Equal(t, processed.Content, data, "the processed image should be stored")
+
+	info, err := storage.HeadObject(t.Context(), key)
+	require.NoError(t, err)
+	assert.Equal(t, "image/jpeg", info.ContentType)
+	assert.Equal(t, fsBaseURL+"/"+key, storage.URL(key))
+}
+
+func TestUpdateAvatarHandler_InvalidFileNotStored(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	storage, err := fs.NewStorage(root, fsBaseURL)
+	require.NoError(t, err)
+	repo := mocks.NewUserRepo()
+	handler := NewUpdateAvatarHandler(UpdateAvatarHandlerArgs{
+		AvatarDomainService: user.NewAvatarService(fsBaseURL),
+		Storage:             storage,
+		UserRepo:            repo,
+		Uploads:             repo,
+	})
+
+	u := builders.NewUserBuilder().WithEmptyAvatar().Build()
+	repo.SeedUser(t, u)
+
+	err = handler.Handle(t.Context(), &UpdateAvatar{
+		UserID:      u.ID(),
+		File:        bytes.NewReader(fixtures.InvalidFormatAvatar),
+		Size:        int64(len(fixtures.InvalidFormatAvatar)),
+		ContentType: "text/plain",
+		Filename:    "avatar.txt",
+	})
+	require.Error(t, err)
+
+	updated, err := repo.GetUserByID(t.Context(), u.ID())
+	require.NoError(t, err)
+	assert.True(t, updated.Avatar().IsZero())
+}
+
+type countingStorage struct {
+	*fs.Storage
+	uploads int
+}
+
+func (s *countingStorage) UploadFile(ctx context.Context, key string, file io.Reader, contentType string) error {
+	s.uploads++
+	return s.Storage.UploadFile(ctx, key, file, contentType)
+}
+
+func TestUpdateAvatarHandler_DedupesIdenticalContent(t *testing.T) {
+	t.Parallel()
+
+	fsStorage, err := fs.NewStorage(t.TempDir(), fsBaseURL)
+	require.NoError(t, err)
+	storage := &countingStorage{Storage: fsStorage}
+	repo := mocks.NewUserRepo()
+	handler := NewUpdateAvatarHandler(UpdateAvatarHandlerArgs{
+		AvatarDomainService: user.NewAvatarService(fsBaseURL),
+		Storage:             storage,
+		UserRepo:            repo,
+		Uploads:             repo,
+	})
+
+	first := builders.NewUserBuilder().WithEmptyAvatar().Build()
+	second := builders.NewUserBuilder().WithEmptyAvatar().Build()
+	repo.SeedUser(t, first)
+	repo.SeedUser(t, second)
+
+	for _, u := range []*user.User{first, second} {
+		err := handler.Handle(t.Context(), &UpdateAvatar{
+			UserID:      u.ID(),
+			File:        bytes.NewReader(fixtures.ValidJPEGAvatar),
+			Size:        int64(len(fixtures.ValidJPEGAvatar)),
+			ContentType: "image/jpeg",
+			Filename:    "avatar.jpg",
+		})
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, 1, storage.uploads, "second upload of the same content should be skipped")
+	assert.Equal(t, first.Avatar().S3Key, second.Avatar().S3Key, "users should share the object")
+	processed, err := user.NewAvatarService(fsBaseURL).ProcessAvatar("image/jpeg", fixtures.ValidJPEGAvatar)
+	require.NoError(t, err)
+	assert.Equal(t, user.NewAvatarService(fsBaseURL).GenerateS3Key(processed.Content), first.Avatar().S3Key)
+}
+
+func TestUpdateAvatarHandler_RejectsInvalidImages(t *testing.T) {
+	t.Parallel()
+
+	fsStorage, err := fs.NewStorage(t.TempDir(), fsBaseURL)
+	require.NoError(t, err)
+	storage := &countingStorage{Storage: fsStorage}
+	repo := mocks.NewUserRepo()
+	handler := NewUpdateAvatarHandler(UpdateAvatarHandlerArgs{
+		AvatarDomainService: user.NewAvatarService(fsBaseURL),
+		Storage:             storage,
+		UserRepo:            repo,
+		Uploads:             repo,
+	})
+
+	tests := []struct {
+		name        string
+		content     []byte
+		contentType string
+		wantKey     string
+	}{
+		{
+			name:        "animated gif",
+			content:     fixtures.AnimatedGIFAvatar,
+			contentType: "image/gif",
+			wantKey:     i18nx.KeyUploadImageAnimated,
+		},
+		{
+			name:        "content type mismatch",
+			content:     fixtures.ValidPNGAvatar,
+			contentType: "image/jpeg",
+			wantKey:     i18nx.KeyUploadImageInvalid,
+		},
+		{
+			name:        "not an image",
+			content:     fixtures.InvalidFormatAvatar,
+			contentType: "image/jpeg",
+			wantKey:     i18nx.KeyUploadImageInvalid,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := builders.NewUserBuilder().WithEmptyAvatar().Build()
+			repo.SeedUser(t, u)
+
+			err := handler.Handle(t.Context(), &UpdateAvatar{
+				UserID:      u.ID(),
+				File:        bytes.NewReader(tt.content),
+				Size:        int64(len(tt.content)),
+				ContentType: tt.contentType,
+				Filename:    "avatar",
+			})
+
+			var i18nErr *errorx.I18nError
+			require.ErrorAs(t, err, &i18nErr)
+			assert.Equal(t, http.StatusUnprocessableEntity, i18nErr.HTTPStatusCode())
+			assert.Equal(t, tt.wantKey, i18nErr.MessageKey)
+			assert.True(t, u.Avatar().IsZero())
+		})
+	}
+	assert.Zero(t, storage.uploads, "rejected images must not be stored")
+}
+
+func TestUpdateAvatarHandler_ChecksumMismatch(t *testing.T) {
+	t.Parallel()
+
+	fsStorage, err := fs.NewStorage(t.TempDir(), fsBaseURL)
+	require.NoError(t, err)
+	storage := &countingStorage{Storage: fsStorage}
+	repo := mocks.NewUserRepo()
+	handler := NewUpdateAvatarHandler(UpdateAvatarHandlerArgs{
+		AvatarDomainService: user.NewAvatarService(fsBaseURL),
+		Storage:             storage,
+		UserRepo:            repo,
+		Uploads:             repo,
+	})
+
+	u := builders.NewUserBuilder().WithEmptyAvatar().Build()
+	repo.SeedUser(t, u)
+
+	otherMD5 := md5.Sum([]byte("something else"))
+	err = handler.Handle(t.Context(), &UpdateAvatar{
+		UserID:      u.ID(),
+		File:        bytes.NewReader(fixtures.ValidJPEGAvatar),
+		Size:        int64(len(fixtures.ValidJPEGAvatar)),
+		ContentType: "image/jpeg",
+		Filename:    "avatar.jpg",
+		ContentMD5:  base64.StdEncoding.EncodeToString(otherMD5[:]),
+	})
+	require.ErrorIs(t, err, user.ErrAvatarChecksumMismatch)
+
+	var i18nErr *errorx.I18nError
+	require.ErrorAs(t, err, &i18nErr)
+	assert.Equal(t, http.StatusUnprocessableEntity, i18nErr.HTTPStatusCode())
+
+	assert.Zero(t, storage.uploads, "mismatched content must not be stored")
+	assert.True(t, u.Avatar().IsZero())
+}
+
+// releasingStorage deletes the object right after the first check finds it,
+// like the release of its last reference racing with the upload.
+type releasingStorage struct {
+	*countingStorage
+	released bool
+}
+
+func (s *releasingStorage) HeadObject(ctx context.Context, key string) (storagex.ObjectInfo, error) {
+	info, err := s.countingStorage.HeadObject(ctx, key)
+	if err == nil && !s.released {
+		s.released = true
+		return info, s.DeleteFile(ctx, key)
+	}
+	return info, err
+}
+
+func TestUpdateAvatarHandler_ReuploadsReleasedObject(t *testing.T) {
+	t.Parallel()
+
+	fsStorage, err := fs.NewStorage(t.TempDir(), fsBaseURL)
+	require.NoError(t, err)
+	storage := &releasingStorage{countingStorage: &countingStorage{Storage: fsStorage}}
+	repo := mocks.NewUserRepo()
+	service := user.NewAvatarService(fsBaseURL)
+	handler := NewUpdateAvatarHandler(UpdateAvatarHandlerArgs{
+		AvatarDomainService: service,
+		Storage:             storage,
+		UserRepo:            repo,
+		Uploads:             repo,
+	})
+
+	processed, err := service.ProcessAvatar("image/jpeg", fixtures.ValidJPEGAvatar)
+	require.NoError(t, err)
+	key := service.GenerateS3Key(processed.Content)
+	require.NoError(t, fsStorage.UploadFile(t.Context(), key, bytes.NewReader(processed.Content), processed.Format.ContentType()))
+
+	u := builders.NewUserBuilder().WithEmptyAvatar().Build()
+	repo.SeedUser(t, u)
+
+	err = handler.Handle(t.Context(), &UpdateAvatar{
+		UserID:      u.ID(),
+		File:        bytes.NewReader(fixtures.ValidJPEGAvatar),
+		Size:        int64(len(fixtures.ValidJPEGAvatar)),
+		ContentType: "image/jpeg",
+		Filename:    "avatar.jpg",
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, storage.uploads, "the released object should be uploaded again")
+	_, err = fsStorage.HeadObject(t.Context(), key)
+	assert.NoError(t, err, "the referenced object should be stored")
+}
+
+// expiringStorage ages the pending uploads while the object is stored, like
+// an upload outliving its token.
+type expiringStorage struct {
+	*countingStorage
+	repo *mocks.UserRepo
+}
+
+func (s *expiringStorage) UploadFile(ctx context.Context, key string, file io.Reader, contentType string) error {
+	s.repo.ExpirePendingUploads()
+	return s.countingStorage.UploadFile(ctx, key, file, contentType)
+}
+
+func TestUpdateAvatarHandler_ExpiredUploadRefused(t *testing.T) {
+	t.Parallel()
+
+	fsStorage, err := fs.NewStorage(t.TempDir(), fsBaseURL)
+	require.NoError(t, err)
+	repo := mocks.NewUserRepo()
+	handler := NewUpdateAvatarHandler(UpdateAvatarHandlerArgs{
+		AvatarDomainService: user.NewAvatarService(fsBaseURL),
+		Storage:             &expiringStorage{countingStorage: &countingStorage{Storage: fsStorage}, repo: repo},
+		UserRepo:            repo,
+		Uploads:             repo,
+	})
+
+	u := builders.NewUserBuilder().WithEmptyAvatar().Build()
+	repo.SeedUser(t, u)
+
+	err = handler.Handle(t.Context(), &UpdateAvatar{
+		UserID:      u.ID(),
+		File:        bytes.NewReader(fixtures.ValidJPEGAvatar),
+		Size:        int64(len(fixtures.ValidJPEGAvatar)),
+		ContentType: "image/jpeg",
+		Filename:    "avatar.jpg",
+	})
+	require.ErrorIs(t, err, user.ErrUploadExpired)
+	assert.True(t, u.Avatar().IsZero(), "the upload is not accepted without a valid token")
+}
+
+func newScanningHandler(t *testing.T, cfg storagex.UploadScannerConfig) (*UpdateAvatarHandler, *countingStorage, *mocks.UserRepo, *mocks.ClamdServer) {
+	t.Helper()
+
+	fsStorage, err := fs.NewStorage(t.TempDir(), fsBaseURL)
+	require.NoError(t, err)
+	storage := &countingStorage{Storage: fsStorage}
+	repo := mocks.NewUserRepo()
+	clamd := mocks.NewClamdServer(t)
+	handler := NewUpdateAvatarHandler(UpdateAvatarHandlerArgs{
+		AvatarDomainService: user.NewAvatarService(fsBaseURL),
+		Storage:             storage,
+		UserRepo:            repo,
+		Uploads:             repo,
+		Scanner:             storagex.NewUploadScanner(clamav.NewClient(clamd.Addr()), cfg),
+	})
+
+	return handler, storage, repo, clamd
+}
+
+func TestUpdateAvatarHandler_ScansUploads(t *testing.T) {
+	t.Parallel()
+	handler, storage, repo, clamd := newScanningHandler(t, storagex.UploadScannerConfig{})
+
+	u := builders.NewUserBuilder().WithEmptyAvatar().Build()
+	repo.SeedUser(t, u)
+
+	err := handler.Handle(t.Context(), &UpdateAvatar{
+		UserID:      u.ID(),
+		File:        bytes.NewReader(fixtures.ValidJPEGAvatar),
+		Size:        int64(len(fixtures.ValidJPEGAvatar)),
+		ContentType: "image/jpeg",
+		Filename:    "avatar.jpg",
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, clamd.Scans())
+	assert.Equal(t, 1, storage.uploads)
+	assert.False(t, u.Avatar().IsZero())
+}
+
+func TestUpdateAvatarHandler_InfectedUploadRejected(t *testing.T) {
+	t.Parallel()
+	handler, storage, repo, _ := newScanningHandler(t, storagex.UploadScannerConfig{})
+
+	u := builders.NewUserBuilder().WithEmptyAvatar().Build()
+	repo.SeedUser(t, u)
+
+	infected := append(bytes.Clone(fixtures.ValidJPEGAvatar), []byte(mocks.EICAR)...)
+	err := handler.Handle(t.Context(), &UpdateAvatar{
+		UserID:      u.ID(),
+		File:        bytes.NewReader(infected),
+		Size:        int64(len(infected)),
+		ContentType: "image/jpeg",
+		Filename:    "avatar.jpg",
+	})
+	require.ErrorIs(t, err, storagex.ErrInfected)
+
+	var i18nErr *errorx.I18nError
+	require.ErrorAs(t, err, &i18nErr)
+	assert.Equal(t, http.StatusUnprocessableEntity, i18nErr.HTTPStatusCode())
+	assert.Equal(t, i18nx.KeyUploadInfected, i18nErr.MessageKey)
+
+	assert.Zero(t, storage.uploads, "infected content must not be stored")
+	processed, err := user.NewAvatarService(fsBaseURL).ProcessAvatar("image/jpeg", infected)
+	require.NoError(t, err)
+	_, err = storage.HeadObject(t.Context(), user.NewAvatarService(fsBaseURL).GenerateS3Key(processed.Content))
+	assert.True(t, errorx.IsNotFound(err), "nothing should be left in the storage")
+	assert.True(t, u.Avatar().IsZero())
+
+	e := event.AssertSingleEvent[*user.UserAvatarRejected](t, u.GetUncommittedEvents())
+	assert.Equal(t, u.ID(), e.UserID)
+	assert.Equal(t, "avatar.jpg", e.Filename)
+	assert.NotEmpty(t, e.Signature)
+}
+
+func TestUpdateAvatarHandler_ScannerUnavailable(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		failOpen bool
+		wantErr  error
+	}{
+		{name: "fail closed", failOpen: false, wantErr: storagex.ErrScannerUnavailable},
+		{name: "fail open", failOpen: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			handler, storage, repo, clamd := newScanningHandler(t, storagex.UploadScannerConfig{
+				Timeout:  100 * time.Millisecond,
+				FailOpen: tt.failOpen,
+			})
+			clamd.SetHang(true)
+
+			u := builders.NewUserBuilder().WithEmptyAvatar().Build()
+			repo.SeedUser(t, u)
+
+			err := handler.Handle(t.Context(), &UpdateAvatar{
+				UserID:      u.ID(),
+				File:        bytes.NewReader(fixtures.ValidJPEGAvatar),
+				Size:        int64(len(fixtures.ValidJPEGAvatar)),
+				ContentType: "image/jpeg",
+				Filename:    "avatar.jpg",
+			})
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				var i18nErr *errorx.I18nError
+				require.ErrorAs(t, err, &i18nErr)
+				assert.Equal(t, http.StatusServiceUnavailable, i18nErr.HTTPStatusCode())
+				assert.Zero(t, storage.uploads)
+				assert.True(t, u.Avatar().IsZero())
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, 1, storage.uploads, "upload should be accepted unscanned")
+			assert.False(t, u.Avatar().IsZero())
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package userevent
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/storagex"
+)
+
+type AvatarObjectHeader interface {
+	HeadObject(ctx context.Context, key string) (storagex.ObjectInfo, error)
+}
+
+type UserUpdater interface {
+	UpdateUser(ctx context.Context, id user.ID, fn func(context.Context, *user.User) error) error
+}
+
+// AvatarMissingHandler clears the avatar reference a profile read found
+// stale. The object is checked again first: the read may have trusted a
+// cached answer while the same avatar was uploaded again.
+type AvatarMissingHandler struct {
+	objects AvatarObjectHeader
+	users   UserUpdater
+}
+
+func NewAvatarMissingHandler(objects AvatarObjectHeader, users UserUpdater) *AvatarMissingHandler {
+	return &AvatarMissingHandler{
+		objects: objects,
+		users:   users,
+	}
+}
+
+func (h *AvatarMissingHandler) Handle(ctx context.Context, e *user.UserAvatarMissing) error {
+	ctx, span := tracer.Start(ctx, "AvatarMissingHandler.Handle",
+		trace.WithAttributes(
+			attribute.String("event.user.id", e.UserID.String()),
+			attribute.String("event.avatar.s3_key", e.Avatar.S3Key),
+		),
+	)
+	defer span.End()
+
+	_, err := h.objects.HeadObject(ctx, e.Avatar.S3Key)
+	switch {
+	case err == nil:
+		logger.DebugContext(ctx, "missing avatar is back, keeping the reference",
+			slog.String("user_id", e.UserID.String()),
+			slog.String("s3_key", e.Avatar.S3Key))
+		return nil
+	case !errors.Is(err, storagex.ErrObjectNotFound):
+		// Returning the error makes the event to be redelivered.
+		otelx.RecordSpanError(span, err, "failed to check avatar object")
+		return err
+	}
+
+	var cleared bool
+	err = h.users.UpdateUser(ctx, e.UserID, func(_ context.Context, u *user.User) error {
+		cleared = u.ClearMissingAvatar(e.Avatar.S3Key)
+		return nil
+	})
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to clear missing avatar")
+		return err
+	}
+	if cleared {
+		logger.InfoContext(ctx, "cleared missing avatar",
+			slog.String("user_id", e.UserID.String()),
+			slog.String("s3_key", e.Avatar.S3Key))
+	}
+
+	return nil
+}
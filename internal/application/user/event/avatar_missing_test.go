@@ -0,0 +1,48 @@
+package userevent
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/adapters/services/fs"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/avatars"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/builders"
+	"gitlab.com/ucmsv2/ucms-backend/tests/mocks"
+)
+
+func TestAvatarMissingHandler(t *testing.T) {
+	storage, err := fs.NewStorage(t.TempDir(), "http://localhost:8080/v1/files")
+	require.NoError(t, err)
+	repo := mocks.NewUserRepo()
+	handler := NewAvatarMissingHandler(storage, repo)
+
+	gone := user.AvatarKeyPrefix + "gone"
+	back := user.AvatarKeyPrefix + "back"
+	require.NoError(t, storage.UploadFile(t.Context(), back, strings.NewReader("back"), "image/png"))
+	stale := builders.NewUserBuilder().WithS3Avatar(gone).Build()
+	uploadedAgain := builders.NewUserBuilder().WithS3Avatar(back).Build()
+	moved := builders.NewUserBuilder().WithS3Avatar(back).Build()
+	for _, u := range []*user.User{stale, uploadedAgain, moved} {
+		repo.SeedUser(t, u)
+	}
+
+	avatarOf := func(u *user.User) avatars.Avatar {
+		t.Helper()
+		got, err := repo.GetUserByID(t.Context(), u.ID())
+		require.NoError(t, err)
+		return got.Avatar()
+	}
+
+	require.NoError(t, handler.Handle(t.Context(), user.NewUserAvatarMissing(stale.ID(), avatars.NewS3Avatar(gone))))
+	assert.True(t, avatarOf(stale).IsZero(), "the stale reference is cleared")
+
+	require.NoError(t, handler.Handle(t.Context(), user.NewUserAvatarMissing(uploadedAgain.ID(), avatars.NewS3Avatar(back))))
+	assert.Equal(t, back, avatarOf(uploadedAgain).S3Key, "an object uploaded again is kept")
+
+	require.NoError(t, handler.Handle(t.Context(), user.NewUserAvatarMissing(moved.ID(), avatars.NewS3Avatar(gone))))
+	assert.Equal(t, back, avatarOf(moved).S3Key, "a user who moved to another avatar keeps it")
+}
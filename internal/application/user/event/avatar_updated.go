@@ -11,6 +11,7 @@ import (
 
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/avatars"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx"
 )
 
 var (
@@ -22,20 +23,27 @@ type AvatarStorage interface {
 	DeleteFile(ctx context.Context, key string) error
 }
 
+// AvatarRefReleaser calls remove once no user references the avatar object
+// anymore and reports whether it did. Avatars are keyed by content hash, so
+// users share objects; no reference is taken while remove runs.
+type AvatarRefReleaser interface {
+	ReleaseAvatarRef(ctx context.Context, key string, remove func(context.Context) error) (bool, error)
+}
+
 type AvatarUpdatedHandler struct {
 	avatarStorage AvatarStorage
+	avatarRefs    AvatarRefReleaser
 }
 
-func NewAvatarUpdatedHandler(avatarStorage AvatarStorage) *AvatarUpdatedHandler {
+func NewAvatarUpdatedHandler(avatarStorage AvatarStorage, avatarRefs AvatarRefReleaser) *AvatarUpdatedHandler {
 	return &AvatarUpdatedHandler{
 		avatarStorage: avatarStorage,
+		avatarRefs:    avatarRefs,
 	}
 }
 
 func (h *AvatarUpdatedHandler) Handle(ctx context.Context, e *user.UserAvatarUpdated) error {
 	ctx, span := tracer.Start(ctx, "AvatarUpdatedHandler.Handle",
-		trace.WithNewRoot(),
-		trace.WithLinks(trace.LinkFromContext(e.Extract())),
 		trace.WithAttributes(
 			attribute.String("event.user.id", e.UserID.String()),
 			attribute.String("event.old_avatar.source", e.OldAvatar.Source.String()),
@@ -47,13 +55,27 @@ func (h *AvatarUpdatedHandler) Handle(ctx context.Context, e *user.UserAvatarUpd
 	defer span.End()
 
 	if e.OldAvatar.Source == avatars.SourceS3 && e.OldAvatar.S3Key != "" && e.OldAvatar.S3Key != e.NewAvatar.S3Key {
-		err := h.avatarStorage.DeleteFile(ctx, e.OldAvatar.S3Key)
-		if err != nil {
+		var deleteErr error
+		removed, err := h.avatarRefs.ReleaseAvatarRef(ctx, e.OldAvatar.S3Key, func(ctx context.Context) error {
+			deleteErr = h.avatarStorage.DeleteFile(ctx, e.OldAvatar.S3Key)
+			return deleteErr
+		})
+		switch {
+		case deleteErr != nil:
+			// The orphaned avatar collection removes it later.
 			logger.WarnContext(ctx, "failed to delete previous avatar from S3",
 				slog.String("user_id", e.UserID.String()),
 				slog.String("previous_s3_key", e.OldAvatar.S3Key),
-				slog.String("error", err.Error()))
-		} else {
+				slog.String("error", deleteErr.Error()))
+		case err != nil:
+			// Returning the error makes the event to be redelivered.
+			otelx.RecordSpanError(span, err, "failed to release avatar reference")
+			return err
+		case !removed:
+			logger.DebugContext(ctx, "previous avatar is still referenced by other users, keeping it",
+				slog.String("user_id", e.UserID.String()),
+				slog.String("previous_s3_key", e.OldAvatar.S3Key))
+		default:
 			logger.DebugContext(ctx, "successfully deleted previous avatar from S3",
 				slog.String("user_id", e.UserID.String()),
 				slog.String("previous_s3_key", e.OldAvatar.S3Key))
@@ -0,0 +1,51 @@
+package userevent
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/adapters/services/fs"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/event"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/avatars"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/builders"
+	"gitlab.com/ucmsv2/ucms-backend/tests/mocks"
+)
+
+func TestAvatarUpdatedHandler_RefcountedDelete(t *testing.T) {
+	storage, err := fs.NewStorage(t.TempDir(), "http://localhost:8080/v1/files")
+	require.NoError(t, err)
+	repo := mocks.NewUserRepo()
+	handler := NewAvatarUpdatedHandler(storage, repo)
+
+	sharedKey := user.AvatarKeyPrefix + "shared"
+	require.NoError(t, storage.UploadFile(t.Context(), sharedKey, strings.NewReader("shared"), "image/png"))
+
+	first := builders.NewUserBuilder().WithS3Avatar(sharedKey).Build()
+	second := builders.NewUserBuilder().WithS3Avatar(sharedKey).Build()
+	repo.SeedUser(t, first)
+	repo.SeedUser(t, second)
+
+	deleteAvatar := func(u *user.User) {
+		t.Helper()
+		require.NoError(t, u.DeleteAvatar())
+		err := handler.Handle(t.Context(), &user.UserAvatarUpdated{
+			Header:    event.NewEventHeader(),
+			UserID:    u.ID(),
+			OldAvatar: avatars.NewS3Avatar(sharedKey),
+		})
+		require.NoError(t, err)
+	}
+
+	deleteAvatar(first)
+	_, err = storage.HeadObject(t.Context(), sharedKey)
+	require.NoError(t, err, "object must survive while another user references it")
+
+	deleteAvatar(second)
+	_, err = storage.HeadObject(t.Context(), sharedKey)
+	assert.True(t, errorx.IsNotFound(err), "object should be deleted once unreferenced, got %v", err)
+}
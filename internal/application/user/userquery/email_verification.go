@@ -0,0 +1,76 @@
+package userquery
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx"
+)
+
+type EmailVerifiedChecker interface {
+	IsEmailVerified(ctx context.Context, id user.ID) (bool, error)
+}
+
+// EmailVerificationHandler gates the staff who have not verified their
+// email, the HTTP port asks it on every staff request.
+//
+// An email is never unverified again, so the verified users are remembered
+// and only the unverified ones are looked up.
+type EmailVerificationHandler struct {
+	tracer   trace.Tracer
+	logger   *slog.Logger
+	repo     EmailVerifiedChecker
+	verified sync.Map // user.ID -> struct{}
+}
+
+type EmailVerificationHandlerArgs struct {
+	Tracer   trace.Tracer
+	Logger   *slog.Logger
+	UserRepo EmailVerifiedChecker
+}
+
+func NewEmailVerificationHandler(args EmailVerificationHandlerArgs) *EmailVerificationHandler {
+	if args.Tracer == nil {
+		args.Tracer = tracer
+	}
+	if args.Logger == nil {
+		args.Logger = logger
+	}
+
+	return &EmailVerificationHandler{
+		tracer: args.Tracer,
+		logger: args.Logger,
+		repo:   args.UserRepo,
+	}
+}
+
+// CheckEmailVerified returns user.ErrEmailVerificationRequired when the user
+// has not verified their email.
+func (h *EmailVerificationHandler) CheckEmailVerified(ctx context.Context, userID user.ID) error {
+	const op = "userquery.EmailVerificationHandler.CheckEmailVerified"
+	if _, ok := h.verified.Load(userID); ok {
+		return nil
+	}
+	ctx, span := h.tracer.Start(ctx, "EmailVerificationHandler.CheckEmailVerified",
+		trace.WithAttributes(attribute.String("user.id", userID.String())))
+	defer span.End()
+
+	verified, err := h.repo.IsEmailVerified(ctx, userID)
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to check email verification")
+		return errorx.Wrap(err, op)
+	}
+	if !verified {
+		span.AddEvent("email verification required")
+		return errorx.Wrap(user.ErrEmailVerificationRequired, op)
+	}
+
+	h.verified.Store(userID, struct{}{})
+	return nil
+}
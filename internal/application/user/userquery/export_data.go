@@ -0,0 +1,155 @@
+package userquery
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"path"
+
+	"go.opentelemetry.io/contrib/bridges/otelslog"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/avatars"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/httpx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/storagex"
+)
+
+var (
+	tracer = otel.Tracer("ucms/internal/application/user/query")
+	logger = otelslog.NewLogger("ucms/internal/application/user/query")
+)
+
+const exportProfileName = "profile.json"
+
+type UserGetter interface {
+	GetUserByID(ctx context.Context, id user.ID) (*user.User, error)
+}
+
+type ExportData struct {
+	UserID user.ID
+}
+
+// ExportedProfile is the user's own data as included in the export.
+type ExportedProfile struct {
+	ID           string     `json:"id"`
+	Barcode      string     `json:"barcode"`
+	Username     string     `json:"username,omitempty"`
+	Email        string     `json:"email"`
+	FirstName    string     `json:"first_name"`
+	LastName     string     `json:"last_name"`
+	Role         string     `json:"role"`
+	AvatarSource string     `json:"avatar_source,omitempty"`
+	AvatarURL    string     `json:"avatar_url,omitempty"`
+	CreatedAt    httpx.Time `json:"created_at"`
+	UpdatedAt    httpx.Time `json:"updated_at"`
+}
+
+// Export is a prepared data export, nothing has been written yet.
+type Export struct {
+	Filename string
+
+	storage storagex.ObjectOpener
+	files   []storagex.ZIPFile
+	keys    []string
+}
+
+// Write streams the export as a ZIP archive to w.
+func (e *Export) Write(ctx context.Context, w io.Writer) (*storagex.ZIPManifest, error) {
+	const op = "userquery.Export.Write"
+	manifest, err := storagex.WriteZIP(ctx, w, e.storage, e.keys, storagex.ZIPOptions{
+		Files:     e.files,
+		EntryName: func(key string) string { return "avatar/" + path.Base(key) },
+	})
+	if err != nil {
+		return nil, errorx.Wrap(err, op)
+	}
+	return manifest, nil
+}
+
+// ExportDataHandler prepares the personal data export of a user.
+//
+// The user is loaded up front so lookup errors can be reported before the
+// archive starts streaming, after that only the storage can fail.
+type ExportDataHandler struct {
+	tracer  trace.Tracer
+	logger  *slog.Logger
+	repo    UserGetter
+	storage storagex.ObjectOpener
+}
+
+type ExportDataHandlerArgs struct {
+	Tracer   trace.Tracer
+	Logger   *slog.Logger
+	UserRepo UserGetter
+	Storage  storagex.ObjectOpener
+}
+
+func NewExportDataHandler(args ExportDataHandlerArgs) *ExportDataHandler {
+	if args.Tracer == nil {
+		args.Tracer = tracer
+	}
+	if args.Logger == nil {
+		args.Logger = logger
+	}
+
+	return &ExportDataHandler{
+		tracer:  args.Tracer,
+		logger:  args.Logger,
+		repo:    args.UserRepo,
+		storage: args.Storage,
+	}
+}
+
+func (h *ExportDataHandler) Handle(ctx context.Context, q ExportData) (*Export, error) {
+	const op = "userquery.ExportDataHandler.Handle"
+	ctx, span := h.tracer.Start(ctx, "ExportDataHandler.Handle", trace.WithAttributes(
+		attribute.String("user.id", q.UserID.String()),
+	))
+	defer span.End()
+
+	u, err := h.repo.GetUserByID(ctx, q.UserID)
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to get user")
+		return nil, errorx.Wrap(err, op)
+	}
+
+	profile := ExportedProfile{
+		ID:        u.ID().String(),
+		Barcode:   string(u.Barcode()),
+		Username:  u.Username(),
+		Email:     u.Email().String(),
+		FirstName: u.FirstName(),
+		LastName:  u.LastName(),
+		Role:      u.Role().String(),
+		CreatedAt: httpx.NewTime(u.CreatedAt()),
+		UpdatedAt: httpx.NewTime(u.UpdatedAt()),
+	}
+	var keys []string
+	switch avatar := u.Avatar(); avatar.Source {
+	case avatars.SourceS3:
+		profile.AvatarSource = avatar.Source.String()
+		keys = append(keys, avatar.S3Key)
+	case avatars.SourceExternal:
+		profile.AvatarSource = avatar.Source.String()
+		profile.AvatarURL = avatar.External
+	}
+
+	content, err := json.MarshalIndent(profile, "", "  ")
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to marshal profile")
+		return nil, errorx.Wrap(err, op)
+	}
+
+	return &Export{
+		Filename: "ucms-export-" + u.ID().String() + ".zip",
+		storage:  h.storage,
+		files:    []storagex.ZIPFile{{Name: exportProfileName, Content: content}},
+		keys:     keys,
+	}, nil
+}
@@ -0,0 +1,103 @@
+package userquery
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/adapters/services/fs"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/storagex"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/builders"
+	"gitlab.com/ucmsv2/ucms-backend/tests/mocks"
+)
+
+func newExportTestHandler(t *testing.T) (*ExportDataHandler, *fs.Storage, *mocks.UserRepo) {
+	t.Helper()
+	storage, err := fs.NewStorage(t.TempDir(), "http://localhost:8080/v1/files")
+	require.NoError(t, err)
+	repo := mocks.NewUserRepo()
+
+	return NewExportDataHandler(ExportDataHandlerArgs{UserRepo: repo, Storage: storage}), storage, repo
+}
+
+func writeExport(t *testing.T, export *Export) (*zip.Reader, *storagex.ZIPManifest) {
+	t.Helper()
+	var buf bytes.Buffer
+	manifest, err := export.Write(t.Context(), &buf)
+	require.NoError(t, err)
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	return zr, manifest
+}
+
+func readEntry(t *testing.T, zr *zip.Reader, name string) []byte {
+	t.Helper()
+	rc, err := zr.Open(name)
+	require.NoError(t, err)
+	defer rc.Close()
+	content, err := io.ReadAll(rc)
+	require.NoError(t, err)
+	return content
+}
+
+func TestExportDataHandler_IncludesProfileAndAvatar(t *testing.T) {
+	t.Parallel()
+	h, storage, repo := newExportTestHandler(t)
+
+	key := user.AvatarKeyPrefix + "abc"
+	require.NoError(t, storage.UploadFile(t.Context(), key, strings.NewReader("jpeg"), "image/jpeg"))
+	u := builders.NewUserBuilder().WithS3Avatar(key).Build()
+	repo.SeedUser(t, u)
+
+	export, err := h.Handle(t.Context(), ExportData{UserID: u.ID()})
+	require.NoError(t, err)
+	assert.Equal(t, "ucms-export-"+u.ID().String()+".zip", export.Filename)
+
+	zr, manifest := writeExport(t, export)
+
+	var profile ExportedProfile
+	require.NoError(t, json.Unmarshal(readEntry(t, zr, exportProfileName), &profile))
+	assert.Equal(t, u.ID().String(), profile.ID)
+	assert.Equal(t, u.Email().String(), profile.Email)
+	assert.Equal(t, "s3", profile.AvatarSource)
+
+	assert.Equal(t, "jpeg", string(readEntry(t, zr, "avatar/abc")))
+	assert.Empty(t, manifest.Missing)
+	assert.Len(t, manifest.Files, 2)
+}
+
+func TestExportDataHandler_MissingAvatarIsRecorded(t *testing.T) {
+	t.Parallel()
+	h, _, repo := newExportTestHandler(t)
+
+	key := user.AvatarKeyPrefix + "gone"
+	u := builders.NewUserBuilder().WithS3Avatar(key).Build()
+	repo.SeedUser(t, u)
+
+	export, err := h.Handle(t.Context(), ExportData{UserID: u.ID()})
+	require.NoError(t, err)
+
+	zr, manifest := writeExport(t, export)
+	assert.Equal(t, []string{key}, manifest.Missing)
+
+	var written storagex.ZIPManifest
+	require.NoError(t, json.Unmarshal(readEntry(t, zr, storagex.ZIPManifestName), &written))
+	assert.Equal(t, []string{key}, written.Missing)
+}
+
+func TestExportDataHandler_UnknownUser(t *testing.T) {
+	t.Parallel()
+	h, _, _ := newExportTestHandler(t)
+
+	_, err := h.Handle(t.Context(), ExportData{UserID: user.NewID()})
+	assert.True(t, errorx.IsNotFound(err), "got %v", err)
+}
@@ -0,0 +1,274 @@
+package announcement
+
+import (
+	"encoding/json"
+	"slices"
+	"time"
+
+	"github.com/ARUMANDESU/validation"
+	"github.com/google/uuid"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/event"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/group"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/validationx"
+)
+
+const EventStreamName = "events_announcement"
+
+const (
+	MaxTitleLen = 200
+	MaxBodyLen  = 5000
+	// MaxTargetGroups is the number of groups an announcement may target,
+	// more are better targeted at all students.
+	MaxTargetGroups = 50
+)
+
+type ID uuid.UUID
+
+func NewID() ID {
+	return ID(uuid.New())
+}
+
+func (id ID) String() string {
+	return uuid.UUID(id).String()
+}
+
+func (id ID) MarshalJSON() ([]byte, error) {
+	return json.Marshal(uuid.UUID(id).String())
+}
+
+func (id *ID) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+
+	uid, err := uuid.Parse(s)
+	if err != nil {
+		return err
+	}
+
+	*id = ID(uid)
+	return nil
+}
+
+// Announcement is a message of the student association to the students of
+// its target groups, or to all students when it targets none. It is
+// published once created, the staff moderate it by unpublishing it.
+type Announcement struct {
+	event.Recorder
+	id            ID
+	authorID      user.ID
+	title         string
+	body          string
+	groupIDs      []group.ID
+	sendEmail     bool
+	createdAt     time.Time
+	unpublishedAt *time.Time
+	unpublishedBy *user.ID
+	clock         clock.Clock
+}
+
+type CreateArgs struct {
+	AuthorID user.ID
+	Title    string
+	Body     string
+	// GroupIDs are the target groups, none targets all students.
+	GroupIDs []group.ID
+	// SendEmail mails the announcement to its students besides notifying
+	// them.
+	SendEmail bool
+	// Clock defaults to clock.Real.
+	Clock clock.Clock
+}
+
+func New(args CreateArgs) (*Announcement, error) {
+	const op = "announcement.New"
+	err := validation.ValidateStruct(&args,
+		validation.Field(&args.AuthorID, validationx.Required),
+		validation.Field(&args.Title, validation.Required, validation.RuneLength(1, MaxTitleLen)),
+		validation.Field(&args.Body, validation.Required, validation.RuneLength(1, MaxBodyLen)),
+		validation.Field(&args.GroupIDs,
+			validation.Count(0, MaxTargetGroups),
+			validation.By(noDuplicateGroups),
+			validation.Each(validationx.Required),
+		),
+	)
+	if err != nil {
+		return nil, errorx.Wrap(err, op)
+	}
+
+	a := &Announcement{
+		id:        NewID(),
+		authorID:  args.AuthorID,
+		title:     args.Title,
+		body:      args.Body,
+		groupIDs:  slices.Clone(args.GroupIDs),
+		sendEmail: args.SendEmail,
+		createdAt: clock.Or(args.Clock).Now().UTC(),
+		clock:     args.Clock,
+	}
+
+	a.Record(&Published{
+		AnnouncementID: a.id,
+		AuthorID:       a.authorID,
+		Title:          a.title,
+		Body:           a.body,
+		GroupIDs:       slices.Clone(a.groupIDs),
+		SendEmail:      a.sendEmail,
+	}, uuid.UUID(a.id), uuid.UUID(a.authorID))
+
+	return a, nil
+}
+
+func noDuplicateGroups(value any) error {
+	ids, _ := value.([]group.ID)
+	seen := make(map[group.ID]struct{}, len(ids))
+	for _, id := range ids {
+		if _, ok := seen[id]; ok {
+			return validationx.ErrDuplicate
+		}
+		seen[id] = struct{}{}
+	}
+	return nil
+}
+
+type RehydrateArgs struct {
+	ID            ID
+	AuthorID      user.ID
+	Title         string
+	Body          string
+	GroupIDs      []group.ID
+	SendEmail     bool
+	CreatedAt     time.Time
+	UnpublishedAt *time.Time
+	UnpublishedBy *user.ID
+	// Clock defaults to clock.Real.
+	Clock clock.Clock
+}
+
+func Rehydrate(args RehydrateArgs) *Announcement {
+	return &Announcement{
+		id:            args.ID,
+		authorID:      args.AuthorID,
+		title:         args.Title,
+		body:          args.Body,
+		groupIDs:      args.GroupIDs,
+		sendEmail:     args.SendEmail,
+		createdAt:     args.CreatedAt,
+		unpublishedAt: args.UnpublishedAt,
+		unpublishedBy: args.UnpublishedBy,
+		clock:         args.Clock,
+	}
+}
+
+// Unpublish hides the announcement from the students, by is the staff
+// moderating it. Unpublishing it again changes nothing.
+func (a *Announcement) Unpublish(by user.ID) {
+	if a.unpublishedAt != nil {
+		return
+	}
+
+	now := clock.Or(a.clock).Now().UTC()
+	a.unpublishedAt = &now
+	a.unpublishedBy = &by
+
+	a.Record(&Unpublished{
+		AnnouncementID: a.id,
+		UnpublishedBy:  by,
+	}, uuid.UUID(a.id), uuid.UUID(by))
+}
+
+func (a *Announcement) ID() ID {
+	return a.id
+}
+
+func (a *Announcement) AuthorID() user.ID {
+	return a.authorID
+}
+
+func (a *Announcement) Title() string {
+	return a.title
+}
+
+func (a *Announcement) Body() string {
+	return a.body
+}
+
+// GroupIDs are the target groups, none when the announcement targets all
+// students.
+func (a *Announcement) GroupIDs() []group.ID {
+	return a.groupIDs
+}
+
+func (a *Announcement) TargetsAllStudents() bool {
+	return len(a.groupIDs) == 0
+}
+
+func (a *Announcement) SendEmail() bool {
+	return a.sendEmail
+}
+
+func (a *Announcement) CreatedAt() time.Time {
+	return a.createdAt
+}
+
+func (a *Announcement) IsPublished() bool {
+	return a.unpublishedAt == nil
+}
+
+func (a *Announcement) UnpublishedAt() *time.Time {
+	return a.unpublishedAt
+}
+
+func (a *Announcement) UnpublishedBy() *user.ID {
+	return a.unpublishedBy
+}
+
+// ListParams selects a page of the announcements, the newest first.
+type ListParams struct {
+	// GroupID selects the published announcements the students of the group
+	// see, the ones targeting all students included. Zero selects the ones
+	// targeting all students only.
+	GroupID group.ID
+	// All selects every announcement, the unpublished ones included, for the
+	// staff moderating them. GroupID is then ignored.
+	All    bool
+	Limit  int
+	Offset int
+}
+
+// Recipient is a student an announcement is delivered to.
+type Recipient struct {
+	UserID user.ID
+	Email  string
+}
+
+type Published struct {
+	event.Header
+	event.Otel
+	AnnouncementID ID         `json:"announcement_id"`
+	AuthorID       user.ID    `json:"author_id"`
+	Title          string     `json:"title"`
+	Body           string     `json:"body"`
+	GroupIDs       []group.ID `json:"group_ids,omitempty"`
+	SendEmail      bool       `json:"send_email,omitempty"`
+}
+
+func (e *Published) GetStreamName() string {
+	return EventStreamName
+}
+
+type Unpublished struct {
+	event.Header
+	event.Otel
+	AnnouncementID ID      `json:"announcement_id"`
+	UnpublishedBy  user.ID `json:"unpublished_by"`
+}
+
+func (e *Unpublished) GetStreamName() string {
+	return EventStreamName
+}
@@ -0,0 +1,115 @@
+package announcement_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ARUMANDESU/validation"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/announcement"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/event"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/group"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
+)
+
+var announceNow = time.Date(2026, time.March, 2, 9, 0, 0, 0, time.UTC)
+
+func newArgs() announcement.CreateArgs {
+	return announcement.CreateArgs{
+		AuthorID: user.NewID(),
+		Title:    "Spring festival",
+		Body:     "Join us on Friday in the main hall.",
+		GroupIDs: []group.ID{group.NewID()},
+		Clock:    clock.NewFake(announceNow),
+	}
+}
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	args := newArgs()
+	args.SendEmail = true
+	a, err := announcement.New(args)
+	require.NoError(t, err)
+
+	assert.Equal(t, args.AuthorID, a.AuthorID())
+	assert.Equal(t, args.GroupIDs, a.GroupIDs())
+	assert.False(t, a.TargetsAllStudents())
+	assert.True(t, a.IsPublished())
+	assert.Equal(t, announceNow, a.CreatedAt())
+
+	published := event.AssertSingleEvent[*announcement.Published](t, a.GetUncommittedEvents())
+	assert.Equal(t, a.ID(), published.AnnouncementID)
+	assert.Equal(t, args.GroupIDs, published.GroupIDs)
+	assert.True(t, published.SendEmail)
+	event.AssertHeader(t, published, uuid.UUID(a.ID()), uuid.UUID(args.AuthorID), 1)
+}
+
+func TestNew_AllStudents(t *testing.T) {
+	t.Parallel()
+
+	args := newArgs()
+	args.GroupIDs = nil
+	a, err := announcement.New(args)
+	require.NoError(t, err)
+	assert.True(t, a.TargetsAllStudents())
+}
+
+func TestNew_Invalid(t *testing.T) {
+	t.Parallel()
+
+	duplicate := group.NewID()
+	tooMany := make([]group.ID, announcement.MaxTargetGroups+1)
+	for i := range tooMany {
+		tooMany[i] = group.NewID()
+	}
+
+	tests := map[string]struct {
+		change func(*announcement.CreateArgs)
+		field  string
+	}{
+		"missing author":   {change: func(a *announcement.CreateArgs) { a.AuthorID = user.ID{} }, field: "AuthorID"},
+		"missing title":    {change: func(a *announcement.CreateArgs) { a.Title = "" }, field: "Title"},
+		"title too long":   {change: func(a *announcement.CreateArgs) { a.Title = strings.Repeat("a", announcement.MaxTitleLen+1) }, field: "Title"},
+		"missing body":     {change: func(a *announcement.CreateArgs) { a.Body = "" }, field: "Body"},
+		"duplicate groups": {change: func(a *announcement.CreateArgs) { a.GroupIDs = []group.ID{duplicate, duplicate} }, field: "GroupIDs"},
+		"too many groups":  {change: func(a *announcement.CreateArgs) { a.GroupIDs = tooMany }, field: "GroupIDs"},
+		"missing group id": {change: func(a *announcement.CreateArgs) { a.GroupIDs = []group.ID{{}} }, field: "GroupIDs"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			args := newArgs()
+			tt.change(&args)
+
+			_, err := announcement.New(args)
+			var verrs validation.Errors
+			require.ErrorAs(t, err, &verrs)
+			assert.Contains(t, verrs, tt.field)
+		})
+	}
+}
+
+func TestUnpublish(t *testing.T) {
+	t.Parallel()
+
+	a, err := announcement.New(newArgs())
+	require.NoError(t, err)
+	a.CommitEvents()
+	staffID := user.NewID()
+
+	a.Unpublish(staffID)
+	a.Unpublish(user.NewID())
+
+	assert.False(t, a.IsPublished())
+	require.NotNil(t, a.UnpublishedBy())
+	assert.Equal(t, staffID, *a.UnpublishedBy())
+	assert.Equal(t, announceNow, *a.UnpublishedAt())
+	unpublished := event.AssertSingleEvent[*announcement.Unpublished](t, a.GetUncommittedEvents())
+	event.AssertHeader(t, unpublished, uuid.UUID(a.ID()), uuid.UUID(staffID), 2)
+}
@@ -0,0 +1,175 @@
+package event
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// Matcher matches one event of the sequence AssertEvents checks.
+type Matcher struct {
+	desc  string
+	match func(Event) bool
+}
+
+func (m Matcher) String() string {
+	return m.desc
+}
+
+// OfType matches an event of type T for which every check returns true,
+// e.g. OfType(func(e *staffinvitation.Deleted) bool { return e.StaffInvitationID == id }).
+func OfType[T Event](checks ...func(T) bool) Matcher {
+	desc := fmt.Sprintf("%T", *new(T))
+	if len(checks) > 0 {
+		desc += fmt.Sprintf(" matching %d check(s)", len(checks))
+	}
+	return Matcher{
+		desc: desc,
+		match: func(e Event) bool {
+			typed, ok := e.(T)
+			if !ok {
+				return false
+			}
+			for _, check := range checks {
+				if !check(typed) {
+					return false
+				}
+			}
+			return true
+		},
+	}
+}
+
+// Order is how AssertEvents matches the events against the matchers.
+type Order int
+
+const (
+	// InOrder matches the i-th event with the i-th matcher.
+	InOrder Order = iota
+	// AnyOrder matches every event with a distinct matcher, whatever their
+	// order.
+	AnyOrder
+)
+
+// AssertEvents checks that events are exactly the ones the matchers
+// describe, in order. Pass AnyOrder to AssertEventsIn when the aggregate does
+// not guarantee one.
+func AssertEvents(t testing.TB, events []Event, matchers ...Matcher) bool {
+	t.Helper()
+	return AssertEventsIn(t, InOrder, events, matchers...)
+}
+
+// AssertEventsIn checks that events are exactly the ones the matchers
+// describe, in the given order.
+func AssertEventsIn(t testing.TB, order Order, events []Event, matchers ...Matcher) bool {
+	t.Helper()
+
+	if len(events) != len(matchers) {
+		t.Errorf("expected %d event(s), got %d\nexpected: %s\ngot:      %s",
+			len(matchers), len(events), describeMatchers(matchers), describeEvents(events))
+		return false
+	}
+
+	switch order {
+	case InOrder:
+		for i, m := range matchers {
+			if !m.match(events[i]) {
+				t.Errorf("event %d does not match %s\nexpected: %s\ngot:      %s",
+					i, m, describeMatchers(matchers), describeEvents(events))
+				return false
+			}
+		}
+	case AnyOrder:
+		if !matchAnyOrder(events, matchers, make([]bool, len(events))) {
+			t.Errorf("events do not match in any order\nexpected: %s\ngot:      %s",
+				describeMatchers(matchers), describeEvents(events))
+			return false
+		}
+	default:
+		t.Errorf("unknown event order %d", order)
+		return false
+	}
+	return true
+}
+
+// matchAnyOrder reports whether every matcher matches a distinct event not
+// used yet. It backtracks, so that a matcher taking an event another one
+// needed does not fail the match.
+func matchAnyOrder(events []Event, matchers []Matcher, used []bool) bool {
+	if len(matchers) == 0 {
+		return true
+	}
+	for i, e := range events {
+		if used[i] || !matchers[0].match(e) {
+			continue
+		}
+		used[i] = true
+		if matchAnyOrder(events, matchers[1:], used) {
+			return true
+		}
+		used[i] = false
+	}
+	return false
+}
+
+// AssertNoEventOfType checks that no event of type T is among events,
+// whatever the other events.
+func AssertNoEventOfType[T Event](t testing.TB, events []Event) bool {
+	t.Helper()
+
+	for i, e := range events {
+		if _, ok := e.(T); ok {
+			t.Errorf("expected no %T event, got one at %d\ngot: %s", *new(T), i, describeEvents(events))
+			return false
+		}
+	}
+	return true
+}
+
+// AssertHeader checks the header the Recorder stamped on e: an ID, a time,
+// the aggregate, the actor and the version.
+func AssertHeader(t testing.TB, e Event, aggregateID, actorID uuid.UUID, version int) bool {
+	t.Helper()
+
+	h := e.GetEventHeader()
+	ok := assert.NotEqual(t, uuid.Nil, h.EventID, "event ID of %T", e)
+	ok = assert.False(t, h.OccurredAt.IsZero(), "occurred at of %T", e) && ok
+	ok = assert.Equal(t, aggregateID, h.AggregateID, "aggregate ID of %T", e) && ok
+	ok = assert.Equal(t, actorID, h.ActorID, "actor ID of %T", e) && ok
+	return assert.Equal(t, version, h.AggregateVersion, "aggregate version of %T", e) && ok
+}
+
+// Aggregate is what records the events of a domain object, e.g. the
+// Recorder it embeds.
+type Aggregate interface {
+	GetUncommittedEvents() []Event
+	CommitEvents()
+}
+
+// Drain returns the uncommitted events of a and clears them, so that the
+// next step of a test only sees the events it caused.
+func Drain(a Aggregate) []Event {
+	events := slices.Clone(a.GetUncommittedEvents())
+	a.CommitEvents()
+	return events
+}
+
+func describeMatchers(matchers []Matcher) string {
+	descs := make([]string, len(matchers))
+	for i, m := range matchers {
+		descs[i] = m.String()
+	}
+	return "[" + strings.Join(descs, ", ") + "]"
+}
+
+func describeEvents(events []Event) string {
+	types := make([]string, len(events))
+	for i, e := range events {
+		types[i] = fmt.Sprintf("%T", e)
+	}
+	return "[" + strings.Join(types, ", ") + "]"
+}
@@ -0,0 +1,145 @@
+package event
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recorder records the failures of the assertions instead of failing the
+// test.
+type recorder struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+type created struct {
+	Header
+	Name string
+}
+
+func (e *created) GetStreamName() string { return "test" }
+
+type deleted struct {
+	Header
+}
+
+func (e *deleted) GetStreamName() string { return "test" }
+
+type aggregate struct {
+	Recorder
+}
+
+func named(name string) func(*created) bool {
+	return func(e *created) bool { return e.Name == name }
+}
+
+func TestAssertEvents(t *testing.T) {
+	events := []Event{&created{Name: "a"}, &created{Name: "b"}, &deleted{}}
+
+	tests := []struct {
+		name     string
+		order    Order
+		matchers []Matcher
+		wantErr  string
+	}{
+		{
+			name:     "in order",
+			order:    InOrder,
+			matchers: []Matcher{OfType(named("a")), OfType(named("b")), OfType[*deleted]()},
+		},
+		{
+			name:     "in order with the events swapped",
+			order:    InOrder,
+			matchers: []Matcher{OfType(named("b")), OfType(named("a")), OfType[*deleted]()},
+			wantErr:  "event 0 does not match *event.created matching 1 check(s)",
+		},
+		{
+			name:     "any order",
+			order:    AnyOrder,
+			matchers: []Matcher{OfType[*deleted](), OfType(named("b")), OfType(named("a"))},
+		},
+		{
+			name:  "any order backtracks",
+			order: AnyOrder,
+			// the first matcher would take "a", which only the second matches
+			matchers: []Matcher{OfType[*created](), OfType(named("a")), OfType[*deleted]()},
+		},
+		{
+			name:     "any order with a missing event",
+			order:    AnyOrder,
+			matchers: []Matcher{OfType(named("a")), OfType(named("a")), OfType[*deleted]()},
+			wantErr:  "events do not match in any order",
+		},
+		{
+			name:     "fewer matchers than events",
+			order:    AnyOrder,
+			matchers: []Matcher{OfType(named("a")), OfType[*deleted]()},
+			wantErr:  "expected 2 event(s), got 3",
+		},
+		{
+			name:     "unknown order",
+			order:    Order(42),
+			matchers: []Matcher{OfType(named("a")), OfType(named("b")), OfType[*deleted]()},
+			wantErr:  "unknown event order 42",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &recorder{}
+			ok := AssertEventsIn(r, tt.order, events, tt.matchers...)
+			if tt.wantErr == "" {
+				assert.True(t, ok)
+				assert.Empty(t, r.errors)
+				return
+			}
+			assert.False(t, ok)
+			require.Len(t, r.errors, 1)
+			assert.Contains(t, r.errors[0], tt.wantErr)
+		})
+	}
+}
+
+func TestAssertEvents_FailureListsTheEvents(t *testing.T) {
+	r := &recorder{}
+	AssertEvents(r, []Event{&deleted{}}, OfType[*created]())
+
+	require.Len(t, r.errors, 1)
+	assert.Contains(t, r.errors[0], "expected: [*event.created]")
+	assert.Contains(t, r.errors[0], "got:      [*event.deleted]")
+}
+
+func TestAssertNoEventOfType(t *testing.T) {
+	r := &recorder{}
+	assert.True(t, AssertNoEventOfType[*deleted](r, []Event{&created{}, &created{}}))
+	assert.True(t, AssertNoEventOfType[*deleted](r, nil))
+	assert.Empty(t, r.errors)
+
+	assert.False(t, AssertNoEventOfType[*deleted](r, []Event{&created{}, &deleted{}}))
+	require.Len(t, r.errors, 1)
+	assert.Contains(t, r.errors[0], "expected no *event.deleted event, got one at 1")
+}
+
+func TestDrain(t *testing.T) {
+	a := &aggregate{}
+	a.AddEvent(&created{Name: "a"})
+
+	first := Drain(a)
+	assert.Empty(t, a.GetUncommittedEvents())
+
+	a.AddEvent(&deleted{})
+	second := Drain(a)
+
+	AssertEvents(t, first, OfType(named("a")))
+	AssertEvents(t, second, OfType[*deleted]())
+	assert.Empty(t, Drain(a))
+}
@@ -7,6 +7,8 @@ import (
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
 )
 
 type Event interface {
@@ -14,34 +16,107 @@ type Event interface {
 	GetStreamName() string
 }
 
+// Header is what every event carries besides its business fields, the
+// Recorder stamps it when the aggregate records the event. The JSON names of
+// EventID and OccurredAt are the ones they had before they were renamed, so
+// that the messages already published still decode.
 type Header struct {
-	ID        uuid.UUID
-	Timestamp time.Time
-	Metadata  map[string]string
+	EventID    uuid.UUID `json:"ID"`
+	OccurredAt time.Time `json:"Timestamp"`
+	// ActorID is the user who made the change, uuid.Nil for the system or an
+	// anonymous visitor.
+	ActorID uuid.UUID `json:",omitzero"`
+	// ImpersonatorID is the staff member who made the change impersonating
+	// ActorID, uuid.Nil outside the impersonation sessions.
+	ImpersonatorID uuid.UUID `json:",omitzero"`
+	AggregateID    uuid.UUID `json:",omitzero"`
+	// AggregateVersion is the version of the aggregate once the event is
+	// committed, the first event of an aggregate is version 1.
+	AggregateVersion int `json:",omitzero"`
+	Metadata         map[string]string
 }
 
 func (e *Header) GetEventHeader() Header {
 	return *e
 }
 
+func (e *Header) header() *Header {
+	return e
+}
+
+// stamped is implemented by the events embedding a Header.
+type stamped interface {
+	header() *Header
+}
+
+// NewEventHeader returns the header of a new event, timestamped with the
+// wall time of clock.Real whatever the clock of the aggregate recording it.
 func NewEventHeader() Header {
 	return Header{
-		ID:        uuid.New(),
-		Timestamp: time.Now(),
+		EventID:    uuid.New(),
+		OccurredAt: clock.Real.Now(),
 	}
 }
 
+// Recorder is the base of the aggregates, they embed it to record the events
+// of their changes. The repositories publish the uncommitted events with the
+// changes and call CommitEvents once they are saved, so that a retried save
+// of the same aggregate does not publish them twice.
+//
+// The version of an aggregate counts its committed events, it is what the
+// optimistic locking compares.
+//
+// A Recorder is not safe for concurrent use, like the aggregate embedding it.
 type Recorder struct {
-	events []Event
+	events  []Event
+	version int
 }
 
+// AddEvent records event with the next version of the aggregate. The ID and
+// the time of the event are set if it has none, those of an event recorded
+// again are kept.
 func (e *Recorder) AddEvent(event Event) {
 	if e == nil {
 		return
 	}
+	if s, ok := event.(stamped); ok {
+		h := s.header()
+		if h.EventID == uuid.Nil {
+			h.EventID = uuid.New()
+		}
+		if h.OccurredAt.IsZero() {
+			h.OccurredAt = clock.Real.Now()
+		}
+		h.AggregateVersion = e.version + len(e.events) + 1
+	}
 	e.events = append(e.events, event)
 }
 
+// Record is AddEvent stamping event with the aggregate it is about and the
+// user who made the change, see Header.ActorID.
+func (e *Recorder) Record(event Event, aggregateID, actorID uuid.UUID) {
+	if e == nil {
+		return
+	}
+	if s, ok := event.(stamped); ok {
+		h := s.header()
+		h.AggregateID = aggregateID
+		h.ActorID = actorID
+	}
+	e.AddEvent(event)
+}
+
+// SetImpersonator stamps event with the staff member who made the change
+// impersonating its actor, see Header.ImpersonatorID. The publishers call it
+// for the changes made in an impersonation session.
+func SetImpersonator(event Event, impersonatorID uuid.UUID) {
+	if s, ok := event.(stamped); ok {
+		s.header().ImpersonatorID = impersonatorID
+	}
+}
+
+// GetUncommittedEvents returns the events recorded since the last
+// CommitEvents, oldest first.
 func (e *Recorder) GetUncommittedEvents() []Event {
 	if e == nil {
 		return nil
@@ -49,13 +124,38 @@ func (e *Recorder) GetUncommittedEvents() []Event {
 	return e.events
 }
 
-func (e *Recorder) MarkEventsAsCommitted() {
+// CommitEvents clears the uncommitted events and adds them to the version,
+// the repositories call it once the events are saved.
+func (e *Recorder) CommitEvents() {
 	if e == nil {
 		return
 	}
+	e.version += len(e.events)
 	e.events = []Event{}
 }
 
+// Deprecated: MarkEventsAsCommitted is CommitEvents.
+func (e *Recorder) MarkEventsAsCommitted() {
+	e.CommitEvents()
+}
+
+// Version returns the version of the aggregate, the uncommitted events are
+// not counted.
+func (e *Recorder) Version() int {
+	if e == nil {
+		return 0
+	}
+	return e.version
+}
+
+// SetVersion sets the version of an aggregate read back from a repository.
+func (e *Recorder) SetVersion(version int) {
+	if e == nil {
+		return
+	}
+	e.version = version
+}
+
 // AssertSingleEvent checks that exactly one event of the expected type was emitted
 func AssertSingleEvent[T Event](t *testing.T, events []Event) T {
 	t.Helper()
@@ -0,0 +1,124 @@
+package event
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecorder_CommitEvents(t *testing.T) {
+	a := &aggregate{}
+	assert.Equal(t, 0, a.Version())
+
+	a.AddEvent(&created{Name: "a"})
+	a.AddEvent(&deleted{})
+	assert.Len(t, a.GetUncommittedEvents(), 2)
+	assert.Equal(t, 0, a.Version(), "the uncommitted events are not counted")
+
+	a.CommitEvents()
+	assert.Empty(t, a.GetUncommittedEvents())
+	assert.Equal(t, 2, a.Version())
+
+	// a retried save after the commit has nothing to publish again
+	a.CommitEvents()
+	assert.Empty(t, a.GetUncommittedEvents())
+	assert.Equal(t, 2, a.Version())
+
+	a.AddEvent(&created{Name: "b"})
+	AssertEvents(t, a.GetUncommittedEvents(), OfType(named("b")))
+	a.CommitEvents()
+	assert.Equal(t, 3, a.Version())
+}
+
+func TestRecorder_FailedSaveKeepsEvents(t *testing.T) {
+	a := &aggregate{}
+	a.AddEvent(&created{Name: "a"})
+
+	// the save failed, the repository did not commit
+	first := a.GetUncommittedEvents()
+	retried := a.GetUncommittedEvents()
+
+	AssertEvents(t, retried, OfType(named("a")))
+	assert.Equal(t, first, retried)
+	assert.Equal(t, 0, a.Version())
+}
+
+func TestRecorder_SetVersion(t *testing.T) {
+	a := &aggregate{}
+	a.SetVersion(7)
+	a.AddEvent(&deleted{})
+	a.CommitEvents()
+
+	assert.Equal(t, 8, a.Version())
+}
+
+func TestRecorder_StampsHeader(t *testing.T) {
+	aggregateID, actorID := uuid.New(), uuid.New()
+	a := &aggregate{}
+	a.SetVersion(4) // read back from a repository
+
+	a.Record(&created{Name: "a"}, aggregateID, actorID)
+	a.AddEvent(&deleted{})
+
+	events := a.GetUncommittedEvents()
+	AssertHeader(t, events[0], aggregateID, actorID, 5)
+	AssertHeader(t, events[1], uuid.Nil, uuid.Nil, 6)
+	assert.NotEqual(t, events[0].GetEventHeader().EventID, events[1].GetEventHeader().EventID)
+}
+
+func TestRecorder_KeepsOccurredAt(t *testing.T) {
+	occurredAt := time.Date(2026, time.March, 2, 9, 0, 0, 0, time.UTC)
+	eventID := uuid.New()
+	e := &created{Header: Header{EventID: eventID, OccurredAt: occurredAt}, Name: "a"}
+
+	// e.g. an event decoded from a message and recorded by a rehydrated
+	// aggregate
+	a := &aggregate{}
+	a.SetVersion(1)
+	a.AddEvent(e)
+
+	h := e.GetEventHeader()
+	assert.Equal(t, eventID, h.EventID)
+	assert.Equal(t, occurredAt, h.OccurredAt)
+	assert.Equal(t, 2, h.AggregateVersion)
+}
+
+func TestRecorder_Nil(t *testing.T) {
+	var r *Recorder
+	assert.NotPanics(t, func() {
+		r.AddEvent(&deleted{})
+		r.CommitEvents()
+		r.SetVersion(1)
+	})
+	assert.Nil(t, r.GetUncommittedEvents())
+	assert.Equal(t, 0, r.Version())
+}
+
+// A Recorder is not safe for concurrent use, each request works on its own
+// aggregate. Run with -race to check that the aggregates share nothing.
+func TestRecorder_AggregatePerGoroutine(t *testing.T) {
+	const goroutines, events = 8, 100
+
+	aggregates := make([]*aggregate, goroutines)
+	var wg sync.WaitGroup
+	for i := range aggregates {
+		aggregates[i] = &aggregate{}
+		wg.Add(1)
+		go func(a *aggregate) {
+			defer wg.Done()
+			for range events {
+				a.AddEvent(&created{})
+				a.CommitEvents()
+			}
+		}(aggregates[i])
+	}
+	wg.Wait()
+
+	for _, a := range aggregates {
+		assert.Empty(t, a.GetUncommittedEvents())
+		assert.Equal(t, events, a.Version())
+	}
+}
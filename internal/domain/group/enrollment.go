@@ -0,0 +1,106 @@
+package group
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ARUMANDESU/validation"
+
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/i18nx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/validationx"
+)
+
+// ErrEnrollmentClosed matches the errors of CheckEnrollmentOpen with
+// errors.Is.
+var ErrEnrollmentClosed = errorx.NewGroupEnrollmentClosed()
+
+// SetEnrollmentWindow replaces the enrollment window of the group, checked
+// like the validity of the staff invitations: an end that changes can not be
+// in the past and the window lasts at least MinEnrollmentWindow. A nil end
+// leaves that side open, two nil ends make the group always open. It reports
+// whether anything changed.
+func (g *Group) SetEnrollmentWindow(opensAt, closesAt *time.Time, now time.Time) (bool, error) {
+	const op = "group.Group.SetEnrollmentWindow"
+	opensAt, closesAt = utc(opensAt), utc(closesAt)
+	opensChanged := !sameTime(g.enrollmentOpensAt, opensAt)
+	closesChanged := !sameTime(g.enrollmentClosesAt, closesAt)
+	if !opensChanged && !closesChanged {
+		return false, nil
+	}
+
+	clk := func() time.Time { return now }
+	err := validation.Errors{
+		i18nx.FieldEnrollmentOpensAt: validation.Validate(opensAt,
+			validation.NilOrNotEmpty,
+			validation.When(opensChanged, validationx.FutureTime(clk)),
+		),
+		i18nx.FieldEnrollmentClosesAt: validation.Validate(closesAt,
+			validation.NilOrNotEmpty,
+			validation.When(closesChanged, validationx.FutureTime(clk)),
+			validationx.TimeWindowRule{From: opensAt, MinDuration: MinEnrollmentWindow},
+		),
+	}.Filter()
+	if err != nil {
+		return false, errorx.Wrap(err, op)
+	}
+
+	g.enrollmentOpensAt = opensAt
+	g.enrollmentClosesAt = closesAt
+	g.updatedAt = now.UTC()
+	return true, nil
+}
+
+// EnrollmentOpen reports whether students may join the group at now, the
+// window includes its opening and excludes its closing.
+func (g *Group) EnrollmentOpen(now time.Time) bool {
+	if g.enrollmentOpensAt != nil && now.Before(*g.enrollmentOpensAt) {
+		return false
+	}
+	if g.enrollmentClosesAt != nil && !now.Before(*g.enrollmentClosesAt) {
+		return false
+	}
+	return true
+}
+
+// CheckEnrollmentOpen returns ErrEnrollmentClosed with the window in its
+// details when students can not join the group at now.
+func (g *Group) CheckEnrollmentOpen(now time.Time) error {
+	const op = "group.Group.CheckEnrollmentOpen"
+	if g.EnrollmentOpen(now) {
+		return nil
+	}
+
+	args := make(map[string]any, 2)
+	var window []string
+	if g.enrollmentOpensAt != nil {
+		at := g.enrollmentOpensAt.UTC().Format(time.RFC3339)
+		args[i18nx.ArgOpensAt] = at
+		window = append(window, "opens at "+at)
+	}
+	if g.enrollmentClosesAt != nil {
+		at := g.enrollmentClosesAt.UTC().Format(time.RFC3339)
+		args[i18nx.ArgClosesAt] = at
+		window = append(window, "closes at "+at)
+	}
+	return errorx.NewGroupEnrollmentClosed().
+		WithArgs(args).
+		WithDetails(fmt.Sprintf("the enrollment of group %s %s", g.id, strings.Join(window, " and "))).
+		WithOp(op)
+}
+
+func utc(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	u := t.UTC()
+	return &u
+}
+
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
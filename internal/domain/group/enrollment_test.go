@@ -0,0 +1,149 @@
+package group_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ARUMANDESU/validation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/group"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/i18nx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/validationx"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/builders"
+)
+
+var windowNow = time.Date(2026, time.August, 3, 9, 0, 0, 0, time.UTC)
+
+func at(d time.Duration) *time.Time {
+	t := windowNow.Add(d)
+	return &t
+}
+
+func TestGroup_EnrollmentOpen(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		opensAt  *time.Time
+		closesAt *time.Time
+		want     bool
+	}{
+		{name: "no window", want: true},
+		{name: "before opening", opensAt: at(time.Hour), want: false},
+		{name: "at opening", opensAt: at(0), closesAt: at(time.Hour), want: true},
+		{name: "inside", opensAt: at(-time.Hour), closesAt: at(time.Hour), want: true},
+		{name: "at closing", opensAt: at(-time.Hour), closesAt: at(0), want: false},
+		{name: "after closing", closesAt: at(-time.Hour), want: false},
+		{name: "open ended", opensAt: at(-time.Hour), want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			g := builders.NewGroupBuilder().WithEnrollmentWindow(tt.opensAt, tt.closesAt).Build()
+
+			assert.Equal(t, tt.want, g.EnrollmentOpen(windowNow))
+			err := g.CheckEnrollmentOpen(windowNow)
+			if tt.want {
+				assert.NoError(t, err)
+				return
+			}
+			require.ErrorIs(t, err, group.ErrEnrollmentClosed)
+			var i18nErr *errorx.I18nError
+			require.ErrorAs(t, err, &i18nErr)
+			assert.Equal(t, errorx.CodeGroupEnrollmentClosed, i18nErr.Code)
+			if tt.opensAt != nil {
+				assert.Equal(t, tt.opensAt.Format(time.RFC3339), i18nErr.MessageArgs[i18nx.ArgOpensAt])
+				assert.Contains(t, i18nErr.Details, tt.opensAt.Format(time.RFC3339))
+			}
+			if tt.closesAt != nil {
+				assert.Equal(t, tt.closesAt.Format(time.RFC3339), i18nErr.MessageArgs[i18nx.ArgClosesAt])
+				assert.Contains(t, i18nErr.Details, tt.closesAt.Format(time.RFC3339))
+			}
+		})
+	}
+}
+
+func TestGroup_SetEnrollmentWindow(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		current    [2]*time.Time
+		opensAt    *time.Time
+		closesAt   *time.Time
+		wantErrs   map[string]validation.Error
+		wantChange bool
+	}{
+		{name: "sets a window", opensAt: at(time.Hour), closesAt: at(2 * time.Hour), wantChange: true},
+		{name: "sets an open end", closesAt: at(time.Hour), wantChange: true},
+		{name: "clears the window", current: [2]*time.Time{at(-time.Hour), at(time.Hour)}, wantChange: true},
+		{
+			name:    "keeps the window",
+			current: [2]*time.Time{at(-time.Hour), at(time.Hour)},
+			opensAt: at(-time.Hour), closesAt: at(time.Hour),
+		},
+		{
+			name:    "an unchanged end may be past",
+			current: [2]*time.Time{at(-time.Hour), at(time.Hour)},
+			opensAt: at(-time.Hour), closesAt: at(3 * time.Hour),
+			wantChange: true,
+		},
+		{
+			name:     "opening in the past",
+			opensAt:  at(-time.Minute),
+			closesAt: at(time.Hour),
+			wantErrs: map[string]validation.Error{i18nx.FieldEnrollmentOpensAt: validationx.ErrTimeInPast},
+		},
+		{
+			name:     "closing in the past",
+			closesAt: at(-time.Minute),
+			wantErrs: map[string]validation.Error{i18nx.FieldEnrollmentClosesAt: validationx.ErrTimeInPast},
+		},
+		{
+			name:     "closing before opening",
+			opensAt:  at(2 * time.Hour),
+			closesAt: at(time.Hour),
+			wantErrs: map[string]validation.Error{i18nx.FieldEnrollmentClosesAt: validationx.ErrTimeBeforeThreshold},
+		},
+		{
+			name:     "window too short",
+			opensAt:  at(time.Hour),
+			closesAt: at(time.Hour + group.MinEnrollmentWindow - time.Second),
+			wantErrs: map[string]validation.Error{i18nx.FieldEnrollmentClosesAt: validationx.ErrTimeBeforeThreshold},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			g := builders.NewGroupBuilder().WithEnrollmentWindow(tt.current[0], tt.current[1]).Build()
+			before := g.UpdatedAt()
+
+			changed, err := g.SetEnrollmentWindow(tt.opensAt, tt.closesAt, windowNow)
+			if tt.wantErrs != nil {
+				var errs validation.Errors
+				require.ErrorAs(t, err, &errs)
+				require.Len(t, errs, len(tt.wantErrs))
+				for field, want := range tt.wantErrs {
+					var got validation.Error
+					require.ErrorAs(t, errs[field], &got, field)
+					assert.Equal(t, want.Code(), got.Code(), field)
+				}
+				assert.Equal(t, tt.current[0], g.EnrollmentOpensAt(), "a rejected window is not set")
+				assert.Equal(t, tt.current[1], g.EnrollmentClosesAt(), "a rejected window is not set")
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantChange, changed)
+			assert.Equal(t, tt.opensAt, g.EnrollmentOpensAt())
+			assert.Equal(t, tt.closesAt, g.EnrollmentClosesAt())
+			if tt.wantChange {
+				assert.Equal(t, windowNow, g.UpdatedAt())
+			} else {
+				assert.Equal(t, before, g.UpdatedAt())
+			}
+		})
+	}
+}
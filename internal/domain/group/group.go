@@ -10,7 +10,9 @@ import (
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/term"
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/majors"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
 	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
 )
 
@@ -19,6 +21,9 @@ const (
 	MaxNameLength = 100
 	MinYearLength = 1
 	MaxYearLength = 3
+	// MinEnrollmentWindow is the shortest enrollment window, like the
+	// validity of the staff invitations.
+	MinEnrollmentWindow = time.Minute
 )
 
 var YearPattern = regexp.MustCompile(`^\d{1,3}$`)
@@ -59,13 +64,55 @@ type Group struct {
 	year      string
 	createdAt time.Time
 	updatedAt time.Time
+	// enrollmentOpensAt and enrollmentClosesAt bound when students may join
+	// the group, a nil end leaves that side open.
+	enrollmentOpensAt  *time.Time
+	enrollmentClosesAt *time.Time
+	// termID is the term the group studies in, nil for the groups created
+	// before the terms.
+	termID *term.ID
 }
 
 func NewGroup(name, year string, m majors.Major) (*Group, error) {
 	const op = "group.NewGroup"
+	if err := validate(name, year, m); err != nil {
+		return nil, errorx.Wrap(err, op)
+	}
+
+	now := clock.Real.Now().UTC()
+
+	return &Group{
+		id:        NewID(),
+		name:      name,
+		major:     m,
+		year:      year,
+		createdAt: now,
+		updatedAt: now,
+	}, nil
+}
+
+// Update replaces the name, the year and the major of the group, checked
+// like NewGroup checks them. It reports whether anything changed.
+func (g *Group) Update(name, year string, m majors.Major) (bool, error) {
+	const op = "group.Group.Update"
+	if err := validate(name, year, m); err != nil {
+		return false, errorx.Wrap(err, op)
+	}
+	if g.name == name && g.year == year && g.major == m {
+		return false, nil
+	}
+
+	g.name = name
+	g.year = year
+	g.major = m
+	g.updatedAt = clock.Real.Now().UTC()
+	return true, nil
+}
+
+func validate(name, year string, m majors.Major) error {
 	err := validation.Validate(name, validation.Required, validation.Length(MinNameLength, MaxNameLength))
 	if err != nil {
-		return nil, errorx.Wrap(err, op)
+		return err
 	}
 	err = validation.Validate(
 		year,
@@ -74,41 +121,37 @@ func NewGroup(name, year string, m majors.Major) (*Group, error) {
 		validation.Match(YearPattern).Error("validation_"),
 	)
 	if err != nil {
-		return nil, errorx.Wrap(err, op)
+		return err
 	}
 	if !majors.IsValid(m) {
-		return nil, errorx.Wrap(majors.ErrInvalidMajor, op)
+		return majors.ErrInvalidMajor
 	}
-
-	now := time.Now().UTC()
-
-	return &Group{
-		id:        NewID(),
-		name:      name,
-		major:     m,
-		year:      year,
-		createdAt: now,
-		updatedAt: now,
-	}, nil
+	return nil
 }
 
 type RehydrateArgs struct {
-	ID        ID
-	Name      string
-	Major     majors.Major
-	Year      string
-	CreatedAt time.Time
-	UpdatedAt time.Time
+	ID                 ID
+	Name               string
+	Major              majors.Major
+	Year               string
+	CreatedAt          time.Time
+	UpdatedAt          time.Time
+	EnrollmentOpensAt  *time.Time
+	EnrollmentClosesAt *time.Time
+	TermID             *term.ID
 }
 
 func Rehydrate(args RehydrateArgs) *Group {
 	return &Group{
-		id:        args.ID,
-		name:      args.Name,
-		major:     args.Major,
-		year:      args.Year,
-		createdAt: args.CreatedAt,
-		updatedAt: args.UpdatedAt,
+		id:                 args.ID,
+		name:               args.Name,
+		major:              args.Major,
+		year:               args.Year,
+		createdAt:          args.CreatedAt,
+		updatedAt:          args.UpdatedAt,
+		enrollmentOpensAt:  args.EnrollmentOpensAt,
+		enrollmentClosesAt: args.EnrollmentClosesAt,
+		termID:             args.TermID,
 	}
 }
 
@@ -136,6 +179,36 @@ func (g *Group) UpdatedAt() time.Time {
 	return g.updatedAt
 }
 
+// EnrollmentOpensAt returns when students may start joining the group, nil
+// when they always could.
+func (g *Group) EnrollmentOpensAt() *time.Time {
+	return g.enrollmentOpensAt
+}
+
+// EnrollmentClosesAt returns when students stop being able to join the
+// group, nil when they always will.
+func (g *Group) EnrollmentClosesAt() *time.Time {
+	return g.enrollmentClosesAt
+}
+
+// TermID returns the term the group studies in, nil when it has none.
+func (g *Group) TermID() *term.ID {
+	return g.termID
+}
+
+// SetTerm moves the group to the term id, set when the group is created and
+// when it rolls over to the next term. A nil id leaves the group without a
+// term. It reports whether the term changed.
+func (g *Group) SetTerm(id *term.ID) bool {
+	if g.termID == nil && id == nil || g.termID != nil && id != nil && *g.termID == *id {
+		return false
+	}
+
+	g.termID = id
+	g.updatedAt = clock.Real.Now().UTC()
+	return true
+}
+
 type GroupAssertion struct {
 	group *Group
 }
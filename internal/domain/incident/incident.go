@@ -0,0 +1,264 @@
+package incident
+
+import (
+	"encoding/json"
+	"slices"
+	"time"
+
+	"github.com/ARUMANDESU/validation"
+	"github.com/google/uuid"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/validationx"
+)
+
+const (
+	MaxTitleLen = 200
+	MaxNoteLen  = 2000
+	// MaxNotes is the number of update notes of an incident, a longer one
+	// is better closed and followed by a new one.
+	MaxNotes = 100
+)
+
+type ID uuid.UUID
+
+func NewID() ID {
+	return ID(uuid.New())
+}
+
+func (id ID) String() string {
+	return uuid.UUID(id).String()
+}
+
+func (id ID) MarshalJSON() ([]byte, error) {
+	return json.Marshal(uuid.UUID(id).String())
+}
+
+func (id *ID) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+
+	uid, err := uuid.Parse(s)
+	if err != nil {
+		return err
+	}
+
+	*id = ID(uid)
+	return nil
+}
+
+// Severity is the impact of an incident on the users.
+type Severity string
+
+const (
+	SeverityMinor    Severity = "minor"
+	SeverityMajor    Severity = "major"
+	SeverityCritical Severity = "critical"
+)
+
+var Severities = []any{SeverityMinor, SeverityMajor, SeverityCritical}
+
+// Note is an update posted on an incident, e.g. the cause once it is found.
+type Note struct {
+	Text string    `json:"text"`
+	At   time.Time `json:"at"`
+}
+
+// Incident is an outage or a degradation the staff report on the status
+// page, from its start until it is resolved.
+type Incident struct {
+	id         ID
+	title      string
+	severity   Severity
+	startedAt  time.Time
+	resolvedAt *time.Time
+	notes      []Note
+	createdBy  user.ID
+	createdAt  time.Time
+	updatedAt  time.Time
+	clock      clock.Clock
+}
+
+type CreateArgs struct {
+	// ID defaults to a new one.
+	ID        ID
+	CreatedBy user.ID
+	Title     string
+	Severity  Severity
+	// StartedAt defaults to now.
+	StartedAt time.Time
+	// ResolvedAt reports an incident already over.
+	ResolvedAt *time.Time
+	// Note is the first update, optional.
+	Note string
+	// Clock defaults to clock.Real.
+	Clock clock.Clock
+}
+
+func New(args CreateArgs) (*Incident, error) {
+	const op = "incident.New"
+	now := clock.Or(args.Clock).Now().UTC()
+	if args.StartedAt.IsZero() {
+		args.StartedAt = now
+	}
+	err := validation.ValidateStruct(&args,
+		validation.Field(&args.CreatedBy, validationx.Required),
+		validation.Field(&args.Title, validation.Required, validation.RuneLength(1, MaxTitleLen)),
+		validation.Field(&args.Severity, validation.Required, validation.In(Severities...)),
+		validation.Field(&args.ResolvedAt, validationx.AfterField(&args.StartedAt)),
+		validation.Field(&args.Note, validation.RuneLength(0, MaxNoteLen)),
+	)
+	if err != nil {
+		return nil, errorx.Wrap(err, op)
+	}
+
+	if args.ID == (ID{}) {
+		args.ID = NewID()
+	}
+	i := &Incident{
+		id:         args.ID,
+		title:      args.Title,
+		severity:   args.Severity,
+		startedAt:  args.StartedAt.UTC(),
+		resolvedAt: utc(args.ResolvedAt),
+		notes:      []Note{},
+		createdBy:  args.CreatedBy,
+		createdAt:  now,
+		updatedAt:  now,
+		clock:      args.Clock,
+	}
+	if args.Note != "" {
+		i.notes = append(i.notes, Note{Text: args.Note, At: now})
+	}
+
+	return i, nil
+}
+
+type UpdateArgs struct {
+	Title     string
+	Severity  Severity
+	StartedAt time.Time
+	// ResolvedAt resolves the incident, nil reopens it.
+	ResolvedAt *time.Time
+	// Note is appended to the updates, optional.
+	Note string
+}
+
+// Update replaces the fields of the incident with args and posts its note.
+func (i *Incident) Update(args UpdateArgs) error {
+	const op = "incident.Incident.Update"
+	err := validation.ValidateStruct(&args,
+		validation.Field(&args.Title, validation.Required, validation.RuneLength(1, MaxTitleLen)),
+		validation.Field(&args.Severity, validation.Required, validation.In(Severities...)),
+		validation.Field(&args.StartedAt, validation.Required),
+		validation.Field(&args.ResolvedAt, validationx.AfterField(&args.StartedAt)),
+		validation.Field(&args.Note, validation.RuneLength(0, MaxNoteLen)),
+	)
+	if err != nil {
+		return errorx.Wrap(err, op)
+	}
+
+	now := clock.Or(i.clock).Now().UTC()
+	notes := i.notes
+	if args.Note != "" {
+		notes = append(slices.Clip(notes), Note{Text: args.Note, At: now})
+	}
+	err = validation.Errors{
+		"notes": validation.Validate(notes, validation.Count(0, MaxNotes)),
+	}.Filter()
+	if err != nil {
+		return errorx.Wrap(err, op)
+	}
+
+	i.title = args.Title
+	i.severity = args.Severity
+	i.startedAt = args.StartedAt.UTC()
+	i.resolvedAt = utc(args.ResolvedAt)
+	i.notes = notes
+	i.updatedAt = now
+
+	return nil
+}
+
+func utc(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	u := t.UTC()
+	return &u
+}
+
+type RehydrateArgs struct {
+	ID         ID
+	Title      string
+	Severity   Severity
+	StartedAt  time.Time
+	ResolvedAt *time.Time
+	Notes      []Note
+	CreatedBy  user.ID
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	// Clock defaults to clock.Real.
+	Clock clock.Clock
+}
+
+func Rehydrate(args RehydrateArgs) *Incident {
+	return &Incident{
+		id:         args.ID,
+		title:      args.Title,
+		severity:   args.Severity,
+		startedAt:  args.StartedAt,
+		resolvedAt: args.ResolvedAt,
+		notes:      args.Notes,
+		createdBy:  args.CreatedBy,
+		createdAt:  args.CreatedAt,
+		updatedAt:  args.UpdatedAt,
+		clock:      args.Clock,
+	}
+}
+
+func (i *Incident) ID() ID {
+	return i.id
+}
+
+func (i *Incident) Title() string {
+	return i.title
+}
+
+func (i *Incident) Severity() Severity {
+	return i.severity
+}
+
+func (i *Incident) StartedAt() time.Time {
+	return i.startedAt
+}
+
+// ResolvedAt is nil while the incident is ongoing.
+func (i *Incident) ResolvedAt() *time.Time {
+	return i.resolvedAt
+}
+
+func (i *Incident) IsResolved() bool {
+	return i.resolvedAt != nil
+}
+
+// Notes are the updates, the oldest first.
+func (i *Incident) Notes() []Note {
+	return slices.Clone(i.notes)
+}
+
+func (i *Incident) CreatedBy() user.ID {
+	return i.createdBy
+}
+
+func (i *Incident) CreatedAt() time.Time {
+	return i.createdAt
+}
+
+func (i *Incident) UpdatedAt() time.Time {
+	return i.updatedAt
+}
@@ -0,0 +1,100 @@
+package incident_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/incident"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
+)
+
+var incidentNow = time.Date(2026, time.May, 6, 9, 0, 0, 0, time.UTC)
+
+func validArgs(clk clock.Clock) incident.CreateArgs {
+	return incident.CreateArgs{
+		CreatedBy: user.NewID(),
+		Title:     "Avatar uploads failing",
+		Severity:  incident.SeverityMajor,
+		Note:      "Investigating",
+		Clock:     clk,
+	}
+}
+
+func TestNew(t *testing.T) {
+	t.Run("started now by default", func(t *testing.T) {
+		i, err := incident.New(validArgs(clock.NewFake(incidentNow)))
+		require.NoError(t, err)
+		assert.Equal(t, incidentNow, i.StartedAt())
+		assert.False(t, i.IsResolved())
+		assert.Equal(t, []incident.Note{{Text: "Investigating", At: incidentNow}}, i.Notes())
+	})
+
+	tests := []struct {
+		name   string
+		modify func(*incident.CreateArgs)
+	}{
+		{"no title", func(a *incident.CreateArgs) { a.Title = "" }},
+		{"long title", func(a *incident.CreateArgs) { a.Title = strings.Repeat("a", incident.MaxTitleLen+1) }},
+		{"unknown severity", func(a *incident.CreateArgs) { a.Severity = "apocalyptic" }},
+		{"no creator", func(a *incident.CreateArgs) { a.CreatedBy = user.ID{} }},
+		{"resolved before started", func(a *incident.CreateArgs) {
+			resolved := incidentNow.Add(-time.Hour)
+			a.ResolvedAt = &resolved
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := validArgs(clock.NewFake(incidentNow))
+			tt.modify(&args)
+			_, err := incident.New(args)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestIncident_Update(t *testing.T) {
+	clk := clock.NewFake(incidentNow)
+	i, err := incident.New(validArgs(clk))
+	require.NoError(t, err)
+
+	clk.Advance(time.Hour)
+	resolved := clk.Now()
+	err = i.Update(incident.UpdateArgs{
+		Title:      "Avatar uploads failed",
+		Severity:   incident.SeverityMinor,
+		StartedAt:  i.StartedAt(),
+		ResolvedAt: &resolved,
+		Note:       "Storage is back",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Avatar uploads failed", i.Title())
+	assert.Equal(t, incident.SeverityMinor, i.Severity())
+	assert.Equal(t, &resolved, i.ResolvedAt())
+	assert.Equal(t, resolved, i.UpdatedAt())
+	assert.Len(t, i.Notes(), 2)
+
+	err = i.Update(incident.UpdateArgs{Title: "x", Severity: incident.SeverityMinor, StartedAt: i.StartedAt()})
+	require.NoError(t, err)
+	assert.False(t, i.IsResolved(), "a nil ResolvedAt reopens it")
+	assert.Len(t, i.Notes(), 2, "no note is posted without one")
+}
+
+func TestIncident_Update_TooManyNotes(t *testing.T) {
+	notes := make([]incident.Note, incident.MaxNotes)
+	i := incident.Rehydrate(incident.RehydrateArgs{
+		ID:        incident.NewID(),
+		Title:     "Outage",
+		Severity:  incident.SeverityCritical,
+		StartedAt: incidentNow,
+		Notes:     notes,
+	})
+
+	err := i.Update(incident.UpdateArgs{Title: "Outage", Severity: incident.SeverityCritical, StartedAt: incidentNow, Note: "one more"})
+	assert.Error(t, err)
+	assert.Len(t, i.Notes(), incident.MaxNotes, "the incident is left unchanged")
+}
@@ -0,0 +1,206 @@
+package notification
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/ARUMANDESU/validation"
+	"github.com/google/uuid"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/validationx"
+)
+
+const (
+	MaxTitleLen = 200
+	MaxBodyLen  = 2000
+	MaxLinkLen  = 2048
+)
+
+// Type is what a notification is about, the clients pick its icon from it.
+type Type string
+
+const (
+	TypeInvitationAccepted Type = "invitation_accepted"
+	TypeGroupChanged       Type = "group_changed"
+	TypeAnnouncement       Type = "announcement"
+	TypeAvatarRejected     Type = "avatar_rejected"
+)
+
+func (t Type) String() string {
+	return string(t)
+}
+
+type ID uuid.UUID
+
+func NewID() ID {
+	return ID(uuid.New())
+}
+
+func (id ID) String() string {
+	return uuid.UUID(id).String()
+}
+
+func (id ID) MarshalJSON() ([]byte, error) {
+	return json.Marshal(uuid.UUID(id).String())
+}
+
+func (id *ID) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+
+	uid, err := uuid.Parse(s)
+	if err != nil {
+		return err
+	}
+
+	*id = ID(uid)
+	return nil
+}
+
+// Notification is an in-app message for one user. It is created from the
+// event it tells about, a user gets at most one notification per event.
+type Notification struct {
+	id        ID
+	userID    user.ID
+	eventID   uuid.UUID
+	typ       Type
+	title     string
+	body      string
+	link      string
+	readAt    *time.Time
+	createdAt time.Time
+	clock     clock.Clock
+}
+
+type CreateArgs struct {
+	UserID user.ID
+	// EventID is the ID of the event the notification tells about, see
+	// event.Header.
+	EventID uuid.UUID
+	Type    Type
+	Title   string
+	Body    string
+	// Link is the page of the client the notification opens, it is
+	// optional.
+	Link string
+	// Clock defaults to clock.Real.
+	Clock clock.Clock
+}
+
+func New(args CreateArgs) (*Notification, error) {
+	const op = "notification.New"
+	err := validation.ValidateStruct(&args,
+		validation.Field(&args.UserID, validationx.Required),
+		validation.Field(&args.EventID, validationx.Required),
+		validation.Field(&args.Type, validation.Required, validation.In(TypeInvitationAccepted, TypeGroupChanged, TypeAnnouncement, TypeAvatarRejected)),
+		validation.Field(&args.Title, validation.Required, validation.RuneLength(1, MaxTitleLen)),
+		validation.Field(&args.Body, validation.RuneLength(0, MaxBodyLen)),
+		validation.Field(&args.Link, validation.RuneLength(0, MaxLinkLen)),
+	)
+	if err != nil {
+		return nil, errorx.Wrap(err, op)
+	}
+
+	return &Notification{
+		id:        NewID(),
+		userID:    args.UserID,
+		eventID:   args.EventID,
+		typ:       args.Type,
+		title:     args.Title,
+		body:      args.Body,
+		link:      args.Link,
+		createdAt: clock.Or(args.Clock).Now().UTC(),
+		clock:     args.Clock,
+	}, nil
+}
+
+type RehydrateArgs struct {
+	ID        ID
+	UserID    user.ID
+	EventID   uuid.UUID
+	Type      Type
+	Title     string
+	Body      string
+	Link      string
+	ReadAt    *time.Time
+	CreatedAt time.Time
+	// Clock defaults to clock.Real.
+	Clock clock.Clock
+}
+
+func Rehydrate(args RehydrateArgs) *Notification {
+	return &Notification{
+		id:        args.ID,
+		userID:    args.UserID,
+		eventID:   args.EventID,
+		typ:       args.Type,
+		title:     args.Title,
+		body:      args.Body,
+		link:      args.Link,
+		readAt:    args.ReadAt,
+		createdAt: args.CreatedAt,
+		clock:     args.Clock,
+	}
+}
+
+// MarkRead marks the notification as read, reading it again changes nothing.
+func (n *Notification) MarkRead() {
+	if n.readAt != nil {
+		return
+	}
+	now := clock.Or(n.clock).Now().UTC()
+	n.readAt = &now
+}
+
+func (n *Notification) ID() ID {
+	return n.id
+}
+
+func (n *Notification) UserID() user.ID {
+	return n.userID
+}
+
+func (n *Notification) EventID() uuid.UUID {
+	return n.eventID
+}
+
+func (n *Notification) Type() Type {
+	return n.typ
+}
+
+func (n *Notification) Title() string {
+	return n.title
+}
+
+func (n *Notification) Body() string {
+	return n.body
+}
+
+func (n *Notification) Link() string {
+	return n.link
+}
+
+func (n *Notification) ReadAt() *time.Time {
+	return n.readAt
+}
+
+func (n *Notification) IsRead() bool {
+	return n.readAt != nil
+}
+
+func (n *Notification) CreatedAt() time.Time {
+	return n.createdAt
+}
+
+// ListParams selects a page of the notifications of a user, the newest
+// first.
+type ListParams struct {
+	UnreadOnly bool
+	Limit      int
+	Offset     int
+}
@@ -0,0 +1,96 @@
+package notification_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ARUMANDESU/validation"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/notification"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/fixtures"
+)
+
+var testNow = time.Date(2026, time.March, 2, 9, 0, 0, 0, time.UTC)
+
+func validArgs() notification.CreateArgs {
+	return notification.CreateArgs{
+		UserID:  fixtures.TestStudent.ID,
+		EventID: uuid.New(),
+		Type:    notification.TypeGroupChanged,
+		Title:   "You were moved to another group",
+		Body:    "You are now in SE-2301.",
+		Clock:   clock.NewFake(testNow),
+	}
+}
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	args := validArgs()
+	n, err := notification.New(args)
+	require.NoError(t, err)
+	assert.NotEqual(t, notification.ID{}, n.ID())
+	assert.Equal(t, args.UserID, n.UserID())
+	assert.Equal(t, args.EventID, n.EventID())
+	assert.Equal(t, notification.TypeGroupChanged, n.Type())
+	assert.Equal(t, args.Title, n.Title())
+	assert.Equal(t, args.Body, n.Body())
+	assert.Equal(t, testNow, n.CreatedAt())
+	assert.False(t, n.IsRead())
+}
+
+func TestNew_ArgValidation(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		modify func(*notification.CreateArgs)
+		field  string
+	}{
+		{name: "no user", modify: func(a *notification.CreateArgs) { a.UserID = user.ID{} }, field: "UserID"},
+		{name: "no event", modify: func(a *notification.CreateArgs) { a.EventID = uuid.Nil }, field: "EventID"},
+		{name: "unknown type", modify: func(a *notification.CreateArgs) { a.Type = "party" }, field: "Type"},
+		{name: "no title", modify: func(a *notification.CreateArgs) { a.Title = "" }, field: "Title"},
+		{name: "body too long", modify: func(a *notification.CreateArgs) {
+			a.Body = strings.Repeat("a", notification.MaxBodyLen+1)
+		}, field: "Body"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			args := validArgs()
+			tt.modify(&args)
+
+			n, err := notification.New(args)
+			assert.Nil(t, n)
+			var verrs validation.Errors
+			require.ErrorAs(t, err, &verrs)
+			assert.Contains(t, verrs, tt.field)
+		})
+	}
+}
+
+func TestNotification_MarkRead(t *testing.T) {
+	t.Parallel()
+
+	clk := clock.NewFake(testNow)
+	args := validArgs()
+	args.Clock = clk
+	n, err := notification.New(args)
+	require.NoError(t, err)
+
+	n.MarkRead()
+	require.NotNil(t, n.ReadAt())
+	assert.Equal(t, testNow, *n.ReadAt())
+
+	clk.Advance(time.Hour)
+	n.MarkRead()
+	assert.Equal(t, testNow, *n.ReadAt(), "reading it again keeps the first read")
+}
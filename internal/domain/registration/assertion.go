@@ -5,7 +5,10 @@ import (
 	"testing"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/event"
 )
 
 type RegistrationAssertion struct {
@@ -24,7 +27,7 @@ func (ra *RegistrationAssertion) AssertStatus(t *testing.T, expected Status) *Re
 
 func (ra *RegistrationAssertion) AssertEmail(t *testing.T, expected string) *RegistrationAssertion {
 	t.Helper()
-	assert.Equal(t, expected, ra.Registration.email, "Expected registration email to be %s, got %s", expected, ra.Registration.email)
+	assert.Equal(t, expected, ra.Registration.email.String(), "Expected registration email to be %s, got %s", expected, ra.Registration.email)
 	return ra
 }
 
@@ -101,25 +104,30 @@ func (ra *RegistrationAssertion) AssertResendTimeout(t *testing.T, expected time
 	return ra
 }
 
+// NextResendAt returns the stored end of the resend cooldown.
+func (ra *RegistrationAssertion) NextResendAt() time.Time {
+	return ra.Registration.resendTimeout
+}
+
 func (ra *RegistrationAssertion) AssertResendNotAvailable(t *testing.T) *RegistrationAssertion {
 	t.Helper()
 	assert.True(
 		t,
 		// check if resend timeout is in the future, because if it is, then resend is not available
-		ra.Registration.resendTimeout.After(time.Now()),
+		ra.Registration.resendTimeout.After(ra.Registration.now()),
 		"Expected registration resend timeout to be in the future, got %s; current time is %s",
 		ra.Registration.resendTimeout,
-		time.Now(),
+		ra.Registration.now(),
 	)
 	return ra
 }
 
 func (ra *RegistrationAssertion) AssertIsNotExpired(t *testing.T) *RegistrationAssertion {
 	t.Helper()
-	assert.True(t, ra.Registration.codeExpiresAt.After(time.Now()),
+	assert.True(t, ra.Registration.codeExpiresAt.After(ra.Registration.now()),
 		"Expected registration code to not be expired, but it is; code expires at %s, current time is %s",
 		ra.Registration.codeExpiresAt,
-		time.Now(),
+		ra.Registration.now(),
 	)
 	return ra
 }
@@ -166,7 +174,7 @@ func (rsa *RegistrationStartedAssertion) AssertRegistrationID(t *testing.T, expe
 
 func (rsa *RegistrationStartedAssertion) AssertEmail(t *testing.T, expected string) *RegistrationStartedAssertion {
 	t.Helper()
-	assert.Equal(t, expected, rsa.event.Email, "Expected registration email to be %s, got %s", expected, rsa.event.Email)
+	assert.Equal(t, expected, rsa.event.Email.String(), "Expected registration email to be %s, got %s", expected, rsa.event.Email)
 	return rsa
 }
 
@@ -189,6 +197,14 @@ func (rsa *RegistrationStartedAssertion) AssertRegistrationIDNotEmpty(t *testing
 	return rsa
 }
 
+// AssertHeader checks that the event is the first one of the registration,
+// started by an anonymous visitor.
+func (rsa *RegistrationStartedAssertion) AssertHeader(t *testing.T) *RegistrationStartedAssertion {
+	t.Helper()
+	event.AssertHeader(t, rsa.event, uuid.UUID(rsa.event.RegistrationID), uuid.Nil, 1)
+	return rsa
+}
+
 func (rsa *RegistrationStartedAssertion) AssertVerificationCodeNotEmpty(t *testing.T) *RegistrationStartedAssertion {
 	t.Helper()
 	assert.NotEmpty(t, rsa.event.VerificationCode, "Expected registration verification code to not be empty")
@@ -211,7 +227,7 @@ func (vsa *VerificationCodeResentAssertion) AssertRegistrationID(t *testing.T, e
 
 func (vsa *VerificationCodeResentAssertion) AssertEmail(t *testing.T, expected string) *VerificationCodeResentAssertion {
 	t.Helper()
-	assert.Equal(t, expected, vsa.event.Email, "Expected registration email to be %s, got %s", expected, vsa.event.Email)
+	assert.Equal(t, expected, vsa.event.Email.String(), "Expected registration email to be %s, got %s", expected, vsa.event.Email)
 	return vsa
 }
 
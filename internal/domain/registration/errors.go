@@ -2,6 +2,7 @@ package registration
 
 import (
 	"net/http"
+	"time"
 
 	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
 	"gitlab.com/ucmsv2/ucms-backend/pkg/i18nx"
@@ -21,3 +22,56 @@ var (
 	)
 	ErrVerifyFirst = errorx.NewInvalidRequest().WithKey(i18nx.KeyVerifyFirst)
 )
+
+// ErrResendTooSoon is returned by ResendCode during the resend cooldown, it
+// matches ErrWaitUntilResend.
+type ErrResendTooSoon struct {
+	AvailableAt time.Time
+	wait        time.Duration
+}
+
+func newErrResendTooSoon(availableAt, now time.Time) *ErrResendTooSoon {
+	return &ErrResendTooSoon{AvailableAt: availableAt, wait: availableAt.Sub(now)}
+}
+
+func (e *ErrResendTooSoon) Error() string {
+	return "resend available at " + e.AvailableAt.UTC().Format(time.RFC3339)
+}
+
+func (e *ErrResendTooSoon) Unwrap() error {
+	return errorx.NewRateLimitExceededWithRetry(errorx.RetryAfterSeconds(e.wait))
+}
+
+func (e *ErrResendTooSoon) RetryAt() time.Time        { return e.AvailableAt }
+func (e *ErrResendTooSoon) RetryAfter() time.Duration { return e.wait }
+
+// ErrTooManyAttempts is returned by VerifyCode on the last failed attempt,
+// it matches ErrPersistentTooManyAttempts and is persisted like it. The
+// registration is expired, a new code is sent once the resend cooldown ends
+// at LockedUntil.
+type ErrTooManyAttempts struct {
+	LockedUntil time.Time
+	wait        time.Duration
+}
+
+func newErrTooManyAttempts(lockedUntil, now time.Time) *ErrTooManyAttempts {
+	if lockedUntil.Before(now) {
+		lockedUntil = now
+	}
+	return &ErrTooManyAttempts{LockedUntil: lockedUntil, wait: lockedUntil.Sub(now)}
+}
+
+func (e *ErrTooManyAttempts) Error() string {
+	return "too many attempts, locked until " + e.LockedUntil.UTC().Format(time.RFC3339)
+}
+
+func (e *ErrTooManyAttempts) Unwrap() error {
+	return errorx.NewPersistable(errorx.NewRateLimitExceededWithRetry(errorx.RetryAfterSeconds(e.wait)))
+}
+
+func (e *ErrTooManyAttempts) Is(target error) bool {
+	return target == ErrPersistentTooManyAttempts
+}
+
+func (e *ErrTooManyAttempts) RetryAt() time.Time        { return e.LockedUntil }
+func (e *ErrTooManyAttempts) RetryAfter() time.Duration { return e.wait }
@@ -1,7 +1,11 @@
 package registration
 
 import (
+	"github.com/ARUMANDESU/validation"
+
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/event"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/emails"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/validationx"
 )
 
 const EventStreamName = "events_registration"
@@ -9,20 +13,29 @@ const EventStreamName = "events_registration"
 type RegistrationStarted struct {
 	event.Header
 	event.Otel
-	RegistrationID   ID     `json:"registration_id"`
-	Email            string `json:"email"`
-	VerificationCode string `json:"verification_code"`
+	RegistrationID   ID           `json:"registration_id"`
+	Email            emails.Email `json:"email"`
+	VerificationCode string       `json:"verification_code"`
 }
 
 func (e *RegistrationStarted) GetStreamName() string {
 	return EventStreamName
 }
 
+// Validate reports the fields the mail handlers need.
+func (e *RegistrationStarted) Validate() error {
+	return validation.ValidateStruct(e,
+		validation.Field(&e.RegistrationID, validationx.Required),
+		validation.Field(&e.Email, validation.Required),
+		validation.Field(&e.VerificationCode, validation.Required),
+	)
+}
+
 type EmailVerified struct {
 	event.Header
 	event.Otel
-	RegistrationID ID     `json:"registration_id"`
-	Email          string `json:"email"`
+	RegistrationID ID           `json:"registration_id"`
+	Email          emails.Email `json:"email"`
 }
 
 func (e *EmailVerified) GetStreamName() string {
@@ -43,11 +56,20 @@ func (e *RegistrationFailed) GetStreamName() string {
 type VerificationCodeResent struct {
 	event.Header
 	event.Otel
-	RegistrationID   ID     `json:"registration_id"`
-	Email            string `json:"email"`
-	VerificationCode string `json:"verification_code"`
+	RegistrationID   ID           `json:"registration_id"`
+	Email            emails.Email `json:"email"`
+	VerificationCode string       `json:"verification_code"`
 }
 
 func (e *VerificationCodeResent) GetStreamName() string {
 	return EventStreamName
 }
+
+// Validate reports the fields the mail handlers need.
+func (e *VerificationCodeResent) Validate() error {
+	return validation.ValidateStruct(e,
+		validation.Field(&e.RegistrationID, validationx.Required),
+		validation.Field(&e.Email, validation.Required),
+		validation.Field(&e.VerificationCode, validation.Required),
+	)
+}
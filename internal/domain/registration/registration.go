@@ -10,6 +10,8 @@ import (
 	"github.com/google/uuid"
 
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/event"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/emails"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
 	"gitlab.com/ucmsv2/ucms-backend/pkg/env"
 	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
 	"gitlab.com/ucmsv2/ucms-backend/pkg/randcode"
@@ -68,7 +70,7 @@ func (id *ID) UnmarshalJSON(data []byte) error {
 type Registration struct {
 	event.Recorder
 	id               ID
-	email            string
+	email            emails.Email
 	status           Status
 	verificationCode string
 	codeAttempts     int8
@@ -76,9 +78,12 @@ type Registration struct {
 	codeExpiresAt    time.Time
 	createdAt        time.Time
 	updatedAt        time.Time
+	clock            clock.Clock
 }
 
-func NewRegistration(email string, mode env.Mode) (*Registration, error) {
+// NewRegistration starts the registration of email. clk is the clock of the
+// registration, a nil clk is clock.Real.
+func NewRegistration(email emails.Email, mode env.Mode, clk clock.Clock) (*Registration, error) {
 	const op = "registration.NewRegistration"
 	err := validation.Validate(&email, validation.Required, is.Email)
 	if err != nil {
@@ -89,7 +94,7 @@ func NewRegistration(email string, mode env.Mode) (*Registration, error) {
 	if err != nil {
 		return nil, errorx.Wrap(err, op)
 	}
-	now := time.Now().UTC()
+	now := clock.Or(clk).Now().UTC()
 
 	reg := &Registration{
 		id:               NewID(),
@@ -101,21 +106,21 @@ func NewRegistration(email string, mode env.Mode) (*Registration, error) {
 		codeAttempts:     0,
 		createdAt:        now,
 		updatedAt:        now,
+		clock:            clk,
 	}
 
-	reg.AddEvent(&RegistrationStarted{
-		Header:           event.NewEventHeader(),
+	reg.Record(&RegistrationStarted{
 		RegistrationID:   reg.id,
 		Email:            email,
 		VerificationCode: code,
-	})
+	}, uuid.UUID(reg.id), uuid.Nil)
 
 	return reg, nil
 }
 
 type RehydrateArgs struct {
 	ID               ID
-	Email            string
+	Email            emails.Email
 	Status           Status
 	VerificationCode string
 	CodeAttempts     int8
@@ -123,6 +128,8 @@ type RehydrateArgs struct {
 	ResendTimeout    time.Time
 	CreatedAt        time.Time
 	UpdatedAt        time.Time
+	// Clock defaults to clock.Real.
+	Clock clock.Clock
 }
 
 func Rehydrate(args RehydrateArgs) *Registration {
@@ -136,6 +143,7 @@ func Rehydrate(args RehydrateArgs) *Registration {
 		resendTimeout:    args.ResendTimeout,
 		createdAt:        args.CreatedAt,
 		updatedAt:        args.UpdatedAt,
+		clock:            args.Clock,
 	}
 }
 
@@ -145,7 +153,7 @@ func (r *Registration) VerifyCode(code string) error {
 		return errorx.Wrap(ErrInvalidStatus, op)
 	}
 
-	if time.Now().After(r.codeExpiresAt) {
+	if r.now().After(r.codeExpiresAt) {
 		r.status = StatusExpired
 		return errorx.Wrap(ErrCodeExpired, op)
 	}
@@ -154,23 +162,21 @@ func (r *Registration) VerifyCode(code string) error {
 		r.codeAttempts++
 		if r.codeAttempts >= MaxVerificationCodeAttempts {
 			r.status = StatusExpired
-			r.AddEvent(&RegistrationFailed{
-				Header:         event.NewEventHeader(),
+			r.Record(&RegistrationFailed{
 				RegistrationID: r.id,
 				Reason:         "too many failed attempts",
-			})
-			return errorx.Wrap(ErrPersistentTooManyAttempts, op)
+			}, uuid.UUID(r.id), uuid.Nil)
+			return errorx.Wrap(newErrTooManyAttempts(r.resendTimeout, r.now()), op)
 		}
 		return errorx.Wrap(ErrPersistentVerificationCodeMismatch, op)
 	}
 
-	r.updatedAt = time.Now().UTC()
+	r.updatedAt = r.now()
 	r.status = StatusVerified
-	r.AddEvent(&EmailVerified{
-		Header:         event.NewEventHeader(),
+	r.Record(&EmailVerified{
 		RegistrationID: r.id,
 		Email:          r.email,
-	})
+	}, uuid.UUID(r.id), uuid.Nil)
 
 	return nil
 }
@@ -184,7 +190,7 @@ func (r *Registration) CheckCode(code string) error {
 		return errorx.Wrap(ErrVerifyFirst, op)
 	}
 
-	if time.Now().After(r.codeExpiresAt) {
+	if r.now().After(r.codeExpiresAt) {
 		return errorx.Wrap(ErrCodeExpired, op)
 	}
 
@@ -197,8 +203,8 @@ func (r *Registration) CheckCode(code string) error {
 
 func (r *Registration) ResendCode() error {
 	const op = "registration.Registration.ResendCode"
-	if !r.resendTimeout.IsZero() && !time.Now().After(r.resendTimeout) {
-		return errorx.Wrap(ErrWaitUntilResend, op)
+	if now := r.now(); !r.resendTimeout.IsZero() && !now.After(r.resendTimeout) {
+		return errorx.Wrap(newErrResendTooSoon(r.resendTimeout, now), op)
 	}
 
 	if r.IsCompleted() {
@@ -211,18 +217,18 @@ func (r *Registration) ResendCode() error {
 	}
 
 	r.verificationCode = code
-	r.codeExpiresAt = time.Now().UTC().Add(10 * time.Minute)
-	r.resendTimeout = time.Now().UTC().Add(ResendTimeout)
+	now := r.now()
+	r.codeExpiresAt = now.Add(ExpiresAt)
+	r.resendTimeout = now.Add(ResendTimeout)
 	r.codeAttempts = 0
-	r.updatedAt = time.Now().UTC()
+	r.updatedAt = now
 	r.status = StatusPending
 
-	r.AddEvent(&VerificationCodeResent{
-		Header:           event.NewEventHeader(),
+	r.Record(&VerificationCodeResent{
 		RegistrationID:   r.id,
 		Email:            r.email,
 		VerificationCode: code,
-	})
+	}, uuid.UUID(r.id), uuid.Nil)
 
 	return nil
 }
@@ -237,7 +243,7 @@ func (r *Registration) Complete() error {
 	}
 
 	r.status = StatusCompleted
-	r.updatedAt = time.Now().UTC()
+	r.updatedAt = r.now()
 	return nil
 }
 
@@ -261,7 +267,7 @@ func (r *Registration) ID() ID {
 	return r.id
 }
 
-func (r *Registration) Email() string {
+func (r *Registration) Email() emails.Email {
 	if r == nil {
 		return ""
 	}
@@ -325,6 +331,10 @@ func (r *Registration) UpdatedAt() time.Time {
 	return r.updatedAt
 }
 
+func (r *Registration) now() time.Time {
+	return clock.Or(r.clock).Now().UTC()
+}
+
 func generateCode() (string, error) {
 	const op = "registration.generateCode"
 	code, err := randcode.GenerateAlphaNumericCode(VerificationCodeLength)
@@ -7,16 +7,23 @@ import (
 
 	"github.com/ARUMANDESU/validation"
 	"github.com/ARUMANDESU/validation/is"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/event"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/emails"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
 	"gitlab.com/ucmsv2/ucms-backend/pkg/env"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
 )
 
+var testNow = time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+
 func TestNewRegistration(t *testing.T) {
 	tests := []struct {
 		name        string
-		email       string
+		email       emails.Email
 		mode        env.Mode
 		expectError bool
 		errorType   error
@@ -48,7 +55,7 @@ func TestNewRegistration(t *testing.T) {
 		},
 		{
 			name:        "email too long",
-			email:       "a" + strings.Repeat("b", 255) + "@example.com", // 256 characters
+			email:       emails.Email("a" + strings.Repeat("b", 255) + "@example.com"), // 256 characters
 			mode:        env.Test,
 			expectError: true,
 			errorType:   is.ErrEmail,
@@ -85,7 +92,7 @@ func TestNewRegistration(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			reg, err := NewRegistration(tt.email, tt.mode)
+			reg, err := NewRegistration(tt.email, tt.mode, clock.NewFake(testNow))
 
 			if tt.expectError {
 				require.Error(t, err)
@@ -101,11 +108,11 @@ func TestNewRegistration(t *testing.T) {
 
 				NewRegistrationAssertion(reg).
 					AssertStatus(t, StatusPending).
-					AssertEmail(t, tt.email).
+					AssertEmail(t, tt.email.String()).
 					AssertVerificationCodeNotEmpty(t).
 					AssertCodeAttempts(t, 0).
-					AssertCodeExpiresAt(t, time.Now().Add(ExpiresAt)).
-					AssertResendTimeout(t, time.Now().Add(ResendTimeout)).
+					AssertCodeExpiresAt(t, testNow.Add(ExpiresAt)).
+					AssertResendTimeout(t, testNow.Add(ResendTimeout)).
 					AssertEventsCount(t, 1)
 
 				events := reg.GetUncommittedEvents()
@@ -115,6 +122,7 @@ func TestNewRegistration(t *testing.T) {
 				assert.Equal(t, reg.id, startedEvent.RegistrationID)
 				assert.Equal(t, tt.email, startedEvent.Email)
 				assert.Equal(t, reg.verificationCode, startedEvent.VerificationCode)
+				NewRegistrationStartedAssertion(startedEvent).AssertHeader(t)
 			}
 		})
 	}
@@ -138,6 +146,7 @@ func TestRegistration_VerifyCode(t *testing.T) {
 		assert.True(t, ok)
 		assert.Equal(t, reg.id, verifiedEvent.RegistrationID)
 		assert.Equal(t, reg.email, verifiedEvent.Email)
+		event.AssertHeader(t, verifiedEvent, uuid.UUID(reg.id), uuid.Nil, 2)
 	})
 
 	t.Run("invalid code", func(t *testing.T) {
@@ -173,10 +182,29 @@ func TestRegistration_VerifyCode(t *testing.T) {
 		assert.Equal(t, "too many failed attempts", failedEvent.Reason)
 	})
 
+	t.Run("too many failed attempts carries the lock", func(t *testing.T) {
+		clk := clock.NewFake(testNow)
+		reg := validRegistrationWithClock(t, clk)
+		clk.Advance(20 * time.Second)
+
+		var err error
+		for range MaxVerificationCodeAttempts {
+			err = reg.VerifyCode("wrongcode")
+		}
+		assert.ErrorIs(t, err, ErrPersistentTooManyAttempts)
+		assert.True(t, errorx.IsPersistable(err))
+
+		var locked *ErrTooManyAttempts
+		require.ErrorAs(t, err, &locked)
+		assert.Equal(t, reg.resendTimeout, locked.LockedUntil, "a new code is sent once the cooldown ends")
+		assert.Equal(t, ResendTimeout-20*time.Second, locked.RetryAfter())
+	})
+
 	t.Run("expired code", func(t *testing.T) {
-		reg := validRegistration(t)
+		clk := clock.NewFake(testNow)
+		reg := validRegistrationWithClock(t, clk)
 
-		reg.codeExpiresAt = time.Now().Add(-1 * time.Minute)
+		clk.Advance(ExpiresAt + time.Second)
 
 		err := reg.VerifyCode(reg.verificationCode)
 		assert.ErrorIs(t, err, ErrCodeExpired)
@@ -221,10 +249,11 @@ func TestRegistration_CheckCode(t *testing.T) {
 	})
 
 	t.Run("expired code", func(t *testing.T) {
-		reg := validRegistration(t)
+		clk := clock.NewFake(testNow)
+		reg := validRegistrationWithClock(t, clk)
 		reg.status = StatusVerified
 
-		reg.codeExpiresAt = time.Now().Add(-1 * time.Minute)
+		clk.Advance(ExpiresAt + time.Second)
 
 		err := reg.CheckCode(reg.verificationCode)
 		assert.ErrorIs(t, err, ErrCodeExpired)
@@ -242,15 +271,16 @@ func TestRegistration_CheckCode(t *testing.T) {
 
 func TestRegistration_ResendCode(t *testing.T) {
 	t.Run("successful resend after timeout", func(t *testing.T) {
-		reg := validRegistration(t)
-		reg.resendTimeout = time.Now().Add(-1 * time.Minute)
+		clk := clock.NewFake(testNow)
+		reg := validRegistrationWithClock(t, clk)
+		clk.Advance(ResendTimeout + time.Second)
 		originalCode := reg.verificationCode
 
 		err := reg.ResendCode()
 		require.NoError(t, err)
 		NewRegistrationAssertion(reg).
 			AssertStatus(t, StatusPending).
-			AssertEmail(t, reg.email).
+			AssertEmail(t, reg.email.String()).
 			AssertVerificationCodeIsNot(t, originalCode).
 			AssertCodeAttempts(t, 0).
 			AssertResendNotAvailable(t).
@@ -271,6 +301,36 @@ func TestRegistration_ResendCode(t *testing.T) {
 		err := reg.ResendCode()
 		assert.ErrorIs(t, err, ErrWaitUntilResend)
 	})
+
+	t.Run("resend too early carries the cooldown", func(t *testing.T) {
+		clk := clock.NewFake(testNow)
+		reg := validRegistrationWithClock(t, clk)
+		clk.Advance(15 * time.Second)
+
+		err := reg.ResendCode()
+		var tooSoon *ErrResendTooSoon
+		require.ErrorAs(t, err, &tooSoon)
+		assert.Equal(t, reg.resendTimeout, tooSoon.AvailableAt)
+		assert.Equal(t, ResendTimeout-15*time.Second, tooSoon.RetryAfter())
+
+		retry, ok := errorx.RetryOf(err)
+		require.True(t, ok)
+		assert.Equal(t, reg.resendTimeout, retry.RetryAt())
+	})
+
+	t.Run("resend at the timeout", func(t *testing.T) {
+		clk := clock.NewFake(testNow)
+		reg := validRegistrationWithClock(t, clk)
+
+		clk.Advance(ResendTimeout)
+		assert.ErrorIs(t, reg.ResendCode(), ErrWaitUntilResend)
+
+		clk.Advance(time.Nanosecond)
+		require.NoError(t, reg.ResendCode())
+		NewRegistrationAssertion(reg).
+			AssertCodeExpiresAt(t, clk.Now().Add(ExpiresAt)).
+			AssertResendTimeout(t, clk.Now().Add(ResendTimeout))
+	})
 }
 
 func TestRegistration_Complete(t *testing.T) {
@@ -328,7 +388,7 @@ func TestRegistration_Complete(t *testing.T) {
 				require.NoError(t, err)
 				NewRegistrationAssertion(reg).
 					AssertStatus(t, StatusCompleted).
-					AssertEmail(t, reg.email).
+					AssertEmail(t, reg.email.String()).
 					AssertVerificationCodeNotEmpty(t).
 					AssertCodeAttempts(t, 0).
 					AssertResendNotAvailable(t).
@@ -359,7 +419,11 @@ func TestRegistration_IsCompleted(t *testing.T) {
 }
 
 func validRegistration(t *testing.T) *Registration {
-	reg, err := NewRegistration("test@example.com", env.Test)
+	return validRegistrationWithClock(t, clock.NewFake(testNow))
+}
+
+func validRegistrationWithClock(t *testing.T, clk clock.Clock) *Registration {
+	reg, err := NewRegistration("test@example.com", env.Test, clk)
 	require.NoError(t, err, "Failed to create valid registration")
 	reg.MarkEventsAsCommitted()
 	return reg
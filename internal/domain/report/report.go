@@ -0,0 +1,126 @@
+package report
+
+import (
+	"time"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/term"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+)
+
+// Week is the length of the window of a weekly report.
+const Week = 7 * 24 * time.Hour
+
+// WeekStart returns the start of the week t is in, Monday 00:00 UTC. The
+// weekly reports are keyed by it.
+func WeekStart(t time.Time) time.Time {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	// time.Sunday is 0, the weeks start on Monday.
+	offset := (int(day.Weekday()) + 6) % 7
+	return day.AddDate(0, 0, -offset)
+}
+
+// Provisioning are the accounts provisioned in a window.
+type Provisioning struct {
+	// Registrations are the student registrations started in the window.
+	Registrations int64 `json:"registrations"`
+	// CompletedRegistrations are the ones of Registrations completed since.
+	CompletedRegistrations int64 `json:"completed_registrations"`
+	// NewStaff are the staff accounts created in the window, from the
+	// accepted invitations mostly.
+	NewStaff int64 `json:"new_staff"`
+	// SuspendedAccounts are the students sent on academic leave or expelled
+	// in the window who still are, they can not log in.
+	SuspendedAccounts int64 `json:"suspended_accounts"`
+}
+
+// CompletionRate is the share of Registrations completed, 0 without any.
+func (p Provisioning) CompletionRate() float64 {
+	if p.Registrations == 0 {
+		return 0
+	}
+	return float64(p.CompletedRegistrations) / float64(p.Registrations)
+}
+
+// Term is the academic term a report is scoped by.
+type Term struct {
+	ID       term.ID   `json:"id"`
+	Code     string    `json:"code"`
+	Name     string    `json:"name"`
+	StartsAt time.Time `json:"starts_at"`
+	EndsAt   time.Time `json:"ends_at"`
+}
+
+// NewTerm returns the Term of t, nil for a nil t.
+func NewTerm(t *term.Term) *Term {
+	if t == nil {
+		return nil
+	}
+	return &Term{
+		ID:       t.ID(),
+		Code:     t.Code(),
+		Name:     t.Name(),
+		StartsAt: t.StartsAt(),
+		EndsAt:   t.EndsAt(),
+	}
+}
+
+// TermProvisioning are the accounts provisioned in a term from its start
+// until To.
+type TermProvisioning struct {
+	Term Term      `json:"term"`
+	To   time.Time `json:"to"`
+	Provisioning
+	CompletionRate float64 `json:"completion_rate"`
+}
+
+// NewTermProvisioning returns the provisioning p of t until to.
+func NewTermProvisioning(t *term.Term, to time.Time, p Provisioning) *TermProvisioning {
+	return &TermProvisioning{
+		Term:           *NewTerm(t),
+		To:             to.UTC(),
+		Provisioning:   p,
+		CompletionRate: p.CompletionRate(),
+	}
+}
+
+// Weekly is the provisioning report of the week starting at WeekStart,
+// generated once the week is over and mailed to the staff.
+type Weekly struct {
+	WeekStart time.Time `json:"week_start"`
+	WeekEnd   time.Time `json:"week_end"`
+	Provisioning
+	CompletionRate float64 `json:"completion_rate"`
+	// Term is the provisioning of the term the week starts in up to the end
+	// of the week, nil when the week starts between the terms.
+	Term        *TermProvisioning `json:"term,omitempty"`
+	GeneratedAt time.Time         `json:"generated_at"`
+}
+
+// NewWeekly returns the report of the week weekStart is in.
+func NewWeekly(weekStart time.Time, p Provisioning, now time.Time) *Weekly {
+	start := WeekStart(weekStart)
+	return &Weekly{
+		WeekStart:      start,
+		WeekEnd:        start.Add(Week),
+		Provisioning:   p,
+		CompletionRate: p.CompletionRate(),
+		GeneratedAt:    now.UTC(),
+	}
+}
+
+// Recipient is a staff member the reports are mailed to.
+type Recipient struct {
+	UserID    user.ID
+	Email     string
+	FirstName string
+}
+
+// Invitations are the staff invitations that can be accepted.
+type Invitations struct {
+	// Active are the invitations not deleted whose validity window is open.
+	Active int64 `json:"active"`
+	// OutstandingRecipients are the recipients of Active who did not accept
+	// their invitation yet, accepting removes the recipient.
+	OutstandingRecipients int64 `json:"outstanding_recipients"`
+}
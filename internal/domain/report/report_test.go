@@ -0,0 +1,49 @@
+package report_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/report"
+)
+
+func TestWeekStart(t *testing.T) {
+	monday := time.Date(2026, time.May, 4, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		t    time.Time
+	}{
+		{name: "monday midnight", t: monday},
+		{name: "wednesday", t: time.Date(2026, time.May, 6, 15, 30, 0, 0, time.UTC)},
+		{name: "sunday night", t: time.Date(2026, time.May, 10, 23, 59, 59, 0, time.UTC)},
+		{name: "other zone", t: time.Date(2026, time.May, 11, 2, 0, 0, 0, time.FixedZone("UTC+5", 5*60*60))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, monday, report.WeekStart(tt.t))
+		})
+	}
+}
+
+func TestNewWeekly(t *testing.T) {
+	now := time.Date(2026, time.May, 11, 6, 0, 0, 0, time.UTC)
+
+	w := report.NewWeekly(time.Date(2026, time.May, 7, 0, 0, 0, 0, time.UTC), report.Provisioning{
+		Registrations:          4,
+		CompletedRegistrations: 3,
+		NewStaff:               1,
+		SuspendedAccounts:      2,
+	}, now)
+
+	assert.Equal(t, time.Date(2026, time.May, 4, 0, 0, 0, 0, time.UTC), w.WeekStart)
+	assert.Equal(t, time.Date(2026, time.May, 11, 0, 0, 0, 0, time.UTC), w.WeekEnd)
+	assert.InDelta(t, 0.75, w.CompletionRate, 1e-9)
+	assert.Equal(t, now, w.GeneratedAt)
+
+	t.Run("no registrations", func(t *testing.T) {
+		assert.Zero(t, report.Provisioning{}.CompletionRate())
+	})
+}
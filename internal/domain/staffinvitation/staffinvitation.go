@@ -14,6 +14,7 @@ import (
 
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/event"
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
 	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
 	"gitlab.com/ucmsv2/ucms-backend/pkg/i18nx"
 	"gitlab.com/ucmsv2/ucms-backend/pkg/randcode"
@@ -26,14 +27,19 @@ const (
 	CodeLength         = 20
 	MaxEmails          = 25
 	ValidFromThreshold = time.Minute
+	// MaxPendingRecipients caps the recipients a creator has invited and
+	// who have not accepted yet, over the invitations neither deleted nor
+	// expired.
+	MaxPendingRecipients = 500
 )
 
 var (
-	ErrTimeInPast          = validation.NewError(i18nx.ValidationTimeInPast, i18nx.MsgValidationTimeInPastOther)
-	ErrTimeBeforeThreshold = validation.NewError(i18nx.ValidationTimeBeforeThreshold, i18nx.MsgValidationTimeBeforeThresholdOther)
-	ErrForbidden           = errorx.NewForbidden()
-	ErrNotFoundOrDeleted   = errorx.NewNotFound().WithKey(i18nx.KeyNotFoundOrDeleted)
-	ErrInvalidInvitation   = errorx.NewInvalidRequest().WithKey(i18nx.KeyInvalidInvitation)
+	ErrTimeInPast                = validationx.ErrTimeInPast
+	ErrTimeBeforeThreshold       = validationx.ErrTimeBeforeThreshold
+	ErrForbidden                 = errorx.NewForbidden()
+	ErrNotFoundOrDeleted         = errorx.NewNotFound().WithKey(i18nx.KeyNotFoundOrDeleted)
+	ErrInvalidInvitation         = errorx.NewInvalidRequest().WithKey(i18nx.KeyInvalidInvitation)
+	ErrPendingRecipientsExceeded = errorx.NewBusinessRuleViolation().WithKey(i18nx.KeyPendingRecipientsExceeded).WithArgs(map[string]any{i18nx.ArgMaxRecipients: MaxPendingRecipients})
 )
 
 var (
@@ -45,19 +51,19 @@ var (
 			is.EmailFormat,
 		),
 	}
-	validFromRules = func(validFrom *time.Time) []validation.Rule {
+	validFromRules = func(validFrom *time.Time, now time.Time) []validation.Rule {
 		rules := []validation.Rule{
 			validation.NilOrNotEmpty,
 		}
 		if validFrom != nil {
-			rules = append(rules, validation.Min(time.Now().UTC()).ErrorObject(ErrTimeInPast))
+			rules = append(rules, validation.Min(now).ErrorObject(ErrTimeInPast))
 		}
 		return rules
 	}
-	validUntilRules = func(validUntil *time.Time, validFrom *time.Time) []validation.Rule {
+	validUntilRules = func(validUntil *time.Time, validFrom *time.Time, now time.Time) []validation.Rule {
 		rules := []validation.Rule{validation.NilOrNotEmpty}
 		if validUntil != nil {
-			rules = append(rules, validation.Min(time.Now().UTC()).ErrorObject(ErrTimeInPast))
+			rules = append(rules, validation.Min(now).ErrorObject(ErrTimeInPast))
 
 			if validFrom != nil {
 				rules = append(rules, validation.Min(validFrom.Add(ValidFromThreshold)).ErrorObject(ErrTimeBeforeThreshold))
@@ -96,6 +102,16 @@ func (id *ID) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// CheckPendingRecipients checks that a creator with pending recipients can
+// invite adding more, see MaxPendingRecipients.
+func CheckPendingRecipients(pending, adding int) error {
+	const op = "staffinvitation.CheckPendingRecipients"
+	if pending+adding > MaxPendingRecipients {
+		return errorx.Wrap(ErrPendingRecipientsExceeded, op)
+	}
+	return nil
+}
+
 type StaffInvitation struct {
 	event.Recorder
 	id              ID
@@ -104,9 +120,12 @@ type StaffInvitation struct {
 	validFrom       *time.Time
 	validUntil      *time.Time
 	creatorID       user.ID
+	department      string
+	position        string
 	createdAt       time.Time
 	updatedAt       time.Time
 	deletedAt       *time.Time
+	clock           clock.Clock
 }
 
 type CreateArgs struct {
@@ -114,18 +133,26 @@ type CreateArgs struct {
 	CreatorID       user.ID    `json:"creator_id"`
 	ValidFrom       *time.Time `json:"valid_from"`
 	ValidUntil      *time.Time `json:"valid_until"`
+	// Department and Position pre-fill the staff accounts of the recipients,
+	// both are optional.
+	Department string `json:"department"`
+	Position   string `json:"position"`
+	// Clock defaults to clock.Real.
+	Clock clock.Clock `json:"-"`
 }
 
 func NewStaffInvitation(args CreateArgs) (*StaffInvitation, error) {
 	const op = "staffinvitation.NewStaffInvitation"
-	now := time.Now().UTC()
+	now := clock.Or(args.Clock).Now().UTC()
 
 	err := validation.ValidateStruct(
 		&args,
 		validation.Field(&args.CreatorID, validationx.Required),
 		validation.Field(&args.RecipientsEmail, recipientsEmailRules...),
-		validation.Field(&args.ValidFrom, validFromRules(args.ValidFrom)...),
-		validation.Field(&args.ValidUntil, validUntilRules(args.ValidUntil, args.ValidFrom)...),
+		validation.Field(&args.ValidFrom, validFromRules(args.ValidFrom, now)...),
+		validation.Field(&args.ValidUntil, validUntilRules(args.ValidUntil, args.ValidFrom, now)...),
+		validation.Field(&args.Department, validation.RuneLength(0, user.MaxDepartmentLen)),
+		validation.Field(&args.Position, validation.RuneLength(0, user.MaxPositionLen)),
 	)
 	if err != nil {
 		return nil, errorx.Wrap(err, op)
@@ -143,19 +170,23 @@ func NewStaffInvitation(args CreateArgs) (*StaffInvitation, error) {
 		validFrom:       args.ValidFrom,
 		validUntil:      args.ValidUntil,
 		creatorID:       args.CreatorID,
+		department:      args.Department,
+		position:        args.Position,
 		createdAt:       now,
 		updatedAt:       now,
+		clock:           args.Clock,
 	}
 
-	staffInvitation.AddEvent(&Created{
-		Header:            event.NewEventHeader(),
+	staffInvitation.Record(&Created{
 		StaffInvitationID: staffInvitation.id,
 		Code:              staffInvitation.code,
 		RecipientsEmail:   staffInvitation.recipientsEmail,
 		ValidFrom:         staffInvitation.validFrom,
 		ValidUntil:        staffInvitation.validUntil,
 		CreatorID:         args.CreatorID,
-	})
+		Department:        args.Department,
+		Position:          args.Position,
+	}, uuid.UUID(staffInvitation.id), uuid.UUID(args.CreatorID))
 
 	return staffInvitation, nil
 }
@@ -167,9 +198,13 @@ type RehydrateArgs struct {
 	ValidFrom       *time.Time
 	ValidUntil      *time.Time
 	CreatorID       user.ID
+	Department      string
+	Position        string
 	CreatedAt       time.Time
 	UpdatedAt       time.Time
 	DeletedAt       *time.Time
+	// Clock defaults to clock.Real.
+	Clock clock.Clock
 }
 
 func Rehydrate(args RehydrateArgs) *StaffInvitation {
@@ -180,9 +215,12 @@ func Rehydrate(args RehydrateArgs) *StaffInvitation {
 		validFrom:       args.ValidFrom,
 		validUntil:      args.ValidUntil,
 		creatorID:       args.CreatorID,
+		department:      args.Department,
+		position:        args.Position,
 		createdAt:       args.CreatedAt,
 		updatedAt:       args.UpdatedAt,
 		deletedAt:       args.DeletedAt,
+		clock:           args.Clock,
 	}
 }
 
@@ -226,15 +264,14 @@ func (s *StaffInvitation) UpdateRecipients(userID user.ID, emails []string) erro
 	}
 
 	s.recipientsEmail = emails
-	s.updatedAt = time.Now().UTC()
+	s.updatedAt = s.now()
 
-	s.AddEvent(&RecipientsUpdated{
-		Header:                 event.NewEventHeader(),
+	s.Record(&RecipientsUpdated{
 		StaffInvitationID:      s.id,
 		Code:                   s.code,
 		NewRecipientsEmail:     newEmails,
 		CurrentRecipientsEmail: s.recipientsEmail,
-	})
+	}, uuid.UUID(s.id), uuid.UUID(userID))
 
 	return nil
 }
@@ -248,10 +285,11 @@ func (s *StaffInvitation) UpdateValidity(userID user.ID, from *time.Time, until
 		return errorx.Wrap(ErrNotFoundOrDeleted, op)
 	}
 
-	if err := validation.Validate(from, validFromRules(from)...); err != nil {
+	now := s.now()
+	if err := validation.Validate(from, validFromRules(from, now)...); err != nil {
 		return errorx.Wrap(err, op)
 	}
-	if err := validation.Validate(until, validUntilRules(until, from)...); err != nil {
+	if err := validation.Validate(until, validUntilRules(until, from, now)...); err != nil {
 		return errorx.Wrap(err, op)
 	}
 
@@ -265,14 +303,13 @@ func (s *StaffInvitation) UpdateValidity(userID user.ID, from *time.Time, until
 
 	s.validFrom = from
 	s.validUntil = until
-	s.updatedAt = time.Now().UTC()
+	s.updatedAt = now
 
-	s.AddEvent(&ValidityUpdated{
-		Header:            event.NewEventHeader(),
+	s.Record(&ValidityUpdated{
 		StaffInvitationID: s.id,
 		ValidFrom:         s.validFrom,
 		ValidUntil:        s.validUntil,
-	})
+	}, uuid.UUID(s.id), uuid.UUID(userID))
 
 	return nil
 }
@@ -282,19 +319,29 @@ func (s *StaffInvitation) MarkDeleted(userID user.ID) error {
 	if s.creatorID != userID {
 		return errorx.Wrap(ErrForbidden, op)
 	}
+
+	s.revoke(uuid.UUID(userID))
+	return nil
+}
+
+// Revoke deletes the invitation whoever created it, it is what an operator
+// runs with `ucms-api invitation revoke`. Revoking a deleted invitation
+// changes nothing.
+func (s *StaffInvitation) Revoke() {
+	s.revoke(uuid.Nil)
+}
+
+func (s *StaffInvitation) revoke(actorID uuid.UUID) {
 	if s.deletedAt != nil {
-		return nil
+		return
 	}
 
-	now := time.Now().UTC()
+	now := s.now()
 	s.deletedAt = &now
 
-	s.AddEvent(&Deleted{
-		Header:            event.NewEventHeader(),
+	s.Record(&Deleted{
 		StaffInvitationID: s.id,
-	})
-
-	return nil
+	}, uuid.UUID(s.id), actorID)
 }
 
 func (s *StaffInvitation) ValidateInvitationAccess(email, code string) error {
@@ -313,6 +360,27 @@ func (s *StaffInvitation) ValidateInvitationAccess(email, code string) error {
 	return errorx.Wrap(ErrInvalidInvitation, op)
 }
 
+// Accept consumes the invitation of the recipient email, who can not accept
+// it again.
+func (s *StaffInvitation) Accept(email string) error {
+	const op = "staffinvitation.StaffInvitation.Accept"
+	if s.deletedAt != nil {
+		return errorx.Wrap(ErrNotFoundOrDeleted, op)
+	}
+	i := slices.Index(s.recipientsEmail, email)
+	if i < 0 {
+		return errorx.Wrap(ErrInvalidInvitation, op)
+	}
+
+	s.recipientsEmail = slices.Delete(slices.Clone(s.recipientsEmail), i, i+1)
+	s.updatedAt = s.now()
+	return nil
+}
+
+func (s *StaffInvitation) now() time.Time {
+	return clock.Or(s.clock).Now().UTC()
+}
+
 func (s *StaffInvitation) ID() ID {
 	if s == nil {
 		return ID{}
@@ -361,6 +429,24 @@ func (s *StaffInvitation) CreatorID() user.ID {
 	return s.creatorID
 }
 
+// Department is the department the recipients join.
+func (s *StaffInvitation) Department() string {
+	if s == nil {
+		return ""
+	}
+
+	return s.department
+}
+
+// Position is the position the recipients are invited to.
+func (s *StaffInvitation) Position() string {
+	if s == nil {
+		return ""
+	}
+
+	return s.position
+}
+
 func (s *StaffInvitation) CreatedAt() time.Time {
 	if s == nil {
 		return time.Time{}
@@ -394,12 +480,22 @@ type Created struct {
 	ValidFrom         *time.Time `json:"valid_from,omitempty"`
 	ValidUntil        *time.Time `json:"valid_until,omitempty"`
 	CreatorID         user.ID    `json:"creator_id"`
+	Department        string     `json:"department,omitempty"`
+	Position          string     `json:"position,omitempty"`
 }
 
 func (e *Created) GetStreamName() string {
 	return EventStreamName
 }
 
+func (e *Created) Validate() error {
+	return validation.ValidateStruct(e,
+		validation.Field(&e.StaffInvitationID, validationx.Required),
+		validation.Field(&e.Code, validation.Required),
+		validation.Field(&e.CreatorID, validationx.Required),
+	)
+}
+
 type RecipientsUpdated struct {
 	event.Header
 	event.Otel
@@ -413,6 +509,13 @@ func (e *RecipientsUpdated) GetStreamName() string {
 	return EventStreamName
 }
 
+func (e *RecipientsUpdated) Validate() error {
+	return validation.ValidateStruct(e,
+		validation.Field(&e.StaffInvitationID, validationx.Required),
+		validation.Field(&e.Code, validation.Required),
+	)
+}
+
 type ValidityUpdated struct {
 	event.Header
 	event.Otel
@@ -474,6 +577,13 @@ func (a *Assertion) AssertRecipientsEmail(expected []string) *Assertion {
 	return a
 }
 
+// AssertRecipient checks whether email may still accept the invitation.
+func (a *Assertion) AssertRecipient(email string, expected bool) *Assertion {
+	a.t.Helper()
+	assert.Equal(a.t, expected, slices.Contains(a.s.recipientsEmail, email), "%s should be a recipient: %t", email, expected)
+	return a
+}
+
 func (a *Assertion) AssertValidFrom(expected *time.Time) *Assertion {
 	a.t.Helper()
 	if expected == nil {
@@ -2,17 +2,20 @@ package staffinvitation_test
 
 import (
 	"fmt"
+	"slices"
 	"testing"
 	"time"
 
 	"github.com/ARUMANDESU/validation"
 	"github.com/ARUMANDESU/validation/is"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/event"
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/staffinvitation"
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
 	"gitlab.com/ucmsv2/ucms-backend/pkg/validationx"
 	"gitlab.com/ucmsv2/ucms-backend/tests/integration/builders"
 	"gitlab.com/ucmsv2/ucms-backend/tests/integration/fixtures"
@@ -27,6 +30,14 @@ const (
 	invalidCode  = "invalid-code"
 )
 
+var testNow = time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+
+// invitationBuilder returns a builder of invitations living on a fake clock
+// set to testNow.
+func invitationBuilder() *builders.StaffInvitationBuilder {
+	return builders.NewStaffInvitationBuilder().WithClock(clock.NewFake(testNow))
+}
+
 // generateTestEmails creates a slice of test emails with the given count
 func generateTestEmails(count int) []string {
 	emails := make([]string, count)
@@ -54,14 +65,15 @@ func assertStaffInvitationFields(t *testing.T, inv *staffinvitation.StaffInvitat
 }
 
 // assertCreatedEvent validates the Created event properties
-func assertCreatedEvent(t *testing.T, inv *staffinvitation.StaffInvitation, event *staffinvitation.Created) {
+func assertCreatedEvent(t *testing.T, inv *staffinvitation.StaffInvitation, e *staffinvitation.Created) {
 	t.Helper()
-	assert.Equal(t, inv.ID(), event.StaffInvitationID)
-	assert.Equal(t, inv.Code(), event.Code)
-	assert.Equal(t, inv.RecipientsEmail(), event.RecipientsEmail)
-	assert.Equal(t, inv.CreatorID(), event.CreatorID)
-	assert.Equal(t, inv.ValidFrom(), event.ValidFrom)
-	assert.Equal(t, inv.ValidUntil(), event.ValidUntil)
+	assert.Equal(t, inv.ID(), e.StaffInvitationID)
+	assert.Equal(t, inv.Code(), e.Code)
+	assert.Equal(t, inv.RecipientsEmail(), e.RecipientsEmail)
+	assert.Equal(t, inv.CreatorID(), e.CreatorID)
+	assert.Equal(t, inv.ValidFrom(), e.ValidFrom)
+	assert.Equal(t, inv.ValidUntil(), e.ValidUntil)
+	event.AssertHeader(t, e, uuid.UUID(inv.ID()), uuid.UUID(inv.CreatorID()), 1)
 }
 
 func assertTimePointerWithinDuration(t *testing.T, expected, actual *time.Time, delta time.Duration) {
@@ -78,9 +90,9 @@ func assertTimePointerWithinDuration(t *testing.T, expected, actual *time.Time,
 func TestNewStaffInvitation(t *testing.T) {
 	t.Parallel()
 
-	minuteLater := time.Now().Add(1 * time.Minute)
-	twoMinutesLater := time.Now().Add(2 * time.Minute)
-	minuteAgo := time.Now().Add(-1 * time.Minute)
+	minuteLater := testNow.Add(1 * time.Minute)
+	twoMinutesLater := testNow.Add(2 * time.Minute)
+	minuteAgo := testNow.Add(-1 * time.Minute)
 	tests := []struct {
 		name    string
 		args    staffinvitation.CreateArgs
@@ -207,6 +219,7 @@ func TestNewStaffInvitation(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			tt.args.Clock = clock.NewFake(testNow)
 			staffInvitation, err := staffinvitation.NewStaffInvitation(tt.args)
 			if tt.wantErr != nil {
 				require.Error(t, err)
@@ -218,6 +231,7 @@ func TestNewStaffInvitation(t *testing.T) {
 				require.NotNil(t, staffInvitation)
 
 				assertStaffInvitationFields(t, staffInvitation, tt.args)
+				assert.Equal(t, testNow, staffInvitation.CreatedAt())
 
 				e := event.AssertSingleEvent[*staffinvitation.Created](t, staffInvitation.GetUncommittedEvents())
 				assertCreatedEvent(t, staffInvitation, e)
@@ -381,7 +395,7 @@ func TestStaffInvitation_UpdateRecipientsEmail(t *testing.T) {
 			name: "invalid already deleted",
 			staffInvitation: builders.NewStaffInvitationBuilder().
 				WithCreatorID(fixtures.TestStaff.ID).
-				WithDeletedAt(timePointer(time.Now().Add(-1 * time.Minute))).
+				WithDeletedAt(timePointer(testNow.Add(-1 * time.Minute))).
 				Build(),
 			userID:  fixtures.TestStaff.ID,
 			emails:  []string{fixtures.ValidStaff3Email, fixtures.ValidStaff4Email},
@@ -391,7 +405,7 @@ func TestStaffInvitation_UpdateRecipientsEmail(t *testing.T) {
 			name: "invalid already deleted with non creator",
 			staffInvitation: builders.NewStaffInvitationBuilder().
 				WithCreatorID(fixtures.TestStaff.ID).
-				WithDeletedAt(timePointer(time.Now().Add(-1 * time.Minute))).
+				WithDeletedAt(timePointer(testNow.Add(-1 * time.Minute))).
 				Build(),
 			userID:  fixtures.TestStaff2.ID,
 			emails:  []string{fixtures.ValidStaff3Email, fixtures.ValidStaff4Email},
@@ -410,6 +424,7 @@ func TestStaffInvitation_UpdateRecipientsEmail(t *testing.T) {
 					assert.ErrorIs(t, err, tt.wantErr)
 				}
 				assert.Equal(t, tt.staffInvitation.RecipientsEmail(), tt.staffInvitation.RecipientsEmail()) // no change
+				event.AssertNoEventOfType[*staffinvitation.RecipientsUpdated](t, tt.staffInvitation.GetUncommittedEvents())
 			} else {
 				require.NoError(t, err)
 				assert.ElementsMatch(t, tt.wantEmails, tt.staffInvitation.RecipientsEmail())
@@ -419,6 +434,7 @@ func TestStaffInvitation_UpdateRecipientsEmail(t *testing.T) {
 
 					e := event.AssertSingleEvent[*staffinvitation.RecipientsUpdated](t, events)
 					assert.Equal(t, tt.staffInvitation.ID(), e.StaffInvitationID)
+					assert.Equal(t, uuid.UUID(tt.userID), e.ActorID)
 					assert.NotEmpty(t, e.Code)
 					assert.Equal(t, tt.wantEmails, e.CurrentRecipientsEmail)
 					if tt.newEmails != nil {
@@ -427,7 +443,7 @@ func TestStaffInvitation_UpdateRecipientsEmail(t *testing.T) {
 						assert.ElementsMatch(t, tt.emails, e.NewRecipientsEmail)
 					}
 				} else {
-					event.AssertNoEvents(t, events)
+					event.AssertNoEventOfType[*staffinvitation.RecipientsUpdated](t, events)
 				}
 			}
 		})
@@ -451,36 +467,36 @@ func TestStaffInvitation_UpdateValidity(t *testing.T) {
 	}{
 		{
 			name:            "valid update by the creator to set both validFrom and validUntil",
-			staffInvitation: builders.NewStaffInvitationBuilder().WithCreatorID(fixtures.TestStaff.ID).Build(),
+			staffInvitation: invitationBuilder().WithCreatorID(fixtures.TestStaff.ID).Build(),
 			userID:          fixtures.TestStaff.ID,
-			validFrom:       timePointer(time.Now().Add(1 * time.Minute)),
-			validUntil:      timePointer(time.Now().Add(2 * time.Minute)),
-			wantValidFrom:   timePointer(time.Now().Add(1 * time.Minute)),
-			wantValidUntil:  timePointer(time.Now().Add(2 * time.Minute)),
+			validFrom:       timePointer(testNow.Add(1 * time.Minute)),
+			validUntil:      timePointer(testNow.Add(2 * time.Minute)),
+			wantValidFrom:   timePointer(testNow.Add(1 * time.Minute)),
+			wantValidUntil:  timePointer(testNow.Add(2 * time.Minute)),
 		},
 		{
 			name:            "valid update by the creator to set only validFrom",
-			staffInvitation: builders.NewStaffInvitationBuilder().WithCreatorID(fixtures.TestStaff.ID).Build(),
+			staffInvitation: invitationBuilder().WithCreatorID(fixtures.TestStaff.ID).Build(),
 			userID:          fixtures.TestStaff.ID,
-			validFrom:       timePointer(time.Now().Add(1 * time.Minute)),
+			validFrom:       timePointer(testNow.Add(1 * time.Minute)),
 			validUntil:      nil,
-			wantValidFrom:   timePointer(time.Now().Add(1 * time.Minute)),
+			wantValidFrom:   timePointer(testNow.Add(1 * time.Minute)),
 			wantValidUntil:  nil,
 		},
 		{
 			name:            "valid update by the creator to set only validUntil",
-			staffInvitation: builders.NewStaffInvitationBuilder().WithCreatorID(fixtures.TestStaff.ID).Build(),
+			staffInvitation: invitationBuilder().WithCreatorID(fixtures.TestStaff.ID).Build(),
 			userID:          fixtures.TestStaff.ID,
 			validFrom:       nil,
-			validUntil:      timePointer(time.Now().Add(2 * time.Minute)),
+			validUntil:      timePointer(testNow.Add(2 * time.Minute)),
 			wantValidFrom:   nil,
-			wantValidUntil:  timePointer(time.Now().Add(2 * time.Minute)),
+			wantValidUntil:  timePointer(testNow.Add(2 * time.Minute)),
 		},
 		{
 			name: "valid update by the creator to clear both validFrom and validUntil",
-			staffInvitation: builders.NewStaffInvitationBuilder().
+			staffInvitation: invitationBuilder().
 				WithCreatorID(fixtures.TestStaff.ID).
-				WithValidFrom(timePointer(time.Now().Add(1 * time.Minute))).Build(),
+				WithValidFrom(timePointer(testNow.Add(1 * time.Minute))).Build(),
 			userID:         fixtures.TestStaff.ID,
 			validFrom:      nil,
 			validUntil:     nil,
@@ -489,20 +505,20 @@ func TestStaffInvitation_UpdateValidity(t *testing.T) {
 		},
 		{
 			name:            "invalid update by another staff",
-			staffInvitation: builders.NewStaffInvitationBuilder().WithCreatorID(fixtures.TestStaff.ID).Build(),
+			staffInvitation: invitationBuilder().WithCreatorID(fixtures.TestStaff.ID).Build(),
 			userID:          fixtures.TestStaff2.ID,
-			validFrom:       timePointer(time.Now().Add(1 * time.Minute)),
-			validUntil:      timePointer(time.Now().Add(2 * time.Minute)),
+			validFrom:       timePointer(testNow.Add(1 * time.Minute)),
+			validUntil:      timePointer(testNow.Add(2 * time.Minute)),
 			wantErr:         staffinvitation.ErrForbidden,
 			wantValidFrom:   nil,
 			wantValidUntil:  nil,
 		},
 		{
 			name:            "invalid update with validFrom in the past",
-			staffInvitation: builders.NewStaffInvitationBuilder().WithCreatorID(fixtures.TestStaff.ID).Build(),
+			staffInvitation: invitationBuilder().WithCreatorID(fixtures.TestStaff.ID).Build(),
 			userID:          fixtures.TestStaff.ID,
-			validFrom:       timePointer(time.Now().Add(-1 * time.Minute)),
-			validUntil:      timePointer(time.Now().Add(1 * time.Minute)),
+			validFrom:       timePointer(testNow.Add(-1 * time.Minute)),
+			validUntil:      timePointer(testNow.Add(1 * time.Minute)),
 			wantErr:         staffinvitation.ErrTimeInPast,
 			isValidationErr: true,
 			wantValidFrom:   nil,
@@ -510,10 +526,10 @@ func TestStaffInvitation_UpdateValidity(t *testing.T) {
 		},
 		{
 			name:            "invalid update with validUntil in the past",
-			staffInvitation: builders.NewStaffInvitationBuilder().WithCreatorID(fixtures.TestStaff.ID).Build(),
+			staffInvitation: invitationBuilder().WithCreatorID(fixtures.TestStaff.ID).Build(),
 			userID:          fixtures.TestStaff.ID,
-			validFrom:       timePointer(time.Now().Add(1 * time.Minute)),
-			validUntil:      timePointer(time.Now().Add(-1 * time.Minute)),
+			validFrom:       timePointer(testNow.Add(1 * time.Minute)),
+			validUntil:      timePointer(testNow.Add(-1 * time.Minute)),
 			wantErr:         staffinvitation.ErrTimeInPast,
 			isValidationErr: true,
 			wantValidFrom:   nil,
@@ -521,10 +537,10 @@ func TestStaffInvitation_UpdateValidity(t *testing.T) {
 		},
 		{
 			name:            "invalid update with validUntil before validFrom",
-			staffInvitation: builders.NewStaffInvitationBuilder().WithCreatorID(fixtures.TestStaff.ID).Build(),
+			staffInvitation: invitationBuilder().WithCreatorID(fixtures.TestStaff.ID).Build(),
 			userID:          fixtures.TestStaff.ID,
-			validFrom:       timePointer(time.Now().Add(2 * time.Minute)),
-			validUntil:      timePointer(time.Now().Add(1 * time.Minute)),
+			validFrom:       timePointer(testNow.Add(2 * time.Minute)),
+			validUntil:      timePointer(testNow.Add(1 * time.Minute)),
 			wantErr:         staffinvitation.ErrTimeBeforeThreshold,
 			isValidationErr: true,
 			wantValidFrom:   nil,
@@ -532,38 +548,38 @@ func TestStaffInvitation_UpdateValidity(t *testing.T) {
 		},
 		{
 			name: "no change, thus no event is emitted",
-			staffInvitation: builders.NewStaffInvitationBuilder().
+			staffInvitation: invitationBuilder().
 				WithCreatorID(fixtures.TestStaff.ID).
-				WithValidFrom(timePointer(time.Now().Add(1 * time.Minute))).Build(),
+				WithValidFrom(timePointer(testNow.Add(1 * time.Minute))).Build(),
 			userID:            fixtures.TestStaff.ID,
-			validFrom:         timePointer(time.Now().Add(1 * time.Minute)),
+			validFrom:         timePointer(testNow.Add(1 * time.Minute)),
 			validUntil:        nil,
-			wantValidFrom:     timePointer(time.Now().Add(1 * time.Minute)),
+			wantValidFrom:     timePointer(testNow.Add(1 * time.Minute)),
 			wantValidUntil:    nil,
 			isEventNotEmitted: true,
 		},
 		{
 			name: "invalid already deleted",
-			staffInvitation: builders.NewStaffInvitationBuilder().
+			staffInvitation: invitationBuilder().
 				WithCreatorID(fixtures.TestStaff.ID).
-				WithDeletedAt(timePointer(time.Now().Add(-1 * time.Minute))).
+				WithDeletedAt(timePointer(testNow.Add(-1 * time.Minute))).
 				Build(),
 			userID:         fixtures.TestStaff.ID,
-			validFrom:      timePointer(time.Now().Add(1 * time.Minute)),
-			validUntil:     timePointer(time.Now().Add(2 * time.Minute)),
+			validFrom:      timePointer(testNow.Add(1 * time.Minute)),
+			validUntil:     timePointer(testNow.Add(2 * time.Minute)),
 			wantErr:        staffinvitation.ErrNotFoundOrDeleted,
 			wantValidFrom:  nil,
 			wantValidUntil: nil,
 		},
 		{
 			name: "invalid already deleted with non creator",
-			staffInvitation: builders.NewStaffInvitationBuilder().
+			staffInvitation: invitationBuilder().
 				WithCreatorID(fixtures.TestStaff.ID).
-				WithDeletedAt(timePointer(time.Now().Add(-1 * time.Minute))).
+				WithDeletedAt(timePointer(testNow.Add(-1 * time.Minute))).
 				Build(),
 			userID:         fixtures.TestStaff2.ID,
-			validFrom:      timePointer(time.Now().Add(1 * time.Minute)),
-			validUntil:     timePointer(time.Now().Add(2 * time.Minute)),
+			validFrom:      timePointer(testNow.Add(1 * time.Minute)),
+			validUntil:     timePointer(testNow.Add(2 * time.Minute)),
 			wantErr:        staffinvitation.ErrForbidden,
 			wantValidFrom:  nil,
 			wantValidUntil: nil,
@@ -582,6 +598,7 @@ func TestStaffInvitation_UpdateValidity(t *testing.T) {
 				}
 				assert.Equal(t, tt.staffInvitation.ValidFrom(), tt.staffInvitation.ValidFrom())   // no change
 				assert.Equal(t, tt.staffInvitation.ValidUntil(), tt.staffInvitation.ValidUntil()) // no change
+				event.AssertNoEventOfType[*staffinvitation.ValidityUpdated](t, tt.staffInvitation.GetUncommittedEvents())
 			} else {
 				require.NoError(t, err)
 				assertTimePointerWithinDuration(t, tt.wantValidFrom, tt.staffInvitation.ValidFrom(), time.Second)
@@ -594,13 +611,50 @@ func TestStaffInvitation_UpdateValidity(t *testing.T) {
 					assertTimePointerWithinDuration(t, tt.wantValidFrom, e.ValidFrom, time.Second)
 					assertTimePointerWithinDuration(t, tt.wantValidUntil, e.ValidUntil, time.Second)
 				} else {
-					event.AssertNoEvents(t, events)
+					event.AssertNoEventOfType[*staffinvitation.ValidityUpdated](t, events)
 				}
 			}
 		})
 	}
 }
 
+func TestStaffInvitation_ClockAdvanced(t *testing.T) {
+	t.Parallel()
+
+	clk := clock.NewFake(testNow)
+	validFrom := testNow.Add(time.Minute)
+	inv, err := staffinvitation.NewStaffInvitation(staffinvitation.CreateArgs{
+		RecipientsEmail: []string{testEmail1},
+		CreatorID:       fixtures.TestStaff.ID,
+		ValidFrom:       &validFrom,
+		Clock:           clk,
+	})
+	require.NoError(t, err)
+	event.AssertEvents(t, event.Drain(inv), event.OfType[*staffinvitation.Created]())
+
+	clk.Advance(2 * time.Minute)
+
+	err = inv.UpdateValidity(fixtures.TestStaff.ID, &validFrom, nil)
+	validationx.AssertValidationError(t, err, staffinvitation.ErrTimeInPast)
+	event.AssertNoEventOfType[*staffinvitation.ValidityUpdated](t, inv.GetUncommittedEvents())
+
+	require.NoError(t, inv.UpdateRecipients(fixtures.TestStaff.ID, []string{testEmail2}))
+	assert.Equal(t, clk.Now(), inv.UpdatedAt())
+
+	require.NoError(t, inv.MarkDeleted(fixtures.TestStaff.ID))
+	require.NotNil(t, inv.DeletedAt())
+	assert.Equal(t, clk.Now(), *inv.DeletedAt())
+
+	event.AssertEvents(t, event.Drain(inv),
+		event.OfType(func(e *staffinvitation.RecipientsUpdated) bool {
+			return slices.Equal(e.CurrentRecipientsEmail, []string{testEmail2})
+		}),
+		event.OfType(func(e *staffinvitation.Deleted) bool {
+			return e.StaffInvitationID == inv.ID()
+		}),
+	)
+}
+
 func TestStaffInvitation_MarkDeleted(t *testing.T) {
 	t.Parallel()
 
@@ -626,7 +680,7 @@ func TestStaffInvitation_MarkDeleted(t *testing.T) {
 			name: "invalid delete when already deleted",
 			staffInvitation: builders.NewStaffInvitationBuilder().
 				WithCreatorID(fixtures.TestStaff.ID).
-				WithDeletedAt(timePointer(time.Now().Add(-1 * time.Minute))).
+				WithDeletedAt(timePointer(testNow.Add(-1 * time.Minute))).
 				Build(),
 			userID:            fixtures.TestStaff.ID,
 			wantErr:           nil, // idempotent
@@ -641,6 +695,7 @@ func TestStaffInvitation_MarkDeleted(t *testing.T) {
 				require.Error(t, err)
 				assert.ErrorIs(t, err, tt.wantErr)
 				assert.Nil(t, tt.staffInvitation.DeletedAt()) // not deleted
+				event.AssertNoEventOfType[*staffinvitation.Deleted](t, tt.staffInvitation.GetUncommittedEvents())
 			} else {
 				require.NoError(t, err)
 				require.NotNil(t, tt.staffInvitation.DeletedAt())
@@ -650,13 +705,88 @@ func TestStaffInvitation_MarkDeleted(t *testing.T) {
 					e := event.AssertSingleEvent[*staffinvitation.Deleted](t, events)
 					assert.Equal(t, tt.staffInvitation.ID(), e.StaffInvitationID)
 				} else {
-					event.AssertNoEvents(t, events)
+					event.AssertNoEventOfType[*staffinvitation.Deleted](t, events)
 				}
 			}
 		})
 	}
 }
 
+func TestStaffInvitation_CommitEvents(t *testing.T) {
+	t.Parallel()
+
+	invitation := builders.NewStaffInvitationBuilder().WithCreatorID(fixtures.TestStaff.ID).Build()
+	require.NoError(t, invitation.MarkDeleted(fixtures.TestStaff.ID))
+	event.AssertSingleEvent[*staffinvitation.Deleted](t, invitation.GetUncommittedEvents())
+	version := invitation.Version()
+
+	invitation.CommitEvents()
+	event.AssertNoEvents(t, invitation.GetUncommittedEvents())
+	assert.Equal(t, version+1, invitation.Version())
+
+	// deleting again changes nothing, nothing is left to publish
+	require.NoError(t, invitation.MarkDeleted(fixtures.TestStaff.ID))
+	event.AssertNoEvents(t, invitation.GetUncommittedEvents())
+	assert.Equal(t, version+1, invitation.Version())
+}
+
+func TestStaffInvitation_Revoke(t *testing.T) {
+	t.Parallel()
+
+	t.Run("another creator", func(t *testing.T) {
+		t.Parallel()
+		si := builders.NewStaffInvitationBuilder().WithCreatorID(fixtures.TestStaff2.ID).Build()
+
+		si.Revoke()
+
+		require.NotNil(t, si.DeletedAt())
+		e := event.AssertSingleEvent[*staffinvitation.Deleted](t, si.GetUncommittedEvents())
+		assert.Equal(t, si.ID(), e.StaffInvitationID)
+		assert.Equal(t, uuid.Nil, e.ActorID, "an operator revokes as the system")
+	})
+
+	t.Run("already deleted", func(t *testing.T) {
+		t.Parallel()
+		deletedAt := testNow.Add(-1 * time.Minute)
+		si := builders.NewStaffInvitationBuilder().WithDeletedAt(&deletedAt).Build()
+
+		si.Revoke()
+
+		assert.Equal(t, deletedAt, *si.DeletedAt())
+		event.AssertNoEventOfType[*staffinvitation.Deleted](t, si.GetUncommittedEvents())
+	})
+}
+
+func TestStaffInvitation_Accept(t *testing.T) {
+	t.Parallel()
+
+	t.Run("consumes the recipient", func(t *testing.T) {
+		t.Parallel()
+		recipients := []string{fixtures.ValidStaff3Email, fixtures.ValidStaff4Email}
+		si := builders.NewStaffInvitationBuilder().WithRecipientsEmail(recipients).WithCode(validCode).Build()
+
+		require.NoError(t, si.Accept(fixtures.ValidStaff3Email))
+
+		staffinvitation.NewAssertion(t, si).
+			AssertRecipient(fixtures.ValidStaff3Email, false).
+			AssertRecipient(fixtures.ValidStaff4Email, true)
+		assert.Equal(t, []string{fixtures.ValidStaff3Email, fixtures.ValidStaff4Email}, recipients, "the slice is not shared")
+		assert.ErrorIs(t, si.ValidateInvitationAccess(fixtures.ValidStaff3Email, validCode), staffinvitation.ErrInvalidInvitation)
+		assert.ErrorIs(t, si.Accept(fixtures.ValidStaff3Email), staffinvitation.ErrInvalidInvitation, "it is accepted once")
+	})
+
+	t.Run("deleted", func(t *testing.T) {
+		t.Parallel()
+		deletedAt := testNow.Add(-1 * time.Minute)
+		si := builders.NewStaffInvitationBuilder().
+			WithRecipientsEmail([]string{fixtures.ValidStaff3Email}).
+			WithDeletedAt(&deletedAt).
+			Build()
+
+		assert.ErrorIs(t, si.Accept(fixtures.ValidStaff3Email), staffinvitation.ErrNotFoundOrDeleted)
+	})
+}
+
 func TestStaffInvitation_ValidateInvitationAccess(t *testing.T) {
 	t.Parallel()
 
@@ -739,7 +869,7 @@ func TestStaffInvitation_ValidateInvitationAccess(t *testing.T) {
 				WithRecipientsEmail([]string{fixtures.ValidStaff3Email, fixtures.ValidStaff4Email}).
 				WithCode(validCode).
 				WithCreatorID(fixtures.TestStaff.ID).
-				WithDeletedAt(timePointer(time.Now().Add(-1 * time.Minute))).
+				WithDeletedAt(timePointer(testNow.Add(-1 * time.Minute))).
 				Build(),
 			email:   fixtures.ValidStaff3Email,
 			code:    validCode,
@@ -0,0 +1,256 @@
+package term
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/ARUMANDESU/validation"
+	"github.com/google/uuid"
+
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/i18nx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/validationx"
+)
+
+const (
+	MaxCodeLen = 32
+	MaxNameLen = 100
+	// MinDuration is the shortest term, a shorter one is a typo.
+	MinDuration = 24 * time.Hour
+)
+
+// CodePattern is the year the term starts in and its season, e.g. 2025-Fall.
+var CodePattern = regexp.MustCompile(`^\d{4}-[A-Za-z][A-Za-z0-9-]*$`)
+
+// ErrOverlap matches the errors of CheckOverlaps with errors.Is.
+var ErrOverlap = errorx.NewTermOverlap()
+
+type ID uuid.UUID
+
+func NewID() ID {
+	return ID(uuid.New())
+}
+
+func (id ID) String() string {
+	return uuid.UUID(id).String()
+}
+
+func (id ID) MarshalJSON() ([]byte, error) {
+	return json.Marshal(uuid.UUID(id).String())
+}
+
+func (id *ID) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+
+	uid, err := uuid.Parse(s)
+	if err != nil {
+		return err
+	}
+
+	*id = ID(uid)
+	return nil
+}
+
+// Term is an academic term, e.g. 2025-Fall, the groups and the reports are
+// scoped by. It starts at StartsAt and ends right before EndsAt, the terms do
+// not overlap so a time is in one term at most.
+type Term struct {
+	id        ID
+	code      string
+	name      string
+	startsAt  time.Time
+	endsAt    time.Time
+	createdAt time.Time
+	updatedAt time.Time
+	clock     clock.Clock
+}
+
+type CreateArgs struct {
+	// ID defaults to a new one.
+	ID       ID
+	Code     string
+	Name     string
+	StartsAt time.Time
+	EndsAt   time.Time
+	// Clock defaults to clock.Real.
+	Clock clock.Clock
+}
+
+// New returns the term of args. It is not checked against the other terms,
+// see CheckOverlaps.
+func New(args CreateArgs) (*Term, error) {
+	const op = "term.New"
+	err := validate(args.Code, args.Name, args.StartsAt, args.EndsAt)
+	if err != nil {
+		return nil, errorx.Wrap(err, op)
+	}
+
+	if args.ID == (ID{}) {
+		args.ID = NewID()
+	}
+	now := clock.Or(args.Clock).Now().UTC()
+	return &Term{
+		id:        args.ID,
+		code:      args.Code,
+		name:      args.Name,
+		startsAt:  args.StartsAt.UTC(),
+		endsAt:    args.EndsAt.UTC(),
+		createdAt: now,
+		updatedAt: now,
+		clock:     args.Clock,
+	}, nil
+}
+
+type UpdateArgs struct {
+	Code     string
+	Name     string
+	StartsAt time.Time
+	EndsAt   time.Time
+}
+
+// Update replaces the fields of the term, checked like New checks them. It
+// reports whether anything changed.
+func (t *Term) Update(args UpdateArgs) (bool, error) {
+	const op = "term.Term.Update"
+	err := validate(args.Code, args.Name, args.StartsAt, args.EndsAt)
+	if err != nil {
+		return false, errorx.Wrap(err, op)
+	}
+	if t.code == args.Code && t.name == args.Name && t.startsAt.Equal(args.StartsAt) && t.endsAt.Equal(args.EndsAt) {
+		return false, nil
+	}
+
+	t.code = args.Code
+	t.name = args.Name
+	t.startsAt = args.StartsAt.UTC()
+	t.endsAt = args.EndsAt.UTC()
+	t.updatedAt = clock.Or(t.clock).Now().UTC()
+	return true, nil
+}
+
+func validate(code, name string, startsAt, endsAt time.Time) error {
+	return validation.Errors{
+		"code": validation.Validate(code,
+			validation.Required,
+			validation.RuneLength(1, MaxCodeLen),
+			validation.Match(CodePattern),
+		),
+		"name":      validation.Validate(name, validation.Required, validation.RuneLength(1, MaxNameLen)),
+		"starts_at": validation.Validate(startsAt, validation.Required),
+		"ends_at": validation.Validate(endsAt,
+			validation.Required,
+			validationx.TimeWindowRule{From: &startsAt, MinDuration: MinDuration},
+		),
+	}.Filter()
+}
+
+// Contains reports whether at is in the term, which includes its start and
+// excludes its end.
+func (t *Term) Contains(at time.Time) bool {
+	return !at.Before(t.startsAt) && at.Before(t.endsAt)
+}
+
+// Overlaps reports whether the terms share a moment, a term ending when the
+// other starts does not overlap it.
+func (t *Term) Overlaps(other *Term) bool {
+	return t.startsAt.Before(other.endsAt) && other.startsAt.Before(t.endsAt)
+}
+
+// CheckOverlaps returns ErrOverlap naming the first of others the term
+// overlaps, the term itself is skipped so that others may be all the terms.
+func (t *Term) CheckOverlaps(others []*Term) error {
+	const op = "term.Term.CheckOverlaps"
+	for _, other := range others {
+		if other.id == t.id || !t.Overlaps(other) {
+			continue
+		}
+		return errorx.NewTermOverlap().
+			WithArgs(map[string]any{i18nx.ArgTerm: other.code}).
+			WithDetails(fmt.Sprintf("the term overlaps the term %s (%s), from %s to %s", other.code, other.id,
+				other.startsAt.Format(time.RFC3339), other.endsAt.Format(time.RFC3339))).
+			WithOp(op)
+	}
+	return nil
+}
+
+type CurrentGetter interface {
+	// GetTermAt returns the term containing at, a not found error when none
+	// does.
+	GetTermAt(ctx context.Context, at time.Time) (*Term, error)
+}
+
+// Current returns the term containing now, nil when there is none or g is
+// nil, e.g. between two terms.
+func Current(ctx context.Context, g CurrentGetter, now time.Time) (*Term, error) {
+	if g == nil {
+		return nil, nil
+	}
+	t, err := g.GetTermAt(ctx, now)
+	if errorx.IsNotFound(err) {
+		return nil, nil
+	}
+	return t, err
+}
+
+type RehydrateArgs struct {
+	ID        ID
+	Code      string
+	Name      string
+	StartsAt  time.Time
+	EndsAt    time.Time
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	// Clock defaults to clock.Real.
+	Clock clock.Clock
+}
+
+func Rehydrate(args RehydrateArgs) *Term {
+	return &Term{
+		id:        args.ID,
+		code:      args.Code,
+		name:      args.Name,
+		startsAt:  args.StartsAt,
+		endsAt:    args.EndsAt,
+		createdAt: args.CreatedAt,
+		updatedAt: args.UpdatedAt,
+		clock:     args.Clock,
+	}
+}
+
+func (t *Term) ID() ID {
+	return t.id
+}
+
+// Code is the short name of the term, e.g. 2025-Fall, unique.
+func (t *Term) Code() string {
+	return t.code
+}
+
+func (t *Term) Name() string {
+	return t.name
+}
+
+// StartsAt is the first moment of the term.
+func (t *Term) StartsAt() time.Time {
+	return t.startsAt
+}
+
+// EndsAt is the first moment after the term.
+func (t *Term) EndsAt() time.Time {
+	return t.endsAt
+}
+
+func (t *Term) CreatedAt() time.Time {
+	return t.createdAt
+}
+
+func (t *Term) UpdatedAt() time.Time {
+	return t.updatedAt
+}
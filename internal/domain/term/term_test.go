@@ -0,0 +1,138 @@
+package term_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ARUMANDESU/validation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/term"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/i18nx"
+)
+
+var (
+	termNow   = time.Date(2025, time.August, 1, 10, 0, 0, 0, time.UTC)
+	fallStart = time.Date(2025, time.September, 1, 0, 0, 0, 0, time.UTC)
+	fallEnd   = time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)
+)
+
+func newTerm(t *testing.T, code string, startsAt, endsAt time.Time) *term.Term {
+	t.Helper()
+	tm, err := term.New(term.CreateArgs{
+		Code:     code,
+		Name:     code,
+		StartsAt: startsAt,
+		EndsAt:   endsAt,
+		Clock:    clock.NewFake(termNow),
+	})
+	require.NoError(t, err)
+	return tm
+}
+
+func TestNew(t *testing.T) {
+	fall := newTerm(t, "2025-Fall", fallStart, fallEnd)
+	assert.Equal(t, "2025-Fall", fall.Code())
+	assert.Equal(t, fallStart, fall.StartsAt())
+	assert.Equal(t, fallEnd, fall.EndsAt())
+	assert.Equal(t, termNow, fall.CreatedAt())
+
+	tests := []struct {
+		name  string
+		args  term.CreateArgs
+		field string
+	}{
+		{name: "no code", args: term.CreateArgs{Name: "Fall", StartsAt: fallStart, EndsAt: fallEnd}, field: "code"},
+		{name: "code without year", args: term.CreateArgs{Code: "Fall", Name: "Fall", StartsAt: fallStart, EndsAt: fallEnd}, field: "code"},
+		{name: "no name", args: term.CreateArgs{Code: "2025-Fall", StartsAt: fallStart, EndsAt: fallEnd}, field: "name"},
+		{name: "ends before it starts", args: term.CreateArgs{Code: "2025-Fall", Name: "Fall", StartsAt: fallEnd, EndsAt: fallStart}, field: "ends_at"},
+		{name: "too short", args: term.CreateArgs{Code: "2025-Fall", Name: "Fall", StartsAt: fallStart, EndsAt: fallStart.Add(time.Hour)}, field: "ends_at"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := term.New(tt.args)
+			var errs validation.Errors
+			require.ErrorAs(t, err, &errs)
+			assert.Contains(t, errs, tt.field)
+		})
+	}
+}
+
+func TestTerm_Contains(t *testing.T) {
+	fall := newTerm(t, "2025-Fall", fallStart, fallEnd)
+
+	assert.False(t, fall.Contains(fallStart.Add(-time.Nanosecond)))
+	assert.True(t, fall.Contains(fallStart), "the start is in the term")
+	assert.True(t, fall.Contains(fallEnd.Add(-time.Nanosecond)))
+	assert.False(t, fall.Contains(fallEnd), "the end is not in the term")
+}
+
+func TestTerm_CheckOverlaps(t *testing.T) {
+	fall := newTerm(t, "2025-Fall", fallStart, fallEnd)
+	spring := newTerm(t, "2026-Spring", fallEnd, fallEnd.AddDate(0, 5, 0))
+
+	t.Run("adjacent terms", func(t *testing.T) {
+		assert.NoError(t, spring.CheckOverlaps([]*term.Term{fall}))
+		assert.NoError(t, fall.CheckOverlaps([]*term.Term{spring}))
+	})
+
+	t.Run("itself", func(t *testing.T) {
+		assert.NoError(t, fall.CheckOverlaps([]*term.Term{fall, spring}))
+	})
+
+	t.Run("overlap names the conflicting term", func(t *testing.T) {
+		winter := newTerm(t, "2025-Winter", fallEnd.AddDate(0, 0, -10), fallEnd.AddDate(0, 1, 0))
+		err := winter.CheckOverlaps([]*term.Term{fall, spring})
+		require.ErrorIs(t, err, term.ErrOverlap)
+
+		var i18nErr *errorx.I18nError
+		require.ErrorAs(t, err, &i18nErr)
+		assert.Equal(t, "2025-Fall", i18nErr.MessageArgs[i18nx.ArgTerm])
+		assert.Contains(t, i18nErr.Details, "2025-Fall")
+		assert.Equal(t, errorx.CodeTermOverlap, errorx.CodeOf(err))
+	})
+
+	t.Run("updated into another term", func(t *testing.T) {
+		_, err := spring.Update(term.UpdateArgs{
+			Code:     spring.Code(),
+			Name:     spring.Name(),
+			StartsAt: fallEnd.AddDate(0, -1, 0),
+			EndsAt:   spring.EndsAt(),
+		})
+		require.NoError(t, err)
+		assert.ErrorIs(t, spring.CheckOverlaps([]*term.Term{fall}), term.ErrOverlap)
+	})
+}
+
+type currentGetter struct {
+	t   *term.Term
+	err error
+}
+
+func (g currentGetter) GetTermAt(context.Context, time.Time) (*term.Term, error) {
+	return g.t, g.err
+}
+
+func TestCurrent(t *testing.T) {
+	fall := newTerm(t, "2025-Fall", fallStart, fallEnd)
+
+	got, err := term.Current(t.Context(), currentGetter{t: fall}, fallStart)
+	require.NoError(t, err)
+	assert.Equal(t, fall, got)
+
+	got, err = term.Current(t.Context(), currentGetter{err: errorx.NewResourceNotFound(i18nx.FieldTerm)}, fallEnd)
+	require.NoError(t, err)
+	assert.Nil(t, got, "between terms")
+
+	got, err = term.Current(t.Context(), nil, fallStart)
+	require.NoError(t, err)
+	assert.Nil(t, got)
+
+	_, err = term.Current(t.Context(), currentGetter{err: errors.New("boom")}, fallStart)
+	assert.Error(t, err)
+}
@@ -0,0 +1,125 @@
+package tos
+
+import (
+	"context"
+	"time"
+
+	"github.com/ARUMANDESU/validation"
+	"github.com/ARUMANDESU/validation/is"
+
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+)
+
+const (
+	MaxVersionLen     = 32
+	MaxDocumentURLLen = 2048
+)
+
+// ErrReconsentRequired is returned to the users who accepted an older
+// version than the current one, until they accept the current one.
+var ErrReconsentRequired = errorx.NewTOSReconsentRequired()
+
+// Version is a published version of the terms of service. The version in
+// effect is the latest one published, a version published in the future
+// takes effect then.
+type Version struct {
+	version     string
+	documentURL string
+	publishedAt time.Time
+	createdAt   time.Time
+}
+
+type CreateArgs struct {
+	Version     string
+	DocumentURL string
+	// PublishedAt defaults to now.
+	PublishedAt time.Time
+	// Clock defaults to clock.Real.
+	Clock clock.Clock
+}
+
+func NewVersion(args CreateArgs) (*Version, error) {
+	const op = "tos.NewVersion"
+	err := validation.ValidateStruct(&args,
+		validation.Field(&args.Version, validation.Required, validation.RuneLength(1, MaxVersionLen)),
+		validation.Field(&args.DocumentURL, validation.Required, validation.Length(1, MaxDocumentURLLen), is.URL),
+	)
+	if err != nil {
+		return nil, errorx.Wrap(err, op)
+	}
+
+	now := clock.Or(args.Clock).Now().UTC()
+	publishedAt := args.PublishedAt.UTC()
+	if args.PublishedAt.IsZero() {
+		publishedAt = now
+	}
+
+	return &Version{
+		version:     args.Version,
+		documentURL: args.DocumentURL,
+		publishedAt: publishedAt,
+		createdAt:   now,
+	}, nil
+}
+
+type RehydrateArgs struct {
+	Version     string
+	DocumentURL string
+	PublishedAt time.Time
+	CreatedAt   time.Time
+}
+
+func Rehydrate(args RehydrateArgs) *Version {
+	return &Version{
+		version:     args.Version,
+		documentURL: args.DocumentURL,
+		publishedAt: args.PublishedAt,
+		createdAt:   args.CreatedAt,
+	}
+}
+
+func (v *Version) Version() string {
+	if v == nil {
+		return ""
+	}
+	return v.version
+}
+
+func (v *Version) DocumentURL() string {
+	if v == nil {
+		return ""
+	}
+	return v.documentURL
+}
+
+func (v *Version) PublishedAt() time.Time {
+	if v == nil {
+		return time.Time{}
+	}
+	return v.publishedAt
+}
+
+func (v *Version) CreatedAt() time.Time {
+	if v == nil {
+		return time.Time{}
+	}
+	return v.createdAt
+}
+
+type CurrentGetter interface {
+	GetCurrentTOSVersion(ctx context.Context, now time.Time) (*Version, error)
+}
+
+// Current returns the version in effect at now, nil while none is published
+// or g is nil.
+func Current(ctx context.Context, g CurrentGetter, now time.Time) (*Version, error) {
+	if g == nil {
+		return nil, nil
+	}
+	v, err := g.GetCurrentTOSVersion(ctx, now)
+	if errorx.IsNotFound(err) {
+		return nil, nil
+	}
+	return v, err
+}
@@ -0,0 +1,100 @@
+package tos_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/tos"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/i18nx"
+)
+
+var tosNow = time.Date(2026, time.May, 4, 10, 0, 0, 0, time.UTC)
+
+func TestNewVersion(t *testing.T) {
+	valid := func() tos.CreateArgs {
+		return tos.CreateArgs{
+			Version:     "2026-05",
+			DocumentURL: "https://ucms.kz/legal/tos/2026-05",
+			Clock:       clock.NewFake(tosNow),
+		}
+	}
+
+	t.Run("published now by default", func(t *testing.T) {
+		v, err := tos.NewVersion(valid())
+		require.NoError(t, err)
+		assert.Equal(t, "2026-05", v.Version())
+		assert.Equal(t, "https://ucms.kz/legal/tos/2026-05", v.DocumentURL())
+		assert.Equal(t, tosNow, v.PublishedAt())
+		assert.Equal(t, tosNow, v.CreatedAt())
+	})
+
+	t.Run("scheduled", func(t *testing.T) {
+		args := valid()
+		args.PublishedAt = tosNow.Add(24 * time.Hour)
+		v, err := tos.NewVersion(args)
+		require.NoError(t, err)
+		assert.Equal(t, tosNow.Add(24*time.Hour), v.PublishedAt())
+	})
+
+	tests := []struct {
+		name   string
+		modify func(*tos.CreateArgs)
+	}{
+		{name: "empty version", modify: func(a *tos.CreateArgs) { a.Version = "" }},
+		{name: "version too long", modify: func(a *tos.CreateArgs) { a.Version = strings.Repeat("1", tos.MaxVersionLen+1) }},
+		{name: "empty url", modify: func(a *tos.CreateArgs) { a.DocumentURL = "" }},
+		{name: "invalid url", modify: func(a *tos.CreateArgs) { a.DocumentURL = "not a url" }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := valid()
+			tt.modify(&args)
+			_, err := tos.NewVersion(args)
+			assert.Error(t, err)
+		})
+	}
+}
+
+type currentGetter struct {
+	v   *tos.Version
+	err error
+}
+
+func (g currentGetter) GetCurrentTOSVersion(context.Context, time.Time) (*tos.Version, error) {
+	return g.v, g.err
+}
+
+func TestCurrent(t *testing.T) {
+	v := tos.Rehydrate(tos.RehydrateArgs{Version: "2026-05", PublishedAt: tosNow})
+
+	t.Run("published", func(t *testing.T) {
+		got, err := tos.Current(t.Context(), currentGetter{v: v}, tosNow)
+		require.NoError(t, err)
+		assert.Equal(t, v, got)
+	})
+
+	t.Run("none published", func(t *testing.T) {
+		got, err := tos.Current(t.Context(), currentGetter{err: errorx.NewResourceNotFound(i18nx.FieldTOSVersion)}, tosNow)
+		require.NoError(t, err)
+		assert.Nil(t, got)
+	})
+
+	t.Run("no getter", func(t *testing.T) {
+		got, err := tos.Current(t.Context(), nil, tosNow)
+		require.NoError(t, err)
+		assert.Nil(t, got)
+	})
+
+	t.Run("failure", func(t *testing.T) {
+		_, err := tos.Current(t.Context(), currentGetter{err: errors.New("boom")}, tosNow)
+		assert.Error(t, err)
+	})
+}
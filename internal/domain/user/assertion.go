@@ -77,7 +77,7 @@ func (u *UserAssertions) AssertEmptyAvatar() *UserAssertions {
 
 func (u *UserAssertions) AssertEmail(expected string) *UserAssertions {
 	u.t.Helper()
-	assert.Equal(u.t, expected, u.user.email, "Email mismatch")
+	assert.Equal(u.t, expected, u.user.email.String(), "Email mismatch")
 	return u
 }
 
@@ -134,9 +134,11 @@ func (s *StaffAssertions) AssertByAcceptStaffInvitationArgs(t *testing.T, args A
 	assert.Equal(t, args.FirstName, s.staff.user.firstName, "FirstName mismatch")
 	assert.Equal(t, args.LastName, s.staff.user.lastName, "LastName mismatch")
 	assert.Equal(t, args.Email, s.staff.user.email, "Email mismatch")
+	assert.Equal(t, args.Department, s.staff.department, "Department mismatch")
+	assert.Equal(t, args.Position, s.staff.position, "Position mismatch")
 	assert.Equal(t, roles.Staff, s.staff.user.role, "Role mismatch")
-	assert.WithinDuration(t, time.Now(), s.staff.user.createdAt, time.Minute, "CreatedAt should be recent")
-	assert.WithinDuration(t, time.Now(), s.staff.user.updatedAt, time.Minute, "UpdatedAt should be recent")
+	assert.WithinDuration(t, s.staff.user.now(), s.staff.user.createdAt, time.Minute, "CreatedAt should be recent")
+	assert.WithinDuration(t, s.staff.user.now(), s.staff.user.updatedAt, time.Minute, "UpdatedAt should be recent")
 
 	assert.NoError(t, bcrypt.CompareHashAndPassword(s.staff.user.passHash, []byte(args.Password)), "PassHash mismatch")
 
@@ -164,8 +166,8 @@ func (s *StaffAssertions) AssertByCreateInitialArgs(t *testing.T, args CreateIni
 	assert.Equal(t, args.LastName, s.staff.user.lastName, "LastName mismatch")
 	assert.Equal(t, args.Email, s.staff.user.email, "Email mismatch")
 	assert.Equal(t, roles.Staff, s.staff.user.role, "Role mismatch")
-	assert.WithinDuration(t, time.Now(), s.staff.user.createdAt, time.Minute, "CreatedAt should be recent")
-	assert.WithinDuration(t, time.Now(), s.staff.user.updatedAt, time.Minute, "UpdatedAt should be recent")
+	assert.WithinDuration(t, s.staff.user.now(), s.staff.user.createdAt, time.Minute, "CreatedAt should be recent")
+	assert.WithinDuration(t, s.staff.user.now(), s.staff.user.updatedAt, time.Minute, "UpdatedAt should be recent")
 
 	assert.NoError(t, bcrypt.CompareHashAndPassword(s.staff.user.passHash, []byte(args.Password)), "PassHash mismatch")
 
@@ -195,6 +197,18 @@ func (s *StaffAssertions) AssertBarcode(t *testing.T, expected Barcode) *StaffAs
 	return s
 }
 
+func (s *StaffAssertions) AssertDepartment(t *testing.T, expected string) *StaffAssertions {
+	t.Helper()
+	assert.Equal(t, expected, s.staff.department, "Department mismatch")
+	return s
+}
+
+func (s *StaffAssertions) AssertPosition(t *testing.T, expected string) *StaffAssertions {
+	t.Helper()
+	assert.Equal(t, expected, s.staff.position, "Position mismatch")
+	return s
+}
+
 func (s *StaffAssertions) AssertUsername(t *testing.T, expected string) *StaffAssertions {
 	t.Helper()
 	assert.Equal(t, expected, s.staff.user.username, "Username mismatch")
@@ -227,7 +241,7 @@ func (s *StaffAssertions) AssertAvatarNotEmpty(t *testing.T) *StaffAssertions {
 
 func (s *StaffAssertions) AssertEmail(t *testing.T, expected string) *StaffAssertions {
 	t.Helper()
-	assert.Equal(t, expected, s.staff.user.email, "Email mismatch")
+	assert.Equal(t, expected, s.staff.user.email.String(), "Email mismatch")
 	return s
 }
 
@@ -344,7 +358,7 @@ func (s *StudentAssertions) AssertAvatarNotEmpty(t *testing.T) *StudentAssertion
 
 func (s *StudentAssertions) AssertEmail(t *testing.T, expected string) *StudentAssertions {
 	t.Helper()
-	assert.Equal(t, expected, s.student.user.email, "Email mismatch")
+	assert.Equal(t, expected, s.student.user.email.String(), "Email mismatch")
 	return s
 }
 
@@ -423,7 +437,7 @@ func (s *StudentRegistrationAssertions) AssertRegistrationID(expected registrati
 
 func (s *StudentRegistrationAssertions) AssertEmail(expected string) *StudentRegistrationAssertions {
 	s.t.Helper()
-	assert.Equal(s.t, expected, s.event.Email, "Email mismatch")
+	assert.Equal(s.t, expected, s.event.Email.String(), "Email mismatch")
 	return s
 }
 
@@ -0,0 +1,62 @@
+package user
+
+import (
+	"github.com/google/uuid"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/event"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/avatars"
+)
+
+// AvatarReference is a user pointing to an S3 avatar object.
+type AvatarReference struct {
+	UserID ID
+	S3Key  string
+}
+
+// UserAvatarMissing is published when a profile read finds the object of the
+// S3 avatar of the user gone, e.g. deleted from the bucket by hand. Its
+// handler clears the stale reference.
+type UserAvatarMissing struct {
+	event.Header
+	event.Otel
+	UserID ID             `json:"user_id"`
+	Avatar avatars.Avatar `json:"avatar"`
+}
+
+func (e *UserAvatarMissing) GetStreamName() string {
+	return UserEventStreamName
+}
+
+// NewUserAvatarMissing returns the event of the missing avatar of the user
+// id. The reads publish it without loading the user, so it is not recorded
+// by the aggregate.
+func NewUserAvatarMissing(id ID, avatar avatars.Avatar) *UserAvatarMissing {
+	header := event.NewEventHeader()
+	header.AggregateID = uuid.UUID(id)
+	return &UserAvatarMissing{
+		Header: header,
+		UserID: id,
+		Avatar: avatar,
+	}
+}
+
+// ClearMissingAvatar removes the S3 avatar key whose object the storage no
+// longer has and reports whether it did. A user who moved to another avatar
+// meanwhile keeps it. The change is the system's, UserAvatarUpdated releases
+// the reference to the key.
+func (u *User) ClearMissingAvatar(key string) bool {
+	if u == nil || key == "" || u.avatar.Source != avatars.SourceS3 || u.avatar.S3Key != key {
+		return false
+	}
+
+	oldAvatar := u.avatar
+	u.avatar = avatars.Avatar{}
+	u.updatedAt = u.now()
+
+	u.Record(&UserAvatarUpdated{
+		UserID:    u.id,
+		NewAvatar: u.avatar,
+		OldAvatar: oldAvatar,
+	}, uuid.UUID(u.id), uuid.Nil)
+	return true
+}
@@ -1,26 +1,64 @@
 package user
 
 import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"net/http"
+	"strings"
 	"time"
 
 	"github.com/ARUMANDESU/validation"
 
 	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
 	"gitlab.com/ucmsv2/ucms-backend/pkg/i18nx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/imagex"
 )
 
 const (
 	MinAvatarSize = 100             // 100 bytes
 	MaxAvatarSize = 5 * 1024 * 1024 // 5 MB
+
+	// MaxAvatarDimension caps both the width and the height, larger images are
+	// rejected from their headers before they are decoded.
+	MaxAvatarDimension = 8000
+
+	// AvatarKeyPrefix is the storage prefix every uploaded avatar lives under.
+	AvatarKeyPrefix = "avatars/"
+
+	// PendingUploadTTL is how long the token of an avatar upload is valid,
+	// its reference must be committed before. The garbage collection keeps
+	// the objects of the younger tokens.
+	PendingUploadTTL = 24 * time.Hour
 )
 
+// ErrUploadExpired is an upload whose token expired or was consumed already,
+// its object may have been collected meanwhile.
+var ErrUploadExpired = errorx.NewConflict().WithDetails("the upload expired, upload the avatar again")
+
 var (
 	ErrInvalidFileType = validation.NewError(i18nx.ValidationInvalidFileType, i18nx.MsgValidationInvalidFileTypeOther)
 	ErrAvatarTooLarge  = validation.NewError(i18nx.ValidationFileSizeTooLarge, i18nx.MsgValidationFileSizeTooLargeOther).
 				SetParams(map[string]any{i18nx.ArgThreshold: MaxAvatarSize / (1024 * 1024), i18nx.ArgUnit: "MB"})
 	ErrAvatarTooSmall = validation.NewError(i18nx.ValidationFileSizeTooSmall, i18nx.MsgValidationFileSizeTooSmallOther).
 				SetParams(map[string]any{i18nx.ArgThreshold: MinAvatarSize, i18nx.ArgUnit: "bytes"})
+	ErrAvatarChecksumMismatch = errorx.NewValidationFieldFailed(i18nx.FieldAvatar).
+					WithHTTPCode(http.StatusUnprocessableEntity).
+					WithDetails("avatar content does not match the provided checksum")
+	ErrAvatarInvalidImage = errorx.NewValidationFieldFailed(i18nx.FieldAvatar).
+				WithHTTPCode(http.StatusUnprocessableEntity).
+				WithKey(i18nx.KeyUploadImageInvalid)
+	ErrAvatarAnimated = errorx.NewValidationFieldFailed(i18nx.FieldAvatar).
+				WithHTTPCode(http.StatusUnprocessableEntity).
+				WithKey(i18nx.KeyUploadImageAnimated)
+	ErrAvatarDimensionsTooLarge = errorx.NewValidationFieldFailed(i18nx.FieldAvatar).
+					WithHTTPCode(http.StatusUnprocessableEntity).
+					WithKey(i18nx.KeyUploadImageDimensionsTooBig).
+					WithArgs(map[string]any{i18nx.ArgThreshold: MaxAvatarDimension})
 )
 
 type AvatarService struct {
@@ -57,15 +95,73 @@ func (s *AvatarService) ValidateAvatarFile(contentType string, size int64) error
 	return nil
 }
 
+// ProcessAvatar sanitizes the avatar before it is stored: the EXIF
+// orientation is applied and metadata is stripped. Animated images, images
+// larger than MaxAvatarDimension and content that does not match the declared
+// content type are rejected.
+func (s *AvatarService) ProcessAvatar(contentType string, content []byte) (*imagex.Result, error) {
+	const op = "user.AvatarService.ProcessAvatar"
+
+	if imagex.DetectFormat(content).ContentType() != contentType {
+		return nil, errorx.Wrap(ErrAvatarInvalidImage, op)
+	}
+
+	res, err := imagex.Sanitize(content, imagex.Options{
+		MaxWidth:  MaxAvatarDimension,
+		MaxHeight: MaxAvatarDimension,
+	})
+	switch {
+	case errors.Is(err, imagex.ErrAnimated):
+		return nil, errorx.Wrap(ErrAvatarAnimated, op)
+	case errors.Is(err, imagex.ErrTooLarge):
+		return nil, errorx.Wrap(ErrAvatarDimensionsTooLarge, op)
+	case errors.Is(err, imagex.ErrUnsupported), errors.Is(err, imagex.ErrMalformed):
+		return nil, errorx.Wrap(ErrAvatarInvalidImage, op)
+	case err != nil:
+		return nil, errorx.Wrap(err, op)
+	}
+
+	return res, nil
+}
+
 func (s *AvatarService) BuildAvatarURL(s3Key string) string {
 	return fmt.Sprintf("%s/%s", s.s3BaseURL, s3Key)
 }
 
-// GenerateS3Key generates a unique S3 key for the user's avatar based on their user ID and current timestamp.
-func (s *AvatarService) GenerateS3Key(userID ID) string {
-	return fmt.Sprintf("avatars/%s/%d", userID.String(), timestampMillis())
+// GenerateS3Key derives the avatar key from the SHA-256 of its content, so
+// identical images uploaded by any user end up in the same object.
+func (s *AvatarService) GenerateS3Key(content []byte) string {
+	sum := sha256.Sum256(content)
+	return AvatarKeyPrefix + hex.EncodeToString(sum[:])
 }
 
-func timestampMillis() int64 {
-	return time.Now().UnixNano() / int64(time.Millisecond)
+// VerifyChecksum compares the content against the checksums the client sent
+// along with the upload. contentMD5 is base64 encoded as in the Content-MD5
+// header, contentSHA256 is hex encoded. Empty checksums are not checked.
+func (s *AvatarService) VerifyChecksum(content []byte, contentMD5, contentSHA256 string) error {
+	const op = "user.AvatarService.VerifyChecksum"
+
+	if contentMD5 = strings.TrimSpace(contentMD5); contentMD5 != "" {
+		want, err := base64.StdEncoding.DecodeString(contentMD5)
+		if err != nil {
+			return errorx.Wrap(ErrAvatarChecksumMismatch, op)
+		}
+		got := md5.Sum(content)
+		if !bytes.Equal(want, got[:]) {
+			return errorx.Wrap(ErrAvatarChecksumMismatch, op)
+		}
+	}
+
+	if contentSHA256 = strings.TrimSpace(contentSHA256); contentSHA256 != "" {
+		want, err := hex.DecodeString(contentSHA256)
+		if err != nil {
+			return errorx.Wrap(ErrAvatarChecksumMismatch, op)
+		}
+		got := sha256.Sum256(content)
+		if !bytes.Equal(want, got[:]) {
+			return errorx.Wrap(ErrAvatarChecksumMismatch, op)
+		}
+	}
+
+	return nil
 }
@@ -1,11 +1,19 @@
 package user_test
 
 import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
 	"testing"
 
 	"github.com/stretchr/testify/require"
 
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/httpx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/i18nx"
 	"gitlab.com/ucmsv2/ucms-backend/pkg/validationx"
 	"gitlab.com/ucmsv2/ucms-backend/tests/integration/fixtures"
 )
@@ -100,10 +108,118 @@ func TestAvatarService_BuildAvatarURL(t *testing.T) {
 func TestAvatarService_GenerateS3Key(t *testing.T) {
 	s := newAvatarService()
 
-	userID := user.NewID()
-	s3Key := s.GenerateS3Key(userID)
+	s3Key := s.GenerateS3Key([]byte("avatar content"))
 
-	require.Contains(t, s3Key, "avatars/"+userID.String()+"/")
+	sum := sha256.Sum256([]byte("avatar content"))
+	require.Equal(t, user.AvatarKeyPrefix+hex.EncodeToString(sum[:]), s3Key)
+	require.Equal(t, s3Key, s.GenerateS3Key([]byte("avatar content")), "same content must map to the same key")
+	require.NotEqual(t, s3Key, s.GenerateS3Key([]byte("other content")))
+}
+
+func TestAvatarService_VerifyChecksum(t *testing.T) {
+	s := newAvatarService()
+	content := []byte("avatar content")
+	md5sum := md5.Sum(content)
+	shasum := sha256.Sum256(content)
+
+	tests := []struct {
+		name          string
+		contentMD5    string
+		contentSHA256 string
+		wantErr       bool
+	}{
+		{name: "no checksums"},
+		{name: "matching md5", contentMD5: base64.StdEncoding.EncodeToString(md5sum[:])},
+		{name: "matching sha256", contentSHA256: hex.EncodeToString(shasum[:])},
+		{
+			name:          "both matching",
+			contentMD5:    base64.StdEncoding.EncodeToString(md5sum[:]),
+			contentSHA256: hex.EncodeToString(shasum[:]),
+		},
+		{name: "md5 mismatch", contentMD5: base64.StdEncoding.EncodeToString(make([]byte, 16)), wantErr: true},
+		{name: "sha256 mismatch", contentSHA256: hex.EncodeToString(make([]byte, 32)), wantErr: true},
+		{name: "malformed md5", contentMD5: "not base64!", wantErr: true},
+		{name: "malformed sha256", contentSHA256: "xyz", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := s.VerifyChecksum(content, tt.contentMD5, tt.contentSHA256)
+			if tt.wantErr {
+				require.ErrorIs(t, err, user.ErrAvatarChecksumMismatch)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestAvatarService_ProcessAvatar(t *testing.T) {
+	s := newAvatarService()
+	localizer := httpx.NewErrorHandler().Localizer("en")
+
+	tests := []struct {
+		name        string
+		contentType string
+		content     []byte
+		wantErr     error
+		wantKey     string
+		wantMessage string
+	}{
+		{name: "valid jpeg", contentType: "image/jpeg", content: fixtures.ValidJPEGAvatar},
+		{name: "valid png", contentType: "image/png", content: fixtures.ValidPNGAvatar},
+		{name: "valid gif", contentType: "image/gif", content: fixtures.ValidGIFAvatar},
+		{name: "valid webp", contentType: "image/webp", content: fixtures.ValidWebPAvatar},
+		{
+			name:        "animated gif",
+			contentType: "image/gif",
+			content:     fixtures.AnimatedGIFAvatar,
+			wantErr:     user.ErrAvatarAnimated,
+			wantKey:     i18nx.KeyUploadImageAnimated,
+		},
+		{
+			name:        "too large dimensions",
+			contentType: "image/png",
+			content:     fixtures.HugePNGAvatar,
+			wantErr:     user.ErrAvatarDimensionsTooLarge,
+			wantKey:     i18nx.KeyUploadImageDimensionsTooBig,
+			wantMessage: "Image dimensions must not exceed 8000x8000 pixels",
+		},
+		{
+			name:        "declared type does not match",
+			contentType: "image/png",
+			content:     fixtures.ValidJPEGAvatar,
+			wantErr:     user.ErrAvatarInvalidImage,
+			wantKey:     i18nx.KeyUploadImageInvalid,
+		},
+		{
+			name:        "truncated image",
+			contentType: "image/jpeg",
+			content:     fixtures.TinyJPEGAvatar,
+			wantErr:     user.ErrAvatarInvalidImage,
+			wantKey:     i18nx.KeyUploadImageInvalid,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := s.ProcessAvatar(tt.contentType, tt.content)
+			if tt.wantErr == nil {
+				require.NoError(t, err)
+				require.Equal(t, tt.contentType, res.Format.ContentType())
+				return
+			}
+
+			require.ErrorIs(t, err, tt.wantErr)
+			var i18nErr *errorx.I18nError
+			require.ErrorAs(t, err, &i18nErr)
+			require.Equal(t, http.StatusUnprocessableEntity, i18nErr.HTTPStatusCode())
+			require.Equal(t, tt.wantKey, i18nErr.MessageKey)
+			if tt.wantMessage != "" {
+				require.Equal(t, tt.wantMessage, i18nErr.Localize(localizer))
+			}
+		})
+	}
 }
 
 func newAvatarService() *user.AvatarService {
@@ -0,0 +1,81 @@
+package user
+
+import (
+	"context"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/avatars"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+)
+
+// ObjectURLBuilder turns a storage key into a URL clients can fetch,
+// e.g. storagex.URLBuilder.
+type ObjectURLBuilder interface {
+	URL(ctx context.Context, key string) (string, error)
+}
+
+// ObjectChecker reports whether the storage still has an object, e.g.
+// storagex.ExistenceCache.
+type ObjectChecker interface {
+	ObjectExists(ctx context.Context, key string) (bool, error)
+}
+
+// AvatarMissingPublisher publishes UserAvatarMissing, e.g. the user repo.
+type AvatarMissingPublisher interface {
+	PublishAvatarMissing(ctx context.Context, e *UserAvatarMissing) error
+}
+
+// AvatarURLBuilder is the single place avatar URLs are built for responses,
+// so the configured URL strategy (public, signed, cdn) applies everywhere.
+type AvatarURLBuilder struct {
+	objects ObjectURLBuilder
+
+	// checker and missing are set by VerifyObjects.
+	checker ObjectChecker
+	missing AvatarMissingPublisher
+}
+
+func NewAvatarURLBuilder(objects ObjectURLBuilder) *AvatarURLBuilder {
+	return &AvatarURLBuilder{objects: objects}
+}
+
+// VerifyObjects makes UserAvatarURL check that the S3 avatars still exist
+// before handing out their URL, publishing the missing ones to missing.
+func (b *AvatarURLBuilder) VerifyObjects(checker ObjectChecker, missing AvatarMissingPublisher) *AvatarURLBuilder {
+	b.checker = checker
+	b.missing = missing
+	return b
+}
+
+func (b *AvatarURLBuilder) Build(ctx context.Context, avatar avatars.Avatar) (string, error) {
+	const op = "user.AvatarURLBuilder.Build"
+	switch avatar.Source {
+	case avatars.SourceS3:
+		u, err := b.objects.URL(ctx, avatar.S3Key)
+		if err != nil {
+			return "", errorx.Wrap(err, op)
+		}
+		return u, nil
+	case avatars.SourceExternal:
+		return avatar.External, nil
+	default:
+		return "", nil
+	}
+}
+
+// UserAvatarURL is Build for the avatar of the user id. With VerifyObjects,
+// the URL of an S3 avatar whose object is gone is empty, so that clients show
+// no broken image, and UserAvatarMissing is published to clear the reference.
+// Neither fails the read: a failed check serves the URL as usual, a failed
+// publish is retried by the reads after the checked answer expires.
+func (b *AvatarURLBuilder) UserAvatarURL(ctx context.Context, id ID, avatar avatars.Avatar) (string, error) {
+	if b.checker != nil && avatar.Source == avatars.SourceS3 && avatar.S3Key != "" {
+		exists, err := b.checker.ObjectExists(ctx, avatar.S3Key)
+		if err == nil && !exists {
+			if b.missing != nil {
+				_ = b.missing.PublishAvatarMissing(ctx, NewUserAvatarMissing(id, avatar))
+			}
+			return "", nil
+		}
+	}
+	return b.Build(ctx, avatar)
+}
@@ -0,0 +1,179 @@
+package user_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/event"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/avatars"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/storagex"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/builders"
+)
+
+func TestAvatarURLBuilder_Build(t *testing.T) {
+	public, err := storagex.NewURLBuilder(storagex.URLBuilderConfig{
+		Strategy:      storagex.URLStrategyPublic,
+		PublicBaseURL: "http://localhost:9000/ucms-avatars",
+	})
+	require.NoError(t, err)
+	cdn, err := storagex.NewURLBuilder(storagex.URLBuilderConfig{
+		Strategy:   storagex.URLStrategyCDN,
+		CDNBaseURL: "https://cdn.example.com",
+	})
+	require.NoError(t, err)
+
+	key := user.AvatarKeyPrefix + "abc"
+	tests := []struct {
+		name    string
+		objects user.ObjectURLBuilder
+		avatar  avatars.Avatar
+		want    string
+	}{
+		{
+			name:    "public s3 avatar",
+			objects: public,
+			avatar:  avatars.NewS3Avatar(key),
+			want:    "http://localhost:9000/ucms-avatars/" + key,
+		},
+		{
+			name:    "cdn s3 avatar",
+			objects: cdn,
+			avatar:  avatars.NewS3Avatar(key),
+			want:    "https://cdn.example.com/" + key,
+		},
+		{
+			name:    "external avatar is never rewritten",
+			objects: cdn,
+			avatar:  avatars.NewExternalAvatar("https://example.com/me.png"),
+			want:    "https://example.com/me.png",
+		},
+		{
+			name:    "no avatar",
+			objects: cdn,
+			avatar:  avatars.Avatar{},
+			want:    "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := user.NewAvatarURLBuilder(tt.objects).Build(t.Context(), tt.avatar)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+type fakeObjectChecker struct {
+	missing map[string]bool
+	err     error
+}
+
+func (c fakeObjectChecker) ObjectExists(_ context.Context, key string) (bool, error) {
+	return !c.missing[key], c.err
+}
+
+type fakeMissingPublisher struct {
+	published []*user.UserAvatarMissing
+}
+
+func (p *fakeMissingPublisher) PublishAvatarMissing(_ context.Context, e *user.UserAvatarMissing) error {
+	p.published = append(p.published, e)
+	return nil
+}
+
+func TestAvatarURLBuilder_UserAvatarURL(t *testing.T) {
+	public, err := storagex.NewURLBuilder(storagex.URLBuilderConfig{
+		Strategy:      storagex.URLStrategyPublic,
+		PublicBaseURL: "http://localhost:9000/ucms-avatars",
+	})
+	require.NoError(t, err)
+
+	present := user.AvatarKeyPrefix + "present"
+	gone := user.AvatarKeyPrefix + "gone"
+	id := user.NewID()
+	tests := []struct {
+		name          string
+		checker       user.ObjectChecker
+		avatar        avatars.Avatar
+		want          string
+		wantPublished bool
+	}{
+		{
+			name:   "not verifying",
+			avatar: avatars.NewS3Avatar(gone),
+			want:   "http://localhost:9000/ucms-avatars/" + gone,
+		},
+		{
+			name:    "existing object",
+			checker: fakeObjectChecker{missing: map[string]bool{gone: true}},
+			avatar:  avatars.NewS3Avatar(present),
+			want:    "http://localhost:9000/ucms-avatars/" + present,
+		},
+		{
+			name:          "missing object",
+			checker:       fakeObjectChecker{missing: map[string]bool{gone: true}},
+			avatar:        avatars.NewS3Avatar(gone),
+			want:          "",
+			wantPublished: true,
+		},
+		{
+			name:    "failed check serves the url",
+			checker: fakeObjectChecker{missing: map[string]bool{gone: true}, err: errors.New("timeout")},
+			avatar:  avatars.NewS3Avatar(gone),
+			want:    "http://localhost:9000/ucms-avatars/" + gone,
+		},
+		{
+			name:    "external avatar is not checked",
+			checker: fakeObjectChecker{missing: map[string]bool{"https://example.com/me.png": true}},
+			avatar:  avatars.NewExternalAvatar("https://example.com/me.png"),
+			want:    "https://example.com/me.png",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			builder := user.NewAvatarURLBuilder(public)
+			publisher := &fakeMissingPublisher{}
+			if tt.checker != nil {
+				builder.VerifyObjects(tt.checker, publisher)
+			}
+
+			got, err := builder.UserAvatarURL(t.Context(), id, tt.avatar)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			if !tt.wantPublished {
+				assert.Empty(t, publisher.published)
+				return
+			}
+			require.Len(t, publisher.published, 1)
+			assert.Equal(t, id, publisher.published[0].UserID)
+			assert.Equal(t, tt.avatar, publisher.published[0].Avatar)
+			assert.Equal(t, uuid.UUID(id), publisher.published[0].AggregateID)
+		})
+	}
+}
+
+func TestUser_ClearMissingAvatar(t *testing.T) {
+	key := user.AvatarKeyPrefix + "gone"
+	u := builders.NewUserBuilder().WithS3Avatar(key).Build()
+	u.CommitEvents()
+
+	assert.False(t, u.ClearMissingAvatar(user.AvatarKeyPrefix+"other"), "another key is kept")
+	assert.Equal(t, key, u.Avatar().S3Key)
+	assert.Empty(t, u.GetUncommittedEvents())
+
+	require.True(t, u.ClearMissingAvatar(key))
+	assert.True(t, u.Avatar().IsZero())
+	event.AssertEvents(t, u.GetUncommittedEvents(), event.OfType(func(e *user.UserAvatarUpdated) bool {
+		return e.OldAvatar.S3Key == key && e.NewAvatar.IsZero() && e.ActorID == uuid.Nil
+	}))
+
+	assert.False(t, u.ClearMissingAvatar(key), "a cleared avatar is not cleared again")
+}
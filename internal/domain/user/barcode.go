@@ -0,0 +1,140 @@
+package user
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"github.com/ARUMANDESU/validation"
+	"github.com/ARUMANDESU/validation/is"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/roles"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/i18nx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/validationx"
+)
+
+// Barcode is the campus card number of a user. Build it with NewBarcode, it
+// is stored uppercased so "abc123" and "ABC123" are the same barcode.
+type Barcode string
+
+// NewBarcode trims s, checks it with validationx.IsBarcode and returns its
+// uppercased form. The characters are checked before uppercasing, so a
+// unicode letter uppercased to an ASCII one is rejected.
+func NewBarcode(s string) (Barcode, error) {
+	const op = "user.NewBarcode"
+	s = strings.TrimSpace(s)
+	if err := validation.Validate(s, validation.Required, validationx.IsBarcode); err != nil {
+		return "", errorx.Wrap(validation.Errors{i18nx.FieldBarcode: err}, op)
+	}
+	return Barcode(strings.ToUpper(s)), nil
+}
+
+func (barcode Barcode) String() string {
+	if barcode == "" {
+		return ""
+	}
+	return string(barcode)
+}
+
+// normalized returns the stored form of a barcode that passed
+// validationx.IsBarcode.
+func (barcode Barcode) normalized() Barcode {
+	return Barcode(strings.ToUpper(string(barcode)))
+}
+
+// BarcodeProfile narrows the barcodes of a role within the shared
+// validationx.IsBarcode rule. The zero profile adds nothing to it.
+type BarcodeProfile struct {
+	// MinLen and MaxLen bound the length, 0 keeps the shared bound.
+	MinLen int `yaml:"min_len"`
+	MaxLen int `yaml:"max_len"`
+	// DigitsOnly rejects the letters.
+	DigitsOnly bool `yaml:"digits_only"`
+}
+
+var (
+	// AlphanumericBarcodes is the shared rule alone.
+	AlphanumericBarcodes = BarcodeProfile{}
+	// NumericBarcodes are the 6 to 10 digits of the student cards.
+	NumericBarcodes = BarcodeProfile{MinLen: 6, MaxLen: 10, DigitsOnly: true}
+)
+
+// ParseBarcodeProfile returns the profile named "alphanumeric" or "numeric".
+func ParseBarcodeProfile(name string) (BarcodeProfile, error) {
+	switch name {
+	case "alphanumeric":
+		return AlphanumericBarcodes, nil
+	case "numeric":
+		return NumericBarcodes, nil
+	default:
+		return BarcodeProfile{}, fmt.Errorf("unknown barcode profile %q, the profiles are alphanumeric and numeric", name)
+	}
+}
+
+// UnmarshalText reads a profile name with ParseBarcodeProfile, so that the
+// config can name a profile instead of spelling it out.
+func (p *BarcodeProfile) UnmarshalText(text []byte) error {
+	parsed, err := ParseBarcodeProfile(string(text))
+	if err != nil {
+		return err
+	}
+	*p = parsed
+	return nil
+}
+
+// Rule checks a barcode against the profile, validationx.IsBarcode is
+// expected to run before it.
+func (p BarcodeProfile) Rule() validation.Rule {
+	return validation.By(func(value any) error {
+		value, isNil := validation.Indirect(value)
+		if isNil || validation.IsEmpty(value) {
+			return nil
+		}
+		if p.DigitsOnly {
+			if err := is.Digit.Validate(value); err != nil {
+				return err
+			}
+		}
+		minLen, maxLen := p.MinLen, p.MaxLen
+		if minLen <= 0 {
+			minLen = validationx.MinBarcodeLen
+		}
+		if maxLen <= 0 {
+			maxLen = validationx.MaxBarcodeLen
+		}
+		return validation.Length(minLen, maxLen).Validate(value)
+	})
+}
+
+// BarcodeProfiles are the barcode profiles of the roles, the AITUSA members
+// follow the students.
+type BarcodeProfiles struct {
+	Student BarcodeProfile `yaml:"student"`
+	Staff   BarcodeProfile `yaml:"staff"`
+}
+
+var barcodeProfiles atomic.Pointer[BarcodeProfiles]
+
+func init() {
+	SetBarcodeProfiles(BarcodeProfiles{})
+}
+
+// SetBarcodeProfiles replaces the profiles checked by the factories, it is
+// safe to call while requests are being served.
+func SetBarcodeProfiles(p BarcodeProfiles) {
+	barcodeProfiles.Store(&p)
+}
+
+// barcodeRules are the rules of the barcodes of role.
+func barcodeRules(role roles.Global) []validation.Rule {
+	rules := []validation.Rule{validation.Required, validationx.IsBarcode}
+	profiles := barcodeProfiles.Load()
+	switch {
+	case role.IsStudentLike():
+		rules = append(rules, profiles.Student.Rule())
+	case role.IsStaffLike():
+		rules = append(rules, profiles.Staff.Rule())
+	}
+	return rules
+}
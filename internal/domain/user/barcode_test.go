@@ -0,0 +1,137 @@
+package user_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ARUMANDESU/validation"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/validationx"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/builders"
+)
+
+func TestNewBarcode(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		input   string
+		want    user.Barcode
+		wantErr bool
+	}{
+		{name: "digits", input: "210107", want: "210107"},
+		{name: "uppercase", input: "STU001", want: "STU001"},
+		{name: "lowercase", input: "stu001", want: "STU001"},
+		{name: "mixed case", input: "sTu001", want: "STU001"},
+		{name: "surrounding spaces", input: " stu001 ", want: "STU001"},
+		{name: "letter O among digits", input: "00000O", want: "00000O"},
+		{name: "empty", input: "", wantErr: true},
+		{name: "too short", input: "STU01", wantErr: true},
+		{name: "too long", input: strings.Repeat("A", validationx.MaxBarcodeLen+1), wantErr: true},
+		{name: "null byte", input: "STU001\x00admin", wantErr: true},
+		{name: "trailing null byte", input: "STU001\x00", wantErr: true},
+		{name: "cyrillic look-alike", input: "СТУ001", wantErr: true},
+		{name: "fullwidth digits", input: "ＳＴＵ００１", wantErr: true},
+		{name: "dotless i uppercased to ASCII", input: "stuı01", wantErr: true},
+		{name: "long s uppercased to ASCII", input: "ſtu001", wantErr: true},
+		{name: "overlong encoded slash", input: "STU\xc0\xaf001", wantErr: true},
+		{name: "sql injection", input: "STU001'||pg_sleep(5)||'", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := user.NewBarcode(tt.input)
+			if tt.wantErr {
+				var verrs validation.Errors
+				require.ErrorAs(t, err, &verrs)
+				assert.Contains(t, verrs, "barcode")
+				assert.Empty(t, got)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestNewBarcode_MixedCaseLookupEquivalence(t *testing.T) {
+	t.Parallel()
+
+	lower, err := user.NewBarcode("abc123")
+	require.NoError(t, err)
+	upper, err := user.NewBarcode("ABC123")
+	require.NoError(t, err)
+	mixed, err := user.NewBarcode("aBc123")
+	require.NoError(t, err)
+
+	assert.Equal(t, upper, lower)
+	assert.Equal(t, upper, mixed)
+}
+
+func TestBarcodeProfile_Rule(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		profile user.BarcodeProfile
+		barcode string
+		wantErr bool
+	}{
+		{name: "alphanumeric accepts letters", profile: user.AlphanumericBarcodes, barcode: "STU001"},
+		{name: "alphanumeric accepts the shared max", profile: user.AlphanumericBarcodes, barcode: strings.Repeat("1", validationx.MaxBarcodeLen)},
+		{name: "numeric accepts digits", profile: user.NumericBarcodes, barcode: "210107"},
+		{name: "numeric accepts 10 digits", profile: user.NumericBarcodes, barcode: "2101070001"},
+		{name: "numeric rejects a letter O", profile: user.NumericBarcodes, barcode: "00000O", wantErr: true},
+		{name: "numeric rejects 11 digits", profile: user.NumericBarcodes, barcode: "21010700011", wantErr: true},
+		{name: "custom bounds", profile: user.BarcodeProfile{MinLen: 8}, barcode: "STU0001", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := tt.profile.Rule().Validate(tt.barcode)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestParseBarcodeProfile(t *testing.T) {
+	t.Parallel()
+
+	p, err := user.ParseBarcodeProfile("numeric")
+	require.NoError(t, err)
+	assert.Equal(t, user.NumericBarcodes, p)
+
+	p, err = user.ParseBarcodeProfile("alphanumeric")
+	require.NoError(t, err)
+	assert.Equal(t, user.AlphanumericBarcodes, p)
+
+	_, err = user.ParseBarcodeProfile("hex")
+	assert.Error(t, err)
+}
+
+// The profiles are process wide, the test does not run in parallel.
+func TestFactories_BarcodeProfiles(t *testing.T) {
+	user.SetBarcodeProfiles(user.BarcodeProfiles{Student: user.NumericBarcodes})
+	t.Cleanup(func() { user.SetBarcodeProfiles(user.BarcodeProfiles{}) })
+
+	_, err := user.RegisterStudent(builders.NewStudentBuilder().WithBarcode("STU001").BuildRegisterArgs())
+	var verrs validation.Errors
+	require.ErrorAs(t, err, &verrs, "the students follow the numeric profile")
+	assert.Contains(t, verrs, "barcode")
+
+	staff, err := user.AcceptStaffInvitation(builders.NewStaffBuilder().
+		WithBarcode("stf001").
+		BuildAcceptStaffInvitationArgs(uuid.New()))
+	require.NoError(t, err, "the staff keep the alphanumeric barcodes")
+	assert.Equal(t, user.Barcode("STF001"), staff.User().Barcode(), "the barcode is stored uppercased")
+}
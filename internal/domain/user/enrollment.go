@@ -0,0 +1,191 @@
+package user
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/ARUMANDESU/validation"
+	"github.com/google/uuid"
+
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/i18nx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/validationx"
+)
+
+// EnrollmentStatus is where a student is in their studies. The accounts of
+// the students who left are kept, the status decides what they can do.
+type EnrollmentStatus string
+
+const (
+	Enrolled      EnrollmentStatus = "enrolled"
+	AcademicLeave EnrollmentStatus = "academic_leave"
+	Graduated     EnrollmentStatus = "graduated"
+	Expelled      EnrollmentStatus = "expelled"
+)
+
+const MaxExpelReasonLen = 500
+
+var (
+	ErrIllegalEnrollmentTransition = errorx.NewBusinessRuleViolation().WithKey(i18nx.KeyIllegalEnrollmentTransition)
+	ErrStudentExpelled             = errorx.NewStudentExpelled()
+)
+
+// enrollmentTransitions lists the statuses a student can move to from each
+// status. The graduates and the expelled students stay as they are.
+var enrollmentTransitions = map[EnrollmentStatus][]EnrollmentStatus{
+	Enrolled:      {AcademicLeave, Graduated, Expelled},
+	AcademicLeave: {AcademicLeave, Enrolled, Expelled},
+}
+
+// ParseEnrollmentStatus returns the status named s.
+func ParseEnrollmentStatus(s string) (EnrollmentStatus, error) {
+	const op = "user.ParseEnrollmentStatus"
+	status := EnrollmentStatus(s)
+	switch status {
+	case Enrolled, AcademicLeave, Graduated, Expelled:
+		return status, nil
+	default:
+		return "", errorx.NewInvalidRequest().WithCause(
+			fmt.Errorf("unknown enrollment status %q, the statuses are enrolled, academic_leave, graduated and expelled", s), op)
+	}
+}
+
+func (s EnrollmentStatus) String() string {
+	return string(s)
+}
+
+// TakeLeave puts the student on academic leave until the given time, a
+// student already on leave gets the new end of the leave. by is the staff
+// member making the change, like for the other transitions.
+func (s *Student) TakeLeave(by ID, until time.Time) error {
+	const op = "user.Student.TakeLeave"
+	now := s.user.now()
+	if err := validation.Validate(until, validation.Required, validation.Min(now).ErrorObject(validationx.ErrTimeInPast)); err != nil {
+		return errorx.Wrap(err, op)
+	}
+	until = until.UTC()
+	if s.EnrollmentStatus() == AcademicLeave && s.leaveUntil != nil && s.leaveUntil.Equal(until) {
+		return nil // No change needed
+	}
+	from, err := s.changeEnrollment(AcademicLeave, now)
+	if err != nil {
+		return errorx.Wrap(err, op)
+	}
+	s.leaveUntil = &until
+
+	s.addEnrollmentEvent(by, from, "")
+	return nil
+}
+
+// Return ends the academic leave of the student. Returning an enrolled
+// student changes nothing.
+func (s *Student) Return(by ID) error {
+	const op = "user.Student.Return"
+	if s.EnrollmentStatus() == Enrolled {
+		return nil
+	}
+	from, err := s.changeEnrollment(Enrolled, s.user.now())
+	if err != nil {
+		return errorx.Wrap(err, op)
+	}
+	s.leaveUntil = nil
+
+	s.addEnrollmentEvent(by, from, "")
+	return nil
+}
+
+// Graduate marks an enrolled student as graduated, a student on leave
+// returns first. Graduating a graduate changes nothing.
+func (s *Student) Graduate(by ID) error {
+	const op = "user.Student.Graduate"
+	if s.EnrollmentStatus() == Graduated {
+		return nil
+	}
+	from, err := s.changeEnrollment(Graduated, s.user.now())
+	if err != nil {
+		return errorx.Wrap(err, op)
+	}
+
+	s.addEnrollmentEvent(by, from, "")
+	return nil
+}
+
+// Expel expels the student for reason, the expelled students can not log
+// in. Expelling an expelled student changes nothing.
+func (s *Student) Expel(by ID, reason string) error {
+	const op = "user.Student.Expel"
+	err := validation.Validate(reason, validation.Required, validation.RuneLength(1, MaxExpelReasonLen))
+	if err != nil {
+		return errorx.Wrap(validation.Errors{"reason": err}, op)
+	}
+	if s.EnrollmentStatus() == Expelled {
+		return nil
+	}
+	from, err := s.changeEnrollment(Expelled, s.user.now())
+	if err != nil {
+		return errorx.Wrap(err, op)
+	}
+	s.leaveUntil = nil
+	s.expelReason = reason
+
+	s.addEnrollmentEvent(by, from, reason)
+	return nil
+}
+
+// CanLogIn returns ErrStudentExpelled for an expelled student. The students
+// on leave and the graduates can log in, the clients show their status.
+func (s *Student) CanLogIn() error {
+	if s.EnrollmentStatus() == Expelled {
+		return ErrStudentExpelled
+	}
+	return nil
+}
+
+// changeEnrollment moves the student to status to and returns the status it
+// left.
+func (s *Student) changeEnrollment(to EnrollmentStatus, now time.Time) (EnrollmentStatus, error) {
+	from := s.EnrollmentStatus()
+	if !slices.Contains(enrollmentTransitions[from], to) {
+		return from, fmt.Errorf("student is %s, it can not become %s: %w", from, to, ErrIllegalEnrollmentTransition)
+	}
+	s.enrollment = to
+	s.user.updatedAt = now
+	return from, nil
+}
+
+func (s *Student) addEnrollmentEvent(by ID, from EnrollmentStatus, reason string) {
+	s.Record(&EnrollmentStatusChanged{
+		StudentID:  s.user.id,
+		From:       from,
+		To:         s.enrollment,
+		LeaveUntil: s.leaveUntil,
+		Reason:     reason,
+	}, uuid.UUID(s.user.id), uuid.UUID(by))
+}
+
+// EnrollmentStatus returns the status of the student, the students saved
+// before the statuses existed are enrolled.
+func (s *Student) EnrollmentStatus() EnrollmentStatus {
+	if s == nil || s.enrollment == "" {
+		return Enrolled
+	}
+	return s.enrollment
+}
+
+// LeaveUntil returns the end of the academic leave, nil when the student is
+// not on leave.
+func (s *Student) LeaveUntil() *time.Time {
+	if s == nil {
+		return nil
+	}
+	return s.leaveUntil
+}
+
+// ExpelReason returns why the student was expelled.
+func (s *Student) ExpelReason() string {
+	if s == nil {
+		return ""
+	}
+	return s.expelReason
+}
@@ -0,0 +1,205 @@
+package user_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ARUMANDESU/validation"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/event"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/i18nx"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/builders"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/fixtures"
+)
+
+var enrollmentNow = time.Date(2026, time.March, 2, 9, 0, 0, 0, time.UTC)
+
+func newEnrolledStudent(t *testing.T, status user.EnrollmentStatus) *user.Student {
+	t.Helper()
+	var leaveUntil *time.Time
+	if status == user.AcademicLeave {
+		until := enrollmentNow.AddDate(0, 6, 0)
+		leaveUntil = &until
+	}
+	args := builders.NewStudentBuilder().WithEnrollment(status, leaveUntil, "").RehydrateStudentArgs()
+	args.Clock = clock.NewFake(enrollmentNow)
+	return user.RehydrateStudent(args)
+}
+
+func TestStudent_EnrollmentTransitions(t *testing.T) {
+	t.Parallel()
+
+	until := enrollmentNow.AddDate(0, 6, 0)
+	takeLeave := func(s *user.Student) error { return s.TakeLeave(fixtures.TestStaff.ID, until) }
+	ret := func(s *user.Student) error { return s.Return(fixtures.TestStaff.ID) }
+	graduate := func(s *user.Student) error { return s.Graduate(fixtures.TestStaff.ID) }
+	expel := func(s *user.Student) error { return s.Expel(fixtures.TestStaff.ID, "academic misconduct") }
+
+	tests := []struct {
+		name      string
+		from      user.EnrollmentStatus
+		change    func(*user.Student) error
+		want      user.EnrollmentStatus
+		wantEvent bool
+		wantErr   bool
+	}{
+		{name: "enrolled takes leave", from: user.Enrolled, change: takeLeave, want: user.AcademicLeave, wantEvent: true},
+		{name: "enrolled graduates", from: user.Enrolled, change: graduate, want: user.Graduated, wantEvent: true},
+		{name: "enrolled is expelled", from: user.Enrolled, change: expel, want: user.Expelled, wantEvent: true},
+		{name: "enrolled returns", from: user.Enrolled, change: ret, want: user.Enrolled},
+		{name: "on leave returns", from: user.AcademicLeave, change: ret, want: user.Enrolled, wantEvent: true},
+		{name: "on leave extends the leave", from: user.AcademicLeave, change: func(s *user.Student) error {
+			return s.TakeLeave(fixtures.TestStaff.ID, until.AddDate(0, 1, 0))
+		}, want: user.AcademicLeave, wantEvent: true},
+		{name: "on leave takes the same leave", from: user.AcademicLeave, change: takeLeave, want: user.AcademicLeave},
+		{name: "on leave is expelled", from: user.AcademicLeave, change: expel, want: user.Expelled, wantEvent: true},
+		{name: "on leave graduates", from: user.AcademicLeave, change: graduate, want: user.AcademicLeave, wantErr: true},
+		{name: "graduated takes leave", from: user.Graduated, change: takeLeave, want: user.Graduated, wantErr: true},
+		{name: "graduated returns", from: user.Graduated, change: ret, want: user.Graduated, wantErr: true},
+		{name: "graduated is expelled", from: user.Graduated, change: expel, want: user.Graduated, wantErr: true},
+		{name: "graduated graduates", from: user.Graduated, change: graduate, want: user.Graduated},
+		{name: "expelled returns", from: user.Expelled, change: ret, want: user.Expelled, wantErr: true},
+		{name: "expelled takes leave", from: user.Expelled, change: takeLeave, want: user.Expelled, wantErr: true},
+		{name: "expelled graduates", from: user.Expelled, change: graduate, want: user.Expelled, wantErr: true},
+		{name: "expelled is expelled", from: user.Expelled, change: expel, want: user.Expelled},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			s := newEnrolledStudent(t, tt.from)
+
+			err := tt.change(s)
+			if tt.wantErr {
+				require.ErrorIs(t, err, user.ErrIllegalEnrollmentTransition)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tt.want, s.EnrollmentStatus())
+
+			events := s.GetUncommittedEvents()
+			if !tt.wantEvent {
+				assert.Empty(t, events)
+				return
+			}
+			require.Len(t, events, 1)
+			changed, ok := events[0].(*user.EnrollmentStatusChanged)
+			require.True(t, ok, "got %T", events[0])
+			assert.Equal(t, s.User().ID(), changed.StudentID)
+			assert.Equal(t, tt.from, changed.From)
+			assert.Equal(t, tt.want, changed.To)
+			event.AssertHeader(t, changed, uuid.UUID(s.User().ID()), uuid.UUID(fixtures.TestStaff.ID), 1)
+		})
+	}
+}
+
+func TestStudent_IllegalTransitionIs422(t *testing.T) {
+	t.Parallel()
+
+	s := newEnrolledStudent(t, user.Graduated)
+	err := s.TakeLeave(fixtures.TestStaff.ID, enrollmentNow.AddDate(0, 6, 0))
+
+	var i18nErr *errorx.I18nError
+	require.ErrorAs(t, err, &i18nErr)
+	assert.Equal(t, errorx.CodeBusinessRuleViolation, i18nErr.Code)
+	assert.Equal(t, i18nx.KeyIllegalEnrollmentTransition, i18nErr.MessageKey)
+	assert.Equal(t, 422, i18nErr.HTTPStatusCode())
+}
+
+func TestStudent_TakeLeave(t *testing.T) {
+	t.Parallel()
+
+	t.Run("records the end of the leave", func(t *testing.T) {
+		t.Parallel()
+		s := newEnrolledStudent(t, user.Enrolled)
+		until := enrollmentNow.AddDate(0, 6, 0)
+
+		require.NoError(t, s.TakeLeave(fixtures.TestStaff.ID, until))
+		require.NotNil(t, s.LeaveUntil())
+		assert.Equal(t, until, *s.LeaveUntil())
+		assert.Equal(t, enrollmentNow, s.User().UpdatedAt())
+	})
+
+	t.Run("until in the past", func(t *testing.T) {
+		t.Parallel()
+		s := newEnrolledStudent(t, user.Enrolled)
+
+		err := s.TakeLeave(fixtures.TestStaff.ID, enrollmentNow.Add(-time.Hour))
+		require.Error(t, err)
+		assert.Equal(t, user.Enrolled, s.EnrollmentStatus())
+		assert.Empty(t, s.GetUncommittedEvents())
+	})
+
+	t.Run("return clears the leave", func(t *testing.T) {
+		t.Parallel()
+		s := newEnrolledStudent(t, user.AcademicLeave)
+
+		require.NoError(t, s.Return(fixtures.TestStaff.ID))
+		assert.Nil(t, s.LeaveUntil())
+	})
+}
+
+func TestStudent_Expel(t *testing.T) {
+	t.Parallel()
+
+	t.Run("records the reason", func(t *testing.T) {
+		t.Parallel()
+		s := newEnrolledStudent(t, user.AcademicLeave)
+
+		require.NoError(t, s.Expel(fixtures.TestStaff.ID, "academic misconduct"))
+		assert.Equal(t, "academic misconduct", s.ExpelReason())
+		assert.Nil(t, s.LeaveUntil())
+
+		events := s.GetUncommittedEvents()
+		require.Len(t, events, 1)
+		assert.Equal(t, "academic misconduct", events[0].(*user.EnrollmentStatusChanged).Reason)
+	})
+
+	for name, reason := range map[string]string{
+		"missing reason":  "",
+		"reason too long": strings.Repeat("a", user.MaxExpelReasonLen+1),
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			s := newEnrolledStudent(t, user.Enrolled)
+
+			err := s.Expel(fixtures.TestStaff.ID, reason)
+			var verrs validation.Errors
+			require.ErrorAs(t, err, &verrs)
+			assert.Contains(t, verrs, "reason")
+			assert.Equal(t, user.Enrolled, s.EnrollmentStatus())
+		})
+	}
+}
+
+func TestStudent_CanLogIn(t *testing.T) {
+	t.Parallel()
+
+	for _, status := range []user.EnrollmentStatus{user.Enrolled, user.AcademicLeave, user.Graduated} {
+		assert.NoError(t, newEnrolledStudent(t, status).CanLogIn(), status)
+	}
+
+	err := newEnrolledStudent(t, user.Expelled).CanLogIn()
+	var i18nErr *errorx.I18nError
+	require.ErrorAs(t, err, &i18nErr)
+	assert.Equal(t, errorx.CodeStudentExpelled, i18nErr.Code)
+	assert.Equal(t, 403, i18nErr.HTTPStatusCode())
+}
+
+func TestParseEnrollmentStatus(t *testing.T) {
+	t.Parallel()
+
+	status, err := user.ParseEnrollmentStatus("academic_leave")
+	require.NoError(t, err)
+	assert.Equal(t, user.AcademicLeave, status)
+
+	_, err = user.ParseEnrollmentStatus("dropped")
+	assert.Error(t, err)
+}
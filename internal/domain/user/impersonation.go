@@ -0,0 +1,104 @@
+package user
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/roles"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/sanitizex"
+)
+
+// ImpersonationTTL bounds an impersonation session, its access token is not
+// refreshed.
+const ImpersonationTTL = 15 * time.Minute
+
+// Impersonation is a session of a staff member acting as another user to see
+// what they see, the support debugs with it. The sessions are kept as their
+// audit.
+type Impersonation struct {
+	id         uuid.UUID
+	actorID    ID
+	targetID   ID
+	targetRole roles.Global
+	ip         string
+	startedAt  time.Time
+	expiresAt  time.Time
+}
+
+// NewImpersonation starts the session of actor impersonating target from ip
+// at now. Only the staff impersonate, and never another staff member.
+func NewImpersonation(actor, target *User, ip string, now time.Time) (*Impersonation, error) {
+	const op = "user.NewImpersonation"
+	if actor == nil || target == nil {
+		return nil, errorx.Wrap(errors.New("actor and target are required"), op)
+	}
+	if !actor.Role().IsStaffLike() {
+		return nil, errorx.NewForbidden().WithDetails("only the staff impersonate users").WithOp(op)
+	}
+	if target.Role().IsStaffLike() {
+		return nil, errorx.NewForbidden().WithDetails("staff members can not be impersonated").WithOp(op)
+	}
+
+	return &Impersonation{
+		id:         uuid.New(),
+		actorID:    actor.ID(),
+		targetID:   target.ID(),
+		targetRole: target.Role(),
+		ip:         sanitizex.TruncateRunes(sanitizex.CleanSingleLine(ip), MaxTOSIPLen),
+		startedAt:  now.UTC(),
+		expiresAt:  now.UTC().Add(ImpersonationTTL),
+	}, nil
+}
+
+type RehydrateImpersonationArgs struct {
+	ID         uuid.UUID
+	ActorID    ID
+	TargetID   ID
+	TargetRole roles.Global
+	IP         string
+	StartedAt  time.Time
+	ExpiresAt  time.Time
+}
+
+func RehydrateImpersonation(args RehydrateImpersonationArgs) *Impersonation {
+	return &Impersonation{
+		id:         args.ID,
+		actorID:    args.ActorID,
+		targetID:   args.TargetID,
+		targetRole: args.TargetRole,
+		ip:         args.IP,
+		startedAt:  args.StartedAt,
+		expiresAt:  args.ExpiresAt,
+	}
+}
+
+func (i *Impersonation) ID() uuid.UUID {
+	return i.id
+}
+
+func (i *Impersonation) ActorID() ID {
+	return i.actorID
+}
+
+func (i *Impersonation) TargetID() ID {
+	return i.targetID
+}
+
+func (i *Impersonation) TargetRole() roles.Global {
+	return i.targetRole
+}
+
+func (i *Impersonation) IP() string {
+	return i.ip
+}
+
+func (i *Impersonation) StartedAt() time.Time {
+	return i.startedAt
+}
+
+func (i *Impersonation) ExpiresAt() time.Time {
+	return i.expiresAt
+}
@@ -0,0 +1,149 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ARUMANDESU/validation"
+	"golang.org/x/crypto/bcrypt"
+
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/i18nx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/validationx"
+)
+
+const (
+	MinPasswordLen = 8
+	// MaxPasswordLen is the longest password in bytes, bcrypt does not hash
+	// more.
+	MaxPasswordLen = 72
+	// PasswordHistorySize is how many of the last passwords of a user, the
+	// current one included, can not be used again.
+	PasswordHistorySize = 5
+	// DefaultBreachCheckTimeout bounds a breach check, the password is
+	// accepted unchecked past it.
+	DefaultBreachCheckTimeout = time.Second
+)
+
+var (
+	ErrPasswordReused   = errorx.NewPasswordReused()
+	ErrPasswordBreached = errorx.NewBusinessRuleViolation().WithKey(i18nx.KeyPasswordBreached)
+
+	errPasswordLength = validation.ErrLengthOutOfRange.SetParams(map[string]any{"min": MinPasswordLen, "max": MaxPasswordLen})
+)
+
+// PasswordRules are the format rules of a password, the factories of the
+// aggregates check them. The reuse and breach checks need the history and
+// I/O, they are left to PasswordPolicy.Check.
+var PasswordRules = []validation.Rule{
+	validation.Required,
+	validation.Length(MinPasswordLen, MaxPasswordLen),
+	// Length counts the runes, bcrypt the bytes.
+	validation.By(func(value any) error {
+		if s, ok := value.(string); ok && len(s) > MaxPasswordLen {
+			return errPasswordLength
+		}
+		return nil
+	}),
+	validationx.PasswordFormat,
+}
+
+// BreachChecker reports whether a password is known from a data breach.
+type BreachChecker interface {
+	IsBreached(ctx context.Context, password string) (bool, error)
+}
+
+// NoopBreachChecker knows no breached password, for setups without a breach
+// check.
+type NoopBreachChecker struct{}
+
+func (NoopBreachChecker) IsBreached(context.Context, string) (bool, error) {
+	return false, nil
+}
+
+type PasswordPolicyArgs struct {
+	// BreachChecker defaults to NoopBreachChecker.
+	BreachChecker BreachChecker
+	// BreachCheckTimeout defaults to DefaultBreachCheckTimeout.
+	BreachCheckTimeout time.Duration
+}
+
+// PasswordPolicy decides whether a password can be set: it follows
+// PasswordRules, it is none of the last PasswordHistorySize passwords of the
+// user and it is not known from a data breach.
+//
+// The breach check fails open: when the checker fails or does not answer in
+// time the password is accepted, the checker is an extra and not a
+// dependency of the sign up.
+type PasswordPolicy struct {
+	breaches BreachChecker
+	timeout  time.Duration
+}
+
+func NewPasswordPolicy(p PasswordPolicyArgs) *PasswordPolicy {
+	if p.BreachChecker == nil {
+		p.BreachChecker = NoopBreachChecker{}
+	}
+	if p.BreachCheckTimeout <= 0 {
+		p.BreachCheckTimeout = DefaultBreachCheckTimeout
+	}
+
+	return &PasswordPolicy{
+		breaches: p.BreachChecker,
+		timeout:  p.BreachCheckTimeout,
+	}
+}
+
+// Check returns nil when password can be set by a user whose last password
+// hashes are used, newest first. It returns ErrPasswordReused when password
+// is one of the first PasswordHistorySize of used and ErrPasswordBreached when
+// it is known from a data breach.
+func (p *PasswordPolicy) Check(ctx context.Context, password string, used ...[]byte) error {
+	const op = "user.PasswordPolicy.Check"
+	if p == nil {
+		return errorx.Wrap(errors.New("password policy is nil"), op)
+	}
+	if err := validation.Validate(password, PasswordRules...); err != nil {
+		return errorx.Wrap(err, op)
+	}
+	if isPasswordReused(password, used) {
+		return errorx.Wrap(ErrPasswordReused, op)
+	}
+	if p.isBreached(ctx, password) {
+		return errorx.Wrap(ErrPasswordBreached, op)
+	}
+	return nil
+}
+
+func isPasswordReused(password string, used [][]byte) bool {
+	for i, hash := range used {
+		if i == PasswordHistorySize {
+			break
+		}
+		if bcrypt.CompareHashAndPassword(hash, []byte(password)) == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// isBreached runs the breach check within the timeout of the policy, a
+// checker that ignores the cancellation of ctx is not waited for.
+func (p *PasswordPolicy) isBreached(ctx context.Context, password string) bool {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	verdict := make(chan bool, 1)
+	go func() {
+		breached, err := p.breaches.IsBreached(ctx, password)
+		verdict <- err == nil && breached
+	}()
+
+	select {
+	case breached := <-verdict:
+		return breached
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,94 @@
+package user_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/i18nx"
+)
+
+type breachCheckerFunc func(ctx context.Context, password string) (bool, error)
+
+func (f breachCheckerFunc) IsBreached(ctx context.Context, password string) (bool, error) {
+	return f(ctx, password)
+}
+
+func TestPasswordPolicy_Check(t *testing.T) {
+	breached := breachCheckerFunc(func(_ context.Context, password string) (bool, error) {
+		return password == "Breached@123", nil
+	})
+	policy := user.NewPasswordPolicy(user.PasswordPolicyArgs{BreachChecker: breached})
+	used, err := user.NewPasswordHash("OldP@ssw0rd")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		password string
+		wantCode errorx.Code
+		wantKey  string
+	}{
+		{name: "valid", password: "NewP@ssw0rd"},
+		{name: "weak", password: "password", wantCode: errorx.CodeValidationFailed},
+		{name: "too long", password: "P@ssw0rd" + strings.Repeat("a", user.MaxPasswordLen), wantCode: errorx.CodeValidationFailed},
+		{name: "reused", password: "OldP@ssw0rd", wantCode: errorx.CodePasswordReused, wantKey: i18nx.KeyPasswordReused},
+		{name: "breached", password: "Breached@123", wantCode: errorx.CodeBusinessRuleViolation, wantKey: i18nx.KeyPasswordBreached},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := policy.Check(t.Context(), tt.password, used)
+			if tt.wantCode == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			if tt.wantKey != "" {
+				var i18nErr *errorx.I18nError
+				require.ErrorAs(t, err, &i18nErr)
+				assert.Equal(t, tt.wantCode, i18nErr.Code)
+				assert.Equal(t, tt.wantKey, i18nErr.MessageKey)
+			}
+		})
+	}
+}
+
+func TestPasswordPolicy_Check_BreachCheckerTimesOut(t *testing.T) {
+	unblock := make(chan struct{})
+	t.Cleanup(func() { close(unblock) })
+	// The checker ignores the cancellation, the policy must not wait for it.
+	stuck := breachCheckerFunc(func(context.Context, string) (bool, error) {
+		<-unblock
+		return true, nil
+	})
+	policy := user.NewPasswordPolicy(user.PasswordPolicyArgs{
+		BreachChecker:      stuck,
+		BreachCheckTimeout: 20 * time.Millisecond,
+	})
+
+	done := make(chan error, 1)
+	go func() { done <- policy.Check(t.Context(), "NewP@ssw0rd") }()
+
+	select {
+	case err := <-done:
+		assert.NoError(t, err, "the breach check fails open")
+	case <-time.After(time.Second):
+		t.Fatal("the policy waits for a stuck breach checker")
+	}
+}
+
+func TestPasswordPolicy_Check_BreachCheckerFails(t *testing.T) {
+	failing := breachCheckerFunc(func(context.Context, string) (bool, error) {
+		return true, errors.New("service unavailable")
+	})
+	policy := user.NewPasswordPolicy(user.PasswordPolicyArgs{BreachChecker: failing})
+
+	assert.NoError(t, policy.Check(t.Context(), "NewP@ssw0rd"), "the breach check fails open")
+}
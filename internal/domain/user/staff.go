@@ -1,54 +1,82 @@
 package user
 
 import (
-	"time"
+	"errors"
+	"fmt"
 
 	"github.com/ARUMANDESU/validation"
 	"github.com/ARUMANDESU/validation/is"
 	"github.com/google/uuid"
 
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/event"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/emails"
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/roles"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
 	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
 	"gitlab.com/ucmsv2/ucms-backend/pkg/validationx"
 )
 
+// MaxDepartmentLen and MaxPositionLen bound the optional department and
+// position of a staff member.
+const (
+	MaxDepartmentLen = 100
+	MaxPositionLen   = 100
+)
+
+var (
+	departmentRules = []validation.Rule{validation.RuneLength(0, MaxDepartmentLen)}
+	positionRules   = []validation.Rule{validation.RuneLength(0, MaxPositionLen)}
+)
+
 type Staff struct {
 	event.Recorder
-	user User
+	user       User
+	department string
+	position   string
 }
 
 type AcceptStaffInvitationArgs struct {
-	Barcode      Barcode   `json:"barcode"`
-	Username     string    `json:"username"`
-	Email        string    `json:"email"`
-	Password     string    `json:"password"`
-	FirstName    string    `json:"first_name"`
-	LastName     string    `json:"last_name"`
-	InvitationID uuid.UUID `json:"invitation_id"`
+	Barcode      Barcode      `json:"barcode"`
+	Username     string       `json:"username"`
+	Email        emails.Email `json:"email"`
+	Password     string       `json:"password"`
+	FirstName    string       `json:"first_name"`
+	LastName     string       `json:"last_name"`
+	InvitationID uuid.UUID    `json:"invitation_id"`
+	// Department and Position are optional, the invitation pre-fills them.
+	Department string `json:"department"`
+	Position   string `json:"position"`
+	// TOS is the terms of service the staff member accepted with the
+	// invitation.
+	TOS TOSConsent `json:"-"`
+	// Clock defaults to clock.Real.
+	Clock clock.Clock `json:"-"`
 }
 
 func AcceptStaffInvitation(p AcceptStaffInvitationArgs) (*Staff, error) {
 	const op = "user.AcceptStaffInvitation"
 	err := validation.ValidateStruct(&p,
-		validation.Field(&p.Barcode, validation.Required),
+		validation.Field(&p.Barcode, barcodeRules(roles.Staff)...),
 		validation.Field(&p.Username, validation.Required, validationx.IsUsername),
 		validation.Field(&p.Email, validation.Required, is.EmailFormat),
 		validation.Field(&p.FirstName, validation.Required, validation.Length(MinFirstNameLen, MaxFirstNameLen)),
 		validation.Field(&p.LastName, validation.Required, validation.Length(MinLastNameLen, MaxLastNameLen)),
-		validation.Field(&p.Password, validationx.PasswordRules...),
+		validation.Field(&p.Password, PasswordRules...),
 		validation.Field(&p.InvitationID, validationx.Required, is.UUID),
+		validation.Field(&p.Department, departmentRules...),
+		validation.Field(&p.Position, positionRules...),
 	)
 	if err != nil {
 		return nil, errorx.Wrap(err, op)
 	}
+	p.Barcode = p.Barcode.normalized()
 
 	passhash, err := NewPasswordHash(p.Password)
 	if err != nil {
 		return nil, errorx.Wrap(err, op)
 	}
 
-	now := time.Now().UTC()
+	now := clock.Or(p.Clock).Now().UTC()
 
 	staff := &Staff{
 		user: User{
@@ -60,13 +88,18 @@ func AcceptStaffInvitation(p AcceptStaffInvitationArgs) (*Staff, error) {
 			role:      roles.Staff,
 			email:     p.Email,
 			passHash:  passhash,
-			createdAt: now,
-			updatedAt: now,
+			tos:       p.TOS.acceptance(now),
+			// The invitation was mailed to the email.
+			emailVerified: true,
+			createdAt:     now,
+			updatedAt:     now,
+			clock:         p.Clock,
 		},
+		department: p.Department,
+		position:   p.Position,
 	}
 
-	staff.AddEvent(&StaffInvitationAccepted{
-		Header:        event.NewEventHeader(),
+	staff.Record(&StaffInvitationAccepted{
 		StaffID:       staff.user.id,
 		StaffBarcode:  p.Barcode,
 		StaffUsername: p.Username,
@@ -74,40 +107,126 @@ func AcceptStaffInvitation(p AcceptStaffInvitationArgs) (*Staff, error) {
 		LastName:      p.LastName,
 		Email:         p.Email,
 		InvitationID:  p.InvitationID,
-	})
+		Department:    p.Department,
+		Position:      p.Position,
+	}, uuid.UUID(staff.user.id), uuid.UUID(staff.user.id))
+
+	return staff, nil
+}
+
+type LinkStaffAccountArgs struct {
+	// Password is the current password of the student, the invitee proves
+	// with it that the account is theirs.
+	Password     string    `json:"-"`
+	InvitationID uuid.UUID `json:"invitation_id"`
+	// Department and Position are optional, the invitation pre-fills them.
+	Department string `json:"department"`
+	Position   string `json:"position"`
+	// TOS is the terms of service the staff member accepted with the
+	// invitation, the earlier acceptance is kept without one.
+	TOS TOSConsent `json:"-"`
+}
+
+// LinkStaffAccount accepts a staff invitation with the existing account of a
+// student instead of a new one. The user is granted the staff role beside
+// the student one, the barcode, the history and the student record are
+// kept, see RoleGranted.
+func LinkStaffAccount(u *User, p LinkStaffAccountArgs) (*Staff, error) {
+	const op = "user.LinkStaffAccount"
+	if u == nil {
+		return nil, errorx.Wrap(errors.New("user is nil"), op)
+	}
+	err := validation.ValidateStruct(&p,
+		validation.Field(&p.Password, validation.Required),
+		validation.Field(&p.InvitationID, validationx.Required, is.UUID),
+		validation.Field(&p.Department, departmentRules...),
+		validation.Field(&p.Position, positionRules...),
+	)
+	if err != nil {
+		return nil, errorx.Wrap(err, op)
+	}
+	if u.Roles().IsStaffLike() {
+		return nil, errorx.NewConflict().WithCause(
+			fmt.Errorf("user has role %s, they are staff already", u.role), op)
+	}
+	if !u.Roles().IsStudentLike() {
+		return nil, errorx.NewConflict().WithCause(
+			fmt.Errorf("only students can link their account, user has role %s", u.role), op)
+	}
+	if err := u.ComparePassword(p.Password); err != nil {
+		return nil, errorx.NewInvalidCredentials().WithCause(err, op)
+	}
+
+	u.grantRole(roles.Staff)
+	if p.TOS.Version != "" {
+		u.tos = p.TOS.acceptance(u.now())
+	}
+	// The invitation was mailed to the email.
+	u.emailVerified = true
+
+	staff := &Staff{
+		user:       *u,
+		department: p.Department,
+		position:   p.Position,
+	}
+	// The staff member continues the version of the user, they share the
+	// aggregate id.
+	staff.SetVersion(u.Version())
+
+	staff.Record(&StaffInvitationAccepted{
+		StaffID:       u.id,
+		StaffBarcode:  u.barcode,
+		StaffUsername: u.username,
+		FirstName:     u.firstName,
+		LastName:      u.lastName,
+		Email:         u.email,
+		InvitationID:  p.InvitationID,
+		Department:    p.Department,
+		Position:      p.Position,
+		Linked:        true,
+	}, uuid.UUID(u.id), uuid.UUID(u.id))
+	staff.Record(&RoleGranted{
+		UserID:       u.id,
+		Role:         roles.Staff,
+		Roles:        u.Roles(),
+		InvitationID: p.InvitationID,
+	}, uuid.UUID(u.id), uuid.UUID(u.id))
 
 	return staff, nil
 }
 
 type CreateInitialStaffArgs struct {
-	Email     string  `json:"email"`
-	Password  string  `json:"password"`
-	Barcode   Barcode `json:"barcode"`
-	Username  string  `json:"username"`
-	FirstName string  `json:"first_name"`
-	LastName  string  `json:"last_name"`
+	Email     emails.Email `json:"email"`
+	Password  string       `json:"password"`
+	Barcode   Barcode      `json:"barcode"`
+	Username  string       `json:"username"`
+	FirstName string       `json:"first_name"`
+	LastName  string       `json:"last_name"`
+	// Clock defaults to clock.Real.
+	Clock clock.Clock `json:"-"`
 }
 
 func CreateInitialStaff(p CreateInitialStaffArgs) (*Staff, error) {
 	const op = "user.CreateInitialStaff"
 	err := validation.ValidateStruct(&p,
-		validation.Field(&p.Barcode, validation.Required),
+		validation.Field(&p.Barcode, barcodeRules(roles.Staff)...),
 		validation.Field(&p.Username, validation.Required, validationx.IsUsername),
 		validation.Field(&p.Email, validation.Required, is.EmailFormat),
 		validation.Field(&p.FirstName, validation.Required, validation.Length(MinFirstNameLen, MaxFirstNameLen)),
 		validation.Field(&p.LastName, validation.Required, validation.Length(MinLastNameLen, MaxLastNameLen)),
-		validation.Field(&p.Password, validationx.PasswordRules...),
+		validation.Field(&p.Password, PasswordRules...),
 	)
 	if err != nil {
 		return nil, errorx.Wrap(err, op)
 	}
+	p.Barcode = p.Barcode.normalized()
 
 	passhash, err := NewPasswordHash(p.Password)
 	if err != nil {
 		return nil, errorx.Wrap(err, op)
 	}
 
-	now := time.Now().UTC()
+	now := clock.Or(p.Clock).Now().UTC()
 
 	staff := &Staff{
 		user: User{
@@ -119,32 +238,92 @@ func CreateInitialStaff(p CreateInitialStaffArgs) (*Staff, error) {
 			role:      roles.Staff,
 			email:     p.Email,
 			passHash:  passhash,
-			createdAt: now,
-			updatedAt: now,
+			// The email comes from the configuration, it is verified on
+			// the first login, see User.RequestEmailVerification.
+			emailVerified: false,
+			createdAt:     now,
+			updatedAt:     now,
+			clock:         p.Clock,
 		},
 	}
 
-	staff.AddEvent(&InitialStaffCreated{
-		Header:        event.NewEventHeader(),
+	staff.Record(&InitialStaffCreated{
 		StaffID:       staff.user.id,
 		StaffBarcode:  p.Barcode,
 		StaffUsername: p.Username,
 		FirstName:     p.FirstName,
 		LastName:      p.LastName,
 		Email:         p.Email,
-	})
+	}, uuid.UUID(staff.user.id), uuid.Nil)
 
 	return staff, nil
 }
 
+// BootstrapAction is what bootstrapping the initial staff did at startup,
+// the changes are recorded in the staff_bootstrap_audit table.
+type BootstrapAction string
+
+const (
+	// BootstrapSkipped left everything as is: the initial staff exists and
+	// its password is not rotated, or other staff exist already.
+	BootstrapSkipped         BootstrapAction = "skipped"
+	BootstrapCreated         BootstrapAction = "created"
+	BootstrapPasswordRotated BootstrapAction = "password_rotated"
+)
+
 type RehydrateStaffArgs struct {
 	RehydrateUserArgs
+	Department string
+	Position   string
 }
 
 func RehydrateStaff(p RehydrateStaffArgs) *Staff {
 	return &Staff{
-		user: *RehydrateUser(p.RehydrateUserArgs),
+		user:       *RehydrateUser(p.RehydrateUserArgs),
+		department: p.Department,
+		position:   p.Position,
+	}
+}
+
+// UpdateDepartmentAndPosition replaces the department and the position, an
+// empty one clears it.
+func (s *Staff) UpdateDepartmentAndPosition(department, position string) error {
+	const op = "user.Staff.UpdateDepartmentAndPosition"
+	err := validation.Errors{
+		"department": validation.Validate(department, departmentRules...),
+		"position":   validation.Validate(position, positionRules...),
+	}.Filter()
+	if err != nil {
+		return errorx.Wrap(err, op)
+	}
+	if s.department == department && s.position == position {
+		return nil // No change needed
+	}
+
+	s.department = department
+	s.position = position
+	s.user.updatedAt = s.user.now()
+
+	s.Record(&StaffDepartmentAndPositionUpdated{
+		StaffID:    s.user.id,
+		Department: department,
+		Position:   position,
+	}, uuid.UUID(s.user.id), uuid.UUID(s.user.id))
+	return nil
+}
+
+func (s *Staff) Department() string {
+	if s == nil {
+		return ""
+	}
+	return s.department
+}
+
+func (s *Staff) Position() string {
+	if s == nil {
+		return ""
 	}
+	return s.position
 }
 
 func (s *Staff) User() *User {
@@ -3,10 +3,13 @@ package user
 import (
 	"testing"
 
+	"github.com/ARUMANDESU/validation"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/event"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/emails"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/validationx"
 )
 
 const StaffEventStreamName = "events_staff"
@@ -19,14 +22,27 @@ type StaffInvitationAccepted struct {
 	StaffUsername string
 	FirstName     string
 	LastName      string
-	Email         string
+	Email         emails.Email
 	InvitationID  uuid.UUID
+	Department    string
+	Position      string
+	// Linked is set when the invitation was accepted with the account of a
+	// student, see LinkStaffAccount.
+	Linked bool
 }
 
 func (e *StaffInvitationAccepted) GetStreamName() string {
 	return StaffEventStreamName
 }
 
+func (e *StaffInvitationAccepted) Validate() error {
+	return validation.ValidateStruct(e,
+		validation.Field(&e.StaffID, validationx.Required),
+		validation.Field(&e.InvitationID, validationx.Required),
+		validation.Field(&e.Email, validation.Required),
+	)
+}
+
 type InitialStaffCreated struct {
 	event.Header
 	event.Otel
@@ -35,13 +51,25 @@ type InitialStaffCreated struct {
 	StaffUsername string
 	FirstName     string
 	LastName      string
-	Email         string
+	Email         emails.Email
 }
 
 func (e *InitialStaffCreated) GetStreamName() string {
 	return StaffEventStreamName
 }
 
+type StaffDepartmentAndPositionUpdated struct {
+	event.Header
+	event.Otel
+	StaffID    ID
+	Department string
+	Position   string
+}
+
+func (e *StaffDepartmentAndPositionUpdated) GetStreamName() string {
+	return StaffEventStreamName
+}
+
 type StaffInvitationAcceptedAssertion struct {
 	e *StaffInvitationAccepted
 	t *testing.T
@@ -83,7 +111,7 @@ func (a *StaffInvitationAcceptedAssertion) AssertLastName(expected string) *Staf
 
 func (a *StaffInvitationAcceptedAssertion) AssertEmail(expected string) *StaffInvitationAcceptedAssertion {
 	a.t.Helper()
-	assert.Equal(a.t, expected, a.e.Email, "Email should match")
+	assert.Equal(a.t, expected, a.e.Email.String(), "Email should match")
 	return a
 }
 
@@ -1,16 +1,23 @@
 package user_test
 
 import (
+	"slices"
+	"strings"
 	"testing"
 
 	"github.com/ARUMANDESU/validation"
 	"github.com/ARUMANDESU/validation/is"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/event"
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/roles"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
 	"gitlab.com/ucmsv2/ucms-backend/pkg/validationx"
 	"gitlab.com/ucmsv2/ucms-backend/tests/integration/builders"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/fixtures"
 )
 
 func TestAcceptStaffInvitation_ArgValidation(t *testing.T) {
@@ -256,3 +263,123 @@ func TestCreateInitialStaff_EmptyArgs(t *testing.T) {
 	})
 	assert.Nil(t, staff, "expected staff to be nil on error")
 }
+
+func TestStaff_UpdateDepartmentAndPosition(t *testing.T) {
+	t.Run("records the change", func(t *testing.T) {
+		s := builders.NewStaffBuilder().Build()
+
+		require.NoError(t, s.UpdateDepartmentAndPosition("Registrar's Office", "Coordinator"))
+		assert.Equal(t, "Registrar's Office", s.Department())
+		assert.Equal(t, "Coordinator", s.Position())
+
+		events := s.GetUncommittedEvents()
+		require.Len(t, events, 1)
+		updated, ok := events[0].(*user.StaffDepartmentAndPositionUpdated)
+		require.True(t, ok, "got %T", events[0])
+		assert.Equal(t, s.User().ID(), updated.StaffID)
+		assert.Equal(t, "Registrar's Office", updated.Department)
+		assert.Equal(t, "Coordinator", updated.Position)
+	})
+
+	t.Run("unchanged values record nothing", func(t *testing.T) {
+		s := builders.NewStaffBuilder().Build()
+
+		require.NoError(t, s.UpdateDepartmentAndPosition("", ""))
+		assert.Empty(t, s.GetUncommittedEvents())
+	})
+
+	t.Run("too long", func(t *testing.T) {
+		s := builders.NewStaffBuilder().Build()
+		long := strings.Repeat("a", user.MaxDepartmentLen+1)
+
+		err := s.UpdateDepartmentAndPosition(long, long)
+		var verrs validation.Errors
+		require.ErrorAs(t, err, &verrs)
+		assert.Contains(t, verrs, "department")
+		assert.Contains(t, verrs, "position")
+		assert.Empty(t, s.Department())
+		assert.Empty(t, s.GetUncommittedEvents())
+	})
+}
+
+func TestLinkStaffAccount(t *testing.T) {
+	args := func() user.LinkStaffAccountArgs {
+		return user.LinkStaffAccountArgs{
+			Password:     fixtures.TestStudent.Password,
+			InvitationID: uuid.New(),
+			Department:   "Registrar",
+			Position:     "Assistant",
+		}
+	}
+
+	t.Run("grants staff beside the student role", func(t *testing.T) {
+		u := builders.NewUserBuilder().AsStudent().Build()
+		a := args()
+
+		staff, err := user.LinkStaffAccount(u, a)
+		require.NoError(t, err)
+
+		assert.Equal(t, u.ID(), staff.User().ID())
+		assert.Equal(t, u.Barcode(), staff.User().Barcode())
+		assert.Equal(t, roles.Staff, staff.User().Role())
+		assert.Equal(t, roles.NewSet(roles.Student, roles.Staff), staff.User().Roles())
+		assert.Equal(t, "Registrar", staff.Department())
+		assert.Equal(t, "Assistant", staff.Position())
+		event.AssertEvents(t, staff.GetUncommittedEvents(),
+			event.OfType(func(e *user.StaffInvitationAccepted) bool {
+				return e.StaffID == u.ID() && e.InvitationID == a.InvitationID && e.Linked
+			}),
+			event.OfType(func(e *user.RoleGranted) bool {
+				return e.UserID == u.ID() && e.Role == roles.Staff &&
+					slices.Equal(roles.NewSet(roles.Student, roles.Staff), e.Roles)
+			}),
+		)
+	})
+
+	t.Run("continues the version of the user", func(t *testing.T) {
+		u := builders.NewUserBuilder().AsStudent().Build()
+		u.SetVersion(3) // read back from a repository
+
+		staff, err := user.LinkStaffAccount(u, args())
+		require.NoError(t, err)
+
+		events := staff.GetUncommittedEvents()
+		require.Len(t, events, 2)
+		assert.Equal(t, 4, events[0].GetEventHeader().AggregateVersion)
+		assert.Equal(t, 5, events[1].GetEventHeader().AggregateVersion)
+	})
+
+	t.Run("keeps the aitusa role", func(t *testing.T) {
+		u := builders.NewUserBuilder().AsAITUSA().Build()
+
+		staff, err := user.LinkStaffAccount(u, args())
+		require.NoError(t, err)
+		assert.True(t, staff.User().Roles().CanAnnounce())
+		assert.True(t, staff.User().Roles().IsStaffLike())
+	})
+
+	t.Run("wrong password", func(t *testing.T) {
+		u := builders.NewUserBuilder().AsStudent().Build()
+		a := args()
+		a.Password = "wrong" + a.Password
+
+		_, err := user.LinkStaffAccount(u, a)
+		assert.True(t, errorx.IsCode(err, errorx.CodeInvalidCredentials), "unexpected error: %v", err)
+		assert.Equal(t, roles.Student, u.Role(), "the role is kept")
+		assert.Equal(t, roles.NewSet(roles.Student), u.Roles())
+	})
+
+	t.Run("not a student", func(t *testing.T) {
+		for _, role := range []roles.Global{roles.Guest, roles.Staff} {
+			u := builders.NewUserBuilder().WithRole(role).Build()
+
+			_, err := user.LinkStaffAccount(u, args())
+			assert.True(t, errorx.IsCode(err, errorx.CodeConflict), "%s: unexpected error: %v", role, err)
+		}
+	})
+
+	t.Run("nil user", func(t *testing.T) {
+		_, err := user.LinkStaffAccount(nil, args())
+		assert.ErrorContains(t, err, "user is nil")
+	})
+}
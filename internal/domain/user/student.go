@@ -5,19 +5,25 @@ import (
 
 	"github.com/ARUMANDESU/validation"
 	"github.com/ARUMANDESU/validation/is"
+	"github.com/google/uuid"
 
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/event"
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/group"
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/registration"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/emails"
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/roles"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
 	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
 	"gitlab.com/ucmsv2/ucms-backend/pkg/validationx"
 )
 
 type Student struct {
 	event.Recorder
-	user    User
-	groupID group.ID
+	user        User
+	groupID     group.ID
+	enrollment  EnrollmentStatus
+	leaveUntil  *time.Time
+	expelReason string
 }
 
 type RegisterStudentArgs struct {
@@ -26,33 +32,38 @@ type RegisterStudentArgs struct {
 	RegistrationID registration.ID `json:"registration_id"`
 	FirstName      string          `json:"first_name"`
 	LastName       string          `json:"last_name"`
-	Email          string          `json:"email"`
+	Email          emails.Email    `json:"email"`
 	Password       string          `json:"password"`
 	GroupID        group.ID        `json:"group_id"`
+	// TOS is the terms of service the student accepted to register.
+	TOS TOSConsent `json:"-"`
+	// Clock defaults to clock.Real.
+	Clock clock.Clock `json:"-"`
 }
 
 func RegisterStudent(p RegisterStudentArgs) (*Student, error) {
 	const op = "user.RegisterStudent"
 	err := validation.ValidateStruct(&p,
 		validation.Field(&p.Username, validation.Required, validationx.IsUsername),
-		validation.Field(&p.Barcode, validation.Required, validation.Length(6, 20), is.Alphanumeric),
+		validation.Field(&p.Barcode, barcodeRules(roles.Student)...),
 		validation.Field(&p.RegistrationID, validationx.Required),
 		validation.Field(&p.Email, validation.Required, is.EmailFormat),
 		validation.Field(&p.FirstName, validation.Required, validation.Length(MinFirstNameLen, MaxFirstNameLen)),
 		validation.Field(&p.LastName, validation.Required, validation.Length(MinLastNameLen, MaxLastNameLen)),
-		validation.Field(&p.Password, validationx.PasswordRules...),
+		validation.Field(&p.Password, PasswordRules...),
 		validation.Field(&p.GroupID, validationx.Required),
 	)
 	if err != nil {
 		return nil, errorx.Wrap(err, op)
 	}
+	p.Barcode = p.Barcode.normalized()
 
 	passhash, err := NewPasswordHash(p.Password)
 	if err != nil {
 		return nil, errorx.Wrap(err, op)
 	}
 
-	now := time.Now().UTC()
+	now := clock.Or(p.Clock).Now().UTC()
 
 	student := &Student{
 		user: User{
@@ -64,14 +75,18 @@ func RegisterStudent(p RegisterStudentArgs) (*Student, error) {
 			role:      roles.Student,
 			email:     p.Email,
 			passHash:  passhash,
-			createdAt: now,
-			updatedAt: now,
+			tos:       p.TOS.acceptance(now),
+			// The registration verified the email.
+			emailVerified: true,
+			createdAt:     now,
+			updatedAt:     now,
+			clock:         p.Clock,
 		},
-		groupID: p.GroupID,
+		groupID:    p.GroupID,
+		enrollment: Enrolled,
 	}
 
-	student.AddEvent(&StudentRegistered{
-		Header:          event.NewEventHeader(),
+	student.Record(&StudentRegistered{
 		StudentID:       student.user.id,
 		StudentBarcode:  p.Barcode,
 		StudentUsername: p.Username,
@@ -80,7 +95,7 @@ func RegisterStudent(p RegisterStudentArgs) (*Student, error) {
 		FirstName:       p.FirstName,
 		LastName:        p.LastName,
 		GroupID:         p.GroupID,
-	})
+	}, uuid.UUID(student.user.id), uuid.UUID(student.user.id))
 
 	return student, nil
 }
@@ -88,12 +103,23 @@ func RegisterStudent(p RegisterStudentArgs) (*Student, error) {
 type RehydrateStudentArgs struct {
 	RehydrateUserArgs
 	GroupID group.ID
+	// EnrollmentStatus defaults to Enrolled.
+	EnrollmentStatus EnrollmentStatus
+	LeaveUntil       *time.Time
+	ExpelReason      string
 }
 
 func RehydrateStudent(p RehydrateStudentArgs) *Student {
+	if p.EnrollmentStatus == "" {
+		p.EnrollmentStatus = Enrolled
+	}
+
 	return &Student{
-		user:    *RehydrateUser(p.RehydrateUserArgs),
-		groupID: p.GroupID,
+		user:        *RehydrateUser(p.RehydrateUserArgs),
+		groupID:     p.GroupID,
+		enrollment:  p.EnrollmentStatus,
+		leaveUntil:  p.LeaveUntil,
+		expelReason: p.ExpelReason,
 	}
 }
 
@@ -108,6 +134,29 @@ func (s *Student) SetGroupID(groupID group.ID) error {
 	return nil
 }
 
+// TransferToGroup moves the student to groupID, by is the staff member making
+// the change. Transferring a student to its group changes nothing.
+func (s *Student) TransferToGroup(by ID, groupID group.ID) error {
+	const op = "user.Student.TransferToGroup"
+	if err := validation.Validate(groupID, validationx.Required); err != nil {
+		return errorx.Wrap(validation.Errors{"group_id": err}, op)
+	}
+	if s.groupID == groupID {
+		return nil // No change needed
+	}
+
+	from := s.groupID
+	s.groupID = groupID
+	s.user.updatedAt = s.user.now()
+
+	s.Record(&StudentGroupChanged{
+		StudentID: s.user.id,
+		From:      from,
+		To:        groupID,
+	}, uuid.UUID(s.user.id), uuid.UUID(by))
+	return nil
+}
+
 func (s *Student) User() *User {
 	if s == nil {
 		return nil
@@ -1,9 +1,15 @@
 package user
 
 import (
+	"time"
+
+	"github.com/ARUMANDESU/validation"
+
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/event"
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/group"
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/registration"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/emails"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/validationx"
 )
 
 const (
@@ -17,7 +23,7 @@ type StudentRegistered struct {
 	StudentBarcode  Barcode
 	StudentUsername string
 	RegistrationID  registration.ID
-	Email           string
+	Email           emails.Email
 	FirstName       string
 	LastName        string
 	GroupID         group.ID
@@ -26,3 +32,52 @@ type StudentRegistered struct {
 func (e *StudentRegistered) GetStreamName() string {
 	return StudentEventStreamName
 }
+
+// Validate reports the fields the mail and registration handlers need, a
+// missing group must not become the zero group of the student.
+func (e *StudentRegistered) Validate() error {
+	return validation.ValidateStruct(e,
+		validation.Field(&e.StudentID, validationx.Required),
+		validation.Field(&e.RegistrationID, validationx.Required),
+		validation.Field(&e.GroupID, validationx.Required),
+		validation.Field(&e.Email, validation.Required),
+	)
+}
+
+// EnrollmentStatusChanged is recorded when a student takes a leave, returns,
+// graduates or is expelled. LeaveUntil is set for a leave and Reason for an
+// expulsion.
+type EnrollmentStatusChanged struct {
+	event.Header
+	event.Otel
+	StudentID  ID
+	From       EnrollmentStatus
+	To         EnrollmentStatus
+	LeaveUntil *time.Time
+	Reason     string
+}
+
+func (e *EnrollmentStatusChanged) GetStreamName() string {
+	return StudentEventStreamName
+}
+
+// StudentGroupChanged is recorded when a staff member transfers a student to
+// another group.
+type StudentGroupChanged struct {
+	event.Header
+	event.Otel
+	StudentID ID
+	From      group.ID
+	To        group.ID
+}
+
+func (e *StudentGroupChanged) GetStreamName() string {
+	return StudentEventStreamName
+}
+
+func (e *StudentGroupChanged) Validate() error {
+	return validation.ValidateStruct(e,
+		validation.Field(&e.StudentID, validationx.Required),
+		validation.Field(&e.To, validationx.Required),
+	)
+}
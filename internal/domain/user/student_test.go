@@ -9,11 +9,13 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/event"
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/group"
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/registration"
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
 	"gitlab.com/ucmsv2/ucms-backend/pkg/validationx"
 	"gitlab.com/ucmsv2/ucms-backend/tests/integration/builders"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/fixtures"
 )
 
 func TestRegisterStudent_ArgValidation(t *testing.T) {
@@ -196,3 +198,40 @@ func TestStudent_SetGroupID(t *testing.T) {
 		})
 	}
 }
+
+func TestStudent_TransferToGroup(t *testing.T) {
+	t.Parallel()
+
+	t.Run("records the change", func(t *testing.T) {
+		t.Parallel()
+		student := builders.NewStudentBuilder().Build()
+		from, to := student.GroupID(), group.NewID()
+
+		require.NoError(t, student.TransferToGroup(fixtures.TestStaff.ID, to))
+		assert.Equal(t, to, student.GroupID())
+
+		e := event.AssertSingleEvent[*user.StudentGroupChanged](t, student.GetUncommittedEvents())
+		assert.Equal(t, student.User().ID(), e.StudentID)
+		assert.Equal(t, from, e.From)
+		assert.Equal(t, to, e.To)
+		event.AssertHeader(t, e, uuid.UUID(student.User().ID()), uuid.UUID(fixtures.TestStaff.ID), 1)
+	})
+
+	t.Run("same group", func(t *testing.T) {
+		t.Parallel()
+		student := builders.NewStudentBuilder().Build()
+
+		require.NoError(t, student.TransferToGroup(fixtures.TestStaff.ID, student.GroupID()))
+		event.AssertNoEvents(t, student.GetUncommittedEvents())
+	})
+
+	t.Run("no group", func(t *testing.T) {
+		t.Parallel()
+		student := builders.NewStudentBuilder().Build()
+
+		err := student.TransferToGroup(fixtures.TestStaff.ID, group.ID{})
+		var verrs validation.Errors
+		require.ErrorAs(t, err, &verrs)
+		assert.Contains(t, verrs, "group_id")
+	})
+}
@@ -0,0 +1,89 @@
+package user
+
+import (
+	"errors"
+	"time"
+
+	"github.com/ARUMANDESU/validation"
+	"github.com/google/uuid"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/event"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/sanitizex"
+)
+
+// MaxTOSIPLen fits an IPv6 address with its zone.
+const MaxTOSIPLen = 64
+
+// TOSAcceptance is the version of the terms of service the user accepted
+// last, when and from where. Legal keeps it as the proof of the consent.
+type TOSAcceptance struct {
+	Version    string
+	AcceptedAt time.Time
+	IP         string
+}
+
+func (a TOSAcceptance) IsZero() bool {
+	return a.Version == ""
+}
+
+// TOSConsent is the consent given while creating the account, an empty
+// Version means no terms are published yet.
+type TOSConsent struct {
+	Version string
+	IP      string
+}
+
+func (c TOSConsent) acceptance(now time.Time) TOSAcceptance {
+	if c.Version == "" {
+		return TOSAcceptance{}
+	}
+	return TOSAcceptance{
+		Version:    c.Version,
+		AcceptedAt: now,
+		IP:         sanitizex.TruncateRunes(sanitizex.CleanSingleLine(c.IP), MaxTOSIPLen),
+	}
+}
+
+// AcceptTOS records that the user accepted the version of the terms of
+// service from ip.
+func (u *User) AcceptTOS(version, ip string) error {
+	const op = "user.User.AcceptTOS"
+	if u == nil {
+		return errorx.Wrap(errors.New("user is nil"), op)
+	}
+	if err := validation.Validate(version, validation.Required); err != nil {
+		return errorx.Wrap(err, op)
+	}
+
+	u.updatedAt = u.now()
+	u.tos = TOSConsent{Version: version, IP: ip}.acceptance(u.updatedAt)
+
+	u.Record(&UserTOSAccepted{
+		UserID:  u.id,
+		Version: u.tos.Version,
+		IP:      u.tos.IP,
+	}, uuid.UUID(u.id), uuid.UUID(u.id))
+	return nil
+}
+
+// TOS returns the last terms of service acceptance of the user, zero when
+// they accepted none.
+func (u *User) TOS() TOSAcceptance {
+	if u == nil {
+		return TOSAcceptance{}
+	}
+	return u.tos
+}
+
+type UserTOSAccepted struct {
+	event.Header
+	event.Otel
+	UserID  ID     `json:"user_id"`
+	Version string `json:"version"`
+	IP      string `json:"ip"`
+}
+
+func (e *UserTOSAccepted) GetStreamName() string {
+	return UserEventStreamName
+}
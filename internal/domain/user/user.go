@@ -1,9 +1,11 @@
 package user
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"slices"
 	"time"
 
 	"github.com/ARUMANDESU/validation"
@@ -12,7 +14,9 @@ import (
 
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/event"
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/avatars"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/emails"
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/roles"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
 	"gitlab.com/ucmsv2/ucms-backend/pkg/env"
 	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
 	"gitlab.com/ucmsv2/ucms-backend/pkg/sanitizex"
@@ -28,9 +32,10 @@ const (
 	MinFirstNameLen   = 2
 	MaxLastNameLen    = 100
 	MinLastNameLen    = 2
-	MaxBarcodeLen     = 100
-	MinBarcodeLen     = 6
 	MaxAvatarS3KeyLen = 255
+	// MaxAvatarFilenameLen bounds the client supplied filename kept in the
+	// rejection events.
+	MaxAvatarFilenameLen = 255
 )
 
 type ID uuid.UUID
@@ -62,15 +67,6 @@ func (id *ID) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
-type Barcode string
-
-func (barcode Barcode) String() string {
-	if barcode == "" {
-		return ""
-	}
-	return string(barcode)
-}
-
 type User struct {
 	event.Recorder
 	id        ID
@@ -80,10 +76,24 @@ type User struct {
 	lastName  string
 	avatar    avatars.Avatar
 	role      roles.Global
-	email     string
-	passHash  []byte
-	createdAt time.Time
-	updatedAt time.Time
+	// otherRoles are the roles held beside role, e.g. the student role of a
+	// linked staff member, see LinkStaffAccount.
+	otherRoles roles.Set
+	email      emails.Email
+	passHash   []byte
+	// passHistory holds the hashes of the previous passwords, newest first,
+	// see PasswordHistorySize.
+	passHistory [][]byte
+	tos         TOSAcceptance
+	// emailVerification is the pending code, see RequestEmailVerification.
+	emailVerified     bool
+	emailVerification *EmailVerification
+	// lastSeenAt is the time of an authenticated request of the user, zero
+	// until the first one, see lastseen.Tracker.
+	lastSeenAt time.Time
+	createdAt  time.Time
+	updatedAt  time.Time
+	clock      clock.Clock
 }
 
 type RehydrateUserArgs struct {
@@ -93,29 +103,53 @@ type RehydrateUserArgs struct {
 	FirstName string
 	LastName  string
 	Role      roles.Global
-	Avatar    avatars.Avatar
-	Email     string
-	PassHash  []byte
-	CreatedAt time.Time
-	UpdatedAt time.Time
+	// OtherRoles are the roles held beside Role.
+	OtherRoles roles.Set
+	Avatar     avatars.Avatar
+	Email      emails.Email
+	PassHash   []byte
+	// PassHistory holds the hashes of the previous passwords, newest first.
+	PassHistory [][]byte
+	TOS         TOSAcceptance
+	// EmailVerification is the pending code, only loaded where it is
+	// verified.
+	EmailVerified     bool
+	EmailVerification *EmailVerification
+	// LastSeenAt is zero for the users never seen.
+	LastSeenAt time.Time
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	// Clock defaults to clock.Real.
+	Clock clock.Clock
 }
 
 func RehydrateUser(p RehydrateUserArgs) *User {
 	return &User{
-		id:        p.ID,
-		barcode:   p.Barcode,
-		username:  p.Username,
-		firstName: p.FirstName,
-		lastName:  p.LastName,
-		role:      p.Role,
-		avatar:    p.Avatar,
-		email:     p.Email,
-		passHash:  p.PassHash,
-		createdAt: p.CreatedAt,
-		updatedAt: p.UpdatedAt,
+		id:                p.ID,
+		barcode:           p.Barcode,
+		username:          p.Username,
+		firstName:         p.FirstName,
+		lastName:          p.LastName,
+		role:              p.Role,
+		otherRoles:        p.OtherRoles,
+		avatar:            p.Avatar,
+		email:             p.Email,
+		passHash:          p.PassHash,
+		passHistory:       p.PassHistory,
+		tos:               p.TOS,
+		emailVerified:     p.EmailVerified,
+		emailVerification: p.EmailVerification,
+		lastSeenAt:        p.LastSeenAt,
+		createdAt:         p.CreatedAt,
+		updatedAt:         p.UpdatedAt,
+		clock:             p.Clock,
 	}
 }
 
+func (u *User) now() time.Time {
+	return clock.Or(u.clock).Now().UTC()
+}
+
 func (u *User) SetAvatarFromS3(s3Key string) error {
 	const op = "user.User.SetAvatarFromS3"
 	if u == nil {
@@ -133,14 +167,13 @@ func (u *User) SetAvatarFromS3(s3Key string) error {
 		S3Key:    s3Key,
 		External: "",
 	}
-	u.updatedAt = time.Now().UTC()
+	u.updatedAt = u.now()
 
-	u.AddEvent(&UserAvatarUpdated{
-		Header:    event.NewEventHeader(),
+	u.Record(&UserAvatarUpdated{
 		UserID:    u.id,
 		NewAvatar: u.avatar,
 		OldAvatar: oldAvatar,
-	})
+	}, uuid.UUID(u.id), uuid.UUID(u.id))
 	return nil
 }
 
@@ -159,14 +192,29 @@ func (u *User) DeleteAvatar() error {
 		S3Key:    "",
 		External: "",
 	}
-	u.updatedAt = time.Now().UTC()
+	u.updatedAt = u.now()
 
-	u.AddEvent(&UserAvatarUpdated{
-		Header:    event.NewEventHeader(),
+	u.Record(&UserAvatarUpdated{
 		UserID:    u.id,
 		NewAvatar: u.avatar,
 		OldAvatar: oldAvatar,
-	})
+	}, uuid.UUID(u.id), uuid.UUID(u.id))
+	return nil
+}
+
+// RejectAvatar records that the avatar upload was rejected as infected, the
+// avatar itself is left as it is. signature is the malware the scanner found.
+func (u *User) RejectAvatar(filename, signature string) error {
+	const op = "user.User.RejectAvatar"
+	if u == nil {
+		return errorx.Wrap(errors.New("user is nil"), op)
+	}
+
+	u.Record(&UserAvatarRejected{
+		UserID:    u.id,
+		Filename:  sanitizex.TruncateRunes(sanitizex.CleanSingleLine(filename), MaxAvatarFilenameLen),
+		Signature: sanitizex.CleanSingleLine(signature),
+	}, uuid.UUID(u.id), uuid.UUID(u.id))
 	return nil
 }
 
@@ -174,6 +222,64 @@ func (u *User) ComparePassword(password string) error {
 	return bcrypt.CompareHashAndPassword(u.passHash, []byte(password))
 }
 
+// ChangePassword replaces the password hash, e.g. when the initial staff
+// password is rotated. The password must pass policy, the current one and
+// the previous ones are checked for reuse, and the current hash is moved to
+// the history.
+func (u *User) ChangePassword(ctx context.Context, policy *PasswordPolicy, password string) error {
+	const op = "user.User.ChangePassword"
+	if u == nil {
+		return errorx.Wrap(errors.New("user is nil"), op)
+	}
+	used := append([][]byte{u.passHash}, u.passHistory...)
+	if err := policy.Check(ctx, password, used...); err != nil {
+		return errorx.Wrap(err, op)
+	}
+
+	passhash, err := NewPasswordHash(password)
+	if err != nil {
+		return errorx.Wrap(err, op)
+	}
+	u.passHistory = used[:min(len(used), PasswordHistorySize-1)]
+	u.passHash = passhash
+	u.updatedAt = u.now()
+	return nil
+}
+
+// globalRoleRank orders the global roles, Promote only moves a user up.
+var globalRoleRank = map[roles.Global]int{
+	roles.Guest:   0,
+	roles.Student: 1,
+	roles.AITUSA:  2,
+	roles.Staff:   3,
+}
+
+// Promote raises the global role of the user to role. AITUSA is given to
+// students only, the AITUSA members are students. Making a user staff needs
+// the staff record as well, see postgres.UserRepo.PromoteUser.
+func (u *User) Promote(role roles.Global) error {
+	const op = "user.User.Promote"
+	if u == nil {
+		return errorx.Wrap(errors.New("user is nil"), op)
+	}
+	if !roles.IsGlobalValid(role) {
+		return errorx.NewInvalidRequest().WithCause(
+			fmt.Errorf("unknown role %q, the roles are guest, student, aitusa and staff", role), op)
+	}
+	if globalRoleRank[role] <= globalRoleRank[u.role] {
+		return errorx.NewConflict().WithCause(
+			fmt.Errorf("user has role %s, it can not be promoted to %s", u.role, role), op)
+	}
+	if role == roles.AITUSA && u.role != roles.Student {
+		return errorx.NewConflict().WithCause(
+			fmt.Errorf("only students can be promoted to %s, user has role %s", role, u.role), op)
+	}
+
+	u.role = role
+	u.updatedAt = u.now()
+	return nil
+}
+
 func (u *User) ID() ID {
 	if u == nil {
 		return ID{}
@@ -222,6 +328,34 @@ func (u *User) Role() roles.Global {
 	return u.role
 }
 
+// Roles returns all the roles of the user, Role is the most privileged of
+// them.
+func (u *User) Roles() roles.Set {
+	if u == nil {
+		return nil
+	}
+
+	return roles.NewSet(append(slices.Clone(u.otherRoles), u.role)...)
+}
+
+// OtherRoles returns the roles held beside Role.
+func (u *User) OtherRoles() roles.Set {
+	if u == nil {
+		return nil
+	}
+
+	return roles.NewSet(u.otherRoles...)
+}
+
+// grantRole adds role to the roles of the user, the most privileged one
+// becomes its role.
+func (u *User) grantRole(role roles.Global) {
+	set := roles.NewSet(append(slices.Clone(u.otherRoles), u.role, role)...)
+	u.role = set.Primary()
+	u.otherRoles = set[:len(set)-1]
+	u.updatedAt = u.now()
+}
+
 func (u *User) Avatar() avatars.Avatar {
 	if u == nil {
 		return avatars.Avatar{}
@@ -230,7 +364,7 @@ func (u *User) Avatar() avatars.Avatar {
 	return u.avatar
 }
 
-func (u *User) Email() string {
+func (u *User) Email() emails.Email {
 	if u == nil {
 		return ""
 	}
@@ -246,6 +380,25 @@ func (u *User) PassHash() []byte {
 	return u.passHash
 }
 
+// PassHistory returns the hashes of the previous passwords, newest first.
+func (u *User) PassHistory() [][]byte {
+	if u == nil {
+		return nil
+	}
+
+	return u.passHistory
+}
+
+// LastSeenAt is the time of a recent authenticated request of the user,
+// accurate to lastseen.MinInterval. It is zero for the users never seen.
+func (u *User) LastSeenAt() time.Time {
+	if u == nil {
+		return time.Time{}
+	}
+
+	return u.lastSeenAt
+}
+
 func (u *User) CreatedAt() time.Time {
 	if u == nil {
 		return time.Time{}
@@ -262,10 +415,47 @@ func (u *User) UpdatedAt() time.Time {
 	return u.updatedAt
 }
 
+// ListKey is the position of a user in the listing, ordered by last name,
+// first name and id.
+type ListKey struct {
+	LastName  string
+	FirstName string
+	ID        ID
+}
+
+// Key returns the position of u in the listing.
+func (u *User) Key() ListKey {
+	return ListKey{LastName: u.lastName, FirstName: u.firstName, ID: u.id}
+}
+
+type ListParams struct {
+	// Query keeps the users whose barcode, username, email or name contain
+	// it, empty keeps all of them.
+	Query string
+	// EnrollmentStatus keeps the students with the status, empty keeps all
+	// of the users.
+	EnrollmentStatus EnrollmentStatus
+	// Department keeps the staff members of the department, empty keeps all
+	// of the users.
+	Department string
+	// After resumes the listing after the key, Offset is then ignored.
+	After  *ListKey
+	Limit  int
+	Offset int
+}
+
+// InactiveParams pages through the users not seen since Since, the users
+// never seen included.
+type InactiveParams struct {
+	Since  time.Time
+	Limit  int
+	Offset int
+}
+
 func NewPasswordHash(password string) ([]byte, error) {
 	const op = "user.NewPasswordHash"
 	costFactor := PasswordCostFactor
-	if env.Current() == env.Test {
+	if env.Allows(env.CapFastPasswordHash) {
 		costFactor = bcrypt.MinCost
 	}
 	passhash, err := bcrypt.GenerateFromPassword([]byte(password), costFactor)
@@ -286,3 +476,33 @@ type UserAvatarUpdated struct {
 func (e *UserAvatarUpdated) GetStreamName() string {
 	return UserEventStreamName
 }
+
+// UserAvatarRejected is recorded when the malware scan rejects an avatar
+// upload, nothing of the upload is stored.
+type UserAvatarRejected struct {
+	event.Header
+	event.Otel
+	UserID    ID     `json:"user_id"`
+	Filename  string `json:"filename"`
+	Signature string `json:"signature"`
+}
+
+func (e *UserAvatarRejected) GetStreamName() string {
+	return UserEventStreamName
+}
+
+// RoleGranted is recorded when a user is given a role beside the ones they
+// hold, e.g. a student linking their account to a staff invitation. Roles
+// are all the roles of the user afterwards.
+type RoleGranted struct {
+	event.Header
+	event.Otel
+	UserID       ID           `json:"user_id"`
+	Role         roles.Global `json:"role"`
+	Roles        roles.Set    `json:"roles"`
+	InvitationID uuid.UUID    `json:"invitation_id"`
+}
+
+func (e *RoleGranted) GetStreamName() string {
+	return UserEventStreamName
+}
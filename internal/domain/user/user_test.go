@@ -2,15 +2,21 @@ package user_test
 
 import (
 	"errors"
+	"fmt"
+	"net/http"
 	"testing"
+	"time"
 
 	"github.com/ARUMANDESU/validation"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/event"
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/avatars"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/roles"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
 	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
 	"gitlab.com/ucmsv2/ucms-backend/pkg/validationx"
 	"gitlab.com/ucmsv2/ucms-backend/tests/integration/builders"
@@ -190,3 +196,263 @@ func TestUser_DeleteAvatar(t *testing.T) {
 		})
 	}
 }
+
+func TestUser_RejectAvatar(t *testing.T) {
+	t.Run("records the rejection", func(t *testing.T) {
+		u := builders.UserWithValidAvatar().Build()
+		avatar := u.Avatar()
+
+		err := u.RejectAvatar("  evil\n.jpg  ", "Eicar-Test-Signature")
+		require.NoError(t, err)
+		assert.Equal(t, avatar, u.Avatar(), "the avatar should be kept")
+
+		e := event.AssertSingleEvent[*user.UserAvatarRejected](t, u.GetUncommittedEvents())
+		assert.Equal(t, u.ID(), e.UserID)
+		assert.Equal(t, "evil .jpg", e.Filename)
+		assert.Equal(t, "Eicar-Test-Signature", e.Signature)
+	})
+
+	t.Run("nil user", func(t *testing.T) {
+		var u *user.User
+		assert.ErrorContains(t, u.RejectAvatar("avatar.jpg", "Eicar-Test-Signature"), "user is nil")
+	})
+}
+
+func TestUser_ChangePassword(t *testing.T) {
+	policy := user.NewPasswordPolicy(user.PasswordPolicyArgs{})
+
+	t.Run("valid password", func(t *testing.T) {
+		u := builders.NewUserBuilder().WithPassword("OldP@ssw0rd").Build()
+		updatedAt := u.UpdatedAt()
+
+		require.NoError(t, u.ChangePassword(t.Context(), policy, "NewP@ssw0rd"))
+		assert.NoError(t, u.ComparePassword("NewP@ssw0rd"))
+		assert.Error(t, u.ComparePassword("OldP@ssw0rd"))
+		assert.False(t, u.UpdatedAt().Before(updatedAt))
+		assert.Len(t, u.PassHistory(), 1)
+		assert.Empty(t, u.GetUncommittedEvents())
+	})
+
+	t.Run("weak password", func(t *testing.T) {
+		u := builders.NewUserBuilder().WithPassword("OldP@ssw0rd").Build()
+
+		err := u.ChangePassword(t.Context(), policy, "weak")
+		require.Error(t, err)
+		assert.NoError(t, u.ComparePassword("OldP@ssw0rd"), "the password is kept")
+	})
+
+	t.Run("current password", func(t *testing.T) {
+		u := builders.NewUserBuilder().WithPassword("OldP@ssw0rd").Build()
+
+		err := u.ChangePassword(t.Context(), policy, "OldP@ssw0rd")
+		assert.True(t, errorx.IsCode(err, errorx.CodePasswordReused), "unexpected error: %v", err)
+	})
+
+	t.Run("nil user", func(t *testing.T) {
+		var u *user.User
+		assert.ErrorContains(t, u.ChangePassword(t.Context(), policy, "NewP@ssw0rd"), "user is nil")
+	})
+}
+
+func TestUser_ChangePassword_ReuseWindow(t *testing.T) {
+	policy := user.NewPasswordPolicy(user.PasswordPolicyArgs{})
+	u := builders.NewUserBuilder().WithPassword("P@ssw0rd0").Build()
+
+	require.NoError(t, u.ChangePassword(t.Context(), policy, "P@ssw0rd1"))
+	require.NoError(t, u.ChangePassword(t.Context(), policy, "P@ssw0rd2"))
+
+	for _, password := range []string{"P@ssw0rd0", "P@ssw0rd1", "P@ssw0rd2"} {
+		err := u.ChangePassword(t.Context(), policy, password)
+		assert.True(t, errorx.IsCode(err, errorx.CodePasswordReused), "%s: unexpected error: %v", password, err)
+		assert.Equal(t, http.StatusUnprocessableEntity, errorx.HTTPStatusCode(errorx.CodeOf(err)))
+	}
+	assert.NoError(t, u.ComparePassword("P@ssw0rd2"), "a rejected change keeps the password")
+
+	for i := 3; i < user.PasswordHistorySize+1; i++ {
+		require.NoError(t, u.ChangePassword(t.Context(), policy, fmt.Sprintf("P@ssw0rd%d", i)))
+	}
+	assert.Len(t, u.PassHistory(), user.PasswordHistorySize-1)
+	err := u.ChangePassword(t.Context(), policy, "P@ssw0rd2")
+	assert.True(t, errorx.IsCode(err, errorx.CodePasswordReused), "the last %d passwords are kept", user.PasswordHistorySize)
+	assert.NoError(t, u.ChangePassword(t.Context(), policy, "P@ssw0rd0"), "older passwords can be used again")
+}
+
+func TestUser_Promote(t *testing.T) {
+	tests := []struct {
+		name     string
+		from     roles.Global
+		to       roles.Global
+		wantCode errorx.Code
+	}{
+		{name: "student to aitusa", from: roles.Student, to: roles.AITUSA},
+		{name: "student to staff", from: roles.Student, to: roles.Staff},
+		{name: "guest to student", from: roles.Guest, to: roles.Student},
+		{name: "aitusa to staff", from: roles.AITUSA, to: roles.Staff},
+		{name: "unknown role", from: roles.Student, to: roles.Global("admin"), wantCode: errorx.CodeInvalid},
+		{name: "same role", from: roles.Staff, to: roles.Staff, wantCode: errorx.CodeConflict},
+		{name: "demotion", from: roles.Staff, to: roles.Student, wantCode: errorx.CodeConflict},
+		{name: "guest to aitusa", from: roles.Guest, to: roles.AITUSA, wantCode: errorx.CodeConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := builders.NewUserBuilder().WithRole(tt.from).Build()
+
+			err := u.Promote(tt.to)
+			if tt.wantCode != "" {
+				require.Error(t, err)
+				assert.True(t, errorx.IsCode(err, tt.wantCode), "unexpected error: %v", err)
+				assert.Equal(t, tt.from, u.Role(), "the role is kept")
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.to, u.Role())
+		})
+	}
+
+	t.Run("nil user", func(t *testing.T) {
+		var u *user.User
+		assert.ErrorContains(t, u.Promote(roles.Staff), "user is nil")
+	})
+}
+
+func TestUser_AcceptTOS(t *testing.T) {
+	t.Run("records the acceptance", func(t *testing.T) {
+		u := builders.NewUserBuilder().Build()
+
+		require.NoError(t, u.AcceptTOS("2026-05", " 203.0.113.7\n"))
+		assert.Equal(t, "2026-05", u.TOS().Version)
+		assert.Equal(t, "203.0.113.7", u.TOS().IP)
+		assert.Equal(t, u.UpdatedAt(), u.TOS().AcceptedAt)
+
+		e := event.AssertSingleEvent[*user.UserTOSAccepted](t, u.GetUncommittedEvents())
+		assert.Equal(t, u.ID(), e.UserID)
+		assert.Equal(t, "2026-05", e.Version)
+		assert.Equal(t, "203.0.113.7", e.IP)
+	})
+
+	t.Run("empty version", func(t *testing.T) {
+		u := builders.NewUserBuilder().Build()
+
+		assert.Error(t, u.AcceptTOS("", "203.0.113.7"))
+		assert.True(t, u.TOS().IsZero())
+		assert.Empty(t, u.GetUncommittedEvents())
+	})
+
+	t.Run("nil user", func(t *testing.T) {
+		var u *user.User
+		assert.ErrorContains(t, u.AcceptTOS("2026-05", ""), "user is nil")
+	})
+}
+
+var emailVerificationNow = time.Date(2026, time.March, 2, 9, 0, 0, 0, time.UTC)
+
+func TestUser_EmailVerification(t *testing.T) {
+	newUser := func(clk *clock.Fake) *user.User {
+		args := builders.NewUserBuilder().AsStaff().WithEmailUnverified().RehydrateArgs()
+		args.Clock = clk
+		return user.RehydrateUser(args)
+	}
+
+	t.Run("verifies with the mailed code", func(t *testing.T) {
+		u := newUser(clock.NewFake(emailVerificationNow))
+
+		require.NoError(t, u.RequestEmailVerification())
+		e := event.AssertSingleEvent[*user.EmailVerificationRequested](t, u.GetUncommittedEvents())
+		assert.Equal(t, u.Email(), e.Email)
+		require.NotNil(t, u.EmailVerification())
+		assert.Equal(t, u.EmailVerification().Code, e.VerificationCode)
+		u.CommitEvents()
+
+		require.NoError(t, u.VerifyEmail(e.VerificationCode))
+		assert.True(t, u.EmailVerified())
+		assert.Nil(t, u.EmailVerification())
+		event.AssertSingleEvent[*user.UserEmailVerified](t, u.GetUncommittedEvents())
+
+		assert.ErrorIs(t, u.VerifyEmail(e.VerificationCode), user.ErrEmailAlreadyVerified)
+		require.NoError(t, u.RequestEmailVerification())
+		assert.Nil(t, u.EmailVerification(), "no code for a verified email")
+	})
+
+	t.Run("resends after the cooldown", func(t *testing.T) {
+		clk := clock.NewFake(emailVerificationNow)
+		u := newUser(clk)
+
+		require.NoError(t, u.RequestEmailVerification())
+		first := *u.EmailVerification()
+		require.NoError(t, u.RequestEmailVerification())
+		assert.Equal(t, first, *u.EmailVerification(), "kept during the cooldown")
+		assert.Len(t, u.GetUncommittedEvents(), 1)
+
+		clk.Advance(user.EmailVerificationResendTimeout)
+		require.NoError(t, u.RequestEmailVerification())
+		assert.NotEqual(t, first.ExpiresAt, u.EmailVerification().ExpiresAt)
+		assert.Len(t, u.GetUncommittedEvents(), 2)
+	})
+
+	t.Run("expired code", func(t *testing.T) {
+		clk := clock.NewFake(emailVerificationNow)
+		u := newUser(clk)
+		assert.ErrorIs(t, u.VerifyEmail("ABC123"), user.ErrEmailVerificationExpired)
+
+		require.NoError(t, u.RequestEmailVerification())
+		clk.Advance(user.EmailVerificationExpiresAt + time.Second)
+		assert.ErrorIs(t, u.VerifyEmail(u.EmailVerification().Code), user.ErrEmailVerificationExpired)
+		assert.False(t, u.EmailVerified())
+	})
+
+	t.Run("too many wrong codes drop the code", func(t *testing.T) {
+		u := newUser(clock.NewFake(emailVerificationNow))
+		require.NoError(t, u.RequestEmailVerification())
+
+		for range user.MaxEmailVerificationAttempts - 1 {
+			err := u.VerifyEmail("wrong")
+			assert.ErrorIs(t, err, user.ErrPersistentEmailVerificationMismatch)
+			assert.True(t, errorx.IsPersistable(err))
+		}
+		err := u.VerifyEmail("wrong")
+		assert.ErrorIs(t, err, user.ErrPersistentEmailVerificationTooManyAttempts)
+		assert.Nil(t, u.EmailVerification())
+		assert.False(t, u.EmailVerified())
+	})
+
+	t.Run("joining verifies the email", func(t *testing.T) {
+		initial, err := user.CreateInitialStaff(builders.NewStaffBuilder().BuildCreateInitialStaffArgs())
+		require.NoError(t, err)
+		assert.False(t, initial.User().EmailVerified())
+
+		student, err := user.RegisterStudent(builders.NewStudentBuilder().BuildRegisterArgs())
+		require.NoError(t, err)
+		assert.True(t, student.User().EmailVerified())
+
+		staff, err := user.AcceptStaffInvitation(builders.NewStaffBuilder().BuildAcceptStaffInvitationArgs(uuid.New()))
+		require.NoError(t, err)
+		assert.True(t, staff.User().EmailVerified())
+	})
+}
+
+func TestNewImpersonation(t *testing.T) {
+	now := time.Date(2026, time.May, 4, 9, 0, 0, 0, time.UTC)
+	staff := builders.NewUserBuilder().AsStaff().Build()
+	student := builders.NewUserBuilder().Build()
+
+	t.Run("staff impersonating a student", func(t *testing.T) {
+		imp, err := user.NewImpersonation(staff, student, "203.0.113.7", now)
+		require.NoError(t, err)
+		assert.Equal(t, staff.ID(), imp.ActorID())
+		assert.Equal(t, student.ID(), imp.TargetID())
+		assert.Equal(t, roles.Student, imp.TargetRole())
+		assert.Equal(t, now, imp.StartedAt())
+		assert.Equal(t, now.Add(user.ImpersonationTTL), imp.ExpiresAt())
+	})
+
+	t.Run("staff target", func(t *testing.T) {
+		_, err := user.NewImpersonation(staff, builders.NewUserBuilder().AsStaff().Build(), "", now)
+		assert.True(t, errorx.IsCode(err, errorx.CodeForbidden), "expected forbidden, got: %v", err)
+	})
+
+	t.Run("student actor", func(t *testing.T) {
+		_, err := user.NewImpersonation(student, builders.NewUserBuilder().Build(), "", now)
+		assert.True(t, errorx.IsCode(err, errorx.CodeForbidden), "expected forbidden, got: %v", err)
+	})
+}
@@ -0,0 +1,127 @@
+package emails
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+
+	"github.com/ARUMANDESU/validation/is"
+
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/i18nx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/sanitizex"
+)
+
+// MaxLen is the longest address, in bytes.
+const MaxLen = 255
+
+// Email is a normalized and valid email address. Build it with New, the
+// zero value is the missing address. Two addresses are the same when they
+// are equal, New normalizes them with sanitizex.NormalizeEmail.
+//
+// It is stored and serialized as the plain address, Scan and UnmarshalJSON
+// reject an invalid one.
+type Email string
+
+// New normalizes s with sanitizex.NormalizeEmail and validates it.
+func New(s string) (Email, error) {
+	const op = "emails.New"
+	if s == "" {
+		return "", errorx.Invalid(i18nx.KeyEmptyEmail, op)
+	}
+	normalized, err := sanitizex.NormalizeEmail(s)
+	if err != nil {
+		return "", errorx.Invalid(i18nx.KeyInvalidEmailFormat, "").WithCause(err, op)
+	}
+	if err := validate(normalized); err != nil {
+		return "", errorx.Wrap(err, op)
+	}
+	return Email(normalized), nil
+}
+
+// MustNew is New panicking on an invalid s, for the addresses known at
+// compile time.
+func MustNew(s string) Email {
+	e, err := New(s)
+	if err != nil {
+		panic(err)
+	}
+	return e
+}
+
+// validate checks an address that is already normalized.
+func validate(s string) error {
+	const op = "emails.validate"
+	if s == "" {
+		return errorx.Invalid(i18nx.KeyEmptyEmail, op)
+	}
+	if len(s) > MaxLen {
+		return errorx.Invalid(i18nx.KeyEmailMaxLen, op)
+	}
+	if err := is.EmailFormat.Validate(s); err != nil {
+		return errorx.Invalid(i18nx.KeyInvalidEmailFormat, "").WithCause(err, op)
+	}
+	return nil
+}
+
+func (e Email) String() string {
+	return string(e)
+}
+
+func (e Email) IsZero() bool {
+	return e == ""
+}
+
+func (e Email) MarshalJSON() ([]byte, error) {
+	return json.Marshal(string(e))
+}
+
+// UnmarshalJSON reads the address with New, an empty string is the zero
+// Email.
+func (e *Email) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	if s == "" {
+		*e = ""
+		return nil
+	}
+	parsed, err := New(s)
+	if err != nil {
+		return err
+	}
+	*e = parsed
+	return nil
+}
+
+// Value stores the address as text, the zero Email as NULL.
+func (e Email) Value() (driver.Value, error) {
+	if e == "" {
+		return nil, nil
+	}
+	return string(e), nil
+}
+
+// Scan reads a stored address. It is validated but not normalized again,
+// so that a change of the email policy does not change the stored ones.
+func (e *Email) Scan(src any) error {
+	const op = "emails.Email.Scan"
+	var s string
+	switch v := src.(type) {
+	case nil:
+		*e = ""
+		return nil
+	case string:
+		s = v
+	case []byte:
+		s = string(v)
+	default:
+		return fmt.Errorf("%s: cannot scan %T into an email", op, src)
+	}
+	if err := validate(s); err != nil {
+		return errorx.Wrap(err, op)
+	}
+	*e = Email(s)
+	return nil
+}
@@ -0,0 +1,127 @@
+package emails
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/i18nx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/sanitizex"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    Email
+		wantKey string
+	}{
+		{name: "valid", input: "user@test.com", want: "user@test.com"},
+		{name: "domain lowercased", input: "User@Test.COM", want: "User@test.com"},
+		{name: "trimmed", input: "  user@test.com\n", want: "user@test.com"},
+		{name: "idn domain to punycode", input: "user@bücher.de", want: "user@xn--bcher-kva.de"},
+		{name: "empty", input: "", wantKey: i18nx.KeyEmptyEmail},
+		{name: "no at", input: "notanemail", wantKey: i18nx.KeyInvalidEmailFormat},
+		{name: "no domain", input: "user@", wantKey: i18nx.KeyInvalidEmailFormat},
+		{name: "header injection", input: "user@test.com\r\nBcc: x@test.com", wantKey: i18nx.KeyInvalidEmailFormat},
+		{name: "too long", input: strings.Repeat("a", 250) + "@test.com", wantKey: i18nx.KeyEmailMaxLen},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := New(tt.input)
+			if tt.wantKey != "" {
+				require.Error(t, err)
+				assert.True(t, errorx.IsCode(err, errorx.CodeInvalid), "unexpected error: %v", err)
+				var i18nErr *errorx.I18nError
+				require.ErrorAs(t, err, &i18nErr)
+				assert.Equal(t, tt.wantKey, i18nErr.MessageKey)
+				assert.True(t, got.IsZero())
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, string(tt.want), got.String())
+		})
+	}
+}
+
+func TestNew_Comparison(t *testing.T) {
+	t.Cleanup(func() { sanitizex.SetEmailPolicy(sanitizex.EmailPolicy{}) })
+
+	assert.Equal(t, MustNew("user@test.com"), MustNew(" user@TEST.com "), "the domain is case insensitive")
+	assert.NotEqual(t, MustNew("user@test.com"), MustNew("User@test.com"), "the local part keeps its case by default")
+	assert.NotEqual(t, MustNew("user@test.com"), MustNew("user+tag@test.com"), "the tag is kept")
+
+	sanitizex.SetEmailPolicy(sanitizex.EmailPolicy{LowercaseLocal: true})
+	assert.Equal(t, MustNew("user@test.com"), MustNew("User@test.com"))
+}
+
+func TestMustNew_Panics(t *testing.T) {
+	assert.Panics(t, func() { MustNew("notanemail") })
+}
+
+func TestEmail_JSON(t *testing.T) {
+	type payload struct {
+		Email Email `json:"email"`
+	}
+
+	data, err := json.Marshal(payload{Email: MustNew("user@test.com")})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"email":"user@test.com"}`, string(data))
+
+	var p payload
+	require.NoError(t, json.Unmarshal(data, &p))
+	assert.Equal(t, MustNew("user@test.com"), p.Email)
+
+	require.NoError(t, json.Unmarshal([]byte(`{"email":"User@TEST.com"}`), &p))
+	assert.Equal(t, Email("User@test.com"), p.Email, "the decoded address is normalized")
+
+	require.NoError(t, json.Unmarshal([]byte(`{"email":""}`), &p))
+	assert.True(t, p.Email.IsZero())
+
+	err = json.Unmarshal([]byte(`{"email":"notanemail"}`), &p)
+	assert.True(t, errorx.IsCode(err, errorx.CodeInvalid), "unexpected error: %v", err)
+}
+
+func TestEmail_ScanValue(t *testing.T) {
+	email := MustNew("user@test.com")
+
+	v, err := email.Value()
+	require.NoError(t, err)
+	assert.Equal(t, "user@test.com", v)
+
+	var scanned Email
+	require.NoError(t, scanned.Scan(v))
+	assert.Equal(t, email, scanned)
+
+	require.NoError(t, scanned.Scan([]byte("other@test.com")))
+	assert.Equal(t, Email("other@test.com"), scanned)
+
+	require.NoError(t, scanned.Scan(nil))
+	assert.True(t, scanned.IsZero())
+
+	v, err = Email("").Value()
+	require.NoError(t, err)
+	assert.Nil(t, v)
+}
+
+func TestEmail_Scan_Invalid(t *testing.T) {
+	var scanned Email
+	assert.Error(t, scanned.Scan("notanemail"), "an invalid stored address is rejected")
+	assert.Error(t, scanned.Scan(42))
+	assert.True(t, scanned.IsZero())
+}
+
+func TestEmail_Scan_NotNormalizedAgain(t *testing.T) {
+	t.Cleanup(func() { sanitizex.SetEmailPolicy(sanitizex.EmailPolicy{}) })
+	sanitizex.SetEmailPolicy(sanitizex.EmailPolicy{LowercaseLocal: true})
+
+	var scanned Email
+	require.NoError(t, scanned.Scan("User@test.com"))
+	assert.Equal(t, Email("User@test.com"), scanned)
+}
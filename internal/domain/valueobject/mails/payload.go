@@ -4,4 +4,7 @@ type Payload struct {
 	To      string
 	Subject string
 	Body    string
+	// HTML is the optional HTML alternative of Body, the senders send both
+	// when it is set.
+	HTML string
 }
@@ -1,5 +1,17 @@
 package roles
 
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+)
+
+// Global is the role of a user across the application, it is carried by the
+// access tokens. Read it with Parse, Unknown and the other strings are not
+// roles.
 type Global string
 
 const (
@@ -10,15 +22,88 @@ const (
 	Staff   = Global("staff")
 )
 
+// all lists the roles, Parse accepts these only.
+var all = []Global{Guest, Student, AITUSA, Staff}
+
+// All returns the roles, from the least privileged.
+func All() []Global {
+	return slices.Clone(all)
+}
+
+// Parse returns the role named s, the names are case sensitive.
+func Parse(s string) (Global, error) {
+	const op = "roles.Parse"
+	if !IsGlobalValid(s) {
+		return Unknown, errorx.NewInvalidRequest().WithCause(
+			fmt.Errorf("unknown role %q, the roles are guest, student, aitusa and staff", s), op)
+	}
+	return Global(s), nil
+}
+
 func (g Global) String() string {
 	return string(g)
 }
 
+// IsStaffLike reports whether the role administers the application.
+func (g Global) IsStaffLike() bool {
+	return g == Staff
+}
+
+// IsStudentLike reports whether the role is held by students, the AITUSA
+// members are students.
+func (g Global) IsStudentLike() bool {
+	return g == Student || g == AITUSA
+}
+
+// CanAnnounce reports whether the role publishes announcements to the
+// students, the student association does.
+func (g Global) CanAnnounce() bool {
+	return g == AITUSA
+}
+
 func IsGlobalValid[T Global | string](role T) bool {
-	switch Global(role) {
-	case Guest, Student, AITUSA, Staff:
-		return true
+	return slices.Contains(all, Global(role))
+}
+
+func (g Global) MarshalJSON() ([]byte, error) {
+	return json.Marshal(string(g))
+}
+
+// UnmarshalJSON reads the role with Parse.
+func (g *Global) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	parsed, err := Parse(s)
+	if err != nil {
+		return err
+	}
+	*g = parsed
+	return nil
+}
+
+// Value stores the name of the role.
+func (g Global) Value() (driver.Value, error) {
+	return string(g), nil
+}
+
+// Scan reads a stored role name with Parse.
+func (g *Global) Scan(src any) error {
+	const op = "roles.Global.Scan"
+	var s string
+	switch v := src.(type) {
+	case string:
+		s = v
+	case []byte:
+		s = string(v)
 	default:
-		return false
+		return fmt.Errorf("%s: cannot scan %T into a role", op, src)
+	}
+	parsed, err := Parse(s)
+	if err != nil {
+		return errorx.Wrap(err, op)
 	}
+	*g = parsed
+	return nil
 }
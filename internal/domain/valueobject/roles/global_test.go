@@ -1,6 +1,15 @@
 package roles
 
-import "testing"
+import (
+	"encoding/json"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+)
 
 func TestIsGlobalValid(t *testing.T) {
 	tests := []struct {
@@ -31,3 +40,65 @@ func TestIsGlobalValid(t *testing.T) {
 		})
 	}
 }
+
+// matrix lists every role with its name and kind. A role added to all
+// without a row here fails TestAll_Matrix.
+var matrix = map[Global]struct {
+	name        string
+	staffLike   bool
+	studentLike bool
+	canAnnounce bool
+}{
+	Guest:   {name: "guest"},
+	Student: {name: "student", studentLike: true},
+	AITUSA:  {name: "aitusa", studentLike: true, canAnnounce: true},
+	Staff:   {name: "staff", staffLike: true},
+}
+
+func TestAll_Matrix(t *testing.T) {
+	require.Len(t, All(), len(matrix), "the matrix misses a role")
+
+	for _, role := range All() {
+		want, ok := matrix[role]
+		require.True(t, ok, "the matrix misses %s", role)
+
+		assert.Equal(t, want.name, role.String())
+		assert.Equal(t, want.staffLike, role.IsStaffLike(), "%s.IsStaffLike", role)
+		assert.Equal(t, want.studentLike, role.IsStudentLike(), "%s.IsStudentLike", role)
+		assert.Equal(t, want.canAnnounce, role.CanAnnounce(), "%s.CanAnnounce", role)
+
+		parsed, err := Parse(want.name)
+		require.NoError(t, err)
+		assert.Equal(t, role, parsed)
+
+		data, err := json.Marshal(role)
+		require.NoError(t, err)
+		assert.JSONEq(t, strconv.Quote(want.name), string(data))
+		var decoded Global
+		require.NoError(t, json.Unmarshal(data, &decoded))
+		assert.Equal(t, role, decoded)
+
+		v, err := role.Value()
+		require.NoError(t, err)
+		var scanned Global
+		require.NoError(t, scanned.Scan(v))
+		assert.Equal(t, role, scanned)
+		require.NoError(t, scanned.Scan([]byte(want.name)))
+		assert.Equal(t, role, scanned)
+	}
+}
+
+func TestParse_Unknown(t *testing.T) {
+	for _, s := range []string{"", "unknown", "admin", "Staff", " staff", "staff\x00"} {
+		role, err := Parse(s)
+		assert.True(t, errorx.IsCode(err, errorx.CodeInvalid), "%q: unexpected error: %v", s, err)
+		assert.Equal(t, Unknown, role)
+
+		var decoded Global
+		assert.Error(t, json.Unmarshal([]byte(strconv.Quote(s)), &decoded), "%q", s)
+		assert.Error(t, decoded.Scan(s), "%q", s)
+	}
+
+	var scanned Global
+	assert.Error(t, scanned.Scan(42))
+}
@@ -0,0 +1,74 @@
+package roles
+
+import (
+	"slices"
+
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+)
+
+// Set is the roles a user holds, e.g. a student who joined the staff holds
+// both. It is ordered from the least privileged, see All. The checks pass
+// when any role of the set passes them.
+type Set []Global
+
+// NewSet returns the set of the roles rs, the ones not in All are dropped.
+func NewSet(rs ...Global) Set {
+	s := make(Set, 0, len(rs))
+	for _, r := range all {
+		if slices.Contains(rs, r) {
+			s = append(s, r)
+		}
+	}
+	return s
+}
+
+// ParseSet reads the role names with Parse.
+func ParseSet(names []string) (Set, error) {
+	const op = "roles.ParseSet"
+	rs := make([]Global, 0, len(names))
+	for _, name := range names {
+		r, err := Parse(name)
+		if err != nil {
+			return nil, errorx.Wrap(err, op)
+		}
+		rs = append(rs, r)
+	}
+	return NewSet(rs...), nil
+}
+
+func (s Set) Has(role Global) bool {
+	return slices.Contains(s, role)
+}
+
+// Primary returns the most privileged role of the set, Unknown for the
+// empty one. It is the role of the user where a single one is kept.
+func (s Set) Primary() Global {
+	if len(s) == 0 {
+		return Unknown
+	}
+	return s[len(s)-1]
+}
+
+// IsStaffLike reports whether a role of the set administers the application.
+func (s Set) IsStaffLike() bool {
+	return slices.ContainsFunc(s, Global.IsStaffLike)
+}
+
+// IsStudentLike reports whether a role of the set is held by students.
+func (s Set) IsStudentLike() bool {
+	return slices.ContainsFunc(s, Global.IsStudentLike)
+}
+
+// CanAnnounce reports whether a role of the set publishes announcements.
+func (s Set) CanAnnounce() bool {
+	return slices.ContainsFunc(s, Global.CanAnnounce)
+}
+
+// Strings returns the role names, as carried by the access tokens.
+func (s Set) Strings() []string {
+	names := make([]string, len(s))
+	for i, r := range s {
+		names[i] = r.String()
+	}
+	return names
+}
@@ -0,0 +1,55 @@
+package roles
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+)
+
+func TestNewSet(t *testing.T) {
+	s := NewSet(Staff, Student, Unknown, Staff)
+
+	assert.Equal(t, Set{Student, Staff}, s)
+	assert.Equal(t, Staff, s.Primary())
+	assert.True(t, s.Has(Student))
+	assert.False(t, s.Has(AITUSA))
+	assert.Equal(t, []string{"student", "staff"}, s.Strings())
+
+	assert.Equal(t, Unknown, NewSet().Primary())
+}
+
+func TestSet_Union(t *testing.T) {
+	tests := []struct {
+		name        string
+		set         Set
+		staffLike   bool
+		studentLike bool
+		canAnnounce bool
+	}{
+		{name: "empty"},
+		{name: "guest", set: NewSet(Guest)},
+		{name: "student", set: NewSet(Student), studentLike: true},
+		{name: "staff", set: NewSet(Staff), staffLike: true},
+		{name: "student and staff", set: NewSet(Student, Staff), staffLike: true, studentLike: true},
+		{name: "aitusa and staff", set: NewSet(AITUSA, Staff), staffLike: true, studentLike: true, canAnnounce: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.staffLike, tt.set.IsStaffLike())
+			assert.Equal(t, tt.studentLike, tt.set.IsStudentLike())
+			assert.Equal(t, tt.canAnnounce, tt.set.CanAnnounce())
+		})
+	}
+}
+
+func TestParseSet(t *testing.T) {
+	s, err := ParseSet([]string{"staff", "aitusa"})
+	require.NoError(t, err)
+	assert.Equal(t, Set{AITUSA, Staff}, s)
+
+	_, err = ParseSet([]string{"student", "admin"})
+	assert.True(t, errorx.IsCode(err, errorx.CodeInvalid), "unexpected error: %v", err)
+}
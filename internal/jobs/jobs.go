@@ -0,0 +1,104 @@
+// Package jobs runs the periodic background jobs of the instances. A job
+// runs on one instance at a time: the Runner takes the lock of the job
+// before every run and skips the run when another instance holds it.
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// DefaultTimeout bounds a run of a Job without a Timeout.
+const DefaultTimeout = 10 * time.Minute
+
+// Job is a function the Runner runs on its Schedule.
+type Job struct {
+	// Name identifies the job on the ops endpoints and in the metrics, it
+	// is also the key of its lock.
+	Name     string
+	Schedule Schedule
+	// Jitter delays every scheduled run by up to Jitter, so that the
+	// instances started together do not contend for the lock at the same
+	// instant.
+	Jitter time.Duration
+	// Timeout bounds a run, defaults to DefaultTimeout.
+	Timeout time.Duration
+	// RunOnStart runs the job when the Runner starts instead of waiting for
+	// the first scheduled run.
+	RunOnStart bool
+	Run        func(ctx context.Context) error
+}
+
+// Schedule returns the time of the run following now.
+type Schedule interface {
+	Next(now time.Time) time.Time
+	String() string
+}
+
+// Every runs a job at a fixed interval.
+type Every time.Duration
+
+func (e Every) Next(now time.Time) time.Time {
+	return now.Add(time.Duration(e))
+}
+
+func (e Every) String() string {
+	return "every " + time.Duration(e).String()
+}
+
+// Weekly runs a job every Monday at At past midnight UTC.
+type Weekly struct {
+	At time.Duration
+}
+
+func (w Weekly) Next(now time.Time) time.Time {
+	now = now.UTC()
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	// time.Sunday is 0, the weeks start on Monday.
+	monday := day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	next := monday.Add(w.At)
+	if !next.After(now) {
+		next = monday.AddDate(0, 0, 7).Add(w.At)
+	}
+	return next
+}
+
+func (w Weekly) String() string {
+	return fmt.Sprintf("weekly on Monday at %s UTC", time.Time{}.Add(w.At).Format("15:04"))
+}
+
+// Result is the outcome of a run.
+type Result string
+
+const (
+	ResultSuccess Result = "success"
+	ResultFailure Result = "failure"
+	// ResultSkipped is a run another instance was running already.
+	ResultSkipped Result = "skipped"
+)
+
+// Status is what the Runner knows about a job on this instance.
+type Status struct {
+	Name     string
+	Schedule string
+	Running  bool
+	// NextRunAt is zero until the Runner runs.
+	NextRunAt time.Time
+	// LastStartedAt, LastDuration, LastResult and LastError are zero until
+	// the first run.
+	LastStartedAt time.Time
+	LastDuration  time.Duration
+	LastResult    Result
+	LastError     string
+	Successes     int64
+	Failures      int64
+	Skips         int64
+}
+
+// Locker serializes the runs of a job across the instances.
+type Locker interface {
+	// TryLock takes the lock of name unless it is held already, ok is false
+	// then. unlock releases a lock that was taken.
+	TryLock(ctx context.Context, name string) (unlock func(), ok bool, err error)
+}
@@ -0,0 +1,349 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"runtime/debug"
+	"slices"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/contrib/bridges/otelslog"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx/metrics"
+)
+
+var (
+	tracer = otel.Tracer("ucms/internal/jobs")
+	logger = otelslog.NewLogger("ucms/internal/jobs")
+)
+
+// Runner runs the registered jobs on their schedule until the context of
+// Run is done, and on demand through Trigger.
+type Runner struct {
+	tracer   trace.Tracer
+	logger   *slog.Logger
+	clock    clock.Clock
+	locker   Locker
+	runs     metric.Int64Counter
+	duration metric.Float64Histogram
+
+	mu      sync.Mutex
+	jobs    []*entry
+	byName  map[string]*entry
+	ctx     context.Context
+	stopped bool
+	wg      sync.WaitGroup
+}
+
+type entry struct {
+	job         Job
+	status      Status
+	lastSuccess time.Time
+}
+
+type RunnerArgs struct {
+	Tracer trace.Tracer
+	Logger *slog.Logger
+	// Clock defaults to clock.Real.
+	Clock  clock.Clock
+	Locker Locker
+	// Metrics defaults to metrics.Default().
+	Metrics *metrics.Registry
+}
+
+// NewRunner creates a Runner. The last success gauge is registered once per
+// metrics registry, it reports the jobs of the first Runner.
+//
+//	WARNING: panics if Locker is nil
+func NewRunner(args RunnerArgs) *Runner {
+	if args.Locker == nil {
+		panic("locker is required")
+	}
+	if args.Tracer == nil {
+		args.Tracer = tracer
+	}
+	if args.Logger == nil {
+		args.Logger = logger
+	}
+	if args.Metrics == nil {
+		args.Metrics = metrics.Default()
+	}
+
+	r := &Runner{
+		tracer: args.Tracer,
+		logger: args.Logger,
+		clock:  clock.Or(args.Clock),
+		locker: args.Locker,
+		runs: args.Metrics.Int64Counter(metrics.JobRuns,
+			metric.WithDescription("Runs of the background jobs"),
+			metric.WithUnit("{run}"),
+		),
+		duration: args.Metrics.Float64Histogram(metrics.JobDuration,
+			metric.WithDescription("Duration of the background job runs"),
+			metric.WithUnit("s"),
+		),
+		byName: make(map[string]*entry),
+	}
+
+	err := args.Metrics.RegisterInt64Gauge(metrics.JobLastSuccess, r.observeLastSuccess,
+		metric.WithDescription("Unix time of the last successful run of the background jobs"),
+		metric.WithUnit("s"),
+	)
+	if err != nil {
+		r.logger.Warn("failed to register job gauge", slog.String("error", err.Error()))
+	}
+
+	return r
+}
+
+// Register adds job to the Runner, the jobs are registered before Run.
+func (r *Runner) Register(job Job) error {
+	const op = "jobs.Runner.Register"
+	if job.Name == "" || job.Schedule == nil || job.Run == nil {
+		return errorx.Wrap(errors.New("job name, schedule and run function are required"), op)
+	}
+	if job.Timeout <= 0 {
+		job.Timeout = DefaultTimeout
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.ctx != nil {
+		return errorx.Wrap(fmt.Errorf("job %q registered after the runner started", job.Name), op)
+	}
+	if _, ok := r.byName[job.Name]; ok {
+		return errorx.Wrap(fmt.Errorf("job %q is registered already", job.Name), op)
+	}
+
+	e := &entry{job: job, status: Status{Name: job.Name, Schedule: job.Schedule.String()}}
+	r.jobs = append(r.jobs, e)
+	r.byName[job.Name] = e
+	return nil
+}
+
+// Run runs the jobs on their schedule until ctx is done, then waits for the
+// runs in progress. A Runner runs once.
+func (r *Runner) Run(ctx context.Context) {
+	r.mu.Lock()
+	if r.ctx != nil {
+		r.mu.Unlock()
+		panic("jobs.Runner.Run called twice")
+	}
+	r.ctx = ctx
+	jobs := slices.Clone(r.jobs)
+	r.wg.Add(len(jobs))
+	r.mu.Unlock()
+
+	for _, e := range jobs {
+		go func() {
+			defer r.wg.Done()
+			r.loop(ctx, e)
+		}()
+	}
+
+	<-ctx.Done()
+	r.mu.Lock()
+	r.stopped = true
+	r.mu.Unlock()
+	r.wg.Wait()
+}
+
+// Trigger starts a run of the job of name now, outside of its schedule. It
+// does not wait for the run, Statuses reports its outcome.
+func (r *Runner) Trigger(ctx context.Context, name string) error {
+	const op = "jobs.Runner.Trigger"
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	e, ok := r.byName[name]
+	if !ok {
+		return errorx.NewNotFound().WithDetails(fmt.Sprintf("job %q does not exist", name)).WithOp(op)
+	}
+	if r.ctx == nil || r.stopped {
+		return errorx.NewServiceUnavailable().WithDetails("the job runner is not running").WithOp(op)
+	}
+	if e.status.Running {
+		return errorx.NewConflict().WithDetails(fmt.Sprintf("job %q is running", name)).WithOp(op)
+	}
+	e.status.Running = true
+
+	r.logger.InfoContext(ctx, "job triggered", slog.String("job", name))
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		r.execute(r.ctx, e)
+	}()
+	return nil
+}
+
+// Statuses returns the status of the jobs in the order they were
+// registered.
+func (r *Runner) Statuses() []Status {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	statuses := make([]Status, len(r.jobs))
+	for i, e := range r.jobs {
+		statuses[i] = e.status
+	}
+	return statuses
+}
+
+func (r *Runner) loop(ctx context.Context, e *entry) {
+	now := r.clock.Now()
+	next := e.job.Schedule.Next(now)
+	if e.job.RunOnStart {
+		next = now
+	}
+
+	timer := r.clock.NewTimer(r.delay(e, now, next))
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C():
+			if r.claim(e) {
+				r.execute(ctx, e)
+			}
+			now := r.clock.Now()
+			timer.Reset(r.delay(e, now, e.job.Schedule.Next(now)))
+		}
+	}
+}
+
+// delay returns the time until next plus the jitter of the job, and records
+// the time of the run in the status.
+func (r *Runner) delay(e *entry, now, next time.Time) time.Duration {
+	d := next.Sub(now)
+	if e.job.Jitter > 0 {
+		d += rand.N(e.job.Jitter)
+	}
+
+	r.mu.Lock()
+	e.status.NextRunAt = now.Add(d)
+	r.mu.Unlock()
+	return d
+}
+
+// claim marks the job running, false when it is running on this instance
+// already, e.g. from a Trigger.
+func (r *Runner) claim(e *entry) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if e.status.Running {
+		return false
+	}
+	e.status.Running = true
+	return true
+}
+
+// execute runs a claimed job under its lock and records the outcome.
+func (r *Runner) execute(ctx context.Context, e *entry) {
+	ctx, span := r.tracer.Start(ctx, "Runner.Execute", trace.WithAttributes(
+		attribute.String("job.name", e.job.Name),
+	))
+	defer span.End()
+
+	start := r.clock.Now()
+	result, err := r.lockAndRun(ctx, e.job)
+	duration := r.clock.Now().Sub(start)
+	otelx.RecordSpanError(span, err, "job failed")
+	span.SetAttributes(attribute.String("job.result", string(result)))
+
+	r.mu.Lock()
+	e.status.Running = false
+	e.status.LastStartedAt = start
+	e.status.LastDuration = duration
+	e.status.LastResult = result
+	e.status.LastError = ""
+	switch result {
+	case ResultSuccess:
+		e.status.Successes++
+		e.lastSuccess = start
+	case ResultFailure:
+		e.status.Failures++
+		e.status.LastError = err.Error()
+	case ResultSkipped:
+		e.status.Skips++
+	}
+	r.mu.Unlock()
+
+	attrs := metric.WithAttributes(
+		attribute.String(metrics.AttrJob, e.job.Name),
+		attribute.String(metrics.AttrJobResult, string(result)),
+	)
+	r.runs.Add(ctx, 1, attrs)
+	if result != ResultSkipped {
+		r.duration.Record(ctx, duration.Seconds(), attrs)
+	}
+
+	switch result {
+	case ResultFailure:
+		r.logger.ErrorContext(ctx, "job failed",
+			slog.String("job", e.job.Name),
+			slog.Duration("duration", duration),
+			slog.String("error", err.Error()))
+	case ResultSkipped:
+		r.logger.DebugContext(ctx, "job skipped, it is running on another instance", slog.String("job", e.job.Name))
+	default:
+		r.logger.InfoContext(ctx, "job succeeded", slog.String("job", e.job.Name), slog.Duration("duration", duration))
+	}
+}
+
+func (r *Runner) lockAndRun(ctx context.Context, job Job) (Result, error) {
+	unlock, ok, err := r.locker.TryLock(ctx, job.Name)
+	if err != nil {
+		return ResultFailure, fmt.Errorf("failed to take the job lock: %w", err)
+	}
+	if !ok {
+		return ResultSkipped, nil
+	}
+	defer unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, job.Timeout)
+	defer cancel()
+	if err := r.call(ctx, job); err != nil {
+		return ResultFailure, err
+	}
+	return ResultSuccess, nil
+}
+
+// call runs job, a panic is returned as an error so that it fails the run
+// instead of the instance.
+func (r *Runner) call(ctx context.Context, job Job) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			r.logger.ErrorContext(ctx, "job panicked",
+				slog.String("job", job.Name),
+				slog.Any("panic", p),
+				slog.String("stack", string(debug.Stack())))
+			err = fmt.Errorf("job panicked: %v", p)
+		}
+	}()
+	return job.Run(ctx)
+}
+
+func (r *Runner) observeLastSuccess(_ context.Context, o metric.Int64Observer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, e := range r.jobs {
+		if e.lastSuccess.IsZero() {
+			continue
+		}
+		o.Observe(e.lastSuccess.Unix(), metric.WithAttributes(attribute.String(metrics.AttrJob, e.job.Name)))
+	}
+	return nil
+}
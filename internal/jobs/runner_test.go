@@ -0,0 +1,277 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/metric/noop"
+
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx/metrics"
+)
+
+var testNow = time.Date(2025, 3, 12, 12, 0, 0, 0, time.UTC) // a Wednesday
+
+const waitFor = 2 * time.Second
+
+// memLocker is the advisory lock of the database shared by the runners of a
+// test.
+type memLocker struct {
+	mu   sync.Mutex
+	held map[string]bool
+}
+
+func newMemLocker() *memLocker {
+	return &memLocker{held: make(map[string]bool)}
+}
+
+func (l *memLocker) TryLock(_ context.Context, name string) (func(), bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.held[name] {
+		return nil, false, nil
+	}
+	l.held[name] = true
+	return func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		delete(l.held, name)
+	}, true, nil
+}
+
+func newTestRunner(t *testing.T, clk clock.Clock, locker Locker, jobs ...Job) *Runner {
+	t.Helper()
+	r := NewRunner(RunnerArgs{
+		Clock:   clk,
+		Locker:  locker,
+		Metrics: metrics.NewRegistry(noop.NewMeterProvider().Meter("test")),
+	})
+	for _, job := range jobs {
+		require.NoError(t, r.Register(job))
+	}
+	return r
+}
+
+// start runs r until the test ends and waits until its jobs armed their
+// timers on clk.
+func start(t *testing.T, r *Runner, clk *clock.Fake, timers int) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		r.Run(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	require.Eventually(t, func() bool { return clk.Timers() == timers }, waitFor, time.Millisecond)
+}
+
+func status(r *Runner, name string) Status {
+	for _, s := range r.Statuses() {
+		if s.Name == name {
+			return s
+		}
+	}
+	return Status{}
+}
+
+func TestRunner_Schedule(t *testing.T) {
+	clk := clock.NewFake(testNow)
+	var runs atomic.Int32
+	r := newTestRunner(t, clk, newMemLocker(), Job{
+		Name:     "tick",
+		Schedule: Every(time.Minute),
+		Run: func(context.Context) error {
+			runs.Add(1)
+			return nil
+		},
+	})
+	start(t, r, clk, 1)
+	assert.Equal(t, testNow.Add(time.Minute), status(r, "tick").NextRunAt)
+
+	clk.Advance(59 * time.Second)
+	assert.Zero(t, runs.Load())
+
+	clk.Advance(time.Second)
+	require.Eventually(t, func() bool { return status(r, "tick").Successes == 1 }, waitFor, time.Millisecond)
+	require.Eventually(t, func() bool { return clk.Timers() == 1 }, waitFor, time.Millisecond)
+
+	clk.Advance(time.Minute)
+	require.Eventually(t, func() bool { return status(r, "tick").Successes == 2 }, waitFor, time.Millisecond)
+
+	s := status(r, "tick")
+	assert.Equal(t, int32(2), runs.Load())
+	assert.Equal(t, ResultSuccess, s.LastResult)
+	assert.Equal(t, testNow.Add(2*time.Minute), s.LastStartedAt)
+	assert.Equal(t, "every 1m0s", s.Schedule)
+}
+
+func TestRunner_RunOnStart(t *testing.T) {
+	clk := clock.NewFake(testNow)
+	r := newTestRunner(t, clk, newMemLocker(), Job{
+		Name:       "catch-up",
+		Schedule:   Weekly{At: 6 * time.Hour},
+		RunOnStart: true,
+		Run:        func(context.Context) error { return nil },
+	})
+	start(t, r, clk, 1)
+
+	require.Eventually(t, func() bool { return status(r, "catch-up").Successes == 1 }, waitFor, time.Millisecond)
+	require.Eventually(t, func() bool {
+		return status(r, "catch-up").NextRunAt.Equal(time.Date(2025, 3, 17, 6, 0, 0, 0, time.UTC))
+	}, waitFor, time.Millisecond)
+}
+
+func TestRunner_SingleExecutionUnderContention(t *testing.T) {
+	clk := clock.NewFake(testNow)
+	locker := newMemLocker()
+	var runs atomic.Int32
+	release := make(chan struct{})
+	job := Job{
+		Name:     "exclusive",
+		Schedule: Every(time.Minute),
+		Run: func(context.Context) error {
+			runs.Add(1)
+			<-release
+			return nil
+		},
+	}
+	// Two instances running the same job against the same lock.
+	first := newTestRunner(t, clk, locker, job)
+	second := newTestRunner(t, clk, locker, job)
+	start(t, first, clk, 1)
+	start(t, second, clk, 2)
+
+	clk.Advance(time.Minute)
+	require.Eventually(t, func() bool {
+		return status(first, "exclusive").Skips+status(second, "exclusive").Skips == 1
+	}, waitFor, time.Millisecond)
+	close(release)
+	require.Eventually(t, func() bool {
+		return status(first, "exclusive").Successes+status(second, "exclusive").Successes == 1
+	}, waitFor, time.Millisecond)
+
+	assert.Equal(t, int32(1), runs.Load())
+}
+
+func TestRunner_Failures(t *testing.T) {
+	clk := clock.NewFake(testNow)
+	r := newTestRunner(t, clk, newMemLocker(),
+		Job{
+			Name:     "fails",
+			Schedule: Every(time.Minute),
+			Run:      func(context.Context) error { return errors.New("boom") },
+		},
+		Job{
+			Name:     "panics",
+			Schedule: Every(time.Minute),
+			Run:      func(context.Context) error { panic("boom") },
+		},
+		Job{
+			Name:     "hangs",
+			Schedule: Every(time.Minute),
+			Timeout:  10 * time.Millisecond,
+			Run: func(ctx context.Context) error {
+				<-ctx.Done()
+				return ctx.Err()
+			},
+		},
+	)
+	start(t, r, clk, 3)
+
+	clk.Advance(time.Minute)
+	require.Eventually(t, func() bool {
+		for _, s := range r.Statuses() {
+			if s.Failures != 1 {
+				return false
+			}
+		}
+		return true
+	}, waitFor, time.Millisecond)
+
+	assert.Equal(t, "boom", status(r, "fails").LastError)
+	assert.Equal(t, "job panicked: boom", status(r, "panics").LastError)
+	assert.Equal(t, context.DeadlineExceeded.Error(), status(r, "hangs").LastError)
+
+	// The runner keeps running the jobs after a panic.
+	require.Eventually(t, func() bool { return clk.Timers() == 3 }, waitFor, time.Millisecond)
+	clk.Advance(time.Minute)
+	require.Eventually(t, func() bool { return status(r, "panics").Failures == 2 }, waitFor, time.Millisecond)
+}
+
+func TestRunner_Trigger(t *testing.T) {
+	clk := clock.NewFake(testNow)
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	r := newTestRunner(t, clk, newMemLocker(), Job{
+		Name:     "manual",
+		Schedule: Every(time.Hour),
+		Run: func(context.Context) error {
+			started <- struct{}{}
+			<-release
+			return nil
+		},
+	})
+
+	err := r.Trigger(context.Background(), "manual")
+	assert.Equal(t, errorx.CodeServiceUnavailable, errorx.CodeOf(err), "the runner is not running yet")
+
+	start(t, r, clk, 1)
+
+	err = r.Trigger(context.Background(), "unknown")
+	assert.Equal(t, errorx.CodeNotFound, errorx.CodeOf(err))
+
+	require.NoError(t, r.Trigger(context.Background(), "manual"))
+	<-started
+	assert.True(t, status(r, "manual").Running)
+
+	err = r.Trigger(context.Background(), "manual")
+	assert.Equal(t, errorx.CodeConflict, errorx.CodeOf(err), "the job is running")
+
+	close(release)
+	require.Eventually(t, func() bool { return status(r, "manual").Successes == 1 }, waitFor, time.Millisecond)
+	assert.False(t, status(r, "manual").Running)
+	assert.Equal(t, testNow.Add(time.Hour), status(r, "manual").NextRunAt, "a manual run keeps the schedule")
+}
+
+func TestRunner_Register(t *testing.T) {
+	r := newTestRunner(t, clock.NewFake(testNow), newMemLocker())
+	job := Job{Name: "job", Schedule: Every(time.Minute), Run: func(context.Context) error { return nil }}
+
+	require.NoError(t, r.Register(job))
+	assert.Error(t, r.Register(job), "duplicate name")
+	assert.Error(t, r.Register(Job{Name: "no-run", Schedule: Every(time.Minute)}))
+	assert.Equal(t, DefaultTimeout, r.jobs[0].job.Timeout)
+}
+
+func TestWeekly_Next(t *testing.T) {
+	w := Weekly{At: 6 * time.Hour}
+	monday := time.Date(2025, 3, 10, 6, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		now  time.Time
+		want time.Time
+	}{
+		{"before this week's run", monday.Add(-time.Hour), monday},
+		{"at this week's run", monday, monday.AddDate(0, 0, 7)},
+		{"later in the week", testNow, monday.AddDate(0, 0, 7)},
+		{"on Sunday", time.Date(2025, 3, 16, 23, 0, 0, 0, time.UTC), monday.AddDate(0, 0, 7)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, w.Next(tt.now))
+		})
+	}
+	assert.Equal(t, "weekly on Monday at 06:00 UTC", w.String())
+}
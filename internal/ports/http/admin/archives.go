@@ -0,0 +1,105 @@
+package adminhttp
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"slices"
+
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/httpx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/retention"
+)
+
+const (
+	defaultArchivesPageSize = 50
+	maxArchivesPageSize     = 200
+)
+
+// Archives are the manifests of the rows archived by the retention job.
+type Archives interface {
+	ListArchives(ctx context.Context, params retention.ListParams) ([]retention.Archive, error)
+}
+
+// ArchiveURLs sign the download URLs of the archives, they are private
+// objects.
+type ArchiveURLs interface {
+	URL(ctx context.Context, key string) (string, error)
+}
+
+type ArchiveResponse struct {
+	ID        string     `json:"id"`
+	Category  string     `json:"category"`
+	Table     string     `json:"table"`
+	Key       string     `json:"key"`
+	Rows      int64      `json:"rows"`
+	SizeBytes int64      `json:"size_bytes"`
+	Cutoff    httpx.Time `json:"cutoff"`
+	CreatedAt httpx.Time `json:"created_at"`
+	// DownloadURL is a signed URL, valid for STORAGE_SIGNED_URL_EXPIRY.
+	DownloadURL string `json:"download_url"`
+}
+
+// archiveTables are the tables ?table accepts.
+func archiveTables() []string {
+	var tables []string
+	for _, c := range retention.Categories {
+		tables = append(tables, c.Tables()...)
+	}
+	return slices.Sorted(slices.Values(tables))
+}
+
+// ListArchives lists the archives, the latest first, ?table narrows them to
+// the archives of a table and ?page pages them.
+func (h *HTTP) ListArchives(w http.ResponseWriter, r *http.Request) {
+	const op = "adminhttp.ListArchives"
+	ctx, span := h.tracer.Start(r.Context(), "HTTP.ListArchives")
+	defer span.End()
+
+	query := httpx.Query(r)
+	table := query.Enum("table", archiveTables()...)
+	page := query.Int("page", 1, math.MaxInt32, 1)
+	pageSize := query.Int("page_size", 1, maxArchivesPageSize, defaultArchivesPageSize)
+	if err := query.Err(); err != nil {
+		h.errhandler.HandleError(w, r, span, errorx.Wrap(err, op), "invalid query parameters")
+		return
+	}
+	otelx.SetSpanAttrs(span, map[string]any{
+		"request.table":     table,
+		"request.page":      page,
+		"request.page_size": pageSize,
+	})
+
+	archives, err := h.archives.ListArchives(ctx, retention.ListParams{
+		Table:  table,
+		Limit:  pageSize,
+		Offset: (page - 1) * pageSize,
+	})
+	if err != nil {
+		h.errhandler.HandleError(w, r, span, err, "failed to list archives")
+		return
+	}
+
+	res := make([]ArchiveResponse, len(archives))
+	for i, a := range archives {
+		downloadURL, err := h.archiveURLs.URL(ctx, a.Key)
+		if err != nil {
+			h.errhandler.HandleError(w, r, span, errorx.Wrap(err, op), "failed to sign archive URL")
+			return
+		}
+		res[i] = ArchiveResponse{
+			ID:          a.ID.String(),
+			Category:    string(a.Category),
+			Table:       a.Table,
+			Key:         a.Key,
+			Rows:        a.Rows,
+			SizeBytes:   a.Size,
+			Cutoff:      httpx.NewTime(a.Cutoff),
+			CreatedAt:   httpx.NewTime(a.CreatedAt),
+			DownloadURL: downloadURL,
+		}
+	}
+
+	httpx.Success(w, r, http.StatusOK, httpx.Envelope{"archives": res, "page": page})
+}
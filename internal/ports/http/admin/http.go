@@ -0,0 +1,387 @@
+package adminhttp
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/ARUMANDESU/validation"
+	"github.com/go-chi/chi/v5"
+	"go.opentelemetry.io/contrib/bridges/otelslog"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/jobs"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/ctxs"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorinbox"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/httpx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx/slowlog"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/validationstats"
+)
+
+var (
+	tracer = otel.Tracer("ucms/internal/ports/http/admin")
+	logger = otelslog.NewLogger("ucms/internal/ports/http/admin")
+)
+
+// maxThresholdMs caps the thresholds, a larger value effectively disables
+// the check, which is what zero is for.
+const maxThresholdMs = int64(10 * time.Minute / time.Millisecond)
+
+var thresholdRules = []validation.Rule{validation.Min(int64(0)), validation.Max(maxThresholdMs)}
+
+// ErrorEvents are the errors recorded by the error inbox.
+type ErrorEvents interface {
+	ListErrorEvents(ctx context.Context, params errorinbox.ListParams) ([]errorinbox.ErrorEvent, error)
+	SetErrorEventStatus(ctx context.Context, signature string, status errorinbox.Status) error
+}
+
+// ValidationStats are the request fields failing validation, counted by
+// validationstats.Recorder.
+type ValidationStats interface {
+	ListValidationStats(ctx context.Context, params validationstats.StatsParams) ([]validationstats.FieldStat, error)
+}
+
+// Jobs are the background jobs of the instance.
+type Jobs interface {
+	Statuses() []jobs.Status
+	Trigger(ctx context.Context, name string) error
+}
+
+// HTTP serves the operational settings that can be changed without a
+// restart, the error inbox, the validation stats, the background jobs and
+// the archives of the retention job.
+type HTTP struct {
+	tracer          trace.Tracer
+	logger          *slog.Logger
+	clock           clock.Clock
+	slow            *slowlog.Monitor
+	errorEvents     ErrorEvents
+	validationStats ValidationStats
+	jobs            Jobs
+	archives        Archives
+	archiveURLs     ArchiveURLs
+	errhandler      *httpx.ErrorHandler
+}
+
+type Args struct {
+	Tracer trace.Tracer
+	Logger *slog.Logger
+	Slow   *slowlog.Monitor
+	// Clock decides the days of the validation stats, defaults to clock.Real.
+	Clock clock.Clock
+	// ErrorEvents mounts /v1/staffs/system/errors when set.
+	ErrorEvents ErrorEvents
+	// ValidationStats mounts /v1/staffs/system/validation-stats when set.
+	ValidationStats ValidationStats
+	// Jobs mounts /v1/staffs/system/jobs when set.
+	Jobs Jobs
+	// Archives and ArchiveURLs mount /v1/staffs/system/archives when both
+	// are set.
+	Archives    Archives
+	ArchiveURLs ArchiveURLs
+	Errhandler  *httpx.ErrorHandler
+}
+
+func NewHTTP(args Args) *HTTP {
+	h := &HTTP{
+		tracer:          args.Tracer,
+		logger:          args.Logger,
+		clock:           clock.Or(args.Clock),
+		slow:            args.Slow,
+		errorEvents:     args.ErrorEvents,
+		validationStats: args.ValidationStats,
+		jobs:            args.Jobs,
+		archives:        args.Archives,
+		archiveURLs:     args.ArchiveURLs,
+		errhandler:      args.Errhandler,
+	}
+
+	if h.tracer == nil {
+		h.tracer = tracer
+	}
+	if h.logger == nil {
+		h.logger = logger
+	}
+	if h.slow == nil {
+		h.slow = slowlog.Default()
+	}
+	if h.errhandler == nil {
+		h.errhandler = httpx.NewErrorHandler()
+	}
+
+	return h
+}
+
+func (h *HTTP) Route(r chi.Router) {
+	r.Route("/v1/admin", func(r chi.Router) {
+		r.Get("/slow-thresholds", h.GetSlowThresholds)
+		r.Put("/slow-thresholds", h.UpdateSlowThresholds)
+	})
+
+	if h.errorEvents != nil {
+		r.Route("/v1/staffs/system/errors", func(r chi.Router) {
+			r.Get("/", h.ListErrors)
+			r.Post("/{signature}/resolve", h.setErrorStatus(errorinbox.StatusResolved))
+			r.Post("/{signature}/mute", h.setErrorStatus(errorinbox.StatusMuted))
+		})
+	}
+
+	if h.validationStats != nil {
+		r.Get("/v1/staffs/system/validation-stats", h.ListValidationStats)
+	}
+
+	if h.jobs != nil {
+		r.Route("/v1/staffs/system/jobs", func(r chi.Router) {
+			r.Get("/", h.ListJobs)
+			r.Post("/{name}/run", h.RunJob)
+		})
+	}
+
+	if h.archives != nil && h.archiveURLs != nil {
+		r.Get("/v1/staffs/system/archives", h.ListArchives)
+	}
+}
+
+type SlowThresholdsResponse struct {
+	HandlerMs int64 `json:"handler_ms"`
+	QueryMs   int64 `json:"query_ms"`
+}
+
+func newSlowThresholdsResponse(t slowlog.Thresholds) SlowThresholdsResponse {
+	return SlowThresholdsResponse{
+		HandlerMs: t.Handler.Milliseconds(),
+		QueryMs:   t.Query.Milliseconds(),
+	}
+}
+
+func (h *HTTP) GetSlowThresholds(w http.ResponseWriter, r *http.Request) {
+	_, span := h.tracer.Start(r.Context(), "HTTP.GetSlowThresholds")
+	defer span.End()
+
+	httpx.Success(w, r, http.StatusOK, httpx.Envelope{
+		"thresholds": newSlowThresholdsResponse(h.slow.Thresholds()),
+	})
+}
+
+// UpdateSlowThresholdsRequest changes the thresholds in milliseconds, a
+// missing field keeps the current value and zero disables the check.
+type UpdateSlowThresholdsRequest struct {
+	HandlerMs *int64 `json:"handler_ms"`
+	QueryMs   *int64 `json:"query_ms"`
+}
+
+func (r *UpdateSlowThresholdsRequest) SetSpanAttrs(span trace.Span) {
+	otelx.SetSpanAttrs(span, map[string]any{
+		"request.handler_ms": r.HandlerMs,
+		"request.query_ms":   r.QueryMs,
+	})
+}
+
+func (r *UpdateSlowThresholdsRequest) Validate() error {
+	return validation.ValidateStruct(r,
+		validation.Field(&r.HandlerMs, thresholdRules...),
+		validation.Field(&r.QueryMs, thresholdRules...),
+	)
+}
+
+func (h *HTTP) UpdateSlowThresholds(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "HTTP.UpdateSlowThresholds")
+	defer span.End()
+
+	ctxUser, err := ctxs.UserFromCtx(ctx)
+	if err != nil {
+		h.errhandler.HandleError(w, r, span, err, "failed to get user from context")
+		return
+	}
+	ctxUser.SetSpanAttrs(span)
+
+	var req UpdateSlowThresholdsRequest
+	if err := httpx.ReadJSON(w, r, &req); err != nil {
+		h.errhandler.HandleError(w, r, span, err, "failed to read body")
+		return
+	}
+
+	req.SetSpanAttrs(span)
+	err = req.Validate()
+	if err != nil {
+		h.errhandler.HandleError(w, r, span, err, "validation failed")
+		return
+	}
+
+	thresholds := h.slow.Thresholds()
+	if req.HandlerMs != nil {
+		thresholds.Handler = time.Duration(*req.HandlerMs) * time.Millisecond
+	}
+	if req.QueryMs != nil {
+		thresholds.Query = time.Duration(*req.QueryMs) * time.Millisecond
+	}
+	h.slow.SetThresholds(thresholds)
+
+	h.logger.InfoContext(ctx, "slow thresholds updated",
+		slog.String("user_id", ctxUser.ID.String()),
+		slog.Duration("handler", thresholds.Handler),
+		slog.Duration("query", thresholds.Query),
+	)
+
+	httpx.Success(w, r, http.StatusOK, httpx.Envelope{
+		"thresholds": newSlowThresholdsResponse(thresholds),
+	})
+}
+
+const (
+	defaultErrorsLimit = 50
+	maxErrorsLimit     = 200
+	statusAll          = "all"
+)
+
+type ErrorEventResponse struct {
+	Signature           string     `json:"signature"`
+	Type                string     `json:"type"`
+	Message             string     `json:"message"`
+	Frames              []string   `json:"frames"`
+	Route               string     `json:"route"`
+	SampleCorrelationID string     `json:"sample_correlation_id"`
+	Count               int64      `json:"count"`
+	Status              string     `json:"status"`
+	FirstSeen           httpx.Time `json:"first_seen"`
+	LastSeen            httpx.Time `json:"last_seen"`
+}
+
+// ListErrors lists the open errors, ?status=resolved, muted or all lists the
+// others and ?limit caps the number of errors.
+func (h *HTTP) ListErrors(w http.ResponseWriter, r *http.Request) {
+	const op = "adminhttp.ListErrors"
+	ctx, span := h.tracer.Start(r.Context(), "HTTP.ListErrors")
+	defer span.End()
+
+	query := httpx.Query(r)
+	params := errorinbox.ListParams{
+		Statuses: []errorinbox.Status{errorinbox.StatusOpen},
+		Limit:    query.Int("limit", 1, maxErrorsLimit, defaultErrorsLimit),
+	}
+	switch status := query.Enum("status", statusAll, string(errorinbox.StatusOpen), string(errorinbox.StatusResolved), string(errorinbox.StatusMuted)); status {
+	case "":
+	case statusAll:
+		params.Statuses = []errorinbox.Status{errorinbox.StatusOpen, errorinbox.StatusResolved, errorinbox.StatusMuted}
+	default:
+		params.Statuses = []errorinbox.Status{errorinbox.Status(status)}
+	}
+	if err := query.Err(); err != nil {
+		h.errhandler.HandleError(w, r, span, errorx.Wrap(err, op), "invalid query parameters")
+		return
+	}
+
+	events, err := h.errorEvents.ListErrorEvents(ctx, params)
+	if err != nil {
+		h.errhandler.HandleError(w, r, span, err, "failed to list error events")
+		return
+	}
+
+	res := make([]ErrorEventResponse, len(events))
+	for i, e := range events {
+		res[i] = ErrorEventResponse{
+			Signature:           e.Signature,
+			Type:                e.Type,
+			Message:             e.Message,
+			Frames:              e.Frames,
+			Route:               e.Route,
+			SampleCorrelationID: e.CorrelationID,
+			Count:               e.Count,
+			Status:              string(e.Status),
+			FirstSeen:           httpx.NewTime(e.FirstSeen),
+			LastSeen:            httpx.NewTime(e.LastSeen),
+		}
+	}
+
+	httpx.Success(w, r, http.StatusOK, httpx.Envelope{"errors": res})
+}
+
+const (
+	defaultValidationStatsDays = 7
+	maxValidationStatsDays     = 90
+	maxValidationStats         = 500
+)
+
+type ValidationStatResponse struct {
+	Route string `json:"route"`
+	Field string `json:"field"`
+	Count int64  `json:"count"`
+}
+
+// ListValidationStats lists the fields failing validation the most over the
+// last ?days, today included, ?route narrows them to one route, e.g.
+// POST /v1/registrations/students/complete.
+func (h *HTTP) ListValidationStats(w http.ResponseWriter, r *http.Request) {
+	const op = "adminhttp.ListValidationStats"
+	ctx, span := h.tracer.Start(r.Context(), "HTTP.ListValidationStats")
+	defer span.End()
+
+	query := httpx.Query(r)
+	route := query.String("route")
+	days := query.Int("days", 1, maxValidationStatsDays, defaultValidationStatsDays)
+	if err := query.Err(); err != nil {
+		h.errhandler.HandleError(w, r, span, errorx.Wrap(err, op), "invalid query parameters")
+		return
+	}
+	otelx.SetSpanAttrs(span, map[string]any{
+		"request.route": route,
+		"request.days":  days,
+	})
+
+	today := h.clock.Now().UTC().Truncate(24 * time.Hour)
+	stats, err := h.validationStats.ListValidationStats(ctx, validationstats.StatsParams{
+		Route: route,
+		Since: today.AddDate(0, 0, 1-days),
+		Limit: maxValidationStats,
+	})
+	if err != nil {
+		h.errhandler.HandleError(w, r, span, err, "failed to list validation stats")
+		return
+	}
+
+	res := make([]ValidationStatResponse, len(stats))
+	for i, s := range stats {
+		res[i] = ValidationStatResponse{Route: s.Route, Field: s.Field, Count: s.Count}
+	}
+
+	httpx.Success(w, r, http.StatusOK, httpx.Envelope{"stats": res, "days": days})
+}
+
+func (h *HTTP) setErrorStatus(status errorinbox.Status) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := h.tracer.Start(r.Context(), "HTTP.SetErrorStatus")
+		defer span.End()
+
+		ctxUser, err := ctxs.UserFromCtx(ctx)
+		if err != nil {
+			h.errhandler.HandleError(w, r, span, err, "failed to get user from context")
+			return
+		}
+		ctxUser.SetSpanAttrs(span)
+
+		signature := chi.URLParam(r, "signature")
+		otelx.SetSpanAttrs(span, map[string]any{
+			"request.signature": signature,
+			"request.status":    string(status),
+		})
+
+		err = h.errorEvents.SetErrorEventStatus(ctx, signature, status)
+		if err != nil {
+			h.errhandler.HandleError(w, r, span, err, "failed to set error event status")
+			return
+		}
+
+		h.logger.InfoContext(ctx, "error event status changed",
+			slog.String("user_id", ctxUser.ID.String()),
+			slog.String("signature", signature),
+			slog.String("status", string(status)),
+		)
+
+		httpx.Success(w, r, http.StatusOK, nil)
+	}
+}
@@ -0,0 +1,77 @@
+package adminhttp
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/roles"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/ctxs"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx/metrics"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx/slowlog"
+)
+
+// newRouter routes the port bare, the router of the application applies the
+// access policies.
+func newRouter(monitor *slowlog.Monitor) chi.Router {
+	r := chi.NewRouter()
+	NewHTTP(Args{Slow: monitor}).Route(r)
+	return r
+}
+
+// request is made by a staff member, as the auth middleware leaves it.
+func request(method, body string) *http.Request {
+	req := httptest.NewRequest(method, "/v1/admin/slow-thresholds", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	return req.WithContext(ctxs.WithUser(req.Context(), &ctxs.User{ID: user.NewID(), Role: roles.Staff}))
+}
+
+func TestUpdateSlowThresholds(t *testing.T) {
+	monitor := slowlog.NewMonitor(slowlog.Args{Metrics: metrics.NewRegistry(sdkmetric.NewMeterProvider().Meter("test"))})
+	router := newRouter(monitor)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, request(http.MethodPut, `{"query_ms": 50}`))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, slowlog.Thresholds{Handler: slowlog.DefaultHandlerThreshold, Query: 50 * time.Millisecond}, monitor.Thresholds())
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, request(http.MethodGet, ""))
+	require.Equal(t, http.StatusOK, rec.Code)
+	var body struct {
+		Thresholds SlowThresholdsResponse `json:"thresholds"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, SlowThresholdsResponse{HandlerMs: 2000, QueryMs: 50}, body.Thresholds)
+}
+
+func TestUpdateSlowThresholds_Rejected(t *testing.T) {
+	monitor := slowlog.NewMonitor(slowlog.Args{Metrics: metrics.NewRegistry(sdkmetric.NewMeterProvider().Meter("test"))})
+	router := newRouter(monitor)
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+	}{
+		{name: "negative", body: `{"handler_ms": -1}`, status: http.StatusBadRequest},
+		{name: "too large", body: `{"query_ms": 3600000}`, status: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, request(http.MethodPut, tt.body))
+			assert.Equal(t, tt.status, rec.Code, rec.Body.String())
+			assert.Equal(t, slowlog.DefaultThresholds(), monitor.Thresholds())
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package adminhttp
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/jobs"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/ctxs"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/httpx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx"
+)
+
+type JobResponse struct {
+	Name      string      `json:"name"`
+	Schedule  string      `json:"schedule"`
+	Running   bool        `json:"running"`
+	NextRunAt *httpx.Time `json:"next_run_at,omitempty"`
+	// The last run fields are left out until the first run.
+	LastStartedAt  *httpx.Time `json:"last_started_at,omitempty"`
+	LastDurationMs int64       `json:"last_duration_ms"`
+	LastResult     string      `json:"last_result,omitempty"`
+	LastError      string      `json:"last_error,omitempty"`
+	Successes      int64       `json:"successes"`
+	Failures       int64       `json:"failures"`
+	Skips          int64       `json:"skips"`
+}
+
+func newJobResponse(s jobs.Status) JobResponse {
+	return JobResponse{
+		Name:           s.Name,
+		Schedule:       s.Schedule,
+		Running:        s.Running,
+		NextRunAt:      zeroAsNil(s.NextRunAt),
+		LastStartedAt:  zeroAsNil(s.LastStartedAt),
+		LastDurationMs: s.LastDuration.Milliseconds(),
+		LastResult:     string(s.LastResult),
+		LastError:      s.LastError,
+		Successes:      s.Successes,
+		Failures:       s.Failures,
+		Skips:          s.Skips,
+	}
+}
+
+func zeroAsNil(t time.Time) *httpx.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &httpx.Time{Time: t}
+}
+
+// ListJobs lists the background jobs and their runs on this instance.
+func (h *HTTP) ListJobs(w http.ResponseWriter, r *http.Request) {
+	_, span := h.tracer.Start(r.Context(), "HTTP.ListJobs")
+	defer span.End()
+
+	statuses := h.jobs.Statuses()
+	res := make([]JobResponse, len(statuses))
+	for i, s := range statuses {
+		res[i] = newJobResponse(s)
+	}
+
+	httpx.Success(w, r, http.StatusOK, httpx.Envelope{"jobs": res})
+}
+
+// RunJob starts a run of the job now, it responds before the run ends.
+func (h *HTTP) RunJob(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "HTTP.RunJob")
+	defer span.End()
+
+	ctxUser, err := ctxs.UserFromCtx(ctx)
+	if err != nil {
+		h.errhandler.HandleError(w, r, span, err, "failed to get user from context")
+		return
+	}
+	ctxUser.SetSpanAttrs(span)
+
+	name := chi.URLParam(r, "name")
+	otelx.SetSpanAttrs(span, map[string]any{"request.job": name})
+
+	if err := h.jobs.Trigger(ctx, name); err != nil {
+		h.errhandler.HandleError(w, r, span, err, "failed to trigger job")
+		return
+	}
+
+	h.logger.InfoContext(ctx, "job run requested",
+		slog.String("user_id", ctxUser.ID.String()),
+		slog.String("job", name),
+	)
+
+	httpx.Success(w, r, http.StatusAccepted, nil)
+}
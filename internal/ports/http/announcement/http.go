@@ -0,0 +1,215 @@
+package announcementhttp
+
+import (
+	"log/slog"
+	"math"
+	"net/http"
+
+	"github.com/ARUMANDESU/validation"
+	"github.com/ARUMANDESU/validation/is"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/contrib/bridges/otelslog"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	announcementapp "gitlab.com/ucmsv2/ucms-backend/internal/application/announcement"
+	"gitlab.com/ucmsv2/ucms-backend/internal/application/announcement/announcementquery"
+	"gitlab.com/ucmsv2/ucms-backend/internal/application/announcement/cmd"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/announcement"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/group"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/ctxs"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/httpx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/sanitizex"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/validationx"
+)
+
+var (
+	tracer = otel.Tracer("ucms/internal/ports/http/announcement")
+	logger = otelslog.NewLogger("ucms/internal/ports/http/announcement")
+)
+
+type HTTP struct {
+	tracer     trace.Tracer
+	logger     *slog.Logger
+	app        *announcementapp.App
+	errhandler *httpx.ErrorHandler
+}
+
+type Args struct {
+	Tracer     trace.Tracer
+	Logger     *slog.Logger
+	App        *announcementapp.App
+	Errhandler *httpx.ErrorHandler
+}
+
+func NewHTTP(args Args) *HTTP {
+	if args.Tracer == nil {
+		args.Tracer = tracer
+	}
+	if args.Logger == nil {
+		args.Logger = logger
+	}
+
+	return &HTTP{
+		tracer:     args.Tracer,
+		logger:     args.Logger,
+		app:        args.App,
+		errhandler: args.Errhandler,
+	}
+}
+
+func (h *HTTP) Route(r chi.Router) {
+	r.Get("/v1/announcements", h.ListAnnouncements)
+	r.Post("/v1/aitusa/announcements", h.CreateAnnouncement)
+
+	// The staff port mounts /v1/staffs, chi matches this static path before
+	// the mount.
+	r.Post("/v1/staffs/announcements/{id}/unpublish", h.UnpublishAnnouncement)
+}
+
+type CreateAnnouncementRequest struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+	// GroupIDs are the target groups, none targets all students.
+	GroupIDs  []uuid.UUID `json:"group_ids"`
+	SendEmail bool        `json:"send_email"`
+}
+
+// Sanitize strips the markup of the title and the body, the students see
+// them in the notification center and the emails.
+func (r *CreateAnnouncementRequest) Sanitize() {
+	r.Title = sanitizex.TruncateRunes(sanitizex.CleanSingleLine(sanitizex.StripHTML(r.Title)), announcement.MaxTitleLen)
+	r.Body = sanitizex.CleanFreeText(r.Body, announcement.MaxBodyLen)
+}
+
+func (r *CreateAnnouncementRequest) SetSpanAttrs(span trace.Span) {
+	otelx.SetSpanAttrs(span, map[string]any{
+		"request.groups_count": len(r.GroupIDs),
+		"request.send_email":   r.SendEmail,
+	})
+}
+
+func (r *CreateAnnouncementRequest) Validate() error {
+	return validation.ValidateStruct(r,
+		validation.Field(&r.Title, validation.Required, validation.RuneLength(1, announcement.MaxTitleLen)),
+		validation.Field(&r.Body, validation.Required, validation.RuneLength(1, announcement.MaxBodyLen)),
+		validation.Field(&r.GroupIDs,
+			validation.Count(0, announcement.MaxTargetGroups),
+			validation.Each(validationx.Required),
+		),
+	)
+}
+
+func (h *HTTP) CreateAnnouncement(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "HTTP.CreateAnnouncement")
+	defer span.End()
+
+	ctxUser, err := ctxs.UserFromCtx(ctx)
+	if err != nil {
+		h.errhandler.HandleError(w, r, span, err, "failed to get user from context")
+		return
+	}
+	ctxUser.SetSpanAttrs(span)
+
+	var req CreateAnnouncementRequest
+	if err := httpx.ReadJSON(w, r, &req); err != nil {
+		h.errhandler.HandleError(w, r, span, err, "failed to read body")
+		return
+	}
+
+	req.Sanitize()
+	req.SetSpanAttrs(span)
+	if err := req.Validate(); err != nil {
+		h.errhandler.HandleError(w, r, span, err, "validation failed")
+		return
+	}
+
+	groupIDs := make([]group.ID, len(req.GroupIDs))
+	for i, id := range req.GroupIDs {
+		groupIDs[i] = group.ID(id)
+	}
+	err = h.app.Command.CreateAnnouncement.Handle(ctx, cmd.CreateAnnouncement{
+		AuthorID:  ctxUser.ID,
+		Title:     req.Title,
+		Body:      req.Body,
+		GroupIDs:  groupIDs,
+		SendEmail: req.SendEmail,
+	})
+	if err != nil {
+		h.errhandler.HandleError(w, r, span, err, "failed to create announcement")
+		return
+	}
+
+	httpx.Success(w, r, http.StatusCreated, nil)
+}
+
+// ListAnnouncements lists the announcements the user sees, the newest first,
+// ?page pages them. The staff see the unpublished ones too.
+func (h *HTTP) ListAnnouncements(w http.ResponseWriter, r *http.Request) {
+	const op = "announcementhttp.HTTP.ListAnnouncements"
+	ctx, span := h.tracer.Start(r.Context(), "HTTP.ListAnnouncements")
+	defer span.End()
+
+	ctxUser, err := ctxs.UserFromCtx(ctx)
+	if err != nil {
+		h.errhandler.HandleError(w, r, span, err, "failed to get user from context")
+		return
+	}
+	ctxUser.SetSpanAttrs(span)
+
+	query := httpx.Query(r)
+	list := announcementquery.ListAnnouncements{
+		UserID:   ctxUser.ID,
+		Role:     ctxUser.Role,
+		Page:     query.Int("page", 1, math.MaxInt32, 1),
+		PageSize: query.Int("page_size", 1, announcementquery.MaxPageSize, announcementquery.DefaultPageSize),
+	}
+	if err := query.Err(); err != nil {
+		h.errhandler.HandleError(w, r, span, errorx.Wrap(err, op), "invalid query parameters")
+		return
+	}
+
+	res, err := h.app.Query.ListAnnouncements.Handle(ctx, list)
+	if err != nil {
+		h.errhandler.HandleError(w, r, span, err, "failed to list announcements")
+		return
+	}
+
+	httpx.Success(w, r, http.StatusOK, httpx.Envelope{"announcements": res.Announcements})
+}
+
+func (h *HTTP) UnpublishAnnouncement(w http.ResponseWriter, r *http.Request) {
+	const op = "announcementhttp.HTTP.UnpublishAnnouncement"
+	ctx, span := h.tracer.Start(r.Context(), "HTTP.UnpublishAnnouncement")
+	defer span.End()
+
+	ctxUser, err := ctxs.UserFromCtx(ctx)
+	if err != nil {
+		h.errhandler.HandleError(w, r, span, err, "failed to get user from context")
+		return
+	}
+	ctxUser.SetSpanAttrs(span)
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		err = errorx.Wrap(validation.Errors{"id": is.ErrUUID}, op)
+		h.errhandler.HandleError(w, r, span, err, "invalid announcement id")
+		return
+	}
+	span.SetAttributes(attribute.String("request.announcement_id", id.String()))
+
+	err = h.app.Command.UnpublishAnnouncement.Handle(ctx, cmd.UnpublishAnnouncement{
+		StaffID:        ctxUser.ID,
+		AnnouncementID: announcement.ID(id),
+	})
+	if err != nil {
+		h.errhandler.HandleError(w, r, span, err, "failed to unpublish announcement")
+		return
+	}
+
+	httpx.Success(w, r, http.StatusOK, nil)
+}
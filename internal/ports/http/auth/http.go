@@ -8,7 +8,6 @@ import (
 	"time"
 
 	"github.com/ARUMANDESU/validation"
-	"github.com/ARUMANDESU/validation/is"
 	"github.com/go-chi/chi/v5"
 	"go.opentelemetry.io/contrib/bridges/otelslog"
 	"go.opentelemetry.io/otel"
@@ -19,6 +18,7 @@ import (
 	"gitlab.com/ucmsv2/ucms-backend/pkg/env"
 	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
 	"gitlab.com/ucmsv2/ucms-backend/pkg/httpx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx"
 	"gitlab.com/ucmsv2/ucms-backend/pkg/sanitizex"
 	"gitlab.com/ucmsv2/ucms-backend/pkg/validationx"
 )
@@ -51,6 +51,11 @@ type Args struct {
 	App          *authapp.App
 	Errhandler   *httpx.ErrorHandler
 	CookieDomain string
+	// TLS is set when the server terminates TLS itself, the cookies are then
+	// Secure in the modes allowing env.CapPlainHTTP as well.
+	TLS bool
+	// Mode defaults to env.Current.
+	Mode env.Mode
 }
 
 func NewHTTP(args Args) *HTTP {
@@ -74,9 +79,12 @@ func NewHTTP(args Args) *HTTP {
 	if h.errhandler == nil {
 		h.errhandler = httpx.NewErrorHandler()
 	}
-	if env.Current() == env.Local {
+	if args.Mode == "" {
+		args.Mode = env.Current()
+	}
+	if args.Mode.Allows(env.CapPlainHTTP) {
 		h.cookiedomain = "localhost"
-		h.secure = false // for local development with http
+		h.secure = args.TLS // for local development with http
 	}
 
 	return h
@@ -86,6 +94,11 @@ func (h *HTTP) Route(r chi.Router) {
 	r.Post("/v1/auth/login", h.Login)
 	r.Post("/v1/auth/refresh", h.Refresh)
 	r.Post("/v1/auth/logout", h.Logout)
+
+	// The staff port mounts /v1/staffs, chi matches these static paths before
+	// the mount.
+	r.Post("/v1/staffs/users/{barcode}/impersonate", h.Impersonate)
+	r.Get("/v1/staffs/audit/impersonations", h.ListImpersonations)
 }
 
 type LoginRequest struct {
@@ -98,13 +111,16 @@ func (r *LoginRequest) Sanitized() {
 	r.EmailOrBarcode = sanitizex.CleanSingleLine(r.EmailOrBarcode)
 	r.Password = strings.TrimSpace(r.Password)
 	r.isEmail, r.isBarcode = validationx.IsEmailOrBarcode(r.EmailOrBarcode)
+	if r.isEmail {
+		r.EmailOrBarcode = sanitizex.CleanEmail(r.EmailOrBarcode)
+	}
 }
 
 func (r *LoginRequest) SetSpanAttrs(span trace.Span) {
 	if r.isEmail {
-		span.SetAttributes(attribute.String("email", r.EmailOrBarcode))
+		span.SetAttributes(attribute.String("email", otelx.Redact("email", r.EmailOrBarcode)))
 	} else if r.isBarcode {
-		span.SetAttributes(attribute.String("barcode", r.EmailOrBarcode))
+		span.SetAttributes(attribute.String("barcode", otelx.Redact("barcode", r.EmailOrBarcode)))
 	}
 }
 
@@ -113,7 +129,7 @@ func (r *LoginRequest) Validate() error {
 	if r.isEmail {
 		copy(validationRules, validationx.EmailRules)
 	} else if r.isBarcode {
-		validationRules = append(validationRules, validation.Length(0, 80), is.Alphanumeric)
+		validationRules = append(validationRules, validationx.IsBarcode)
 	}
 
 	return validation.ValidateStruct(r,
@@ -203,7 +219,11 @@ func (h *HTTP) Refresh(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	res, err := h.app.RefreshHandle(ctx, authapp.Refresh{RefreshToken: refreshCookie.Value})
+	refresh := authapp.Refresh{RefreshToken: refreshCookie.Value}
+	if accessCookie, err := r.Cookie(AccessJWTCookie); err == nil {
+		refresh.AccessToken = accessCookie.Value
+	}
+	res, err := h.app.RefreshHandle(ctx, refresh)
 	if err != nil {
 		h.resetCookies(w)
 		err = errorx.NewInvalidCredentials().WithCause(err, op)
@@ -0,0 +1,88 @@
+package authhttp
+
+import (
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	authapp "gitlab.com/ucmsv2/ucms-backend/internal/application/auth"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/ctxs"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/httpx"
+)
+
+type ImpersonateResponse struct {
+	TargetID  string     `json:"target_id"`
+	ExpiresAt httpx.Time `json:"expires_at"`
+}
+
+// Impersonate replaces the access token of the staff member with one of the
+// user of the barcode. The refresh token is left as it is, the refresh of the
+// impersonation token fails and ends the session with a new login.
+func (h *HTTP) Impersonate(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "HTTP.Impersonate")
+	defer span.End()
+
+	ctxUser, err := ctxs.UserFromCtx(ctx)
+	if err != nil {
+		h.errhandler.HandleError(w, r, span, err, "failed to get user from context")
+		return
+	}
+	ctxUser.SetSpanAttrs(span)
+
+	res, err := h.app.ImpersonateHandle(ctx, authapp.Impersonate{
+		ActorID:       ctxUser.ID,
+		TargetBarcode: chi.URLParam(r, "barcode"),
+		IP:            ctxs.ClientIPFromCtx(ctx),
+	})
+	if err != nil {
+		h.errhandler.HandleError(w, r, span, err, "failed to impersonate user")
+		return
+	}
+
+	expiresAt := time.Now().Add(res.AccessTokenExp).UTC()
+	http.SetCookie(w, &http.Cookie{
+		Name:     AccessJWTCookie,
+		Value:    res.AccessToken,
+		Path:     "/",
+		Domain:   h.cookiedomain,
+		Expires:  expiresAt,
+		MaxAge:   int(res.AccessTokenExp.Seconds()),
+		Secure:   h.secure,
+		HttpOnly: h.httpOnly,
+		SameSite: h.sameSite,
+	})
+
+	httpx.Success(w, r, http.StatusOK, httpx.Envelope{"impersonation": ImpersonateResponse{
+		TargetID:  res.TargetID.String(),
+		ExpiresAt: httpx.NewTime(expiresAt),
+	}})
+}
+
+// ListImpersonations lists the impersonation sessions, the latest first,
+// ?page pages them.
+func (h *HTTP) ListImpersonations(w http.ResponseWriter, r *http.Request) {
+	const op = "authhttp.HTTP.ListImpersonations"
+	ctx, span := h.tracer.Start(r.Context(), "HTTP.ListImpersonations")
+	defer span.End()
+
+	query := httpx.Query(r)
+	list := authapp.ListImpersonations{
+		Page:     query.Int("page", 1, math.MaxInt32, 1),
+		PageSize: query.Int("page_size", 1, authapp.MaxImpersonationsPageSize, authapp.DefaultImpersonationsPageSize),
+	}
+	if err := query.Err(); err != nil {
+		h.errhandler.HandleError(w, r, span, errorx.Wrap(err, op), "invalid query parameters")
+		return
+	}
+
+	res, err := h.app.ListImpersonationsHandle(ctx, list)
+	if err != nil {
+		h.errhandler.HandleError(w, r, span, err, "failed to list impersonations")
+		return
+	}
+
+	httpx.Success(w, r, http.StatusOK, httpx.Envelope{"impersonations": res})
+}
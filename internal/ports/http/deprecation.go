@@ -0,0 +1,112 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"go.opentelemetry.io/contrib/bridges/otelslog"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx/metrics"
+)
+
+var logger = otelslog.NewLogger("ucms/internal/ports/http")
+
+// Deprecation marks a route of Policies the clients must move off, its
+// responses carry the Deprecation (RFC 9745), Sunset (RFC 8594) and
+// successor Link headers.
+type Deprecation struct {
+	Method  string
+	Pattern string
+	// Since is when the route was deprecated.
+	Since time.Time
+	// Sunset is when the route goes away, zero until it is planned.
+	Sunset time.Time
+	// Successor is the path of the route replacing it, usually its /v2
+	// variant, empty when there is none.
+	Successor string
+}
+
+// Deprecations are the deprecated routes, next to their Policies. Keep a
+// route here until its sunset, then remove it with its policy. The router
+// refuses to start with a deprecation of a route without a policy or with a
+// successor it does not route, see deprecationIndex and checkSuccessors.
+var Deprecations = []Deprecation{}
+
+func deprecationIndex(deprecations []Deprecation, policies map[string]Access) map[string]Deprecation {
+	index := make(map[string]Deprecation, len(deprecations))
+	for _, d := range deprecations {
+		key := PolicyKey(d.Method, d.Pattern)
+		if _, ok := policies[key]; !ok {
+			panic("deprecation of a route without a policy " + key)
+		}
+		if _, ok := index[key]; ok {
+			panic("duplicate route deprecation " + key)
+		}
+		index[key] = d
+	}
+	return index
+}
+
+// checkSuccessors panics when the successor of a deprecation is not served
+// by routes with the method of the deprecated route, run it once every route
+// is registered.
+func checkSuccessors(deprecations []Deprecation, routes chi.Routes) {
+	for _, d := range deprecations {
+		if d.Successor != "" && !routes.Match(chi.NewRouteContext(), d.Method, d.Successor) {
+			panic(fmt.Sprintf("deprecation of %s with an unrouted successor %s",
+				PolicyKey(d.Method, d.Pattern), d.Successor))
+		}
+	}
+}
+
+// setHeaders sets the deprecation headers of the responses of d.
+func (d Deprecation) setHeaders(h http.Header) {
+	h.Set("Deprecation", "@"+strconv.FormatInt(d.Since.Unix(), 10))
+	if !d.Sunset.IsZero() {
+		h.Set("Sunset", d.Sunset.UTC().Format(http.TimeFormat))
+	}
+	if d.Successor != "" {
+		h.Add("Link", fmt.Sprintf("<%s>; rel=\"successor-version\"", d.Successor))
+	}
+}
+
+// deprecatedCalls counts the calls of the deprecated routes, to tell when a
+// route is unused and can go before its sunset.
+type deprecatedCalls struct {
+	counter metric.Int64Counter
+}
+
+func newDeprecatedCalls(reg *metrics.Registry) deprecatedCalls {
+	return deprecatedCalls{counter: reg.Int64Counter(metrics.DeprecatedCalls,
+		metric.WithDescription("Number of requests to deprecated routes"),
+		metric.WithUnit("{request}"),
+	)}
+}
+
+func (c deprecatedCalls) add(ctx context.Context, key string) {
+	c.counter.Add(ctx, 1, metric.WithAttributes(attribute.String(metrics.AttrRoute, key)))
+}
+
+// logDeprecations lists the deprecated routes once at startup.
+func logDeprecations(ctx context.Context, deprecations []Deprecation) {
+	for _, d := range deprecations {
+		attrs := []any{
+			slog.String("route", PolicyKey(d.Method, d.Pattern)),
+			slog.Time("since", d.Since),
+		}
+		if !d.Sunset.IsZero() {
+			attrs = append(attrs, slog.Time("sunset", d.Sunset))
+		}
+		if d.Successor != "" {
+			attrs = append(attrs, slog.String("successor", d.Successor))
+		}
+		logger.InfoContext(ctx, "Serving a deprecated route", attrs...)
+	}
+}
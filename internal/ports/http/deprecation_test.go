@@ -0,0 +1,120 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+
+	authapp "gitlab.com/ucmsv2/ucms-backend/internal/application/auth"
+	"gitlab.com/ucmsv2/ucms-backend/internal/application/registration"
+	staffapp "gitlab.com/ucmsv2/ucms-backend/internal/application/staff"
+	studentapp "gitlab.com/ucmsv2/ucms-backend/internal/application/student"
+	userapp "gitlab.com/ucmsv2/ucms-backend/internal/application/user"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/buildinfo"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx/metrics"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/urlx"
+)
+
+// deprecate marks routes deprecated for the test, the Port reads
+// Deprecations when it routes.
+func deprecate(t *testing.T, deprecations ...Deprecation) {
+	t.Helper()
+	saved := Deprecations
+	Deprecations = deprecations
+	t.Cleanup(func() { Deprecations = saved })
+}
+
+func TestDeprecations_HavePolicies(t *testing.T) {
+	policies := policyIndex(Policies)
+	assert.NotPanics(t, func() { deprecationIndex(Deprecations, policies) })
+	assert.Panics(t, func() {
+		deprecationIndex([]Deprecation{{Method: http.MethodGet, Pattern: "/v1/nothing"}}, policies)
+	}, "a deprecated route needs a policy")
+}
+
+func TestDeprecations_SuccessorsRouted(t *testing.T) {
+	router := newTestPort(false).Route(nil)
+	assert.NotPanics(t, func() { checkSuccessors(Deprecations, router) })
+	assert.NotPanics(t, func() {
+		checkSuccessors([]Deprecation{{Method: http.MethodGet, Pattern: "/v1/version", Successor: "/v2/version"}}, router)
+	})
+	assert.Panics(t, func() {
+		checkSuccessors([]Deprecation{{Method: http.MethodGet, Pattern: "/v1/version", Successor: "/v3/version"}}, router)
+	}, "a successor needs a route")
+	assert.Panics(t, func() {
+		checkSuccessors([]Deprecation{{Method: http.MethodPost, Pattern: "/v1/version", Successor: "/v2/version"}}, router)
+	}, "a successor needs a route of the same method")
+}
+
+func TestPort_Deprecation(t *testing.T) {
+	since := time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)
+	sunset := time.Date(2027, 3, 1, 0, 0, 0, 0, time.UTC)
+	deprecate(t, Deprecation{
+		Method:    http.MethodGet,
+		Pattern:   "/v1/version",
+		Since:     since,
+		Sunset:    sunset,
+		Successor: "/v2/version",
+	})
+
+	reader := sdkmetric.NewManualReader()
+	router := NewPort(Args{
+		RegistrationApp:         &registration.App{},
+		AuthApp:                 &authapp.App{},
+		StudentApp:              &studentapp.App{},
+		StaffApp:                &staffapp.App{},
+		UserApp:                 &userapp.App{},
+		Secret:                  []byte("secret"),
+		AcceptInvitationPageURL: urlx.MustParse("https://ucms.kz/invitations/accept"),
+		InvitationTokenKey:      "secret",
+		BuildInfo:               buildinfo.Info{Version: "1.4.0", Commit: "4b158c7", BuildDate: "2025-08-01T10:00:00Z"},
+		Metrics:                 metrics.NewRegistry(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)).Meter("test")),
+	}).Route(nil)
+
+	serve := func(path string) (*httptest.ResponseRecorder, map[string]any) {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		require.Equal(t, http.StatusOK, rec.Code)
+		var body map[string]any
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		return rec, body
+	}
+
+	v1, v1Body := serve("/v1/version")
+	assert.Equal(t, "@1788220800", v1.Header().Get("Deprecation"))
+	assert.Equal(t, "Mon, 01 Mar 2027 00:00:00 GMT", v1.Header().Get("Sunset"))
+	assert.Equal(t, `</v2/version>; rel="successor-version"`, v1.Header().Get("Link"))
+	assert.Equal(t, "1.4.0", v1Body["version"], "the /v1 shape is kept")
+
+	v2, v2Body := serve("/v2/version")
+	assert.Empty(t, v2.Header().Get("Deprecation"))
+	assert.Empty(t, v2.Header().Get("Sunset"))
+	assert.Empty(t, v2.Header().Get("Link"))
+	assert.NotContains(t, v2Body, "version")
+	assert.Equal(t, map[string]any{
+		"version":    "1.4.0",
+		"commit":     "4b158c7",
+		"build_date": "2025-08-01T10:00:00Z",
+	}, v2Body["build"])
+
+	serve("/v1/version")
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	require.Len(t, rm.ScopeMetrics, 1)
+	require.Len(t, rm.ScopeMetrics[0].Metrics, 1)
+	m := rm.ScopeMetrics[0].Metrics[0]
+	assert.Equal(t, metrics.DeprecatedCalls, m.Name)
+	dps := m.Data.(metricdata.Sum[int64]).DataPoints
+	require.Len(t, dps, 1)
+	assert.Equal(t, int64(2), dps[0].Value, "the /v2 calls are not counted")
+	route, _ := dps[0].Attributes.Value(metrics.AttrRoute)
+	assert.Equal(t, "GET /v1/version", route.AsString())
+}
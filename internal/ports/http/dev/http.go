@@ -0,0 +1,129 @@
+package devhttp
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"go.opentelemetry.io/contrib/bridges/otelslog"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/env"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/httpx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx"
+)
+
+var (
+	tracer = otel.Tracer("ucms/internal/ports/http/dev")
+	logger = otelslog.NewLogger("ucms/internal/ports/http/dev")
+)
+
+// HTTP serves the endpoints that help to test the deployed application by
+// hand, e.g. moving its clock past a cooldown. It is mounted only in the
+// modes allowing env.CapDevClock.
+type HTTP struct {
+	tracer     trace.Tracer
+	logger     *slog.Logger
+	clock      clock.Settable
+	errhandler *httpx.ErrorHandler
+	mode       env.Mode
+}
+
+type Args struct {
+	Tracer trace.Tracer
+	Logger *slog.Logger
+	// Clock is the clock of the application, POST /v1/dev/clock is mounted
+	// only when it is set.
+	Clock      clock.Settable
+	Errhandler *httpx.ErrorHandler
+	// Mode defaults to env.Current.
+	Mode env.Mode
+}
+
+func NewHTTP(args Args) *HTTP {
+	h := &HTTP{
+		tracer:     args.Tracer,
+		logger:     args.Logger,
+		clock:      args.Clock,
+		errhandler: args.Errhandler,
+		mode:       args.Mode,
+	}
+
+	if h.tracer == nil {
+		h.tracer = tracer
+	}
+	if h.logger == nil {
+		h.logger = logger
+	}
+	if h.errhandler == nil {
+		h.errhandler = httpx.NewErrorHandler()
+	}
+	if h.mode == "" {
+		h.mode = env.Current()
+	}
+
+	return h
+}
+
+func (h *HTTP) Route(r chi.Router) {
+	if !h.mode.Allows(env.CapDevClock) {
+		return
+	}
+
+	if h.clock != nil {
+		r.Post("/v1/dev/clock", h.SetClock)
+	}
+}
+
+// SetClockRequest moves the clock either by Advance, a Go duration such as
+// "90s", or to Set.
+type SetClockRequest struct {
+	Advance string     `json:"advance"`
+	Set     *time.Time `json:"set"`
+}
+
+func (r *SetClockRequest) SetSpanAttrs(span trace.Span) {
+	otelx.SetSpanAttrs(span, map[string]any{
+		"request.advance": r.Advance,
+		"request.set":     r.Set,
+	})
+}
+
+func (h *HTTP) SetClock(w http.ResponseWriter, r *http.Request) {
+	const op = "devhttp.SetClock"
+	ctx, span := h.tracer.Start(r.Context(), "HTTP.SetClock")
+	defer span.End()
+
+	var req SetClockRequest
+	if err := httpx.ReadJSON(w, r, &req); err != nil {
+		h.errhandler.HandleError(w, r, span, err, "failed to read body")
+		return
+	}
+	req.SetSpanAttrs(span)
+
+	switch {
+	case req.Advance != "" && req.Set == nil:
+		d, err := time.ParseDuration(req.Advance)
+		if err != nil {
+			err = errorx.NewInvalidRequest().WithCause(err, op).WithDetails("advance must be a duration such as 90s")
+			h.errhandler.HandleError(w, r, span, err, "invalid advance")
+			return
+		}
+		h.clock.Advance(d)
+	case req.Advance == "" && req.Set != nil:
+		h.clock.Set(*req.Set)
+	default:
+		err := errorx.NewInvalidRequest().WithOp(op).WithDetails("exactly one of advance and set is required")
+		h.errhandler.HandleError(w, r, span, err, "invalid request")
+		return
+	}
+
+	now := h.clock.Now()
+	h.logger.InfoContext(ctx, "clock moved", slog.Time("now", now))
+
+	httpx.Success(w, r, http.StatusOK, httpx.Envelope{"now": now})
+}
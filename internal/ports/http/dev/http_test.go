@@ -0,0 +1,90 @@
+package devhttp
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/env"
+)
+
+var testNow = time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func setMode(t *testing.T, mode env.Mode) {
+	t.Helper()
+	prev := env.Current()
+	env.SetMode(mode)
+	t.Cleanup(func() { env.SetMode(prev) })
+}
+
+func serve(t *testing.T, clk clock.Settable, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	r := chi.NewRouter()
+	NewHTTP(Args{Clock: clk}).Route(r)
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/dev/clock", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestSetClock(t *testing.T) {
+	setMode(t, env.Test)
+
+	tests := []struct {
+		name     string
+		body     string
+		wantCode int
+		wantNow  time.Time
+	}{
+		{name: "advance", body: `{"advance":"90s"}`, wantCode: http.StatusOK, wantNow: testNow.Add(90 * time.Second)},
+		{name: "set", body: `{"set":"2025-03-02T08:00:00Z"}`, wantCode: http.StatusOK, wantNow: time.Date(2025, 3, 2, 8, 0, 0, 0, time.UTC)},
+		{name: "invalid duration", body: `{"advance":"soon"}`, wantCode: http.StatusBadRequest, wantNow: testNow},
+		{name: "both", body: `{"advance":"1m","set":"2025-03-02T08:00:00Z"}`, wantCode: http.StatusBadRequest, wantNow: testNow},
+		{name: "neither", body: `{}`, wantCode: http.StatusBadRequest, wantNow: testNow},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clk := clock.NewFake(testNow)
+
+			rec := serve(t, clk, tt.body)
+
+			require.Equal(t, tt.wantCode, rec.Code, rec.Body.String())
+			assert.True(t, tt.wantNow.Equal(clk.Now()), "clock is at %s, want %s", clk.Now(), tt.wantNow)
+			if tt.wantCode != http.StatusOK {
+				return
+			}
+			var body struct{ Now time.Time }
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+			assert.True(t, tt.wantNow.Equal(body.Now), "response now is %s, want %s", body.Now, tt.wantNow)
+		})
+	}
+}
+
+func TestSetClock_NotMountedOutsideDev(t *testing.T) {
+	setMode(t, env.Prod)
+	clk := clock.NewFake(testNow)
+
+	rec := serve(t, clk, `{"advance":"1h"}`)
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.True(t, testNow.Equal(clk.Now()))
+}
+
+func TestSetClock_NotMountedWithoutClock(t *testing.T) {
+	setMode(t, env.Dev)
+
+	rec := serve(t, nil, `{"advance":"1h"}`)
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
@@ -0,0 +1,133 @@
+package fileshttp
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"go.opentelemetry.io/contrib/bridges/otelslog"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/httpx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/storagex"
+)
+
+var (
+	tracer = otel.Tracer("ucms/internal/ports/http/files")
+	logger = otelslog.NewLogger("ucms/internal/ports/http/files")
+)
+
+const (
+	cacheControl        = "public, max-age=604800" // 1 week, same as the S3 uploads
+	privateCacheControl = "private, no-store"
+)
+
+type FileStorage interface {
+	OpenObject(ctx context.Context, key string) (io.ReadCloser, storagex.ObjectInfo, error)
+	// VerifySignature checks a presigned URL of the object.
+	VerifySignature(key string, query url.Values) error
+}
+
+// HTTP serves objects from the local filesystem storage. It is only mounted
+// when STORAGE_BACKEND=fs, with S3 the clients fetch objects from the bucket directly.
+type HTTP struct {
+	tracer     trace.Tracer
+	logger     *slog.Logger
+	storage    FileStorage
+	errhandler *httpx.ErrorHandler
+}
+
+type Args struct {
+	Tracer     trace.Tracer
+	Logger     *slog.Logger
+	Storage    FileStorage
+	Errhandler *httpx.ErrorHandler
+}
+
+func NewHTTP(args Args) *HTTP {
+	if args.Tracer == nil {
+		args.Tracer = tracer
+	}
+	if args.Logger == nil {
+		args.Logger = logger
+	}
+
+	return &HTTP{
+		tracer:     args.Tracer,
+		logger:     args.Logger,
+		storage:    args.Storage,
+		errhandler: args.Errhandler,
+	}
+}
+
+func (h *HTTP) Route(r chi.Router) {
+	r.Get("/v1/files/*", h.GetFile)
+}
+
+func (h *HTTP) GetFile(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "HTTP.GetFile")
+	defer span.End()
+
+	key := chi.URLParam(r, "*")
+	span.SetAttributes(attribute.String("file.key", key))
+
+	// Only the avatars are public. The archives are only served on a
+	// presigned URL, the way a private bucket would, and the other objects,
+	// e.g. the tmp/ ones, are not served at all.
+	private := strings.HasPrefix(key, storagex.ArchiveKeyPrefix)
+	switch {
+	case private:
+		if err := h.storage.VerifySignature(key, r.URL.Query()); err != nil {
+			h.errhandler.HandleError(w, r, span, err, "invalid file signature")
+			return
+		}
+	case !strings.HasPrefix(key, user.AvatarKeyPrefix):
+		h.errhandler.HandleError(w, r, span, storagex.ErrObjectNotFound, "file is not public")
+		return
+	}
+
+	body, info, err := h.storage.OpenObject(ctx, key)
+	if err != nil {
+		h.errhandler.HandleError(w, r, span, err, "failed to open file")
+		return
+	}
+	defer func() {
+		if cerr := body.Close(); cerr != nil {
+			h.logger.WarnContext(ctx, "failed to close file", slog.String("error", cerr.Error()))
+		}
+	}()
+
+	contentType := info.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	w.Header().Set("Content-Type", contentType)
+	if private {
+		w.Header().Set("Cache-Control", privateCacheControl)
+	} else {
+		w.Header().Set("Cache-Control", cacheControl)
+	}
+
+	if rs, ok := body.(io.ReadSeeker); ok {
+		// ServeContent takes care of Range, If-Modified-Since and HEAD requests.
+		http.ServeContent(w, r, "", info.LastModified, rs)
+		return
+	}
+
+	w.Header().Set("Content-Length", strconv.FormatInt(info.Size, 10))
+	if !info.LastModified.IsZero() {
+		w.Header().Set("Last-Modified", info.LastModified.UTC().Format(http.TimeFormat))
+	}
+	w.WriteHeader(http.StatusOK)
+	if _, err := io.Copy(w, body); err != nil {
+		h.logger.WarnContext(ctx, "failed to write file", slog.String("key", key), slog.String("error", err.Error()))
+	}
+}
@@ -0,0 +1,44 @@
+package fileshttp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/adapters/services/fs"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/httpx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/storagex"
+)
+
+func TestGetFile_OnlyAvatarsArePublic(t *testing.T) {
+	storage, err := fs.NewStorage(t.TempDir(), "http://localhost:8080/v1/files")
+	require.NoError(t, err)
+	storage.WithSigningKey([]byte("test-signing-key"))
+
+	keys := map[string]int{
+		user.AvatarKeyPrefix + "abc":             http.StatusOK,
+		storagex.TmpKeyPrefix + "selftest/abc":   http.StatusNotFound,
+		"other/abc":                              http.StatusNotFound,
+		storagex.ArchiveKeyPrefix + "abc.ndjson": http.StatusForbidden,
+	}
+	for key := range keys {
+		require.NoError(t, storage.UploadFile(t.Context(), key, strings.NewReader("content"), "text/plain"))
+	}
+
+	r := chi.NewRouter()
+	NewHTTP(Args{Storage: storage, Errhandler: httpx.NewErrorHandler()}).Route(r)
+
+	for key, want := range keys {
+		t.Run(key, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/files/"+key, nil))
+			assert.Equal(t, want, rec.Code)
+		})
+	}
+}
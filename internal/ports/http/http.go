@@ -1,6 +1,7 @@
 package http
 
 import (
+	"context"
 	"net/http"
 	"time"
 
@@ -8,27 +9,87 @@ import (
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/golang-jwt/jwt/v5"
 
+	announcementapp "gitlab.com/ucmsv2/ucms-backend/internal/application/announcement"
 	authapp "gitlab.com/ucmsv2/ucms-backend/internal/application/auth"
+	notificationapp "gitlab.com/ucmsv2/ucms-backend/internal/application/notification"
 	"gitlab.com/ucmsv2/ucms-backend/internal/application/registration"
+	reportapp "gitlab.com/ucmsv2/ucms-backend/internal/application/report"
+	searchapp "gitlab.com/ucmsv2/ucms-backend/internal/application/search"
 	staffapp "gitlab.com/ucmsv2/ucms-backend/internal/application/staff"
+	statusapp "gitlab.com/ucmsv2/ucms-backend/internal/application/status"
 	studentapp "gitlab.com/ucmsv2/ucms-backend/internal/application/student"
+	termapp "gitlab.com/ucmsv2/ucms-backend/internal/application/term"
+	tosapp "gitlab.com/ucmsv2/ucms-backend/internal/application/tos"
 	userapp "gitlab.com/ucmsv2/ucms-backend/internal/application/user"
+	adminhttp "gitlab.com/ucmsv2/ucms-backend/internal/ports/http/admin"
+	announcementhttp "gitlab.com/ucmsv2/ucms-backend/internal/ports/http/announcement"
 	authhttp "gitlab.com/ucmsv2/ucms-backend/internal/ports/http/auth"
+	devhttp "gitlab.com/ucmsv2/ucms-backend/internal/ports/http/dev"
+	fileshttp "gitlab.com/ucmsv2/ucms-backend/internal/ports/http/files"
 	"gitlab.com/ucmsv2/ucms-backend/internal/ports/http/middlewares"
 	registrationhttp "gitlab.com/ucmsv2/ucms-backend/internal/ports/http/registration"
+	reporthttp "gitlab.com/ucmsv2/ucms-backend/internal/ports/http/report"
+	searchhttp "gitlab.com/ucmsv2/ucms-backend/internal/ports/http/search"
 	staffhttp "gitlab.com/ucmsv2/ucms-backend/internal/ports/http/staff"
+	statushttp "gitlab.com/ucmsv2/ucms-backend/internal/ports/http/status"
 	studenthttp "gitlab.com/ucmsv2/ucms-backend/internal/ports/http/student"
+	termhttp "gitlab.com/ucmsv2/ucms-backend/internal/ports/http/term"
+	toshttp "gitlab.com/ucmsv2/ucms-backend/internal/ports/http/tos"
 	userhttp "gitlab.com/ucmsv2/ucms-backend/internal/ports/http/user"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/buildinfo"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/env"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorinbox"
 	"gitlab.com/ucmsv2/ucms-backend/pkg/httpx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/lastseen"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx/metrics"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx/slowlog"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/schemaversion"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/urlx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/validationstats"
 )
 
+// Schema is the schema of the database against the migrations of the
+// binary, see schemaversion.Checker.
+type Schema interface {
+	Versions() schemaversion.Versions
+	Readiness
+}
+
+// Readiness fails GET /ready with the reason while the instance must not be
+// sent traffic.
+type Readiness interface {
+	Ready() (bool, string)
+}
+
 type Port struct {
 	serviceName string
+	schema      Schema
+	storage     Readiness
+	tls         bool
+	mode        env.Mode
+	opsListener bool
+	opsLoopback bool
+	buildInfo   buildinfo.Info
+	slow        *slowlog.Monitor
+	deprecated  deprecatedCalls
+	panics      middlewares.PanicRecorder
+	middleware  *middlewares.Middleware
+	errhandler  *httpx.ErrorHandler
+	admin       *adminhttp.HTTP
 	reg         *registrationhttp.HTTP
 	auth        *authhttp.HTTP
 	student     *studenthttp.HTTP
 	staff       *staffhttp.HTTP
 	user        *userhttp.HTTP
+	announce    *announcementhttp.HTTP
+	tos         *toshttp.HTTP
+	report      *reporthttp.HTTP
+	search      *searchhttp.HTTP
+	status      *statushttp.HTTP
+	term        *termhttp.HTTP
+	files       *fileshttp.HTTP
+	dev         *devhttp.HTTP
 }
 
 type Args struct {
@@ -38,49 +99,248 @@ type Args struct {
 	StudentApp              *studentapp.App
 	StaffApp                *staffapp.App
 	UserApp                 *userapp.App
+	NotificationApp         *notificationapp.App
+	AnnouncementApp         *announcementapp.App
 	CookieDomain            string
 	Secret                  []byte
-	AcceptInvitationPageURL string
+	AcceptInvitationPageURL urlx.URL
 	InvitationTokenAlg      jwt.SigningMethod
 	InvitationTokenKey      string
 	InvitationTokenExp      time.Duration
+	// AccessTokenExp is the lifetime of the access token cookie, defaults to
+	// authapp.AccessTokenExpDuration.
+	AccessTokenExp time.Duration
+	// FileStorage is set only when objects are stored on the local
+	// filesystem, it mounts GET /v1/files/{key}.
+	FileStorage fileshttp.FileStorage
+	// BuildInfo is served publicly on GET /v1/version.
+	BuildInfo buildinfo.Info
+	// Schema adds the schema versions to GET /v1/version and decides the
+	// readiness served on GET /ready, optional.
+	Schema Schema
+	// Storage fails the readiness served on GET /ready while the storage
+	// can not be written to, optional.
+	Storage Readiness
+	// Metrics counts the calls of the deprecated routes, defaults to
+	// metrics.Default.
+	Metrics *metrics.Registry
+	// SlowMonitor reports slow handlers, defaults to slowlog.Default. Its
+	// thresholds are served on /v1/admin/slow-thresholds.
+	SlowMonitor *slowlog.Monitor
+	// ErrorRecorder records panics and 5xx responses in the error inbox,
+	// ErrorEvents serves it on /v1/staffs/system/errors. Both are optional.
+	ErrorRecorder *errorinbox.Recorder
+	ErrorEvents   adminhttp.ErrorEvents
+	// ValidationRecorder counts the request fields failing validation,
+	// ValidationStats serves the counts on /v1/staffs/system/validation-stats.
+	// Both are optional.
+	ValidationRecorder *validationstats.Recorder
+	ValidationStats    adminhttp.ValidationStats
+	// LastSeen records the time of the authenticated requests of the users,
+	// optional.
+	LastSeen *lastseen.Tracker
+	// Jobs are the background jobs served on /v1/staffs/system/jobs,
+	// optional.
+	Jobs adminhttp.Jobs
+	// Archives are the manifests of the retention job served on
+	// /v1/staffs/system/archives with the download URLs ArchiveURLs signs.
+	// Both are optional.
+	Archives    adminhttp.Archives
+	ArchiveURLs adminhttp.ArchiveURLs
+	// TOSApp serves the terms of service and gates the users who have not
+	// accepted the current version, nil leaves both off.
+	TOSApp *tosapp.App
+	// ReportApp serves the weekly reports on /v1/staffs/reports/weekly, nil
+	// leaves it off.
+	ReportApp *reportapp.App
+	// SearchApp serves the typeahead of the staff on /v1/staffs/search, nil
+	// leaves it off.
+	SearchApp *searchapp.App
+	// StatusApp serves the public status page on /v1/status and the
+	// incidents of the staff on /v1/staffs/incidents, nil leaves both off.
+	StatusApp *statusapp.App
+	// TermApp serves the academic terms on /v1/staffs/terms, nil leaves it
+	// off.
+	TermApp *termapp.App
+	// Clock is the time the handlers validate against, defaults to
+	// clock.Real. DevClock, when set, is moved by POST /v1/dev/clock in the
+	// dev, local and test modes, usually it is Clock as well.
+	Clock    clock.Clock
+	DevClock clock.Settable
+	// TLS is set when the server terminates TLS itself, the cookies are then
+	// Secure and HSTS is sent in the local mode as well, where both are off
+	// for plain http.
+	TLS bool
+	// OpsListener moves the ops routes off the public router, RouteOps serves
+	// them on their own listener.
+	OpsListener bool
+	// OpsLoopback serves pprof on the ops listener without authentication,
+	// it is bound to a loopback address. Otherwise pprof is for the staff,
+	// like the admin routes.
+	OpsLoopback bool
+	// Mode decides the dev endpoints and the plain http allowances, see
+	// env.Capability. Defaults to env.Current.
+	Mode env.Mode
+	// UnitOfWork runs the registration completion, the invitation acceptance
+	// and the invitation creation in one transaction each, see
+	// middlewares.Transactional.
+	// Without it their writes commit one by one.
+	UnitOfWork middlewares.UnitOfWork
 }
 
 func NewPort(args Args) *Port {
 	errorHandler := httpx.NewErrorHandler()
-	m := middlewares.NewMiddleware(middlewares.Args{
+	// A nil *Recorder in the interfaces would not compare equal to nil.
+	var panics middlewares.PanicRecorder
+	if args.ErrorRecorder != nil {
+		errorHandler = errorHandler.WithRecorder(args.ErrorRecorder)
+		panics = args.ErrorRecorder
+	}
+	if args.ValidationRecorder != nil {
+		errorHandler = errorHandler.WithValidationRecorder(args.ValidationRecorder)
+	}
+	mArgs := middlewares.Args{
 		Secret:     args.Secret,
-		Exp:        authapp.AccessTokenExpDuration,
+		Exp:        args.AccessTokenExp,
 		Errhandler: errorHandler,
-	})
+	}
+	// A nil *ConsentHandler in the interface would not compare equal to nil.
+	if args.TOSApp != nil {
+		mArgs.TOS = args.TOSApp.Query.Consent
+	}
+	if args.UserApp != nil && args.UserApp.Query.EmailVerification != nil {
+		mArgs.EmailVerification = args.UserApp.Query.EmailVerification
+	}
+	if args.LastSeen != nil {
+		mArgs.LastSeen = args.LastSeen
+	}
+	m := middlewares.NewMiddleware(mArgs)
+	var transactional func(http.Handler) http.Handler
+	if args.UnitOfWork != nil {
+		transactional = middlewares.Transactional(args.UnitOfWork, errorHandler)
+	}
+	var terms *toshttp.HTTP
+	if args.TOSApp != nil {
+		terms = toshttp.NewHTTP(toshttp.Args{
+			App:        args.TOSApp,
+			Errhandler: errorHandler,
+		})
+	}
+	var report *reporthttp.HTTP
+	if args.ReportApp != nil {
+		report = reporthttp.NewHTTP(reporthttp.Args{
+			App:        args.ReportApp,
+			Errhandler: errorHandler,
+		})
+	}
+	var search *searchhttp.HTTP
+	if args.SearchApp != nil {
+		search = searchhttp.NewHTTP(searchhttp.Args{
+			App:        args.SearchApp,
+			Errhandler: errorHandler,
+		})
+	}
+	var status *statushttp.HTTP
+	if args.StatusApp != nil {
+		status = statushttp.NewHTTP(statushttp.Args{
+			App:        args.StatusApp,
+			Errhandler: errorHandler,
+		})
+	}
+	var term *termhttp.HTTP
+	if args.TermApp != nil {
+		term = termhttp.NewHTTP(termhttp.Args{
+			App:        args.TermApp,
+			Errhandler: errorHandler,
+		})
+	}
+	var files *fileshttp.HTTP
+	if args.FileStorage != nil {
+		files = fileshttp.NewHTTP(fileshttp.Args{
+			Storage:    args.FileStorage,
+			Errhandler: errorHandler,
+		})
+	}
+
+	if args.SlowMonitor == nil {
+		args.SlowMonitor = slowlog.Default()
+	}
+	if args.Metrics == nil {
+		args.Metrics = metrics.Default()
+	}
+	if args.Mode == "" {
+		args.Mode = env.Current()
+	}
+
 	return &Port{
 		serviceName: args.ServiceName,
-		reg: registrationhttp.NewHTTP(registrationhttp.Args{
-			App:        args.RegistrationApp,
+		schema:      args.Schema,
+		storage:     args.Storage,
+		tls:         args.TLS,
+		mode:        args.Mode,
+		opsListener: args.OpsListener,
+		opsLoopback: args.OpsLoopback,
+		buildInfo:   args.BuildInfo,
+		slow:        args.SlowMonitor,
+		deprecated:  newDeprecatedCalls(args.Metrics),
+		panics:      panics,
+		middleware:  m,
+		errhandler:  errorHandler,
+		files:       files,
+		tos:         terms,
+		report:      report,
+		search:      search,
+		status:      status,
+		term:        term,
+		dev: devhttp.NewHTTP(devhttp.Args{
+			Clock:      args.DevClock,
+			Mode:       args.Mode,
 			Errhandler: errorHandler,
 		}),
+		admin: adminhttp.NewHTTP(adminhttp.Args{
+			Slow:            args.SlowMonitor,
+			Clock:           args.Clock,
+			ErrorEvents:     args.ErrorEvents,
+			ValidationStats: args.ValidationStats,
+			Jobs:            args.Jobs,
+			Archives:        args.Archives,
+			ArchiveURLs:     args.ArchiveURLs,
+			Errhandler:      errorHandler,
+		}),
+		reg: registrationhttp.NewHTTP(registrationhttp.Args{
+			App:           args.RegistrationApp,
+			Mode:          args.Mode,
+			Errhandler:    errorHandler,
+			Transactional: transactional,
+		}),
 		auth: authhttp.NewHTTP(authhttp.Args{
 			App:          args.AuthApp,
 			CookieDomain: args.CookieDomain,
+			TLS:          args.TLS,
+			Mode:         args.Mode,
 			Errhandler:   errorHandler,
 		}),
 		student: studenthttp.NewHTTP(studenthttp.Args{
 			App:        args.StudentApp,
 			Errhandler: errorHandler,
-			Middleware: m,
 		}),
 		staff: staffhttp.NewHTTP(staffhttp.Args{
 			App:                     args.StaffApp,
 			Errhandler:              errorHandler,
-			Middleware:              m,
 			AcceptInvitationPageURL: args.AcceptInvitationPageURL,
 			InvitationTokenAlg:      args.InvitationTokenAlg,
 			InvitationTokenKey:      args.InvitationTokenKey,
 			InvitationTokenExp:      args.InvitationTokenExp,
+			Clock:                   args.Clock,
+			Transactional:           transactional,
 		}),
 		user: userhttp.NewHTTP(userhttp.Args{
-			UserApp:    args.UserApp,
-			Middleware: m,
+			UserApp:         args.UserApp,
+			NotificationApp: args.NotificationApp,
+			Errhandler:      errorHandler,
+		}),
+		announce: announcementhttp.NewHTTP(announcementhttp.Args{
+			App:        args.AnnouncementApp,
 			Errhandler: errorHandler,
 		}),
 	}
@@ -92,32 +352,169 @@ func (p *Port) Route(r chi.Router) chi.Router {
 	}
 	r.Use(middleware.CleanPath)
 	r.Use(middleware.RealIP)
+	r.Use(middlewares.RequestContext)
 	r.Use(middlewares.OTel)
 	r.Use(middlewares.Logger)
+	r.Use(middlewares.Slow(p.slow, userhttp.StreamPath))
 	r.Use(middleware.AllowContentType("application/json", "multipart/form-data"))
-	r.Use(middleware.Recoverer)
-	r.Use(middleware.Timeout(60 * time.Second))
+	r.Use(middlewares.Recoverer(p.panics))
+	r.Use(middlewares.Timeout(60*time.Second, userhttp.StreamPath))
 	r.Use(middleware.Heartbeat("/ping"))
-	r.Use(func(h http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			w.Header().Set("X-Content-Type-Options", "nosniff")
-			w.Header().Set("X-Frame-Options", "DENY")
-			w.Header().Set("X-XSS-Protection", "1; mode=block")
-			w.Header().Set("Strict-Transport-Security", "max-age=31536000; includeSubDomains")
-			w.Header().Set("Content-Security-Policy", "default-src 'self'")
-			h.ServeHTTP(w, r)
-		})
-	})
+	r.Use(securityHeaders(p.tls, p.mode))
+	r.Use(p.authorize(r))
 	r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 		_, _ = w.Write([]byte("OK"))
 	})
+	r.Get("/ready", readyHandler(p.schema, p.storage))
+	r.Get("/v1/version", versionHandler(p.buildInfo, p.schema))
+	r.Route("/v2", p.routeV2)
 
 	p.reg.Route(r)
 	p.auth.Route(r)
 	p.student.Route(r)
 	p.staff.Route(r)
 	p.user.Route(r)
+	p.announce.Route(r)
+	if p.tos != nil {
+		p.tos.Route(r)
+	}
+	if p.report != nil {
+		p.report.Route(r)
+	}
+	if p.search != nil {
+		p.search.Route(r)
+	}
+	if p.status != nil {
+		p.status.Route(r)
+	}
+	if p.term != nil {
+		p.term.Route(r)
+	}
+	if !p.opsListener {
+		p.admin.Route(r)
+	}
+	p.dev.Route(r)
+	if p.files != nil {
+		p.files.Route(r)
+	}
+	checkSuccessors(Deprecations, r)
+	logDeprecations(context.Background(), Deprecations)
+
+	return r
+}
+
+// routeV2 routes the /v2 variants of the routes whose shapes change, they
+// are served next to the /v1 ones until those reach their sunset, see
+// Deprecations. The ports register them relative to /v2 here, as Route
+// does for the rest.
+func (p *Port) routeV2(r chi.Router) {
+	r.Get("/version", versionHandlerV2(p.buildInfo, p.schema))
+}
+
+// RouteOps routes the ops surface, the admin settings, the error inbox and
+// pprof, for the internal listener of Args.OpsListener. It shares the apps of
+// the public router but none of its other routes.
+func (p *Port) RouteOps(r chi.Router) chi.Router {
+	if r == nil {
+		r = chi.NewRouter()
+	}
+	// No CleanPath, pprof serves its index on the trailing slash.
+	r.Use(middlewares.RequestContext)
+	r.Use(middlewares.OTel)
+	r.Use(middlewares.Logger)
+	r.Use(middlewares.Recoverer(p.panics))
+	r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("OK"))
+	})
+	r.Get("/ready", readyHandler(p.schema, p.storage))
+	r.Get("/v1/version", versionHandler(p.buildInfo, p.schema))
+	// The profiles run longer than the request timeout of the public router.
+	if p.opsLoopback {
+		r.Mount("/debug", middleware.Profiler())
+	} else {
+		r.With(p.middleware.Auth, p.middleware.StaffOnly).Mount("/debug", middleware.Profiler())
+	}
+
+	r.Group(func(r chi.Router) {
+		r.Use(middleware.AllowContentType("application/json"))
+		r.Use(middleware.Timeout(60 * time.Second))
+		r.Use(p.authorize(r))
+		p.admin.Route(r)
+	})
 
 	return r
 }
+
+// securityHeaders sets the security headers of every response. HSTS is left
+// out over plain http in the modes allowing it, where the browser would pin
+// localhost to https.
+func securityHeaders(tls bool, mode env.Mode) func(http.Handler) http.Handler {
+	hsts := tls || !mode.Allows(env.CapPlainHTTP)
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Content-Type-Options", "nosniff")
+			w.Header().Set("X-Frame-Options", "DENY")
+			w.Header().Set("X-XSS-Protection", "1; mode=block")
+			if hsts {
+				w.Header().Set("Strict-Transport-Security", "max-age=31536000; includeSubDomains")
+			}
+			w.Header().Set("Content-Security-Policy", "default-src 'self'")
+			h.ServeHTTP(w, r)
+		})
+	}
+}
+
+// versionHandlerV2 is versionHandler with the build info under its own key,
+// the shape of the /v2 responses.
+func versionHandlerV2(info buildinfo.Info, schema Schema) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		res := httpx.Envelope{"build": info}
+		if schema != nil {
+			res["schema"] = schema.Versions()
+		}
+		httpx.Success(w, r, http.StatusOK, res)
+	}
+}
+
+// versionHandler serves the build info and the schema versions, it is
+// public and must not expose anything but the versions.
+func versionHandler(info buildinfo.Info, schema Schema) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		res := httpx.Envelope{
+			"version":    info.Version,
+			"commit":     info.Commit,
+			"build_date": info.BuildDate,
+		}
+		if schema != nil {
+			res["schema"] = schema.Versions()
+		}
+		httpx.Success(w, r, http.StatusOK, res)
+	}
+}
+
+// readyHandler answers 503 with the reason while the instance must not be
+// sent traffic, e.g. it is older than the schema, and 200 otherwise. It is
+// not an error of the request, the error inbox does not record it.
+func readyHandler(schema Schema, storage Readiness) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		for _, check := range []Readiness{schema, storage} {
+			if check == nil {
+				continue
+			}
+			if ready, reason := check.Ready(); !ready {
+				err := httpx.WriteJSON(w, http.StatusServiceUnavailable, httpx.Envelope{
+					"success": false,
+					"ready":   false,
+					"reason":  reason,
+				}, nil)
+				if err != nil {
+					logger.ErrorContext(r.Context(), "failed to write readiness", "error", err)
+				}
+				return
+			}
+		}
+		httpx.Success(w, r, http.StatusOK, httpx.Envelope{"ready": true})
+	}
+}
@@ -0,0 +1,322 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	announcementapp "gitlab.com/ucmsv2/ucms-backend/internal/application/announcement"
+	authapp "gitlab.com/ucmsv2/ucms-backend/internal/application/auth"
+	notificationapp "gitlab.com/ucmsv2/ucms-backend/internal/application/notification"
+	"gitlab.com/ucmsv2/ucms-backend/internal/application/registration"
+	reportapp "gitlab.com/ucmsv2/ucms-backend/internal/application/report"
+	searchapp "gitlab.com/ucmsv2/ucms-backend/internal/application/search"
+	staffapp "gitlab.com/ucmsv2/ucms-backend/internal/application/staff"
+	statusapp "gitlab.com/ucmsv2/ucms-backend/internal/application/status"
+	studentapp "gitlab.com/ucmsv2/ucms-backend/internal/application/student"
+	termapp "gitlab.com/ucmsv2/ucms-backend/internal/application/term"
+	tosapp "gitlab.com/ucmsv2/ucms-backend/internal/application/tos"
+	userapp "gitlab.com/ucmsv2/ucms-backend/internal/application/user"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/roles"
+	adminhttp "gitlab.com/ucmsv2/ucms-backend/internal/ports/http/admin"
+	authhttp "gitlab.com/ucmsv2/ucms-backend/internal/ports/http/auth"
+	fileshttp "gitlab.com/ucmsv2/ucms-backend/internal/ports/http/files"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/buildinfo"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/env"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/schemaversion"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/storagex"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/urlx"
+)
+
+func TestVersionHandler(t *testing.T) {
+	handler := versionHandler(buildinfo.Info{
+		Version:   "1.4.0",
+		Commit:    "4b158c7",
+		BuildDate: "2025-08-01T10:00:00Z",
+	}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/version", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	var body map[string]any
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, map[string]any{
+		"success":    true,
+		"version":    "1.4.0",
+		"commit":     "4b158c7",
+		"build_date": "2025-08-01T10:00:00Z",
+	}, body)
+}
+
+type stubSchema struct {
+	versions schemaversion.Versions
+	reason   string
+}
+
+func (s stubSchema) Versions() schemaversion.Versions { return s.versions }
+func (s stubSchema) Ready() (bool, string)            { return s.reason == "", s.reason }
+
+func TestVersionHandler_Schema(t *testing.T) {
+	handler := versionHandler(buildinfo.Info{Version: "1.4.0"}, stubSchema{
+		versions: schemaversion.Versions{Expected: 20, Applied: 21},
+	})
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/version", nil))
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	var body struct {
+		Schema schemaversion.Versions `json:"schema"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, schemaversion.Versions{Expected: 20, Applied: 21}, body.Schema)
+}
+
+func TestReadyHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		schema     Schema
+		storage    Readiness
+		wantStatus int
+		wantReason string
+	}{
+		{name: "without schema", wantStatus: http.StatusOK},
+		{name: "matching schema", schema: stubSchema{}, wantStatus: http.StatusOK},
+		{
+			name:       "breaking schema",
+			schema:     stubSchema{reason: schemaversion.ReasonVersionMismatch},
+			wantStatus: http.StatusServiceUnavailable,
+			wantReason: schemaversion.ReasonVersionMismatch,
+		},
+		{
+			name:       "failed storage self-test",
+			schema:     stubSchema{},
+			storage:    stubSchema{reason: storagex.ReasonSelfTestFailed},
+			wantStatus: http.StatusServiceUnavailable,
+			wantReason: storagex.ReasonSelfTestFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			readyHandler(tt.schema, tt.storage).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/ready", nil))
+
+			require.Equal(t, tt.wantStatus, rec.Code)
+			var body struct {
+				Ready  bool   `json:"ready"`
+				Reason string `json:"reason"`
+			}
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+			assert.Equal(t, tt.wantReason == "", body.Ready)
+			assert.Equal(t, tt.wantReason, body.Reason)
+		})
+	}
+}
+
+func TestSecurityHeaders_HSTS(t *testing.T) {
+	tests := []struct {
+		name     string
+		mode     env.Mode
+		tls      bool
+		wantHSTS bool
+	}{
+		{name: "prod", mode: env.Prod, wantHSTS: true},
+		{name: "local over plain http", mode: env.Local},
+		{name: "local with tls", mode: env.Local, tls: true, wantHSTS: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := securityHeaders(tt.tls, tt.mode)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+
+			require.Equal(t, http.StatusOK, rec.Code)
+			if tt.wantHSTS {
+				assert.Equal(t, "max-age=31536000; includeSubDomains", rec.Header().Get("Strict-Transport-Security"))
+			} else {
+				assert.Empty(t, rec.Header().Get("Strict-Transport-Security"))
+			}
+		})
+	}
+}
+
+func newTestPort(opsListener bool) *Port {
+	return NewPort(Args{
+		RegistrationApp:         &registration.App{},
+		AuthApp:                 &authapp.App{},
+		StudentApp:              &studentapp.App{},
+		StaffApp:                &staffapp.App{},
+		UserApp:                 &userapp.App{},
+		Secret:                  []byte("secret"),
+		AcceptInvitationPageURL: urlx.MustParse("https://ucms.kz/invitations/accept"),
+		InvitationTokenKey:      "secret",
+		OpsListener:             opsListener,
+	})
+}
+
+func get(t *testing.T, url string) int {
+	t.Helper()
+	resp, err := http.Get(url)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	return resp.StatusCode
+}
+
+func TestPort_OpsListener(t *testing.T) {
+	opsRoutes := []string{"/v1/admin/slow-thresholds", "/debug/pprof/"}
+
+	t.Run("separate listener", func(t *testing.T) {
+		port := newTestPort(true)
+		public := httptest.NewServer(port.Route(nil))
+		defer public.Close()
+		ops := httptest.NewServer(port.RouteOps(nil))
+		defer ops.Close()
+
+		for _, route := range opsRoutes {
+			assert.Equal(t, http.StatusNotFound, get(t, public.URL+route), "%s on the public port", route)
+		}
+		assert.Equal(t, http.StatusUnauthorized, get(t, ops.URL+"/v1/admin/slow-thresholds"), "admin routes keep the auth")
+		assert.Equal(t, http.StatusUnauthorized, get(t, ops.URL+"/debug/pprof/"), "pprof is for the staff off the loopback")
+		assert.Equal(t, http.StatusOK, get(t, ops.URL+"/health"))
+		assert.Equal(t, http.StatusOK, get(t, public.URL+"/health"))
+		assert.Equal(t, http.StatusNotFound, get(t, ops.URL+"/v1/auth/login"), "the public routes are not on the ops port")
+	})
+
+	t.Run("loopback listener", func(t *testing.T) {
+		port := newTestPort(true)
+		port.opsLoopback = true
+		ops := httptest.NewServer(port.RouteOps(nil))
+		defer ops.Close()
+
+		assert.Equal(t, http.StatusOK, get(t, ops.URL+"/debug/pprof/"))
+		assert.Equal(t, http.StatusUnauthorized, get(t, ops.URL+"/v1/admin/slow-thresholds"), "admin routes keep the auth")
+	})
+
+	t.Run("single listener", func(t *testing.T) {
+		public := httptest.NewServer(newTestPort(false).Route(nil))
+		defer public.Close()
+
+		assert.Equal(t, http.StatusUnauthorized, get(t, public.URL+"/v1/admin/slow-thresholds"), "the admin routes stay on the public port")
+		assert.Equal(t, http.StatusNotFound, get(t, public.URL+"/debug/pprof/"), "pprof is never public")
+	})
+}
+
+// stubJobs, stubErrorEvents, stubValidationStats and the archive stubs mount
+// the optional admin routes, the tests only walk them.
+type stubJobs struct{ adminhttp.Jobs }
+
+type stubErrorEvents struct{ adminhttp.ErrorEvents }
+
+type stubValidationStats struct{ adminhttp.ValidationStats }
+
+type stubArchives struct{ adminhttp.Archives }
+
+type stubArchiveURLs struct{ adminhttp.ArchiveURLs }
+
+type stubFileStorage struct{ fileshttp.FileStorage }
+
+func TestPolicies_CoverRoutes(t *testing.T) {
+	port := NewPort(Args{
+		RegistrationApp:         &registration.App{},
+		AuthApp:                 &authapp.App{},
+		StudentApp:              &studentapp.App{},
+		StaffApp:                &staffapp.App{},
+		UserApp:                 &userapp.App{},
+		NotificationApp:         &notificationapp.App{},
+		AnnouncementApp:         &announcementapp.App{},
+		TOSApp:                  &tosapp.App{},
+		ReportApp:               &reportapp.App{},
+		SearchApp:               &searchapp.App{},
+		StatusApp:               &statusapp.App{},
+		TermApp:                 &termapp.App{},
+		Jobs:                    stubJobs{},
+		ErrorEvents:             stubErrorEvents{},
+		ValidationStats:         stubValidationStats{},
+		Archives:                stubArchives{},
+		ArchiveURLs:             stubArchiveURLs{},
+		FileStorage:             stubFileStorage{},
+		DevClock:                clock.NewFake(time.Now()),
+		Mode:                    env.Test,
+		Secret:                  []byte("secret"),
+		AcceptInvitationPageURL: urlx.MustParse("https://ucms.kz/invitations/accept"),
+		InvitationTokenKey:      "secret",
+	})
+
+	routes := make(map[string]bool)
+	err := chi.Walk(port.Route(nil), func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		routes[PolicyKey(method, route)] = true
+		return nil
+	})
+	require.NoError(t, err)
+
+	policies := policyIndex(Policies)
+	for route := range routes {
+		assert.Contains(t, policies, route, "the route has no access policy in Policies")
+	}
+	for route := range policies {
+		assert.True(t, routes[route], "the policy %s has no route", route)
+	}
+}
+
+func TestPort_Authorize(t *testing.T) {
+	secret := []byte("secret")
+	token := func(role roles.Global) *http.Cookie {
+		signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+			"iss":       authapp.ISS,
+			"sub":       authapp.UserSubject,
+			"uid":       uuid.NewString(),
+			"user_role": role.String(),
+			"exp":       time.Now().Add(time.Minute).Unix(),
+		}).SignedString(secret)
+		require.NoError(t, err)
+		return &http.Cookie{Name: authhttp.AccessJWTCookie, Value: signed}
+	}
+
+	router := newTestPort(false).Route(nil)
+	// Registered past the policies, as a port forgetting its own would.
+	router.Get("/v1/undeclared", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		cookie *http.Cookie
+		status int
+	}{
+		{name: "anonymous", method: http.MethodGet, path: "/v1/staffs/me", status: http.StatusUnauthorized},
+		{name: "student on a staff route", method: http.MethodGet, path: "/v1/admin/slow-thresholds", cookie: token(roles.Student), status: http.StatusForbidden},
+		{name: "staff on an aitusa route", method: http.MethodPost, path: "/v1/aitusa/announcements", cookie: token(roles.Staff), status: http.StatusForbidden},
+		{name: "staff", method: http.MethodGet, path: "/v1/admin/slow-thresholds", cookie: token(roles.Staff), status: http.StatusOK},
+		{name: "unknown route", method: http.MethodGet, path: "/v1/staffs/nothing", status: http.StatusNotFound},
+		{name: "unclean path", method: http.MethodGet, path: "/v1//admin/slow-thresholds", status: http.StatusUnauthorized},
+		{name: "undeclared route", method: http.MethodGet, path: "/v1/undeclared", status: http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.cookie != nil {
+				req.AddCookie(tt.cookie)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			assert.Equal(t, tt.status, rec.Code, rec.Body.String())
+		})
+	}
+}
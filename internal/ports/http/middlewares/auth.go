@@ -1,10 +1,12 @@
 package middlewares
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/ARUMANDESU/validation"
@@ -28,12 +30,33 @@ var (
 	logger = otelslog.NewLogger("ucms/internal/ports/http/middleware")
 )
 
+// TOSConsentChecker returns an error when the user has to accept the current
+// terms of service before going on.
+type TOSConsentChecker interface {
+	CheckTOSConsent(ctx context.Context, userID user.ID) error
+}
+
+// EmailVerificationChecker returns an error when the user has to verify
+// their email before going on.
+type EmailVerificationChecker interface {
+	CheckEmailVerified(ctx context.Context, userID user.ID) error
+}
+
+// LastSeenToucher records that a user made a request, without waiting on
+// the write, see lastseen.Tracker.
+type LastSeenToucher interface {
+	Touch(ctx context.Context, userID user.ID)
+}
+
 type Middleware struct {
 	tracer     trace.Tracer
 	logger     *slog.Logger
 	secret     []byte
 	exp        time.Duration
 	errhandler *httpx.ErrorHandler
+	tos        TOSConsentChecker
+	emails     EmailVerificationChecker
+	lastSeen   LastSeenToucher
 }
 
 type Args struct {
@@ -42,6 +65,15 @@ type Args struct {
 	Secret     []byte
 	Exp        time.Duration
 	Errhandler *httpx.ErrorHandler
+	// TOS gates the authenticated requests of the users who have not
+	// accepted the current terms of service, nil gates nothing.
+	TOS TOSConsentChecker
+	// EmailVerification gates the staff requests of the users who have not
+	// verified their email, nil gates nothing.
+	EmailVerification EmailVerificationChecker
+	// LastSeen is touched by the authenticated requests, except those of the
+	// impersonation sessions. Nil records nothing.
+	LastSeen LastSeenToucher
 }
 
 func NewMiddleware(args Args) *Middleware {
@@ -51,6 +83,9 @@ func NewMiddleware(args Args) *Middleware {
 		secret:     args.Secret,
 		exp:        args.Exp,
 		errhandler: args.Errhandler,
+		tos:        args.TOS,
+		emails:     args.EmailVerification,
+		lastSeen:   args.LastSeen,
 	}
 
 	if m.tracer == nil {
@@ -121,9 +156,16 @@ func (m *Middleware) Auth(next http.Handler) http.Handler {
 			m.errhandler.HandleError(w, r, span, err, "role not found or type assertion failed in access token claims")
 			return
 		}
-		if userRole == "" {
-			err = errorx.NewInvalidCredentials().WithCause(errors.New("role is empty in access token claims"), op)
-			m.errhandler.HandleError(w, r, span, err, "role is empty in access token claims")
+		role, err := roles.Parse(userRole)
+		if err != nil {
+			err = errorx.NewInvalidCredentials().WithCause(err, op)
+			m.errhandler.HandleError(w, r, span, err, "unknown role in access token claims")
+			return
+		}
+		roleSet, err := tokenRoles(accessClaims, role)
+		if err != nil {
+			err = errorx.NewInvalidCredentials().WithCause(err, op)
+			m.errhandler.HandleError(w, r, span, err, "invalid roles in access token claims")
 			return
 		}
 		uid, ok := accessClaims["uid"].(string)
@@ -153,14 +195,117 @@ func (m *Middleware) Auth(next http.Handler) http.Handler {
 			return
 		}
 
-		ctx = ctxs.WithUser(ctx, &ctxs.User{
-			ID:   user.ID(userID),
-			Role: roles.Global(userRole),
-		})
+		impersonatorID, err := impersonator(accessClaims)
+		if err != nil {
+			err = errorx.NewInvalidCredentials().WithCause(err, op)
+			m.errhandler.HandleError(w, r, span, err, "invalid actor in access token claims")
+			return
+		}
+
+		if m.tos != nil && !tosExempt(r) {
+			if err := m.tos.CheckTOSConsent(ctx, user.ID(userID)); err != nil {
+				m.errhandler.HandleError(w, r, span, err, "terms of service consent required")
+				return
+			}
+		}
+
+		ctxUser := &ctxs.User{
+			ID:             user.ID(userID),
+			Role:           role,
+			Roles:          roleSet,
+			ImpersonatorID: impersonatorID,
+		}
+		if ctxUser.IsImpersonated() {
+			// The UI shows a banner while a staff member acts as the user.
+			w.Header().Set(HeaderImpersonating, impersonatorID.String())
+			ctxUser.SetSpanAttrs(span)
+		} else if m.lastSeen != nil {
+			m.lastSeen.Touch(ctx, ctxUser.ID)
+		}
+
+		ctx = ctxs.WithUser(ctx, ctxUser)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
+// tokenRoles returns the roles claim of an access token, role alone for the
+// tokens minted before it. role must be the most privileged of the roles.
+func tokenRoles(claims jwt.MapClaims, role roles.Global) (roles.Set, error) {
+	claim, ok := claims["user_roles"]
+	if !ok {
+		return roles.NewSet(role), nil
+	}
+	items, ok := claim.([]any)
+	if !ok {
+		return nil, fmt.Errorf("roles claim is not an array: %T", claim)
+	}
+	names := make([]string, len(items))
+	for i, item := range items {
+		name, ok := item.(string)
+		if !ok {
+			return nil, fmt.Errorf("role in the roles claim is not a string: %T", item)
+		}
+		names[i] = name
+	}
+	set, err := roles.ParseSet(names)
+	if err != nil {
+		return nil, err
+	}
+	if set.Primary() != role {
+		return nil, fmt.Errorf("role %s is not the most privileged of the roles %v", role, set.Strings())
+	}
+	return set, nil
+}
+
+// HeaderImpersonating is set on the responses to the impersonation sessions,
+// to the ID of the staff member impersonating the user.
+const HeaderImpersonating = "X-Impersonating"
+
+// impersonator returns the staff member in the actor claim of an
+// impersonation access token, the zero ID for the other tokens.
+func impersonator(claims jwt.MapClaims) (user.ID, error) {
+	act, ok := claims[authapp.ActorClaim]
+	if !ok {
+		return user.ID{}, nil
+	}
+	actor, ok := act.(map[string]any)
+	if !ok {
+		return user.ID{}, fmt.Errorf("actor claim is not an object: %T", act)
+	}
+	uid, ok := actor["uid"].(string)
+	if !ok {
+		return user.ID{}, fmt.Errorf("actor user id not found or type assertion failed: %T", actor["uid"])
+	}
+	id, err := uuid.Parse(uid)
+	if err != nil {
+		return user.ID{}, err
+	}
+	return user.ID(id), nil
+}
+
+// tosExempt reports whether the request is let through without the current
+// terms of service accepted: reading the own profile, the terms themselves
+// and accepting them, and the auth endpoints.
+func tosExempt(r *http.Request) bool {
+	path := r.URL.Path
+	switch {
+	case r.Method == http.MethodGet && strings.HasSuffix(path, "/me"):
+		return true
+	case strings.HasPrefix(path, "/v1/users/me/tos/"), strings.HasPrefix(path, "/v1/tos/"):
+		return true
+	case strings.HasPrefix(path, "/v1/auth/"):
+		return true
+	}
+	return false
+}
+
+// emailVerificationExempt are the staff routes served before the email is
+// verified, by method and chi pattern, see ctxs.RoutePatternFromCtx: reading
+// the own profile, the clients show the verification step with it.
+var emailVerificationExempt = map[string]bool{
+	http.MethodGet + " /v1/staffs/me": true,
+}
+
 func (m *Middleware) StaffOnly(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		const op = "http.middleware.StaffOnly"
@@ -174,11 +319,43 @@ func (m *Middleware) StaffOnly(next http.Handler) http.Handler {
 		}
 		ctxUser.SetSpanAttrs(span)
 
-		if ctxUser.Role != roles.Staff {
-			err = errorx.NewForbidden().WithCause(fmt.Errorf("user role %s is not allowed", ctxUser.Role), op)
+		if !ctxUser.AllRoles().IsStaffLike() {
+			err = errorx.NewForbidden().WithCause(fmt.Errorf("user roles %v are not allowed", ctxUser.AllRoles().Strings()), op)
 			m.errhandler.HandleError(w, r, span, err, "user is not staff")
 			return
 		}
+		route := r.Method + " " + ctxs.RoutePatternFromCtx(ctx)
+		if m.emails != nil && !emailVerificationExempt[route] {
+			if err := m.emails.CheckEmailVerified(ctx, ctxUser.ID); err != nil {
+				m.errhandler.HandleError(w, r, span, err, "email verification required")
+				return
+			}
+		}
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// AITUSAOnly lets through the users with a role publishing announcements,
+// see roles.Set.CanAnnounce.
+func (m *Middleware) AITUSAOnly(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		const op = "http.middleware.AITUSAOnly"
+		ctx, span := tracer.Start(r.Context(), "AITUSAOnlyMiddleware")
+		defer span.End()
+
+		ctxUser, err := ctxs.UserFromCtx(ctx)
+		if err != nil {
+			m.errhandler.HandleError(w, r, span, err, "failed to get user from context")
+			return
+		}
+		ctxUser.SetSpanAttrs(span)
+
+		if !ctxUser.AllRoles().CanAnnounce() {
+			err = errorx.NewForbidden().WithCause(fmt.Errorf("user roles %v are not allowed", ctxUser.AllRoles().Strings()), op)
+			m.errhandler.HandleError(w, r, span, err, "user is not aitusa")
+			return
+		}
 
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
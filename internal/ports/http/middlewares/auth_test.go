@@ -0,0 +1,151 @@
+package middlewares
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	authapp "gitlab.com/ucmsv2/ucms-backend/internal/application/auth"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/roles"
+	authhttp "gitlab.com/ucmsv2/ucms-backend/internal/ports/http/auth"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/ctxs"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+)
+
+var testSecret = []byte("test-secret")
+
+// accessToken mints an access token of role, the roles claim is left out
+// when names is nil, like in the tokens minted before the role sets.
+func accessToken(t *testing.T, role string, names []string) string {
+	t.Helper()
+	claims := jwt.MapClaims{
+		"iss":       authapp.ISS,
+		"sub":       authapp.UserSubject,
+		"exp":       time.Now().Add(time.Minute).Unix(),
+		"iat":       time.Now().Unix(),
+		"uid":       user.NewID().String(),
+		"user_role": role,
+	}
+	if names != nil {
+		claims["user_roles"] = names
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(testSecret)
+	require.NoError(t, err)
+	return token
+}
+
+func serveAuth(t *testing.T, gate func(http.Handler) http.Handler, token string) (*httptest.ResponseRecorder, *ctxs.User) {
+	t.Helper()
+	var got *ctxs.User
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, _ = ctxs.UserFromCtx(r.Context())
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/staffs/students", nil)
+	req.AddCookie(&http.Cookie{Name: authhttp.AccessJWTCookie, Value: token})
+	res := httptest.NewRecorder()
+	m := NewMiddleware(Args{Secret: testSecret})
+	m.Auth(gate(next)).ServeHTTP(res, req)
+	return res, got
+}
+
+func TestMiddleware_RoleUnion(t *testing.T) {
+	m := NewMiddleware(Args{Secret: testSecret})
+	gates := map[string]func(http.Handler) http.Handler{
+		"staff only":  m.StaffOnly,
+		"aitusa only": m.AITUSAOnly,
+	}
+	tests := []struct {
+		name  string
+		role  string
+		roles []string
+		// allowed are the gates letting the token through.
+		allowed []string
+	}{
+		{name: "student", role: "student", roles: []string{"student"}},
+		{name: "staff", role: "staff", roles: []string{"staff"}, allowed: []string{"staff only"}},
+		{name: "linked student", role: "staff", roles: []string{"student", "staff"}, allowed: []string{"staff only"}},
+		{name: "linked aitusa", role: "staff", roles: []string{"aitusa", "staff"}, allowed: []string{"staff only", "aitusa only"}},
+		{name: "token without roles", role: "aitusa", allowed: []string{"aitusa only"}},
+	}
+	for _, tt := range tests {
+		for name, gate := range gates {
+			t.Run(tt.name+"/"+name, func(t *testing.T) {
+				res, got := serveAuth(t, gate, accessToken(t, tt.role, tt.roles))
+
+				if !slices.Contains(tt.allowed, name) {
+					assert.Equal(t, http.StatusForbidden, res.Code)
+					return
+				}
+				require.Equal(t, http.StatusNoContent, res.Code)
+				want := roles.NewSet(roles.Global(tt.role))
+				if tt.roles != nil {
+					want, _ = roles.ParseSet(tt.roles)
+				}
+				assert.Equal(t, want, got.AllRoles())
+			})
+		}
+	}
+}
+
+func TestMiddleware_Auth_InvalidRoles(t *testing.T) {
+	pass := func(next http.Handler) http.Handler { return next }
+	for name, token := range map[string]string{
+		"unknown role":            accessToken(t, "staff", []string{"student", "admin"}),
+		"role not the primary":    accessToken(t, "student", []string{"student", "staff"}),
+		"role missing from roles": accessToken(t, "staff", []string{"student"}),
+	} {
+		t.Run(name, func(t *testing.T) {
+			res, _ := serveAuth(t, pass, token)
+			assert.Equal(t, http.StatusUnauthorized, res.Code)
+		})
+	}
+}
+
+type unverifiedEmails struct{}
+
+func (unverifiedEmails) CheckEmailVerified(context.Context, user.ID) error {
+	return errorx.NewForbidden().WithDetails("verify your email")
+}
+
+func TestMiddleware_StaffOnly_EmailVerificationExempt(t *testing.T) {
+	m := NewMiddleware(Args{Secret: testSecret, EmailVerification: unverifiedEmails{}})
+	token := accessToken(t, "staff", []string{"staff"})
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	tests := []struct {
+		name    string
+		method  string
+		path    string
+		pattern string
+		want    int
+	}{
+		{name: "own profile", method: http.MethodGet, path: "/v1/staffs/me", pattern: "/v1/staffs/me", want: http.StatusNoContent},
+		{name: "profile update", method: http.MethodPatch, path: "/v1/staffs/me", pattern: "/v1/staffs/me", want: http.StatusForbidden},
+		{name: "path ending in me", method: http.MethodGet, path: "/v1/staffs/students/me", pattern: "/v1/staffs/students/{barcode}", want: http.StatusForbidden},
+		{name: "unmatched route", method: http.MethodGet, path: "/v1/staffs/me", want: http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			req.AddCookie(&http.Cookie{Name: authhttp.AccessJWTCookie, Value: token})
+			if tt.pattern != "" {
+				req = req.WithContext(ctxs.WithRoutePattern(req.Context(), tt.pattern))
+			}
+			res := httptest.NewRecorder()
+			m.Auth(m.StaffOnly(next)).ServeHTTP(res, req)
+			assert.Equal(t, tt.want, res.Code)
+		})
+	}
+}
@@ -0,0 +1,18 @@
+package middlewares
+
+import (
+	"net/http"
+
+	"gitlab.com/ucmsv2/ucms-backend/pkg/ctxs"
+)
+
+// RequestContext stores the request ID, locale and client IP of the request
+// in its context, see ctxs.FromHTTP, and echoes the request ID in the
+// response.
+func RequestContext(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := ctxs.FromHTTP(r)
+		w.Header().Set(ctxs.RequestIDHeader, ctxs.RequestIDFromCtx(ctx))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
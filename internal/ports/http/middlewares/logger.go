@@ -7,6 +7,8 @@ import (
 	"time"
 
 	"github.com/go-chi/chi/v5/middleware"
+
+	"gitlab.com/ucmsv2/ucms-backend/pkg/ctxs"
 )
 
 func Logger(next http.Handler) http.Handler {
@@ -31,7 +33,7 @@ func Logger(next http.Handler) http.Handler {
 				ww.BytesWritten(),
 				time.Since(t1),
 			)
-			logger := slog.With(
+			attrs := append(ctxs.LogAttrs(r.Context()),
 				slog.String("method", r.Method),
 				slog.String("url", fmt.Sprintf("%s://%s%s", scheme, r.Host, r.RequestURI)),
 				slog.String("proto", r.Proto),
@@ -41,13 +43,13 @@ func Logger(next http.Handler) http.Handler {
 				slog.Duration("duration", time.Since(t1)),
 			)
 
+			level := slog.LevelInfo
 			if ww.Status() >= 500 {
-				logger.ErrorContext(r.Context(), logstr)
+				level = slog.LevelError
 			} else if ww.Status() >= 400 {
-				logger.WarnContext(r.Context(), logstr)
-			} else {
-				logger.InfoContext(r.Context(), logstr)
+				level = slog.LevelWarn
 			}
+			slog.Default().LogAttrs(r.Context(), level, logstr, attrs...)
 		}()
 
 		next.ServeHTTP(ww, r)
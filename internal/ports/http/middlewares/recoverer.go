@@ -0,0 +1,50 @@
+package middlewares
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+
+	"gitlab.com/ucmsv2/ucms-backend/pkg/httpx"
+)
+
+// PanicRecorder is notified of the panics recovered from handlers.
+type PanicRecorder interface {
+	RecordPanic(ctx context.Context, route string, v any)
+}
+
+// Recoverer answers 500 to requests whose handler panicked, it logs the stack
+// and reports the panic to rec when it is not nil.
+func Recoverer(rec PanicRecorder) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer func() {
+				v := recover()
+				if v == nil {
+					return
+				}
+				if v == http.ErrAbortHandler {
+					// The client is gone, net/http handles it silently.
+					panic(v)
+				}
+
+				route := r.Method + " " + httpx.RoutePattern(r)
+				if rec != nil {
+					rec.RecordPanic(r.Context(), route, v)
+				}
+				logger.ErrorContext(r.Context(), "panic recovered",
+					slog.String("route", route),
+					slog.Any("panic", v),
+					slog.String("stack", string(debug.Stack())),
+				)
+
+				if r.Header.Get("Connection") != "Upgrade" {
+					w.WriteHeader(http.StatusInternalServerError)
+				}
+			}()
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
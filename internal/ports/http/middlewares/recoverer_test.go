@@ -0,0 +1,54 @@
+package middlewares
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+type panicRecorder struct {
+	routes []string
+	values []any
+}
+
+func (r *panicRecorder) RecordPanic(_ context.Context, route string, v any) {
+	r.routes = append(r.routes, route)
+	r.values = append(r.values, v)
+}
+
+func TestRecoverer(t *testing.T) {
+	rec := &panicRecorder{}
+	r := chi.NewRouter()
+	r.Use(Recoverer(rec))
+	r.Get("/v1/users/{user_id}", func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+	r.Get("/v1/ok", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	res := httptest.NewRecorder()
+	r.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/v1/users/42", nil))
+	assert.Equal(t, http.StatusInternalServerError, res.Code)
+	assert.Equal(t, []string{"GET /v1/users/{user_id}"}, rec.routes)
+	assert.Equal(t, []any{"boom"}, rec.values)
+
+	res = httptest.NewRecorder()
+	r.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/v1/ok", nil))
+	assert.Equal(t, http.StatusNoContent, res.Code)
+	assert.Len(t, rec.routes, 1)
+}
+
+func TestRecoverer_AbortHandlerIsRepanicked(t *testing.T) {
+	handler := Recoverer(nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	})
+}
@@ -0,0 +1,33 @@
+package middlewares
+
+import (
+	"net/http"
+	"slices"
+	"time"
+
+	"gitlab.com/ucmsv2/ucms-backend/pkg/httpx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx/slowlog"
+)
+
+// Slow reports requests slower than the monitor handler threshold. The route
+// pattern is reported instead of the path so IDs do not end up in the logs.
+// The exempt paths are the streams, they are slow by design.
+func Slow(monitor *slowlog.Monitor, exempt ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if slices.Contains(exempt, r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			start := time.Now()
+			next.ServeHTTP(w, r)
+
+			d := time.Since(start)
+			if threshold := monitor.Thresholds().Handler; threshold == 0 || d < threshold {
+				return
+			}
+			monitor.ObserveHandler(r.Context(), r.Method+" "+httpx.RoutePattern(r), d)
+		})
+	}
+}
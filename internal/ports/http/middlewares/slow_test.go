@@ -0,0 +1,96 @@
+package middlewares
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx/metrics"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx/slowlog"
+)
+
+// delay makes the handlers of slow requests take d, requests are slow when
+// they carry the X-Slow header.
+func delay(d time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("X-Slow") != "" {
+				time.Sleep(d)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func TestSlow(t *testing.T) {
+	var logs bytes.Buffer
+	reader := sdkmetric.NewManualReader()
+	monitor := slowlog.NewMonitor(slowlog.Args{
+		Logger:     slog.New(slog.NewJSONHandler(&logs, nil)),
+		Metrics:    metrics.NewRegistry(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)).Meter("test")),
+		Thresholds: &slowlog.Thresholds{Handler: 20 * time.Millisecond},
+	})
+
+	r := chi.NewRouter()
+	r.Use(Slow(monitor))
+	r.Use(delay(30 * time.Millisecond))
+	r.Get("/v1/users/{user_id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	slowCount := func() int64 {
+		var rm metricdata.ResourceMetrics
+		require.NoError(t, reader.Collect(context.Background(), &rm))
+		for _, sm := range rm.ScopeMetrics {
+			for _, m := range sm.Metrics {
+				if m.Name == metrics.SlowOperations {
+					dp := m.Data.(metricdata.Sum[int64]).DataPoints[0]
+					kind, _ := dp.Attributes.Value(metrics.AttrKind)
+					assert.Equal(t, slowlog.KindHandler, kind.AsString())
+					return dp.Value
+				}
+			}
+		}
+		return 0
+	}
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/v1/users/42", nil))
+	assert.Empty(t, logs.String(), "fast requests are not logged")
+	assert.Zero(t, slowCount())
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/users/42", nil)
+	req.Header.Set("X-Slow", "1")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	var entry map[string]any
+	require.NoError(t, json.Unmarshal(logs.Bytes(), &entry))
+	assert.Equal(t, "WARN", entry["level"])
+	assert.Equal(t, slowlog.KindHandler, entry["kind"])
+	assert.Equal(t, "GET /v1/users/{user_id}", entry["route"])
+	assert.GreaterOrEqual(t, entry["duration_ms"], float64(30))
+	assert.Contains(t, entry, "correlation_id")
+	assert.Equal(t, int64(1), slowCount())
+}
+
+func BenchmarkSlow_Fast(b *testing.B) {
+	monitor := slowlog.NewMonitor(slowlog.Args{Metrics: metrics.NewRegistry(sdkmetric.NewMeterProvider().Meter("test"))})
+	handler := Slow(monitor)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	req := httptest.NewRequest(http.MethodGet, "/v1/users", nil)
+	w := httptest.NewRecorder()
+
+	b.ReportAllocs()
+	for b.Loop() {
+		handler.ServeHTTP(w, req)
+	}
+}
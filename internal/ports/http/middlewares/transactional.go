@@ -0,0 +1,53 @@
+package middlewares
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/httpx"
+)
+
+// UnitOfWork runs fn in a transaction the repositories join through its
+// context, see postgres.UnitOfWork.
+type UnitOfWork interface {
+	Do(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// errNotSuccess rolls back the transaction of a request not answered 2xx.
+var errNotSuccess = errors.New("request not answered with a success")
+
+// Transactional runs the handler in a unit of work, it is set per route on
+// the handlers whose writes must land together. The response is held back
+// until the transaction commits, on a 2xx; any other status or a panic rolls
+// it back, and a failed commit is answered 500 instead. The streams and the
+// long running handlers must not use it, they would hold the transaction
+// open, httpx.NewEventStream panics under it.
+func Transactional(uow UnitOfWork, errhandler *httpx.ErrorHandler) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			const op = "middlewares.Transactional"
+			buf := httpx.NewBufferedResponse(w)
+			err := uow.Do(r.Context(), func(ctx context.Context) error {
+				next.ServeHTTP(buf, r.WithContext(ctx))
+				if status := buf.Status(); status < 200 || status >= 300 {
+					return errNotSuccess
+				}
+				return nil
+			})
+			if err != nil && !errors.Is(err, errNotSuccess) {
+				errhandler.HandleError(w, r, trace.SpanFromContext(r.Context()),
+					errorx.NewInternalError().WithCause(err, op), "failed to run the request transaction")
+				return
+			}
+
+			if err := buf.Send(); err != nil {
+				logger.ErrorContext(r.Context(), "failed to write the buffered response", slog.String("error", err.Error()))
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package middlewares
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"gitlab.com/ucmsv2/ucms-backend/pkg/httpx"
+)
+
+// fakeUnitOfWork records the outcome of its transactions like
+// postgres.UnitOfWork, commitErr fails the commits.
+type fakeUnitOfWork struct {
+	commitErr  error
+	committed  int
+	rolledBack int
+}
+
+func (u *fakeUnitOfWork) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	defer func() {
+		if v := recover(); v != nil {
+			u.rolledBack++
+			panic(v)
+		}
+	}()
+	if err := fn(ctx); err != nil {
+		u.rolledBack++
+		return err
+	}
+	if u.commitErr != nil {
+		u.rolledBack++
+		return u.commitErr
+	}
+	u.committed++
+	return nil
+}
+
+func serveTransactional(uow *fakeUnitOfWork, h http.HandlerFunc) *httptest.ResponseRecorder {
+	res := httptest.NewRecorder()
+	Transactional(uow, httpx.NewErrorHandler())(h).ServeHTTP(res, httptest.NewRequest(http.MethodPost, "/", nil))
+	return res
+}
+
+func TestTransactional(t *testing.T) {
+	created := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Location", "/v1/staffs/me")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"success":true}`))
+	}
+
+	t.Run("commits a success", func(t *testing.T) {
+		uow := &fakeUnitOfWork{}
+		res := serveTransactional(uow, created)
+
+		assert.Equal(t, 1, uow.committed)
+		assert.Equal(t, http.StatusCreated, res.Code)
+		assert.Equal(t, "/v1/staffs/me", res.Header().Get("Location"))
+		assert.JSONEq(t, `{"success":true}`, res.Body.String())
+	})
+
+	t.Run("rolls back an error response", func(t *testing.T) {
+		uow := &fakeUnitOfWork{}
+		res := serveTransactional(uow, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusConflict)
+		})
+
+		assert.Equal(t, 1, uow.rolledBack)
+		assert.Zero(t, uow.committed)
+		assert.Equal(t, http.StatusConflict, res.Code, "the response of the handler is kept")
+	})
+
+	t.Run("answers 500 when the commit fails", func(t *testing.T) {
+		uow := &fakeUnitOfWork{commitErr: errors.New("connection reset")}
+		res := serveTransactional(uow, created)
+
+		assert.Equal(t, http.StatusInternalServerError, res.Code)
+		assert.Empty(t, res.Header().Get("Location"), "the success is not sent")
+	})
+
+	t.Run("rolls back a panic", func(t *testing.T) {
+		uow := &fakeUnitOfWork{}
+		assert.PanicsWithValue(t, "boom", func() {
+			serveTransactional(uow, func(w http.ResponseWriter, r *http.Request) { panic("boom") })
+		})
+		assert.Equal(t, 1, uow.rolledBack)
+	})
+
+	t.Run("refuses an event stream", func(t *testing.T) {
+		uow := &fakeUnitOfWork{}
+		assert.Panics(t, func() {
+			serveTransactional(uow, func(w http.ResponseWriter, r *http.Request) {
+				_, _ = httpx.NewEventStream(w, 0)
+			})
+		})
+		assert.Equal(t, 1, uow.rolledBack)
+	})
+}
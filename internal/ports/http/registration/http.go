@@ -6,7 +6,6 @@ import (
 	"strings"
 
 	"github.com/ARUMANDESU/validation"
-	"github.com/ARUMANDESU/validation/is"
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 	"go.opentelemetry.io/contrib/bridges/otelslog"
@@ -18,6 +17,8 @@ import (
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/group"
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/registration"
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/emails"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/ctxs"
 	"gitlab.com/ucmsv2/ucms-backend/pkg/env"
 	"gitlab.com/ucmsv2/ucms-backend/pkg/httpx"
 	"gitlab.com/ucmsv2/ucms-backend/pkg/logging"
@@ -37,6 +38,8 @@ type HTTP struct {
 	cmd        *registrationapp.Command
 	query      *registrationapp.Query
 	errhandler *httpx.ErrorHandler
+	mode       env.Mode
+	tx         func(http.Handler) http.Handler
 }
 
 type Args struct {
@@ -44,6 +47,12 @@ type Args struct {
 	Logger     *slog.Logger
 	App        *registrationapp.App
 	Errhandler *httpx.ErrorHandler
+	// Mode mounts the dev endpoints when it allows env.CapDebugEndpoints,
+	// defaults to env.Current.
+	Mode env.Mode
+	// Transactional runs the completion in one transaction, see
+	// middlewares.Transactional. Optional.
+	Transactional func(http.Handler) http.Handler
 }
 
 func NewHTTP(args Args) *HTTP {
@@ -53,6 +62,12 @@ func NewHTTP(args Args) *HTTP {
 	if args.Logger == nil {
 		args.Logger = logger
 	}
+	if args.Mode == "" {
+		args.Mode = env.Current()
+	}
+	if args.Transactional == nil {
+		args.Transactional = func(next http.Handler) http.Handler { return next }
+	}
 
 	return &HTTP{
 		tracer:     args.Tracer,
@@ -60,6 +75,8 @@ func NewHTTP(args Args) *HTTP {
 		cmd:        &args.App.Command,
 		query:      &args.App.Query,
 		errhandler: args.Errhandler,
+		mode:       args.Mode,
+		tx:         args.Transactional,
 	}
 }
 
@@ -68,10 +85,10 @@ func (h *HTTP) Route(r chi.Router) {
 		r.Post("/verify", h.Verify)
 		r.Post("/resend", h.ResendVerificationCode)
 		r.Post("/students/start", h.StartStudentRegistration)
-		r.Post("/students/complete", h.CompleteStudentRegistration)
+		r.With(h.tx).Post("/students/complete", h.CompleteStudentRegistration)
 	})
 
-	if env.Current() == env.Dev || env.Current() == env.Local || env.Current() == env.Test {
+	if h.mode.Allows(env.CapDebugEndpoints) {
 		r.Get("/dev/registrations/verification-code/{email}", h.GetVerificationCode)
 	}
 }
@@ -81,7 +98,7 @@ type StartStudentRegistrationRequest struct {
 }
 
 func (r *StartStudentRegistrationRequest) Sanitized() {
-	r.Email = sanitizex.CleanSingleLine(r.Email)
+	r.Email = sanitizex.CleanEmail(r.Email)
 }
 
 func (r *StartStudentRegistrationRequest) SetSpanAttrs(span trace.Span) {
@@ -111,8 +128,15 @@ func (h *HTTP) StartStudentRegistration(w http.ResponseWriter, r *http.Request)
 		h.errhandler.HandleError(w, r, span, err, "failed to validate request body")
 		return
 	}
+	// The rules above report the errors to the client, the address is valid
+	// by now and New only types it.
+	email, err := emails.New(req.Email)
+	if err != nil {
+		h.errhandler.HandleError(w, r, span, err, "failed to validate request body")
+		return
+	}
 
-	if err := h.cmd.StartStudent.Handle(ctx, cmd.StartStudent{Email: req.Email}); err != nil {
+	if err := h.cmd.StartStudent.Handle(ctx, cmd.StartStudent{Email: email}); err != nil {
 		h.errhandler.HandleError(w, r, span, err, "failed to start student registration")
 		return
 	}
@@ -126,7 +150,7 @@ type VerifyRequest struct {
 }
 
 func (r *VerifyRequest) Sanitized() {
-	r.Email = sanitizex.CleanSingleLine(r.Email)
+	r.Email = sanitizex.CleanEmail(r.Email)
 	r.VerificationCode = sanitizex.CleanSingleLine(r.VerificationCode)
 }
 
@@ -139,8 +163,7 @@ func (r *VerifyRequest) Validate() error {
 		validation.Field(&r.Email, validationx.EmailRules...),
 		validation.Field(&r.VerificationCode,
 			validation.Required,
-			validation.Length(registration.VerificationCodeLength, registration.VerificationCodeLength),
-			is.Alphanumeric,
+			validationx.IsVerificationCode(registration.VerificationCodeLength),
 		),
 	)
 }
@@ -162,9 +185,14 @@ func (h *HTTP) Verify(w http.ResponseWriter, r *http.Request) {
 		h.errhandler.HandleError(w, r, span, err, "failed to validate request body")
 		return
 	}
+	email, err := emails.New(req.Email)
+	if err != nil {
+		h.errhandler.HandleError(w, r, span, err, "failed to validate request body")
+		return
+	}
 
 	cmd := cmd.Verify{
-		Email: req.Email,
+		Email: email,
 		Code:  req.VerificationCode,
 	}
 	if err := h.cmd.Verify.Handle(ctx, cmd); err != nil {
@@ -184,12 +212,14 @@ type CompleteStudentRegistrationRequest struct {
 	LastName         string    `json:"last_name"`
 	Password         string    `json:"password"`
 	VerificationCode string    `json:"verification_code"`
+	// AcceptTOS must be true once terms of service are published.
+	AcceptTOS bool `json:"accept_tos"`
 }
 
 func (r *CompleteStudentRegistrationRequest) Sanitized() {
 	r.Barcode = sanitizex.CleanSingleLine(r.Barcode)
 	r.Username = sanitizex.CleanSingleLine(r.Username)
-	r.Email = sanitizex.CleanSingleLine(r.Email)
+	r.Email = sanitizex.CleanEmail(r.Email)
 	r.FirstName = sanitizex.CleanSingleLine(r.FirstName)
 	r.LastName = sanitizex.CleanSingleLine(r.LastName)
 	r.VerificationCode = sanitizex.CleanSingleLine(r.VerificationCode)
@@ -209,18 +239,28 @@ func (r *CompleteStudentRegistrationRequest) Validate() error {
 		validation.Field(&r.Email, validationx.EmailRules...),
 		validation.Field(&r.VerificationCode,
 			validation.Required,
-			validation.Length(registration.VerificationCodeLength, registration.VerificationCodeLength),
-			is.Alphanumeric,
+			validationx.IsVerificationCode(registration.VerificationCodeLength),
 		),
-		validation.Field(&r.Username, validation.Required, validation.Length(2, 100)),
+		validation.Field(&r.Username, validation.Required, validation.Length(2, 100), validationx.IsLatinUsername),
 		validation.Field(&r.FirstName, validationx.NameRules...),
 		validation.Field(&r.LastName, validationx.NameRules...),
-		validation.Field(&r.Password, validationx.PasswordRules...),
-		validation.Field(&r.Barcode, validation.Required, validation.Length(1, 100), is.Alphanumeric),
+		validation.Field(&r.Password, user.PasswordRules...),
+		validation.Field(&r.Barcode, validation.Required, validationx.IsBarcode),
 		validation.Field(&r.GroupId, validationx.Required),
 	)
 }
 
+// Warnings normalizes the names written in capitals and the usernames
+// wrapped in dots and flags the aliased emails, run it on a valid request.
+func (r *CompleteStudentRegistrationRequest) Warnings() validationx.Warnings {
+	var warnings validationx.Warnings
+	warnings.Check("first_name", &r.FirstName, validationx.TitleCaseAllCaps)
+	warnings.Check("last_name", &r.LastName, validationx.TitleCaseAllCaps)
+	warnings.Check("username", &r.Username, validationx.TrimEdgeDots)
+	warnings.Check("email", &r.Email, validationx.PlusTag)
+	return warnings
+}
+
 func (h *HTTP) CompleteStudentRegistration(w http.ResponseWriter, r *http.Request) {
 	ctx, span := h.tracer.Start(r.Context(), "CompleteStudentRegistration")
 	defer span.End()
@@ -238,23 +278,36 @@ func (h *HTTP) CompleteStudentRegistration(w http.ResponseWriter, r *http.Reques
 		h.errhandler.HandleError(w, r, span, err, "failed to validate request body")
 		return
 	}
+	warnings := req.Warnings()
+	email, err := emails.New(req.Email)
+	if err != nil {
+		h.errhandler.HandleError(w, r, span, err, "failed to validate request body")
+		return
+	}
+	barcode, err := user.NewBarcode(req.Barcode)
+	if err != nil {
+		h.errhandler.HandleError(w, r, span, err, "failed to validate request body")
+		return
+	}
 
 	cmd := cmd.StudentComplete{
-		Email:            req.Email,
+		Email:            email,
 		VerificationCode: req.VerificationCode,
-		Barcode:          user.Barcode(req.Barcode),
+		Barcode:          barcode,
 		Username:         req.Username,
 		FirstName:        req.FirstName,
 		LastName:         req.LastName,
 		Password:         req.Password,
 		GroupID:          group.ID(req.GroupId),
+		AcceptTOS:        req.AcceptTOS,
+		IP:               ctxs.ClientIPFromCtx(ctx),
 	}
 	if err := h.cmd.StudentComplete.Handle(ctx, cmd); err != nil {
 		h.errhandler.HandleError(w, r, span, err, "failed to complete student registration")
 		return
 	}
 
-	httpx.Success(w, r, http.StatusOK, nil)
+	httpx.SuccessWithWarnings(w, r, http.StatusOK, nil, warnings)
 }
 
 type ResendVerificationCodeRequest struct {
@@ -262,7 +315,7 @@ type ResendVerificationCodeRequest struct {
 }
 
 func (r *ResendVerificationCodeRequest) Sanitized() {
-	r.Email = sanitizex.CleanSingleLine(r.Email)
+	r.Email = sanitizex.CleanEmail(r.Email)
 }
 
 func (r *ResendVerificationCodeRequest) SetSpanAttrs(span trace.Span) {
@@ -292,8 +345,13 @@ func (h *HTTP) ResendVerificationCode(w http.ResponseWriter, r *http.Request) {
 		h.errhandler.HandleError(w, r, span, err, "failed to validate request body")
 		return
 	}
+	email, err := emails.New(req.Email)
+	if err != nil {
+		h.errhandler.HandleError(w, r, span, err, "failed to validate request body")
+		return
+	}
 
-	cmd := cmd.ResendCode{Email: req.Email}
+	cmd := cmd.ResendCode{Email: email}
 	if err := h.cmd.ResendCode.Handle(ctx, cmd); err != nil {
 		h.errhandler.HandleError(w, r, span, err, "failed to resend verification code")
 		return
@@ -307,7 +365,7 @@ func (h *HTTP) GetVerificationCode(w http.ResponseWriter, r *http.Request) {
 	defer span.End()
 
 	email := chi.URLParam(r, "email")
-	email = sanitizex.CleanSingleLine(email)
+	email = sanitizex.CleanEmail(email)
 
 	err := validation.Validate(email, validationx.EmailRules...)
 	if err != nil {
@@ -0,0 +1,169 @@
+package reporthttp
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/contrib/bridges/otelslog"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+
+	reportapp "gitlab.com/ucmsv2/ucms-backend/internal/application/report"
+	"gitlab.com/ucmsv2/ucms-backend/internal/application/report/reportquery"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/report"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/term"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/httpx"
+)
+
+var (
+	tracer = otel.Tracer("ucms/internal/ports/http/report")
+	logger = otelslog.NewLogger("ucms/internal/ports/http/report")
+)
+
+// HTTP serves the generated reports to the staff.
+type HTTP struct {
+	tracer     trace.Tracer
+	logger     *slog.Logger
+	app        *reportapp.App
+	errhandler *httpx.ErrorHandler
+}
+
+type Args struct {
+	Tracer     trace.Tracer
+	Logger     *slog.Logger
+	App        *reportapp.App
+	Errhandler *httpx.ErrorHandler
+}
+
+func NewHTTP(args Args) *HTTP {
+	if args.Tracer == nil {
+		args.Tracer = tracer
+	}
+	if args.Logger == nil {
+		args.Logger = logger
+	}
+
+	return &HTTP{
+		tracer:     args.Tracer,
+		logger:     args.Logger,
+		app:        args.App,
+		errhandler: args.Errhandler,
+	}
+}
+
+func (h *HTTP) Route(r chi.Router) {
+	// The staff port mounts /v1/staffs, chi matches this static path before
+	// the mount.
+	r.Get("/v1/staffs/reports/weekly", h.GetWeeklyReport)
+	r.Get("/v1/staffs/dashboard", h.GetDashboard)
+}
+
+// GetWeeklyReport returns the report of the week the week query parameter,
+// a YYYY-MM-DD date, is in. It defaults to the last week that is over.
+func (h *HTTP) GetWeeklyReport(w http.ResponseWriter, r *http.Request) {
+	const op = "reporthttp.HTTP.GetWeeklyReport"
+	ctx, span := h.tracer.Start(r.Context(), "HTTP.GetWeeklyReport")
+	defer span.End()
+
+	var day time.Time
+	if week := r.URL.Query().Get("week"); week != "" {
+		var err error
+		day, err = time.Parse(time.DateOnly, week)
+		if err != nil {
+			err = errorx.NewInvalidRequest().WithCause(err, op).WithDetails("week must be a date such as 2026-05-04")
+			h.errhandler.HandleError(w, r, span, err, "failed to parse week")
+			return
+		}
+	}
+
+	res, err := h.app.Query.Weekly.Get(ctx, day)
+	if err != nil {
+		h.errhandler.HandleError(w, r, span, err, "failed to get weekly report")
+		return
+	}
+
+	httpx.Success(w, r, http.StatusOK, httpx.Envelope{"report": newWeeklyResponse(res)})
+}
+
+// GetDashboard returns the summary of the home page of the admin UI, see
+// reportquery.Dashboard. The term query parameter, a term id, filters it by
+// that term instead of the current one. A partial dashboard is still a 200.
+func (h *HTTP) GetDashboard(w http.ResponseWriter, r *http.Request) {
+	const op = "reporthttp.HTTP.GetDashboard"
+	ctx, span := h.tracer.Start(r.Context(), "HTTP.GetDashboard")
+	defer span.End()
+
+	var f reportquery.DashboardFilter
+	if t := r.URL.Query().Get("term"); t != "" {
+		id, err := uuid.Parse(t)
+		if err != nil {
+			err = errorx.NewInvalidRequest().WithCause(err, op).WithDetails("term must be a term id")
+			h.errhandler.HandleError(w, r, span, err, "failed to parse term")
+			return
+		}
+		f.TermID = term.ID(id)
+	}
+
+	res, err := h.app.Query.Dashboard.Get(ctx, f)
+	if err != nil {
+		h.errhandler.HandleError(w, r, span, err, "failed to get dashboard")
+		return
+	}
+
+	httpx.Success(w, r, http.StatusOK, httpx.Envelope{"dashboard": res})
+}
+
+// WeeklyResponse is the report.Weekly of the API, with its times in UTC.
+type WeeklyResponse struct {
+	WeekStart httpx.Time `json:"week_start"`
+	WeekEnd   httpx.Time `json:"week_end"`
+	report.Provisioning
+	CompletionRate float64                   `json:"completion_rate"`
+	Term           *TermProvisioningResponse `json:"term"`
+	GeneratedAt    httpx.Time                `json:"generated_at"`
+}
+
+// TermProvisioningResponse is the report.TermProvisioning of the API.
+type TermProvisioningResponse struct {
+	Term TermResponse `json:"term"`
+	To   httpx.Time   `json:"to"`
+	report.Provisioning
+	CompletionRate float64 `json:"completion_rate"`
+}
+
+type TermResponse struct {
+	ID       string     `json:"id"`
+	Code     string     `json:"code"`
+	Name     string     `json:"name"`
+	StartsAt httpx.Time `json:"starts_at"`
+	EndsAt   httpx.Time `json:"ends_at"`
+}
+
+func newWeeklyResponse(w *report.Weekly) WeeklyResponse {
+	res := WeeklyResponse{
+		WeekStart:      httpx.NewTime(w.WeekStart),
+		WeekEnd:        httpx.NewTime(w.WeekEnd),
+		Provisioning:   w.Provisioning,
+		CompletionRate: w.CompletionRate,
+		GeneratedAt:    httpx.NewTime(w.GeneratedAt),
+	}
+	if w.Term != nil {
+		res.Term = &TermProvisioningResponse{
+			Term: TermResponse{
+				ID:       w.Term.Term.ID.String(),
+				Code:     w.Term.Term.Code,
+				Name:     w.Term.Term.Name,
+				StartsAt: httpx.NewTime(w.Term.Term.StartsAt),
+				EndsAt:   httpx.NewTime(w.Term.Term.EndsAt),
+			},
+			To:             httpx.NewTime(w.Term.To),
+			Provisioning:   w.Term.Provisioning,
+			CompletionRate: w.Term.CompletionRate,
+		}
+	}
+	return res
+}
@@ -0,0 +1,273 @@
+package searchhttp
+
+import (
+	"log/slog"
+	"math"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ARUMANDESU/validation"
+	"github.com/go-chi/chi/v5"
+	"go.opentelemetry.io/contrib/bridges/otelslog"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+
+	searchapp "gitlab.com/ucmsv2/ucms-backend/internal/application/search"
+	"gitlab.com/ucmsv2/ucms-backend/internal/application/search/searchquery"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/httpx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx"
+)
+
+var (
+	tracer = otel.Tracer("ucms/internal/ports/http/search")
+	logger = otelslog.NewLogger("ucms/internal/ports/http/search")
+)
+
+// HTTP serves the typeahead search of the staff.
+type HTTP struct {
+	tracer     trace.Tracer
+	logger     *slog.Logger
+	app        *searchapp.App
+	errhandler *httpx.ErrorHandler
+}
+
+type Args struct {
+	Tracer     trace.Tracer
+	Logger     *slog.Logger
+	App        *searchapp.App
+	Errhandler *httpx.ErrorHandler
+}
+
+func NewHTTP(args Args) *HTTP {
+	if args.Tracer == nil {
+		args.Tracer = tracer
+	}
+	if args.Logger == nil {
+		args.Logger = logger
+	}
+
+	return &HTTP{
+		tracer:     args.Tracer,
+		logger:     args.Logger,
+		app:        args.App,
+		errhandler: args.Errhandler,
+	}
+}
+
+func (h *HTTP) Route(r chi.Router) {
+	// The staff port mounts /v1/staffs, chi matches this static path before
+	// the mount.
+	r.Get("/v1/staffs/search", h.Search)
+	r.Get("/v1/staffs/search/users", h.ListUsers)
+	r.Get("/v1/staffs/users/inactive", h.ListInactiveUsers)
+}
+
+type SearchRequest struct {
+	Query string
+	Types []searchquery.Type
+	Limit int
+}
+
+func (r *SearchRequest) SetSpanAttrs(span trace.Span) {
+	otelx.SetSpanAttrs(span, map[string]any{
+		"request.query_len": len(r.Query),
+		"request.limit":     r.Limit,
+	})
+}
+
+func (r *SearchRequest) Validate() error {
+	return validation.ValidateStruct(r,
+		validation.Field(&r.Query, validation.Required, validation.RuneLength(searchquery.MinQueryLen, searchquery.MaxQueryLen)),
+	)
+}
+
+// Search looks up ?q in the users, groups and invitations, ?types restricts
+// the buckets and ?limit caps each of them.
+func (h *HTTP) Search(w http.ResponseWriter, r *http.Request) {
+	const op = "searchhttp.HTTP.Search"
+	ctx, span := h.tracer.Start(r.Context(), "HTTP.Search")
+	defer span.End()
+
+	query := httpx.Query(r)
+	req := SearchRequest{
+		Query: query.String("q"),
+		Limit: query.Int("limit", 1, searchquery.MaxLimit, searchquery.DefaultLimit),
+	}
+	types := make([]string, len(searchquery.Types))
+	for i, t := range searchquery.Types {
+		types[i] = string(t)
+	}
+	for _, t := range query.EnumList("types", types...) {
+		req.Types = append(req.Types, searchquery.Type(t))
+	}
+	if err := query.Err(); err != nil {
+		h.errhandler.HandleError(w, r, span, errorx.Wrap(err, op), "invalid query parameters")
+		return
+	}
+
+	req.SetSpanAttrs(span)
+	if err := req.Validate(); err != nil {
+		h.errhandler.HandleError(w, r, span, err, "validation failed")
+		return
+	}
+
+	res, err := h.app.Query.Search.Handle(ctx, searchquery.Search{
+		Query: req.Query,
+		Types: req.Types,
+		Limit: req.Limit,
+	})
+	if err != nil {
+		h.errhandler.HandleError(w, r, span, err, "failed to search")
+		return
+	}
+
+	httpx.Success(w, r, http.StatusOK, httpx.Envelope{"results": res})
+}
+
+type ListUsersRequest struct {
+	Query            string
+	EnrollmentStatus string
+	Department       string
+	Cursor           string
+	Page             int
+	PageSize         int
+}
+
+func (r *ListUsersRequest) SetSpanAttrs(span trace.Span) {
+	otelx.SetSpanAttrs(span, map[string]any{
+		"request.query_len":  len(r.Query),
+		"request.status":     r.EnrollmentStatus,
+		"request.department": r.Department,
+		"request.cursor":     r.Cursor != "",
+		"request.page":       r.Page,
+		"request.page_size":  r.PageSize,
+	})
+}
+
+func (r *ListUsersRequest) Validate() error {
+	return validation.ValidateStruct(r,
+		validation.Field(&r.Query, validation.RuneLength(searchquery.MinQueryLen, searchquery.MaxQueryLen)),
+		validation.Field(&r.EnrollmentStatus, validation.In(
+			user.Enrolled.String(), user.AcademicLeave.String(), user.Graduated.String(), user.Expelled.String(),
+		)),
+		validation.Field(&r.Department, validation.RuneLength(0, user.MaxDepartmentLen)),
+		// A cursor carries its position, a page along with it is ambiguous.
+		validation.Field(&r.Page, validation.When(r.Cursor != "", validation.In(1))),
+	)
+}
+
+// ListUsers pages through the users, those containing ?q only when it is
+// set. ?status keeps the students with the enrollment status, ?department the
+// staff members of the department. ?cursor resumes after the page that
+// returned meta.next_cursor, ?page pages by offset without it.
+func (h *HTTP) ListUsers(w http.ResponseWriter, r *http.Request) {
+	const op = "searchhttp.HTTP.ListUsers"
+	ctx, span := h.tracer.Start(r.Context(), "HTTP.ListUsers")
+	defer span.End()
+
+	query := httpx.Query(r)
+	req := ListUsersRequest{
+		Query:            query.String("q"),
+		EnrollmentStatus: query.String("status"),
+		Department:       query.String("department"),
+		Cursor:           query.String("cursor"),
+		Page:             query.Int("page", 1, math.MaxInt32, 1),
+		PageSize:         query.Int("page_size", 1, searchquery.MaxPageSize, searchquery.DefaultPageSize),
+	}
+	if err := query.Err(); err != nil {
+		h.errhandler.HandleError(w, r, span, errorx.Wrap(err, op), "invalid query parameters")
+		return
+	}
+
+	req.SetSpanAttrs(span)
+	if err := req.Validate(); err != nil {
+		h.errhandler.HandleError(w, r, span, err, "validation failed")
+		return
+	}
+
+	res, err := h.app.Query.ListUsers.Handle(ctx, searchquery.ListUsers{
+		Query:            req.Query,
+		EnrollmentStatus: user.EnrollmentStatus(req.EnrollmentStatus),
+		Department:       req.Department,
+		Cursor:           req.Cursor,
+		Page:             req.Page,
+		PageSize:         req.PageSize,
+	})
+	if err != nil {
+		h.errhandler.HandleError(w, r, span, err, "failed to list users")
+		return
+	}
+
+	httpx.Success(w, r, http.StatusOK, httpx.Envelope{"users": res.Users, "meta": res.Meta})
+}
+
+type ListInactiveUsersRequest struct {
+	// Since is the time the users have not been seen for, in days, e.g. 90d.
+	Since    string
+	Page     int
+	PageSize int
+}
+
+func (r *ListInactiveUsersRequest) SetSpanAttrs(span trace.Span) {
+	otelx.SetSpanAttrs(span, map[string]any{
+		"request.since":     r.Since,
+		"request.page":      r.Page,
+		"request.page_size": r.PageSize,
+	})
+}
+
+func (r *ListInactiveUsersRequest) Validate() error {
+	return validation.ValidateStruct(r,
+		validation.Field(&r.Since, validation.Match(sinceDays)),
+	)
+}
+
+// sinceDays matches the ?since of the inactive users, a number of days.
+var sinceDays = regexp.MustCompile(`^[1-9][0-9]{0,3}d$`)
+
+// days returns the number of days of Since, 0 when it is not set.
+func (r *ListInactiveUsersRequest) days() int {
+	days, _ := strconv.Atoi(strings.TrimSuffix(r.Since, "d"))
+	return days
+}
+
+// ListInactiveUsers pages through the users not seen for ?since days, 90d by
+// default, those never seen first.
+func (h *HTTP) ListInactiveUsers(w http.ResponseWriter, r *http.Request) {
+	const op = "searchhttp.HTTP.ListInactiveUsers"
+	ctx, span := h.tracer.Start(r.Context(), "HTTP.ListInactiveUsers")
+	defer span.End()
+
+	query := httpx.Query(r)
+	req := ListInactiveUsersRequest{
+		Since:    query.String("since"),
+		Page:     query.Int("page", 1, math.MaxInt32, 1),
+		PageSize: query.Int("page_size", 1, searchquery.MaxPageSize, searchquery.DefaultPageSize),
+	}
+	if err := query.Err(); err != nil {
+		h.errhandler.HandleError(w, r, span, errorx.Wrap(err, op), "invalid query parameters")
+		return
+	}
+
+	req.SetSpanAttrs(span)
+	if err := req.Validate(); err != nil {
+		h.errhandler.HandleError(w, r, span, err, "validation failed")
+		return
+	}
+
+	res, err := h.app.Query.ListInactiveUsers.Handle(ctx, searchquery.ListInactiveUsers{
+		Days:     req.days(),
+		Page:     req.Page,
+		PageSize: req.PageSize,
+	})
+	if err != nil {
+		h.errhandler.HandleError(w, r, span, err, "failed to list inactive users")
+		return
+	}
+
+	httpx.Success(w, r, http.StatusOK, httpx.Envelope{"users": res.Users, "since": res.Since, "meta": res.Meta})
+}
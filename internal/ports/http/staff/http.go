@@ -4,7 +4,7 @@ import (
 	"fmt"
 	"log/slog"
 	"net/http"
-	"net/url"
+	"slices"
 	"strings"
 	"time"
 
@@ -19,15 +19,18 @@ import (
 
 	staffapp "gitlab.com/ucmsv2/ucms-backend/internal/application/staff"
 	"gitlab.com/ucmsv2/ucms-backend/internal/application/staff/cmd"
+	"gitlab.com/ucmsv2/ucms-backend/internal/application/staff/staffquery"
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/staffinvitation"
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
-	"gitlab.com/ucmsv2/ucms-backend/internal/ports/http/middlewares"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/emails"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
 	"gitlab.com/ucmsv2/ucms-backend/pkg/ctxs"
 	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
 	"gitlab.com/ucmsv2/ucms-backend/pkg/httpx"
 	"gitlab.com/ucmsv2/ucms-backend/pkg/logging"
 	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx"
 	"gitlab.com/ucmsv2/ucms-backend/pkg/sanitizex"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/urlx"
 	"gitlab.com/ucmsv2/ucms-backend/pkg/validationx"
 )
 
@@ -43,7 +46,20 @@ var (
 
 var (
 	recipientsEmailRules = []validation.Rule{validation.Count(0, 100), validation.Each(validation.Required, is.Email)}
-	validityRules        = []validation.Rule{validation.NilOrNotEmpty}
+	validFromRules       = func(now func() time.Time) []validation.Rule {
+		return []validation.Rule{validation.NilOrNotEmpty, validationx.FutureTime(now)}
+	}
+	departmentRules = []validation.Rule{validation.RuneLength(0, user.MaxDepartmentLen)}
+	positionRules   = []validation.Rule{validation.RuneLength(0, user.MaxPositionLen)}
+	// validUntilRules mirror the checks of the staffinvitation domain, which
+	// stays the authority, so that the errors name the fields.
+	validUntilRules = func(validFrom *time.Time, now func() time.Time) []validation.Rule {
+		return []validation.Rule{
+			validation.NilOrNotEmpty,
+			validationx.FutureTime(now),
+			validationx.TimeWindowRule{From: validFrom, MinDuration: staffinvitation.ValidFromThreshold},
+		}
+	}
 )
 
 type HTTP struct {
@@ -52,33 +68,39 @@ type HTTP struct {
 	cmd                     *staffapp.Command
 	query                   *staffapp.Query
 	errhandler              *httpx.ErrorHandler
-	middleware              *middlewares.Middleware
-	acceptInvitationPageURL string
+	acceptInvitationPageURL urlx.URL
 	signingMethod           jwt.SigningMethod
 	secretKey               string
 	invitationTokenExp      time.Duration
+	clock                   clock.Clock
+	tx                      func(http.Handler) http.Handler
 }
 
 type Args struct {
-	Tracer                  trace.Tracer
-	Logger                  *slog.Logger
-	App                     *staffapp.App
-	Errhandler              *httpx.ErrorHandler
-	Middleware              *middlewares.Middleware
-	AcceptInvitationPageURL string
+	Tracer     trace.Tracer
+	Logger     *slog.Logger
+	App        *staffapp.App
+	Errhandler *httpx.ErrorHandler
+	// AcceptInvitationPageURL is the page a validated invitation is
+	// redirected to, with the signed token in its query.
+	AcceptInvitationPageURL urlx.URL
 	InvitationTokenAlg      jwt.SigningMethod
 	InvitationTokenKey      string
 	InvitationTokenExp      time.Duration
+	// Clock is the time the validity periods must be in the future of,
+	// defaults to clock.Real.
+	Clock clock.Clock
+	// Transactional runs the acceptance and the creation of invitations in
+	// one transaction, see middlewares.Transactional. Optional, without it
+	// the pending recipients limit is not serialized per creator.
+	Transactional func(http.Handler) http.Handler
 }
 
 func NewHTTP(args Args) *HTTP {
 	if args.App == nil {
 		panic("app is required")
 	}
-	if args.Middleware == nil {
-		panic("middleware is required")
-	}
-	if args.AcceptInvitationPageURL == "" {
+	if args.AcceptInvitationPageURL.IsZero() {
 		panic("accept invitation page url is required")
 	}
 	h := &HTTP{
@@ -87,11 +109,12 @@ func NewHTTP(args Args) *HTTP {
 		cmd:                     &args.App.Command,
 		query:                   &args.App.Query,
 		errhandler:              args.Errhandler,
-		middleware:              args.Middleware,
 		acceptInvitationPageURL: args.AcceptInvitationPageURL,
 		signingMethod:           args.InvitationTokenAlg,
 		secretKey:               args.InvitationTokenKey,
 		invitationTokenExp:      args.InvitationTokenExp,
+		clock:                   clock.Or(args.Clock),
+		tx:                      args.Transactional,
 	}
 
 	if h.tracer == nil {
@@ -109,6 +132,9 @@ func NewHTTP(args Args) *HTTP {
 	if h.signingMethod == nil {
 		h.signingMethod = jwt.SigningMethodHS256
 	}
+	if h.tx == nil {
+		h.tx = func(next http.Handler) http.Handler { return next }
+	}
 	if h.secretKey == "" {
 		panic("secret key is required for invitation token")
 	}
@@ -118,10 +144,12 @@ func NewHTTP(args Args) *HTTP {
 
 func (h *HTTP) Route(r chi.Router) {
 	r.Route("/v1/staffs", func(r chi.Router) {
-		r.Use(h.middleware.Auth, h.middleware.StaffOnly)
+		r.Get("/me", h.GetProfile)
+		r.Patch("/me", h.UpdateProfile)
 
 		r.Route("/invitations", func(r chi.Router) {
-			r.Post("/", h.CreateInvitation)
+			r.With(h.tx).Post("/", h.CreateInvitation)
+			r.With(h.tx).Post("/bulk", h.BulkCreateInvitations)
 			r.Put("/{invitation_id}/recipients", h.UpdateInvitationRecipients)
 			r.Put("/{invitation_id}/validity", h.UpdateInvitationValidity)
 			r.Delete("/{invitation_id}", h.DeleteInvitation)
@@ -130,7 +158,7 @@ func (h *HTTP) Route(r chi.Router) {
 
 	r.Route("/v1/invitations", func(r chi.Router) {
 		r.Get("/{invitation_code}/validate", h.Validate)
-		r.Post("/accept", h.AcceptInvitation)
+		r.With(h.tx).Post("/accept", h.AcceptInvitation)
 	})
 }
 
@@ -138,10 +166,37 @@ type CreateInvitationRequest struct {
 	Recipients []string   `json:"recipients_email"`
 	ValidFrom  *time.Time `json:"valid_from"`
 	ValidUntil *time.Time `json:"valid_until"`
+	// Department and Position pre-fill the accounts of the recipients.
+	Department string `json:"department"`
+	Position   string `json:"position"`
 }
 
 func (c *CreateInvitationRequest) Sanitize() {
-	c.Recipients = sanitizex.DeduplicateSlice(c.Recipients, sanitizex.StringTransformFunc(sanitizex.CleanSingleLine))
+	c.Recipients = sanitizeRecipients(c.Recipients)
+	c.Department = sanitizex.CleanSingleLine(c.Department)
+	c.Position = sanitizex.CleanSingleLine(c.Position)
+	c.ValidFrom = httpx.NormalizeTimePtr(c.ValidFrom)
+	c.ValidUntil = httpx.NormalizeTimePtr(c.ValidUntil)
+}
+
+// sanitizeRecipients normalizes the emails and drops the duplicates, a+b@x.com
+// and A@x.com are the same recipient. The first address of every recipient
+// is kept, normalized.
+func sanitizeRecipients(recipients []string) []string {
+	normalized := make([]string, len(recipients))
+	for i, email := range recipients {
+		normalized[i] = sanitizex.CleanEmail(email)
+	}
+	return sanitizex.DeduplicateSliceBy(normalized, recipientKey)
+}
+
+// recipientKey identifies the recipient of an email, see
+// sanitizex.EmailDedupKey.
+func recipientKey(email string) string {
+	if key, err := sanitizex.EmailDedupKey(email); err == nil {
+		return key
+	}
+	return email
 }
 
 func (c *CreateInvitationRequest) SetSpanAttrs(span trace.Span) {
@@ -153,10 +208,16 @@ func (c *CreateInvitationRequest) SetSpanAttrs(span trace.Span) {
 }
 
 func (c *CreateInvitationRequest) Validate() error {
+	return c.validate(clock.Real)
+}
+
+func (c *CreateInvitationRequest) validate(clk clock.Clock) error {
 	return validation.ValidateStruct(c,
 		validation.Field(&c.Recipients, recipientsEmailRules...),
-		validation.Field(&c.ValidFrom, validityRules...),
-		validation.Field(&c.ValidUntil, validityRules...),
+		validation.Field(&c.ValidFrom, validFromRules(clk.Now)...),
+		validation.Field(&c.ValidUntil, validUntilRules(c.ValidFrom, clk.Now)...),
+		validation.Field(&c.Department, departmentRules...),
+		validation.Field(&c.Position, positionRules...),
 	)
 }
 
@@ -179,7 +240,7 @@ func (h *HTTP) CreateInvitation(w http.ResponseWriter, r *http.Request) {
 
 	req.Sanitize()
 	req.SetSpanAttrs(span)
-	err = req.Validate()
+	err = req.validate(h.clock)
 	if err != nil {
 		h.errhandler.HandleError(w, r, span, err, "validation failed")
 		return
@@ -190,6 +251,8 @@ func (h *HTTP) CreateInvitation(w http.ResponseWriter, r *http.Request) {
 		RecipientsEmail: req.Recipients,
 		ValidFrom:       req.ValidFrom,
 		ValidUntil:      req.ValidUntil,
+		Department:      req.Department,
+		Position:        req.Position,
 	})
 	if err != nil {
 		h.errhandler.HandleError(w, r, span, err, "failed to create invitation")
@@ -199,12 +262,161 @@ func (h *HTTP) CreateInvitation(w http.ResponseWriter, r *http.Request) {
 	httpx.Success(w, r, http.StatusCreated, nil)
 }
 
+// maxBulkSpecs caps the specs of a bulk invitation, the recipients are capped
+// by staffinvitation.MaxPendingRecipients.
+const maxBulkSpecs = 50
+
+// BulkCreateInvitationsRequest invites the recipients of several specs, each
+// split into invitations of at most staffinvitation.MaxEmails recipients.
+type BulkCreateInvitationsRequest struct {
+	Invitations []BulkInvitationSpec `json:"invitations"`
+	ValidFrom   *time.Time           `json:"valid_from"`
+	ValidUntil  *time.Time           `json:"valid_until"`
+	// Position is the role shared by the recipients of the specs without
+	// their own.
+	Position string `json:"position"`
+}
+
+type BulkInvitationSpec struct {
+	Recipients []string `json:"recipients_email"`
+	Department string   `json:"department"`
+	Position   string   `json:"position"`
+}
+
+func (s BulkInvitationSpec) Validate() error {
+	return validation.ValidateStruct(&s,
+		validation.Field(&s.Recipients,
+			validation.Required,
+			validation.Count(1, staffinvitation.MaxPendingRecipients),
+			// The format only, unlike recipientsEmailRules, a domain lookup
+			// per recipient does not scale to the bulk.
+			validation.Each(validation.Required, is.EmailFormat),
+		),
+		validation.Field(&s.Department, departmentRules...),
+		validation.Field(&s.Position, positionRules...),
+	)
+}
+
+// Sanitize normalizes the specs and drops the recipients already listed by
+// an earlier spec, a recipient is invited once.
+func (c *BulkCreateInvitationsRequest) Sanitize() {
+	c.Position = sanitizex.CleanSingleLine(c.Position)
+	c.ValidFrom = httpx.NormalizeTimePtr(c.ValidFrom)
+	c.ValidUntil = httpx.NormalizeTimePtr(c.ValidUntil)
+
+	seen := make(map[string]struct{})
+	for i := range c.Invitations {
+		spec := &c.Invitations[i]
+		spec.Department = sanitizex.CleanSingleLine(spec.Department)
+		spec.Position = sanitizex.CleanSingleLine(spec.Position)
+		if spec.Position == "" {
+			spec.Position = c.Position
+		}
+		spec.Recipients = slices.DeleteFunc(sanitizeRecipients(spec.Recipients), func(email string) bool {
+			key := recipientKey(email)
+			if _, ok := seen[key]; ok {
+				return true
+			}
+			seen[key] = struct{}{}
+			return false
+		})
+	}
+}
+
+func (c *BulkCreateInvitationsRequest) SetSpanAttrs(span trace.Span) {
+	var recipients int
+	for _, spec := range c.Invitations {
+		recipients += len(spec.Recipients)
+	}
+	otelx.SetSpanAttrs(span, map[string]any{
+		"request.specs_count":      len(c.Invitations),
+		"request.recipients_count": recipients,
+		"request.valid_from":       c.ValidFrom,
+		"request.valid_until":      c.ValidUntil,
+	})
+}
+
+func (c *BulkCreateInvitationsRequest) Validate() error {
+	return c.validate(clock.Real)
+}
+
+func (c *BulkCreateInvitationsRequest) validate(clk clock.Clock) error {
+	return validation.ValidateStruct(c,
+		validation.Field(&c.Invitations, validation.Required, validation.Length(1, maxBulkSpecs)),
+		validation.Field(&c.ValidFrom, validFromRules(clk.Now)...),
+		validation.Field(&c.ValidUntil, validUntilRules(c.ValidFrom, clk.Now)...),
+		validation.Field(&c.Position, positionRules...),
+	)
+}
+
+type BulkInvitationResponse struct {
+	ID staffinvitation.ID `json:"id"`
+	// Spec is the index of the spec the recipients of the invitation come
+	// from.
+	Spec            int `json:"spec"`
+	RecipientsCount int `json:"recipients_count"`
+}
+
+func (h *HTTP) BulkCreateInvitations(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "HTTP.BulkCreateInvitations")
+	defer span.End()
+
+	ctxUser, err := ctxs.UserFromCtx(ctx)
+	if err != nil {
+		h.errhandler.HandleError(w, r, span, err, "failed to get user from context")
+		return
+	}
+	ctxUser.SetSpanAttrs(span)
+
+	var req BulkCreateInvitationsRequest
+	if err := httpx.ReadJSON(w, r, &req); err != nil {
+		h.errhandler.HandleError(w, r, span, err, "failed to read body")
+		return
+	}
+
+	req.Sanitize()
+	req.SetSpanAttrs(span)
+	err = req.validate(h.clock)
+	if err != nil {
+		h.errhandler.HandleError(w, r, span, err, "validation failed")
+		return
+	}
+
+	specs := make([]cmd.InvitationSpec, len(req.Invitations))
+	for i, spec := range req.Invitations {
+		specs[i] = cmd.InvitationSpec{
+			RecipientsEmail: spec.Recipients,
+			Department:      spec.Department,
+			Position:        spec.Position,
+		}
+	}
+	res, err := h.cmd.BulkCreateInvitations.Handle(ctx, cmd.BulkCreateInvitations{
+		CreatorID:  ctxUser.ID,
+		Specs:      specs,
+		ValidFrom:  req.ValidFrom,
+		ValidUntil: req.ValidUntil,
+	})
+	if err != nil {
+		h.errhandler.HandleError(w, r, span, err, "failed to create invitations")
+		return
+	}
+
+	invitations := make([]BulkInvitationResponse, len(res.Invitations))
+	for i, inv := range res.Invitations {
+		invitations[i] = BulkInvitationResponse{ID: inv.ID, Spec: inv.Spec, RecipientsCount: inv.RecipientsCount}
+	}
+	httpx.Success(w, r, http.StatusCreated, httpx.Envelope{
+		"invitations":      invitations,
+		"recipients_count": res.RecipientsCount,
+	})
+}
+
 type UpdateInvitationRecipientsRequest struct {
 	Recipients []string `json:"recipients_email"`
 }
 
 func (r *UpdateInvitationRecipientsRequest) Sanitize() {
-	r.Recipients = sanitizex.DeduplicateSlice(r.Recipients, sanitizex.StringTransformFunc(sanitizex.CleanSingleLine))
+	r.Recipients = sanitizeRecipients(r.Recipients)
 }
 
 func (r *UpdateInvitationRecipientsRequest) SetSpanAttrs(span trace.Span) {
@@ -267,6 +479,11 @@ type UpdateInvitationValidityRequest struct {
 	ValidUntil *time.Time `json:"valid_until"`
 }
 
+func (r *UpdateInvitationValidityRequest) Sanitize() {
+	r.ValidFrom = httpx.NormalizeTimePtr(r.ValidFrom)
+	r.ValidUntil = httpx.NormalizeTimePtr(r.ValidUntil)
+}
+
 func (r *UpdateInvitationValidityRequest) SetSpanAttrs(span trace.Span) {
 	otelx.SetSpanAttrs(span, map[string]any{
 		"request.valid_from":  r.ValidFrom,
@@ -275,9 +492,13 @@ func (r *UpdateInvitationValidityRequest) SetSpanAttrs(span trace.Span) {
 }
 
 func (r *UpdateInvitationValidityRequest) Validate() error {
+	return r.validate(clock.Real)
+}
+
+func (r *UpdateInvitationValidityRequest) validate(clk clock.Clock) error {
 	return validation.ValidateStruct(r,
-		validation.Field(&r.ValidFrom, validityRules...),
-		validation.Field(&r.ValidUntil, validityRules...),
+		validation.Field(&r.ValidFrom, validFromRules(clk.Now)...),
+		validation.Field(&r.ValidUntil, validUntilRules(r.ValidFrom, clk.Now)...),
 	)
 }
 
@@ -305,8 +526,9 @@ func (h *HTTP) UpdateInvitationValidity(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	req.Sanitize()
 	req.SetSpanAttrs(span)
-	err = req.Validate()
+	err = req.validate(h.clock)
 	if err != nil {
 		h.errhandler.HandleError(w, r, span, err, "validation failed")
 		return
@@ -369,7 +591,7 @@ func (h *HTTP) Validate(w http.ResponseWriter, r *http.Request) {
 	}
 
 	email := r.URL.Query().Get("email")
-	email = sanitizex.CleanSingleLine(email)
+	email = sanitizex.CleanEmail(email)
 	err = validation.Validate(email, validation.Required, is.EmailFormat)
 	if err != nil {
 		h.errhandler.HandleError(w, r, span, err, "invalid email")
@@ -397,7 +619,7 @@ func (h *HTTP) Validate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	http.Redirect(w, r, fmt.Sprintf("%s?token=%s", h.acceptInvitationPageURL, url.QueryEscape(signedToken)), http.StatusFound)
+	http.Redirect(w, r, h.acceptInvitationPageURL.WithQuery("token", signedToken).String(), http.StatusFound)
 }
 
 func SignInvitationJWTToken(
@@ -430,6 +652,12 @@ type AcceptInvitationRequest struct {
 	Password  string `json:"password"`
 	FirstName string `json:"first_name"`
 	LastName  string `json:"last_name"`
+	// AcceptTOS must be true once terms of service are published.
+	AcceptTOS bool `json:"accept_tos"`
+	// LinkExistingAccount accepts the invitation with the student account
+	// of the email, Password is then its current password and the other
+	// fields are kept from the account.
+	LinkExistingAccount bool `json:"link_existing_account"`
 }
 
 func (r *AcceptInvitationRequest) Sanitize() {
@@ -443,17 +671,24 @@ func (r *AcceptInvitationRequest) Sanitize() {
 
 func (r *AcceptInvitationRequest) SetSpanAttrs(span trace.Span) {
 	otelx.SetSpanAttrs(span, map[string]any{
-		"request.token":    r.Token,
-		"request.username": logging.RedactUsername(r.Username),
+		"request.token":                 r.Token,
+		"request.username":              logging.RedactUsername(r.Username),
+		"request.link_existing_account": r.LinkExistingAccount,
 	})
 }
 
 func (r *AcceptInvitationRequest) Validate() error {
+	if r.LinkExistingAccount {
+		return validation.ValidateStruct(r,
+			validation.Field(&r.Token, validation.Required, validation.Length(1, 1000)),
+			validation.Field(&r.Password, validation.Required, validation.Length(1, 1000)),
+		)
+	}
 	return validation.ValidateStruct(r,
 		validation.Field(&r.Token, validation.Required, validation.Length(1, 1000)),
-		validation.Field(&r.Barcode, validation.Required, validation.Length(1, 80), is.Alphanumeric),
+		validation.Field(&r.Barcode, validation.Required, validationx.IsBarcode),
 		validation.Field(&r.Username, validation.Required, validation.Length(2, 100), validationx.IsUsername),
-		validation.Field(&r.Password, validationx.PasswordRules...),
+		validation.Field(&r.Password, user.PasswordRules...),
 		validation.Field(&r.FirstName, validationx.NameRules...),
 		validation.Field(&r.LastName, validationx.NameRules...),
 	)
@@ -477,20 +712,36 @@ func (h *HTTP) AcceptInvitation(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	invitationCode, email, err := ParseInvitationJWTToken(req.Token, h.signingMethod, h.secretKey)
+	invitationCode, tokenEmail, err := ParseInvitationJWTToken(req.Token, h.signingMethod, h.secretKey)
 	if err != nil {
 		h.errhandler.HandleError(w, r, span, err, "invalid or expired token")
 		return
 	}
+	email, err := emails.New(tokenEmail)
+	if err != nil {
+		h.errhandler.HandleError(w, r, span, err, "invalid email in token")
+		return
+	}
+	var barcode user.Barcode
+	if !req.LinkExistingAccount {
+		barcode, err = user.NewBarcode(req.Barcode)
+		if err != nil {
+			h.errhandler.HandleError(w, r, span, err, "validation failed")
+			return
+		}
+	}
 
 	cmd := cmd.AcceptInvitation{
-		InvitationCode: invitationCode,
-		Email:          email,
-		Barcode:        user.Barcode(req.Barcode),
-		Username:       req.Username,
-		Password:       req.Password,
-		FirstName:      req.FirstName,
-		LastName:       req.LastName,
+		InvitationCode:      invitationCode,
+		Email:               email,
+		Barcode:             barcode,
+		Username:            req.Username,
+		Password:            req.Password,
+		FirstName:           req.FirstName,
+		LastName:            req.LastName,
+		AcceptTOS:           req.AcceptTOS,
+		IP:                  ctxs.ClientIPFromCtx(ctx),
+		LinkExistingAccount: req.LinkExistingAccount,
 	}
 	err = h.cmd.AcceptInvitation.Handle(ctx, cmd)
 	if err != nil {
@@ -534,3 +785,93 @@ func ParseInvitationJWTToken(tokenString string, signingMethod jwt.SigningMethod
 
 	return invitationCode, email, nil
 }
+
+func (h *HTTP) GetProfile(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "HTTP.GetProfile")
+	defer span.End()
+
+	ctxUser, err := ctxs.UserFromCtx(ctx)
+	if err != nil {
+		h.errhandler.HandleError(w, r, span, err, "failed to get user from context")
+		return
+	}
+	ctxUser.SetSpanAttrs(span)
+
+	res, err := h.query.GetStaff.Handle(ctx, staffquery.GetStaff{ID: ctxUser.ID})
+	if err != nil {
+		h.errhandler.HandleError(w, r, span, err, "failed to get staff")
+		return
+	}
+
+	httpx.Success(w, r, http.StatusOK, httpx.Envelope{"staff": res})
+}
+
+// UpdateProfileRequest is a JSON Merge Patch, the fields left out are kept
+// and a null or empty one is cleared.
+type UpdateProfileRequest struct {
+	Department httpx.Field[string] `json:"department,omitzero"`
+	Position   httpx.Field[string] `json:"position,omitzero"`
+}
+
+func (r *UpdateProfileRequest) Sanitize() {
+	r.Department.Value = sanitizex.CleanSingleLine(r.Department.Value)
+	r.Position.Value = sanitizex.CleanSingleLine(r.Position.Value)
+}
+
+func (r *UpdateProfileRequest) Validate() error {
+	return validation.Errors{
+		"department": validation.Validate(r.Department.Value, departmentRules...),
+		"position":   validation.Validate(r.Position.Value, positionRules...),
+	}.Filter()
+}
+
+// Warnings flags a department or position written in capitals, run it on a
+// valid request.
+func (r *UpdateProfileRequest) Warnings() validationx.Warnings {
+	var warnings validationx.Warnings
+	if r.Department.Set {
+		warnings.Check("department", &r.Department.Value, validationx.AllCaps)
+	}
+	if r.Position.Set {
+		warnings.Check("position", &r.Position.Value, validationx.AllCaps)
+	}
+	return warnings
+}
+
+func (h *HTTP) UpdateProfile(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "HTTP.UpdateProfile")
+	defer span.End()
+
+	ctxUser, err := ctxs.UserFromCtx(ctx)
+	if err != nil {
+		h.errhandler.HandleError(w, r, span, err, "failed to get user from context")
+		return
+	}
+	ctxUser.SetSpanAttrs(span)
+
+	patch, err := httpx.ReadPatch[UpdateProfileRequest](w, r)
+	if err != nil {
+		h.errhandler.HandleError(w, r, span, err, "failed to read body")
+		return
+	}
+
+	req := &patch.Changes
+	req.Sanitize()
+	if err := req.Validate(); err != nil {
+		h.errhandler.HandleError(w, r, span, err, "validation failed")
+		return
+	}
+	warnings := req.Warnings()
+
+	err = h.cmd.UpdateProfile.Handle(ctx, cmd.UpdateProfile{
+		StaffID:    ctxUser.ID,
+		Department: req.Department.Optional(),
+		Position:   req.Position.Optional(),
+	})
+	if err != nil {
+		h.errhandler.HandleError(w, r, span, err, "failed to update profile")
+		return
+	}
+
+	httpx.SuccessWithWarnings(w, r, http.StatusOK, nil, warnings)
+}
@@ -0,0 +1,224 @@
+package statushttp
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/ARUMANDESU/validation"
+	"github.com/ARUMANDESU/validation/is"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/contrib/bridges/otelslog"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	statusapp "gitlab.com/ucmsv2/ucms-backend/internal/application/status"
+	"gitlab.com/ucmsv2/ucms-backend/internal/application/status/cmd"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/incident"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/ctxs"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/httpx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/sanitizex"
+)
+
+var (
+	tracer = otel.Tracer("ucms/internal/ports/http/status")
+	logger = otelslog.NewLogger("ucms/internal/ports/http/status")
+)
+
+// statusCacheControl lets the browsers and the proxies in front serve the
+// status page, it is polled by anyone while something is down.
+const statusCacheControl = "public, max-age=30"
+
+// HTTP serves the public status page and the incidents the staff report on
+// it.
+type HTTP struct {
+	tracer     trace.Tracer
+	logger     *slog.Logger
+	app        *statusapp.App
+	errhandler *httpx.ErrorHandler
+}
+
+type Args struct {
+	Tracer     trace.Tracer
+	Logger     *slog.Logger
+	App        *statusapp.App
+	Errhandler *httpx.ErrorHandler
+}
+
+func NewHTTP(args Args) *HTTP {
+	if args.Tracer == nil {
+		args.Tracer = tracer
+	}
+	if args.Logger == nil {
+		args.Logger = logger
+	}
+
+	return &HTTP{
+		tracer:     args.Tracer,
+		logger:     args.Logger,
+		app:        args.App,
+		errhandler: args.Errhandler,
+	}
+}
+
+func (h *HTTP) Route(r chi.Router) {
+	r.Get("/v1/status", h.GetStatus)
+
+	// The staff port mounts /v1/staffs, chi matches these static paths
+	// before the mount.
+	r.Post("/v1/staffs/incidents", h.CreateIncident)
+	r.Put("/v1/staffs/incidents/{id}", h.UpdateIncident)
+}
+
+func (h *HTTP) GetStatus(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "HTTP.GetStatus")
+	defer span.End()
+
+	res, err := h.app.Query.Status.Get(ctx)
+	if err != nil {
+		h.errhandler.HandleError(w, r, span, err, "failed to get status")
+		return
+	}
+
+	w.Header().Set("Cache-Control", statusCacheControl)
+	httpx.Success(w, r, http.StatusOK, httpx.Envelope{"status": res})
+}
+
+type IncidentRequest struct {
+	Title    string            `json:"title"`
+	Severity incident.Severity `json:"severity"`
+	// StartedAt defaults to now on create and to the current start on
+	// update.
+	StartedAt *time.Time `json:"started_at"`
+	// ResolvedAt resolves the incident, on update a null reopens it.
+	ResolvedAt *time.Time `json:"resolved_at"`
+	// Note is an update posted on the incident, optional.
+	Note string `json:"note"`
+}
+
+func (r *IncidentRequest) Sanitize() {
+	r.Title = sanitizex.TruncateRunes(sanitizex.CleanSingleLine(sanitizex.StripHTML(r.Title)), incident.MaxTitleLen)
+	r.Note = sanitizex.CleanFreeText(r.Note, incident.MaxNoteLen)
+	r.StartedAt = httpx.NormalizeTimePtr(r.StartedAt)
+	r.ResolvedAt = httpx.NormalizeTimePtr(r.ResolvedAt)
+}
+
+func (r *IncidentRequest) SetSpanAttrs(span trace.Span) {
+	otelx.SetSpanAttrs(span, map[string]any{
+		"request.severity": string(r.Severity),
+		"request.resolved": r.ResolvedAt != nil,
+	})
+}
+
+func (r *IncidentRequest) Validate() error {
+	return validation.ValidateStruct(r,
+		validation.Field(&r.Title, validation.Required, validation.RuneLength(1, incident.MaxTitleLen)),
+		validation.Field(&r.Severity, validation.Required, validation.In(incident.Severities...)),
+		validation.Field(&r.Note, validation.RuneLength(0, incident.MaxNoteLen)),
+	)
+}
+
+func (r *IncidentRequest) startedAt() time.Time {
+	if r.StartedAt == nil {
+		return time.Time{}
+	}
+	return *r.StartedAt
+}
+
+func (h *HTTP) readIncident(w http.ResponseWriter, r *http.Request, span trace.Span) (*IncidentRequest, bool) {
+	var req IncidentRequest
+	if err := httpx.ReadJSON(w, r, &req); err != nil {
+		h.errhandler.HandleError(w, r, span, err, "failed to read body")
+		return nil, false
+	}
+
+	req.Sanitize()
+	req.SetSpanAttrs(span)
+	if err := req.Validate(); err != nil {
+		h.errhandler.HandleError(w, r, span, err, "validation failed")
+		return nil, false
+	}
+	return &req, true
+}
+
+// CreateIncident reports an incident, it answers with its id.
+func (h *HTTP) CreateIncident(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "HTTP.CreateIncident")
+	defer span.End()
+
+	ctxUser, err := ctxs.UserFromCtx(ctx)
+	if err != nil {
+		h.errhandler.HandleError(w, r, span, err, "failed to get user from context")
+		return
+	}
+	ctxUser.SetSpanAttrs(span)
+
+	req, ok := h.readIncident(w, r, span)
+	if !ok {
+		return
+	}
+
+	id := incident.NewID()
+	err = h.app.Command.CreateIncident.Handle(ctx, cmd.CreateIncident{
+		ID:         id,
+		StaffID:    ctxUser.ID,
+		Title:      req.Title,
+		Severity:   req.Severity,
+		StartedAt:  req.startedAt(),
+		ResolvedAt: req.ResolvedAt,
+		Note:       req.Note,
+	})
+	if err != nil {
+		h.errhandler.HandleError(w, r, span, err, "failed to create incident")
+		return
+	}
+
+	httpx.Success(w, r, http.StatusCreated, httpx.Envelope{"incident": httpx.Envelope{"id": id}})
+}
+
+// UpdateIncident replaces the fields of an incident and posts its note.
+func (h *HTTP) UpdateIncident(w http.ResponseWriter, r *http.Request) {
+	const op = "statushttp.HTTP.UpdateIncident"
+	ctx, span := h.tracer.Start(r.Context(), "HTTP.UpdateIncident")
+	defer span.End()
+
+	ctxUser, err := ctxs.UserFromCtx(ctx)
+	if err != nil {
+		h.errhandler.HandleError(w, r, span, err, "failed to get user from context")
+		return
+	}
+	ctxUser.SetSpanAttrs(span)
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		err = errorx.Wrap(validation.Errors{"id": is.ErrUUID}, op)
+		h.errhandler.HandleError(w, r, span, err, "invalid incident id")
+		return
+	}
+	span.SetAttributes(attribute.String("request.incident_id", id.String()))
+
+	req, ok := h.readIncident(w, r, span)
+	if !ok {
+		return
+	}
+
+	err = h.app.Command.UpdateIncident.Handle(ctx, cmd.UpdateIncident{
+		StaffID:    ctxUser.ID,
+		ID:         incident.ID(id),
+		Title:      req.Title,
+		Severity:   req.Severity,
+		StartedAt:  req.startedAt(),
+		ResolvedAt: req.ResolvedAt,
+		Note:       req.Note,
+	})
+	if err != nil {
+		h.errhandler.HandleError(w, r, span, err, "failed to update incident")
+		return
+	}
+
+	httpx.Success(w, r, http.StatusOK, nil)
+}
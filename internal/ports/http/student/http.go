@@ -4,18 +4,31 @@ import (
 	"errors"
 	"log/slog"
 	"net/http"
+	"time"
 
+	"github.com/ARUMANDESU/validation"
+	"github.com/ARUMANDESU/validation/is"
 	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
 	"go.opentelemetry.io/contrib/bridges/otelslog"
 	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
 
 	studentapp "gitlab.com/ucmsv2/ucms-backend/internal/application/student"
+	studentcmd "gitlab.com/ucmsv2/ucms-backend/internal/application/student/cmd"
 	"gitlab.com/ucmsv2/ucms-backend/internal/application/student/studentquery"
-	"gitlab.com/ucmsv2/ucms-backend/internal/ports/http/middlewares"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/group"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/term"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/majors"
 	"gitlab.com/ucmsv2/ucms-backend/pkg/ctxs"
 	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
 	"gitlab.com/ucmsv2/ucms-backend/pkg/httpx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/optional"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/sanitizex"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/validationx"
 )
 
 var (
@@ -27,7 +40,6 @@ type HTTP struct {
 	tracer     trace.Tracer
 	logger     *slog.Logger
 	app        *studentapp.App
-	middleware *middlewares.Middleware
 	errhandler *httpx.ErrorHandler
 }
 
@@ -35,7 +47,6 @@ type Args struct {
 	Tracer     trace.Tracer
 	Logger     *slog.Logger
 	App        *studentapp.App
-	Middleware *middlewares.Middleware
 	Errhandler *httpx.ErrorHandler
 }
 
@@ -51,15 +62,23 @@ func NewHTTP(args Args) *HTTP {
 		tracer:     args.Tracer,
 		logger:     args.Logger,
 		app:        args.App,
-		middleware: args.Middleware,
 		errhandler: args.Errhandler,
 	}
 }
 
 func (h *HTTP) Route(r chi.Router) {
 	r.Route("/v1/students", func(r chi.Router) {
-		r.With(h.middleware.Auth).Get("/me", h.GetStudent)
+		r.Get("/me", h.GetStudent)
 	})
+
+	// The staff port mounts /v1/staffs, chi matches this static path before
+	// the mount.
+	r.Put("/v1/staffs/students/{barcode}/status", h.ChangeEnrollmentStatus)
+	r.Put("/v1/staffs/students/{barcode}/group", h.TransferGroup)
+	r.Post("/v1/staffs/groups", h.CreateGroup)
+	r.Get("/v1/staffs/groups/{id}", h.GetGroup)
+	r.Patch("/v1/staffs/groups/{id}", h.UpdateGroup)
+	r.Get("/v1/groups", h.ListGroups)
 }
 
 type GetStudentResponse struct {
@@ -71,6 +90,11 @@ type GetStudentResponse struct {
 	Role         string    `json:"role"`
 	Group        GroupInfo `json:"group"`
 	RegisteredAt string    `json:"registered_at"`
+	// EnrollmentStatus flags the students on leave and the graduates.
+	EnrollmentStatus string  `json:"enrollment_status"`
+	LeaveUntil       *string `json:"leave_until,omitempty"`
+	// UnreadNotifications is the number on the bell of the client.
+	UnreadNotifications int `json:"unread_notifications"`
 }
 
 type GroupInfo struct {
@@ -116,8 +140,355 @@ func (h *HTTP) GetStudent(w http.ResponseWriter, r *http.Request) {
 			Name:  res.Group.Name,
 			Year:  res.Group.Year,
 		},
-		RegisteredAt: res.RegisteredAt.Format("2006-01-02T15:04:05Z07:00"),
+		RegisteredAt:        httpx.FormatTime(res.RegisteredAt),
+		EnrollmentStatus:    res.EnrollmentStatus,
+		UnreadNotifications: res.UnreadNotifications,
+	}
+	if res.LeaveUntil != nil {
+		leaveUntil := httpx.FormatTime(*res.LeaveUntil)
+		httpRes.LeaveUntil = &leaveUntil
 	}
 
 	httpx.Success(w, r, http.StatusOK, httpx.Envelope{"student": httpRes})
 }
+
+type ChangeEnrollmentStatusRequest struct {
+	Status     string     `json:"status"`
+	LeaveUntil *time.Time `json:"leave_until"`
+	Reason     string     `json:"reason"`
+}
+
+func (r *ChangeEnrollmentStatusRequest) Sanitize() {
+	r.LeaveUntil = httpx.NormalizeTimePtr(r.LeaveUntil)
+}
+
+func (r *ChangeEnrollmentStatusRequest) SetSpanAttrs(span trace.Span) {
+	otelx.SetSpanAttrs(span, map[string]any{
+		"request.status":      r.Status,
+		"request.leave_until": r.LeaveUntil,
+	})
+}
+
+func (r *ChangeEnrollmentStatusRequest) Validate() error {
+	return validation.ValidateStruct(r,
+		validation.Field(&r.Status, validation.Required, validation.In(
+			user.Enrolled.String(), user.AcademicLeave.String(), user.Graduated.String(), user.Expelled.String(),
+		)),
+		validation.Field(&r.LeaveUntil, validation.When(r.Status == user.AcademicLeave.String(), validation.Required)),
+		validation.Field(&r.Reason, validation.When(r.Status == user.Expelled.String(), validation.Required)),
+	)
+}
+
+func (h *HTTP) ChangeEnrollmentStatus(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "ChangeEnrollmentStatus")
+	defer span.End()
+
+	ctxUser, err := ctxs.UserFromCtx(ctx)
+	if err != nil {
+		h.errhandler.HandleError(w, r, span, err, "failed to get user from context")
+		return
+	}
+	ctxUser.SetSpanAttrs(span)
+
+	barcode, err := user.NewBarcode(chi.URLParam(r, "barcode"))
+	if err != nil {
+		h.errhandler.HandleError(w, r, span, err, "invalid barcode")
+		return
+	}
+	span.SetAttributes(attribute.String("request.barcode", barcode.String()))
+
+	var req ChangeEnrollmentStatusRequest
+	if err := httpx.ReadJSON(w, r, &req); err != nil {
+		h.errhandler.HandleError(w, r, span, err, "failed to read body")
+		return
+	}
+
+	req.Sanitize()
+	req.SetSpanAttrs(span)
+	if err := req.Validate(); err != nil {
+		h.errhandler.HandleError(w, r, span, err, "validation failed")
+		return
+	}
+
+	err = h.app.Command.ChangeEnrollmentStatus.Handle(ctx, studentcmd.ChangeEnrollmentStatus{
+		StaffID:    ctxUser.ID,
+		Barcode:    barcode,
+		Status:     user.EnrollmentStatus(req.Status),
+		LeaveUntil: req.LeaveUntil,
+		Reason:     req.Reason,
+	})
+	if err != nil {
+		h.errhandler.HandleError(w, r, span, err, "failed to change enrollment status")
+		return
+	}
+
+	httpx.Success(w, r, http.StatusOK, nil)
+}
+
+type TransferGroupRequest struct {
+	GroupID uuid.UUID `json:"group_id"`
+}
+
+func (r *TransferGroupRequest) SetSpanAttrs(span trace.Span) {
+	span.SetAttributes(attribute.String("request.group_id", r.GroupID.String()))
+}
+
+func (r *TransferGroupRequest) Validate() error {
+	return validation.ValidateStruct(r,
+		validation.Field(&r.GroupID, validationx.Required),
+	)
+}
+
+func (h *HTTP) TransferGroup(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "TransferGroup")
+	defer span.End()
+
+	ctxUser, err := ctxs.UserFromCtx(ctx)
+	if err != nil {
+		h.errhandler.HandleError(w, r, span, err, "failed to get user from context")
+		return
+	}
+	ctxUser.SetSpanAttrs(span)
+
+	barcode, err := user.NewBarcode(chi.URLParam(r, "barcode"))
+	if err != nil {
+		h.errhandler.HandleError(w, r, span, err, "invalid barcode")
+		return
+	}
+	span.SetAttributes(attribute.String("request.barcode", barcode.String()))
+
+	var req TransferGroupRequest
+	if err := httpx.ReadJSON(w, r, &req); err != nil {
+		h.errhandler.HandleError(w, r, span, err, "failed to read body")
+		return
+	}
+
+	req.SetSpanAttrs(span)
+	if err := req.Validate(); err != nil {
+		h.errhandler.HandleError(w, r, span, err, "validation failed")
+		return
+	}
+
+	err = h.app.Command.TransferGroup.Handle(ctx, studentcmd.TransferGroup{
+		StaffID: ctxUser.ID,
+		Barcode: barcode,
+		GroupID: group.ID(req.GroupID),
+	})
+	if err != nil {
+		h.errhandler.HandleError(w, r, span, err, "failed to transfer student to group")
+		return
+	}
+
+	httpx.Success(w, r, http.StatusOK, nil)
+}
+
+// GetGroup gets a group with its member count, the count follows the
+// transfers and registrations within the eventual consistency of their
+// events.
+func (h *HTTP) GetGroup(w http.ResponseWriter, r *http.Request) {
+	const op = "studenthttp.HTTP.GetGroup"
+	ctx, span := h.tracer.Start(r.Context(), "GetGroup")
+	defer span.End()
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		err = errorx.Wrap(validation.Errors{"id": is.ErrUUID}, op)
+		h.errhandler.HandleError(w, r, span, err, "invalid group id")
+		return
+	}
+	span.SetAttributes(attribute.String("request.group_id", id.String()))
+
+	res, err := h.app.Query.GetGroup.Handle(ctx, studentquery.GetGroup{ID: group.ID(id)})
+	if err != nil {
+		h.errhandler.HandleError(w, r, span, err, "failed to get group")
+		return
+	}
+
+	httpx.Success(w, r, http.StatusOK, httpx.Envelope{"group": res})
+}
+
+// ListGroups lists the groups with whether they can be joined now, it is
+// public for the registration form.
+func (h *HTTP) ListGroups(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "ListGroups")
+	defer span.End()
+
+	res, err := h.app.Query.ListGroups.Handle(ctx, studentquery.ListGroups{})
+	if err != nil {
+		h.errhandler.HandleError(w, r, span, err, "failed to list groups")
+		return
+	}
+
+	httpx.Success(w, r, http.StatusOK, httpx.Envelope{"groups": res.Groups})
+}
+
+type CreateGroupRequest struct {
+	Name  string `json:"name"`
+	Year  string `json:"year"`
+	Major string `json:"major"`
+	// TermID is the term the group studies in, optional.
+	TermID *term.ID `json:"term_id"`
+}
+
+func (r *CreateGroupRequest) Sanitize() {
+	r.Name = sanitizex.CleanSingleLine(r.Name)
+	r.Year = sanitizex.CleanSingleLine(r.Year)
+	r.Major = sanitizex.CleanSingleLine(r.Major)
+}
+
+// Validate mirrors the checks of the group domain, see UpdateGroupRequest.
+func (r *CreateGroupRequest) Validate() error {
+	return validation.ValidateStruct(r,
+		validation.Field(&r.Name, validation.Required, validation.RuneLength(group.MinNameLength, group.MaxNameLength)),
+		validation.Field(&r.Year, validation.Required, validation.Match(group.YearPattern)),
+		validation.Field(&r.Major, validation.Required, validation.By(func(any) error {
+			if !majors.IsValid(r.Major) {
+				return majors.ErrInvalidMajor
+			}
+			return nil
+		})),
+	)
+}
+
+// CreateGroup adds a group, it answers with its id. An unknown term is a not
+// found error.
+func (h *HTTP) CreateGroup(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "CreateGroup")
+	defer span.End()
+
+	ctxUser, err := ctxs.UserFromCtx(ctx)
+	if err != nil {
+		h.errhandler.HandleError(w, r, span, err, "failed to get user from context")
+		return
+	}
+	ctxUser.SetSpanAttrs(span)
+
+	var req CreateGroupRequest
+	if err := httpx.ReadJSON(w, r, &req); err != nil {
+		h.errhandler.HandleError(w, r, span, err, "failed to read body")
+		return
+	}
+	req.Sanitize()
+	if err := req.Validate(); err != nil {
+		h.errhandler.HandleError(w, r, span, err, "validation failed")
+		return
+	}
+
+	id := group.NewID()
+	err = h.app.Command.CreateGroup.Handle(ctx, studentcmd.CreateGroup{
+		ID:      id,
+		StaffID: ctxUser.ID,
+		Name:    req.Name,
+		Year:    req.Year,
+		Major:   majors.Major(req.Major),
+		TermID:  req.TermID,
+	})
+	if err != nil {
+		h.errhandler.HandleError(w, r, span, err, "failed to create group")
+		return
+	}
+
+	httpx.Success(w, r, http.StatusCreated, httpx.Envelope{"group": httpx.Envelope{"id": id}})
+}
+
+// UpdateGroupRequest is a JSON Merge Patch of the group, the fields left out
+// are kept. The name, the year and the major are required, a null one fails
+// validation. A null end of the enrollment window leaves that side open, a
+// null term leaves the group without one.
+type UpdateGroupRequest struct {
+	Name               httpx.Field[string]     `json:"name,omitzero"`
+	Year               httpx.Field[string]     `json:"year,omitzero"`
+	Major              httpx.Field[string]     `json:"major,omitzero"`
+	EnrollmentOpensAt  httpx.Field[*time.Time] `json:"enrollment_opens_at,omitzero"`
+	EnrollmentClosesAt httpx.Field[*time.Time] `json:"enrollment_closes_at,omitzero"`
+	TermID             httpx.Field[*term.ID]   `json:"term_id,omitzero"`
+}
+
+func (r *UpdateGroupRequest) Sanitize() {
+	r.Name.Value = sanitizex.CleanSingleLine(r.Name.Value)
+	r.Year.Value = sanitizex.CleanSingleLine(r.Year.Value)
+	r.Major.Value = sanitizex.CleanSingleLine(r.Major.Value)
+}
+
+// Validate mirrors the checks of the group domain, which stays the
+// authority, so that the errors name the fields. The enrollment window is
+// only checked against the clock by the domain, which knows the ends that
+// change.
+func (r *UpdateGroupRequest) Validate() error {
+	return validation.Errors{
+		"name": validation.Validate(r.Name.Value, validation.When(r.Name.Set,
+			validation.Required, validation.RuneLength(group.MinNameLength, group.MaxNameLength),
+		)),
+		"year": validation.Validate(r.Year.Value, validation.When(r.Year.Set,
+			validation.Required, validation.Match(group.YearPattern),
+		)),
+		"major": validation.Validate(r.Major.Value, validation.When(r.Major.Set,
+			validation.Required, validation.By(func(any) error {
+				if !majors.IsValid(r.Major.Value) {
+					return majors.ErrInvalidMajor
+				}
+				return nil
+			}),
+		)),
+		"enrollment_closes_at": validation.Validate(r.EnrollmentClosesAt.Value, validation.When(r.EnrollmentClosesAt.Set,
+			validation.NilOrNotEmpty,
+			validationx.TimeWindowRule{From: r.EnrollmentOpensAt.Value, MinDuration: group.MinEnrollmentWindow},
+		)),
+	}.Filter()
+}
+
+// UpdateGroup changes the group, a patch that changes nothing succeeds
+// without touching it.
+func (h *HTTP) UpdateGroup(w http.ResponseWriter, r *http.Request) {
+	const op = "studenthttp.HTTP.UpdateGroup"
+	ctx, span := h.tracer.Start(r.Context(), "UpdateGroup")
+	defer span.End()
+
+	ctxUser, err := ctxs.UserFromCtx(ctx)
+	if err != nil {
+		h.errhandler.HandleError(w, r, span, err, "failed to get user from context")
+		return
+	}
+	ctxUser.SetSpanAttrs(span)
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		err = errorx.Wrap(validation.Errors{"id": is.ErrUUID}, op)
+		h.errhandler.HandleError(w, r, span, err, "invalid group id")
+		return
+	}
+	span.SetAttributes(attribute.String("request.group_id", id.String()))
+
+	patch, err := httpx.ReadPatch[UpdateGroupRequest](w, r)
+	if err != nil {
+		h.errhandler.HandleError(w, r, span, err, "failed to read body")
+		return
+	}
+	span.SetAttributes(attribute.StringSlice("request.keys", patch.Keys))
+
+	req := &patch.Changes
+	req.Sanitize()
+	if err := req.Validate(); err != nil {
+		h.errhandler.HandleError(w, r, span, err, "validation failed")
+		return
+	}
+
+	err = h.app.Command.UpdateGroup.Handle(ctx, studentcmd.UpdateGroup{
+		StaffID: ctxUser.ID,
+		GroupID: group.ID(id),
+		Name:    req.Name.Optional(),
+		Year:    req.Year.Optional(),
+		Major: optional.Map(req.Major.Optional(), func(major string) majors.Major {
+			return majors.Major(major)
+		}),
+		EnrollmentOpensAt:  req.EnrollmentOpensAt.Optional(),
+		EnrollmentClosesAt: req.EnrollmentClosesAt.Optional(),
+		TermID:             req.TermID.Optional(),
+	})
+	if err != nil {
+		h.errhandler.HandleError(w, r, span, err, "failed to update group")
+		return
+	}
+
+	httpx.Success(w, r, http.StatusOK, nil)
+}
@@ -0,0 +1,296 @@
+package termhttp
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/ARUMANDESU/validation"
+	"github.com/ARUMANDESU/validation/is"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/contrib/bridges/otelslog"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	termapp "gitlab.com/ucmsv2/ucms-backend/internal/application/term"
+	"gitlab.com/ucmsv2/ucms-backend/internal/application/term/cmd"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/term"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/ctxs"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/httpx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/sanitizex"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/validationx"
+)
+
+var (
+	tracer = otel.Tracer("ucms/internal/ports/http/term")
+	logger = otelslog.NewLogger("ucms/internal/ports/http/term")
+)
+
+// HTTP serves the academic terms to the staff who manage them.
+type HTTP struct {
+	tracer     trace.Tracer
+	logger     *slog.Logger
+	app        *termapp.App
+	errhandler *httpx.ErrorHandler
+}
+
+type Args struct {
+	Tracer     trace.Tracer
+	Logger     *slog.Logger
+	App        *termapp.App
+	Errhandler *httpx.ErrorHandler
+}
+
+func NewHTTP(args Args) *HTTP {
+	if args.Tracer == nil {
+		args.Tracer = tracer
+	}
+	if args.Logger == nil {
+		args.Logger = logger
+	}
+
+	return &HTTP{
+		tracer:     args.Tracer,
+		logger:     args.Logger,
+		app:        args.App,
+		errhandler: args.Errhandler,
+	}
+}
+
+func (h *HTTP) Route(r chi.Router) {
+	// The staff port mounts /v1/staffs, chi matches these static paths
+	// before the mount.
+	r.Get("/v1/staffs/terms", h.ListTerms)
+	r.Post("/v1/staffs/terms", h.CreateTerm)
+	r.Get("/v1/staffs/terms/current", h.GetCurrentTerm)
+	r.Get("/v1/staffs/terms/{id}", h.GetTerm)
+	r.Patch("/v1/staffs/terms/{id}", h.UpdateTerm)
+	r.Delete("/v1/staffs/terms/{id}", h.DeleteTerm)
+}
+
+func (h *HTTP) ListTerms(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "HTTP.ListTerms")
+	defer span.End()
+
+	res, err := h.app.Query.Term.List(ctx)
+	if err != nil {
+		h.errhandler.HandleError(w, r, span, err, "failed to list terms")
+		return
+	}
+
+	httpx.Success(w, r, http.StatusOK, httpx.Envelope{"terms": res})
+}
+
+// GetCurrentTerm returns the term now is in, not found between the terms.
+func (h *HTTP) GetCurrentTerm(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "HTTP.GetCurrentTerm")
+	defer span.End()
+
+	res, err := h.app.Query.Term.Current(ctx)
+	if err != nil {
+		h.errhandler.HandleError(w, r, span, err, "failed to get current term")
+		return
+	}
+
+	httpx.Success(w, r, http.StatusOK, httpx.Envelope{"term": res})
+}
+
+func (h *HTTP) GetTerm(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "HTTP.GetTerm")
+	defer span.End()
+
+	id, ok := h.termID(w, r, span)
+	if !ok {
+		return
+	}
+
+	res, err := h.app.Query.Term.Get(ctx, id)
+	if err != nil {
+		h.errhandler.HandleError(w, r, span, err, "failed to get term")
+		return
+	}
+
+	httpx.Success(w, r, http.StatusOK, httpx.Envelope{"term": res})
+}
+
+type CreateTermRequest struct {
+	Code     string    `json:"code"`
+	Name     string    `json:"name"`
+	StartsAt time.Time `json:"starts_at"`
+	EndsAt   time.Time `json:"ends_at"`
+}
+
+func (r *CreateTermRequest) Sanitize() {
+	r.Code = sanitizex.CleanSingleLine(r.Code)
+	r.Name = sanitizex.TruncateRunes(sanitizex.CleanSingleLine(sanitizex.StripHTML(r.Name)), term.MaxNameLen)
+	r.StartsAt = httpx.NormalizeTime(r.StartsAt)
+	r.EndsAt = httpx.NormalizeTime(r.EndsAt)
+}
+
+// Validate mirrors the checks of the term domain, which stays the authority
+// and checks the overlaps.
+func (r *CreateTermRequest) Validate() error {
+	return validation.ValidateStruct(r,
+		validation.Field(&r.Code, validation.Required, validation.RuneLength(1, term.MaxCodeLen), validation.Match(term.CodePattern)),
+		validation.Field(&r.Name, validation.Required, validation.RuneLength(1, term.MaxNameLen)),
+		validation.Field(&r.StartsAt, validation.Required),
+		validation.Field(&r.EndsAt, validation.Required,
+			validationx.TimeWindowRule{From: &r.StartsAt, MinDuration: term.MinDuration}),
+	)
+}
+
+// CreateTerm adds a term, it answers with its id. A term overlapping another
+// one is a 422 naming that term.
+func (h *HTTP) CreateTerm(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "HTTP.CreateTerm")
+	defer span.End()
+
+	ctxUser, err := ctxs.UserFromCtx(ctx)
+	if err != nil {
+		h.errhandler.HandleError(w, r, span, err, "failed to get user from context")
+		return
+	}
+	ctxUser.SetSpanAttrs(span)
+
+	var req CreateTermRequest
+	if err := httpx.ReadJSON(w, r, &req); err != nil {
+		h.errhandler.HandleError(w, r, span, err, "failed to read body")
+		return
+	}
+	req.Sanitize()
+	if err := req.Validate(); err != nil {
+		h.errhandler.HandleError(w, r, span, err, "validation failed")
+		return
+	}
+
+	id := term.NewID()
+	err = h.app.Command.CreateTerm.Handle(ctx, cmd.CreateTerm{
+		ID:       id,
+		StaffID:  ctxUser.ID,
+		Code:     req.Code,
+		Name:     req.Name,
+		StartsAt: req.StartsAt,
+		EndsAt:   req.EndsAt,
+	})
+	if err != nil {
+		h.errhandler.HandleError(w, r, span, err, "failed to create term")
+		return
+	}
+
+	httpx.Success(w, r, http.StatusCreated, httpx.Envelope{"term": httpx.Envelope{"id": id}})
+}
+
+// UpdateTermRequest is a JSON Merge Patch of the term, the fields left out
+// are kept. The fields are required, a null one fails validation.
+type UpdateTermRequest struct {
+	Code     httpx.Field[string]    `json:"code,omitzero"`
+	Name     httpx.Field[string]    `json:"name,omitzero"`
+	StartsAt httpx.Field[time.Time] `json:"starts_at,omitzero"`
+	EndsAt   httpx.Field[time.Time] `json:"ends_at,omitzero"`
+}
+
+func (r *UpdateTermRequest) Sanitize() {
+	r.Code.Value = sanitizex.CleanSingleLine(r.Code.Value)
+	r.Name.Value = sanitizex.TruncateRunes(sanitizex.CleanSingleLine(sanitizex.StripHTML(r.Name.Value)), term.MaxNameLen)
+	r.StartsAt.Value = httpx.NormalizeTime(r.StartsAt.Value)
+	r.EndsAt.Value = httpx.NormalizeTime(r.EndsAt.Value)
+}
+
+// Validate checks the fields set, the window is checked by the domain which
+// knows the ends that are kept.
+func (r *UpdateTermRequest) Validate() error {
+	return validation.Errors{
+		"code": validation.Validate(r.Code.Value, validation.When(r.Code.Set,
+			validation.Required, validation.RuneLength(1, term.MaxCodeLen), validation.Match(term.CodePattern),
+		)),
+		"name": validation.Validate(r.Name.Value, validation.When(r.Name.Set,
+			validation.Required, validation.RuneLength(1, term.MaxNameLen),
+		)),
+		"starts_at": validation.Validate(r.StartsAt.Value, validation.When(r.StartsAt.Set, validation.Required)),
+		"ends_at":   validation.Validate(r.EndsAt.Value, validation.When(r.EndsAt.Set, validation.Required)),
+	}.Filter()
+}
+
+func (h *HTTP) UpdateTerm(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "HTTP.UpdateTerm")
+	defer span.End()
+
+	ctxUser, err := ctxs.UserFromCtx(ctx)
+	if err != nil {
+		h.errhandler.HandleError(w, r, span, err, "failed to get user from context")
+		return
+	}
+	ctxUser.SetSpanAttrs(span)
+
+	id, ok := h.termID(w, r, span)
+	if !ok {
+		return
+	}
+
+	var req UpdateTermRequest
+	if err := httpx.ReadJSON(w, r, &req); err != nil {
+		h.errhandler.HandleError(w, r, span, err, "failed to read body")
+		return
+	}
+	req.Sanitize()
+	if err := req.Validate(); err != nil {
+		h.errhandler.HandleError(w, r, span, err, "validation failed")
+		return
+	}
+
+	err = h.app.Command.UpdateTerm.Handle(ctx, cmd.UpdateTerm{
+		StaffID:  ctxUser.ID,
+		ID:       id,
+		Code:     req.Code.Optional(),
+		Name:     req.Name.Optional(),
+		StartsAt: req.StartsAt.Optional(),
+		EndsAt:   req.EndsAt.Optional(),
+	})
+	if err != nil {
+		h.errhandler.HandleError(w, r, span, err, "failed to update term")
+		return
+	}
+
+	httpx.Success(w, r, http.StatusOK, nil)
+}
+
+// DeleteTerm deletes a term, a term groups study in is a conflict.
+func (h *HTTP) DeleteTerm(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "HTTP.DeleteTerm")
+	defer span.End()
+
+	ctxUser, err := ctxs.UserFromCtx(ctx)
+	if err != nil {
+		h.errhandler.HandleError(w, r, span, err, "failed to get user from context")
+		return
+	}
+	ctxUser.SetSpanAttrs(span)
+
+	id, ok := h.termID(w, r, span)
+	if !ok {
+		return
+	}
+
+	err = h.app.Command.DeleteTerm.Handle(ctx, cmd.DeleteTerm{StaffID: ctxUser.ID, ID: id})
+	if err != nil {
+		h.errhandler.HandleError(w, r, span, err, "failed to delete term")
+		return
+	}
+
+	httpx.Success(w, r, http.StatusOK, nil)
+}
+
+func (h *HTTP) termID(w http.ResponseWriter, r *http.Request, span trace.Span) (term.ID, bool) {
+	const op = "termhttp.HTTP.termID"
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		err = errorx.Wrap(validation.Errors{"id": is.ErrUUID}, op)
+		h.errhandler.HandleError(w, r, span, err, "invalid term id")
+		return term.ID{}, false
+	}
+	span.SetAttributes(attribute.String("request.term_id", id.String()))
+	return term.ID(id), true
+}
@@ -0,0 +1,187 @@
+package toshttp
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/ARUMANDESU/validation"
+	"github.com/go-chi/chi/v5"
+	"go.opentelemetry.io/contrib/bridges/otelslog"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+
+	tosapp "gitlab.com/ucmsv2/ucms-backend/internal/application/tos"
+	"gitlab.com/ucmsv2/ucms-backend/internal/application/tos/cmd"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/tos"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/ctxs"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/httpx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/sanitizex"
+)
+
+var (
+	tracer = otel.Tracer("ucms/internal/ports/http/tos")
+	logger = otelslog.NewLogger("ucms/internal/ports/http/tos")
+)
+
+// HTTP serves the terms of service: the current version, the consent of the
+// user and the versions managed by the staff.
+type HTTP struct {
+	tracer     trace.Tracer
+	logger     *slog.Logger
+	app        *tosapp.App
+	errhandler *httpx.ErrorHandler
+}
+
+type Args struct {
+	Tracer     trace.Tracer
+	Logger     *slog.Logger
+	App        *tosapp.App
+	Errhandler *httpx.ErrorHandler
+}
+
+func NewHTTP(args Args) *HTTP {
+	if args.Tracer == nil {
+		args.Tracer = tracer
+	}
+	if args.Logger == nil {
+		args.Logger = logger
+	}
+
+	return &HTTP{
+		tracer:     args.Tracer,
+		logger:     args.Logger,
+		app:        args.App,
+		errhandler: args.Errhandler,
+	}
+}
+
+func (h *HTTP) Route(r chi.Router) {
+	// The registration forms show the current version before there is a user.
+	r.Get("/v1/tos/current", h.GetCurrentVersion)
+
+	r.Route("/v1/tos/versions", func(r chi.Router) {
+		r.Get("/", h.ListVersions)
+		r.Post("/", h.PublishVersion)
+	})
+
+	// The user port mounts /v1/users, chi matches this static path before
+	// the mount.
+	r.Post("/v1/users/me/tos/accept", h.AcceptTOS)
+}
+
+func (h *HTTP) GetCurrentVersion(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "HTTP.GetCurrentVersion")
+	defer span.End()
+
+	res, err := h.app.Query.Version.Current(ctx)
+	if err != nil {
+		h.errhandler.HandleError(w, r, span, err, "failed to get current tos version")
+		return
+	}
+
+	httpx.Success(w, r, http.StatusOK, httpx.Envelope{"tos": res})
+}
+
+func (h *HTTP) ListVersions(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "HTTP.ListVersions")
+	defer span.End()
+
+	res, err := h.app.Query.Version.List(ctx)
+	if err != nil {
+		h.errhandler.HandleError(w, r, span, err, "failed to list tos versions")
+		return
+	}
+
+	httpx.Success(w, r, http.StatusOK, httpx.Envelope{"versions": res})
+}
+
+type PublishVersionRequest struct {
+	Version     string `json:"version"`
+	DocumentURL string `json:"document_url"`
+	// PublishedAt defaults to now, a later time schedules the version.
+	PublishedAt *time.Time `json:"published_at"`
+}
+
+func (r *PublishVersionRequest) Sanitize() {
+	r.Version = sanitizex.CleanSingleLine(r.Version)
+	r.DocumentURL = sanitizex.CleanSingleLine(r.DocumentURL)
+	r.PublishedAt = httpx.NormalizeTimePtr(r.PublishedAt)
+}
+
+func (r *PublishVersionRequest) SetSpanAttrs(span trace.Span) {
+	otelx.SetSpanAttrs(span, map[string]any{
+		"request.version": r.Version,
+	})
+}
+
+func (r *PublishVersionRequest) Validate() error {
+	return validation.ValidateStruct(r,
+		validation.Field(&r.Version, validation.Required, validation.RuneLength(1, tos.MaxVersionLen)),
+		validation.Field(&r.DocumentURL, validation.Required, validation.Length(1, tos.MaxDocumentURLLen)),
+	)
+}
+
+func (h *HTTP) PublishVersion(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "HTTP.PublishVersion")
+	defer span.End()
+
+	ctxUser, err := ctxs.UserFromCtx(ctx)
+	if err != nil {
+		h.errhandler.HandleError(w, r, span, err, "failed to get user from context")
+		return
+	}
+	ctxUser.SetSpanAttrs(span)
+
+	var req PublishVersionRequest
+	if err := httpx.ReadJSON(w, r, &req); err != nil {
+		h.errhandler.HandleError(w, r, span, err, "failed to read body")
+		return
+	}
+
+	req.Sanitize()
+	req.SetSpanAttrs(span)
+	if err := req.Validate(); err != nil {
+		h.errhandler.HandleError(w, r, span, err, "validation failed")
+		return
+	}
+
+	publish := cmd.PublishVersion{
+		StaffID:     ctxUser.ID,
+		Version:     req.Version,
+		DocumentURL: req.DocumentURL,
+	}
+	if req.PublishedAt != nil {
+		publish.PublishedAt = *req.PublishedAt
+	}
+	if err := h.app.Command.PublishVersion.Handle(ctx, publish); err != nil {
+		h.errhandler.HandleError(w, r, span, err, "failed to publish tos version")
+		return
+	}
+
+	httpx.Success(w, r, http.StatusCreated, nil)
+}
+
+func (h *HTTP) AcceptTOS(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "HTTP.AcceptTOS")
+	defer span.End()
+
+	ctxUser, err := ctxs.UserFromCtx(ctx)
+	if err != nil {
+		h.errhandler.HandleError(w, r, span, err, "failed to get user from context")
+		return
+	}
+	ctxUser.SetSpanAttrs(span)
+
+	err = h.app.Command.AcceptTOS.Handle(ctx, cmd.AcceptTOS{
+		UserID: ctxUser.ID,
+		IP:     ctxs.ClientIPFromCtx(ctx),
+	})
+	if err != nil {
+		h.errhandler.HandleError(w, r, span, err, "failed to accept tos")
+		return
+	}
+
+	httpx.Success(w, r, http.StatusOK, nil)
+}
@@ -2,21 +2,34 @@ package userhttp
 
 import (
 	"log/slog"
+	"math"
+	"mime"
 	"net/http"
+	"time"
 
 	"github.com/ARUMANDESU/validation"
+	"github.com/ARUMANDESU/validation/is"
 	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
 	"go.opentelemetry.io/contrib/bridges/otelslog"
 	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
 
+	notificationapp "gitlab.com/ucmsv2/ucms-backend/internal/application/notification"
+	notificationcmd "gitlab.com/ucmsv2/ucms-backend/internal/application/notification/cmd"
+	"gitlab.com/ucmsv2/ucms-backend/internal/application/notification/notificationquery"
 	userapp "gitlab.com/ucmsv2/ucms-backend/internal/application/user"
 	usercmd "gitlab.com/ucmsv2/ucms-backend/internal/application/user/cmd"
+	"gitlab.com/ucmsv2/ucms-backend/internal/application/user/userquery"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/notification"
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
-	"gitlab.com/ucmsv2/ucms-backend/internal/ports/http/middlewares"
 	"gitlab.com/ucmsv2/ucms-backend/pkg/ctxs"
 	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
 	"gitlab.com/ucmsv2/ucms-backend/pkg/httpx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/sanitizex"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/validationx"
 )
 
 var (
@@ -24,20 +37,47 @@ var (
 	logger = otelslog.NewLogger("ucms/internal/ports/http/user")
 )
 
+const (
+	headerContentMD5    = "Content-MD5"
+	headerContentSHA256 = "X-Content-SHA256"
+	headerLastEventID   = "Last-Event-ID"
+)
+
+// StreamPath is the notification stream, it stays open past the request
+// timeout of the router.
+const StreamPath = "/v1/users/me/notifications/stream"
+
+const (
+	// HeartbeatInterval keeps the idle streams open through the proxies.
+	HeartbeatInterval = 25 * time.Second
+	// streamWriteTimeout is the time every event of the stream has to be
+	// written in, the heartbeats come before it runs out.
+	streamWriteTimeout = 2 * HeartbeatInterval
+)
+
+// The events of the notification stream.
+const (
+	EventUnreadCount  = "unread_count"
+	EventNotification = "notification"
+)
+
 type HTTP struct {
 	tracer     trace.Tracer
 	logger     *slog.Logger
 	cmd        userapp.Command
-	middleware *middlewares.Middleware
+	query      userapp.Query
+	notify     *notificationapp.App
 	errhandler *httpx.ErrorHandler
 }
 
 type Args struct {
-	Tracer     trace.Tracer
-	Logger     *slog.Logger
-	UserApp    *userapp.App
-	Middleware *middlewares.Middleware
-	Errhandler *httpx.ErrorHandler
+	Tracer  trace.Tracer
+	Logger  *slog.Logger
+	UserApp *userapp.App
+	// NotificationApp serves /v1/users/me/notifications, nil leaves them
+	// unmounted.
+	NotificationApp *notificationapp.App
+	Errhandler      *httpx.ErrorHandler
 }
 
 func NewHTTP(args Args) *HTTP {
@@ -52,17 +92,25 @@ func NewHTTP(args Args) *HTTP {
 		tracer:     args.Tracer,
 		logger:     args.Logger,
 		cmd:        args.UserApp.Command,
-		middleware: args.Middleware,
+		query:      args.UserApp.Query,
+		notify:     args.NotificationApp,
 		errhandler: args.Errhandler,
 	}
 }
 
 func (h *HTTP) Route(r chi.Router) {
 	r.Route("/v1/users", func(r chi.Router) {
-		r.Use(h.middleware.Auth)
-
 		r.Patch("/me/avatar", h.UpdateAvatar)
 		r.Delete("/me/avatar", h.DeleteAvatar)
+		r.Get("/me/export", h.ExportData)
+		r.Post("/me/email/verify", h.VerifyEmail)
+
+		if h.notify != nil {
+			r.Get("/me/notifications", h.ListNotifications)
+			r.Get("/me/notifications/stream", h.StreamNotifications)
+			r.Post("/me/notifications/read-all", h.MarkAllNotificationsRead)
+			r.Post("/me/notifications/{id}/read", h.MarkNotificationRead)
+		}
 	})
 }
 
@@ -112,6 +160,9 @@ func (h *HTTP) UpdateAvatar(w http.ResponseWriter, r *http.Request) {
 		Size:        header.Size,
 		ContentType: header.Header.Get("Content-Type"),
 		Filename:    header.Filename,
+		// Checksums may be sent either on the file part or on the request itself.
+		ContentMD5:    firstNonEmpty(header.Header.Get(headerContentMD5), r.Header.Get(headerContentMD5)),
+		ContentSHA256: firstNonEmpty(header.Header.Get(headerContentSHA256), r.Header.Get(headerContentSHA256)),
 	}
 
 	err = h.cmd.UpdateAvatar.Handle(ctx, cmd)
@@ -142,3 +193,257 @@ func (h *HTTP) DeleteAvatar(w http.ResponseWriter, r *http.Request) {
 
 	httpx.Success(w, r, http.StatusOK, nil)
 }
+
+type VerifyEmailRequest struct {
+	Code string `json:"code"`
+}
+
+func (r *VerifyEmailRequest) Sanitize() {
+	r.Code = sanitizex.CleanSingleLine(r.Code)
+}
+
+func (r *VerifyEmailRequest) Validate() error {
+	return validation.ValidateStruct(r,
+		validation.Field(&r.Code,
+			validation.Required,
+			validationx.IsVerificationCode(user.EmailVerificationCodeLength),
+		),
+	)
+}
+
+// VerifyEmail confirms the email of the current user with the code mailed on
+// login, it lets the initial staff into the staff endpoints.
+func (h *HTTP) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "HTTP.VerifyEmail")
+	defer span.End()
+
+	ctxUser, err := ctxs.UserFromCtx(ctx)
+	if err != nil {
+		h.errhandler.HandleError(w, r, span, err, "failed to get user from context")
+		return
+	}
+	ctxUser.SetSpanAttrs(span)
+
+	var req VerifyEmailRequest
+	if err := httpx.ReadJSON(w, r, &req); err != nil {
+		h.errhandler.HandleError(w, r, span, err, "failed to read body")
+		return
+	}
+	req.Sanitize()
+	if err := req.Validate(); err != nil {
+		h.errhandler.HandleError(w, r, span, err, "validation failed")
+		return
+	}
+
+	err = h.cmd.VerifyEmail.Handle(ctx, usercmd.VerifyEmail{UserID: ctxUser.ID, Code: req.Code})
+	if err != nil {
+		h.errhandler.HandleError(w, r, span, err, "failed to verify email")
+		return
+	}
+
+	httpx.Success(w, r, http.StatusOK, nil)
+}
+
+// ExportData streams the personal data of the current user as a ZIP archive.
+func (h *HTTP) ExportData(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "HTTP.ExportData")
+	defer span.End()
+
+	ctxUser, err := ctxs.UserFromCtx(ctx)
+	if err != nil {
+		h.errhandler.HandleError(w, r, span, err, "failed to get user from context")
+		return
+	}
+	ctxUser.SetSpanAttrs(span)
+
+	export, err := h.query.ExportData.Handle(ctx, userquery.ExportData{UserID: ctxUser.ID})
+	if err != nil {
+		h.errhandler.HandleError(w, r, span, err, "failed to prepare data export")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": export.Filename}))
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+
+	manifest, err := export.Write(ctx, w)
+	if err != nil {
+		// The status is already sent, the client is left with a truncated archive.
+		otelx.RecordSpanError(span, err, "failed to stream data export")
+		h.logger.ErrorContext(ctx, "failed to stream data export", slog.String("error", err.Error()))
+		return
+	}
+	span.SetAttributes(
+		attribute.Int("export.files", len(manifest.Files)),
+		attribute.Int("export.missing", len(manifest.Missing)),
+	)
+}
+
+// ListNotifications lists the notifications of the user, the newest first.
+// ?unread_only=true leaves out the read ones and ?page pages them.
+func (h *HTTP) ListNotifications(w http.ResponseWriter, r *http.Request) {
+	const op = "userhttp.HTTP.ListNotifications"
+	ctx, span := h.tracer.Start(r.Context(), "HTTP.ListNotifications")
+	defer span.End()
+
+	ctxUser, err := ctxs.UserFromCtx(ctx)
+	if err != nil {
+		h.errhandler.HandleError(w, r, span, err, "failed to get user from context")
+		return
+	}
+	ctxUser.SetSpanAttrs(span)
+
+	query := httpx.Query(r)
+	list := notificationquery.ListNotifications{
+		UserID:     ctxUser.ID,
+		UnreadOnly: query.Bool("unread_only", false),
+		Page:       query.Int("page", 1, math.MaxInt32, 1),
+		PageSize:   query.Int("page_size", 1, notificationquery.MaxPageSize, notificationquery.DefaultPageSize),
+	}
+	if err := query.Err(); err != nil {
+		h.errhandler.HandleError(w, r, span, errorx.Wrap(err, op), "invalid query parameters")
+		return
+	}
+
+	res, err := h.notify.Query.ListNotifications.Handle(ctx, list)
+	if err != nil {
+		h.errhandler.HandleError(w, r, span, err, "failed to list notifications")
+		return
+	}
+
+	httpx.Success(w, r, http.StatusOK, httpx.Envelope{
+		"notifications": res.Notifications,
+		"unread_count":  res.UnreadCount,
+	})
+}
+
+// StreamNotifications streams the notifications of the user as server-sent
+// events: the unread count first, then every new notification with its ID as
+// the event id. A reconnecting client sends the last one it got as
+// Last-Event-ID and receives the ones it missed before the new ones.
+func (h *HTTP) StreamNotifications(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "HTTP.StreamNotifications")
+	defer span.End()
+
+	ctxUser, err := ctxs.UserFromCtx(ctx)
+	if err != nil {
+		h.errhandler.HandleError(w, r, span, err, "failed to get user from context")
+		return
+	}
+	ctxUser.SetSpanAttrs(span)
+
+	open := notificationquery.OpenStream{UserID: ctxUser.ID}
+	// An unknown Last-Event-ID resumes nothing, the client reloads the list.
+	if id, err := uuid.Parse(r.Header.Get(headerLastEventID)); err == nil {
+		lastEventID := notification.ID(id)
+		open.LastEventID = &lastEventID
+		span.SetAttributes(attribute.String("request.last_event_id", id.String()))
+	}
+
+	stream, err := h.notify.Query.OpenStream.Handle(ctx, open)
+	if err != nil {
+		h.errhandler.HandleError(w, r, span, err, "failed to open notification stream")
+		return
+	}
+	defer stream.Close()
+
+	events, err := httpx.NewEventStream(w, streamWriteTimeout)
+	if err != nil {
+		h.errhandler.HandleError(w, r, span, err, "failed to start event stream")
+		return
+	}
+
+	err = events.Send("", EventUnreadCount, httpx.Envelope{"unread_count": stream.UnreadCount})
+	for i := 0; err == nil && i < len(stream.Missed); i++ {
+		err = events.Send(stream.Missed[i].ID, EventNotification, stream.Missed[i])
+	}
+
+	heartbeat := time.NewTicker(HeartbeatInterval)
+	defer heartbeat.Stop()
+	for err == nil {
+		select {
+		case <-ctx.Done():
+			return
+		case n, ok := <-stream.C:
+			if !ok {
+				h.logger.InfoContext(ctx, "notification stream fell behind, closing it",
+					slog.String("user.id", ctxUser.ID.String()))
+				return
+			}
+			if stream.Resumed(n) {
+				continue
+			}
+			res := notificationquery.NewNotificationResponse(n)
+			err = events.Send(res.ID, EventNotification, res)
+		case <-heartbeat.C:
+			err = events.Comment("heartbeat")
+		}
+	}
+
+	// The client is gone, its connection failed the write.
+	h.logger.DebugContext(ctx, "notification stream closed",
+		slog.String("user.id", ctxUser.ID.String()),
+		slog.String("error", err.Error()))
+}
+
+func (h *HTTP) MarkNotificationRead(w http.ResponseWriter, r *http.Request) {
+	const op = "userhttp.HTTP.MarkNotificationRead"
+	ctx, span := h.tracer.Start(r.Context(), "HTTP.MarkNotificationRead")
+	defer span.End()
+
+	ctxUser, err := ctxs.UserFromCtx(ctx)
+	if err != nil {
+		h.errhandler.HandleError(w, r, span, err, "failed to get user from context")
+		return
+	}
+	ctxUser.SetSpanAttrs(span)
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		err = errorx.Wrap(validation.Errors{"id": is.ErrUUID}, op)
+		h.errhandler.HandleError(w, r, span, err, "invalid notification id")
+		return
+	}
+	span.SetAttributes(attribute.String("request.notification_id", id.String()))
+
+	err = h.notify.Command.MarkRead.Handle(ctx, notificationcmd.MarkRead{
+		UserID:         ctxUser.ID,
+		NotificationID: notification.ID(id),
+	})
+	if err != nil {
+		h.errhandler.HandleError(w, r, span, err, "failed to mark notification as read")
+		return
+	}
+
+	httpx.Success(w, r, http.StatusOK, nil)
+}
+
+func (h *HTTP) MarkAllNotificationsRead(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "HTTP.MarkAllNotificationsRead")
+	defer span.End()
+
+	ctxUser, err := ctxs.UserFromCtx(ctx)
+	if err != nil {
+		h.errhandler.HandleError(w, r, span, err, "failed to get user from context")
+		return
+	}
+	ctxUser.SetSpanAttrs(span)
+
+	err = h.notify.Command.MarkAllRead.Handle(ctx, notificationcmd.MarkAllRead{UserID: ctxUser.ID})
+	if err != nil {
+		h.errhandler.HandleError(w, r, span, err, "failed to mark notifications as read")
+		return
+	}
+
+	httpx.Success(w, r, http.StatusOK, nil)
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -8,14 +8,18 @@ import (
 	"github.com/ThreeDotsLabs/watermill/components/cqrs"
 	"github.com/ThreeDotsLabs/watermill/message"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel"
 
 	mailevent "gitlab.com/ucmsv2/ucms-backend/internal/application/mail/event"
+	notificationevent "gitlab.com/ucmsv2/ucms-backend/internal/application/notification/event"
 	"gitlab.com/ucmsv2/ucms-backend/internal/application/registration"
 	studentapp "gitlab.com/ucmsv2/ucms-backend/internal/application/student"
 	userapp "gitlab.com/ucmsv2/ucms-backend/internal/application/user"
 	"gitlab.com/ucmsv2/ucms-backend/pkg/watermillx"
 )
 
+var tracer = otel.Tracer("ucms/internal/ports/watermill")
+
 type Port struct {
 	eventProcessor      *cqrs.EventProcessor
 	eventGroupProcessor *cqrs.EventGroupProcessor
@@ -27,9 +31,16 @@ type AppEventHandlers struct {
 	Mail         *mailevent.MailEventHandler
 	Student      studentapp.Event
 	User         userapp.Event
+	Notification *notificationevent.NotificationEventHandler
 }
 
 func NewPort(router *message.Router, conn *pgxpool.Pool, wmlogger watermill.LoggerAdapter) (*Port, error) {
+	poisonQueue, err := watermillx.NewSQLPoisonQueue(conn, wmlogger)
+	if err != nil {
+		return nil, err
+	}
+	router.AddMiddleware(poisonQueue)
+
 	eventProcessor, err := watermillx.NewEventProcessor(router, conn, wmlogger)
 	if err != nil {
 		return nil, err
@@ -47,6 +58,12 @@ func NewPort(router *message.Router, conn *pgxpool.Pool, wmlogger watermill.Logg
 }
 
 func NewPortForTest(router *message.Router, conn *pgxpool.Pool, wmlogger watermill.LoggerAdapter) (*Port, error) {
+	poisonQueue, err := watermillx.NewSQLPoisonQueue(conn, wmlogger)
+	if err != nil {
+		return nil, err
+	}
+	router.AddMiddleware(poisonQueue)
+
 	eventProcessor, err := watermillx.NewEventProcessorForTests(router, conn, wmlogger)
 	if err != nil {
 		return nil, err
@@ -64,21 +81,65 @@ func NewPortForTest(router *message.Router, conn *pgxpool.Pool, wmlogger watermi
 }
 
 func (p *Port) Run(ctx context.Context, handlers AppEventHandlers) error {
-	err := p.eventProcessor.AddHandlers(
-		cqrs.NewEventHandler("MailOnRegistrationStarted", handlers.Mail.HandleRegistrationStarted),
-		cqrs.NewEventHandler("MailOnVerificationCodeResent", handlers.Mail.HandleVerificationCodeResent),
-		cqrs.NewEventHandler("MailOnStudentRegistered", handlers.Mail.HandleStudentRegistered),
-		cqrs.NewEventHandler("MailOnStaffInvitationCreated", handlers.Mail.HandleStaffInvitationCreated),
-		cqrs.NewEventHandler("MailOnStaffInvitationRecipientsUpdated", handlers.Mail.HandleStaffInvitationRecipientsUpdated),
-		cqrs.NewEventHandler("MailOnStaffInvitationAccepted", handlers.Mail.HandleStaffInvitationAccepted),
-
-		cqrs.NewEventHandler("RegistrationOnStudentRegistered", handlers.Registration.Registration.StudentHandle),
-
-		cqrs.NewEventHandler("UserOnAvatarUpdated", handlers.User.AvatarUpdated.Handle),
-	)
+	err := p.eventProcessor.AddHandlers(append(mailHandlers(handlers.Mail),
+		traced("RegistrationOnStudentRegistered", handlers.Registration.Registration.StudentHandle),
+
+		traced("StudentOnGroupChangedRefreshSummary", handlers.Student.GroupSummary.HandleStudentGroupChanged),
+		traced("StudentOnStudentRegisteredRefreshSummary", handlers.Student.GroupSummary.HandleStudentRegistered),
+
+		traced("UserOnAvatarUpdated", handlers.User.AvatarUpdated.Handle),
+		traced("UserOnAvatarMissing", handlers.User.AvatarMissing.Handle),
+
+		traced("NotificationOnStaffInvitationAccepted", handlers.Notification.HandleStaffInvitationAccepted),
+		traced("NotificationOnStudentGroupChanged", handlers.Notification.HandleStudentGroupChanged),
+		traced("NotificationOnAnnouncementPublished", handlers.Notification.HandleAnnouncementPublished),
+		traced("NotificationOnUserAvatarRejected", handlers.Notification.HandleUserAvatarRejected),
+	)...)
 	if err != nil {
 		return fmt.Errorf("failed to add event handlers: %w", err)
 	}
 
 	return nil
 }
+
+func mailHandlers(mail *mailevent.MailEventHandler) []cqrs.EventHandler {
+	return []cqrs.EventHandler{
+		traced("MailOnRegistrationStarted", mail.HandleRegistrationStarted),
+		traced("MailOnVerificationCodeResent", mail.HandleVerificationCodeResent),
+		traced("MailOnStudentRegistered", mail.HandleStudentRegistered),
+		traced("MailOnStaffInvitationCreated", mail.HandleStaffInvitationCreated),
+		traced("MailOnStaffInvitationRecipientsUpdated", mail.HandleStaffInvitationRecipientsUpdated),
+		traced("MailOnStaffInvitationAccepted", mail.HandleStaffInvitationAccepted),
+		traced("MailOnAnnouncementPublished", mail.HandleAnnouncementPublished),
+		traced("MailOnEmailVerificationRequested", mail.HandleEmailVerificationRequested),
+	}
+}
+
+// MailHandlerNames returns the names of the event handlers sending the
+// emails, their dead letters are the mail failures.
+func MailHandlerNames() []string {
+	handlers := mailHandlers(&mailevent.MailEventHandler{})
+	names := make([]string, len(handlers))
+	for i, h := range handlers {
+		names[i] = h.HandlerName()
+	}
+	return names
+}
+
+// RequeueMailDeadLetters sends the emails of the dead letters of the mail
+// handlers again, see watermillx.RedeliverDeadLetters. It needs neither the
+// router nor the Port, `ucms-api mail requeue-dead-letters` runs it while
+// the API is serving.
+func RequeueMailDeadLetters(
+	ctx context.Context,
+	conn *pgxpool.Pool,
+	mail *mailevent.MailEventHandler,
+) (watermillx.RedeliverResult, error) {
+	return watermillx.RedeliverDeadLetters(ctx, conn, mailHandlers(mail))
+}
+
+// traced returns the typed handler of the events handled by handle, see
+// watermillx.HandleTyped.
+func traced[T any](name string, handle func(ctx context.Context, event *T) error) cqrs.EventHandler {
+	return watermillx.HandleTypedWith(watermillx.TypedOptions{Tracer: tracer}, name, handle)
+}
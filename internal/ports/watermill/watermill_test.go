@@ -0,0 +1,188 @@
+package watermill
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill/components/cqrs"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/ThreeDotsLabs/watermill/pubsub/gochannel"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"gitlab.com/ucmsv2/ucms-backend/pkg/watermillx"
+)
+
+var (
+	installProviderOnce sync.Once
+	testSpans           *tracetest.SpanRecorder
+	testProvider        *sdktrace.TracerProvider
+)
+
+type pinged struct {
+	Fail bool
+}
+
+type tracedPubSub struct {
+	bus      *cqrs.EventBus
+	spans    *tracetest.SpanRecorder
+	tracer   trace.Tracer
+	handled  chan struct{}
+	handlerC chan trace.SpanContext
+	failed   atomic.Bool
+}
+
+// newTracedPubSub runs a router on an in-memory pub/sub with a single traced
+// handler. The publisher goes through watermillx.InjectTraceOnPublish and the
+// messages are copied, as a database round trip would, so only the metadata
+// carries the trace.
+func newTracedPubSub(t *testing.T) *tracedPubSub {
+	t.Helper()
+
+	// The package tracer delegates to the first global provider, it is set
+	// once for all tests and spans are told apart by their IDs.
+	installProviderOnce.Do(func() {
+		testSpans = tracetest.NewSpanRecorder()
+		testProvider = sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(testSpans))
+		otel.SetTracerProvider(testProvider)
+	})
+
+	logger := watermill.NopLogger{}
+	pubsub := gochannel.NewGoChannel(gochannel.Config{}, logger)
+	router, err := message.NewRouter(message.RouterConfig{}, logger)
+	require.NoError(t, err)
+
+	ps := &tracedPubSub{
+		spans:    testSpans,
+		tracer:   testProvider.Tracer("test"),
+		handled:  make(chan struct{}, 1),
+		handlerC: make(chan trace.SpanContext, 1),
+	}
+
+	processor, err := cqrs.NewEventProcessorWithConfig(router, cqrs.EventProcessorConfig{
+		GenerateSubscribeTopic: func(cqrs.EventProcessorGenerateSubscribeTopicParams) (string, error) {
+			return "pings", nil
+		},
+		SubscriberConstructor: func(cqrs.EventProcessorSubscriberConstructorParams) (message.Subscriber, error) {
+			return pubsub, nil
+		},
+		Marshaler: cqrs.JSONMarshaler{},
+		Logger:    logger,
+	})
+	require.NoError(t, err)
+	require.NoError(t, processor.AddHandlers(traced("MailOnPinged", func(ctx context.Context, e *pinged) error {
+		defer func() { ps.handled <- struct{}{} }()
+		ps.handlerC <- trace.SpanContextFromContext(ctx)
+		// Fail the first delivery only, the redelivery is acked.
+		if e.Fail && ps.failed.CompareAndSwap(false, true) {
+			return errors.New("smtp unavailable")
+		}
+		return nil
+	})))
+
+	ps.bus, err = cqrs.NewEventBusWithConfig(copyingPublisher{pubsub}, cqrs.EventBusConfig{
+		GeneratePublishTopic: func(cqrs.GenerateEventPublishTopicParams) (string, error) { return "pings", nil },
+		OnPublish:            watermillx.InjectTraceOnPublish,
+		Marshaler:            cqrs.JSONMarshaler{},
+		Logger:               logger,
+	})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go func() { _ = router.Run(ctx) }()
+	<-router.Running()
+
+	return ps
+}
+
+// copyingPublisher drops the in-process context of the published messages.
+type copyingPublisher struct {
+	message.Publisher
+}
+
+func (p copyingPublisher) Publish(topic string, msgs ...*message.Message) error {
+	copies := make([]*message.Message, len(msgs))
+	for i, msg := range msgs {
+		copies[i] = msg.Copy()
+	}
+	return p.Publisher.Publish(topic, copies...)
+}
+
+func (ps *tracedPubSub) publish(t *testing.T, e *pinged) (trace.SpanContext, trace.SpanContext) {
+	t.Helper()
+
+	ctx, span := ps.tracer.Start(context.Background(), "POST /v1/pings", trace.WithSpanKind(trace.SpanKindServer))
+	require.NoError(t, ps.bus.Publish(ctx, e))
+	span.End()
+
+	var handlerCtx trace.SpanContext
+	select {
+	case handlerCtx = <-ps.handlerC:
+	case <-time.After(5 * time.Second):
+		t.Fatal("event was not handled")
+	}
+	<-ps.handled
+	return span.SpanContext(), handlerCtx
+}
+
+func (ps *tracedPubSub) consumerSpan(t *testing.T, sc trace.SpanContext) sdktrace.ReadOnlySpan {
+	t.Helper()
+	require.Eventually(t, func() bool {
+		for _, s := range ps.spans.Ended() {
+			if s.SpanContext().SpanID() == sc.SpanID() {
+				return true
+			}
+		}
+		return false
+	}, time.Second, 10*time.Millisecond)
+	for _, s := range ps.spans.Ended() {
+		if s.SpanContext().SpanID() == sc.SpanID() {
+			return s
+		}
+	}
+	return nil
+}
+
+func TestTraced_ContinuesPublisherTrace(t *testing.T) {
+	ps := newTracedPubSub(t)
+
+	httpSpan, handlerSpan := ps.publish(t, &pinged{})
+
+	assert.Equal(t, httpSpan.TraceID(), handlerSpan.TraceID(), "handler should continue the request trace")
+	span := ps.consumerSpan(t, handlerSpan)
+	assert.Equal(t, "MailOnPinged", span.Name())
+	assert.Equal(t, trace.SpanKindConsumer, span.SpanKind())
+	assert.Equal(t, httpSpan.SpanID(), span.Parent().SpanID())
+	assert.NotEqual(t, codes.Error, span.Status().Code)
+}
+
+func TestTraced_RecordsHandlerFailure(t *testing.T) {
+	ps := newTracedPubSub(t)
+
+	_, handlerSpan := ps.publish(t, &pinged{Fail: true})
+
+	span := ps.consumerSpan(t, handlerSpan)
+	assert.Equal(t, codes.Error, span.Status().Code)
+	assert.Equal(t, "smtp unavailable", span.Status().Description)
+	require.NotEmpty(t, span.Events())
+	exception := span.Events()[0]
+	assert.Equal(t, "exception", exception.Name)
+	var hasStack bool
+	for _, attr := range exception.Attributes {
+		if attr.Key == "exception.stacktrace" && attr.Value.AsString() != "" {
+			hasStack = true
+		}
+	}
+	assert.True(t, hasStack, "error should be recorded with its stack trace")
+}
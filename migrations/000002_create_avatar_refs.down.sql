@@ -0,0 +1 @@
+drop table avatar_refs;
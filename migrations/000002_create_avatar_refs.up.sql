@@ -0,0 +1,14 @@
+-- avatars are stored under their content hash and shared between users,
+-- the object may only be removed once nobody references it anymore.
+create table avatar_refs (
+    s3_key text primary key,
+    ref_count integer not null default 0,
+    updated_at timestamptz not null default now(),
+    constraint avatar_refs_ref_count_check check (ref_count >= 0)
+);
+
+insert into avatar_refs (s3_key, ref_count)
+select avatar_s3_key, count(*)
+from users
+where avatar_source = 's3' and avatar_s3_key <> ''
+group by avatar_s3_key;
@@ -0,0 +1 @@
+drop table error_events;
@@ -0,0 +1,18 @@
+-- server errors and panics aggregated by signature, see pkg/errorinbox.
+-- request bodies are never stored.
+create table error_events (
+    signature text primary key,
+    type text not null,
+    message text not null,
+    frames text[] not null default '{}',
+    route text not null default '',
+    sample_correlation_id text not null default '',
+    count bigint not null default 0,
+    status text not null default 'open',
+    first_seen timestamptz not null,
+    last_seen timestamptz not null,
+    updated_at timestamptz not null default now(),
+    constraint error_events_status_check check (status in ('open', 'resolved', 'muted'))
+);
+
+create index error_events_status_last_seen_idx on error_events (status, last_seen desc);
@@ -0,0 +1,3 @@
+alter table registrations drop column campus_id;
+alter table groups drop column campus_id;
+alter table users drop column campus_id;
@@ -0,0 +1,10 @@
+-- groundwork for multi-campus deployments, rows written before the service
+-- had a campus belong to the default one. keep it in sync with
+-- ctxs.DefaultCampus.
+alter table users add column campus_id text not null default 'main';
+alter table groups add column campus_id text not null default 'main';
+alter table registrations add column campus_id text not null default 'main';
+
+create index users_campus_id_idx on users (campus_id);
+create index groups_campus_id_idx on groups (campus_id);
+create index registrations_campus_id_idx on registrations (campus_id);
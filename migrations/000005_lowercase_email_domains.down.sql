@@ -0,0 +1,3 @@
+-- the original case of the domains is not kept, only the conflicts are
+-- dropped.
+drop table migration_conflicts;
@@ -0,0 +1,77 @@
+-- the http ports store emails normalized by sanitizex.NormalizeEmail, which
+-- lowercases the domain. lowercase the domains written before so the lookups
+-- of the normalized addresses still find them. IDN domains are not
+-- converted.
+--
+-- a row is left as it is when its normalized value is taken, by a row already
+-- normalized or by an older row normalized along with it. such a row is
+-- unreachable by the normalized lookups, it is recorded in
+-- migration_conflicts for the staff to merge or rename, the API warns about
+-- them at startup.
+create table migration_conflicts (
+    id bigserial primary key,
+    migration text not null,
+    table_name text not null,
+    row_id uuid not null,
+    value text not null,
+    normalized text not null,
+    created_at timestamptz not null default now()
+);
+
+with candidates as (
+    select id, email, normalized,
+           row_number() over (partition by normalized order by created_at, id) as rn
+    from (
+        select id, email, created_at,
+               split_part(email, '@', 1) || '@' || lower(split_part(email, '@', 2)) as normalized
+        from users
+        where email like '%_@_%' and email not like '%@%@%'
+    ) u
+    where email <> normalized
+),
+conflicts as (
+    insert into migration_conflicts (migration, table_name, row_id, value, normalized)
+    select '000005_lowercase_email_domains', 'users', c.id, c.email, c.normalized
+    from candidates c
+    where c.rn > 1 or exists (select 1 from users o where o.email = c.normalized)
+    returning row_id
+)
+update users u
+set email = c.normalized
+from candidates c
+where u.id = c.id
+  and c.id not in (select row_id from conflicts);
+
+with candidates as (
+    select id, email, normalized,
+           row_number() over (partition by normalized order by created_at, id) as rn
+    from (
+        select id, email, created_at,
+               split_part(email, '@', 1) || '@' || lower(split_part(email, '@', 2)) as normalized
+        from registrations
+        where email like '%_@_%' and email not like '%@%@%'
+    ) r
+    where email <> normalized
+),
+conflicts as (
+    insert into migration_conflicts (migration, table_name, row_id, value, normalized)
+    select '000005_lowercase_email_domains', 'registrations', c.id, c.email, c.normalized
+    from candidates c
+    where c.rn > 1 or exists (select 1 from registrations o where o.email = c.normalized)
+    returning row_id
+)
+update registrations r
+set email = c.normalized
+from candidates c
+where r.id = c.id
+  and c.id not in (select row_id from conflicts);
+
+update staff_invitations
+set recipients_email = array(
+    select case when e like '%_@_%' and e not like '%@%@%'
+                then split_part(e, '@', 1) || '@' || lower(split_part(e, '@', 2))
+                else e end
+    from unnest(recipients_email) with ordinality as t(e, i)
+    order by i
+)
+where recipients_email is not null;
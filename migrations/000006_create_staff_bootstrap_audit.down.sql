@@ -0,0 +1 @@
+drop table staff_bootstrap_audit;
@@ -0,0 +1,13 @@
+-- changes made by bootstrapping the initial staff at startup, see the
+-- BootstrapInitialStaff command. passwords are never stored here.
+create table staff_bootstrap_audit (
+    id bigserial primary key,
+    user_id uuid not null,
+    action text not null,
+    instance_id text not null default '',
+    created_at timestamptz not null default now(),
+    constraint staff_bootstrap_audit_user_id_fkey foreign key (user_id) references users(id),
+    constraint staff_bootstrap_audit_action_check check (action in ('created', 'password_rotated'))
+);
+
+create index staff_bootstrap_audit_user_id_idx on staff_bootstrap_audit (user_id, created_at desc);
@@ -0,0 +1 @@
+alter table users drop column previous_pass_hashes;
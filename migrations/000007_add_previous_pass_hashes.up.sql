@@ -0,0 +1,3 @@
+-- hashes of the previous passwords of a user, newest first, so that the last
+-- ones are not reused. see user.PasswordHistorySize.
+alter table users add column previous_pass_hashes bytea[] not null default '{}';
@@ -0,0 +1,3 @@
+-- the original case of the barcodes is not kept, only the conflicts are
+-- dropped.
+delete from migration_conflicts where migration = '000008_uppercase_barcodes';
@@ -0,0 +1,24 @@
+-- barcodes are stored uppercased by user.NewBarcode and looked up the same
+-- way. uppercase the barcodes written before so the lookups still find them.
+--
+-- a row is left as it is when its uppercased barcode is taken, by a row
+-- already uppercased or by an older row uppercased along with it, and is
+-- recorded in migration_conflicts, see 000005_lowercase_email_domains.
+with candidates as (
+    select id, barcode, upper(barcode) as normalized,
+           row_number() over (partition by upper(barcode) order by created_at, id) as rn
+    from users
+    where barcode <> upper(barcode)
+),
+conflicts as (
+    insert into migration_conflicts (migration, table_name, row_id, value, normalized)
+    select '000008_uppercase_barcodes', 'users', c.id, c.barcode, c.normalized
+    from candidates c
+    where c.rn > 1 or exists (select 1 from users o where o.barcode = c.normalized)
+    returning row_id
+)
+update users u
+set barcode = c.normalized
+from candidates c
+where u.id = c.id
+  and c.id not in (select row_id from conflicts);
@@ -0,0 +1,7 @@
+drop index if exists students_enrollment_status_idx;
+
+alter table students
+    drop constraint students_enrollment_status_check,
+    drop column expel_reason,
+    drop column leave_until,
+    drop column enrollment_status;
@@ -0,0 +1,10 @@
+-- enrollment status of a student, see user.EnrollmentStatus. leave_until is
+-- set while the student is on academic_leave, expel_reason once expelled.
+alter table students
+    add column enrollment_status text not null default 'enrolled',
+    add column leave_until timestamptz,
+    add column expel_reason text,
+    add constraint students_enrollment_status_check
+        check (enrollment_status in ('enrolled', 'academic_leave', 'graduated', 'expelled'));
+
+create index students_enrollment_status_idx on students (enrollment_status);
@@ -0,0 +1,9 @@
+drop index if exists staffs_department_idx;
+
+alter table staff_invitations
+    drop column position,
+    drop column department;
+
+alter table staffs
+    drop column position,
+    drop column department;
@@ -0,0 +1,11 @@
+-- optional department and position of the staff, an invitation pre-fills
+-- them for its recipients. An empty string is no department or position.
+alter table staffs
+    add column department text not null default '',
+    add column position text not null default '';
+
+alter table staff_invitations
+    add column department text not null default '',
+    add column position text not null default '';
+
+create index staffs_department_idx on staffs (department) where department <> '';
@@ -0,0 +1,18 @@
+-- in-app notifications, see internal/domain/notification. A user gets at
+-- most one notification per event, so that a redelivered event adds none.
+create table notifications (
+    id uuid primary key,
+    user_id uuid not null,
+    event_id uuid not null,
+    type text not null,
+    title text not null,
+    body text not null default '',
+    link text not null default '',
+    read_at timestamptz,
+    created_at timestamptz not null,
+    constraint notifications_user_id_fkey foreign key (user_id) references users(id) on delete cascade,
+    constraint notifications_user_event_key unique (user_id, event_id)
+);
+
+create index notifications_user_created_at_idx on notifications (user_id, created_at desc);
+create index notifications_user_unread_idx on notifications (user_id) where read_at is null;
@@ -0,0 +1,19 @@
+-- announcements of the student association, see internal/domain/announcement.
+-- an empty target_group_ids targets all students. the staff unpublish them
+-- instead of deleting, the students see the published ones only.
+create table announcements (
+    id uuid primary key,
+    author_id uuid not null,
+    title text not null,
+    body text not null,
+    target_group_ids uuid[] not null default '{}',
+    send_email boolean not null default false,
+    created_at timestamptz not null,
+    unpublished_at timestamptz,
+    unpublished_by uuid,
+    constraint announcements_author_id_fkey foreign key (author_id) references users(id),
+    constraint announcements_unpublished_by_fkey foreign key (unpublished_by) references users(id)
+);
+
+create index announcements_published_created_at_idx on announcements (created_at desc) where unpublished_at is null;
+create index announcements_target_group_ids_idx on announcements using gin (target_group_ids);
@@ -0,0 +1,7 @@
+alter table users
+    drop constraint users_tos_version_fkey,
+    drop column tos_accepted_ip,
+    drop column tos_accepted_at,
+    drop column tos_version;
+
+drop table tos_versions;
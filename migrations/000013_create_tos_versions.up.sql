@@ -0,0 +1,18 @@
+-- versions of the terms of service, see internal/domain/tos. the version in
+-- effect is the latest one published, the users who accepted an older one
+-- have to accept it again.
+create table tos_versions (
+    version text primary key,
+    document_url text not null,
+    published_at timestamptz not null,
+    created_at timestamptz not null
+);
+
+create index tos_versions_published_at_idx on tos_versions (published_at desc);
+
+-- the last acceptance of the user, legal keeps it as the proof of consent.
+alter table users
+    add column tos_version text,
+    add column tos_accepted_at timestamptz,
+    add column tos_accepted_ip text,
+    add constraint users_tos_version_fkey foreign key (tos_version) references tos_versions(version);
@@ -0,0 +1,4 @@
+drop table weekly_reports;
+
+drop index students_enrollment_changed_at_idx;
+alter table students drop column enrollment_changed_at;
@@ -0,0 +1,14 @@
+-- the time the enrollment status of a student last changed, the weekly
+-- report counts the students suspended in the week by it.
+alter table students add column enrollment_changed_at timestamptz;
+
+create index students_enrollment_changed_at_idx on students (enrollment_changed_at)
+    where enrollment_changed_at is not null;
+
+-- the weekly provisioning reports, see internal/domain/report. the row is
+-- the claim of the instance mailing it, the others skip the week.
+create table weekly_reports (
+    week_start timestamptz primary key,
+    report jsonb not null,
+    generated_at timestamptz not null
+);
@@ -0,0 +1,15 @@
+-- the impersonation sessions of the staff, see user.Impersonation. they are
+-- kept as the audit of the support acting as the users.
+create table impersonations (
+    id uuid primary key,
+    actor_id uuid not null,
+    target_id uuid not null,
+    target_role text not null,
+    ip text not null default '',
+    started_at timestamptz not null,
+    expires_at timestamptz not null,
+    constraint impersonations_actor_id_fkey foreign key (actor_id) references users(id),
+    constraint impersonations_target_id_fkey foreign key (target_id) references users(id)
+);
+
+create index impersonations_started_at_idx on impersonations (started_at desc);
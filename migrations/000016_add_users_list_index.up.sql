@@ -0,0 +1,2 @@
+-- the keyset pagination of the user listing seeks on its sort key.
+create index users_name_id_idx on users (last_name, first_name, id);
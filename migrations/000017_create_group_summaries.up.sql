@@ -0,0 +1,12 @@
+-- the projection of the member counts of the groups, the student event
+-- handlers refresh it when the students register or move.
+create table group_summaries (
+    group_id uuid primary key references groups(id) on delete cascade,
+    member_count integer not null,
+    refreshed_at timestamptz not null default now()
+);
+
+insert into group_summaries (group_id, member_count)
+select g.id, count(s.user_id)
+from groups g left join students s on s.group_id = g.id
+group by g.id;
@@ -0,0 +1,17 @@
+-- incidents reported by the staff on the status page, see
+-- internal/domain/incident. notes are the updates posted on an incident, the
+-- oldest first. the page shows the ongoing ones and the recently resolved.
+create table incidents (
+    id uuid primary key,
+    title text not null,
+    severity text not null,
+    started_at timestamptz not null,
+    resolved_at timestamptz,
+    notes jsonb not null default '[]',
+    created_by uuid not null,
+    created_at timestamptz not null,
+    updated_at timestamptz not null,
+    constraint incidents_created_by_fkey foreign key (created_by) references users(id)
+);
+
+create index incidents_started_at_idx on incidents (started_at desc);
@@ -0,0 +1,18 @@
+-- whether the user confirmed their email. the invited staff and the
+-- registered students did so while joining, the initial staff created from
+-- the configuration confirms it with a code mailed on login.
+alter table users add column email_verified boolean not null default true;
+
+update users set email_verified = false
+where id in (select user_id from staff_bootstrap_audit where action = 'created');
+
+-- the pending code of a user, see user.EmailVerification. it is dropped once
+-- the email is verified or after too many wrong codes.
+create table email_verifications (
+    user_id uuid primary key,
+    code text not null,
+    attempts smallint not null default 0,
+    expires_at timestamptz not null,
+    resend_at timestamptz not null,
+    constraint email_verifications_user_id_fkey foreign key (user_id) references users(id) on delete cascade
+);
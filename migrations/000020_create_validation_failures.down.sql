@@ -0,0 +1 @@
+drop table validation_failures;
@@ -0,0 +1,11 @@
+-- request fields failing validation counted by day, see pkg/validationstats.
+-- only the field names are stored, never the submitted values.
+create table validation_failures (
+    day date not null,
+    route text not null,
+    field text not null,
+    count bigint not null default 0,
+    primary key (day, route, field)
+);
+
+create index validation_failures_route_day_idx on validation_failures (route, day);
@@ -0,0 +1,9 @@
+-- the migrations embedded by the binaries run against the database, see
+-- pkg/schemaversion. an instance of an older release reads the newer
+-- migrations here to tell whether one of them is breaking.
+create table schema_manifest (
+    version bigint primary key,
+    name text not null,
+    breaking boolean not null default false,
+    recorded_at timestamptz not null default now()
+);
@@ -0,0 +1,3 @@
+drop index if exists users_last_seen_at_idx;
+
+alter table users drop column if exists last_seen_at;
@@ -0,0 +1,6 @@
+-- the time of a recent authenticated request of the user, null until the
+-- first one. it is written at most every 15 minutes, see pkg/lastseen.
+alter table users add column last_seen_at timestamptz;
+
+-- the inactive accounts report seeks the users not seen since a time.
+create index users_last_seen_at_idx on users (last_seen_at nulls first, id);
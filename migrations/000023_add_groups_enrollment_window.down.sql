@@ -0,0 +1,2 @@
+alter table groups drop column if exists enrollment_closes_at;
+alter table groups drop column if exists enrollment_opens_at;
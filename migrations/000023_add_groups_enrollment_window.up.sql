@@ -0,0 +1,5 @@
+-- the window the students may join the group in, by registering or by a
+-- transfer. a null end leaves that side open, the groups are always open by
+-- default.
+alter table groups add column enrollment_opens_at timestamptz;
+alter table groups add column enrollment_closes_at timestamptz;
@@ -0,0 +1,4 @@
+drop index if exists groups_term_id_idx;
+alter table groups drop constraint if exists groups_term_id_fkey;
+alter table groups drop column if exists term_id;
+drop table if exists terms;
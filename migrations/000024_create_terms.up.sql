@@ -0,0 +1,21 @@
+-- academic terms, see internal/domain/term. a term includes its start and
+-- excludes its end, the exclusion constraint backs the overlap check of the
+-- domain against the concurrent writes.
+create table terms (
+    id uuid primary key,
+    code text not null,
+    name text not null,
+    starts_at timestamptz not null,
+    ends_at timestamptz not null,
+    created_at timestamptz not null,
+    updated_at timestamptz not null,
+    constraint terms_code_key unique (code),
+    constraint terms_ends_after_starts check (ends_at > starts_at),
+    constraint terms_no_overlap exclude using gist (tstzrange(starts_at, ends_at) with &&)
+);
+
+-- the term the group studies in, set when it is created or rolled over to
+-- the next term. the groups created before the terms have none.
+alter table groups add column term_id uuid;
+alter table groups add constraint groups_term_id_fkey foreign key (term_id) references terms(id);
+create index groups_term_id_idx on groups (term_id);
@@ -0,0 +1,11 @@
+-- the roles a user holds beside users.role_id, which stays the most
+-- privileged one: a student who accepted a staff invitation with their
+-- account keeps the student role here, see user.LinkStaffAccount.
+create table user_roles (
+    user_id uuid not null,
+    role_id smallint not null,
+    granted_at timestamptz not null,
+    constraint user_roles_pkey primary key (user_id, role_id),
+    constraint user_roles_user_id_fkey foreign key (user_id) references users(id) on delete cascade,
+    constraint user_roles_role_id_fkey foreign key (role_id) references global_roles(id)
+);
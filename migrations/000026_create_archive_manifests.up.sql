@@ -0,0 +1,17 @@
+-- the objects the retention job archived expired rows to before deleting
+-- them, see pkg/retention. an object holds the rows of one table older than
+-- cutoff as gzipped NDJSON.
+create table archive_manifests (
+    id uuid primary key,
+    category text not null,
+    table_name text not null,
+    object_key text not null,
+    row_count bigint not null,
+    size_bytes bigint not null,
+    cutoff timestamptz not null,
+    created_at timestamptz not null,
+    constraint archive_manifests_object_key_key unique (object_key)
+);
+
+create index archive_manifests_created_at_idx on archive_manifests (created_at desc);
+create index archive_manifests_table_name_idx on archive_manifests (table_name, created_at desc);
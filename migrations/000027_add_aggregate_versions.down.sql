@@ -0,0 +1,4 @@
+alter table staff_invitations drop column if exists version;
+alter table announcements drop column if exists version;
+alter table registrations drop column if exists version;
+alter table users drop column if exists version;
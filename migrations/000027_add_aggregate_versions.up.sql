@@ -0,0 +1,7 @@
+-- the version of the aggregates counts their published events, see
+-- event.Recorder. the events of the staff members and the students are
+-- those of their user, they share the version of the users row.
+alter table users add column version integer not null default 0;
+alter table registrations add column version integer not null default 0;
+alter table announcements add column version integer not null default 0;
+alter table staff_invitations add column version integer not null default 0;
@@ -0,0 +1,11 @@
+-- the tokens of the avatar uploads whose object is stored but whose reference
+-- is not committed yet. the garbage collection keeps the objects of the
+-- tokens younger than a day, the upload is refused once its token is gone.
+create table pending_uploads (
+    id uuid primary key,
+    user_id uuid not null references users (id) on delete cascade,
+    s3_key text not null,
+    created_at timestamptz not null default now()
+);
+
+create index pending_uploads_s3_key_idx on pending_uploads (s3_key, created_at);
@@ -0,0 +1,49 @@
+// Package buildinfo exposes the version the binary was built from. The
+// values are injected at build time:
+//
+//	go build -ldflags "\
+//	  -X gitlab.com/ucmsv2/ucms-backend/pkg/buildinfo.Version=1.2.0 \
+//	  -X gitlab.com/ucmsv2/ucms-backend/pkg/buildinfo.Commit=$(git rev-parse HEAD) \
+//	  -X gitlab.com/ucmsv2/ucms-backend/pkg/buildinfo.Date=$(date -u +%Y-%m-%dT%H:%M:%SZ)" ./cmd/api
+package buildinfo
+
+import "runtime/debug"
+
+var (
+	Version = "dev"
+	Commit  = ""
+	Date    = ""
+)
+
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildDate string `json:"build_date"`
+}
+
+// Get returns the injected build info. Without ldflags the commit and date
+// fall back to the VCS stamp of the Go toolchain, or "unknown".
+func Get() Info {
+	info := Info{Version: Version, Commit: Commit, BuildDate: Date}
+	if info.Commit != "" && info.BuildDate != "" {
+		return info
+	}
+
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range bi.Settings {
+			switch {
+			case s.Key == "vcs.revision" && info.Commit == "":
+				info.Commit = s.Value
+			case s.Key == "vcs.time" && info.BuildDate == "":
+				info.BuildDate = s.Value
+			}
+		}
+	}
+	if info.Commit == "" {
+		info.Commit = "unknown"
+	}
+	if info.BuildDate == "" {
+		info.BuildDate = "unknown"
+	}
+	return info
+}
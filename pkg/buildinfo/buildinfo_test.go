@@ -0,0 +1,21 @@
+package buildinfo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGet(t *testing.T) {
+	prevVersion, prevCommit, prevDate := Version, Commit, Date
+	t.Cleanup(func() { Version, Commit, Date = prevVersion, prevCommit, prevDate })
+
+	Version, Commit, Date = "1.4.0", "4b158c7", "2025-08-01T10:00:00Z"
+	assert.Equal(t, Info{Version: "1.4.0", Commit: "4b158c7", BuildDate: "2025-08-01T10:00:00Z"}, Get())
+
+	Version, Commit, Date = "dev", "", ""
+	info := Get()
+	assert.Equal(t, "dev", info.Version)
+	assert.NotEmpty(t, info.Commit)
+	assert.NotEmpty(t, info.BuildDate)
+}
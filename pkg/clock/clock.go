@@ -0,0 +1,60 @@
+// Package clock abstracts the current time and the timers, so that the code
+// depending on them is tested by advancing a Fake clock instead of sleeping.
+package clock
+
+import "time"
+
+type Clock interface {
+	Now() time.Time
+	NewTimer(d time.Duration) Timer
+	After(d time.Duration) <-chan time.Time
+}
+
+// Settable is a Clock that can be moved, the Fake of the tests and the
+// Offset of the dev servers.
+type Settable interface {
+	Clock
+	Advance(d time.Duration)
+	Set(t time.Time)
+}
+
+// Timer is the time.Timer of a Clock.
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+	Reset(d time.Duration) bool
+}
+
+// Real is the Clock of the time package.
+var Real Clock = realClock{}
+
+// Or returns c, or Real if c is nil. The structs taking an optional Clock
+// resolve it with Or.
+func Or(c Clock) Clock {
+	if c == nil {
+		return Real
+	}
+	return c
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) NewTimer(d time.Duration) Timer {
+	return realTimer{time.NewTimer(d)}
+}
+
+func (realClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+type realTimer struct {
+	*time.Timer
+}
+
+func (t realTimer) C() <-chan time.Time {
+	return t.Timer.C
+}
@@ -0,0 +1,105 @@
+package clock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testNow = time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+
+func TestFake_Now(t *testing.T) {
+	f := NewFake(testNow)
+	assert.Equal(t, testNow, f.Now())
+
+	f.Advance(time.Minute)
+	assert.Equal(t, testNow.Add(time.Minute), f.Now())
+
+	f.Set(testNow)
+	assert.Equal(t, testNow, f.Now())
+}
+
+func TestFake_Timer(t *testing.T) {
+	t.Run("fires at the deadline", func(t *testing.T) {
+		f := NewFake(testNow)
+		timer := f.NewTimer(time.Minute)
+
+		f.Advance(59 * time.Second)
+		assertNotFired(t, timer.C())
+		assert.Equal(t, 1, f.Timers())
+
+		f.Advance(time.Second)
+		assertFired(t, timer.C(), testNow.Add(time.Minute))
+		assert.Zero(t, f.Timers())
+	})
+
+	t.Run("stop", func(t *testing.T) {
+		f := NewFake(testNow)
+		timer := f.NewTimer(time.Minute)
+
+		assert.True(t, timer.Stop())
+		assert.False(t, timer.Stop(), "a stopped timer is not active")
+
+		f.Advance(time.Hour)
+		assertNotFired(t, timer.C())
+	})
+
+	t.Run("reset", func(t *testing.T) {
+		f := NewFake(testNow)
+		timer := f.NewTimer(time.Minute)
+
+		assert.True(t, timer.Reset(2*time.Minute))
+		f.Advance(time.Minute)
+		assertNotFired(t, timer.C())
+
+		f.Advance(time.Minute)
+		assertFired(t, timer.C(), testNow.Add(2*time.Minute))
+
+		assert.False(t, timer.Reset(time.Minute), "a fired timer is not active")
+		f.Advance(time.Minute)
+		assertFired(t, timer.C(), testNow.Add(3*time.Minute))
+	})
+
+	t.Run("non positive duration fires at once", func(t *testing.T) {
+		f := NewFake(testNow)
+		assertFired(t, f.After(0), testNow)
+	})
+
+	t.Run("several timers", func(t *testing.T) {
+		f := NewFake(testNow)
+		first := f.After(time.Minute)
+		second := f.After(time.Hour)
+
+		f.Advance(time.Minute)
+		assertFired(t, first, testNow.Add(time.Minute))
+		assertNotFired(t, second)
+		assert.Equal(t, 1, f.Timers())
+	})
+}
+
+func TestOr(t *testing.T) {
+	assert.Equal(t, Real, Or(nil))
+
+	f := NewFake(testNow)
+	assert.Same(t, f, Or(f))
+}
+
+func assertFired(t *testing.T, c <-chan time.Time, want time.Time) {
+	t.Helper()
+	select {
+	case got := <-c:
+		assert.Equal(t, want, got)
+	default:
+		t.Error("timer did not fire")
+	}
+}
+
+func assertNotFired(t *testing.T, c <-chan time.Time) {
+	t.Helper()
+	select {
+	case got := <-c:
+		t.Errorf("timer fired at %s", got)
+	default:
+	}
+}
@@ -0,0 +1,54 @@
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Offset is the Real clock moved by an offset, so that a dev server keeps
+// running while its time is moved from the outside. Its timers are the real
+// ones, moving the clock does not fire them.
+type Offset struct {
+	mu     sync.Mutex
+	offset time.Duration
+}
+
+// NewOffset returns an Offset clock at the real time.
+func NewOffset() *Offset {
+	return &Offset{}
+}
+
+func (o *Offset) Now() time.Time {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return Real.Now().Add(o.offset)
+}
+
+// Advance moves the clock forward by d.
+func (o *Offset) Advance(d time.Duration) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.offset += d
+}
+
+// Set moves the clock to t, from where it keeps running.
+func (o *Offset) Set(t time.Time) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.offset = time.Until(t)
+}
+
+// Reset moves the clock back to the real time.
+func (o *Offset) Reset() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.offset = 0
+}
+
+func (o *Offset) NewTimer(d time.Duration) Timer {
+	return Real.NewTimer(d)
+}
+
+func (o *Offset) After(d time.Duration) <-chan time.Time {
+	return Real.After(d)
+}
@@ -0,0 +1,28 @@
+package clock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	_ Settable = (*Fake)(nil)
+	_ Settable = (*Offset)(nil)
+)
+
+func TestOffset_Now(t *testing.T) {
+	o := NewOffset()
+	assert.WithinDuration(t, time.Now(), o.Now(), time.Second)
+
+	o.Advance(time.Hour)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), o.Now(), time.Second)
+
+	o.Set(testNow)
+	assert.WithinDuration(t, testNow, o.Now(), time.Second)
+
+	before := o.Now()
+	time.Sleep(10 * time.Millisecond)
+	assert.True(t, o.Now().After(before), "the clock keeps running after Set")
+}
@@ -0,0 +1,61 @@
+package ctxs
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+const (
+	CampusKey      = contextKey("campusKey")
+	CampusScopeKey = contextKey("campusScopeKey")
+)
+
+// DefaultCampus is the campus of the deployments that do not configure one,
+// it is also the default of the campus_id columns.
+const DefaultCampus = "main"
+
+var serviceCampus atomic.Pointer[string]
+
+// SetServiceCampus sets the campus of the running service, it is returned by
+// CampusFromCtx for the contexts that do not carry one.
+func SetServiceCampus(id string) {
+	if id == "" {
+		id = DefaultCampus
+	}
+	serviceCampus.Store(&id)
+}
+
+// ServiceCampus returns the campus set with SetServiceCampus, DefaultCampus
+// if it was never set.
+func ServiceCampus() string {
+	if id := serviceCampus.Load(); id != nil {
+		return *id
+	}
+	return DefaultCampus
+}
+
+func WithCampus(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, CampusKey, id)
+}
+
+// CampusFromCtx returns the campus ctx belongs to, the service campus when
+// ctx does not carry one.
+func CampusFromCtx(ctx context.Context) string {
+	if id, ok := ctx.Value(CampusKey).(string); ok && id != "" {
+		return id
+	}
+	return ServiceCampus()
+}
+
+// WithCampusScope restricts the repository reads made with ctx to the rows
+// of the campus. Reads are not scoped unless asked to.
+func WithCampusScope(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, CampusScopeKey, id)
+}
+
+// CampusScopeFromCtx returns the campus the reads made with ctx are
+// restricted to, ok is false when they are not scoped.
+func CampusScopeFromCtx(ctx context.Context) (id string, ok bool) {
+	id, ok = ctx.Value(CampusScopeKey).(string)
+	return id, ok && id != ""
+}
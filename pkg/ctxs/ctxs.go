@@ -24,8 +24,29 @@ const (
 )
 
 type User struct {
-	ID   user.ID
+	ID user.ID
+	// Role is the most privileged of Roles.
 	Role roles.Global
+	// Roles are all the roles of the user, the access checks pass when any
+	// of them does. Use AllRoles, the tokens minted before the role sets
+	// carry Role only.
+	Roles roles.Set
+	// ImpersonatorID is the staff member acting as the user in an
+	// impersonation session, the zero ID outside of one.
+	ImpersonatorID user.ID
+}
+
+// AllRoles returns Roles, Role alone when they are not set.
+func (u User) AllRoles() roles.Set {
+	if len(u.Roles) == 0 {
+		return roles.NewSet(u.Role)
+	}
+	return u.Roles
+}
+
+// IsImpersonated reports whether a staff member is acting as the user.
+func (u User) IsImpersonated() bool {
+	return u.ImpersonatorID != user.ID{}
 }
 
 func WithUser(ctx context.Context, user *User) context.Context {
@@ -46,6 +67,16 @@ func UserFromCtx(ctx context.Context) (*User, error) {
 	return user, nil
 }
 
+// ImpersonatorFromCtx returns the staff member acting as the user of ctx,
+// false outside an impersonation session.
+func ImpersonatorFromCtx(ctx context.Context) (user.ID, bool) {
+	u, ok := ctx.Value(UserKey).(*User)
+	if !ok || u == nil || !u.IsImpersonated() {
+		return user.ID{}, false
+	}
+	return u.ImpersonatorID, true
+}
+
 func (u User) SetSpanAttrs(span trace.Span) {
 	if span == nil {
 		return
@@ -53,5 +84,9 @@ func (u User) SetSpanAttrs(span trace.Span) {
 	span.SetAttributes(
 		attribute.String("user.id", u.ID.String()),
 		attribute.String("user.role", u.Role.String()),
+		attribute.StringSlice("user.roles", u.AllRoles().Strings()),
 	)
+	if u.IsImpersonated() {
+		span.SetAttributes(attribute.String("user.impersonator_id", u.ImpersonatorID.String()))
+	}
 }
@@ -0,0 +1,73 @@
+package ctxs
+
+import (
+	"context"
+	"log/slog"
+)
+
+// Log attribute keys set by LogAttrs.
+const (
+	LogKeyRequestID = "request_id"
+	LogKeyClientIP  = "client_ip"
+	LogKeyLocale    = "locale"
+	LogKeyCampus    = "campus_id"
+)
+
+// LogAttrs returns the request attributes of ctx every log line carries.
+// The request ID and client IP are left out when ctx has none.
+func LogAttrs(ctx context.Context) []slog.Attr {
+	attrs := make([]slog.Attr, 0, 4)
+	if id := RequestIDFromCtx(ctx); id != "" {
+		attrs = append(attrs, slog.String(LogKeyRequestID, id))
+	}
+	if ip := ClientIPFromCtx(ctx); ip != "" {
+		attrs = append(attrs, slog.String(LogKeyClientIP, ip))
+	}
+	attrs = append(attrs,
+		slog.String(LogKeyLocale, LocaleFromCtx(ctx)),
+		slog.String(LogKeyCampus, CampusFromCtx(ctx)),
+	)
+	return attrs
+}
+
+// LogHandler adds LogAttrs to the records logged with a request context.
+// Records that already carry the request ID, e.g. the access log line, are
+// passed on as they are. Under a group the attributes are added to the
+// group.
+type LogHandler struct {
+	next slog.Handler
+}
+
+func NewLogHandler(next slog.Handler) *LogHandler {
+	return &LogHandler{next: next}
+}
+
+func (h *LogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *LogHandler) Handle(ctx context.Context, r slog.Record) error {
+	if RequestIDFromCtx(ctx) == "" || hasAttr(r, LogKeyRequestID) {
+		return h.next.Handle(ctx, r)
+	}
+	r = r.Clone()
+	r.AddAttrs(LogAttrs(ctx)...)
+	return h.next.Handle(ctx, r)
+}
+
+func (h *LogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &LogHandler{next: h.next.WithAttrs(attrs)}
+}
+
+func (h *LogHandler) WithGroup(name string) slog.Handler {
+	return &LogHandler{next: h.next.WithGroup(name)}
+}
+
+func hasAttr(r slog.Record, key string) bool {
+	found := false
+	r.Attrs(func(a slog.Attr) bool {
+		found = a.Key == key
+		return !found
+	})
+	return found
+}
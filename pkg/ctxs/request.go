@@ -0,0 +1,117 @@
+package ctxs
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+const (
+	requestIDKey = contextKey("requestIDKey")
+	localeKey    = contextKey("localeKey")
+	clientIPKey  = contextKey("clientIPKey")
+	routeKey     = contextKey("routeKey")
+)
+
+// RequestIDHeader carries the request ID set by the client or a proxy.
+const RequestIDHeader = "X-Request-ID"
+
+// DefaultLocale is the locale of the contexts that do not carry one.
+const DefaultLocale = "en"
+
+// maxRequestIDLen caps request IDs taken from the request headers.
+const maxRequestIDLen = 64
+
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey, id)
+}
+
+// RequestIDFromCtx returns the request ID of ctx, or "".
+func RequestIDFromCtx(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+func WithLocale(ctx context.Context, locale string) context.Context {
+	return context.WithValue(ctx, localeKey, locale)
+}
+
+// LocaleFromCtx returns the locale of ctx, DefaultLocale if it carries none.
+func LocaleFromCtx(ctx context.Context) string {
+	if locale, ok := ctx.Value(localeKey).(string); ok && locale != "" {
+		return locale
+	}
+	return DefaultLocale
+}
+
+func WithClientIP(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, clientIPKey, ip)
+}
+
+// ClientIPFromCtx returns the IP of the client that sent the request of ctx,
+// or "".
+func ClientIPFromCtx(ctx context.Context) string {
+	ip, _ := ctx.Value(clientIPKey).(string)
+	return ip
+}
+
+// WithRoutePattern returns ctx with the chi pattern of the route its request
+// matches, set by the router before the access checks run.
+func WithRoutePattern(ctx context.Context, pattern string) context.Context {
+	return context.WithValue(ctx, routeKey, pattern)
+}
+
+// RoutePatternFromCtx returns the route pattern of ctx, or "" before the
+// request is matched to a route.
+func RoutePatternFromCtx(ctx context.Context) string {
+	pattern, _ := ctx.Value(routeKey).(string)
+	return pattern
+}
+
+// FromHTTP returns the context of r with the request ID, locale and client
+// IP of r. The request ID is taken from RequestIDHeader when it is valid and
+// generated otherwise. The client IP is the host of r.RemoteAddr, which
+// middleware.RealIP sets from the proxy headers. The campus is not taken
+// from the request, it is set by the service.
+func FromHTTP(r *http.Request) context.Context {
+	ctx := r.Context()
+	ctx = WithRequestID(ctx, requestID(r.Header.Get(RequestIDHeader)))
+	ctx = WithLocale(ctx, parseLocale(r.Header.Get("Accept-Language")))
+	ctx = WithClientIP(ctx, clientIP(r.RemoteAddr))
+	return ctx
+}
+
+func requestID(header string) string {
+	header = strings.TrimSpace(header)
+	if header == "" || len(header) > maxRequestIDLen {
+		return uuid.NewString()
+	}
+	for _, c := range header {
+		if c < '!' || c > '~' {
+			return uuid.NewString()
+		}
+	}
+	return header
+}
+
+// parseLocale returns the primary language of the first language range of
+// an Accept-Language header, e.g. "ru" for "ru-RU,ru;q=0.9,en;q=0.8".
+func parseLocale(header string) string {
+	locale, _, _ := strings.Cut(header, ",")
+	locale, _, _ = strings.Cut(locale, ";")
+	locale, _, _ = strings.Cut(strings.TrimSpace(locale), "-")
+	if locale == "" || locale == "*" {
+		return DefaultLocale
+	}
+	return strings.ToLower(locale)
+}
+
+func clientIP(remoteAddr string) string {
+	if host, _, err := net.SplitHostPort(remoteAddr); err == nil {
+		return host
+	}
+	return remoteAddr
+}
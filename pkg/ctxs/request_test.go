@@ -0,0 +1,143 @@
+package ctxs
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaults(t *testing.T) {
+	ctx := context.Background()
+
+	assert.Empty(t, RequestIDFromCtx(ctx))
+	assert.Equal(t, DefaultLocale, LocaleFromCtx(ctx))
+	assert.Empty(t, ClientIPFromCtx(ctx))
+	assert.Equal(t, ServiceCampus(), CampusFromCtx(ctx))
+
+	assert.Equal(t, []slog.Attr{
+		slog.String(LogKeyLocale, DefaultLocale),
+		slog.String(LogKeyCampus, ServiceCampus()),
+	}, LogAttrs(ctx))
+}
+
+func TestFromHTTP(t *testing.T) {
+	tests := []struct {
+		name       string
+		requestID  string
+		language   string
+		remoteAddr string
+		wantID     string
+		wantLocale string
+		wantIP     string
+	}{
+		{
+			name:       "headers",
+			requestID:  "req-123",
+			language:   "ru-RU,ru;q=0.9,en;q=0.8",
+			remoteAddr: "10.0.0.7:51234",
+			wantID:     "req-123",
+			wantLocale: "ru",
+			wantIP:     "10.0.0.7",
+		},
+		{
+			name:       "ipv6 and upper case language",
+			language:   "KK",
+			remoteAddr: "[2001:db8::1]:443",
+			wantLocale: "kk",
+			wantIP:     "2001:db8::1",
+		},
+		{
+			name:       "address without port",
+			language:   "*",
+			remoteAddr: "203.0.113.9",
+			wantLocale: DefaultLocale,
+			wantIP:     "203.0.113.9",
+		},
+		{
+			name:       "invalid request id",
+			requestID:  "id with spaces",
+			wantLocale: DefaultLocale,
+		},
+		{
+			name:       "too long request id",
+			requestID:  strings.Repeat("a", maxRequestIDLen+1),
+			wantLocale: DefaultLocale,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = tt.remoteAddr
+			if tt.requestID != "" {
+				r.Header.Set(RequestIDHeader, tt.requestID)
+			}
+			if tt.language != "" {
+				r.Header.Set("Accept-Language", tt.language)
+			}
+
+			ctx := FromHTTP(r)
+
+			if tt.wantID != "" {
+				assert.Equal(t, tt.wantID, RequestIDFromCtx(ctx))
+			} else {
+				_, err := uuid.Parse(RequestIDFromCtx(ctx))
+				assert.NoError(t, err, "a request ID is generated")
+			}
+			assert.Equal(t, tt.wantLocale, LocaleFromCtx(ctx))
+			assert.Equal(t, tt.wantIP, ClientIPFromCtx(ctx))
+		})
+	}
+}
+
+func TestLogHandler(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(NewLogHandler(slog.NewTextHandler(&buf, nil)))
+
+	ctx := WithClientIP(WithLocale(WithRequestID(context.Background(), "req-1"), "kk"), "10.0.0.1")
+	logger.InfoContext(ctx, "handled")
+	assert.Contains(t, buf.String(), "request_id=req-1 client_ip=10.0.0.1 locale=kk campus_id="+ServiceCampus())
+
+	buf.Reset()
+	logger.LogAttrs(ctx, slog.LevelInfo, "access", LogAttrs(ctx)...)
+	assert.Equal(t, 1, strings.Count(buf.String(), "request_id="), "records with the attributes are not enriched twice")
+
+	buf.Reset()
+	logger.InfoContext(context.Background(), "background")
+	require.NotEmpty(t, buf.String())
+	assert.NotContains(t, buf.String(), "locale=", "records without a request context are not enriched")
+}
+
+func BenchmarkReads(b *testing.B) {
+	ctx := WithCampus(WithClientIP(WithLocale(WithRequestID(context.Background(), "req-1"), "ru"), "10.0.0.1"), "north")
+
+	b.ReportAllocs()
+
+	for b.Loop() {
+		_ = RequestIDFromCtx(ctx)
+		_ = LocaleFromCtx(ctx)
+		_ = ClientIPFromCtx(ctx)
+		_ = CampusFromCtx(ctx)
+	}
+}
+
+func TestReadsDoNotAllocate(t *testing.T) {
+	ctx := WithCampus(WithClientIP(WithLocale(WithRequestID(context.Background(), "req-1"), "ru"), "10.0.0.1"), "north")
+
+	allocs := testing.AllocsPerRun(100, func() {
+		_ = RequestIDFromCtx(ctx)
+		_ = LocaleFromCtx(ctx)
+		_ = ClientIPFromCtx(ctx)
+		_ = CampusFromCtx(ctx)
+		_ = LocaleFromCtx(context.Background())
+	})
+	assert.Zero(t, allocs)
+}
@@ -0,0 +1,79 @@
+package env
+
+import "slices"
+
+// Capability is a behavior that is convenient in development but dangerous
+// in production. The modes allowing each one are listed in capabilities, a
+// mode check is a lookup there rather than a comparison with a mode.
+type Capability string
+
+const (
+	// CapDebugEndpoints mounts the endpoints that help to test the
+	// application by hand, e.g. GET /dev/registrations/verification-code.
+	CapDebugEndpoints Capability = "debug_endpoints"
+	// CapDevClock makes the clock of the application settable through
+	// POST /v1/dev/clock.
+	CapDevClock Capability = "dev_clock"
+	// CapFakeMail allows the emails to be kept in memory instead of sent.
+	CapFakeMail Capability = "fake_mail"
+	// CapPermissiveCORS allows the requests of the frontend dev servers and
+	// of any origin.
+	CapPermissiveCORS Capability = "permissive_cors"
+	// CapPlainHTTP allows to serve over plain http: the cookies are not
+	// Secure and HSTS is left out unless TLS is terminated by the server.
+	CapPlainHTTP Capability = "plain_http"
+	// CapFastPasswordHash hashes the passwords with the minimal bcrypt cost.
+	CapFastPasswordHash Capability = "fast_password_hash"
+	// CapPlaintextTelemetry keeps the PII of the logs and spans in plaintext.
+	CapPlaintextTelemetry Capability = "plaintext_telemetry"
+	// CapSQLInSpans records the SQL statements in the span attributes.
+	CapSQLInSpans Capability = "sql_in_spans"
+)
+
+// capabilities lists the capabilities each mode allows, a mode missing here
+// allows none.
+var capabilities = map[Mode][]Capability{
+	Test: {
+		CapDebugEndpoints,
+		CapDevClock,
+		CapFakeMail,
+		CapFastPasswordHash,
+		CapSQLInSpans,
+	},
+	Local: {
+		CapDebugEndpoints,
+		CapDevClock,
+		CapFakeMail,
+		CapPlainHTTP,
+		CapPlaintextTelemetry,
+		CapSQLInSpans,
+	},
+	Dev: {
+		CapDebugEndpoints,
+		CapDevClock,
+		CapFakeMail,
+		CapPermissiveCORS,
+		CapPlaintextTelemetry,
+		CapSQLInSpans,
+	},
+	Prod: {},
+}
+
+// Allows reports whether the current mode allows c.
+func Allows(c Capability) bool {
+	return currentMode.Allows(c)
+}
+
+// Allows reports whether mode e allows c.
+func (e Mode) Allows(c Capability) bool {
+	return slices.Contains(capabilities[e], c)
+}
+
+// Capabilities returns the capabilities mode e allows.
+func (e Mode) Capabilities() []Capability {
+	return slices.Clone(capabilities[e])
+}
+
+func (c Capability) String() string {
+	return string(c)
+}
@@ -0,0 +1,64 @@
+package env
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMode_Allows(t *testing.T) {
+	modes := []Mode{Test, Local, Dev, Prod}
+	matrix := map[Capability][]Mode{
+		CapDebugEndpoints:     {Test, Local, Dev},
+		CapDevClock:           {Test, Local, Dev},
+		CapFakeMail:           {Test, Local, Dev},
+		CapPermissiveCORS:     {Dev},
+		CapPlainHTTP:          {Local},
+		CapFastPasswordHash:   {Test},
+		CapPlaintextTelemetry: {Local, Dev},
+		CapSQLInSpans:         {Test, Local, Dev},
+	}
+
+	for c, allowed := range matrix {
+		for _, mode := range modes {
+			want := false
+			for _, m := range allowed {
+				want = want || m == mode
+			}
+			assert.Equal(t, want, mode.Allows(c), "%s allows %s", mode, c)
+		}
+	}
+
+	for _, mode := range modes {
+		assert.Len(t, mode.Capabilities(), countAllowed(matrix, mode), "the matrix misses a capability of %s", mode)
+	}
+}
+
+func TestMode_Allows_Unknown(t *testing.T) {
+	assert.False(t, Mode("staging").Allows(CapDebugEndpoints))
+	assert.False(t, Prod.Allows(Capability("unknown")))
+	assert.Empty(t, Prod.Capabilities())
+}
+
+func TestAllows_CurrentMode(t *testing.T) {
+	prev := Current()
+	t.Cleanup(func() { SetMode(prev) })
+
+	SetMode(Prod)
+	assert.False(t, Allows(CapDebugEndpoints))
+
+	SetMode(Local)
+	assert.True(t, Allows(CapDebugEndpoints))
+}
+
+func countAllowed(matrix map[Capability][]Mode, mode Mode) int {
+	n := 0
+	for _, allowed := range matrix {
+		for _, m := range allowed {
+			if m == mode {
+				n++
+			}
+		}
+	}
+	return n
+}
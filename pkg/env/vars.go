@@ -0,0 +1,252 @@
+package env
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Vars reads typed environment variables. Unset and empty variables get the
+// default, invalid values get the default too and are reported by Require,
+// so that every mistake is listed at once.
+type Vars struct {
+	lookup     func(string) (string, bool)
+	invalid    []InvalidVar
+	deprecated []DeprecatedVar
+}
+
+// DeprecatedVar is a renamed variable that is still read, see Alias.
+type DeprecatedVar struct {
+	Key         string
+	Replacement string
+}
+
+// InvalidVar is a variable whose value could not be parsed.
+type InvalidVar struct {
+	Key   string
+	Value string
+	Err   error
+}
+
+// VarsError lists the variables that are missing, empty or invalid.
+type VarsError struct {
+	Missing []string
+	Empty   []string
+	Invalid []InvalidVar
+}
+
+func (e *VarsError) Error() string {
+	var parts []string
+	if len(e.Missing) > 0 {
+		parts = append(parts, "missing "+strings.Join(e.Missing, ", "))
+	}
+	if len(e.Empty) > 0 {
+		parts = append(parts, "empty "+strings.Join(e.Empty, ", "))
+	}
+	for _, v := range e.Invalid {
+		parts = append(parts, fmt.Sprintf("invalid %s=%q: %v", v.Key, v.Value, v.Err))
+	}
+	return "environment: " + strings.Join(parts, "; ")
+}
+
+// NewVars reads the variables through lookup, e.g. os.LookupEnv.
+func NewVars(lookup func(string) (string, bool)) *Vars {
+	return &Vars{lookup: lookup}
+}
+
+// OS reads the variables of the process environment.
+func OS() *Vars {
+	return NewVars(os.LookupEnv)
+}
+
+func (v *Vars) value(key string) (string, bool) {
+	value, ok := v.lookup(key)
+	if !ok || strings.TrimSpace(value) == "" {
+		return "", false
+	}
+	return value, true
+}
+
+func (v *Vars) addInvalid(key, value string, err error) {
+	v.invalid = append(v.invalid, InvalidVar{Key: key, Value: value, Err: err})
+}
+
+func (v *Vars) GetString(key, defaultValue string) string {
+	if value, ok := v.value(key); ok {
+		return value
+	}
+	return defaultValue
+}
+
+// GetSecret reads key like GetString, or else the file named by key+"_FILE",
+// e.g. a Docker secret mounted under /run/secrets. The trailing newline of
+// the file is dropped. Setting both is reported by Require, key is used then.
+func (v *Vars) GetSecret(key, defaultValue string) string {
+	fileKey := key + "_FILE"
+	path, fromFile := v.value(fileKey)
+	if value, ok := v.value(key); ok {
+		if fromFile {
+			v.addInvalid(fileKey, path, fmt.Errorf("%s is set as well, set only one of them", key))
+		}
+		return value
+	}
+	if !fromFile {
+		return defaultValue
+	}
+	content, err := os.ReadFile(strings.TrimSpace(path))
+	if err != nil {
+		v.addInvalid(fileKey, path, fmt.Errorf("read secret file: %w", err))
+		return defaultValue
+	}
+	if value := strings.TrimRight(string(content), "\r\n"); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+// GetText reads a value dst parses, e.g. a urlx.URL. dst keeps its value
+// when key is unset or invalid.
+func (v *Vars) GetText(key string, dst encoding.TextUnmarshaler) {
+	value, ok := v.value(key)
+	if !ok {
+		return
+	}
+	if err := dst.UnmarshalText([]byte(strings.TrimSpace(value))); err != nil {
+		v.addInvalid(key, value, err)
+	}
+}
+
+// Alias returns the name to read key under: key itself, or deprecated, its
+// former name, when only that one is set. The deprecated names read are
+// listed by Deprecated.
+func (v *Vars) Alias(key, deprecated string) string {
+	if _, ok := v.value(key); ok {
+		return key
+	}
+	if _, ok := v.value(deprecated); ok {
+		v.deprecated = append(v.deprecated, DeprecatedVar{Key: deprecated, Replacement: key})
+		return deprecated
+	}
+	return key
+}
+
+// Deprecated lists the deprecated names Alias returned, so that a warning
+// asks to rename them.
+func (v *Vars) Deprecated() []DeprecatedVar {
+	return v.deprecated
+}
+
+func (v *Vars) GetInt(key string, defaultValue int) int {
+	value, ok := v.value(key)
+	if !ok {
+		return defaultValue
+	}
+	i, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil {
+		v.addInvalid(key, value, errors.New("expected an integer"))
+		return defaultValue
+	}
+	return i
+}
+
+// GetBool accepts the values of strconv.ParseBool: 1, t, true, 0, f, false
+// and their upper case forms.
+func (v *Vars) GetBool(key string, defaultValue bool) bool {
+	value, ok := v.value(key)
+	if !ok {
+		return defaultValue
+	}
+	b, err := strconv.ParseBool(strings.TrimSpace(value))
+	if err != nil {
+		v.addInvalid(key, value, errors.New("expected true or false"))
+		return defaultValue
+	}
+	return b
+}
+
+// GetDuration accepts the time.ParseDuration format, e.g. 90s or 1h30m.
+func (v *Vars) GetDuration(key string, defaultValue time.Duration) time.Duration {
+	value, ok := v.value(key)
+	if !ok {
+		return defaultValue
+	}
+	d, err := time.ParseDuration(strings.TrimSpace(value))
+	if err != nil {
+		v.addInvalid(key, value, errors.New("expected a duration like 30s or 1h30m"))
+		return defaultValue
+	}
+	return d
+}
+
+// GetMillis reads a duration given as a non negative number of milliseconds.
+func (v *Vars) GetMillis(key string, defaultValue time.Duration) time.Duration {
+	value, ok := v.value(key)
+	if !ok {
+		return defaultValue
+	}
+	ms, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+	if err != nil || ms < 0 {
+		v.addInvalid(key, value, errors.New("expected a number of milliseconds"))
+		return defaultValue
+	}
+	return time.Duration(ms) * time.Millisecond
+}
+
+// GetStringSlice reads a comma separated list, the items are trimmed and
+// empty items dropped.
+func (v *Vars) GetStringSlice(key string, defaultValue []string) []string {
+	value, ok := v.value(key)
+	if !ok {
+		return defaultValue
+	}
+	var items []string
+	for item := range strings.SplitSeq(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	if len(items) == 0 {
+		return defaultValue
+	}
+	return items
+}
+
+// Require checks that the keys are set and not empty. The returned
+// *VarsError lists them together with the invalid values read so far, call
+// it after reading the variables.
+func (v *Vars) Require(keys ...string) error {
+	var verr VarsError
+	for _, key := range keys {
+		value, ok := v.lookup(key)
+		switch {
+		case !ok:
+			verr.Missing = append(verr.Missing, key)
+		case strings.TrimSpace(value) == "":
+			verr.Empty = append(verr.Empty, key)
+		}
+	}
+	verr.Invalid = append(verr.Invalid, v.invalid...)
+
+	if len(verr.Missing) == 0 && len(verr.Empty) == 0 && len(verr.Invalid) == 0 {
+		return nil
+	}
+	return &verr
+}
+
+// GetMode reads one of the modes, local, test, dev or prod.
+func (v *Vars) GetMode(key string, defaultValue Mode) Mode {
+	value, ok := v.value(key)
+	if !ok {
+		return defaultValue
+	}
+	mode := Mode(strings.TrimSpace(value))
+	if !mode.Validate() {
+		v.addInvalid(key, value, errors.New("expected local, test, dev or prod"))
+		return defaultValue
+	}
+	return mode
+}
@@ -0,0 +1,255 @@
+package env
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func lookupMap(m map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		v, ok := m[key]
+		return v, ok
+	}
+}
+
+func TestVars_GetDuration(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    time.Duration
+		invalid bool
+	}{
+		{name: "valid", value: "90s", want: 90 * time.Second},
+		{name: "compound", value: "1h30m", want: 90 * time.Minute},
+		{name: "surrounding whitespace", value: " 5m ", want: 5 * time.Minute},
+		{name: "zero", value: "0", want: 0},
+		{name: "empty", value: "", want: time.Minute},
+		{name: "missing unit", value: "30", want: time.Minute, invalid: true},
+		{name: "unknown unit", value: "5x", want: time.Minute, invalid: true},
+		{name: "days are not supported", value: "1d", want: time.Minute, invalid: true},
+		{name: "garbage", value: "soon", want: time.Minute, invalid: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vars := NewVars(lookupMap(map[string]string{"TTL": tt.value}))
+
+			assert.Equal(t, tt.want, vars.GetDuration("TTL", time.Minute))
+
+			err := vars.Require()
+			if !tt.invalid {
+				assert.NoError(t, err)
+				return
+			}
+			var verr *VarsError
+			require.ErrorAs(t, err, &verr)
+			require.Len(t, verr.Invalid, 1)
+			assert.Equal(t, "TTL", verr.Invalid[0].Key)
+			assert.Equal(t, tt.value, verr.Invalid[0].Value)
+		})
+	}
+}
+
+func TestVars_MissingAndEmpty(t *testing.T) {
+	vars := NewVars(lookupMap(map[string]string{
+		"EMPTY": "",
+		"BLANK": "  ",
+		"SET":   "value",
+	}))
+
+	assert.Equal(t, "default", vars.GetString("MISSING", "default"))
+	assert.Equal(t, "default", vars.GetString("EMPTY", "default"), "empty variables get the default")
+	assert.Equal(t, "default", vars.GetString("BLANK", "default"))
+	assert.Equal(t, "value", vars.GetString("SET", "default"))
+
+	err := vars.Require("MISSING", "EMPTY", "BLANK", "SET", "ALSO_MISSING")
+	var verr *VarsError
+	require.ErrorAs(t, err, &verr)
+	assert.Equal(t, []string{"MISSING", "ALSO_MISSING"}, verr.Missing)
+	assert.Equal(t, []string{"EMPTY", "BLANK"}, verr.Empty)
+	assert.Empty(t, verr.Invalid)
+	assert.EqualError(t, err, "environment: missing MISSING, ALSO_MISSING; empty EMPTY, BLANK")
+
+	assert.NoError(t, vars.Require("SET"))
+}
+
+func TestVars_RequireListsEverything(t *testing.T) {
+	vars := NewVars(lookupMap(map[string]string{
+		"PORT":    "http",
+		"ENABLED": "yes",
+		"TIMEOUT": "5",
+		"MODE":    "staging",
+	}))
+
+	assert.Equal(t, 8080, vars.GetInt("PORT", 8080))
+	assert.True(t, vars.GetBool("ENABLED", true))
+	assert.Equal(t, time.Second, vars.GetDuration("TIMEOUT", time.Second))
+	assert.Equal(t, Dev, vars.GetMode("MODE", Dev))
+
+	err := vars.Require("PG_DSN")
+	var verr *VarsError
+	require.ErrorAs(t, err, &verr)
+	assert.Equal(t, []string{"PG_DSN"}, verr.Missing)
+	keys := make([]string, len(verr.Invalid))
+	for i, v := range verr.Invalid {
+		keys[i] = v.Key
+	}
+	assert.Equal(t, []string{"PORT", "ENABLED", "TIMEOUT", "MODE"}, keys)
+	assert.Contains(t, err.Error(), `invalid TIMEOUT="5": expected a duration`)
+}
+
+func TestVars_GetStringSlice(t *testing.T) {
+	def := []string{"http://localhost:3000"}
+	tests := []struct {
+		name  string
+		value *string
+		want  []string
+	}{
+		{name: "missing", want: def},
+		{name: "empty", value: ptr(""), want: def},
+		{name: "single", value: ptr("https://ucms.kz"), want: []string{"https://ucms.kz"}},
+		{name: "whitespace around items", value: ptr(" https://a.kz ,https://b.kz,\thttps://c.kz "), want: []string{"https://a.kz", "https://b.kz", "https://c.kz"}},
+		{name: "empty items dropped", value: ptr("a,, ,b,"), want: []string{"a", "b"}},
+		{name: "only separators", value: ptr(" , ,"), want: def},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := map[string]string{}
+			if tt.value != nil {
+				m["ORIGINS"] = *tt.value
+			}
+			assert.Equal(t, tt.want, NewVars(lookupMap(m)).GetStringSlice("ORIGINS", def))
+		})
+	}
+}
+
+func TestVars_GetBoolAndMillis(t *testing.T) {
+	vars := NewVars(lookupMap(map[string]string{
+		"A":   "true",
+		"B":   "0",
+		"C":   "TRUE",
+		"MS":  "250",
+		"NEG": "-1",
+	}))
+
+	assert.True(t, vars.GetBool("A", false))
+	assert.False(t, vars.GetBool("B", true))
+	assert.True(t, vars.GetBool("C", false))
+	assert.Equal(t, 250*time.Millisecond, vars.GetMillis("MS", time.Second))
+	assert.Equal(t, time.Second, vars.GetMillis("NEG", time.Second))
+
+	var verr *VarsError
+	require.True(t, errors.As(vars.Require(), &verr))
+	require.Len(t, verr.Invalid, 1)
+	assert.Equal(t, "NEG", verr.Invalid[0].Key)
+}
+
+func TestVars_GetSecret(t *testing.T) {
+	dir := t.TempDir()
+	secretFile := filepath.Join(dir, "secret")
+	require.NoError(t, os.WriteFile(secretFile, []byte("from-file\n"), 0o600))
+	emptyFile := filepath.Join(dir, "empty")
+	require.NoError(t, os.WriteFile(emptyFile, []byte("\n"), 0o600))
+
+	tests := []struct {
+		name    string
+		vars    map[string]string
+		want    string
+		invalid bool
+	}{
+		{name: "missing", vars: map[string]string{}, want: "default"},
+		{name: "variable", vars: map[string]string{"SECRET": "from-env"}, want: "from-env"},
+		{name: "file", vars: map[string]string{"SECRET_FILE": secretFile}, want: "from-file"},
+		{name: "empty variable falls back to the file", vars: map[string]string{"SECRET": "", "SECRET_FILE": secretFile}, want: "from-file"},
+		{name: "empty file", vars: map[string]string{"SECRET_FILE": emptyFile}, want: "default"},
+		{name: "unreadable file", vars: map[string]string{"SECRET_FILE": filepath.Join(dir, "missing")}, want: "default", invalid: true},
+		{name: "both set", vars: map[string]string{"SECRET": "from-env", "SECRET_FILE": secretFile}, want: "from-env", invalid: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vars := NewVars(lookupMap(tt.vars))
+			assert.Equal(t, tt.want, vars.GetSecret("SECRET", "default"))
+
+			err := vars.Require()
+			if !tt.invalid {
+				assert.NoError(t, err)
+				return
+			}
+			var verr *VarsError
+			require.ErrorAs(t, err, &verr)
+			require.Len(t, verr.Invalid, 1)
+			assert.Equal(t, "SECRET_FILE", verr.Invalid[0].Key)
+		})
+	}
+}
+
+// upper parses text by upper casing it, it refuses digits.
+type upper string
+
+func (u *upper) UnmarshalText(text []byte) error {
+	if strings.ContainsAny(string(text), "0123456789") {
+		return errors.New("expected letters")
+	}
+	*u = upper(strings.ToUpper(string(text)))
+	return nil
+}
+
+func TestVars_GetText(t *testing.T) {
+	vars := NewVars(lookupMap(map[string]string{
+		"VALID":   " abc ",
+		"INVALID": "a1",
+		"EMPTY":   "",
+	}))
+
+	valid, invalid, empty, missing := upper("default"), upper("default"), upper("default"), upper("default")
+	vars.GetText("VALID", &valid)
+	vars.GetText("INVALID", &invalid)
+	vars.GetText("EMPTY", &empty)
+	vars.GetText("MISSING", &missing)
+
+	assert.Equal(t, upper("ABC"), valid)
+	assert.Equal(t, upper("default"), invalid)
+	assert.Equal(t, upper("default"), empty)
+	assert.Equal(t, upper("default"), missing)
+
+	var verr *VarsError
+	require.ErrorAs(t, vars.Require(), &verr)
+	require.Len(t, verr.Invalid, 1)
+	assert.Equal(t, "INVALID", verr.Invalid[0].Key)
+}
+
+func TestVars_Alias(t *testing.T) {
+	tests := []struct {
+		name           string
+		vars           map[string]string
+		want           string
+		wantDeprecated []DeprecatedVar
+	}{
+		{name: "neither set", vars: map[string]string{}, want: "NEW"},
+		{name: "new name", vars: map[string]string{"NEW": "a"}, want: "NEW"},
+		{name: "both set", vars: map[string]string{"NEW": "a", "OLD": "b"}, want: "NEW"},
+		{name: "empty new name", vars: map[string]string{"NEW": "", "OLD": "b"}, want: "OLD", wantDeprecated: []DeprecatedVar{{Key: "OLD", Replacement: "NEW"}}},
+		{name: "deprecated name", vars: map[string]string{"OLD": "b"}, want: "OLD", wantDeprecated: []DeprecatedVar{{Key: "OLD", Replacement: "NEW"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vars := NewVars(lookupMap(tt.vars))
+			assert.Equal(t, tt.want, vars.Alias("NEW", "OLD"))
+			assert.Equal(t, tt.wantDeprecated, vars.Deprecated())
+		})
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
@@ -0,0 +1,177 @@
+// Package errorinbox aggregates server errors and panics by signature so
+// they can be reviewed and triaged instead of vanishing into the logs.
+//
+// A signature is the hash of the error type, its message and the top stack
+// frames. Occurrences are counted in memory and written in batches, an error
+// storm costs one upsert per signature per flush interval.
+package errorinbox
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+	"time"
+
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx"
+)
+
+// Status of an aggregated error. Resolved errors are reopened when they
+// happen again, muted ones stay muted.
+type Status string
+
+const (
+	StatusOpen     Status = "open"
+	StatusResolved Status = "resolved"
+	StatusMuted    Status = "muted"
+)
+
+func (s Status) IsValid() bool {
+	switch s {
+	case StatusOpen, StatusResolved, StatusMuted:
+		return true
+	default:
+		return false
+	}
+}
+
+const (
+	maxFrames     = 5
+	maxMessageLen = 1000
+)
+
+// ErrorEvent is an aggregated error. Request bodies are never recorded, only
+// the route pattern and the trace ID of one of the requests.
+type ErrorEvent struct {
+	Signature string
+	Type      string
+	Message   string
+	// Frames are the top function names, without file and line so a
+	// signature survives unrelated edits.
+	Frames        []string
+	Route         string
+	CorrelationID string
+	Count         int64
+	Status        Status
+	FirstSeen     time.Time
+	LastSeen      time.Time
+}
+
+// Store persists aggregated errors, Count of the events is added to the
+// stored count.
+type Store interface {
+	UpsertErrorEvents(ctx context.Context, events []ErrorEvent) error
+}
+
+// ListParams filters the listed errors, the most recent come first.
+type ListParams struct {
+	Statuses []Status
+	Limit    int
+}
+
+// newEvent builds the event of one occurrence from the stack pcs. The stack
+// of a panic is taken in the deferred recover, fromPanic drops the frames
+// above runtime.gopanic.
+func newEvent(typ, message, route, correlationID string, pcs []uintptr, fromPanic bool, now time.Time) ErrorEvent {
+	message = truncate(otelx.Redact("error.message", message), maxMessageLen)
+	frames := topFrames(pcs, fromPanic)
+
+	return ErrorEvent{
+		Signature:     Signature(typ, message, frames),
+		Type:          typ,
+		Message:       message,
+		Frames:        frames,
+		Route:         route,
+		CorrelationID: correlationID,
+		Count:         1,
+		Status:        StatusOpen,
+		FirstSeen:     now,
+		LastSeen:      now,
+	}
+}
+
+// Signature identifies the errors with the same type, message and frames.
+func Signature(typ, message string, frames []string) string {
+	h := sha256.New()
+	h.Write([]byte(typ))
+	h.Write([]byte{0})
+	h.Write([]byte(message))
+	for _, f := range frames {
+		h.Write([]byte{0})
+		h.Write([]byte(f))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// errorType returns the type of the innermost error, wrappers such as
+// errorx.Wrap would otherwise give every error the same type.
+func errorType(err error) string {
+	for {
+		next := errors.Unwrap(err)
+		if next == nil {
+			return fmt.Sprintf("%T", err)
+		}
+		err = next
+	}
+}
+
+// panicType returns the type of a recovered value.
+func panicType(v any) string {
+	if err, ok := v.(error); ok {
+		return errorType(err)
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+func panicMessage(v any) string {
+	if err, ok := v.(error); ok {
+		return err.Error()
+	}
+	return fmt.Sprint(v)
+}
+
+// skippedFramePrefixes are the runtime and the error plumbing, they are the
+// same for every error.
+var skippedFramePrefixes = []string{
+	"runtime.",
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorinbox.(*Recorder).",
+	"gitlab.com/ucmsv2/ucms-backend/pkg/httpx.",
+}
+
+func topFrames(pcs []uintptr, fromPanic bool) []string {
+	frames := runtime.CallersFrames(pcs)
+	out := make([]string, 0, maxFrames)
+	panicked := !fromPanic
+	for len(out) < maxFrames {
+		frame, more := frames.Next()
+		switch {
+		case !panicked:
+			panicked = frame.Function == "runtime.gopanic"
+		case frame.Function != "" && !skippedFrame(frame.Function):
+			out = append(out, frame.Function)
+		}
+		if !more {
+			break
+		}
+	}
+	return out
+}
+
+func skippedFrame(function string) bool {
+	for _, prefix := range skippedFramePrefixes {
+		if strings.HasPrefix(function, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return strings.ToValidUTF8(s[:n], "") + "..."
+}
@@ -0,0 +1,160 @@
+package errorinbox
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/contrib/bridges/otelslog"
+
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx"
+)
+
+var logger = otelslog.NewLogger("ucms/pkg/errorinbox")
+
+const (
+	DefaultFlushInterval = 5 * time.Second
+	// DefaultMaxPending bounds the signatures buffered between two flushes,
+	// occurrences of new signatures past it are dropped.
+	DefaultMaxPending = 100
+
+	flushTimeout = 10 * time.Second
+	maxStack     = 32
+)
+
+// Recorder buffers errors in memory and writes them to the Store in the
+// background, recording never blocks on the database.
+type Recorder struct {
+	store         Store
+	logger        *slog.Logger
+	flushInterval time.Duration
+	maxPending    int
+	clock         clock.Clock
+
+	mu      sync.Mutex
+	pending map[string]*ErrorEvent
+	dropped int
+}
+
+type RecorderArgs struct {
+	Store         Store
+	Logger        *slog.Logger
+	FlushInterval time.Duration
+	MaxPending    int
+	// Clock defaults to clock.Real.
+	Clock clock.Clock
+}
+
+// NewRecorder creates a Recorder, Run must be started to write the errors.
+//
+//	WARNING; panics if store is nil
+func NewRecorder(args RecorderArgs) *Recorder {
+	if args.Store == nil {
+		panic("store is required")
+	}
+	if args.Logger == nil {
+		args.Logger = logger
+	}
+	if args.FlushInterval <= 0 {
+		args.FlushInterval = DefaultFlushInterval
+	}
+	if args.MaxPending <= 0 {
+		args.MaxPending = DefaultMaxPending
+	}
+
+	return &Recorder{
+		store:         args.Store,
+		logger:        args.Logger,
+		flushInterval: args.FlushInterval,
+		maxPending:    args.MaxPending,
+		clock:         clock.Or(args.Clock),
+		pending:       make(map[string]*ErrorEvent),
+	}
+}
+
+// RecordError records an error answered with a 5xx status on route. The
+// stack of the caller is part of the signature.
+func (r *Recorder) RecordError(ctx context.Context, route string, err error) {
+	if err == nil {
+		return
+	}
+	pcs := make([]uintptr, maxStack)
+	n := runtime.Callers(2, pcs)
+	r.add(newEvent(errorType(err), err.Error(), route, otelx.CorrelationID(ctx), pcs[:n], false, r.clock.Now()))
+}
+
+// RecordPanic records a value recovered on route, it must be called from the
+// deferred function that recovered it so the stack still holds the panic.
+func (r *Recorder) RecordPanic(ctx context.Context, route string, v any) {
+	pcs := make([]uintptr, maxStack)
+	n := runtime.Callers(2, pcs)
+	r.add(newEvent(panicType(v), panicMessage(v), route, otelx.CorrelationID(ctx), pcs[:n], true, r.clock.Now()))
+}
+
+func (r *Recorder) add(e ErrorEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if p, ok := r.pending[e.Signature]; ok {
+		p.Count++
+		p.LastSeen = e.LastSeen
+		return
+	}
+	if len(r.pending) >= r.maxPending {
+		r.dropped++
+		return
+	}
+	r.pending[e.Signature] = &e
+}
+
+// Run flushes the buffered errors every flush interval until ctx is done,
+// then flushes one last time.
+func (r *Recorder) Run(ctx context.Context) {
+	timer := r.clock.NewTimer(r.flushInterval)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-timer.C():
+			r.flushLogged(ctx)
+			timer.Reset(r.flushInterval)
+		case <-ctx.Done():
+			r.flushLogged(context.WithoutCancel(ctx))
+			return
+		}
+	}
+}
+
+func (r *Recorder) flushLogged(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, flushTimeout)
+	defer cancel()
+
+	if err := r.Flush(ctx); err != nil {
+		r.logger.ErrorContext(ctx, "failed to flush error inbox", slog.String("error", err.Error()))
+	}
+}
+
+// Flush writes the buffered errors. They are dropped if the write fails, so a
+// database outage does not grow the buffer.
+func (r *Recorder) Flush(ctx context.Context) error {
+	r.mu.Lock()
+	pending, dropped := r.pending, r.dropped
+	r.pending, r.dropped = make(map[string]*ErrorEvent, len(pending)), 0
+	r.mu.Unlock()
+
+	if dropped > 0 {
+		r.logger.WarnContext(ctx, "error inbox is full, errors were not recorded", slog.Int("dropped", dropped))
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	events := make([]ErrorEvent, 0, len(pending))
+	for _, e := range pending {
+		events = append(events, *e)
+	}
+	return r.store.UpsertErrorEvents(ctx, events)
+}
@@ -0,0 +1,188 @@
+package errorinbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
+)
+
+type fakeStore struct {
+	mu     sync.Mutex
+	events []ErrorEvent
+	err    error
+}
+
+func (s *fakeStore) UpsertErrorEvents(_ context.Context, events []ErrorEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return s.err
+	}
+	s.events = append(s.events, events...)
+	return nil
+}
+
+func (s *fakeStore) bySignature() map[string]ErrorEvent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m := make(map[string]ErrorEvent, len(s.events))
+	for _, e := range s.events {
+		m[e.Signature] = e
+	}
+	return m
+}
+
+type wrappedError struct{ err error }
+
+func (e wrappedError) Error() string { return "wrapped: " + e.err.Error() }
+func (e wrappedError) Unwrap() error { return e.err }
+
+var errDatabase = errors.New("connection refused")
+
+func failingHandler(rec *Recorder) {
+	rec.RecordError(context.Background(), "GET /v1/users", wrappedError{errDatabase})
+}
+
+func TestRecorder_AggregatesBySignature(t *testing.T) {
+	store := &fakeStore{}
+	now := time.Date(2025, 8, 1, 10, 0, 0, 0, time.UTC)
+	clk := clock.NewFake(now)
+	rec := NewRecorder(RecorderArgs{Store: store, Clock: clk})
+
+	failingHandler(rec)
+	clk.Advance(time.Minute)
+	failingHandler(rec)
+	rec.RecordError(context.Background(), "GET /v1/users", errors.New("another error"))
+	rec.RecordError(context.Background(), "GET /v1/users", nil)
+
+	require.NoError(t, rec.Flush(t.Context()))
+	events := store.bySignature()
+	require.Len(t, events, 2)
+
+	var e ErrorEvent
+	for _, ev := range events {
+		if ev.Count == 2 {
+			e = ev
+		}
+	}
+	assert.Equal(t, "*errors.errorString", e.Type, "the innermost error type is recorded")
+	assert.Equal(t, "wrapped: connection refused", e.Message)
+	assert.Equal(t, "GET /v1/users", e.Route)
+	assert.Equal(t, StatusOpen, e.Status)
+	assert.Equal(t, now, e.FirstSeen)
+	assert.Equal(t, clk.Now(), e.LastSeen)
+	require.NotEmpty(t, e.Frames)
+	assert.Equal(t, "gitlab.com/ucmsv2/ucms-backend/pkg/errorinbox.failingHandler", e.Frames[0])
+	assert.Equal(t, Signature(e.Type, e.Message, e.Frames), e.Signature)
+
+	require.NoError(t, rec.Flush(t.Context()))
+	assert.Len(t, store.events, 2, "flushed errors are not written again")
+}
+
+func panickingHandler() {
+	var m map[string]int
+	m["boom"]++
+}
+
+func TestRecorder_RecordPanic(t *testing.T) {
+	store := &fakeStore{}
+	rec := NewRecorder(RecorderArgs{Store: store})
+
+	for range 2 {
+		func() {
+			defer func() {
+				rec.RecordPanic(context.Background(), "POST /v1/users", recover())
+			}()
+			panickingHandler()
+		}()
+	}
+
+	require.NoError(t, rec.Flush(t.Context()))
+	require.Len(t, store.events, 1)
+	e := store.events[0]
+	assert.Equal(t, int64(2), e.Count)
+	assert.Equal(t, "runtime.plainError", e.Type)
+	assert.Equal(t, "assignment to entry in nil map", e.Message)
+	require.NotEmpty(t, e.Frames)
+	assert.Equal(t, "gitlab.com/ucmsv2/ucms-backend/pkg/errorinbox.panickingHandler", e.Frames[0],
+		"frames start at the function that panicked")
+}
+
+func TestRecorder_BoundedAndDropsOnFailure(t *testing.T) {
+	store := &fakeStore{err: errors.New("database is down")}
+	rec := NewRecorder(RecorderArgs{Store: store, MaxPending: 2})
+
+	for i := range 5 {
+		rec.RecordError(context.Background(), "GET /v1/users", fmt.Errorf("error %d", i))
+	}
+	assert.Len(t, rec.pending, 2, "new signatures past MaxPending are dropped")
+	assert.Equal(t, 3, rec.dropped)
+
+	require.Error(t, rec.Flush(t.Context()))
+	assert.Empty(t, rec.pending, "errors are dropped when the write fails")
+	assert.Zero(t, rec.dropped)
+}
+
+func TestRecorder_RunFlushesOnStop(t *testing.T) {
+	store := &fakeStore{}
+	rec := NewRecorder(RecorderArgs{Store: store, FlushInterval: time.Hour})
+
+	ctx, cancel := context.WithCancel(t.Context())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		rec.Run(ctx)
+	}()
+
+	failingHandler(rec)
+	cancel()
+	<-done
+
+	assert.Len(t, store.bySignature(), 1)
+}
+
+func TestRecorder_RunFlushesEveryInterval(t *testing.T) {
+	store := &fakeStore{}
+	clk := clock.NewFake(time.Date(2025, 8, 1, 10, 0, 0, 0, time.UTC))
+	rec := NewRecorder(RecorderArgs{Store: store, FlushInterval: time.Minute, Clock: clk})
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+	go rec.Run(ctx)
+
+	failingHandler(rec)
+	require.Eventually(t, func() bool { return clk.Timers() == 1 }, time.Second, time.Millisecond)
+	assert.Empty(t, store.bySignature(), "nothing is written before the interval")
+
+	clk.Advance(time.Minute)
+	require.Eventually(t, func() bool { return len(store.bySignature()) == 1 }, time.Second, time.Millisecond)
+
+	rec.RecordError(t.Context(), "GET /v1/groups", errors.New("another error"))
+	require.Eventually(t, func() bool { return clk.Timers() == 1 }, time.Second, time.Millisecond, "the timer is armed again")
+	clk.Advance(time.Minute)
+	require.Eventually(t, func() bool { return len(store.bySignature()) == 2 }, time.Second, time.Millisecond)
+}
+
+func TestRecordError_RedactsAndTruncatesMessage(t *testing.T) {
+	store := &fakeStore{}
+	rec := NewRecorder(RecorderArgs{Store: store})
+
+	rec.RecordError(context.Background(), "", errors.New("john.doe@example.com"))
+	rec.RecordError(context.Background(), "", errors.New(string(make([]byte, 2*maxMessageLen))))
+	require.NoError(t, rec.Flush(t.Context()))
+
+	messages := map[int]string{}
+	for _, e := range store.events {
+		messages[len(e.Message)] = e.Message
+	}
+	assert.Contains(t, messages, len("j***@example.com"))
+	assert.Contains(t, messages, maxMessageLen+len("..."))
+}
@@ -0,0 +1,51 @@
+package errorx
+
+import "errors"
+
+// NotFound returns a not found error with the message key, i18nx.KeyNotFound
+// when key is empty. op is recorded as the internal cause.
+func NotFound(key, op string) *I18nError {
+	return withKeyAndOp(NewNotFound(), key, op)
+}
+
+// Conflict returns a conflict error with the message key, i18nx.KeyConflict
+// when key is empty.
+func Conflict(key, op string) *I18nError {
+	return withKeyAndOp(NewConflict(), key, op)
+}
+
+// Invalid returns an invalid request error with the message key,
+// i18nx.KeyInvalid when key is empty.
+func Invalid(key, op string) *I18nError {
+	return withKeyAndOp(NewInvalidRequest(), key, op)
+}
+
+// Forbidden returns a forbidden error with the message key,
+// i18nx.KeyForbidden when key is empty.
+func Forbidden(key, op string) *I18nError {
+	return withKeyAndOp(NewForbidden(), key, op)
+}
+
+func withKeyAndOp(e *I18nError, key, op string) *I18nError {
+	if key != "" {
+		e = e.WithKey(key)
+	}
+	if op != "" {
+		e = e.WithOp(op)
+	}
+	return e
+}
+
+// CodeOf returns the code of the outermost I18nError in the chain of err, or
+// CodeInternal when there is none.
+func CodeOf(err error) Code {
+	var i18nErr *I18nError
+	if errors.As(err, &i18nErr) {
+		return i18nErr.Code
+	}
+	var i18nErrs I18nErrors
+	if errors.As(err, &i18nErrs) {
+		return i18nErrs.Code()
+	}
+	return CodeInternal
+}
@@ -0,0 +1,64 @@
+package errorx
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"gitlab.com/ucmsv2/ucms-backend/pkg/i18nx"
+)
+
+func TestClassificationSurvivesWrapping(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    *I18nError
+		code   Code
+		status int
+		key    string
+	}{
+		{name: "not found", err: NotFound("", "repo.Get"), code: CodeNotFound, status: http.StatusNotFound, key: i18nx.KeyNotFound},
+		{name: "not found with key", err: NotFound(i18nx.KeyNotFoundOrDeleted, "repo.Get"), code: CodeNotFound, status: http.StatusNotFound, key: i18nx.KeyNotFoundOrDeleted},
+		{name: "conflict", err: Conflict("", "repo.Save"), code: CodeConflict, status: http.StatusConflict, key: i18nx.KeyConflict},
+		{name: "invalid", err: Invalid("", "http.Decode"), code: CodeInvalid, status: http.StatusBadRequest, key: i18nx.KeyInvalid},
+		{name: "forbidden", err: Forbidden("", "app.Delete"), code: CodeForbidden, status: http.StatusForbidden, key: i18nx.KeyForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wrapped := Wrap(Wrap(tt.err, "app.Handle"), "http.Handler")
+			fmtWrapped := fmt.Errorf("outer: %w", fmt.Errorf("inner: %w", tt.err))
+
+			for _, err := range []error{wrapped, fmtWrapped} {
+				assert.Equal(t, tt.code, CodeOf(err))
+				assert.True(t, IsCode(err, tt.code))
+				assert.True(t, errors.Is(err, &I18nError{Code: tt.code}))
+
+				var target *I18nError
+				if assert.ErrorAs(t, err, &target) {
+					assert.Equal(t, tt.status, target.HTTPStatusCode())
+					assert.Equal(t, tt.key, target.MessageKey)
+				}
+			}
+			assert.Contains(t, wrapped.Error(), "http.Handler: app.Handle: ")
+		})
+	}
+}
+
+func TestClassificationKeepsCause(t *testing.T) {
+	cause := errors.New("no rows in result set")
+	err := Wrap(NewNotFound().WithCause(cause, "repo.Get"), "app.Handle")
+
+	assert.True(t, IsNotFound(err))
+	assert.ErrorIs(t, err, cause)
+}
+
+func TestCodeOf(t *testing.T) {
+	assert.Equal(t, CodeInternal, CodeOf(errors.New("boom")))
+	assert.Equal(t, CodeInternal, CodeOf(nil))
+
+	errs := I18nErrors{NewDuplicateEntry(), NewConflict()}
+	assert.Equal(t, CodeDuplicateEntry, CodeOf(Wrap(errs, "app.Handle")))
+}
@@ -3,6 +3,8 @@ package errorx
 import (
 	"errors"
 	"net/http"
+
+	"gitlab.com/ucmsv2/ucms-backend/pkg/i18nx"
 )
 
 type Code string
@@ -34,6 +36,20 @@ const (
 	CodeAlreadyProcessed        Code = "ALREADY_PROCESSED"
 	CodeBusinessRuleViolation   Code = "BUSINESS_RULE_VIOLATION"
 	CodeInsufficientPermissions Code = "INSUFFICIENT_PERMISSIONS"
+	CodePasswordReused          Code = "PASSWORD_REUSED"
+	CodeStudentExpelled         Code = "STUDENT_EXPELLED"
+	// CodeTOSReconsentRequired is the code the clients check to show the
+	// current terms of service, hence it is spelled like its message key.
+	CodeTOSReconsentRequired Code = "tos.reconsent_required"
+	// CodeEmailVerificationRequired asks the clients for the code mailed on
+	// login, it is spelled like its message key as well.
+	CodeEmailVerificationRequired Code = "email.verification_required"
+	// CodeGroupEnrollmentClosed tells the clients the group can not be
+	// joined outside its enrollment window, spelled like its message key.
+	CodeGroupEnrollmentClosed Code = "group.enrollment_closed"
+	// CodeTermOverlap tells the clients the term overlaps another one, named
+	// in the message, spelled like its message key.
+	CodeTermOverlap Code = "term.overlap"
 
 	// Server errors (5xx)
 	CodeInternal           Code = "INTERNAL_ERROR"
@@ -46,7 +62,7 @@ func HTTPStatusCode(code Code) int {
 		return http.StatusBadRequest
 	case CodeUnauthorized, CodeInvalidCredentials, CodeTokenExpired:
 		return http.StatusUnauthorized
-	case CodeForbidden, CodeInsufficientPermissions:
+	case CodeForbidden, CodeInsufficientPermissions, CodeStudentExpelled, CodeEmailVerificationRequired:
 		return http.StatusForbidden
 	case CodeNotFound:
 		return http.StatusNotFound
@@ -54,8 +70,10 @@ func HTTPStatusCode(code Code) int {
 		return http.StatusConflict
 	case CodeDuplicateEntry:
 		return http.StatusConflict
-	case CodeBusinessRuleViolation, CodeIdempotencyKeyMismatch:
+	case CodeBusinessRuleViolation, CodePasswordReused, CodeIdempotencyKeyMismatch, CodeGroupEnrollmentClosed, CodeTermOverlap:
 		return http.StatusUnprocessableEntity
+	case CodeTOSReconsentRequired:
+		return http.StatusPreconditionRequired
 	case CodeRateLimitExceeded:
 		return http.StatusTooManyRequests
 	case CodeServiceUnavailable:
@@ -84,6 +102,16 @@ func IsNotFound(err error) bool {
 	return IsCode(err, CodeNotFound)
 }
 
+// IsResourceNotFound reports whether err is the NewResourceNotFound of
+// resourceType, to tell apart the resources missing in one operation.
+func IsResourceNotFound(err error, resourceType string) bool {
+	var i18nErr *I18nError
+	if !errors.As(err, &i18nErr) || i18nErr.Code != CodeNotFound {
+		return false
+	}
+	return i18nErr.MessageArgs[i18nx.ArgLocaleResourceType] == resourceType
+}
+
 func IsConflict(err error) bool {
 	return IsCode(err, CodeConflict)
 }
@@ -357,6 +357,54 @@ func NewBusinessRuleViolation() *I18nError {
 	}
 }
 
+func NewPasswordReused() *I18nError {
+	return &I18nError{
+		MessageKey: i18nx.KeyPasswordReused,
+		Code:       CodePasswordReused,
+		HTTPCode:   http.StatusUnprocessableEntity,
+	}
+}
+
+func NewStudentExpelled() *I18nError {
+	return &I18nError{
+		MessageKey: i18nx.KeyStudentExpelled,
+		Code:       CodeStudentExpelled,
+		HTTPCode:   http.StatusForbidden,
+	}
+}
+
+func NewTOSReconsentRequired() *I18nError {
+	return &I18nError{
+		MessageKey: i18nx.KeyTOSReconsentRequired,
+		Code:       CodeTOSReconsentRequired,
+		HTTPCode:   http.StatusPreconditionRequired,
+	}
+}
+
+func NewEmailVerificationRequired() *I18nError {
+	return &I18nError{
+		MessageKey: i18nx.KeyEmailVerificationRequired,
+		Code:       CodeEmailVerificationRequired,
+		HTTPCode:   http.StatusForbidden,
+	}
+}
+
+func NewGroupEnrollmentClosed() *I18nError {
+	return &I18nError{
+		MessageKey: i18nx.KeyGroupEnrollmentClosed,
+		Code:       CodeGroupEnrollmentClosed,
+		HTTPCode:   http.StatusUnprocessableEntity,
+	}
+}
+
+func NewTermOverlap() *I18nError {
+	return &I18nError{
+		MessageKey: i18nx.KeyTermOverlap,
+		Code:       CodeTermOverlap,
+		HTTPCode:   http.StatusUnprocessableEntity,
+	}
+}
+
 func NewInsufficientPermissions() *I18nError {
 	return &I18nError{
 		MessageKey: i18nx.KeyInsufficientPermissions,
@@ -0,0 +1,35 @@
+package errorx
+
+import (
+	"errors"
+	"math"
+	"time"
+)
+
+// Retryable is an error the request may be retried after, the HTTP layer
+// answers it with a Retry-After header and the available_at of the body.
+type Retryable interface {
+	error
+	// RetryAt is when the request is accepted again.
+	RetryAt() time.Time
+	// RetryAfter is the time left until RetryAt, as of the error.
+	RetryAfter() time.Duration
+}
+
+// RetryOf returns the first Retryable in the chain of err.
+func RetryOf(err error) (Retryable, bool) {
+	var r Retryable
+	if errors.As(err, &r) {
+		return r, true
+	}
+	return nil, false
+}
+
+// RetryAfterSeconds rounds d up to whole seconds, a client retrying on time
+// must not be refused again.
+func RetryAfterSeconds(d time.Duration) int {
+	if d <= 0 {
+		return 0
+	}
+	return int(math.Ceil(d.Seconds()))
+}
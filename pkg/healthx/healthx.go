@@ -0,0 +1,268 @@
+// Package healthx probes the dependencies of the API in the background and
+// reports the state of each as a component of the status page.
+package healthx
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/contrib/bridges/otelslog"
+
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
+)
+
+var logger = otelslog.NewLogger("ucms/pkg/healthx")
+
+const (
+	// DefaultInterval is the time between two probes of the checks.
+	DefaultInterval = 15 * time.Second
+	// DefaultTimeout bounds a probe.
+	DefaultTimeout = 5 * time.Second
+	// DefaultDegradedAfter are the consecutive failed probes degrading a
+	// component.
+	DefaultDegradedAfter = 3
+	// DefaultDownAfter are the consecutive failed probes taking a component
+	// down.
+	DefaultDownAfter = 8
+	// DefaultRecoverAfter are the consecutive successful probes restoring a
+	// degraded or down component.
+	DefaultRecoverAfter = 3
+)
+
+// State is the state of a component, from the best to the worst.
+type State string
+
+const (
+	Operational State = "operational"
+	Degraded    State = "degraded"
+	Down        State = "down"
+)
+
+func (s State) rank() int {
+	switch s {
+	case Degraded:
+		return 1
+	case Down:
+		return 2
+	}
+	return 0
+}
+
+// Worst returns the worst of states, Operational without any.
+func Worst(states ...State) State {
+	worst := Operational
+	for _, s := range states {
+		if s.rank() > worst.rank() {
+			worst = s
+		}
+	}
+	return worst
+}
+
+// Check is a dependency probed by the Monitor.
+type Check struct {
+	Name string
+	// Probe fails while the dependency is unreachable. A nil Probe keeps the
+	// component operational, e.g. the API itself, which answers the status.
+	Probe func(ctx context.Context) error
+}
+
+// Component is the state of a Check.
+type Component struct {
+	Name  string
+	State State
+	// Since is when the component entered State, the start of the Monitor
+	// for a component that never changed.
+	Since time.Time
+}
+
+// Monitor probes its checks on an interval and moves their components
+// between the states with hysteresis: a component is degraded after
+// DegradedAfter consecutive failures and down after DownAfter, and it is
+// operational again after RecoverAfter consecutive successes only, so that a
+// dependency failing every other probe does not flap.
+type Monitor struct {
+	logger        *slog.Logger
+	clock         clock.Clock
+	interval      time.Duration
+	timeout       time.Duration
+	degradedAfter int
+	downAfter     int
+	recoverAfter  int
+	startedAt     time.Time
+
+	mu     sync.Mutex
+	checks []*check
+}
+
+type check struct {
+	Check
+	state     State
+	since     time.Time
+	failures  int
+	successes int
+}
+
+type MonitorArgs struct {
+	Logger *slog.Logger
+	// Clock defaults to clock.Real.
+	Clock  clock.Clock
+	Checks []Check
+	// Interval defaults to DefaultInterval.
+	Interval time.Duration
+	// Timeout defaults to DefaultTimeout.
+	Timeout time.Duration
+	// DegradedAfter defaults to DefaultDegradedAfter.
+	DegradedAfter int
+	// DownAfter defaults to DefaultDownAfter.
+	DownAfter int
+	// RecoverAfter defaults to DefaultRecoverAfter.
+	RecoverAfter int
+}
+
+// NewMonitor returns a Monitor with every component operational, Run probes
+// them.
+//
+//	WARNING: panics if two checks have the same name
+func NewMonitor(args MonitorArgs) *Monitor {
+	if args.Logger == nil {
+		args.Logger = logger
+	}
+	if args.Interval <= 0 {
+		args.Interval = DefaultInterval
+	}
+	if args.Timeout <= 0 {
+		args.Timeout = DefaultTimeout
+	}
+	if args.DegradedAfter <= 0 {
+		args.DegradedAfter = DefaultDegradedAfter
+	}
+	if args.DownAfter <= args.DegradedAfter {
+		args.DownAfter = max(DefaultDownAfter, args.DegradedAfter+1)
+	}
+	if args.RecoverAfter <= 0 {
+		args.RecoverAfter = DefaultRecoverAfter
+	}
+
+	m := &Monitor{
+		logger:        args.Logger,
+		clock:         args.Clock,
+		interval:      args.Interval,
+		timeout:       args.Timeout,
+		degradedAfter: args.DegradedAfter,
+		downAfter:     args.DownAfter,
+		recoverAfter:  args.RecoverAfter,
+		startedAt:     clock.Or(args.Clock).Now().UTC(),
+	}
+	seen := make(map[string]bool, len(args.Checks))
+	for _, c := range args.Checks {
+		if seen[c.Name] {
+			panic("duplicate health check " + c.Name)
+		}
+		seen[c.Name] = true
+		m.checks = append(m.checks, &check{Check: c, state: Operational, since: m.startedAt})
+	}
+	return m
+}
+
+// StartedAt is when the Monitor was created, the start of the process.
+func (m *Monitor) StartedAt() time.Time {
+	return m.startedAt
+}
+
+// Components returns the components in the order of the checks.
+func (m *Monitor) Components() []Component {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	components := make([]Component, len(m.checks))
+	for i, c := range m.checks {
+		components[i] = Component{Name: c.Name, State: c.state, Since: c.since}
+	}
+	return components
+}
+
+// Run probes the checks every interval until ctx is done.
+func (m *Monitor) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+	for {
+		m.ProbeOnce(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ProbeOnce probes every check concurrently and records the results.
+func (m *Monitor) ProbeOnce(ctx context.Context) {
+	results := make([]error, len(m.checks))
+	var wg sync.WaitGroup
+	for i, c := range m.checks {
+		if c.Probe == nil {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(ctx, m.timeout)
+			defer cancel()
+			results[i] = c.Probe(ctx)
+		}()
+	}
+	wg.Wait()
+	// A probe cut short by the shutdown says nothing of the dependency.
+	if ctx.Err() != nil {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := clock.Or(m.clock).Now().UTC()
+	for i, c := range m.checks {
+		if c.Probe == nil {
+			continue
+		}
+		m.record(ctx, c, results[i], now)
+	}
+}
+
+func (m *Monitor) record(ctx context.Context, c *check, err error, now time.Time) {
+	next := c.state
+	if err != nil {
+		c.failures++
+		c.successes = 0
+		switch {
+		case c.failures >= m.downAfter:
+			next = Down
+		case c.failures >= m.degradedAfter:
+			next = Worst(c.state, Degraded)
+		}
+	} else {
+		c.successes++
+		c.failures = 0
+		if c.successes >= m.recoverAfter {
+			next = Operational
+		}
+	}
+	if next == c.state {
+		return
+	}
+
+	attrs := []any{
+		slog.String("component", c.Name),
+		slog.String("from", string(c.state)),
+		slog.String("to", string(next)),
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+		m.logger.WarnContext(ctx, "Component health changed", attrs...)
+	} else {
+		m.logger.InfoContext(ctx, "Component health changed", attrs...)
+	}
+	c.state, c.since = next, now
+}
@@ -0,0 +1,125 @@
+package healthx
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
+)
+
+// flaky is a probe failing while failing is set.
+type flaky struct {
+	failing bool
+}
+
+func (f *flaky) probe(context.Context) error {
+	if f.failing {
+		return errors.New("unreachable")
+	}
+	return nil
+}
+
+func newMonitor(t *testing.T, clk clock.Clock, probe *flaky) *Monitor {
+	t.Helper()
+	return NewMonitor(MonitorArgs{
+		Clock: clk,
+		Checks: []Check{
+			{Name: "api"},
+			{Name: "storage", Probe: probe.probe},
+		},
+		DegradedAfter: 2,
+		DownAfter:     4,
+		RecoverAfter:  2,
+	})
+}
+
+func state(t *testing.T, m *Monitor, name string) Component {
+	t.Helper()
+	for _, c := range m.Components() {
+		if c.Name == name {
+			return c
+		}
+	}
+	require.FailNow(t, "no component "+name)
+	return Component{}
+}
+
+func TestMonitor_Hysteresis(t *testing.T) {
+	t.Parallel()
+	clk := clock.NewFake(time.Date(2026, 5, 6, 12, 0, 0, 0, time.UTC))
+	probe := &flaky{}
+	m := newMonitor(t, clk, probe)
+	ctx := t.Context()
+
+	assert.Equal(t, Operational, state(t, m, "storage").State)
+
+	probe.failing = true
+	m.ProbeOnce(ctx)
+	assert.Equal(t, Operational, state(t, m, "storage").State, "a single failure is tolerated")
+
+	clk.Advance(time.Minute)
+	m.ProbeOnce(ctx)
+	storage := state(t, m, "storage")
+	assert.Equal(t, Degraded, storage.State)
+	assert.Equal(t, clk.Now(), storage.Since)
+	assert.Equal(t, Operational, state(t, m, "api").State, "the other components are left alone")
+
+	m.ProbeOnce(ctx)
+	m.ProbeOnce(ctx)
+	assert.Equal(t, Down, state(t, m, "storage").State)
+
+	probe.failing = false
+	m.ProbeOnce(ctx)
+	assert.Equal(t, Down, state(t, m, "storage").State, "a single success does not restore it")
+	probe.failing = true
+	m.ProbeOnce(ctx)
+	probe.failing = false
+	m.ProbeOnce(ctx)
+	assert.Equal(t, Down, state(t, m, "storage").State, "the successes must be consecutive")
+	m.ProbeOnce(ctx)
+	assert.Equal(t, Operational, state(t, m, "storage").State)
+}
+
+func TestMonitor_FlappingStaysOperational(t *testing.T) {
+	t.Parallel()
+	probe := &flaky{}
+	m := newMonitor(t, nil, probe)
+
+	for i := range 10 {
+		probe.failing = i%2 == 0
+		m.ProbeOnce(t.Context())
+		assert.Equal(t, Operational, state(t, m, "storage").State)
+	}
+}
+
+func TestMonitor_CanceledProbeNotRecorded(t *testing.T) {
+	t.Parallel()
+	probe := &flaky{failing: true}
+	m := newMonitor(t, nil, probe)
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	for range 5 {
+		m.ProbeOnce(ctx)
+	}
+	assert.Equal(t, Operational, state(t, m, "storage").State)
+}
+
+func TestWorst(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, Operational, Worst())
+	assert.Equal(t, Degraded, Worst(Operational, Degraded, Operational))
+	assert.Equal(t, Down, Worst(Down, Degraded))
+}
+
+func TestNewMonitor_DuplicateCheck(t *testing.T) {
+	t.Parallel()
+	assert.Panics(t, func() {
+		NewMonitor(MonitorArgs{Checks: []Check{{Name: "db"}, {Name: "db"}}})
+	})
+}
@@ -0,0 +1,181 @@
+// Package httpclient builds the HTTP clients of the adapters calling other
+// services. Unlike http.DefaultClient they time out, trace their requests and
+// cap the responses they read.
+package httpclient
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	semconv "go.opentelemetry.io/otel/semconv/v1.36.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// DefaultTimeout bounds a request, its retries included, from dialing to
+	// reading the end of the body.
+	DefaultTimeout = 10 * time.Second
+	// DefaultDialTimeout bounds opening a connection.
+	DefaultDialTimeout = 5 * time.Second
+	// DefaultTLSHandshakeTimeout bounds the TLS handshake of a connection.
+	DefaultTLSHandshakeTimeout = 5 * time.Second
+	// DefaultMaxConnsPerHost caps the connections to a host, the requests
+	// past it wait for one.
+	DefaultMaxConnsPerHost = 32
+	// DefaultMaxIdleConnsPerHost are the connections to a host kept open
+	// between requests.
+	DefaultMaxIdleConnsPerHost = 8
+	// DefaultIdleConnTimeout closes the connections idle for longer.
+	DefaultIdleConnTimeout = 90 * time.Second
+	// DefaultMaxResponseSize caps the bodies read from the responses.
+	DefaultMaxResponseSize = 10 << 20 // 10MB
+)
+
+// ErrResponseTooLarge is returned reading a response body past the cap of
+// the client, see WithMaxResponseSize.
+var ErrResponseTooLarge = errors.New("httpclient: response body too large")
+
+type config struct {
+	timeout         time.Duration
+	maxResponseSize int64
+	retry           *Retry
+	transport       http.RoundTripper
+	tracerProvider  trace.TracerProvider
+}
+
+// Option changes a default of New.
+type Option func(*config)
+
+// WithTimeout replaces DefaultTimeout.
+func WithTimeout(d time.Duration) Option {
+	return func(c *config) { c.timeout = d }
+}
+
+// WithMaxResponseSize replaces DefaultMaxResponseSize, in bytes.
+func WithMaxResponseSize(n int64) Option {
+	return func(c *config) { c.maxResponseSize = n }
+}
+
+// WithRetry retries the idempotent requests failing transiently, see Retry.
+// The requests are not retried without it.
+func WithRetry(r Retry) Option {
+	return func(c *config) { c.retry = &r }
+}
+
+// WithTransport sends the requests with rt instead of a transport with the
+// defaults of the package, e.g. to reach a fake.
+func WithTransport(rt http.RoundTripper) Option {
+	return func(c *config) { c.transport = rt }
+}
+
+// WithTracerProvider traces the requests with tp instead of the global
+// provider.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(c *config) { c.tracerProvider = tp }
+}
+
+// New returns the client of the service name, e.g. "hibp". Its requests are
+// traced as client spans named after it and carrying it as peer.service.
+func New(name string, opts ...Option) *http.Client {
+	cfg := config{
+		timeout:         DefaultTimeout,
+		maxResponseSize: DefaultMaxResponseSize,
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if cfg.transport == nil {
+		cfg.transport = newTransport()
+	}
+
+	otelOpts := []otelhttp.Option{
+		otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+			return fmt.Sprintf("%s %s", name, r.Method)
+		}),
+		otelhttp.WithSpanOptions(trace.WithAttributes(semconv.PeerService(name))),
+	}
+	if cfg.tracerProvider != nil {
+		otelOpts = append(otelOpts, otelhttp.WithTracerProvider(cfg.tracerProvider))
+	}
+
+	// Every attempt of a retried request is a span of its own.
+	var rt http.RoundTripper = otelhttp.NewTransport(cfg.transport, otelOpts...)
+	if cfg.retry != nil {
+		rt = newRetryTransport(rt, *cfg.retry)
+	}
+	rt = &limitTransport{next: rt, limit: cfg.maxResponseSize}
+
+	return &http.Client{
+		Transport: rt,
+		Timeout:   cfg.timeout,
+	}
+}
+
+func newTransport() *http.Transport {
+	return &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   DefaultDialTimeout,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		ForceAttemptHTTP2:     true,
+		TLSHandshakeTimeout:   DefaultTLSHandshakeTimeout,
+		MaxConnsPerHost:       DefaultMaxConnsPerHost,
+		MaxIdleConns:          4 * DefaultMaxIdleConnsPerHost,
+		MaxIdleConnsPerHost:   DefaultMaxIdleConnsPerHost,
+		IdleConnTimeout:       DefaultIdleConnTimeout,
+		ExpectContinueTimeout: time.Second,
+	}
+}
+
+// limitTransport caps the bodies of the responses of next at limit bytes.
+type limitTransport struct {
+	next  http.RoundTripper
+	limit int64
+}
+
+func (t *limitTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(r)
+	if err != nil {
+		return nil, err
+	}
+	if resp.ContentLength > t.limit {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%w: %d bytes", ErrResponseTooLarge, resp.ContentLength)
+	}
+	resp.Body = &limitedBody{body: resp.Body, left: t.limit}
+	return resp, nil
+}
+
+// limitedBody fails with ErrResponseTooLarge once more than left bytes are
+// read, where io.LimitReader would end the body silently.
+type limitedBody struct {
+	body io.ReadCloser
+	left int64
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.left < 0 {
+		return 0, ErrResponseTooLarge
+	}
+	// One more byte than left tells a body of exactly the cap from a larger
+	// one.
+	if int64(len(p)) > b.left+1 {
+		p = p[:b.left+1]
+	}
+	n, err := b.body.Read(p)
+	b.left -= int64(n)
+	if b.left < 0 {
+		return n + int(b.left), ErrResponseTooLarge
+	}
+	return n, err
+}
+
+func (b *limitedBody) Close() error {
+	return b.body.Close()
+}
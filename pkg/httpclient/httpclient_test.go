@@ -0,0 +1,215 @@
+package httpclient
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.36.0"
+)
+
+func newServer(t *testing.T, handler http.HandlerFunc) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// unavailable answers 503 with retryAfter to the first calls calls, then
+// 200 with the body of the request.
+func unavailable(calls int, retryAfter string, got *atomic.Int32) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if int(got.Add(1)) <= calls {
+			if retryAfter != "" {
+				w.Header().Set("Retry-After", retryAfter)
+			}
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		_, _ = w.Write(body)
+	}
+}
+
+func TestNew_Timeout(t *testing.T) {
+	t.Parallel()
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	})
+	c := New("slow", WithTimeout(50*time.Millisecond))
+
+	start := time.Now()
+	_, err := c.Get(server.URL)
+	var netErr net.Error
+	require.ErrorAs(t, err, &netErr)
+	assert.True(t, netErr.Timeout())
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+}
+
+func TestNew_Defaults(t *testing.T) {
+	t.Parallel()
+	c := New("api")
+	assert.Equal(t, DefaultTimeout, c.Timeout)
+}
+
+func TestRetry_RetryAfter(t *testing.T) {
+	t.Parallel()
+	var calls atomic.Int32
+	server := newServer(t, unavailable(1, "1", &calls))
+	c := New("api", WithRetry(Retry{Backoff: time.Millisecond}))
+
+	start := time.Now()
+	resp, err := c.Get(server.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, int32(2), calls.Load())
+	assert.GreaterOrEqual(t, time.Since(start), time.Second, "the Retry-After replaces the backoff")
+}
+
+func TestRetry_RetryAfterOverMaxWait(t *testing.T) {
+	t.Parallel()
+	var calls atomic.Int32
+	server := newServer(t, unavailable(1, "120", &calls))
+	c := New("api", WithRetry(Retry{}))
+
+	resp, err := c.Get(server.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode, "the response is returned rather than waited on")
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestRetry_Exhausted(t *testing.T) {
+	t.Parallel()
+	var calls atomic.Int32
+	server := newServer(t, unavailable(10, "", &calls))
+	c := New("api", WithRetry(Retry{Attempts: 3, Backoff: time.Millisecond}))
+
+	resp, err := c.Get(server.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+func TestRetry_Idempotency(t *testing.T) {
+	t.Parallel()
+
+	t.Run("post", func(t *testing.T) {
+		var calls atomic.Int32
+		server := newServer(t, unavailable(1, "0", &calls))
+		c := New("api", WithRetry(Retry{}))
+
+		resp, err := c.Post(server.URL, "application/json", strings.NewReader(`{}`))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+		assert.Equal(t, int32(1), calls.Load(), "a POST is not retried")
+	})
+
+	t.Run("post with an idempotency key", func(t *testing.T) {
+		var calls atomic.Int32
+		server := newServer(t, unavailable(1, "0", &calls))
+		c := New("api", WithRetry(Retry{}))
+
+		req, err := http.NewRequestWithContext(t.Context(), http.MethodPost, server.URL, strings.NewReader(`{"id":1}`))
+		require.NoError(t, err)
+		req.Header.Set("Idempotency-Key", "k1")
+		resp, err := c.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		assert.Equal(t, `{"id":1}`, string(body), "the body is sent again")
+		assert.Equal(t, int32(2), calls.Load())
+	})
+}
+
+func TestRetryAfter(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2026, 5, 6, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Duration
+		ok    bool
+	}{
+		{"", 0, false},
+		{"3", 3 * time.Second, true},
+		{"-1", 0, true},
+		{"Wed, 06 May 2026 12:00:30 GMT", 30 * time.Second, true},
+		{"Wed, 06 May 2026 11:00:00 GMT", 0, true},
+		{"soon", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := retryAfter(tt.value, now)
+		assert.Equal(t, tt.ok, ok, tt.value)
+		assert.Equal(t, tt.want, got, tt.value)
+	}
+}
+
+func TestMaxResponseSize(t *testing.T) {
+	t.Parallel()
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Has("chunked") {
+			w.(http.Flusher).Flush()
+		}
+		_, _ = w.Write([]byte(strings.Repeat("a", 1024)))
+	})
+
+	t.Run("at the cap", func(t *testing.T) {
+		resp, err := New("api", WithMaxResponseSize(1024)).Get(server.URL + "?chunked")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		assert.Len(t, body, 1024)
+	})
+
+	t.Run("over the cap", func(t *testing.T) {
+		resp, err := New("api", WithMaxResponseSize(1000)).Get(server.URL + "?chunked")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		assert.ErrorIs(t, err, ErrResponseTooLarge)
+		assert.Len(t, body, 1000)
+	})
+
+	t.Run("over the cap by its length", func(t *testing.T) {
+		_, err := New("api", WithMaxResponseSize(1000)).Get(server.URL)
+		assert.ErrorIs(t, err, ErrResponseTooLarge)
+	})
+}
+
+func TestNew_Spans(t *testing.T) {
+	t.Parallel()
+	var calls atomic.Int32
+	server := newServer(t, unavailable(1, "0", &calls))
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	c := New("hibp", WithRetry(Retry{}), WithTracerProvider(tp))
+
+	resp, err := c.Get(server.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2, "every attempt has a span")
+	for _, span := range spans {
+		assert.Equal(t, "hibp GET", span.Name())
+		assert.Contains(t, span.Attributes(), semconv.PeerService("hibp"))
+	}
+}
@@ -0,0 +1,156 @@
+package httpclient
+
+import (
+	"context"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// DefaultRetryAttempts are the attempts of a request, the first one
+	// included.
+	DefaultRetryAttempts = 3
+	// DefaultRetryBackoff is the wait before the first retry, it doubles
+	// with every retry.
+	DefaultRetryBackoff = 100 * time.Millisecond
+	// DefaultRetryMaxWait caps the wait before a retry.
+	DefaultRetryMaxWait = 2 * time.Second
+)
+
+// Retry retries the idempotent requests failing with a network error or a
+// 429, 502, 503 or 504, with an exponential backoff. A Retry-After of the
+// response replaces the backoff, the request is not retried when it is over
+// MaxWait. The requests with a body are retried only when it can be read
+// again, see http.Request.GetBody.
+type Retry struct {
+	// Attempts defaults to DefaultRetryAttempts.
+	Attempts int
+	// Backoff defaults to DefaultRetryBackoff.
+	Backoff time.Duration
+	// MaxWait defaults to DefaultRetryMaxWait.
+	MaxWait time.Duration
+}
+
+type retryTransport struct {
+	next  http.RoundTripper
+	retry Retry
+}
+
+func newRetryTransport(next http.RoundTripper, r Retry) *retryTransport {
+	if r.Attempts <= 0 {
+		r.Attempts = DefaultRetryAttempts
+	}
+	if r.Backoff <= 0 {
+		r.Backoff = DefaultRetryBackoff
+	}
+	if r.MaxWait <= 0 {
+		r.MaxWait = DefaultRetryMaxWait
+	}
+	return &retryTransport{next: next, retry: r}
+}
+
+func (t *retryTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	if !isIdempotent(r) || (r.Body != nil && r.Body != http.NoBody && r.GetBody == nil) {
+		return t.next.RoundTrip(r)
+	}
+
+	for attempt := 1; ; attempt++ {
+		resp, err := t.next.RoundTrip(r)
+		if attempt == t.retry.Attempts || !retryable(r.Context(), resp, err) {
+			return resp, err
+		}
+
+		wait, ok := t.wait(attempt, resp)
+		if !ok {
+			return resp, err
+		}
+		if resp != nil {
+			// Drained, the connection is reused for the retry.
+			_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+			resp.Body.Close()
+		}
+		if err := sleep(r.Context(), wait); err != nil {
+			return nil, err
+		}
+
+		if r.GetBody != nil {
+			body, err := r.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			r = r.Clone(r.Context())
+			r.Body = body
+		}
+	}
+}
+
+// wait returns the wait before the retry following attempt, false when the
+// server asks for more than MaxWait.
+func (t *retryTransport) wait(attempt int, resp *http.Response) (time.Duration, bool) {
+	if resp != nil {
+		if after, ok := retryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+			return after, after <= t.retry.MaxWait
+		}
+	}
+	backoff := t.retry.Backoff << (attempt - 1)
+	if backoff <= 0 || backoff > t.retry.MaxWait {
+		backoff = t.retry.MaxWait
+	}
+	// Full jitter over the upper half, the clients failing together do not
+	// retry together.
+	return backoff/2 + rand.N(backoff/2+1), true
+}
+
+func isIdempotent(r *http.Request) bool {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace,
+		http.MethodPut, http.MethodDelete:
+		return true
+	}
+	// The services deduplicating on an Idempotency-Key get the same request
+	// twice at worst.
+	return r.Header.Get("Idempotency-Key") != ""
+}
+
+func retryable(ctx context.Context, resp *http.Response, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if err != nil {
+		return true
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// retryAfter parses a Retry-After header, in seconds or an HTTP date.
+func retryAfter(v string, now time.Time) (time.Duration, bool) {
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		return max(time.Duration(secs)*time.Second, 0), true
+	}
+	if at, err := http.ParseTime(v); err == nil {
+		return max(at.Sub(now), 0), true
+	}
+	return 0, false
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
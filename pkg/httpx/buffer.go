@@ -0,0 +1,74 @@
+package httpx
+
+import (
+	"bytes"
+	"net/http"
+)
+
+// BufferedResponse holds a response back until Send, so that it can still be
+// replaced, e.g. when the transaction of the request fails to commit. It
+// does not stream, NewEventStream panics on it.
+type BufferedResponse struct {
+	w      http.ResponseWriter
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func NewBufferedResponse(w http.ResponseWriter) *BufferedResponse {
+	return &BufferedResponse{w: w, header: w.Header().Clone()}
+}
+
+func (b *BufferedResponse) Header() http.Header {
+	return b.header
+}
+
+func (b *BufferedResponse) WriteHeader(status int) {
+	if b.status == 0 {
+		b.status = status
+	}
+}
+
+func (b *BufferedResponse) Write(p []byte) (int, error) {
+	b.WriteHeader(http.StatusOK)
+	return b.body.Write(p)
+}
+
+// Status is the status written so far, 200 once the body is written without
+// one and 0 before anything is written.
+func (b *BufferedResponse) Status() int {
+	return b.status
+}
+
+// Send writes the held response to the underlying writer.
+func (b *BufferedResponse) Send() error {
+	h := b.w.Header()
+	for k := range h {
+		if _, ok := b.header[k]; !ok {
+			delete(h, k)
+		}
+	}
+	for k, v := range b.header {
+		h[k] = v
+	}
+	if b.status == 0 {
+		b.status = http.StatusOK
+	}
+	b.w.WriteHeader(b.status)
+	_, err := b.body.WriteTo(b.w)
+	return err
+}
+
+// isBuffered reports whether w, or a writer it wraps, is a BufferedResponse.
+func isBuffered(w http.ResponseWriter) bool {
+	for {
+		if _, ok := w.(*BufferedResponse); ok {
+			return true
+		}
+		u, ok := w.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			return false
+		}
+		w = u.Unwrap()
+	}
+}
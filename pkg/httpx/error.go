@@ -1,28 +1,47 @@
 package httpx
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/ARUMANDESU/validation"
 	"github.com/BurntSushi/toml"
+	"github.com/go-chi/chi/v5"
 	"github.com/nicksnyder/go-i18n/v2/i18n"
 	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/text/language"
 
 	ucmsv2 "gitlab.com/ucmsv2/ucms-backend"
 	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/i18nx"
 	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx"
 )
 
+// ErrorRecorder is notified of the errors answered with a 5xx status.
+type ErrorRecorder interface {
+	RecordError(ctx context.Context, route string, err error)
+}
+
+// ValidationRecorder is notified of the requests rejected because of invalid
+// fields, with the names of the fields.
+type ValidationRecorder interface {
+	RecordValidationFailure(ctx context.Context, route string, fields []string)
+}
+
 type ErrorHandler struct {
-	bundle *i18n.Bundle
-	enloc  *i18n.Localizer
-	kkloc  *i18n.Localizer
-	ruloc  *i18n.Localizer
+	recorder   ErrorRecorder
+	validation ValidationRecorder
+	bundle     *i18n.Bundle
+	enloc      *i18n.Localizer
+	kkloc      *i18n.Localizer
+	ruloc      *i18n.Localizer
 }
 
 func NewErrorHandler() *ErrorHandler {
@@ -55,6 +74,19 @@ func NewErrorHandler() *ErrorHandler {
 	}
 }
 
+// WithRecorder makes the handler report 5xx responses to rec.
+func (h *ErrorHandler) WithRecorder(rec ErrorRecorder) *ErrorHandler {
+	h.recorder = rec
+	return h
+}
+
+// WithValidationRecorder makes the handler report the fields failing
+// validation to rec.
+func (h *ErrorHandler) WithValidationRecorder(rec ValidationRecorder) *ErrorHandler {
+	h.validation = rec
+	return h
+}
+
 func (h *ErrorHandler) Localizer(lang string) *i18n.Localizer {
 	switch lang {
 	case "kk":
@@ -77,21 +109,29 @@ func (h *ErrorHandler) HandleError(w http.ResponseWriter, r *http.Request, span
 	var valErrs validation.Errors
 	var valErr validation.Error
 
+	var availableAt time.Time
+	if retry, ok := errorx.RetryOf(err); ok {
+		availableAt = retry.RetryAt()
+		w.Header().Set("Retry-After", strconv.Itoa(errorx.RetryAfterSeconds(retry.RetryAfter())))
+	}
+
 	var isClientErr bool
 	switch {
 	case errors.As(err, &appErrs):
 		writeError(w, r, httpErrorResponse{
-			Status:  appErrs.HTTPStatusCode(),
-			Code:    appErrs.Code(),
-			Message: appErrs.Localize(localizer),
+			Status:      appErrs.HTTPStatusCode(),
+			Code:        appErrs.Code(),
+			Message:     appErrs.Localize(localizer),
+			AvailableAt: availableAt,
 		})
 		isClientErr = appErrs.HTTPStatusCode() >= 400 && appErrs.HTTPStatusCode() < 500
 	case errors.As(err, &appErr):
 		writeError(w, r, httpErrorResponse{
-			Status:  appErr.HTTPStatusCode(),
-			Code:    appErr.Code,
-			Message: appErr.Localize(localizer),
-			Details: appErr.Details,
+			Status:      appErr.HTTPStatusCode(),
+			Code:        appErr.Code,
+			Message:     appErr.Localize(localizer),
+			Details:     appErr.Details,
+			AvailableAt: availableAt,
 		})
 		isClientErr = appErr.HTTPStatusCode() >= 400 && appErr.HTTPStatusCode() < 500
 	case errors.As(err, &valErrs):
@@ -135,15 +175,92 @@ func (h *ErrorHandler) HandleError(w http.ResponseWriter, r *http.Request, span
 			Code:    internalErr.Code,
 			Message: internalErr.Localize(localizer),
 		})
+		h.record(r, err)
 		return
 	}
 
 	if isClientErr {
+		h.recordValidation(r, span, err)
 		slog.WarnContext(r.Context(), "HTTP client error response", "error", err.Error())
 		return
 	}
 
 	slog.ErrorContext(r.Context(), "HTTP error response", "error", err.Error())
+	h.record(r, err)
+}
+
+func (h *ErrorHandler) record(r *http.Request, err error) {
+	if h.recorder != nil {
+		h.recorder.RecordError(r.Context(), r.Method+" "+RoutePattern(r), err)
+	}
+}
+
+// recordValidation reports the fields of a validation error. Only the field
+// names are recorded, they are the JSON names of the request fields or the
+// field names of errorx.NewValidationFieldFailed, never the values.
+func (h *ErrorHandler) recordValidation(r *http.Request, span trace.Span, err error) {
+	fields := failedFields(err)
+	if len(fields) == 0 {
+		return
+	}
+	otelx.SetSpanAttrs(span, map[string]any{"validation.failed_fields": fields})
+	if h.validation != nil {
+		h.validation.RecordValidationFailure(r.Context(), r.Method+" "+RoutePattern(r), fields)
+	}
+}
+
+// failedFields returns the sorted names of the invalid fields of err, nil
+// when err is not a validation error of named fields. The unknown body
+// fields are left out, their names are chosen by the client and would make
+// the recorded fields unbounded.
+func failedFields(err error) []string {
+	var fields []string
+	var valErrs validation.Errors
+	var appErrs errorx.I18nErrors
+	var appErr *errorx.I18nError
+	switch {
+	case errors.As(err, &valErrs):
+		for field, fieldErr := range valErrs {
+			if isUnknownField(fieldErr) {
+				continue
+			}
+			fields = append(fields, field)
+		}
+	case errors.As(err, &appErrs):
+		for _, e := range appErrs {
+			fields = append(fields, validationField(e)...)
+		}
+	case errors.As(err, &appErr):
+		fields = validationField(appErr)
+	}
+	slices.Sort(fields)
+	return slices.Compact(fields)
+}
+
+func isUnknownField(err error) bool {
+	var valErr validation.Error
+	return errors.As(err, &valErr) && valErr.Code() == ErrUnknownField.Code()
+}
+
+func validationField(err *errorx.I18nError) []string {
+	if err == nil || err.Code != errorx.CodeValidationFailed {
+		return nil
+	}
+	if field, ok := err.MessageArgs[i18nx.ArgField].(string); ok && field != "" {
+		return []string{field}
+	}
+	return nil
+}
+
+// RoutePattern returns the chi route pattern of r, e.g. /v1/users/{user_id},
+// so IDs are not recorded. It is only complete once the request is routed.
+func RoutePattern(r *http.Request) string {
+	if rctx := chi.RouteContext(r.Context()); rctx != nil {
+		if pattern := rctx.RoutePattern(); pattern != "" {
+			return pattern
+		}
+	}
+	return "unmatched"
 }
 
 type httpErrorResponse struct {
@@ -152,15 +269,21 @@ type httpErrorResponse struct {
 	Code    errorx.Code `json:"code,omitempty"`
 	Message string      `json:"message,omitempty"`
 	Details string      `json:"details,omitempty"`
+	// AvailableAt is when a retryable request is accepted again.
+	AvailableAt time.Time `json:"-"`
 }
 
 func (h *httpErrorResponse) Envelope() map[string]any {
-	return map[string]any{
+	envelope := map[string]any{
 		"success": h.Success,
 		"code":    h.Code,
 		"message": h.Message,
 		"details": h.Details,
 	}
+	if !h.AvailableAt.IsZero() {
+		envelope["available_at"] = h.AvailableAt.UTC().Format(time.RFC3339)
+	}
+	return envelope
 }
 
 func writeError(w http.ResponseWriter, r *http.Request, res httpErrorResponse) {
@@ -0,0 +1,125 @@
+package httpx
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ARUMANDESU/validation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+)
+
+type cooldownError struct {
+	at   time.Time
+	wait time.Duration
+}
+
+func (e *cooldownError) Error() string { return "cooldown" }
+func (e *cooldownError) Unwrap() error {
+	return errorx.NewRateLimitExceededWithRetry(errorx.RetryAfterSeconds(e.wait))
+}
+func (e *cooldownError) RetryAt() time.Time        { return e.at }
+func (e *cooldownError) RetryAfter() time.Duration { return e.wait }
+
+func TestHandleError_Retryable(t *testing.T) {
+	at := time.Date(2025, 3, 10, 12, 1, 0, 0, time.UTC)
+	err := fmt.Errorf("app.Resend: %w", &cooldownError{at: at, wait: 41500 * time.Millisecond})
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/", nil)
+	NewErrorHandler().HandleError(w, r, trace.SpanFromContext(r.Context()), err, "resend")
+
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "42", w.Header().Get("Retry-After"), "the wait is rounded up")
+
+	var body map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, string(errorx.CodeRateLimitExceeded), body["code"])
+	assert.Equal(t, "2025-03-10T12:01:00Z", body["available_at"])
+	assert.Contains(t, body["message"], "42")
+}
+
+func TestHandleError_NotRetryable(t *testing.T) {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/", nil)
+	NewErrorHandler().HandleError(w, r, trace.SpanFromContext(r.Context()), errorx.NewRateLimitExceeded(), "resend")
+
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Empty(t, w.Header().Get("Retry-After"))
+	assert.NotContains(t, w.Body.String(), "available_at")
+}
+
+type validationRecorder struct {
+	route  string
+	fields []string
+}
+
+func (r *validationRecorder) RecordValidationFailure(_ context.Context, route string, fields []string) {
+	r.route, r.fields = route, fields
+}
+
+func TestHandleError_RecordsValidationFields(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want []string
+	}{
+		{
+			name: "validation errors",
+			err: fmt.Errorf("handler: %w", validation.Errors{
+				"password":   validation.NewError("validation_length_out_of_range", "too short"),
+				"first_name": validation.NewError("validation_required", "required"),
+			}),
+			want: []string{"first_name", "password"},
+		},
+		{
+			name: "unknown fields",
+			err: validation.Errors{
+				"email":    validation.NewError("validation_required", "required"),
+				"x9f3kq2z": ErrUnknownField,
+			},
+			want: []string{"email"},
+		},
+		{
+			name: "unknown fields only",
+			err:  validation.Errors{"x9f3kq2z": ErrUnknownField, "GroupID": ErrUnknownField},
+		},
+		{
+			name: "field error",
+			err:  errorx.NewValidationFieldFailed("field.verification_code"),
+			want: []string{"field.verification_code"},
+		},
+		{
+			name: "not a validation error",
+			err:  errorx.NewNotFound(),
+		},
+		{
+			name: "no field",
+			err:  validation.NewError("validation_required", "required"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := &validationRecorder{}
+			h := NewErrorHandler().WithValidationRecorder(rec)
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/v1/registrations/students/complete", nil)
+
+			h.HandleError(w, r, trace.SpanFromContext(r.Context()), tt.err, "validation failed")
+
+			assert.Equal(t, tt.want, rec.fields)
+			if tt.want != nil {
+				assert.Equal(t, "POST unmatched", rec.route)
+			}
+		})
+	}
+}
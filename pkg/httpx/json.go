@@ -1,6 +1,8 @@
 package httpx
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -8,68 +10,65 @@ import (
 	"log/slog"
 	"maps"
 	"net/http"
+	"strconv"
 	"strings"
 
+	"github.com/ARUMANDESU/validation"
+	"github.com/ARUMANDESU/validation/is"
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 
 	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/i18nx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/validationx"
 )
 
 type Envelope map[string]any
 
 const maxRequestBodySize = 10 << 20 // 10MB
 
+var (
+	// ErrUnknownField is reported for the body fields the request does not
+	// have, see AllowUnknownFields.
+	ErrUnknownField = validation.NewError(i18nx.ValidationUnknownField, i18nx.MsgValidationUnknownFieldOther)
+	// ErrDuplicateKey is reported for the keys repeated in a JSON object.
+	ErrDuplicateKey = validation.NewError(i18nx.ValidationDuplicateKey, i18nx.MsgValidationDuplicateKeyOther)
+)
+
+type allowUnknownFieldsKey struct{}
+
+// AllowUnknownFields is the middleware of the routes whose bodies may carry
+// fields the request does not have, ReadJSON ignores them instead of
+// rejecting the body. Duplicate keys and trailing data are still rejected.
+func AllowUnknownFields(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), allowUnknownFieldsKey{}, true)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// ReadJSON decodes the body of r, a single JSON value, into v. The body is
+// strict: a field v does not have fails with ErrUnknownField unless the
+// route allows them, a key repeated in an object fails with ErrDuplicateKey,
+// both as validation.Errors naming the key. The request fields differing
+// only in case are repeated keys too, encoding/json fills the same field
+// with both; the nested keys are compared exactly, e.g. the keys of a map.
 func ReadJSON(w http.ResponseWriter, r *http.Request, v any) error {
 	const op = "httpx.ReadJSON"
 	r.Body = http.MaxBytesReader(w, r.Body, maxRequestBodySize)
 
-	dec := json.NewDecoder(r.Body)
-	dec.DisallowUnknownFields()
-
-	err := dec.Decode(v)
+	body, err := io.ReadAll(r.Body)
 	if err != nil {
-		var syntaxError *json.SyntaxError
-		var unmarshalTypeError *json.UnmarshalTypeError
-		var invalidUnmarshalError *json.InvalidUnmarshalError
-		var maxBytesError *http.MaxBytesError
-
-		malformedErr := errorx.NewMalformedJSON().WithCause(err, op)
-		switch {
-		case errors.As(err, &syntaxError):
-			_ = malformedErr.WithDetails(fmt.Sprintf("badly-formed JSON (at character %d)", syntaxError.Offset))
-		case errors.Is(err, io.ErrUnexpectedEOF):
-			_ = malformedErr.WithDetails("body contains badly-formed JSON")
-		case errors.As(err, &unmarshalTypeError):
-			if unmarshalTypeError.Field != "" {
-				_ = malformedErr.WithDetails(
-					fmt.Sprintf("body contains incorrect JSON type for field %q (at character %d)",
-						unmarshalTypeError.Field,
-						unmarshalTypeError.Offset,
-					),
-				)
-			} else {
-				_ = malformedErr.WithDetails(fmt.Sprintf("body contains incorrect JSON type (at character %d)", unmarshalTypeError.Offset))
-			}
-		case errors.Is(err, io.EOF):
-			_ = malformedErr.WithDetails("body must not be empty")
-		case strings.HasPrefix(err.Error(), "json: unknown field "):
-			fieldName := strings.TrimPrefix(err.Error(), "json: unknown field ")
-			_ = malformedErr.WithDetails(fmt.Sprintf("body contains unknown field %s", fieldName))
-		case errors.As(err, &maxBytesError):
-			if maxBytesError.Limit < 1<<20 { // 1MB
-				_ = malformedErr.WithDetails(fmt.Sprintf("body must not be larger than %d KB", maxBytesError.Limit/1024))
-			} else {
-				_ = malformedErr.WithDetails(fmt.Sprintf("body must not be larger than %d MB", maxBytesError.Limit/(1<<20)))
-			}
-		case errors.As(err, &invalidUnmarshalError):
-			_ = malformedErr.WithDetails("body contains invalid JSON")
-		default:
-			_ = malformedErr.WithDetails("body contains invalid JSON")
-		}
+		return decodeError(err, op)
+	}
 
-		return malformedErr
+	dec := json.NewDecoder(bytes.NewReader(body))
+	if allow, _ := r.Context().Value(allowUnknownFieldsKey{}).(bool); !allow {
+		dec.DisallowUnknownFields()
+	}
 
+	if err := dec.Decode(v); err != nil {
+		return decodeError(err, op)
 	}
 
 	// This is to ensure that the body contains only a single JSON value.
@@ -78,15 +77,126 @@ func ReadJSON(w http.ResponseWriter, r *http.Request, v any) error {
 		return errorx.NewMalformedJSON().WithDetails("body must only contain a single JSON value").WithCause(err, op)
 	}
 
+	if key, err := duplicateKey(json.NewDecoder(bytes.NewReader(body)), "", true); err != nil {
+		return errorx.NewMalformedJSON().WithDetails("body contains invalid JSON").WithCause(err, op)
+	} else if key != "" {
+		return validation.Errors{key: ErrDuplicateKey}
+	}
+
 	return nil
 }
 
+func decodeError(err error, op string) error {
+	var syntaxError *json.SyntaxError
+	var unmarshalTypeError *json.UnmarshalTypeError
+	var invalidUnmarshalError *json.InvalidUnmarshalError
+	var maxBytesError *http.MaxBytesError
+
+	if field, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+		if unquoted, err := strconv.Unquote(field); err == nil {
+			field = unquoted
+		}
+		return validation.Errors{field: ErrUnknownField}
+	}
+
+	malformedErr := errorx.NewMalformedJSON().WithCause(err, op)
+	switch {
+	case errors.As(err, &syntaxError):
+		_ = malformedErr.WithDetails(fmt.Sprintf("badly-formed JSON (at character %d)", syntaxError.Offset))
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		_ = malformedErr.WithDetails("body contains badly-formed JSON")
+	case errors.As(err, &unmarshalTypeError):
+		if unmarshalTypeError.Field != "" {
+			_ = malformedErr.WithDetails(
+				fmt.Sprintf("body contains incorrect JSON type for field %q (at character %d)",
+					unmarshalTypeError.Field,
+					unmarshalTypeError.Offset,
+				),
+			)
+		} else {
+			_ = malformedErr.WithDetails(fmt.Sprintf("body contains incorrect JSON type (at character %d)", unmarshalTypeError.Offset))
+		}
+	case errors.Is(err, io.EOF):
+		_ = malformedErr.WithDetails("body must not be empty")
+	case errors.As(err, &maxBytesError):
+		if maxBytesError.Limit < 1<<20 { // 1MB
+			_ = malformedErr.WithDetails(fmt.Sprintf("body must not be larger than %d KB", maxBytesError.Limit/1024))
+		} else {
+			_ = malformedErr.WithDetails(fmt.Sprintf("body must not be larger than %d MB", maxBytesError.Limit/(1<<20)))
+		}
+	case errors.As(err, &invalidUnmarshalError):
+		_ = malformedErr.WithDetails("body contains invalid JSON")
+	default:
+		_ = malformedErr.WithDetails("body contains invalid JSON")
+	}
+
+	return malformedErr
+}
+
+// duplicateKey returns the path of the first key repeated in an object of
+// the JSON value read from dec, e.g. "student.email", or "" if there is none.
+// fold compares the keys of the top level object case insensitively.
+func duplicateKey(dec *json.Decoder, path string, fold bool) (string, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return "", err
+	}
+
+	switch tok {
+	case json.Delim('{'):
+		seen := make(map[string]struct{})
+		for dec.More() {
+			tok, err := dec.Token()
+			if err != nil {
+				return "", err
+			}
+			key, _ := tok.(string)
+			keyPath := joinKeyPath(path, key)
+			seenKey := key
+			if fold {
+				seenKey = strings.ToLower(key)
+			}
+			if _, ok := seen[seenKey]; ok {
+				return keyPath, nil
+			}
+			seen[seenKey] = struct{}{}
+
+			if dup, err := duplicateKey(dec, keyPath, false); dup != "" || err != nil {
+				return dup, err
+			}
+		}
+	case json.Delim('['):
+		for i := 0; dec.More(); i++ {
+			if dup, err := duplicateKey(dec, joinKeyPath(path, strconv.Itoa(i)), false); dup != "" || err != nil {
+				return dup, err
+			}
+		}
+	default:
+		return "", nil
+	}
+
+	// the closing delimiter
+	_, err = dec.Token()
+	return "", err
+}
+
+func joinKeyPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// ReadUUIDUrlParam parses the URL parameter param, the error is a
+// validation.Errors naming param like the ones of Query.
 func ReadUUIDUrlParam(r *http.Request, param string) (uuid.UUID, error) {
-	const op = "httpx.ReadUUIDUrlParam"
-	idStr := chi.URLParam(r, param)
+	idStr := strings.TrimSpace(chi.URLParam(r, param))
+	if idStr == "" {
+		return uuid.Nil, validation.Errors{param: validation.ErrRequired}
+	}
 	id, err := uuid.Parse(idStr)
 	if err != nil {
-		return uuid.Nil, errorx.NewInvalidRequest().WithCause(err, op)
+		return uuid.Nil, validation.Errors{param: is.ErrUUID}
 	}
 	return id, nil
 }
@@ -122,3 +232,20 @@ func Success(w http.ResponseWriter, r *http.Request, status int, message Envelop
 		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 	}
 }
+
+// SuccessWithWarnings is Success for a request accepted with warnings, they
+// are sent in the warnings array and logged with the request attributes for
+// later analysis. Without warnings it is Success.
+func SuccessWithWarnings(w http.ResponseWriter, r *http.Request, status int, message Envelope, warnings validationx.Warnings) {
+	if len(warnings) > 0 {
+		if message == nil {
+			message = make(Envelope, 2)
+		}
+		message["warnings"] = warnings
+		slog.InfoContext(r.Context(), "request accepted with warnings",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.Any("warnings", warnings.Codes()))
+	}
+	Success(w, r, status, message)
+}
@@ -0,0 +1,137 @@
+package httpx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ARUMANDESU/validation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/validationx"
+)
+
+type studentRequest struct {
+	Email   string `json:"email"`
+	GroupID string `json:"group_id"`
+	Profile struct {
+		FirstName string `json:"first_name"`
+	} `json:"profile"`
+	Tags []map[string]string `json:"tags"`
+}
+
+func readJSON(t *testing.T, body string, middlewares ...func(http.Handler) http.Handler) (studentRequest, error) {
+	t.Helper()
+
+	var (
+		req studentRequest
+		err error
+	)
+	var h http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		err = ReadJSON(w, r, &req)
+	})
+	for _, mw := range middlewares {
+		h = mw(h)
+	}
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)))
+	return req, err
+}
+
+func TestReadJSON_Valid(t *testing.T) {
+	req, err := readJSON(t, `{"email":"a@b.kz","group_id":"g1","profile":{"first_name":"Aru"},"tags":[{"k":"v"},{"k":"w"}]}`)
+	require.NoError(t, err)
+	assert.Equal(t, "a@b.kz", req.Email)
+	assert.Equal(t, "g1", req.GroupID)
+	assert.Equal(t, "Aru", req.Profile.FirstName)
+	assert.Len(t, req.Tags, 2)
+}
+
+func TestReadJSON_NestedKeysComparedExactly(t *testing.T) {
+	req, err := readJSON(t, `{"email":"a@b.kz","tags":[{"k":"v","K":"w"}]}`)
+	require.NoError(t, err, "nested keys differing only in case are not repeated")
+	assert.Equal(t, map[string]string{"k": "v", "K": "w"}, req.Tags[0])
+}
+
+func TestReadJSON_UnknownField(t *testing.T) {
+	_, err := readJSON(t, `{"email":"a@b.kz","GroupID":"g1"}`)
+
+	var errs validation.Errors
+	require.ErrorAs(t, err, &errs)
+	require.Contains(t, errs, "GroupID", "the offending key is named")
+	assertCode(t, errs["GroupID"], ErrUnknownField)
+}
+
+func TestReadJSON_AllowUnknownFields(t *testing.T) {
+	req, err := readJSON(t, `{"email":"a@b.kz","GroupID":"g1"}`, AllowUnknownFields)
+	require.NoError(t, err)
+	assert.Equal(t, "a@b.kz", req.Email)
+	assert.Empty(t, req.GroupID)
+
+	_, err = readJSON(t, `{"email":"a@b.kz","email":"c@d.kz"}`, AllowUnknownFields)
+	assert.Error(t, err, "duplicate keys are still rejected")
+}
+
+func TestReadJSON_DuplicateKey(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		key  string
+	}{
+		{name: "top level", body: `{"email":"a@b.kz","group_id":"g1","email":"c@d.kz"}`, key: "email"},
+		{name: "case insensitive", body: `{"email":"a@b.kz","Email":"c@d.kz"}`, key: "Email"},
+		{name: "nested object", body: `{"profile":{"first_name":"A","first_name":"B"}}`, key: "profile.first_name"},
+		{name: "in array", body: `{"tags":[{"k":"v"},{"k":"v","k":"w"}]}`, key: "tags.1.k"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := readJSON(t, tt.body)
+
+			var errs validation.Errors
+			require.ErrorAs(t, err, &errs)
+			require.Contains(t, errs, tt.key)
+			assertCode(t, errs[tt.key], ErrDuplicateKey)
+		})
+	}
+}
+
+func TestReadJSON_Malformed(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		details string
+	}{
+		{name: "trailing data", body: `{"email":"a@b.kz"} garbage`, details: "single JSON value"},
+		{name: "second value", body: `{"email":"a@b.kz"}{"email":"c@d.kz"}`, details: "single JSON value"},
+		{name: "truncated", body: `{"email":"a@b.kz"`, details: "badly-formed JSON"},
+		{name: "empty", body: ``, details: "must not be empty"},
+		{name: "wrong type", body: `{"email":42}`, details: `field "email"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := readJSON(t, tt.body)
+
+			require.True(t, errorx.IsCode(err, errorx.CodeMalformedJSON), "got %v", err)
+			var appErr *errorx.I18nError
+			require.ErrorAs(t, err, &appErr)
+			assert.Contains(t, appErr.Details, tt.details)
+		})
+	}
+}
+
+func TestSuccessWithWarnings(t *testing.T) {
+	rec := httptest.NewRecorder()
+	SuccessWithWarnings(rec, httptest.NewRequest(http.MethodPost, "/", nil), http.StatusOK, nil, validationx.Warnings{
+		{Field: "first_name", Code: validationx.WarningAllCapsNormalized},
+	})
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"success": true, "warnings": [{"field": "first_name", "code": "all_caps_normalized"}]}`, rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	SuccessWithWarnings(rec, httptest.NewRequest(http.MethodPost, "/", nil), http.StatusOK, nil, nil)
+	assert.JSONEq(t, `{"success": true}`, rec.Body.String(), "the array is left out without warnings")
+}
@@ -0,0 +1,148 @@
+package httpx
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/ARUMANDESU/validation"
+
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/optional"
+)
+
+// Field is a field of a JSON Merge Patch (RFC 7396): left out it is kept,
+// null clears it, any other value replaces it. The zero Field is left out,
+// tag it omitzero for the patches that are sent.
+type Field[T any] struct {
+	// Value is the new value, the zero value of T for a null.
+	Value T
+	// Set tells the field was in the patch, with a value or null.
+	Set bool
+	// Null tells the field was null.
+	Null bool
+}
+
+// SetTo returns the field replaced with v.
+func SetTo[T any](v T) Field[T] {
+	return Field[T]{Value: v, Set: true}
+}
+
+// Apply returns the value of the field patched onto current: current when the
+// field was left out, the zero value of T for a null, the new value else.
+func (f Field[T]) Apply(current T) T {
+	if !f.Set {
+		return current
+	}
+	return f.Value
+}
+
+// Clear returns the field set to null.
+func Clear[T any]() Field[T] {
+	return Field[T]{Set: true, Null: true}
+}
+
+// Optional returns the field for the application layer, a null is a
+// cleared field.
+func (f Field[T]) Optional() optional.Field[T] {
+	return optional.Field[T]{Value: f.Value, Set: f.Set, Null: f.Null}
+}
+
+func (f Field[T]) MarshalJSON() ([]byte, error) {
+	if f.Null {
+		return []byte("null"), nil
+	}
+	return json.Marshal(f.Value)
+}
+
+func (f *Field[T]) UnmarshalJSON(data []byte) error {
+	f.Set = true
+	if string(data) == "null" {
+		var zero T
+		f.Value, f.Null = zero, true
+		return nil
+	}
+	f.Null = false
+	return json.Unmarshal(data, &f.Value)
+}
+
+// Patch is the body of a PATCH request, a JSON Merge Patch of T. T is a
+// struct of Field, one per field of the resource the patch may change.
+type Patch[T any] struct {
+	// Changes are the fields of the patch, a Field left out is not Set.
+	Changes T
+	// Keys are the keys of the patch, sorted.
+	Keys []string
+}
+
+// IsEmpty tells the patch changes nothing.
+func (p *Patch[T]) IsEmpty() bool {
+	return len(p.Keys) == 0
+}
+
+// ReadPatch reads the body of r, a JSON object, as a Patch of T. Only the
+// keys of the json tags of T are allowed, the others fail with
+// ErrUnknownField, all of them at once, whether the route allows unknown
+// fields or not. The other checks of ReadJSON apply.
+func ReadPatch[T any](w http.ResponseWriter, r *http.Request) (*Patch[T], error) {
+	const op = "httpx.ReadPatch"
+
+	var fields map[string]json.RawMessage
+	if err := ReadJSON(w, r, &fields); err != nil {
+		return nil, err
+	}
+	if fields == nil {
+		return nil, errorx.NewMalformedJSON().WithDetails("body must be a JSON object").WithOp(op)
+	}
+
+	allowed := patchKeys(reflect.TypeFor[T]())
+	unknown := validation.Errors{}
+	for key := range fields {
+		if !slices.Contains(allowed, key) {
+			unknown[key] = ErrUnknownField
+		}
+	}
+	if len(unknown) > 0 {
+		return nil, unknown
+	}
+
+	// The fields are checked already, decoding them again fills T through
+	// its json tags.
+	body, err := json.Marshal(fields)
+	if err != nil {
+		return nil, errorx.NewMalformedJSON().WithCause(err, op)
+	}
+	var p Patch[T]
+	if err := json.NewDecoder(bytes.NewReader(body)).Decode(&p.Changes); err != nil {
+		return nil, decodeError(err, op)
+	}
+	for key := range fields {
+		p.Keys = append(p.Keys, key)
+	}
+	slices.Sort(p.Keys)
+
+	return &p, nil
+}
+
+// patchKeys returns the json keys of the exported fields of the struct t.
+func patchKeys(t reflect.Type) []string {
+	var keys []string
+	for i := range t.NumField() {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		switch name {
+		case "-":
+			continue
+		case "":
+			name = f.Name
+		}
+		keys = append(keys, name)
+	}
+	return keys
+}
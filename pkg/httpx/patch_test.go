@@ -0,0 +1,103 @@
+package httpx
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ARUMANDESU/validation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/optional"
+)
+
+type testPatch struct {
+	Name   Field[string] `json:"name,omitzero"`
+	Avatar Field[string] `json:"avatar,omitzero"`
+	Year   Field[int]    `json:"year,omitzero"`
+}
+
+func readTestPatch(t *testing.T, body string) (*Patch[testPatch], error) {
+	t.Helper()
+	r := httptest.NewRequest(http.MethodPatch, "/", strings.NewReader(body))
+	return ReadPatch[testPatch](httptest.NewRecorder(), r)
+}
+
+func TestReadPatch_OmittedAndNull(t *testing.T) {
+	p, err := readTestPatch(t, `{"name":"Jane","avatar":null}`)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"avatar", "name"}, p.Keys)
+	assert.False(t, p.IsEmpty())
+	assert.Equal(t, SetTo("Jane"), p.Changes.Name)
+	assert.Equal(t, Clear[string](), p.Changes.Avatar, "an explicit null clears the field")
+	assert.Equal(t, Field[int]{}, p.Changes.Year, "an omitted field is not set")
+
+	assert.Equal(t, "Jane", p.Changes.Name.Apply("John"))
+	assert.Empty(t, p.Changes.Avatar.Apply("avatars/1.png"))
+	assert.Equal(t, 2024, p.Changes.Year.Apply(2024))
+}
+
+func TestReadPatch_Empty(t *testing.T) {
+	p, err := readTestPatch(t, `{}`)
+	require.NoError(t, err)
+	assert.True(t, p.IsEmpty())
+	assert.Equal(t, testPatch{}, p.Changes)
+}
+
+func TestReadPatch_UnknownFields(t *testing.T) {
+	_, err := readTestPatch(t, `{"name":"Jane","role":"staff","Avatar":"x"}`)
+
+	var errs validation.Errors
+	require.ErrorAs(t, err, &errs)
+	assert.Len(t, errs, 2)
+	assert.Equal(t, ErrUnknownField, errs["role"])
+	assert.Equal(t, ErrUnknownField, errs["Avatar"], "the keys are matched exactly")
+}
+
+func TestReadPatch_Malformed(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"not an object", `["name"]`},
+		{"null", `null`},
+		{"wrong type", `{"year":"2024"}`},
+		{"trailing data", `{"name":"Jane"} {}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := readTestPatch(t, tt.body)
+			var i18nErr *errorx.I18nError
+			require.ErrorAs(t, err, &i18nErr)
+			assert.Equal(t, errorx.CodeMalformedJSON, i18nErr.Code)
+		})
+	}
+}
+
+func TestReadPatch_DuplicateKey(t *testing.T) {
+	_, err := readTestPatch(t, `{"name":"Jane","name":null}`)
+
+	var errs validation.Errors
+	require.ErrorAs(t, err, &errs)
+	assert.Equal(t, ErrDuplicateKey, errs["name"])
+}
+
+func TestField_MarshalJSON(t *testing.T) {
+	data, err := json.Marshal(testPatch{Name: SetTo("Jane"), Avatar: Clear[string]()})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"name":"Jane","avatar":null}`, string(data))
+}
+
+func TestField_Optional(t *testing.T) {
+	p, err := readTestPatch(t, `{"name":"Jane","avatar":null}`)
+	require.NoError(t, err)
+
+	assert.Equal(t, optional.SetTo("Jane"), p.Changes.Name.Optional())
+	assert.Equal(t, optional.Clear[string](), p.Changes.Avatar.Optional())
+	assert.Equal(t, optional.Field[int]{}, p.Changes.Year.Optional(), "a field left out is not set")
+}
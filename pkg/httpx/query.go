@@ -0,0 +1,161 @@
+package httpx
+
+import (
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ARUMANDESU/validation"
+	"github.com/ARUMANDESU/validation/is"
+	"github.com/google/uuid"
+
+	"gitlab.com/ucmsv2/ucms-backend/pkg/i18nx"
+)
+
+var ErrInvalidBool = validation.NewError(i18nx.ValidationIsBool, i18nx.MsgValidationIsBoolOther)
+
+// QueryParams reads typed query parameters. Missing parameters are optional
+// and get the zero value or the default, Require makes them required. The
+// errors are collected per parameter and returned together by Err, so that
+// the error handler renders them like the body validation errors.
+type QueryParams struct {
+	values url.Values
+	errs   validation.Errors
+}
+
+func Query(r *http.Request) *QueryParams {
+	return &QueryParams{values: r.URL.Query(), errs: validation.Errors{}}
+}
+
+// Err returns the collected errors as validation.Errors, or nil.
+func (q *QueryParams) Err() error {
+	return q.errs.Filter()
+}
+
+func (q *QueryParams) get(name string) (string, bool) {
+	value := strings.TrimSpace(q.values.Get(name))
+	return value, value != ""
+}
+
+func (q *QueryParams) fail(name string, err error) {
+	if _, ok := q.errs[name]; !ok {
+		q.errs[name] = err
+	}
+}
+
+// Require reports the parameters that are missing or empty.
+func (q *QueryParams) Require(names ...string) *QueryParams {
+	for _, name := range names {
+		if _, ok := q.get(name); !ok {
+			q.fail(name, validation.ErrRequired)
+		}
+	}
+	return q
+}
+
+func (q *QueryParams) String(name string) string {
+	value, _ := q.get(name)
+	return value
+}
+
+// UUID returns uuid.Nil when the parameter is missing or invalid.
+func (q *QueryParams) UUID(name string) uuid.UUID {
+	value, ok := q.get(name)
+	if !ok {
+		return uuid.Nil
+	}
+	id, err := uuid.Parse(value)
+	if err != nil {
+		q.fail(name, is.ErrUUID)
+		return uuid.Nil
+	}
+	return id
+}
+
+// Time parses the parameter with layout, e.g. time.RFC3339 or time.DateOnly.
+// It returns the zero time when the parameter is missing or invalid.
+func (q *QueryParams) Time(name, layout string) time.Time {
+	value, ok := q.get(name)
+	if !ok {
+		return time.Time{}
+	}
+	t, err := time.Parse(layout, value)
+	if err != nil {
+		q.fail(name, validation.ErrDateInvalid)
+		return time.Time{}
+	}
+	return t
+}
+
+// Int returns the parameter between minValue and maxValue inclusive, or
+// defaultValue when it is missing or invalid.
+func (q *QueryParams) Int(name string, minValue, maxValue, defaultValue int) int {
+	value, ok := q.get(name)
+	if !ok {
+		return defaultValue
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		q.fail(name, is.ErrInt)
+		return defaultValue
+	}
+	if err := validation.Validate(n, validation.Min(minValue), validation.Max(maxValue)); err != nil {
+		q.fail(name, err)
+		return defaultValue
+	}
+	return n
+}
+
+// Enum returns the parameter when it is one of allowed, or "" when it is
+// missing or unknown.
+func (q *QueryParams) Enum(name string, allowed ...string) string {
+	value, ok := q.get(name)
+	if !ok {
+		return ""
+	}
+	if !slices.Contains(allowed, value) {
+		q.fail(name, validation.ErrInInvalid)
+		return ""
+	}
+	return value
+}
+
+// EnumList returns the comma separated values of the parameter when all are
+// one of allowed, duplicates removed, or nil when it is missing or has an
+// unknown value.
+func (q *QueryParams) EnumList(name string, allowed ...string) []string {
+	value, ok := q.get(name)
+	if !ok {
+		return nil
+	}
+	var values []string
+	for _, v := range strings.Split(value, ",") {
+		v = strings.TrimSpace(v)
+		if !slices.Contains(allowed, v) {
+			q.fail(name, validation.ErrInInvalid)
+			return nil
+		}
+		if !slices.Contains(values, v) {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// Bool accepts the values of strconv.ParseBool, it returns defaultValue when
+// the parameter is missing or invalid.
+func (q *QueryParams) Bool(name string, defaultValue bool) bool {
+	value, ok := q.get(name)
+	if !ok {
+		return defaultValue
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		q.fail(name, ErrInvalidBool)
+		return defaultValue
+	}
+	return b
+}
@@ -0,0 +1,133 @@
+package httpx
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ARUMANDESU/validation"
+	"github.com/ARUMANDESU/validation/is"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func queryErrors(t *testing.T, q *QueryParams) validation.Errors {
+	t.Helper()
+	var errs validation.Errors
+	require.ErrorAs(t, q.Err(), &errs)
+	return errs
+}
+
+func assertCode(t *testing.T, err error, expected validation.Error) {
+	t.Helper()
+	var verr validation.Error
+	require.ErrorAs(t, err, &verr)
+	assert.Equal(t, expected.Code(), verr.Code())
+}
+
+func TestQuery_Valid(t *testing.T) {
+	id := uuid.New()
+	r := httptest.NewRequest(http.MethodGet, "/?id="+id.String()+"&from=2025-03-10&page=3&sort=name&active=false", nil)
+
+	q := Query(r).Require("id", "page")
+	assert.Equal(t, id, q.UUID("id"))
+	assert.Equal(t, time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC), q.Time("from", time.DateOnly))
+	assert.Equal(t, 3, q.Int("page", 1, 100, 1))
+	assert.Equal(t, "name", q.Enum("sort", "name", "created_at"))
+	assert.False(t, q.Bool("active", true))
+	assert.NoError(t, q.Err())
+}
+
+func TestQuery_MissingOptional(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/?page=&sort=%20", nil)
+
+	q := Query(r)
+	assert.Equal(t, uuid.Nil, q.UUID("id"))
+	assert.True(t, q.Time("from", time.RFC3339).IsZero())
+	assert.Equal(t, 1, q.Int("page", 1, 100, 1), "empty parameters are missing")
+	assert.Empty(t, q.Enum("sort", "name"))
+	assert.True(t, q.Bool("active", true))
+	assert.NoError(t, q.Err())
+}
+
+func TestQuery_MissingRequired(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/?page=2&id=", nil)
+
+	errs := queryErrors(t, Query(r).Require("id", "page", "status"))
+	assert.Len(t, errs, 2)
+	assertCode(t, errs["id"], validation.ErrRequired)
+	assertCode(t, errs["status"], validation.ErrRequired)
+}
+
+func TestQuery_Invalid(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/?id=123&from=yesterday&page=abc&limit=500&offset=-1&sort=drop&active=maybe", nil)
+
+	q := Query(r)
+	assert.Equal(t, uuid.Nil, q.UUID("id"))
+	assert.True(t, q.Time("from", time.DateOnly).IsZero())
+	assert.Equal(t, 1, q.Int("page", 1, 100, 1))
+	assert.Equal(t, 50, q.Int("limit", 1, 200, 50))
+	assert.Equal(t, 0, q.Int("offset", 0, 1000, 0))
+	assert.Empty(t, q.Enum("sort", "name", "created_at"))
+	assert.True(t, q.Bool("active", true))
+
+	errs := queryErrors(t, q)
+	assert.Len(t, errs, 7, "every invalid parameter is reported")
+	assertCode(t, errs["id"], is.ErrUUID)
+	assertCode(t, errs["from"], validation.ErrDateInvalid)
+	assertCode(t, errs["page"], is.ErrInt)
+	assertCode(t, errs["limit"], validation.ErrMaxLessEqualThanRequired)
+	assertCode(t, errs["offset"], validation.ErrMinGreaterEqualThanRequired)
+	assertCode(t, errs["sort"], validation.ErrInInvalid)
+	assertCode(t, errs["active"], ErrInvalidBool)
+}
+
+func TestQuery_EnumList(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/?types=users,%20groups,users&bad=users,,groups", nil)
+
+	q := Query(r)
+	assert.Equal(t, []string{"users", "groups"}, q.EnumList("types", "users", "groups"))
+	assert.Nil(t, q.EnumList("missing", "users"))
+	assert.Nil(t, q.EnumList("bad", "users", "groups"))
+
+	errs := queryErrors(t, q)
+	assert.Len(t, errs, 1)
+	assertCode(t, errs["bad"], validation.ErrInInvalid)
+}
+
+func TestQuery_FirstErrorWins(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/?id=nope", nil)
+
+	q := Query(r)
+	q.UUID("id")
+	q.Require("id")
+
+	assertCode(t, queryErrors(t, q)["id"], is.ErrUUID)
+}
+
+func TestReadUUIDUrlParam(t *testing.T) {
+	withParam := func(value string) *http.Request {
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("invitation_id", value)
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		return r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+	}
+
+	id := uuid.New()
+	got, err := ReadUUIDUrlParam(withParam(id.String()), "invitation_id")
+	require.NoError(t, err)
+	assert.Equal(t, id, got)
+
+	_, err = ReadUUIDUrlParam(withParam("not-a-uuid"), "invitation_id")
+	var errs validation.Errors
+	require.ErrorAs(t, err, &errs)
+	assertCode(t, errs["invitation_id"], is.ErrUUID)
+
+	_, err = ReadUUIDUrlParam(withParam(""), "invitation_id")
+	require.ErrorAs(t, err, &errs)
+	assertCode(t, errs["invitation_id"], validation.ErrRequired)
+}
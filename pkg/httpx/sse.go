@@ -0,0 +1,89 @@
+package httpx
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// EventStream writes server-sent events, each one flushed to the client as
+// soon as it is written.
+type EventStream struct {
+	w    http.ResponseWriter
+	rc   *http.ResponseController
+	wait time.Duration
+}
+
+// NewEventStream starts the event stream response of w. Every event must be
+// written within writeTimeout, it replaces the write timeout of the server
+// that would otherwise end the stream; 0 keeps the server's. It panics on a
+// BufferedResponse, a stream must not run in the transaction of its request.
+func NewEventStream(w http.ResponseWriter, writeTimeout time.Duration) (*EventStream, error) {
+	if isBuffered(w) {
+		panic("httpx: event stream on a buffered response, the route must not be transactional")
+	}
+	s := &EventStream{w: w, rc: http.NewResponseController(w), wait: writeTimeout}
+
+	h := w.Header()
+	h.Set("Content-Type", "text/event-stream")
+	h.Set("Cache-Control", "no-cache")
+	h.Set("Connection", "keep-alive")
+	h.Set("X-Accel-Buffering", "no") // Nginx must not buffer the events.
+	if err := s.extendDeadline(); err != nil {
+		return nil, err
+	}
+	w.WriteHeader(http.StatusOK)
+
+	return s, s.rc.Flush()
+}
+
+// Send writes the event of data as JSON. id is the Last-Event-ID the client
+// resumes from, the events it does not resume from have no id; event names
+// the listener of the client, "" is "message".
+func (s *EventStream) Send(id, event string, data any) error {
+	js, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	if id != "" {
+		fmt.Fprintf(&b, "id: %s\n", id)
+	}
+	if event != "" {
+		fmt.Fprintf(&b, "event: %s\n", event)
+	}
+	fmt.Fprintf(&b, "data: %s\n\n", js)
+
+	return s.write(b.String())
+}
+
+// Comment writes a comment the client ignores, it keeps the connection and
+// the proxies in between alive.
+func (s *EventStream) Comment(text string) error {
+	return s.write(": " + text + "\n\n")
+}
+
+func (s *EventStream) write(frame string) error {
+	if err := s.extendDeadline(); err != nil {
+		return err
+	}
+	if _, err := s.w.Write([]byte(frame)); err != nil {
+		return err
+	}
+	return s.rc.Flush()
+}
+
+func (s *EventStream) extendDeadline() error {
+	if s.wait == 0 {
+		return nil
+	}
+	err := s.rc.SetWriteDeadline(time.Now().Add(s.wait))
+	if errors.Is(err, http.ErrNotSupported) {
+		return nil
+	}
+	return err
+}
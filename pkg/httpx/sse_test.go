@@ -0,0 +1,37 @@
+package httpx
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventStream(t *testing.T) {
+	w := httptest.NewRecorder()
+
+	s, err := NewEventStream(w, time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, "text/event-stream", w.Header().Get("Content-Type"))
+	assert.Equal(t, "no-cache", w.Header().Get("Cache-Control"))
+	assert.True(t, w.Flushed)
+
+	require.NoError(t, s.Send("", "unread_count", map[string]int{"unread_count": 2}))
+	require.NoError(t, s.Comment("heartbeat"))
+	require.NoError(t, s.Send("42", "notification", map[string]string{"id": "42"}))
+
+	assert.Equal(t, "event: unread_count\ndata: {\"unread_count\":2}\n\n"+
+		": heartbeat\n\n"+
+		"id: 42\nevent: notification\ndata: {\"id\":\"42\"}\n\n", w.Body.String())
+}
+
+func TestEventStream_UnmarshalableData(t *testing.T) {
+	w := httptest.NewRecorder()
+	s, err := NewEventStream(w, 0)
+	require.NoError(t, err)
+
+	assert.Error(t, s.Send("", "", func() {}))
+	assert.Empty(t, w.Body.String())
+}
@@ -0,0 +1,62 @@
+package httpx
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Time is a time.Time in the JSON of the API: RFC3339 in UTC with second
+// precision, e.g. "2025-03-30T01:30:00Z". It reads any RFC3339 time and
+// normalizes it the same way, see NormalizeTime.
+type Time struct {
+	time.Time
+}
+
+// NewTime returns t as a Time.
+func NewTime(t time.Time) Time {
+	return Time{Time: t}
+}
+
+// NewTimePtr returns t as a Time, nil for nil.
+func NewTimePtr(t *time.Time) *Time {
+	if t == nil {
+		return nil
+	}
+	return &Time{Time: *t}
+}
+
+func (t Time) MarshalJSON() ([]byte, error) {
+	return json.Marshal(FormatTime(t.Time))
+}
+
+func (t *Time) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	var parsed time.Time
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		return err
+	}
+	t.Time = NormalizeTime(parsed)
+	return nil
+}
+
+// FormatTime formats t the way Time does.
+func FormatTime(t time.Time) string {
+	return NormalizeTime(t).Format(time.RFC3339)
+}
+
+// NormalizeTime returns t in UTC without the fraction of a second, the
+// times read from the requests are normalized before reaching the domain.
+func NormalizeTime(t time.Time) time.Time {
+	return t.UTC().Truncate(time.Second)
+}
+
+// NormalizeTimePtr is NormalizeTime for an optional time, nil stays nil.
+func NormalizeTimePtr(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	normalized := NormalizeTime(*t)
+	return &normalized
+}
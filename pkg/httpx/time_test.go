@@ -0,0 +1,61 @@
+package httpx
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTime_MarshalJSON(t *testing.T) {
+	almaty := time.FixedZone("Asia/Almaty", 5*60*60)
+	tests := []struct {
+		name     string
+		time     time.Time
+		expected string
+	}{
+		{"utc", time.Date(2025, 3, 30, 1, 30, 0, 0, time.UTC), `"2025-03-30T01:30:00Z"`},
+		{"offset is converted to utc", time.Date(2025, 3, 30, 6, 30, 0, 0, almaty), `"2025-03-30T01:30:00Z"`},
+		{"fraction is dropped", time.Date(2025, 3, 30, 1, 30, 0, 999_999_999, time.UTC), `"2025-03-30T01:30:00Z"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(NewTime(tt.time))
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, string(data))
+		})
+	}
+}
+
+func TestTime_UnmarshalJSON(t *testing.T) {
+	var v struct {
+		At       Time  `json:"at"`
+		Optional *Time `json:"optional"`
+	}
+	err := json.Unmarshal([]byte(`{"at":"2025-10-26T03:30:00.123+02:00","optional":null}`), &v)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2025, 10, 26, 1, 30, 0, 0, time.UTC), v.At.Time)
+	assert.Equal(t, time.UTC, v.At.Location())
+	assert.Nil(t, v.Optional)
+
+	err = json.Unmarshal([]byte(`{"at":"2025-10-26 03:30"}`), &v)
+	assert.Error(t, err)
+}
+
+func TestTime_RoundTrip(t *testing.T) {
+	in := `"2025-10-26T01:30:00Z"`
+	var v Time
+	require.NoError(t, json.Unmarshal([]byte(in), &v))
+	out, err := json.Marshal(v)
+	require.NoError(t, err)
+	assert.Equal(t, in, string(out))
+}
+
+func TestNormalizeTimePtr(t *testing.T) {
+	assert.Nil(t, NormalizeTimePtr(nil))
+
+	in := time.Date(2025, 3, 30, 3, 30, 0, 500, time.FixedZone("CEST", 2*60*60))
+	assert.Equal(t, time.Date(2025, 3, 30, 1, 30, 0, 0, time.UTC), *NormalizeTimePtr(&in))
+}
@@ -21,6 +21,7 @@ const (
 	KeyDuplicateEntryWithField   = "duplicate_entry_with_field"
 	KeyRateLimitExceeded         = "rate_limit_exceeded"
 	KeyRateLimitExceededWithTime = "rate_limit_exceeded_with_time"
+	KeyInvalidCursor             = "invalid_cursor"
 
 	// Idempotency errors
 	KeyIdempotencyKeyMissing    = "idempotency_key_missing"
@@ -30,6 +31,8 @@ const (
 	// Password validation
 	KeyPasswordTooWeak       = "password_too_weak"
 	KeyPasswordFormatInvalid = "password_format_invalid"
+	KeyPasswordReused        = "password_reused"
+	KeyPasswordBreached      = "password_breached"
 
 	// Business logic errors
 	KeyAlreadyProcessed        = "already_processed"
@@ -49,6 +52,7 @@ const (
 	KeyInvalidRefreshTokenClaims = "invalid_refresh_token_claims"
 	KeyInvalidRefreshTokenExp    = "invalid_refresh_token_exp"
 	KeyRefreshTokenExpired       = "refresh_token_expired"
+	KeyStudentExpelled           = "student_expelled"
 
 	// Registration specific
 	KeyEmailMaxLen          = "email_max_len"
@@ -59,16 +63,38 @@ const (
 	KeyUsernameNotAvailable = "error_username_not_available"
 
 	// Staff invitation specific
-	KeyInvalidInvitation       = "invalid_invitation"
-	KeyTimestampInPast         = "timestamp_in_past"
-	KeyAtLeastOneEmail         = "at_least_one_email"
-	KeyEmailAlreadyExistsField = "email_already_exists_field"
-	KeyMaxEmailsExceededField  = "max_emails_exceeded_field"
+	KeyInvalidInvitation         = "invalid_invitation"
+	KeyTimestampInPast           = "timestamp_in_past"
+	KeyAtLeastOneEmail           = "at_least_one_email"
+	KeyEmailAlreadyExistsField   = "email_already_exists_field"
+	KeyMaxEmailsExceededField    = "max_emails_exceeded_field"
+	KeyPendingRecipientsExceeded = "pending_recipients_exceeded"
 
 	// Business errors
 	KeyCodeExpired             = "business_error_code_expired"
 	KeyVerifyFirst             = "business_error_verify_first"
 	KeyInvalidVerificationCode = "business_error_invalid_verification_code"
+
+	// Student enrollment
+	KeyIllegalEnrollmentTransition = "illegal_enrollment_transition"
+
+	// Upload errors
+	KeyUploadInfected              = "upload.infected"
+	KeyUploadImageInvalid          = "upload.image_invalid"
+	KeyUploadImageAnimated         = "upload.image_animated"
+	KeyUploadImageDimensionsTooBig = "upload.image_dimensions_too_big"
+
+	// Terms of service
+	KeyTOSReconsentRequired = "tos.reconsent_required"
+
+	// Email verification
+	KeyEmailVerificationRequired = "email.verification_required"
+
+	// Groups
+	KeyGroupEnrollmentClosed = "group.enrollment_closed"
+
+	// Terms
+	KeyTermOverlap = "term.overlap"
 )
 
 // Validation message keys (project-specific validation errors)
@@ -79,12 +105,19 @@ const (
 	ValidationIsPassword          = "validation_is_password"
 	ValidationIsName              = "validation_is_name"
 	ValidationIsUsername          = "validation_is_username"
+	ValidationIsPhone             = "validation_is_phone"
+	ValidationIsBool              = "validation_is_bool"
 	ValidationNoDuplicate         = "validation_no_duplicate"
 	ValidationTimeInPast          = "validation_time_in_past"
 	ValidationTimeBeforeThreshold = "validation_time_before_threshold"
+	ValidationTimeAfterThreshold  = "validation_time_after_threshold"
 	ValidationFileSizeTooLarge    = "validation_file_size_too_large"
 	ValidationFileSizeTooSmall    = "validation_file_size_too_small"
 	ValidationInvalidFileType     = "validation_invalid_file_type"
+	ValidationUnknownField        = "validation.unknown_field"
+	ValidationDuplicateKey        = "validation.duplicate_key"
+	ValidationNameScript          = "validation.name_script"
+	ValidationUsernameScript      = "validation.username_script"
 )
 
 // Validation messages (English defaults)
@@ -93,12 +126,19 @@ const (
 	MsgValidationIsPasswordOther          = "must contain at least 8 characters with uppercase, lowercase, number, and special character"
 	MsgValidationIsNameOther              = "must contain only letters, spaces, and common name characters"
 	MsgValidationIsUsernameOther          = "must be between 3 and 30 characters long, start with a letter, and contain only lowercase letters, digits, periods, and underscores. Cannot contain consecutive periods or underscores, or period followed by underscore or vice versa"
+	MsgValidationIsPhoneOther             = "must be a valid phone number, e.g. +77021234567"
+	MsgValidationIsBoolOther              = "must be true or false"
 	MsgValidationNoDuplicateOther         = "duplicate values are not allowed"
 	MsgValidationTimeInPastOther          = "time cannot be in the past"
 	MsgValidationTimeBeforeThresholdOther = "time must be after {{.threshold}}"
+	MsgValidationTimeAfterThresholdOther  = "time must not be after {{.threshold}}"
 	MsgValidationFileSizeTooLargeOther    = "file size must not exceed {{.threshold}} {{.unit}}"
 	MsgValidationFileSizeTooSmallOther    = "file size must be at least {{.threshold}} {{.unit}}"
 	MsgValidationInvalidFileTypeOther     = "file type must be one of the allowed types: {{.list}}"
+	MsgValidationUnknownFieldOther        = "is not a known field"
+	MsgValidationDuplicateKeyOther        = "must not be repeated"
+	MsgValidationNameScriptOther          = "must be written in plain Latin, Cyrillic or CJK letters, without mixing them"
+	MsgValidationUsernameScriptOther      = "must be written in English letters"
 )
 
 // Field name keys
@@ -116,6 +156,16 @@ const (
 	FieldStatus           = "status"
 	FieldRecipientsEmail  = "recipients_email"
 	FieldMajor            = "major"
+	FieldAvatar           = "avatar"
+	FieldPhone            = "phone"
+	FieldTOSVersion       = "tos_version"
+	FieldAcceptTOS        = "accept_tos"
+
+	FieldEnrollmentOpensAt  = "enrollment_opens_at"
+	FieldEnrollmentClosesAt = "enrollment_closes_at"
+
+	FieldTerm   = "term"
+	FieldTermID = "term_id"
 )
 
 // Template argument keys (snake_case naming)
@@ -123,11 +173,15 @@ const (
 	ArgLocalePrefix       = "locale_"
 	ArgLocaleResourceType = "locale_resource_type"
 
-	ArgField        = "field"
-	ArgResourceType = "resource_type"
-	ArgRetryAfter   = "retry_after"
-	ArgMaxEmails    = "max_emails"
-	ArgThreshold    = "threshold"
-	ArgUnit         = "unit"
-	ArgList         = "list"
+	ArgField         = "field"
+	ArgResourceType  = "resource_type"
+	ArgRetryAfter    = "retry_after"
+	ArgMaxEmails     = "max_emails"
+	ArgMaxRecipients = "max_recipients"
+	ArgThreshold     = "threshold"
+	ArgUnit          = "unit"
+	ArgList          = "list"
+	ArgOpensAt       = "opens_at"
+	ArgClosesAt      = "closes_at"
+	ArgTerm          = "term"
 )
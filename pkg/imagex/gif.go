@@ -0,0 +1,116 @@
+package imagex
+
+import (
+	"bytes"
+	"fmt"
+	"image/gif"
+)
+
+const (
+	gifExtension       = 0x21
+	gifImageDescriptor = 0x2C
+	gifTrailer         = 0x3B
+
+	gifCommentLabel     = 0xFE
+	gifApplicationLabel = 0xFF // looping, XMP
+)
+
+func sanitizeGIF(content []byte, opts Options) (*Result, error) {
+	cfg, err := gif.DecodeConfig(bytes.NewReader(content))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrMalformed, err)
+	}
+	if err := checkDimensions(cfg.Width, cfg.Height, opts); err != nil {
+		return nil, err
+	}
+
+	stripped, err := stripGIF(content)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Result{Format: FormatGIF, Width: cfg.Width, Height: cfg.Height, Content: stripped}, nil
+}
+
+// stripGIF walks the GIF blocks without decoding any frame. It rejects more
+// than one frame, drops comment and application extensions and anything
+// after the trailer.
+func stripGIF(content []byte) ([]byte, error) {
+	const headerLen = 13 // signature and logical screen descriptor
+	if len(content) < headerLen {
+		return nil, fmt.Errorf("%w: truncated gif header", ErrMalformed)
+	}
+	pos := headerLen
+	if flags := content[10]; flags&0x80 != 0 {
+		pos += 3 << ((flags & 0x07) + 1) // global color table
+	}
+	if pos > len(content) {
+		return nil, fmt.Errorf("%w: truncated gif color table", ErrMalformed)
+	}
+
+	out := make([]byte, 0, len(content))
+	out = append(out, content[:pos]...)
+	frames := 0
+
+	for pos < len(content) {
+		start := pos
+		switch content[pos] {
+		case gifTrailer:
+			if frames == 0 {
+				return nil, fmt.Errorf("%w: gif without frames", ErrMalformed)
+			}
+			return append(out, gifTrailer), nil
+		case gifExtension:
+			if pos+2 > len(content) {
+				return nil, fmt.Errorf("%w: truncated gif extension", ErrMalformed)
+			}
+			label := content[pos+1]
+			end, err := skipGIFSubBlocks(content, pos+2)
+			if err != nil {
+				return nil, err
+			}
+			pos = end
+			if label == gifCommentLabel || label == gifApplicationLabel {
+				continue
+			}
+		case gifImageDescriptor:
+			frames++
+			if frames > 1 {
+				return nil, ErrAnimated
+			}
+			pos += 10
+			if pos > len(content) {
+				return nil, fmt.Errorf("%w: truncated gif image descriptor", ErrMalformed)
+			}
+			if flags := content[pos-1]; flags&0x80 != 0 {
+				pos += 3 << ((flags & 0x07) + 1) // local color table
+			}
+			pos++ // LZW minimum code size
+			end, err := skipGIFSubBlocks(content, pos)
+			if err != nil {
+				return nil, err
+			}
+			pos = end
+		default:
+			return nil, fmt.Errorf("%w: unknown gif block 0x%02x", ErrMalformed, content[pos])
+		}
+		out = append(out, content[start:pos]...)
+	}
+
+	return nil, fmt.Errorf("%w: missing gif trailer", ErrMalformed)
+}
+
+// skipGIFSubBlocks returns the position after the sub-blocks starting at pos.
+func skipGIFSubBlocks(content []byte, pos int) (int, error) {
+	for {
+		if pos >= len(content) {
+			return 0, fmt.Errorf("%w: truncated gif data", ErrMalformed)
+		}
+		size := int(content[pos])
+		pos++
+		if size == 0 {
+			return pos, nil
+		}
+		pos += size
+	}
+}
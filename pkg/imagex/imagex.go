@@ -0,0 +1,112 @@
+// Package imagex sanitizes user uploaded images before they are stored.
+//
+// Images are inspected from their headers first, so oversized and animated
+// images are rejected before anything is decoded. Metadata is stripped
+// without re-encoding where possible, only images that need their EXIF
+// orientation applied are decoded and re-encoded.
+package imagex
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+type Format string
+
+const (
+	FormatJPEG Format = "jpeg"
+	FormatPNG  Format = "png"
+	FormatGIF  Format = "gif"
+	FormatWebP Format = "webp"
+)
+
+func (f Format) ContentType() string {
+	return "image/" + string(f)
+}
+
+const (
+	DefaultMaxDimension = 8000
+	DefaultJPEGQuality  = 90
+)
+
+var (
+	ErrUnsupported = errors.New("unsupported image format")
+	ErrMalformed   = errors.New("malformed image")
+	ErrAnimated    = errors.New("animated images are not supported")
+	ErrTooLarge    = errors.New("image dimensions exceed the limit")
+)
+
+type Options struct {
+	// MaxWidth and MaxHeight default to DefaultMaxDimension.
+	MaxWidth  int
+	MaxHeight int
+	// JPEGQuality is used when a JPEG has to be re-encoded. Defaults to DefaultJPEGQuality.
+	JPEGQuality int
+}
+
+type Result struct {
+	Format Format
+	// Width and Height are the dimensions after the orientation was applied.
+	Width  int
+	Height int
+	// Content is the sanitized image.
+	Content []byte
+	// Reencoded is set when the image had to be decoded to apply its orientation.
+	Reencoded bool
+}
+
+// Sanitize detects the image format from the content, enforces the
+// dimension limits, rejects animations, applies the EXIF orientation and
+// strips metadata as well as any data trailing the image.
+func Sanitize(content []byte, opts Options) (*Result, error) {
+	if opts.MaxWidth <= 0 {
+		opts.MaxWidth = DefaultMaxDimension
+	}
+	if opts.MaxHeight <= 0 {
+		opts.MaxHeight = DefaultMaxDimension
+	}
+	if opts.JPEGQuality <= 0 {
+		opts.JPEGQuality = DefaultJPEGQuality
+	}
+
+	switch DetectFormat(content) {
+	case FormatJPEG:
+		return sanitizeJPEG(content, opts)
+	case FormatPNG:
+		return sanitizePNG(content, opts)
+	case FormatGIF:
+		return sanitizeGIF(content, opts)
+	case FormatWebP:
+		return sanitizeWebP(content, opts)
+	default:
+		return nil, ErrUnsupported
+	}
+}
+
+// DetectFormat sniffs the format from the magic bytes, it returns an empty
+// format for anything else.
+func DetectFormat(content []byte) Format {
+	switch {
+	case bytes.HasPrefix(content, []byte{0xFF, 0xD8, 0xFF}):
+		return FormatJPEG
+	case bytes.HasPrefix(content, pngSignature):
+		return FormatPNG
+	case bytes.HasPrefix(content, []byte("GIF87a")), bytes.HasPrefix(content, []byte("GIF89a")):
+		return FormatGIF
+	case len(content) >= 12 && string(content[:4]) == "RIFF" && string(content[8:12]) == "WEBP":
+		return FormatWebP
+	default:
+		return ""
+	}
+}
+
+func checkDimensions(width, height int, opts Options) error {
+	if width <= 0 || height <= 0 {
+		return fmt.Errorf("%w: invalid dimensions %dx%d", ErrMalformed, width, height)
+	}
+	if width > opts.MaxWidth || height > opts.MaxHeight {
+		return fmt.Errorf("%w: %dx%d, max %dx%d", ErrTooLarge, width, height, opts.MaxWidth, opts.MaxHeight)
+	}
+	return nil
+}
@@ -0,0 +1,278 @@
+package imagex
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	red  = color.RGBA{R: 255, A: 255}
+	blue = color.RGBA{B: 255, A: 255}
+)
+
+// halfImage is red on the left half and blue on the right half.
+func halfImage(w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := range h {
+		for x := range w {
+			if x < w/2 {
+				img.Set(x, y, red)
+			} else {
+				img.Set(x, y, blue)
+			}
+		}
+	}
+	return img
+}
+
+// exifSegment builds an APP1 segment holding only the orientation tag.
+func exifSegment(orientation uint16) []byte {
+	tiff := []byte("MM\x00\x2a\x00\x00\x00\x08")
+	tiff = binary.BigEndian.AppendUint16(tiff, 1) // one IFD entry
+	tiff = binary.BigEndian.AppendUint16(tiff, tagOrientation)
+	tiff = binary.BigEndian.AppendUint16(tiff, typeShort)
+	tiff = binary.BigEndian.AppendUint32(tiff, 1)
+	tiff = binary.BigEndian.AppendUint16(tiff, orientation)
+	tiff = append(tiff, 0, 0, 0, 0, 0, 0) // value padding and next IFD
+
+	payload := append(bytes.Clone(exifHeader), tiff...)
+	segment := []byte{0xFF, markerAPP1}
+	segment = binary.BigEndian.AppendUint16(segment, uint16(len(payload)+2))
+	return append(segment, payload...)
+}
+
+func jpegWithOrientation(t *testing.T, img image.Image, orientation uint16) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, img, &jpeg.Options{Quality: 95}))
+	encoded := buf.Bytes()
+
+	out := append([]byte{}, encoded[:2]...)
+	out = append(out, exifSegment(orientation)...)
+	return append(out, encoded[2:]...)
+}
+
+func assertColor(t *testing.T, want color.RGBA, got color.Color, msg string) {
+	t.Helper()
+	r, g, b, _ := got.RGBA()
+	wr, wg, wb, _ := want.RGBA()
+	near := func(a, b uint32) bool { return max(a, b)-min(a, b) < 0x3000 }
+	assert.True(t, near(r, wr) && near(g, wg) && near(b, wb), "%s: got %v, want %v", msg, got, want)
+}
+
+func TestSanitize_JPEGOrientation6IsUpright(t *testing.T) {
+	// Stored 32x16, red left and blue right. Orientation 6 means the camera
+	// was rotated, the upright image is 16x32 with red on top.
+	content := jpegWithOrientation(t, halfImage(32, 16), 6)
+
+	res, err := Sanitize(content, Options{})
+	require.NoError(t, err)
+	assert.Equal(t, FormatJPEG, res.Format)
+	assert.True(t, res.Reencoded)
+	assert.Equal(t, 16, res.Width)
+	assert.Equal(t, 32, res.Height)
+
+	img, err := jpeg.Decode(bytes.NewReader(res.Content))
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 16, 32), img.Bounds())
+	assertColor(t, red, img.At(8, 4), "top should be red")
+	assertColor(t, blue, img.At(8, 28), "bottom should be blue")
+
+	assert.NotContains(t, string(res.Content), "Exif", "metadata should be stripped")
+}
+
+func TestApplyOrientation(t *testing.T) {
+	// 2x1: red, blue.
+	src := halfImage(2, 1)
+
+	tests := []struct {
+		orientation int
+		bounds      image.Rectangle
+		first       color.RGBA // pixel at 0,0
+	}{
+		{orientation: 1, bounds: image.Rect(0, 0, 2, 1), first: red},
+		{orientation: 2, bounds: image.Rect(0, 0, 2, 1), first: blue},
+		{orientation: 3, bounds: image.Rect(0, 0, 2, 1), first: blue},
+		{orientation: 4, bounds: image.Rect(0, 0, 2, 1), first: red},
+		{orientation: 5, bounds: image.Rect(0, 0, 1, 2), first: red},
+		{orientation: 6, bounds: image.Rect(0, 0, 1, 2), first: red},
+		{orientation: 7, bounds: image.Rect(0, 0, 1, 2), first: blue},
+		{orientation: 8, bounds: image.Rect(0, 0, 1, 2), first: blue},
+	}
+
+	for _, tt := range tests {
+		got := applyOrientation(src, tt.orientation)
+		assert.Equal(t, tt.bounds, got.Bounds(), "orientation %d", tt.orientation)
+		assertColor(t, tt.first, got.At(0, 0), fmt.Sprintf("orientation %d", tt.orientation))
+	}
+}
+
+func TestSanitize_JPEGWithoutOrientationIsNotReencoded(t *testing.T) {
+	content := jpegWithOrientation(t, halfImage(8, 8), 1)
+	content = append(content, []byte("trailing data")...)
+
+	res, err := Sanitize(content, Options{})
+	require.NoError(t, err)
+	assert.False(t, res.Reencoded)
+	assert.NotContains(t, string(res.Content), "Exif")
+	assert.NotContains(t, string(res.Content), "trailing data")
+	assert.True(t, bytes.HasSuffix(res.Content, []byte{0xFF, markerEOI}))
+
+	_, err = jpeg.Decode(bytes.NewReader(res.Content))
+	assert.NoError(t, err)
+}
+
+// animatedWebP builds the container of an animated WebP, frames are not
+// needed to detect the animation.
+func animatedWebP() []byte {
+	chunk := func(fourCC string, payload []byte) []byte {
+		c := append([]byte(fourCC), binary.LittleEndian.AppendUint32(nil, uint32(len(payload)))...)
+		c = append(c, payload...)
+		if len(payload)%2 == 1 {
+			c = append(c, 0)
+		}
+		return c
+	}
+	vp8x := make([]byte, 10)
+	vp8x[0] = webpFlagAnimation
+	vp8x[4], vp8x[7] = 63, 63 // 64x64 canvas
+
+	body := append([]byte("WEBP"), chunk("VP8X", vp8x)...)
+	body = append(body, chunk("ANIM", make([]byte, 6))...)
+	body = append(body, chunk("ANMF", make([]byte, 16))...)
+	return append(append([]byte("RIFF"), binary.LittleEndian.AppendUint32(nil, uint32(len(body)))...), body...)
+}
+
+func TestSanitize_AnimatedWebPRejected(t *testing.T) {
+	_, err := Sanitize(animatedWebP(), Options{})
+	assert.ErrorIs(t, err, ErrAnimated)
+}
+
+func TestSanitize_AnimatedGIFRejected(t *testing.T) {
+	frame := func(c color.Color) *image.Paletted {
+		img := image.NewPaletted(image.Rect(0, 0, 4, 4), color.Palette{red, blue})
+		for i := range img.Pix {
+			img.Pix[i] = uint8(img.Palette.Index(c))
+		}
+		return img
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, gif.EncodeAll(&buf, &gif.GIF{
+		Image: []*image.Paletted{frame(red), frame(blue)},
+		Delay: []int{10, 10},
+	}))
+	_, err := Sanitize(buf.Bytes(), Options{})
+	assert.ErrorIs(t, err, ErrAnimated)
+
+	buf.Reset()
+	require.NoError(t, gif.EncodeAll(&buf, &gif.GIF{Image: []*image.Paletted{frame(red)}, Delay: []int{0}}))
+	res, err := Sanitize(buf.Bytes(), Options{})
+	require.NoError(t, err, "a single frame gif is fine")
+	_, err = gif.Decode(bytes.NewReader(res.Content))
+	assert.NoError(t, err)
+}
+
+func TestSanitize_AnimatedPNGRejected(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, halfImage(4, 4)))
+	content := buf.Bytes()
+
+	// acTL goes before the first IDAT, right after IHDR.
+	ihdrEnd := len(pngSignature) + 12 + 13
+	apng := append(bytes.Clone(content[:ihdrEnd]), pngChunk("acTL", make([]byte, 8))...)
+	apng = append(apng, content[ihdrEnd:]...)
+
+	_, err := Sanitize(apng, Options{})
+	assert.ErrorIs(t, err, ErrAnimated)
+}
+
+func pngChunk(typ string, data []byte) []byte {
+	c := binary.BigEndian.AppendUint32(nil, uint32(len(data)))
+	c = append(c, typ...)
+	c = append(c, data...)
+	return binary.BigEndian.AppendUint32(c, crc32.ChecksumIEEE(c[4:]))
+}
+
+// pngBomb declares a 20000x20000 image with a tiny, bogus payload.
+func pngBomb() []byte {
+	ihdr := binary.BigEndian.AppendUint32(nil, 20000)
+	ihdr = binary.BigEndian.AppendUint32(ihdr, 20000)
+	ihdr = append(ihdr, 8, 6, 0, 0, 0) // 8 bit RGBA
+
+	out := bytes.Clone(pngSignature)
+	out = append(out, pngChunk("IHDR", ihdr)...)
+	out = append(out, pngChunk("IDAT", []byte{0x78, 0x9c, 0x03, 0x00})...)
+	return append(out, pngChunk("IEND", nil)...)
+}
+
+func TestSanitize_DecompressionBombRejectedWithoutAllocating(t *testing.T) {
+	bomb := pngBomb()
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	_, err := Sanitize(bomb, Options{MaxWidth: 8000, MaxHeight: 8000})
+	runtime.ReadMemStats(&after)
+
+	require.ErrorIs(t, err, ErrTooLarge)
+	// Decoding would need 20000*20000*4 bytes, header sniffing a few KB.
+	assert.Less(t, after.TotalAlloc-before.TotalAlloc, uint64(1<<20), "bomb should be rejected before decoding")
+}
+
+func TestSanitize_PNGMetadataStripped(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, halfImage(4, 4)))
+	content := buf.Bytes()
+
+	ihdrEnd := len(pngSignature) + 12 + 13
+	withText := append(bytes.Clone(content[:ihdrEnd]), pngChunk("tEXt", []byte("Comment\x00secret location"))...)
+	withText = append(withText, content[ihdrEnd:]...)
+	withText = append(withText, []byte("trailing data")...)
+
+	res, err := Sanitize(withText, Options{})
+	require.NoError(t, err)
+	assert.Equal(t, content, res.Content)
+}
+
+func TestSanitize_WebPMetadataStripped(t *testing.T) {
+	vp8x := make([]byte, 10)
+	vp8x[0] = webpFlagEXIF
+	body := []byte("WEBP")
+	body = append(body, "VP8X"...)
+	body = binary.LittleEndian.AppendUint32(body, 10)
+	body = append(body, vp8x...)
+	body = append(body, "VP8L"...)
+	body = binary.LittleEndian.AppendUint32(body, 6)
+	body = append(body, 0x2F, 0, 0, 0, 0, 0) // 1x1
+	body = append(body, "EXIF"...)
+	body = binary.LittleEndian.AppendUint32(body, 4)
+	body = append(body, "MM\x00\x2a"...)
+	content := append(append([]byte("RIFF"), binary.LittleEndian.AppendUint32(nil, uint32(len(body)))...), body...)
+
+	res, err := Sanitize(content, Options{})
+	require.NoError(t, err)
+	assert.Equal(t, 1, res.Width)
+	assert.NotContains(t, string(res.Content), "EXIF")
+	assert.Zero(t, res.Content[20]&webpFlagEXIF, "EXIF flag should be cleared")
+	assert.Equal(t, uint32(len(res.Content)-8), binary.LittleEndian.Uint32(res.Content[4:8]))
+}
+
+func TestSanitize_Unsupported(t *testing.T) {
+	_, err := Sanitize([]byte("%PDF-1.4 not an image"), Options{})
+	assert.ErrorIs(t, err, ErrUnsupported)
+
+	_, err = Sanitize([]byte{0xFF, 0xD8, 0xFF, 0xE0, 0x00}, Options{})
+	assert.ErrorIs(t, err, ErrMalformed)
+}
@@ -0,0 +1,116 @@
+package imagex
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image/jpeg"
+)
+
+const (
+	markerSOS  = 0xDA
+	markerEOI  = 0xD9
+	markerAPP1 = 0xE1 // Exif, XMP
+	markerAPPD = 0xED // IPTC, Photoshop
+	markerCOM  = 0xFE
+)
+
+var exifHeader = []byte("Exif\x00\x00")
+
+func sanitizeJPEG(content []byte, opts Options) (*Result, error) {
+	cfg, err := jpeg.DecodeConfig(bytes.NewReader(content))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrMalformed, err)
+	}
+	if err := checkDimensions(cfg.Width, cfg.Height, opts); err != nil {
+		return nil, err
+	}
+
+	stripped, orientation, err := stripJPEG(content)
+	if err != nil {
+		return nil, err
+	}
+
+	if !needsOrientation(orientation) {
+		return &Result{Format: FormatJPEG, Width: cfg.Width, Height: cfg.Height, Content: stripped}, nil
+	}
+
+	img, err := jpeg.Decode(bytes.NewReader(stripped))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrMalformed, err)
+	}
+	img = applyOrientation(img, orientation)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: opts.JPEGQuality}); err != nil {
+		return nil, err
+	}
+
+	return &Result{
+		Format:    FormatJPEG,
+		Width:     img.Bounds().Dx(),
+		Height:    img.Bounds().Dy(),
+		Content:   buf.Bytes(),
+		Reencoded: true,
+	}, nil
+}
+
+// stripJPEG drops the Exif, XMP, IPTC and comment segments and anything
+// after the end of the image. Segments needed to render the image, e.g. the
+// ICC profile or the Adobe color transform, are kept.
+func stripJPEG(content []byte) ([]byte, int, error) {
+	out := make([]byte, 0, len(content))
+	out = append(out, content[:2]...) // SOI
+	orientation := orientationNormal
+
+	pos := 2
+	for {
+		if pos+2 > len(content) || content[pos] != 0xFF {
+			return nil, 0, fmt.Errorf("%w: invalid jpeg marker at %d", ErrMalformed, pos)
+		}
+		marker := content[pos+1]
+		switch {
+		case marker == 0xFF:
+			// Fill byte before a marker.
+			pos++
+			continue
+		case marker == 0x01 || (marker >= 0xD0 && marker <= 0xD7):
+			// Standalone markers without a length.
+			out = append(out, content[pos:pos+2]...)
+			pos += 2
+			continue
+		case marker == markerEOI:
+			return append(out, content[pos:pos+2]...), orientation, nil
+		}
+
+		if pos+4 > len(content) {
+			return nil, 0, fmt.Errorf("%w: truncated jpeg segment", ErrMalformed)
+		}
+		end := pos + 2 + int(binary.BigEndian.Uint16(content[pos+2:]))
+		if end < pos+4 || end > len(content) {
+			return nil, 0, fmt.Errorf("%w: truncated jpeg segment", ErrMalformed)
+		}
+		payload := content[pos+4 : end]
+
+		switch marker {
+		case markerSOS:
+			// The entropy coded data can not contain 0xFFD9, the first one
+			// ends the image. Progressive scans are copied along with it.
+			eoi := bytes.Index(content[end:], []byte{0xFF, markerEOI})
+			if eoi < 0 {
+				return nil, 0, fmt.Errorf("%w: missing end of image", ErrMalformed)
+			}
+			return append(out, content[pos:end+eoi+2]...), orientation, nil
+		case markerAPP1:
+			if bytes.HasPrefix(payload, exifHeader) {
+				if o, ok := exifOrientation(payload[len(exifHeader):]); ok {
+					orientation = o
+				}
+			}
+		case markerAPPD, markerCOM:
+		default:
+			out = append(out, content[pos:end]...)
+		}
+		pos = end
+	}
+}
@@ -0,0 +1,101 @@
+package imagex
+
+import (
+	"encoding/binary"
+	"image"
+)
+
+const (
+	orientationNormal = 1
+	tagOrientation    = 0x0112
+	typeShort         = 3
+)
+
+// exifOrientation reads the orientation tag from the TIFF structure stored in
+// the JPEG APP1 segment and the PNG eXIf chunk.
+func exifOrientation(tiff []byte) (int, bool) {
+	if len(tiff) < 8 {
+		return 0, false
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 0, false
+	}
+	if order.Uint16(tiff[2:]) != 42 {
+		return 0, false
+	}
+
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd < 8 || ifd+2 > len(tiff) {
+		return 0, false
+	}
+	entries := int(order.Uint16(tiff[ifd:]))
+	for i := range entries {
+		entry := ifd + 2 + i*12
+		if entry+12 > len(tiff) {
+			return 0, false
+		}
+		if order.Uint16(tiff[entry:]) != tagOrientation {
+			continue
+		}
+		if order.Uint16(tiff[entry+2:]) != typeShort {
+			return 0, false
+		}
+		orientation := int(order.Uint16(tiff[entry+8:]))
+		return orientation, orientation >= 1 && orientation <= 8
+	}
+
+	return 0, false
+}
+
+// needsOrientation reports whether the orientation changes the pixels.
+func needsOrientation(orientation int) bool {
+	return orientation > orientationNormal && orientation <= 8
+}
+
+// applyOrientation returns the image as it should be displayed for the EXIF
+// orientation, see https://www.exif.org/Exif2-2.PDF page 18.
+func applyOrientation(img image.Image, orientation int) image.Image {
+	if !needsOrientation(orientation) {
+		return img
+	}
+
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	dw, dh := w, h
+	if orientation >= 5 {
+		// 5 to 8 swap the axes.
+		dw, dh = h, w
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := range dh {
+		for x := range dw {
+			var sx, sy int
+			switch orientation {
+			case 2: // mirrored horizontally
+				sx, sy = w-1-x, y
+			case 3: // rotated 180
+				sx, sy = w-1-x, h-1-y
+			case 4: // mirrored vertically
+				sx, sy = x, h-1-y
+			case 5: // transposed
+				sx, sy = y, x
+			case 6: // rotate 90 clockwise
+				sx, sy = y, h-1-x
+			case 7: // transversed
+				sx, sy = w-1-y, h-1-x
+			case 8: // rotate 90 counter clockwise
+				sx, sy = w-1-y, x
+			}
+			dst.Set(x, y, img.At(b.Min.X+sx, b.Min.Y+sy))
+		}
+	}
+
+	return dst
+}
@@ -0,0 +1,95 @@
+package imagex
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image/png"
+)
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// pngMetadataChunks are ancillary chunks that only carry metadata.
+var pngMetadataChunks = map[string]bool{
+	"tEXt": true,
+	"zTXt": true,
+	"iTXt": true,
+	"eXIf": true,
+	"tIME": true,
+}
+
+func sanitizePNG(content []byte, opts Options) (*Result, error) {
+	// DecodeConfig only reads the IHDR chunk.
+	cfg, err := png.DecodeConfig(bytes.NewReader(content))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrMalformed, err)
+	}
+	if err := checkDimensions(cfg.Width, cfg.Height, opts); err != nil {
+		return nil, err
+	}
+
+	stripped, orientation, err := stripPNG(content)
+	if err != nil {
+		return nil, err
+	}
+
+	if !needsOrientation(orientation) {
+		return &Result{Format: FormatPNG, Width: cfg.Width, Height: cfg.Height, Content: stripped}, nil
+	}
+
+	img, err := png.Decode(bytes.NewReader(stripped))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrMalformed, err)
+	}
+	img = applyOrientation(img, orientation)
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+
+	return &Result{
+		Format:    FormatPNG,
+		Width:     img.Bounds().Dx(),
+		Height:    img.Bounds().Dy(),
+		Content:   buf.Bytes(),
+		Reencoded: true,
+	}, nil
+}
+
+// stripPNG drops the metadata chunks and anything after IEND, and rejects
+// animated PNGs.
+func stripPNG(content []byte) ([]byte, int, error) {
+	out := make([]byte, 0, len(content))
+	out = append(out, pngSignature...)
+	orientation := orientationNormal
+
+	pos := len(pngSignature)
+	for {
+		if pos+12 > len(content) {
+			return nil, 0, fmt.Errorf("%w: truncated png chunk", ErrMalformed)
+		}
+		length := int(binary.BigEndian.Uint32(content[pos:]))
+		end := pos + 12 + length
+		if length < 0 || end > len(content) {
+			return nil, 0, fmt.Errorf("%w: truncated png chunk", ErrMalformed)
+		}
+		typ := string(content[pos+4 : pos+8])
+
+		switch {
+		case typ == "acTL":
+			return nil, 0, ErrAnimated
+		case typ == "eXIf":
+			if o, ok := exifOrientation(content[pos+8 : pos+8+length]); ok {
+				orientation = o
+			}
+		case pngMetadataChunks[typ]:
+		default:
+			out = append(out, content[pos:end]...)
+		}
+		if typ == "IEND" {
+			return out, orientation, nil
+		}
+		pos = end
+	}
+}
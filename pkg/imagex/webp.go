@@ -0,0 +1,110 @@
+package imagex
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+const (
+	webpFlagAnimation = 0x02
+	webpFlagXMP       = 0x04
+	webpFlagEXIF      = 0x08
+)
+
+// sanitizeWebP works on the RIFF container only, the standard library can
+// not decode WebP. The EXIF orientation is therefore not applied, it is
+// rarely set on WebP images and viewers ignore it as well.
+func sanitizeWebP(content []byte, opts Options) (*Result, error) {
+	riffEnd := 8 + int(binary.LittleEndian.Uint32(content[4:8]))
+	if riffEnd > len(content) || riffEnd < 12 {
+		return nil, fmt.Errorf("%w: truncated webp container", ErrMalformed)
+	}
+
+	var (
+		width, height int
+		vp8x          []byte
+		animated      bool
+		chunks        [][]byte
+	)
+	for pos := 12; pos < riffEnd; {
+		if pos+8 > riffEnd {
+			return nil, fmt.Errorf("%w: truncated webp chunk", ErrMalformed)
+		}
+		fourCC := string(content[pos : pos+4])
+		size := int(binary.LittleEndian.Uint32(content[pos+4 : pos+8]))
+		end := pos + 8 + size + size%2 // chunks are padded to an even size
+		if size < 0 || pos+8+size > riffEnd {
+			return nil, fmt.Errorf("%w: truncated webp chunk", ErrMalformed)
+		}
+		end = min(end, riffEnd)
+		payload := content[pos+8 : pos+8+size]
+
+		switch fourCC {
+		case "VP8X":
+			if size < 10 {
+				return nil, fmt.Errorf("%w: invalid webp VP8X chunk", ErrMalformed)
+			}
+			animated = animated || payload[0]&webpFlagAnimation != 0
+			width = int(uint24(payload[4:7])) + 1
+			height = int(uint24(payload[7:10])) + 1
+			vp8x = append([]byte(nil), content[pos:end]...)
+			chunks = append(chunks, vp8x)
+			pos = end
+			continue
+		case "ANIM", "ANMF":
+			animated = true
+		case "VP8 ":
+			// Frame tag, start code, then 14 bit width and height.
+			if size < 10 {
+				return nil, fmt.Errorf("%w: invalid webp VP8 chunk", ErrMalformed)
+			}
+			if vp8x == nil {
+				width = int(binary.LittleEndian.Uint16(payload[6:8]) & 0x3FFF)
+				height = int(binary.LittleEndian.Uint16(payload[8:10]) & 0x3FFF)
+			}
+		case "VP8L":
+			// Signature, then 14 bit width-1 and height-1.
+			if size < 5 || payload[0] != 0x2F {
+				return nil, fmt.Errorf("%w: invalid webp VP8L chunk", ErrMalformed)
+			}
+			if vp8x == nil {
+				bits := binary.LittleEndian.Uint32(payload[1:5])
+				width = int(bits&0x3FFF) + 1
+				height = int((bits>>14)&0x3FFF) + 1
+			}
+		case "EXIF", "XMP ":
+			pos = end
+			continue
+		}
+		chunks = append(chunks, content[pos:end])
+		pos = end
+	}
+
+	if err := checkDimensions(width, height, opts); err != nil {
+		return nil, err
+	}
+	if animated {
+		return nil, ErrAnimated
+	}
+
+	if vp8x != nil {
+		vp8x[8] &^= webpFlagEXIF | webpFlagXMP
+	}
+	size := 4
+	for _, c := range chunks {
+		size += len(c)
+	}
+	out := make([]byte, 0, 8+size)
+	out = append(out, "RIFF"...)
+	out = binary.LittleEndian.AppendUint32(out, uint32(size))
+	out = append(out, "WEBP"...)
+	for _, c := range chunks {
+		out = append(out, c...)
+	}
+
+	return &Result{Format: FormatWebP, Width: width, Height: height, Content: out}, nil
+}
+
+func uint24(b []byte) uint32 {
+	return uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16
+}
@@ -0,0 +1,201 @@
+// Package lastseen keeps the time users were last seen, for the report of
+// the accounts nobody uses anymore.
+//
+// The authenticated requests touch the user, the time is written at most
+// every MinInterval per user and in batches, like the error inbox, so the
+// auth path never waits on an UPDATE.
+package lastseen
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/contrib/bridges/otelslog"
+	"go.opentelemetry.io/otel/metric"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx/metrics"
+)
+
+var logger = otelslog.NewLogger("ucms/pkg/lastseen")
+
+const (
+	// MinInterval is the age the last seen time must reach before it is
+	// written again.
+	MinInterval = 15 * time.Minute
+
+	DefaultFlushInterval = 5 * time.Second
+	// DefaultMaxPending bounds the users buffered between two flushes, the
+	// touches of new users past it are dropped.
+	DefaultMaxPending = 10_000
+
+	flushTimeout = 10 * time.Second
+)
+
+// Seen is the time a user was seen.
+type Seen struct {
+	UserID user.ID
+	At     time.Time
+}
+
+// Store persists the last seen times. It keeps a stored time younger than
+// minInterval, so the instances not sharing the memory of their Tracker do
+// not write it each, and returns the number of users written.
+type Store interface {
+	TouchLastSeen(ctx context.Context, seen []Seen, minInterval time.Duration) (int64, error)
+}
+
+// Tracker buffers the touches for the Store and counts the users written in
+// the ucms.last_seen.writes metric.
+type Tracker struct {
+	store         Store
+	logger        *slog.Logger
+	flushInterval time.Duration
+	maxPending    int
+	clock         clock.Clock
+	writes        metric.Int64Counter
+	written       atomic.Int64
+
+	mu sync.Mutex
+	// seen is the time last buffered for each user, the users are forgotten
+	// once it is older than MinInterval.
+	seen    map[user.ID]time.Time
+	pending map[user.ID]time.Time
+	dropped int
+}
+
+type TrackerArgs struct {
+	Store         Store
+	Logger        *slog.Logger
+	FlushInterval time.Duration
+	MaxPending    int
+	// Clock defaults to clock.Real.
+	Clock clock.Clock
+	// Metrics defaults to metrics.Default.
+	Metrics *metrics.Registry
+}
+
+// NewTracker creates a Tracker, Run must be started to write the touches.
+//
+//	WARNING; panics if store is nil
+func NewTracker(args TrackerArgs) *Tracker {
+	if args.Store == nil {
+		panic("store is required")
+	}
+	if args.Logger == nil {
+		args.Logger = logger
+	}
+	if args.FlushInterval <= 0 {
+		args.FlushInterval = DefaultFlushInterval
+	}
+	if args.MaxPending <= 0 {
+		args.MaxPending = DefaultMaxPending
+	}
+	if args.Metrics == nil {
+		args.Metrics = metrics.Default()
+	}
+
+	return &Tracker{
+		store:         args.Store,
+		logger:        args.Logger,
+		flushInterval: args.FlushInterval,
+		maxPending:    args.MaxPending,
+		clock:         clock.Or(args.Clock),
+		writes: args.Metrics.Int64Counter(metrics.LastSeenWrites,
+			metric.WithDescription("Number of users whose last seen time was written"),
+			metric.WithUnit("{user}"),
+		),
+		seen:    make(map[user.ID]time.Time),
+		pending: make(map[user.ID]time.Time),
+	}
+}
+
+// Touch records that the user was seen now. It is a no-op when the user was
+// touched less than MinInterval ago.
+func (t *Tracker) Touch(_ context.Context, userID user.ID) {
+	now := t.clock.Now().UTC()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if at, ok := t.seen[userID]; ok && now.Sub(at) < MinInterval {
+		return
+	}
+	if _, ok := t.pending[userID]; !ok && len(t.pending) >= t.maxPending {
+		t.dropped++
+		return
+	}
+	t.seen[userID] = now
+	t.pending[userID] = now
+}
+
+// Writes returns the number of users written since the Tracker was created.
+func (t *Tracker) Writes() int64 {
+	return t.written.Load()
+}
+
+// Run flushes the buffered touches every flush interval until ctx is done,
+// then flushes one last time.
+func (t *Tracker) Run(ctx context.Context) {
+	timer := t.clock.NewTimer(t.flushInterval)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-timer.C():
+			t.flushLogged(ctx)
+			timer.Reset(t.flushInterval)
+		case <-ctx.Done():
+			t.flushLogged(context.WithoutCancel(ctx))
+			return
+		}
+	}
+}
+
+func (t *Tracker) flushLogged(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, flushTimeout)
+	defer cancel()
+
+	if err := t.Flush(ctx); err != nil {
+		t.logger.ErrorContext(ctx, "failed to flush last seen times", slog.String("error", err.Error()))
+	}
+}
+
+// Flush writes the buffered touches. They are dropped if the write fails,
+// the next touch after MinInterval writes the user again.
+func (t *Tracker) Flush(ctx context.Context) error {
+	now := t.clock.Now().UTC()
+
+	t.mu.Lock()
+	pending, dropped := t.pending, t.dropped
+	t.pending, t.dropped = make(map[user.ID]time.Time, len(pending)), 0
+	for id, at := range t.seen {
+		if now.Sub(at) >= MinInterval {
+			delete(t.seen, id)
+		}
+	}
+	t.mu.Unlock()
+
+	if dropped > 0 {
+		t.logger.WarnContext(ctx, "last seen buffer is full, touches were not recorded", slog.Int("dropped", dropped))
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	seen := make([]Seen, 0, len(pending))
+	for id, at := range pending {
+		seen = append(seen, Seen{UserID: id, At: at})
+	}
+	n, err := t.store.TouchLastSeen(ctx, seen, MinInterval)
+	if err != nil {
+		return err
+	}
+	t.written.Add(n)
+	t.writes.Add(ctx, n)
+	return nil
+}
@@ -0,0 +1,118 @@
+package lastseen
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/metric/noop"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx/metrics"
+)
+
+type fakeStore struct {
+	mu   sync.Mutex
+	seen []Seen
+	err  error
+}
+
+func (s *fakeStore) TouchLastSeen(_ context.Context, seen []Seen, _ time.Duration) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return 0, s.err
+	}
+	s.seen = append(s.seen, seen...)
+	return int64(len(seen)), nil
+}
+
+func newTestTracker(store Store, clk clock.Clock) *Tracker {
+	return NewTracker(TrackerArgs{
+		Store:   store,
+		Clock:   clk,
+		Metrics: metrics.NewRegistry(noop.NewMeterProvider().Meter("test")),
+	})
+}
+
+func TestTracker_WritesOncePerInterval(t *testing.T) {
+	store := &fakeStore{}
+	clk := clock.NewFake(time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC))
+	tr := newTestTracker(store, clk)
+	id := user.ID(uuid.New())
+
+	tr.Touch(t.Context(), id)
+	require.NoError(t, tr.Flush(t.Context()))
+	assert.Equal(t, []Seen{{UserID: id, At: clk.Now()}}, store.seen)
+	assert.Equal(t, int64(1), tr.Writes())
+
+	clk.Advance(MinInterval - time.Second)
+	tr.Touch(t.Context(), id)
+	require.NoError(t, tr.Flush(t.Context()))
+	assert.Len(t, store.seen, 1, "a touch within the interval is not written")
+	assert.Equal(t, int64(1), tr.Writes())
+
+	clk.Advance(time.Second)
+	tr.Touch(t.Context(), id)
+	require.NoError(t, tr.Flush(t.Context()))
+	require.Len(t, store.seen, 2)
+	assert.Equal(t, clk.Now(), store.seen[1].At)
+	assert.Equal(t, int64(2), tr.Writes())
+}
+
+func TestTracker_DropsPastMaxPending(t *testing.T) {
+	store := &fakeStore{}
+	tr := NewTracker(TrackerArgs{
+		Store:      store,
+		MaxPending: 1,
+		Metrics:    metrics.NewRegistry(noop.NewMeterProvider().Meter("test")),
+	})
+	first, second := user.ID(uuid.New()), user.ID(uuid.New())
+
+	tr.Touch(t.Context(), first)
+	tr.Touch(t.Context(), second)
+	require.NoError(t, tr.Flush(t.Context()))
+	require.Len(t, store.seen, 1)
+	assert.Equal(t, first, store.seen[0].UserID)
+
+	tr.Touch(t.Context(), second)
+	require.NoError(t, tr.Flush(t.Context()))
+	assert.Len(t, store.seen, 2, "a dropped user is written on the next touch")
+}
+
+func TestTracker_FlushOnShutdown(t *testing.T) {
+	store := &fakeStore{}
+	clk := clock.NewFake(time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC))
+	tr := newTestTracker(store, clk)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	done := make(chan struct{})
+	go func() {
+		tr.Run(ctx)
+		close(done)
+	}()
+
+	tr.Touch(t.Context(), user.ID(uuid.New()))
+	cancel()
+	<-done
+	assert.Len(t, store.seen, 1)
+}
+
+func TestTracker_FlushErrorDropsTouches(t *testing.T) {
+	store := &fakeStore{err: errors.New("connection refused")}
+	tr := newTestTracker(store, nil)
+
+	tr.Touch(t.Context(), user.ID(uuid.New()))
+	require.Error(t, tr.Flush(t.Context()))
+	assert.Zero(t, tr.Writes())
+
+	store.err = nil
+	require.NoError(t, tr.Flush(t.Context()))
+	assert.Empty(t, store.seen)
+}
@@ -0,0 +1,77 @@
+// Package listenx opens the listeners of the servers for the rolling
+// restarts: the sockets handed over by systemd socket activation, or sockets
+// bound with SO_REUSEPORT, so that the new process accepts connections while
+// the old one drains.
+package listenx
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+)
+
+// Options configure Listen.
+type Options struct {
+	// ReusePort binds with SO_REUSEPORT, several processes then listen on the
+	// same port and the kernel spreads the new connections between them.
+	ReusePort bool
+}
+
+// Listen announces on the TCP address addr.
+func Listen(ctx context.Context, addr string, opts Options) (net.Listener, error) {
+	var lc net.ListenConfig
+	if opts.ReusePort {
+		if !reusePortSupported {
+			return nil, fmt.Errorf("listen on %s: SO_REUSEPORT is not supported on this platform", addr)
+		}
+		lc.Control = setReusePort
+	}
+	return lc.Listen(ctx, "tcp", addr)
+}
+
+// listenFDsStart is the first file descriptor passed by systemd, after
+// stdin, stdout and stderr.
+const listenFDsStart = 3
+
+// Inherited returns the listening sockets passed by systemd socket
+// activation, in the order of the ListenStream lines of the socket unit. It
+// returns none unless LISTEN_PID names this process. The LISTEN_* variables
+// are unset, so that the children do not take the sockets for theirs.
+func Inherited() ([]net.Listener, error) {
+	defer func() {
+		_ = os.Unsetenv("LISTEN_PID")
+		_ = os.Unsetenv("LISTEN_FDS")
+		_ = os.Unsetenv("LISTEN_FDNAMES")
+	}()
+
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || pid != os.Getpid() {
+		return nil, nil
+	}
+	count, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || count < 0 {
+		return nil, fmt.Errorf("invalid LISTEN_FDS %q", os.Getenv("LISTEN_FDS"))
+	}
+	return fileListeners(listenFDsStart, count)
+}
+
+// fileListeners returns the listeners of the count descriptors from first.
+func fileListeners(first, count int) ([]net.Listener, error) {
+	listeners := make([]net.Listener, 0, count)
+	for fd := first; fd < first+count; fd++ {
+		f := os.NewFile(uintptr(fd), "LISTEN_FD_"+strconv.Itoa(fd))
+		// FileListener duplicates the descriptor.
+		ln, err := net.FileListener(f)
+		_ = f.Close()
+		if err != nil {
+			for _, l := range listeners {
+				_ = l.Close()
+			}
+			return nil, fmt.Errorf("inherited descriptor %d: %w", fd, err)
+		}
+		listeners = append(listeners, ln)
+	}
+	return listeners, nil
+}
@@ -0,0 +1,56 @@
+package listenx
+
+import (
+	"net"
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListen_ReusePort(t *testing.T) {
+	if !reusePortSupported {
+		t.Skip("SO_REUSEPORT is not supported on this platform")
+	}
+
+	first, err := Listen(t.Context(), "127.0.0.1:0", Options{ReusePort: true})
+	require.NoError(t, err)
+	defer first.Close()
+
+	second, err := Listen(t.Context(), first.Addr().String(), Options{ReusePort: true})
+	require.NoError(t, err, "a second process can bind the port while the first drains")
+	defer second.Close()
+
+	_, err = Listen(t.Context(), first.Addr().String(), Options{})
+	assert.Error(t, err, "without SO_REUSEPORT the port is taken")
+}
+
+func TestFileListeners(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+	f, err := ln.(*net.TCPListener).File()
+	require.NoError(t, err)
+
+	inherited, err := fileListeners(int(f.Fd()), 1)
+	require.NoError(t, err)
+	require.Len(t, inherited, 1)
+	defer inherited[0].Close()
+	assert.Equal(t, ln.Addr().String(), inherited[0].Addr().String())
+
+	_, err = fileListeners(int(f.Fd()), 1)
+	assert.Error(t, err, "the descriptor is closed once taken over")
+}
+
+func TestInherited_OtherProcess(t *testing.T) {
+	t.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()+1))
+	t.Setenv("LISTEN_FDS", "1")
+
+	listeners, err := Inherited()
+	require.NoError(t, err)
+	assert.Empty(t, listeners, "the sockets belong to another process")
+	_, set := os.LookupEnv("LISTEN_FDS")
+	assert.False(t, set, "the variables are unset")
+}
@@ -0,0 +1,11 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd)
+
+package listenx
+
+import "syscall"
+
+const reusePortSupported = false
+
+func setReusePort(_, _ string, _ syscall.RawConn) error {
+	return nil
+}
@@ -10,8 +10,10 @@ import (
 // - empty
 // - malformed (no '@' or '@' at ends)
 // - local part has fewer than 3 runes (too short to meaningfully redact)
-func RedactEmail(s string) string {
-	s = strings.TrimSpace(s)
+//
+// It takes any string type, e.g. an emails.Email.
+func RedactEmail[S ~string](email S) string {
+	s := strings.TrimSpace(string(email))
 	if s == "" {
 		return ""
 	}
@@ -7,6 +7,7 @@ import (
 	"os"
 	"path/filepath"
 
+	"gitlab.com/ucmsv2/ucms-backend/pkg/ctxs"
 	"gitlab.com/ucmsv2/ucms-backend/pkg/env"
 )
 
@@ -48,6 +49,7 @@ func Setup(mode env.Mode) (*slog.Logger, func()) {
 			slog.NewJSONHandler(multiWriter, &slog.HandlerOptions{Level: slog.LevelInfo}))
 	}
 
+	log = slog.New(ctxs.NewLogHandler(log.Handler()))
 	slog.SetDefault(log)
 
 	return log, func() {
@@ -0,0 +1,46 @@
+// Package optional holds the fields of a partial update: a field left out
+// keeps the current value, a cleared one resets it, any other replaces it.
+// The transports map their own encoding onto it, e.g. httpx.Field for the
+// JSON Merge Patches.
+package optional
+
+// Field is a field of a partial update. The zero Field is left out.
+type Field[T any] struct {
+	// Value is the new value, the zero value of T for a cleared field.
+	Value T
+	// Set tells the field was in the update, with a value or cleared.
+	Set bool
+	// Null tells the field was cleared.
+	Null bool
+}
+
+// SetTo returns the field replaced with v.
+func SetTo[T any](v T) Field[T] {
+	return Field[T]{Value: v, Set: true}
+}
+
+// Clear returns the field cleared.
+func Clear[T any]() Field[T] {
+	return Field[T]{Set: true, Null: true}
+}
+
+// Apply returns the value of the field applied to current: current when the
+// field was left out, the zero value of T when cleared, the new value else.
+func (f Field[T]) Apply(current T) T {
+	if !f.Set {
+		return current
+	}
+	return f.Value
+}
+
+// Map converts the value of f with fn, a field left out or cleared stays so.
+func Map[T, U any](f Field[T], fn func(T) U) Field[U] {
+	switch {
+	case !f.Set:
+		return Field[U]{}
+	case f.Null:
+		return Clear[U]()
+	default:
+		return SetTo(fn(f.Value))
+	}
+}
@@ -0,0 +1,28 @@
+package optional
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestField_Apply(t *testing.T) {
+	assert.Equal(t, "current", Field[string]{}.Apply("current"), "a field left out keeps the value")
+	assert.Empty(t, Clear[string]().Apply("current"))
+	assert.Equal(t, "new", SetTo("new").Apply("current"))
+}
+
+func TestMap(t *testing.T) {
+	assert.Equal(t, Field[int]{}, Map(Field[string]{}, mustAtoi))
+	assert.Equal(t, Clear[int](), Map(Clear[string](), mustAtoi))
+	assert.Equal(t, SetTo(42), Map(SetTo("42"), mustAtoi))
+}
+
+func mustAtoi(s string) int {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		panic(err)
+	}
+	return n
+}
@@ -0,0 +1,39 @@
+package otelsdk
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/log"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+
+	"gitlab.com/ucmsv2/ucms-backend/pkg/ctxs"
+)
+
+// CampusKey is the span, log record and resource attribute holding the
+// campus, see ctxs.CampusFromCtx.
+const CampusKey = attribute.Key("campus.id")
+
+// campusSpanProcessor stamps the campus of the context every span is started
+// with on the span.
+type campusSpanProcessor struct{}
+
+func (campusSpanProcessor) OnStart(parent context.Context, s sdktrace.ReadWriteSpan) {
+	s.SetAttributes(CampusKey.String(ctxs.CampusFromCtx(parent)))
+}
+
+func (campusSpanProcessor) OnEnd(sdktrace.ReadOnlySpan)      {}
+func (campusSpanProcessor) Shutdown(context.Context) error   { return nil }
+func (campusSpanProcessor) ForceFlush(context.Context) error { return nil }
+
+// campusLogProcessor stamps the campus of the context every log record is
+// emitted with on the record.
+type campusLogProcessor struct {
+	sdklog.Processor
+}
+
+func (p campusLogProcessor) OnEmit(ctx context.Context, record *sdklog.Record) error {
+	record.AddAttributes(log.String(string(CampusKey), ctxs.CampusFromCtx(ctx)))
+	return p.Processor.OnEmit(ctx, record)
+}
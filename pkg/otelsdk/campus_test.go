@@ -0,0 +1,39 @@
+package otelsdk
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"gitlab.com/ucmsv2/ucms-backend/pkg/ctxs"
+)
+
+func TestCampusSpanProcessor(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSpanProcessor(campusSpanProcessor{}),
+		sdktrace.WithSpanProcessor(recorder),
+	)
+	tracer := tp.Tracer("test")
+
+	_, span := tracer.Start(t.Context(), "service campus")
+	span.End()
+	_, span = tracer.Start(ctxs.WithCampus(t.Context(), "north"), "request campus")
+	span.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	campus := func(s sdktrace.ReadOnlySpan) string {
+		for _, kv := range s.Attributes() {
+			if kv.Key == CampusKey {
+				return kv.Value.AsString()
+			}
+		}
+		return ""
+	}
+	assert.Equal(t, ctxs.ServiceCampus(), campus(spans[0]))
+	assert.Equal(t, "north", campus(spans[1]))
+}
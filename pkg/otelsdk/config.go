@@ -0,0 +1,141 @@
+// Package otelsdk sets up the OpenTelemetry trace, metric and log pipelines
+// exporting to an OTLP collector.
+package otelsdk
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	ProtocolGRPC         = "grpc"
+	ProtocolHTTPProtobuf = "http/protobuf"
+)
+
+const (
+	DefaultTraceBatchTimeout = 5 * time.Second
+	DefaultMetricInterval    = time.Minute
+)
+
+type Config struct {
+	// Enabled installs the exporting providers, otherwise no-op providers are
+	// installed and nothing is exported.
+	Enabled bool
+	// Endpoint is host:port or a URL, empty uses the exporter default
+	// (localhost:4317 for grpc, localhost:4318 for http/protobuf). The HTTP
+	// exporters append the path of their signal to a URL, e.g. v1/traces.
+	Endpoint string
+	// Protocol is ProtocolGRPC or ProtocolHTTPProtobuf.
+	Protocol string
+	// Insecure disables TLS towards the collector.
+	Insecure bool
+	// Headers are sent with every export, e.g. an authorization token.
+	Headers map[string]string
+	// SampleRatio is the ratio of root traces that are sampled, child spans
+	// follow the decision of their parent.
+	SampleRatio       float64
+	TraceBatchTimeout time.Duration
+	MetricInterval    time.Duration
+}
+
+// LoadConfig reads the configuration from the environment through getenv:
+//
+//	OTEL_ENABLED                 true (default) or false
+//	OTEL_EXPORTER_OTLP_ENDPOINT  collector host:port or URL
+//	OTEL_EXPORTER_OTLP_PROTOCOL  grpc (default) or http/protobuf
+//	OTEL_EXPORTER_OTLP_INSECURE  defaults to false for https:// endpoints, true otherwise
+//	OTEL_EXPORTER_OTLP_HEADERS   comma separated key=value pairs, values may be URL encoded
+//	OTEL_TRACES_SAMPLER_ARG      sampling ratio between 0 and 1, default 1
+//	OTEL_TRACE_BATCH_TIMEOUT     e.g. 5s
+//	OTEL_METRIC_INTERVAL         e.g. 1m
+func LoadConfig(getenv func(string) string) (Config, error) {
+	cfg := Config{
+		Enabled:           true,
+		Endpoint:          strings.TrimSpace(getenv("OTEL_EXPORTER_OTLP_ENDPOINT")),
+		Protocol:          ProtocolGRPC,
+		SampleRatio:       1,
+		TraceBatchTimeout: DefaultTraceBatchTimeout,
+		MetricInterval:    DefaultMetricInterval,
+	}
+
+	var err error
+	if v := getenv("OTEL_ENABLED"); v != "" {
+		if cfg.Enabled, err = strconv.ParseBool(v); err != nil {
+			return cfg, fmt.Errorf("invalid OTEL_ENABLED %q: %w", v, err)
+		}
+	}
+
+	if v := getenv("OTEL_EXPORTER_OTLP_PROTOCOL"); v != "" {
+		cfg.Protocol = v
+	}
+	switch cfg.Protocol {
+	case ProtocolGRPC, ProtocolHTTPProtobuf:
+	default:
+		return cfg, fmt.Errorf("invalid OTEL_EXPORTER_OTLP_PROTOCOL %q, expected %q or %q",
+			cfg.Protocol, ProtocolGRPC, ProtocolHTTPProtobuf)
+	}
+
+	cfg.Insecure = !strings.HasPrefix(cfg.Endpoint, "https://")
+	if v := getenv("OTEL_EXPORTER_OTLP_INSECURE"); v != "" {
+		if cfg.Insecure, err = strconv.ParseBool(v); err != nil {
+			return cfg, fmt.Errorf("invalid OTEL_EXPORTER_OTLP_INSECURE %q: %w", v, err)
+		}
+	}
+
+	if cfg.Headers, err = parseHeaders(getenv("OTEL_EXPORTER_OTLP_HEADERS")); err != nil {
+		return cfg, fmt.Errorf("invalid OTEL_EXPORTER_OTLP_HEADERS: %w", err)
+	}
+
+	if v := getenv("OTEL_TRACES_SAMPLER_ARG"); v != "" {
+		cfg.SampleRatio, err = strconv.ParseFloat(v, 64)
+		if err != nil || cfg.SampleRatio < 0 || cfg.SampleRatio > 1 {
+			return cfg, fmt.Errorf("invalid OTEL_TRACES_SAMPLER_ARG %q, expected a ratio between 0 and 1", v)
+		}
+	}
+
+	if cfg.TraceBatchTimeout, err = parseDuration(getenv, "OTEL_TRACE_BATCH_TIMEOUT", cfg.TraceBatchTimeout); err != nil {
+		return cfg, err
+	}
+	if cfg.MetricInterval, err = parseDuration(getenv, "OTEL_METRIC_INTERVAL", cfg.MetricInterval); err != nil {
+		return cfg, err
+	}
+
+	return cfg, nil
+}
+
+// parseHeaders parses the OTLP headers format, "key1=value1,key2=value2".
+func parseHeaders(raw string) (map[string]string, error) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+
+	headers := make(map[string]string)
+	for pair := range strings.SplitSeq(raw, ",") {
+		key, value, ok := strings.Cut(pair, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("expected key=value, got %q", pair)
+		}
+		decoded, err := url.PathUnescape(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("header %q: %w", key, err)
+		}
+		headers[key] = decoded
+	}
+	return headers, nil
+}
+
+func parseDuration(getenv func(string) string, key string, defaultValue time.Duration) (time.Duration, error) {
+	v := getenv(key)
+	if v == "" {
+		return defaultValue, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid %s %q, expected a positive duration", key, v)
+	}
+	return d, nil
+}
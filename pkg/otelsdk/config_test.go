@@ -0,0 +1,89 @@
+package otelsdk
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func envFunc(vars map[string]string) func(string) string {
+	return func(key string) string { return vars[key] }
+}
+
+func TestLoadConfig_Defaults(t *testing.T) {
+	cfg, err := LoadConfig(envFunc(nil))
+	require.NoError(t, err)
+
+	assert.Equal(t, Config{
+		Enabled:           true,
+		Protocol:          ProtocolGRPC,
+		Insecure:          true,
+		SampleRatio:       1,
+		TraceBatchTimeout: DefaultTraceBatchTimeout,
+		MetricInterval:    DefaultMetricInterval,
+	}, cfg)
+}
+
+func TestLoadConfig(t *testing.T) {
+	cfg, err := LoadConfig(envFunc(map[string]string{
+		"OTEL_ENABLED":                "false",
+		"OTEL_EXPORTER_OTLP_ENDPOINT": "https://collector.example.com:4317",
+		"OTEL_EXPORTER_OTLP_HEADERS":  "authorization=Bearer%20secret, x-tenant = ucms",
+		"OTEL_TRACES_SAMPLER_ARG":     "0.25",
+		"OTEL_TRACE_BATCH_TIMEOUT":    "1s",
+		"OTEL_METRIC_INTERVAL":        "30s",
+	}))
+	require.NoError(t, err)
+
+	assert.False(t, cfg.Enabled)
+	assert.Equal(t, "https://collector.example.com:4317", cfg.Endpoint)
+	assert.False(t, cfg.Insecure, "https endpoints use TLS by default")
+	assert.Equal(t, map[string]string{"authorization": "Bearer secret", "x-tenant": "ucms"}, cfg.Headers)
+	assert.InDelta(t, 0.25, cfg.SampleRatio, 0)
+	assert.Equal(t, time.Second, cfg.TraceBatchTimeout)
+	assert.Equal(t, 30*time.Second, cfg.MetricInterval)
+}
+
+func TestLoadConfig_Protocol(t *testing.T) {
+	for _, protocol := range []string{ProtocolGRPC, ProtocolHTTPProtobuf} {
+		t.Run(protocol, func(t *testing.T) {
+			cfg, err := LoadConfig(envFunc(map[string]string{"OTEL_EXPORTER_OTLP_PROTOCOL": protocol}))
+			require.NoError(t, err)
+			assert.Equal(t, protocol, cfg.Protocol)
+		})
+	}
+}
+
+func TestLoadConfig_InsecureOverride(t *testing.T) {
+	cfg, err := LoadConfig(envFunc(map[string]string{
+		"OTEL_EXPORTER_OTLP_ENDPOINT": "collector:4317",
+		"OTEL_EXPORTER_OTLP_INSECURE": "false",
+	}))
+	require.NoError(t, err)
+	assert.False(t, cfg.Insecure)
+}
+
+func TestLoadConfig_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+	}{
+		{name: "enabled", env: map[string]string{"OTEL_ENABLED": "maybe"}},
+		{name: "unknown protocol", env: map[string]string{"OTEL_EXPORTER_OTLP_PROTOCOL": "udp"}},
+		{name: "insecure", env: map[string]string{"OTEL_EXPORTER_OTLP_INSECURE": "yes please"}},
+		{name: "headers", env: map[string]string{"OTEL_EXPORTER_OTLP_HEADERS": "authorization"}},
+		{name: "ratio not a number", env: map[string]string{"OTEL_TRACES_SAMPLER_ARG": "half"}},
+		{name: "ratio out of range", env: map[string]string{"OTEL_TRACES_SAMPLER_ARG": "1.5"}},
+		{name: "batch timeout", env: map[string]string{"OTEL_TRACE_BATCH_TIMEOUT": "soon"}},
+		{name: "metric interval", env: map[string]string{"OTEL_METRIC_INTERVAL": "-1s"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadConfig(envFunc(tt.env))
+			assert.Error(t, err)
+		})
+	}
+}
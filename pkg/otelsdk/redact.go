@@ -0,0 +1,56 @@
+package otelsdk
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/log"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx"
+)
+
+// redactingProcessor redacts the attributes of every log record before it is
+// exported. It covers the loggers created with otelslog.NewLogger, which do
+// not go through the default slog handler.
+type redactingProcessor struct {
+	sdklog.Processor
+}
+
+func (p redactingProcessor) OnEmit(ctx context.Context, record *sdklog.Record) error {
+	attrs := make([]log.KeyValue, 0, record.AttributesLen())
+	record.WalkAttributes(func(kv log.KeyValue) bool {
+		attrs = append(attrs, log.KeyValue{Key: kv.Key, Value: redactLogValue(kv.Key, kv.Value)})
+		return true
+	})
+	record.SetAttributes(attrs...)
+
+	return p.Processor.OnEmit(ctx, record)
+}
+
+func redactLogValue(key string, v log.Value) log.Value {
+	switch v.Kind() {
+	case log.KindString:
+		return log.StringValue(otelx.Redact(key, v.AsString()))
+	case log.KindMap:
+		kvs := v.AsMap()
+		redacted := make([]log.KeyValue, len(kvs))
+		for i, kv := range kvs {
+			redacted[i] = log.KeyValue{Key: kv.Key, Value: redactLogValue(key+"."+kv.Key, kv.Value)}
+		}
+		return log.MapValue(redacted...)
+	case log.KindSlice:
+		values := v.AsSlice()
+		redacted := make([]log.Value, len(values))
+		for i, sv := range values {
+			redacted[i] = redactLogValue(key, sv)
+		}
+		return log.SliceValue(redacted...)
+	case log.KindEmpty:
+		return v
+	default:
+		if otelx.IsSecretKey(key) {
+			return log.StringValue(otelx.Redacted)
+		}
+		return v
+	}
+}
@@ -0,0 +1,323 @@
+package otelsdk
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/contrib/bridges/otelslog"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploggrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploghttp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	otellog "go.opentelemetry.io/otel/log"
+	"go.opentelemetry.io/otel/log/global"
+	lognoop "go.opentelemetry.io/otel/log/noop"
+	otelmetric "go.opentelemetry.io/otel/metric"
+	metricnoop "go.opentelemetry.io/otel/metric/noop"
+	"go.opentelemetry.io/otel/propagation"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	oteltrace "go.opentelemetry.io/otel/trace"
+	tracenoop "go.opentelemetry.io/otel/trace/noop"
+
+	"gitlab.com/ucmsv2/ucms-backend/pkg/ctxs"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx"
+)
+
+// Providers holds the providers built by New.
+type Providers struct {
+	TracerProvider oteltrace.TracerProvider
+	MeterProvider  otelmetric.MeterProvider
+	LoggerProvider otellog.LoggerProvider
+
+	shutdownFuncs []func(context.Context) error
+}
+
+// probeTimeout bounds the check that the collector is reachable.
+const probeTimeout = 2 * time.Second
+
+// The collector of the exporters when Endpoint is empty, and the port of
+// an endpoint URL without one, per protocol.
+const (
+	defaultGRPCEndpoint = "localhost:4317"
+	defaultHTTPEndpoint = "localhost:4318"
+)
+
+// New builds the providers for cfg. With cfg.Enabled false the providers are
+// no-ops, no exporter is created and no background goroutine is started.
+//
+// The telemetry is not worth failing the startup for: when the collector is
+// unreachable or the exporters can not be created, New logs a single warning
+// and returns the no-op providers as well, instead of exporters logging
+// their failures for as long as the process runs.
+func New(ctx context.Context, cfg Config, res *resource.Resource) *Providers {
+	if !cfg.Enabled {
+		return noopProviders()
+	}
+
+	endpoint := probeAddr(cfg.Endpoint, cfg.Protocol)
+	if err := probe(ctx, endpoint); err != nil {
+		slog.WarnContext(ctx, "OpenTelemetry collector is unreachable, telemetry is not exported",
+			"endpoint", endpoint, "error", err)
+		return noopProviders()
+	}
+
+	p, err := newExportingProviders(ctx, cfg, res)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to create the OpenTelemetry exporters, telemetry is not exported",
+			"endpoint", endpoint, "error", err)
+		return noopProviders()
+	}
+	return p
+}
+
+func noopProviders() *Providers {
+	return &Providers{
+		TracerProvider: tracenoop.NewTracerProvider(),
+		MeterProvider:  metricnoop.NewMeterProvider(),
+		LoggerProvider: lognoop.NewLoggerProvider(),
+	}
+}
+
+func newExportingProviders(ctx context.Context, cfg Config, res *resource.Resource) (*Providers, error) {
+	p := &Providers{}
+
+	tracerProvider, err := newTracerProvider(ctx, cfg, res)
+	if err != nil {
+		return nil, errors.Join(err, p.Shutdown(ctx))
+	}
+	p.TracerProvider = tracerProvider
+	p.shutdownFuncs = append(p.shutdownFuncs, tracerProvider.Shutdown)
+
+	meterProvider, err := newMeterProvider(ctx, cfg, res)
+	if err != nil {
+		return nil, errors.Join(err, p.Shutdown(ctx))
+	}
+	p.MeterProvider = meterProvider
+	p.shutdownFuncs = append(p.shutdownFuncs, meterProvider.Shutdown)
+
+	loggerProvider, err := newLoggerProvider(ctx, cfg, res)
+	if err != nil {
+		return nil, errors.Join(err, p.Shutdown(ctx))
+	}
+	p.LoggerProvider = loggerProvider
+	p.shutdownFuncs = append(p.shutdownFuncs, loggerProvider.Shutdown)
+
+	return p, nil
+}
+
+// probeAddr returns the host:port the exporters of protocol connect to for
+// endpoint.
+func probeAddr(endpoint, protocol string) string {
+	defaultEndpoint := defaultGRPCEndpoint
+	if protocol == ProtocolHTTPProtobuf {
+		defaultEndpoint = defaultHTTPEndpoint
+	}
+	if endpoint == "" {
+		return defaultEndpoint
+	}
+	if !isURL(endpoint) {
+		return endpoint
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" {
+		return endpoint
+	}
+	if u.Port() == "" {
+		_, port, _ := net.SplitHostPort(defaultEndpoint)
+		return net.JoinHostPort(u.Hostname(), port)
+	}
+	return u.Host
+}
+
+// signalURL returns the URL the HTTP exporter of a signal posts to for the
+// endpoint URL, the path of the signal is appended to it like the OTLP
+// specification does for OTEL_EXPORTER_OTLP_ENDPOINT, e.g. v1/traces.
+func signalURL(endpoint, path string) string {
+	u, err := url.JoinPath(endpoint, path)
+	if err != nil {
+		return endpoint
+	}
+	return u
+}
+
+// probe checks that something accepts connections on addr. It does not speak
+// OTLP, an exporter failing later is still logged by the SDK.
+func probe(ctx context.Context, addr string) error {
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+// Install registers the providers and the propagator globally. When
+// exporting is enabled the default slog logger is replaced by one writing to
+// the log pipeline.
+func (p *Providers) Install(serviceName string) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+	otel.SetTracerProvider(p.TracerProvider)
+	otel.SetMeterProvider(p.MeterProvider)
+	global.SetLoggerProvider(p.LoggerProvider)
+
+	if _, ok := p.LoggerProvider.(*sdklog.LoggerProvider); ok {
+		slog.SetDefault(slog.New(ctxs.NewLogHandler(otelx.NewRedactingHandler(otelslog.NewHandler(
+			serviceName,
+			otelslog.WithLoggerProvider(p.LoggerProvider),
+			otelslog.WithSource(true),
+		)))))
+	}
+}
+
+// Shutdown flushes and stops the exporting providers.
+func (p *Providers) Shutdown(ctx context.Context) error {
+	var err error
+	for _, fn := range p.shutdownFuncs {
+		err = errors.Join(err, fn(ctx))
+	}
+	p.shutdownFuncs = nil
+	return err
+}
+
+func newTracerProvider(ctx context.Context, cfg Config, res *resource.Resource) (*sdktrace.TracerProvider, error) {
+	exporter, err := newSpanExporter(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithResource(res),
+		sdktrace.WithSpanProcessor(campusSpanProcessor{}),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+		sdktrace.WithBatcher(exporter, sdktrace.WithBatchTimeout(cfg.TraceBatchTimeout)),
+	), nil
+}
+
+func newMeterProvider(ctx context.Context, cfg Config, res *resource.Resource) (*sdkmetric.MeterProvider, error) {
+	exporter, err := newMetricExporter(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return sdkmetric.NewMeterProvider(
+		sdkmetric.WithResource(res),
+		sdkmetric.WithReader(sdkmetric.NewPeriodicReader(exporter, sdkmetric.WithInterval(cfg.MetricInterval))),
+	), nil
+}
+
+func newLoggerProvider(ctx context.Context, cfg Config, res *resource.Resource) (*sdklog.LoggerProvider, error) {
+	exporter, err := newLogExporter(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return sdklog.NewLoggerProvider(
+		sdklog.WithResource(res),
+		sdklog.WithProcessor(campusLogProcessor{redactingProcessor{sdklog.NewBatchProcessor(exporter)}}),
+	), nil
+}
+
+func newSpanExporter(ctx context.Context, cfg Config) (sdktrace.SpanExporter, error) {
+	if cfg.Protocol == ProtocolHTTPProtobuf {
+		opts := []otlptracehttp.Option{otlptracehttp.WithHeaders(cfg.Headers)}
+		switch {
+		case isURL(cfg.Endpoint):
+			opts = append(opts, otlptracehttp.WithEndpointURL(signalURL(cfg.Endpoint, "v1/traces")))
+		case cfg.Endpoint != "":
+			opts = append(opts, otlptracehttp.WithEndpoint(cfg.Endpoint))
+		}
+		if cfg.Insecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		return otlptracehttp.New(ctx, opts...)
+	}
+
+	opts := []otlptracegrpc.Option{otlptracegrpc.WithHeaders(cfg.Headers)}
+	switch {
+	case isURL(cfg.Endpoint):
+		opts = append(opts, otlptracegrpc.WithEndpointURL(cfg.Endpoint))
+	case cfg.Endpoint != "":
+		opts = append(opts, otlptracegrpc.WithEndpoint(cfg.Endpoint))
+	}
+	if cfg.Insecure {
+		opts = append(opts, otlptracegrpc.WithInsecure())
+	}
+	return otlptracegrpc.New(ctx, opts...)
+}
+
+func newMetricExporter(ctx context.Context, cfg Config) (sdkmetric.Exporter, error) {
+	if cfg.Protocol == ProtocolHTTPProtobuf {
+		opts := []otlpmetrichttp.Option{otlpmetrichttp.WithHeaders(cfg.Headers)}
+		switch {
+		case isURL(cfg.Endpoint):
+			opts = append(opts, otlpmetrichttp.WithEndpointURL(signalURL(cfg.Endpoint, "v1/metrics")))
+		case cfg.Endpoint != "":
+			opts = append(opts, otlpmetrichttp.WithEndpoint(cfg.Endpoint))
+		}
+		if cfg.Insecure {
+			opts = append(opts, otlpmetrichttp.WithInsecure())
+		}
+		return otlpmetrichttp.New(ctx, opts...)
+	}
+
+	opts := []otlpmetricgrpc.Option{otlpmetricgrpc.WithHeaders(cfg.Headers)}
+	switch {
+	case isURL(cfg.Endpoint):
+		opts = append(opts, otlpmetricgrpc.WithEndpointURL(cfg.Endpoint))
+	case cfg.Endpoint != "":
+		opts = append(opts, otlpmetricgrpc.WithEndpoint(cfg.Endpoint))
+	}
+	if cfg.Insecure {
+		opts = append(opts, otlpmetricgrpc.WithInsecure())
+	}
+	return otlpmetricgrpc.New(ctx, opts...)
+}
+
+func newLogExporter(ctx context.Context, cfg Config) (sdklog.Exporter, error) {
+	if cfg.Protocol == ProtocolHTTPProtobuf {
+		opts := []otlploghttp.Option{otlploghttp.WithHeaders(cfg.Headers)}
+		switch {
+		case isURL(cfg.Endpoint):
+			opts = append(opts, otlploghttp.WithEndpointURL(signalURL(cfg.Endpoint, "v1/logs")))
+		case cfg.Endpoint != "":
+			opts = append(opts, otlploghttp.WithEndpoint(cfg.Endpoint))
+		}
+		if cfg.Insecure {
+			opts = append(opts, otlploghttp.WithInsecure())
+		}
+		return otlploghttp.New(ctx, opts...)
+	}
+
+	opts := []otlploggrpc.Option{otlploggrpc.WithHeaders(cfg.Headers)}
+	switch {
+	case isURL(cfg.Endpoint):
+		opts = append(opts, otlploggrpc.WithEndpointURL(cfg.Endpoint))
+	case cfg.Endpoint != "":
+		opts = append(opts, otlploggrpc.WithEndpoint(cfg.Endpoint))
+	}
+	if cfg.Insecure {
+		opts = append(opts, otlploggrpc.WithInsecure())
+	}
+	return otlploggrpc.New(ctx, opts...)
+}
+
+func isURL(endpoint string) bool {
+	return strings.Contains(endpoint, "://")
+}
@@ -0,0 +1,162 @@
+package otelsdk
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	lognoop "go.opentelemetry.io/otel/log/noop"
+	metricnoop "go.opentelemetry.io/otel/metric/noop"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	tracenoop "go.opentelemetry.io/otel/trace/noop"
+)
+
+func TestNew_Disabled(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	p := New(t.Context(), Config{Enabled: false}, resource.Empty())
+
+	assert.IsType(t, tracenoop.TracerProvider{}, p.TracerProvider)
+	assert.IsType(t, metricnoop.MeterProvider{}, p.MeterProvider)
+	assert.IsType(t, lognoop.LoggerProvider{}, p.LoggerProvider)
+	assert.Equal(t, before, runtime.NumGoroutine(), "no exporter goroutines should be started")
+	assert.NoError(t, p.Shutdown(t.Context()))
+}
+
+// listenCollector accepts connections without speaking OTLP, enough for the
+// reachability check, the exporters connect lazily.
+func listenCollector(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			_ = conn.Close()
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func TestNew_Enabled(t *testing.T) {
+	for _, protocol := range []string{ProtocolGRPC, ProtocolHTTPProtobuf} {
+		t.Run(protocol, func(t *testing.T) {
+			testNewEnabled(t, protocol)
+		})
+	}
+}
+
+func testNewEnabled(t *testing.T, protocol string) {
+	cfg, err := LoadConfig(envFunc(map[string]string{
+		"OTEL_EXPORTER_OTLP_ENDPOINT": "http://" + listenCollector(t),
+		"OTEL_EXPORTER_OTLP_PROTOCOL": protocol,
+		"OTEL_TRACES_SAMPLER_ARG":     "0",
+	}))
+	require.NoError(t, err)
+
+	p := New(t.Context(), cfg, resource.Empty())
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		_ = p.Shutdown(ctx)
+	})
+
+	require.IsType(t, &sdktrace.TracerProvider{}, p.TracerProvider)
+	assert.IsType(t, &sdkmetric.MeterProvider{}, p.MeterProvider)
+	assert.IsType(t, &sdklog.LoggerProvider{}, p.LoggerProvider)
+
+	// A ratio of 0 drops root spans, children of a sampled parent are kept.
+	tracer := p.TracerProvider.Tracer("test")
+	_, root := tracer.Start(t.Context(), "root")
+	assert.False(t, root.SpanContext().IsSampled())
+	root.End()
+
+	parent := sdktrace.NewTracerProvider(sdktrace.WithSampler(sdktrace.AlwaysSample()))
+	ctx, sampled := parent.Tracer("test").Start(t.Context(), "parent")
+	_, child := tracer.Start(ctx, "child")
+	assert.True(t, child.SpanContext().IsSampled())
+	child.End()
+	sampled.End()
+}
+
+func TestNew_HTTPExportsToSignalPaths(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		paths []string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		paths = append(paths, r.URL.Path)
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/x-protobuf")
+	}))
+	t.Cleanup(srv.Close)
+
+	cfg, err := LoadConfig(envFunc(map[string]string{
+		"OTEL_EXPORTER_OTLP_ENDPOINT": srv.URL,
+		"OTEL_EXPORTER_OTLP_PROTOCOL": ProtocolHTTPProtobuf,
+	}))
+	require.NoError(t, err)
+
+	p := New(t.Context(), cfg, resource.Empty())
+	require.IsType(t, &sdktrace.TracerProvider{}, p.TracerProvider)
+	_, span := p.TracerProvider.Tracer("test").Start(t.Context(), "span")
+	span.End()
+
+	ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, p.Shutdown(ctx))
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Contains(t, paths, "/v1/traces")
+}
+
+func TestNew_UnreachableCollector(t *testing.T) {
+	// Nothing listens on the port 1.
+	cfg, err := LoadConfig(envFunc(map[string]string{
+		"OTEL_EXPORTER_OTLP_ENDPOINT": "http://127.0.0.1:1",
+	}))
+	require.NoError(t, err)
+
+	before := runtime.NumGoroutine()
+	start := time.Now()
+	p := New(t.Context(), cfg, resource.Empty())
+
+	assert.Less(t, time.Since(start), probeTimeout+time.Second, "the startup does not wait on the collector")
+	assert.IsType(t, tracenoop.TracerProvider{}, p.TracerProvider)
+	assert.IsType(t, metricnoop.MeterProvider{}, p.MeterProvider)
+	assert.IsType(t, lognoop.LoggerProvider{}, p.LoggerProvider)
+	assert.Equal(t, before, runtime.NumGoroutine(), "no exporter goroutines should be started")
+	assert.NoError(t, p.Shutdown(t.Context()))
+}
+
+func TestProbeAddr(t *testing.T) {
+	assert.Equal(t, "localhost:4317", probeAddr("", ProtocolGRPC))
+	assert.Equal(t, "collector:4317", probeAddr("collector:4317", ProtocolGRPC))
+	assert.Equal(t, "collector:4317", probeAddr("http://collector", ProtocolGRPC))
+	assert.Equal(t, "collector:14317", probeAddr("https://collector:14317/v1", ProtocolGRPC))
+
+	assert.Equal(t, "localhost:4318", probeAddr("", ProtocolHTTPProtobuf))
+	assert.Equal(t, "collector:4318", probeAddr("http://collector", ProtocolHTTPProtobuf))
+	assert.Equal(t, "collector:14318", probeAddr("https://collector:14318", ProtocolHTTPProtobuf))
+}
+
+func TestSignalURL(t *testing.T) {
+	assert.Equal(t, "http://collector:4318/v1/traces", signalURL("http://collector:4318", "v1/traces"))
+	assert.Equal(t, "https://collector/otlp/v1/logs", signalURL("https://collector/otlp/", "v1/logs"))
+}
@@ -0,0 +1,107 @@
+package otelx
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx/metrics"
+)
+
+var tracer = otel.Tracer("ucms/pkg/otelx")
+
+// Handler handles commands of type T.
+type Handler[T any] interface {
+	Handle(ctx context.Context, cmd T) error
+}
+
+type HandlerFunc[T any] func(ctx context.Context, cmd T) error
+
+func (f HandlerFunc[T]) Handle(ctx context.Context, cmd T) error {
+	return f(ctx, cmd)
+}
+
+// SpanAttributer is implemented by commands that describe themselves on the
+// span of their handler. The attributes are redacted, see SetSpanAttrs.
+type SpanAttributer interface {
+	SpanAttrs() map[string]any
+}
+
+// Traced runs fn in a span named name and records the error it returns.
+func Traced(ctx context.Context, tracer trace.Tracer, name string, fn func(ctx context.Context) error) error {
+	ctx, span := tracer.Start(ctx, name)
+	defer span.End()
+
+	err := fn(ctx)
+	RecordSpanError(span, err, "")
+	return err
+}
+
+type InstrumentOptions struct {
+	// Tracer defaults to the global tracer provider.
+	Tracer trace.Tracer
+	// Metrics defaults to metrics.Default().
+	Metrics *metrics.Registry
+}
+
+// InstrumentCommand wraps h so that every command is handled in a span named
+// name, with the attributes of the command if it is a SpanAttributer. The
+// returned error is recorded on the span and the duration is recorded in
+// the metrics.CommandDuration histogram.
+func InstrumentCommand[T any](name string, h Handler[T]) Handler[T] {
+	return InstrumentCommandWith(InstrumentOptions{}, name, h)
+}
+
+// InstrumentCommandWith is InstrumentCommand with another tracer or metrics
+// registry.
+func InstrumentCommandWith[T any](opts InstrumentOptions, name string, h Handler[T]) Handler[T] {
+	if opts.Tracer == nil {
+		opts.Tracer = tracer
+	}
+	if opts.Metrics == nil {
+		opts.Metrics = metrics.Default()
+	}
+
+	return &instrumentedCommand[T]{
+		name:    name,
+		handler: h,
+		tracer:  opts.Tracer,
+		duration: opts.Metrics.Float64Histogram(metrics.CommandDuration,
+			metric.WithDescription("Duration of application commands"),
+			metric.WithUnit("s"),
+		),
+	}
+}
+
+type instrumentedCommand[T any] struct {
+	name     string
+	handler  Handler[T]
+	tracer   trace.Tracer
+	duration metric.Float64Histogram
+}
+
+func (c *instrumentedCommand[T]) Handle(ctx context.Context, cmd T) error {
+	start := time.Now()
+	ctx, span := c.tracer.Start(ctx, c.name)
+	defer span.End()
+
+	if attributer, ok := any(cmd).(SpanAttributer); ok {
+		SetSpanAttrs(span, attributer.SpanAttrs())
+	}
+
+	err := c.handler.Handle(ctx, cmd)
+	RecordSpanError(span, err, "")
+
+	attrs := []attribute.KeyValue{attribute.String(metrics.AttrCommand, c.name)}
+	if err != nil {
+		attrs = append(attrs, attribute.String(metrics.AttrErrorType, string(errorx.CodeOf(err))))
+	}
+	c.duration.Record(ctx, time.Since(start).Seconds(), metric.WithAttributes(attrs...))
+
+	return err
+}
@@ -0,0 +1,141 @@
+package otelx
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx/metrics"
+)
+
+type testCommand struct {
+	Email string
+	Count int
+}
+
+func (c testCommand) SpanAttrs() map[string]any {
+	return map[string]any{
+		"email":      c.Email,
+		"item_count": c.Count,
+	}
+}
+
+type plainCommand struct{}
+
+type telemetryRecorder struct {
+	opts   InstrumentOptions
+	spans  *tracetest.InMemoryExporter
+	reader *sdkmetric.ManualReader
+}
+
+func newTelemetryRecorder() *telemetryRecorder {
+	spans := tracetest.NewInMemoryExporter()
+	reader := sdkmetric.NewManualReader()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(spans))
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	return &telemetryRecorder{
+		opts: InstrumentOptions{
+			Tracer:  tp.Tracer("test"),
+			Metrics: metrics.NewRegistry(mp.Meter("test")),
+		},
+		spans:  spans,
+		reader: reader,
+	}
+}
+
+func (r *telemetryRecorder) durations(t *testing.T) []metricdata.HistogramDataPoint[float64] {
+	t.Helper()
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, r.reader.Collect(context.Background(), &rm))
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name == metrics.CommandDuration {
+				return m.Data.(metricdata.Histogram[float64]).DataPoints
+			}
+		}
+	}
+	return nil
+}
+
+func TestInstrumentCommand_Success(t *testing.T) {
+	rec := newTelemetryRecorder()
+	var handledIn trace.SpanContext
+	h := InstrumentCommandWith(rec.opts, "TestHandler.Handle", HandlerFunc[testCommand](func(ctx context.Context, cmd testCommand) error {
+		handledIn = trace.SpanContextFromContext(ctx)
+		return nil
+	}))
+
+	require.NoError(t, h.Handle(t.Context(), testCommand{Email: "john.doe@example.com", Count: 3}))
+
+	spans := rec.spans.GetSpans()
+	require.Len(t, spans, 1)
+	span := spans[0]
+	assert.Equal(t, "TestHandler.Handle", span.Name)
+	assert.Equal(t, span.SpanContext, handledIn, "the handler runs in the command span")
+	assert.Equal(t, codes.Unset, span.Status.Code)
+
+	attrs := attribute.NewSet(span.Attributes...)
+	count, ok := attrs.Value("item_count")
+	require.True(t, ok)
+	assert.Equal(t, int64(3), count.AsInt64())
+	email, ok := attrs.Value("email")
+	require.True(t, ok)
+	assert.NotEqual(t, "john.doe@example.com", email.AsString(), "attributes are redacted")
+
+	points := rec.durations(t)
+	require.Len(t, points, 1)
+	assert.Equal(t, uint64(1), points[0].Count)
+	assert.Equal(t, attribute.NewSet(attribute.String(metrics.AttrCommand, "TestHandler.Handle")), points[0].Attributes)
+}
+
+func TestInstrumentCommand_Error(t *testing.T) {
+	rec := newTelemetryRecorder()
+	h := InstrumentCommandWith(rec.opts, "PlainHandler.Handle", HandlerFunc[plainCommand](func(context.Context, plainCommand) error {
+		return errorx.NewNotFound().WithCause(errors.New("no rows"), "test")
+	}))
+
+	err := h.Handle(t.Context(), plainCommand{})
+	require.Error(t, err)
+	assert.True(t, errorx.IsNotFound(err), "the error is returned as is")
+
+	spans := rec.spans.GetSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, codes.Error, spans[0].Status.Code)
+	assert.Empty(t, spans[0].Attributes, "commands without SpanAttrs add no attributes")
+	require.Len(t, spans[0].Events, 1)
+	assert.Equal(t, "exception", spans[0].Events[0].Name)
+
+	points := rec.durations(t)
+	require.Len(t, points, 1)
+	errorType, ok := points[0].Attributes.Value(metrics.AttrErrorType)
+	require.True(t, ok)
+	assert.Equal(t, string(errorx.CodeNotFound), errorType.AsString())
+}
+
+func TestTraced(t *testing.T) {
+	rec := newTelemetryRecorder()
+	wantErr := errors.New("boom")
+
+	err := Traced(t.Context(), rec.opts.Tracer, "step", func(ctx context.Context) error {
+		assert.True(t, trace.SpanContextFromContext(ctx).IsValid())
+		return wantErr
+	})
+	assert.ErrorIs(t, err, wantErr)
+
+	spans := rec.spans.GetSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "step", spans[0].Name)
+	assert.Equal(t, codes.Error, spans[0].Status.Code)
+	assert.Equal(t, "boom", spans[0].Status.Description)
+}
@@ -1,9 +1,12 @@
 package otelx
 
 import (
+	"context"
 	"fmt"
 	"reflect"
+	"slices"
 	"time"
+	"unicode/utf8"
 
 	"github.com/ARUMANDESU/validation"
 	"github.com/google/uuid"
@@ -23,24 +26,157 @@ func RecordSpanError(span trace.Span, err error, desc string) {
 	span.SetStatus(codes.Error, desc)
 }
 
+// CorrelationID returns the trace ID of the span in ctx, it links logs and
+// recorded errors to the trace of the request. It is empty without a span.
+func CorrelationID(ctx context.Context) string {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.HasTraceID() {
+		return ""
+	}
+	return sc.TraceID().String()
+}
+
+const (
+	// MaxSpanAttrs caps the attributes set by one SetSpanAttrs call, keys are
+	// added in sorted order and the rest are dropped.
+	MaxSpanAttrs = 64
+	// MaxSpanAttrValueLen caps string values in bytes. Longer values are
+	// truncated with an ellipsis and marked with a <key>.truncated attribute.
+	MaxSpanAttrValueLen = 4 << 10
+)
+
+// SpanAttrTag names the struct fields recorded when a struct is passed to
+// SetSpanAttrs, e.g. `otel:"status"`. Fields without it are not recorded.
+const SpanAttrTag = "otel"
+
 // SetSpanAttrs sets attributes on a span from a map of key-value pairs.
 // It handles various Go types and converts them to appropriate OpenTelemetry attributes.
+// Values are redacted according to the redaction policy, see Redact.
+//
+// Nested map[string]any values, SpanAttributer values and structs with
+// SpanAttrTag fields are flattened one level, with dot joined keys.
 func SetSpanAttrs(span trace.Span, attrs map[string]any) {
-	if span == nil || attrs == nil || len(attrs) == 0 {
+	if span == nil || len(attrs) == 0 {
 		return
 	}
 
-	spanAttrs := make([]attribute.KeyValue, 0, len(attrs))
+	b := spanAttrsBuilder{attrs: make([]attribute.KeyValue, 0, min(len(attrs), MaxSpanAttrs))}
+	for _, key := range sortedKeys(attrs) {
+		if !b.add(key, attrs[key], true) {
+			break
+		}
+	}
+
+	if len(b.attrs) > 0 {
+		span.SetAttributes(b.attrs...)
+	}
+}
+
+type spanAttrsBuilder struct {
+	attrs []attribute.KeyValue
+}
+
+// add appends the attribute of value, flattened when flatten is true. It
+// reports whether there is room for more attributes.
+func (b *spanAttrsBuilder) add(key string, value any, flatten bool) bool {
+	if flatten {
+		if nested, ok := nestedAttrs(value); ok {
+			for _, k := range sortedKeys(nested) {
+				if !b.add(key+"."+k, nested[k], false) {
+					return false
+				}
+			}
+			return true
+		}
+	}
+
+	attr := convertToAttribute(key, value)
+	if !attr.Valid() {
+		return true
+	}
+	attr = RedactAttr(attr)
+
+	truncated := false
+	if attr.Value.Type() == attribute.STRING {
+		var str string
+		if str, truncated = truncate(attr.Value.AsString(), MaxSpanAttrValueLen); truncated {
+			attr = attribute.String(key, str)
+		}
+	}
+
+	if len(b.attrs) >= MaxSpanAttrs {
+		return false
+	}
+	b.attrs = append(b.attrs, attr)
+	if truncated && len(b.attrs) < MaxSpanAttrs {
+		b.attrs = append(b.attrs, attribute.Bool(key+".truncated", true))
+	}
+	return len(b.attrs) < MaxSpanAttrs
+}
+
+// nestedAttrs returns the attributes of values that are flattened.
+func nestedAttrs(value any) (map[string]any, bool) {
+	switch v := value.(type) {
+	case map[string]any:
+		return v, true
+	case SpanAttributer:
+		if rv := reflect.ValueOf(v); rv.Kind() == reflect.Ptr && rv.IsNil() {
+			return nil, false
+		}
+		return v.SpanAttrs(), true
+	}
+	return taggedFields(value)
+}
+
+// taggedFields returns the exported fields of a struct with SpanAttrTag, by
+// their tag name.
+func taggedFields(value any) (map[string]any, bool) {
+	v := reflect.ValueOf(value)
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil, false
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil, false
+	}
 
-	for key, value := range attrs {
-		if attr := convertToAttribute(key, value); attr.Valid() {
-			spanAttrs = append(spanAttrs, attr)
+	var fields map[string]any
+	t := v.Type()
+	for i := range t.NumField() {
+		field := t.Field(i)
+		name := field.Tag.Get(SpanAttrTag)
+		if name == "" || name == "-" || !field.IsExported() {
+			continue
+		}
+		if fields == nil {
+			fields = make(map[string]any)
 		}
+		fields[name] = v.Field(i).Interface()
 	}
+	return fields, fields != nil
+}
+
+// truncate cuts s to at most n bytes on a rune boundary and appends an
+// ellipsis, it reports whether s was cut.
+func truncate(s string, n int) (string, bool) {
+	if len(s) <= n {
+		return s, false
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n] + "…", true
+}
 
-	if len(spanAttrs) > 0 {
-		span.SetAttributes(spanAttrs...)
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
 	}
+	slices.Sort(keys)
+	return keys
 }
 
 // convertToAttribute converts a value to an OpenTelemetry attribute.
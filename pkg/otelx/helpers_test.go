@@ -211,6 +211,103 @@ func TestSetSpanAttrs(t *testing.T) {
 		assert.Contains(t, spans[0].Attributes, attribute.String("unsupported", "<unsupported type: chan int>"))
 		assert.Len(t, spans[0].Attributes, 2)
 	})
+
+	t.Run("Nested map", func(t *testing.T) {
+		exporter := tracetest.NewInMemoryExporter()
+		provider := trace.NewTracerProvider(trace.WithSyncer(exporter))
+		tracer := provider.Tracer("test")
+		_, span := tracer.Start(context.TODO(), "test")
+
+		attrs := map[string]any{
+			"request": map[string]any{
+				"method": "POST",
+				"size":   512,
+				"inner":  map[string]any{"deep": true},
+			},
+			"empty": map[string]any{},
+		}
+
+		SetSpanAttrs(span, attrs)
+		span.End()
+
+		spans := exporter.GetSpans()
+		assert.Len(t, spans, 1)
+		assert.Contains(t, spans[0].Attributes, attribute.String("request.method", "POST"))
+		assert.Contains(t, spans[0].Attributes, attribute.Int("request.size", 512))
+		assert.Contains(t, spans[0].Attributes, attribute.String("request.inner", "<unsupported type: map[string]interface {}>"),
+			"only one level is flattened")
+		assert.Len(t, spans[0].Attributes, 3)
+	})
+
+	t.Run("Structs", func(t *testing.T) {
+		exporter := tracetest.NewInMemoryExporter()
+		provider := trace.NewTracerProvider(trace.WithSyncer(exporter))
+		tracer := provider.Tracer("test")
+		_, span := tracer.Start(context.TODO(), "test")
+
+		attrs := map[string]any{
+			"invitation": &taggedStruct{ID: 7, Status: "active", Secret: "hidden", internal: "unexported"},
+			"cmd":        attributerStruct{Count: 3},
+			"nilCmd":     (*attributerPtr)(nil),
+			"plain":      struct{ A int }{A: 1},
+		}
+
+		SetSpanAttrs(span, attrs)
+		span.End()
+
+		spans := exporter.GetSpans()
+		assert.Len(t, spans, 1)
+		assert.Contains(t, spans[0].Attributes, attribute.Int64("invitation.id", 7))
+		assert.Contains(t, spans[0].Attributes, attribute.String("invitation.status", "active"))
+		assert.Contains(t, spans[0].Attributes, attribute.Int("cmd.count", 3))
+		assert.Contains(t, spans[0].Attributes, attribute.String("nilCmd", "<nil>"))
+		assert.Contains(t, spans[0].Attributes, attribute.String("plain", "{A:1}"), "structs without tags keep their format")
+		assert.Len(t, spans[0].Attributes, 5)
+	})
+
+	t.Run("Caps", func(t *testing.T) {
+		exporter := tracetest.NewInMemoryExporter()
+		provider := trace.NewTracerProvider(trace.WithSyncer(exporter))
+		tracer := provider.Tracer("test")
+		_, span := tracer.Start(context.TODO(), "test")
+
+		attrs := make(map[string]any, 100)
+		for i := range 100 {
+			attrs[fmt.Sprintf("key_%03d", i)] = i
+		}
+		attrs["big"] = strings.Repeat("a", MaxSpanAttrValueLen-1) + "ё"
+
+		SetSpanAttrs(span, attrs)
+		span.End()
+
+		spans := exporter.GetSpans()
+		assert.Len(t, spans, 1)
+		assert.Len(t, spans[0].Attributes, MaxSpanAttrs)
+		assert.Contains(t, spans[0].Attributes, attribute.String("big", strings.Repeat("a", MaxSpanAttrValueLen-1)+"…"),
+			"values are cut on a rune boundary")
+		assert.Contains(t, spans[0].Attributes, attribute.Bool("big.truncated", true))
+		assert.Contains(t, spans[0].Attributes, attribute.Int("key_061", 61))
+		assert.NotContains(t, spans[0].Attributes, attribute.Int("key_062", 62), "keys are added in sorted order")
+	})
+}
+
+type taggedStruct struct {
+	ID       int    `otel:"id"`
+	Status   string `otel:"status"`
+	Secret   string `otel:"-"`
+	internal string `otel:"internal"`
+}
+
+type attributerStruct struct{ Count int }
+
+func (a attributerStruct) SpanAttrs() map[string]any {
+	return map[string]any{"count": a.Count}
+}
+
+type attributerPtr struct{}
+
+func (a *attributerPtr) SpanAttrs() map[string]any {
+	return map[string]any{"never": true}
 }
 
 func BenchmarkSetSpanAttrs_SmallMap(b *testing.B) {
@@ -696,6 +793,31 @@ func BenchmarkSetSpanAttrs_WorstCase(b *testing.B) {
 	}
 }
 
+func BenchmarkSetSpanAttrs_Flatten(b *testing.B) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := trace.NewTracerProvider(trace.WithSyncer(exporter))
+	tracer := provider.Tracer("test")
+
+	attrs := map[string]any{
+		"request": map[string]any{
+			"method": "POST",
+			"path":   "/api/v1/staff/invitations",
+			"size":   1024,
+		},
+		"invitation": taggedStruct{ID: 42, Status: "active"},
+		"cmd":        attributerStruct{Count: 5},
+		"user.id":    "user_12345",
+	}
+
+	b.ReportAllocs()
+
+	for b.Loop() {
+		_, span := tracer.Start(context.TODO(), "test")
+		SetSpanAttrs(span, attrs)
+		span.End()
+	}
+}
+
 // Benchmark for concurrent usage
 func BenchmarkSetSpanAttrs_Concurrent(b *testing.B) {
 	exporter := tracetest.NewInMemoryExporter()
@@ -0,0 +1,49 @@
+package metrics
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// DefaultCacheTTL is how long gauge values backed by database queries are
+// reused, collections happen far more often than the numbers change.
+const DefaultCacheTTL = 60 * time.Second
+
+// Cached memoizes the result of load for ttl. Failed loads are not cached.
+type Cached[T any] struct {
+	load func(context.Context) (T, error)
+	ttl  time.Duration
+	now  func() time.Time
+
+	mu        sync.Mutex
+	value     T
+	expiresAt time.Time
+}
+
+func NewCached[T any](ttl time.Duration, load func(context.Context) (T, error)) *Cached[T] {
+	if ttl <= 0 {
+		ttl = DefaultCacheTTL
+	}
+	return &Cached[T]{load: load, ttl: ttl, now: time.Now}
+}
+
+// Get returns the cached value or loads a fresh one once the ttl elapsed.
+func (c *Cached[T]) Get(ctx context.Context) (T, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	if now.Before(c.expiresAt) {
+		return c.value, nil
+	}
+
+	value, err := c.load(ctx)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	c.value = value
+	c.expiresAt = now.Add(c.ttl)
+	return value, nil
+}
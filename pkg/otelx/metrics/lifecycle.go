@@ -0,0 +1,59 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+)
+
+// Shutdown kinds recorded in the AttrShutdownKind attribute.
+const (
+	ShutdownClean  = "clean"
+	ShutdownForced = "forced"
+)
+
+// RegisterBuildInfo registers the BuildInfo gauge, it always reports 1 so
+// the running versions can be queried by their attributes.
+func RegisterBuildInfo(r *Registry, version, commit, buildDate string) error {
+	const op = "metrics.RegisterBuildInfo"
+	attrs := metric.WithAttributes(
+		attribute.String(AttrVersion, version),
+		attribute.String(AttrCommit, commit),
+		attribute.String(AttrBuildDate, buildDate),
+	)
+	err := r.RegisterInt64Gauge(BuildInfo,
+		func(_ context.Context, o metric.Int64Observer) error {
+			o.Observe(1, attrs)
+			return nil
+		},
+		metric.WithDescription("Build information of the running binary, always 1"),
+	)
+	if err != nil {
+		return errorx.Wrap(err, op)
+	}
+	return nil
+}
+
+// RecordStartDuration records how long the process took to become ready.
+func RecordStartDuration(ctx context.Context, r *Registry, d time.Duration) {
+	r.Float64Histogram(StartDuration,
+		metric.WithDescription("Time from process start until the server accepts requests"),
+		metric.WithUnit("s"),
+	).Record(ctx, d.Seconds())
+}
+
+// RecordShutdown counts a shutdown, forced ones did not drain in time.
+func RecordShutdown(ctx context.Context, r *Registry, forced bool) {
+	kind := ShutdownClean
+	if forced {
+		kind = ShutdownForced
+	}
+	r.Int64Counter(Shutdowns,
+		metric.WithDescription("Number of server shutdowns by kind"),
+		metric.WithUnit("{shutdown}"),
+	).Add(ctx, 1, metric.WithAttributes(attribute.String(AttrShutdownKind, kind)))
+}
@@ -0,0 +1,49 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestRegisterBuildInfo(t *testing.T) {
+	reg, reader := newTestRegistry()
+
+	require.NoError(t, RegisterBuildInfo(reg, "1.4.0", "4b158c7", "2025-08-01T10:00:00Z"))
+
+	gauge := collect(t, reader)[BuildInfo].(metricdata.Gauge[int64])
+	require.Len(t, gauge.DataPoints, 1)
+	dp := gauge.DataPoints[0]
+	assert.Equal(t, int64(1), dp.Value)
+	assert.Equal(t, attribute.NewSet(
+		attribute.String(AttrVersion, "1.4.0"),
+		attribute.String(AttrCommit, "4b158c7"),
+		attribute.String(AttrBuildDate, "2025-08-01T10:00:00Z"),
+	), dp.Attributes)
+}
+
+func TestRecordLifecycle(t *testing.T) {
+	reg, reader := newTestRegistry()
+
+	RecordStartDuration(t.Context(), reg, 1500*time.Millisecond)
+	RecordShutdown(t.Context(), reg, false)
+	RecordShutdown(t.Context(), reg, true)
+	RecordShutdown(t.Context(), reg, true)
+
+	got := collect(t, reader)
+
+	hist := got[StartDuration].(metricdata.Histogram[float64])
+	require.Len(t, hist.DataPoints, 1)
+	assert.Equal(t, 1.5, hist.DataPoints[0].Sum)
+
+	byKind := make(map[string]int64)
+	for _, dp := range got[Shutdowns].(metricdata.Sum[int64]).DataPoints {
+		kind, _ := dp.Attributes.Value(AttrShutdownKind)
+		byKind[kind.AsString()] = dp.Value
+	}
+	assert.Equal(t, map[string]int64{ShutdownClean: 1, ShutdownForced: 2}, byKind)
+}
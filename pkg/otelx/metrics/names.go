@@ -0,0 +1,82 @@
+// Package metrics registers business level instruments once per meter.
+//
+// Instrument names follow the OpenTelemetry conventions: dot separated,
+// prefixed with "ucms", the entity first and the event or state last, e.g.
+// ucms.registration.completed. Counters count events, gauges report the
+// current state of the database.
+package metrics
+
+// Instrument names.
+const (
+	// UsersByRole reports the number of users, by AttrRole.
+	UsersByRole = "ucms.users"
+	// RegistrationsPending reports the number of started but not completed
+	// registrations.
+	RegistrationsPending = "ucms.registration.pending"
+	// RegistrationCompleted counts students who completed their registration.
+	RegistrationCompleted = "ucms.registration.completed"
+	// StaffInvitationAccepted counts staff invitations accepted by recipients.
+	StaffInvitationAccepted = "ucms.staff_invitation.accepted"
+
+	// BuildInfo reports 1 with the version of the running binary.
+	BuildInfo = "ucms.build_info"
+	// StartDuration records the time the process took to start serving.
+	StartDuration = "ucms.start.duration"
+	// Shutdowns counts shutdowns, by AttrShutdownKind.
+	Shutdowns = "ucms.shutdown"
+	// CommandDuration records the duration of application commands, by
+	// AttrCommand and AttrErrorType.
+	CommandDuration = "ucms.command.duration"
+	// SlowOperations counts handlers and queries over their threshold, by
+	// AttrKind.
+	SlowOperations = "ucms.slow_operations"
+	// DeprecatedCalls counts the requests to the deprecated routes, by
+	// AttrRoute.
+	DeprecatedCalls = "ucms.http.deprecated_calls"
+	// SingleflightShared counts the commands given the result of a
+	// concurrent identical command instead of running, by AttrFlight.
+	SingleflightShared = "ucms.singleflight.shared"
+	// ValidationFailures counts the request fields failing validation, by
+	// AttrRoute and AttrField.
+	ValidationFailures = "ucms.validation.failure"
+	// MailSenderKind reports 1 with the kind of the mail sender, by
+	// AttrSenderKind.
+	MailSenderKind = "ucms.mail.sender_kind"
+	// LastSeenWrites counts the users whose last seen time was written.
+	LastSeenWrites = "ucms.last_seen.writes"
+	// RetentionDeleted counts the rows deleted past their retention window,
+	// by AttrTable.
+	RetentionDeleted = "ucms.retention.deleted"
+	// RetentionArchived counts the rows archived before their deletion, by
+	// AttrTable.
+	RetentionArchived = "ucms.retention.archived"
+
+	// JobRuns counts the runs of the background jobs, by AttrJob and
+	// AttrJobResult.
+	JobRuns = "ucms.job.runs"
+	// JobDuration records the duration of the background job runs, by
+	// AttrJob and AttrJobResult.
+	JobDuration = "ucms.job.duration"
+	// JobLastSuccess reports the unix time of the last successful run of the
+	// background jobs, by AttrJob.
+	JobLastSuccess = "ucms.job.last_success"
+)
+
+// Attribute keys.
+const (
+	AttrRole         = "user.role"
+	AttrVersion      = "service.version"
+	AttrCommit       = "vcs.revision"
+	AttrBuildDate    = "build.date"
+	AttrShutdownKind = "shutdown.kind"
+	AttrKind         = "kind"
+	AttrRoute        = "http.route"
+	AttrCommand      = "command.name"
+	AttrErrorType    = "error.type"
+	AttrFlight       = "singleflight.group"
+	AttrJob          = "job.name"
+	AttrJobResult    = "job.result"
+	AttrField        = "validation.field"
+	AttrSenderKind   = "mail.sender_kind"
+	AttrTable        = "db.table"
+)
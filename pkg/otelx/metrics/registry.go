@@ -0,0 +1,117 @@
+package metrics
+
+import (
+	"errors"
+	"log/slog"
+	"sync"
+
+	"go.opentelemetry.io/contrib/bridges/otelslog"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/noop"
+
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+)
+
+var logger = otelslog.NewLogger("ucms/pkg/otelx/metrics")
+
+// Registry creates instruments on first use and hands out the same
+// instrument afterwards, so handlers built more than once (e.g. in tests or
+// per tenant) do not register duplicates or observe gauges twice.
+type Registry struct {
+	meter metric.Meter
+
+	mu          sync.Mutex
+	instruments map[string]any
+}
+
+func NewRegistry(meter metric.Meter) *Registry {
+	return &Registry{
+		meter:       meter,
+		instruments: make(map[string]any),
+	}
+}
+
+var (
+	defaultRegistry     *Registry
+	defaultRegistryOnce sync.Once
+)
+
+// Default returns the registry backed by the global meter provider.
+func Default() *Registry {
+	defaultRegistryOnce.Do(func() {
+		defaultRegistry = NewRegistry(otel.Meter("ucms/business"))
+	})
+	return defaultRegistry
+}
+
+// Int64Counter returns the counter registered under name, creating it on the
+// first call. Options of later calls are ignored. A no-op counter is returned
+// if the instrument cannot be created, metrics never fail a command.
+func (r *Registry) Int64Counter(name string, opts ...metric.Int64CounterOption) metric.Int64Counter {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if inst, ok := r.instruments[name]; ok {
+		if counter, ok := inst.(metric.Int64Counter); ok {
+			return counter
+		}
+		logger.Warn("instrument already registered with another kind", slog.String("name", name))
+		return noop.Int64Counter{}
+	}
+
+	counter, err := r.meter.Int64Counter(name, opts...)
+	if err != nil {
+		logger.Warn("failed to create counter", slog.String("name", name), slog.String("error", err.Error()))
+		return noop.Int64Counter{}
+	}
+	r.instruments[name] = counter
+	return counter
+}
+
+// Float64Histogram returns the histogram registered under name, creating it
+// on the first call, see Int64Counter.
+func (r *Registry) Float64Histogram(name string, opts ...metric.Float64HistogramOption) metric.Float64Histogram {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if inst, ok := r.instruments[name]; ok {
+		if histogram, ok := inst.(metric.Float64Histogram); ok {
+			return histogram
+		}
+		logger.Warn("instrument already registered with another kind", slog.String("name", name))
+		return noop.Float64Histogram{}
+	}
+
+	histogram, err := r.meter.Float64Histogram(name, opts...)
+	if err != nil {
+		logger.Warn("failed to create histogram", slog.String("name", name), slog.String("error", err.Error()))
+		return noop.Float64Histogram{}
+	}
+	r.instruments[name] = histogram
+	return histogram
+}
+
+// RegisterInt64Gauge registers an observable gauge reporting observe on every
+// collection. Registering the same name again is a no-op, the first callback
+// stays in place.
+func (r *Registry) RegisterInt64Gauge(name string, observe metric.Int64Callback, opts ...metric.Int64ObservableGaugeOption) error {
+	const op = "metrics.Registry.RegisterInt64Gauge"
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if inst, ok := r.instruments[name]; ok {
+		if _, ok := inst.(metric.Int64ObservableGauge); ok {
+			return nil
+		}
+		return errorx.Wrap(errors.New("instrument "+name+" already registered with another kind"), op)
+	}
+
+	opts = append(opts, metric.WithInt64Callback(observe))
+	gauge, err := r.meter.Int64ObservableGauge(name, opts...)
+	if err != nil {
+		return errorx.Wrap(err, op)
+	}
+	r.instruments[name] = gauge
+	return nil
+}
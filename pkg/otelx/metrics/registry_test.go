@@ -0,0 +1,105 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/metric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func newTestRegistry() (*Registry, *sdkmetric.ManualReader) {
+	reader := sdkmetric.NewManualReader()
+	return NewRegistry(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)).Meter("test")), reader
+}
+
+func collect(t *testing.T, reader *sdkmetric.ManualReader) map[string]metricdata.Aggregation {
+	t.Helper()
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	got := make(map[string]metricdata.Aggregation)
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			got[m.Name] = m.Data
+		}
+	}
+	return got
+}
+
+func TestRegistry_Int64CounterRegisteredOnce(t *testing.T) {
+	reg, reader := newTestRegistry()
+
+	first := reg.Int64Counter(RegistrationCompleted)
+	second := reg.Int64Counter(RegistrationCompleted)
+	assert.Same(t, first, second)
+
+	first.Add(t.Context(), 1)
+	second.Add(t.Context(), 1)
+
+	sum := collect(t, reader)[RegistrationCompleted].(metricdata.Sum[int64])
+	require.Len(t, sum.DataPoints, 1)
+	assert.Equal(t, int64(2), sum.DataPoints[0].Value)
+}
+
+func TestRegistry_RegisterInt64GaugeOnce(t *testing.T) {
+	reg, reader := newTestRegistry()
+
+	calls := 0
+	observe := func(_ context.Context, o metric.Int64Observer) error {
+		calls++
+		o.Observe(7)
+		return nil
+	}
+	require.NoError(t, reg.RegisterInt64Gauge(RegistrationsPending, observe))
+	require.NoError(t, reg.RegisterInt64Gauge(RegistrationsPending, observe))
+
+	gauge := collect(t, reader)[RegistrationsPending].(metricdata.Gauge[int64])
+	require.Len(t, gauge.DataPoints, 1)
+	assert.Equal(t, int64(7), gauge.DataPoints[0].Value)
+	assert.Equal(t, 1, calls, "the callback should be registered once")
+}
+
+func TestRegistry_KindMismatch(t *testing.T) {
+	reg, _ := newTestRegistry()
+
+	reg.Int64Counter(UsersByRole)
+	err := reg.RegisterInt64Gauge(UsersByRole, func(context.Context, metric.Int64Observer) error { return nil })
+	assert.Error(t, err)
+}
+
+func TestCached(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	loads := 0
+	var loadErr error
+	c := NewCached(time.Minute, func(context.Context) (int, error) {
+		loads++
+		return loads, loadErr
+	})
+	c.now = func() time.Time { return now }
+
+	v, err := c.Get(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, 1, v)
+
+	now = now.Add(59 * time.Second)
+	v, _ = c.Get(t.Context())
+	assert.Equal(t, 1, v, "value should be reused within the ttl")
+
+	now = now.Add(time.Second)
+	v, _ = c.Get(t.Context())
+	assert.Equal(t, 2, v, "value should be reloaded once the ttl elapsed")
+
+	now = now.Add(time.Minute)
+	loadErr = errors.New("db down")
+	_, err = c.Get(t.Context())
+	assert.Error(t, err)
+	loadErr = nil
+	v, err = c.Get(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, 4, v, "failed loads should not be cached")
+}
@@ -0,0 +1,207 @@
+package otelx
+
+import (
+	"net/mail"
+	"strings"
+	"sync/atomic"
+	"unicode/utf8"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"gitlab.com/ucmsv2/ucms-backend/pkg/env"
+)
+
+// Redacted replaces values of secret attributes.
+const Redacted = "[REDACTED]"
+
+// RedactionPolicy decides which span attributes and log attributes are
+// redacted. Keys are matched case-insensitively against their dot, dash or
+// underscore separated segments, e.g. "verification_code" matches "code".
+type RedactionPolicy struct {
+	// Enabled turns redaction on, when false values are kept as they are.
+	Enabled bool
+	// SecretKeys are replaced with Redacted whatever their value.
+	SecretKeys []string
+	// MaskedKeys keep a short prefix of their value, e.g. barcodes.
+	MaskedKeys []string
+	// AllowedKeys are never redacted, e.g. http.status_code contains "code".
+	AllowedKeys []string
+}
+
+// DefaultRedactionPolicy redacts credentials and verification codes, masks
+// barcodes and usernames, and masks anything that looks like an email.
+func DefaultRedactionPolicy() RedactionPolicy {
+	return RedactionPolicy{
+		Enabled:    true,
+		SecretKeys: []string{"password", "token", "secret", "code", "otp", "authorization", "cookie"},
+		MaskedKeys: []string{"barcode", "username"},
+		AllowedKeys: []string{
+			"http.status_code",
+			"http.response.status_code",
+			"rpc.grpc.status_code",
+			"error.code",
+			"error.type",
+			"code.function",
+			"code.filepath",
+			"code.lineno",
+			"code.namespace",
+		},
+	}
+}
+
+// RedactionEnabledFor reports whether PII should be redacted in mode. The
+// modes allowing env.CapPlaintextTelemetry keep plaintext to ease debugging.
+func RedactionEnabledFor(mode env.Mode) bool {
+	return !mode.Allows(env.CapPlaintextTelemetry)
+}
+
+var policy atomic.Pointer[compiledPolicy]
+
+func init() {
+	SetRedactionPolicy(DefaultRedactionPolicy())
+}
+
+// SetRedactionPolicy replaces the policy used by Redact, SetSpanAttrs and the
+// redacting log handler.
+func SetRedactionPolicy(p RedactionPolicy) {
+	policy.Store(compilePolicy(p))
+}
+
+// Redact returns value as it may be recorded under key.
+func Redact(key, value string) string {
+	return policy.Load().redact(key, value)
+}
+
+// IsSecretKey reports whether values under key are replaced with Redacted.
+func IsSecretKey(key string) bool {
+	p := policy.Load()
+	return p.enabled && p.classify(key) == classSecret
+}
+
+// RedactAttr redacts a span attribute. Non string attributes under secret
+// keys are replaced as well.
+func RedactAttr(kv attribute.KeyValue) attribute.KeyValue {
+	p := policy.Load()
+	if !p.enabled {
+		return kv
+	}
+
+	key := string(kv.Key)
+	switch kv.Value.Type() {
+	case attribute.STRING:
+		return attribute.String(key, p.redact(key, kv.Value.AsString()))
+	case attribute.STRINGSLICE:
+		values := kv.Value.AsStringSlice()
+		redacted := make([]string, len(values))
+		for i, v := range values {
+			redacted[i] = p.redact(key, v)
+		}
+		return attribute.StringSlice(key, redacted)
+	default:
+		if p.classify(key) == classSecret {
+			return attribute.String(key, Redacted)
+		}
+		return kv
+	}
+}
+
+type keyClass int
+
+const (
+	classPlain keyClass = iota
+	classSecret
+	classMasked
+)
+
+type compiledPolicy struct {
+	enabled bool
+	secret  map[string]bool
+	masked  map[string]bool
+	allowed map[string]bool
+}
+
+func compilePolicy(p RedactionPolicy) *compiledPolicy {
+	set := func(keys []string) map[string]bool {
+		m := make(map[string]bool, len(keys))
+		for _, k := range keys {
+			m[strings.ToLower(k)] = true
+		}
+		return m
+	}
+	return &compiledPolicy{
+		enabled: p.Enabled,
+		secret:  set(p.SecretKeys),
+		masked:  set(p.MaskedKeys),
+		allowed: set(p.AllowedKeys),
+	}
+}
+
+func (p *compiledPolicy) classify(key string) keyClass {
+	key = strings.ToLower(key)
+	if p.allowed[key] {
+		return classPlain
+	}
+
+	class := classPlain
+	segments := strings.FieldsFunc(key, func(r rune) bool { return r == '.' || r == '_' || r == '-' })
+	for _, s := range segments {
+		if p.secret[s] {
+			return classSecret
+		}
+		if p.masked[s] {
+			class = classMasked
+		}
+	}
+	return class
+}
+
+func (p *compiledPolicy) redact(key, value string) string {
+	if !p.enabled || value == "" {
+		return value
+	}
+
+	switch p.classify(key) {
+	case classSecret:
+		return Redacted
+	case classMasked:
+		if isEmail(value) {
+			return maskEmail(value)
+		}
+		return maskPrefix(value, 2)
+	}
+
+	// Emails are masked whatever the key, e.g. "email_or_barcode" or "to".
+	if isEmail(value) {
+		return maskEmail(value)
+	}
+	return value
+}
+
+func isEmail(s string) bool {
+	at := strings.IndexByte(s, '@')
+	if at <= 0 || at == len(s)-1 || strings.ContainsAny(s, " <>") {
+		return false
+	}
+	_, err := mail.ParseAddress(s)
+	return err == nil
+}
+
+// maskEmail keeps the first rune of the local part and the domain, e.g.
+// n***@example.com.
+func maskEmail(s string) string {
+	at := strings.LastIndexByte(s, '@')
+	_, size := utf8.DecodeRuneInString(s)
+	return s[:size] + "***" + s[at:]
+}
+
+func maskPrefix(s string, keep int) string {
+	if utf8.RuneCountInString(s) <= keep {
+		return strings.Repeat("*", utf8.RuneCountInString(s))
+	}
+	offset := 0
+	for range keep {
+		_, size := utf8.DecodeRuneInString(s[offset:])
+		offset += size
+	}
+	return s[:offset] + "***"
+}
@@ -0,0 +1,144 @@
+package otelx
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"gitlab.com/ucmsv2/ucms-backend/pkg/env"
+)
+
+// Barcode mirrors user.Barcode, a named string type.
+type Barcode string
+
+type stringerBarcode struct{ v string }
+
+func (b stringerBarcode) String() string { return b.v }
+
+func withPolicy(t *testing.T, p RedactionPolicy) {
+	t.Helper()
+	SetRedactionPolicy(p)
+	t.Cleanup(func() { SetRedactionPolicy(DefaultRedactionPolicy()) })
+}
+
+func TestRedact(t *testing.T) {
+	tests := []struct {
+		key   string
+		value string
+		want  string
+	}{
+		{key: "email", value: "nurlan@example.com", want: "n***@example.com"},
+		{key: "user.email", value: "ab@example.com", want: "a***@example.com"},
+		{key: "request.to", value: "someone@astanait.edu.kz", want: "s***@astanait.edu.kz"},
+		{key: "verification_code", value: "ABC123", want: Redacted},
+		{key: "invitation.code", value: "xyz", want: Redacted},
+		{key: "password", value: "StrongP@ssw0rd", want: Redacted},
+		{key: "refresh_token", value: "eyJhbGciOi", want: Redacted},
+		{key: "Authorization", value: "Bearer abc", want: Redacted},
+		{key: "student.barcode", value: "230107", want: "23***"},
+		{key: "username", value: "nurlan", want: "nu***"},
+		{key: "http.status_code", value: "404", want: "404"},
+		{key: "user.id", value: "0198c1b6-6f2a-7c6e-8b1a-5d3c9f0e1a2b", want: "0198c1b6-6f2a-7c6e-8b1a-5d3c9f0e1a2b"},
+		{key: "message", value: "not an email @ all", want: "not an email @ all"},
+		{key: "email", value: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key+"="+tt.value, func(t *testing.T) {
+			assert.Equal(t, tt.want, Redact(tt.key, tt.value))
+		})
+	}
+}
+
+func TestRedact_Disabled(t *testing.T) {
+	p := DefaultRedactionPolicy()
+	p.Enabled = false
+	withPolicy(t, p)
+
+	assert.Equal(t, "nurlan@example.com", Redact("email", "nurlan@example.com"))
+	assert.Equal(t, "ABC123", Redact("verification_code", "ABC123"))
+}
+
+func TestRedactionEnabledFor(t *testing.T) {
+	assert.False(t, RedactionEnabledFor(env.Dev))
+	assert.False(t, RedactionEnabledFor(env.Local))
+	assert.True(t, RedactionEnabledFor(env.Test))
+	assert.True(t, RedactionEnabledFor(env.Prod))
+}
+
+func TestSetSpanAttrs_Redacts(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := trace.NewTracerProvider(trace.WithSyncer(exporter))
+	_, span := provider.Tracer("test").Start(context.TODO(), "test")
+
+	barcode := Barcode("230107")
+	SetSpanAttrs(span, map[string]any{
+		"email":              "nurlan@example.com",
+		"student.barcode":    &barcode,
+		"staff.barcode":      stringerBarcode{v: "000001"},
+		"verification_code":  123456,
+		"recipients":         []string{"a.b@example.com", "c.d@example.com"},
+		"request.group_id":   "group-1",
+		"http.status_code":   200,
+		"invitation.expired": false,
+	})
+	span.End()
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	attrs := make(map[attribute.Key]attribute.Value)
+	for _, a := range spans[0].Attributes {
+		attrs[a.Key] = a.Value
+	}
+
+	assert.Equal(t, "n***@example.com", attrs["email"].AsString())
+	assert.Equal(t, "23***", attrs["student.barcode"].AsString())
+	assert.Equal(t, "00***", attrs["staff.barcode"].AsString())
+	assert.Equal(t, Redacted, attrs["verification_code"].AsString())
+	assert.Equal(t, []string{"a***@example.com", "c***@example.com"}, attrs["recipients"].AsStringSlice())
+	assert.Equal(t, "group-1", attrs["request.group_id"].AsString())
+	assert.Equal(t, int64(200), attrs["http.status_code"].AsInt64())
+	assert.False(t, attrs["invitation.expired"].AsBool())
+}
+
+func TestRedactingHandler(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(NewRedactingHandler(slog.NewJSONHandler(&buf, nil)))
+
+	logger.With(slog.String("email", "nurlan@example.com")).
+		WithGroup("user").
+		Info("login",
+			slog.Any("barcode", Barcode("230107")),
+			slog.String("password", "hunter22"),
+			slog.Int("code", 123456),
+			slog.Group("meta", slog.String("token", "abc"), slog.String("ip", "127.0.0.1")),
+		)
+
+	var got map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &got))
+	assert.Equal(t, "n***@example.com", got["email"])
+	user := got["user"].(map[string]any)
+	assert.Equal(t, "23***", user["barcode"])
+	assert.Equal(t, Redacted, user["password"])
+	assert.Equal(t, Redacted, user["code"])
+	meta := user["meta"].(map[string]any)
+	assert.Equal(t, Redacted, meta["token"])
+	assert.Equal(t, "127.0.0.1", meta["ip"])
+}
+
+func TestRedactSlogAttr_AsReplaceAttr(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{ReplaceAttr: RedactSlogAttr}))
+
+	logger.Info("sent", slog.String("to", "nurlan@example.com"))
+	assert.Contains(t, buf.String(), "to=n***@example.com")
+	assert.NotContains(t, buf.String(), "nurlan@")
+}
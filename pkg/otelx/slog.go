@@ -0,0 +1,88 @@
+package otelx
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// RedactSlogAttr redacts a log attribute, it has the signature of
+// slog.HandlerOptions.ReplaceAttr. Groups are part of the matched key, e.g.
+// "user.email".
+func RedactSlogAttr(groups []string, a slog.Attr) slog.Attr {
+	if !policy.Load().enabled {
+		return a
+	}
+
+	key := a.Key
+	if len(groups) > 0 {
+		key = strings.Join(groups, ".") + "." + a.Key
+	}
+
+	v := a.Value.Resolve()
+	switch v.Kind() {
+	case slog.KindGroup:
+		attrs := v.Group()
+		sub := append(groups[:len(groups):len(groups)], a.Key)
+		redacted := make([]slog.Attr, len(attrs))
+		for i, ga := range attrs {
+			redacted[i] = RedactSlogAttr(sub, ga)
+		}
+		return slog.Attr{Key: a.Key, Value: slog.GroupValue(redacted...)}
+	case slog.KindString:
+		return slog.String(a.Key, Redact(key, v.String()))
+	case slog.KindAny:
+		// Stringers and named string types, e.g. user.Barcode.
+		kv := RedactAttr(convertToAttribute(key, v.Any()))
+		if kv.Value.Type() == attribute.STRING {
+			return slog.String(a.Key, kv.Value.AsString())
+		}
+		return a
+	default:
+		if IsSecretKey(key) {
+			return slog.String(a.Key, Redacted)
+		}
+		return slog.Attr{Key: a.Key, Value: v}
+	}
+}
+
+// RedactingHandler redacts the attributes of every record before passing it
+// on, for handlers without a ReplaceAttr option like the otelslog bridge.
+type RedactingHandler struct {
+	next   slog.Handler
+	groups []string
+}
+
+func NewRedactingHandler(next slog.Handler) *RedactingHandler {
+	return &RedactingHandler{next: next}
+}
+
+func (h *RedactingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *RedactingHandler) Handle(ctx context.Context, r slog.Record) error {
+	redacted := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		redacted.AddAttrs(RedactSlogAttr(h.groups, a))
+		return true
+	})
+	return h.next.Handle(ctx, redacted)
+}
+
+func (h *RedactingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		redacted[i] = RedactSlogAttr(h.groups, a)
+	}
+	return &RedactingHandler{next: h.next.WithAttrs(redacted), groups: h.groups}
+}
+
+func (h *RedactingHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &RedactingHandler{next: h.next.WithGroup(name), groups: append(h.groups[:len(h.groups):len(h.groups)], name)}
+}
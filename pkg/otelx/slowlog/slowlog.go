@@ -0,0 +1,143 @@
+// Package slowlog warns about HTTP handlers and database queries slower than
+// a threshold. It is a cheap always-on signal, the traces tell the details.
+package slowlog
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/contrib/bridges/otelslog"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx/metrics"
+)
+
+var logger = otelslog.NewLogger("ucms/pkg/otelx/slowlog")
+
+// Kinds recorded in the metrics.AttrKind attribute and the kind log attribute.
+const (
+	KindHandler = "http_handler"
+	KindQuery   = "db_query"
+)
+
+const (
+	DefaultHandlerThreshold = 2 * time.Second
+	DefaultQueryThreshold   = 500 * time.Millisecond
+)
+
+// Thresholds above which operations are reported, zero disables the check.
+type Thresholds struct {
+	Handler time.Duration `yaml:"handler"`
+	Query   time.Duration `yaml:"query"`
+}
+
+// DefaultThresholds returns DefaultHandlerThreshold and DefaultQueryThreshold.
+func DefaultThresholds() Thresholds {
+	return Thresholds{
+		Handler: DefaultHandlerThreshold,
+		Query:   DefaultQueryThreshold,
+	}
+}
+
+// Monitor compares durations against thresholds that can be changed while
+// the server is running.
+type Monitor struct {
+	logger  *slog.Logger
+	metrics *metrics.Registry
+
+	handler atomic.Int64
+	query   atomic.Int64
+}
+
+type Args struct {
+	Logger  *slog.Logger
+	Metrics *metrics.Registry
+	// Thresholds default to DefaultThresholds when nil.
+	Thresholds *Thresholds
+}
+
+func NewMonitor(args Args) *Monitor {
+	if args.Logger == nil {
+		args.Logger = logger
+	}
+	if args.Metrics == nil {
+		args.Metrics = metrics.Default()
+	}
+	if args.Thresholds == nil {
+		t := DefaultThresholds()
+		args.Thresholds = &t
+	}
+
+	m := &Monitor{
+		logger:  args.Logger,
+		metrics: args.Metrics,
+	}
+	m.SetThresholds(*args.Thresholds)
+	return m
+}
+
+var (
+	defaultMonitor     *Monitor
+	defaultMonitorOnce sync.Once
+)
+
+// Default returns the monitor shared by the HTTP port and the database pool.
+func Default() *Monitor {
+	defaultMonitorOnce.Do(func() {
+		defaultMonitor = NewMonitor(Args{})
+	})
+	return defaultMonitor
+}
+
+// SetThresholds replaces both thresholds, negative values are treated as zero.
+func (m *Monitor) SetThresholds(t Thresholds) {
+	m.handler.Store(int64(max(t.Handler, 0)))
+	m.query.Store(int64(max(t.Query, 0)))
+}
+
+func (m *Monitor) Thresholds() Thresholds {
+	return Thresholds{
+		Handler: time.Duration(m.handler.Load()),
+		Query:   time.Duration(m.query.Load()),
+	}
+}
+
+// ObserveHandler reports a handler of route that took d, it returns whether
+// it was slow.
+func (m *Monitor) ObserveHandler(ctx context.Context, route string, d time.Duration) bool {
+	threshold := time.Duration(m.handler.Load())
+	if threshold == 0 || d < threshold {
+		return false
+	}
+	m.report(ctx, KindHandler, "route", route, d, threshold)
+	return true
+}
+
+// ObserveQuery reports a query that took d, it returns whether it was slow.
+func (m *Monitor) ObserveQuery(ctx context.Context, query string, d time.Duration) bool {
+	threshold := time.Duration(m.query.Load())
+	if threshold == 0 || d < threshold {
+		return false
+	}
+	m.report(ctx, KindQuery, "query", query, d, threshold)
+	return true
+}
+
+func (m *Monitor) report(ctx context.Context, kind, nameKey, name string, d, threshold time.Duration) {
+	m.logger.WarnContext(ctx, "slow "+kind,
+		slog.String("kind", kind),
+		slog.String(nameKey, name),
+		slog.Int64("duration_ms", d.Milliseconds()),
+		slog.Int64("threshold_ms", threshold.Milliseconds()),
+		slog.String("correlation_id", otelx.CorrelationID(ctx)),
+	)
+	m.metrics.Int64Counter(metrics.SlowOperations,
+		metric.WithDescription("Number of handlers and queries slower than their threshold"),
+		metric.WithUnit("{operation}"),
+	).Add(ctx, 1, metric.WithAttributes(attribute.String(metrics.AttrKind, kind)))
+}
@@ -0,0 +1,105 @@
+package slowlog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx/metrics"
+)
+
+type testMonitor struct {
+	*Monitor
+	logs    *bytes.Buffer
+	metrics *sdkmetric.ManualReader
+}
+
+func newTestMonitor(t Thresholds) *testMonitor {
+	logs := &bytes.Buffer{}
+	reader := sdkmetric.NewManualReader()
+	return &testMonitor{
+		Monitor: NewMonitor(Args{
+			Logger:     slog.New(slog.NewJSONHandler(logs, nil)),
+			Metrics:    metrics.NewRegistry(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)).Meter("test")),
+			Thresholds: &t,
+		}),
+		logs:    logs,
+		metrics: reader,
+	}
+}
+
+// slowCount returns the SlowOperations counter value by kind.
+func (m *testMonitor) slowCount(t *testing.T) map[string]int64 {
+	t.Helper()
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, m.metrics.Collect(context.Background(), &rm))
+
+	counts := map[string]int64{}
+	for _, sm := range rm.ScopeMetrics {
+		for _, mm := range sm.Metrics {
+			if mm.Name != metrics.SlowOperations {
+				continue
+			}
+			for _, dp := range mm.Data.(metricdata.Sum[int64]).DataPoints {
+				kind, _ := dp.Attributes.Value(metrics.AttrKind)
+				counts[kind.AsString()] = dp.Value
+			}
+		}
+	}
+	return counts
+}
+
+func TestMonitor_ObserveQuery(t *testing.T) {
+	m := newTestMonitor(Thresholds{Handler: time.Second, Query: 100 * time.Millisecond})
+
+	tp := sdktrace.NewTracerProvider()
+	ctx, span := tp.Tracer("test").Start(t.Context(), "request")
+	defer span.End()
+
+	assert.False(t, m.ObserveQuery(ctx, "SELECT 1", 99*time.Millisecond))
+	assert.Empty(t, m.logs.String(), "fast queries are not logged")
+	assert.Empty(t, m.slowCount(t))
+
+	assert.True(t, m.ObserveQuery(ctx, "SELECT pg_sleep($1)", 150*time.Millisecond))
+
+	var entry map[string]any
+	require.NoError(t, json.Unmarshal(m.logs.Bytes(), &entry))
+	assert.Equal(t, "WARN", entry["level"])
+	assert.Equal(t, KindQuery, entry["kind"])
+	assert.Equal(t, "SELECT pg_sleep($1)", entry["query"])
+	assert.EqualValues(t, 150, entry["duration_ms"])
+	assert.EqualValues(t, 100, entry["threshold_ms"])
+	assert.Equal(t, span.SpanContext().TraceID().String(), entry["correlation_id"])
+	assert.Equal(t, map[string]int64{KindQuery: 1}, m.slowCount(t))
+}
+
+func TestMonitor_SetThresholds(t *testing.T) {
+	m := newTestMonitor(DefaultThresholds())
+	assert.Equal(t, Thresholds{Handler: 2 * time.Second, Query: 500 * time.Millisecond}, m.Thresholds())
+	assert.False(t, m.ObserveHandler(t.Context(), "GET /v1/users", time.Second))
+
+	m.SetThresholds(Thresholds{Handler: 500 * time.Millisecond, Query: -1})
+	assert.Equal(t, Thresholds{Handler: 500 * time.Millisecond}, m.Thresholds())
+	assert.True(t, m.ObserveHandler(t.Context(), "GET /v1/users", time.Second))
+	assert.False(t, m.ObserveQuery(t.Context(), "SELECT 1", time.Hour), "a zero threshold disables the check")
+	assert.Equal(t, map[string]int64{KindHandler: 1}, m.slowCount(t))
+}
+
+func BenchmarkMonitor_ObserveHandler_Fast(b *testing.B) {
+	m := newTestMonitor(DefaultThresholds())
+	ctx := context.Background()
+
+	b.ReportAllocs()
+	for b.Loop() {
+		m.ObserveHandler(ctx, "GET /v1/users", time.Millisecond)
+	}
+}
@@ -0,0 +1,111 @@
+// Package pagination encodes the keyset cursors of the list responses. A
+// cursor is the sort key and the id of the last row of a page, the next page
+// resumes after it. It is signed, the clients can not forge sort keys.
+package pagination
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/i18nx"
+)
+
+// ErrInvalidCursor is returned for a cursor that was not issued by the Codec,
+// was altered or does not fit the listing.
+var ErrInvalidCursor = errorx.NewInvalidRequest().WithKey(i18nx.KeyInvalidCursor)
+
+// cursorLabel separates the cursor key from the other uses of the secret.
+const cursorLabel = "ucms pagination cursor"
+
+// Cursor is the position of the last row of a page.
+type Cursor struct {
+	// Keys are the sort columns of the row, in the order of the listing.
+	Keys []string `json:"k"`
+	ID   string   `json:"id"`
+}
+
+// Meta is the "meta" of a list response.
+type Meta struct {
+	// NextCursor resumes after the page, it is empty on the last page.
+	NextCursor string `json:"next_cursor,omitempty"`
+	// Page is the page number of the offset mode, 0 in the cursor mode.
+	Page     int `json:"page,omitempty"`
+	PageSize int `json:"page_size"`
+}
+
+// Codec signs and verifies the cursors.
+type Codec struct {
+	key []byte
+}
+
+// NewCodec returns a codec signing with a key derived from secret, it panics
+// when secret is empty.
+func NewCodec(secret []byte) *Codec {
+	if len(secret) == 0 {
+		panic("pagination: empty cursor secret")
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(cursorLabel))
+	return &Codec{key: mac.Sum(nil)}
+}
+
+// Encode returns the opaque cursor of c, the base64 of its JSON and of its
+// signature.
+func (c *Codec) Encode(cur Cursor) string {
+	payload, err := json.Marshal(cur)
+	if err != nil {
+		// A Cursor of strings always marshals.
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(payload) + "." + base64.RawURLEncoding.EncodeToString(c.sign(payload))
+}
+
+// Decode verifies token and returns its cursor, with keys sort keys.
+func (c *Codec) Decode(token string, keys int) (Cursor, error) {
+	const op = "pagination.Codec.Decode"
+	encoded, sig, ok := strings.Cut(token, ".")
+	if !ok {
+		return Cursor{}, invalid(errors.New("missing signature"), op)
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return Cursor{}, invalid(err, op)
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil {
+		return Cursor{}, invalid(err, op)
+	}
+	if !hmac.Equal(mac, c.sign(payload)) {
+		return Cursor{}, invalid(errors.New("signature mismatch"), op)
+	}
+
+	var cur Cursor
+	dec := json.NewDecoder(bytes.NewReader(payload))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&cur); err != nil {
+		return Cursor{}, invalid(err, op)
+	}
+	if len(cur.Keys) != keys || cur.ID == "" {
+		return Cursor{}, invalid(errors.New("cursor of another listing"), op)
+	}
+	return cur, nil
+}
+
+// invalid returns ErrInvalidCursor with its cause, ErrInvalidCursor is shared
+// and must not be modified.
+func invalid(cause error, op string) error {
+	return fmt.Errorf("%s: %w: %w", op, ErrInvalidCursor, cause)
+}
+
+func (c *Codec) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, c.key)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
@@ -0,0 +1,50 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+)
+
+func TestCodec(t *testing.T) {
+	codec := NewCodec([]byte("secret"))
+	cur := Cursor{Keys: []string{"Lee", "Maria"}, ID: "2f0c8f3e-8f7e-4e36-9a55-3d1e2f6f8a10"}
+
+	token := codec.Encode(cur)
+	got, err := codec.Decode(token, 2)
+	require.NoError(t, err)
+	assert.Equal(t, cur, got)
+
+	payload, sig, _ := strings.Cut(token, ".")
+	otherKey := NewCodec([]byte("other")).Encode(cur)
+	_, otherSig, _ := strings.Cut(otherKey, ".")
+
+	tests := []struct {
+		name  string
+		token string
+		keys  int
+	}{
+		{"no signature", payload, 2},
+		{"signature of another secret", payload + "." + otherSig, 2},
+		{"altered payload", base64.RawURLEncoding.EncodeToString([]byte(`{"k":["Lee","Anna"],"id":"`+cur.ID+`"}`)) + "." + sig, 2},
+		{"not base64", "!!." + sig, 2},
+		{"cursor of another listing", token, 3},
+		{"empty", "", 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := codec.Decode(tt.token, tt.keys)
+			require.ErrorIs(t, err, ErrInvalidCursor)
+			assert.True(t, errorx.IsCode(err, errorx.CodeInvalid))
+		})
+	}
+}
+
+func TestNewCodec_EmptySecret(t *testing.T) {
+	assert.Panics(t, func() { NewCodec(nil) })
+}
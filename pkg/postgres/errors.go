@@ -0,0 +1,52 @@
+package postgres
+
+import (
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+)
+
+// SQLSTATE codes translated by TranslateError.
+const (
+	CodeUniqueViolation     = "23505"
+	CodeForeignKeyViolation = "23503"
+)
+
+// ConstraintErrors maps constraint names to the constructors of the errors
+// reported when they are violated. Constructors, because the errors are
+// mutated with their cause.
+type ConstraintErrors map[string]func() *errorx.I18nError
+
+// TranslateError classifies err for the layers above, wrapped with op:
+//   - pgx.ErrNoRows is a not found error,
+//   - a violated constraint found in constraints gets its error,
+//   - other unique violations are duplicate entries and foreign key
+//     violations are not found errors, the referenced row is missing,
+//   - anything else is only wrapped.
+func TranslateError(err error, op string, constraints ConstraintErrors) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, pgx.ErrNoRows) {
+		return errorx.NewNotFound().WithCause(err, op)
+	}
+
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return errorx.Wrap(err, op)
+	}
+	if newErr, ok := constraints[pgErr.ConstraintName]; ok && pgErr.ConstraintName != "" {
+		return newErr().WithCause(err, op)
+	}
+	switch pgErr.Code {
+	case CodeUniqueViolation:
+		return errorx.NewDuplicateEntry().WithCause(err, op)
+	case CodeForeignKeyViolation:
+		return errorx.NewNotFound().WithCause(err, op)
+	default:
+		return errorx.Wrap(err, op)
+	}
+}
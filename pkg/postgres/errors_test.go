@@ -0,0 +1,72 @@
+package postgres
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/i18nx"
+)
+
+func TestTranslateError(t *testing.T) {
+	constraints := ConstraintErrors{
+		"users_email_key": func() *errorx.I18nError {
+			return errorx.NewDuplicateEntry().WithKey(i18nx.KeyEmailNotAvailable)
+		},
+		"students_group_id_fkey": func() *errorx.I18nError {
+			return errorx.NewResourceNotFound(i18nx.FieldGroup)
+		},
+	}
+	pgErr := func(code, constraint string) error {
+		return fmt.Errorf("exec: %w", &pgconn.PgError{Code: code, ConstraintName: constraint})
+	}
+
+	tests := []struct {
+		name string
+		err  error
+		code errorx.Code
+		key  string
+	}{
+		{name: "no rows", err: pgx.ErrNoRows, code: errorx.CodeNotFound, key: i18nx.KeyNotFound},
+		{name: "mapped unique violation", err: pgErr(CodeUniqueViolation, "users_email_key"), code: errorx.CodeDuplicateEntry, key: i18nx.KeyEmailNotAvailable},
+		{name: "unmapped unique violation", err: pgErr(CodeUniqueViolation, "users_username_key"), code: errorx.CodeDuplicateEntry, key: i18nx.KeyDuplicateEntry},
+		{name: "mapped foreign key violation", err: pgErr(CodeForeignKeyViolation, "students_group_id_fkey"), code: errorx.CodeNotFound, key: i18nx.KeyNotFoundWithType},
+		{name: "unmapped foreign key violation", err: pgErr(CodeForeignKeyViolation, "staffs_user_id_fkey"), code: errorx.CodeNotFound, key: i18nx.KeyNotFound},
+		{name: "other pg error", err: pgErr("40001", ""), code: errorx.CodeInternal},
+		{name: "other error", err: errors.New("connection reset"), code: errorx.CodeInternal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := errorx.Wrap(TranslateError(tt.err, "repo.Save", constraints), "app.Handle")
+
+			assert.Equal(t, tt.code, errorx.CodeOf(err))
+			assert.ErrorIs(t, err, tt.err, "the cause is kept")
+			if tt.key != "" {
+				var i18nErr *errorx.I18nError
+				if assert.ErrorAs(t, err, &i18nErr) {
+					assert.Equal(t, tt.key, i18nErr.MessageKey)
+				}
+			}
+		})
+	}
+
+	assert.NoError(t, TranslateError(nil, "repo.Save", constraints))
+}
+
+func TestTranslateError_FreshErrorPerCall(t *testing.T) {
+	constraints := ConstraintErrors{
+		"users_email_key": errorx.NewDuplicateEntry,
+	}
+	first := TranslateError(&pgconn.PgError{Code: CodeUniqueViolation, ConstraintName: "users_email_key"}, "first", constraints)
+	second := TranslateError(&pgconn.PgError{Code: CodeUniqueViolation, ConstraintName: "users_email_key"}, "second", constraints)
+
+	assert.Contains(t, first.Error(), "first")
+	assert.NotContains(t, first.Error(), "second", "the errors are not shared between calls")
+	assert.Contains(t, second.Error(), "second")
+}
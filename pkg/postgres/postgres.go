@@ -6,17 +6,21 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"time"
 
 	"github.com/exaring/otelpgx"
 	"github.com/golang-migrate/migrate/v4"
 	_ "github.com/golang-migrate/migrate/v4/database/pgx"
 	"github.com/golang-migrate/migrate/v4/source/iofs"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/multitracer"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jackc/pgx/v5/pgxpool"
 	_ "github.com/jackc/pgx/v5/stdlib" // Import the stdlib driver for pgx
 
 	"gitlab.com/ucmsv2/ucms-backend/pkg/env"
 	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx/slowlog"
 )
 
 func NewPgxPool(ctx context.Context, pgdsn string, mode env.Mode) (*pgxpool.Pool, error) {
@@ -28,11 +32,16 @@ func NewPgxPool(ctx context.Context, pgdsn string, mode env.Mode) (*pgxpool.Pool
 	opts := []otelpgx.Option{
 		otelpgx.WithTrimSQLInSpanName(),
 	}
-	if mode == env.Prod {
+	if !mode.Allows(env.CapSQLInSpans) {
 		opts = append(opts, otelpgx.WithDisableSQLStatementInAttributes()) // disable SQL statements in attributes to avoid PII/high-cardinality
 	}
 
-	cfg.ConnConfig.Tracer = otelpgx.NewTracer(opts...)
+	cfg.ConnConfig.Tracer = multitracer.New(
+		otelpgx.NewTracer(opts...),
+		slowQueryTracer{monitor: slowlog.Default()},
+	)
+
+	UseUTC(cfg)
 
 	pool, err := pgxpool.NewWithConfig(ctx, cfg)
 	if err != nil {
@@ -41,12 +50,34 @@ func NewPgxPool(ctx context.Context, pgdsn string, mode env.Mode) (*pgxpool.Pool
 
 	err = pool.Ping(ctx)
 	if err != nil {
+		pool.Close()
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
 	return pool, nil
 }
 
+// UseUTC makes the connections of cfg work in UTC: the session time zone is
+// UTC, so the SQL date arithmetic does not depend on the server's, and the
+// timestamptz values are read in UTC instead of the local time zone of the
+// process.
+func UseUTC(cfg *pgxpool.Config) {
+	cfg.ConnConfig.RuntimeParams["timezone"] = "UTC"
+
+	afterConnect := cfg.AfterConnect
+	cfg.AfterConnect = func(ctx context.Context, conn *pgx.Conn) error {
+		conn.TypeMap().RegisterType(&pgtype.Type{
+			Name:  "timestamptz",
+			OID:   pgtype.TimestamptzOID,
+			Codec: &pgtype.TimestamptzCodec{ScanLocation: time.UTC},
+		})
+		if afterConnect != nil {
+			return afterConnect(ctx, conn)
+		}
+		return nil
+	}
+}
+
 func Migrate(dsn string, fs *embed.FS) error {
 	driver, err := iofs.New(fs, "migrations")
 	if err != nil {
@@ -80,8 +111,11 @@ func Migrate(dsn string, fs *embed.FS) error {
 	return nil
 }
 
+// WithTx calls fn in a transaction, within a UnitOfWork in a savepoint of
+// its transaction. The persistable errors of fn commit the transaction, or
+// release the savepoint, and are returned.
 func WithTx(ctx context.Context, pool *pgxpool.Pool, fn func(ctx context.Context, tx pgx.Tx) error) error {
-	tx, err := pool.Begin(ctx)
+	tx, err := begin(ctx, pool)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
@@ -0,0 +1,50 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+const (
+	retryInitialBackoff = 250 * time.Millisecond
+	retryMaxBackoff     = 5 * time.Second
+)
+
+// Retry calls fn until it succeeds, backing off from 250ms up to 5s between
+// the attempts, for at most maxWait. It is meant for the startup, when the
+// database may not accept connections yet, e.g. started by the same docker
+// compose. With maxWait 0 fn is called once. The error of the last attempt
+// is returned.
+func Retry(ctx context.Context, maxWait time.Duration, fn func(ctx context.Context) error) error {
+	deadline := time.Now().Add(maxWait)
+	backoff := retryInitialBackoff
+
+	for attempt := 1; ; attempt++ {
+		err := fn(ctx)
+		if err == nil {
+			return nil
+		}
+
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			if attempt > 1 {
+				return fmt.Errorf("gave up after %d attempts in %s: %w", attempt, maxWait, err)
+			}
+			return err
+		}
+		wait := min(backoff, remaining)
+		slog.WarnContext(ctx, "Database is not ready, retrying",
+			"attempt", attempt, "retry_in", wait, "error", err)
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("%w: %w", ctx.Err(), err)
+		case <-timer.C:
+		}
+		backoff = min(2*backoff, retryMaxBackoff)
+	}
+}
@@ -0,0 +1,90 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// freeAddr returns an address nothing listens on.
+func freeAddr(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := ln.Addr().String()
+	require.NoError(t, ln.Close())
+	return addr
+}
+
+func dial(addr string) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		var d net.Dialer
+		conn, err := d.DialContext(ctx, "tcp", addr)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}
+}
+
+func TestRetry_ConvergesOnceTheServerListens(t *testing.T) {
+	addr := freeAddr(t)
+
+	// The server comes up while the first attempts fail.
+	go func() {
+		time.Sleep(600 * time.Millisecond)
+		ln, err := net.Listen("tcp", addr)
+		if err != nil {
+			return
+		}
+		t.Cleanup(func() { _ = ln.Close() })
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			_ = conn.Close()
+		}
+	}()
+
+	start := time.Now()
+	err := Retry(t.Context(), 10*time.Second, dial(addr))
+	require.NoError(t, err)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestRetry_GivesUpAfterMaxWait(t *testing.T) {
+	addr := freeAddr(t)
+
+	start := time.Now()
+	err := Retry(t.Context(), 400*time.Millisecond, dial(addr))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "gave up after")
+	var opErr *net.OpError
+	assert.ErrorAs(t, err, &opErr, "the error of the last attempt is kept")
+	assert.Less(t, time.Since(start), 2*time.Second)
+}
+
+func TestRetry_NoWaitTriesOnce(t *testing.T) {
+	calls := 0
+	failure := errors.New("refused")
+	err := Retry(t.Context(), 0, func(context.Context) error {
+		calls++
+		return failure
+	})
+	assert.Same(t, failure, err)
+	assert.Equal(t, 1, calls)
+}
+
+func TestRetry_StopsWithTheContext(t *testing.T) {
+	ctx, cancel := context.WithTimeout(t.Context(), 100*time.Millisecond)
+	defer cancel()
+
+	err := Retry(ctx, time.Minute, dial(freeAddr(t)))
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
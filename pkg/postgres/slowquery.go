@@ -0,0 +1,52 @@
+package postgres
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx/slowlog"
+)
+
+const maxQueryNameLen = 200
+
+// slowQueryTracer reports queries slower than the monitor query threshold.
+type slowQueryTracer struct {
+	monitor *slowlog.Monitor
+}
+
+type queryStartKey struct{}
+
+type queryStart struct {
+	sql   string
+	start time.Time
+}
+
+func (t slowQueryTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	return context.WithValue(ctx, queryStartKey{}, queryStart{sql: data.SQL, start: time.Now()})
+}
+
+func (t slowQueryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, _ pgx.TraceQueryEndData) {
+	qs, ok := ctx.Value(queryStartKey{}).(queryStart)
+	if !ok {
+		return
+	}
+	// Checked here as well so the statement is only formatted for slow queries.
+	d := time.Since(qs.start)
+	if threshold := t.monitor.Thresholds().Query; threshold == 0 || d < threshold {
+		return
+	}
+	t.monitor.ObserveQuery(ctx, queryName(qs.sql), d)
+}
+
+// queryName collapses the whitespace of sql and truncates it, statements use
+// placeholders so no values end up in the logs.
+func queryName(sql string) string {
+	name := strings.Join(strings.Fields(sql), " ")
+	if len(name) > maxQueryNameLen {
+		name = strings.ToValidUTF8(name[:maxQueryNameLen], "") + "..."
+	}
+	return name
+}
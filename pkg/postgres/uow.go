@@ -0,0 +1,75 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type txKey struct{}
+
+// UnitOfWork runs several repository writes in one transaction. The
+// transaction is carried by the context, WithTx joins it with a savepoint
+// instead of beginning its own. Only the writes made through WithTx join it,
+// the reads on the pool do not see its rows until it commits.
+type UnitOfWork struct {
+	pool *pgxpool.Pool
+}
+
+func NewUnitOfWork(pool *pgxpool.Pool) *UnitOfWork {
+	return &UnitOfWork{pool: pool}
+}
+
+// Do calls fn with a context carrying the transaction, which is committed
+// when fn returns nil and rolled back when it fails or panics. Within a unit
+// of work already, fn joins it.
+func (u *UnitOfWork) Do(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	if InTx(ctx) {
+		return fn(ctx)
+	}
+
+	tx, err := u.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		v := recover()
+		if v == nil && err == nil {
+			return
+		}
+		// The request may be canceled, the connection must be released
+		// clean anyway.
+		if rerr := tx.Rollback(context.WithoutCancel(ctx)); rerr != nil {
+			slog.ErrorContext(ctx, "failed to rollback unit of work", slog.String("error", rerr.Error()))
+		}
+		if v != nil {
+			panic(v)
+		}
+	}()
+
+	if err := fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// InTx reports whether ctx carries the transaction of a unit of work.
+func InTx(ctx context.Context) bool {
+	_, ok := ctx.Value(txKey{}).(pgx.Tx)
+	return ok
+}
+
+// begin begins a transaction on pool, or a savepoint of the transaction ctx
+// carries.
+func begin(ctx context.Context, pool *pgxpool.Pool) (pgx.Tx, error) {
+	if tx, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return tx.Begin(ctx)
+	}
+	return pool.Begin(ctx)
+}
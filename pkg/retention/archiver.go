@@ -0,0 +1,331 @@
+package retention
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/contrib/bridges/otelslog"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx/metrics"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/storagex"
+)
+
+var (
+	tracer = otel.Tracer("ucms/pkg/retention")
+	logger = otelslog.NewLogger("ucms/pkg/retention")
+)
+
+const (
+	// DefaultChunkSize is the number of rows deleted per transaction, and
+	// read per query when archiving.
+	DefaultChunkSize = 5000
+
+	// ArchiveContentType is the type of the archive objects, gzipped NDJSON.
+	ArchiveContentType = "application/gzip"
+
+	// maxArchivesPerDay bounds the suffixes tried for the key of an archive
+	// when the table was archived on the same day already.
+	maxArchivesPerDay = 100
+)
+
+// TableResult is the outcome of a run for a table.
+type TableResult struct {
+	Category Category
+	Table    string
+	Deleted  int64
+	// Archive is nil unless the rows were archived.
+	Archive *Archive
+}
+
+// Archiver applies the policies to the tables of their category.
+//
+// The expired rows of a table only ever get fewer: the rows are written with
+// the time they are written at, and the time of an error only moves forward.
+// So in the archive mode the rows deleted after the archive are part of it.
+type Archiver struct {
+	tracer    trace.Tracer
+	logger    *slog.Logger
+	store     Store
+	storage   ObjectStorage
+	policies  Policies
+	chunkSize int
+	tempDir   string
+	clock     clock.Clock
+	deleted   metric.Int64Counter
+	archived  metric.Int64Counter
+}
+
+type ArchiverArgs struct {
+	Tracer trace.Tracer
+	Logger *slog.Logger
+	Store  Store
+	// Storage receives the archives, it is required when a policy archives.
+	Storage  ObjectStorage
+	Policies Policies
+	// ChunkSize defaults to DefaultChunkSize.
+	ChunkSize int
+	// TempDir is where the archives are written before the upload, which
+	// needs their length, defaults to os.TempDir.
+	TempDir string
+	// Clock defaults to clock.Real.
+	Clock clock.Clock
+	// Metrics defaults to metrics.Default.
+	Metrics *metrics.Registry
+}
+
+// NewArchiver checks the policies and creates an Archiver.
+//
+//	WARNING; panics if store is nil
+func NewArchiver(args ArchiverArgs) (*Archiver, error) {
+	const op = "retention.NewArchiver"
+	if args.Store == nil {
+		panic("store is required")
+	}
+	if err := args.Policies.Validate(); err != nil {
+		return nil, errorx.Wrap(err, op)
+	}
+	if args.Policies.Archives() && args.Storage == nil {
+		return nil, errorx.Wrap(errors.New("the archive mode requires a storage"), op)
+	}
+	if args.Tracer == nil {
+		args.Tracer = tracer
+	}
+	if args.Logger == nil {
+		args.Logger = logger
+	}
+	if args.ChunkSize <= 0 {
+		args.ChunkSize = DefaultChunkSize
+	}
+	if args.Metrics == nil {
+		args.Metrics = metrics.Default()
+	}
+
+	return &Archiver{
+		tracer:    args.Tracer,
+		logger:    args.Logger,
+		store:     args.Store,
+		storage:   args.Storage,
+		policies:  args.Policies,
+		chunkSize: args.ChunkSize,
+		tempDir:   args.TempDir,
+		clock:     clock.Or(args.Clock),
+		deleted: args.Metrics.Int64Counter(metrics.RetentionDeleted,
+			metric.WithDescription("Number of rows deleted past their retention window"),
+			metric.WithUnit("{row}"),
+		),
+		archived: args.Metrics.Int64Counter(metrics.RetentionArchived,
+			metric.WithDescription("Number of rows archived before their deletion"),
+			metric.WithUnit("{row}"),
+		),
+	}, nil
+}
+
+// Run applies the policies once. A table failing does not stop the others,
+// the errors are joined.
+func (a *Archiver) Run(ctx context.Context) ([]TableResult, error) {
+	const op = "retention.Archiver.Run"
+	ctx, span := a.tracer.Start(ctx, "Archiver.Run")
+	defer span.End()
+
+	now := a.clock.Now().UTC()
+	var (
+		results []TableResult
+		errs    []error
+	)
+	for _, c := range Categories {
+		p := a.policies[c]
+		if !p.Enabled() {
+			continue
+		}
+		cutoff := now.Add(-p.Window())
+		for _, table := range c.Tables() {
+			res, err := a.runTable(ctx, c, p, table, cutoff, now)
+			results = append(results, res)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", table, err))
+			}
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		otelx.RecordSpanError(span, err, "failed to apply the retention")
+		return results, errorx.Wrap(err, op)
+	}
+	return results, nil
+}
+
+func (a *Archiver) runTable(ctx context.Context, c Category, p Policy, table string, cutoff, now time.Time) (TableResult, error) {
+	ctx, span := a.tracer.Start(ctx, "Archiver.runTable", trace.WithAttributes(
+		attribute.String("retention.category", string(c)),
+		attribute.String("retention.table", table),
+		attribute.String("retention.cutoff", cutoff.Format(time.RFC3339)),
+	))
+	defer span.End()
+
+	res := TableResult{Category: c, Table: table}
+	if p.Mode == ModeArchive {
+		archive, err := a.archive(ctx, c, table, cutoff, now)
+		if err != nil {
+			otelx.RecordSpanError(span, err, "failed to archive the expired rows")
+			return res, err
+		}
+		res.Archive = archive
+	}
+
+	deleted, err := a.deleteExpired(ctx, table, cutoff)
+	res.Deleted = deleted
+	span.SetAttributes(attribute.Int64("retention.deleted", deleted))
+	if err != nil {
+		otelx.RecordSpanError(span, err, "failed to delete the expired rows")
+		return res, err
+	}
+
+	if res.Archive != nil || res.Deleted > 0 {
+		a.logger.InfoContext(ctx, "retention applied",
+			slog.String("category", string(c)),
+			slog.String("table", table),
+			slog.Time("cutoff", cutoff),
+			slog.Int64("deleted", res.Deleted),
+			slog.Bool("archived", res.Archive != nil))
+	}
+	return res, nil
+}
+
+// deleteExpired deletes the expired rows of table a chunk at a time.
+func (a *Archiver) deleteExpired(ctx context.Context, table string, cutoff time.Time) (int64, error) {
+	var total int64
+	for {
+		n, err := a.store.DeleteExpiredRows(ctx, table, cutoff, a.chunkSize)
+		if err != nil {
+			return total, err
+		}
+		total += n
+		a.deleted.Add(ctx, n, metric.WithAttributes(attribute.String(metrics.AttrTable, table)))
+		if n < int64(a.chunkSize) {
+			return total, nil
+		}
+		if err := ctx.Err(); err != nil {
+			return total, err
+		}
+	}
+}
+
+// archive writes the expired rows of table to the storage and records the
+// manifest, it returns nil when no row expired.
+func (a *Archiver) archive(ctx context.Context, c Category, table string, cutoff, now time.Time) (*Archive, error) {
+	rows, err := a.store.ListExpiredRows(ctx, table, cutoff, "", a.chunkSize)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	key, err := a.archiveKey(ctx, table, now)
+	if err != nil {
+		return nil, err
+	}
+
+	spool, err := os.CreateTemp(a.tempDir, "retention-*.ndjson.gz")
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = spool.Close()
+		_ = os.Remove(spool.Name())
+	}()
+
+	count, err := a.writeRows(ctx, spool, table, cutoff, rows)
+	if err != nil {
+		return nil, err
+	}
+	size, err := spool.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := spool.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	if err := a.storage.UploadFile(ctx, key, spool, ArchiveContentType); err != nil {
+		return nil, err
+	}
+
+	archive := Archive{
+		ID:        uuid.New(),
+		Category:  c,
+		Table:     table,
+		Key:       key,
+		Rows:      count,
+		Size:      size,
+		Cutoff:    cutoff,
+		CreatedAt: now,
+	}
+	// Without the manifest the rows are kept, the next run archives them
+	// again under a new key.
+	if err := a.store.SaveArchive(ctx, archive); err != nil {
+		return nil, err
+	}
+	a.archived.Add(ctx, count, metric.WithAttributes(attribute.String(metrics.AttrTable, table)))
+	return &archive, nil
+}
+
+// writeRows writes the expired rows as gzipped NDJSON, starting with the
+// first page already read, and returns their number.
+func (a *Archiver) writeRows(ctx context.Context, w io.Writer, table string, cutoff time.Time, rows []Row) (int64, error) {
+	gz := gzip.NewWriter(w)
+	var count int64
+	for {
+		for _, row := range rows {
+			if _, err := gz.Write(row.Data); err != nil {
+				return count, err
+			}
+			if _, err := gz.Write([]byte{'\n'}); err != nil {
+				return count, err
+			}
+		}
+		count += int64(len(rows))
+		if len(rows) < a.chunkSize {
+			break
+		}
+
+		var err error
+		rows, err = a.store.ListExpiredRows(ctx, table, cutoff, rows[len(rows)-1].Key, a.chunkSize)
+		if err != nil {
+			return count, err
+		}
+	}
+	return count, gz.Close()
+}
+
+// archiveKey returns archives/<table>/<date>.ndjson.gz, with a suffix when
+// the table was archived on the same day already, an archive is never
+// overwritten.
+func (a *Archiver) archiveKey(ctx context.Context, table string, now time.Time) (string, error) {
+	base := storagex.ArchiveKeyPrefix + table + "/" + now.Format(time.DateOnly)
+	key := base + ".ndjson.gz"
+	for n := 2; n <= maxArchivesPerDay; n++ {
+		_, err := a.storage.HeadObject(ctx, key)
+		if errors.Is(err, storagex.ErrObjectNotFound) {
+			return key, nil
+		}
+		if err != nil {
+			return "", err
+		}
+		key = fmt.Sprintf("%s-%d.ndjson.gz", base, n)
+	}
+	return "", fmt.Errorf("table %s was archived %d times on %s already", table, maxArchivesPerDay, now.Format(time.DateOnly))
+}
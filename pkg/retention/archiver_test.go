@@ -0,0 +1,216 @@
+package retention
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/storagex"
+)
+
+var testNow = time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+
+type testRow struct {
+	key string
+	at  time.Time
+}
+
+// memStore keeps the rows of the tables in memory, ordered by key.
+type memStore struct {
+	rows     map[string][]testRow
+	archives []Archive
+	// deletes are the sizes of the deleted chunks.
+	deletes []int64
+}
+
+func newMemStore() *memStore {
+	return &memStore{rows: make(map[string][]testRow)}
+}
+
+func (s *memStore) seed(table string, n int, at time.Time) {
+	for range n {
+		s.rows[table] = append(s.rows[table], testRow{key: fmt.Sprintf("%06d", len(s.rows[table])), at: at})
+	}
+}
+
+func (s *memStore) ListExpiredRows(_ context.Context, table string, cutoff time.Time, after string, limit int) ([]Row, error) {
+	var rows []Row
+	for _, r := range s.rows[table] {
+		if r.at.Before(cutoff) && r.key > after && len(rows) < limit {
+			data, _ := json.Marshal(map[string]any{"key": r.key, "at": r.at})
+			rows = append(rows, Row{Key: r.key, Data: data})
+		}
+	}
+	return rows, nil
+}
+
+func (s *memStore) DeleteExpiredRows(_ context.Context, table string, cutoff time.Time, limit int) (int64, error) {
+	var n int64
+	s.rows[table] = slices.DeleteFunc(s.rows[table], func(r testRow) bool {
+		if r.at.Before(cutoff) && n < int64(limit) {
+			n++
+			return true
+		}
+		return false
+	})
+	s.deletes = append(s.deletes, n)
+	return n, nil
+}
+
+func (s *memStore) SaveArchive(_ context.Context, a Archive) error {
+	s.archives = append(s.archives, a)
+	return nil
+}
+
+type memStorage struct {
+	objects map[string][]byte
+}
+
+func (s *memStorage) UploadFile(_ context.Context, key string, file io.Reader, _ string) error {
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return err
+	}
+	s.objects[key] = data
+	return nil
+}
+
+func (s *memStorage) HeadObject(_ context.Context, key string) (storagex.ObjectInfo, error) {
+	data, ok := s.objects[key]
+	if !ok {
+		return storagex.ObjectInfo{}, storagex.ErrObjectNotFound
+	}
+	return storagex.ObjectInfo{Key: key, Size: int64(len(data))}, nil
+}
+
+// lines decompresses an archive and returns its NDJSON lines.
+func lines(t *testing.T, data []byte) []string {
+	t.Helper()
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	require.NoError(t, err)
+	var out []string
+	sc := bufio.NewScanner(gz)
+	for sc.Scan() {
+		out = append(out, sc.Text())
+	}
+	require.NoError(t, sc.Err())
+	return out
+}
+
+func newTestArchiver(t *testing.T, store *memStore, storage *memStorage, policies Policies) *Archiver {
+	t.Helper()
+	a, err := NewArchiver(ArchiverArgs{
+		Store:     store,
+		Storage:   storage,
+		Policies:  policies,
+		ChunkSize: 10,
+		TempDir:   t.TempDir(),
+		Clock:     clock.NewFake(testNow),
+	})
+	require.NoError(t, err)
+	return a
+}
+
+func TestArchiver_Archive(t *testing.T) {
+	store := newMemStore()
+	store.seed("error_events", 25, testNow.AddDate(0, 0, -40))
+	store.seed("error_events", 3, testNow.AddDate(0, 0, -5))
+	storage := &memStorage{objects: make(map[string][]byte)}
+	a := newTestArchiver(t, store, storage, Policies{
+		CategoryErrorInbox: {Days: 30, Mode: ModeArchive},
+	})
+
+	results, err := a.Run(t.Context())
+	require.NoError(t, err)
+
+	require.Len(t, results, 1)
+	res := results[0]
+	assert.Equal(t, int64(25), res.Deleted)
+	assert.Equal(t, []int64{10, 10, 5}, store.deletes, "the rows are deleted in chunks")
+	assert.Len(t, store.rows["error_events"], 3, "only the expired rows are deleted")
+
+	key := "archives/error_events/2025-03-10.ndjson.gz"
+	require.NotNil(t, res.Archive)
+	require.Contains(t, storage.objects, key)
+	archived := lines(t, storage.objects[key])
+	require.Len(t, archived, 25)
+	assert.True(t, json.Valid([]byte(archived[0])))
+	assert.Contains(t, archived[24], `"key":"000024"`, "the rows are archived in key order")
+
+	require.Len(t, store.archives, 1)
+	manifest := store.archives[0]
+	assert.Equal(t, *res.Archive, manifest)
+	assert.Equal(t, CategoryErrorInbox, manifest.Category)
+	assert.Equal(t, "error_events", manifest.Table)
+	assert.Equal(t, key, manifest.Key)
+	assert.Equal(t, int64(25), manifest.Rows)
+	assert.Equal(t, int64(len(storage.objects[key])), manifest.Size)
+	assert.Equal(t, testNow.AddDate(0, 0, -30), manifest.Cutoff)
+}
+
+func TestArchiver_ArchiveSameDay(t *testing.T) {
+	store := newMemStore()
+	storage := &memStorage{objects: map[string][]byte{
+		"archives/impersonations/2025-03-10.ndjson.gz": []byte("earlier run"),
+	}}
+	store.seed("impersonations", 2, testNow.AddDate(0, 0, -100))
+	a := newTestArchiver(t, store, storage, Policies{
+		CategoryAuthAudit: {Days: 90, Mode: ModeArchive},
+	})
+
+	_, err := a.Run(t.Context())
+	require.NoError(t, err)
+
+	assert.Equal(t, []byte("earlier run"), storage.objects["archives/impersonations/2025-03-10.ndjson.gz"],
+		"an archive is never overwritten")
+	assert.Len(t, lines(t, storage.objects["archives/impersonations/2025-03-10-2.ndjson.gz"]), 2)
+}
+
+func TestArchiver_Delete(t *testing.T) {
+	store := newMemStore()
+	store.seed("impersonations", 4, testNow.AddDate(0, 0, -100))
+	store.seed("staff_bootstrap_audit", 1, testNow.AddDate(0, 0, -100))
+	store.seed("error_events", 4, testNow.AddDate(0, 0, -100))
+	storage := &memStorage{objects: make(map[string][]byte)}
+	a := newTestArchiver(t, store, storage, Policies{
+		CategoryAuthAudit: {Days: 90},
+		CategoryMailLog:   {Days: 30, Mode: ModeArchive},
+	})
+
+	results, err := a.Run(t.Context())
+	require.NoError(t, err)
+
+	require.Len(t, results, 2, "the mail log has no table yet")
+	assert.Equal(t, int64(4), results[0].Deleted)
+	assert.Equal(t, int64(1), results[1].Deleted)
+	assert.Nil(t, results[0].Archive)
+	assert.Empty(t, storage.objects)
+	assert.Empty(t, store.archives)
+	assert.Len(t, store.rows["error_events"], 4, "the error inbox has no policy")
+}
+
+func TestNewArchiver_InvalidPolicies(t *testing.T) {
+	store := newMemStore()
+	for name, policies := range map[string]Policies{
+		"unknown category":    {"sessions": {Days: 1}},
+		"negative days":       {CategoryAuthAudit: {Days: -1}},
+		"unknown mode":        {CategoryAuthAudit: {Days: 1, Mode: "move"}},
+		"archive w/o storage": {CategoryAuthAudit: {Days: 1, Mode: ModeArchive}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := NewArchiver(ArchiverArgs{Store: store, Policies: policies})
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,192 @@
+// Package retention removes the audit and log rows older than the retention
+// window of their category, the Archiver runs as a background job.
+//
+// A category either deletes its expired rows or archives them first: the
+// rows of a table are written as gzipped NDJSON, one JSON object per line,
+// to archives/<table>/<date>.ndjson.gz, a manifest row records the object
+// and the rows are deleted then. The rows are deleted in chunks, one
+// transaction each, so a large backlog never holds the locks of a table for
+// long.
+package retention
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+
+	"gitlab.com/ucmsv2/ucms-backend/pkg/storagex"
+)
+
+// Category groups the tables sharing a retention policy.
+type Category string
+
+const (
+	// CategoryAuthAudit are the impersonation sessions and the bootstrap of
+	// the initial staff.
+	CategoryAuthAudit      Category = "auth_audit"
+	CategoryProfileHistory Category = "profile_history"
+	CategoryMailLog        Category = "mail_log"
+	// CategoryErrorInbox are the errors of the error inbox, by their last
+	// occurrence.
+	CategoryErrorInbox Category = "error_inbox"
+)
+
+// Categories are the categories in the order the Archiver runs them.
+var Categories = []Category{CategoryAuthAudit, CategoryProfileHistory, CategoryMailLog, CategoryErrorInbox}
+
+// tables are the tables of the categories. The profile history and the mail
+// log are not stored yet, their policies remove nothing until they are.
+var tables = map[Category][]string{
+	CategoryAuthAudit:  {"impersonations", "staff_bootstrap_audit"},
+	CategoryErrorInbox: {"error_events"},
+}
+
+func (c Category) IsValid() bool {
+	return slices.Contains(Categories, c)
+}
+
+// Tables returns the tables of the category.
+func (c Category) Tables() []string {
+	return tables[c]
+}
+
+// Mode is what happens to the expired rows.
+type Mode string
+
+const (
+	ModeDelete Mode = "delete"
+	// ModeArchive writes the rows to the object storage before deleting
+	// them.
+	ModeArchive Mode = "archive"
+)
+
+func ParseMode(s string) (Mode, error) {
+	switch mode := Mode(s); mode {
+	case ModeDelete, ModeArchive:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unknown retention mode %q, expected delete or archive", s)
+	}
+}
+
+// UnmarshalText reads a mode with ParseMode, for the config file and the
+// environment.
+func (m *Mode) UnmarshalText(text []byte) error {
+	mode, err := ParseMode(string(text))
+	if err != nil {
+		return err
+	}
+	*m = mode
+	return nil
+}
+
+// Policy is the retention of a category.
+type Policy struct {
+	// Days the rows are kept for, 0 keeps them forever.
+	Days int `yaml:"days"`
+	// Mode defaults to ModeDelete.
+	Mode Mode `yaml:"mode"`
+}
+
+// Enabled reports whether the policy removes rows.
+func (p Policy) Enabled() bool {
+	return p.Days > 0
+}
+
+// Window is how long the rows are kept for.
+func (p Policy) Window() time.Duration {
+	return time.Duration(p.Days) * 24 * time.Hour
+}
+
+// Policies are the policies by category, the categories left out are kept
+// forever.
+type Policies map[Category]Policy
+
+func (ps Policies) Validate() error {
+	for c, p := range ps {
+		if !c.IsValid() {
+			return fmt.Errorf("unknown retention category %q, expected one of auth_audit, profile_history, mail_log, error_inbox", c)
+		}
+		if p.Days < 0 {
+			return fmt.Errorf("retention days of %s must not be negative, got %d", c, p.Days)
+		}
+		if p.Mode != "" {
+			if _, err := ParseMode(string(p.Mode)); err != nil {
+				return fmt.Errorf("retention of %s: %w", c, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Enabled reports whether a policy removes rows.
+func (ps Policies) Enabled() bool {
+	for _, p := range ps {
+		if p.Enabled() {
+			return true
+		}
+	}
+	return false
+}
+
+// Archives reports whether a policy archives rows.
+func (ps Policies) Archives() bool {
+	for _, p := range ps {
+		if p.Enabled() && p.Mode == ModeArchive {
+			return true
+		}
+	}
+	return false
+}
+
+// Row is an expired row of a table. Key is its primary key as text, it
+// orders the rows of a table, and Data the row as a JSON object.
+type Row struct {
+	Key  string
+	Data json.RawMessage
+}
+
+// Archive is the manifest of an archived object, the rows of a table older
+// than Cutoff.
+type Archive struct {
+	ID       uuid.UUID
+	Category Category
+	Table    string
+	// Key is the object key, under storagex.ArchiveKeyPrefix.
+	Key  string
+	Rows int64
+	// Size is the compressed size in bytes.
+	Size      int64
+	Cutoff    time.Time
+	CreatedAt time.Time
+}
+
+// Store reads and deletes the expired rows and keeps the archive manifests.
+type Store interface {
+	// ListExpiredRows returns up to limit rows of table older than cutoff
+	// with a key after the after key, ordered by key.
+	ListExpiredRows(ctx context.Context, table string, cutoff time.Time, after string, limit int) ([]Row, error)
+	// DeleteExpiredRows deletes up to limit rows of table older than cutoff
+	// in one transaction and returns the number deleted.
+	DeleteExpiredRows(ctx context.Context, table string, cutoff time.Time, limit int) (int64, error)
+	SaveArchive(ctx context.Context, archive Archive) error
+}
+
+// ListParams filters the listed archives, the latest come first.
+type ListParams struct {
+	// Table narrows the archives to one table, empty for all of them.
+	Table  string
+	Limit  int
+	Offset int
+}
+
+// ObjectStorage receives the archives.
+type ObjectStorage interface {
+	UploadFile(ctx context.Context, key string, file io.Reader, contentType string) error
+	HeadObject(ctx context.Context, key string) (storagex.ObjectInfo, error)
+}
@@ -0,0 +1,96 @@
+package sanitizex
+
+import (
+	"errors"
+	"strings"
+	"sync/atomic"
+	"unicode"
+
+	"golang.org/x/net/idna"
+)
+
+var (
+	ErrInvalidEmail      = errors.New("invalid email address")
+	ErrEmailControlChars = errors.New("email address contains control characters")
+)
+
+// EmailPolicy configures NormalizeEmail.
+type EmailPolicy struct {
+	// LowercaseLocal lowercases the local part as well as the domain. The
+	// case of the local part is up to the receiving server, most ignore it.
+	LowercaseLocal bool `yaml:"lowercase_local"`
+}
+
+var emailPolicy atomic.Pointer[EmailPolicy]
+
+func init() {
+	SetEmailPolicy(EmailPolicy{})
+}
+
+// SetEmailPolicy replaces the policy used by NormalizeEmail, it is safe to
+// call while requests are being served.
+func SetEmailPolicy(p EmailPolicy) {
+	emailPolicy.Store(&p)
+}
+
+// NormalizeEmail returns the address to store for s. It is cleaned with
+// CleanSingleLine, the domain is lowercased and IDN domains are converted to
+// punycode, so look-alike domains do not compare equal to the ASCII ones.
+// Control characters, CR and LF included, are rejected instead of cleaned as
+// they are the header injection vectors of the sent mails.
+func NormalizeEmail(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	if strings.ContainsFunc(s, func(r rune) bool { return r == '\u007f' || unicode.IsControl(r) }) {
+		return "", ErrEmailControlChars
+	}
+	s = CleanSingleLine(s)
+
+	at := strings.LastIndexByte(s, '@')
+	if at <= 0 || at == len(s)-1 || strings.ContainsRune(s, ' ') {
+		return "", ErrInvalidEmail
+	}
+	local, domain := s[:at], s[at+1:]
+
+	domain, err := idna.Lookup.ToASCII(domain)
+	if err != nil {
+		return "", errors.Join(ErrInvalidEmail, err)
+	}
+	domain = strings.ToLower(domain)
+
+	if emailPolicy.Load().LowercaseLocal {
+		local = strings.ToLower(local)
+	}
+	return local + "@" + domain, nil
+}
+
+// EmailDedupKey returns the key to detect duplicates of s with: the
+// normalized address lowercased and without the +tag of the local part, so
+// a+b@test.com and A@test.com are the same. It is never stored, the address
+// is the one returned by NormalizeEmail.
+func EmailDedupKey(s string) (string, error) {
+	email, err := NormalizeEmail(s)
+	if err != nil {
+		return "", err
+	}
+	at := strings.LastIndexByte(email, '@')
+	local, domain := email[:at], email[at:]
+	if plus := strings.IndexByte(local, '+'); plus > 0 {
+		local = local[:plus]
+	}
+	return strings.ToLower(local + domain), nil
+}
+
+// CleanEmail returns NormalizeEmail(s), or CleanSingleLine(s) when s is not
+// a valid address so that the validation rules report it. An address with
+// control characters is returned as is: cleaning would drop them and make
+// it valid.
+func CleanEmail(s string) string {
+	email, err := NormalizeEmail(s)
+	if errors.Is(err, ErrEmailControlChars) {
+		return s
+	}
+	if err != nil {
+		return CleanSingleLine(s)
+	}
+	return email
+}
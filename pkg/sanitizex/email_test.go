@@ -0,0 +1,143 @@
+package sanitizex
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeEmail(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+		err      error
+	}{
+		{
+			name:     "domain lowercased",
+			input:    "Foo@Bar.COM",
+			expected: "Foo@bar.com",
+		},
+		{
+			name:     "trimmed",
+			input:    "  user@test.com\n",
+			expected: "user@test.com",
+		},
+		{
+			name:     "plus tag kept",
+			input:    "a+b@test.com",
+			expected: "a+b@test.com",
+		},
+		{
+			name:     "IDN domain converted to punycode",
+			input:    "user@Bücher.example",
+			expected: "user@xn--bcher-kva.example",
+		},
+		{
+			name:     "Cyrillic homograph does not match the ASCII domain",
+			input:    "admin@аpple.com", // the first a is U+0430
+			expected: "admin@xn--pple-43d.com",
+		},
+		{
+			name:  "CRLF injection",
+			input: "test@test.com\r\nBcc:attacker@evil.com",
+			err:   ErrEmailControlChars,
+		},
+		{
+			name:  "NUL byte",
+			input: "test@test.com\x00",
+			err:   ErrEmailControlChars,
+		},
+		{
+			name:  "missing domain",
+			input: "user@",
+			err:   ErrInvalidEmail,
+		},
+		{
+			name:  "missing local part",
+			input: "@test.com",
+			err:   ErrInvalidEmail,
+		},
+		{
+			name:  "no at sign",
+			input: "user.test.com",
+			err:   ErrInvalidEmail,
+		},
+		{
+			name:  "space inside",
+			input: "us er@test.com",
+			err:   ErrInvalidEmail,
+		},
+		{
+			name:  "invalid domain",
+			input: "user@-test-.com",
+			err:   ErrInvalidEmail,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizeEmail(tt.input)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestNormalizeEmail_LowercaseLocal(t *testing.T) {
+	SetEmailPolicy(EmailPolicy{LowercaseLocal: true})
+	t.Cleanup(func() { SetEmailPolicy(EmailPolicy{}) })
+
+	got, err := NormalizeEmail("Foo@Bar.COM")
+	require.NoError(t, err)
+	assert.Equal(t, "foo@bar.com", got)
+}
+
+func TestEmailDedupKey(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{input: "a+b@test.com", expected: "a@test.com"},
+		{input: "A@Test.com", expected: "a@test.com"},
+		{input: "a+b+c@test.com", expected: "a@test.com"},
+		{input: "+tag@test.com", expected: "+tag@test.com"},
+		{input: "Foo@Bar.COM", expected: "foo@bar.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := EmailDedupKey(tt.input)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+
+	normalized, err := NormalizeEmail("a+b@test.com")
+	require.NoError(t, err)
+	assert.Equal(t, "a+b@test.com", normalized, "the stored address keeps its tag")
+}
+
+func TestCleanEmail(t *testing.T) {
+	assert.Equal(t, "Foo@bar.com", CleanEmail(" Foo@Bar.COM "))
+	assert.Equal(t, "Foo@Bar .com", CleanEmail("  Foo@Bar  .com"),
+		"invalid addresses are only cleaned, the validation rules reject them")
+	for _, s := range []string{"a@b.com\x00", "test@test.com\r\nBcc:attacker@evil.com"} {
+		assert.Equal(t, s, CleanEmail(s), "control characters are kept for the validation to reject")
+		_, err := NormalizeEmail(CleanEmail(s))
+		assert.ErrorIs(t, err, ErrEmailControlChars)
+	}
+}
+
+func TestDeduplicateSliceFunc(t *testing.T) {
+	got := DeduplicateSliceFunc([]string{"a+b@test.com", "A@test.com", "c@test.com"}, func(s string) string {
+		key, _ := EmailDedupKey(s)
+		return key
+	})
+	assert.Equal(t, []string{"a+b@test.com", "c@test.com"}, got)
+}
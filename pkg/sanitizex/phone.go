@@ -0,0 +1,104 @@
+package sanitizex
+
+import (
+	"errors"
+	"strings"
+	"sync/atomic"
+)
+
+// MaxPhoneInputLen bounds the submitted phone number before normalization,
+// separators included.
+const MaxPhoneInputLen = 32
+
+const (
+	minE164Digits = 7
+	maxE164Digits = 15
+)
+
+var ErrInvalidPhone = errors.New("invalid phone number")
+
+// PhonePolicy configures NormalizePhone for numbers written without the
+// country code.
+type PhonePolicy struct {
+	// CountryCode is the calling code of the numbers without one, without
+	// the +.
+	CountryCode string
+	// TrunkPrefix replaces the country code in national numbers, e.g. the 8
+	// of 8 702 123 45 67.
+	TrunkPrefix string
+	// NationalLength is the number of digits after the country code.
+	NationalLength int
+}
+
+// DefaultPhonePolicy is the policy of Kazakhstan, +7.
+func DefaultPhonePolicy() PhonePolicy {
+	return PhonePolicy{
+		CountryCode:    "7",
+		TrunkPrefix:    "8",
+		NationalLength: 10,
+	}
+}
+
+var phonePolicy atomic.Pointer[PhonePolicy]
+
+func init() {
+	SetPhonePolicy(DefaultPhonePolicy())
+}
+
+// SetPhonePolicy replaces the policy used by NormalizePhone, it is safe to
+// call while requests are being served.
+func SetPhonePolicy(p PhonePolicy) {
+	phonePolicy.Store(&p)
+}
+
+// NormalizePhone returns s in E.164, e.g. +77021234567. Spaces, dashes, dots
+// and parentheses are stripped, numbers without a country code get the one
+// of the policy. Letters, extensions and any other character are rejected.
+func NormalizePhone(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || len(s) > MaxPhoneInputLen {
+		return "", ErrInvalidPhone
+	}
+
+	var b strings.Builder
+	b.Grow(len(s))
+	for i, r := range s {
+		switch {
+		case r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case r == '+' && i == 0:
+		case r == ' ' || r == '-' || r == '.' || r == '(' || r == ')':
+		default:
+			return "", ErrInvalidPhone
+		}
+	}
+	digits := b.String()
+
+	switch p := phonePolicy.Load(); {
+	case s[0] == '+':
+	case strings.HasPrefix(digits, "00"):
+		digits = digits[2:]
+	case p.NationalLength > 0 && len(digits) == p.NationalLength:
+		digits = p.CountryCode + digits
+	case p.TrunkPrefix != "" && len(digits) == len(p.TrunkPrefix)+p.NationalLength && strings.HasPrefix(digits, p.TrunkPrefix):
+		digits = p.CountryCode + digits[len(p.TrunkPrefix):]
+	case len(digits) == len(p.CountryCode)+p.NationalLength && strings.HasPrefix(digits, p.CountryCode):
+	default:
+		return "", ErrInvalidPhone
+	}
+
+	if len(digits) < minE164Digits || len(digits) > maxE164Digits || digits[0] == '0' {
+		return "", ErrInvalidPhone
+	}
+	return "+" + digits, nil
+}
+
+// CleanPhone returns NormalizePhone(s), or CleanSingleLine(s) when s is not
+// a valid number so that the validation rules report it.
+func CleanPhone(s string) string {
+	phone, err := NormalizePhone(s)
+	if err != nil {
+		return CleanSingleLine(s)
+	}
+	return phone
+}
@@ -0,0 +1,87 @@
+package sanitizex
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizePhone(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+		err      error
+	}{
+		{name: "national with trunk prefix and spaces", input: "8 702 123 45 67", expected: "+77021234567"},
+		{name: "national with trunk prefix", input: "87021234567", expected: "+77021234567"},
+		{name: "national without trunk prefix", input: "702 123-45-67", expected: "+77021234567"},
+		{name: "country code without plus", input: "77021234567", expected: "+77021234567"},
+		{name: "E.164", input: "+77021234567", expected: "+77021234567"},
+		{name: "parentheses and dashes", input: "+7 (702) 123-45-67", expected: "+77021234567"},
+		{name: "trimmed", input: "  +7 702 123 45 67\n", expected: "+77021234567"},
+		{name: "international prefix", input: "00 7 702 123 45 67", expected: "+77021234567"},
+		{name: "US number", input: "+1-202-555-0143", expected: "+12025550143"},
+		{name: "dots", input: "+1.202.555.0143", expected: "+12025550143"},
+		{name: "UK number", input: "+44 20 7946 0958", expected: "+442079460958"},
+		{name: "longest E.164", input: "+123456789012345", expected: "+123456789012345"},
+		{name: "empty", input: "", err: ErrInvalidPhone},
+		{name: "blank", input: "   ", err: ErrInvalidPhone},
+		{name: "letters", input: "+7 702 ABC 45 67", err: ErrInvalidPhone},
+		{name: "vanity number", input: "1-800-FLOWERS", err: ErrInvalidPhone},
+		{name: "extension with x", input: "+1 202 555 0143 x12", err: ErrInvalidPhone},
+		{name: "extension with ext", input: "+1 202 555 0143 ext. 12", err: ErrInvalidPhone},
+		{name: "extension with semicolon", input: "+12025550143;ext=12", err: ErrInvalidPhone},
+		{name: "extension with hash", input: "+12025550143#12", err: ErrInvalidPhone},
+		{name: "NoSQL injection", input: `{"$ne":null}`, err: ErrInvalidPhone},
+		{name: "SQL injection", input: "'; DROP TABLE users; --", err: ErrInvalidPhone},
+		{name: "plus in the middle", input: "7+7021234567", err: ErrInvalidPhone},
+		{name: "double plus", input: "++77021234567", err: ErrInvalidPhone},
+		{name: "plus only", input: "+", err: ErrInvalidPhone},
+		{name: "too short", input: "+12345", err: ErrInvalidPhone},
+		{name: "too many digits", input: "+1234567890123456", err: ErrInvalidPhone},
+		{name: "overlong input", input: "+7 " + strings.Repeat(" ", MaxPhoneInputLen) + "7021234567", err: ErrInvalidPhone},
+		{name: "country code starting with zero", input: "+07021234567", err: ErrInvalidPhone},
+		{name: "national number of the wrong length", input: "870212345", err: ErrInvalidPhone},
+		{name: "local number without area code", input: "1234567", err: ErrInvalidPhone},
+		{name: "fullwidth digits", input: "+７７０２１２３４５６７", err: ErrInvalidPhone},
+		{name: "newline inside", input: "+7702\n1234567", err: ErrInvalidPhone},
+		{name: "null byte", input: "+77021234567\x00", err: ErrInvalidPhone},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizePhone(tt.input)
+			if tt.err != nil {
+				require.ErrorIs(t, err, tt.err)
+				assert.Empty(t, got)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestNormalizePhone_Policy(t *testing.T) {
+	t.Cleanup(func() { SetPhonePolicy(DefaultPhonePolicy()) })
+	SetPhonePolicy(PhonePolicy{CountryCode: "44", TrunkPrefix: "0", NationalLength: 10})
+
+	got, err := NormalizePhone("020 7946 0958")
+	require.NoError(t, err)
+	assert.Equal(t, "+442079460958", got)
+
+	got, err = NormalizePhone("+7 702 123 45 67")
+	require.NoError(t, err)
+	assert.Equal(t, "+77021234567", got, "numbers with a country code ignore the policy")
+
+	_, err = NormalizePhone("8 702 123 45 67")
+	assert.ErrorIs(t, err, ErrInvalidPhone)
+}
+
+func TestCleanPhone(t *testing.T) {
+	assert.Equal(t, "+77021234567", CleanPhone("8 (702) 123-45-67"))
+	assert.Equal(t, "call me", CleanPhone("  call me\n"), "invalid numbers are left to the validation rules")
+}
@@ -89,3 +89,45 @@ func DeduplicateSlice[T comparable](s []T, transforms ...StringTransformFunc) []
 	}
 	return s[:j]
 }
+
+// DeduplicateSliceFunc removes the elements of s whose key was already seen,
+// the first element of every key is kept as is.
+func DeduplicateSliceFunc[T any, K comparable](s []T, key func(T) K) []T {
+	if len(s) == 0 {
+		return s
+	}
+
+	seen := make(map[K]struct{}, len(s))
+	j := 0
+	for _, v := range s {
+		k := key(v)
+		if _, ok := seen[k]; !ok {
+			seen[k] = struct{}{}
+			s[j] = v
+			j++
+		}
+	}
+	return s[:j]
+}
+
+// DeduplicateSliceBy returns the elements of in whose key was not seen
+// before, in their original order and unchanged, e.g. the first casing of
+// every name when key folds the case. Unlike DeduplicateSliceFunc, in is not
+// modified.
+func DeduplicateSliceBy[T comparable, K comparable](in []T, key func(T) K) []T {
+	if in == nil {
+		return nil
+	}
+
+	out := make([]T, 0, len(in))
+	seen := make(map[K]struct{}, len(in))
+	for _, v := range in {
+		k := key(v)
+		if _, ok := seen[k]; ok {
+			continue
+		}
+		seen[k] = struct{}{}
+		out = append(out, v)
+	}
+	return out
+}
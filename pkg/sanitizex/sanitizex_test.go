@@ -2,6 +2,7 @@ package sanitizex
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 	"testing"
 	"unicode"
@@ -745,6 +746,116 @@ func TestDeduplicateSlice(t *testing.T) {
 }
 
 // Benchmark tests
+func TestDeduplicateSliceBy(t *testing.T) {
+	t.Parallel()
+
+	identity := func(s string) string {
+		return s
+	}
+
+	trimLower := func(s string) string {
+		return strings.ToLower(strings.TrimSpace(s))
+	}
+
+	t.Run("string slices with identity key", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			input    []string
+			expected []string
+		}{
+			{
+				name:     "no duplicates",
+				input:    []string{"apple", "banana", "cherry"},
+				expected: []string{"apple", "banana", "cherry"},
+			},
+			{
+				name:     "exact duplicates",
+				input:    []string{"apple", "banana", "apple", "cherry"},
+				expected: []string{"apple", "banana", "cherry"},
+			},
+			{
+				name:     "empty slice",
+				input:    []string{},
+				expected: []string{},
+			},
+			{
+				name:     "single element",
+				input:    []string{"single"},
+				expected: []string{"single"},
+			},
+			{
+				name:     "all same elements",
+				input:    []string{"same", "same", "same"},
+				expected: []string{"same"},
+			},
+			{
+				name:     "case sensitive",
+				input:    []string{"Apple", "apple", "APPLE"},
+				expected: []string{"Apple", "apple", "APPLE"},
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				result := DeduplicateSliceBy(tt.input, identity)
+				assert.Equal(t, tt.expected, result)
+			})
+		}
+	})
+
+	t.Run("case preserving with trimLower key", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			input    []string
+			expected []string
+		}{
+			{
+				name:     "first casing wins",
+				input:    []string{"Apple", "apple", "APPLE"},
+				expected: []string{"Apple"},
+			},
+			{
+				name:     "whitespace is kept",
+				input:    []string{"  Apple ", "apple", "Banana", " banana"},
+				expected: []string{"  Apple ", "Banana"},
+			},
+			{
+				name:     "emails",
+				input:    []string{"John.Doe@Example.com", "john.doe@example.com", "jane@example.com"},
+				expected: []string{"John.Doe@Example.com", "jane@example.com"},
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				result := DeduplicateSliceBy(tt.input, trimLower)
+				assert.Equal(t, tt.expected, result)
+			})
+		}
+	})
+
+	t.Run("input is not modified", func(t *testing.T) {
+		input := []string{"b", "A", "a", "B", "c"}
+		result := DeduplicateSliceBy(input, strings.ToLower)
+		assert.Equal(t, []string{"b", "A", "c"}, result)
+		assert.Equal(t, []string{"b", "A", "a", "B", "c"}, input)
+	})
+
+	t.Run("nil slice", func(t *testing.T) {
+		assert.Nil(t, DeduplicateSliceBy(nil, identity))
+	})
+
+	t.Run("struct elements", func(t *testing.T) {
+		type user struct {
+			ID   int
+			Name string
+		}
+		input := []user{{1, "first"}, {2, "second"}, {1, "duplicate"}}
+		result := DeduplicateSliceBy(input, func(u user) int { return u.ID })
+		assert.Equal(t, []user{{1, "first"}, {2, "second"}}, result)
+	})
+}
+
 func BenchmarkCleanSingleLine(b *testing.B) {
 	input := "  hello\tworld\nwith\rmixed   whitespace  "
 	for b.Loop() {
@@ -836,3 +947,26 @@ func BenchmarkDeduplicateSlice(b *testing.B) {
 		}
 	})
 }
+
+func BenchmarkDeduplicateSliceBy(b *testing.B) {
+	trimLower := func(s string) string {
+		return strings.ToLower(strings.TrimSpace(s))
+	}
+
+	input := make([]string, 10000)
+	for i := range input {
+		input[i] = fmt.Sprintf("  Item%d ", i%1000) // 1000 unique items, 10 copies each
+	}
+
+	b.Run("DeduplicateSlice", func(b *testing.B) {
+		for b.Loop() {
+			DeduplicateSlice(slices.Clone(input), strings.TrimSpace, strings.ToLower)
+		}
+	})
+
+	b.Run("DeduplicateSliceBy", func(b *testing.B) {
+		for b.Loop() {
+			DeduplicateSliceBy(input, trimLower)
+		}
+	})
+}
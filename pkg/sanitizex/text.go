@@ -0,0 +1,171 @@
+package sanitizex
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// maxStripPasses bounds the passes of StripHTML over entity encoded markup,
+// e.g. &amp;lt;script&amp;gt; needs two.
+const maxStripPasses = 4
+
+// StripHTML returns the text content of s without tags, comments and the
+// contents of script and style elements. Entities are decoded, markup they
+// decode to is stripped too. Block elements and <br> end with a newline so
+// that paragraphs stay apart, run the result through CleanMultiline and
+// CollapseBlankLines to tidy it up.
+func StripHTML(s string) string {
+	for range maxStripPasses {
+		if !strings.ContainsAny(s, "<&") {
+			return s
+		}
+		stripped := stripHTMLOnce(s)
+		if stripped == s {
+			return s
+		}
+		s = stripped
+	}
+	// Still decoding to markup, drop the brackets rather than return a tag.
+	return strings.NewReplacer("<", "", ">", "").Replace(s)
+}
+
+func stripHTMLOnce(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+
+	z := html.NewTokenizer(strings.NewReader(s))
+	var skip atom.Atom
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return b.String()
+		case html.TextToken:
+			if skip == 0 {
+				b.Write(z.Text())
+			}
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, _ := z.TagName()
+			a := atom.Lookup(name)
+			switch {
+			case skip != 0:
+			case a == atom.Script || a == atom.Style:
+				skip = a
+			case a == atom.Br:
+				b.WriteByte('\n')
+			}
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			a := atom.Lookup(name)
+			switch {
+			case skip != 0:
+				if a == skip {
+					skip = 0
+				}
+			case isBlockElement(a):
+				b.WriteByte('\n')
+			}
+		}
+	}
+}
+
+func isBlockElement(a atom.Atom) bool {
+	switch a {
+	case atom.P, atom.Div, atom.Li, atom.Tr, atom.Blockquote, atom.Pre,
+		atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		return true
+	}
+	return false
+}
+
+// TruncateRunes returns the first max runes of s. The cut is moved back so
+// it does not split combining marks, emoji ZWJ sequences, skin tone
+// modifiers and flags from their base character, unless the first cluster
+// alone is longer than max.
+func TruncateRunes(s string, max int) string {
+	if max <= 0 {
+		return ""
+	}
+	if utf8.RuneCountInString(s) <= max {
+		return s
+	}
+
+	runes := []rune(s)
+	cut := max
+	for cut > 0 && continuesCluster(runes, cut) {
+		cut--
+	}
+	if cut == 0 {
+		cut = max
+	}
+	return string(runes[:cut])
+}
+
+// continuesCluster reports whether runes[i] belongs to the grapheme cluster
+// of runes[i-1].
+func continuesCluster(runes []rune, i int) bool {
+	r, prev := runes[i], runes[i-1]
+	switch {
+	case prev == '\u200d', r == '\u200d':
+		return true
+	case unicode.In(r, unicode.Mn, unicode.Me, unicode.Mc):
+		return true
+	case r >= '\ufe00' && r <= '\ufe0f': // variation selectors
+		return true
+	case r >= 0x1f3fb && r <= 0x1f3ff: // skin tone modifiers
+		return true
+	case r >= 0xe0020 && r <= 0xe007f: // tag sequences of subdivision flags
+		return true
+	case isRegionalIndicator(r) && isRegionalIndicator(prev):
+		// Flags are pairs, r ends a flag when an odd number of regional
+		// indicators precede it.
+		n := 0
+		for j := i - 1; j >= 0 && isRegionalIndicator(runes[j]); j-- {
+			n++
+		}
+		return n%2 == 1
+	}
+	return false
+}
+
+func isRegionalIndicator(r rune) bool {
+	return r >= 0x1f1e6 && r <= 0x1f1ff
+}
+
+// CollapseBlankLines keeps at most maxConsecutive blank lines in a row, lines
+// with only whitespace count as blank.
+func CollapseBlankLines(s string, maxConsecutive int) string {
+	if s == "" {
+		return ""
+	}
+	maxConsecutive = max(maxConsecutive, 0)
+
+	lines := strings.Split(s, "\n")
+	kept := lines[:0]
+	blank := 0
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			blank++
+			if blank > maxConsecutive {
+				continue
+			}
+		} else {
+			blank = 0
+		}
+		kept = append(kept, line)
+	}
+	return strings.Join(kept, "\n")
+}
+
+// CleanFreeText neutralizes user written text shown to other users, like
+// announcement bodies: markup is stripped, the text cleaned with
+// CleanMultiline, runs of blank lines collapsed to one and the result
+// truncated to maxRunes.
+func CleanFreeText(s string, maxRunes int) string {
+	s = CleanMultiline(StripHTML(s))
+	s = strings.TrimSpace(CollapseBlankLines(s, 1))
+	return TruncateRunes(s, maxRunes)
+}
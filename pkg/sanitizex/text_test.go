@@ -0,0 +1,117 @@
+package sanitizex
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStripHTML(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "plain text",
+			input:    "Exams start on Monday",
+			expected: "Exams start on Monday",
+		},
+		{
+			name:     "nested tags",
+			input:    "<div><p>Hello <b><i>dear</i> students</b></p></div>",
+			expected: "Hello dear students\n\n",
+		},
+		{
+			name:     "script and style contents dropped",
+			input:    "a<script>alert(1)</script>b<style>p{color:red}</style>c",
+			expected: "abc",
+		},
+		{
+			name:     "attributes dropped",
+			input:    `<img src=x onerror="alert(1)">caption<a href="javascript:alert(1)">link</a>`,
+			expected: "captionlink",
+		},
+		{
+			name:     "entities decoded",
+			input:    "Tom &amp; Jerry &quot;quoted&quot; 1 &lt; 2",
+			expected: `Tom & Jerry "quoted" 1 < 2`,
+		},
+		{
+			name:     "entity encoded markup stripped",
+			input:    "&lt;script&gt;alert(1)&lt;/script&gt;text",
+			expected: "text",
+		},
+		{
+			name:     "double encoded markup stripped",
+			input:    "&amp;lt;b&amp;gt;bold&amp;lt;/b&amp;gt;",
+			expected: "bold",
+		},
+		{
+			name:     "line breaks kept",
+			input:    "line 1<br>line 2<br/>line 3",
+			expected: "line 1\nline 2\nline 3",
+		},
+		{
+			name:     "comments dropped",
+			input:    "a<!-- <script>alert(1)</script> -->b",
+			expected: "ab",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, StripHTML(tt.input))
+		})
+	}
+}
+
+func TestStripHTML_Polyglot(t *testing.T) {
+	// The payload of the registration security tests.
+	polyglot := "jaVasCript:/*-/*`/*\\`/*'/*\"/**/(/* */oNcliCk=alert() )//%0D%0A%0d%0a//</stYle/</titLe/</teXtarEa/</scRipt/--!>\\x3csVg/<sVg/oNloAd=alert()//"
+
+	got := StripHTML(polyglot)
+	assert.NotContains(t, got, "<")
+	assert.NotContains(t, got, ">")
+	assert.Contains(t, got, "jaVasCript:", "the text before the first tag is kept as text")
+}
+
+func TestTruncateRunes(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		max      int
+		expected string
+	}{
+		{name: "shorter", input: "hello", max: 10, expected: "hello"},
+		{name: "exact", input: "hello", max: 5, expected: "hello"},
+		{name: "ascii", input: "hello world", max: 5, expected: "hello"},
+		{name: "multibyte", input: "привет мир", max: 6, expected: "привет"},
+		{name: "zero", input: "hello", max: 0, expected: ""},
+		{name: "emoji", input: "hi 👋🌍", max: 4, expected: "hi 👋"},
+		{name: "combining mark", input: "cafe\u0301 au lait", max: 4, expected: "caf"},
+		{name: "skin tone", input: "ok \U0001f44d\U0001f3fd", max: 4, expected: "ok "},
+		{name: "zwj family", input: "a\U0001f468\u200d\U0001f469\u200d\U0001f467", max: 4, expected: "a"},
+		{name: "flags", input: "🇰🇿🇫🇷", max: 3, expected: "🇰🇿"},
+		{name: "cluster longer than max", input: "\U0001f468\u200d\U0001f469", max: 2, expected: "\U0001f468\u200d"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, TruncateRunes(tt.input, tt.max))
+		})
+	}
+}
+
+func TestCollapseBlankLines(t *testing.T) {
+	assert.Equal(t, "a\n\nb", CollapseBlankLines("a\n\n\n\n\nb", 1))
+	assert.Equal(t, "a\nb", CollapseBlankLines("a\n  \n\t\nb", 0))
+	assert.Equal(t, "a\n\n\nb", CollapseBlankLines("a\n\n\nb", 2))
+	assert.Equal(t, "", CollapseBlankLines("", 1))
+}
+
+func TestCleanFreeText(t *testing.T) {
+	input := "<h1>Exams</h1>\n\n\n<p>Start on <b>Monday</b>&nbsp;</p><script>steal()</script>\n\n\n\n<p>Good luck!</p>"
+	assert.Equal(t, "Exams\n\nStart on Monday\n\nGood luck!", CleanFreeText(input, 100))
+	assert.Equal(t, "Exams", CleanFreeText(input, 5))
+}
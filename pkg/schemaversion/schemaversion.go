@@ -0,0 +1,255 @@
+// Package schemaversion compares the schema of the database with the
+// migrations embedded in the binary. During a rolling deploy an instance of
+// the previous release keeps running against the migrated schema; when a
+// migration it does not know is breaking, e.g. it drops a column the old
+// queries read, the instance reports itself not ready instead of failing on
+// every request.
+//
+// A migration is breaking when its name ends with BreakingSuffix, e.g.
+// 000021_drop_users_phone_breaking.up.sql. An old binary cannot read the
+// names of the migrations it does not embed, so every instance records the
+// migrations it embeds in the manifest table, see Store.RecordMigrations,
+// and the old ones look the newer migrations up there.
+package schemaversion
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/contrib/bridges/otelslog"
+
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
+)
+
+var logger = otelslog.NewLogger("ucms/pkg/schemaversion")
+
+const (
+	// BreakingSuffix ends the name of the breaking migrations.
+	BreakingSuffix = "_breaking"
+	// ReasonVersionMismatch is the reason of the readiness of an instance
+	// older than a breaking migration applied to its database.
+	ReasonVersionMismatch = "schema.version_mismatch"
+	// DefaultInterval is the time between two checks.
+	DefaultInterval = 30 * time.Second
+)
+
+// Migration is a migration embedded in a binary.
+type Migration struct {
+	Version  uint
+	Name     string
+	Breaking bool
+}
+
+// upMigration matches the up migrations, as golang-migrate names them.
+var upMigration = regexp.MustCompile(`^([0-9]+)_(.*)\.up\.sql$`)
+
+// Embedded returns the up migrations of dir in fsys, by version.
+func Embedded(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var migrations []Migration
+	for _, e := range entries {
+		m := upMigration.FindStringSubmatch(e.Name())
+		if m == nil {
+			continue
+		}
+		version, err := strconv.ParseUint(m[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version %s: %w", e.Name(), err)
+		}
+		migrations = append(migrations, Migration{
+			Version:  uint(version),
+			Name:     m[2],
+			Breaking: strings.HasSuffix(m[2], BreakingSuffix),
+		})
+	}
+	slices.SortFunc(migrations, func(a, b Migration) int { return cmp.Compare(a.Version, b.Version) })
+	return migrations, nil
+}
+
+// Store reads the applied version and keeps the manifest of the migrations.
+type Store interface {
+	// AppliedVersion returns the version of the last applied migration.
+	AppliedVersion(ctx context.Context) (version uint, dirty bool, err error)
+	// RecordMigrations adds the migrations to the manifest.
+	RecordMigrations(ctx context.Context, migrations []Migration) error
+	// FirstBreakingAfter returns the first breaking migration of the manifest
+	// newer than version, false when there is none.
+	FirstBreakingAfter(ctx context.Context, version uint) (Migration, bool, error)
+}
+
+// Versions are the schema version the binary expects and the one applied to
+// the database.
+type Versions struct {
+	Expected uint `json:"expected"`
+	Applied  uint `json:"applied"`
+	Dirty    bool `json:"dirty"`
+}
+
+// Checker checks the schema of the database against the embedded
+// migrations, Run checks it periodically.
+type Checker struct {
+	store      Store
+	migrations []Migration
+	logger     *slog.Logger
+	clock      clock.Clock
+	interval   time.Duration
+
+	mu       sync.Mutex
+	versions Versions
+	reason   string
+}
+
+type CheckerArgs struct {
+	Store Store
+	// Migrations are the embedded migrations, see Embedded.
+	Migrations []Migration
+	Logger     *slog.Logger
+	// Clock defaults to clock.Real.
+	Clock clock.Clock
+	// Interval defaults to DefaultInterval.
+	Interval time.Duration
+}
+
+// NewChecker returns a Checker ready until a check says otherwise.
+//
+//	WARNING; panics if store is nil
+func NewChecker(args CheckerArgs) *Checker {
+	if args.Store == nil {
+		panic("store is required")
+	}
+	if args.Logger == nil {
+		args.Logger = logger
+	}
+	if args.Interval <= 0 {
+		args.Interval = DefaultInterval
+	}
+
+	c := &Checker{
+		store:      args.Store,
+		migrations: args.Migrations,
+		logger:     args.Logger,
+		clock:      clock.Or(args.Clock),
+		interval:   args.Interval,
+	}
+	if n := len(args.Migrations); n > 0 {
+		c.versions.Expected = args.Migrations[n-1].Version
+	}
+	return c
+}
+
+// Record adds the embedded migrations to the manifest, for the older
+// instances to read, and checks the schema once. Call it at startup, after
+// migrating.
+func (c *Checker) Record(ctx context.Context) error {
+	if err := c.store.RecordMigrations(ctx, c.migrations); err != nil {
+		return fmt.Errorf("failed to record the migrations: %w", err)
+	}
+	if err := c.Check(ctx); err != nil {
+		return err
+	}
+
+	v := c.Versions()
+	c.logger.InfoContext(ctx, "Schema version",
+		slog.Uint64("expected", uint64(v.Expected)),
+		slog.Uint64("applied", uint64(v.Applied)),
+		slog.Bool("dirty", v.Dirty),
+	)
+	return nil
+}
+
+// Check reads the applied version and updates the readiness: the instance is
+// not ready while a breaking migration newer than the binary is applied. An
+// error leaves the last result in place.
+func (c *Checker) Check(ctx context.Context) error {
+	applied, dirty, err := c.store.AppliedVersion(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read the applied schema version: %w", err)
+	}
+
+	c.mu.Lock()
+	expected := c.versions.Expected
+	c.mu.Unlock()
+
+	var (
+		reason   string
+		breaking Migration
+	)
+	if applied > expected {
+		var found bool
+		breaking, found, err = c.store.FirstBreakingAfter(ctx, expected)
+		if err != nil {
+			return fmt.Errorf("failed to read the schema manifest: %w", err)
+		}
+		if found && breaking.Version <= applied {
+			reason = ReasonVersionMismatch
+		}
+	}
+
+	c.mu.Lock()
+	previous := c.reason
+	c.versions.Applied, c.versions.Dirty = applied, dirty
+	c.reason = reason
+	c.mu.Unlock()
+
+	switch {
+	case reason != "" && previous == "":
+		c.logger.ErrorContext(ctx, "The schema has a breaking migration this binary does not know, the instance is not ready",
+			slog.Uint64("expected", uint64(expected)),
+			slog.Uint64("applied", uint64(applied)),
+			slog.Uint64("breaking_version", uint64(breaking.Version)),
+			slog.String("breaking_migration", breaking.Name),
+		)
+	case reason == "" && previous != "":
+		c.logger.InfoContext(ctx, "The schema matches the binary again, the instance is ready",
+			slog.Uint64("expected", uint64(expected)),
+			slog.Uint64("applied", uint64(applied)),
+		)
+	}
+	return nil
+}
+
+// Run checks the schema every interval until ctx is done.
+func (c *Checker) Run(ctx context.Context) {
+	timer := c.clock.NewTimer(c.interval)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-timer.C():
+			if err := c.Check(ctx); err != nil && ctx.Err() == nil {
+				c.logger.WarnContext(ctx, "Failed to check the schema version", slog.String("error", err.Error()))
+			}
+			timer.Reset(c.interval)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Versions returns the versions of the last check.
+func (c *Checker) Versions() Versions {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.versions
+}
+
+// Ready reports whether the schema is one the binary can serve, with the
+// reason when it is not.
+func (c *Checker) Ready() (bool, string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.reason == "", c.reason
+}
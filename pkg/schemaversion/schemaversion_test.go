@@ -0,0 +1,119 @@
+package schemaversion
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeStore struct {
+	applied  uint
+	dirty    bool
+	manifest map[uint]Migration
+	err      error
+}
+
+func (s *fakeStore) AppliedVersion(context.Context) (uint, bool, error) {
+	return s.applied, s.dirty, s.err
+}
+
+func (s *fakeStore) RecordMigrations(_ context.Context, migrations []Migration) error {
+	if s.manifest == nil {
+		s.manifest = make(map[uint]Migration)
+	}
+	for _, m := range migrations {
+		s.manifest[m.Version] = m
+	}
+	return nil
+}
+
+func (s *fakeStore) FirstBreakingAfter(_ context.Context, version uint) (Migration, bool, error) {
+	var first Migration
+	found := false
+	for v, m := range s.manifest {
+		if v > version && m.Breaking && (!found || v < first.Version) {
+			first, found = m, true
+		}
+	}
+	return first, found, nil
+}
+
+func TestEmbedded(t *testing.T) {
+	fsys := fstest.MapFS{
+		"migrations/000002_drop_phone_breaking.up.sql":   {},
+		"migrations/000002_drop_phone_breaking.down.sql": {},
+		"migrations/000001_create_users.up.sql":          {},
+		"migrations/000001_create_users.down.sql":        {},
+		"migrations/README.md":                           {},
+	}
+
+	migrations, err := Embedded(fsys, "migrations")
+	require.NoError(t, err)
+	assert.Equal(t, []Migration{
+		{Version: 1, Name: "create_users"},
+		{Version: 2, Name: "drop_phone_breaking", Breaking: true},
+	}, migrations)
+}
+
+func TestChecker(t *testing.T) {
+	embedded := []Migration{{Version: 1, Name: "create_users"}, {Version: 2, Name: "add_phone"}}
+
+	t.Run("matching schema", func(t *testing.T) {
+		store := &fakeStore{applied: 2}
+		c := NewChecker(CheckerArgs{Store: store, Migrations: embedded})
+
+		require.NoError(t, c.Record(t.Context()))
+		assert.Equal(t, Versions{Expected: 2, Applied: 2}, c.Versions())
+		ready, reason := c.Ready()
+		assert.True(t, ready)
+		assert.Empty(t, reason)
+		assert.Len(t, store.manifest, 2, "the embedded migrations are recorded")
+	})
+
+	t.Run("newer compatible schema", func(t *testing.T) {
+		store := &fakeStore{applied: 3, manifest: map[uint]Migration{3: {Version: 3, Name: "add_index"}}}
+		c := NewChecker(CheckerArgs{Store: store, Migrations: embedded})
+
+		require.NoError(t, c.Check(t.Context()))
+		ready, _ := c.Ready()
+		assert.True(t, ready, "a binary runs on the schemas its migrations are compatible with")
+		assert.Equal(t, Versions{Expected: 2, Applied: 3}, c.Versions())
+	})
+
+	t.Run("breaking migration", func(t *testing.T) {
+		store := &fakeStore{applied: 4, manifest: map[uint]Migration{
+			3: {Version: 3, Name: "add_index"},
+			4: {Version: 4, Name: "drop_phone_breaking", Breaking: true},
+		}}
+		c := NewChecker(CheckerArgs{Store: store, Migrations: embedded})
+
+		require.NoError(t, c.Check(t.Context()))
+		ready, reason := c.Ready()
+		assert.False(t, ready)
+		assert.Equal(t, ReasonVersionMismatch, reason)
+
+		// Rolled back, e.g. the deploy was aborted.
+		store.applied = 3
+		require.NoError(t, c.Check(t.Context()))
+		ready, reason = c.Ready()
+		assert.True(t, ready)
+		assert.Empty(t, reason)
+	})
+
+	t.Run("failed check keeps the last result", func(t *testing.T) {
+		store := &fakeStore{applied: 4, manifest: map[uint]Migration{
+			4: {Version: 4, Name: "drop_phone_breaking", Breaking: true},
+		}}
+		c := NewChecker(CheckerArgs{Store: store, Migrations: embedded})
+		require.NoError(t, c.Check(t.Context()))
+
+		store.err = errors.New("connection refused")
+		require.Error(t, c.Check(t.Context()))
+		ready, _ := c.Ready()
+		assert.False(t, ready)
+	})
+}
@@ -0,0 +1,112 @@
+// Package singleflightx coalesces concurrent identical commands, e.g. a
+// double submitted form: the first call of a key runs, the calls of the same
+// key arriving while it runs wait for it and get its result.
+package singleflightx
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/sync/singleflight"
+
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx/metrics"
+)
+
+// Group coalesces the concurrent calls of the same key. Only the calls with
+// equal keys are coalesced, a key must tell apart every command that could
+// have another outcome.
+type Group struct {
+	name    string
+	flights singleflight.Group
+	shared  metric.Int64Counter
+}
+
+// NewGroup returns the group name, e.g. "registration.start", counting the
+// shared calls in reg. A nil reg defaults to metrics.Default().
+func NewGroup(name string, reg *metrics.Registry) *Group {
+	if reg == nil {
+		reg = metrics.Default()
+	}
+	return &Group{
+		name: name,
+		shared: reg.Int64Counter(metrics.SingleflightShared,
+			metric.WithDescription("Number of calls given the result of a concurrent identical call"),
+			metric.WithUnit("{call}"),
+		),
+	}
+}
+
+// Do runs fn once for the concurrent calls of key and returns its error to
+// all of them.
+//
+// fn runs with the context of the first call without its cancellation, its
+// deadline kept: a caller leaving, e.g. a closed connection, does not fail
+// the others. Every caller stops waiting when its own ctx is done. Do not
+// coalesce calls running in a transaction of the context, the others would
+// not run in theirs.
+func (g *Group) Do(ctx context.Context, key string, fn func(ctx context.Context) error) error {
+	// Written by the call running fn before the result is sent, so read
+	// after it is received.
+	leader := false
+	ch := g.flights.DoChan(key, func() (any, error) {
+		leader = true
+		return nil, run(ctx, fn)
+	})
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case res := <-ch:
+		if p, ok := res.Err.(*panicError); ok && leader {
+			panic(p.value)
+		}
+		if !leader {
+			g.shared.Add(ctx, 1, metric.WithAttributes(attribute.String(metrics.AttrFlight, g.name)))
+			trace.SpanFromContext(ctx).AddEvent("shared the result of a concurrent call")
+		}
+		return res.Err
+	}
+}
+
+// run runs fn detached from the cancellation of ctx. A panic is returned as
+// a panicError: singleflight would panic it again in a goroutine of its own,
+// out of reach of the recovering middlewares.
+func run(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	detached := context.WithoutCancel(ctx)
+	if deadline, ok := ctx.Deadline(); ok {
+		var cancel context.CancelFunc
+		detached, cancel = context.WithDeadline(detached, deadline)
+		defer cancel()
+	}
+
+	defer func() {
+		if v := recover(); v != nil {
+			err = &panicError{value: v, stack: debug.Stack()}
+		}
+	}()
+	return fn(detached)
+}
+
+type panicError struct {
+	value any
+	stack []byte
+}
+
+func (e *panicError) Error() string {
+	return fmt.Sprintf("singleflightx: coalesced call panicked: %v\n%s", e.value, e.stack)
+}
+
+// Command wraps h so that the concurrent commands with the same key, as
+// returned by key, are handled once in g.
+func Command[T any](g *Group, key func(T) string, h otelx.Handler[T]) otelx.Handler[T] {
+	return otelx.HandlerFunc[T](func(ctx context.Context, cmd T) error {
+		return g.Do(ctx, key(cmd), func(ctx context.Context) error {
+			return h.Handle(ctx, cmd)
+		})
+	})
+}
@@ -0,0 +1,158 @@
+package singleflightx
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx/metrics"
+)
+
+func newGroup(t *testing.T) (*Group, *sdkmetric.ManualReader) {
+	t.Helper()
+	reader := sdkmetric.NewManualReader()
+	reg := metrics.NewRegistry(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)).Meter("test"))
+	return NewGroup("test", reg), reader
+}
+
+func sharedCalls(t *testing.T, reader *sdkmetric.ManualReader) int64 {
+	t.Helper()
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name != metrics.SingleflightShared {
+				continue
+			}
+			var n int64
+			for _, dp := range m.Data.(metricdata.Sum[int64]).DataPoints {
+				n += dp.Value
+			}
+			return n
+		}
+	}
+	return 0
+}
+
+func TestGroup_Do_SameKey(t *testing.T) {
+	t.Parallel()
+	g, reader := newGroup(t)
+	errBoom := errors.New("boom")
+
+	var runs atomic.Int32
+	release := make(chan struct{})
+	const calls = 10
+	var wg, started sync.WaitGroup
+	errs := make([]error, calls)
+	for i := range calls {
+		wg.Add(1)
+		started.Add(1)
+		go func() {
+			defer wg.Done()
+			started.Done()
+			errs[i] = g.Do(t.Context(), "key", func(ctx context.Context) error {
+				runs.Add(1)
+				<-release
+				return errBoom
+			})
+		}()
+	}
+	started.Wait()
+	// The calls wait for the first one.
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), runs.Load())
+	for _, err := range errs {
+		assert.ErrorIs(t, err, errBoom, "every call gets the result")
+	}
+	assert.Equal(t, int64(calls-1), sharedCalls(t, reader))
+}
+
+func TestGroup_Do_OtherKeys(t *testing.T) {
+	t.Parallel()
+	g, reader := newGroup(t)
+
+	// a waits for b, coalescing or serializing them would block.
+	bRan := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		assert.NoError(t, g.Do(t.Context(), "a", func(ctx context.Context) error {
+			select {
+			case <-bRan:
+				return nil
+			case <-time.After(5 * time.Second):
+				return errors.New("b did not run")
+			}
+		}))
+	}()
+	go func() {
+		defer wg.Done()
+		assert.NoError(t, g.Do(t.Context(), "b", func(ctx context.Context) error {
+			close(bRan)
+			return nil
+		}))
+	}()
+	wg.Wait()
+
+	assert.Zero(t, sharedCalls(t, reader))
+}
+
+func TestGroup_Do_CallerLeaves(t *testing.T) {
+	t.Parallel()
+	g, _ := newGroup(t)
+
+	running := make(chan struct{})
+	release := make(chan struct{})
+	var fnErr error
+	leaderCtx, cancel := context.WithCancel(t.Context())
+	leaderDone := make(chan error)
+	go func() {
+		leaderDone <- g.Do(leaderCtx, "key", func(ctx context.Context) error {
+			close(running)
+			<-release
+			fnErr = ctx.Err()
+			return nil
+		})
+	}()
+	<-running
+
+	followerDone := make(chan error)
+	go func() {
+		followerDone <- g.Do(t.Context(), "key", func(ctx context.Context) error {
+			return errors.New("not shared")
+		})
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	cancel()
+	assert.ErrorIs(t, <-leaderDone, context.Canceled)
+	close(release)
+	assert.NoError(t, <-followerDone, "the call goes on without the first caller")
+	assert.NoError(t, fnErr)
+}
+
+func TestGroup_Do_Panic(t *testing.T) {
+	t.Parallel()
+	g, _ := newGroup(t)
+
+	assert.PanicsWithValue(t, "boom", func() {
+		_ = g.Do(t.Context(), "key", func(ctx context.Context) error {
+			panic("boom")
+		})
+	}, "the panic reaches the caller running the call")
+
+	err := g.Do(t.Context(), "key", func(ctx context.Context) error { return nil })
+	assert.NoError(t, err, "the key is released")
+}
@@ -0,0 +1,132 @@
+package storagex
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/singleflightx"
+)
+
+const (
+	// DefaultExistenceTTL is how long ExistenceCache trusts an answer, short
+	// enough that a deleted object is noticed within seconds.
+	DefaultExistenceTTL = 5 * time.Second
+
+	// maxCachedExistences bounds the existence cache, the expired answers are
+	// dropped when exceeded and all of them if that is not enough.
+	maxCachedExistences = 10_000
+)
+
+// ObjectHeader reads the metadata of an object, it returns ErrObjectNotFound
+// for a missing one.
+type ObjectHeader interface {
+	HeadObject(ctx context.Context, key string) (ObjectInfo, error)
+}
+
+// ExistenceCache tells whether objects exist with HEAD requests. It trusts
+// an answer for the TTL and makes a single request for the concurrent checks
+// of a key, so a hot object costs one request per TTL whatever the traffic.
+// Failed requests are not cached.
+type ExistenceCache struct {
+	objects ObjectHeader
+	ttl     time.Duration
+	now     func() time.Time
+	flights *singleflightx.Group
+
+	mu    sync.Mutex
+	cache map[string]cachedExistence
+}
+
+type cachedExistence struct {
+	exists    bool
+	expiresAt time.Time
+}
+
+type ExistenceCacheConfig struct {
+	Objects ObjectHeader
+	// TTL defaults to DefaultExistenceTTL.
+	TTL time.Duration
+	Now func() time.Time
+}
+
+func NewExistenceCache(cfg ExistenceCacheConfig) *ExistenceCache {
+	if cfg.TTL <= 0 {
+		cfg.TTL = DefaultExistenceTTL
+	}
+	if cfg.Now == nil {
+		cfg.Now = time.Now
+	}
+
+	return &ExistenceCache{
+		objects: cfg.Objects,
+		ttl:     cfg.TTL,
+		now:     cfg.Now,
+		flights: singleflightx.NewGroup("storage.exists", nil),
+		cache:   make(map[string]cachedExistence),
+	}
+}
+
+// ObjectExists reports whether the object key exists, from the cache while
+// the last answer is younger than the TTL.
+func (c *ExistenceCache) ObjectExists(ctx context.Context, key string) (bool, error) {
+	const op = "storagex.ExistenceCache.ObjectExists"
+	if exists, ok := c.cached(key, true); ok {
+		return exists, nil
+	}
+
+	err := c.flights.Do(ctx, key, func(ctx context.Context) error {
+		// A flight that just finished may have answered meanwhile.
+		if _, ok := c.cached(key, true); ok {
+			return nil
+		}
+		_, err := c.objects.HeadObject(ctx, key)
+		switch {
+		case err == nil:
+			c.store(key, true)
+		case errors.Is(err, ErrObjectNotFound):
+			c.store(key, false)
+		default:
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return false, errorx.Wrap(err, op)
+	}
+
+	// The answer of the flight is read back even if it expired meanwhile.
+	// It is gone only if the cache was cleared, the object is then assumed
+	// to exist until the next check.
+	exists, ok := c.cached(key, false)
+	return exists || !ok, nil
+}
+
+func (c *ExistenceCache) cached(key string, fresh bool) (exists, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.cache[key]
+	if !ok || (fresh && !c.now().Before(entry.expiresAt)) {
+		return false, false
+	}
+	return entry.exists, true
+}
+
+func (c *ExistenceCache) store(key string, exists bool) {
+	now := c.now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.cache) >= maxCachedExistences {
+		for k, entry := range c.cache {
+			if !now.Before(entry.expiresAt) {
+				delete(c.cache, k)
+			}
+		}
+		if len(c.cache) >= maxCachedExistences {
+			c.cache = make(map[string]cachedExistence)
+		}
+	}
+	c.cache[key] = cachedExistence{exists: exists, expiresAt: now.Add(c.ttl)}
+}
@@ -0,0 +1,119 @@
+package storagex
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+)
+
+// fakeHeader answers the HEAD requests from objects, counting them. A non
+// nil release holds the requests until it is closed.
+type fakeHeader struct {
+	mu      sync.Mutex
+	objects map[string]bool
+	err     error
+	release chan struct{}
+	heads   atomic.Int64
+}
+
+func (h *fakeHeader) HeadObject(ctx context.Context, key string) (ObjectInfo, error) {
+	h.heads.Add(1)
+	if h.release != nil {
+		<-h.release
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.err != nil {
+		return ObjectInfo{}, h.err
+	}
+	if !h.objects[key] {
+		return ObjectInfo{}, errorx.Wrap(ErrObjectNotFound, "fakeHeader.HeadObject")
+	}
+	return ObjectInfo{Key: key}, nil
+}
+
+func (h *fakeHeader) set(key string, exists bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.objects[key] = exists
+}
+
+func TestExistenceCache_CachesForTTL(t *testing.T) {
+	clk := &fakeClock{now: time.Date(2026, time.March, 1, 12, 0, 0, 0, time.UTC)}
+	objects := &fakeHeader{objects: map[string]bool{"avatars/a": true}}
+	cache := NewExistenceCache(ExistenceCacheConfig{Objects: objects, TTL: 5 * time.Second, Now: clk.Now})
+
+	exists, err := cache.ObjectExists(t.Context(), "avatars/a")
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	objects.set("avatars/a", false)
+	clk.now = clk.now.Add(4 * time.Second)
+	exists, err = cache.ObjectExists(t.Context(), "avatars/a")
+	require.NoError(t, err)
+	assert.True(t, exists, "the answer is trusted for the ttl")
+	assert.EqualValues(t, 1, objects.heads.Load())
+
+	clk.now = clk.now.Add(time.Second)
+	exists, err = cache.ObjectExists(t.Context(), "avatars/a")
+	require.NoError(t, err)
+	assert.False(t, exists, "the object is checked again once the ttl elapsed")
+	assert.EqualValues(t, 2, objects.heads.Load())
+
+	exists, err = cache.ObjectExists(t.Context(), "avatars/a")
+	require.NoError(t, err)
+	assert.False(t, exists, "a missing object is cached as well")
+	assert.EqualValues(t, 2, objects.heads.Load())
+}
+
+func TestExistenceCache_ConcurrentChecksShareOneRequest(t *testing.T) {
+	objects := &fakeHeader{objects: map[string]bool{}, release: make(chan struct{})}
+	cache := NewExistenceCache(ExistenceCacheConfig{Objects: objects})
+
+	const callers = 50
+	var wg sync.WaitGroup
+	results := make([]bool, callers)
+	errs := make([]error, callers)
+	for i := range callers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], errs[i] = cache.ObjectExists(context.Background(), "avatars/hot")
+		}()
+	}
+	require.Eventually(t, func() bool { return objects.heads.Load() == 1 }, time.Second, time.Millisecond)
+	// Let the callers queue behind the request before it returns.
+	time.Sleep(20 * time.Millisecond)
+	close(objects.release)
+	wg.Wait()
+
+	assert.EqualValues(t, 1, objects.heads.Load(), "a hot object is requested once")
+	for i := range callers {
+		require.NoError(t, errs[i])
+		assert.False(t, results[i])
+	}
+}
+
+func TestExistenceCache_FailedRequestNotCached(t *testing.T) {
+	objects := &fakeHeader{objects: map[string]bool{"avatars/a": true}, err: errors.New("connection reset")}
+	cache := NewExistenceCache(ExistenceCacheConfig{Objects: objects})
+
+	_, err := cache.ObjectExists(t.Context(), "avatars/a")
+	require.Error(t, err)
+
+	objects.mu.Lock()
+	objects.err = nil
+	objects.mu.Unlock()
+	exists, err := cache.ObjectExists(t.Context(), "avatars/a")
+	require.NoError(t, err)
+	assert.True(t, exists)
+	assert.EqualValues(t, 2, objects.heads.Load())
+}
@@ -0,0 +1,89 @@
+package storagex
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/i18nx"
+)
+
+const DefaultScanTimeout = 30 * time.Second
+
+var (
+	ErrInfected           = errorx.NewBusinessRuleViolation().WithKey(i18nx.KeyUploadInfected)
+	ErrScannerUnavailable = errorx.NewServiceUnavailable().WithDetails("malware scanner unavailable")
+)
+
+// Scanner inspects uploaded content for malware.
+type Scanner interface {
+	Scan(ctx context.Context, content io.Reader) (ScanResult, error)
+}
+
+type ScanResult struct {
+	Infected bool
+	// Signature names the detected malware.
+	Signature string
+	// Skipped is set when the scanner failed and the upload policy let the
+	// content through unscanned.
+	Skipped bool
+}
+
+// NoopScanner accepts everything, for development setups without a scanner.
+type NoopScanner struct{}
+
+func (NoopScanner) Scan(context.Context, io.Reader) (ScanResult, error) {
+	return ScanResult{}, nil
+}
+
+type UploadScannerConfig struct {
+	// Timeout bounds a single scan. Defaults to DefaultScanTimeout.
+	Timeout time.Duration
+	// FailOpen accepts uploads when the scanner fails or times out, by
+	// default they are rejected with ErrScannerUnavailable.
+	FailOpen bool
+}
+
+// UploadScanner applies the upload policy around a Scanner.
+type UploadScanner struct {
+	scanner  Scanner
+	timeout  time.Duration
+	failOpen bool
+}
+
+func NewUploadScanner(scanner Scanner, cfg UploadScannerConfig) *UploadScanner {
+	if scanner == nil {
+		scanner = NoopScanner{}
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultScanTimeout
+	}
+
+	return &UploadScanner{
+		scanner:  scanner,
+		timeout:  cfg.Timeout,
+		failOpen: cfg.FailOpen,
+	}
+}
+
+// Check scans the content and returns ErrInfected when malware was found.
+// The result is returned alongside, it tells whether the scan was skipped.
+func (s *UploadScanner) Check(ctx context.Context, content io.Reader) (ScanResult, error) {
+	const op = "storagex.UploadScanner.Check"
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	res, err := s.scanner.Scan(ctx, content)
+	if err != nil {
+		if s.failOpen {
+			return ScanResult{Skipped: true}, nil
+		}
+		return res, errorx.NewServiceUnavailable().WithDetails(ErrScannerUnavailable.Details).WithCause(err, op)
+	}
+	if res.Infected {
+		return res, errorx.Wrap(ErrInfected, op)
+	}
+
+	return res, nil
+}
@@ -0,0 +1,65 @@
+package storagex
+
+import (
+	"context"
+	"sync"
+)
+
+// ReasonSelfTestFailed is the reason of the readiness of an instance whose
+// storage credentials can not write, read back and delete an object.
+const ReasonSelfTestFailed = "storage.self_test_failed"
+
+// SelfTester writes, reads back and deletes an object to verify the access
+// to the storage, see s3.Client.SelfTest.
+type SelfTester interface {
+	SelfTest(ctx context.Context) error
+}
+
+// SelfTestReadiness keeps the result of the last storage self-test, the
+// instance is not ready while it fails. The process keeps running so that
+// the access can be fixed without a restart, see Probe.
+type SelfTestReadiness struct {
+	tester SelfTester
+
+	mu  sync.Mutex
+	err error
+}
+
+func NewSelfTestReadiness(tester SelfTester) *SelfTestReadiness {
+	return &SelfTestReadiness{tester: tester}
+}
+
+// Check runs the self-test and keeps its result.
+func (r *SelfTestReadiness) Check(ctx context.Context) error {
+	err := r.tester.SelfTest(ctx)
+
+	r.mu.Lock()
+	r.err = err
+	r.mu.Unlock()
+	return err
+}
+
+// Probe runs the self-test again only while the last one failed, a passing
+// storage is not written to on every probe.
+func (r *SelfTestReadiness) Probe(ctx context.Context) error {
+	if err := r.Err(); err == nil {
+		return nil
+	}
+	return r.Check(ctx)
+}
+
+// Err returns the error of the last self-test, nil when it passed.
+func (r *SelfTestReadiness) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+// Ready reports whether the last self-test passed, with the reason when it
+// did not.
+func (r *SelfTestReadiness) Ready() (bool, string) {
+	if r.Err() != nil {
+		return false, ReasonSelfTestFailed
+	}
+	return true, ""
+}
@@ -0,0 +1,43 @@
+package storagex
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSelfTester fails with err, counting the self-tests.
+type fakeSelfTester struct {
+	err   error
+	tests int
+}
+
+func (t *fakeSelfTester) SelfTest(context.Context) error {
+	t.tests++
+	return t.err
+}
+
+func TestSelfTestReadiness(t *testing.T) {
+	tester := &fakeSelfTester{err: errors.New("access denied")}
+	r := NewSelfTestReadiness(tester)
+
+	require.Error(t, r.Check(t.Context()))
+	ready, reason := r.Ready()
+	assert.False(t, ready)
+	assert.Equal(t, ReasonSelfTestFailed, reason)
+
+	require.Error(t, r.Probe(t.Context()))
+	assert.Equal(t, 2, tester.tests, "a failed self-test is run again on the probe")
+
+	tester.err = nil
+	require.NoError(t, r.Probe(t.Context()))
+	ready, reason = r.Ready()
+	assert.True(t, ready, "the instance recovers once the access is fixed")
+	assert.Empty(t, reason)
+
+	require.NoError(t, r.Probe(t.Context()))
+	assert.Equal(t, 3, tester.tests, "a passing storage is not written to on every probe")
+}
@@ -0,0 +1,71 @@
+package storagex
+
+import (
+	"context"
+	"io"
+	"strings"
+	"time"
+	"unicode"
+
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+)
+
+const (
+	MaxKeyLength = 512
+
+	// TmpKeyPrefix holds short lived objects, e.g. presigned uploads that were
+	// not confirmed yet. Backends expire them after a day where supported.
+	TmpKeyPrefix = "tmp/"
+
+	// ArchiveKeyPrefix holds the archived database rows, see pkg/retention.
+	// They are private and only handed out as signed URLs.
+	ArchiveKeyPrefix = "archives/"
+)
+
+var (
+	ErrInvalidKey     = errorx.NewInvalidRequest().WithDetails("invalid object key")
+	ErrObjectNotFound = errorx.NewNotFound().WithDetails("object not found")
+)
+
+// ObjectInfo describes a stored object without its content.
+type ObjectInfo struct {
+	Key          string
+	ContentType  string
+	Size         int64
+	LastModified time.Time
+}
+
+// ValidateKey checks that the key is a relative, slash separated path that
+// cannot escape the storage root. Backends must call it before touching the key.
+func ValidateKey(key string) error {
+	const op = "storagex.ValidateKey"
+	if key == "" || len(key) > MaxKeyLength {
+		return errorx.Wrap(ErrInvalidKey, op)
+	}
+	if strings.HasPrefix(key, "/") || strings.HasSuffix(key, "/") || strings.Contains(key, `\`) {
+		return errorx.Wrap(ErrInvalidKey, op)
+	}
+	for _, r := range key {
+		if r == unicode.ReplacementChar || unicode.IsControl(r) {
+			return errorx.Wrap(ErrInvalidKey, op)
+		}
+	}
+	for segment := range strings.SplitSeq(key, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return errorx.Wrap(ErrInvalidKey, op)
+		}
+	}
+
+	return nil
+}
+
+// Storage is the object storage contract shared by the S3 and local
+// filesystem backends.
+type Storage interface {
+	UploadFile(ctx context.Context, key string, file io.Reader, contentType string) error
+	OpenObject(ctx context.Context, key string) (io.ReadCloser, ObjectInfo, error)
+	HeadObject(ctx context.Context, key string) (ObjectInfo, error)
+	DeleteFile(ctx context.Context, key string) error
+	URL(key string) string
+	ListObjects(ctx context.Context, prefix, token string, limit int) ([]ObjectInfo, string, error)
+}
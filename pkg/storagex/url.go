@@ -0,0 +1,196 @@
+package storagex
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+)
+
+// URLStrategy decides how object URLs handed out to clients are built.
+type URLStrategy string
+
+const (
+	// URLStrategyPublic joins the key to a public base URL, the bucket must allow anonymous reads.
+	URLStrategyPublic URLStrategy = "public"
+	// URLStrategySigned hands out presigned GET URLs of a private bucket.
+	URLStrategySigned URLStrategy = "signed"
+	// URLStrategyCDN rewrites the URL to the CDN, optionally signing it.
+	URLStrategyCDN URLStrategy = "cdn"
+)
+
+const (
+	DefaultSignedURLExpiry = 15 * time.Minute
+
+	// maxCachedURLs bounds the signed URL cache, it is cleared when exceeded.
+	maxCachedURLs = 10_000
+)
+
+func ParseURLStrategy(s string) (URLStrategy, error) {
+	switch strategy := URLStrategy(strings.ToLower(strings.TrimSpace(s))); strategy {
+	case URLStrategyPublic, URLStrategySigned, URLStrategyCDN:
+		return strategy, nil
+	case "":
+		return URLStrategyPublic, nil
+	default:
+		return "", fmt.Errorf("unknown url strategy %q, expected one of public, signed, cdn", s)
+	}
+}
+
+// Presigner creates time limited GET URLs for objects in a private bucket.
+type Presigner interface {
+	PresignGet(ctx context.Context, key string, expiry time.Duration) (string, error)
+}
+
+// URLSigner signs an already built URL, e.g. CloudFront signed URLs.
+type URLSigner interface {
+	SignURL(rawURL string, expiresAt time.Time) (string, error)
+}
+
+type URLBuilderConfig struct {
+	Strategy URLStrategy
+
+	// PublicBaseURL is used by the public strategy.
+	PublicBaseURL string
+
+	// Presigner is required by the signed strategy.
+	Presigner Presigner
+	// SignedURLExpiry is the lifetime of signed URLs, for both the signed and
+	// the signed cdn strategies. Defaults to DefaultSignedURLExpiry.
+	SignedURLExpiry time.Duration
+
+	// CDNBaseURL is used by the cdn strategy.
+	CDNBaseURL string
+	// CDNSigner is optional, when set cdn URLs are signed.
+	CDNSigner URLSigner
+
+	Now func() time.Time
+}
+
+// URLBuilder builds client facing URLs for object keys according to the
+// configured strategy. Signed URLs are cached until they get close to expiry,
+// so building a list response does not sign every key on every request.
+type URLBuilder struct {
+	strategy  URLStrategy
+	baseURL   string
+	presigner Presigner
+	signer    URLSigner
+	expiry    time.Duration
+	now       func() time.Time
+
+	mu    sync.Mutex
+	cache map[string]cachedURL
+}
+
+type cachedURL struct {
+	url       string
+	expiresAt time.Time
+}
+
+func NewURLBuilder(cfg URLBuilderConfig) (*URLBuilder, error) {
+	const op = "storagex.NewURLBuilder"
+	if cfg.Strategy == "" {
+		cfg.Strategy = URLStrategyPublic
+	}
+	if cfg.SignedURLExpiry <= 0 {
+		cfg.SignedURLExpiry = DefaultSignedURLExpiry
+	}
+	if cfg.Now == nil {
+		cfg.Now = time.Now
+	}
+
+	b := &URLBuilder{
+		strategy:  cfg.Strategy,
+		presigner: cfg.Presigner,
+		signer:    cfg.CDNSigner,
+		expiry:    cfg.SignedURLExpiry,
+		now:       cfg.Now,
+		cache:     make(map[string]cachedURL),
+	}
+
+	switch cfg.Strategy {
+	case URLStrategyPublic:
+		b.baseURL = strings.TrimRight(cfg.PublicBaseURL, "/")
+	case URLStrategySigned:
+		if cfg.Presigner == nil {
+			return nil, errorx.Wrap(errors.New("signed url strategy requires a presigner"), op)
+		}
+	case URLStrategyCDN:
+		if cfg.CDNBaseURL == "" {
+			return nil, errorx.Wrap(errors.New("cdn url strategy requires a cdn base url"), op)
+		}
+		if _, err := url.Parse(cfg.CDNBaseURL); err != nil {
+			return nil, errorx.Wrap(err, op)
+		}
+		b.baseURL = strings.TrimRight(cfg.CDNBaseURL, "/")
+	default:
+		return nil, errorx.Wrap(fmt.Errorf("unknown url strategy %q", cfg.Strategy), op)
+	}
+
+	return b, nil
+}
+
+func (b *URLBuilder) Strategy() URLStrategy {
+	return b.strategy
+}
+
+// URL returns the URL clients should use to fetch the object.
+func (b *URLBuilder) URL(ctx context.Context, key string) (string, error) {
+	const op = "storagex.URLBuilder.URL"
+	if key == "" {
+		return "", nil
+	}
+
+	switch {
+	case b.strategy == URLStrategySigned:
+		u, err := b.cached(key, func(time.Time) (string, error) {
+			return b.presigner.PresignGet(ctx, key, b.expiry)
+		})
+		return u, errorx.Wrap(err, op)
+	case b.strategy == URLStrategyCDN && b.signer != nil:
+		u, err := b.cached(key, func(expiresAt time.Time) (string, error) {
+			return b.signer.SignURL(b.join(key), expiresAt)
+		})
+		return u, errorx.Wrap(err, op)
+	default:
+		return b.join(key), nil
+	}
+}
+
+func (b *URLBuilder) join(key string) string {
+	return b.baseURL + "/" + key
+}
+
+// cached returns a cached signed URL unless it is within the last quarter of
+// its lifetime, then a fresh one is signed.
+func (b *URLBuilder) cached(key string, sign func(expiresAt time.Time) (string, error)) (string, error) {
+	now := b.now()
+	refreshAfter := b.expiry / 4
+
+	b.mu.Lock()
+	entry, ok := b.cache[key]
+	b.mu.Unlock()
+	if ok && entry.expiresAt.Sub(now) > refreshAfter {
+		return entry.url, nil
+	}
+
+	expiresAt := now.Add(b.expiry)
+	signed, err := sign(expiresAt)
+	if err != nil {
+		return "", err
+	}
+
+	b.mu.Lock()
+	if len(b.cache) >= maxCachedURLs {
+		b.cache = make(map[string]cachedURL)
+	}
+	b.cache[key] = cachedURL{url: signed, expiresAt: expiresAt}
+	b.mu.Unlock()
+
+	return signed, nil
+}
@@ -0,0 +1,178 @@
+package storagex
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakePresigner struct {
+	calls int
+}
+
+func (p *fakePresigner) PresignGet(_ context.Context, key string, expiry time.Duration) (string, error) {
+	p.calls++
+	return fmt.Sprintf("https://s3.local/bucket/%s?X-Amz-Expires=%d&sig=%d", key, int(expiry.Seconds()), p.calls), nil
+}
+
+type fakeClock struct{ now time.Time }
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func TestParseURLStrategy(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    URLStrategy
+		wantErr bool
+	}{
+		{in: "", want: URLStrategyPublic},
+		{in: "public", want: URLStrategyPublic},
+		{in: " Signed ", want: URLStrategySigned},
+		{in: "cdn", want: URLStrategyCDN},
+		{in: "cloudfront", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseURLStrategy(tt.in)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestURLBuilder_Public(t *testing.T) {
+	b, err := NewURLBuilder(URLBuilderConfig{
+		Strategy:      URLStrategyPublic,
+		PublicBaseURL: "http://localhost:9000/ucms-avatars/",
+	})
+	require.NoError(t, err)
+
+	got, err := b.URL(t.Context(), "avatars/abc")
+	require.NoError(t, err)
+	assert.Equal(t, "http://localhost:9000/ucms-avatars/avatars/abc", got)
+
+	got, err = b.URL(t.Context(), "")
+	require.NoError(t, err)
+	assert.Empty(t, got, "no key, no url")
+}
+
+func TestURLBuilder_SignedCachesUntilNearExpiry(t *testing.T) {
+	presigner := &fakePresigner{}
+	clock := &fakeClock{now: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+	b, err := NewURLBuilder(URLBuilderConfig{
+		Strategy:        URLStrategySigned,
+		Presigner:       presigner,
+		SignedURLExpiry: 20 * time.Minute,
+		Now:             clock.Now,
+	})
+	require.NoError(t, err)
+
+	first, err := b.URL(t.Context(), "avatars/abc")
+	require.NoError(t, err)
+	assert.Equal(t, "https://s3.local/bucket/avatars/abc?X-Amz-Expires=1200&sig=1", first)
+
+	clock.now = clock.now.Add(10 * time.Minute)
+	again, err := b.URL(t.Context(), "avatars/abc")
+	require.NoError(t, err)
+	assert.Equal(t, first, again, "url should be served from cache")
+	assert.Equal(t, 1, presigner.calls)
+
+	_, err = b.URL(t.Context(), "avatars/other")
+	require.NoError(t, err)
+	assert.Equal(t, 2, presigner.calls, "every key is signed separately")
+
+	// 4 minutes left out of 20, inside the refresh window.
+	clock.now = clock.now.Add(6 * time.Minute)
+	refreshed, err := b.URL(t.Context(), "avatars/abc")
+	require.NoError(t, err)
+	assert.NotEqual(t, first, refreshed)
+	assert.Equal(t, 3, presigner.calls)
+}
+
+func TestURLBuilder_CDN(t *testing.T) {
+	b, err := NewURLBuilder(URLBuilderConfig{
+		Strategy:   URLStrategyCDN,
+		CDNBaseURL: "https://cdn.example.com/",
+	})
+	require.NoError(t, err)
+
+	got, err := b.URL(t.Context(), "avatars/abc")
+	require.NoError(t, err)
+	assert.Equal(t, "https://cdn.example.com/avatars/abc", got)
+}
+
+func TestURLBuilder_SignedCDN(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	pemKey := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	signer, err := NewCloudFrontSigner("K2JCJMDEHXQW5F", pemKey)
+	require.NoError(t, err)
+
+	clock := &fakeClock{now: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+	b, err := NewURLBuilder(URLBuilderConfig{
+		Strategy:        URLStrategyCDN,
+		CDNBaseURL:      "https://cdn.example.com",
+		CDNSigner:       signer,
+		SignedURLExpiry: time.Hour,
+		Now:             clock.Now,
+	})
+	require.NoError(t, err)
+
+	got, err := b.URL(t.Context(), "avatars/abc")
+	require.NoError(t, err)
+
+	u, err := url.Parse(got)
+	require.NoError(t, err)
+	assert.Equal(t, "cdn.example.com", u.Host)
+	assert.Equal(t, "/avatars/abc", u.Path)
+
+	q := u.Query()
+	expires := clock.now.Add(time.Hour).Unix()
+	assert.Equal(t, strconv.FormatInt(expires, 10), q.Get("Expires"))
+	assert.Equal(t, "K2JCJMDEHXQW5F", q.Get("Key-Pair-Id"))
+
+	sig, err := base64.StdEncoding.DecodeString(
+		strings.NewReplacer("-", "+", "_", "=", "~", "/").Replace(q.Get("Signature")),
+	)
+	require.NoError(t, err)
+	policy := fmt.Sprintf(
+		`{"Statement":[{"Resource":"https://cdn.example.com/avatars/abc","Condition":{"DateLessThan":{"AWS:EpochTime":%d}}}]}`,
+		expires,
+	)
+	digest := sha1.Sum([]byte(policy))
+	assert.NoError(t, rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA1, digest[:], sig), "signature should verify")
+
+	cached, err := b.URL(t.Context(), "avatars/abc")
+	require.NoError(t, err)
+	assert.Equal(t, got, cached)
+}
+
+func TestNewURLBuilder_Misconfigured(t *testing.T) {
+	_, err := NewURLBuilder(URLBuilderConfig{Strategy: URLStrategySigned})
+	assert.Error(t, err, "signed requires a presigner")
+
+	_, err = NewURLBuilder(URLBuilderConfig{Strategy: URLStrategyCDN})
+	assert.Error(t, err, "cdn requires a base url")
+
+	_, err = NewURLBuilder(URLBuilderConfig{Strategy: "ftp"})
+	assert.Error(t, err)
+}
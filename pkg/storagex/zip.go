@@ -0,0 +1,227 @@
+package storagex
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+)
+
+const (
+	// ZIPManifestName is the archive entry describing what the archive contains.
+	ZIPManifestName = "manifest.json"
+
+	DefaultZIPPrefetch = 4
+)
+
+// ObjectOpener is the read side of Storage needed to stream objects.
+type ObjectOpener interface {
+	OpenObject(ctx context.Context, key string) (io.ReadCloser, ObjectInfo, error)
+}
+
+// ZIPFile is a small in-memory archive entry, e.g. a JSON document.
+type ZIPFile struct {
+	Name    string
+	Content []byte
+}
+
+type ZIPOptions struct {
+	// Prefetch is how many objects are opened ahead of the one being
+	// written. Defaults to DefaultZIPPrefetch.
+	Prefetch int
+	// Files are written before the objects.
+	Files []ZIPFile
+	// EntryName maps an object key to its path inside the archive, defaults
+	// to the key itself.
+	EntryName func(key string) string
+	Now       func() time.Time
+}
+
+// ZIPManifest is written as the last archive entry. Missing lists the keys
+// that did not exist in the storage and were skipped.
+type ZIPManifest struct {
+	CreatedAt time.Time          `json:"created_at"`
+	Files     []ZIPManifestEntry `json:"files"`
+	Missing   []string           `json:"missing"`
+}
+
+type ZIPManifestEntry struct {
+	Name        string `json:"name"`
+	Key         string `json:"key,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+	Size        int64  `json:"size"`
+}
+
+type openedObject struct {
+	body io.ReadCloser
+	info ObjectInfo
+	err  error
+}
+
+// WriteZIP streams the objects behind keys into a ZIP archive written to w.
+//
+// Objects are never buffered: up to Prefetch objects are opened ahead so the
+// next body is ready once the current one is copied, but their content is
+// read only while it is written. Missing objects are skipped and recorded in
+// the manifest, any other error aborts the archive, as does cancelling ctx.
+// On error w holds a truncated archive.
+func WriteZIP(ctx context.Context, w io.Writer, objects ObjectOpener, keys []string, opts ZIPOptions) (*ZIPManifest, error) {
+	const op = "storagex.WriteZIP"
+	if opts.Prefetch <= 0 {
+		opts.Prefetch = DefaultZIPPrefetch
+	}
+	if opts.EntryName == nil {
+		opts.EntryName = func(key string) string { return key }
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	results := make([]chan openedObject, len(keys))
+	for i := range results {
+		results[i] = make(chan openedObject, 1)
+	}
+	var wg sync.WaitGroup
+	next := 0
+	defer func() {
+		// Stop prefetching and close whatever was opened but not written.
+		cancel()
+		wg.Wait()
+		for _, ch := range results[next:] {
+			select {
+			case res := <-ch:
+				if res.body != nil {
+					_ = res.body.Close()
+				}
+			default:
+			}
+		}
+	}()
+
+	slots := make(chan struct{}, opts.Prefetch)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i, key := range keys {
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				body, info, err := objects.OpenObject(ctx, key)
+				results[i] <- openedObject{body: body, info: info, err: err}
+			}()
+		}
+	}()
+
+	manifest := &ZIPManifest{
+		CreatedAt: opts.Now().UTC(),
+		Files:     make([]ZIPManifestEntry, 0, len(opts.Files)+len(keys)),
+		Missing:   []string{},
+	}
+	zw := zip.NewWriter(w)
+	buf := make([]byte, 32<<10)
+
+	for _, f := range opts.Files {
+		if err := writeZIPEntry(ctx, zw, &zip.FileHeader{
+			Name:     f.Name,
+			Method:   zip.Deflate,
+			Modified: manifest.CreatedAt,
+		}, bytes.NewReader(f.Content), buf); err != nil {
+			return nil, errorx.Wrap(err, op)
+		}
+		manifest.Files = append(manifest.Files, ZIPManifestEntry{Name: f.Name, Size: int64(len(f.Content))})
+	}
+
+	for ; next < len(keys); next++ {
+		var res openedObject
+		select {
+		case res = <-results[next]:
+		case <-ctx.Done():
+			return nil, errorx.Wrap(ctx.Err(), op)
+		}
+
+		key := keys[next]
+		if res.err != nil {
+			<-slots
+			if errorx.IsNotFound(res.err) {
+				manifest.Missing = append(manifest.Missing, key)
+				continue
+			}
+			return nil, errorx.Wrap(fmt.Errorf("open %q: %w", key, res.err), op)
+		}
+
+		name := opts.EntryName(key)
+		// Stored objects are mostly media that is already compressed.
+		err := writeZIPEntry(ctx, zw, &zip.FileHeader{
+			Name:     name,
+			Method:   zip.Store,
+			Modified: res.info.LastModified,
+		}, res.body, buf)
+		_ = res.body.Close()
+		<-slots
+		if err != nil {
+			return nil, errorx.Wrap(fmt.Errorf("write %q: %w", key, err), op)
+		}
+		manifest.Files = append(manifest.Files, ZIPManifestEntry{
+			Name:        name,
+			Key:         key,
+			ContentType: res.info.ContentType,
+			Size:        res.info.Size,
+		})
+	}
+
+	content, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, errorx.Wrap(err, op)
+	}
+	if err := writeZIPEntry(ctx, zw, &zip.FileHeader{
+		Name:     ZIPManifestName,
+		Method:   zip.Deflate,
+		Modified: manifest.CreatedAt,
+	}, bytes.NewReader(content), buf); err != nil {
+		return nil, errorx.Wrap(err, op)
+	}
+
+	if err := zw.Close(); err != nil {
+		return nil, errorx.Wrap(err, op)
+	}
+
+	return manifest, nil
+}
+
+func writeZIPEntry(ctx context.Context, zw *zip.Writer, header *zip.FileHeader, r io.Reader, buf []byte) error {
+	if header.Modified.IsZero() {
+		header.Modified = time.Now()
+	}
+	entry, err := zw.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+	_, err = io.CopyBuffer(entry, &ctxReader{ctx: ctx, r: r}, buf)
+	return err
+}
+
+// ctxReader stops a copy once the context is done, for bodies that are not
+// bound to it, e.g. local files.
+type ctxReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r *ctxReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
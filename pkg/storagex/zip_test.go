@@ -0,0 +1,203 @@
+package storagex
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+)
+
+type memoryObjects struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	open    int
+	maxOpen int
+}
+
+func (m *memoryObjects) OpenObject(_ context.Context, key string) (io.ReadCloser, ObjectInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	content, ok := m.objects[key]
+	if !ok {
+		return nil, ObjectInfo{}, errorx.Wrap(ErrObjectNotFound, "memoryObjects.OpenObject")
+	}
+	m.open++
+	m.maxOpen = max(m.maxOpen, m.open)
+	return &trackedBody{Reader: bytes.NewReader(content), m: m}, ObjectInfo{
+		Key:          key,
+		ContentType:  "text/plain",
+		Size:         int64(len(content)),
+		LastModified: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+	}, nil
+}
+
+type trackedBody struct {
+	io.Reader
+	m *memoryObjects
+}
+
+func (b *trackedBody) Close() error {
+	b.m.mu.Lock()
+	b.m.open--
+	b.m.mu.Unlock()
+	return nil
+}
+
+func TestWriteZIP_StreamsOverHTTP(t *testing.T) {
+	objects := &memoryObjects{objects: make(map[string][]byte)}
+	keys := make([]string, 0, 51)
+	for i := range 50 {
+		key := fmt.Sprintf("photos/%02d.txt", i)
+		objects.objects[key] = []byte(fmt.Sprintf("object %d", i))
+		keys = append(keys, key)
+	}
+	keys = append(keys[:25], append([]string{"photos/missing.txt"}, keys[25:]...)...)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/zip")
+		_, err := WriteZIP(r.Context(), w, objects, keys, ZIPOptions{
+			Prefetch: 3,
+			Files:    []ZIPFile{{Name: "profile.json", Content: []byte(`{"id":"1"}`)}},
+		})
+		assert.NoError(t, err)
+	}))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	zr, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+	require.NoError(t, err)
+	require.Len(t, zr.File, 52, "profile, 50 objects and the manifest")
+	assert.Equal(t, "profile.json", zr.File[0].Name)
+	assert.Equal(t, ZIPManifestName, zr.File[51].Name)
+
+	for i, f := range zr.File[1:51] {
+		assert.Equal(t, fmt.Sprintf("photos/%02d.txt", i), f.Name, "objects keep the order of the keys")
+		assert.Equal(t, objects.objects[f.Name], readZIPFile(t, f))
+	}
+
+	var manifest ZIPManifest
+	require.NoError(t, json.Unmarshal(readZIPFile(t, zr.File[51]), &manifest))
+	assert.Equal(t, []string{"photos/missing.txt"}, manifest.Missing)
+	require.Len(t, manifest.Files, 51)
+	assert.Equal(t, "photos/00.txt", manifest.Files[1].Key)
+	assert.Equal(t, int64(len("object 0")), manifest.Files[1].Size)
+
+	assert.Zero(t, objects.open, "every body should be closed")
+	assert.LessOrEqual(t, objects.maxOpen, 3, "prefetch should be bounded")
+}
+
+func TestWriteZIP_EntryName(t *testing.T) {
+	objects := &memoryObjects{objects: map[string][]byte{"avatars/abc": []byte("jpeg")}}
+
+	var buf bytes.Buffer
+	_, err := WriteZIP(t.Context(), &buf, objects, []string{"avatars/abc"}, ZIPOptions{
+		EntryName: func(key string) string { return "avatar/" + key[len("avatars/"):] + ".jpg" },
+	})
+	require.NoError(t, err)
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	require.Len(t, zr.File, 2)
+	assert.Equal(t, "avatar/abc.jpg", zr.File[0].Name)
+}
+
+type failingObjects struct{}
+
+func (failingObjects) OpenObject(context.Context, string) (io.ReadCloser, ObjectInfo, error) {
+	return nil, ObjectInfo{}, fmt.Errorf("connection reset")
+}
+
+func TestWriteZIP_OpenErrorAborts(t *testing.T) {
+	_, err := WriteZIP(t.Context(), io.Discard, failingObjects{}, []string{"a", "b"}, ZIPOptions{})
+	assert.ErrorContains(t, err, "connection reset")
+}
+
+func TestWriteZIP_ContextCancelled(t *testing.T) {
+	objects := &memoryObjects{objects: map[string][]byte{"a": []byte("a"), "b": []byte("b")}}
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	_, err := WriteZIP(ctx, io.Discard, objects, []string{"a", "b"}, ZIPOptions{})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Zero(t, objects.open, "prefetched bodies should be closed")
+}
+
+func readZIPFile(t *testing.T, f *zip.File) []byte {
+	t.Helper()
+	rc, err := f.Open()
+	require.NoError(t, err)
+	defer rc.Close()
+	content, err := io.ReadAll(rc)
+	require.NoError(t, err)
+	return content
+}
+
+// generatedObjects produces objects of the given size on the fly, without
+// holding their content in memory.
+type generatedObjects struct {
+	size int64
+}
+
+func (g generatedObjects) OpenObject(_ context.Context, key string) (io.ReadCloser, ObjectInfo, error) {
+	return io.NopCloser(io.LimitReader(patternReader{}, g.size)), ObjectInfo{Key: key, Size: g.size}, nil
+}
+
+type patternReader struct{}
+
+func (patternReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = byte(i)
+	}
+	return len(p), nil
+}
+
+// BenchmarkWriteZIP_500MB streams 500 objects of 1 MiB and fails when the
+// allocated memory grows with the archive instead of staying flat.
+func BenchmarkWriteZIP_500MB(b *testing.B) {
+	const (
+		objectSize = 1 << 20
+		objectsN   = 500
+		maxAlloc   = 8 << 20
+	)
+	keys := make([]string, objectsN)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("objects/%03d", i)
+	}
+	objects := generatedObjects{size: objectSize}
+
+	b.SetBytes(objectSize * objectsN)
+	b.ReportAllocs()
+	for b.Loop() {
+		var before, after runtime.MemStats
+		runtime.ReadMemStats(&before)
+
+		if _, err := WriteZIP(b.Context(), io.Discard, objects, keys, ZIPOptions{}); err != nil {
+			b.Fatal(err)
+		}
+
+		runtime.ReadMemStats(&after)
+		allocated := after.TotalAlloc - before.TotalAlloc
+		b.ReportMetric(float64(allocated)/(1<<20), "MiB-allocated/op")
+		if allocated > maxAlloc {
+			b.Fatalf("allocated %d MiB while streaming, memory should stay flat", allocated>>20)
+		}
+	}
+}
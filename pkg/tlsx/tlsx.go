@@ -0,0 +1,112 @@
+// Package tlsx terminates TLS in the server itself, for the deployments
+// without a reverse proxy in front of it.
+package tlsx
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync/atomic"
+
+	"go.opentelemetry.io/contrib/bridges/otelslog"
+)
+
+var logger = otelslog.NewLogger("ucms/pkg/tlsx")
+
+// ServerConfig returns the TLS configuration of the server: TLS 1.2 or later
+// with forward secret AEAD ciphers, and HTTP/2 offered before HTTP/1.1. The
+// certificate is served by getCertificate, see Reloader.GetCertificate.
+func ServerConfig(getCertificate func(*tls.ClientHelloInfo) (*tls.Certificate, error)) *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		// The TLS 1.3 suites are not configurable, these apply to TLS 1.2.
+		CipherSuites: []uint16{
+			tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+			tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+			tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256,
+			tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256,
+		},
+		CurvePreferences: []tls.CurveID{tls.X25519, tls.CurveP256, tls.CurveP384},
+		NextProtos:       []string{"h2", "http/1.1"},
+		GetCertificate:   getCertificate,
+	}
+}
+
+// Reloader serves the certificate of a cert and key file pair and loads it
+// again on Reload, so that a renewed certificate is used without a restart.
+type Reloader struct {
+	certFile string
+	keyFile  string
+	logger   *slog.Logger
+	cert     atomic.Pointer[tls.Certificate]
+}
+
+// NewReloader loads the pair, it fails when the files are missing or do not
+// match.
+func NewReloader(certFile, keyFile string) (*Reloader, error) {
+	r := &Reloader{
+		certFile: certFile,
+		keyFile:  keyFile,
+		logger:   logger,
+	}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Reload loads the pair again. On error the previous certificate is kept.
+func (r *Reloader) Reload() error {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("load tls key pair %s, %s: %w", r.certFile, r.keyFile, err)
+	}
+	r.cert.Store(&cert)
+	return nil
+}
+
+// GetCertificate serves the last loaded certificate, see tls.Config.
+func (r *Reloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return r.cert.Load(), nil
+}
+
+// ReloadOn reloads the pair every time a signal is received on signals,
+// usually SIGHUP, until ctx is done. The failures are logged.
+func (r *Reloader) ReloadOn(ctx context.Context, signals <-chan os.Signal) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-signals:
+			if err := r.Reload(); err != nil {
+				r.logger.ErrorContext(ctx, "Failed to reload the TLS certificate, keeping the previous one", "error", err)
+				continue
+			}
+			r.logger.InfoContext(ctx, "TLS certificate reloaded", "cert_file", r.certFile)
+		}
+	}
+}
+
+// RedirectHandler answers every request with a permanent redirect to the same
+// URL over https on httpsPort.
+func RedirectHandler(httpsPort string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		} else {
+			host = strings.Trim(host, "[]")
+		}
+		if httpsPort != "" && httpsPort != "443" {
+			host = net.JoinHostPort(host, httpsPort)
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusMovedPermanently)
+	})
+}
@@ -0,0 +1,181 @@
+package tlsx
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeSelfSigned writes a self-signed pair for localhost to dir and returns
+// the certificate.
+func writeSelfSigned(t *testing.T, dir, commonName string) *x509.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "cert.pem"), certPEM, 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "key.pem"), keyPEM, 0o600))
+
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return cert
+}
+
+// serveTLS runs a server with the configuration of ServerConfig on a free
+// port and returns its address.
+func serveTLS(t *testing.T, reloader *Reloader) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	srv := &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(r.Proto))
+		}),
+		TLSConfig: ServerConfig(reloader.GetCertificate),
+	}
+	go func() {
+		if err := srv.ServeTLS(ln, "", ""); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			t.Errorf("serve tls: %v", err)
+		}
+	}()
+	t.Cleanup(func() { _ = srv.Close() })
+	return ln.Addr().String()
+}
+
+func trustingClient(certs ...*x509.Certificate) *http.Client {
+	pool := x509.NewCertPool()
+	for _, cert := range certs {
+		pool.AddCert(cert)
+	}
+	return &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig:   &tls.Config{RootCAs: pool},
+			ForceAttemptHTTP2: true,
+		},
+	}
+}
+
+func TestServer_HTTPS(t *testing.T) {
+	dir := t.TempDir()
+	cert := writeSelfSigned(t, dir, "first")
+	reloader, err := NewReloader(filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem"))
+	require.NoError(t, err)
+	addr := serveTLS(t, reloader)
+
+	resp, err := trustingClient(cert).Get("https://" + addr + "/")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, 2, resp.ProtoMajor, "HTTP/2 is negotiated")
+	require.NotNil(t, resp.TLS)
+	assert.GreaterOrEqual(t, resp.TLS.Version, uint16(tls.VersionTLS12))
+
+	t.Run("old protocol versions are refused", func(t *testing.T) {
+		client := trustingClient(cert)
+		client.Transport.(*http.Transport).TLSClientConfig.MaxVersion = tls.VersionTLS11
+		_, err := client.Get("https://" + addr + "/")
+		assert.Error(t, err)
+	})
+}
+
+func TestReloader_Reload(t *testing.T) {
+	dir := t.TempDir()
+	first := writeSelfSigned(t, dir, "first")
+	reloader, err := NewReloader(filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem"))
+	require.NoError(t, err)
+	addr := serveTLS(t, reloader)
+
+	second := writeSelfSigned(t, dir, "second")
+	require.NoError(t, reloader.Reload())
+
+	client := trustingClient(first, second)
+	resp, err := client.Get("https://" + addr + "/")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, "second", resp.TLS.PeerCertificates[0].Subject.CommonName)
+
+	t.Run("a broken pair keeps the previous certificate", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "key.pem"), []byte("broken"), 0o600))
+		assert.Error(t, reloader.Reload())
+
+		got, err := reloader.GetCertificate(nil)
+		require.NoError(t, err)
+		assert.Equal(t, second.Raw, got.Certificate[0])
+	})
+
+	t.Run("missing files", func(t *testing.T) {
+		_, err := NewReloader(filepath.Join(dir, "missing.pem"), filepath.Join(dir, "key.pem"))
+		assert.ErrorContains(t, err, "load tls key pair")
+	})
+}
+
+func TestRedirectHandler(t *testing.T) {
+	srv := httptest.NewServer(RedirectHandler("8443"))
+	defer srv.Close()
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+
+	resp, err := client.Get(srv.URL + "/v1/version?verbose=1")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusMovedPermanently, resp.StatusCode)
+	assert.Equal(t, "https://127.0.0.1:8443/v1/version?verbose=1", resp.Header.Get("Location"))
+
+	tests := []struct {
+		name      string
+		httpsPort string
+		host      string
+		want      string
+	}{
+		{name: "default port", httpsPort: "443", host: "ucms.kz", want: "https://ucms.kz/path"},
+		{name: "host with port", httpsPort: "8443", host: "ucms.kz:8080", want: "https://ucms.kz:8443/path"},
+		{name: "ipv6", httpsPort: "8443", host: "[::1]", want: "https://[::1]:8443/path"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "http://"+tt.host+"/path", nil)
+			rec := httptest.NewRecorder()
+			RedirectHandler(tt.httpsPort).ServeHTTP(rec, req)
+
+			assert.Equal(t, http.StatusMovedPermanently, rec.Code)
+			assert.Equal(t, tt.want, rec.Header().Get("Location"))
+		})
+	}
+}
@@ -0,0 +1,112 @@
+// Package urlx holds the absolute URLs of the configuration that links are
+// built from, e.g. the page an invitation email points to.
+package urlx
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// URL is an absolute http or https URL without a trailing slash. It is
+// parsed once at startup, the links are built from it with WithPath and
+// WithQuery, which escape what they add. The zero URL is unset.
+type URL struct {
+	u *url.URL
+}
+
+// Parse checks that raw is an absolute http or https URL without a
+// fragment and drops the trailing slashes of its path.
+func Parse(raw string) (URL, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return URL{}, errors.New("url is empty")
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return URL{}, fmt.Errorf("invalid url %q: %w", raw, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return URL{}, fmt.Errorf("invalid url %q: expected an absolute http or https url", raw)
+	}
+	if u.Host == "" {
+		return URL{}, fmt.Errorf("invalid url %q: host is empty", raw)
+	}
+	if u.Fragment != "" {
+		return URL{}, fmt.Errorf("invalid url %q: links can not be built on a fragment", raw)
+	}
+
+	u.Path = strings.TrimRight(u.Path, "/")
+	u.RawPath = strings.TrimRight(u.RawPath, "/")
+	return URL{u: u}, nil
+}
+
+// MustParse is Parse for the URLs known to be valid, e.g. the defaults.
+func MustParse(raw string) URL {
+	u, err := Parse(raw)
+	if err != nil {
+		panic(err)
+	}
+	return u
+}
+
+// IsZero reports whether the URL is unset.
+func (u URL) IsZero() bool {
+	return u.u == nil
+}
+
+// WithPath returns the URL with the segments appended to its path, each one
+// escaped, so that a segment can not add a path of its own.
+func (u URL) WithPath(segments ...string) URL {
+	c := u.clone()
+	rawPath := c.EscapedPath()
+	for _, segment := range segments {
+		c.Path += "/" + segment
+		rawPath += "/" + url.PathEscape(segment)
+	}
+	c.RawPath = rawPath
+	return URL{u: c}
+}
+
+// WithQuery returns the URL with the query parameter key set to value.
+func (u URL) WithQuery(key, value string) URL {
+	c := u.clone()
+	q := c.Query()
+	q.Set(key, value)
+	c.RawQuery = q.Encode()
+	return URL{u: c}
+}
+
+func (u URL) String() string {
+	if u.u == nil {
+		return ""
+	}
+	return u.u.String()
+}
+
+func (u URL) MarshalText() ([]byte, error) {
+	return []byte(u.String()), nil
+}
+
+// UnmarshalText parses the URL of a config file or a variable, see Parse.
+func (u *URL) UnmarshalText(text []byte) error {
+	parsed, err := Parse(string(text))
+	if err != nil {
+		return err
+	}
+	*u = parsed
+	return nil
+}
+
+func (u URL) clone() *url.URL {
+	if u.u == nil {
+		panic("urlx: building a link on an unset url")
+	}
+	c := *u.u
+	if u.u.User != nil {
+		user := *u.u.User
+		c.User = &user
+	}
+	return &c
+}
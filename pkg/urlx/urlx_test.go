@@ -0,0 +1,103 @@
+package urlx
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		want    string
+		wantErr string
+	}{
+		{name: "valid", raw: "https://ucms.kz/invitations/accept", want: "https://ucms.kz/invitations/accept"},
+		{name: "trailing slash", raw: "https://ucms.kz/invitations/accept/", want: "https://ucms.kz/invitations/accept"},
+		{name: "trailing slashes", raw: "http://localhost:3000/invitations//", want: "http://localhost:3000/invitations"},
+		{name: "root", raw: "https://ucms.kz/", want: "https://ucms.kz"},
+		{name: "query is kept", raw: "https://ucms.kz/accept?lang=ru", want: "https://ucms.kz/accept?lang=ru"},
+		{name: "surrounding whitespace", raw: " https://ucms.kz/accept ", want: "https://ucms.kz/accept"},
+		{name: "empty", raw: "", wantErr: "url is empty"},
+		{name: "relative", raw: "/invitations/accept", wantErr: "expected an absolute http or https url"},
+		{name: "no scheme", raw: "ucms.kz/invitations", wantErr: "expected an absolute http or https url"},
+		{name: "other scheme", raw: "ftp://ucms.kz/invitations", wantErr: "expected an absolute http or https url"},
+		{name: "javascript", raw: "javascript:alert(1)", wantErr: "expected an absolute http or https url"},
+		{name: "no host", raw: "https:///invitations", wantErr: "host is empty"},
+		{name: "fragment", raw: "https://ucms.kz/#/invitations", wantErr: "fragment"},
+		{name: "unparsable", raw: "https://ucms.kz/%zz", wantErr: "invalid url"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := Parse(tt.raw)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				assert.True(t, u.IsZero())
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, u.String())
+		})
+	}
+}
+
+func TestURL_Links(t *testing.T) {
+	base := MustParse("https://ucms.kz/invitations/accept/")
+
+	tests := []struct {
+		name string
+		got  URL
+		want string
+	}{
+		{name: "path", got: base.WithPath("ABC123"), want: "https://ucms.kz/invitations/accept/ABC123"},
+		{name: "path is escaped", got: base.WithPath("a/b c?d"), want: "https://ucms.kz/invitations/accept/a%2Fb%20c%3Fd"},
+		{name: "segments", got: base.WithPath("staff", "ABC123"), want: "https://ucms.kz/invitations/accept/staff/ABC123"},
+		{
+			name: "email query",
+			got:  base.WithPath("ABC123").WithQuery("email", "first.last+tag@test.com"),
+			want: "https://ucms.kz/invitations/accept/ABC123?email=first.last%2Btag%40test.com",
+		},
+		{
+			name: "query with special characters",
+			got:  base.WithQuery("email", "a&b=c#d@test.com"),
+			want: "https://ucms.kz/invitations/accept?email=a%26b%3Dc%23d%40test.com",
+		},
+		{
+			name: "query replaces the value",
+			got:  MustParse("https://ucms.kz/accept?lang=ru&token=old").WithQuery("token", "new"),
+			want: "https://ucms.kz/accept?lang=ru&token=new",
+		},
+		{name: "root", got: MustParse("https://ucms.kz/").WithPath("ABC123"), want: "https://ucms.kz/ABC123"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.got.String())
+		})
+	}
+
+	assert.Equal(t, "https://ucms.kz/invitations/accept", base.String(), "the builders do not change the base")
+}
+
+func TestURL_UnmarshalText(t *testing.T) {
+	var u URL
+	require.NoError(t, u.UnmarshalText([]byte("https://ucms.kz/accept/")))
+	assert.Equal(t, "https://ucms.kz/accept", u.String())
+
+	assert.Error(t, u.UnmarshalText([]byte("not a url")))
+	assert.Equal(t, "https://ucms.kz/accept", u.String(), "an invalid value keeps the url")
+
+	text, err := u.MarshalText()
+	require.NoError(t, err)
+	assert.Equal(t, "https://ucms.kz/accept", string(text))
+}
+
+func TestURL_Zero(t *testing.T) {
+	var u URL
+	assert.True(t, u.IsZero())
+	assert.Equal(t, "", u.String())
+	assert.Panics(t, func() { u.WithPath("code") })
+}
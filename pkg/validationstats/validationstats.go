@@ -0,0 +1,210 @@
+// Package validationstats counts the request fields failing validation, by
+// route and by day, so the forms users struggle with can be told apart and
+// their hints improved.
+//
+// Only the names of the declared request fields are recorded, never the
+// submitted values. Failures are counted in memory and written in batches,
+// like the error inbox, a burst of bad requests costs one upsert per field
+// per flush interval.
+package validationstats
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/contrib/bridges/otelslog"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx/metrics"
+)
+
+var logger = otelslog.NewLogger("ucms/pkg/validationstats")
+
+const (
+	DefaultFlushInterval = 5 * time.Second
+	// DefaultMaxPending bounds the route and field pairs buffered between two
+	// flushes, failures of new pairs past it are dropped.
+	DefaultMaxPending = 500
+
+	flushTimeout = 10 * time.Second
+	// maxFieldLen drops the field names no request declares, e.g. the keys
+	// of a validated map.
+	maxFieldLen = 64
+)
+
+// Failure is the number of failures of a field of a route on a day.
+type Failure struct {
+	// Day is the UTC midnight of the day.
+	Day   time.Time
+	Route string
+	Field string
+	Count int64
+}
+
+// Store persists the failures, Count is added to the stored count.
+type Store interface {
+	AddValidationFailures(ctx context.Context, failures []Failure) error
+}
+
+// StatsParams filters the aggregated failures.
+type StatsParams struct {
+	// Route is the method and the route pattern, e.g.
+	// POST /v1/registrations/students/complete, empty for all routes.
+	Route string
+	// Since is the first day counted.
+	Since time.Time
+	Limit int
+}
+
+// FieldStat is the number of failures of a field of a route since
+// StatsParams.Since.
+type FieldStat struct {
+	Route string
+	Field string
+	Count int64
+}
+
+type failureKey struct {
+	day   time.Time
+	route string
+	field string
+}
+
+// Recorder counts the failures in the ucms.validation.failure metric and
+// buffers them for the Store, recording never blocks on the database.
+type Recorder struct {
+	store         Store
+	logger        *slog.Logger
+	flushInterval time.Duration
+	maxPending    int
+	clock         clock.Clock
+	failures      metric.Int64Counter
+
+	mu      sync.Mutex
+	pending map[failureKey]int64
+	dropped int
+}
+
+type RecorderArgs struct {
+	Store         Store
+	Logger        *slog.Logger
+	FlushInterval time.Duration
+	MaxPending    int
+	// Clock decides the day of the failures, defaults to clock.Real.
+	Clock clock.Clock
+	// Metrics defaults to metrics.Default.
+	Metrics *metrics.Registry
+}
+
+// NewRecorder creates a Recorder, Run must be started to write the failures.
+//
+//	WARNING; panics if store is nil
+func NewRecorder(args RecorderArgs) *Recorder {
+	if args.Store == nil {
+		panic("store is required")
+	}
+	if args.Logger == nil {
+		args.Logger = logger
+	}
+	if args.FlushInterval <= 0 {
+		args.FlushInterval = DefaultFlushInterval
+	}
+	if args.MaxPending <= 0 {
+		args.MaxPending = DefaultMaxPending
+	}
+	if args.Metrics == nil {
+		args.Metrics = metrics.Default()
+	}
+
+	return &Recorder{
+		store:         args.Store,
+		logger:        args.Logger,
+		flushInterval: args.FlushInterval,
+		maxPending:    args.MaxPending,
+		clock:         clock.Or(args.Clock),
+		failures: args.Metrics.Int64Counter(metrics.ValidationFailures,
+			metric.WithDescription("Number of request fields failing validation"),
+			metric.WithUnit("{failure}"),
+		),
+		pending: make(map[failureKey]int64),
+	}
+}
+
+// RecordValidationFailure records a request of route rejected because of
+// fields. The fields are the names of the declared request fields.
+func (r *Recorder) RecordValidationFailure(ctx context.Context, route string, fields []string) {
+	day := r.clock.Now().UTC().Truncate(24 * time.Hour)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, field := range fields {
+		if field == "" || len(field) > maxFieldLen {
+			continue
+		}
+		r.failures.Add(ctx, 1, metric.WithAttributes(
+			attribute.String(metrics.AttrRoute, route),
+			attribute.String(metrics.AttrField, field),
+		))
+
+		key := failureKey{day: day, route: route, field: field}
+		if _, ok := r.pending[key]; !ok && len(r.pending) >= r.maxPending {
+			r.dropped++
+			continue
+		}
+		r.pending[key]++
+	}
+}
+
+// Run flushes the buffered failures every flush interval until ctx is done,
+// then flushes one last time.
+func (r *Recorder) Run(ctx context.Context) {
+	timer := r.clock.NewTimer(r.flushInterval)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-timer.C():
+			r.flushLogged(ctx)
+			timer.Reset(r.flushInterval)
+		case <-ctx.Done():
+			r.flushLogged(context.WithoutCancel(ctx))
+			return
+		}
+	}
+}
+
+func (r *Recorder) flushLogged(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, flushTimeout)
+	defer cancel()
+
+	if err := r.Flush(ctx); err != nil {
+		r.logger.ErrorContext(ctx, "failed to flush validation failures", slog.String("error", err.Error()))
+	}
+}
+
+// Flush writes the buffered failures. They are dropped if the write fails,
+// so a database outage does not grow the buffer.
+func (r *Recorder) Flush(ctx context.Context) error {
+	r.mu.Lock()
+	pending, dropped := r.pending, r.dropped
+	r.pending, r.dropped = make(map[failureKey]int64, len(pending)), 0
+	r.mu.Unlock()
+
+	if dropped > 0 {
+		r.logger.WarnContext(ctx, "validation failures buffer is full, failures were not recorded", slog.Int("dropped", dropped))
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	failures := make([]Failure, 0, len(pending))
+	for k, count := range pending {
+		failures = append(failures, Failure{Day: k.day, Route: k.route, Field: k.field, Count: count})
+	}
+	return r.store.AddValidationFailures(ctx, failures)
+}
@@ -0,0 +1,113 @@
+package validationstats
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/metric/noop"
+
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/otelx/metrics"
+)
+
+type fakeStore struct {
+	mu       sync.Mutex
+	failures []Failure
+	err      error
+}
+
+func (s *fakeStore) AddValidationFailures(_ context.Context, failures []Failure) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return s.err
+	}
+	s.failures = append(s.failures, failures...)
+	return nil
+}
+
+func (s *fakeStore) counts() map[string]int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m := make(map[string]int64, len(s.failures))
+	for _, f := range s.failures {
+		m[f.Day.Format(time.DateOnly)+" "+f.Route+" "+f.Field] += f.Count
+	}
+	return m
+}
+
+const route = "POST /v1/registrations/students/complete"
+
+func newTestRecorder(store Store, clk clock.Clock) *Recorder {
+	return NewRecorder(RecorderArgs{
+		Store:   store,
+		Clock:   clk,
+		Metrics: metrics.NewRegistry(noop.NewMeterProvider().Meter("test")),
+	})
+}
+
+func TestRecorder_CountsByDayRouteAndField(t *testing.T) {
+	store := &fakeStore{}
+	clk := clock.NewFake(time.Date(2025, 8, 1, 23, 59, 0, 0, time.UTC))
+	rec := newTestRecorder(store, clk)
+
+	rec.RecordValidationFailure(t.Context(), route, []string{"first_name", "password"})
+	rec.RecordValidationFailure(t.Context(), route, []string{"first_name"})
+	clk.Advance(time.Minute)
+	rec.RecordValidationFailure(t.Context(), route, []string{"first_name"})
+	require.NoError(t, rec.Flush(t.Context()))
+
+	assert.Equal(t, map[string]int64{
+		"2025-08-01 " + route + " first_name": 2,
+		"2025-08-01 " + route + " password":   1,
+		"2025-08-02 " + route + " first_name": 1,
+	}, store.counts())
+
+	require.NoError(t, rec.Flush(t.Context()))
+	assert.Len(t, store.failures, 3, "flushed failures are not written twice")
+}
+
+func TestRecorder_SkipsUndeclaredFields(t *testing.T) {
+	store := &fakeStore{}
+	rec := newTestRecorder(store, clock.NewFake(time.Date(2025, 8, 1, 10, 0, 0, 0, time.UTC)))
+
+	long := string(make([]byte, maxFieldLen+1))
+	rec.RecordValidationFailure(t.Context(), route, []string{"", long, "email"})
+	require.NoError(t, rec.Flush(t.Context()))
+
+	require.Len(t, store.failures, 1)
+	assert.Equal(t, "email", store.failures[0].Field)
+}
+
+func TestRecorder_DropsPastMaxPending(t *testing.T) {
+	store := &fakeStore{}
+	rec := NewRecorder(RecorderArgs{
+		Store:      store,
+		MaxPending: 1,
+		Metrics:    metrics.NewRegistry(noop.NewMeterProvider().Meter("test")),
+	})
+
+	rec.RecordValidationFailure(t.Context(), route, []string{"email", "password"})
+	rec.RecordValidationFailure(t.Context(), route, []string{"email"})
+	require.NoError(t, rec.Flush(t.Context()))
+
+	require.Len(t, store.failures, 1)
+	assert.Equal(t, int64(2), store.failures[0].Count, "known pairs are still counted")
+}
+
+func TestRecorder_FlushErrorDropsFailures(t *testing.T) {
+	store := &fakeStore{err: errors.New("connection refused")}
+	rec := newTestRecorder(store, nil)
+
+	rec.RecordValidationFailure(t.Context(), route, []string{"email"})
+	require.Error(t, rec.Flush(t.Context()))
+
+	store.err = nil
+	require.NoError(t, rec.Flush(t.Context()))
+	assert.Empty(t, store.failures)
+}
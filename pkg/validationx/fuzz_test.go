@@ -0,0 +1,51 @@
+package validationx
+
+import (
+	"strings"
+	"testing"
+
+	"gitlab.com/ucmsv2/ucms-backend/pkg/sanitizex"
+)
+
+func FuzzIsUsername(f *testing.F) {
+	for _, seed := range []string{
+		"user_name123",
+		"user.name",
+		"username_",
+		"_username",
+		"user..name",
+		"user._name",
+		"us",
+		strings.Repeat("a", 31),
+		"user\x00name",
+		"user\r\nname",
+		"\u0430dmin",
+		"\uff41dmin",
+		"admin\u200B",
+		"admin'--",
+		"{{username}}",
+	} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, s string) {
+		if err := IsUsername.Validate(s); err != nil || s == "" {
+			return
+		}
+
+		if len(s) < 3 || len(s) > 30 {
+			t.Fatalf("IsUsername accepted %q of length %d", s, len(s))
+		}
+		if !usernameRegex.MatchString(s) {
+			t.Fatalf("IsUsername accepted %q, which does not match the username format", s)
+		}
+		if strings.ContainsFunc(s, func(r rune) bool {
+			return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '.' || r == '_')
+		}) {
+			t.Fatalf("IsUsername accepted %q, which has characters other than ASCII letters, digits, '.' and '_'", s)
+		}
+		if got := sanitizex.CleanSingleLine(s); got != s {
+			t.Fatalf("IsUsername accepted %q, which CleanSingleLine changes to %q", s, got)
+		}
+	})
+}
@@ -3,6 +3,8 @@ package validationx
 import (
 	"github.com/ARUMANDESU/validation"
 	"github.com/ARUMANDESU/validation/is"
+
+	"gitlab.com/ucmsv2/ucms-backend/pkg/sanitizex"
 )
 
 var (
@@ -16,11 +18,13 @@ var (
 		validation.Required,
 		validation.Length(1, 150),
 		IsPersonName,
+		IsSingleScriptName,
 	}
 
-	PasswordRules = []validation.Rule{
-		validation.Required,
-		validation.Length(8, 128),
-		PasswordFormat,
+	// PhoneRules are the rules of an optional phone number, sanitize it with
+	// sanitizex.CleanPhone first so that the canonical number is stored.
+	PhoneRules = []validation.Rule{
+		validation.Length(0, sanitizex.MaxPhoneInputLen),
+		IsPhone,
 	}
 )
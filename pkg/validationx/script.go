@@ -0,0 +1,95 @@
+package validationx
+
+import (
+	"errors"
+	"unicode"
+
+	"github.com/ARUMANDESU/validation"
+	"golang.org/x/text/unicode/norm"
+
+	"gitlab.com/ucmsv2/ucms-backend/pkg/i18nx"
+)
+
+var (
+	ErrNameScript     = validation.NewError(i18nx.ValidationNameScript, i18nx.MsgValidationNameScriptOther)
+	ErrUsernameScript = validation.NewError(i18nx.ValidationUsernameScript, i18nx.MsgValidationUsernameScriptOther)
+)
+
+// script is a writing system a name may be written in. Han, Hiragana,
+// Katakana and Hangul are one script, a Japanese name mixes them.
+type script int
+
+const (
+	scriptNone script = iota
+	scriptLatin
+	scriptCyrillic
+	scriptCJK
+)
+
+func scriptOf(r rune) script {
+	switch {
+	case unicode.Is(unicode.Latin, r):
+		return scriptLatin
+	case unicode.Is(unicode.Cyrillic, r):
+		return scriptCyrillic
+	case unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul, unicode.Bopomofo):
+		return scriptCJK
+	}
+	return scriptNone
+}
+
+// compatibilityForm reports whether r is a compatibility character, e.g. the
+// full-width Ａ, that renders like another letter without being it.
+func compatibilityForm(r rune) bool {
+	s := string(r)
+	return norm.NFKC.String(s) != s
+}
+
+// IsSingleScriptName checks that the letters of a person name are all Latin,
+// all Cyrillic or all CJK: "Аdam" with a Cyrillic А reads as another name in
+// the directory. The zero-width and the other format characters, and the
+// compatibility forms such as the full-width ＡＤＭＩＮ, are rejected
+// outright. Marks take the script of the letter they follow.
+var IsSingleScriptName = validation.By(func(value any) error {
+	s, ok := value.(string)
+	if !ok {
+		return errors.New("value is not a string")
+	}
+
+	found := scriptNone
+	for _, r := range s {
+		if unicode.Is(unicode.Cf, r) {
+			return ErrInvalidNameFormat
+		}
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		if compatibilityForm(r) {
+			return ErrNameScript
+		}
+
+		sc := scriptOf(r)
+		if sc == scriptNone || (found != scriptNone && sc != found) {
+			return ErrNameScript
+		}
+		found = sc
+	}
+	return nil
+})
+
+// IsLatinUsername checks that the letters of a username are the English
+// ones, so that "аdmin" with a Cyrillic а is not taken for admin. IsUsername
+// checks it too, use this one where the format is checked later.
+var IsLatinUsername = validation.By(func(value any) error {
+	s, ok := value.(string)
+	if !ok {
+		return errors.New("value is not a string")
+	}
+
+	for _, r := range s {
+		if unicode.Is(unicode.Cf, r) || (unicode.IsLetter(r) && r > unicode.MaxASCII) {
+			return ErrUsernameScript
+		}
+	}
+	return nil
+})
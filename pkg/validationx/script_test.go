@@ -0,0 +1,76 @@
+package validationx
+
+import (
+	"testing"
+)
+
+func TestIsSingleScriptName(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		personName string
+		want       error // compared by message, validation errors are not comparable
+	}{
+		{"empty", "", nil},
+		{"latin", "John O'Connor-Smith", nil},
+		{"latin with accents", "José Ángel", nil},
+		{"turkish dotted i", "İlkay", nil},
+		{"decomposed accent", "José", nil},
+		{"cyrillic", "Иван Петров", nil},
+		{"kazakh cyrillic", "Әлихан Бөкейхан", nil},
+		{"chinese", "李小龙", nil},
+		{"japanese kanji and kana", "山田はなこ", nil},
+		{"korean", "김민준", nil},
+		{"cyrillic a in latin word", "аdmin", ErrNameScript},
+		{"latin o in cyrillic word", "Ивoн", ErrNameScript},
+		{"latin and cyrillic words", "John Иванов", ErrNameScript},
+		{"greek", "Αλέξανδρος", ErrNameScript},
+		// Full-width letters and a Turkish İ are all Latin letters, but the
+		// full-width ones only render like ADMIN: rejected.
+		{"full-width", "ＡＤＭİＮ", ErrNameScript},
+		{"ligature", "ﬁnn", ErrNameScript},
+		{"zero-width space", "John\u200BSmith", ErrInvalidNameFormat},
+		{"zero-width joiner", "John\u200DSmith", ErrInvalidNameFormat},
+		{"byte order mark", "\uFEFFJohn", ErrInvalidNameFormat},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := IsSingleScriptName.Validate(tt.personName)
+			if (err == nil) != (tt.want == nil) || (err != nil && err.Error() != tt.want.Error()) {
+				t.Errorf("IsSingleScriptName(%q) = %v, want %v", tt.personName, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsLatinUsername(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		username string
+		valid    bool
+	}{
+		{"empty", "", true},
+		{"latin", "user_name.1", true},
+		{"edge dots", ".admin.", true}, // IsUsername rejects them
+		{"cyrillic a", "аdmin", false},
+		{"all cyrillic", "админ", false},
+		{"full-width", "ａｄｍｉｎ", false},
+		{"accented", "josé", false},
+		{"zero-width", "ad\u200Bmin", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := IsLatinUsername.Validate(tt.username)
+			if (err == nil) != tt.valid {
+				t.Errorf("IsLatinUsername(%q) = %v, expected valid: %v", tt.username, err, tt.valid)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package validationx
+
+import (
+	"errors"
+	"time"
+
+	"github.com/ARUMANDESU/validation"
+
+	"gitlab.com/ucmsv2/ucms-backend/pkg/i18nx"
+)
+
+var (
+	ErrTimeInPast          = validation.NewError(i18nx.ValidationTimeInPast, i18nx.MsgValidationTimeInPastOther)
+	ErrTimeBeforeThreshold = validation.NewError(i18nx.ValidationTimeBeforeThreshold, i18nx.MsgValidationTimeBeforeThresholdOther)
+	ErrTimeAfterThreshold  = validation.NewError(i18nx.ValidationTimeAfterThreshold, i18nx.MsgValidationTimeAfterThresholdOther)
+)
+
+// FutureTime checks that a time is not before clock(), a nil clock is
+// time.Now. Equal times pass.
+// types: time.Time, *time.Time
+func FutureTime(clock func() time.Time) validation.Rule {
+	if clock == nil {
+		clock = time.Now
+	}
+	return validation.By(func(value any) error {
+		t, ok, err := timeValue(value)
+		if err != nil || !ok {
+			return err
+		}
+
+		if t.Before(clock()) {
+			return ErrTimeInPast
+		}
+		return nil
+	})
+}
+
+// AfterField checks that a time is after the time other points to, equal
+// times fail. It passes when other is nil, let the rules of the other field
+// report it.
+// types: time.Time, *time.Time
+func AfterField(other *time.Time) validation.Rule {
+	return TimeWindowRule{From: other}
+}
+
+// TimeWindowRule checks the window from From to Until: Until must be after
+// From and the window at least MinDuration and at most MaxDuration long, a
+// zero MaxDuration is no limit. A nil Until is the validated value, so the
+// rule is attached to the end field and its errors are reported there. The
+// window is only checked when both ends are set.
+// types: time.Time, *time.Time
+type TimeWindowRule struct {
+	From        *time.Time
+	Until       *time.Time
+	MinDuration time.Duration
+	MaxDuration time.Duration
+}
+
+func (r TimeWindowRule) Validate(value any) error {
+	until, ok, err := timeValue(value)
+	if err != nil {
+		return err
+	}
+	if r.Until != nil {
+		until, ok = *r.Until, !r.Until.IsZero()
+	}
+	if !ok || r.From == nil || r.From.IsZero() {
+		return nil
+	}
+
+	from := *r.From
+	if earliest := from.Add(r.MinDuration); until.Before(earliest) || !until.After(from) {
+		return ErrTimeBeforeThreshold.SetParams(map[string]any{i18nx.ArgThreshold: earliest.UTC().Format(time.RFC3339)})
+	}
+	if r.MaxDuration > 0 {
+		if latest := from.Add(r.MaxDuration); until.After(latest) {
+			return ErrTimeAfterThreshold.SetParams(map[string]any{i18nx.ArgThreshold: latest.UTC().Format(time.RFC3339)})
+		}
+	}
+	return nil
+}
+
+// timeValue returns the time in value, ok is false for nil and zero times so
+// that Required and NilOrNotEmpty handle them.
+func timeValue(value any) (t time.Time, ok bool, err error) {
+	value, isNil := validation.Indirect(value)
+	if isNil {
+		return time.Time{}, false, nil
+	}
+
+	t, isTime := value.(time.Time)
+	if !isTime {
+		return time.Time{}, false, errors.New("value is not a time")
+	}
+	return t, !t.IsZero(), nil
+}
@@ -0,0 +1,154 @@
+package validationx
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ARUMANDESU/validation"
+	"github.com/stretchr/testify/assert"
+)
+
+var testNow = time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+
+func testClock() time.Time { return testNow }
+
+func TestFutureTime(t *testing.T) {
+	past := testNow.Add(-time.Second)
+	future := testNow.Add(time.Hour)
+
+	tests := []struct {
+		name    string
+		value   any
+		wantErr error
+	}{
+		{name: "future", value: future},
+		{name: "future pointer", value: &future},
+		{name: "equal to now", value: testNow},
+		{name: "past", value: past, wantErr: ErrTimeInPast},
+		{name: "past pointer", value: &past, wantErr: ErrTimeInPast},
+		{name: "nil pointer", value: (*time.Time)(nil)},
+		{name: "zero time", value: time.Time{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validation.Validate(tt.value, FutureTime(testClock))
+			if tt.wantErr != nil {
+				AssertValidationError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+
+	assert.Error(t, validation.Validate("2025-03-10", FutureTime(testClock)), "non time values are rejected")
+}
+
+func TestAfterField(t *testing.T) {
+	from := testNow
+	after := from.Add(time.Nanosecond)
+	before := from.Add(-time.Minute)
+
+	assert.NoError(t, validation.Validate(&after, AfterField(&from)))
+	AssertValidationError(t, validation.Validate(from, AfterField(&from)), ErrTimeBeforeThreshold)
+	AssertValidationError(t, validation.Validate(&before, AfterField(&from)), ErrTimeBeforeThreshold)
+	assert.NoError(t, validation.Validate(&before, AfterField(nil)), "nil other field is left to its own rules")
+	assert.NoError(t, validation.Validate((*time.Time)(nil), AfterField(&from)))
+}
+
+func TestTimeWindowRule(t *testing.T) {
+	from := testNow
+	at := func(d time.Duration) *time.Time {
+		ts := from.Add(d)
+		return &ts
+	}
+
+	tests := []struct {
+		name    string
+		rule    TimeWindowRule
+		until   *time.Time
+		wantErr error
+	}{
+		{
+			name:  "within window",
+			rule:  TimeWindowRule{From: &from, MinDuration: time.Minute, MaxDuration: time.Hour},
+			until: at(30 * time.Minute),
+		},
+		{
+			name:    "equal timestamps",
+			rule:    TimeWindowRule{From: &from},
+			until:   at(0),
+			wantErr: ErrTimeBeforeThreshold,
+		},
+		{
+			name:    "until before from",
+			rule:    TimeWindowRule{From: &from},
+			until:   at(-time.Hour),
+			wantErr: ErrTimeBeforeThreshold,
+		},
+		{
+			name:    "shorter than min duration",
+			rule:    TimeWindowRule{From: &from, MinDuration: time.Minute},
+			until:   at(time.Minute - time.Second),
+			wantErr: ErrTimeBeforeThreshold,
+		},
+		{
+			name:  "exactly min duration",
+			rule:  TimeWindowRule{From: &from, MinDuration: time.Minute},
+			until: at(time.Minute),
+		},
+		{
+			name:  "exactly max duration",
+			rule:  TimeWindowRule{From: &from, MaxDuration: time.Hour},
+			until: at(time.Hour),
+		},
+		{
+			name:    "longer than max duration",
+			rule:    TimeWindowRule{From: &from, MaxDuration: time.Hour},
+			until:   at(time.Hour + time.Second),
+			wantErr: ErrTimeAfterThreshold,
+		},
+		{
+			name:  "zero max duration is no limit",
+			rule:  TimeWindowRule{From: &from},
+			until: at(24 * 365 * time.Hour),
+		},
+		{
+			name:  "nil from",
+			rule:  TimeWindowRule{MaxDuration: time.Hour},
+			until: at(-time.Hour),
+		},
+		{
+			name: "nil until",
+			rule: TimeWindowRule{From: &from},
+		},
+		{
+			name:    "until set on the rule",
+			rule:    TimeWindowRule{From: &from, Until: at(2 * time.Hour), MaxDuration: time.Hour},
+			wantErr: ErrTimeAfterThreshold,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validation.Validate(tt.until, tt.rule)
+			if tt.wantErr != nil {
+				AssertValidationError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestTimeWindowRule_ThresholdParam(t *testing.T) {
+	from := testNow
+	until := from.Add(2 * time.Hour)
+
+	err := TimeWindowRule{From: &from, MaxDuration: time.Hour}.Validate(&until)
+
+	var verr validation.Error
+	if assert.ErrorAs(t, err, &verr) {
+		assert.Equal(t, "2025-03-10T13:00:00Z", verr.Params()["threshold"])
+	}
+}
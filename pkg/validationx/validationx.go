@@ -11,14 +11,17 @@ import (
 	"unicode"
 
 	"github.com/ARUMANDESU/validation"
+	"github.com/ARUMANDESU/validation/is"
 
 	"gitlab.com/ucmsv2/ucms-backend/pkg/i18nx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/sanitizex"
 )
 
 var (
 	ErrInvalidPasswordFormat = validation.NewError(i18nx.ValidationIsPassword, i18nx.MsgValidationIsPasswordOther)
 	ErrInvalidNameFormat     = validation.NewError(i18nx.ValidationIsName, i18nx.MsgValidationIsNameOther)
 	ErrInvalidUsernameFormat = validation.NewError(i18nx.ValidationIsUsername, i18nx.MsgValidationIsUsernameOther)
+	ErrInvalidPhoneFormat    = validation.NewError(i18nx.ValidationIsPhone, i18nx.MsgValidationIsPhoneOther)
 	ErrDuplicate             = validation.NewError(i18nx.ValidationNoDuplicate, i18nx.MsgValidationNoDuplicateOther)
 )
 
@@ -32,8 +35,6 @@ var (
 	// Allow Unicode letters, spaces, hyphens, apostrophes, periods
 	nameRegex  = regexp.MustCompile(`^[\p{L}\p{M}\s'\-\.]+$`)
 	emailRegex = regexp.MustCompile(`^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`)
-	// Allow alphanumeric characters
-	barcodeRegex = regexp.MustCompile(`^[A-Z0-9]{6,20}$`)
 
 	usernameRegex = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9]*(?:[._][a-zA-Z0-9]+)*$`)
 )
@@ -62,6 +63,10 @@ var IsUsername = validation.By(func(value any) error {
 		return nil // Let Required handle emptiness
 	}
 
+	if err := IsLatinUsername.Validate(s); err != nil {
+		return err
+	}
+
 	if len(s) < 3 || len(s) > 30 {
 		return ErrInvalidUsernameFormat
 	}
@@ -77,6 +82,61 @@ var IsUsername = validation.By(func(value any) error {
 	return nil
 })
 
+const (
+	MinBarcodeLen = 6
+	MaxBarcodeLen = 20
+)
+
+// IsBarcode checks that a barcode contains English letters and digits only
+// and is MinBarcodeLen to MaxBarcodeLen long. The characters are checked
+// first, so a long injection attempt is reported as such.
+var IsBarcode = validation.By(func(value any) error {
+	value, isNil := validation.Indirect(value)
+	if isNil || validation.IsEmpty(value) {
+		return nil // Let Required handle emptiness
+	}
+
+	if err := is.Alphanumeric.Validate(value); err != nil {
+		return err
+	}
+	return validation.Length(MinBarcodeLen, MaxBarcodeLen).Validate(value)
+})
+
+// IsVerificationCode checks that a verification code is exactly length
+// English letters and digits.
+func IsVerificationCode(length int) validation.Rule {
+	return validation.By(func(value any) error {
+		value, isNil := validation.Indirect(value)
+		if isNil || validation.IsEmpty(value) {
+			return nil // Let Required handle emptiness
+		}
+
+		if err := validation.Length(length, length).Validate(value); err != nil {
+			return err
+		}
+		return is.Alphanumeric.Validate(value)
+	})
+}
+
+// IsPhone checks that a phone number normalizes to E.164 with
+// sanitizex.NormalizePhone, numbers without a country code get the one of
+// the phone policy.
+var IsPhone = validation.By(func(value any) error {
+	value, isNil := validation.Indirect(value)
+	if isNil || validation.IsEmpty(value) {
+		return nil // Let Required handle emptiness
+	}
+
+	s, ok := value.(string)
+	if !ok {
+		return errors.New("value is not a string")
+	}
+	if _, err := sanitizex.NormalizePhone(s); err != nil {
+		return ErrInvalidPhoneFormat
+	}
+	return nil
+})
+
 // NoDuplicate checks that a slice of strings has no duplicate entries.
 // types: slice or array of strings, int, uint, float64, slice of bytes
 var NoDuplicate = validation.By(func(value any) error {
@@ -258,5 +318,6 @@ func AssertValidationError(t *testing.T, err error, expected error) {
 }
 
 func IsEmailOrBarcode(emailbarcode string) (isEmail bool, isBarcode bool) {
-	return emailRegex.MatchString(emailbarcode), barcodeRegex.MatchString(emailbarcode)
+	isBarcode = emailbarcode != "" && IsBarcode.Validate(emailbarcode) == nil
+	return emailRegex.MatchString(emailbarcode), isBarcode
 }
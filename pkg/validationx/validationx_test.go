@@ -288,6 +288,8 @@ func TestIsUsername(t *testing.T) {
 		{"only digits", "123456", false},
 		{"only special chars", "___", false},
 		{"mixed invalid chars", "user@name!", false},
+		{"cyrillic homograph", "аdmin", false},
+		{"full-width", "ａｄｍｉｎ", false},
 	}
 
 	for _, tt := range tests {
@@ -619,3 +621,138 @@ func TestNoDuplicateEdgeCases(t *testing.T) {
 		assert.NoError(t, err, "Boundary unsigned integer values should work")
 	})
 }
+
+func TestIsBarcode(t *testing.T) {
+	t.Parallel()
+
+	const (
+		alnumMsg  = "must contain English letters and digits only"
+		lengthMsg = "the length must be between 6 and 20"
+	)
+
+	tests := []struct {
+		name    string
+		barcode any
+		msg     string
+	}{
+		{"valid digits", "210107", ""},
+		{"valid letters and digits", "STU001", ""},
+		{"lowercase", "stu001", ""},
+		{"max length", strings.Repeat("A", 20), ""},
+		{"empty", "", ""}, // Let Required handle emptiness
+		{"named string type", barcode("230001"), ""},
+		{"too short", "STU01", lengthMsg},
+		{"too long", strings.Repeat("A", 21), lengthMsg},
+		{"hyphen", "INVALID-BARCODE", alnumMsg},
+		{"space", "STU 001", alnumMsg},
+		{"bengali", "STUদেন্ট001", alnumMsg},
+		{"cyrillic look-alike", "СТУ001", alnumMsg},
+		{"fullwidth digits", "ＳＴＵ００１", alnumMsg},
+		{"null byte", "STU001\x00admin", alnumMsg},
+		{"overlong encoded slash", "STU\xc0\xaf001", alnumMsg},
+		{"long sql injection reports characters", "STU001'||pg_sleep(5)||'", alnumMsg},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := IsBarcode.Validate(tt.barcode)
+			if tt.msg == "" {
+				assert.NoError(t, err)
+				return
+			}
+			if assert.Error(t, err) {
+				assert.Equal(t, tt.msg, err.Error())
+			}
+		})
+	}
+}
+
+type barcode string
+
+func TestIsVerificationCode(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		code string
+		msg  string
+	}{
+		{"valid", "A1B2C3", ""},
+		{"empty", "", ""}, // Let Required handle emptiness
+		{"too long", "WRONG123", "the length must be exactly 6"},
+		{"too short", "ABC12", "the length must be exactly 6"},
+		{"special characters", "AB-12!", "must contain English letters and digits only"},
+		{"null byte", "ABC\x0012", "must contain English letters and digits only"},
+		{"unicode counts bytes", "АВС123", "the length must be exactly 6"},
+		{"overlong encoding", "AB\xc0\xaf12", "must contain English letters and digits only"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := IsVerificationCode(6).Validate(tt.code)
+			if tt.msg == "" {
+				assert.NoError(t, err)
+				return
+			}
+			if assert.Error(t, err) {
+				assert.Equal(t, tt.msg, err.Error())
+			}
+		})
+	}
+}
+
+func TestIsEmailOrBarcode(t *testing.T) {
+	t.Parallel()
+
+	isEmail, isBarcode := IsEmailOrBarcode("student@test.com")
+	assert.True(t, isEmail)
+	assert.False(t, isBarcode)
+
+	isEmail, isBarcode = IsEmailOrBarcode("210107")
+	assert.False(t, isEmail)
+	assert.True(t, isBarcode)
+
+	isEmail, isBarcode = IsEmailOrBarcode("STU001\x00admin")
+	assert.False(t, isEmail)
+	assert.False(t, isBarcode)
+}
+
+func TestIsPhone(t *testing.T) {
+	t.Parallel()
+
+	phone := "+77021234567"
+
+	tests := []struct {
+		name  string
+		phone any
+		valid bool
+	}{
+		{"E.164", "+77021234567", true},
+		{"national with trunk prefix", "8 702 123 45 67", true},
+		{"US number", "+1-202-555-0143", true},
+		{"pointer", &phone, true},
+		{"empty", "", true}, // Let Required handle emptiness
+		{"nil pointer", (*string)(nil), true},
+		{"letters", "+7 702 ABC 45 67", false},
+		{"extension", "+1 202 555 0143 ext. 12", false},
+		{"NoSQL injection", `{"$ne":null}`, false},
+		{"too short", "12345", false},
+		{"overlong", strings.Repeat("7", 40), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := IsPhone.Validate(tt.phone)
+			if tt.valid {
+				assert.NoError(t, err)
+				return
+			}
+			AssertValidationError(t, err, ErrInvalidPhoneFormat)
+		})
+	}
+
+	assert.Error(t, IsPhone.Validate(77021234567), "non string values are rejected")
+}
@@ -0,0 +1,132 @@
+package validationx
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Warning codes, the clients show their own text for each.
+const (
+	// WarningAllCapsNormalized is set when a value written in capitals was
+	// title-cased.
+	WarningAllCapsNormalized = "all_caps_normalized"
+	// WarningAllCaps is set when a value is written in capitals and kept.
+	WarningAllCaps = "all_caps"
+	// WarningEdgeDotsTrimmed is set when the leading and trailing dots of a
+	// username were trimmed.
+	WarningEdgeDotsTrimmed = "edge_dots_trimmed"
+	// WarningEmailPlusTag is set when an email address has a plus tag.
+	WarningEmailPlusTag = "email_plus_tag"
+)
+
+// Warning is a finding on a valid request that does not fail it.
+type Warning struct {
+	Field string `json:"field"`
+	Code  string `json:"code"`
+}
+
+// WarningRule inspects a valid value, it returns the value to keep, which
+// it may normalize, and the code of its warning, empty for none.
+type WarningRule func(value string) (kept string, code string)
+
+// Warnings are collected apart from the validation errors, once the request
+// is valid:
+//
+//	var warnings validationx.Warnings
+//	warnings.Check("first_name", &r.FirstName, validationx.TitleCaseAllCaps)
+type Warnings []Warning
+
+// Check runs rules on value in order, each on the value the previous one
+// kept, and records their warnings under field.
+func (w *Warnings) Check(field string, value *string, rules ...WarningRule) {
+	for _, rule := range rules {
+		kept, code := rule(*value)
+		*value = kept
+		if code != "" {
+			*w = append(*w, Warning{Field: field, Code: code})
+		}
+	}
+}
+
+// Codes returns the field and code of every warning, for the logs.
+func (w Warnings) Codes() []string {
+	codes := make([]string, len(w))
+	for i, warning := range w {
+		codes[i] = warning.Field + ":" + warning.Code
+	}
+	return codes
+}
+
+// minAllCapsLetters is the least capitals AllCaps warns on, the acronyms
+// like IT are left alone.
+const minAllCapsLetters = 4
+
+// TitleCaseAllCaps title-cases a name written in capitals, MARY-JANE O'NEIL
+// becomes Mary-Jane O'Neil.
+var TitleCaseAllCaps WarningRule = func(value string) (string, string) {
+	if !allCaps(value, 2) {
+		return value, ""
+	}
+	return titleCase(value), WarningAllCapsNormalized
+}
+
+// AllCaps warns on a free text written in capitals and keeps it, its
+// acronyms would not survive title-casing.
+var AllCaps WarningRule = func(value string) (string, string) {
+	if !allCaps(value, minAllCapsLetters) {
+		return value, ""
+	}
+	return value, WarningAllCaps
+}
+
+// TrimEdgeDots trims the dots a username starts or ends with, IsUsername
+// would reject them.
+var TrimEdgeDots WarningRule = func(value string) (string, string) {
+	trimmed := strings.Trim(value, ".")
+	if trimmed == value || trimmed == "" {
+		return value, ""
+	}
+	return trimmed, WarningEdgeDotsTrimmed
+}
+
+// PlusTag warns on an email address with a plus tag, usually an alias of
+// another mailbox.
+var PlusTag WarningRule = func(value string) (string, string) {
+	local, _, ok := strings.Cut(value, "@")
+	if ok && strings.Contains(local, "+") {
+		return value, WarningEmailPlusTag
+	}
+	return value, ""
+}
+
+// allCaps reports whether value has least capitals or more and no lower
+// case letter.
+func allCaps(value string, least int) bool {
+	upper := 0
+	for _, r := range value {
+		switch {
+		case unicode.IsLower(r):
+			return false
+		case unicode.IsUpper(r):
+			upper++
+		}
+	}
+	return upper >= least
+}
+
+// titleCase lowers value and capitalizes the first letter of each of its
+// words, the hyphens and apostrophes start a word too.
+func titleCase(value string) string {
+	var b strings.Builder
+	b.Grow(len(value))
+	start := true
+	for _, r := range value {
+		if start {
+			b.WriteRune(unicode.ToTitle(r))
+		} else {
+			b.WriteRune(unicode.ToLower(r))
+		}
+		start = !unicode.IsLetter(r) && !unicode.IsMark(r)
+	}
+	return b.String()
+}
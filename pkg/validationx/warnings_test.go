@@ -0,0 +1,53 @@
+package validationx
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWarningRules(t *testing.T) {
+	tests := []struct {
+		name string
+		rule WarningRule
+		in   string
+		kept string
+		code string
+	}{
+		{name: "capitals", rule: TitleCaseAllCaps, in: "MARY-JANE O'NEIL", kept: "Mary-Jane O'Neil", code: WarningAllCapsNormalized},
+		{name: "cyrillic capitals", rule: TitleCaseAllCaps, in: "АЙГЕРІМ", kept: "Айгерім", code: WarningAllCapsNormalized},
+		{name: "mixed case", rule: TitleCaseAllCaps, in: "McDONALD", kept: "McDONALD"},
+		{name: "initial", rule: TitleCaseAllCaps, in: "J", kept: "J"},
+		{name: "free text in capitals", rule: AllCaps, in: "DEAN'S OFFICE", kept: "DEAN'S OFFICE", code: WarningAllCaps},
+		{name: "acronym", rule: AllCaps, in: "IT", kept: "IT"},
+		{name: "edge dots", rule: TrimEdgeDots, in: ".anna.", kept: "anna", code: WarningEdgeDotsTrimmed},
+		{name: "inner dot", rule: TrimEdgeDots, in: "anna.lee", kept: "anna.lee"},
+		{name: "only dots", rule: TrimEdgeDots, in: "..", kept: ".."},
+		{name: "plus tag", rule: PlusTag, in: "anna+ucms@astanait.edu.kz", kept: "anna+ucms@astanait.edu.kz", code: WarningEmailPlusTag},
+		{name: "plain email", rule: PlusTag, in: "anna@astanait.edu.kz", kept: "anna@astanait.edu.kz"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kept, code := tt.rule(tt.in)
+			assert.Equal(t, tt.kept, kept)
+			assert.Equal(t, tt.code, code)
+		})
+	}
+}
+
+func TestWarnings_Check(t *testing.T) {
+	first, username := "ANNA", ".anna"
+
+	var warnings Warnings
+	warnings.Check("first_name", &first, TitleCaseAllCaps)
+	warnings.Check("username", &username, TrimEdgeDots, TitleCaseAllCaps)
+
+	assert.Equal(t, "Anna", first)
+	assert.Equal(t, "anna", username)
+	assert.Equal(t, Warnings{
+		{Field: "first_name", Code: WarningAllCapsNormalized},
+		{Field: "username", Code: WarningEdgeDotsTrimmed},
+	}, warnings)
+	assert.Equal(t, []string{"first_name:all_caps_normalized", "username:edge_dots_trimmed"}, warnings.Codes())
+}
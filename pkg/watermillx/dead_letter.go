@@ -0,0 +1,146 @@
+package watermillx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill/components/cqrs"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/ThreeDotsLabs/watermill/message/router/middleware"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// deadLetterTable is the table the SQL publisher writes PoisonTopic to.
+const deadLetterTable = "watermill_" + PoisonTopic
+
+// CountDeadLetters counts the dead letters poisoned since since by the
+// handlers named handlers, by any handler when handlers is empty.
+func CountDeadLetters(ctx context.Context, conn *pgxpool.Pool, handlers []string, since time.Time) (int64, error) {
+	const op = "watermillx.CountDeadLetters"
+
+	// created_at is a timestamp in the time zone of the session, UTC.
+	var count int64
+	err := conn.QueryRow(ctx, `
+		SELECT count(*)
+		FROM `+deadLetterTable+`
+		WHERE created_at >= $1::timestamptz
+		  AND (coalesce(cardinality($2::text[]), 0) = 0 OR metadata->>$3::text = ANY($2))
+	`, since.UTC(), handlers, middleware.PoisonedHandlerKey).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return count, nil
+}
+
+// RedeliverResult counts the dead letters RedeliverDeadLetters handed over.
+type RedeliverResult struct {
+	// Redelivered were handled and removed from the poison queue.
+	Redelivered int
+	// Failed failed again and stay in the poison queue.
+	Failed int
+}
+
+// RedeliverDeadLetters hands the dead letters of handlers back to the
+// handler that poisoned them, e.g. once the cause is fixed, and removes the
+// ones it handles from the poison queue. The dead letters of other handlers
+// are left alone.
+//
+// The messages are not published to their topic again: the other consumer
+// groups of the topic handled them already and would get them twice.
+func RedeliverDeadLetters(ctx context.Context, conn *pgxpool.Pool, handlers []cqrs.EventHandler) (RedeliverResult, error) {
+	const op = "watermillx.RedeliverDeadLetters"
+
+	byName := make(map[string]cqrs.EventHandler, len(handlers))
+	names := make([]string, 0, len(handlers))
+	for _, h := range handlers {
+		byName[h.HandlerName()] = h
+		names = append(names, h.HandlerName())
+	}
+
+	var (
+		res   RedeliverResult
+		after int64
+	)
+	for {
+		offset, handled, err := redeliverNext(ctx, conn, byName, names, after)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return res, nil
+		}
+		if err != nil {
+			return res, fmt.Errorf("%s: %w", op, err)
+		}
+		after = offset
+		if handled {
+			res.Redelivered++
+		} else {
+			res.Failed++
+		}
+	}
+}
+
+// redeliverNext redelivers the first dead letter of names after the offset
+// after. The row stays locked while it is handled, so that concurrent runs
+// do not deliver it twice. It returns pgx.ErrNoRows when there is none left.
+func redeliverNext(
+	ctx context.Context,
+	conn *pgxpool.Pool,
+	byName map[string]cqrs.EventHandler,
+	names []string,
+	after int64,
+) (offset int64, handled bool, err error) {
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return 0, false, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback(context.WithoutCancel(ctx)) }()
+
+	var (
+		uuid     string
+		payload  []byte
+		metadata map[string]string
+	)
+	err = tx.QueryRow(ctx, `
+		SELECT "offset", uuid, payload, metadata
+		FROM `+deadLetterTable+`
+		WHERE "offset" > $1 AND metadata->>$2::text = ANY($3)
+		ORDER BY "offset"
+		LIMIT 1
+		FOR UPDATE SKIP LOCKED
+	`, after, middleware.PoisonedHandlerKey, names).Scan(&offset, &uuid, &payload, &metadata)
+	if err != nil {
+		return 0, false, err
+	}
+
+	msg := message.NewMessage(uuid, payload)
+	msg.Metadata = metadata
+	handlerName := metadata[middleware.PoisonedHandlerKey]
+	if err := handleDeadLetter(ctx, byName[handlerName], msg); err != nil {
+		slog.WarnContext(ctx, "Dead letter failed again, it stays in the poison queue",
+			"offset", offset, "message_uuid", uuid, "handler", handlerName, "error", err)
+		return offset, false, nil
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM `+deadLetterTable+` WHERE "offset" = $1`, offset); err != nil {
+		return offset, false, fmt.Errorf("delete dead letter %d: %w", offset, err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return offset, false, fmt.Errorf("commit transaction: %w", err)
+	}
+	return offset, true, nil
+}
+
+// handleDeadLetter runs h on msg the way the event processor does.
+func handleDeadLetter(ctx context.Context, h cqrs.EventHandler, msg *message.Message) error {
+	ctx = cqrs.CtxWithOriginalMessage(ctx, msg)
+	msg.SetContext(ctx)
+
+	event := h.NewEvent()
+	if err := Marshaler.Unmarshal(msg, event); err != nil {
+		return err
+	}
+	return h.Handle(ctx, event)
+}
@@ -9,13 +9,16 @@ import (
 	watermillSQL "github.com/ThreeDotsLabs/watermill-sql/v4/pkg/sql"
 	"github.com/ThreeDotsLabs/watermill/components/cqrs"
 	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/announcement"
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/event"
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/registration"
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/staffinvitation"
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/ctxs"
 )
 
 func NewEventProcessor(router *message.Router, conn *pgxpool.Pool, logger watermill.LoggerAdapter) (*cqrs.EventProcessor, error) {
@@ -40,7 +43,7 @@ func NewEventProcessor(router *message.Router, conn *pgxpool.Pool, logger waterm
 				logger,
 			)
 		},
-		Marshaler:         cqrs.JSONMarshaler{},
+		Marshaler:         Marshaler,
 		Logger:            logger,
 		OnHandle:          nil,
 		AckOnUnknownEvent: true,
@@ -72,7 +75,7 @@ func NewEventGroupProcessor(router *message.Router, conn *pgxpool.Pool, logger w
 		},
 		OnHandle:          nil,
 		AckOnUnknownEvent: true,
-		Marshaler:         cqrs.JSONMarshaler{},
+		Marshaler:         Marshaler,
 		Logger:            logger,
 	})
 }
@@ -104,7 +107,7 @@ func NewEventGroupProcessorForTests(router *message.Router, conn *pgxpool.Pool,
 		},
 		OnHandle:          nil,
 		AckOnUnknownEvent: true,
-		Marshaler:         cqrs.JSONMarshaler{},
+		Marshaler:         Marshaler,
 		Logger:            logger,
 	})
 }
@@ -134,7 +137,7 @@ func NewEventProcessorForTests(router *message.Router, conn *pgxpool.Pool, logge
 				logger,
 			)
 		},
-		Marshaler:         cqrs.JSONMarshaler{},
+		Marshaler:         Marshaler,
 		Logger:            logger,
 		OnHandle:          nil,
 		AckOnUnknownEvent: true,
@@ -163,9 +166,9 @@ func NewTxEventBus(tx pgx.Tx, logger watermill.LoggerAdapter) (*cqrs.EventBus, e
 
 			return MessageTopic(evt)
 		},
-		Marshaler: cqrs.JSONMarshaler{},
+		Marshaler: Marshaler,
 		Logger:    logger,
-		OnPublish: nil,
+		OnPublish: OnPublish,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("%s: failed to create event bus: %w", op, err)
@@ -185,7 +188,12 @@ func Publish(ctx context.Context, tx pgx.Tx, logger watermill.LoggerAdapter, evt
 		return fmt.Errorf("%s: failed to create event bus: %w", op, err)
 	}
 
+	// The changes made in an impersonation session are the impersonator's.
+	impersonatorID, impersonating := ctxs.ImpersonatorFromCtx(ctx)
 	for _, evt := range evts {
+		if impersonating {
+			event.SetImpersonator(evt, uuid.UUID(impersonatorID))
+		}
 		if err := eventBus.Publish(ctx, evt); err != nil {
 			return fmt.Errorf("%s: failed to publish event %T: %w", op, evt, err)
 		}
@@ -225,6 +233,8 @@ func InitializeEventSchema(ctx context.Context, conn *pgxpool.Pool, logger water
 		user.StaffEventStreamName,
 		user.UserEventStreamName,
 		staffinvitation.EventStreamName,
+		announcement.EventStreamName,
+		PoisonTopic,
 	}
 
 	for _, eventStream := range events {
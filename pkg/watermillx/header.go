@@ -0,0 +1,77 @@
+package watermillx
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill/components/cqrs"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/google/uuid"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/event"
+)
+
+// The metadata keys of the event header, see InjectHeader.
+const (
+	MetadataEventID          = "event_id"
+	MetadataOccurredAt       = "occurred_at"
+	MetadataActorID          = "actor_id"
+	MetadataImpersonatorID   = "impersonator_id"
+	MetadataAggregateID      = "aggregate_id"
+	MetadataAggregateVersion = "aggregate_version"
+)
+
+// InjectHeader stores the header of e in the message metadata, so that the
+// consumers know who did what and when without decoding the payload. The
+// message takes the ID of the event, if it has one. The zero actor,
+// impersonator, aggregate and version are left out.
+func InjectHeader(msg *message.Message, e event.Event) {
+	h := e.GetEventHeader()
+	if msg.Metadata == nil {
+		msg.Metadata = make(message.Metadata)
+	}
+	if h.EventID != uuid.Nil {
+		msg.UUID = h.EventID.String()
+		msg.Metadata.Set(MetadataEventID, h.EventID.String())
+	}
+	if !h.OccurredAt.IsZero() {
+		msg.Metadata.Set(MetadataOccurredAt, h.OccurredAt.UTC().Format(time.RFC3339Nano))
+	}
+	if h.ActorID != uuid.Nil {
+		msg.Metadata.Set(MetadataActorID, h.ActorID.String())
+	}
+	if h.ImpersonatorID != uuid.Nil {
+		msg.Metadata.Set(MetadataImpersonatorID, h.ImpersonatorID.String())
+	}
+	if h.AggregateID != uuid.Nil {
+		msg.Metadata.Set(MetadataAggregateID, h.AggregateID.String())
+	}
+	if h.AggregateVersion != 0 {
+		msg.Metadata.Set(MetadataAggregateVersion, strconv.Itoa(h.AggregateVersion))
+	}
+}
+
+// HeaderFromMetadata returns the header InjectHeader stored in msg, the
+// missing or malformed entries are left zero.
+func HeaderFromMetadata(msg *message.Message) event.Header {
+	var h event.Header
+	if msg == nil {
+		return h
+	}
+	h.EventID, _ = uuid.Parse(msg.Metadata.Get(MetadataEventID))
+	h.OccurredAt, _ = time.Parse(time.RFC3339Nano, msg.Metadata.Get(MetadataOccurredAt))
+	h.ActorID, _ = uuid.Parse(msg.Metadata.Get(MetadataActorID))
+	h.ImpersonatorID, _ = uuid.Parse(msg.Metadata.Get(MetadataImpersonatorID))
+	h.AggregateID, _ = uuid.Parse(msg.Metadata.Get(MetadataAggregateID))
+	h.AggregateVersion, _ = strconv.Atoi(msg.Metadata.Get(MetadataAggregateVersion))
+	return h
+}
+
+// OnPublish is the cqrs.EventBusConfig.OnPublish hook of the event buses,
+// it stores the header of the event and the publisher span in the message.
+func OnPublish(params cqrs.OnEventSendParams) error {
+	if e, ok := params.Event.(event.Event); ok {
+		InjectHeader(params.Message, e)
+	}
+	return InjectTraceOnPublish(params)
+}
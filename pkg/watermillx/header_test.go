@@ -0,0 +1,87 @@
+package watermillx
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill/components/cqrs"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/event"
+)
+
+type groupRenamed struct {
+	event.Header
+	Name string `json:"name"`
+}
+
+func (e *groupRenamed) GetStreamName() string { return "events_group" }
+
+func TestInjectHeader(t *testing.T) {
+	groupID, actorID := uuid.New(), uuid.New()
+	var r event.Recorder
+	r.SetVersion(2)
+	r.Record(&groupRenamed{Name: "SE-2301"}, groupID, actorID)
+	e := r.GetUncommittedEvents()[0]
+	h := e.GetEventHeader()
+
+	msg, err := Marshaler.Marshal(e)
+	require.NoError(t, err)
+	require.NoError(t, OnPublish(cqrs.OnEventSendParams{Event: e, Message: msg}))
+
+	assert.Equal(t, h.EventID.String(), msg.UUID, "the message takes the ID of the event")
+	assert.Equal(t, actorID.String(), msg.Metadata.Get(MetadataActorID))
+	assert.Equal(t, groupID.String(), msg.Metadata.Get(MetadataAggregateID))
+	assert.Equal(t, "3", msg.Metadata.Get(MetadataAggregateVersion))
+
+	got := HeaderFromMetadata(msg)
+	assert.Equal(t, h.EventID, got.EventID)
+	assert.True(t, h.OccurredAt.Equal(got.OccurredAt))
+	assert.Equal(t, actorID, got.ActorID)
+	assert.Equal(t, groupID, got.AggregateID)
+	assert.Equal(t, 3, got.AggregateVersion)
+
+	t.Run("the system as the actor is left out", func(t *testing.T) {
+		var r event.Recorder
+		r.Record(&groupRenamed{Name: "SE-2302"}, groupID, uuid.Nil)
+		msg := message.NewMessage(watermill.NewUUID(), nil)
+
+		InjectHeader(msg, r.GetUncommittedEvents()[0])
+		_, ok := msg.Metadata[MetadataActorID]
+		assert.False(t, ok)
+		assert.Equal(t, uuid.Nil, HeaderFromMetadata(msg).ActorID)
+		_, ok = msg.Metadata[MetadataImpersonatorID]
+		assert.False(t, ok)
+	})
+
+	t.Run("impersonator", func(t *testing.T) {
+		impersonatorID := uuid.New()
+		var r event.Recorder
+		r.Record(&groupRenamed{Name: "SE-2303"}, groupID, actorID)
+		e := r.GetUncommittedEvents()[0]
+		event.SetImpersonator(e, impersonatorID)
+		msg := message.NewMessage(watermill.NewUUID(), nil)
+
+		InjectHeader(msg, e)
+		assert.Equal(t, impersonatorID.String(), msg.Metadata.Get(MetadataImpersonatorID))
+		got := HeaderFromMetadata(msg)
+		assert.Equal(t, actorID, got.ActorID)
+		assert.Equal(t, impersonatorID, got.ImpersonatorID)
+	})
+}
+
+func TestHeader_DecodesTheOldNames(t *testing.T) {
+	id := uuid.New()
+	msg := message.NewMessage(watermill.NewUUID(), []byte(
+		`{"ID":"`+id.String()+`","Timestamp":"2026-03-02T09:00:00Z","Metadata":null,"name":"SE-2301"}`))
+
+	var e groupRenamed
+	require.NoError(t, Marshaler.Unmarshal(msg, &e))
+	assert.Equal(t, id, e.EventID)
+	assert.Equal(t, time.Date(2026, time.March, 2, 9, 0, 0, 0, time.UTC), e.OccurredAt)
+	assert.Zero(t, e.AggregateVersion)
+}
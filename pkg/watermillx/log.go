@@ -2,86 +2,195 @@ package watermillx
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/ThreeDotsLabs/watermill"
-	"go.opentelemetry.io/otel/log"
-	"go.opentelemetry.io/otel/log/global"
 )
 
+// LevelTrace is the slog level of watermill Trace lines, below slog.LevelDebug.
+const LevelTrace = slog.LevelDebug - 4
+
+// DefaultSampleEvery is the default 1-in-N ratio of the sampled lines.
+const DefaultSampleEvery = 100
+
+// DefaultSampledPrefixes are the per message lines of the router and the SQL
+// Pub/Sub, one of each is logged for every message handled.
+var DefaultSampledPrefixes = []string{
+	"Received message",
+	"Message acked",
+	"Message nacked",
+	"Sending produced messages",
+	"Inserting message to SQL",
+	"Querying message",
+	"Backing off querying",
+	"Executing ack message query",
+	"Executed ack message query",
+	"Executing query to confirm message consumed",
+	"Executed query to confirm message consumed",
+}
+
+// Sampling configures the sampling of the noisy trace and debug lines. Each
+// prefix is sampled on its own, warnings and errors are never sampled.
+type Sampling struct {
+	// Prefixes of the messages that are sampled.
+	Prefixes []string
+	// Every is N in 1-in-N, 0 or 1 logs every line.
+	Every uint64
+	// Seed is the initial value of the counters, the line is logged when its
+	// counter is a multiple of Every. Tests use it to get a known sequence.
+	Seed uint64
+}
+
+func DefaultSampling() Sampling {
+	return Sampling{
+		Prefixes: DefaultSampledPrefixes,
+		Every:    DefaultSampleEvery,
+	}
+}
+
+// sampler counts the lines of every prefix, it is shared by the loggers
+// created with With.
+type sampler struct {
+	every    uint64
+	prefixes []string
+	counters []atomic.Uint64
+}
+
+func newSampler(s Sampling) *sampler {
+	if s.Every <= 1 || len(s.Prefixes) == 0 {
+		return nil
+	}
+	sm := &sampler{
+		every:    s.Every,
+		prefixes: slices.Clone(s.Prefixes),
+		counters: make([]atomic.Uint64, len(s.Prefixes)),
+	}
+	for i := range sm.counters {
+		sm.counters[i].Store(s.Seed)
+	}
+	return sm
+}
+
+// keep reports whether the line with msg is logged.
+func (s *sampler) keep(msg string) bool {
+	if s == nil {
+		return true
+	}
+	for i, prefix := range s.prefixes {
+		if strings.HasPrefix(msg, prefix) {
+			return (s.counters[i].Add(1)-1)%s.every == 0
+		}
+	}
+	return true
+}
+
 type OTelFilteredSlogLogger struct {
-	logger     *slog.Logger
-	minLevel   slog.Level
-	otelLogger log.Logger
+	logger   *slog.Logger
+	minLevel slog.Level
+	sampler  *sampler
 }
 
+// NewOTelFilteredSlogLogger adapts logger to watermill with DefaultSampling.
+// Lines below minLevel or disabled by the logger handler are dropped.
 func NewOTelFilteredSlogLogger(logger *slog.Logger, minLevel slog.Level) watermill.LoggerAdapter {
+	return NewSampledSlogLogger(logger, minLevel, DefaultSampling())
+}
+
+// NewSampledSlogLogger is NewOTelFilteredSlogLogger with custom sampling.
+func NewSampledSlogLogger(logger *slog.Logger, minLevel slog.Level, sampling Sampling) watermill.LoggerAdapter {
 	return &OTelFilteredSlogLogger{
-		logger:     logger,
-		minLevel:   minLevel,
-		otelLogger: global.GetLoggerProvider().Logger("watermill"),
+		logger:   logger,
+		minLevel: minLevel,
+		sampler:  newSampler(sampling),
 	}
 }
 
-func (l *OTelFilteredSlogLogger) shouldLog(level slog.Level) bool {
+func (l *OTelFilteredSlogLogger) log(level slog.Level, msg string, fields watermill.LogFields, extra ...slog.Attr) {
 	ctx := context.Background()
-
-	var otelSeverity log.Severity
-	switch {
-	case level >= slog.LevelError:
-		otelSeverity = log.SeverityError
-	case level >= slog.LevelWarn:
-		otelSeverity = log.SeverityWarn
-	case level >= slog.LevelInfo:
-		otelSeverity = log.SeverityInfo
-	case level >= slog.LevelDebug:
-		otelSeverity = log.SeverityDebug
-	default:
-		otelSeverity = log.SeverityTrace
+	if level < l.minLevel || !l.logger.Enabled(ctx, level) {
+		return
 	}
-
-	return l.otelLogger.Enabled(ctx, log.EnabledParameters{Severity: otelSeverity})
+	if level < slog.LevelInfo && !l.sampler.keep(msg) {
+		return
+	}
+	l.logger.LogAttrs(ctx, level, msg, fieldsToAttrs(fields, extra...)...)
 }
 
 func (l *OTelFilteredSlogLogger) Error(msg string, err error, fields watermill.LogFields) {
-	if l.shouldLog(slog.LevelError) {
-		l.logger.ErrorContext(context.Background(), msg, l.fieldsToAttrs(fields, slog.Any("error", err))...)
-	}
+	l.log(slog.LevelError, msg, fields, slog.Any("error", err))
 }
 
 func (l *OTelFilteredSlogLogger) Info(msg string, fields watermill.LogFields) {
-	if l.shouldLog(slog.LevelInfo) {
-		l.logger.InfoContext(context.Background(), msg, l.fieldsToAttrs(fields)...)
-	}
+	l.log(slog.LevelInfo, msg, fields)
 }
 
 func (l *OTelFilteredSlogLogger) Debug(msg string, fields watermill.LogFields) {
-	if l.shouldLog(slog.LevelDebug) {
-		l.logger.DebugContext(context.Background(), msg, l.fieldsToAttrs(fields)...)
-	}
+	l.log(slog.LevelDebug, msg, fields)
 }
 
 func (l *OTelFilteredSlogLogger) Trace(msg string, fields watermill.LogFields) {
-	if l.minLevel < slog.LevelDebug {
-		l.logger.DebugContext(context.Background(), msg, l.fieldsToAttrs(fields)...)
-	}
+	l.log(LevelTrace, msg, fields)
 }
 
 func (l *OTelFilteredSlogLogger) With(fields watermill.LogFields) watermill.LoggerAdapter {
+	attrs := fieldsToAttrs(fields)
+	args := make([]any, len(attrs))
+	for i, a := range attrs {
+		args[i] = a
+	}
 	return &OTelFilteredSlogLogger{
-		logger:     l.logger.With(l.fieldsToAttrs(fields)...),
-		minLevel:   l.minLevel,
-		otelLogger: l.otelLogger,
+		logger:   l.logger.With(args...),
+		minLevel: l.minLevel,
+		sampler:  l.sampler,
 	}
 }
 
-func (l *OTelFilteredSlogLogger) fieldsToAttrs(fields watermill.LogFields, extra ...slog.Attr) []any {
-	attrs := make([]any, 0, len(fields)+len(extra))
+// fieldKeys renames the watermill fields to the attribute names used in the
+// rest of the logs.
+var fieldKeys = map[string]string{
+	"handler_name": "handler",
+}
+
+// fieldsToAttrs converts the fields to typed attributes sorted by key, so
+// that message_uuid, topic and handler can be queried in the log backend.
+func fieldsToAttrs(fields watermill.LogFields, extra ...slog.Attr) []slog.Attr {
+	attrs := make([]slog.Attr, 0, len(fields)+len(extra))
 	for k, v := range fields {
-		attrs = append(attrs, slog.Any(k, v))
+		if renamed, ok := fieldKeys[k]; ok {
+			k = renamed
+		}
+		attrs = append(attrs, fieldAttr(k, v))
 	}
-	for _, attr := range extra {
-		attrs = append(attrs, attr)
+	slices.SortFunc(attrs, func(a, b slog.Attr) int { return strings.Compare(a.Key, b.Key) })
+	return append(attrs, extra...)
+}
+
+func fieldAttr(key string, v any) slog.Attr {
+	switch v := v.(type) {
+	case string:
+		return slog.String(key, v)
+	case int:
+		return slog.Int(key, v)
+	case int64:
+		return slog.Int64(key, v)
+	case uint64:
+		return slog.Uint64(key, v)
+	case bool:
+		return slog.Bool(key, v)
+	case time.Duration:
+		return slog.Duration(key, v)
+	case time.Time:
+		return slog.Time(key, v)
+	case error:
+		return slog.String(key, v.Error())
+	case fmt.Stringer:
+		return slog.String(key, v.String())
+	default:
+		return slog.Any(key, v)
 	}
-	return attrs
 }
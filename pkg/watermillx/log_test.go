@@ -0,0 +1,147 @@
+package watermillx
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"testing"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingHandler struct {
+	mu      sync.Mutex
+	level   slog.Level
+	records []slog.Record
+}
+
+func (h *recordingHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level
+}
+
+func (h *recordingHandler) Handle(_ context.Context, r slog.Record) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.records = append(h.records, r)
+	return nil
+}
+
+func (h *recordingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &withAttrsHandler{recordingHandler: h, attrs: attrs}
+}
+
+func (h *recordingHandler) WithGroup(string) slog.Handler { return h }
+
+type withAttrsHandler struct {
+	*recordingHandler
+	attrs []slog.Attr
+}
+
+func (h *withAttrsHandler) Handle(ctx context.Context, r slog.Record) error {
+	r.AddAttrs(h.attrs...)
+	return h.recordingHandler.Handle(ctx, r)
+}
+
+func recordAttrs(r slog.Record) map[string]slog.Value {
+	attrs := map[string]slog.Value{}
+	r.Attrs(func(a slog.Attr) bool {
+		attrs[a.Key] = a.Value
+		return true
+	})
+	return attrs
+}
+
+func TestOTelFilteredSlogLogger_Sampling(t *testing.T) {
+	h := &recordingHandler{level: LevelTrace}
+	l := NewSampledSlogLogger(slog.New(h), LevelTrace, Sampling{
+		Prefixes: []string{"Received message", "Message acked"},
+		Every:    10,
+		Seed:     3,
+	})
+
+	for range 1000 {
+		l.Trace("Received message", nil)
+		l.Debug("Message acked by subscriber", nil)
+		l.Trace("Subscribing to topic", nil)
+	}
+	for range 5 {
+		l.Error("Received message", errors.New("handler failed"), nil)
+		l.Info("Received message", nil)
+	}
+
+	counts := map[string]map[slog.Level]int{}
+	for _, r := range h.records {
+		if counts[r.Message] == nil {
+			counts[r.Message] = map[slog.Level]int{}
+		}
+		counts[r.Message][r.Level]++
+	}
+
+	// The counters start at 3, so lines 8, 18, ... 998 are kept.
+	assert.Equal(t, 100, counts["Received message"][LevelTrace])
+	assert.Equal(t, 100, counts["Message acked by subscriber"][slog.LevelDebug], "each prefix has its own counter")
+	assert.Equal(t, 1000, counts["Subscribing to topic"][LevelTrace], "lines without a sampled prefix all pass")
+	assert.Equal(t, 5, counts["Received message"][slog.LevelError], "errors are never sampled")
+	assert.Equal(t, 5, counts["Received message"][slog.LevelInfo])
+}
+
+func TestOTelFilteredSlogLogger_SeedDecidesFirstLine(t *testing.T) {
+	for seed, want := range map[uint64]int{0: 1, 1: 0, 100: 1} {
+		h := &recordingHandler{level: LevelTrace}
+		l := NewSampledSlogLogger(slog.New(h), LevelTrace, Sampling{
+			Prefixes: []string{"Received message"},
+			Every:    100,
+			Seed:     seed,
+		})
+		l.Trace("Received message", nil)
+		assert.Len(t, h.records, want, "seed %d", seed)
+	}
+}
+
+func TestOTelFilteredSlogLogger_Levels(t *testing.T) {
+	h := &recordingHandler{level: slog.LevelInfo}
+	l := NewSampledSlogLogger(slog.New(h), slog.LevelDebug, Sampling{})
+
+	l.Trace("trace", nil)
+	l.Debug("debug", nil)
+	l.Info("info", nil)
+	l.Error("error", errors.New("boom"), nil)
+
+	require.Len(t, h.records, 2, "lines disabled by the handler are dropped")
+	assert.Equal(t, slog.LevelInfo, h.records[0].Level)
+	assert.Equal(t, slog.LevelError, h.records[1].Level)
+
+	h = &recordingHandler{level: LevelTrace}
+	l = NewSampledSlogLogger(slog.New(h), slog.LevelDebug, Sampling{})
+	l.Trace("trace", nil)
+	l.Debug("debug", nil)
+	require.Len(t, h.records, 1, "lines below minLevel are dropped")
+	assert.Equal(t, slog.LevelDebug, h.records[0].Level)
+}
+
+func TestOTelFilteredSlogLogger_Attributes(t *testing.T) {
+	h := &recordingHandler{level: LevelTrace}
+	l := NewSampledSlogLogger(slog.New(h), LevelTrace, Sampling{}).
+		With(watermill.LogFields{"handler_name": "OnRegistrationStarted", "subscriber_name": "sql"})
+
+	l.Error("Handler returned error", errors.New("boom"), watermill.LogFields{
+		"message_uuid": "0198a1b2-c3d4",
+		"topic":        "registration.RegistrationStarted",
+		"retries":      3,
+	})
+
+	require.Len(t, h.records, 1)
+	attrs := recordAttrs(h.records[0])
+	assert.Equal(t, "0198a1b2-c3d4", attrs["message_uuid"].String())
+	assert.Equal(t, slog.KindString, attrs["topic"].Kind())
+	assert.Equal(t, "registration.RegistrationStarted", attrs["topic"].String())
+	assert.Equal(t, slog.KindInt64, attrs["retries"].Kind())
+	assert.Equal(t, int64(3), attrs["retries"].Int64())
+	assert.Equal(t, "OnRegistrationStarted", attrs["handler"].String(), "handler_name is renamed to handler")
+	assert.NotContains(t, attrs, "handler_name")
+	assert.Equal(t, "sql", attrs["subscriber_name"].String())
+	assert.Equal(t, "boom", attrs["error"].Any().(error).Error())
+}
@@ -0,0 +1,48 @@
+package watermillx
+
+import (
+	"context"
+
+	"github.com/ThreeDotsLabs/watermill/components/cqrs"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/ThreeDotsLabs/watermill/message/router/middleware"
+	"go.opentelemetry.io/otel/propagation"
+
+	"gitlab.com/ucmsv2/ucms-backend/pkg/ctxs"
+)
+
+// propagator writes W3C traceparent, tracestate and baggage entries. It does
+// not depend on the global propagator, so traces are continued even before
+// the SDK is installed.
+var propagator = propagation.NewCompositeTextMapPropagator(
+	propagation.TraceContext{},
+	propagation.Baggage{},
+)
+
+// InjectTrace stores the span context of ctx in the message metadata, and
+// the request ID of ctx as the correlation ID of the message.
+func InjectTrace(ctx context.Context, msg *message.Message) {
+	if msg.Metadata == nil {
+		msg.Metadata = make(message.Metadata)
+	}
+	propagator.Inject(ctx, propagation.MapCarrier(msg.Metadata))
+	if id := ctxs.RequestIDFromCtx(ctx); id != "" && middleware.MessageCorrelationID(msg) == "" {
+		middleware.SetCorrelationID(id, msg)
+	}
+}
+
+// ExtractTrace returns ctx carrying the remote span context stored in the
+// message metadata by InjectTrace. ctx is returned as is if there is none.
+func ExtractTrace(ctx context.Context, msg *message.Message) context.Context {
+	if msg == nil || len(msg.Metadata) == 0 {
+		return ctx
+	}
+	return propagator.Extract(ctx, propagation.MapCarrier(msg.Metadata))
+}
+
+// InjectTraceOnPublish is a cqrs.EventBusConfig.OnPublish hook propagating
+// the publisher span to the message handlers.
+func InjectTraceOnPublish(params cqrs.OnEventSendParams) error {
+	InjectTrace(params.Message.Context(), params.Message)
+	return nil
+}
@@ -0,0 +1,197 @@
+package watermillx
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill"
+	watermillSQL "github.com/ThreeDotsLabs/watermill-sql/v4/pkg/sql"
+	"github.com/ThreeDotsLabs/watermill/components/cqrs"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/ThreeDotsLabs/watermill/message/router/middleware"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/event"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/ctxs"
+)
+
+var tracer = otel.Tracer("ucms/pkg/watermillx")
+
+// PoisonTopic receives the messages that can never be handled, see
+// PoisonError.
+const PoisonTopic = "events_poison"
+
+// PoisonError marks a message that fails the same way on every delivery,
+// e.g. an invalid payload. The poison queue middleware moves it to
+// PoisonTopic instead of redelivering it.
+type PoisonError struct {
+	Err error
+}
+
+func (e *PoisonError) Error() string {
+	return "poison message: " + e.Err.Error()
+}
+
+func (e *PoisonError) Unwrap() error {
+	return e.Err
+}
+
+func IsPoison(err error) bool {
+	var poison *PoisonError
+	return errors.As(err, &poison)
+}
+
+// NewPoisonQueue returns the router middleware publishing the messages that
+// failed with a PoisonError to PoisonTopic, the other failures are retried.
+func NewPoisonQueue(pub message.Publisher) (message.HandlerMiddleware, error) {
+	return middleware.PoisonQueueWithFilter(pub, PoisonTopic, IsPoison)
+}
+
+// NewSQLPoisonQueue is NewPoisonQueue with a publisher writing to the
+// PoisonTopic table of conn.
+func NewSQLPoisonQueue(conn *pgxpool.Pool, logger watermill.LoggerAdapter) (message.HandlerMiddleware, error) {
+	const op = "watermillx.NewSQLPoisonQueue"
+	publisher, err := watermillSQL.NewPublisher(
+		watermillSQL.BeginnerFromPgx(conn),
+		watermillSQL.PublisherConfig{
+			SchemaAdapter: watermillSQL.DefaultPostgreSQLSchema{},
+		},
+		logger,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to create publisher: %w", op, err)
+	}
+	return NewPoisonQueue(publisher)
+}
+
+// poisonMarshaler reports the payloads it cannot unmarshal as poison, the
+// processors unmarshal the events before calling the handlers.
+type poisonMarshaler struct {
+	cqrs.CommandEventMarshaler
+}
+
+func (m poisonMarshaler) Unmarshal(msg *message.Message, v any) error {
+	if err := m.CommandEventMarshaler.Unmarshal(msg, v); err != nil {
+		return &PoisonError{Err: err}
+	}
+	return nil
+}
+
+// Marshaler is the marshaler of the event buses and processors.
+var Marshaler cqrs.CommandEventMarshaler = poisonMarshaler{cqrs.JSONMarshaler{}}
+
+// Validator is implemented by the events checked before they are handled.
+type Validator interface {
+	Validate() error
+}
+
+type TypedOptions struct {
+	// Tracer defaults to the package tracer.
+	Tracer trace.Tracer
+	// DisallowUnknownFields rejects payloads with fields the event does not
+	// have. Off by default, so that publishers can add fields first.
+	DisallowUnknownFields bool
+}
+
+// HandleTyped returns an event handler named name for the events of type T.
+// The payload is decoded again from the original message, strictly, and
+// validated if T implements Validator. Payloads that cannot be decoded or
+// are invalid fail with a PoisonError and handle is not called.
+//
+// handle runs in a consumer span continuing the trace of the publisher, see
+// InjectTrace, with the correlation ID of the message as the request ID of
+// its context. The span carries the event header, see InjectHeader. Failures
+// are recorded on the span with their stack trace.
+func HandleTyped[T any](name string, handle func(ctx context.Context, event *T) error) cqrs.EventHandler {
+	return HandleTypedWith(TypedOptions{}, name, handle)
+}
+
+// HandleTypedWith is HandleTyped with options.
+func HandleTypedWith[T any](opts TypedOptions, name string, handle func(ctx context.Context, event *T) error) cqrs.EventHandler {
+	if opts.Tracer == nil {
+		opts.Tracer = tracer
+	}
+
+	return cqrs.NewEventHandler(name, func(ctx context.Context, event *T) (err error) {
+		msg := cqrs.OriginalMessageFromCtx(ctx)
+		ctx = ExtractTrace(ctx, msg)
+
+		ctx, span := opts.Tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindConsumer))
+		defer span.End()
+		span.SetAttributes(
+			attribute.String("messaging.system", "watermill"),
+			attribute.String("messaging.consumer.group.name", name),
+		)
+		if msg != nil {
+			span.SetAttributes(attribute.String("messaging.message.id", msg.UUID))
+			span.SetAttributes(headerAttributes(HeaderFromMetadata(msg))...)
+			if id := middleware.MessageCorrelationID(msg); id != "" {
+				ctx = ctxs.WithRequestID(ctx, id)
+			}
+		}
+
+		defer func() {
+			if err != nil {
+				span.RecordError(err, trace.WithStackTrace(true))
+				span.SetStatus(codes.Error, err.Error())
+			}
+		}()
+
+		if msg != nil {
+			if event, err = decodeStrict[T](msg.Payload, opts.DisallowUnknownFields); err != nil {
+				return &PoisonError{Err: fmt.Errorf("decode %T: %w", event, err)}
+			}
+		}
+		if v, ok := any(event).(Validator); ok {
+			if err := v.Validate(); err != nil {
+				return &PoisonError{Err: fmt.Errorf("validate %T: %w", event, err)}
+			}
+		}
+
+		return handle(ctx, event)
+	})
+}
+
+func decodeStrict[T any](payload []byte, disallowUnknownFields bool) (*T, error) {
+	event := new(T)
+	dec := json.NewDecoder(bytes.NewReader(payload))
+	if disallowUnknownFields {
+		dec.DisallowUnknownFields()
+	}
+	if err := dec.Decode(event); err != nil {
+		return event, err
+	}
+	if dec.More() {
+		return event, errors.New("unexpected data after the payload")
+	}
+	return event, nil
+}
+
+// headerAttributes returns the span attributes of the set fields of h.
+func headerAttributes(h event.Header) []attribute.KeyValue {
+	var attrs []attribute.KeyValue
+	if h.EventID != uuid.Nil {
+		attrs = append(attrs, attribute.String("event.id", h.EventID.String()))
+	}
+	if !h.OccurredAt.IsZero() {
+		attrs = append(attrs, attribute.String("event.occurred_at", h.OccurredAt.Format(time.RFC3339Nano)))
+	}
+	if h.ActorID != uuid.Nil {
+		attrs = append(attrs, attribute.String("event.actor.id", h.ActorID.String()))
+	}
+	if h.AggregateID != uuid.Nil {
+		attrs = append(attrs,
+			attribute.String("event.aggregate.id", h.AggregateID.String()),
+			attribute.Int("event.aggregate.version", h.AggregateVersion))
+	}
+	return attrs
+}
@@ -0,0 +1,167 @@
+package watermillx
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ARUMANDESU/validation"
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill/components/cqrs"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/ThreeDotsLabs/watermill/message/router/middleware"
+	"github.com/ThreeDotsLabs/watermill/pubsub/gochannel"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/ucmsv2/ucms-backend/pkg/ctxs"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/validationx"
+)
+
+const joinedTopic = "events_joined"
+
+type studentJoined struct {
+	StudentID uuid.UUID `json:"student_id"`
+	GroupID   uuid.UUID `json:"group_id"`
+}
+
+func (e *studentJoined) Validate() error {
+	return validation.ValidateStruct(e,
+		validation.Field(&e.StudentID, validationx.Required),
+		validation.Field(&e.GroupID, validationx.Required),
+	)
+}
+
+type typedPubSub struct {
+	pubsub  *gochannel.GoChannel
+	handled chan handledEvent
+	poison  <-chan *message.Message
+}
+
+type handledEvent struct {
+	event     *studentJoined
+	requestID string
+}
+
+// newTypedPubSub runs a router with the poison queue and a single typed
+// handler on an in-memory pub/sub.
+func newTypedPubSub(t *testing.T, opts TypedOptions) *typedPubSub {
+	t.Helper()
+
+	logger := watermill.NopLogger{}
+	pubsub := gochannel.NewGoChannel(gochannel.Config{Persistent: true}, logger)
+	router, err := message.NewRouter(message.RouterConfig{}, logger)
+	require.NoError(t, err)
+
+	poisonQueue, err := NewPoisonQueue(pubsub)
+	require.NoError(t, err)
+	router.AddMiddleware(poisonQueue)
+
+	ps := &typedPubSub{pubsub: pubsub, handled: make(chan handledEvent, 1)}
+
+	processor, err := cqrs.NewEventProcessorWithConfig(router, cqrs.EventProcessorConfig{
+		GenerateSubscribeTopic: func(cqrs.EventProcessorGenerateSubscribeTopicParams) (string, error) {
+			return joinedTopic, nil
+		},
+		SubscriberConstructor: func(cqrs.EventProcessorSubscriberConstructorParams) (message.Subscriber, error) {
+			return pubsub, nil
+		},
+		Marshaler: Marshaler,
+		Logger:    logger,
+	})
+	require.NoError(t, err)
+	require.NoError(t, processor.AddHandlers(HandleTypedWith(opts, "GroupOnStudentJoined", func(ctx context.Context, e *studentJoined) error {
+		ps.handled <- handledEvent{event: e, requestID: ctxs.RequestIDFromCtx(ctx)}
+		return nil
+	})))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	ps.poison, err = pubsub.Subscribe(ctx, PoisonTopic)
+	require.NoError(t, err)
+	go func() { _ = router.Run(ctx) }()
+	<-router.Running()
+
+	return ps
+}
+
+func (ps *typedPubSub) publish(t *testing.T, e *studentJoined, payload string) {
+	t.Helper()
+	msg, err := Marshaler.Marshal(e)
+	require.NoError(t, err)
+	if payload != "" {
+		msg.Payload = []byte(payload)
+	}
+	InjectTrace(ctxs.WithRequestID(context.Background(), "req-42"), msg)
+	require.NoError(t, ps.pubsub.Publish(joinedTopic, msg))
+}
+
+func (ps *typedPubSub) requirePoisoned(t *testing.T) *message.Message {
+	t.Helper()
+	select {
+	case msg := <-ps.poison:
+		msg.Ack()
+		select {
+		case <-ps.handled:
+			t.Fatal("poison message was handled")
+		default:
+		}
+		return msg
+	case e := <-ps.handled:
+		t.Fatalf("invalid event was handled: %+v", e.event)
+	case <-time.After(5 * time.Second):
+		t.Fatal("message was not moved to the poison topic")
+	}
+	return nil
+}
+
+func TestHandleTyped_Valid(t *testing.T) {
+	ps := newTypedPubSub(t, TypedOptions{})
+	e := &studentJoined{StudentID: uuid.New(), GroupID: uuid.New()}
+
+	ps.publish(t, e, "")
+
+	select {
+	case got := <-ps.handled:
+		assert.Equal(t, e, got.event)
+		assert.Equal(t, "req-42", got.requestID, "the correlation ID is the request ID of the handler")
+	case <-time.After(5 * time.Second):
+		t.Fatal("event was not handled")
+	}
+}
+
+func TestHandleTyped_MissingRequiredField(t *testing.T) {
+	ps := newTypedPubSub(t, TypedOptions{})
+
+	ps.publish(t, &studentJoined{StudentID: uuid.New()}, "")
+
+	msg := ps.requirePoisoned(t)
+	assert.Contains(t, msg.Metadata.Get(middleware.ReasonForPoisonedKey), "group_id")
+}
+
+func TestHandleTyped_MalformedPayload(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    TypedOptions
+		payload string
+	}{
+		{name: "wrong type", payload: `{"student_id": 42}`},
+		{name: "not json", payload: `group`},
+		{
+			name:    "unknown field",
+			opts:    TypedOptions{DisallowUnknownFields: true},
+			payload: `{"student_id":"` + uuid.NewString() + `","group_id":"` + uuid.NewString() + `","grade":5}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ps := newTypedPubSub(t, tt.opts)
+
+			ps.publish(t, &studentJoined{}, tt.payload)
+
+			ps.requirePoisoned(t)
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package announcement
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/application/announcement/announcementquery"
+	"gitlab.com/ucmsv2/ucms-backend/internal/application/notification/notificationquery"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/group"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/notification"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	announcementhttp "gitlab.com/ucmsv2/ucms-backend/internal/ports/http/announcement"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/builders"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/fixtures"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/framework"
+	httpframework "gitlab.com/ucmsv2/ucms-backend/tests/integration/framework/http"
+)
+
+type AnnouncementSuite struct {
+	framework.IntegrationTestSuite
+}
+
+func TestAnnouncementSuite(t *testing.T) {
+	suite.Run(t, new(AnnouncementSuite))
+}
+
+func (s *AnnouncementSuite) seedAITUSA(t *testing.T, groupID group.ID) *user.Student {
+	t.Helper()
+	member := builders.NewStudentBuilder().AsAITUSA().WithEmail(fixtures.ValidStudent4Email).WithGroupID(groupID).Build()
+	s.DB.SeedStudent(t, member)
+	return member
+}
+
+func (s *AnnouncementSuite) listAnnouncements(t *testing.T, opt httpframework.RequestBuilderOptions) []announcementquery.AnnouncementResponse {
+	t.Helper()
+	var res announcementquery.ListAnnouncementsResponse
+	s.HTTP.ListAnnouncements(t, 1, opt).
+		RequireStatus(http.StatusOK).
+		RequireParseJSON(&res)
+	return res.Announcements
+}
+
+func titles(announcements []announcementquery.AnnouncementResponse) []string {
+	got := make([]string, len(announcements))
+	for i, a := range announcements {
+		got[i] = a.Title
+	}
+	return got
+}
+
+func (s *AnnouncementSuite) TestCreate_VisibleByGroup() {
+	t := s.T()
+	se := s.SeedGroup(t)
+	it := group.NewID()
+	s.DB.SeedGroup(t, it, "IT-2402", fixtures.SEGroup.Year, fixtures.SEGroup.Major)
+	member := s.seedAITUSA(t, se)
+	inSE := s.SeedStudent(t, fixtures.ValidStudentEmail, se)
+	inIT := s.SeedStudent(t, fixtures.ValidStudent2Email, it)
+	asMember := httpframework.WithAITUSA(t, member.User().ID())
+
+	s.HTTP.CreateAnnouncement(t, announcementhttp.CreateAnnouncementRequest{
+		Title:     "SE <b>meetup</b>",
+		Body:      "<p>Room 301</p><script>alert(1)</script>",
+		GroupIDs:  []uuid.UUID{uuid.UUID(se)},
+		SendEmail: true,
+	}, asMember).RequireStatus(http.StatusCreated)
+	s.HTTP.CreateAnnouncement(t, announcementhttp.CreateAnnouncementRequest{
+		Title: "Spring fest",
+		Body:  "Everyone is welcome.",
+	}, asMember).RequireStatus(http.StatusCreated)
+
+	seList := s.listAnnouncements(t, httpframework.WithStudent(t, inSE.User().ID()))
+	assert.ElementsMatch(t, []string{"SE meetup", "Spring fest"}, titles(seList))
+	for _, a := range seList {
+		assert.NotContains(t, a.Body, "<", "the markup should be stripped")
+	}
+	assert.Equal(t, []string{"Spring fest"}, titles(s.listAnnouncements(t, httpframework.WithStudent(t, inIT.User().ID()))))
+
+	var notifications notificationquery.ListNotificationsResponse
+	require.Eventually(t, func() bool {
+		s.HTTP.ListNotifications(t, false, httpframework.WithStudent(t, inSE.User().ID())).
+			RequireStatus(http.StatusOK).
+			RequireParseJSON(&notifications)
+		return len(notifications.Notifications) == 2
+	}, 5*time.Second, 50*time.Millisecond, "the SE student should be notified of both")
+	assert.Equal(t, notification.TypeAnnouncement.String(), notifications.Notifications[0].Type)
+
+	require.Eventually(t, func() bool {
+		return len(s.MockMailSender.MailsTo(fixtures.ValidStudentEmail)) == 1
+	}, 5*time.Second, 50*time.Millisecond, "the SE student should get the email of the SE meetup")
+	assert.Empty(t, s.MockMailSender.MailsTo(fixtures.ValidStudent2Email))
+}
+
+func (s *AnnouncementSuite) TestStaffUnpublish() {
+	t := s.T()
+	se := s.SeedGroup(t)
+	member := s.seedAITUSA(t, se)
+	student := s.SeedStudent(t, fixtures.ValidStudentEmail, se)
+	staff := s.SeedStaff(t, fixtures.ValidStaffEmail)
+	asStudent := httpframework.WithStudent(t, student.User().ID())
+	asStaff := httpframework.WithStaff(t, staff.User().ID())
+
+	s.HTTP.CreateAnnouncement(t, announcementhttp.CreateAnnouncementRequest{
+		Title: "Spring fest",
+		Body:  "Everyone is welcome.",
+	}, httpframework.WithAITUSA(t, member.User().ID())).RequireStatus(http.StatusCreated)
+	listed := s.listAnnouncements(t, asStudent)
+	require.Len(t, listed, 1)
+
+	s.HTTP.UnpublishAnnouncement(t, listed[0].ID, asStudent).AssertStatus(http.StatusForbidden)
+	s.HTTP.UnpublishAnnouncement(t, listed[0].ID, asStaff).RequireStatus(http.StatusOK)
+
+	assert.Empty(t, s.listAnnouncements(t, asStudent))
+	moderated := s.listAnnouncements(t, asStaff)
+	require.Len(t, moderated, 1)
+	assert.False(t, moderated[0].Published)
+	assert.NotNil(t, moderated[0].UnpublishedAt)
+
+	s.HTTP.UnpublishAnnouncement(t, uuid.NewString(), asStaff).AssertStatus(http.StatusNotFound)
+}
+
+func (s *AnnouncementSuite) TestCreate_StudentForbidden() {
+	t := s.T()
+	student := s.SeedStudent(t, fixtures.ValidStudentEmail, s.SeedGroup(t))
+	staff := s.SeedStaff(t, fixtures.ValidStaffEmail)
+	req := announcementhttp.CreateAnnouncementRequest{Title: "Spring fest", Body: "Everyone is welcome."}
+
+	s.HTTP.CreateAnnouncement(t, req, httpframework.WithStudent(t, student.User().ID())).
+		AssertStatus(http.StatusForbidden)
+	s.HTTP.CreateAnnouncement(t, req, httpframework.WithStaff(t, staff.User().ID())).
+		AssertStatus(http.StatusForbidden)
+	s.HTTP.CreateAnnouncement(t, req, httpframework.WithAnon()).
+		AssertStatus(http.StatusUnauthorized)
+	assert.Empty(t, s.listAnnouncements(t, httpframework.WithStudent(t, student.User().ID())))
+}
+
+func (s *AnnouncementSuite) TestCreate_UnknownGroup() {
+	t := s.T()
+	member := s.seedAITUSA(t, s.SeedGroup(t))
+
+	s.HTTP.CreateAnnouncement(t, announcementhttp.CreateAnnouncementRequest{
+		Title:    "Spring fest",
+		Body:     "Everyone is welcome.",
+		GroupIDs: []uuid.UUID{uuid.New()},
+	}, httpframework.WithAITUSA(t, member.User().ID())).AssertStatus(http.StatusNotFound)
+}
@@ -69,7 +69,7 @@ func (s *AuthIntegrationSuite) TestAuth_Login() {
 	}{
 		{
 			name:         "login with student email",
-			loginField:   u.Email(),
+			loginField:   u.Email().String(),
 			password:     studentPassword,
 			expectedUID:  u.ID().String(),
 			expectedRole: u.Role().String(),
@@ -83,7 +83,7 @@ func (s *AuthIntegrationSuite) TestAuth_Login() {
 		},
 		{
 			name:         "login with aitusa student email",
-			loginField:   aitusaStudent.Email(),
+			loginField:   aitusaStudent.Email().String(),
 			password:     aitusaStudentPassword,
 			expectedUID:  aitusaStudent.ID().String(),
 			expectedRole: aitusaStudent.Role().String(),
@@ -97,7 +97,7 @@ func (s *AuthIntegrationSuite) TestAuth_Login() {
 		},
 		{
 			name:         "login with staff email",
-			loginField:   staff.Email(),
+			loginField:   staff.Email().String(),
 			password:     staffPassword,
 			expectedUID:  staff.ID().String(),
 			expectedRole: staff.Role().String(),
@@ -275,7 +275,7 @@ func (s *AuthIntegrationSuite) TestAuth_Refresh() {
 
 	s.T().Run("successful refresh with valid token", func(t *testing.T) {
 		// First login to get refresh token
-		loginResp := s.HTTP.Login(t, user.Email(), fixtures.TestStudent.Password)
+		loginResp := s.HTTP.Login(t, user.Email().String(), fixtures.TestStudent.Password)
 		loginResp.AssertSuccess()
 
 		refreshCookie := loginResp.GetCookie(authhttp.RefreshJWTCookie)
@@ -290,7 +290,7 @@ func (s *AuthIntegrationSuite) TestAuth_Refresh() {
 	})
 
 	s.T().Run("successful refresh when user role changes", func(t *testing.T) {
-		loginResp := s.HTTP.Login(t, user.Email(), fixtures.TestStudent.Password)
+		loginResp := s.HTTP.Login(t, user.Email().String(), fixtures.TestStudent.Password)
 		loginResp.AssertSuccess()
 
 		refreshCookie := loginResp.GetCookie(authhttp.RefreshJWTCookie)
@@ -298,7 +298,7 @@ func (s *AuthIntegrationSuite) TestAuth_Refresh() {
 
 		changedUser := builders.NewUserBuilder().
 			WithID(user.ID()).
-			WithEmail(user.Email()).
+			WithEmail(user.Email().String()).
 			WithBarcode(user.Barcode()).
 			WithPassword(fixtures.TestStudent.Password).
 			WithRole(roles.Staff).
@@ -346,7 +346,7 @@ func (s *AuthIntegrationSuite) TestAuth_Logout() {
 
 	s.T().Run("successful logout", func(t *testing.T) {
 		// Login first
-		loginResp := s.HTTP.Login(t, user.Email(), fixtures.TestStudent.Password)
+		loginResp := s.HTTP.Login(t, user.Email().String(), fixtures.TestStudent.Password)
 		loginResp.AssertSuccess()
 
 		accessCookie := loginResp.GetCookie(authhttp.AccessJWTCookie)
@@ -401,7 +401,7 @@ func (s *AuthIntegrationSuite) TestAuth_TokenSecurity() {
 
 	s.T().Run("cross-user token usage", func(t *testing.T) {
 		// Login as user1
-		loginResp := s.HTTP.Login(t, user1.Email(), fixtures.TestStudent.Password)
+		loginResp := s.HTTP.Login(t, user1.Email().String(), fixtures.TestStudent.Password)
 		loginResp.AssertSuccess()
 
 		user1Token := loginResp.GetCookie(authhttp.AccessJWTCookie)
@@ -477,14 +477,14 @@ func (s *AuthIntegrationSuite) TestAuth_EdgeCases() {
 		},
 		{
 			name:            "case sensitivity test",
-			loginField:      strings.ToUpper(user.Email()),
+			loginField:      strings.ToUpper(user.Email().String()),
 			password:        fixtures.TestStudent.Password,
 			expectedStatus:  http.StatusUnauthorized,
 			expectedMessage: "Invalid email/barcode or password",
 		},
 		{
 			name:           "whitespace in credentials", // Successful login with whitespace because http port sanitisizes and normalizes input
-			loginField:     " " + user.Email() + " ",
+			loginField:     " " + user.Email().String() + " ",
 			password:       fixtures.TestStudent.Password,
 			expectedStatus: http.StatusOK,
 		},
@@ -510,7 +510,7 @@ func (s *AuthIntegrationSuite) TestAuth_ContentTypeAndHeaders() {
 	s.T().Run("missing content-type header", func(t *testing.T) {
 		resp := s.HTTP.Do(t, httpframework.NewRequest("POST", "/v1/auth/login").
 			WithJSON(map[string]string{
-				"email_barcode": user.Email(),
+				"email_barcode": user.Email().String(),
 				"password":      fixtures.TestStudent.Password,
 			}).
 			WithHeader("Content-Type", "").
@@ -522,7 +522,7 @@ func (s *AuthIntegrationSuite) TestAuth_ContentTypeAndHeaders() {
 	s.T().Run("wrong content-type header", func(t *testing.T) {
 		resp := s.HTTP.Do(t, httpframework.NewRequest("POST", "/v1/auth/login").
 			WithJSON(map[string]string{
-				"email_barcode": user.Email(),
+				"email_barcode": user.Email().String(),
 				"password":      fixtures.TestStudent.Password,
 			}).
 			WithHeader("Content-Type", "text/plain").
@@ -600,7 +600,7 @@ func (s *AuthIntegrationSuite) TestAuth_RoleBasedAccess() {
 
 	for _, tc := range testCases {
 		s.T().Run(tc.name, func(t *testing.T) {
-			resp := s.HTTP.Login(t, tc.user.Email(), tc.password)
+			resp := s.HTTP.Login(t, tc.user.Email().String(), tc.password)
 			resp.AssertSuccess()
 
 			s.assertValidAccessToken(t, resp, tc.user.ID().String(), tc.expectedRole)
@@ -1115,7 +1115,7 @@ func (s *AuthIntegrationSuite) TestAuth_PasswordFieldInjections() {
 
 	for _, tc := range testCases {
 		s.T().Run(tc.name, func(t *testing.T) {
-			resp := s.HTTP.Login(t, user.Email(), tc.password)
+			resp := s.HTTP.Login(t, user.Email().String(), tc.password)
 			resp.AssertStatus(tc.expectedStatus)
 			if tc.expectedMessage != "" {
 				resp.AssertContainsMessage(tc.expectedMessage)
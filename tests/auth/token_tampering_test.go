@@ -0,0 +1,119 @@
+package auth
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	staffhttp "gitlab.com/ucmsv2/ucms-backend/internal/ports/http/staff"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/builders"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/fixtures"
+	httpframework "gitlab.com/ucmsv2/ucms-backend/tests/integration/framework/http"
+)
+
+// tamperings turn a valid token into one the server must reject. The kid
+// cases sign with the key of another token type and point the kid at it,
+// which is accepted only if the server picks its key by the header.
+func tamperings(otherKid, otherKey string) []struct {
+	name   string
+	tamper func(t *testing.T, b *builders.JWTBuilder) string
+} {
+	return []struct {
+		name   string
+		tamper func(t *testing.T, b *builders.JWTBuilder) string
+	}{
+		{
+			name: "alg none",
+			tamper: func(t *testing.T, b *builders.JWTBuilder) string {
+				return b.WithAlgNone().BuildSignedStringT(t)
+			},
+		},
+		{
+			name: "kid of another token type",
+			tamper: func(t *testing.T, b *builders.JWTBuilder) string {
+				return b.WithKid(otherKid).WithSecret([]byte(otherKey)).BuildSignedStringT(t)
+			},
+		},
+		{
+			name: "unknown kid with wrong key",
+			tamper: func(t *testing.T, b *builders.JWTBuilder) string {
+				return b.WithKid("../../../dev/null").SignedWithWrongKeyT(t)
+			},
+		},
+	}
+}
+
+func (s *AuthIntegrationSuite) TestAuth_TokenTampering() {
+	staff := s.SeedStaff(s.T(), fixtures.TestStaff.Email)
+	uid := staff.User().ID().String()
+	role := staff.User().Role().String()
+
+	s.T().Run("access token", func(t *testing.T) {
+		valid := builders.JWTFactory{}.AccessTokenBuilder(uid, role).BuildSignedStringT(t)
+		resp := s.HTTP.ExportUserData(t, httpframework.WithAccessTokenCookie(valid))
+		require.NotEqual(t, http.StatusUnauthorized, resp.Code, "the untampered token passes the auth middleware")
+
+		for _, tt := range tamperings("refresh", fixtures.RefreshTokenSecretKey) {
+			t.Run(tt.name, func(t *testing.T) {
+				token := tt.tamper(t, builders.JWTFactory{}.AccessTokenBuilder(uid, role))
+				s.HTTP.ExportUserData(t, httpframework.WithAccessTokenCookie(token)).
+					AssertStatus(http.StatusUnauthorized)
+			})
+		}
+
+		t.Run("unknown role", func(t *testing.T) {
+			token := builders.JWTFactory{}.AccessTokenBuilder(uid, "admin").BuildSignedStringT(t)
+			s.HTTP.ExportUserData(t, httpframework.WithAccessTokenCookie(token)).
+				AssertStatus(http.StatusUnauthorized)
+		})
+	})
+
+	s.T().Run("refresh token", func(t *testing.T) {
+		valid := builders.JWTFactory{}.RefreshTokenBuilder(uid).BuildSignedStringT(t)
+		s.HTTP.Refresh(t, valid).AssertSuccess()
+
+		for _, tt := range tamperings("access", fixtures.AccessTokenSecretKey) {
+			t.Run(tt.name, func(t *testing.T) {
+				token := tt.tamper(t, builders.JWTFactory{}.RefreshTokenBuilder(uid))
+				s.HTTP.Refresh(t, token).
+					AssertStatus(http.StatusUnauthorized).
+					AssertContainsMessage("Invalid Credentials")
+			})
+		}
+	})
+
+	s.T().Run("invitation token", func(t *testing.T) {
+		email := fixtures.TestStaff2.Email
+		invitation := builders.NewStaffInvitationBuilder().
+			WithCreatorID(staff.User().ID()).
+			WithAppendRecipientsEmail(email).
+			Build()
+		s.DB.SeedStaffInvitation(t, invitation)
+
+		accept := func(t *testing.T, token string) *httpframework.Response {
+			return s.HTTP.AcceptStaffInvitation(t, staffhttp.AcceptInvitationRequest{
+				Token:     token,
+				Barcode:   fixtures.TestStaff2.Barcode.String(),
+				Username:  fixtures.TestStaff2.Username,
+				Password:  fixtures.TestStaff2.Password,
+				FirstName: fixtures.TestStaff2.FirstName,
+				LastName:  fixtures.TestStaff2.LastName,
+			})
+		}
+
+		for _, tt := range tamperings("access", fixtures.AccessTokenSecretKey) {
+			t.Run(tt.name, func(t *testing.T) {
+				token := tt.tamper(t, builders.JWTFactory{}.InvitationTokenBuilder(email, invitation.Code()))
+				accept(t, token).AssertStatus(http.StatusUnauthorized)
+			})
+		}
+		s.DB.RequireStaffNotExistsByEmail(t, email)
+
+		// the untampered token is accepted, so the rejections above are
+		// down to the tampering alone
+		valid := builders.JWTFactory{}.InvitationTokenBuilder(email, invitation.Code()).BuildSignedStringT(t)
+		accept(t, valid).AssertStatus(http.StatusCreated)
+		s.DB.RequireStaffExistsByEmail(t, email)
+	})
+}
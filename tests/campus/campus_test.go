@@ -0,0 +1,81 @@
+package campus
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/adapters/repos/postgres"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/group"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/ctxs"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/builders"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/fixtures"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/framework"
+)
+
+const serviceCampus = "north"
+
+type CampusSuite struct {
+	framework.IntegrationTestSuite
+}
+
+func TestCampusSuite(t *testing.T) {
+	suite.Run(t, new(CampusSuite))
+}
+
+func (s *CampusSuite) SetupSuite() {
+	ctxs.SetServiceCampus(serviceCampus)
+	s.IntegrationTestSuite.SetupSuite()
+}
+
+func (s *CampusSuite) TearDownSuite() {
+	s.IntegrationTestSuite.TearDownSuite()
+	ctxs.SetServiceCampus(ctxs.DefaultCampus)
+}
+
+func (s *CampusSuite) TestNewRegistration_CarriesServiceCampus() {
+	t := s.T()
+	email := "campus@test.com"
+
+	s.HTTP.StartStudentRegistration(t, email).AssertAccepted()
+
+	var campus string
+	require.NoError(t, s.DB.QueryOne(t, "SELECT campus_id FROM registrations WHERE email = $1", email).Scan(&campus))
+	assert.Equal(t, serviceCampus, campus)
+}
+
+func (s *CampusSuite) TestScopedReads() {
+	t := s.T()
+	southCtx := ctxs.WithCampus(t.Context(), "south")
+
+	groups := postgres.NewGroupRepo(s.Pool(), nil, nil)
+	g := group.Rehydrate(group.RehydrateArgs{
+		ID:        fixtures.SEGroup.ID,
+		Name:      fixtures.SEGroup.Name,
+		Major:     fixtures.SEGroup.Major,
+		Year:      fixtures.SEGroup.Year,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	})
+	require.NoError(t, groups.SaveGroup(southCtx, g))
+
+	registrations := postgres.NewRegistrationRepo(s.Pool(), nil, nil)
+	reg := builders.NewRegistrationBuilder().WithEmail("south@test.com").Build()
+	require.NoError(t, registrations.SaveRegistration(southCtx, reg))
+
+	_, err := groups.GetGroupByID(t.Context(), g.ID())
+	require.NoError(t, err, "reads are not scoped by default")
+	_, err = groups.GetGroupByID(ctxs.WithCampusScope(t.Context(), "south"), g.ID())
+	require.NoError(t, err)
+	_, err = groups.GetGroupByID(ctxs.WithCampusScope(t.Context(), serviceCampus), g.ID())
+	assert.True(t, errorx.IsNotFound(err), "groups of other campuses are filtered out, got %v", err)
+
+	_, err = registrations.GetRegistrationByEmail(ctxs.WithCampusScope(t.Context(), "south"), "south@test.com")
+	require.NoError(t, err)
+	_, err = registrations.GetRegistrationByID(ctxs.WithCampusScope(t.Context(), serviceCampus), reg.ID())
+	assert.True(t, errorx.IsNotFound(err), "registrations of other campuses are filtered out, got %v", err)
+}
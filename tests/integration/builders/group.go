@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/group"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/term"
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/majors"
 	"gitlab.com/ucmsv2/ucms-backend/tests/integration/fixtures"
 )
@@ -39,6 +40,9 @@ type GroupBuilder struct {
 	year      string
 	createdAt time.Time
 	updatedAt time.Time
+	opensAt   *time.Time
+	closesAt  *time.Time
+	termID    *term.ID
 }
 
 func NewGroupBuilder() *GroupBuilder {
@@ -82,13 +86,30 @@ func (b *GroupBuilder) WithUpdatedAt(updatedAt time.Time) *GroupBuilder {
 	return b
 }
 
+// WithEnrollmentWindow sets the window the students may join the group in,
+// a nil end leaves that side open.
+func (b *GroupBuilder) WithEnrollmentWindow(opensAt, closesAt *time.Time) *GroupBuilder {
+	b.opensAt = opensAt
+	b.closesAt = closesAt
+	return b
+}
+
+// WithTermID sets the term the group studies in.
+func (b *GroupBuilder) WithTermID(id term.ID) *GroupBuilder {
+	b.termID = &id
+	return b
+}
+
 func (b *GroupBuilder) Build() *group.Group {
 	return group.Rehydrate(group.RehydrateArgs{
-		ID:        b.id,
-		Name:      b.name,
-		Major:     b.major,
-		Year:      b.year,
-		CreatedAt: b.createdAt,
-		UpdatedAt: b.updatedAt,
+		ID:                 b.id,
+		Name:               b.name,
+		Major:              b.major,
+		Year:               b.year,
+		CreatedAt:          b.createdAt,
+		UpdatedAt:          b.updatedAt,
+		EnrollmentOpensAt:  b.opensAt,
+		EnrollmentClosesAt: b.closesAt,
+		TermID:             b.termID,
 	})
 }
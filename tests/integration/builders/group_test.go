@@ -0,0 +1,22 @@
+package builders
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/group"
+)
+
+func TestGroupBuilder_RespectsInvariants(t *testing.T) {
+	factory := GroupFactory{}
+	for _, g := range []*group.Group{factory.DefaultSEGroup(), factory.ITGroup(), factory.CSGroup()} {
+		t.Run(g.Name(), func(t *testing.T) {
+			_, err := group.NewGroup(g.Name(), g.Year(), g.Major())
+			require.NoError(t, err, "the builder defaults must pass the group validation")
+			assert.NotEmpty(t, g.ID())
+			assert.False(t, g.CreatedAt().IsZero())
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package builders
 
 import (
+	"crypto/rand"
 	"maps"
 	"net/http"
 	"testing"
@@ -49,10 +50,25 @@ func (f JWTFactory) RefreshTokenBuilder(userID string) *JWTBuilder {
 		WithSigningMethod(jwt.SigningMethodHS256)
 }
 
+// InvitationTokenBuilder builds the token the staff invitation link
+// redirects with, as staffhttp.SignInvitationJWTToken mints it.
+func (f JWTFactory) InvitationTokenBuilder(email, code string) *JWTBuilder {
+	return NewJWTBuilder().
+		WithIssuer(fixtures.InvitationTokenIssuer).
+		WithSubject(fixtures.InvitationTokenSubject).
+		WithExpiration(time.Now().Add(fixtures.InvitationTokenExp)).
+		WithDuration(fixtures.InvitationTokenExp).
+		WithClaim("invitation_code", code).
+		WithClaim("email", email).
+		WithSecret([]byte(fixtures.InvitationTokenKey)).
+		WithSigningMethod(fixtures.InvitationTokenAlg)
+}
+
 type JWTBuilder struct {
 	secretKey     []byte
 	signingMethod jwt.SigningMethod
 	mapClaims     jwt.MapClaims
+	header        map[string]any
 	tokenDuration *jwt.NumericDate
 	cookieName    string
 	cookiePath    string
@@ -64,6 +80,7 @@ func NewJWTBuilder() *JWTBuilder {
 		secretKey:     []byte(fixtures.AccessTokenSecretKey),
 		signingMethod: jwt.SigningMethodHS256,
 		mapClaims:     jwt.MapClaims{},
+		header:        map[string]any{},
 		tokenDuration: jwt.NewNumericDate(time.Now().Add(authapp.AccessTokenExpDuration)),
 	}
 }
@@ -149,10 +166,27 @@ func (j *JWTBuilder) WithClaim(key string, value any) *JWTBuilder {
 }
 
 func (j *JWTBuilder) WithClaimEmpty(key string) *JWTBuilder {
-	if j.mapClaims == nil {
-		j.mapClaims = make(jwt.MapClaims)
+	return j.WithoutClaim(key)
+}
+
+// WithoutClaim removes the claim name, e.g. to strip a required claim.
+func (j *JWTBuilder) WithoutClaim(name string) *JWTBuilder {
+	delete(j.mapClaims, name)
+	return j
+}
+
+// WithAlgNone leaves the token unsigned with the "none" algorithm.
+func (j *JWTBuilder) WithAlgNone() *JWTBuilder {
+	j.signingMethod = jwt.SigningMethodNone
+	return j
+}
+
+// WithKid sets the kid header, which the server must not pick its key by.
+func (j *JWTBuilder) WithKid(kid string) *JWTBuilder {
+	if j.header == nil {
+		j.header = make(map[string]any)
 	}
-	delete(j.mapClaims, key)
+	j.header["kid"] = kid
 	return j
 }
 
@@ -182,10 +216,15 @@ func (j *JWTBuilder) WithCookieDomain(domain string) *JWTBuilder {
 }
 
 func (j *JWTBuilder) Build() *jwt.Token {
-	return jwt.NewWithClaims(j.signingMethod, j.mapClaims)
+	token := jwt.NewWithClaims(j.signingMethod, j.mapClaims)
+	maps.Copy(token.Header, j.header)
+	return token
 }
 
 func (j *JWTBuilder) BuildSignedString() (string, error) {
+	if j.signingMethod == jwt.SigningMethodNone {
+		return j.Build().SignedString(jwt.UnsafeAllowNoneSignatureType)
+	}
 	return j.Build().SignedString(j.secretKey)
 }
 
@@ -196,6 +235,16 @@ func (j *JWTBuilder) BuildSignedStringT(t *testing.T) string {
 	return jwt
 }
 
+// SignedWithWrongKeyT signs the token with a random key instead of its secret.
+func (j *JWTBuilder) SignedWithWrongKeyT(t *testing.T) string {
+	t.Helper()
+	key := make([]byte, 32)
+	_, _ = rand.Read(key)
+	token, err := j.Build().SignedString(key)
+	require.NoError(t, err, "failed to sign JWT with a wrong key")
+	return token
+}
+
 func (j *JWTBuilder) BuildHTTPCookie() *http.Cookie {
 	token, err := j.BuildSignedString()
 	if err != nil {
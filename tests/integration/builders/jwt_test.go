@@ -0,0 +1,61 @@
+package builders
+
+import (
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	staffhttp "gitlab.com/ucmsv2/ucms-backend/internal/ports/http/staff"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/fixtures"
+)
+
+func TestJWTFactory_InvitationTokenBuilder(t *testing.T) {
+	token := JWTFactory{}.InvitationTokenBuilder("staff@example.com", fixtures.StaffInvitationValidCode).BuildSignedStringT(t)
+
+	code, email, err := staffhttp.ParseInvitationJWTToken(token, fixtures.InvitationTokenAlg, fixtures.InvitationTokenKey)
+	require.NoError(t, err, "the staff port must accept the built token")
+	assert.Equal(t, fixtures.StaffInvitationValidCode, code)
+	assert.Equal(t, "staff@example.com", email)
+}
+
+func TestJWTBuilder_Tampering(t *testing.T) {
+	parse := func(t *testing.T, token string) (*jwt.Token, jwt.MapClaims) {
+		t.Helper()
+		claims := jwt.MapClaims{}
+		parsed, _, err := jwt.NewParser().ParseUnverified(token, claims)
+		require.NoError(t, err)
+		return parsed, claims
+	}
+
+	t.Run("alg none", func(t *testing.T) {
+		token := JWTFactory{}.AccessTokenBuilder("uid", "staff").WithAlgNone().BuildSignedStringT(t)
+
+		parsed, _ := parse(t, token)
+		assert.Equal(t, "none", parsed.Header["alg"])
+		assert.Regexp(t, `\.$`, token, "the token has no signature")
+	})
+
+	t.Run("without claim", func(t *testing.T) {
+		token := JWTFactory{}.RefreshTokenBuilder("uid").WithoutClaim("jti").BuildSignedStringT(t)
+
+		_, claims := parse(t, token)
+		assert.NotContains(t, claims, "jti")
+		assert.Contains(t, claims, "uid")
+	})
+
+	t.Run("kid", func(t *testing.T) {
+		token := JWTFactory{}.AccessTokenBuilder("uid", "staff").WithKid("refresh").BuildSignedStringT(t)
+
+		parsed, _ := parse(t, token)
+		assert.Equal(t, "refresh", parsed.Header["kid"])
+	})
+
+	t.Run("wrong key", func(t *testing.T) {
+		builder := JWTFactory{}.InvitationTokenBuilder("staff@example.com", fixtures.StaffInvitationValidCode)
+
+		_, _, err := staffhttp.ParseInvitationJWTToken(builder.SignedWithWrongKeyT(t), fixtures.InvitationTokenAlg, fixtures.InvitationTokenKey)
+		assert.ErrorIs(t, err, jwt.ErrSignatureInvalid)
+	})
+}
@@ -1,9 +1,12 @@
 package builders
 
 import (
+	"fmt"
 	"time"
 
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/registration"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/emails"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
 	"gitlab.com/ucmsv2/ucms-backend/pkg/env"
 	"gitlab.com/ucmsv2/ucms-backend/pkg/randcode"
 )
@@ -40,7 +43,7 @@ func (f *RegistrationFactory) RegistrationWithFailedAttempts(email string, attem
 
 type RegistrationBuilder struct {
 	id               registration.ID
-	email            string
+	email            emails.Email
 	status           registration.Status
 	verificationCode string
 	codeAttempts     int8
@@ -48,6 +51,9 @@ type RegistrationBuilder struct {
 	resendTimeout    time.Time
 	createdAt        time.Time
 	updatedAt        time.Time
+	clock            clock.Clock
+	// base is the time the defaults are relative to.
+	base time.Time
 }
 
 func NewRegistrationBuilder() *RegistrationBuilder {
@@ -64,16 +70,31 @@ func NewRegistrationBuilder() *RegistrationBuilder {
 		resendTimeout:    now.Add(1 * time.Minute),
 		createdAt:        now,
 		updatedAt:        now,
+		base:             now,
 	}
 }
 
+// WithClock sets the clock of the registration, the times set so far are
+// moved to it.
+func (b *RegistrationBuilder) WithClock(c clock.Clock) *RegistrationBuilder {
+	shift := c.Now().Sub(b.base)
+	b.clock = c
+	b.base = c.Now()
+	b.codeExpiresAt = b.codeExpiresAt.Add(shift)
+	b.resendTimeout = b.resendTimeout.Add(shift)
+	b.createdAt = b.createdAt.Add(shift)
+	b.updatedAt = b.updatedAt.Add(shift)
+	return b
+}
+
 func (b *RegistrationBuilder) WithID(id registration.ID) *RegistrationBuilder {
 	b.id = id
 	return b
 }
 
+// WithEmail sets the address as is, it is not normalized nor validated.
 func (b *RegistrationBuilder) WithEmail(email string) *RegistrationBuilder {
-	b.email = email
+	b.email = emails.Email(email)
 	return b
 }
 
@@ -92,23 +113,48 @@ func (b *RegistrationBuilder) WithCodeAttempts(attempts int8) *RegistrationBuild
 	return b
 }
 
+// WithAttempts sets the failed verification attempts. Reaching
+// registration.MaxVerificationCodeAttempts expires the registration, as
+// VerifyCode does.
+func (b *RegistrationBuilder) WithAttempts(n int) *RegistrationBuilder {
+	if n < 0 || n > registration.MaxVerificationCodeAttempts {
+		panic(fmt.Sprintf("builders: %d verification attempts, want 0..%d", n, registration.MaxVerificationCodeAttempts))
+	}
+	b.codeAttempts = int8(n)
+	if n == registration.MaxVerificationCodeAttempts {
+		b.status = registration.StatusExpired
+	}
+	return b
+}
+
+// WithCreatedAt sets the creation time and derives the code expiry and
+// resend timeout from it as NewRegistration does, e.g. for the cleanup of
+// old registrations. Set them afterwards to override.
+func (b *RegistrationBuilder) WithCreatedAt(t time.Time) *RegistrationBuilder {
+	b.createdAt = t
+	b.updatedAt = t
+	b.codeExpiresAt = t.Add(registration.ExpiresAt)
+	b.resendTimeout = t.Add(registration.ResendTimeout)
+	return b
+}
+
 func (b *RegistrationBuilder) WithMaxAttemptsReached() *RegistrationBuilder {
 	b.codeAttempts = registration.MaxVerificationCodeAttempts
 	return b
 }
 
 func (b *RegistrationBuilder) WithExpiredCode() *RegistrationBuilder {
-	b.codeExpiresAt = time.Now().Add(-1 * time.Hour)
+	b.codeExpiresAt = b.now().Add(-1 * time.Hour)
 	return b
 }
 
 func (b *RegistrationBuilder) WithResendAvailable() *RegistrationBuilder {
-	b.resendTimeout = time.Now().Add(-1 * time.Minute)
+	b.resendTimeout = b.now().Add(-1 * time.Minute)
 	return b
 }
 
 func (b *RegistrationBuilder) WithResendNotAvailable() *RegistrationBuilder {
-	b.resendTimeout = time.Now().Add(1 * time.Minute)
+	b.resendTimeout = b.now().Add(1 * time.Minute)
 	return b
 }
 
@@ -118,7 +164,7 @@ func (b *RegistrationBuilder) Completed() *RegistrationBuilder {
 }
 
 func (b *RegistrationBuilder) Expired() *RegistrationBuilder {
-	b.codeExpiresAt = time.Now().Add(-1 * time.Hour)
+	b.codeExpiresAt = b.now().Add(-1 * time.Hour)
 	return b
 }
 
@@ -133,9 +179,14 @@ func (b *RegistrationBuilder) Build() *registration.Registration {
 		ResendTimeout:    b.resendTimeout,
 		CreatedAt:        b.createdAt,
 		UpdatedAt:        b.updatedAt,
+		Clock:            b.clock,
 	})
 }
 
 func (b *RegistrationBuilder) BuildNew() (*registration.Registration, error) {
-	return registration.NewRegistration(b.email, env.Test)
+	return registration.NewRegistration(b.email, env.Test, b.clock)
+}
+
+func (b *RegistrationBuilder) now() time.Time {
+	return clock.Or(b.clock).Now()
 }
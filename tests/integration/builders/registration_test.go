@@ -0,0 +1,71 @@
+package builders
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/registration"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
+)
+
+var testNow = time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+
+func TestRegistrationBuilder_Defaults(t *testing.T) {
+	reg := NewRegistrationBuilder().WithClock(clock.NewFake(testNow)).Build()
+
+	assert.Equal(t, registration.StatusPending, reg.Status())
+	assert.Len(t, reg.VerificationCode(), registration.VerificationCodeLength)
+	assert.WithinDuration(t, testNow.Add(registration.ExpiresAt), reg.CodeExpiresAt(), 0)
+	assert.WithinDuration(t, testNow.Add(registration.ResendTimeout), reg.ResendTimeout(), 0)
+
+	require.NoError(t, reg.VerifyCode(reg.VerificationCode()), "a default registration accepts its code")
+	assert.Equal(t, registration.StatusVerified, reg.Status())
+}
+
+func TestRegistrationBuilder_WithAttempts(t *testing.T) {
+	t.Run("one attempt left", func(t *testing.T) {
+		reg := NewRegistrationBuilder().WithAttempts(registration.MaxVerificationCodeAttempts - 1).Build()
+
+		err := reg.VerifyCode("wrong")
+		assert.ErrorIs(t, err, registration.ErrPersistentTooManyAttempts)
+		assert.Equal(t, registration.StatusExpired, reg.Status())
+	})
+
+	t.Run("max attempts expire the registration", func(t *testing.T) {
+		reg := NewRegistrationBuilder().WithAttempts(registration.MaxVerificationCodeAttempts).Build()
+
+		assert.Equal(t, registration.StatusExpired, reg.Status())
+		assert.Error(t, reg.VerifyCode(reg.VerificationCode()))
+	})
+
+	t.Run("out of range", func(t *testing.T) {
+		assert.Panics(t, func() { NewRegistrationBuilder().WithAttempts(-1) })
+		assert.Panics(t, func() { NewRegistrationBuilder().WithAttempts(registration.MaxVerificationCodeAttempts + 1) })
+	})
+}
+
+func TestRegistrationBuilder_WithCreatedAt(t *testing.T) {
+	createdAt := testNow.Add(-48 * time.Hour)
+	reg := NewRegistrationBuilder().
+		WithClock(clock.NewFake(testNow)).
+		WithCreatedAt(createdAt).
+		Build()
+
+	assert.WithinDuration(t, createdAt, reg.CreatedAt(), 0)
+	assert.WithinDuration(t, createdAt, reg.UpdatedAt(), 0)
+	assert.WithinDuration(t, createdAt.Add(registration.ExpiresAt), reg.CodeExpiresAt(), 0)
+	assert.WithinDuration(t, createdAt.Add(registration.ResendTimeout), reg.ResendTimeout(), 0)
+
+	err := reg.VerifyCode(reg.VerificationCode())
+	assert.ErrorIs(t, err, registration.ErrCodeExpired, "an old registration's code has expired")
+}
+
+func TestRegistrationBuilder_WithStatus(t *testing.T) {
+	reg := NewRegistrationBuilder().WithStatus(registration.StatusVerified).Build()
+
+	assert.Equal(t, registration.StatusVerified, reg.Status())
+	require.NoError(t, reg.CheckCode(reg.VerificationCode()), "a verified registration passes the code check")
+}
@@ -5,6 +5,7 @@ import (
 
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/staffinvitation"
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
 	"gitlab.com/ucmsv2/ucms-backend/pkg/randcode"
 	"gitlab.com/ucmsv2/ucms-backend/tests/integration/fixtures"
 )
@@ -16,9 +17,12 @@ type StaffInvitationBuilder struct {
 	validFrom       *time.Time
 	validUntil      *time.Time
 	creatorID       user.ID
+	department      string
+	position        string
 	createdAt       time.Time
 	updatedAt       time.Time
 	deletedAt       *time.Time
+	clock           clock.Clock
 }
 
 func NewStaffInvitationBuilder() *StaffInvitationBuilder {
@@ -33,6 +37,15 @@ func NewStaffInvitationBuilder() *StaffInvitationBuilder {
 	}
 }
 
+// WithClock sets the clock of the invitation, it is created and updated now
+// on c.
+func (b *StaffInvitationBuilder) WithClock(c clock.Clock) *StaffInvitationBuilder {
+	b.clock = c
+	b.createdAt = c.Now()
+	b.updatedAt = c.Now()
+	return b
+}
+
 func (b *StaffInvitationBuilder) WithID(id staffinvitation.ID) *StaffInvitationBuilder {
 	b.id = id
 	return b
@@ -68,6 +81,13 @@ func (b *StaffInvitationBuilder) WithCreatorID(creatorID user.ID) *StaffInvitati
 	return b
 }
 
+// WithDepartmentAndPosition sets what the invitation pre-fills.
+func (b *StaffInvitationBuilder) WithDepartmentAndPosition(department, position string) *StaffInvitationBuilder {
+	b.department = department
+	b.position = position
+	return b
+}
+
 func (b *StaffInvitationBuilder) WithCreatedAt(createdAt time.Time) *StaffInvitationBuilder {
 	b.createdAt = createdAt
 	return b
@@ -91,8 +111,11 @@ func (b *StaffInvitationBuilder) Build() *staffinvitation.StaffInvitation {
 		ValidFrom:       b.validFrom,
 		ValidUntil:      b.validUntil,
 		CreatorID:       b.creatorID,
+		Department:      b.department,
+		Position:        b.position,
 		CreatedAt:       b.createdAt,
 		UpdatedAt:       b.updatedAt,
 		DeletedAt:       b.deletedAt,
+		Clock:           b.clock,
 	})
 }
@@ -11,6 +11,7 @@ import (
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/registration"
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/avatars"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/emails"
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/roles"
 	"gitlab.com/ucmsv2/ucms-backend/tests/integration/fixtures"
 )
@@ -49,13 +50,15 @@ type UserBuilder struct {
 	username  string
 	firstName string
 	lastName  string
-	email     string
+	email     emails.Email
 	password  string
 	passHash  []byte
 	avatar    avatars.Avatar
 	role      roles.Global
-	createdAt time.Time
-	updatedAt time.Time
+	// emailUnverified leaves the email to verify, like the initial staff.
+	emailUnverified bool
+	createdAt       time.Time
+	updatedAt       time.Time
 }
 
 func NewUserBuilder() *UserBuilder {
@@ -68,7 +71,7 @@ func NewUserBuilder() *UserBuilder {
 		username:  fmt.Sprintf("user_%d_%d", rand.Uint()%1000, now.UnixNano()),
 		firstName: fixtures.TestStudent.FirstName,
 		lastName:  fixtures.TestStudent.LastName,
-		email:     fmt.Sprintf("%s@test.com", uuid.NewString()),
+		email:     emails.Email(fmt.Sprintf("%s@test.com", uuid.NewString())),
 		password:  fixtures.TestStudent.Password,
 		passHash:  hash,
 		avatar:    avatars.Avatar{},
@@ -104,8 +107,9 @@ func (b *UserBuilder) WithName(firstName, lastName string) *UserBuilder {
 	return b
 }
 
+// WithEmail sets the address as is, it is not normalized nor validated.
 func (b *UserBuilder) WithEmail(email string) *UserBuilder {
-	b.email = email
+	b.email = emails.Email(email)
 	return b
 }
 
@@ -146,6 +150,12 @@ func (b *UserBuilder) AsAITUSA() *UserBuilder {
 	return b
 }
 
+// WithEmailUnverified builds a user who did not verify their email yet.
+func (b *UserBuilder) WithEmailUnverified() *UserBuilder {
+	b.emailUnverified = true
+	return b
+}
+
 func (b *UserBuilder) WithS3Avatar(s3Key string) *UserBuilder {
 	b.avatar = avatars.NewS3Avatar(s3Key)
 	return b
@@ -174,47 +184,50 @@ func (b *UserBuilder) WithGeneratedS3Avatar() *UserBuilder {
 
 func (b *UserBuilder) Build() *user.User {
 	return user.RehydrateUser(user.RehydrateUserArgs{
-		ID:        b.id,
-		Barcode:   b.barcode,
-		Username:  b.username,
-		FirstName: b.firstName,
-		LastName:  b.lastName,
-		Role:      b.role,
-		Avatar:    b.avatar,
-		Email:     b.email,
-		PassHash:  b.passHash,
-		CreatedAt: b.createdAt,
-		UpdatedAt: b.updatedAt,
+		ID:            b.id,
+		Barcode:       b.barcode,
+		Username:      b.username,
+		FirstName:     b.firstName,
+		LastName:      b.lastName,
+		Role:          b.role,
+		Avatar:        b.avatar,
+		Email:         b.email,
+		PassHash:      b.passHash,
+		EmailVerified: !b.emailUnverified,
+		CreatedAt:     b.createdAt,
+		UpdatedAt:     b.updatedAt,
 	})
 }
 
 func (b *UserBuilder) RehydrateArgs() user.RehydrateUserArgs {
 	return user.RehydrateUserArgs{
-		ID:        b.id,
-		Barcode:   b.barcode,
-		FirstName: b.firstName,
-		LastName:  b.lastName,
-		Role:      b.role,
-		Avatar:    b.avatar,
-		Email:     b.email,
-		PassHash:  b.passHash,
-		CreatedAt: b.createdAt,
-		UpdatedAt: b.updatedAt,
+		ID:            b.id,
+		Barcode:       b.barcode,
+		FirstName:     b.firstName,
+		LastName:      b.lastName,
+		Role:          b.role,
+		Avatar:        b.avatar,
+		Email:         b.email,
+		PassHash:      b.passHash,
+		EmailVerified: !b.emailUnverified,
+		CreatedAt:     b.createdAt,
+		UpdatedAt:     b.updatedAt,
 	}
 }
 
 func (b *UserBuilder) BuildNew() *user.User {
 	return user.RehydrateUser(user.RehydrateUserArgs{
-		ID:        b.id,
-		Barcode:   b.barcode,
-		FirstName: b.firstName,
-		LastName:  b.lastName,
-		Avatar:    b.avatar,
-		Email:     b.email,
-		PassHash:  b.passHash,
-		CreatedAt: b.createdAt,
-		UpdatedAt: b.updatedAt,
-		Role:      b.role,
+		ID:            b.id,
+		Barcode:       b.barcode,
+		FirstName:     b.firstName,
+		LastName:      b.lastName,
+		Avatar:        b.avatar,
+		Email:         b.email,
+		PassHash:      b.passHash,
+		EmailVerified: !b.emailUnverified,
+		CreatedAt:     b.createdAt,
+		UpdatedAt:     b.updatedAt,
+		Role:          b.role,
 	})
 }
 
@@ -223,6 +236,9 @@ type StudentBuilder struct {
 	UserBuilder
 	groupID        group.ID
 	registrationID registration.ID
+	enrollment     user.EnrollmentStatus
+	leaveUntil     *time.Time
+	expelReason    string
 }
 
 func NewStudentBuilder() *StudentBuilder {
@@ -238,6 +254,15 @@ func (b *StudentBuilder) WithGroupID(groupID group.ID) *StudentBuilder {
 	return b
 }
 
+// WithEnrollment sets the enrollment status, leaveUntil is read for
+// AcademicLeave and reason for Expelled.
+func (b *StudentBuilder) WithEnrollment(status user.EnrollmentStatus, leaveUntil *time.Time, reason string) *StudentBuilder {
+	b.enrollment = status
+	b.leaveUntil = leaveUntil
+	b.expelReason = reason
+	return b
+}
+
 func (b *StudentBuilder) WithID(id user.ID) *StudentBuilder {
 	b.UserBuilder.WithID(id)
 	return b
@@ -332,19 +357,23 @@ func (b *StudentBuilder) WithInvalidShortLastName() *StudentBuilder {
 func (b *StudentBuilder) Build() *user.Student {
 	return user.RehydrateStudent(user.RehydrateStudentArgs{
 		RehydrateUserArgs: user.RehydrateUserArgs{
-			ID:        b.id,
-			Barcode:   b.barcode,
-			Username:  b.username,
-			FirstName: b.firstName,
-			LastName:  b.lastName,
-			Role:      roles.Student,
-			Avatar:    b.avatar,
-			Email:     b.email,
-			PassHash:  b.passHash,
-			CreatedAt: b.createdAt,
-			UpdatedAt: b.updatedAt,
+			ID:            b.id,
+			Barcode:       b.barcode,
+			Username:      b.username,
+			FirstName:     b.firstName,
+			LastName:      b.lastName,
+			Role:          roles.Student,
+			Avatar:        b.avatar,
+			Email:         b.email,
+			PassHash:      b.passHash,
+			EmailVerified: !b.emailUnverified,
+			CreatedAt:     b.createdAt,
+			UpdatedAt:     b.updatedAt,
 		},
-		GroupID: b.groupID,
+		GroupID:          b.groupID,
+		EnrollmentStatus: b.enrollment,
+		LeaveUntil:       b.leaveUntil,
+		ExpelReason:      b.expelReason,
 	})
 }
 
@@ -352,6 +381,9 @@ func (b *StudentBuilder) RehydrateStudentArgs() user.RehydrateStudentArgs {
 	return user.RehydrateStudentArgs{
 		RehydrateUserArgs: b.RehydrateArgs(),
 		GroupID:           b.groupID,
+		EnrollmentStatus:  b.enrollment,
+		LeaveUntil:        b.leaveUntil,
+		ExpelReason:       b.expelReason,
 	}
 }
 
@@ -488,16 +520,17 @@ func (b *StaffBuilder) WithInvalidShortLastName() *StaffBuilder {
 func (b *StaffBuilder) Build() *user.Staff {
 	return user.RehydrateStaff(user.RehydrateStaffArgs{
 		RehydrateUserArgs: user.RehydrateUserArgs{
-			ID:        b.id,
-			Barcode:   b.barcode,
-			Username:  b.username,
-			FirstName: b.firstName,
-			LastName:  b.lastName,
-			Role:      roles.Staff,
-			Email:     b.email,
-			PassHash:  b.passHash,
-			CreatedAt: b.createdAt,
-			UpdatedAt: b.updatedAt,
+			ID:            b.id,
+			Barcode:       b.barcode,
+			Username:      b.username,
+			FirstName:     b.firstName,
+			LastName:      b.lastName,
+			Role:          roles.Staff,
+			Email:         b.email,
+			PassHash:      b.passHash,
+			EmailVerified: !b.emailUnverified,
+			CreatedAt:     b.createdAt,
+			UpdatedAt:     b.updatedAt,
 		},
 	})
 }
@@ -2,7 +2,15 @@ package fixtures
 
 import (
 	"bytes"
+	"crypto/rand"
 	"encoding/base64"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
 	"io"
 	"strings"
 )
@@ -22,6 +30,9 @@ var (
 	LargeJPEGAvatar   = createLargeJPEG()
 	MaxSizeJPEGAvatar = createMaxSizeJPEG()
 
+	AnimatedGIFAvatar = createAnimatedGIF()
+	// HugePNGAvatar declares 20000x20000 pixels, decoding it would need 1.6GB.
+	HugePNGAvatar       = createHugePNG()
 	CorruptedJPEGAvatar = createCorruptedJPEG()
 	InvalidFormatAvatar = createInvalidFormat()
 	EmptyAvatar         = createEmpty()
@@ -166,6 +177,38 @@ func createMaxSizeJPEG() []byte {
 	return append(data, padding...)
 }
 
+func createAnimatedGIF() []byte {
+	palette := color.Palette{color.Black, color.White}
+	frames := make([]*image.Paletted, 2)
+	for i := range frames {
+		frames[i] = image.NewPaletted(image.Rect(0, 0, 8, 8), palette)
+		for p := range frames[i].Pix {
+			frames[i].Pix[p] = uint8(i)
+		}
+	}
+
+	var buf bytes.Buffer
+	_ = gif.EncodeAll(&buf, &gif.GIF{Image: frames, Delay: []int{10, 10}})
+	return buf.Bytes()
+}
+
+func createHugePNG() []byte {
+	chunk := func(typ string, data []byte) []byte {
+		c := binary.BigEndian.AppendUint32(nil, uint32(len(data)))
+		c = append(c, typ...)
+		c = append(c, data...)
+		return binary.BigEndian.AppendUint32(c, crc32.ChecksumIEEE(c[4:]))
+	}
+	ihdr := binary.BigEndian.AppendUint32(nil, 20000)
+	ihdr = binary.BigEndian.AppendUint32(ihdr, 20000)
+	ihdr = append(ihdr, 8, 6, 0, 0, 0)
+
+	data := []byte("\x89PNG\r\n\x1a\n")
+	data = append(data, chunk("IHDR", ihdr)...)
+	data = append(data, chunk("IDAT", make([]byte, 64))...)
+	return append(data, chunk("IEND", nil)...)
+}
+
 func createCorruptedJPEG() []byte {
 	return []byte("This is not a valid JPEG file content, just random bytes that should fail image validation")
 }
@@ -188,6 +231,21 @@ func CreateOversizedJPEG() []byte {
 	return append(data, padding...)
 }
 
+// UniqueJPEGAvatar returns a valid JPEG filled with a random color. Avatars
+// are deduplicated by their processed content, use it when a test needs its
+// own object.
+func UniqueJPEGAvatar() []byte {
+	rgb := make([]byte, 3)
+	_, _ = rand.Read(rgb)
+
+	img := image.NewRGBA(image.Rect(0, 0, 8, 8))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.RGBA{R: rgb[0], G: rgb[1], B: rgb[2], A: 255}), image.Point{}, draw.Src)
+
+	var buf bytes.Buffer
+	_ = jpeg.Encode(&buf, img, &jpeg.Options{Quality: 100})
+	return buf.Bytes()
+}
+
 func CreateRandomJPEGWithSize(targetSize int) []byte {
 	jpegHeader := "/9j/4AAQSkZJRgABAQEAYABgAAD/2wBDAAoHBwgHBgoICAgLCgoLDhgQDg0NDh0VFhEYIx8lJCIfIiEmKzcvJik0KSEiMEExNDk7Pj4+JS5ESUM8SDc9Pjv/2wBDAQoLCw4NDhwQEBw7KCIoOzs7Ozs7Ozs7Ozs7Ozs7Ozs7Ozs7Ozs7Ozs7Ozs7Ozs7Ozs7Ozs7Ozs7Ozs7Ozs7Ozv/wAARCAABAAEDASIAAhEBAxEB/8QAFQABAQAAAAAAAAAAAAAAAAAAAAv/xAAUEAEAAAAAAAAAAAAAAAAAAAAA/8QAFQEBAQAAAAAAAAAAAAAAAAAAAAX/xAAUEQEAAAAAAAAAAAAAAAAAAAAA/9oADAMBAAIRAxEAPwCdABmX/9k="
 	data, _ := base64.StdEncoding.DecodeString(jpegHeader)
@@ -142,7 +142,7 @@ var (
 	}{
 		ID:    group.ID(uuid.MustParse("550e8400-e29b-41d4-a716-446655440000")),
 		Name:  "SE-2301",
-		Year:  "2023",
+		Year:  "1",
 		Major: majors.SE,
 	}
 
@@ -154,7 +154,7 @@ var (
 	}{
 		ID:    group.ID(uuid.MustParse("660e8400-e29b-41d4-a716-446655440001")),
 		Name:  "CS-2301",
-		Year:  "2023",
+		Year:  "1",
 		Major: majors.IT,
 	}
 
@@ -166,7 +166,7 @@ var (
 	}{
 		ID:    group.ID(uuid.MustParse("770e8400-e29b-41d4-a716-446655440002")),
 		Name:  "CS-2301",
-		Year:  "2023",
+		Year:  "1",
 		Major: majors.CS,
 	}
 )
@@ -10,6 +10,11 @@ const (
 	StaffInvitationAcceptPageURL = "http://localhost:3000/invitations/staff/accept"
 	InvitationTokenKey           = "invitation_test_key"
 	InvitationTokenExp           = 15 * time.Minute
+	// InvitationTokenIssuer and InvitationTokenSubject are staffhttp.ISS and
+	// staffhttp.InvitationSubject, which the builders cannot import: the
+	// staff commands the port depends on are tested with the builders.
+	InvitationTokenIssuer  = "ucmsv2_invitation"
+	InvitationTokenSubject = "invitation_validation"
 
 	StaffInvitationValidCode   = "F0WNPKO98NOGYVC5BPOZ"
 	StaffInvitationInvalidCode = "INVALIDCODE123456789"
@@ -0,0 +1,105 @@
+// Package authmatrix checks the access policies of the routes against the
+// running application, so that the suites need no 401 and 403 cases of
+// their own.
+package authmatrix
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/roles"
+	httpport "gitlab.com/ucmsv2/ucms-backend/internal/ports/http"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/fixtures"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/framework"
+	httpframework "gitlab.com/ucmsv2/ucms-backend/tests/integration/framework/http"
+)
+
+// requestTimeout ends the requests to the streaming routes, which would
+// otherwise not return.
+const requestTimeout = 5 * time.Second
+
+// placeholder fills the path parameters, it is a valid UUID that matches no
+// row so that the allowed identities get a 404 or 400 rather than a change.
+const placeholder = "00000000-0000-0000-0000-000000000000"
+
+var pathParam = regexp.MustCompile(`\{[^}]+\}|\*$`)
+
+// Routes returns the policies of httpport.Policies whose pattern starts with
+// one of prefixes.
+func Routes(prefixes ...string) []httpport.RoutePolicy {
+	var routes []httpport.RoutePolicy
+	for _, p := range httpport.Policies {
+		if slices.ContainsFunc(prefixes, func(prefix string) bool { return strings.HasPrefix(p.Pattern, prefix) }) {
+			routes = append(routes, p)
+		}
+	}
+	return routes
+}
+
+type identity struct {
+	name string
+	role roles.Global
+	opts []httpframework.RequestBuilderOptions
+}
+
+// Run requests every route of table as the anonymous user, a student, an
+// AITUSA member and a staff member, and asserts 401 for the anonymous user
+// on the routes needing a token, 403 for the roles the policy does not
+// allow, and any other status below 500 otherwise. There is no admin role,
+// the staff administer the application. The requests carry no body, the
+// allowed identities are expected to fail the validation.
+func Run(t *testing.T, s *framework.IntegrationTestSuite, table []httpport.RoutePolicy) {
+	t.Helper()
+
+	student := s.SeedStudent(t, fixtures.TestStudent.Email, s.SeedGroup(t))
+	staff := s.SeedStaff(t, fixtures.TestStaff.Email)
+	identities := []identity{
+		{name: "anon", opts: []httpframework.RequestBuilderOptions{httpframework.WithAnon()}},
+		{name: "student", role: roles.Student, opts: []httpframework.RequestBuilderOptions{
+			httpframework.WithStudent(t, student.User().ID()),
+		}},
+		// The token carries the role, the seeded student stands in for the
+		// member.
+		{name: "aitusa", role: roles.AITUSA, opts: []httpframework.RequestBuilderOptions{
+			httpframework.WithAITUSA(t, student.User().ID()),
+		}},
+		{name: "staff", role: roles.Staff, opts: []httpframework.RequestBuilderOptions{
+			httpframework.WithStaff(t, staff.User().ID()),
+		}},
+	}
+
+	for _, route := range table {
+		path := pathParam.ReplaceAllString(route.Pattern, placeholder)
+		t.Run(route.Method+" "+route.Pattern, func(t *testing.T) {
+			for _, id := range identities {
+				ctx, cancel := context.WithTimeout(t.Context(), requestTimeout)
+				b := httpframework.NewRequest(route.Method, path).WithContext(ctx)
+				for _, opt := range id.opts {
+					opt(b)
+				}
+				status := s.HTTP.Do(t, b.Build()).Code
+				cancel()
+
+				switch {
+				case route.Access == httpport.Public:
+					assert.Less(t, status, http.StatusInternalServerError, "%s on a %s route", id.name, route.Access)
+				case id.role == "":
+					assert.Equal(t, http.StatusUnauthorized, status, "%s on a %s route", id.name, route.Access)
+				case !route.Access.Allows(id.role):
+					assert.Equal(t, http.StatusForbidden, status, "%s on a %s route", id.name, route.Access)
+				default:
+					assert.NotContains(t, []int{http.StatusUnauthorized, http.StatusForbidden}, status,
+						"%s on a %s route", id.name, route.Access)
+					assert.Less(t, status, http.StatusInternalServerError, "%s on a %s route", id.name, route.Access)
+				}
+			}
+		})
+	}
+}
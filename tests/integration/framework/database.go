@@ -0,0 +1,228 @@
+package framework
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+	"github.com/testcontainers/testcontainers-go/wait"
+
+	ucmsv2 "gitlab.com/ucmsv2/ucms-backend"
+	postgrespkg "gitlab.com/ucmsv2/ucms-backend/pkg/postgres"
+)
+
+// DatabaseURLEnv names the PostgreSQL server the suites use instead of
+// starting a container, e.g. the one of the CI job, as a postgres:// URL.
+// Every suite works in its own database on it, so the test packages can run
+// in parallel against it.
+const DatabaseURLEnv = "UCMS_TEST_DATABASE_URL"
+
+// ReuseEnv makes the suites reuse the container named reusedContainerName
+// across runs instead of starting one per test binary. Together with
+// TESTCONTAINERS_RYUK_DISABLED=true the container outlives the run, and so
+// does the migrated template database in it.
+const ReuseEnv = "TESTCONTAINERS_REUSE"
+
+const reusedContainerName = "ucms-test-postgres"
+
+// templateLockKey is the advisory lock the test binaries take to build the
+// template database and clone it one at a time.
+const templateLockKey = 0x75636d73
+
+var server struct {
+	once    sync.Once
+	connStr string
+	err     error
+}
+
+// serverConnString returns the connection string of the PostgreSQL server
+// the suites of the test binary share, started on first use. Unless reused,
+// the container lives as long as the test binary, the testcontainers reaper
+// removes it.
+func serverConnString(ctx context.Context) (string, error) {
+	server.once.Do(func() {
+		if connStr := os.Getenv(DatabaseURLEnv); connStr != "" {
+			server.connStr = connStr
+			return
+		}
+
+		opts := []testcontainers.ContainerCustomizer{
+			postgres.WithDatabase("ucms_test"),
+			postgres.WithUsername("test"),
+			postgres.WithPassword("test"),
+			testcontainers.WithWaitStrategy(
+				wait.ForLog("database system is ready to accept connections").
+					WithOccurrence(2).
+					WithStartupTimeout(10 * time.Second),
+			),
+		}
+		if reuse, _ := strconv.ParseBool(os.Getenv(ReuseEnv)); reuse {
+			opts = append(opts, testcontainers.WithReuseByName(reusedContainerName))
+		}
+
+		container, err := postgres.Run(ctx, "postgres:17-alpine", opts...)
+		if err != nil {
+			server.err = fmt.Errorf("start postgres: %w", err)
+			return
+		}
+		server.connStr, server.err = container.ConnectionString(ctx, "sslmode=disable")
+	})
+	return server.connStr, server.err
+}
+
+// suiteDatabase is the database a suite works in, cloned from the template
+// database the migrations ran in.
+type suiteDatabase struct {
+	name    string
+	connStr string
+	// adminConnStr connects to the database of the server connection string,
+	// the one the suite databases are created and dropped from.
+	adminConnStr string
+	// builtTemplate reports whether the suite migrated the template, i.e. it
+	// paid the cold start.
+	builtTemplate bool
+}
+
+// createSuiteDatabase clones the template database into a uniquely named
+// database, migrating the template first if this version of the migrations
+// has none yet.
+func createSuiteDatabase(ctx context.Context) (*suiteDatabase, error) {
+	adminConnStr, err := serverConnString(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	suffix := make([]byte, 6)
+	_, _ = rand.Read(suffix)
+	db := &suiteDatabase{name: "suite_" + hex.EncodeToString(suffix), adminConnStr: adminConnStr}
+	db.connStr, err = withDatabase(adminConnStr, db.name)
+	if err != nil {
+		return nil, err
+	}
+
+	template, err := templateName()
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := pgx.Connect(ctx, adminConnStr)
+	if err != nil {
+		return nil, err
+	}
+	// closing the connection releases the lock as well
+	defer conn.Close(ctx)
+
+	if _, err := conn.Exec(ctx, "SELECT pg_advisory_lock($1)", templateLockKey); err != nil {
+		return nil, fmt.Errorf("lock template: %w", err)
+	}
+
+	var exists bool
+	err = conn.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM pg_database WHERE datname = $1)", template).Scan(&exists)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		if err := buildTemplate(ctx, conn, adminConnStr, template); err != nil {
+			return nil, err
+		}
+		db.builtTemplate = true
+	}
+
+	_, err = conn.Exec(ctx, "CREATE DATABASE "+pgx.Identifier{db.name}.Sanitize()+" TEMPLATE "+pgx.Identifier{template}.Sanitize())
+	if err != nil {
+		return nil, fmt.Errorf("clone template %s: %w", template, err)
+	}
+
+	return db, nil
+}
+
+// buildTemplate migrates a database under a temporary name and renames it
+// to template, so that a run interrupted while migrating leaves no template
+// behind.
+func buildTemplate(ctx context.Context, conn *pgx.Conn, adminConnStr, template string) error {
+	building := template + "_building"
+	if _, err := conn.Exec(ctx, "DROP DATABASE IF EXISTS "+pgx.Identifier{building}.Sanitize()+" WITH (FORCE)"); err != nil {
+		return err
+	}
+	if _, err := conn.Exec(ctx, "CREATE DATABASE "+pgx.Identifier{building}.Sanitize()); err != nil {
+		return err
+	}
+
+	connStr, err := withDatabase(adminConnStr, building)
+	if err != nil {
+		return err
+	}
+	if err := postgrespkg.Migrate(strings.Replace(connStr, "postgres://", "pgx://", 1), &ucmsv2.Migrations); err != nil {
+		return fmt.Errorf("migrate template %s: %w", template, err)
+	}
+
+	_, err = conn.Exec(ctx, "ALTER DATABASE "+pgx.Identifier{building}.Sanitize()+" RENAME TO "+pgx.Identifier{template}.Sanitize())
+	return err
+}
+
+// templateName names the template database after the migrations, so that
+// changing them builds a new template instead of reusing a stale one.
+func templateName() (string, error) {
+	hash := sha256.New()
+	err := fs.WalkDir(ucmsv2.Migrations, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		content, err := fs.ReadFile(ucmsv2.Migrations, path)
+		if err != nil {
+			return err
+		}
+		hash.Write([]byte(path))
+		hash.Write(content)
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("hash migrations: %w", err)
+	}
+	return "ucms_template_" + hex.EncodeToString(hash.Sum(nil))[:12], nil
+}
+
+// withDatabase returns connStr with its database replaced by name.
+func withDatabase(connStr, name string) (string, error) {
+	u, err := url.Parse(connStr)
+	if err != nil {
+		return "", fmt.Errorf("parse database url: %w", err)
+	}
+	u.Path = "/" + name
+	return u.String(), nil
+}
+
+// newPool connects to the database of the suite the way the app does, see
+// postgres.UseUTC.
+func (d *suiteDatabase) newPool(ctx context.Context) (*pgxpool.Pool, error) {
+	cfg, err := pgxpool.ParseConfig(d.connStr)
+	if err != nil {
+		return nil, err
+	}
+	postgrespkg.UseUTC(cfg)
+	return pgxpool.NewWithConfig(ctx, cfg)
+}
+
+func (d *suiteDatabase) drop(ctx context.Context) error {
+	conn, err := pgx.Connect(ctx, d.adminConnStr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(ctx)
+
+	_, err = conn.Exec(ctx, "DROP DATABASE IF EXISTS "+pgx.Identifier{d.name}.Sanitize()+" WITH (FORCE)")
+	return err
+}
@@ -0,0 +1,135 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill"
+	watermillSQL "github.com/ThreeDotsLabs/watermill-sql/v4/pkg/sql"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/ThreeDotsLabs/watermill/message/router/middleware"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/ucmsv2/ucms-backend/pkg/watermillx"
+)
+
+// deadLetterTable is the table of watermillx.PoisonTopic.
+const deadLetterTable = "watermill_" + watermillx.PoisonTopic
+
+// deadLetterWait is how long RequireDeadLetter waits for the poison queue,
+// which the router feeds asynchronously.
+const deadLetterWait = 5 * time.Second
+
+// DeadLetter is a message the poison queue moved off its topic.
+type DeadLetter struct {
+	Topic   string
+	Handler string
+	Reason  string
+	Payload json.RawMessage
+}
+
+// SeedDeadLetter publishes d to the poison queue the way the poison queue
+// middleware does.
+func (h *Helper) SeedDeadLetter(t *testing.T, d DeadLetter) {
+	t.Helper()
+
+	publisher, err := watermillSQL.NewPublisher(
+		watermillSQL.BeginnerFromPgx(h.pool),
+		watermillSQL.PublisherConfig{SchemaAdapter: watermillSQL.DefaultPostgreSQLSchema{}},
+		watermill.NopLogger{},
+	)
+	require.NoError(t, err)
+
+	msg := message.NewMessage(watermill.NewUUID(), message.Payload(d.Payload))
+	msg.Metadata.Set(middleware.PoisonedTopicKey, d.Topic)
+	msg.Metadata.Set(middleware.PoisonedHandlerKey, d.Handler)
+	msg.Metadata.Set(middleware.ReasonForPoisonedKey, d.Reason)
+	require.NoError(t, publisher.Publish(watermillx.PoisonTopic, msg), "failed to seed dead letter")
+}
+
+// RequireDeadLetter waits for a dead letter from topic and returns the
+// assertion on the latest one.
+func (h *Helper) RequireDeadLetter(t *testing.T, topic string) *DeadLetterAssertion {
+	t.Helper()
+
+	deadline := time.Now().Add(deadLetterWait)
+	for {
+		d, err := h.latestDeadLetter(t.Context(), topic)
+		if err == nil {
+			return &DeadLetterAssertion{DeadLetter: d}
+		}
+		require.ErrorIs(t, err, pgx.ErrNoRows, "failed to query dead letters")
+		if time.Now().After(deadline) {
+			require.FailNowf(t, "dead letter not found", "no dead letter from topic %s within %s", topic, deadLetterWait)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// RequireDeadLetterCount checks the number of dead letters from topic.
+func (h *Helper) RequireDeadLetterCount(t *testing.T, topic string, expected int) {
+	t.Helper()
+
+	var count int
+	err := h.pool.QueryRow(t.Context(),
+		`SELECT COUNT(*) FROM `+deadLetterTable+` WHERE metadata->>$1 = $2`,
+		middleware.PoisonedTopicKey, topic).Scan(&count)
+	require.NoError(t, err)
+	assert.Equal(t, expected, count, "unexpected dead letter count for topic %s", topic)
+}
+
+func (h *Helper) latestDeadLetter(ctx context.Context, topic string) (DeadLetter, error) {
+	var (
+		d        DeadLetter
+		metadata map[string]string
+	)
+	err := h.pool.QueryRow(ctx, `
+        SELECT payload, metadata
+        FROM `+deadLetterTable+`
+        WHERE metadata->>$1 = $2
+        ORDER BY "offset" DESC
+        LIMIT 1
+    `, middleware.PoisonedTopicKey, topic).Scan(&d.Payload, &metadata)
+	if err != nil {
+		return DeadLetter{}, err
+	}
+
+	d.Topic = metadata[middleware.PoisonedTopicKey]
+	d.Handler = metadata[middleware.PoisonedHandlerKey]
+	d.Reason = metadata[middleware.ReasonForPoisonedKey]
+	return d, nil
+}
+
+type DeadLetterAssertion struct {
+	DeadLetter DeadLetter
+}
+
+func (a *DeadLetterAssertion) AssertHandler(t *testing.T, expected string) *DeadLetterAssertion {
+	t.Helper()
+	assert.Equal(t, expected, a.DeadLetter.Handler, "unexpected dead letter handler")
+	return a
+}
+
+func (a *DeadLetterAssertion) AssertReasonContains(t *testing.T, expected string) *DeadLetterAssertion {
+	t.Helper()
+	assert.Contains(t, a.DeadLetter.Reason, expected, "unexpected dead letter reason")
+	return a
+}
+
+// AssertPayload compares the payload with expected as JSON documents.
+func (a *DeadLetterAssertion) AssertPayload(t *testing.T, expected string) *DeadLetterAssertion {
+	t.Helper()
+	assert.JSONEq(t, expected, string(a.DeadLetter.Payload), "unexpected dead letter payload")
+	return a
+}
+
+// ParsePayload decodes the payload into v, e.g. the event that was poisoned.
+func (a *DeadLetterAssertion) ParsePayload(t *testing.T, v any) *DeadLetterAssertion {
+	t.Helper()
+	require.NoError(t, json.Unmarshal(a.DeadLetter.Payload, v), "failed to parse dead letter payload")
+	return a
+}
@@ -0,0 +1,51 @@
+package db_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/registration"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/framework"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/framework/db"
+)
+
+type DeadLetterSuite struct {
+	framework.IntegrationTestSuite
+}
+
+func TestDeadLetterSuite(t *testing.T) {
+	suite.Run(t, new(DeadLetterSuite))
+}
+
+func (s *DeadLetterSuite) TestSeedAndRequire() {
+	t := s.T()
+
+	s.DB.SeedDeadLetter(t, db.DeadLetter{
+		Topic:   registration.EventStreamName,
+		Handler: "MailOnRegistrationStarted",
+		Reason:  "poison message: email: cannot be blank",
+		Payload: []byte(`{"registration_id":"r1","email":""}`),
+	})
+	s.DB.SeedDeadLetter(t, db.DeadLetter{
+		Topic:   registration.EventStreamName,
+		Handler: "MailOnRegistrationStarted",
+		Reason:  "poison message: verification_code: cannot be blank",
+		Payload: []byte(`{"registration_id":"r2","email":"a@test.com"}`),
+	})
+
+	var e registration.RegistrationStarted
+	s.DB.RequireDeadLetter(t, registration.EventStreamName).
+		AssertHandler(t, "MailOnRegistrationStarted").
+		AssertReasonContains(t, "verification_code").
+		AssertPayload(t, `{"email":"a@test.com","registration_id":"r2"}`).
+		ParsePayload(t, &e)
+	s.Equal("a@test.com", e.Email.String())
+
+	s.DB.RequireDeadLetterCount(t, registration.EventStreamName, 2)
+	s.DB.RequireDeadLetterCount(t, "events_other", 0)
+}
+
+func (s *DeadLetterSuite) TestTruncated() {
+	s.DB.RequireDeadLetterCount(s.T(), registration.EventStreamName, 0)
+}
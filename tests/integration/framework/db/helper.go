@@ -19,6 +19,7 @@ import (
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/registration"
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/staffinvitation"
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/emails"
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/majors"
 )
 
@@ -99,16 +100,46 @@ func (h *Helper) Exec(t *testing.T, query string, args ...any) pgconn.CommandTag
 	return tag
 }
 
+// FailInserts makes the inserts into table fail until the test ends, to
+// break a write in the middle of a request.
+func (h *Helper) FailInserts(t *testing.T, table string) {
+	t.Helper()
+
+	h.Exec(t, `CREATE OR REPLACE FUNCTION test_fail_insert() RETURNS trigger AS $$
+        BEGIN
+            RAISE EXCEPTION 'insert into % is failed by the test', TG_TABLE_NAME;
+        END;
+        $$ LANGUAGE plpgsql`)
+	h.Exec(t, fmt.Sprintf(`CREATE TRIGGER test_fail_insert BEFORE INSERT ON %s
+        FOR EACH ROW EXECUTE FUNCTION test_fail_insert()`, table))
+	t.Cleanup(func() {
+		_, err := h.pool.Exec(context.Background(), fmt.Sprintf("DROP TRIGGER IF EXISTS test_fail_insert ON %s", table))
+		assert.NoError(t, err, "failed to drop the trigger failing the inserts into %s", table)
+	})
+}
+
 func (h *Helper) TruncateAll(t *testing.T) {
 	t.Helper()
 
 	tables := []string{
+		"announcements",
+		"incidents",
+		"email_verifications",
+		"notifications",
 		"staff_invitations",
 		"registrations",
+		"staff_bootstrap_audit",
+		"impersonations",
 		"staffs",
 		"students",
 		"groups",
 		"users",
+		"tos_versions",
+		"weekly_reports",
+		"error_events",
+		"validation_failures",
+		"archive_manifests",
+		deadLetterTable,
 	}
 
 	ctx := context.Background()
@@ -121,7 +152,7 @@ func (h *Helper) TruncateAll(t *testing.T) {
 func (h *Helper) RequireRegistrationExists(t *testing.T, email string) *registration.RegistrationAssertion {
 	t.Helper()
 
-	reg, err := h.registration.GetRegistrationByEmail(t.Context(), email)
+	reg, err := h.registration.GetRegistrationByEmail(t.Context(), emails.Email(email))
 	require.NoError(t, err, "registration not found for email: %s", email)
 
 	return registration.NewRegistrationAssertion(reg)
@@ -162,7 +193,7 @@ func (h *Helper) CheckUserExists(t *testing.T, email string) bool {
 func (h *Helper) RequireUserExists(t *testing.T, email string) *user.UserAssertions {
 	t.Helper()
 
-	u, err := h.user.GetUserByEmail(t.Context(), email)
+	u, err := h.user.GetUserByEmail(t.Context(), emails.Email(email))
 	require.NoError(t, err, "user not found for email: %s", email)
 
 	return user.NewUserAssertions(t, u)
@@ -191,7 +222,7 @@ func (h *Helper) RequireStudentExists(t *testing.T, id user.ID) *user.StudentAss
 func (h *Helper) RequireStudentExistsByEmail(t *testing.T, email string) *user.StudentAssertions {
 	t.Helper()
 
-	student, err := h.student.GetStudentByEmail(t.Context(), email)
+	student, err := h.student.GetStudentByEmail(t.Context(), emails.Email(email))
 	require.NoError(t, err, "student not found for email: %s", email)
 
 	return user.NewStudentAssertions(student)
@@ -209,7 +240,7 @@ func (h *Helper) RequireStaffExists(t *testing.T, id user.ID) *user.StaffAsserti
 func (h *Helper) RequireStaffExistsByEmail(t *testing.T, email string) *user.StaffAssertions {
 	t.Helper()
 
-	staff, err := h.staff.GetStaffByEmail(t.Context(), email)
+	staff, err := h.staff.GetStaffByEmail(t.Context(), emails.Email(email))
 	require.NoError(t, err, "staff not found for email: %s", email)
 
 	return user.NewStaffAssertions(staff)
@@ -305,6 +336,13 @@ func (h *Helper) SeedGroup(t *testing.T, groupID group.ID, name string, year str
 	require.NoError(t, h.group.SaveGroup(t.Context(), g))
 }
 
+// SeedBuiltGroup saves a group made with builders.GroupBuilder, for the
+// fields SeedGroup does not take.
+func (h *Helper) SeedBuiltGroup(t *testing.T, g *group.Group) {
+	t.Helper()
+	require.NoError(t, h.group.SaveGroup(t.Context(), g))
+}
+
 func (h *Helper) SeedStaff(t *testing.T, staff *user.Staff) {
 	t.Helper()
 	require.NoError(t, h.staff.SaveStaff(t.Context(), staff))
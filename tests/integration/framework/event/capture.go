@@ -0,0 +1,124 @@
+package event
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+const (
+	pollInterval = 50 * time.Millisecond
+	// SettleWindow is how long RequireNoEvent watches for the event before it
+	// accepts that none was published.
+	SettleWindow = 300 * time.Millisecond
+)
+
+// Message is a captured event: the name of its type and its JSON payload.
+type Message struct {
+	Name    string
+	Payload json.RawMessage
+}
+
+// Capture gives access to the events published during a test.
+type Capture interface {
+	// Messages returns the events captured since the last reset, oldest first.
+	Messages(ctx context.Context) ([]Message, error)
+}
+
+// WaitFor blocks up to timeout for an event of type T matching all matchers
+// and returns the latest such event. T is the event type as it is published,
+// e.g. *registration.RegistrationStarted.
+//
+//	e := event.WaitFor(t, s.Event, 5*time.Second, func(e *registration.RegistrationStarted) bool {
+//		return e.Email == email
+//	})
+func WaitFor[T any](t testing.TB, capture Capture, timeout time.Duration, matchers ...func(T) bool) T {
+	t.Helper()
+
+	name := eventName[T]()
+	deadline := time.Now().Add(timeout)
+	for {
+		e, found, err := find(t.Context(), capture, name, matchers)
+		if err != nil {
+			t.Fatalf("failed to read %s events: %v", name, err)
+			return e
+		}
+		if found {
+			return e
+		}
+		if !time.Now().Before(deadline) {
+			t.Fatalf("no matching %s event within %s", name, timeout)
+			return e
+		}
+		time.Sleep(pollInterval)
+	}
+}
+
+// RequireNoEvent fails the test if an event of type T matching all matchers
+// is captured within the SettleWindow.
+func RequireNoEvent[T any](t testing.TB, capture Capture, matchers ...func(T) bool) {
+	t.Helper()
+
+	name := eventName[T]()
+	deadline := time.Now().Add(SettleWindow)
+	for {
+		e, found, err := find(t.Context(), capture, name, matchers)
+		if err != nil {
+			t.Fatalf("failed to read %s events: %v", name, err)
+			return
+		}
+		if found {
+			t.Fatalf("unexpected %s event: %+v", name, e)
+			return
+		}
+		if !time.Now().Before(deadline) {
+			return
+		}
+		time.Sleep(pollInterval)
+	}
+}
+
+func find[T any](ctx context.Context, capture Capture, name string, matchers []func(T) bool) (T, bool, error) {
+	var zero T
+
+	messages, err := capture.Messages(ctx)
+	if err != nil {
+		return zero, false, err
+	}
+
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Name != name {
+			continue
+		}
+
+		var e T
+		if err := json.Unmarshal(messages[i].Payload, &e); err != nil {
+			return zero, false, err
+		}
+		if matchAll(e, matchers) {
+			return e, true, nil
+		}
+	}
+	return zero, false, nil
+}
+
+func matchAll[T any](e T, matchers []func(T) bool) bool {
+	for _, match := range matchers {
+		if !match(e) {
+			return false
+		}
+	}
+	return true
+}
+
+// eventName returns the name events of type T are published under, the
+// type name without the pointer, e.g. registration.RegistrationStarted.
+func eventName[T any]() string {
+	typ := reflect.TypeFor[T]()
+	if typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	return typ.String()
+}
@@ -0,0 +1,167 @@
+package event
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/registration"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/emails"
+)
+
+type fakeCapture struct {
+	mu       sync.Mutex
+	messages []Message
+	err      error
+}
+
+func (c *fakeCapture) Messages(context.Context) ([]Message, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Message(nil), c.messages...), c.err
+}
+
+func (c *fakeCapture) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.messages = nil
+}
+
+func (c *fakeCapture) publish(t *testing.T, e any) {
+	t.Helper()
+	payload, err := json.Marshal(e)
+	require.NoError(t, err)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.messages = append(c.messages, Message{Name: fmt.Sprintf("%T", e)[1:], Payload: payload})
+}
+
+func (c *fakeCapture) publishAfter(t *testing.T, d time.Duration, e any) {
+	go func() {
+		time.Sleep(d)
+		c.publish(t, e)
+	}()
+}
+
+// fakeT records the failure instead of stopping the test, the helpers
+// return right after reporting it.
+type fakeT struct {
+	testing.TB
+	ctx    context.Context
+	failed string
+}
+
+func newFakeT(t *testing.T) *fakeT {
+	return &fakeT{ctx: t.Context()}
+}
+
+func (t *fakeT) Helper() {}
+
+func (t *fakeT) Context() context.Context {
+	return t.ctx
+}
+
+func (t *fakeT) Fatalf(format string, args ...any) {
+	t.failed = fmt.Sprintf(format, args...)
+}
+
+func started(email string) *registration.RegistrationStarted {
+	return &registration.RegistrationStarted{Email: emails.Email(email), VerificationCode: "123456"}
+}
+
+func withEmail(email string) func(*registration.RegistrationStarted) bool {
+	return func(e *registration.RegistrationStarted) bool { return e.Email.String() == email }
+}
+
+func TestWaitFor(t *testing.T) {
+	t.Run("event published later", func(t *testing.T) {
+		capture := &fakeCapture{}
+		capture.publishAfter(t, 100*time.Millisecond, started("a@test.com"))
+
+		e := WaitFor[*registration.RegistrationStarted](t, capture, time.Second)
+		assert.Equal(t, "a@test.com", e.Email.String())
+	})
+
+	t.Run("matchers select the event", func(t *testing.T) {
+		capture := &fakeCapture{}
+		capture.publish(t, started("a@test.com"))
+		capture.publish(t, &registration.VerificationCodeResent{Email: "b@test.com"})
+		capture.publishAfter(t, 100*time.Millisecond, started("b@test.com"))
+
+		e := WaitFor(t, capture, time.Second, withEmail("b@test.com"))
+		assert.Equal(t, "b@test.com", e.Email.String())
+	})
+
+	t.Run("latest matching event", func(t *testing.T) {
+		capture := &fakeCapture{}
+		capture.publish(t, &registration.RegistrationStarted{Email: "a@test.com", VerificationCode: "111111"})
+		capture.publish(t, &registration.RegistrationStarted{Email: "a@test.com", VerificationCode: "222222"})
+
+		e := WaitFor(t, capture, time.Second, withEmail("a@test.com"))
+		assert.Equal(t, "222222", e.VerificationCode)
+	})
+
+	t.Run("stale event is gone after reset", func(t *testing.T) {
+		capture := &fakeCapture{}
+		capture.publish(t, started("a@test.com"))
+		capture.Reset()
+
+		ft := newFakeT(t)
+		WaitFor(ft, capture, 200*time.Millisecond, withEmail("a@test.com"))
+		assert.Contains(t, ft.failed, "no matching registration.RegistrationStarted event")
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		capture := &fakeCapture{}
+		capture.publish(t, started("a@test.com"))
+		capture.publishAfter(t, time.Second, started("b@test.com"))
+
+		ft := newFakeT(t)
+		begin := time.Now()
+		e := WaitFor(ft, capture, 200*time.Millisecond, withEmail("b@test.com"))
+		assert.Nil(t, e)
+		assert.Contains(t, ft.failed, "within 200ms")
+		assert.Less(t, time.Since(begin), time.Second)
+	})
+
+	t.Run("capture error", func(t *testing.T) {
+		capture := &fakeCapture{err: errors.New("connection refused")}
+
+		ft := newFakeT(t)
+		WaitFor[*registration.RegistrationStarted](ft, capture, time.Second)
+		assert.Contains(t, ft.failed, "connection refused")
+	})
+}
+
+func TestRequireNoEvent(t *testing.T) {
+	t.Run("no event", func(t *testing.T) {
+		capture := &fakeCapture{}
+		capture.publish(t, started("a@test.com"))
+
+		ft := newFakeT(t)
+		RequireNoEvent(ft, capture, withEmail("b@test.com"))
+		assert.Empty(t, ft.failed)
+	})
+
+	t.Run("event within the settle window", func(t *testing.T) {
+		capture := &fakeCapture{}
+		capture.publishAfter(t, SettleWindow/3, started("b@test.com"))
+
+		ft := newFakeT(t)
+		RequireNoEvent(ft, capture, withEmail("b@test.com"))
+		assert.Contains(t, ft.failed, "unexpected registration.RegistrationStarted event")
+	})
+}
+
+func TestEventName(t *testing.T) {
+	assert.Equal(t, "registration.RegistrationStarted", eventName[*registration.RegistrationStarted]())
+	assert.Equal(t, "registration.RegistrationStarted", eventName[registration.RegistrationStarted]())
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sync"
 	"testing"
 	"time"
 
@@ -11,20 +12,95 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/announcement"
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/event"
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/registration"
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/staffinvitation"
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
 )
 
+// streams are the event streams the helper captures and clears.
+var streams = []string{
+	registration.EventStreamName,
+	user.StudentEventStreamName,
+	user.StaffEventStreamName,
+	user.UserEventStreamName,
+	staffinvitation.EventStreamName,
+	announcement.EventStreamName,
+}
+
 type Helper struct {
 	pool *pgxpool.Pool
+
+	mu sync.Mutex
+	// watermarks holds the last offset of each stream at the last Reset,
+	// Messages skips the events up to it.
+	watermarks map[string]int64
+	resetErr   error
 }
 
 func NewHelper(pool *pgxpool.Pool) *Helper {
 	return &Helper{pool: pool}
 }
 
+// Reset makes Messages skip the events published so far, so that a subtest
+// does not pick up the events of the previous one.
+func (h *Helper) Reset() {
+	watermarks := make(map[string]int64, len(streams))
+	var err error
+	for _, stream := range streams {
+		var offset int64
+		query := fmt.Sprintf(`SELECT COALESCE(MAX("offset"), 0) FROM watermill_%s`, stream)
+		if err = h.pool.QueryRow(context.Background(), query).Scan(&offset); err != nil {
+			break
+		}
+		watermarks[stream] = offset
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.watermarks, h.resetErr = watermarks, err
+}
+
+// Messages returns the events published since the last Reset, oldest first
+// within each stream.
+func (h *Helper) Messages(ctx context.Context) ([]Message, error) {
+	h.mu.Lock()
+	watermarks, err := h.watermarks, h.resetErr
+	h.mu.Unlock()
+	if err != nil {
+		return nil, fmt.Errorf("reset: %w", err)
+	}
+
+	var messages []Message
+	for _, stream := range streams {
+		query := fmt.Sprintf(`
+        SELECT metadata->>'name', payload
+        FROM watermill_%s
+        WHERE "offset" > $1
+        ORDER BY "offset"
+    `, stream)
+
+		rows, err := h.pool.Query(ctx, query, watermarks[stream])
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var m Message
+			if err := rows.Scan(&m.Name, &m.Payload); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			messages = append(messages, m)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+	return messages, nil
+}
+
 // WaitForEvent waits for an event to appear in the database
 func (h *Helper) WaitForEvent(t *testing.T, eventType, streamName string, timeout time.Duration) {
 	t.Helper()
@@ -151,25 +227,22 @@ func (h *Helper) GetEventStream(t *testing.T, streamName string) []EventRecord {
 func (h *Helper) ClearAllEvents(t *testing.T) {
 	t.Helper()
 
-	tables := []string{
-		registration.EventStreamName,
-		user.StudentEventStreamName,
-		user.StaffEventStreamName,
-		user.UserEventStreamName,
-		staffinvitation.EventStreamName,
-	}
-
-	for _, table := range tables {
+	for _, table := range streams {
 		table = "watermill_" + table
 		_, err := h.pool.Exec(t.Context(), fmt.Sprintf("TRUNCATE TABLE %s RESTART IDENTITY", table))
 		require.NoError(t, err)
 	}
 
-	for _, table := range tables {
+	for _, table := range streams {
 		table = "watermill_offsets_" + table
 		_, err := h.pool.Exec(t.Context(), `UPDATE `+table+` SET offset_acked = 0, last_processed_transaction_id = '0'::xid8`)
 		require.NoError(t, err)
 	}
+
+	// the offsets restart with the tables
+	h.mu.Lock()
+	h.watermarks, h.resetErr = nil, nil
+	h.mu.Unlock()
 }
 
 func RequireEvent[T event.Event](t *testing.T, h *Helper, e T) T {
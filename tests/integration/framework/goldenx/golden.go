@@ -0,0 +1,150 @@
+// Package goldenx compares JSON documents, usually response bodies, with
+// golden files. The documents are normalized before the comparison: the
+// volatile fields are replaced by Placeholder and the members are sorted and
+// indented, so that a golden file only changes with the shape of the
+// document. Run the tests with -update to rewrite the golden files:
+//
+//	go test ./tests/staff/... -run TestInvitation -update
+package goldenx
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// Placeholder replaces the values of the ignored fields.
+const Placeholder = "<ignored>"
+
+var update = flag.Bool("update", false, "rewrite the golden files with the actual documents")
+
+type config struct {
+	dir    string
+	ignore []string
+}
+
+type Option func(*config)
+
+// IgnoreFields replaces the values selected by selectors with Placeholder,
+// so that the golden file keeps the field but not its value. A selector is
+// either a member name, which matches the member at any depth, or a path
+// from the root:
+//
+//	id                    every "id" member
+//	$.student.group.id    the id of the group of the student
+//	$.invitations[*].code the code of every invitation
+//	$.items[0].id         the id of the first item
+//	$..created_at         every "created_at" member, as a bare name does
+//	$['a.b']              the member named a.b
+//
+// Selectors that match nothing are fine, the golden file shows whether the
+// field is there.
+func IgnoreFields(selectors ...string) Option {
+	return func(c *config) {
+		c.ignore = append(c.ignore, selectors...)
+	}
+}
+
+// Dir sets the directory of the golden files, testdata by default, relative
+// to the package of the test.
+func Dir(dir string) Option {
+	return func(c *config) {
+		c.dir = dir
+	}
+}
+
+// Match compares the normalized got with the golden file name. With -update
+// it writes the golden file instead.
+func Match(t testing.TB, name string, got []byte, opts ...Option) {
+	t.Helper()
+
+	cfg := config{dir: "testdata"}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	actual, err := normalize(got, cfg.ignore)
+	if err != nil {
+		t.Fatalf("goldenx: normalize %s: %v\ndocument: %s", name, err, got)
+		return
+	}
+
+	path := filepath.Join(cfg.dir, name)
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("goldenx: %v", err)
+			return
+		}
+		if err := os.WriteFile(path, actual, 0o644); err != nil {
+			t.Fatalf("goldenx: %v", err)
+			return
+		}
+		t.Logf("goldenx: updated %s", path)
+		return
+	}
+
+	expected, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("goldenx: golden file %s does not exist, run the test with -update to create it", path)
+		return
+	}
+	if err != nil {
+		t.Fatalf("goldenx: %v", err)
+		return
+	}
+
+	if !bytes.Equal(expected, actual) {
+		t.Errorf("goldenx: %s does not match, run the test with -update if the change is intended:\n%s",
+			path, diff(path, expected, actual))
+	}
+}
+
+// normalize decodes doc, replaces the ignored values and encodes it sorted
+// and indented, with a trailing newline.
+func normalize(doc []byte, ignore []string) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(doc))
+	// keep the numbers as they were written, float64 would round the large ones
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+
+	for _, selector := range ignore {
+		steps, err := parseSelector(selector)
+		if err != nil {
+			return nil, err
+		}
+		v = replace(v, steps)
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func diff(path string, expected, actual []byte) string {
+	text, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(string(expected)),
+		B:        difflib.SplitLines(string(actual)),
+		FromFile: path,
+		ToFile:   "actual",
+		Context:  3,
+	})
+	if err != nil {
+		return err.Error()
+	}
+	return text
+}
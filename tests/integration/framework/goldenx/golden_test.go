@@ -0,0 +1,174 @@
+package goldenx
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recorder records the failures of Match instead of failing the test.
+type recorder struct {
+	testing.TB
+	errors []string
+	fatal  bool
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Logf(format string, args ...any) {}
+
+func (r *recorder) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func (r *recorder) Fatalf(format string, args ...any) {
+	r.Errorf(format, args...)
+	r.fatal = true
+}
+
+func setUpdate(t *testing.T, value bool) {
+	t.Helper()
+	prev := *update
+	*update = value
+	t.Cleanup(func() { *update = prev })
+}
+
+func TestNormalize(t *testing.T) {
+	doc := `{"success":true,"student":{"id":"s1","group":{"id":"g1","name":"SE-2301"}},` +
+		`"invitations":[{"code":"c1","emails":["a@test.com"]},{"code":"c2","emails":[]}],"a.b":1,"big":12345678901234567890}`
+
+	tests := []struct {
+		name    string
+		ignore  []string
+		want    string
+		wantErr string
+	}{
+		{
+			name:   "bare name at any depth",
+			ignore: []string{"id"},
+			want: `{"a.b":1,"big":12345678901234567890,"invitations":[{"code":"c1","emails":["a@test.com"]},{"code":"c2","emails":[]}],` +
+				`"student":{"group":{"id":"<ignored>","name":"SE-2301"},"id":"<ignored>"},"success":true}`,
+		},
+		{
+			name:   "nested path",
+			ignore: []string{"$.student.group.id"},
+			want: `{"a.b":1,"big":12345678901234567890,"invitations":[{"code":"c1","emails":["a@test.com"]},{"code":"c2","emails":[]}],` +
+				`"student":{"group":{"id":"<ignored>","name":"SE-2301"},"id":"s1"},"success":true}`,
+		},
+		{
+			name:   "wildcard and index",
+			ignore: []string{"$.invitations[*].code", "$.invitations[0].emails[0]"},
+			want: `{"a.b":1,"big":12345678901234567890,"invitations":[{"code":"<ignored>","emails":["<ignored>"]},{"code":"<ignored>","emails":[]}],` +
+				`"student":{"group":{"id":"g1","name":"SE-2301"},"id":"s1"},"success":true}`,
+		},
+		{
+			name:   "descendant under a path and quoted member",
+			ignore: []string{"$.student..id", "$['a.b']"},
+			want: `{"a.b":"<ignored>","big":12345678901234567890,"invitations":[{"code":"c1","emails":["a@test.com"]},{"code":"c2","emails":[]}],` +
+				`"student":{"group":{"id":"<ignored>","name":"SE-2301"},"id":"<ignored>"},"success":true}`,
+		},
+		{
+			name:   "selector matching nothing",
+			ignore: []string{"$.student.missing", "$.invitations[5].code", "$.success.id"},
+			want: `{"a.b":1,"big":12345678901234567890,"invitations":[{"code":"c1","emails":["a@test.com"]},{"code":"c2","emails":[]}],` +
+				`"student":{"group":{"id":"g1","name":"SE-2301"},"id":"s1"},"success":true}`,
+		},
+		{name: "invalid index", ignore: []string{"$.invitations[x]"}, wantErr: "invalid index"},
+		{name: "unclosed bracket", ignore: []string{"$.invitations[0"}, wantErr: "unclosed ["},
+		{name: "path without root", ignore: []string{"student.id"}, wantErr: "must be a member name or start with $"},
+		{name: "whole document", ignore: []string{"$"}, wantErr: "whole document"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := normalize([]byte(doc), tt.ignore)
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.want, string(got))
+			assert.Contains(t, string(got), "12345678901234567890", "numbers are kept as written")
+		})
+	}
+}
+
+func TestNormalize_Format(t *testing.T) {
+	got, err := normalize([]byte(`{"b":"<a&b>","a":[1,2]}`), nil)
+	require.NoError(t, err)
+	assert.Equal(t, "{\n  \"a\": [\n    1,\n    2\n  ],\n  \"b\": \"<a&b>\"\n}\n", string(got))
+}
+
+func TestMatch(t *testing.T) {
+	dir := t.TempDir()
+	golden := "{\n  \"id\": \"<ignored>\",\n  \"name\": \"board\"\n}\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "item.json"), []byte(golden), 0o644))
+
+	r := &recorder{}
+	Match(r, "item.json", []byte(`{"name":"board","id":"a1"}`), Dir(dir), IgnoreFields("id"))
+
+	assert.Empty(t, r.errors)
+}
+
+func TestMatch_MismatchDiff(t *testing.T) {
+	dir := t.TempDir()
+	golden := "{\n  \"id\": \"<ignored>\",\n  \"name\": \"board\",\n  \"tags\": []\n}\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "item.json"), []byte(golden), 0o644))
+
+	r := &recorder{}
+	Match(r, "item.json", []byte(`{"id":"a1","title":"board","tags":[]}`), Dir(dir), IgnoreFields("id"))
+
+	require.Len(t, r.errors, 1)
+	assert.False(t, r.fatal)
+	msg := r.errors[0]
+	assert.Contains(t, msg, filepath.Join(dir, "item.json")+" does not match")
+	assert.Contains(t, msg, "-update")
+	assert.Contains(t, msg, "--- "+filepath.Join(dir, "item.json"))
+	assert.Contains(t, msg, "+++ actual")
+	assert.Contains(t, msg, "\n-  \"name\": \"board\",\n")
+	assert.Contains(t, msg, "\n+  \"title\": \"board\"\n")
+	assert.Contains(t, msg, "\n   \"id\": \"<ignored>\",\n", "the diff shows the context")
+}
+
+func TestMatch_Update(t *testing.T) {
+	setUpdate(t, true)
+	dir := filepath.Join(t.TempDir(), "testdata")
+	path := filepath.Join(dir, "nested", "item.json")
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte("stale"), 0o644))
+
+	r := &recorder{}
+	Match(r, filepath.Join("nested", "item.json"), []byte(`{"name":"board","id":"a1"}`), Dir(dir), IgnoreFields("id"))
+	require.Empty(t, r.errors)
+
+	written, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "{\n  \"id\": \"<ignored>\",\n  \"name\": \"board\"\n}\n", string(written))
+
+	setUpdate(t, false)
+	r = &recorder{}
+	Match(r, filepath.Join("nested", "item.json"), []byte(`{"id":"b2","name":"board"}`), Dir(dir), IgnoreFields("id"))
+	assert.Empty(t, r.errors, "the updated golden file matches")
+}
+
+func TestMatch_MissingGolden(t *testing.T) {
+	r := &recorder{}
+	Match(r, "missing.json", []byte(`{}`), Dir(t.TempDir()))
+
+	require.Len(t, r.errors, 1)
+	assert.True(t, r.fatal)
+	assert.Contains(t, r.errors[0], "does not exist, run the test with -update")
+}
+
+func TestMatch_InvalidJSON(t *testing.T) {
+	r := &recorder{}
+	Match(r, "item.json", []byte(`not json`), Dir(t.TempDir()))
+
+	require.Len(t, r.errors, 1)
+	assert.True(t, r.fatal)
+	assert.Contains(t, r.errors[0], "normalize item.json")
+}
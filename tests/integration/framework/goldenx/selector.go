@@ -0,0 +1,150 @@
+package goldenx
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type stepKind int
+
+const (
+	stepMember stepKind = iota
+	stepIndex
+	stepWildcard
+	// stepDescendant matches the member at any depth, as JSONPath's ..name.
+	stepDescendant
+)
+
+type step struct {
+	kind  stepKind
+	key   string
+	index int
+}
+
+// parseSelector parses the selectors documented on IgnoreFields.
+func parseSelector(selector string) ([]step, error) {
+	rest, ok := strings.CutPrefix(selector, "$")
+	if !ok {
+		if selector == "" || strings.ContainsAny(selector, ".[]") {
+			return nil, fmt.Errorf("selector %q must be a member name or start with $", selector)
+		}
+		return []step{{kind: stepDescendant, key: selector}}, nil
+	}
+
+	var steps []step
+	for rest != "" {
+		switch {
+		case strings.HasPrefix(rest, ".."):
+			key, tail := cutMember(rest[2:])
+			if key == "" {
+				return nil, fmt.Errorf("selector %q: empty member name after ..", selector)
+			}
+			steps, rest = append(steps, step{kind: stepDescendant, key: key}), tail
+		case strings.HasPrefix(rest, "."):
+			key, tail := cutMember(rest[1:])
+			if key == "" {
+				return nil, fmt.Errorf("selector %q: empty member name", selector)
+			}
+			kind := stepMember
+			if key == "*" {
+				kind = stepWildcard
+			}
+			steps, rest = append(steps, step{kind: kind, key: key}), tail
+		case strings.HasPrefix(rest, "["):
+			end := strings.Index(rest, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("selector %q: unclosed [", selector)
+			}
+			inner := rest[1:end]
+			rest = rest[end+1:]
+			if inner == "*" {
+				steps = append(steps, step{kind: stepWildcard})
+				continue
+			}
+			if key, ok := unquote(inner); ok {
+				steps = append(steps, step{kind: stepMember, key: key})
+				continue
+			}
+			n, err := strconv.Atoi(inner)
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("selector %q: invalid index %q", selector, inner)
+			}
+			steps = append(steps, step{kind: stepIndex, index: n})
+		default:
+			return nil, fmt.Errorf("selector %q: unexpected %q", selector, rest)
+		}
+	}
+	if len(steps) == 0 {
+		return nil, fmt.Errorf("selector %q selects the whole document", selector)
+	}
+	return steps, nil
+}
+
+func cutMember(s string) (key, rest string) {
+	end := strings.IndexAny(s, ".[")
+	if end < 0 {
+		return s, ""
+	}
+	return s[:end], s[end:]
+}
+
+func unquote(s string) (string, bool) {
+	if len(s) < 2 {
+		return "", false
+	}
+	quote := s[0]
+	if (quote != '\'' && quote != '"') || s[len(s)-1] != quote {
+		return "", false
+	}
+	return s[1 : len(s)-1], true
+}
+
+// replace returns v with the values the steps select replaced by
+// Placeholder. The objects and arrays are modified in place.
+func replace(v any, steps []step) any {
+	if len(steps) == 0 {
+		return Placeholder
+	}
+	current, rest := steps[0], steps[1:]
+
+	switch current.kind {
+	case stepMember:
+		if object, ok := v.(map[string]any); ok {
+			if child, ok := object[current.key]; ok {
+				object[current.key] = replace(child, rest)
+			}
+		}
+	case stepIndex:
+		if array, ok := v.([]any); ok && current.index < len(array) {
+			array[current.index] = replace(array[current.index], rest)
+		}
+	case stepWildcard:
+		switch node := v.(type) {
+		case map[string]any:
+			for key, child := range node {
+				node[key] = replace(child, rest)
+			}
+		case []any:
+			for i, child := range node {
+				node[i] = replace(child, rest)
+			}
+		}
+	case stepDescendant:
+		switch node := v.(type) {
+		case map[string]any:
+			for key, child := range node {
+				if key == current.key {
+					node[key] = replace(child, rest)
+					continue
+				}
+				node[key] = replace(child, steps)
+			}
+		case []any:
+			for i, child := range node {
+				node[i] = replace(child, steps)
+			}
+		}
+	}
+	return v
+}
@@ -0,0 +1,218 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+)
+
+// cookieOrigin is the origin the cookies of a session are kept for, the
+// application sets them for the localhost domain and as secure.
+var cookieOrigin = &url.URL{Scheme: "https", Host: "localhost"}
+
+// Principal is a user the requests are made as, e.g. a seeded *user.Staff.
+type Principal interface {
+	User() *user.User
+}
+
+// Client makes requests to the application under test:
+//
+//	s.HTTP.As(staff).Get("/v1/staffs/invitations").WithQuery("page", 2).
+//		Expect(t).Status(http.StatusOK).JSONPath("$.data[0].id", id)
+type Client struct {
+	h         *Helper
+	principal Principal
+	jar       http.CookieJar
+}
+
+// As returns a client authenticated as p, its access token is minted once
+// per suite.
+func (h *Helper) As(p Principal) *Client {
+	return &Client{h: h, principal: p}
+}
+
+// Anon returns a client without credentials.
+func (h *Helper) Anon() *Client {
+	return &Client{h: h}
+}
+
+// Session returns a client without credentials that keeps the cookies the
+// responses set and sends them back, e.g. for the login flow.
+func (h *Helper) Session() *Client {
+	jar, _ := cookiejar.New(nil)
+	return &Client{h: h, jar: jar}
+}
+
+func (c *Client) Get(path string) *Call {
+	return c.newCall(http.MethodGet, path)
+}
+
+func (c *Client) Post(path string) *Call {
+	return c.newCall(http.MethodPost, path)
+}
+
+func (c *Client) Put(path string) *Call {
+	return c.newCall(http.MethodPut, path)
+}
+
+func (c *Client) Patch(path string) *Call {
+	return c.newCall(http.MethodPatch, path)
+}
+
+func (c *Client) Delete(path string) *Call {
+	return c.newCall(http.MethodDelete, path)
+}
+
+func (c *Client) newCall(method, path string) *Call {
+	return &Call{client: c, builder: NewRequest(method, path)}
+}
+
+// Call is a request being built by a Client.
+type Call struct {
+	client  *Client
+	builder *RequestBuilder
+	opts    []RequestBuilderOptions
+}
+
+// WithJSON sends body encoded as JSON.
+func (c *Call) WithJSON(body any) *Call {
+	c.builder.WithJSON(body)
+	return c
+}
+
+// WithBody sends body as is, e.g. a multipart form with its content type
+// set by WithHeader.
+func (c *Call) WithBody(body any) *Call {
+	c.builder.WithBody(body)
+	return c
+}
+
+func (c *Call) WithHeader(key, value string) *Call {
+	c.builder.WithHeader(key, value)
+	return c
+}
+
+// WithQuery sets the query parameter key to value formatted with fmt.Sprint.
+func (c *Call) WithQuery(key string, value any) *Call {
+	c.builder.WithQuery(key, fmt.Sprint(value))
+	return c
+}
+
+// With applies opts after the credentials of the client, e.g. WithAnon.
+func (c *Call) With(opts ...RequestBuilderOptions) *Call {
+	c.opts = append(c.opts, opts...)
+	return c
+}
+
+// Do sends the request and returns the response.
+func (c *Call) Do(t *testing.T) *Response {
+	t.Helper()
+
+	req, origin := c.build(t)
+	resp := c.client.h.Do(t, req)
+	if origin != nil {
+		c.client.jar.SetCookies(origin, resp.Result().Cookies())
+	}
+	return resp
+}
+
+// build returns the request with the credentials of the client and the
+// origin its session cookies are kept for, nil without a session.
+func (c *Call) build(t *testing.T) (Request, *url.URL) {
+	t.Helper()
+
+	client := c.client
+	if client.principal != nil {
+		u := client.principal.User()
+		WithAccessTokenCookie(client.h.tokens.get(t, u.ID(), u.Role()))(c.builder)
+	}
+	for _, opt := range c.opts {
+		opt(c.builder)
+	}
+
+	req := c.builder.Build()
+	var origin *url.URL
+	if client.jar != nil {
+		ref, err := url.Parse(req.Path)
+		require.NoError(t, err)
+		origin = cookieOrigin.ResolveReference(ref)
+		for _, cookie := range client.jar.Cookies(origin) {
+			c.builder.WithCookies([]string{cookie.String()})
+		}
+		req = c.builder.Build()
+	}
+
+	return req, origin
+}
+
+// Expect sends the request and returns the assertions on its response.
+func (c *Call) Expect(t *testing.T) *Expectation {
+	t.Helper()
+	return &Expectation{t: t, resp: c.Do(t)}
+}
+
+// Expectation asserts on a response, a failed assertion stops the test.
+type Expectation struct {
+	t    *testing.T
+	resp *Response
+}
+
+func (e *Expectation) Status(expected int) *Expectation {
+	e.t.Helper()
+	e.resp.RequireStatus(expected)
+	return e
+}
+
+// JSON decodes the response body into v.
+func (e *Expectation) JSON(v any) *Expectation {
+	e.t.Helper()
+	e.resp.RequireParseJSON(v)
+	return e
+}
+
+// JSONPath asserts that the value at path in the response body equals
+// expected once both are in their JSON form, so that e.g. an int matches
+// a JSON number and a uuid.UUID its string.
+func (e *Expectation) JSONPath(path string, expected any) *Expectation {
+	e.t.Helper()
+
+	actual := e.lookup(path)
+
+	encoded, err := json.Marshal(expected)
+	require.NoError(e.t, err, "failed to encode the expected value of %s", path)
+	var want any
+	require.NoError(e.t, json.Unmarshal(encoded, &want))
+
+	assert.Equal(e.t, want, actual, "unexpected value at %s", path)
+	return e
+}
+
+// JSONPathExists asserts that there is a value at path in the response body.
+func (e *Expectation) JSONPathExists(path string) *Expectation {
+	e.t.Helper()
+	e.lookup(path)
+	return e
+}
+
+// Response returns the response for the assertions Expectation lacks.
+func (e *Expectation) Response() *Response {
+	return e.resp
+}
+
+func (e *Expectation) lookup(path string) any {
+	e.t.Helper()
+
+	var doc any
+	e.resp.RequireParseJSON(&doc)
+	value, err := lookupJSONPath(doc, path)
+	require.NoError(e.t, err, "response: %s", e.resp.Body.String())
+	return value
+}
@@ -0,0 +1,204 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/roles"
+	authhttp "gitlab.com/ucmsv2/ucms-backend/internal/ports/http/auth"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/builders"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/framework/goldenx"
+)
+
+type principal struct {
+	u *user.User
+}
+
+func (p principal) User() *user.User {
+	return p.u
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func cookieNames(r *http.Request) []string {
+	names := []string{}
+	for _, c := range r.Cookies() {
+		if c.Value != "" {
+			names = append(names, c.Name)
+		}
+	}
+	return names
+}
+
+// stubRouter stands in for the application: /items needs an access token,
+// /login sets the access and refresh cookies, /me and /refresh echo the
+// cookies they receive.
+func stubRouter() chi.Router {
+	r := chi.NewRouter()
+	r.Get("/items", func(w http.ResponseWriter, r *http.Request) {
+		access, err := r.Cookie(authhttp.AccessJWTCookie)
+		if err != nil || access.Value == "" {
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"message": "unauthorized"})
+			return
+		}
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		writeJSON(w, http.StatusOK, map[string]any{
+			"data":  []map[string]any{{"id": "a1", "page": page}},
+			"token": access.Value,
+		})
+	})
+	r.Post("/items", func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"message": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusCreated, map[string]any{"received": body})
+	})
+	r.Post("/login", func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: authhttp.AccessJWTCookie, Value: "access", Path: "/", Domain: "localhost", Secure: true})
+		http.SetCookie(w, &http.Cookie{Name: authhttp.RefreshJWTCookie, Value: "refresh", Path: "/refresh", Domain: "localhost", Secure: true})
+		writeJSON(w, http.StatusOK, map[string]bool{"success": true})
+	})
+	r.Get("/me", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]any{"cookies": cookieNames(r)})
+	})
+	r.Post("/refresh", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]any{"cookies": cookieNames(r)})
+	})
+	return r
+}
+
+func TestClient_As(t *testing.T) {
+	h := NewHelper(stubRouter())
+	minted := 0
+	mint := h.tokens.mint
+	h.tokens.mint = func(id user.ID, role roles.Global) (string, error) {
+		minted++
+		return mint(id, role)
+	}
+
+	staff := principal{u: builders.NewUserBuilder().AsStaff().Build()}
+	client := h.As(staff)
+
+	first := client.Get("/items").WithQuery("page", 2).
+		Expect(t).
+		Status(http.StatusOK).
+		JSONPath("$.data[0].id", "a1").
+		JSONPath("$.data[0].page", 2).
+		JSONPathExists("$.token")
+
+	var firstBody struct{ Token string }
+	first.JSON(&firstBody)
+	require.NotEmpty(t, firstBody.Token)
+
+	var secondBody struct{ Token string }
+	h.As(staff).Get("/items").Expect(t).Status(http.StatusOK).JSON(&secondBody)
+
+	assert.Equal(t, firstBody.Token, secondBody.Token)
+	assert.Equal(t, 1, minted, "the token is minted once per user")
+
+	other := principal{u: builders.NewUserBuilder().AsStaff().Build()}
+	h.As(other).Get("/items").Expect(t).Status(http.StatusOK)
+	assert.Equal(t, 2, minted)
+}
+
+func TestClient_Anon(t *testing.T) {
+	h := NewHelper(stubRouter())
+
+	h.Anon().Get("/items").Expect(t).
+		Status(http.StatusUnauthorized).
+		JSONPath("$.message", "unauthorized")
+}
+
+func TestClient_JSONBody(t *testing.T) {
+	h := NewHelper(stubRouter())
+
+	h.Anon().Post("/items").
+		WithJSON(map[string]any{"name": "board", "tags": []string{"a", "b"}}).
+		Expect(t).
+		Status(http.StatusCreated).
+		JSONPath("$.received.name", "board").
+		JSONPath("$.received.tags", []string{"a", "b"}).
+		JSONPath("$['received']['tags'][1]", "b")
+}
+
+func TestClient_Session(t *testing.T) {
+	h := NewHelper(stubRouter())
+
+	h.Anon().Post("/login").Expect(t).Status(http.StatusOK)
+	h.Anon().Get("/me").Expect(t).JSONPath("$.cookies", []string{})
+
+	session := h.Session()
+	session.Post("/login").Expect(t).Status(http.StatusOK)
+	session.Get("/me").Expect(t).
+		JSONPath("$.cookies", []string{authhttp.AccessJWTCookie})
+	// the jar sends the cookies with the longer path first
+	session.Post("/refresh").Expect(t).
+		JSONPath("$.cookies", []string{authhttp.RefreshJWTCookie, authhttp.AccessJWTCookie})
+}
+
+func TestResponse_MatchGolden(t *testing.T) {
+	h := NewHelper(stubRouter())
+	dir := t.TempDir()
+	golden := "{\n  \"received\": {\n    \"id\": \"<ignored>\",\n    \"name\": \"board\"\n  }\n}\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "item.json"), []byte(golden), 0o644))
+
+	h.Anon().Post("/items").
+		WithJSON(map[string]any{"id": uuid.NewString(), "name": "board"}).
+		Do(t).
+		RequireStatus(http.StatusCreated).
+		MatchGolden(t, "item.json", goldenx.Dir(dir), goldenx.IgnoreFields("$.received.id"))
+}
+
+func TestLookupJSONPath(t *testing.T) {
+	var doc any
+	require.NoError(t, json.Unmarshal([]byte(`{"data":[{"id":"a1","tags":["x"]}],"meta":{"total":1,"a.b":true}}`), &doc))
+
+	tests := []struct {
+		path    string
+		want    any
+		wantErr string
+	}{
+		{path: "$", want: doc},
+		{path: "$.data[0].id", want: "a1"},
+		{path: "$.data[0].tags[0]", want: "x"},
+		{path: "$.meta.total", want: float64(1)},
+		{path: "$.meta['a.b']", want: true},
+		{path: `$["meta"]["total"]`, want: float64(1)},
+		{path: "data", wantErr: "must start with $"},
+		{path: "$.data[1]", wantErr: "out of range"},
+		{path: "$.data[x]", wantErr: "invalid index"},
+		{path: "$.data[0", wantErr: "unclosed ["},
+		{path: "$.meta[0]", wantErr: "non-array"},
+		{path: "$.data.id", wantErr: "non-object"},
+		{path: "$.missing", wantErr: "not found"},
+		{path: "$..id", wantErr: "empty member name"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, err := lookupJSONPath(doc, tt.path)
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -8,7 +8,9 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"testing"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/stretchr/testify/assert"
@@ -17,15 +19,17 @@ import (
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/roles"
 	authhttp "gitlab.com/ucmsv2/ucms-backend/internal/ports/http/auth"
-	"gitlab.com/ucmsv2/ucms-backend/tests/integration/builders"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/framework/goldenx"
 )
 
 type Helper struct {
 	handler chi.Router
+	// tokens caches the access tokens of the suite's users for As.
+	tokens *tokenCache
 }
 
 func NewHelper(handler chi.Router) *Helper {
-	return &Helper{handler: handler}
+	return &Helper{handler: handler, tokens: newTokenCache()}
 }
 
 type Request struct {
@@ -45,6 +49,15 @@ type Response struct {
 func (h *Helper) Do(t *testing.T, req Request) *Response {
 	t.Helper()
 
+	w := httptest.NewRecorder()
+	h.handler.ServeHTTP(w, newHTTPRequest(t, req))
+
+	return &Response{ResponseRecorder: w, t: t}
+}
+
+func newHTTPRequest(t *testing.T, req Request) *http.Request {
+	t.Helper()
+
 	var body io.Reader
 	if req.Body != nil {
 		// Check if the body is already an io.Reader (for multipart forms)
@@ -82,10 +95,7 @@ func (h *Helper) Do(t *testing.T, req Request) *Response {
 		httpReq = httpReq.WithContext(req.Context)
 	}
 
-	w := httptest.NewRecorder()
-	h.handler.ServeHTTP(w, httpReq)
-
-	return &Response{ResponseRecorder: w, t: t}
+	return httpReq
 }
 
 func (r *Response) AssertStatus(expected int) *Response {
@@ -133,6 +143,26 @@ func (r *Response) AssertHeaderContains(key, value string) *Response {
 	return r
 }
 
+// AssertRetryAfter checks the 429 tells the client to retry at expected,
+// within a second: the Retry-After header counted from now, and the
+// available_at of the body.
+func (r *Response) AssertRetryAfter(now, expected time.Time) *Response {
+	r.t.Helper()
+
+	r.AssertStatus(http.StatusTooManyRequests)
+	seconds, err := strconv.Atoi(r.Header().Get("Retry-After"))
+	require.NoError(r.t, err, "Retry-After is not a number of seconds: %q", r.Header().Get("Retry-After"))
+	assert.WithinDuration(r.t, expected, now.Add(time.Duration(seconds)*time.Second), time.Second,
+		"Retry-After is %ds from %s", seconds, now)
+
+	var body struct {
+		AvailableAt time.Time `json:"available_at"`
+	}
+	r.RequireParseJSON(&body)
+	assert.WithinDuration(r.t, expected, body.AvailableAt, time.Second, "available_at")
+	return r
+}
+
 func (r *Response) AssertMessage(expected string) *Response {
 	r.t.Helper()
 
@@ -236,6 +266,14 @@ func (r *Response) ParseJSONIfExists(v any) *Response {
 	return r
 }
 
+// MatchGolden compares the JSON body with the golden file name in the
+// testdata directory of the test package, see goldenx.Match.
+func (r *Response) MatchGolden(t *testing.T, name string, opts ...goldenx.Option) *Response {
+	t.Helper()
+	goldenx.Match(t, name, r.Body.Bytes(), opts...)
+	return r
+}
+
 func (r *Response) GetCookie(name string) *http.Cookie {
 	r.t.Helper()
 
@@ -271,25 +309,30 @@ func (r *Response) getDetails() string {
 
 type RequestBuilderOptions func(*RequestBuilder)
 
+// WithStaff authenticates the request as the staff with id, the token is
+// minted once per user.
 func WithStaff(t *testing.T, id user.ID) RequestBuilderOptions {
-	token := builders.JWTFactory{}.
-		AccessTokenBuilder(id.String(), roles.Staff.String()).
-		BuildSignedStringT(t)
-	return WithAccessTokenCookie(token)
+	t.Helper()
+	return WithAccessTokenCookie(tokens.get(t, id, roles.Staff))
 }
 
+// WithStudent authenticates the request as the student with id, the token
+// is minted once per user.
 func WithStudent(t *testing.T, id user.ID) RequestBuilderOptions {
-	token := builders.JWTFactory{}.
-		AccessTokenBuilder(id.String(), roles.Student.String()).
-		BuildSignedStringT(t)
-	return WithAccessTokenCookie(token)
+	t.Helper()
+	return WithAccessTokenCookie(tokens.get(t, id, roles.Student))
+}
+
+// WithAITUSA authenticates the request as the student association member
+// with id, the token is minted once per user.
+func WithAITUSA(t *testing.T, id user.ID) RequestBuilderOptions {
+	t.Helper()
+	return WithAccessTokenCookie(tokens.get(t, id, roles.AITUSA))
 }
 
 func WithUserJWT(t *testing.T, id user.ID) RequestBuilderOptions {
-	token := builders.JWTFactory{}.
-		AccessTokenBuilder(id.String(), roles.Unknown.String()).
-		BuildSignedStringT(t)
-	return WithAccessTokenCookie(token)
+	t.Helper()
+	return WithAccessTokenCookie(tokens.get(t, id, roles.Guest))
 }
 
 // WithAccessTokenCookie adds access token cookie to the request to simulate authenticated user
@@ -309,6 +352,20 @@ func WithAccessTokenCookie(token string) RequestBuilderOptions {
 	}
 }
 
+// WithRequestHeader sets a header on the request.
+func WithRequestHeader(key, value string) RequestBuilderOptions {
+	return func(b *RequestBuilder) {
+		b.WithHeader(key, value)
+	}
+}
+
+// WithRequestQuery sets a query parameter on the request.
+func WithRequestQuery(key, value string) RequestBuilderOptions {
+	return func(b *RequestBuilder) {
+		b.WithQuery(key, value)
+	}
+}
+
 // WithAnon removes access token cookie to simulate anonymous user
 func WithAnon() RequestBuilderOptions {
 	return func(b *RequestBuilder) {
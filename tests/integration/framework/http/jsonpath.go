@@ -0,0 +1,87 @@
+package http
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// lookupJSONPath returns the value at path in doc, a document decoded into
+// any. It supports the subset of JSONPath the tests need: the root $,
+// .name and ['name'] members and [n] array indexes, e.g. $.data[0].id.
+func lookupJSONPath(doc any, path string) (any, error) {
+	rest, ok := strings.CutPrefix(path, "$")
+	if !ok {
+		return nil, fmt.Errorf("path %q must start with $", path)
+	}
+
+	current := doc
+	for rest != "" {
+		var (
+			key   string
+			index = -1
+		)
+		switch {
+		case strings.HasPrefix(rest, "."):
+			end := strings.IndexAny(rest[1:], ".[")
+			if end < 0 {
+				end = len(rest) - 1
+			}
+			key, rest = rest[1:end+1], rest[end+1:]
+			if key == "" {
+				return nil, fmt.Errorf("path %q: empty member name", path)
+			}
+		case strings.HasPrefix(rest, "["):
+			end := strings.Index(rest, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("path %q: unclosed [", path)
+			}
+			selector := rest[1:end]
+			rest = rest[end+1:]
+			if unquoted, ok := unquoteSelector(selector); ok {
+				key = unquoted
+				break
+			}
+			n, err := strconv.Atoi(selector)
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("path %q: invalid index %q", path, selector)
+			}
+			index = n
+		default:
+			return nil, fmt.Errorf("path %q: unexpected %q", path, rest)
+		}
+
+		if index >= 0 {
+			array, ok := current.([]any)
+			if !ok {
+				return nil, fmt.Errorf("path %q: [%d] of a non-array", path, index)
+			}
+			if index >= len(array) {
+				return nil, fmt.Errorf("path %q: index %d out of range, length %d", path, index, len(array))
+			}
+			current = array[index]
+			continue
+		}
+
+		object, ok := current.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("path %q: member %q of a non-object", path, key)
+		}
+		current, ok = object[key]
+		if !ok {
+			return nil, fmt.Errorf("path %q: member %q not found", path, key)
+		}
+	}
+	return current, nil
+}
+
+func unquoteSelector(selector string) (string, bool) {
+	if len(selector) < 2 {
+		return "", false
+	}
+	quote := selector[0]
+	if (quote != '\'' && quote != '"') || selector[len(selector)-1] != quote {
+		return "", false
+	}
+	return selector[1 : len(selector)-1], true
+}
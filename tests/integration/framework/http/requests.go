@@ -4,54 +4,58 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/google/uuid"
+
+	announcementhttp "gitlab.com/ucmsv2/ucms-backend/internal/ports/http/announcement"
 	authhttp "gitlab.com/ucmsv2/ucms-backend/internal/ports/http/auth"
+	devhttp "gitlab.com/ucmsv2/ucms-backend/internal/ports/http/dev"
 	registrationhttp "gitlab.com/ucmsv2/ucms-backend/internal/ports/http/registration"
 	staffhttp "gitlab.com/ucmsv2/ucms-backend/internal/ports/http/staff"
+	statushttp "gitlab.com/ucmsv2/ucms-backend/internal/ports/http/status"
+	studenthttp "gitlab.com/ucmsv2/ucms-backend/internal/ports/http/student"
+	termhttp "gitlab.com/ucmsv2/ucms-backend/internal/ports/http/term"
+	toshttp "gitlab.com/ucmsv2/ucms-backend/internal/ports/http/tos"
 )
 
 var ApplicationJSONHeaders = map[string]string{"Content-Type": "application/json"}
 
 func (h *Helper) StartStudentRegistration(t *testing.T, email string) *Response {
-	return h.Do(t, NewRequest("POST", "/v1/registrations/students/start").
+	return h.Anon().Post("/v1/registrations/students/start").
 		WithJSON(map[string]string{"email": email}).
-		Build(),
-	)
+		Do(t)
 }
 
 func (h *Helper) VerifyRegistrationCode(t *testing.T, email, code string) *Response {
-	return h.Do(t, NewRequest("POST", "/v1/registrations/verify").
+	return h.Anon().Post("/v1/registrations/verify").
 		WithJSON(registrationhttp.VerifyRequest{
 			Email:            email,
 			VerificationCode: code,
 		}).
-		Build(),
-	)
+		Do(t)
 }
 
 func (h *Helper) CompleteStudentRegistration(t *testing.T, req registrationhttp.CompleteStudentRegistrationRequest) *Response {
-	return h.Do(t, NewRequest("POST", "/v1/registrations/students/complete").
-		WithJSON(req).
-		Build(),
-	)
+	return h.Anon().Post("/v1/registrations/students/complete").WithJSON(req).Do(t)
 }
 
 func (h *Helper) ResendVerificationCode(t *testing.T, email string) *Response {
-	return h.Do(t, NewRequest("POST", "/v1/registrations/resend").
+	return h.Anon().Post("/v1/registrations/resend").
 		WithJSON(map[string]string{"email": email}).
-		Build(),
-	)
+		Do(t)
 }
 
 func (h *Helper) Login(t *testing.T, emailOrBarcode, password string) *Response {
-	return h.Do(t, NewRequest("POST", "/v1/auth/login").
+	return h.Anon().Post("/v1/auth/login").
 		WithJSON(map[string]string{
 			"email_barcode": emailOrBarcode,
 			"password":      password,
 		}).
-		Build(),
-	)
+		Do(t)
 }
 
 func (h *Helper) Refresh(t *testing.T, refreshToken string) *Response {
@@ -71,9 +75,7 @@ func (h *Helper) Refresh(t *testing.T, refreshToken string) *Response {
 }
 
 func (h *Helper) GetVerificationCode(t *testing.T, email string) *Response {
-	return h.Do(t, NewRequest("GET", "/dev/registrations/verification-code/"+email).
-		Build(),
-	)
+	return h.Anon().Get("/dev/registrations/verification-code/" + email).Do(t)
 }
 
 func (h *Helper) Logout(t *testing.T, accessToken, refreshToken string) *Response {
@@ -103,11 +105,26 @@ func (h *Helper) Logout(t *testing.T, accessToken, refreshToken string) *Respons
 
 func (h *Helper) CreateStaffInvitation(t *testing.T, req staffhttp.CreateInvitationRequest, opts ...RequestBuilderOptions) *Response {
 	t.Helper()
-	r := NewRequest("POST", "/v1/staffs/invitations").WithJSON(req)
-	for _, opt := range opts {
-		opt(r)
-	}
-	return h.Do(t, r.Build())
+	return h.Anon().Post("/v1/staffs/invitations").WithJSON(req).With(opts...).Do(t)
+}
+
+func (h *Helper) BulkCreateStaffInvitations(
+	t *testing.T,
+	req staffhttp.BulkCreateInvitationsRequest,
+	opts ...RequestBuilderOptions,
+) *Response {
+	t.Helper()
+	return h.Anon().Post("/v1/staffs/invitations/bulk").WithJSON(req).With(opts...).Do(t)
+}
+
+func (h *Helper) GetStaffProfile(t *testing.T, opts ...RequestBuilderOptions) *Response {
+	t.Helper()
+	return h.Anon().Get("/v1/staffs/me").With(opts...).Do(t)
+}
+
+func (h *Helper) UpdateStaffProfile(t *testing.T, req staffhttp.UpdateProfileRequest, opts ...RequestBuilderOptions) *Response {
+	t.Helper()
+	return h.Anon().Patch("/v1/staffs/me").WithJSON(req).With(opts...).Do(t)
 }
 
 func (h *Helper) UpdateStaffInvitationRecipients(
@@ -117,11 +134,7 @@ func (h *Helper) UpdateStaffInvitationRecipients(
 	opts ...RequestBuilderOptions,
 ) *Response {
 	t.Helper()
-	r := NewRequest("PUT", "/v1/staffs/invitations/"+invitationID+"/recipients").WithJSON(req)
-	for _, opt := range opts {
-		opt(r)
-	}
-	return h.Do(t, r.Build())
+	return h.Anon().Put("/v1/staffs/invitations/" + invitationID + "/recipients").WithJSON(req).With(opts...).Do(t)
 }
 
 func (h *Helper) UpdateStaffInvitationValidity(
@@ -131,38 +144,22 @@ func (h *Helper) UpdateStaffInvitationValidity(
 	opts ...RequestBuilderOptions,
 ) *Response {
 	t.Helper()
-	r := NewRequest("PUT", "/v1/staffs/invitations/"+invitationID+"/validity").WithJSON(req)
-	for _, opt := range opts {
-		opt(r)
-	}
-	return h.Do(t, r.Build())
+	return h.Anon().Put("/v1/staffs/invitations/" + invitationID + "/validity").WithJSON(req).With(opts...).Do(t)
 }
 
 func (h *Helper) DeleteStaffInvitation(t *testing.T, invitationID string, opts ...RequestBuilderOptions) *Response {
 	t.Helper()
-	r := NewRequest("DELETE", "/v1/staffs/invitations/"+invitationID)
-	for _, opt := range opts {
-		opt(r)
-	}
-	return h.Do(t, r.Build())
+	return h.Anon().Delete("/v1/staffs/invitations/" + invitationID).With(opts...).Do(t)
 }
 
 func (h *Helper) ValidateStaffInvitation(t *testing.T, code string, email string, opts ...RequestBuilderOptions) *Response {
 	t.Helper()
-	r := NewRequest("GET", fmt.Sprintf("/v1/invitations/%s/validate?email=%s", code, email))
-	for _, opt := range opts {
-		opt(r)
-	}
-	return h.Do(t, r.Build())
+	return h.Anon().Get(fmt.Sprintf("/v1/invitations/%s/validate?email=%s", code, email)).With(opts...).Do(t)
 }
 
 func (h *Helper) AcceptStaffInvitation(t *testing.T, req staffhttp.AcceptInvitationRequest, opts ...RequestBuilderOptions) *Response {
 	t.Helper()
-	r := NewRequest("POST", "/v1/invitations/accept").WithJSON(req)
-	for _, opt := range opts {
-		opt(r)
-	}
-	return h.Do(t, r.Build())
+	return h.Anon().Post("/v1/invitations/accept").WithJSON(req).With(opts...).Do(t)
 }
 
 func (h *Helper) UpdateUserAvatar(t *testing.T, fileData []byte, opts ...RequestBuilderOptions) *Response {
@@ -173,36 +170,320 @@ func (h *Helper) UpdateUserAvatar(t *testing.T, fileData []byte, opts ...Request
 		body, contentType = NewMultipartFormBuilder().AddFile("avatar", "avatar.jpg", "image/jpeg", fileData).Build()
 	}
 
-	req := NewRequest("PATCH", "/v1/users/me/avatar")
+	call := h.Anon().Patch("/v1/users/me/avatar")
 	if body != nil {
-		req.WithBody(body).WithHeader("Content-Type", contentType)
-	}
-
-	for _, opt := range opts {
-		opt(req)
+		call.WithBody(body).WithHeader("Content-Type", contentType)
 	}
-
-	return h.Do(t, req.Build())
+	return call.With(opts...).Do(t)
 }
 
 func (h *Helper) UpdateUserAvatarWithFile(t *testing.T, filename, contentType string, fileData []byte, opts ...RequestBuilderOptions) *Response {
 	body, formContentType := NewMultipartFormBuilder().AddFile("avatar", filename, contentType, fileData).Build()
 
-	req := NewRequest("PATCH", "/v1/users/me/avatar").
+	return h.Anon().Patch("/v1/users/me/avatar").
 		WithBody(body).
-		WithHeader("Content-Type", formContentType)
+		WithHeader("Content-Type", formContentType).
+		With(opts...).
+		Do(t)
+}
+
+func (h *Helper) DeleteUserAvatar(t *testing.T, opts ...RequestBuilderOptions) *Response {
+	return h.Anon().Delete("/v1/users/me/avatar").With(opts...).Do(t)
+}
+
+func (h *Helper) GetStudentProfile(t *testing.T, opts ...RequestBuilderOptions) *Response {
+	return h.Anon().Get("/v1/students/me").With(opts...).Do(t)
+}
+
+func (h *Helper) ExportUserData(t *testing.T, opts ...RequestBuilderOptions) *Response {
+	return h.Anon().Get("/v1/users/me/export").With(opts...).Do(t)
+}
+
+// GetVersion gets the build info and the schema versions.
+func (h *Helper) GetVersion(t *testing.T) *Response {
+	t.Helper()
+	return h.Anon().Get("/v1/version").Do(t)
+}
+
+// GetReady gets the readiness of the instance.
+func (h *Helper) GetReady(t *testing.T) *Response {
+	t.Helper()
+	return h.Anon().Get("/ready").Do(t)
+}
 
-	for _, opt := range opts {
-		opt(req)
+// ListSystemErrors lists the error inbox, status may be empty for the open errors.
+func (h *Helper) ListSystemErrors(t *testing.T, status string, opts ...RequestBuilderOptions) *Response {
+	t.Helper()
+	call := h.Anon().Get("/v1/staffs/system/errors")
+	if status != "" {
+		call.WithQuery("status", status)
 	}
+	return call.With(opts...).Do(t)
+}
 
-	return h.Do(t, req.Build())
+// ListValidationStats lists the fields failing validation, route and days
+// are left to the defaults when empty and zero.
+func (h *Helper) ListValidationStats(t *testing.T, route string, days int, opts ...RequestBuilderOptions) *Response {
+	t.Helper()
+	call := h.Anon().Get("/v1/staffs/system/validation-stats")
+	if route != "" {
+		call.WithQuery("route", route)
+	}
+	if days != 0 {
+		call.WithQuery("days", strconv.Itoa(days))
+	}
+	return call.With(opts...).Do(t)
 }
 
-func (h *Helper) DeleteUserAvatar(t *testing.T, opts ...RequestBuilderOptions) *Response {
-	req := NewRequest("DELETE", "/v1/users/me/avatar")
-	for _, opt := range opts {
-		opt(req)
+// ListArchives lists the archives of the retention job, table may be empty
+// for all of them.
+func (h *Helper) ListArchives(t *testing.T, table string, opts ...RequestBuilderOptions) *Response {
+	t.Helper()
+	call := h.Anon().Get("/v1/staffs/system/archives")
+	if table != "" {
+		call.WithQuery("table", table)
 	}
-	return h.Do(t, req.Build())
+	return call.With(opts...).Do(t)
+}
+
+// ResolveSystemError resolves the error inbox entry with signature.
+func (h *Helper) ResolveSystemError(t *testing.T, signature string, opts ...RequestBuilderOptions) *Response {
+	t.Helper()
+	return h.Anon().Post("/v1/staffs/system/errors/" + signature + "/resolve").With(opts...).Do(t)
+}
+
+// ListJobs lists the background jobs of the instance.
+func (h *Helper) ListJobs(t *testing.T, opts ...RequestBuilderOptions) *Response {
+	t.Helper()
+	return h.Anon().Get("/v1/staffs/system/jobs").With(opts...).Do(t)
+}
+
+// RunJob triggers a run of the background job name.
+func (h *Helper) RunJob(t *testing.T, name string, opts ...RequestBuilderOptions) *Response {
+	t.Helper()
+	return h.Anon().Post("/v1/staffs/system/jobs/" + name + "/run").With(opts...).Do(t)
+}
+
+// AdvanceDevClock moves the application clock by d through POST /v1/dev/clock,
+// the way a tester does against a deployed dev environment.
+func (h *Helper) AdvanceDevClock(t *testing.T, d time.Duration) *Response {
+	t.Helper()
+	return h.Anon().Post("/v1/dev/clock").
+		WithJSON(devhttp.SetClockRequest{Advance: d.String()}).
+		Do(t)
+}
+
+// TransferStudentGroup moves the student with barcode to the group groupID.
+func (h *Helper) TransferStudentGroup(t *testing.T, barcode string, groupID uuid.UUID, opts ...RequestBuilderOptions) *Response {
+	t.Helper()
+	return h.Anon().Put("/v1/staffs/students/" + barcode + "/group").
+		WithJSON(studenthttp.TransferGroupRequest{GroupID: groupID}).
+		With(opts...).
+		Do(t)
+}
+
+// UpdateGroup sends body, a merge patch of the group groupID, as is.
+func (h *Helper) UpdateGroup(t *testing.T, groupID uuid.UUID, body string, opts ...RequestBuilderOptions) *Response {
+	t.Helper()
+	return h.Anon().Patch("/v1/staffs/groups/"+groupID.String()).
+		WithHeader("Content-Type", "application/json").
+		WithBody(strings.NewReader(body)).
+		With(opts...).Do(t)
+}
+
+// GetGroup gets the group groupID with its member count.
+func (h *Helper) GetGroup(t *testing.T, groupID uuid.UUID, opts ...RequestBuilderOptions) *Response {
+	t.Helper()
+	return h.Anon().Get("/v1/staffs/groups/" + groupID.String()).With(opts...).Do(t)
+}
+
+func (h *Helper) CreateGroup(t *testing.T, req studenthttp.CreateGroupRequest, opts ...RequestBuilderOptions) *Response {
+	t.Helper()
+	return h.Anon().Post("/v1/staffs/groups").WithJSON(req).With(opts...).Do(t)
+}
+
+// ListGroups lists the groups as the registration form does.
+func (h *Helper) ListGroups(t *testing.T, opts ...RequestBuilderOptions) *Response {
+	t.Helper()
+	return h.Anon().Get("/v1/groups").With(opts...).Do(t)
+}
+
+// ListNotifications lists the notifications of the user, only the unread ones
+// with unreadOnly.
+func (h *Helper) ListNotifications(t *testing.T, unreadOnly bool, opts ...RequestBuilderOptions) *Response {
+	t.Helper()
+	return h.Anon().Get("/v1/users/me/notifications").
+		WithQuery("unread_only", unreadOnly).
+		With(opts...).
+		Do(t)
+}
+
+func (h *Helper) MarkNotificationRead(t *testing.T, id string, opts ...RequestBuilderOptions) *Response {
+	t.Helper()
+	return h.Anon().Post("/v1/users/me/notifications/" + id + "/read").With(opts...).Do(t)
+}
+
+func (h *Helper) MarkAllNotificationsRead(t *testing.T, opts ...RequestBuilderOptions) *Response {
+	t.Helper()
+	return h.Anon().Post("/v1/users/me/notifications/read-all").With(opts...).Do(t)
+}
+
+// StreamNotifications opens the notification stream of the user, resuming
+// after lastEventID unless it is "".
+func (h *Helper) StreamNotifications(t *testing.T, lastEventID string, opts ...RequestBuilderOptions) *Stream {
+	t.Helper()
+	call := h.Anon().Get("/v1/users/me/notifications/stream").With(opts...)
+	if lastEventID != "" {
+		call.WithHeader("Last-Event-ID", lastEventID)
+	}
+	return call.Stream(t)
+}
+
+func (h *Helper) CreateAnnouncement(t *testing.T, req announcementhttp.CreateAnnouncementRequest, opts ...RequestBuilderOptions) *Response {
+	t.Helper()
+	return h.Anon().Post("/v1/aitusa/announcements").WithJSON(req).With(opts...).Do(t)
+}
+
+// ListAnnouncements lists the announcements the user sees, page starts at 1.
+func (h *Helper) ListAnnouncements(t *testing.T, page int, opts ...RequestBuilderOptions) *Response {
+	t.Helper()
+	return h.Anon().Get("/v1/announcements").WithQuery("page", page).With(opts...).Do(t)
+}
+
+func (h *Helper) UnpublishAnnouncement(t *testing.T, id string, opts ...RequestBuilderOptions) *Response {
+	t.Helper()
+	return h.Anon().Post("/v1/staffs/announcements/" + id + "/unpublish").With(opts...).Do(t)
+}
+
+func (h *Helper) GetCurrentTOS(t *testing.T) *Response {
+	t.Helper()
+	return h.Anon().Get("/v1/tos/current").Do(t)
+}
+
+func (h *Helper) PublishTOSVersion(t *testing.T, req toshttp.PublishVersionRequest, opts ...RequestBuilderOptions) *Response {
+	t.Helper()
+	return h.Anon().Post("/v1/tos/versions").WithJSON(req).With(opts...).Do(t)
+}
+
+func (h *Helper) VerifyEmail(t *testing.T, code string, opts ...RequestBuilderOptions) *Response {
+	t.Helper()
+	return h.Anon().Post("/v1/users/me/email/verify").WithJSON(map[string]string{"code": code}).With(opts...).Do(t)
+}
+
+func (h *Helper) AcceptTOS(t *testing.T, opts ...RequestBuilderOptions) *Response {
+	t.Helper()
+	return h.Anon().Post("/v1/users/me/tos/accept").With(opts...).Do(t)
+}
+
+// GetWeeklyReport gets the weekly report of the week day is in, a zero day
+// gets the last week that is over.
+func (h *Helper) GetWeeklyReport(t *testing.T, day time.Time, opts ...RequestBuilderOptions) *Response {
+	t.Helper()
+	call := h.Anon().Get("/v1/staffs/reports/weekly")
+	if !day.IsZero() {
+		call.WithQuery("week", day.Format(time.DateOnly))
+	}
+	return call.With(opts...).Do(t)
+}
+
+func (h *Helper) GetDashboard(t *testing.T, opts ...RequestBuilderOptions) *Response {
+	t.Helper()
+	return h.Anon().Get("/v1/staffs/dashboard").With(opts...).Do(t)
+}
+
+// GetTermDashboard gets the dashboard of the term termID instead of the
+// current one.
+func (h *Helper) GetTermDashboard(t *testing.T, termID string, opts ...RequestBuilderOptions) *Response {
+	t.Helper()
+	return h.Anon().Get("/v1/staffs/dashboard").WithQuery("term", termID).With(opts...).Do(t)
+}
+
+// Search runs the staff typeahead for q, types is comma separated and may be
+// empty for every type.
+func (h *Helper) Search(t *testing.T, q, types string, opts ...RequestBuilderOptions) *Response {
+	t.Helper()
+	call := h.Anon().Get("/v1/staffs/search").WithQuery("q", q)
+	if types != "" {
+		call.WithQuery("types", types)
+	}
+	return call.With(opts...).Do(t)
+}
+
+func (h *Helper) Impersonate(t *testing.T, barcode string, opts ...RequestBuilderOptions) *Response {
+	t.Helper()
+	return h.Anon().Post("/v1/staffs/users/" + barcode + "/impersonate").With(opts...).Do(t)
+}
+
+func (h *Helper) ListImpersonations(t *testing.T, opts ...RequestBuilderOptions) *Response {
+	t.Helper()
+	return h.Anon().Get("/v1/staffs/audit/impersonations").With(opts...).Do(t)
+}
+
+// ListUsers gets a page of the users containing q, an empty q lists all of
+// them; cursor resumes after the page that returned it.
+func (h *Helper) ListUsers(t *testing.T, q, cursor string, opts ...RequestBuilderOptions) *Response {
+	t.Helper()
+	call := h.Anon().Get("/v1/staffs/search/users")
+	if q != "" {
+		call.WithQuery("q", q)
+	}
+	if cursor != "" {
+		call.WithQuery("cursor", cursor)
+	}
+	return call.With(opts...).Do(t)
+}
+
+// ListInactiveUsers gets a page of the users not seen since, e.g. 90d, an
+// empty since uses the default.
+func (h *Helper) ListInactiveUsers(t *testing.T, since string, page int, opts ...RequestBuilderOptions) *Response {
+	t.Helper()
+	call := h.Anon().Get("/v1/staffs/users/inactive").WithQuery("page", page)
+	if since != "" {
+		call.WithQuery("since", since)
+	}
+	return call.With(opts...).Do(t)
+}
+
+func (h *Helper) GetStatus(t *testing.T) *Response {
+	t.Helper()
+	return h.Anon().Get("/v1/status").Do(t)
+}
+
+func (h *Helper) CreateIncident(t *testing.T, req statushttp.IncidentRequest, opts ...RequestBuilderOptions) *Response {
+	t.Helper()
+	return h.Anon().Post("/v1/staffs/incidents").WithJSON(req).With(opts...).Do(t)
+}
+
+func (h *Helper) UpdateIncident(t *testing.T, id string, req statushttp.IncidentRequest, opts ...RequestBuilderOptions) *Response {
+	t.Helper()
+	return h.Anon().Put("/v1/staffs/incidents/" + id).WithJSON(req).With(opts...).Do(t)
+}
+
+func (h *Helper) CreateTerm(t *testing.T, req termhttp.CreateTermRequest, opts ...RequestBuilderOptions) *Response {
+	t.Helper()
+	return h.Anon().Post("/v1/staffs/terms").WithJSON(req).With(opts...).Do(t)
+}
+
+// UpdateTerm sends body, a merge patch of the term id, as is.
+func (h *Helper) UpdateTerm(t *testing.T, id string, body string, opts ...RequestBuilderOptions) *Response {
+	t.Helper()
+	return h.Anon().Patch("/v1/staffs/terms/"+id).
+		WithHeader("Content-Type", "application/json").
+		WithBody(strings.NewReader(body)).
+		With(opts...).Do(t)
+}
+
+func (h *Helper) DeleteTerm(t *testing.T, id string, opts ...RequestBuilderOptions) *Response {
+	t.Helper()
+	return h.Anon().Delete("/v1/staffs/terms/" + id).With(opts...).Do(t)
+}
+
+func (h *Helper) ListTerms(t *testing.T, opts ...RequestBuilderOptions) *Response {
+	t.Helper()
+	return h.Anon().Get("/v1/staffs/terms").With(opts...).Do(t)
+}
+
+func (h *Helper) GetCurrentTerm(t *testing.T, opts ...RequestBuilderOptions) *Response {
+	t.Helper()
+	return h.Anon().Get("/v1/staffs/terms/current").With(opts...).Do(t)
 }
@@ -0,0 +1,182 @@
+package http
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Event is a server-sent event read from a Stream.
+type Event struct {
+	ID    string
+	Event string
+	Data  string
+}
+
+// Stream is a server-sent event stream open on the application under test,
+// the handler runs until the stream is closed:
+//
+//	stream := s.HTTP.As(student).Get("/v1/users/me/notifications/stream").Stream(t)
+//	defer stream.Close()
+//	stream.RequireStatus(http.StatusOK)
+//	event := stream.Next(time.Second)
+type Stream struct {
+	t      *testing.T
+	w      *streamWriter
+	events chan Event
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+	read   chan struct{}
+}
+
+// Stream sends the request and returns once the response status is
+// written, the body is read as server-sent events as it is flushed.
+func (c *Call) Stream(t *testing.T) *Stream {
+	t.Helper()
+
+	req, _ := c.build(t)
+	ctx, cancel := context.WithCancel(t.Context())
+	req.Context = ctx
+	httpReq := newHTTPRequest(t, req)
+
+	pr, pw := io.Pipe()
+	s := &Stream{
+		t:      t,
+		w:      &streamWriter{header: make(http.Header), body: pw, started: make(chan struct{})},
+		events: make(chan Event, 64),
+		ctx:    ctx,
+		cancel: cancel,
+		done:   make(chan struct{}),
+		read:   make(chan struct{}),
+	}
+
+	go s.readEvents(pr)
+	go func() {
+		defer close(s.done)
+		defer pw.Close()
+		c.client.h.handler.ServeHTTP(s.w, httpReq)
+		s.w.WriteHeader(http.StatusOK)
+	}()
+
+	select {
+	case <-s.w.started:
+	case <-time.After(5 * time.Second):
+		s.Close()
+		require.FailNow(t, "the stream did not start")
+	}
+	return s
+}
+
+// Status is the status code of the response.
+func (s *Stream) Status() int {
+	return s.w.status
+}
+
+func (s *Stream) Header() http.Header {
+	return s.w.header
+}
+
+func (s *Stream) RequireStatus(expected int) *Stream {
+	s.t.Helper()
+	require.Equal(s.t, expected, s.w.status, "unexpected status code of the stream")
+	return s
+}
+
+// Next returns the next event, failing the test if none comes within
+// timeout or the stream ends.
+func (s *Stream) Next(timeout time.Duration) Event {
+	s.t.Helper()
+
+	select {
+	case e, ok := <-s.events:
+		require.True(s.t, ok, "the stream ended")
+		return e
+	case <-time.After(timeout):
+		require.FailNow(s.t, "no event within the timeout", timeout.String())
+		return Event{}
+	}
+}
+
+// Close disconnects the client and waits for the handler to return.
+func (s *Stream) Close() {
+	s.cancel()
+	<-s.done
+	<-s.read
+}
+
+// Done is closed when the handler returned.
+func (s *Stream) Done() <-chan struct{} {
+	return s.done
+}
+
+func (s *Stream) readEvents(r io.Reader) {
+	defer close(s.read)
+	defer close(s.events)
+
+	var e Event
+	var data []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			if data != nil {
+				e.Data = strings.Join(data, "\n")
+				// The events of a closed stream are dropped.
+				select {
+				case s.events <- e:
+				case <-s.ctx.Done():
+				}
+			}
+			e, data = Event{}, nil
+			continue
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "id":
+			e.ID = value
+		case "event":
+			e.Event = value
+		case "data":
+			data = append(data, value)
+		}
+	}
+	// The handler may still write after a line too long for the scanner.
+	_, _ = io.Copy(io.Discard, r)
+}
+
+// streamWriter passes every write to the reader of the stream as it comes,
+// so it flushes by itself.
+type streamWriter struct {
+	header  http.Header
+	body    *io.PipeWriter
+	status  int
+	started chan struct{}
+}
+
+func (w *streamWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *streamWriter) WriteHeader(status int) {
+	if w.status != 0 {
+		return
+	}
+	w.status = status
+	close(w.started)
+}
+
+func (w *streamWriter) Write(b []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	return w.body.Write(b)
+}
+
+func (w *streamWriter) Flush() {}
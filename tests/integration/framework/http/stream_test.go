@@ -0,0 +1,54 @@
+package http
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+
+	"gitlab.com/ucmsv2/ucms-backend/pkg/httpx"
+)
+
+func TestStream(t *testing.T) {
+	sent := make(chan string)
+	r := chi.NewRouter()
+	r.Get("/stream", func(w http.ResponseWriter, r *http.Request) {
+		events, err := httpx.NewEventStream(w, 0)
+		if err != nil {
+			return
+		}
+		_ = events.Comment("heartbeat")
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case id := <-sent:
+				_ = events.Send(id, "item", map[string]string{"id": id})
+			}
+		}
+	})
+	r.Get("/denied", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"message": "unauthorized"})
+	})
+	h := NewHelper(r)
+
+	stream := h.Anon().Get("/stream").Stream(t)
+	stream.RequireStatus(http.StatusOK)
+	assert.Equal(t, "text/event-stream", stream.Header().Get("Content-Type"))
+
+	sent <- "a1"
+	assert.Equal(t, Event{ID: "a1", Event: "item", Data: `{"id":"a1"}`}, stream.Next(time.Second))
+
+	stream.Close()
+	select {
+	case <-stream.Done():
+	default:
+		t.Fatal("the handler should return on close")
+	}
+
+	denied := h.Anon().Get("/denied").Stream(t)
+	defer denied.Close()
+	denied.RequireStatus(http.StatusUnauthorized)
+}
@@ -0,0 +1,66 @@
+package http
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	authapp "gitlab.com/ucmsv2/ucms-backend/internal/application/auth"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/roles"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/builders"
+)
+
+// tokenTTL is how long a minted access token is reused, well within its
+// expiration so that a long test never sends an expired one.
+const tokenTTL = authapp.AccessTokenExpDuration / 2
+
+type tokenKey struct {
+	id   user.ID
+	role roles.Global
+}
+
+type cachedToken struct {
+	token    string
+	mintedAt time.Time
+}
+
+// tokenCache mints an access token once per user and role instead of
+// signing one for every request.
+type tokenCache struct {
+	mu     sync.Mutex
+	tokens map[tokenKey]cachedToken
+	mint   func(id user.ID, role roles.Global) (string, error)
+}
+
+func newTokenCache() *tokenCache {
+	return &tokenCache{
+		tokens: make(map[tokenKey]cachedToken),
+		mint: func(id user.ID, role roles.Global) (string, error) {
+			return builders.JWTFactory{}.AccessTokenBuilder(id.String(), role.String()).BuildSignedString()
+		},
+	}
+}
+
+// tokens backs WithStaff, WithStudent and WithUserJWT, which have no helper
+// to hold a cache of their own.
+var tokens = newTokenCache()
+
+func (c *tokenCache) get(t *testing.T, id user.ID, role roles.Global) string {
+	t.Helper()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := tokenKey{id: id, role: role}
+	if cached, ok := c.tokens[key]; ok && time.Since(cached.mintedAt) < tokenTTL {
+		return cached.token
+	}
+
+	token, err := c.mint(id, role)
+	require.NoError(t, err, "failed to build signed JWT string")
+	c.tokens[key] = cachedToken{token: token, mintedAt: time.Now()}
+	return token
+}
@@ -5,6 +5,7 @@ import (
 	"log/slog"
 	"os"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -14,31 +15,29 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/stretchr/testify/suite"
-	"github.com/testcontainers/testcontainers-go"
 	"github.com/testcontainers/testcontainers-go/modules/minio"
-	"github.com/testcontainers/testcontainers-go/modules/postgres"
-	"github.com/testcontainers/testcontainers-go/wait"
 	"go.opentelemetry.io/otel"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 	"go.opentelemetry.io/otel/trace"
 
-	ucmsv2 "gitlab.com/ucmsv2/ucms-backend"
-	postgresrepo "gitlab.com/ucmsv2/ucms-backend/internal/adapters/repos/postgres"
 	"gitlab.com/ucmsv2/ucms-backend/internal/adapters/services/s3"
-	authapp "gitlab.com/ucmsv2/ucms-backend/internal/application/auth"
-	"gitlab.com/ucmsv2/ucms-backend/internal/application/mail"
-	registrationapp "gitlab.com/ucmsv2/ucms-backend/internal/application/registration"
-	staffapp "gitlab.com/ucmsv2/ucms-backend/internal/application/staff"
-	studentapp "gitlab.com/ucmsv2/ucms-backend/internal/application/student"
+	"gitlab.com/ucmsv2/ucms-backend/internal/app"
+	"gitlab.com/ucmsv2/ucms-backend/internal/application/notification/feed"
 	userapp "gitlab.com/ucmsv2/ucms-backend/internal/application/user"
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/group"
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/internal/jobs"
 	httpport "gitlab.com/ucmsv2/ucms-backend/internal/ports/http"
-	watermillport "gitlab.com/ucmsv2/ucms-backend/internal/ports/watermill"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
 	"gitlab.com/ucmsv2/ucms-backend/pkg/env"
-	postgrespkg "gitlab.com/ucmsv2/ucms-backend/pkg/postgres"
-	"gitlab.com/ucmsv2/ucms-backend/pkg/watermillx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorinbox"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/healthx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/lastseen"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/schemaversion"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/storagex"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/urlx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/validationstats"
 	"gitlab.com/ucmsv2/ucms-backend/tests/integration/builders"
 	"gitlab.com/ucmsv2/ucms-backend/tests/integration/fixtures"
 	"gitlab.com/ucmsv2/ucms-backend/tests/integration/framework/db"
@@ -54,13 +53,35 @@ const (
 	MinIOBucket   = "ucms-test-bucket"
 )
 
+// SuiteOptions declare the infrastructure a suite needs besides PostgreSQL.
+type SuiteOptions struct {
+	// Storage starts MinIO and wires the avatar storage to it, the S3 helper
+	// and S3Client are nil without it.
+	Storage bool
+	// VerifyAvatars makes the profiles check that the avatar objects exist,
+	// see app.StorageConfig.VerifyAvatars. It needs Storage.
+	VerifyAvatars bool
+}
+
 type IntegrationTestSuite struct {
 	suite.Suite
 
+	// Options are set by the suites before they run:
+	//
+	//	suite.Run(t, &AvatarSuite{IntegrationTestSuite: framework.IntegrationTestSuite{
+	//		Options: framework.SuiteOptions{Storage: true},
+	//	}})
+	Options SuiteOptions
+
 	HTTPPort *httpport.Port
 
+	// Clock is the time of the registrations and invitations the
+	// application loads and creates. It runs with the real time until a test
+	// moves it with Advance or Set, and is reset before every test.
+	Clock *clock.Offset
+
 	// Infrastructure
-	pgContainer    *postgres.PostgresContainer
+	database       *suiteDatabase
 	pgPool         *pgxpool.Pool
 	minioContainer *minio.MinioContainer
 
@@ -71,11 +92,14 @@ type IntegrationTestSuite struct {
 
 	routerRunning atomic.Bool
 	testStartTime time.Time
+	// stopListening stops the notification and group listeners of the app,
+	// listening is closed once they returned.
+	stopListening context.CancelFunc
+	listening     chan struct{}
 
 	// Application
-	app           *Application
-	watermillPort *watermillport.Port
-	httpHandler   chi.Router
+	app         *app.App
+	httpHandler chi.Router
 
 	// Helpers
 	HTTP    *http.Helper
@@ -86,15 +110,20 @@ type IntegrationTestSuite struct {
 
 	MockMailSender *mocks.MockMailSender
 	S3Client       *s3.Client
-}
-
-type Application struct {
-	Registration *registrationapp.App
-	Mail         *mail.App
-	Student      *studentapp.App
-	Staff        *staffapp.App
-	Auth         *authapp.App
-	User         *userapp.App
+	// ErrorRecorder is not running, tests call Flush to write the errors.
+	ErrorRecorder *errorinbox.Recorder
+	// ValidationRecorder is not running, tests call Flush to write the
+	// failures.
+	ValidationRecorder *validationstats.Recorder
+	// LastSeen is not running, tests call Flush to write the last seen
+	// times.
+	LastSeen *lastseen.Tracker
+	// Schema is not running, tests call Check to compare the schema.
+	Schema *schemaversion.Checker
+	// Jobs is not running, the suites triggering jobs run it.
+	Jobs *jobs.Runner
+	// Health is not running, tests call ProbeOnce to probe the components.
+	Health *healthx.Monitor
 }
 
 func (s *IntegrationTestSuite) SetupSuite() {
@@ -105,38 +134,41 @@ func (s *IntegrationTestSuite) SetupSuite() {
 	s.traceProvider = sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(s.traceRecorder))
 	otel.SetTracerProvider(s.traceProvider)
 
+	setupStart := time.Now()
 	s.startPostgreSQL(ctx)
-	s.runMigrations()
-	s.startMinIO()
+	postgresDuration := time.Since(setupStart)
+
+	var storageDuration time.Duration
+	if s.Options.Storage {
+		storageStart := time.Now()
+		s.startMinIO()
+		storageDuration = time.Since(storageStart)
+	}
+
 	s.initializeWatermill()
 	s.createApplication()
-	s.createWatermillPort()
 	s.initializeHelpers()
 
 	s.startWatermillRouter()
+	s.startNotificationListener()
 
-	s.T().Log("Test suite setup completed")
+	database := "cloned the template database"
+	if s.database.builtTemplate {
+		database = "migrated the template database"
+	}
+	s.T().Logf("Test suite setup completed in %s: postgres %s (%s), storage %s",
+		time.Since(setupStart).Round(time.Millisecond), postgresDuration.Round(time.Millisecond), database,
+		storageDuration.Round(time.Millisecond))
 }
 
+// startPostgreSQL connects the suite to a database of its own on the shared
+// server, so suites seeding the same fixtures can run in parallel.
 func (s *IntegrationTestSuite) startPostgreSQL(ctx context.Context) {
-	pgContainer, err := postgres.Run(ctx,
-		"postgres:17-alpine",
-		postgres.WithDatabase("ucms_test"),
-		postgres.WithUsername("test"),
-		postgres.WithPassword("test"),
-		testcontainers.WithWaitStrategy(
-			wait.ForLog("database system is ready to accept connections").
-				WithOccurrence(2).
-				WithStartupTimeout(10*time.Second),
-		),
-	)
-	s.Require().NoError(err)
-	s.pgContainer = pgContainer
-
-	connStr, err := pgContainer.ConnectionString(ctx, "sslmode=disable")
+	database, err := createSuiteDatabase(ctx)
 	s.Require().NoError(err)
+	s.database = database
 
-	s.pgPool, err = pgxpool.New(ctx, connStr)
+	s.pgPool, err = database.newPool(ctx)
 	s.Require().NoError(err)
 }
 
@@ -149,14 +181,6 @@ func (s *IntegrationTestSuite) startMinIO() {
 	s.minioContainer = minioContainer
 }
 
-func (s *IntegrationTestSuite) runMigrations() {
-	connStr, _ := s.pgContainer.ConnectionString(context.Background(), "sslmode=disable")
-	connStr = strings.Replace(connStr, "postgres://", "pgx://", 1)
-
-	err := postgrespkg.Migrate(connStr, &ucmsv2.Migrations)
-	s.Require().NoError(err)
-}
-
 func (s *IntegrationTestSuite) initializeWatermill() {
 	logger := watermill.NewStdLogger(false, false)
 	s.watermillRouter, _ = message.NewRouter(message.RouterConfig{}, logger)
@@ -168,12 +192,12 @@ func (s *IntegrationTestSuite) initializeWatermill() {
 			}
 		},
 	)
-
-	err := watermillx.InitializeEventSchema(context.Background(), s.pgPool, logger)
-	s.Require().NoError(err)
 }
 
-func (s *IntegrationTestSuite) createApplication() {
+// NewS3Client returns a client for the given bucket on the suite MinIO.
+// The bucket is not created.
+func (s *IntegrationTestSuite) NewS3Client(bucket string) *s3.Client {
+	s.Require().NotNil(s.minioContainer, "MinIO is not running, the suite must set Options.Storage")
 	endpoint, err := s.minioContainer.Endpoint(s.Context(), "")
 	s.Require().NoError(err)
 
@@ -186,112 +210,72 @@ func (s *IntegrationTestSuite) createApplication() {
 		endpoint,
 		MinIOUsername,
 		MinIOPassword,
-		MinIOBucket,
+		bucket,
 		"us-east-1",
 	)
 	s.Require().NoError(err)
+	return s3Client
+}
 
-	// Create the bucket for tests
-	err = s3Client.CreateBucket(s.Context())
-	s.Require().NoError(err)
+// NewAppConfig returns the configuration of the application the suites
+// test, built around the fixtures.
+func NewAppConfig() *app.Config {
+	cfg := app.DefaultConfig()
+	cfg.Mode = env.Test
+	cfg.Port = "0"
+	cfg.AccessTokenSecretKey = fixtures.AccessTokenSecretKey
+	cfg.RefreshTokenSecretKey = fixtures.RefreshTokenSecretKey
+	cfg.InvitationTokenSecretKey = fixtures.InvitationTokenKey
+	cfg.TokenTTL.Invitation = fixtures.InvitationTokenExp
+	cfg.StaffInvitationLinkURL = urlx.MustParse("http://localhost:3000/invitations/staff")
+	cfg.AcceptInvitationPageURL = urlx.MustParse(fixtures.StaffInvitationAcceptPageURL)
+	cfg.Service.Name = fixtures.ServiceName
+	cfg.CookieDomain = "localhost"
+	cfg.Storage.URLStrategy = string(storagex.URLStrategyPublic)
+	cfg.Scanner.Backend = app.ScannerBackendNone
+	cfg.AvatarGC.Interval = 0
+	return cfg
+}
 
-	s.S3Client = s3Client
+func (s *IntegrationTestSuite) createApplication() {
+	var avatarStorage userapp.AvatarStorage
+	if s.Options.Storage {
+		s3Client := s.NewS3Client(MinIOBucket)
 
-	registrationRepo := postgresrepo.NewRegistrationRepo(s.pgPool, nil, nil)
-	userRepo := postgresrepo.NewUserRepo(s.pgPool, nil, nil)
-	studentRepo := postgresrepo.NewStudentRepo(s.pgPool, nil, nil)
-	staffInvitationRepo := postgresrepo.NewStaffInvitationRepo(s.pgPool, nil, nil)
-	staffRepo := postgresrepo.NewStaffRepo(s.pgPool, nil, nil)
-	groupRepo := postgresrepo.NewGroupRepo(s.pgPool, nil, nil)
+		// Create the bucket for tests
+		err := s3Client.CreateBucket(s.Context())
+		s.Require().NoError(err)
 
-	s.MockMailSender = mocks.NewMockMailSender()
-	s.Require().NotNil(s.MockMailSender, "MockMailSender should be initialized")
-
-	regApp := registrationapp.NewApp(registrationapp.Args{
-		Mode:         env.Test,
-		Repo:         registrationRepo,
-		UserGetter:   userRepo,
-		GroupGetter:  groupRepo,
-		StudentSaver: studentRepo,
-		PgxPool:      s.pgPool,
-	})
-	mailApp := mail.NewApp(mail.Args{
-		Mailsender:              s.MockMailSender,
-		StaffInvitationBaseURL:  "http://localhost:3000/invitations/staff",
-		InvitationCreatorGetter: staffRepo,
-	})
-
-	studentApp := studentapp.NewApp(studentapp.Args{
-		Tracer:  nil,
-		Logger:  s.logger,
-		PgxPool: s.pgPool,
-	})
-
-	staffApp := staffapp.NewApp(staffapp.Args{
-		StaffInvitationRepo: staffInvitationRepo,
-		StaffRepo:           staffRepo,
-	})
-
-	authApp := authapp.NewApp(authapp.Args{
-		Tracer:                  nil,
-		Logger:                  s.logger,
-		UserGetter:              userRepo,
-		AccessTokenSecretKey:    fixtures.AccessTokenSecretKey,
-		RefreshTokenSecretKey:   fixtures.RefreshTokenSecretKey,
-		AccessTokenlExpDuration: nil,
-		RefreshTokenExpDuration: nil,
-	})
-
-	userApp := userapp.NewApp(userapp.Args{
-		S3BaseURL:     fixtures.ValidS3BaseURL,
-		AvatarStorage: s3Client,
-		UserRepo:      userRepo,
-	})
-
-	s.app = &Application{
-		Registration: regApp,
-		Mail:         mailApp,
-		Student:      studentApp,
-		Staff:        staffApp,
-		Auth:         authApp,
-		User:         userApp,
+		s.S3Client = s3Client
+		avatarStorage = s3Client
 	}
 
-	s.httpHandler = chi.NewRouter()
-	s.HTTPPort = httpport.NewPort(httpport.Args{
-		RegistrationApp:         regApp,
-		AuthApp:                 authApp,
-		StudentApp:              studentApp,
-		StaffApp:                staffApp,
-		CookieDomain:            "localhost",
-		Secret:                  []byte(fixtures.AccessTokenSecretKey),
-		AcceptInvitationPageURL: fixtures.StaffInvitationAcceptPageURL,
-		InvitationTokenAlg:      fixtures.InvitationTokenAlg,
-		InvitationTokenKey:      fixtures.InvitationTokenKey,
-		InvitationTokenExp:      fixtures.InvitationTokenExp,
-		ServiceName:             fixtures.ServiceName,
-		UserApp:                 userApp,
-	})
-	s.HTTPPort.Route(s.httpHandler)
-}
-
-func (s *IntegrationTestSuite) createWatermillPort() {
-	logger := watermill.NewStdLogger(true, false)
+	s.Clock = clock.NewOffset()
+	s.MockMailSender = mocks.NewMockMailSender()
 
-	port, err := watermillport.NewPortForTest(s.watermillRouter, s.pgPool, logger)
+	cfg := NewAppConfig()
+	cfg.Storage.VerifyAvatars = s.Options.VerifyAvatars
+	application, err := app.New(context.Background(), cfg,
+		app.WithLogger(s.logger),
+		app.WithPool(s.pgPool),
+		app.WithMailSender(s.MockMailSender),
+		app.AllowMockMailSender(),
+		app.WithClock(s.Clock),
+		app.WithStorage(avatarStorage, fixtures.ValidS3BaseURL),
+		app.WithEventRouter(s.watermillRouter),
+		app.WithTestEventProcessors(),
+	)
 	s.Require().NoError(err)
 
-	s.watermillPort = port
-
-	handlers := watermillport.AppEventHandlers{
-		Registration: s.app.Registration.Event,
-		Mail:         s.app.Mail.Event,
-		Student:      s.app.Student.Event,
-		User:         s.app.User.Event,
-	}
-
-	err = s.watermillPort.Run(context.Background(), handlers)
-	s.Require().NoError(err)
+	s.app = application
+	s.HTTPPort = application.HTTPPort
+	s.ErrorRecorder = application.ErrorRecorder
+	s.ValidationRecorder = application.ValidationRecorder
+	s.LastSeen = application.LastSeen
+	s.Schema = application.Schema
+	s.Jobs = application.Jobs
+	s.Health = application.Health
+	s.httpHandler = application.Router
 }
 
 func (s *IntegrationTestSuite) startWatermillRouter() {
@@ -320,32 +304,60 @@ func (s *IntegrationTestSuite) startWatermillRouter() {
 	s.T().Log("Watermill router and handlers are ready")
 }
 
+// startNotificationListener delivers the published notifications to the
+// streams, App.Run does it for the deployed API.
+func (s *IntegrationTestSuite) startNotificationListener() {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.stopListening = cancel
+	s.listening = make(chan struct{})
+	go func() {
+		defer close(s.listening)
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.app.ListenGroupChanges(ctx)
+		}()
+		s.app.ListenNotifications(ctx)
+		wg.Wait()
+	}()
+}
+
 func (s *IntegrationTestSuite) initializeHelpers() {
 	s.HTTP = http.NewHelper(s.httpHandler)
 	s.DB = db.NewHelper(db.Args{Pool: s.pgPool})
 	s.Event = event.NewHelper(s.pgPool)
 	s.Builder = builders.NewFactory()
-	s.S3 = s3helper.NewHelper(s.S3Client)
+	if s.S3Client != nil {
+		s.S3 = s3helper.NewHelper(s.S3Client)
+	}
 }
 
 func (s *IntegrationTestSuite) TearDownSuite() {
-	if s.pgPool != nil {
-		s.pgPool.Close()
+	// The listener holds a connection the pool waits for on Close.
+	if s.stopListening != nil {
+		s.stopListening()
+		<-s.listening
 	}
-
-	if s.minioContainer != nil {
-		_ = s.minioContainer.Terminate(s.Context())
-	}
-	if s.pgContainer != nil {
-		_ = s.pgContainer.Terminate(s.Context())
-	}
-
 	if s.watermillRouter != nil {
 		err := s.watermillRouter.Close()
 		if err != nil {
 			s.T().Logf("Failed to close Watermill router: %v", err)
 		}
 	}
+
+	if s.pgPool != nil {
+		s.pgPool.Close()
+	}
+	if s.database != nil {
+		if err := s.database.drop(context.Background()); err != nil {
+			s.T().Logf("Failed to drop database %s: %v", s.database.name, err)
+		}
+	}
+
+	if s.minioContainer != nil {
+		_ = s.minioContainer.Terminate(s.Context())
+	}
 }
 
 // SetupTest prepares each test
@@ -359,6 +371,7 @@ func (s *IntegrationTestSuite) SetupTest() {
 
 func (s *IntegrationTestSuite) BeforeTest(suiteName, testName string) {
 	s.testStartTime = time.Now()
+	s.Clock.Reset()
 }
 
 func (s *IntegrationTestSuite) AfterTest(suiteName, testName string) {
@@ -398,3 +411,23 @@ func (s *IntegrationTestSuite) SeedStudent(t *testing.T, email string, groupID g
 	s.DB.SeedStudent(t, studentUser)
 	return studentUser
 }
+
+// EndedSpans returns the spans recorded since the test started.
+func (s *IntegrationTestSuite) EndedSpans() []sdktrace.ReadOnlySpan {
+	return s.traceRecorder.Ended()
+}
+
+// Router exposes the HTTP router for tests that mount their own routes.
+func (s *IntegrationTestSuite) Router() chi.Router {
+	return s.httpHandler
+}
+
+// NotificationHub exposes the open notification streams of the app.
+func (s *IntegrationTestSuite) NotificationHub() *feed.Hub {
+	return s.app.Apps.Notification.Hub
+}
+
+// Pool exposes the database pool for tests that wire handlers by hand.
+func (s *IntegrationTestSuite) Pool() *pgxpool.Pool {
+	return s.pgPool
+}
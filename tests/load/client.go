@@ -0,0 +1,105 @@
+//go:build load
+
+package load
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	httpframework "gitlab.com/ucmsv2/ucms-backend/tests/integration/framework/http"
+)
+
+// Client sends the requests of the integration framework to a running
+// instance over a pooled connection, without the per-request assertions of
+// the framework.
+type Client struct {
+	baseURL string
+	http    *http.Client
+}
+
+// NewClient returns a client keeping up to conns idle connections to
+// baseURL, one per worker is enough.
+func NewClient(baseURL string, conns int, timeout time.Duration) *Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConns = conns
+	transport.MaxIdleConnsPerHost = conns
+	transport.MaxConnsPerHost = conns
+
+	return &Client{
+		baseURL: baseURL,
+		http:    &http.Client{Transport: transport, Timeout: timeout},
+	}
+}
+
+// StatusError is returned for a response with an unexpected status.
+type StatusError struct {
+	Method string
+	Path   string
+	Status int
+	Body   string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s %s: status %d: %s", e.Method, e.Path, e.Status, e.Body)
+}
+
+// Do sends the request and decodes the JSON body into out, when set. A
+// status other than expected is a *StatusError.
+func (c *Client) Do(ctx context.Context, b *httpframework.RequestBuilder, expected int, out any) error {
+	req := b.Build()
+
+	var body io.Reader
+	if req.Body != nil {
+		js, err := json.Marshal(req.Body)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(js)
+	}
+
+	u, err := url.Parse(c.baseURL + req.Path)
+	if err != nil {
+		return err
+	}
+	if len(req.Query) > 0 {
+		q := u.Query()
+		for k, v := range req.Query {
+			q.Set(k, v)
+		}
+		u.RawQuery = q.Encode()
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, req.Method, u.String(), body)
+	if err != nil {
+		return err
+	}
+	for k, v := range req.Headers {
+		httpReq.Header.Set(k, v)
+	}
+	if httpReq.Header.Get("Content-Type") == "" {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.http.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != expected {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return &StatusError{Method: req.Method, Path: req.Path, Status: resp.StatusCode, Body: string(msg)}
+	}
+	if out != nil {
+		return json.NewDecoder(resp.Body).Decode(out)
+	}
+	// drain the body so that the connection is reused
+	_, err = io.Copy(io.Discard, resp.Body)
+	return err
+}
@@ -0,0 +1,107 @@
+//go:build load
+
+// The load tests run the registration flow against a running instance in
+// the dev, local or test mode. They are excluded from go test ./... by the
+// load build tag:
+//
+//	go test -tags load ./tests/load -run TestRegistrationLoad -v \
+//		-load.url http://localhost:8080 -load.group 550e8400-e29b-41d4-a716-446655440000
+//
+// -short runs a few seconds at a low rate, to check the harness and the
+// flow in CI rather than the capacity.
+package load
+
+import (
+	"context"
+	"flag"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	httpframework "gitlab.com/ucmsv2/ucms-backend/tests/integration/framework/http"
+)
+
+var (
+	baseURL  = flag.String("load.url", "http://localhost:8080", "base URL of the instance under load")
+	groupID  = flag.String("load.group", "", "ID of an existing group the students complete the registration in")
+	domain   = flag.String("load.email-domain", "test.com", "domain of the registered emails, it must have an MX record")
+	password = flag.String("load.password", "SecurePass123!", "password of the registered students")
+
+	rps      = flag.Int("load.rps", 200, "registrations started per second")
+	duration = flag.Duration("load.duration", time.Minute, "how long registrations are started for")
+	workers  = flag.Int("load.workers", 400, "flows run at once")
+	timeout  = flag.Duration("load.timeout", 10*time.Second, "timeout of a request")
+
+	startP95    = flag.Duration("load.slo.start-p95", 300*time.Millisecond, "SLO of the p95 latency of start")
+	stepP95     = flag.Duration("load.slo.p95", 500*time.Millisecond, "SLO of the p95 latency of verify and complete")
+	maxErrRate  = flag.Float64("load.slo.error-rate", 0.01, "SLO of the error rate of every step")
+	maxDropRate = flag.Float64("load.slo.drop-rate", 0.01, "SLO of the share of registrations dropped while all workers were busy")
+)
+
+// short are the settings of -short.
+var short = struct {
+	rps      int
+	duration time.Duration
+	workers  int
+}{rps: 10, duration: 5 * time.Second, workers: 20}
+
+func scenario(t *testing.T) Scenario {
+	t.Helper()
+
+	group, err := uuid.Parse(*groupID)
+	if err != nil {
+		t.Fatalf("-load.group must be the ID of an existing group: %v", err)
+	}
+
+	s := Scenario{
+		RPS:         *rps,
+		Duration:    *duration,
+		Workers:     *workers,
+		GroupID:     group,
+		EmailDomain: *domain,
+		Password:    *password,
+	}
+	if testing.Short() {
+		s.RPS, s.Duration, s.Workers = short.rps, short.duration, short.workers
+	}
+	s.Client = NewClient(*baseURL, s.Workers, *timeout)
+	return s
+}
+
+func slos() []SLO {
+	return []SLO{
+		{Step: StepStart, P95: *startP95, MaxErrorRate: *maxErrRate},
+		{Step: StepCode, MaxErrorRate: *maxErrRate},
+		{Step: StepVerify, P95: *stepP95, MaxErrorRate: *maxErrRate},
+		{Step: StepComplete, P95: *stepP95, MaxErrorRate: *maxErrRate},
+	}
+}
+
+func TestRegistrationLoad(t *testing.T) {
+	s := scenario(t)
+
+	ctx := t.Context()
+	if err := s.Client.Do(ctx, httpframework.NewRequest(http.MethodGet, "/health"), http.StatusOK, nil); err != nil {
+		t.Fatalf("instance at %s is not healthy: %v", *baseURL, err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.Duration+2*time.Minute)
+	defer cancel()
+
+	t.Logf("starting %d registrations/s for %s on %d workers", s.RPS, s.Duration, s.Workers)
+	res := s.Run(ctx)
+
+	t.Logf("started %d (%.1f/s), dropped %d in %s\n%s",
+		res.Started, res.StartRate(), res.Dropped, res.Elapsed.Round(time.Millisecond), res.Report)
+
+	for _, violation := range res.Report.Check(slos()) {
+		t.Errorf("SLO violated: %v", violation)
+	}
+	if due := res.Started + res.Dropped; due > 0 {
+		if rate := float64(res.Dropped) / float64(due); rate > *maxDropRate {
+			t.Errorf("SLO violated: %.2f%% of the registrations dropped, all %d workers were busy", rate*100, s.Workers)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+//go:build load
+
+package load
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+
+	registrationhttp "gitlab.com/ucmsv2/ucms-backend/internal/ports/http/registration"
+	httpframework "gitlab.com/ucmsv2/ucms-backend/tests/integration/framework/http"
+)
+
+// Scenario starts registrations at a constant rate and runs each through
+// start, verify and complete on a pool of workers. The instance must run in
+// the dev, local or test mode, the verification codes are read from its dev
+// endpoint.
+type Scenario struct {
+	Client *Client
+	// RPS is the rate the registrations are started at.
+	RPS int
+	// Duration is how long registrations are started for, the flows started
+	// last are waited for.
+	Duration time.Duration
+	// Workers is the number of flows run at once. When all are busy the
+	// registrations due are dropped, which is reported as the instance not
+	// keeping up with RPS.
+	Workers int
+	// GroupID is the group the students complete the registration in, it
+	// must exist on the instance.
+	GroupID uuid.UUID
+	// EmailDomain is the domain of the registered emails, the instance
+	// checks that it has an MX record.
+	EmailDomain string
+	// Password is the password of the registered students.
+	Password string
+}
+
+// Result is the outcome of a run.
+type Result struct {
+	Report Report
+	// Started is the number of flows started, Dropped the number of flows due
+	// while all workers were busy.
+	Started int64
+	Dropped int64
+	Elapsed time.Duration
+}
+
+// StartRate is the rate the flows were actually started at.
+func (r Result) StartRate() float64 {
+	if r.Elapsed <= 0 {
+		return 0
+	}
+	return float64(r.Started) / r.Elapsed.Seconds()
+}
+
+func (s Scenario) Run(ctx context.Context) Result {
+	recorder := NewRecorder()
+	// runID keeps the emails, usernames and barcodes of the runs apart
+	runID := uuid.NewString()[:8]
+
+	jobs := make(chan int64)
+	var wg sync.WaitGroup
+	for range s.Workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := range jobs {
+				s.flow(ctx, recorder, runID, n)
+			}
+		}()
+	}
+
+	var started, dropped atomic.Int64
+	begin := time.Now()
+	ticker := time.NewTicker(time.Second / time.Duration(s.RPS))
+	deadline := time.NewTimer(s.Duration)
+
+loop:
+	for n := int64(0); ; n++ {
+		select {
+		case <-ctx.Done():
+			break loop
+		case <-deadline.C:
+			break loop
+		case <-ticker.C:
+			select {
+			case jobs <- n:
+				started.Add(1)
+			default:
+				dropped.Add(1)
+			}
+		}
+	}
+	ticker.Stop()
+	deadline.Stop()
+	elapsed := time.Since(begin)
+	close(jobs)
+	wg.Wait()
+
+	return Result{
+		Report:  recorder.Report(),
+		Started: started.Load(),
+		Dropped: dropped.Load(),
+		Elapsed: elapsed,
+	}
+}
+
+// flow registers the n-th student of the run, it stops at the first failed
+// step.
+func (s Scenario) flow(ctx context.Context, recorder *Recorder, runID string, n int64) {
+	email := fmt.Sprintf("load-%s-%d@%s", runID, n, s.EmailDomain)
+
+	step := func(name Step, b *httpframework.RequestBuilder, expected int, out any) bool {
+		begin := time.Now()
+		err := s.Client.Do(ctx, b, expected, out)
+		recorder.Record(name, time.Since(begin), err)
+		return err == nil
+	}
+
+	if !step(StepStart, httpframework.NewRequest(http.MethodPost, "/v1/registrations/students/start").
+		WithJSON(registrationhttp.StartStudentRegistrationRequest{Email: email}),
+		http.StatusAccepted, nil) {
+		return
+	}
+
+	var code struct {
+		VerificationCode string `json:"verification_code"`
+	}
+	if !step(StepCode, httpframework.NewRequest(http.MethodGet, "/dev/registrations/verification-code/"+url.PathEscape(email)),
+		http.StatusOK, &code) {
+		return
+	}
+
+	if !step(StepVerify, httpframework.NewRequest(http.MethodPost, "/v1/registrations/verify").
+		WithJSON(registrationhttp.VerifyRequest{Email: email, VerificationCode: code.VerificationCode}),
+		http.StatusOK, nil) {
+		return
+	}
+
+	step(StepComplete, httpframework.NewRequest(http.MethodPost, "/v1/registrations/students/complete").
+		WithJSON(registrationhttp.CompleteStudentRegistrationRequest{
+			Barcode:          fmt.Sprintf("L%s%d", runID, n),
+			Username:         fmt.Sprintf("load_%s_%d", runID, n),
+			Email:            email,
+			FirstName:        "Load",
+			LastName:         "Tester",
+			GroupId:          s.GroupID,
+			Password:         s.Password,
+			VerificationCode: code.VerificationCode,
+		}),
+		http.StatusOK, nil)
+}
@@ -0,0 +1,96 @@
+//go:build load
+
+package load
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubInstance serves the registration flow, failing the complete step of
+// the emails containing fail.
+func stubInstance(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	var mu sync.Mutex
+	codes := make(map[string]string)
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/registrations/students/start", func(w http.ResponseWriter, r *http.Request) {
+		var req struct{ Email string }
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		mu.Lock()
+		codes[req.Email] = "123456"
+		mu.Unlock()
+		w.WriteHeader(http.StatusAccepted)
+	})
+	mux.HandleFunc("GET /dev/registrations/verification-code/{email}", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		code := codes[r.PathValue("email")]
+		mu.Unlock()
+		_ = json.NewEncoder(w).Encode(map[string]string{"verification_code": code})
+	})
+	mux.HandleFunc("POST /v1/registrations/verify", func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Email            string
+			VerificationCode string `json:"verification_code"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if req.VerificationCode != "123456" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+	})
+	mux.HandleFunc("POST /v1/registrations/students/complete", func(w http.ResponseWriter, r *http.Request) {
+		var req struct{ Barcode string }
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if strings.HasSuffix(req.Barcode, "0") {
+			http.Error(w, `{"message":"conflict"}`, http.StatusConflict)
+			return
+		}
+	})
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestScenario_Run(t *testing.T) {
+	srv := stubInstance(t)
+	s := Scenario{
+		Client:      NewClient(srv.URL, 4, time.Second),
+		RPS:         100,
+		Duration:    300 * time.Millisecond,
+		Workers:     4,
+		GroupID:     uuid.New(),
+		EmailDomain: "test.com",
+		Password:    "SecurePass123!",
+	}
+
+	res := s.Run(t.Context())
+
+	require.Positive(t, res.Started)
+	for _, step := range Steps {
+		stats, ok := res.Report.Step(step)
+		require.True(t, ok, "step %s did not run", step)
+		assert.EqualValues(t, res.Started, stats.Count, "every started flow runs %s", step)
+	}
+
+	// the barcodes of the flows 0, 10, 20... end with 0, flow 0 always runs
+	complete, _ := res.Report.Step(StepComplete)
+	assert.Positive(t, complete.Errors)
+	assert.Less(t, complete.Errors, complete.Count)
+	var statusErr *StatusError
+	require.ErrorAs(t, complete.FirstError, &statusErr)
+	assert.Equal(t, http.StatusConflict, statusErr.Status)
+	start, _ := res.Report.Step(StepStart)
+	assert.Zero(t, start.Errors)
+}
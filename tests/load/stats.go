@@ -0,0 +1,171 @@
+//go:build load
+
+package load
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Step is a request of the registration flow.
+type Step string
+
+const (
+	StepStart    Step = "start"
+	StepCode     Step = "code"
+	StepVerify   Step = "verify"
+	StepComplete Step = "complete"
+)
+
+// Steps are the steps of the flow in the order they run.
+var Steps = []Step{StepStart, StepCode, StepVerify, StepComplete}
+
+// Recorder collects the latencies and errors of the steps, it is safe for
+// concurrent use by the workers.
+type Recorder struct {
+	mu        sync.Mutex
+	latencies map[Step][]time.Duration
+	errors    map[Step]int
+	// firstErrors keeps an example of each failing step for the report.
+	firstErrors map[Step]error
+}
+
+func NewRecorder() *Recorder {
+	return &Recorder{
+		latencies:   make(map[Step][]time.Duration),
+		errors:      make(map[Step]int),
+		firstErrors: make(map[Step]error),
+	}
+}
+
+// Record records a step that took d, err reports whether it failed.
+func (r *Recorder) Record(step Step, d time.Duration, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.latencies[step] = append(r.latencies[step], d)
+	if err != nil {
+		r.errors[step]++
+		if r.firstErrors[step] == nil {
+			r.firstErrors[step] = err
+		}
+	}
+}
+
+// Report summarizes the recorded steps.
+func (r *Recorder) Report() Report {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	report := make(Report, 0, len(Steps))
+	for _, step := range Steps {
+		latencies := slices.Clone(r.latencies[step])
+		if len(latencies) == 0 {
+			continue
+		}
+		slices.Sort(latencies)
+		report = append(report, StepStats{
+			Step:       step,
+			Count:      len(latencies),
+			Errors:     r.errors[step],
+			FirstError: r.firstErrors[step],
+			P50:        percentile(latencies, 50),
+			P95:        percentile(latencies, 95),
+			P99:        percentile(latencies, 99),
+			Max:        latencies[len(latencies)-1],
+		})
+	}
+	return report
+}
+
+// StepStats are the latency percentiles and the errors of a step.
+type StepStats struct {
+	Step       Step
+	Count      int
+	Errors     int
+	FirstError error
+	P50        time.Duration
+	P95        time.Duration
+	P99        time.Duration
+	Max        time.Duration
+}
+
+func (s StepStats) ErrorRate() float64 {
+	if s.Count == 0 {
+		return 0
+	}
+	return float64(s.Errors) / float64(s.Count)
+}
+
+// Report are the stats of the steps that ran, in the order of Steps.
+type Report []StepStats
+
+func (r Report) Step(step Step) (StepStats, bool) {
+	for _, s := range r {
+		if s.Step == step {
+			return s, true
+		}
+	}
+	return StepStats{}, false
+}
+
+func (r Report) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%-9s %7s %7s %10s %10s %10s %10s\n", "step", "count", "errors", "p50", "p95", "p99", "max")
+	for _, s := range r {
+		fmt.Fprintf(&b, "%-9s %7d %6.2f%% %10s %10s %10s %10s\n", s.Step, s.Count, s.ErrorRate()*100,
+			s.P50.Round(time.Microsecond), s.P95.Round(time.Microsecond),
+			s.P99.Round(time.Microsecond), s.Max.Round(time.Microsecond))
+	}
+	for _, s := range r {
+		if s.FirstError != nil {
+			fmt.Fprintf(&b, "first %s error: %v\n", s.Step, s.FirstError)
+		}
+	}
+	return b.String()
+}
+
+// SLO is the objective of a step, a zero field is not checked.
+type SLO struct {
+	Step         Step
+	P95          time.Duration
+	P99          time.Duration
+	MaxErrorRate float64
+}
+
+// Check returns the SLOs the report violates. A step that did not run
+// violates its SLO, the run was too short or failed before it.
+func (r Report) Check(slos []SLO) []error {
+	var violations []error
+	for _, slo := range slos {
+		s, ok := r.Step(slo.Step)
+		if !ok {
+			violations = append(violations, fmt.Errorf("%s: no requests", slo.Step))
+			continue
+		}
+		if slo.P95 > 0 && s.P95 > slo.P95 {
+			violations = append(violations, fmt.Errorf("%s: p95 %s over %s", slo.Step, s.P95, slo.P95))
+		}
+		if slo.P99 > 0 && s.P99 > slo.P99 {
+			violations = append(violations, fmt.Errorf("%s: p99 %s over %s", slo.Step, s.P99, slo.P99))
+		}
+		if s.ErrorRate() > slo.MaxErrorRate {
+			violations = append(violations, fmt.Errorf("%s: error rate %.2f%% over %.2f%%",
+				slo.Step, s.ErrorRate()*100, slo.MaxErrorRate*100))
+		}
+	}
+	return violations
+}
+
+// percentile returns the nearest-rank percentile p of the sorted latencies.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+	return sorted[min(max(rank, 0), len(sorted)-1)]
+}
@@ -0,0 +1,77 @@
+//go:build load
+
+package load
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPercentile(t *testing.T) {
+	var latencies []time.Duration
+	for i := 1; i <= 100; i++ {
+		latencies = append(latencies, time.Duration(i)*time.Millisecond)
+	}
+
+	assert.Equal(t, 50*time.Millisecond, percentile(latencies, 50))
+	assert.Equal(t, 95*time.Millisecond, percentile(latencies, 95))
+	assert.Equal(t, 99*time.Millisecond, percentile(latencies, 99))
+	assert.Equal(t, 100*time.Millisecond, percentile(latencies, 100))
+	assert.Equal(t, 7*time.Millisecond, percentile([]time.Duration{7 * time.Millisecond}, 99))
+	assert.Zero(t, percentile(nil, 50))
+}
+
+func TestRecorder_Report(t *testing.T) {
+	r := NewRecorder()
+	for i := 1; i <= 20; i++ {
+		var err error
+		if i%10 == 0 {
+			err = errors.New("status 503")
+		}
+		r.Record(StepStart, time.Duration(i)*time.Millisecond, err)
+	}
+	r.Record(StepVerify, 40*time.Millisecond, nil)
+
+	report := r.Report()
+	require.Len(t, report, 2, "the steps that did not run are left out")
+	assert.Equal(t, StepStart, report[0].Step)
+	assert.Equal(t, StepVerify, report[1].Step)
+
+	start := report[0]
+	assert.Equal(t, 20, start.Count)
+	assert.Equal(t, 2, start.Errors)
+	assert.InDelta(t, 0.1, start.ErrorRate(), 1e-9)
+	assert.Equal(t, 10*time.Millisecond, start.P50)
+	assert.Equal(t, 19*time.Millisecond, start.P95)
+	assert.Equal(t, 20*time.Millisecond, start.Max)
+	assert.EqualError(t, start.FirstError, "status 503")
+
+	assert.Contains(t, report.String(), "first start error: status 503")
+}
+
+func TestReport_Check(t *testing.T) {
+	report := Report{
+		{Step: StepStart, Count: 100, Errors: 2, P95: 350 * time.Millisecond, P99: 400 * time.Millisecond},
+		{Step: StepVerify, Count: 100, P95: 100 * time.Millisecond},
+	}
+
+	violations := report.Check([]SLO{
+		{Step: StepStart, P95: 300 * time.Millisecond, P99: time.Second, MaxErrorRate: 0.01},
+		{Step: StepVerify, P95: 300 * time.Millisecond},
+		{Step: StepComplete, P95: 300 * time.Millisecond},
+	})
+
+	var messages []string
+	for _, v := range violations {
+		messages = append(messages, v.Error())
+	}
+	assert.Equal(t, []string{
+		"start: p95 350ms over 300ms",
+		"start: error rate 2.00% over 1.00%",
+		"complete: no requests",
+	}, messages)
+}
@@ -0,0 +1,175 @@
+package mocks
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/announcement"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/group"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+)
+
+// AnnouncementRepo keeps the announcements in memory and hands out copies.
+// The recipients are seeded with SeedRecipient, there are no students to
+// read them from.
+type AnnouncementRepo struct {
+	*EventRepo
+	dbByID     map[announcement.ID]*announcement.Announcement
+	recipients map[group.ID][]announcement.Recipient
+	clock      clock.Clock
+	mu         sync.Mutex
+}
+
+func NewAnnouncementRepo() *AnnouncementRepo {
+	return &AnnouncementRepo{
+		EventRepo:  NewEventRepo(),
+		dbByID:     make(map[announcement.ID]*announcement.Announcement),
+		recipients: make(map[group.ID][]announcement.Recipient),
+	}
+}
+
+// WithClock sets the clock the loaded announcements are rehydrated with,
+// clock.Real by default.
+func (r *AnnouncementRepo) WithClock(c clock.Clock) *AnnouncementRepo {
+	r.clock = c
+	return r
+}
+
+func (r *AnnouncementRepo) SaveAnnouncement(_ context.Context, a *announcement.Announcement) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if a == nil {
+		return errors.New("announcement cannot be nil")
+	}
+	if _, exists := r.dbByID[a.ID()]; exists {
+		return errorx.NewDuplicateEntry()
+	}
+
+	r.dbByID[a.ID()] = r.clone(a)
+	r.appendEvents(a.GetUncommittedEvents()...)
+	a.CommitEvents()
+	return nil
+}
+
+func (r *AnnouncementRepo) UpdateAnnouncement(
+	ctx context.Context,
+	id announcement.ID,
+	fn func(context.Context, *announcement.Announcement) error,
+) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if fn == nil {
+		return errors.New("update function cannot be nil")
+	}
+	stored, ok := r.dbByID[id]
+	if !ok {
+		return errorx.NewNotFound()
+	}
+
+	a := r.clone(stored)
+	if err := fn(ctx, a); err != nil {
+		return err
+	}
+
+	r.dbByID[id] = r.clone(a)
+	r.appendEvents(a.GetUncommittedEvents()...)
+	a.CommitEvents()
+	return nil
+}
+
+func (r *AnnouncementRepo) GetAnnouncement(_ context.Context, id announcement.ID) (*announcement.Announcement, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if a, ok := r.dbByID[id]; ok {
+		return r.clone(a), nil
+	}
+	return nil, errorx.NewNotFound()
+}
+
+func (r *AnnouncementRepo) ListAnnouncements(
+	_ context.Context,
+	params announcement.ListParams,
+) ([]*announcement.Announcement, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var announcements []*announcement.Announcement
+	for _, a := range r.dbByID {
+		visible := a.IsPublished() && (a.TargetsAllStudents() || slices.Contains(a.GroupIDs(), params.GroupID))
+		if params.All || visible {
+			announcements = append(announcements, r.clone(a))
+		}
+	}
+	slices.SortFunc(announcements, func(a, b *announcement.Announcement) int {
+		return b.CreatedAt().Compare(a.CreatedAt())
+	})
+
+	if params.Offset >= len(announcements) {
+		return nil, nil
+	}
+	announcements = announcements[params.Offset:]
+	if params.Limit < len(announcements) {
+		announcements = announcements[:params.Limit]
+	}
+	return announcements, nil
+}
+
+func (r *AnnouncementRepo) ListAnnouncementRecipients(_ context.Context, groupIDs []group.ID) ([]announcement.Recipient, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var recipients []announcement.Recipient
+	for groupID, rs := range r.recipients {
+		if len(groupIDs) == 0 || slices.Contains(groupIDs, groupID) {
+			recipients = append(recipients, rs...)
+		}
+	}
+	return recipients, nil
+}
+
+// SeedRecipient makes a student of groupID a recipient of the announcements
+// targeting it.
+func (r *AnnouncementRepo) SeedRecipient(groupID group.ID, recipient announcement.Recipient) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.recipients[groupID] = append(r.recipients[groupID], recipient)
+}
+
+func (r *AnnouncementRepo) SeedAnnouncement(t *testing.T, a *announcement.Announcement) {
+	t.Helper()
+
+	if err := r.SaveAnnouncement(t.Context(), a); err != nil {
+		t.Fatalf("failed to seed announcement %s: %v", a.ID(), err)
+	}
+}
+
+// clone copies the announcement the way it would be read back from the
+// database.
+func (r *AnnouncementRepo) clone(a *announcement.Announcement) *announcement.Announcement {
+	var unpublishedBy *user.ID
+	if by := a.UnpublishedBy(); by != nil {
+		id := *by
+		unpublishedBy = &id
+	}
+	return announcement.Rehydrate(announcement.RehydrateArgs{
+		ID:            a.ID(),
+		AuthorID:      a.AuthorID(),
+		Title:         a.Title(),
+		Body:          a.Body(),
+		GroupIDs:      slices.Clone(a.GroupIDs()),
+		SendEmail:     a.SendEmail(),
+		CreatedAt:     a.CreatedAt(),
+		UnpublishedAt: cloneTime(a.UnpublishedAt()),
+		UnpublishedBy: unpublishedBy,
+		Clock:         r.clock,
+	})
+}
@@ -0,0 +1,125 @@
+package mocks
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// EICAR is the standard antivirus test file, it is harmless but every
+// scanner reports it as infected.
+const EICAR = `X5O!P%@AP[4\PZX54(P^)7CC)7}$EICAR-STANDARD-ANTIVIRUS-TEST-FILE!$H+H*`
+
+const EICARSignature = "Win.Test.EICAR_HDB-1"
+
+// ClamdServer is a fake clamd speaking the INSTREAM protocol. Streams
+// containing the EICAR string are reported as infected.
+type ClamdServer struct {
+	listener net.Listener
+	hang     atomic.Bool
+	scans    atomic.Int64
+	wg       sync.WaitGroup
+	done     chan struct{}
+}
+
+func NewClamdServer(t *testing.T) *ClamdServer {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	s := &ClamdServer{listener: l, done: make(chan struct{})}
+	s.wg.Add(1)
+	go s.serve()
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+func (s *ClamdServer) Addr() string {
+	return s.listener.Addr().String()
+}
+
+// SetHang makes the server read the stream but never answer, to exercise timeouts.
+func (s *ClamdServer) SetHang(hang bool) {
+	s.hang.Store(hang)
+}
+
+// Scans returns how many streams were scanned.
+func (s *ClamdServer) Scans() int {
+	return int(s.scans.Load())
+}
+
+func (s *ClamdServer) Close() {
+	select {
+	case <-s.done:
+		return
+	default:
+	}
+	close(s.done)
+	_ = s.listener.Close()
+	s.wg.Wait()
+}
+
+func (s *ClamdServer) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			defer conn.Close()
+			s.handle(conn)
+		}()
+	}
+}
+
+func (s *ClamdServer) handle(conn net.Conn) {
+	r := bufio.NewReader(conn)
+	cmd, err := r.ReadString(0)
+	if err != nil {
+		return
+	}
+
+	switch strings.TrimRight(cmd, "\x00") {
+	case "zPING":
+		_, _ = io.WriteString(conn, "PONG\x00")
+	case "zINSTREAM":
+		var stream bytes.Buffer
+		for {
+			var size uint32
+			if err := binary.Read(r, binary.BigEndian, &size); err != nil {
+				return
+			}
+			if size == 0 {
+				break
+			}
+			if _, err := io.CopyN(&stream, r, int64(size)); err != nil {
+				return
+			}
+		}
+		s.scans.Add(1)
+
+		if s.hang.Load() {
+			<-s.done
+			return
+		}
+		if bytes.Contains(stream.Bytes(), []byte(EICAR)) {
+			_, _ = io.WriteString(conn, "stream: "+EICARSignature+" FOUND\x00")
+			return
+		}
+		_, _ = io.WriteString(conn, "stream: OK\x00")
+	default:
+		_, _ = io.WriteString(conn, "UNKNOWN COMMAND\x00")
+	}
+}
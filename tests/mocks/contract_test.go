@@ -0,0 +1,45 @@
+package mocks
+
+import (
+	"testing"
+
+	"gitlab.com/ucmsv2/ucms-backend/tests/repotest"
+)
+
+// The postgres repositories run the same contracts in tests/repos.
+
+func TestUserRepo_Contract(t *testing.T) {
+	repotest.RunUserRepoContract(t, func(*testing.T) repotest.UserRepo {
+		return NewUserRepo()
+	})
+}
+
+func TestGroupRepo_Contract(t *testing.T) {
+	repotest.RunGroupRepoContract(t, func(*testing.T) repotest.GroupRepo {
+		return NewGroupRepo()
+	})
+}
+
+func TestStaffRepo_Contract(t *testing.T) {
+	repotest.RunStaffRepoContract(t, func(*testing.T) repotest.StaffRepo {
+		return NewStaffRepo()
+	})
+}
+
+func TestStaffInvitationRepo_Contract(t *testing.T) {
+	repotest.RunStaffInvitationRepoContract(t, func(*testing.T) repotest.StaffInvitationRepos {
+		return repotest.StaffInvitationRepos{
+			Staff:       NewStaffRepo(),
+			Invitations: NewStaffInvitationRepo(),
+		}
+	})
+}
+
+func TestNotificationRepo_Contract(t *testing.T) {
+	repotest.RunNotificationRepoContract(t, func(*testing.T) repotest.NotificationRepos {
+		return repotest.NotificationRepos{
+			Staff:         NewStaffRepo(),
+			Notifications: NewNotificationRepo(),
+		}
+	})
+}
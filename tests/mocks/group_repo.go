@@ -2,6 +2,7 @@ package mocks
 
 import (
 	"context"
+	"errors"
 	"sync"
 	"testing"
 
@@ -45,6 +46,55 @@ func (r *GroupRepo) GetGroupByName(_ context.Context, name string) (*group.Group
 	return nil, errorx.NewNotFound()
 }
 
+func (r *GroupRepo) SaveGroup(_ context.Context, g *group.Group) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if g == nil {
+		return errors.New("group cannot be nil")
+	}
+	if _, exists := r.dbByID[g.ID()]; exists {
+		return errorx.NewDuplicateEntry()
+	}
+
+	r.dbByID[g.ID()] = g
+	r.dbByName[g.Name()] = g
+	return nil
+}
+
+func (r *GroupRepo) UpdateGroup(ctx context.Context, id group.ID, fn func(context.Context, *group.Group) error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if fn == nil {
+		return errors.New("update function cannot be nil")
+	}
+	stored, exists := r.dbByID[id]
+	if !exists {
+		return errorx.NewNotFound()
+	}
+
+	g := group.Rehydrate(group.RehydrateArgs{
+		ID:                 stored.ID(),
+		Name:               stored.Name(),
+		Major:              stored.Major(),
+		Year:               stored.Year(),
+		CreatedAt:          stored.CreatedAt(),
+		UpdatedAt:          stored.UpdatedAt(),
+		EnrollmentOpensAt:  stored.EnrollmentOpensAt(),
+		EnrollmentClosesAt: stored.EnrollmentClosesAt(),
+		TermID:             stored.TermID(),
+	})
+	if err := fn(ctx, g); err != nil {
+		return err
+	}
+
+	delete(r.dbByName, stored.Name())
+	r.dbByID[id] = g
+	r.dbByName[g.Name()] = g
+	return nil
+}
+
 func (r *GroupRepo) SeedGroup(t *testing.T, group *group.Group) {
 	t.Helper()
 	r.mu.Lock()
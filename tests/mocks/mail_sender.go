@@ -2,7 +2,9 @@ package mocks
 
 import (
 	"context"
+	"errors"
 	"log/slog"
+	"slices"
 	"strings"
 	"sync"
 	"testing"
@@ -13,35 +15,111 @@ import (
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/mails"
 )
 
+// ErrMailFailure is returned by SendMail for the failures injected by FailNext.
+var ErrMailFailure = errors.New("mock mail sender: injected failure")
+
 type MockMailSender struct {
 	mu        sync.Mutex
 	sentMails []mails.Payload
+	// attempts counts the SendMail calls per recipient, the failed included.
+	attempts map[string]int
+	// failNext is the number of the next SendMail calls to fail.
+	failNext int
+	// failFor holds the error SendMail returns for a recipient.
+	failFor map[string]error
 }
 
 func NewMockMailSender() *MockMailSender {
 	return &MockMailSender{
 		sentMails: make([]mails.Payload, 0),
+		attempts:  make(map[string]int),
+		failFor:   make(map[string]error),
 	}
 }
 
+// SenderKind is mail.SenderKindMock, the mail application refuses the mock
+// unless it is allowed to use one.
+func (m *MockMailSender) SenderKind() string {
+	return "mock"
+}
+
 func (m *MockMailSender) SendMail(ctx context.Context, payload mails.Payload) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	m.sentMails = append(m.sentMails, mails.Payload{
-		To:      payload.To,
-		Subject: payload.Subject,
-		Body:    payload.Body,
-	})
+	m.attempts[payload.To]++
+	if err, ok := m.failFor[payload.To]; ok {
+		slog.Debug("MockMailSender: SendMail failed for recipient", "to", payload.To, "error", err)
+		return err
+	}
+	if m.failNext > 0 {
+		m.failNext--
+		slog.Debug("MockMailSender: SendMail failed", "to", payload.To, "remaining", m.failNext)
+		return ErrMailFailure
+	}
+
+	m.sentMails = append(m.sentMails, payload)
 	slog.Debug("MockMailSender: SendMail called", "to", payload.To, "subject", payload.Subject, "body", payload.Body)
 	return nil
 }
 
+// FailNext makes the next n SendMail calls fail with ErrMailFailure.
+func (m *MockMailSender) FailNext(n int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.failNext = n
+}
+
+// FailFor makes SendMail fail with err for email until the recipient or the
+// mock is reset.
+func (m *MockMailSender) FailFor(email string, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.failFor[email] = err
+}
+
+// Attempts returns the number of SendMail calls for email, the failed included.
+func (m *MockMailSender) Attempts(email string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.attempts[email]
+}
+
 func (m *MockMailSender) GetSentMails() []mails.Payload {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	return append([]mails.Payload{}, m.sentMails...)
+	return slices.Clone(m.sentMails)
+}
+
+// MailsTo returns the mails sent to email in the order they were sent.
+func (m *MockMailSender) MailsTo(email string) []mails.Payload {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var sent []mails.Payload
+	for _, mail := range m.sentMails {
+		if mail.To == email {
+			sent = append(sent, mail)
+		}
+	}
+	return sent
+}
+
+// LastMailMatching returns the last mail sent whose subject contains subject.
+func (m *MockMailSender) LastMailMatching(subject string) (mails.Payload, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, mail := range slices.Backward(m.sentMails) {
+		if strings.Contains(mail.Subject, subject) {
+			return mail, true
+		}
+	}
+	return mails.Payload{}, false
 }
 
 func (m *MockMailSender) Reset() {
@@ -49,31 +127,66 @@ func (m *MockMailSender) Reset() {
 	defer m.mu.Unlock()
 
 	m.sentMails = make([]mails.Payload, 0)
+	m.attempts = make(map[string]int)
+	m.failNext = 0
+	m.failFor = make(map[string]error)
+}
+
+// ResetFor forgets the mails, attempts and failures of email only, so that
+// the subtests of other recipients are not affected.
+func (m *MockMailSender) ResetFor(email string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.sentMails = slices.DeleteFunc(m.sentMails, func(mail mails.Payload) bool {
+		return mail.To == email
+	})
+	delete(m.attempts, email)
+	delete(m.failFor, email)
 }
 
 func (m *MockMailSender) AssertMailSent(t *testing.T, email, subject string) {
-	sentMails := m.GetSentMails()
-	for _, mail := range sentMails {
-		if mail.To == email && strings.Contains(mail.Subject, subject) {
-			return
-		}
+	t.Helper()
+	if _, ok := m.findMail(email, subject, ""); !ok {
+		t.Errorf("Expected mail to %s with subject containing %s not found", email, subject)
 	}
-	t.Errorf("Expected mail to %s with subject containing %s not found", email, subject)
 }
 
 // EventuallyRequireMailSent checks periodically for up to 5 seconds if an email with the specified subject has been sent to the given address.
 func (m *MockMailSender) EventuallyRequireMailSent(t *testing.T, email, subject string) *mails.Payload {
 	t.Helper()
-	var foundMail mails.Payload
-	require.Eventually(t, func() bool {
-		sentMails := m.GetSentMails()
-		for _, mail := range sentMails {
-			if mail.To == email && strings.Contains(mail.Subject, subject) {
-				foundMail = mail
-				return true
-			}
+	return m.EventuallyRequireMailSentMatching(t, email, subject, "")
+}
+
+// EventuallyRequireMailSentMatching checks periodically for up to 5 seconds
+// if a mail has been sent to the given address whose subject contains
+// subjectSubstr and whose body contains bodySubstr.
+func (m *MockMailSender) EventuallyRequireMailSentMatching(t *testing.T, to, subjectSubstr, bodySubstr string) *mails.Payload {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if mail, ok := m.findMail(to, subjectSubstr, bodySubstr); ok {
+			return &mail
+		}
+		if time.Now().After(deadline) {
+			require.FailNowf(t, "mail not sent",
+				"Expected mail to %s with subject containing %q and body containing %q not found within timeout; sent to %s: %v",
+				to, subjectSubstr, bodySubstr, to, m.MailsTo(to))
+			return nil
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func (m *MockMailSender) findMail(to, subjectSubstr, bodySubstr string) (mails.Payload, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, mail := range m.sentMails {
+		if mail.To == to && strings.Contains(mail.Subject, subjectSubstr) && strings.Contains(mail.Body, bodySubstr) {
+			return mail, true
 		}
-		return false
-	}, 5*time.Second, 100*time.Millisecond, "Expected mail to %s with subject containing %s not found within timeout", email, subject)
-	return &foundMail
+	}
+	return mails.Payload{}, false
 }
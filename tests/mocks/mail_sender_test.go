@@ -0,0 +1,91 @@
+package mocks
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/mails"
+)
+
+func TestMockMailSender_Queries(t *testing.T) {
+	m := NewMockMailSender()
+	require.NoError(t, m.SendMail(t.Context(), mails.Payload{To: "a@test.com", Subject: "Welcome", Body: "hi a"}))
+	require.NoError(t, m.SendMail(t.Context(), mails.Payload{To: "b@test.com", Subject: "Welcome", Body: "hi b"}))
+	require.NoError(t, m.SendMail(t.Context(), mails.Payload{To: "a@test.com", Subject: "Code", Body: "123456"}))
+
+	assert.Len(t, m.MailsTo("a@test.com"), 2)
+	assert.Empty(t, m.MailsTo("c@test.com"))
+
+	last, ok := m.LastMailMatching("Welcome")
+	require.True(t, ok)
+	assert.Equal(t, "b@test.com", last.To)
+	_, ok = m.LastMailMatching("Invitation")
+	assert.False(t, ok)
+
+	mail := m.EventuallyRequireMailSentMatching(t, "a@test.com", "Code", "1234")
+	assert.Equal(t, "123456", mail.Body)
+
+	m.ResetFor("a@test.com")
+	assert.Empty(t, m.MailsTo("a@test.com"))
+	assert.Len(t, m.MailsTo("b@test.com"), 1, "the other recipients are kept")
+}
+
+func TestMockMailSender_Failures(t *testing.T) {
+	t.Run("fail next", func(t *testing.T) {
+		m := NewMockMailSender()
+		m.FailNext(2)
+
+		for range 2 {
+			assert.ErrorIs(t, m.SendMail(t.Context(), mails.Payload{To: "a@test.com"}), ErrMailFailure)
+		}
+		assert.NoError(t, m.SendMail(t.Context(), mails.Payload{To: "a@test.com"}))
+		assert.Equal(t, 3, m.Attempts("a@test.com"))
+		assert.Len(t, m.MailsTo("a@test.com"), 1)
+	})
+
+	t.Run("fail for recipient", func(t *testing.T) {
+		m := NewMockMailSender()
+		errBounced := errors.New("bounced")
+		m.FailFor("a@test.com", errBounced)
+
+		assert.ErrorIs(t, m.SendMail(t.Context(), mails.Payload{To: "a@test.com"}), errBounced)
+		assert.NoError(t, m.SendMail(t.Context(), mails.Payload{To: "b@test.com"}))
+
+		m.ResetFor("a@test.com")
+		assert.NoError(t, m.SendMail(t.Context(), mails.Payload{To: "a@test.com"}))
+		assert.Equal(t, 1, m.Attempts("a@test.com"))
+	})
+}
+
+// TestMockMailSender_Concurrent is meant for go test -race.
+func TestMockMailSender_Concurrent(t *testing.T) {
+	m := NewMockMailSender()
+	m.FailNext(5)
+
+	var wg sync.WaitGroup
+	for i := range 20 {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			_ = m.SendMail(t.Context(), mails.Payload{To: fmt.Sprintf("%d@test.com", i%4), Subject: "Welcome"})
+		}()
+		go func() {
+			defer wg.Done()
+			m.MailsTo(fmt.Sprintf("%d@test.com", i%4))
+			m.LastMailMatching("Welcome")
+			m.ResetFor("3@test.com")
+		}()
+	}
+	wg.Wait()
+
+	sent := 0
+	for i := range 3 {
+		sent += m.Attempts(fmt.Sprintf("%d@test.com", i))
+	}
+	assert.Equal(t, 15, sent)
+}
@@ -0,0 +1,224 @@
+package mocks
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/notification"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+)
+
+// NotificationRepo keeps the notifications in memory. Like the postgres repo
+// it saves a single notification per user and event and hands out copies.
+type NotificationRepo struct {
+	dbByID map[notification.ID]*notification.Notification
+	clock  clock.Clock
+	mu     sync.Mutex
+}
+
+func NewNotificationRepo() *NotificationRepo {
+	return &NotificationRepo{
+		dbByID: make(map[notification.ID]*notification.Notification),
+	}
+}
+
+// WithClock sets the clock the loaded notifications are rehydrated with,
+// clock.Real by default.
+func (r *NotificationRepo) WithClock(c clock.Clock) *NotificationRepo {
+	r.clock = c
+	return r
+}
+
+func (r *NotificationRepo) SaveNotification(_ context.Context, n *notification.Notification) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if n == nil {
+		return false, errors.New("notification cannot be nil")
+	}
+	for _, stored := range r.dbByID {
+		if stored.UserID() == n.UserID() && stored.EventID() == n.EventID() {
+			return false, nil
+		}
+	}
+	if _, exists := r.dbByID[n.ID()]; exists {
+		return false, errorx.NewDuplicateEntry()
+	}
+
+	r.dbByID[n.ID()] = r.clone(n)
+	return true, nil
+}
+
+func (r *NotificationRepo) GetNotification(_ context.Context, userID user.ID, id notification.ID) (*notification.Notification, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if n, ok := r.dbByID[id]; ok && n.UserID() == userID {
+		return r.clone(n), nil
+	}
+	return nil, errorx.NewNotFound()
+}
+
+func (r *NotificationRepo) ListNotifications(
+	_ context.Context,
+	userID user.ID,
+	params notification.ListParams,
+) ([]*notification.Notification, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var notifications []*notification.Notification
+	for _, n := range r.dbByID {
+		if n.UserID() != userID || (params.UnreadOnly && n.IsRead()) {
+			continue
+		}
+		notifications = append(notifications, r.clone(n))
+	}
+	slices.SortFunc(notifications, func(a, b *notification.Notification) int {
+		if c := b.CreatedAt().Compare(a.CreatedAt()); c != 0 {
+			return c
+		}
+		return compareIDs(a, b)
+	})
+
+	if params.Offset >= len(notifications) {
+		return nil, nil
+	}
+	notifications = notifications[params.Offset:]
+	if params.Limit < len(notifications) {
+		notifications = notifications[:params.Limit]
+	}
+	return notifications, nil
+}
+
+func (r *NotificationRepo) ListNotificationsAfter(
+	_ context.Context,
+	userID user.ID,
+	after notification.ID,
+	limit int,
+) ([]*notification.Notification, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ref, ok := r.dbByID[after]
+	if !ok || ref.UserID() != userID {
+		return nil, nil
+	}
+	// The order of the database, by creation time and then by ID.
+	compare := func(a, b *notification.Notification) int {
+		if c := a.CreatedAt().Compare(b.CreatedAt()); c != 0 {
+			return c
+		}
+		return compareIDs(a, b)
+	}
+
+	var notifications []*notification.Notification
+	for _, n := range r.dbByID {
+		if n.UserID() == userID && compare(n, ref) > 0 {
+			notifications = append(notifications, r.clone(n))
+		}
+	}
+	slices.SortFunc(notifications, compare)
+	if limit < len(notifications) {
+		notifications = notifications[:limit]
+	}
+	return notifications, nil
+}
+
+func (r *NotificationRepo) CountUnreadNotifications(_ context.Context, userID user.ID) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var count int
+	for _, n := range r.dbByID {
+		if n.UserID() == userID && !n.IsRead() {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *NotificationRepo) UpdateNotification(
+	ctx context.Context,
+	userID user.ID,
+	id notification.ID,
+	fn func(context.Context, *notification.Notification) error,
+) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if fn == nil {
+		return errors.New("update function cannot be nil")
+	}
+	stored, ok := r.dbByID[id]
+	if !ok || stored.UserID() != userID {
+		return errorx.NewNotFound()
+	}
+
+	n := r.clone(stored)
+	if err := fn(ctx, n); err != nil {
+		return err
+	}
+
+	r.dbByID[id] = r.clone(n)
+	return nil
+}
+
+func (r *NotificationRepo) MarkAllNotificationsRead(_ context.Context, userID user.ID, readAt time.Time) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var marked int
+	for id, n := range r.dbByID {
+		if n.UserID() != userID || n.IsRead() {
+			continue
+		}
+		r.dbByID[id] = r.rehydrate(n, &readAt)
+		marked++
+	}
+	return marked, nil
+}
+
+func (r *NotificationRepo) SeedNotification(t *testing.T, n *notification.Notification) {
+	t.Helper()
+
+	if _, err := r.SaveNotification(t.Context(), n); err != nil {
+		t.Fatalf("failed to seed notification %s: %v", n.ID(), err)
+	}
+}
+
+// clone copies the notification the way it would be read back from the
+// database.
+func (r *NotificationRepo) clone(n *notification.Notification) *notification.Notification {
+	return r.rehydrate(n, cloneTime(n.ReadAt()))
+}
+
+func (r *NotificationRepo) rehydrate(n *notification.Notification, readAt *time.Time) *notification.Notification {
+	return notification.Rehydrate(notification.RehydrateArgs{
+		ID:        n.ID(),
+		UserID:    n.UserID(),
+		EventID:   n.EventID(),
+		Type:      n.Type(),
+		Title:     n.Title(),
+		Body:      n.Body(),
+		Link:      n.Link(),
+		ReadAt:    readAt,
+		CreatedAt: n.CreatedAt(),
+		Clock:     r.clock,
+	})
+}
+
+// compareIDs orders the notifications by ID the way uuid columns are.
+func compareIDs(a, b *notification.Notification) int {
+	aID, bID := uuid.UUID(a.ID()), uuid.UUID(b.ID())
+	return bytes.Compare(aID[:], bID[:])
+}
@@ -6,15 +6,17 @@ import (
 	"fmt"
 	"sync"
 	"testing"
+	"time"
 
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/event"
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/registration"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/emails"
 	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
 )
 
 type RegistrationRepo struct {
 	*EventRepo
-	dbbyEmail map[string]*registration.Registration
+	dbbyEmail map[emails.Email]*registration.Registration
 	dbbyID    map[registration.ID]*registration.Registration
 	dbbyCode  map[string]*registration.Registration
 	mu        sync.Mutex
@@ -23,18 +25,18 @@ type RegistrationRepo struct {
 func NewRegistrationRepo() *RegistrationRepo {
 	return &RegistrationRepo{
 		EventRepo: NewEventRepo(),
-		dbbyEmail: make(map[string]*registration.Registration),
+		dbbyEmail: make(map[emails.Email]*registration.Registration),
 		dbbyID:    make(map[registration.ID]*registration.Registration),
 		dbbyCode:  make(map[string]*registration.Registration),
 		mu:        sync.Mutex{},
 	}
 }
 
-func (r *RegistrationRepo) GetRegistrationByEmail(ctx context.Context, email string) (*registration.Registration, error) {
+func (r *RegistrationRepo) GetRegistrationByEmail(ctx context.Context, email emails.Email) (*registration.Registration, error) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	if reg, exists := r.dbbyEmail[email]; exists {
+	if reg, exists := r.dbbyEmail[emails.Email(email)]; exists {
 		return reg, nil
 	}
 	return nil, errorx.NewNotFound()
@@ -61,6 +63,7 @@ func (r *RegistrationRepo) SaveRegistration(ctx context.Context, reg *registrati
 	r.dbbyCode[reg.VerificationCode()] = reg
 
 	r.appendEvents(reg.GetUncommittedEvents()...)
+	reg.CommitEvents()
 
 	return nil
 }
@@ -101,7 +104,7 @@ func (r *RegistrationRepo) UpdateRegistration(
 
 func (r *RegistrationRepo) UpdateRegistrationByEmail(
 	ctx context.Context,
-	email string,
+	email emails.Email,
 	fn func(context.Context, *registration.Registration) error,
 ) error {
 	if fn == nil {
@@ -133,6 +136,36 @@ func (r *RegistrationRepo) UpdateRegistrationByEmail(
 	return nil
 }
 
+func (r *RegistrationRepo) CountPendingRegistrations(ctx context.Context) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var count int64
+	for _, reg := range r.dbbyID {
+		if reg.IsStatus(registration.StatusPending) || reg.IsStatus(registration.StatusVerified) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *RegistrationRepo) DeleteUnfinishedRegistrations(ctx context.Context, cutoff time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var deleted int64
+	for id, reg := range r.dbbyID {
+		if reg.IsCompleted() || !reg.CreatedAt().Before(cutoff) {
+			continue
+		}
+		delete(r.dbbyID, id)
+		delete(r.dbbyEmail, reg.Email())
+		delete(r.dbbyCode, reg.VerificationCode())
+		deleted++
+	}
+	return deleted, nil
+}
+
 func (r *RegistrationRepo) SeedRegistration(t *testing.T, reg *registration.Registration) {
 	t.Helper()
 
@@ -158,7 +191,7 @@ func (r *RegistrationRepo) AssertRegistrationExistsByEmail(t *testing.T, email s
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	reg, exists := r.dbbyEmail[email]
+	reg, exists := r.dbbyEmail[emails.Email(email)]
 	if !exists {
 		t.Errorf("expected registration with email %s to exist, but it does not", email)
 		return nil
@@ -171,7 +204,7 @@ func (r *RegistrationRepo) AssertRegistrationNotExistsByEmail(t *testing.T, emai
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	if _, exists := r.dbbyEmail[email]; exists {
+	if _, exists := r.dbbyEmail[emails.Email(email)]; exists {
 		t.Errorf("expected registration with email %s to not exist, but it does", email)
 		return r
 	}
@@ -0,0 +1,212 @@
+package mocks
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/staffinvitation"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+)
+
+// StaffInvitationRepo keeps the invitations in memory. Like the postgres
+// repo it hands out copies, so changes outside UpdateStaffInvitation are not
+// saved, and it keeps the deleted invitations visible.
+type StaffInvitationRepo struct {
+	*EventRepo
+	dbByID map[staffinvitation.ID]*staffinvitation.StaffInvitation
+	clock  clock.Clock
+	mu     sync.Mutex
+}
+
+func NewStaffInvitationRepo() *StaffInvitationRepo {
+	return &StaffInvitationRepo{
+		EventRepo: NewEventRepo(),
+		dbByID:    make(map[staffinvitation.ID]*staffinvitation.StaffInvitation),
+	}
+}
+
+// WithClock sets the clock the loaded invitations are rehydrated with,
+// clock.Real by default.
+func (r *StaffInvitationRepo) WithClock(c clock.Clock) *StaffInvitationRepo {
+	r.clock = c
+	return r
+}
+
+func (r *StaffInvitationRepo) SaveStaffInvitation(_ context.Context, invitation *staffinvitation.StaffInvitation) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if invitation == nil {
+		return errors.New("staff invitation cannot be nil")
+	}
+	if _, exists := r.dbByID[invitation.ID()]; exists {
+		return errorx.NewDuplicateEntry()
+	}
+	if _, ok := r.findByCode(invitation.Code()); ok {
+		return errorx.NewDuplicateEntry()
+	}
+
+	r.dbByID[invitation.ID()] = r.clone(invitation)
+	r.appendEvents(invitation.GetUncommittedEvents()...)
+	invitation.CommitEvents()
+	return nil
+}
+
+func (r *StaffInvitationRepo) UpdateStaffInvitation(
+	ctx context.Context,
+	id staffinvitation.ID,
+	fn func(context.Context, *staffinvitation.StaffInvitation) error,
+) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if fn == nil {
+		return errors.New("update function cannot be nil")
+	}
+	stored, ok := r.dbByID[id]
+	if !ok {
+		return errorx.NewNotFound()
+	}
+
+	invitation := r.clone(stored)
+	fnerr := fn(ctx, invitation)
+	if fnerr != nil && !errorx.IsPersistable(fnerr) {
+		return fnerr
+	}
+
+	r.dbByID[id] = r.clone(invitation)
+	r.appendEvents(invitation.GetUncommittedEvents()...)
+	return fnerr
+}
+
+func (r *StaffInvitationRepo) GetStaffInvitationByID(_ context.Context, id staffinvitation.ID) (*staffinvitation.StaffInvitation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if invitation, exists := r.dbByID[id]; exists {
+		return r.clone(invitation), nil
+	}
+	return nil, errorx.NewNotFound()
+}
+
+func (r *StaffInvitationRepo) GetStaffInvitationByCode(_ context.Context, code string) (*staffinvitation.StaffInvitation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if invitation, ok := r.findByCode(code); ok {
+		return r.clone(invitation), nil
+	}
+	return nil, errorx.NewNotFound()
+}
+
+func (r *StaffInvitationRepo) GetLatestStaffInvitationByCreatorID(
+	_ context.Context,
+	creatorID user.ID,
+) (*staffinvitation.StaffInvitation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var latest *staffinvitation.StaffInvitation
+	for _, invitation := range r.dbByID {
+		if invitation.CreatorID() != creatorID {
+			continue
+		}
+		if latest == nil || invitation.CreatedAt().After(latest.CreatedAt()) {
+			latest = invitation
+		}
+	}
+	if latest == nil {
+		return nil, errorx.NewNotFound()
+	}
+	return r.clone(latest), nil
+}
+
+func (r *StaffInvitationRepo) CountPendingRecipients(_ context.Context, creatorID user.ID, now time.Time) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var count int
+	for _, invitation := range r.dbByID {
+		if invitation.CreatorID() != creatorID || invitation.DeletedAt() != nil {
+			continue
+		}
+		if until := invitation.ValidUntil(); until != nil && !until.After(now) {
+			continue
+		}
+		count += len(invitation.RecipientsEmail())
+	}
+	return count, nil
+}
+
+// StaffInvitationsByCreatorID returns the invitations of the creator, in no
+// particular order.
+func (r *StaffInvitationRepo) StaffInvitationsByCreatorID(creatorID user.ID) []*staffinvitation.StaffInvitation {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var invitations []*staffinvitation.StaffInvitation
+	for _, invitation := range r.dbByID {
+		if invitation.CreatorID() == creatorID {
+			invitations = append(invitations, r.clone(invitation))
+		}
+	}
+	return invitations
+}
+
+func (r *StaffInvitationRepo) SeedStaffInvitation(t *testing.T, invitation *staffinvitation.StaffInvitation) {
+	t.Helper()
+
+	if err := r.SaveStaffInvitation(t.Context(), invitation); err != nil {
+		t.Fatalf("failed to seed staff invitation %s: %v", invitation.ID(), err)
+	}
+}
+
+func (r *StaffInvitationRepo) RequireStaffInvitationByID(t *testing.T, id staffinvitation.ID) *staffinvitation.Assertion {
+	t.Helper()
+
+	invitation, err := r.GetStaffInvitationByID(t.Context(), id)
+	if err != nil {
+		t.Fatalf("staff invitation %s does not exist", id)
+	}
+	return staffinvitation.NewAssertion(t, invitation)
+}
+
+func (r *StaffInvitationRepo) findByCode(code string) (*staffinvitation.StaffInvitation, bool) {
+	for _, invitation := range r.dbByID {
+		if invitation.Code() == code {
+			return invitation, true
+		}
+	}
+	return nil, false
+}
+
+// clone copies the invitation without its uncommitted events, the way it
+// would be read back from the database.
+func (r *StaffInvitationRepo) clone(invitation *staffinvitation.StaffInvitation) *staffinvitation.StaffInvitation {
+	return staffinvitation.Rehydrate(staffinvitation.RehydrateArgs{
+		ID:              invitation.ID(),
+		Code:            invitation.Code(),
+		RecipientsEmail: slices.Clone(invitation.RecipientsEmail()),
+		ValidFrom:       cloneTime(invitation.ValidFrom()),
+		ValidUntil:      cloneTime(invitation.ValidUntil()),
+		CreatorID:       invitation.CreatorID(),
+		CreatedAt:       invitation.CreatedAt(),
+		UpdatedAt:       invitation.UpdatedAt(),
+		DeletedAt:       cloneTime(invitation.DeletedAt()),
+		Clock:           r.clock,
+	})
+}
+
+func cloneTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	c := *t
+	return &c
+}
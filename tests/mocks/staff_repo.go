@@ -0,0 +1,292 @@
+package mocks
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/emails"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/i18nx"
+)
+
+// StaffRepo keeps the staff in memory, the users of the other roles are not
+// taken into account for the uniqueness of the email, username and barcode.
+type StaffRepo struct {
+	*EventRepo
+	dbByID map[user.ID]*user.Staff
+	// users are the accounts of the other roles LinkStaffAccount links, see
+	// SeedUser.
+	users            map[emails.Email]*user.User
+	bootstrapRecords []BootstrapRecord
+	mu               sync.Mutex
+}
+
+func NewStaffRepo() *StaffRepo {
+	return &StaffRepo{
+		EventRepo: NewEventRepo(),
+		dbByID:    make(map[user.ID]*user.Staff),
+		users:     make(map[emails.Email]*user.User),
+	}
+}
+
+func (r *StaffRepo) HasAnyStaff(_ context.Context) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return len(r.dbByID) > 0, nil
+}
+
+func (r *StaffRepo) SaveStaff(_ context.Context, staff *user.Staff) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.save(staff); err != nil {
+		return err
+	}
+	staff.CommitEvents()
+	return nil
+}
+
+// BootstrapInitialStaff mirrors the postgres repo, the bootstrap records are
+// kept in memory, see BootstrapRecords.
+func (r *StaffRepo) BootstrapInitialStaff(
+	ctx context.Context,
+	email emails.Email,
+	instanceID string,
+	fn func(ctx context.Context, existing *user.Staff, hasStaff bool) (*user.Staff, user.BootstrapAction, error),
+) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if fn == nil {
+		return errors.New("bootstrap function cannot be nil")
+	}
+	var existing *user.Staff
+	for _, staff := range r.dbByID {
+		if staff.User().Email() == email {
+			existing = cloneStaff(staff)
+		}
+	}
+
+	staff, action, err := fn(ctx, existing, len(r.dbByID) > 0)
+	if err != nil {
+		return err
+	}
+	switch action {
+	case user.BootstrapCreated:
+		if err := r.save(staff); err != nil {
+			return err
+		}
+	case user.BootstrapPasswordRotated:
+		r.dbByID[staff.User().ID()] = cloneStaff(staff)
+	default:
+		return nil
+	}
+	r.bootstrapRecords = append(r.bootstrapRecords, BootstrapRecord{
+		UserID:     staff.User().ID(),
+		Action:     action,
+		InstanceID: instanceID,
+	})
+	return nil
+}
+
+// BootstrapRecord is a row of the staff_bootstrap_audit table.
+type BootstrapRecord struct {
+	UserID     user.ID
+	Action     user.BootstrapAction
+	InstanceID string
+}
+
+// BootstrapRecords returns what BootstrapInitialStaff changed, oldest first.
+func (r *StaffRepo) BootstrapRecords() []BootstrapRecord {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return slices.Clone(r.bootstrapRecords)
+}
+
+func (r *StaffRepo) save(staff *user.Staff) error {
+	if staff == nil {
+		return errors.New("staff cannot be nil")
+	}
+	u := staff.User()
+	if _, exists := r.dbByID[u.ID()]; exists {
+		return errorx.NewDuplicateEntry()
+	}
+	for _, other := range r.dbByID {
+		switch {
+		case other.User().Email() == u.Email():
+			return errorx.NewDuplicateEntry().WithKey(i18nx.KeyEmailNotAvailable)
+		case other.User().Username() == u.Username():
+			return errorx.NewDuplicateEntry().WithKey(i18nx.KeyUsernameNotAvailable)
+		case other.User().Barcode() == u.Barcode():
+			return errorx.NewDuplicateEntry().WithKey(i18nx.KeyBarcodeNotAvailable)
+		}
+	}
+
+	r.dbByID[u.ID()] = cloneStaff(staff)
+	r.appendEvents(staff.GetUncommittedEvents()...)
+	return nil
+}
+
+func cloneStaff(staff *user.Staff) *user.Staff {
+	u := staff.User()
+	return user.RehydrateStaff(user.RehydrateStaffArgs{
+		RehydrateUserArgs: user.RehydrateUserArgs{
+			ID:            u.ID(),
+			Barcode:       u.Barcode(),
+			Username:      u.Username(),
+			FirstName:     u.FirstName(),
+			LastName:      u.LastName(),
+			Role:          u.Role(),
+			OtherRoles:    u.OtherRoles(),
+			Avatar:        u.Avatar(),
+			Email:         u.Email(),
+			PassHash:      u.PassHash(),
+			EmailVerified: u.EmailVerified(),
+			CreatedAt:     u.CreatedAt(),
+			UpdatedAt:     u.UpdatedAt(),
+		},
+		Department: staff.Department(),
+		Position:   staff.Position(),
+	})
+}
+
+// UpdateStaff mirrors the postgres repo, fn changes the stored staff only
+// when it succeeds.
+func (r *StaffRepo) UpdateStaff(
+	ctx context.Context,
+	id user.ID,
+	fn func(ctx context.Context, staff *user.Staff) error,
+) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if fn == nil {
+		return errors.New("update function cannot be nil")
+	}
+	stored, exists := r.dbByID[id]
+	if !exists {
+		return errorx.NewNotFound()
+	}
+
+	staff := cloneStaff(stored)
+	if err := fn(ctx, staff); err != nil {
+		return err
+	}
+
+	r.dbByID[id] = staff
+	r.appendEvents(staff.GetUncommittedEvents()...)
+	staff.CommitEvents()
+	return nil
+}
+
+// LinkStaffAccount mirrors the postgres repo, the user with email is looked
+// up in the users seeded with SeedUser.
+func (r *StaffRepo) LinkStaffAccount(
+	ctx context.Context,
+	email emails.Email,
+	fn func(ctx context.Context, u *user.User) (*user.Staff, error),
+) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if fn == nil {
+		return errors.New("update function cannot be nil")
+	}
+	stored, exists := r.users[email]
+	if !exists {
+		return errorx.NewNotFound()
+	}
+
+	u := *stored
+	staff, err := fn(ctx, &u)
+	if err != nil {
+		return err
+	}
+	if err := r.save(staff); err != nil {
+		return err
+	}
+	r.users[email] = staff.User()
+	staff.CommitEvents()
+	return nil
+}
+
+func (r *StaffRepo) GetStaffByID(_ context.Context, id user.ID) (*user.Staff, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if staff, exists := r.dbByID[id]; exists {
+		return staff, nil
+	}
+	return nil, errorx.NewNotFound()
+}
+
+func (r *StaffRepo) GetStaffByEmail(_ context.Context, email emails.Email) (*user.Staff, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, staff := range r.dbByID {
+		if staff.User().Email() == email {
+			return staff, nil
+		}
+	}
+	return nil, errorx.NewNotFound()
+}
+
+func (r *StaffRepo) IsStaffExists(
+	_ context.Context,
+	email emails.Email,
+	username string,
+	barcode user.Barcode,
+) (emailExists bool, usernameExists bool, barcodeExists bool, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, staff := range r.dbByID {
+		emailExists = emailExists || staff.User().Email() == email
+		usernameExists = usernameExists || staff.User().Username() == username
+		barcodeExists = barcodeExists || staff.User().Barcode() == barcode
+	}
+	return emailExists, usernameExists, barcodeExists, nil
+}
+
+func (r *StaffRepo) SeedStaff(t *testing.T, staff *user.Staff) {
+	t.Helper()
+
+	if err := r.SaveStaff(t.Context(), staff); err != nil {
+		t.Fatalf("failed to seed staff %s: %v", staff.User().Email(), err)
+	}
+}
+
+// SeedUser stores a user of another role, e.g. a student LinkStaffAccount
+// links.
+func (r *StaffRepo) SeedUser(t *testing.T, u *user.User) {
+	t.Helper()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.users[u.Email()] = u
+}
+
+// Count returns the number of staff saved.
+func (r *StaffRepo) Count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return len(r.dbByID)
+}
+
+func (r *StaffRepo) RequireStaffByEmail(t *testing.T, email string) *user.StaffAssertions {
+	t.Helper()
+
+	staff, err := r.GetStaffByEmail(t.Context(), emails.Email(email))
+	if err != nil {
+		t.Fatalf("staff with email %s does not exist", email)
+	}
+	return user.NewStaffAssertions(staff)
+}
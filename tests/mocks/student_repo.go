@@ -7,12 +7,13 @@ import (
 	"testing"
 
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/emails"
 	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
 )
 
 type StudentRepo struct {
 	*EventRepo
-	dbByEmail map[string]*user.Student
+	dbByEmail map[emails.Email]*user.Student
 	dbByID    map[user.Barcode]*user.Student
 	mu        sync.Mutex
 }
@@ -20,13 +21,13 @@ type StudentRepo struct {
 func NewStudentRepo() *StudentRepo {
 	return &StudentRepo{
 		EventRepo: NewEventRepo(),
-		dbByEmail: make(map[string]*user.Student),
+		dbByEmail: make(map[emails.Email]*user.Student),
 		dbByID:    make(map[user.Barcode]*user.Student),
 		mu:        sync.Mutex{},
 	}
 }
 
-func (r *StudentRepo) GetStudentByEmail(ctx context.Context, email string) (*user.Student, error) {
+func (r *StudentRepo) GetStudentByEmail(ctx context.Context, email emails.Email) (*user.Student, error) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
@@ -46,6 +47,73 @@ func (r *StudentRepo) GetStudentByBarcode(ctx context.Context, barcode user.Barc
 	return nil, errorx.NewNotFound()
 }
 
+func (r *StudentRepo) GetStudentByID(ctx context.Context, id user.ID) (*user.Student, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, student := range r.dbByID {
+		if student.User().ID() == id {
+			return student, nil
+		}
+	}
+	return nil, errorx.NewNotFound()
+}
+
+// UpdateStudentByBarcode mirrors the postgres repo, fn changes the stored
+// student only when it succeeds.
+func (r *StudentRepo) UpdateStudentByBarcode(
+	ctx context.Context,
+	barcode user.Barcode,
+	fn func(ctx context.Context, student *user.Student) error,
+) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if fn == nil {
+		return errors.New("update function cannot be nil")
+	}
+	stored, exists := r.dbByID[barcode]
+	if !exists {
+		return errorx.NewNotFound()
+	}
+
+	student := cloneStudent(stored)
+	if err := fn(ctx, student); err != nil {
+		return err
+	}
+
+	r.dbByID[barcode] = student
+	r.dbByEmail[student.User().Email()] = student
+	r.appendEvents(student.GetUncommittedEvents()...)
+	student.CommitEvents()
+	return nil
+}
+
+func cloneStudent(s *user.Student) *user.Student {
+	u := s.User()
+	return user.RehydrateStudent(user.RehydrateStudentArgs{
+		RehydrateUserArgs: user.RehydrateUserArgs{
+			ID:            u.ID(),
+			Barcode:       u.Barcode(),
+			Username:      u.Username(),
+			FirstName:     u.FirstName(),
+			LastName:      u.LastName(),
+			Role:          u.Role(),
+			Avatar:        u.Avatar(),
+			Email:         u.Email(),
+			PassHash:      u.PassHash(),
+			PassHistory:   u.PassHistory(),
+			EmailVerified: u.EmailVerified(),
+			CreatedAt:     u.CreatedAt(),
+			UpdatedAt:     u.UpdatedAt(),
+		},
+		GroupID:          s.GroupID(),
+		EnrollmentStatus: s.EnrollmentStatus(),
+		LeaveUntil:       s.LeaveUntil(),
+		ExpelReason:      s.ExpelReason(),
+	})
+}
+
 func (r *StudentRepo) SaveStudent(ctx context.Context, student *user.Student) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
@@ -66,6 +134,7 @@ func (r *StudentRepo) SaveStudent(ctx context.Context, student *user.Student) er
 	r.dbByID[student.User().Barcode()] = student
 
 	r.appendEvents(student.GetUncommittedEvents()...)
+	student.CommitEvents()
 
 	return nil
 }
@@ -109,7 +178,7 @@ func (r *StudentRepo) RequireStudentByEmail(t *testing.T, email string) *user.St
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	student, exists := r.dbByEmail[email]
+	student, exists := r.dbByEmail[emails.Email(email)]
 	if !exists {
 		t.Fatalf("student with email %s does not exist", email)
 	}
@@ -135,7 +204,7 @@ func (r *StudentRepo) AssertStudentNotExistsByEmail(t *testing.T, email string)
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	if _, exists := r.dbByEmail[email]; exists {
+	if _, exists := r.dbByEmail[emails.Email(email)]; exists {
 		t.Errorf("expected student with email %s to not exist, but it does", email)
 	}
 	return r
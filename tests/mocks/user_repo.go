@@ -2,26 +2,48 @@ package mocks
 
 import (
 	"context"
+	"errors"
+	"slices"
+	"strings"
 	"sync"
 	"testing"
+	"time"
+
+	"github.com/google/uuid"
 
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/avatars"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/emails"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/roles"
 	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/i18nx"
 )
 
+// UserRepo keeps the users in memory. Unlike the postgres repo it hands out
+// the stored users, so that the tests can change the users they seeded in
+// place, UpdateUser still discards the changes of a failed update.
 type UserRepo struct {
 	dbbyID      map[user.ID]*user.User
-	dbbyEmail   map[string]*user.User
+	dbbyEmail   map[emails.Email]*user.User
 	dbbyBarcode map[user.Barcode]*user.User
 	// events      []event.Event
-	mu sync.Mutex
+	impersonations []*user.Impersonation
+	pendingUploads map[uuid.UUID]pendingUpload
+	mu             sync.Mutex
+}
+
+type pendingUpload struct {
+	userID    user.ID
+	key       string
+	createdAt time.Time
 }
 
 func NewUserRepo() *UserRepo {
 	return &UserRepo{
-		dbbyID:      make(map[user.ID]*user.User),
-		dbbyEmail:   make(map[string]*user.User),
-		dbbyBarcode: make(map[user.Barcode]*user.User),
+		dbbyID:         make(map[user.ID]*user.User),
+		dbbyEmail:      make(map[emails.Email]*user.User),
+		dbbyBarcode:    make(map[user.Barcode]*user.User),
+		pendingUploads: make(map[uuid.UUID]pendingUpload),
 	}
 }
 
@@ -35,7 +57,7 @@ func (r *UserRepo) GetUserByID(ctx context.Context, id user.ID) (*user.User, err
 	return nil, errorx.NewNotFound()
 }
 
-func (r *UserRepo) GetUserByEmail(ctx context.Context, email string) (*user.User, error) {
+func (r *UserRepo) GetUserByEmail(ctx context.Context, email emails.Email) (*user.User, error) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
@@ -57,7 +79,7 @@ func (r *UserRepo) GetUserByBarcode(ctx context.Context, barcode user.Barcode) (
 
 func (r *UserRepo) IsUserExists(
 	ctx context.Context,
-	email, username string,
+	email emails.Email, username string,
 	barcode user.Barcode,
 ) (emailExists, usernameExists, barcodeExists bool, err error) {
 	r.mu.Lock()
@@ -74,6 +96,22 @@ func (r *UserRepo) IsUserExists(
 	return emailExists, usernameExists, barcodeExists, nil
 }
 
+func (r *UserRepo) SaveUser(ctx context.Context, u *user.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if u == nil {
+		return errors.New("user cannot be nil")
+	}
+	if err := r.checkUnique(u, user.ID{}); err != nil {
+		return err
+	}
+
+	r.store(cloneUser(u))
+	u.CommitEvents()
+	return nil
+}
+
 func (r *UserRepo) SeedUser(t *testing.T, u *user.User) {
 	t.Helper()
 
@@ -96,7 +134,275 @@ func (r *UserRepo) SeedUser(t *testing.T, u *user.User) {
 		t.Fatalf("user with email %s already exists", u.Email())
 	}
 
+	r.store(u)
+}
+
+func (r *UserRepo) UpdateUser(
+	ctx context.Context,
+	id user.ID,
+	updateFn func(context.Context, *user.User) error,
+) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if updateFn == nil {
+		return errors.New("update function cannot be nil")
+	}
+	stored, ok := r.dbbyID[id]
+	if !ok {
+		return errorx.NewNotFound()
+	}
+
+	u := cloneUser(stored)
+	fnerr := updateFn(ctx, u)
+	if fnerr != nil && !errorx.IsPersistable(fnerr) {
+		return fnerr
+	}
+	if err := r.checkUnique(u, id); err != nil {
+		return err
+	}
+
+	delete(r.dbbyEmail, stored.Email())
+	delete(r.dbbyBarcode, stored.Barcode())
+	*stored = *u
+	r.store(stored)
+	return fnerr
+}
+
+// UpdateEmailVerification applies fn to the user like UpdateUser, the
+// pending code is kept on the stored user.
+func (r *UserRepo) UpdateEmailVerification(
+	ctx context.Context,
+	id user.ID,
+	fn func(context.Context, *user.User) error,
+) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if fn == nil {
+		return errors.New("update function cannot be nil")
+	}
+	stored, ok := r.dbbyID[id]
+	if !ok {
+		return errorx.NewNotFound()
+	}
+
+	u := cloneUser(stored)
+	fnerr := fn(ctx, u)
+	if fnerr != nil && !errorx.IsPersistable(fnerr) {
+		return fnerr
+	}
+	*stored = *u
+	return fnerr
+}
+
+func (r *UserRepo) IsEmailVerified(ctx context.Context, id user.ID) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u, ok := r.dbbyID[id]
+	if !ok {
+		return false, errorx.NewNotFound()
+	}
+	return u.EmailVerified(), nil
+}
+
+// PromoteUser applies fn to the user with barcode. Unlike the postgres repo
+// it keeps no staff record, the StaffRepo fake is separate.
+func (r *UserRepo) PromoteUser(
+	ctx context.Context,
+	barcode user.Barcode,
+	fn func(context.Context, *user.User) error,
+) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if fn == nil {
+		return errors.New("update function cannot be nil")
+	}
+	stored, ok := r.dbbyBarcode[barcode]
+	if !ok {
+		return errorx.NewNotFound()
+	}
+
+	u := cloneUser(stored)
+	if err := fn(ctx, u); err != nil {
+		return err
+	}
+	*stored = *u
+	return nil
+}
+
+// checkUnique reports the unique column of u that another user than self
+// already has, like the unique constraints of the users table.
+func (r *UserRepo) checkUnique(u *user.User, self user.ID) error {
+	if other, exists := r.dbbyID[u.ID()]; exists && other.ID() != self {
+		return errorx.NewDuplicateEntry()
+	}
+	if other, exists := r.dbbyEmail[u.Email()]; exists && other.ID() != self {
+		return errorx.NewDuplicateEntry().WithKey(i18nx.KeyEmailNotAvailable)
+	}
+	if other, exists := r.dbbyBarcode[u.Barcode()]; exists && other.ID() != self {
+		return errorx.NewDuplicateEntry().WithKey(i18nx.KeyBarcodeNotAvailable)
+	}
+	for _, other := range r.dbbyID {
+		if other.Username() == u.Username() && other.ID() != self {
+			return errorx.NewDuplicateEntry().WithKey(i18nx.KeyUsernameNotAvailable)
+		}
+	}
+	return nil
+}
+
+func (r *UserRepo) store(u *user.User) {
 	r.dbbyID[u.ID()] = u
 	r.dbbyBarcode[u.Barcode()] = u
 	r.dbbyEmail[u.Email()] = u
 }
+
+// cloneUser copies u without its uncommitted events, the way it would be
+// read back from the database.
+func cloneUser(u *user.User) *user.User {
+	var verification *user.EmailVerification
+	if v := u.EmailVerification(); v != nil {
+		verification = new(user.EmailVerification)
+		*verification = *v
+	}
+	return user.RehydrateUser(user.RehydrateUserArgs{
+		ID:                u.ID(),
+		Barcode:           u.Barcode(),
+		Username:          u.Username(),
+		FirstName:         u.FirstName(),
+		LastName:          u.LastName(),
+		Role:              u.Role(),
+		Avatar:            u.Avatar(),
+		Email:             u.Email(),
+		PassHash:          slices.Clone(u.PassHash()),
+		EmailVerified:     u.EmailVerified(),
+		EmailVerification: verification,
+		CreatedAt:         u.CreatedAt(),
+		UpdatedAt:         u.UpdatedAt(),
+	})
+}
+
+func (r *UserRepo) FilterReferencedAvatarKeys(ctx context.Context, keys []string) (map[string]struct{}, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.filterReferencedAvatarKeys(keys), nil
+}
+
+func (r *UserRepo) filterReferencedAvatarKeys(keys []string) map[string]struct{} {
+	referenced := make(map[string]struct{})
+	for _, key := range keys {
+		for _, p := range r.pendingUploads {
+			if p.key == key && time.Since(p.createdAt) < user.PendingUploadTTL {
+				referenced[key] = struct{}{}
+				break
+			}
+		}
+		for _, u := range r.dbbyID {
+			if u.Avatar().Source == avatars.SourceS3 && u.Avatar().S3Key == key {
+				referenced[key] = struct{}{}
+				break
+			}
+		}
+	}
+	return referenced
+}
+
+func (r *UserRepo) CreatePendingUpload(ctx context.Context, userID user.ID, key string) (uuid.UUID, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	token := uuid.New()
+	r.pendingUploads[token] = pendingUpload{userID: userID, key: key, createdAt: time.Now()}
+	return token, nil
+}
+
+func (r *UserRepo) ConsumePendingUpload(ctx context.Context, token uuid.UUID, userID user.ID, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	p, ok := r.pendingUploads[token]
+	if !ok || p.userID != userID || p.key != key || time.Since(p.createdAt) >= user.PendingUploadTTL {
+		return user.ErrUploadExpired
+	}
+	delete(r.pendingUploads, token)
+	return nil
+}
+
+// ExpirePendingUploads ages the pending uploads past user.PendingUploadTTL.
+func (r *UserRepo) ExpirePendingUploads() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for token, p := range r.pendingUploads {
+		p.createdAt = p.createdAt.Add(-user.PendingUploadTTL)
+		r.pendingUploads[token] = p
+	}
+}
+
+func (r *UserRepo) ListAvatarReferences(ctx context.Context, after user.ID, limit int) ([]user.AvatarReference, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var refs []user.AvatarReference
+	for id, u := range r.dbbyID {
+		if u.Avatar().Source != avatars.SourceS3 || u.Avatar().S3Key == "" || id.String() <= after.String() {
+			continue
+		}
+		refs = append(refs, user.AvatarReference{UserID: id, S3Key: u.Avatar().S3Key})
+	}
+	slices.SortFunc(refs, func(a, b user.AvatarReference) int {
+		return strings.Compare(a.UserID.String(), b.UserID.String())
+	})
+	return refs[:min(limit, len(refs))], nil
+}
+
+// ReleaseAvatarRef calls remove while holding the lock of the repo, the
+// updates of the users wait for it like they wait for the key lock in
+// Postgres.
+func (r *UserRepo) ReleaseAvatarRef(ctx context.Context, key string, remove func(context.Context) error) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.filterReferencedAvatarKeys([]string{key})[key]; ok {
+		return false, nil
+	}
+	if err := remove(ctx); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (r *UserRepo) CountUsersByRole(ctx context.Context) (map[roles.Global]int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	counts := make(map[roles.Global]int64)
+	for _, u := range r.dbbyID {
+		counts[u.Role()]++
+	}
+	return counts, nil
+}
+
+func (r *UserRepo) SaveImpersonation(ctx context.Context, imp *user.Impersonation) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.impersonations = append(r.impersonations, imp)
+	return nil
+}
+
+// ListImpersonations lists the sessions, the latest saved first.
+func (r *UserRepo) ListImpersonations(ctx context.Context, limit, offset int) ([]*user.Impersonation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	imps := slices.Clone(r.impersonations)
+	slices.Reverse(imps)
+	if offset >= len(imps) {
+		return nil, nil
+	}
+	return imps[offset:min(offset+limit, len(imps))], nil
+}
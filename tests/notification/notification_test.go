@@ -0,0 +1,225 @@
+package notification
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/application/notification/notificationquery"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/group"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/notification"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	userhttp "gitlab.com/ucmsv2/ucms-backend/internal/ports/http/user"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/fixtures"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/framework"
+	httpframework "gitlab.com/ucmsv2/ucms-backend/tests/integration/framework/http"
+)
+
+type NotificationSuite struct {
+	framework.IntegrationTestSuite
+}
+
+func TestNotificationSuite(t *testing.T) {
+	suite.Run(t, new(NotificationSuite))
+}
+
+func (s *NotificationSuite) listNotifications(t *testing.T, studentID user.ID, unreadOnly bool) notificationquery.ListNotificationsResponse {
+	t.Helper()
+	var res notificationquery.ListNotificationsResponse
+	s.HTTP.ListNotifications(t, unreadOnly, httpframework.WithStudent(t, studentID)).
+		RequireStatus(http.StatusOK).
+		RequireParseJSON(&res)
+	return res
+}
+
+func (s *NotificationSuite) unreadOnProfile(t *testing.T, studentID user.ID) int {
+	t.Helper()
+	var res struct {
+		Student struct {
+			UnreadNotifications int `json:"unread_notifications"`
+		} `json:"student"`
+	}
+	s.HTTP.GetStudentProfile(t, httpframework.WithStudent(t, studentID)).
+		RequireStatus(http.StatusOK).
+		RequireParseJSON(&res)
+	return res.Student.UnreadNotifications
+}
+
+func (s *NotificationSuite) TestGroupTransferNotifiesStudent() {
+	t := s.T()
+	staff := s.SeedStaff(t, fixtures.ValidStaffEmail)
+	student := s.SeedStudent(t, fixtures.ValidStudentEmail, s.SeedGroup(t))
+	target := group.NewID()
+	s.DB.SeedGroup(t, target, "SE-2402", fixtures.SEGroup.Year, fixtures.SEGroup.Major)
+	studentID := student.User().ID()
+
+	s.HTTP.TransferStudentGroup(t, student.User().Barcode().String(), uuid.UUID(target),
+		httpframework.WithStaff(t, staff.User().ID())).
+		RequireStatus(http.StatusOK)
+
+	var list notificationquery.ListNotificationsResponse
+	require.Eventually(t, func() bool {
+		list = s.listNotifications(t, studentID, false)
+		return len(list.Notifications) == 1
+	}, 5*time.Second, 50*time.Millisecond, "the student should be notified of the transfer")
+	got := list.Notifications[0]
+	assert.Equal(t, notification.TypeGroupChanged.String(), got.Type)
+	assert.Contains(t, got.Title, "SE-2402")
+	assert.Nil(t, got.ReadAt)
+	assert.Equal(t, 1, list.UnreadCount)
+	assert.Equal(t, 1, s.unreadOnProfile(t, studentID))
+
+	s.HTTP.MarkNotificationRead(t, got.ID, httpframework.WithStudent(t, studentID)).
+		RequireStatus(http.StatusOK)
+
+	assert.Equal(t, 0, s.unreadOnProfile(t, studentID))
+	unread := s.listNotifications(t, studentID, true)
+	assert.Empty(t, unread.Notifications)
+	assert.Equal(t, 0, unread.UnreadCount)
+}
+
+func (s *NotificationSuite) TestMarkRead_OtherUsersNotification() {
+	t := s.T()
+	staff := s.SeedStaff(t, fixtures.ValidStaffEmail)
+	student := s.SeedStudent(t, fixtures.ValidStudentEmail, s.SeedGroup(t))
+	target := group.NewID()
+	s.DB.SeedGroup(t, target, "SE-2402", fixtures.SEGroup.Year, fixtures.SEGroup.Major)
+
+	s.HTTP.TransferStudentGroup(t, student.User().Barcode().String(), uuid.UUID(target),
+		httpframework.WithStaff(t, staff.User().ID())).
+		RequireStatus(http.StatusOK)
+
+	var list notificationquery.ListNotificationsResponse
+	require.Eventually(t, func() bool {
+		list = s.listNotifications(t, student.User().ID(), false)
+		return len(list.Notifications) == 1
+	}, 5*time.Second, 50*time.Millisecond)
+
+	s.HTTP.MarkNotificationRead(t, list.Notifications[0].ID, httpframework.WithStaff(t, staff.User().ID())).
+		AssertStatus(http.StatusNotFound)
+	assert.Equal(t, 1, s.unreadOnProfile(t, student.User().ID()))
+}
+
+func (s *NotificationSuite) TestTransferToUnknownGroup() {
+	t := s.T()
+	staff := s.SeedStaff(t, fixtures.ValidStaffEmail)
+	student := s.SeedStudent(t, fixtures.ValidStudentEmail, s.SeedGroup(t))
+
+	s.HTTP.TransferStudentGroup(t, student.User().Barcode().String(), uuid.New(),
+		httpframework.WithStaff(t, staff.User().ID())).
+		AssertStatus(http.StatusNotFound)
+}
+
+func (s *NotificationSuite) TestTransfer_StudentForbidden() {
+	t := s.T()
+	student := s.SeedStudent(t, fixtures.ValidStudentEmail, s.SeedGroup(t))
+
+	s.HTTP.TransferStudentGroup(t, student.User().Barcode().String(), uuid.New(),
+		httpframework.WithStudent(t, student.User().ID())).
+		AssertStatus(http.StatusForbidden)
+}
+
+func (s *NotificationSuite) TestMarkAllRead() {
+	t := s.T()
+	staff := s.SeedStaff(t, fixtures.ValidStaffEmail)
+	student := s.SeedStudent(t, fixtures.ValidStudentEmail, s.SeedGroup(t))
+	studentID := student.User().ID()
+	for _, name := range []string{"SE-2402", "SE-2403"} {
+		target := group.NewID()
+		s.DB.SeedGroup(t, target, name, fixtures.SEGroup.Year, fixtures.SEGroup.Major)
+		s.HTTP.TransferStudentGroup(t, student.User().Barcode().String(), uuid.UUID(target),
+			httpframework.WithStaff(t, staff.User().ID())).
+			RequireStatus(http.StatusOK)
+	}
+	require.Eventually(t, func() bool {
+		return s.listNotifications(t, studentID, true).UnreadCount == 2
+	}, 5*time.Second, 50*time.Millisecond)
+
+	s.HTTP.MarkAllNotificationsRead(t, httpframework.WithStudent(t, studentID)).
+		RequireStatus(http.StatusOK)
+
+	assert.Equal(t, 0, s.unreadOnProfile(t, studentID))
+	assert.Len(t, s.listNotifications(t, studentID, false).Notifications, 2)
+}
+
+func (s *NotificationSuite) transfer(t *testing.T, staff *user.Staff, student *user.Student, name string) {
+	t.Helper()
+	target := group.NewID()
+	s.DB.SeedGroup(t, target, name, fixtures.SEGroup.Year, fixtures.SEGroup.Major)
+	s.HTTP.TransferStudentGroup(t, student.User().Barcode().String(), uuid.UUID(target),
+		httpframework.WithStaff(t, staff.User().ID())).
+		RequireStatus(http.StatusOK)
+}
+
+func (s *NotificationSuite) TestStream() {
+	t := s.T()
+	staff := s.SeedStaff(t, fixtures.ValidStaffEmail)
+	student := s.SeedStudent(t, fixtures.ValidStudentEmail, s.SeedGroup(t))
+	studentID := student.User().ID()
+
+	stream := s.HTTP.StreamNotifications(t, "", httpframework.WithStudent(t, studentID))
+	defer stream.Close()
+	stream.RequireStatus(http.StatusOK)
+	assert.Equal(t, "text/event-stream", stream.Header().Get("Content-Type"))
+
+	unread := stream.Next(5 * time.Second)
+	assert.Equal(t, userhttp.EventUnreadCount, unread.Event)
+	assert.Empty(t, unread.ID)
+	assert.JSONEq(t, `{"unread_count":0}`, unread.Data)
+
+	s.transfer(t, staff, student, "SE-2402")
+
+	e := stream.Next(5 * time.Second)
+	assert.Equal(t, userhttp.EventNotification, e.Event)
+	var got notificationquery.NotificationResponse
+	require.NoError(t, json.Unmarshal([]byte(e.Data), &got))
+	assert.Equal(t, got.ID, e.ID, "the id of the event is the notification")
+	assert.Equal(t, notification.TypeGroupChanged.String(), got.Type)
+	assert.Contains(t, got.Title, "SE-2402")
+
+	list := s.listNotifications(t, studentID, false)
+	require.Len(t, list.Notifications, 1)
+	assert.Equal(t, list.Notifications[0].ID, e.ID)
+
+	require.Equal(t, 1, s.NotificationHub().Subscribers(studentID))
+	stream.Close()
+	assert.Zero(t, s.NotificationHub().Subscribers(studentID), "disconnecting unsubscribes")
+}
+
+func (s *NotificationSuite) TestStream_ResumesFromLastEventID() {
+	t := s.T()
+	staff := s.SeedStaff(t, fixtures.ValidStaffEmail)
+	student := s.SeedStudent(t, fixtures.ValidStudentEmail, s.SeedGroup(t))
+	studentID := student.User().ID()
+	for _, name := range []string{"SE-2402", "SE-2403", "SE-2404"} {
+		s.transfer(t, staff, student, name)
+	}
+	var list notificationquery.ListNotificationsResponse
+	require.Eventually(t, func() bool {
+		list = s.listNotifications(t, studentID, false)
+		return len(list.Notifications) == 3
+	}, 5*time.Second, 50*time.Millisecond)
+	oldest := list.Notifications[2]
+
+	stream := s.HTTP.StreamNotifications(t, oldest.ID, httpframework.WithStudent(t, studentID))
+	defer stream.Close()
+	stream.RequireStatus(http.StatusOK)
+
+	assert.JSONEq(t, `{"unread_count":3}`, stream.Next(5*time.Second).Data)
+	assert.Equal(t, list.Notifications[1].ID, stream.Next(5*time.Second).ID)
+	assert.Equal(t, list.Notifications[0].ID, stream.Next(5*time.Second).ID)
+}
+
+func (s *NotificationSuite) TestStream_Unauthenticated() {
+	t := s.T()
+
+	stream := s.HTTP.StreamNotifications(t, "")
+	defer stream.Close()
+	stream.RequireStatus(http.StatusUnauthorized)
+}
@@ -11,12 +11,15 @@ import (
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
 
 	mailevent "gitlab.com/ucmsv2/ucms-backend/internal/application/mail/event"
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/registration"
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/roles"
 	registrationhttp "gitlab.com/ucmsv2/ucms-backend/internal/ports/http/registration"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/validationx"
 	"gitlab.com/ucmsv2/ucms-backend/tests/integration/builders"
 	"gitlab.com/ucmsv2/ucms-backend/tests/integration/fixtures"
 	"gitlab.com/ucmsv2/ucms-backend/tests/integration/framework"
@@ -52,27 +55,20 @@ func (s *RegistrationIntegrationSuite) TestStudentRegistrationFlow() {
 
 	var e *registration.RegistrationStarted
 	s.T().Run("Verify Registration Event", func(t *testing.T) {
-		e = event.RequireEvent(t, s.Event, e)
-		require.NotNil(t, e, "Expected RegistrationStarted event to be emitted")
+		e = event.WaitFor(t, s.Event, 5*time.Second, startedFor(email))
 		registration.NewRegistrationStartedAssertion(e).
 			AssertRegistrationID(t, reg.Registration.ID()).
 			AssertEmail(t, email).
-			AssertVerificationCode(t, reg.Registration.VerificationCode())
+			AssertVerificationCode(t, reg.Registration.VerificationCode()).
+			AssertHeader(t)
 	})
 
 	// 4. Verify email sent (wait for async event processing)
 	s.T().Run("Verify Email Sent", func(t *testing.T) {
-		s.Require().Eventually(func() bool {
-			mails := s.MockMailSender.GetSentMails()
-			return len(mails) > 0
-		}, 5*time.Second, 100*time.Millisecond, "Email should be sent within 5 seconds")
-
-		mails := s.MockMailSender.GetSentMails()
-		s.Require().Len(mails, 1)
-		s.Equal(email, mails[0].To)
-		s.Contains(mails[0].Subject, mailevent.RegistrationStartedSubject)
-		s.Contains(mails[0].Body, reg.Registration.VerificationCode())
-		s.MockMailSender.Reset()
+		mail := s.MockMailSender.EventuallyRequireMailSent(t, email, mailevent.RegistrationStartedSubject)
+		s.Contains(mail.Body, reg.Registration.VerificationCode())
+		s.Len(s.MockMailSender.MailsTo(email), 1)
+		s.MockMailSender.ResetFor(email)
 	})
 
 	s.T().Run("Complete Registration", func(t *testing.T) {
@@ -94,9 +90,10 @@ func (s *RegistrationIntegrationSuite) TestStudentRegistrationFlow() {
 	})
 
 	s.T().Run("Verify Student Creation", func(t *testing.T) {
-		s.Require().Eventually(func() bool {
-			return s.DB.CheckUserExists(t, email)
-		}, 5*time.Second, 100*time.Millisecond, "Student should be created within 5 seconds")
+		e := event.WaitFor(t, s.Event, 5*time.Second, func(e *user.StudentRegistered) bool {
+			return e.Email.String() == email
+		})
+		require.Equal(t, reg.Registration.ID(), e.RegistrationID)
 
 		s.DB.RequireStudentExistsByEmail(t, email).
 			AssertRole(t, roles.Student).
@@ -106,61 +103,55 @@ func (s *RegistrationIntegrationSuite) TestStudentRegistrationFlow() {
 	})
 
 	s.T().Run("Verify Registration Status", func(t *testing.T) {
-		reg := s.DB.RequireRegistrationExists(t, email).Registration
-		require.Eventually(t, func() bool {
-			return reg.IsStatus(registration.StatusCompleted)
-		}, 5*time.Second, 100*time.Millisecond, "")
+		s.DB.RequireRegistrationExists(t, email).
+			AssertStatus(t, registration.StatusCompleted)
 	})
 
 	s.T().Run("Verify Welcome Email Sent", func(t *testing.T) {
-		s.Require().Eventually(func() bool {
-			mails := s.MockMailSender.GetSentMails()
-			return len(mails) > 0
-		}, 5*time.Second, 100*time.Millisecond, "Welcome email should be sent within 5 seconds")
-
-		mails := s.MockMailSender.GetSentMails()
-		s.Require().Len(mails, 1)
-		s.Equal(email, mails[0].To)
-		s.Contains(mails[0].Subject, "Welcome to UCMS")
-		s.Contains(mails[0].Body, fixtures.TestStudent.FirstName)
-		s.MockMailSender.Reset()
+		mail := s.MockMailSender.EventuallyRequireMailSent(t, email, "Welcome to UCMS")
+		s.Contains(mail.Body, fixtures.TestStudent.FirstName)
+		s.Len(s.MockMailSender.MailsTo(email), 1)
+		s.MockMailSender.ResetFor(email)
 	})
 }
 
 func (s *RegistrationIntegrationSuite) TestStudentRegistrationWithResend() {
 	email := "resend@test.com"
 
-	s.T().Run("resend", func(t *testing.T) {
-		reg := builders.NewRegistrationBuilder().
-			WithEmail(email).
-			WithResendAvailable().
-			Build()
-		s.DB.SeedRegistration(t, reg)
+	reg := builders.NewRegistrationBuilder().
+		WithClock(s.Clock).
+		WithEmail(email).
+		Build()
+	s.DB.SeedRegistration(s.T(), reg)
+
+	s.T().Run("resend before the cooldown, should fail", func(t *testing.T) {
+		nextResendAt := s.DB.RequireRegistrationExists(t, email).NextResendAt()
+		s.HTTP.ResendVerificationCode(t, email).AssertRetryAfter(s.Clock.Now(), nextResendAt)
+		event.RequireNoEvent(t, s.Event, resentFor(email))
+	})
+
+	s.T().Run("resend after the cooldown", func(t *testing.T) {
+		s.Clock.Advance(registration.ResendTimeout + time.Second)
 
 		s.HTTP.ResendVerificationCode(t, email).AssertAccepted()
 
-		e := event.RequireEvent(t, s.Event, &registration.VerificationCodeResent{})
+		e := event.WaitFor(t, s.Event, 5*time.Second, resentFor(email))
 		registration.NewVerificationCodeSentAssertion(e).
 			AssertEmail(t, email).
 			AssertRegistrationID(t, reg.ID()).
 			AssertVerificationCodeNotEqual(t, reg.VerificationCode()).
 			AssertVerificationCodeNotEmpty(t)
 
-		s.Require().Eventually(func() bool {
-			mails := s.MockMailSender.GetSentMails()
-			return len(mails) > 0
-		}, 5*time.Second, 100*time.Millisecond, "Email should be sent within 5 seconds")
-
-		mails := s.MockMailSender.GetSentMails()
-		s.Require().Len(mails, 1)
-		s.Equal(email, mails[0].To)
-		s.Contains(mails[0].Subject, "Verification Code Resent")
-		s.Contains(mails[0].Body, e.VerificationCode)
-		s.MockMailSender.Reset()
+		mail := s.MockMailSender.EventuallyRequireMailSent(t, email, "Verification Code Resent")
+		s.Contains(mail.Body, e.VerificationCode)
+		s.Len(s.MockMailSender.MailsTo(email), 1)
+		s.MockMailSender.ResetFor(email)
 	})
 
 	s.T().Run("resend again, should fail", func(t *testing.T) {
+		s.Event.Reset()
 		s.HTTP.ResendVerificationCode(t, email).AssertStatus(http.StatusTooManyRequests)
+		event.RequireNoEvent(t, s.Event, resentFor(email))
 	})
 }
 
@@ -174,6 +165,7 @@ func (s *RegistrationIntegrationSuite) TestStudentRegistration_FailPath() {
 		s.DB.SeedRegistration(s.T(), reg)
 
 		s.HTTP.ResendVerificationCode(t, email).AssertStatus(http.StatusTooManyRequests)
+		event.RequireNoEvent(t, s.Event, resentFor(email))
 	})
 
 	s.T().Run("registration not exists", func(t *testing.T) {
@@ -199,33 +191,29 @@ func (s *RegistrationIntegrationSuite) TestConcurrentRegistrations() {
 
 	wg.Wait()
 
-	// Only one should succeed
+	// The overlapping starts share the result of the first, a start arriving
+	// after it continues its registration and hits the resend cooldown
 	successCount := 0
 	for _, resp := range responses {
 		if resp.Code == http.StatusAccepted {
 			successCount++
+			continue
 		}
+		resp.AssertStatus(http.StatusTooManyRequests)
 	}
 
-	s.Equal(1, successCount, "Only one registration should succeed")
+	s.GreaterOrEqual(successCount, 1, "The first registration should succeed")
 	s.DB.RequireRegistrationCount(s.T(), 1)
 
-	e := event.RequireEvent(s.T(), s.Event, &registration.RegistrationStarted{})
+	e := event.WaitFor(s.T(), s.Event, 5*time.Second, startedFor(email))
 	registration.NewRegistrationStartedAssertion(e).
 		AssertEmail(s.T(), email).
 		AssertVerificationCodeNotEmpty(s.T()).
 		AssertRegistrationIDNotEmpty(s.T())
 
-	s.Eventually(func() bool {
-		mails := s.MockMailSender.GetSentMails()
-		return len(mails) > 0
-	}, 10*time.Second, 100*time.Millisecond, "Email should be sent within 5 seconds")
-
-	mails := s.MockMailSender.GetSentMails()
-	s.Require().Len(mails, 1)
-	s.Equal(email, mails[0].To)
-	s.Contains(mails[0].Subject, mailevent.RegistrationStartedSubject)
-	s.Contains(mails[0].Body, e.VerificationCode)
+	mail := s.MockMailSender.EventuallyRequireMailSent(s.T(), email, mailevent.RegistrationStartedSubject)
+	s.Contains(mail.Body, e.VerificationCode)
+	s.Len(s.MockMailSender.MailsTo(email), 1)
 }
 
 func (s *RegistrationIntegrationSuite) TestStartRegistrationValidation() {
@@ -263,12 +251,12 @@ func (s *RegistrationIntegrationSuite) TestVerificationCodeHandling() {
 			s.HTTP.VerifyRegistrationCode(t, email, "WRONG1").
 				AssertStatus(http.StatusUnprocessableEntity)
 		}
-		s.HTTP.VerifyRegistrationCode(t, email, "WRONG1").
-			AssertStatus(http.StatusTooManyRequests)
+		res := s.HTTP.VerifyRegistrationCode(t, email, "WRONG1")
 
-		s.DB.RequireRegistrationExists(t, email).
+		stored := s.DB.RequireRegistrationExists(t, email).
 			AssertStatus(t, registration.StatusExpired).
 			AssertCodeAttempts(t, 3)
+		res.AssertRetryAfter(s.Clock.Now(), stored.NextResendAt())
 	})
 
 	s.T().Run("Verify Already Expired Code", func(t *testing.T) {
@@ -336,6 +324,35 @@ func (s *RegistrationIntegrationSuite) TestCompleteRegistrationValidation() {
 	})
 }
 
+func (s *RegistrationIntegrationSuite) TestCompleteRegistration_Warnings() {
+	t := s.T()
+	email := "capitals@test.com"
+	s.setupVerifiedRegistration(email)
+
+	var res struct {
+		Warnings validationx.Warnings `json:"warnings"`
+	}
+	s.HTTP.CompleteStudentRegistration(t, registrationhttp.CompleteStudentRegistrationRequest{
+		Email:            email,
+		VerificationCode: s.getVerificationCode(email),
+		Password:         fixtures.TestStudent.Password,
+		Barcode:          "STU003",
+		Username:         "capitals.",
+		FirstName:        "AIGERIM",
+		LastName:         "Student",
+		GroupId:          uuid.UUID(fixtures.SEGroup.ID),
+	}).RequireSuccess().RequireParseJSON(&res)
+
+	require.Equal(t, validationx.Warnings{
+		{Field: "first_name", Code: validationx.WarningAllCapsNormalized},
+		{Field: "username", Code: validationx.WarningEdgeDotsTrimmed},
+	}, res.Warnings)
+	s.DB.RequireStudentExistsByEmail(t, email).
+		AssertFirstName(t, "Aigerim").
+		AssertLastName(t, "Student").
+		AssertUsername(t, "capitals")
+}
+
 func (s *RegistrationIntegrationSuite) TestRegistrationStates() {
 	s.T().Run("Complete Without Verification", func(t *testing.T) {
 		email := "no-verify@test.com"
@@ -377,7 +394,8 @@ func (s *RegistrationIntegrationSuite) TestBusinessRules() {
 	s.T().Run("Registration Already Exists", func(t *testing.T) {
 		email := "existing@test.com"
 		s.HTTP.StartStudentRegistration(t, email).AssertAccepted()
-		s.HTTP.StartStudentRegistration(t, email).AssertStatus(http.StatusTooManyRequests)
+		nextResendAt := s.DB.RequireRegistrationExists(t, email).NextResendAt()
+		s.HTTP.StartStudentRegistration(t, email).AssertRetryAfter(s.Clock.Now(), nextResendAt)
 	})
 
 	s.T().Run("Name Length Validation", func(t *testing.T) {
@@ -397,6 +415,30 @@ func (s *RegistrationIntegrationSuite) TestBusinessRules() {
 	})
 }
 
+// TestStartAgainAfterCooldown checks that starting again, e.g. after a page
+// refresh, continues the pending registration instead of starting a new one.
+func (s *RegistrationIntegrationSuite) TestStartAgainAfterCooldown() {
+	t := s.T()
+	email := "restart@test.com"
+
+	s.HTTP.StartStudentRegistration(t, email).AssertAccepted()
+	started := event.WaitFor(t, s.Event, 5*time.Second, startedFor(email))
+
+	s.Clock.Advance(registration.ResendTimeout + time.Second)
+	s.Event.Reset()
+	s.HTTP.StartStudentRegistration(t, email).AssertAccepted()
+
+	e := event.WaitFor(t, s.Event, 5*time.Second, resentFor(email))
+	registration.NewVerificationCodeSentAssertion(e).
+		AssertEmail(t, email).
+		AssertRegistrationID(t, started.RegistrationID).
+		AssertVerificationCodeNotEqual(t, started.VerificationCode)
+	event.RequireNoEvent(t, s.Event, startedFor(email))
+
+	stored := s.DB.RequireRegistrationExists(t, email).AssertStatus(t, registration.StatusPending)
+	require.Equal(t, started.RegistrationID, stored.Registration.ID(), "no new registration should be started")
+}
+
 func (s *RegistrationIntegrationSuite) TestRegistration_StudentComplete_RequestValidation() {
 	s.DB.SeedGroup(s.T(), fixtures.SEGroup.ID, fixtures.SEGroup.Name, fixtures.SEGroup.Year, fixtures.SEGroup.Major)
 
@@ -1175,12 +1217,28 @@ func (s *RegistrationIntegrationSuite) TestRegistration_AdvancedInjectionVectors
 			setup: func(req *registrationhttp.CompleteStudentRegistrationRequest) {
 				req.FirstName = "ＡＤＭİＮ" // Full-width and Turkish i
 			},
-			expectedStatus: http.StatusOK,
-			setupBefore:    true,
-			description:    "Unicode normalization bypass",
-			assertFunc: func(t *testing.T, u *user.UserAssertions) {
-				u.AssertFirstName("ＡＤＭİＮ")
+			// The full-width letters are Latin, but only render like ADMIN.
+			expectedStatus:  http.StatusBadRequest,
+			expectedMessage: "must be written in plain Latin, Cyrillic or CJK letters",
+			description:     "Unicode normalization bypass",
+		},
+		{
+			name: "Mixed-Script Name",
+			setup: func(req *registrationhttp.CompleteStudentRegistrationRequest) {
+				req.FirstName = "Jоhn" // Cyrillic 'о' instead of Latin 'o'
 			},
+			expectedStatus:  http.StatusBadRequest,
+			expectedMessage: "must be written in plain Latin, Cyrillic or CJK letters",
+			description:     "Homograph name",
+		},
+		{
+			name: "Homograph Username with Cyrillic",
+			setup: func(req *registrationhttp.CompleteStudentRegistrationRequest) {
+				req.Username = "аdmin" // Cyrillic 'а' instead of Latin 'a'
+			},
+			expectedStatus:  http.StatusBadRequest,
+			expectedMessage: "must be written in English letters",
+			description:     "Homograph username",
 		},
 		{
 			name: "Homograph Attack with Cyrillic",
@@ -1446,10 +1504,43 @@ func (s *RegistrationIntegrationSuite) setupCompletedRegistration(email string)
 	}).AssertSuccess()
 }
 
+func startedFor(email string) func(*registration.RegistrationStarted) bool {
+	return func(e *registration.RegistrationStarted) bool { return e.Email.String() == email }
+}
+
+func resentFor(email string) func(*registration.VerificationCodeResent) bool {
+	return func(e *registration.VerificationCodeResent) bool { return e.Email.String() == email }
+}
+
 func (s *RegistrationIntegrationSuite) getVerificationCode(email string) string {
 	return s.DB.RequireRegistrationExists(s.T(), email).Registration.VerificationCode()
 }
 
+func (s *RegistrationIntegrationSuite) TestRegistrationStarted_MailSpanContinuesRequestTrace() {
+	t := s.T()
+	email := "traced@test.com"
+
+	s.HTTP.StartStudentRegistration(t, email).RequireAccepted()
+	s.MockMailSender.EventuallyRequireMailSent(t, email, mailevent.RegistrationStartedSubject)
+
+	var httpSpan, mailSpan sdktrace.ReadOnlySpan
+	require.Eventually(t, func() bool {
+		for _, span := range s.EndedSpans() {
+			switch {
+			case span.SpanKind() == trace.SpanKindServer && strings.HasPrefix(span.Name(), "POST "):
+				httpSpan = span
+			case span.Name() == "MailOnRegistrationStarted":
+				mailSpan = span
+			}
+		}
+		return httpSpan != nil && mailSpan != nil
+	}, 5*time.Second, 50*time.Millisecond, "expected both the http and the mail handler spans")
+
+	require.Equal(t, trace.SpanKindConsumer, mailSpan.SpanKind())
+	require.Equal(t, httpSpan.SpanContext().TraceID(), mailSpan.SpanContext().TraceID(),
+		"mail handler span should belong to the registration request trace")
+}
+
 func (s *RegistrationIntegrationSuite) TestGetVerificationCodeEndpoint() {
 	s.T().Run("Success - Returns verification code for existing registration", func(t *testing.T) {
 		email := "devcode@test.com"
@@ -1475,3 +1566,23 @@ func (s *RegistrationIntegrationSuite) TestGetVerificationCodeEndpoint() {
 			AssertStatus(http.StatusNotFound)
 	})
 }
+
+func (s *RegistrationIntegrationSuite) TestDevClockEndpoint() {
+	email := "devclock@test.com"
+	reg := builders.NewRegistrationBuilder().
+		WithClock(s.Clock).
+		WithEmail(email).
+		Build()
+	s.DB.SeedRegistration(s.T(), reg)
+
+	s.T().Run("Resend waits for the cooldown", func(t *testing.T) {
+		s.HTTP.ResendVerificationCode(t, email).AssertStatus(http.StatusTooManyRequests)
+	})
+
+	s.T().Run("Advancing the clock ends the cooldown", func(t *testing.T) {
+		s.HTTP.AdvanceDevClock(t, registration.ResendTimeout+time.Second).
+			RequireStatus(http.StatusOK)
+
+		s.HTTP.ResendVerificationCode(t, email).AssertAccepted()
+	})
+}
@@ -0,0 +1,156 @@
+package report
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill/message/router/middleware"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/adapters/repos/postgres"
+	"gitlab.com/ucmsv2/ucms-backend/internal/application/report/reportquery"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/registration"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/report"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/watermillx"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/builders"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/fixtures"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/framework"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/framework/db"
+	httpframework "gitlab.com/ucmsv2/ucms-backend/tests/integration/framework/http"
+)
+
+type DashboardSuite struct {
+	framework.IntegrationTestSuite
+}
+
+func TestDashboardSuite(t *testing.T) {
+	suite.Run(t, new(DashboardSuite))
+}
+
+func (s *DashboardSuite) TestGetDashboard() {
+	t := s.T()
+	now := time.Now().UTC()
+
+	admin := s.SeedStaff(t, fixtures.TestStaff.Email)
+	s.SeedStaff(t, fixtures.TestStaff2.Email)
+	groupID := s.SeedGroup(t)
+	s.SeedStudent(t, "dashboard-1@example.com", groupID)
+	s.SeedStudent(t, "dashboard-2@example.com", groupID)
+	s.SeedStudent(t, "dashboard-3@example.com", groupID)
+
+	seedRegistration := func(email string, status registration.Status, at time.Time) {
+		s.DB.SeedRegistration(t, builders.NewRegistrationBuilder().
+			WithEmail(email).
+			WithStatus(status).
+			WithCreatedAt(at).
+			Build())
+	}
+	seedRegistration("dashboard-pending-1@example.com", registration.StatusPending, now.Add(-time.Hour))
+	seedRegistration("dashboard-pending-2@example.com", registration.StatusPending, now.Add(-6*24*time.Hour))
+	seedRegistration("dashboard-completed@example.com", registration.StatusCompleted, now.Add(-2*24*time.Hour))
+	seedRegistration("dashboard-old@example.com", registration.StatusPending, now.Add(-8*24*time.Hour))
+
+	past, future := now.Add(-time.Hour), now.Add(time.Hour)
+	invitation := func() *builders.StaffInvitationBuilder {
+		return builders.NewStaffInvitationBuilder().WithCreatorID(admin.User().ID())
+	}
+	s.DB.SeedStaffInvitation(t, invitation().
+		WithRecipientsEmail([]string{"a@example.com", "b@example.com"}).
+		WithValidFrom(&past).
+		WithValidUntil(&future).
+		Build())
+	s.DB.SeedStaffInvitation(t, invitation().WithRecipientsEmail([]string{"c@example.com"}).Build())
+	s.DB.SeedStaffInvitation(t, invitation().WithValidUntil(&past).Build())
+	s.DB.SeedStaffInvitation(t, invitation().WithValidFrom(&future).Build())
+	deleted := invitation().Build()
+	s.DB.SeedStaffInvitation(t, deleted)
+	s.DB.Exec(t, `UPDATE staff_invitations SET deleted_at = $1 WHERE id = $2`, past, uuid.UUID(deleted.ID()))
+
+	deadLetter := func(handler string) {
+		s.DB.SeedDeadLetter(t, db.DeadLetter{
+			Topic:   "registration",
+			Handler: handler,
+			Reason:  "poisoned",
+			Payload: json.RawMessage(`{}`),
+		})
+	}
+	deadLetter("MailOnRegistrationStarted")
+	deadLetter("MailOnStaffInvitationCreated")
+	deadLetter("NotificationOnStaffInvitationAccepted")
+	deadLetter("MailOnStudentRegistered")
+	s.DB.Exec(t, `
+		UPDATE watermill_`+watermillx.PoisonTopic+` SET created_at = created_at - interval '2 days'
+		WHERE metadata->>$1::text = $2
+	`, middleware.PoisonedHandlerKey, "MailOnStudentRegistered")
+
+	var body struct {
+		Dashboard reportquery.Dashboard `json:"dashboard"`
+	}
+	s.HTTP.GetDashboard(t, httpframework.WithStaff(t, admin.User().ID())).
+		RequireStatus(http.StatusOK).
+		RequireParseJSON(&body)
+	d := body.Dashboard
+
+	assert.False(t, d.Partial)
+	assert.Equal(t, int64(5), d.Users.Total)
+	assert.Equal(t, int64(3), d.Users.ByRole["student"])
+	assert.Equal(t, int64(2), d.Users.ByRole["staff"])
+	assert.Contains(t, d.Users.ByRole, "aitusa", "the roles without users are counted too")
+	assert.Equal(t, int64(3), d.Registrations.Total)
+	assert.Equal(t, map[string]int64{"pending": 2, "completed": 1}, d.Registrations.ByStatus)
+	assert.Equal(t, report.Invitations{Active: 2, OutstandingRecipients: 3}, d.Invitations.Invitations)
+	assert.Equal(t, int64(2), d.MailFailures.Count)
+	assert.Equal(t, int64(4), d.DeadLetters.Count)
+	for _, section := range []string{d.Users.Error, d.Registrations.Error, d.Invitations.Error, d.MailFailures.Error, d.DeadLetters.Error} {
+		assert.Empty(t, section)
+	}
+
+	t.Run("cached", func(t *testing.T) {
+		deadLetter("MailOnRegistrationStarted")
+		var cached struct {
+			Dashboard reportquery.Dashboard `json:"dashboard"`
+		}
+		s.HTTP.GetDashboard(t, httpframework.WithStaff(t, admin.User().ID())).
+			RequireStatus(http.StatusOK).
+			RequireParseJSON(&cached)
+		assert.Equal(t, int64(4), cached.Dashboard.DeadLetters.Count)
+		assert.Equal(t, d.GeneratedAt, cached.Dashboard.GeneratedAt)
+	})
+
+	t.Run("partial", func(t *testing.T) {
+		h := reportquery.NewDashboardHandler(reportquery.DashboardHandlerArgs{
+			Users:        postgres.NewUserRepo(s.Pool(), nil, nil),
+			Counter:      failingInvitations{postgres.NewReportRepo(s.Pool(), nil)},
+			MailHandlers: []string{"MailOnRegistrationStarted"},
+		})
+		res, err := h.Get(t.Context(), reportquery.DashboardFilter{})
+		require.NoError(t, err)
+		assert.True(t, res.Partial)
+		assert.Equal(t, "failed to load", res.Invitations.Error)
+		assert.Zero(t, res.Invitations.Active)
+		assert.Equal(t, int64(3), res.Registrations.Total)
+		assert.Equal(t, int64(5), res.DeadLetters.Count)
+	})
+
+	t.Run("staff only", func(t *testing.T) {
+		student := s.SeedStudent(t, "dashboard-4@example.com", groupID)
+		s.HTTP.GetDashboard(t, httpframework.WithStudent(t, student.User().ID())).
+			AssertStatus(http.StatusForbidden)
+	})
+}
+
+// failingInvitations is a ReportRepo whose invitations can not be counted.
+type failingInvitations struct {
+	*postgres.ReportRepo
+}
+
+func (failingInvitations) CountActiveInvitations(context.Context, time.Time) (report.Invitations, error) {
+	return report.Invitations{}, errors.New("connection reset")
+}
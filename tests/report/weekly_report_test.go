@@ -0,0 +1,130 @@
+package report
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/adapters/repos/postgres"
+	reportcmd "gitlab.com/ucmsv2/ucms-backend/internal/application/report/cmd"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/registration"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/report"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/builders"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/fixtures"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/framework"
+	httpframework "gitlab.com/ucmsv2/ucms-backend/tests/integration/framework/http"
+)
+
+type WeeklyReportSuite struct {
+	framework.IntegrationTestSuite
+}
+
+func TestWeeklyReportSuite(t *testing.T) {
+	suite.Run(t, new(WeeklyReportSuite))
+}
+
+func (s *WeeklyReportSuite) TestGenerateWeeklyReport() {
+	t := s.T()
+
+	weekStart := report.WeekStart(time.Now()).Add(-report.Week)
+	inWeek := weekStart.Add(36 * time.Hour)
+	beforeWeek := weekStart.Add(-time.Hour)
+
+	statuses := []registration.Status{
+		registration.StatusCompleted,
+		registration.StatusCompleted,
+		registration.StatusPending,
+	}
+	for i, status := range statuses {
+		s.DB.SeedRegistration(t, builders.NewRegistrationBuilder().
+			WithEmail(fmt.Sprintf("weekly-%d@example.com", i)).
+			WithStatus(status).
+			WithCreatedAt(inWeek).
+			Build())
+	}
+	s.DB.SeedRegistration(t, builders.NewRegistrationBuilder().
+		WithEmail("weekly-before@example.com").
+		WithStatus(registration.StatusCompleted).
+		WithCreatedAt(beforeWeek).
+		Build())
+
+	admin := s.SeedStaff(t, fixtures.TestStaff.Email)
+	newStaff := s.SeedStaff(t, fixtures.TestStaff2.Email)
+	s.setCreatedAt(t, admin.User().ID(), beforeWeek)
+	s.setCreatedAt(t, newStaff.User().ID(), inWeek)
+
+	groupID := s.SeedGroup(t)
+	expelled := s.SeedStudent(t, "weekly-expelled@example.com", groupID)
+	onLeave := s.SeedStudent(t, "weekly-leave@example.com", groupID)
+	s.setEnrollment(t, expelled.User().ID(), user.Expelled, inWeek)
+	s.setEnrollment(t, onLeave.User().ID(), user.AcademicLeave, beforeWeek)
+
+	handler := reportcmd.NewGenerateWeeklyReportHandler(reportcmd.GenerateWeeklyReportHandlerArgs{
+		ReportRepo: postgres.NewReportRepo(s.Pool(), nil),
+		MailSender: s.MockMailSender,
+	})
+
+	res, err := handler.Handle(t.Context(), reportcmd.GenerateWeeklyReport{})
+	require.NoError(t, err)
+	require.True(t, res.Generated)
+	assert.Equal(t, weekStart, res.Report.WeekStart)
+	assert.Equal(t, int64(3), res.Report.Registrations)
+	assert.Equal(t, int64(2), res.Report.CompletedRegistrations)
+	assert.InDelta(t, 2.0/3.0, res.Report.CompletionRate, 1e-9)
+	assert.Equal(t, int64(1), res.Report.NewStaff)
+	assert.Equal(t, int64(1), res.Report.SuspendedAccounts)
+	assert.Equal(t, 2, res.Mailed)
+	assert.Zero(t, res.Failed)
+
+	for _, email := range []string{fixtures.TestStaff.Email, fixtures.TestStaff2.Email} {
+		sent := s.MockMailSender.MailsTo(email)
+		require.Len(t, sent, 1, "one report mail per staff member")
+		assert.Contains(t, sent[0].Subject, reportcmd.WeeklySubjectPrefix)
+		assert.Contains(t, sent[0].HTML, "<table")
+		assert.Contains(t, sent[0].Body, "Completion rate: 66.7%")
+	}
+	assert.Empty(t, s.MockMailSender.MailsTo("weekly-expelled@example.com"))
+
+	t.Run("generated once", func(t *testing.T) {
+		res, err := handler.Handle(t.Context(), reportcmd.GenerateWeeklyReport{})
+		require.NoError(t, err)
+		assert.False(t, res.Generated)
+		assert.Zero(t, res.Mailed)
+		assert.Len(t, s.MockMailSender.MailsTo(fixtures.TestStaff.Email), 1)
+	})
+
+	t.Run("ops endpoint", func(t *testing.T) {
+		asAdmin := httpframework.WithStaff(t, admin.User().ID())
+
+		var body struct {
+			Report report.Weekly `json:"report"`
+		}
+		s.HTTP.GetWeeklyReport(t, inWeek, asAdmin).RequireStatus(http.StatusOK).RequireParseJSON(&body)
+		assert.Equal(t, int64(3), body.Report.Registrations)
+		assert.Equal(t, int64(1), body.Report.SuspendedAccounts)
+
+		s.HTTP.GetWeeklyReport(t, time.Time{}, asAdmin).RequireStatus(http.StatusOK)
+		s.HTTP.GetWeeklyReport(t, beforeWeek, asAdmin).AssertStatus(http.StatusNotFound)
+		student := s.SeedStudent(t, "weekly-active@example.com", groupID)
+		s.HTTP.GetWeeklyReport(t, inWeek, httpframework.WithStudent(t, student.User().ID())).
+			AssertStatus(http.StatusForbidden)
+	})
+}
+
+func (s *WeeklyReportSuite) setCreatedAt(t *testing.T, id user.ID, at time.Time) {
+	t.Helper()
+	s.DB.Exec(t, `UPDATE users SET created_at = $1 WHERE id = $2`, at, uuid.UUID(id))
+}
+
+func (s *WeeklyReportSuite) setEnrollment(t *testing.T, id user.ID, status user.EnrollmentStatus, at time.Time) {
+	t.Helper()
+	s.DB.Exec(t, `UPDATE students SET enrollment_status = $1, enrollment_changed_at = $2 WHERE user_id = $3`,
+		status.String(), at, uuid.UUID(id))
+}
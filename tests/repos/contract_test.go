@@ -0,0 +1,58 @@
+package repos
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/adapters/repos/postgres"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/framework"
+	"gitlab.com/ucmsv2/ucms-backend/tests/repotest"
+)
+
+// ContractSuite runs the contracts of tests/repotest against the postgres
+// repositories, the in-memory ones of tests/mocks run them in their unit
+// tests.
+type ContractSuite struct {
+	framework.IntegrationTestSuite
+}
+
+func TestContractSuite(t *testing.T) {
+	suite.Run(t, new(ContractSuite))
+}
+
+func (s *ContractSuite) TestUserRepo() {
+	repotest.RunUserRepoContract(s.T(), func(*testing.T) repotest.UserRepo {
+		return postgres.NewUserRepo(s.Pool(), nil, nil)
+	})
+}
+
+func (s *ContractSuite) TestGroupRepo() {
+	repotest.RunGroupRepoContract(s.T(), func(*testing.T) repotest.GroupRepo {
+		return postgres.NewGroupRepo(s.Pool(), nil, nil)
+	})
+}
+
+func (s *ContractSuite) TestStaffRepo() {
+	repotest.RunStaffRepoContract(s.T(), func(*testing.T) repotest.StaffRepo {
+		return postgres.NewStaffRepo(s.Pool(), nil, nil)
+	})
+}
+
+func (s *ContractSuite) TestStaffInvitationRepo() {
+	repotest.RunStaffInvitationRepoContract(s.T(), func(*testing.T) repotest.StaffInvitationRepos {
+		return repotest.StaffInvitationRepos{
+			Staff:       postgres.NewStaffRepo(s.Pool(), nil, nil),
+			Invitations: postgres.NewStaffInvitationRepo(s.Pool(), nil, nil),
+		}
+	})
+}
+
+func (s *ContractSuite) TestNotificationRepo() {
+	repotest.RunNotificationRepoContract(s.T(), func(*testing.T) repotest.NotificationRepos {
+		return repotest.NotificationRepos{
+			Staff:         postgres.NewStaffRepo(s.Pool(), nil, nil),
+			Notifications: postgres.NewNotificationRepo(s.Pool(), nil, nil),
+		}
+	})
+}
@@ -0,0 +1,58 @@
+package repos
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata" // Europe/Berlin without the system zoneinfo
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/adapters/repos/postgres"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/builders"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/fixtures"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/framework"
+)
+
+// TimezoneSuite checks that the times saved in any zone are read back in
+// UTC, see postgres.UseUTC.
+type TimezoneSuite struct {
+	framework.IntegrationTestSuite
+}
+
+func TestTimezoneSuite(t *testing.T) {
+	suite.Run(t, new(TimezoneSuite))
+}
+
+func (s *TimezoneSuite) TestStaffInvitation_AcrossDST() {
+	t := s.T()
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+
+	creator := s.SeedStaff(t, fixtures.TestStaff.Email)
+	repo := postgres.NewStaffInvitationRepo(s.Pool(), nil, nil)
+
+	// The clocks of Berlin jump from 02:00 CET to 03:00 CEST on 2025-03-30,
+	// the two local times are a second apart.
+	beforeDST := time.Date(2025, 3, 30, 1, 59, 59, 0, berlin)
+	afterDST := time.Date(2025, 3, 30, 3, 0, 0, 0, berlin)
+	createdAt := time.Date(2025, 3, 1, 12, 0, 0, 0, berlin)
+	invitation := builders.NewStaffInvitationBuilder().
+		WithCreatorID(creator.User().ID()).
+		WithValidFrom(&beforeDST).
+		WithValidUntil(&afterDST).
+		WithCreatedAt(createdAt).
+		WithUpdatedAt(createdAt).
+		Build()
+	require.NoError(t, repo.SaveStaffInvitation(t.Context(), invitation))
+
+	got, err := repo.GetStaffInvitationByID(t.Context(), invitation.ID())
+	require.NoError(t, err)
+	require.NotNil(t, got.ValidFrom())
+	require.NotNil(t, got.ValidUntil())
+	assert.Equal(t, time.Date(2025, 3, 30, 0, 59, 59, 0, time.UTC), *got.ValidFrom())
+	assert.Equal(t, time.Date(2025, 3, 30, 1, 0, 0, 0, time.UTC), *got.ValidUntil())
+	assert.Equal(t, time.Second, got.ValidUntil().Sub(*got.ValidFrom()))
+	assert.Equal(t, time.Date(2025, 3, 1, 11, 0, 0, 0, time.UTC), got.CreatedAt())
+}
@@ -0,0 +1,54 @@
+package repos
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/adapters/repos/postgres"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/builders"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/framework"
+)
+
+// VersionSuite checks that the versions of the aggregates are saved with
+// their events and read back, see event.Recorder.
+type VersionSuite struct {
+	framework.IntegrationTestSuite
+}
+
+func TestVersionSuite(t *testing.T) {
+	suite.Run(t, new(VersionSuite))
+}
+
+func (s *VersionSuite) TestUser_CountsCommittedEvents() {
+	t := s.T()
+	repo := postgres.NewUserRepo(s.Pool(), nil, nil)
+	u := builders.NewUserBuilder().Build()
+	require.NoError(t, repo.SaveUser(t.Context(), u))
+
+	var versions []int
+	for _, key := range []string{"avatars/version-1", "avatars/version-2"} {
+		err := repo.UpdateUser(t.Context(), u.ID(), func(_ context.Context, u *user.User) error {
+			if err := u.SetAvatarFromS3(key); err != nil {
+				return err
+			}
+			versions = append(versions, u.GetUncommittedEvents()[0].GetEventHeader().AggregateVersion)
+			return nil
+		})
+		require.NoError(t, err)
+	}
+	assert.Equal(t, []int{1, 2}, versions, "the events are stamped with the versions following the stored one")
+
+	got, err := repo.GetUserByID(t.Context(), u.ID())
+	require.NoError(t, err)
+	assert.Equal(t, 2, got.Version())
+
+	var stored int
+	require.NoError(t, s.DB.QueryOne(t, "SELECT version FROM users WHERE id = $1", uuid.UUID(u.ID())).Scan(&stored))
+	assert.Equal(t, 2, stored)
+}
@@ -0,0 +1,127 @@
+package repotest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/group"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/majors"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/builders"
+)
+
+type GroupRepo interface {
+	SaveGroup(ctx context.Context, g *group.Group) error
+	GetGroupByID(ctx context.Context, id group.ID) (*group.Group, error)
+	UpdateGroup(ctx context.Context, id group.ID, fn func(context.Context, *group.Group) error) error
+}
+
+// RunGroupRepoContract checks the repository newRepo returns against the
+// behavior of the groups table.
+func RunGroupRepoContract(t *testing.T, newRepo func(t *testing.T) GroupRepo) {
+	t.Run("saved group is found by id", func(t *testing.T) {
+		repo := newRepo(t)
+		g := builders.NewGroupBuilder().WithID(group.NewID()).Build()
+		require.NoError(t, repo.SaveGroup(t.Context(), g))
+
+		got, err := repo.GetGroupByID(t.Context(), g.ID())
+		require.NoError(t, err)
+		assert.Equal(t, g.ID(), got.ID())
+		assert.Equal(t, g.Name(), got.Name())
+		assert.Equal(t, g.Major(), got.Major())
+		assert.Equal(t, g.Year(), got.Year())
+	})
+
+	t.Run("unknown group is not found", func(t *testing.T) {
+		repo := newRepo(t)
+
+		_, err := repo.GetGroupByID(t.Context(), group.NewID())
+		assertNotFound(t, err)
+	})
+
+	t.Run("id conflicts", func(t *testing.T) {
+		repo := newRepo(t)
+		g := builders.NewGroupBuilder().WithID(group.NewID()).Build()
+		require.NoError(t, repo.SaveGroup(t.Context(), g))
+
+		other := builders.NewGroupBuilder().WithID(g.ID()).WithName("other").Build()
+		assertDuplicateEntry(t, repo.SaveGroup(t.Context(), other))
+	})
+	t.Run("update saves the changes", func(t *testing.T) {
+		repo := newRepo(t)
+		g := builders.NewGroupBuilder().WithID(group.NewID()).Build()
+		require.NoError(t, repo.SaveGroup(t.Context(), g))
+
+		err := repo.UpdateGroup(t.Context(), g.ID(), func(_ context.Context, g *group.Group) error {
+			_, err := g.Update("SE-2502", "25", majors.SE)
+			return err
+		})
+		require.NoError(t, err)
+
+		got, err := repo.GetGroupByID(t.Context(), g.ID())
+		require.NoError(t, err)
+		assert.Equal(t, "SE-2502", got.Name())
+		assert.Equal(t, "25", got.Year())
+		assert.Equal(t, majors.SE, got.Major())
+	})
+
+	t.Run("failed update discards the changes", func(t *testing.T) {
+		repo := newRepo(t)
+		g := builders.NewGroupBuilder().WithID(group.NewID()).Build()
+		require.NoError(t, repo.SaveGroup(t.Context(), g))
+
+		errBoom := errors.New("boom")
+		err := repo.UpdateGroup(t.Context(), g.ID(), func(_ context.Context, g *group.Group) error {
+			_, err := g.Update("SE-2502", "25", majors.SE)
+			require.NoError(t, err)
+			return errBoom
+		})
+		require.ErrorIs(t, err, errBoom)
+
+		got, err := repo.GetGroupByID(t.Context(), g.ID())
+		require.NoError(t, err)
+		assert.Equal(t, g.Name(), got.Name())
+	})
+
+	t.Run("update saves the enrollment window", func(t *testing.T) {
+		repo := newRepo(t)
+		g := builders.NewGroupBuilder().WithID(group.NewID()).Build()
+		require.NoError(t, repo.SaveGroup(t.Context(), g))
+		now := time.Now().UTC().Truncate(time.Second)
+		opensAt, closesAt := now.Add(time.Hour), now.Add(48*time.Hour)
+
+		err := repo.UpdateGroup(t.Context(), g.ID(), func(_ context.Context, g *group.Group) error {
+			_, err := g.SetEnrollmentWindow(&opensAt, &closesAt, now)
+			return err
+		})
+		require.NoError(t, err)
+
+		got, err := repo.GetGroupByID(t.Context(), g.ID())
+		require.NoError(t, err)
+		require.NotNil(t, got.EnrollmentOpensAt())
+		require.NotNil(t, got.EnrollmentClosesAt())
+		assert.True(t, opensAt.Equal(*got.EnrollmentOpensAt()))
+		assert.True(t, closesAt.Equal(*got.EnrollmentClosesAt()))
+
+		err = repo.UpdateGroup(t.Context(), g.ID(), func(_ context.Context, g *group.Group) error {
+			_, err := g.SetEnrollmentWindow(nil, nil, now)
+			return err
+		})
+		require.NoError(t, err)
+		got, err = repo.GetGroupByID(t.Context(), g.ID())
+		require.NoError(t, err)
+		assert.Nil(t, got.EnrollmentOpensAt())
+		assert.Nil(t, got.EnrollmentClosesAt())
+	})
+
+	t.Run("update of an unknown group is not found", func(t *testing.T) {
+		repo := newRepo(t)
+
+		err := repo.UpdateGroup(t.Context(), group.NewID(), func(context.Context, *group.Group) error { return nil })
+		assertNotFound(t, err)
+	})
+}
@@ -0,0 +1,225 @@
+package repotest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/notification"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
+)
+
+type NotificationRepo interface {
+	SaveNotification(ctx context.Context, n *notification.Notification) (bool, error)
+	GetNotification(ctx context.Context, userID user.ID, id notification.ID) (*notification.Notification, error)
+	ListNotifications(ctx context.Context, userID user.ID, params notification.ListParams) ([]*notification.Notification, error)
+	ListNotificationsAfter(ctx context.Context, userID user.ID, after notification.ID, limit int) ([]*notification.Notification, error)
+	CountUnreadNotifications(ctx context.Context, userID user.ID) (int, error)
+	UpdateNotification(
+		ctx context.Context,
+		userID user.ID,
+		id notification.ID,
+		fn func(context.Context, *notification.Notification) error,
+	) error
+	MarkAllNotificationsRead(ctx context.Context, userID user.ID, readAt time.Time) (int, error)
+}
+
+// NotificationRepos are the repositories of the notification contract, the
+// recipients of the notifications are saved with Staff.
+type NotificationRepos struct {
+	Staff         StaffRepo
+	Notifications NotificationRepo
+}
+
+// RunNotificationRepoContract checks the repositories newRepos returns
+// against the behavior of the notifications table.
+func RunNotificationRepoContract(t *testing.T, newRepos func(t *testing.T) NotificationRepos) {
+	seedRecipient := func(t *testing.T, repos NotificationRepos) user.ID {
+		t.Helper()
+		recipient := newStaff()
+		require.NoError(t, repos.Staff.SaveStaff(t.Context(), recipient))
+		return recipient.User().ID()
+	}
+	newNotification := func(t *testing.T, userID user.ID, eventID uuid.UUID, createdAt time.Time) *notification.Notification {
+		t.Helper()
+		n, err := notification.New(notification.CreateArgs{
+			UserID:  userID,
+			EventID: eventID,
+			Type:    notification.TypeGroupChanged,
+			Title:   "You were moved to SE-2402",
+			Body:    "Your group is now SE-2402.",
+			Link:    "/profile",
+			Clock:   clock.NewFake(createdAt),
+		})
+		require.NoError(t, err)
+		return n
+	}
+	save := func(t *testing.T, repos NotificationRepos, n *notification.Notification) {
+		t.Helper()
+		saved, err := repos.Notifications.SaveNotification(t.Context(), n)
+		require.NoError(t, err)
+		require.True(t, saved)
+	}
+
+	t.Run("saved notification is listed", func(t *testing.T) {
+		repos := newRepos(t)
+		userID := seedRecipient(t, repos)
+		n := newNotification(t, userID, uuid.New(), now())
+		save(t, repos, n)
+
+		got, err := repos.Notifications.ListNotifications(t.Context(), userID, notification.ListParams{Limit: 10})
+		require.NoError(t, err)
+		require.Len(t, got, 1)
+		assert.Equal(t, n.ID(), got[0].ID())
+		assert.Equal(t, n.EventID(), got[0].EventID())
+		assert.Equal(t, n.Type(), got[0].Type())
+		assert.Equal(t, n.Title(), got[0].Title())
+		assert.Equal(t, n.Body(), got[0].Body())
+		assert.Equal(t, n.Link(), got[0].Link())
+		assert.Nil(t, got[0].ReadAt())
+		assert.True(t, n.CreatedAt().Equal(got[0].CreatedAt()))
+	})
+
+	t.Run("second notification of an event is ignored", func(t *testing.T) {
+		repos := newRepos(t)
+		userID := seedRecipient(t, repos)
+		eventID := uuid.New()
+		save(t, repos, newNotification(t, userID, eventID, now()))
+		saved, err := repos.Notifications.SaveNotification(t.Context(), newNotification(t, userID, eventID, now()))
+		require.NoError(t, err)
+		assert.False(t, saved)
+
+		count, err := repos.Notifications.CountUnreadNotifications(t.Context(), userID)
+		require.NoError(t, err)
+		assert.Equal(t, 1, count)
+	})
+
+	t.Run("list pages the newest first", func(t *testing.T) {
+		repos := newRepos(t)
+		userID := seedRecipient(t, repos)
+		start := now()
+		var saved []*notification.Notification
+		for i := range 3 {
+			n := newNotification(t, userID, uuid.New(), start.Add(time.Duration(i)*time.Minute))
+			save(t, repos, n)
+			saved = append(saved, n)
+		}
+		other := newNotification(t, seedRecipient(t, repos), uuid.New(), start)
+		save(t, repos, other)
+
+		first, err := repos.Notifications.ListNotifications(t.Context(), userID, notification.ListParams{Limit: 2})
+		require.NoError(t, err)
+		require.Len(t, first, 2)
+		assert.Equal(t, saved[2].ID(), first[0].ID())
+		assert.Equal(t, saved[1].ID(), first[1].ID())
+
+		second, err := repos.Notifications.ListNotifications(t.Context(), userID, notification.ListParams{Limit: 2, Offset: 2})
+		require.NoError(t, err)
+		require.Len(t, second, 1)
+		assert.Equal(t, saved[0].ID(), second[0].ID())
+	})
+
+	t.Run("read notification is left out of the unread", func(t *testing.T) {
+		repos := newRepos(t)
+		userID := seedRecipient(t, repos)
+		read := newNotification(t, userID, uuid.New(), now())
+		unread := newNotification(t, userID, uuid.New(), now())
+		save(t, repos, read)
+		save(t, repos, unread)
+
+		err := repos.Notifications.UpdateNotification(t.Context(), userID, read.ID(),
+			func(_ context.Context, n *notification.Notification) error {
+				n.MarkRead()
+				return nil
+			})
+		require.NoError(t, err)
+
+		got, err := repos.Notifications.ListNotifications(t.Context(), userID, notification.ListParams{UnreadOnly: true, Limit: 10})
+		require.NoError(t, err)
+		require.Len(t, got, 1)
+		assert.Equal(t, unread.ID(), got[0].ID())
+
+		count, err := repos.Notifications.CountUnreadNotifications(t.Context(), userID)
+		require.NoError(t, err)
+		assert.Equal(t, 1, count)
+	})
+
+	t.Run("notification of another user is not found", func(t *testing.T) {
+		repos := newRepos(t)
+		n := newNotification(t, seedRecipient(t, repos), uuid.New(), now())
+		save(t, repos, n)
+
+		otherID := seedRecipient(t, repos)
+		err := repos.Notifications.UpdateNotification(t.Context(), otherID, n.ID(),
+			func(context.Context, *notification.Notification) error { return nil })
+		assertNotFound(t, err)
+
+		_, err = repos.Notifications.GetNotification(t.Context(), otherID, n.ID())
+		assertNotFound(t, err)
+
+		got, err := repos.Notifications.GetNotification(t.Context(), n.UserID(), n.ID())
+		require.NoError(t, err)
+		assert.Equal(t, n.Title(), got.Title())
+	})
+
+	t.Run("list after a notification", func(t *testing.T) {
+		repos := newRepos(t)
+		userID := seedRecipient(t, repos)
+		start := now()
+		var saved []*notification.Notification
+		for i := range 4 {
+			n := newNotification(t, userID, uuid.New(), start.Add(time.Duration(i)*time.Minute))
+			save(t, repos, n)
+			saved = append(saved, n)
+		}
+
+		got, err := repos.Notifications.ListNotificationsAfter(t.Context(), userID, saved[1].ID(), 10)
+		require.NoError(t, err)
+		require.Len(t, got, 2)
+		assert.Equal(t, saved[2].ID(), got[0].ID(), "the oldest first")
+		assert.Equal(t, saved[3].ID(), got[1].ID())
+
+		got, err = repos.Notifications.ListNotificationsAfter(t.Context(), userID, saved[0].ID(), 1)
+		require.NoError(t, err)
+		require.Len(t, got, 1)
+		assert.Equal(t, saved[1].ID(), got[0].ID())
+
+		got, err = repos.Notifications.ListNotificationsAfter(t.Context(), userID, notification.NewID(), 10)
+		require.NoError(t, err)
+		assert.Empty(t, got, "unknown notification")
+
+		got, err = repos.Notifications.ListNotificationsAfter(t.Context(), seedRecipient(t, repos), saved[0].ID(), 10)
+		require.NoError(t, err)
+		assert.Empty(t, got, "notification of another user")
+	})
+
+	t.Run("mark all read", func(t *testing.T) {
+		repos := newRepos(t)
+		userID := seedRecipient(t, repos)
+		for range 2 {
+			save(t, repos, newNotification(t, userID, uuid.New(), now()))
+		}
+		otherID := seedRecipient(t, repos)
+		save(t, repos, newNotification(t, otherID, uuid.New(), now()))
+
+		readAt := now()
+		marked, err := repos.Notifications.MarkAllNotificationsRead(t.Context(), userID, readAt)
+		require.NoError(t, err)
+		assert.Equal(t, 2, marked)
+
+		got, err := repos.Notifications.ListNotifications(t.Context(), userID, notification.ListParams{Limit: 10})
+		require.NoError(t, err)
+		for _, n := range got {
+			assertSameTime(t, &readAt, n.ReadAt(), "read_at")
+		}
+
+		count, err := repos.Notifications.CountUnreadNotifications(t.Context(), otherID)
+		require.NoError(t, err)
+		assert.Equal(t, 1, count, "the notifications of the other users stay unread")
+	})
+}
@@ -0,0 +1,58 @@
+// Package repotest holds the contract tests of the repositories. Each
+// contract runs against the postgres repository in the integration suites
+// and against the in-memory one of tests/mocks in the unit tests, so that the
+// fakes the application tests use behave like the database.
+//
+// The contracts share the database of a suite between their subtests, every
+// subtest works with the users, groups and invitations it creates.
+package repotest
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+)
+
+// uniqueBarcode returns a barcode no other subtest uses, the builders pick
+// theirs among a thousand.
+func uniqueBarcode() user.Barcode {
+	return user.Barcode(strings.ToUpper(uuid.NewString()[:8]))
+}
+
+func uniqueUsername() string {
+	return "contract_" + uuid.NewString()[:12]
+}
+
+func uniqueEmail() string {
+	return uuid.NewString() + "@test.com"
+}
+
+// now returns the current time at the precision of the database.
+func now() time.Time {
+	return time.Now().UTC().Truncate(time.Microsecond)
+}
+
+func assertNotFound(t *testing.T, err error) {
+	t.Helper()
+	assert.True(t, errorx.IsNotFound(err), "expected a not found error, got %v", err)
+}
+
+func assertDuplicateEntry(t *testing.T, err error) {
+	t.Helper()
+	assert.True(t, errorx.IsDuplicateEntry(err), "expected a duplicate entry error, got %v", err)
+}
+
+func assertSameTime(t *testing.T, expected, actual *time.Time, field string) {
+	t.Helper()
+	if expected == nil || actual == nil {
+		assert.Equal(t, expected == nil, actual == nil, "unexpected %s: expected %v, got %v", field, expected, actual)
+		return
+	}
+	assert.True(t, expected.Equal(*actual), "unexpected %s: expected %v, got %v", field, *expected, *actual)
+}
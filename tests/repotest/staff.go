@@ -0,0 +1,100 @@
+package repotest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/emails"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/builders"
+)
+
+type StaffRepo interface {
+	HasAnyStaff(ctx context.Context) (bool, error)
+	SaveStaff(ctx context.Context, staff *user.Staff) error
+	GetStaffByID(ctx context.Context, id user.ID) (*user.Staff, error)
+	GetStaffByEmail(ctx context.Context, email emails.Email) (*user.Staff, error)
+	IsStaffExists(ctx context.Context, email emails.Email, username string, barcode user.Barcode) (bool, bool, bool, error)
+}
+
+func newStaff() *user.Staff {
+	return builders.NewStaffBuilder().
+		WithBarcode(uniqueBarcode()).
+		WithUsername(uniqueUsername()).
+		WithEmail(uniqueEmail()).
+		Build()
+}
+
+// RunStaffRepoContract checks the repository newRepo returns against the
+// behavior of the users and staffs tables.
+func RunStaffRepoContract(t *testing.T, newRepo func(t *testing.T) StaffRepo) {
+	t.Run("saved staff is found by id and email", func(t *testing.T) {
+		repo := newRepo(t)
+		staff := newStaff()
+		require.NoError(t, repo.SaveStaff(t.Context(), staff))
+
+		byID, err := repo.GetStaffByID(t.Context(), staff.User().ID())
+		require.NoError(t, err)
+		assertSameUser(t, staff.User(), byID.User())
+
+		byEmail, err := repo.GetStaffByEmail(t.Context(), staff.User().Email())
+		require.NoError(t, err)
+		assertSameUser(t, staff.User(), byEmail.User())
+
+		hasAny, err := repo.HasAnyStaff(t.Context())
+		require.NoError(t, err)
+		assert.True(t, hasAny)
+	})
+
+	t.Run("unknown staff is not found", func(t *testing.T) {
+		repo := newRepo(t)
+
+		_, err := repo.GetStaffByID(t.Context(), user.NewID())
+		assertNotFound(t, err)
+		_, err = repo.GetStaffByEmail(t.Context(), emails.Email(uniqueEmail()))
+		assertNotFound(t, err)
+	})
+
+	t.Run("unique columns conflict", func(t *testing.T) {
+		repo := newRepo(t)
+		existing := newStaff()
+		require.NoError(t, repo.SaveStaff(t.Context(), existing))
+
+		tests := map[string]*user.Staff{
+			"email": builders.NewStaffBuilder().WithEmail(existing.User().Email().String()).
+				WithBarcode(uniqueBarcode()).WithUsername(uniqueUsername()).Build(),
+			"username": builders.NewStaffBuilder().WithUsername(existing.User().Username()).
+				WithBarcode(uniqueBarcode()).WithEmail(uniqueEmail()).Build(),
+			"barcode": builders.NewStaffBuilder().WithBarcode(existing.User().Barcode()).
+				WithUsername(uniqueUsername()).WithEmail(uniqueEmail()).Build(),
+		}
+		for column, staff := range tests {
+			t.Run(column, func(t *testing.T) {
+				assertDuplicateEntry(t, repo.SaveStaff(t.Context(), staff))
+			})
+		}
+	})
+
+	t.Run("existence is reported per column", func(t *testing.T) {
+		repo := newRepo(t)
+		staff := newStaff()
+		require.NoError(t, repo.SaveStaff(t.Context(), staff))
+
+		emailExists, usernameExists, barcodeExists, err := repo.IsStaffExists(t.Context(),
+			staff.User().Email(), uniqueUsername(), staff.User().Barcode())
+		require.NoError(t, err)
+		assert.True(t, emailExists)
+		assert.False(t, usernameExists)
+		assert.True(t, barcodeExists)
+
+		emailExists, usernameExists, barcodeExists, err = repo.IsStaffExists(t.Context(),
+			emails.Email(uniqueEmail()), staff.User().Username(), uniqueBarcode())
+		require.NoError(t, err)
+		assert.False(t, emailExists)
+		assert.True(t, usernameExists)
+		assert.False(t, barcodeExists)
+	})
+}
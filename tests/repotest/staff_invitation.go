@@ -0,0 +1,227 @@
+package repotest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/staffinvitation"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/builders"
+)
+
+type StaffInvitationRepo interface {
+	SaveStaffInvitation(ctx context.Context, invitation *staffinvitation.StaffInvitation) error
+	UpdateStaffInvitation(ctx context.Context, id staffinvitation.ID, fn func(context.Context, *staffinvitation.StaffInvitation) error) error
+	GetStaffInvitationByID(ctx context.Context, id staffinvitation.ID) (*staffinvitation.StaffInvitation, error)
+	GetStaffInvitationByCode(ctx context.Context, code string) (*staffinvitation.StaffInvitation, error)
+	GetLatestStaffInvitationByCreatorID(ctx context.Context, creatorID user.ID) (*staffinvitation.StaffInvitation, error)
+}
+
+// StaffInvitationRepos are the repositories of the staff invitation
+// contract, the creators of the invitations are saved with Staff.
+type StaffInvitationRepos struct {
+	Staff       StaffRepo
+	Invitations StaffInvitationRepo
+}
+
+// RunStaffInvitationRepoContract checks the repositories newRepos returns
+// against the behavior of the staff_invitations table. The invitations are
+// soft-deleted, the repository keeps returning them with DeletedAt set and
+// leaves it to the domain to refuse them.
+func RunStaffInvitationRepoContract(t *testing.T, newRepos func(t *testing.T) StaffInvitationRepos) {
+	seedCreator := func(t *testing.T, repos StaffInvitationRepos) user.ID {
+		t.Helper()
+		creator := newStaff()
+		require.NoError(t, repos.Staff.SaveStaff(t.Context(), creator))
+		return creator.User().ID()
+	}
+	newInvitation := func(creatorID user.ID, createdAt time.Time) *staffinvitation.StaffInvitation {
+		validFrom := createdAt.Add(time.Hour)
+		return builders.NewStaffInvitationBuilder().
+			WithCreatorID(creatorID).
+			WithRecipientsEmail([]string{uniqueEmail()}).
+			WithValidFrom(&validFrom).
+			WithCreatedAt(createdAt).
+			WithUpdatedAt(createdAt).
+			Build()
+	}
+
+	t.Run("saved invitation is found by id and code", func(t *testing.T) {
+		repos := newRepos(t)
+		invitation := newInvitation(seedCreator(t, repos), now())
+		require.NoError(t, repos.Invitations.SaveStaffInvitation(t.Context(), invitation))
+
+		byID, err := repos.Invitations.GetStaffInvitationByID(t.Context(), invitation.ID())
+		require.NoError(t, err)
+		assertSameInvitation(t, invitation, byID)
+
+		byCode, err := repos.Invitations.GetStaffInvitationByCode(t.Context(), invitation.Code())
+		require.NoError(t, err)
+		assertSameInvitation(t, invitation, byCode)
+	})
+
+	t.Run("unknown invitation is not found", func(t *testing.T) {
+		repos := newRepos(t)
+
+		_, err := repos.Invitations.GetStaffInvitationByID(t.Context(), staffinvitation.NewID())
+		assertNotFound(t, err)
+		_, err = repos.Invitations.GetStaffInvitationByCode(t.Context(), "unknown-code")
+		assertNotFound(t, err)
+		_, err = repos.Invitations.GetLatestStaffInvitationByCreatorID(t.Context(), seedCreator(t, repos))
+		assertNotFound(t, err)
+
+		err = repos.Invitations.UpdateStaffInvitation(t.Context(), staffinvitation.NewID(),
+			func(context.Context, *staffinvitation.StaffInvitation) error { return nil })
+		assertNotFound(t, err)
+	})
+
+	t.Run("id and code conflict", func(t *testing.T) {
+		repos := newRepos(t)
+		creatorID := seedCreator(t, repos)
+		existing := newInvitation(creatorID, now())
+		require.NoError(t, repos.Invitations.SaveStaffInvitation(t.Context(), existing))
+
+		sameID := builders.NewStaffInvitationBuilder().WithID(existing.ID()).WithCreatorID(creatorID).Build()
+		assertDuplicateEntry(t, repos.Invitations.SaveStaffInvitation(t.Context(), sameID))
+
+		sameCode := builders.NewStaffInvitationBuilder().WithCode(existing.Code()).WithCreatorID(creatorID).Build()
+		assertDuplicateEntry(t, repos.Invitations.SaveStaffInvitation(t.Context(), sameCode))
+	})
+
+	t.Run("latest invitation of the creator is the last created", func(t *testing.T) {
+		repos := newRepos(t)
+		creatorID := seedCreator(t, repos)
+		otherCreatorID := seedCreator(t, repos)
+		base := now()
+
+		latest := newInvitation(creatorID, base)
+		for _, invitation := range []*staffinvitation.StaffInvitation{
+			newInvitation(creatorID, base.Add(-2*time.Hour)),
+			latest,
+			newInvitation(creatorID, base.Add(-time.Hour)),
+			newInvitation(otherCreatorID, base.Add(time.Hour)),
+		} {
+			require.NoError(t, repos.Invitations.SaveStaffInvitation(t.Context(), invitation))
+		}
+
+		got, err := repos.Invitations.GetLatestStaffInvitationByCreatorID(t.Context(), creatorID)
+		require.NoError(t, err)
+		assert.Equal(t, latest.ID(), got.ID())
+	})
+
+	t.Run("deleted invitation stays visible", func(t *testing.T) {
+		repos := newRepos(t)
+		creatorID := seedCreator(t, repos)
+		invitation := newInvitation(creatorID, now())
+		require.NoError(t, repos.Invitations.SaveStaffInvitation(t.Context(), invitation))
+
+		err := repos.Invitations.UpdateStaffInvitation(t.Context(), invitation.ID(),
+			func(_ context.Context, si *staffinvitation.StaffInvitation) error {
+				return si.MarkDeleted(creatorID)
+			})
+		require.NoError(t, err)
+
+		byID, err := repos.Invitations.GetStaffInvitationByID(t.Context(), invitation.ID())
+		require.NoError(t, err)
+		assert.NotNil(t, byID.DeletedAt())
+
+		byCode, err := repos.Invitations.GetStaffInvitationByCode(t.Context(), invitation.Code())
+		require.NoError(t, err)
+		assert.NotNil(t, byCode.DeletedAt())
+
+		latest, err := repos.Invitations.GetLatestStaffInvitationByCreatorID(t.Context(), creatorID)
+		require.NoError(t, err)
+		assert.Equal(t, invitation.ID(), latest.ID())
+		assert.NotNil(t, latest.DeletedAt())
+	})
+
+	t.Run("update saves the changes", func(t *testing.T) {
+		repos := newRepos(t)
+		creatorID := seedCreator(t, repos)
+		invitation := newInvitation(creatorID, now())
+		require.NoError(t, repos.Invitations.SaveStaffInvitation(t.Context(), invitation))
+
+		recipients := []string{uniqueEmail(), uniqueEmail()}
+		err := repos.Invitations.UpdateStaffInvitation(t.Context(), invitation.ID(),
+			func(_ context.Context, si *staffinvitation.StaffInvitation) error {
+				return si.UpdateRecipients(creatorID, recipients)
+			})
+		require.NoError(t, err)
+
+		updated, err := repos.Invitations.GetStaffInvitationByID(t.Context(), invitation.ID())
+		require.NoError(t, err)
+		assert.Equal(t, recipients, updated.RecipientsEmail())
+	})
+
+	t.Run("failed update discards the changes", func(t *testing.T) {
+		repos := newRepos(t)
+		creatorID := seedCreator(t, repos)
+		invitation := newInvitation(creatorID, now())
+		require.NoError(t, repos.Invitations.SaveStaffInvitation(t.Context(), invitation))
+
+		errBoom := errors.New("boom")
+		err := repos.Invitations.UpdateStaffInvitation(t.Context(), invitation.ID(),
+			func(_ context.Context, si *staffinvitation.StaffInvitation) error {
+				require.NoError(t, si.MarkDeleted(creatorID))
+				return errBoom
+			})
+		require.ErrorIs(t, err, errBoom)
+
+		got, err := repos.Invitations.GetStaffInvitationByID(t.Context(), invitation.ID())
+		require.NoError(t, err)
+		assert.Nil(t, got.DeletedAt(), "the deletion of a failed update should not be saved")
+	})
+
+	t.Run("persistable error saves the changes", func(t *testing.T) {
+		repos := newRepos(t)
+		creatorID := seedCreator(t, repos)
+		invitation := newInvitation(creatorID, now())
+		require.NoError(t, repos.Invitations.SaveStaffInvitation(t.Context(), invitation))
+
+		errBoom := errors.New("boom")
+		err := repos.Invitations.UpdateStaffInvitation(t.Context(), invitation.ID(),
+			func(_ context.Context, si *staffinvitation.StaffInvitation) error {
+				require.NoError(t, si.MarkDeleted(creatorID))
+				return errorx.NewPersistable(errBoom)
+			})
+		require.ErrorIs(t, err, errBoom)
+
+		got, err := repos.Invitations.GetStaffInvitationByID(t.Context(), invitation.ID())
+		require.NoError(t, err)
+		assert.NotNil(t, got.DeletedAt())
+	})
+
+	t.Run("changes outside an update are not saved", func(t *testing.T) {
+		repos := newRepos(t)
+		creatorID := seedCreator(t, repos)
+		invitation := newInvitation(creatorID, now())
+		require.NoError(t, repos.Invitations.SaveStaffInvitation(t.Context(), invitation))
+
+		loaded, err := repos.Invitations.GetStaffInvitationByID(t.Context(), invitation.ID())
+		require.NoError(t, err)
+		require.NoError(t, loaded.MarkDeleted(creatorID))
+
+		got, err := repos.Invitations.GetStaffInvitationByID(t.Context(), invitation.ID())
+		require.NoError(t, err)
+		assert.Nil(t, got.DeletedAt())
+	})
+}
+
+func assertSameInvitation(t *testing.T, expected, actual *staffinvitation.StaffInvitation) {
+	t.Helper()
+	assert.Equal(t, expected.ID(), actual.ID())
+	assert.Equal(t, expected.Code(), actual.Code())
+	assert.Equal(t, expected.CreatorID(), actual.CreatorID())
+	assert.Equal(t, expected.RecipientsEmail(), actual.RecipientsEmail())
+	assertSameTime(t, expected.ValidFrom(), actual.ValidFrom(), "valid from")
+	assertSameTime(t, expected.ValidUntil(), actual.ValidUntil(), "valid until")
+	createdAt, actualCreatedAt := expected.CreatedAt(), actual.CreatedAt()
+	assertSameTime(t, &createdAt, &actualCreatedAt, "created at")
+	assert.Nil(t, actual.DeletedAt())
+}
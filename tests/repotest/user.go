@@ -0,0 +1,177 @@
+package repotest
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/emails"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/builders"
+)
+
+type UserRepo interface {
+	SaveUser(ctx context.Context, u *user.User) error
+	UpdateUser(ctx context.Context, id user.ID, fn func(context.Context, *user.User) error) error
+	GetUserByID(ctx context.Context, id user.ID) (*user.User, error)
+	GetUserByEmail(ctx context.Context, email emails.Email) (*user.User, error)
+	GetUserByBarcode(ctx context.Context, barcode user.Barcode) (*user.User, error)
+	IsUserExists(ctx context.Context, email emails.Email, username string, barcode user.Barcode) (bool, bool, bool, error)
+}
+
+func newUser() *user.User {
+	return builders.NewUserBuilder().
+		WithBarcode(uniqueBarcode()).
+		WithUsername(uniqueUsername()).
+		WithEmail(uniqueEmail()).
+		WithEmptyAvatar().
+		Build()
+}
+
+// RunUserRepoContract checks the repository newRepo returns against the
+// behavior of the users table.
+func RunUserRepoContract(t *testing.T, newRepo func(t *testing.T) UserRepo) {
+	t.Run("saved user is found by id, email and barcode", func(t *testing.T) {
+		repo := newRepo(t)
+		u := newUser()
+		require.NoError(t, repo.SaveUser(t.Context(), u))
+
+		byID, err := repo.GetUserByID(t.Context(), u.ID())
+		require.NoError(t, err)
+		assertSameUser(t, u, byID)
+
+		byEmail, err := repo.GetUserByEmail(t.Context(), u.Email())
+		require.NoError(t, err)
+		assertSameUser(t, u, byEmail)
+
+		byBarcode, err := repo.GetUserByBarcode(t.Context(), u.Barcode())
+		require.NoError(t, err)
+		assertSameUser(t, u, byBarcode)
+	})
+
+	t.Run("unknown user is not found", func(t *testing.T) {
+		repo := newRepo(t)
+
+		_, err := repo.GetUserByID(t.Context(), user.NewID())
+		assertNotFound(t, err)
+		_, err = repo.GetUserByEmail(t.Context(), emails.Email(uniqueEmail()))
+		assertNotFound(t, err)
+		_, err = repo.GetUserByBarcode(t.Context(), uniqueBarcode())
+		assertNotFound(t, err)
+
+		err = repo.UpdateUser(t.Context(), user.NewID(), func(context.Context, *user.User) error { return nil })
+		assertNotFound(t, err)
+	})
+
+	t.Run("unique columns conflict", func(t *testing.T) {
+		repo := newRepo(t)
+		existing := newUser()
+		require.NoError(t, repo.SaveUser(t.Context(), existing))
+
+		tests := map[string]*user.User{
+			"id": builders.NewUserBuilder().WithID(existing.ID()).
+				WithBarcode(uniqueBarcode()).WithUsername(uniqueUsername()).WithEmail(uniqueEmail()).Build(),
+			"email": builders.NewUserBuilder().WithEmail(existing.Email().String()).
+				WithBarcode(uniqueBarcode()).WithUsername(uniqueUsername()).Build(),
+			"username": builders.NewUserBuilder().WithUsername(existing.Username()).
+				WithBarcode(uniqueBarcode()).WithEmail(uniqueEmail()).Build(),
+			"barcode": builders.NewUserBuilder().WithBarcode(existing.Barcode()).
+				WithUsername(uniqueUsername()).WithEmail(uniqueEmail()).Build(),
+		}
+		for column, u := range tests {
+			t.Run(column, func(t *testing.T) {
+				assertDuplicateEntry(t, repo.SaveUser(t.Context(), u))
+			})
+		}
+	})
+
+	t.Run("existence is reported per column", func(t *testing.T) {
+		repo := newRepo(t)
+		u := newUser()
+		require.NoError(t, repo.SaveUser(t.Context(), u))
+
+		emailExists, usernameExists, barcodeExists, err := repo.IsUserExists(t.Context(), u.Email(), uniqueUsername(), u.Barcode())
+		require.NoError(t, err)
+		assert.True(t, emailExists)
+		assert.False(t, usernameExists)
+		assert.True(t, barcodeExists)
+
+		emailExists, usernameExists, barcodeExists, err = repo.IsUserExists(t.Context(), emails.Email(uniqueEmail()), u.Username(), uniqueBarcode())
+		require.NoError(t, err)
+		assert.False(t, emailExists)
+		assert.True(t, usernameExists)
+		assert.False(t, barcodeExists)
+	})
+
+	t.Run("update saves the changes", func(t *testing.T) {
+		repo := newRepo(t)
+		u := newUser()
+		require.NoError(t, repo.SaveUser(t.Context(), u))
+
+		err := repo.UpdateUser(t.Context(), u.ID(), func(_ context.Context, u *user.User) error {
+			return u.SetAvatarFromS3("avatars/contract-update")
+		})
+		require.NoError(t, err)
+
+		updated, err := repo.GetUserByID(t.Context(), u.ID())
+		require.NoError(t, err)
+		assert.Equal(t, "avatars/contract-update", updated.Avatar().S3Key)
+	})
+
+	t.Run("failed update discards the changes", func(t *testing.T) {
+		repo := newRepo(t)
+		u := newUser()
+		require.NoError(t, repo.SaveUser(t.Context(), u))
+
+		errBoom := errors.New("boom")
+		err := repo.UpdateUser(t.Context(), u.ID(), func(_ context.Context, u *user.User) error {
+			require.NoError(t, u.SetAvatarFromS3("avatars/contract-failed"))
+			return errBoom
+		})
+		require.ErrorIs(t, err, errBoom)
+
+		updated, err := repo.GetUserByID(t.Context(), u.ID())
+		require.NoError(t, err)
+		assert.True(t, updated.Avatar().IsZero(), "the avatar of a failed update should not be saved")
+	})
+
+	t.Run("persistable error saves the changes", func(t *testing.T) {
+		repo := newRepo(t)
+		u := newUser()
+		require.NoError(t, repo.SaveUser(t.Context(), u))
+
+		errBoom := errors.New("boom")
+		err := repo.UpdateUser(t.Context(), u.ID(), func(_ context.Context, u *user.User) error {
+			require.NoError(t, u.SetAvatarFromS3("avatars/contract-persistable"))
+			return errorx.NewPersistable(errBoom)
+		})
+		require.ErrorIs(t, err, errBoom)
+
+		updated, err := repo.GetUserByID(t.Context(), u.ID())
+		require.NoError(t, err)
+		assert.Equal(t, "avatars/contract-persistable", updated.Avatar().S3Key)
+	})
+
+	t.Run("update without a function fails", func(t *testing.T) {
+		repo := newRepo(t)
+		u := newUser()
+		require.NoError(t, repo.SaveUser(t.Context(), u))
+
+		require.Error(t, repo.UpdateUser(t.Context(), u.ID(), nil))
+	})
+}
+
+func assertSameUser(t *testing.T, expected, actual *user.User) {
+	t.Helper()
+	assert.Equal(t, expected.ID(), actual.ID())
+	assert.Equal(t, expected.Email(), actual.Email())
+	assert.Equal(t, expected.Username(), actual.Username())
+	assert.Equal(t, expected.Barcode(), actual.Barcode())
+	assert.Equal(t, expected.FirstName(), actual.FirstName())
+	assert.Equal(t, expected.LastName(), actual.LastName())
+	assert.Equal(t, expected.Role(), actual.Role())
+}
@@ -3,15 +3,20 @@ package staff
 import (
 	"net/http"
 	"net/url"
+	"slices"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 
+	authapp "gitlab.com/ucmsv2/ucms-backend/internal/application/auth"
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/staffinvitation"
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
 	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/roles"
+	authhttp "gitlab.com/ucmsv2/ucms-backend/internal/ports/http/auth"
 	staffhttp "gitlab.com/ucmsv2/ucms-backend/internal/ports/http/staff"
 	"gitlab.com/ucmsv2/ucms-backend/tests/integration/builders"
 	"gitlab.com/ucmsv2/ucms-backend/tests/integration/fixtures"
@@ -193,7 +198,9 @@ func (s *AcceptInvitationTest) TestAccept_HappyPath() {
 		AssertPassword(t, fixtures.TestStaff2.Password).
 		AssertRole(t, roles.Staff)
 
-	e := event.RequireEvent(t, s.Event, &user.StaffInvitationAccepted{})
+	e := event.WaitFor(t, s.Event, 5*time.Second, func(e *user.StaffInvitationAccepted) bool {
+		return e.Email.String() == email
+	})
 	user.NewStaffInvitationAcceptedAssertion(t, e).
 		AssertStaffID(staffAssertion.Staff().User().ID()).
 		AssertStaffBarcode(fixtures.TestStaff2.Barcode).
@@ -202,6 +209,87 @@ func (s *AcceptInvitationTest) TestAccept_HappyPath() {
 		AssertLastName(fixtures.TestStaff2.LastName).
 		AssertInvitationID(uuid.UUID(invitation.ID())).
 		AssertEmail(email)
+	s.DB.RequireStaffInvitationExists(t, invitation.ID()).AssertRecipient(email, false)
+}
+
+func (s *AcceptInvitationTest) TestAccept_FailedWriteRollsBack() {
+	t := s.T()
+
+	staffUser := s.SeedStaff(t, fixtures.TestStaff.Email)
+	email := randomEmail()
+	invitation := builders.NewStaffInvitationBuilder().
+		WithCreatorID(staffUser.User().ID()).
+		WithAppendRecipientsEmail(email).
+		Build()
+	s.DB.SeedStaffInvitation(t, invitation)
+
+	token, err := staffhttp.SignInvitationJWTToken(
+		invitation.Code(),
+		email,
+		fixtures.InvitationTokenAlg,
+		fixtures.InvitationTokenKey,
+		fixtures.InvitationTokenExp,
+	)
+	require.NoError(t, err)
+
+	// The invitation is accepted first, the staff is saved second.
+	s.DB.FailInserts(t, "staffs")
+	s.HTTP.AcceptStaffInvitation(t, staffhttp.AcceptInvitationRequest{
+		Token:     token,
+		Barcode:   fixtures.TestStaff2.Barcode.String(),
+		Username:  fixtures.TestStaff2.Username,
+		Password:  fixtures.TestStaff2.Password,
+		FirstName: fixtures.TestStaff2.FirstName,
+		LastName:  fixtures.TestStaff2.LastName,
+	}).
+		AssertStatus(http.StatusInternalServerError)
+
+	s.DB.RequireStaffInvitationExists(t, invitation.ID()).AssertRecipient(email, true)
+	s.DB.RequireUserNotExists(t, email)
+}
+
+func (s *AcceptInvitationTest) TestAccept_PrefillsDepartmentAndPosition() {
+	t := s.T()
+
+	staffUser := s.SeedStaff(t, fixtures.TestStaff.Email)
+	email := randomEmail()
+	s.HTTP.CreateStaffInvitation(t,
+		staffhttp.CreateInvitationRequest{
+			Recipients: []string{email},
+			Department: "  Registrar's Office ",
+			Position:   "Coordinator",
+		},
+		httpframework.WithStaff(t, staffUser.User().ID()),
+	).RequireStatus(http.StatusCreated)
+
+	e := event.WaitFor(t, s.Event, 5*time.Second, func(e *staffinvitation.Created) bool {
+		return slices.Contains(e.RecipientsEmail, email)
+	})
+	assert.Equal(t, "Registrar's Office", e.Department)
+	assert.Equal(t, "Coordinator", e.Position)
+
+	token, err := staffhttp.SignInvitationJWTToken(
+		e.Code,
+		email,
+		fixtures.InvitationTokenAlg,
+		fixtures.InvitationTokenKey,
+		fixtures.InvitationTokenExp,
+	)
+	require.NoError(t, err)
+
+	s.HTTP.AcceptStaffInvitation(t, staffhttp.AcceptInvitationRequest{
+		Token:     token,
+		Barcode:   fixtures.TestStaff2.Barcode.String(),
+		Username:  fixtures.TestStaff2.Username,
+		Password:  fixtures.TestStaff2.Password,
+		FirstName: fixtures.TestStaff2.FirstName,
+		LastName:  fixtures.TestStaff2.LastName,
+	}).
+		RequireStatus(http.StatusCreated)
+
+	s.DB.RequireStaffExistsByEmail(t, email).
+		AssertDepartment(t, "Registrar's Office").
+		AssertPosition(t, "Coordinator")
 }
 
 func (s *AcceptInvitationTest) TestAccept_FailPath() {
@@ -331,6 +419,32 @@ func (s *AcceptInvitationTest) TestAccept_FailPath() {
 			expectedStatus: http.StatusBadRequest,
 			expectedMsg:    "cannot be blank",
 		},
+		{
+			name: "mixed-script last name",
+			req: staffhttp.AcceptInvitationRequest{
+				Token:     validToken,
+				Barcode:   fixtures.TestStaff2.Barcode.String(),
+				Username:  fixtures.TestStaff2.Username,
+				Password:  fixtures.TestStaff2.Password,
+				FirstName: fixtures.TestStaff2.FirstName,
+				LastName:  "Smіth", // Cyrillic і
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedMsg:    "must be written in plain Latin, Cyrillic or CJK letters",
+		},
+		{
+			name: "homograph username",
+			req: staffhttp.AcceptInvitationRequest{
+				Token:     validToken,
+				Barcode:   fixtures.TestStaff2.Barcode.String(),
+				Username:  "аdmin", // Cyrillic а
+				Password:  fixtures.TestStaff2.Password,
+				FirstName: fixtures.TestStaff2.FirstName,
+				LastName:  fixtures.TestStaff2.LastName,
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedMsg:    "must be written in English letters",
+		},
 	}
 
 	for _, tt := range tests {
@@ -345,6 +459,89 @@ func (s *AcceptInvitationTest) TestAccept_FailPath() {
 	}
 }
 
+func (s *AcceptInvitationTest) TestAccept_LinkExistingAccount() {
+	t := s.T()
+
+	staffUser := s.SeedStaff(t, fixtures.TestStaff.Email)
+	groupID := s.SeedGroup(t)
+	email := randomEmail()
+	student := s.SeedStudent(t, email, groupID)
+	invitation := builders.NewStaffInvitationBuilder().
+		WithCreatorID(staffUser.User().ID()).
+		WithAppendRecipientsEmail(email).
+		Build()
+	s.DB.SeedStaffInvitation(t, invitation)
+
+	token, err := staffhttp.SignInvitationJWTToken(
+		invitation.Code(),
+		email,
+		fixtures.InvitationTokenAlg,
+		fixtures.InvitationTokenKey,
+		fixtures.InvitationTokenExp,
+	)
+	require.NoError(t, err)
+
+	s.HTTP.AcceptStaffInvitation(t, staffhttp.AcceptInvitationRequest{
+		Token:               token,
+		Password:            fixtures.TestStudent.Password,
+		LinkExistingAccount: true,
+	}).
+		RequireStatus(http.StatusCreated)
+
+	s.DB.RequireStaffExistsByEmail(t, email).
+		AssertBarcode(t, student.User().Barcode()).
+		AssertUsername(t, student.User().Username()).
+		AssertPassword(t, fixtures.TestStudent.Password).
+		AssertRole(t, roles.Staff)
+	s.DB.RequireStudentExistsByEmail(t, email).
+		AssertGroupID(t, groupID)
+	s.DB.RequireStaffInvitationExists(t, invitation.ID()).AssertRecipient(email, false)
+
+	e := event.WaitFor(t, s.Event, 5*time.Second, func(e *user.RoleGranted) bool {
+		return e.UserID == student.User().ID()
+	})
+	assert.Equal(t, roles.Staff, e.Role)
+	assert.Equal(t, roles.NewSet(roles.Student, roles.Staff), e.Roles)
+	assert.Equal(t, uuid.UUID(invitation.ID()), e.InvitationID)
+
+	resp := s.HTTP.Login(t, email, fixtures.TestStudent.Password).AssertSuccess()
+	authapp.NewJWTTokenAssertion(t, resp.GetCookie(authhttp.AccessJWTCookie).Value, []byte(fixtures.AccessTokenSecretKey)).
+		AssertUserRole(roles.Staff.String()).
+		AssertUserRoles(roles.Student.String(), roles.Staff.String())
+}
+
+func (s *AcceptInvitationTest) TestAccept_LinkWrongPassword() {
+	t := s.T()
+
+	staffUser := s.SeedStaff(t, fixtures.TestStaff.Email)
+	email := randomEmail()
+	s.SeedStudent(t, email, s.SeedGroup(t))
+	invitation := builders.NewStaffInvitationBuilder().
+		WithCreatorID(staffUser.User().ID()).
+		WithAppendRecipientsEmail(email).
+		Build()
+	s.DB.SeedStaffInvitation(t, invitation)
+
+	token, err := staffhttp.SignInvitationJWTToken(
+		invitation.Code(),
+		email,
+		fixtures.InvitationTokenAlg,
+		fixtures.InvitationTokenKey,
+		fixtures.InvitationTokenExp,
+	)
+	require.NoError(t, err)
+
+	s.HTTP.AcceptStaffInvitation(t, staffhttp.AcceptInvitationRequest{
+		Token:               token,
+		Password:            "wrong" + fixtures.TestStudent.Password,
+		LinkExistingAccount: true,
+	}).
+		AssertStatus(http.StatusUnauthorized)
+
+	s.DB.RequireStudentExistsByEmail(t, email).AssertRole(t, roles.Student)
+	s.DB.RequireStaffInvitationExists(t, invitation.ID()).AssertRecipient(email, true)
+}
+
 func AssertLocation(t *testing.T, resp *httpframework.Response, invitation *staffinvitation.StaffInvitation, email string) {
 	t.Helper()
 
@@ -0,0 +1,22 @@
+package staff
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/framework"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/framework/authmatrix"
+)
+
+type AuthzSuite struct {
+	framework.IntegrationTestSuite
+}
+
+func TestAuthzSuite(t *testing.T) {
+	suite.Run(t, new(AuthzSuite))
+}
+
+func (s *AuthzSuite) TestStaffAndInvitationRoutes() {
+	authmatrix.Run(s.T(), &s.IntegrationTestSuite, authmatrix.Routes("/v1/staffs", "/v1/invitations"))
+}
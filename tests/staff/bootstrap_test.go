@@ -0,0 +1,150 @@
+package staff
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"golang.org/x/crypto/bcrypt"
+
+	postgresrepo "gitlab.com/ucmsv2/ucms-backend/internal/adapters/repos/postgres"
+	"gitlab.com/ucmsv2/ucms-backend/internal/application/staff/cmd"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/builders"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/framework"
+)
+
+type BootstrapInitialStaffTest struct {
+	framework.IntegrationTestSuite
+	handler *cmd.BootstrapInitialStaffHandler
+}
+
+func TestBootstrapInitialStaff(t *testing.T) {
+	suite.Run(t, new(BootstrapInitialStaffTest))
+}
+
+func (s *BootstrapInitialStaffTest) SetupSuite() {
+	s.IntegrationTestSuite.SetupSuite()
+	s.handler = cmd.NewBootstrapInitialStaffHandler(cmd.BootstrapInitialStaffHandlerArgs{
+		InitialStaffRepo: postgresrepo.NewStaffRepo(s.Pool(), nil, nil),
+	})
+}
+
+func (s *BootstrapInitialStaffTest) TestConcurrentInstancesCreateOneStaff() {
+	t := s.T()
+	args := builders.NewStaffBuilder().WithEmail(randomEmail()).BuildCreateInitialStaffArgs()
+
+	var wg sync.WaitGroup
+	errs := make([]error, 2)
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = s.handler.Handle(t.Context(), cmd.BootstrapInitialStaff{
+				Staff:      args,
+				InstanceID: []string{"instance-1", "instance-2"}[i],
+			})
+		}()
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		require.NoError(t, err)
+	}
+	s.requireStaffCount(t, 1)
+	s.DB.RequireStaffExistsByEmail(t, args.Email.String())
+	assert.Equal(t, []string{"created"}, s.auditActions(t))
+}
+
+func (s *BootstrapInitialStaffTest) TestRestartWithoutRotation() {
+	t := s.T()
+	args := builders.NewStaffBuilder().WithEmail(randomEmail()).WithPassword("FirstP@ssw0rd").BuildCreateInitialStaffArgs()
+	require.NoError(t, s.handler.Handle(t.Context(), cmd.BootstrapInitialStaff{Staff: args}))
+	passHash := s.passHash(t, args.Email.String())
+
+	args.Password = "SecondP@ssw0rd"
+	require.NoError(t, s.handler.Handle(t.Context(), cmd.BootstrapInitialStaff{Staff: args}))
+
+	assert.Equal(t, passHash, s.passHash(t, args.Email.String()), "the password hash is untouched")
+	s.requireStaffCount(t, 1)
+	assert.Equal(t, []string{"created"}, s.auditActions(t))
+}
+
+func (s *BootstrapInitialStaffTest) TestRotatePassword() {
+	t := s.T()
+	args := builders.NewStaffBuilder().WithEmail(randomEmail()).WithPassword("FirstP@ssw0rd").BuildCreateInitialStaffArgs()
+	require.NoError(t, s.handler.Handle(t.Context(), cmd.BootstrapInitialStaff{Staff: args}))
+
+	args.Password = "SecondP@ssw0rd"
+	require.NoError(t, s.handler.Handle(t.Context(), cmd.BootstrapInitialStaff{Staff: args, RotatePassword: true}))
+
+	passHash := s.passHash(t, args.Email.String())
+	assert.NoError(t, bcrypt.CompareHashAndPassword(passHash, []byte("SecondP@ssw0rd")))
+	s.requireStaffCount(t, 1)
+	assert.Equal(t, []string{"created", "password_rotated"}, s.auditActions(t))
+
+	// rotating to the same password again changes nothing
+	require.NoError(t, s.handler.Handle(t.Context(), cmd.BootstrapInitialStaff{Staff: args, RotatePassword: true}))
+	assert.Equal(t, passHash, s.passHash(t, args.Email.String()))
+	assert.Equal(t, []string{"created", "password_rotated"}, s.auditActions(t))
+}
+
+func (s *BootstrapInitialStaffTest) TestRotatePassword_RejectsReuse() {
+	t := s.T()
+	args := builders.NewStaffBuilder().WithEmail(randomEmail()).WithPassword("FirstP@ssw0rd").BuildCreateInitialStaffArgs()
+	require.NoError(t, s.handler.Handle(t.Context(), cmd.BootstrapInitialStaff{Staff: args}))
+
+	args.Password = "SecondP@ssw0rd"
+	require.NoError(t, s.handler.Handle(t.Context(), cmd.BootstrapInitialStaff{Staff: args, RotatePassword: true}))
+	args.Password = "ThirdP@ssw0rd"
+	require.NoError(t, s.handler.Handle(t.Context(), cmd.BootstrapInitialStaff{Staff: args, RotatePassword: true}))
+	passHash := s.passHash(t, args.Email.String())
+
+	args.Password = "FirstP@ssw0rd"
+	err := s.handler.Handle(t.Context(), cmd.BootstrapInitialStaff{Staff: args, RotatePassword: true})
+	assert.True(t, errorx.IsCode(err, errorx.CodePasswordReused), "unexpected error: %v", err)
+	assert.Equal(t, passHash, s.passHash(t, args.Email.String()), "the password hash is untouched")
+	assert.Equal(t, []string{"created", "password_rotated", "password_rotated"}, s.auditActions(t))
+}
+
+func (s *BootstrapInitialStaffTest) TestSkipsWhenOtherStaffExist() {
+	t := s.T()
+	s.SeedStaff(t, randomEmail())
+	args := builders.NewStaffBuilder().WithEmail(randomEmail()).BuildCreateInitialStaffArgs()
+
+	require.NoError(t, s.handler.Handle(t.Context(), cmd.BootstrapInitialStaff{Staff: args}))
+
+	s.DB.RequireStaffNotExistsByEmail(t, args.Email.String())
+	assert.Empty(t, s.auditActions(t))
+}
+
+func (s *BootstrapInitialStaffTest) requireStaffCount(t *testing.T, expected int) {
+	t.Helper()
+	var count int
+	require.NoError(t, s.DB.QueryOne(t, `SELECT COUNT(*) FROM staffs`).Scan(&count))
+	require.Equal(t, expected, count)
+}
+
+func (s *BootstrapInitialStaffTest) passHash(t *testing.T, email string) []byte {
+	t.Helper()
+	var passHash []byte
+	require.NoError(t, s.DB.QueryOne(t, `SELECT pass_hash FROM users WHERE email = $1`, email).Scan(&passHash))
+	return passHash
+}
+
+func (s *BootstrapInitialStaffTest) auditActions(t *testing.T) []string {
+	t.Helper()
+	rows, closeRows := s.DB.Query(t, `SELECT action FROM staff_bootstrap_audit ORDER BY id`)
+	defer closeRows()
+
+	actions := []string{}
+	for rows.Next() {
+		var action string
+		require.NoError(t, rows.Scan(&action))
+		actions = append(actions, action)
+	}
+	require.NoError(t, rows.Err())
+	return actions
+}
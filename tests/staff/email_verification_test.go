@@ -0,0 +1,88 @@
+package staff
+
+import (
+	"net/http"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	postgresrepo "gitlab.com/ucmsv2/ucms-backend/internal/adapters/repos/postgres"
+	mailevent "gitlab.com/ucmsv2/ucms-backend/internal/application/mail/event"
+	"gitlab.com/ucmsv2/ucms-backend/internal/application/staff/cmd"
+	authhttp "gitlab.com/ucmsv2/ucms-backend/internal/ports/http/auth"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/builders"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/framework"
+	httpframework "gitlab.com/ucmsv2/ucms-backend/tests/integration/framework/http"
+)
+
+var verificationCodeRe = regexp.MustCompile(`code: (\w+)`)
+
+type EmailVerificationSuite struct {
+	framework.IntegrationTestSuite
+}
+
+func TestEmailVerificationSuite(t *testing.T) {
+	suite.Run(t, new(EmailVerificationSuite))
+}
+
+func (s *EmailVerificationSuite) TestInitialStaffVerifiesOnFirstLogin() {
+	t := s.T()
+	const password = "FirstP@ssw0rd"
+	args := builders.NewStaffBuilder().WithEmail(randomEmail()).WithPassword(password).BuildCreateInitialStaffArgs()
+	bootstrap := cmd.NewBootstrapInitialStaffHandler(cmd.BootstrapInitialStaffHandlerArgs{
+		InitialStaffRepo: postgresrepo.NewStaffRepo(s.Pool(), nil, nil),
+	})
+	require.NoError(t, bootstrap.Handle(t.Context(), cmd.BootstrapInitialStaff{Staff: args}))
+
+	login := s.HTTP.Login(t, args.Email.String(), password).RequireStatus(http.StatusOK)
+	access := login.GetCookie(authhttp.AccessJWTCookie)
+	require.NotNil(t, access)
+	asAdmin := httpframework.WithAccessTokenCookie(access.Value)
+
+	var gated struct {
+		Code errorx.Code `json:"code"`
+	}
+	s.HTTP.GetDashboard(t, asAdmin).
+		RequireStatus(http.StatusForbidden).
+		RequireParseJSON(&gated)
+	assert.Equal(t, errorx.CodeEmailVerificationRequired, gated.Code)
+
+	t.Run("the own profile stays readable", func(t *testing.T) {
+		s.HTTP.GetStaffProfile(t, asAdmin).RequireStatus(http.StatusOK)
+	})
+
+	mail := s.MockMailSender.EventuallyRequireMailSent(t, args.Email.String(), mailevent.EmailVerificationRequestedSubject)
+	match := verificationCodeRe.FindStringSubmatch(mail.Body)
+	require.Len(t, match, 2, "no code in %q", mail.Body)
+
+	t.Run("wrong code", func(t *testing.T) {
+		wrong := "ZZZZZ9"
+		if match[1] == wrong {
+			wrong = "ZZZZZ8"
+		}
+		s.HTTP.VerifyEmail(t, wrong, asAdmin).RequireStatus(http.StatusUnprocessableEntity)
+		s.HTTP.GetDashboard(t, asAdmin).RequireStatus(http.StatusForbidden)
+	})
+
+	s.HTTP.VerifyEmail(t, match[1], asAdmin).RequireStatus(http.StatusOK)
+	s.HTTP.GetDashboard(t, asAdmin).RequireStatus(http.StatusOK)
+
+	t.Run("verified once", func(t *testing.T) {
+		s.HTTP.VerifyEmail(t, match[1], asAdmin).RequireStatus(http.StatusConflict)
+
+		s.MockMailSender.ResetFor(args.Email.String())
+		s.HTTP.Login(t, args.Email.String(), password).RequireStatus(http.StatusOK)
+		assert.Empty(t, s.MockMailSender.MailsTo(args.Email.String()), "no code for a verified email")
+	})
+}
+
+func (s *EmailVerificationSuite) TestSeededStaffAreVerified() {
+	t := s.T()
+	staff := s.SeedStaff(t, randomEmail())
+
+	s.HTTP.GetDashboard(t, httpframework.WithStaff(t, staff.User().ID())).RequireStatus(http.StatusOK)
+}
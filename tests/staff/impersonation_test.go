@@ -0,0 +1,88 @@
+package staff
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	authapp "gitlab.com/ucmsv2/ucms-backend/internal/application/auth"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	authhttp "gitlab.com/ucmsv2/ucms-backend/internal/ports/http/auth"
+	"gitlab.com/ucmsv2/ucms-backend/internal/ports/http/middlewares"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/builders"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/fixtures"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/framework"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/framework/event"
+	httpframework "gitlab.com/ucmsv2/ucms-backend/tests/integration/framework/http"
+)
+
+type ImpersonationSuite struct {
+	framework.IntegrationTestSuite
+}
+
+func TestImpersonationSuite(t *testing.T) {
+	suite.Run(t, new(ImpersonationSuite))
+}
+
+func (s *ImpersonationSuite) TestImpersonate() {
+	t := s.T()
+
+	admin := s.SeedStaff(t, fixtures.TestStaff.Email)
+	asAdmin := httpframework.WithStaff(t, admin.User().ID())
+	student := builders.NewUserBuilder().
+		WithBarcode(fixtures.TestStudent.Barcode).
+		WithExternalAvatar().
+		Build()
+	s.DB.SeedUser(t, student)
+
+	res := s.HTTP.Impersonate(t, student.Barcode().String(), asAdmin).RequireStatus(http.StatusOK)
+	token := res.GetCookie(authhttp.AccessJWTCookie).Value
+	authapp.NewJWTTokenAssertion(t, token, []byte(fixtures.AccessTokenSecretKey)).
+		AssertValid().
+		AssertUID(student.ID().String()).
+		AssertUserRole(student.Role().String()).
+		AssertActor(admin.User().ID().String()).
+		AssertExp(time.Now().Add(user.ImpersonationTTL))
+	asStudent := httpframework.WithAccessTokenCookie(token)
+
+	s.HTTP.DeleteUserAvatar(t, asStudent).
+		RequireStatus(http.StatusOK).
+		AssertHeader(middlewares.HeaderImpersonating, admin.User().ID().String())
+
+	updated := event.WaitFor(t, s.Event, 5*time.Second, func(e *user.UserAvatarUpdated) bool {
+		return e.UserID == student.ID()
+	})
+	assert.Equal(t, uuid.UUID(student.ID()), updated.ActorID)
+	assert.Equal(t, uuid.UUID(admin.User().ID()), updated.ImpersonatorID, "the change is attributed to the admin")
+
+	var list struct {
+		Impersonations []authapp.ImpersonationResponse `json:"impersonations"`
+	}
+	s.HTTP.ListImpersonations(t, asAdmin).RequireStatus(http.StatusOK).RequireParseJSON(&list)
+	require.Len(t, list.Impersonations, 1)
+	assert.Equal(t, admin.User().ID().String(), list.Impersonations[0].ActorID)
+	assert.Equal(t, student.ID().String(), list.Impersonations[0].TargetID)
+
+	t.Run("the session can not impersonate or read the audit", func(t *testing.T) {
+		s.HTTP.ListImpersonations(t, asStudent).AssertStatus(http.StatusForbidden)
+	})
+
+	t.Run("admin target", func(t *testing.T) {
+		other := s.SeedStaff(t, fixtures.TestStaff2.Email)
+
+		s.HTTP.Impersonate(t, other.User().Barcode().String(), asAdmin).AssertStatus(http.StatusForbidden)
+
+		s.HTTP.ListImpersonations(t, asAdmin).RequireStatus(http.StatusOK).RequireParseJSON(&list)
+		assert.Len(t, list.Impersonations, 1, "a denied impersonation is not recorded")
+	})
+
+	t.Run("no impersonation header for the user", func(t *testing.T) {
+		res := s.HTTP.DeleteUserAvatar(t, httpframework.WithStudent(t, student.ID()))
+		assert.Empty(t, res.Header().Get(middlewares.HeaderImpersonating))
+	})
+}
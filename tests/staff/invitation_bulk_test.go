@@ -0,0 +1,199 @@
+package staff
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	mailevent "gitlab.com/ucmsv2/ucms-backend/internal/application/mail/event"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/staffinvitation"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	staffhttp "gitlab.com/ucmsv2/ucms-backend/internal/ports/http/staff"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/builders"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/fixtures"
+	httpframework "gitlab.com/ucmsv2/ucms-backend/tests/integration/framework/http"
+)
+
+type bulkCreateInvitationsResponse struct {
+	Invitations     []staffhttp.BulkInvitationResponse `json:"invitations"`
+	RecipientsCount int                                `json:"recipients_count"`
+}
+
+func bulkEmails(prefix string, n int) []string {
+	emails := make([]string, n)
+	for i := range emails {
+		emails[i] = fmt.Sprintf("%s%03d@test.com", prefix, i)
+	}
+	return emails
+}
+
+func (s *StaffInvitationSuite) invitationsCount(t *testing.T, creatorID user.ID) int {
+	t.Helper()
+	var n int
+	err := s.Pool().QueryRow(t.Context(),
+		"SELECT count(*) FROM staff_invitations WHERE creator_id = $1", creatorID).Scan(&n)
+	require.NoError(t, err)
+	return n
+}
+
+func (s *StaffInvitationSuite) TestBulkCreate_ChunksRecipients() {
+	t := s.T()
+	staffUser := s.SeedStaff(t, fixtures.TestStaff.Email)
+	recipients := bulkEmails("bulk", 230)
+	validUntil := time.Now().AddDate(0, 0, 7).Truncate(time.Second).UTC()
+
+	var res bulkCreateInvitationsResponse
+	s.HTTP.BulkCreateStaffInvitations(t,
+		staffhttp.BulkCreateInvitationsRequest{
+			Invitations: []staffhttp.BulkInvitationSpec{
+				{Recipients: recipients, Department: "Mathematics"},
+				// The recipients already listed are dropped.
+				{Recipients: []string{recipients[0], fixtures.ValidStaff2Email}, Department: "Physics", Position: "Assistant"},
+			},
+			ValidUntil: &validUntil,
+			Position:   "Lecturer",
+		},
+		httpframework.WithStaff(t, staffUser.User().ID()),
+	).RequireStatus(http.StatusCreated).RequireParseJSON(&res)
+
+	assert.Equal(t, 231, res.RecipientsCount)
+	// 230 recipients are 9 full invitations and one of 5.
+	require.Len(t, res.Invitations, 11)
+	for _, inv := range res.Invitations[:9] {
+		assert.Equal(t, 0, inv.Spec)
+		assert.Equal(t, staffinvitation.MaxEmails, inv.RecipientsCount)
+	}
+	assert.Equal(t, 5, res.Invitations[9].RecipientsCount)
+	assert.Equal(t, 1, res.Invitations[10].Spec)
+	s.DB.RequireStaffInvitationExists(t, res.Invitations[10].ID).
+		AssertRecipientsEmail([]string{fixtures.ValidStaff2Email}).
+		AssertValidUntil(&validUntil).
+		AssertCreatorID(staffUser.User().ID())
+	for _, inv := range res.Invitations[:10] {
+		s.DB.RequireStaffInvitationExists(t, inv.ID).AssertValidUntil(&validUntil)
+	}
+
+	require.Eventually(t, func() bool {
+		for _, email := range append(recipients, fixtures.ValidStaff2Email) {
+			if len(s.MockMailSender.MailsTo(email)) == 0 {
+				return false
+			}
+		}
+		return true
+	}, 30*time.Second, 100*time.Millisecond, "every recipient should be mailed")
+	for _, email := range recipients[:3] {
+		mails := s.MockMailSender.MailsTo(email)
+		require.Len(t, mails, 1, "a recipient of several specs should get one mail")
+		assert.Contains(t, mails[0].Subject, mailevent.StaffInvitationSubject)
+	}
+}
+
+func (s *StaffInvitationSuite) TestBulkCreate_PendingRecipientsLimit() {
+	t := s.T()
+	staffUser := s.SeedStaff(t, fixtures.TestStaff.Email)
+	s.DB.SeedStaffInvitation(t, builders.NewStaffInvitationBuilder().
+		WithCreatorID(staffUser.User().ID()).
+		WithRecipientsEmail(bulkEmails("pending", staffinvitation.MaxPendingRecipients-100)).
+		Build())
+	before := s.invitationsCount(t, staffUser.User().ID())
+
+	var exceeded struct {
+		Code errorx.Code `json:"code"`
+	}
+	s.HTTP.BulkCreateStaffInvitations(t,
+		staffhttp.BulkCreateInvitationsRequest{
+			Invitations: []staffhttp.BulkInvitationSpec{
+				{Recipients: bulkEmails("first", 60)},
+				{Recipients: bulkEmails("second", 41)},
+			},
+		},
+		httpframework.WithStaff(t, staffUser.User().ID()),
+	).RequireStatus(http.StatusUnprocessableEntity).RequireParseJSON(&exceeded)
+	assert.Equal(t, errorx.CodeBusinessRuleViolation, exceeded.Code)
+
+	assert.Equal(t, before, s.invitationsCount(t, staffUser.User().ID()), "no invitation of the bulk is created")
+
+	var res bulkCreateInvitationsResponse
+	s.HTTP.BulkCreateStaffInvitations(t,
+		staffhttp.BulkCreateInvitationsRequest{
+			Invitations: []staffhttp.BulkInvitationSpec{{Recipients: bulkEmails("first", 100)}},
+		},
+		httpframework.WithStaff(t, staffUser.User().ID()),
+	).RequireStatus(http.StatusCreated).RequireParseJSON(&res)
+	assert.Equal(t, 100, res.RecipientsCount, "the limit is inclusive")
+	assert.Equal(t, before+4, s.invitationsCount(t, staffUser.User().ID()))
+	assert.Empty(t, s.MockMailSender.MailsTo("second000@test.com"))
+}
+
+func (s *StaffInvitationSuite) TestBulkCreate_ConcurrentPendingRecipientsLimit() {
+	t := s.T()
+	staffUser := s.SeedStaff(t, fixtures.TestStaff.Email)
+	s.DB.SeedStaffInvitation(t, builders.NewStaffInvitationBuilder().
+		WithCreatorID(staffUser.User().ID()).
+		WithRecipientsEmail(bulkEmails("pending", staffinvitation.MaxPendingRecipients-100)).
+		Build())
+	before := s.invitationsCount(t, staffUser.User().ID())
+
+	// Each bulk fits the limit alone, any two of them exceed it.
+	var wg sync.WaitGroup
+	responses := make([]*httpframework.Response, 4)
+	for i := range responses {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			responses[i] = s.HTTP.BulkCreateStaffInvitations(t,
+				staffhttp.BulkCreateInvitationsRequest{
+					Invitations: []staffhttp.BulkInvitationSpec{{Recipients: bulkEmails(fmt.Sprintf("racer%d-", i), 60)}},
+				},
+				httpframework.WithStaff(t, staffUser.User().ID()),
+			)
+		}()
+	}
+	wg.Wait()
+
+	var created int
+	for _, resp := range responses {
+		if resp.Code == http.StatusCreated {
+			created++
+			continue
+		}
+		resp.AssertStatus(http.StatusUnprocessableEntity)
+	}
+	assert.Equal(t, 1, created, "the bulks of a creator are counted one after the other")
+	assert.Equal(t, before+3, s.invitationsCount(t, staffUser.User().ID()))
+}
+
+func (s *StaffInvitationSuite) TestBulkCreate_FailPath() {
+	t := s.T()
+	staffUser := s.SeedStaff(t, fixtures.TestStaff.Email)
+	student := s.SeedStudent(t, fixtures.TestStudent.Email, s.SeedGroup(t))
+
+	s.HTTP.BulkCreateStaffInvitations(t,
+		staffhttp.BulkCreateInvitationsRequest{
+			Invitations: []staffhttp.BulkInvitationSpec{{Recipients: []string{randomEmail()}}},
+		},
+		httpframework.WithStudent(t, student.User().ID()),
+	).AssertStatus(http.StatusForbidden)
+
+	s.HTTP.BulkCreateStaffInvitations(t,
+		staffhttp.BulkCreateInvitationsRequest{},
+		httpframework.WithStaff(t, staffUser.User().ID()),
+	).AssertStatus(http.StatusBadRequest)
+
+	s.HTTP.BulkCreateStaffInvitations(t,
+		staffhttp.BulkCreateInvitationsRequest{
+			Invitations: []staffhttp.BulkInvitationSpec{
+				{Recipients: []string{randomEmail()}},
+				{Recipients: []string{"not-an-email"}},
+			},
+		},
+		httpframework.WithStaff(t, staffUser.User().ID()),
+	).AssertStatus(http.StatusBadRequest)
+	assert.Zero(t, s.invitationsCount(t, staffUser.User().ID()))
+}
@@ -3,12 +3,14 @@ package staff
 import (
 	"fmt"
 	"net/http"
+	"slices"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 
 	mailevent "gitlab.com/ucmsv2/ucms-backend/internal/application/mail/event"
@@ -17,6 +19,7 @@ import (
 	"gitlab.com/ucmsv2/ucms-backend/tests/integration/builders"
 	"gitlab.com/ucmsv2/ucms-backend/tests/integration/fixtures"
 	"gitlab.com/ucmsv2/ucms-backend/tests/integration/framework"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/framework/event"
 	httpframework "gitlab.com/ucmsv2/ucms-backend/tests/integration/framework/http"
 )
 
@@ -43,11 +46,17 @@ func (s *StaffInvitationSuite) TestCreate_HappyPath() {
 			httpframework.WithStaff(t, staffUser.User().ID()),
 		).AssertStatus(http.StatusCreated)
 
+		e := event.WaitFor(t, s.Event, 5*time.Second, func(e *staffinvitation.Created) bool {
+			return slices.Contains(e.RecipientsEmail, fixtures.ValidStaff2Email)
+		})
+		assert.ElementsMatch(t, []string{fixtures.ValidStaff2Email, fixtures.ValidStaff3Email}, e.RecipientsEmail)
+		assert.Equal(t, staffUser.User().ID(), e.CreatorID)
+
 		s.MockMailSender.EventuallyRequireMailSent(t, fixtures.ValidStaff3Email, mailevent.StaffInvitationSubject)
-		mail := s.MockMailSender.EventuallyRequireMailSent(t, fixtures.ValidStaff2Email, mailevent.StaffInvitationSubject)
-		assert.Contains(t, mail.Body, "Please use the following link to accept the invitation:")
+		mail := s.MockMailSender.EventuallyRequireMailSentMatching(t, fixtures.ValidStaff2Email, mailevent.StaffInvitationSubject, acceptLinkText)
 
 		code := parseCodeFromMailBody(t, mail.Body)
+		assert.Equal(t, e.Code, code)
 
 		s.DB.RequireStaffInvitationExistsByCode(t, code).
 			AssertRecipientsEmail([]string{fixtures.ValidStaff2Email, fixtures.ValidStaff3Email}).
@@ -67,8 +76,8 @@ func (s *StaffInvitationSuite) TestCreate_HappyPath() {
 			httpframework.WithStaff(t, staffUser.User().ID()),
 		).AssertStatus(http.StatusCreated)
 
-		mail := s.MockMailSender.EventuallyRequireMailSent(t, email, mailevent.StaffInvitationSubject)
-		assert.Contains(t, mail.Body, "Please use the following link to accept the invitation:")
+		mail := s.MockMailSender.EventuallyRequireMailSentMatching(t, email, mailevent.StaffInvitationSubject, acceptLinkText)
+		assert.Len(t, s.MockMailSender.MailsTo(email), 1, "a duplicate recipient should get one mail")
 		code := parseCodeFromMailBody(t, mail.Body)
 		s.DB.RequireStaffInvitationExistsByCode(t, code).
 			AssertRecipientsEmail([]string{email}).
@@ -106,8 +115,7 @@ func (s *StaffInvitationSuite) TestCreate_HappyPath() {
 			httpframework.WithStaff(t, staffUser.User().ID()),
 		).AssertStatus(http.StatusCreated)
 
-		mail := s.MockMailSender.EventuallyRequireMailSent(t, fixtures.ValidStaff4Email, mailevent.StaffInvitationSubject)
-		assert.Contains(t, mail.Body, "Please use the following link to accept the invitation:")
+		mail := s.MockMailSender.EventuallyRequireMailSentMatching(t, fixtures.ValidStaff4Email, mailevent.StaffInvitationSubject, acceptLinkText)
 
 		code := parseCodeFromMailBody(t, mail.Body)
 
@@ -131,8 +139,7 @@ func (s *StaffInvitationSuite) TestCreate_HappyPath() {
 			httpframework.WithStaff(t, staffUser.User().ID()),
 		).AssertStatus(http.StatusCreated)
 
-		mail := s.MockMailSender.EventuallyRequireMailSent(t, email, mailevent.StaffInvitationSubject)
-		assert.Contains(t, mail.Body, "Please use the following link to accept the invitation:")
+		mail := s.MockMailSender.EventuallyRequireMailSentMatching(t, email, mailevent.StaffInvitationSubject, acceptLinkText)
 
 		code := parseCodeFromMailBody(t, mail.Body)
 		s.DB.RequireStaffInvitationExistsByCode(t, code).
@@ -154,8 +161,7 @@ func (s *StaffInvitationSuite) TestCreate_HappyPath() {
 			httpframework.WithStaff(t, staffUser.User().ID()),
 		).AssertStatus(http.StatusCreated)
 
-		mail := s.MockMailSender.EventuallyRequireMailSent(t, email, mailevent.StaffInvitationSubject)
-		assert.Contains(t, mail.Body, "Please use the following link to accept the invitation:")
+		mail := s.MockMailSender.EventuallyRequireMailSentMatching(t, email, mailevent.StaffInvitationSubject, acceptLinkText)
 		code := parseCodeFromMailBody(t, mail.Body)
 		s.DB.RequireStaffInvitationExistsByCode(t, code).
 			AssertRecipientsEmail([]string{email}).
@@ -176,8 +182,7 @@ func (s *StaffInvitationSuite) TestCreate_HappyPath() {
 			httpframework.WithStaff(t, staffUser.User().ID()),
 		).AssertStatus(http.StatusCreated)
 
-		mail := s.MockMailSender.EventuallyRequireMailSent(t, email, mailevent.StaffInvitationSubject)
-		assert.Contains(t, mail.Body, "Please use the following link to accept the invitation:")
+		mail := s.MockMailSender.EventuallyRequireMailSentMatching(t, email, mailevent.StaffInvitationSubject, acceptLinkText)
 		code := parseCodeFromMailBody(t, mail.Body)
 		s.DB.RequireStaffInvitationExistsByCode(t, code).
 			AssertRecipientsEmail([]string{email}).
@@ -187,12 +192,66 @@ func (s *StaffInvitationSuite) TestCreate_HappyPath() {
 	})
 }
 
+// TestCreate_TimesRoundTrip checks that the validity period reads back as it
+// was sent in the canonical form, and that the times sent with an offset are
+// stored and read back in UTC.
+func (s *StaffInvitationSuite) TestCreate_TimesRoundTrip() {
+	t := s.T()
+
+	staffUser := s.SeedStaff(t, fixtures.TestStaff.Email)
+	asStaff := httpframework.WithStaff(t, staffUser.User().ID())
+	validFrom := time.Now().AddDate(0, 0, 1).UTC().Truncate(time.Second)
+	validUntil := validFrom.AddDate(0, 0, 7)
+	almaty := time.FixedZone("", 5*60*60)
+
+	tests := []struct {
+		name       string
+		validFrom  string
+		validUntil string
+	}{
+		{
+			name:       "canonical",
+			validFrom:  validFrom.Format(time.RFC3339),
+			validUntil: validUntil.Format(time.RFC3339),
+		},
+		{
+			name:       "with offset and fraction",
+			validFrom:  validFrom.Add(250 * time.Millisecond).In(almaty).Format(time.RFC3339Nano),
+			validUntil: validUntil.In(almaty).Format(time.RFC3339),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			email := randomEmail()
+			s.HTTP.Anon().Post("/v1/staffs/invitations").WithJSON(map[string]any{
+				"recipients_email": []string{email},
+				"valid_from":       tt.validFrom,
+				"valid_until":      tt.validUntil,
+			}).With(asStaff).Do(t).RequireStatus(http.StatusCreated)
+
+			var res struct {
+				Results struct {
+					Invitations []struct {
+						ValidFrom  string `json:"valid_from"`
+						ValidUntil string `json:"valid_until"`
+						CreatedAt  string `json:"created_at"`
+					} `json:"invitations"`
+				} `json:"results"`
+			}
+			s.HTTP.Search(t, email, "invitations", asStaff).RequireStatus(http.StatusOK).RequireParseJSON(&res)
+			require.Len(t, res.Results.Invitations, 1)
+			hit := res.Results.Invitations[0]
+			assert.Equal(t, validFrom.Format(time.RFC3339), hit.ValidFrom)
+			assert.Equal(t, validUntil.Format(time.RFC3339), hit.ValidUntil)
+			assert.True(t, strings.HasSuffix(hit.CreatedAt, "Z"), "created_at %q is not in UTC", hit.CreatedAt)
+		})
+	}
+}
+
 func (s *StaffInvitationSuite) TestCreate_FailPath() {
 	t := s.T()
 
 	staffUser := s.SeedStaff(t, fixtures.TestStaff.Email)
-	groupID := s.SeedGroup(t)
-	studentUser := s.SeedStudent(t, fixtures.TestStudent.Email, groupID)
 	authOpts := []httpframework.RequestBuilderOptions{
 		httpframework.WithStaff(t, staffUser.User().ID()),
 	}
@@ -201,34 +260,10 @@ func (s *StaffInvitationSuite) TestCreate_FailPath() {
 		name    string
 		request staffhttp.CreateInvitationRequest
 		opts    []httpframework.RequestBuilderOptions
+		// advance moves the clock before the request.
+		advance time.Duration
 		assert  func(t *testing.T, resp *httpframework.Response)
 	}{
-		{
-			name: "unauthenticated",
-			request: staffhttp.CreateInvitationRequest{
-				Recipients: []string{fixtures.ValidStaff2Email},
-				ValidFrom:  nil,
-				ValidUntil: nil,
-			},
-			opts: []httpframework.RequestBuilderOptions{httpframework.WithAnon()},
-			assert: func(t *testing.T, resp *httpframework.Response) {
-				resp.AssertStatus(http.StatusUnauthorized)
-			},
-		},
-		{
-			name: "forbidden for non-staff user",
-			request: staffhttp.CreateInvitationRequest{
-				Recipients: []string{fixtures.ValidStaff2Email},
-				ValidFrom:  nil,
-				ValidUntil: nil,
-			},
-			opts: []httpframework.RequestBuilderOptions{
-				httpframework.WithStudent(t, studentUser.User().ID()),
-			},
-			assert: func(t *testing.T, resp *httpframework.Response) {
-				resp.AssertStatus(http.StatusForbidden)
-			},
-		},
 		{
 			name: "invalid email in recipients",
 			request: staffhttp.CreateInvitationRequest{
@@ -273,9 +308,10 @@ func (s *StaffInvitationSuite) TestCreate_FailPath() {
 			request: staffhttp.CreateInvitationRequest{
 				Recipients: []string{fixtures.ValidStaff2Email},
 				ValidFrom:  nil,
-				ValidUntil: ptrToTime(time.Now().Add(-1 * time.Hour).Truncate(time.Second).UTC()), // 1 hour ago
+				ValidUntil: ptrToTime(time.Now().Add(1 * time.Hour).Truncate(time.Second).UTC()), // 1 hour ago once advanced
 			},
-			opts: authOpts,
+			opts:    authOpts,
+			advance: 2 * time.Hour,
 			assert: func(t *testing.T, resp *httpframework.Response) {
 				resp.AssertStatus(http.StatusBadRequest).
 					AssertContainsMessage("valid_until time cannot be in the past")
@@ -425,6 +461,7 @@ func (s *StaffInvitationSuite) TestCreate_FailPath() {
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
+			s.advanceClock(t, tc.advance)
 			resp := s.HTTP.CreateStaffInvitation(t, tc.request, tc.opts...)
 			tc.assert(t, resp)
 		})
@@ -503,8 +540,6 @@ func (s *StaffInvitationSuite) TestUpdateRecipients_FailPath() {
 	t := s.T()
 
 	staffUser := s.SeedStaff(t, fixtures.TestStaff.Email)
-	groupID := s.SeedGroup(t)
-	studentUser := s.SeedStudent(t, fixtures.TestStudent.Email, groupID)
 	invitation := builders.NewStaffInvitationBuilder().
 		WithRecipientsEmail([]string{fixtures.ValidStaff2Email}).
 		WithCreatorID(staffUser.User().ID()).
@@ -518,30 +553,6 @@ func (s *StaffInvitationSuite) TestUpdateRecipients_FailPath() {
 		opts         []httpframework.RequestBuilderOptions
 		assert       func(t *testing.T, resp *httpframework.Response)
 	}{
-		{
-			name:         "unauthenticated",
-			invitationID: invitation.ID().String(),
-			request: staffhttp.UpdateInvitationRecipientsRequest{
-				Recipients: []string{fixtures.ValidStaff3Email},
-			},
-			opts: []httpframework.RequestBuilderOptions{httpframework.WithAnon()},
-			assert: func(t *testing.T, resp *httpframework.Response) {
-				resp.AssertStatus(http.StatusUnauthorized)
-			},
-		},
-		{
-			name:         "forbidden for non-staff user",
-			invitationID: invitation.ID().String(),
-			request: staffhttp.UpdateInvitationRecipientsRequest{
-				Recipients: []string{fixtures.ValidStaff3Email},
-			},
-			opts: []httpframework.RequestBuilderOptions{
-				httpframework.WithStudent(t, studentUser.User().ID()),
-			},
-			assert: func(t *testing.T, resp *httpframework.Response) {
-				resp.AssertStatus(http.StatusForbidden)
-			},
-		},
 		{
 			name:         "invitation not found",
 			invitationID: staffinvitation.NewID().String(),
@@ -709,8 +720,6 @@ func (s *StaffInvitationSuite) TestUpdateValidity_FailPath() {
 	t := s.T()
 
 	staffUser := s.SeedStaff(t, fixtures.TestStaff.Email)
-	groupID := s.SeedGroup(t)
-	studentUser := s.SeedStudent(t, fixtures.TestStudent.Email, groupID)
 
 	invitation := builders.NewStaffInvitationBuilder().
 		WithRecipientsEmail([]string{fixtures.ValidStaff2Email}).
@@ -723,34 +732,10 @@ func (s *StaffInvitationSuite) TestUpdateValidity_FailPath() {
 		invitationID string
 		request      staffhttp.UpdateInvitationValidityRequest
 		opts         []httpframework.RequestBuilderOptions
-		assert       func(t *testing.T, resp *httpframework.Response)
+		// advance moves the clock before the request.
+		advance time.Duration
+		assert  func(t *testing.T, resp *httpframework.Response)
 	}{
-		{
-			name:         "unauthenticated",
-			invitationID: invitation.ID().String(),
-			request: staffhttp.UpdateInvitationValidityRequest{
-				ValidFrom:  ptrToTime(time.Now().AddDate(0, 0, 1).Truncate(time.Second).UTC()),
-				ValidUntil: nil,
-			},
-			opts: []httpframework.RequestBuilderOptions{httpframework.WithAnon()},
-			assert: func(t *testing.T, resp *httpframework.Response) {
-				resp.AssertStatus(http.StatusUnauthorized)
-			},
-		},
-		{
-			name:         "forbidden for non-staff user",
-			invitationID: invitation.ID().String(),
-			request: staffhttp.UpdateInvitationValidityRequest{
-				ValidFrom:  ptrToTime(time.Now().AddDate(0, 0, 1).Truncate(time.Second).UTC()),
-				ValidUntil: nil,
-			},
-			opts: []httpframework.RequestBuilderOptions{
-				httpframework.WithStudent(t, studentUser.User().ID()),
-			},
-			assert: func(t *testing.T, resp *httpframework.Response) {
-				resp.AssertStatus(http.StatusForbidden)
-			},
-		},
 		{
 			name:         "invitation not found",
 			invitationID: staffinvitation.NewID().String(),
@@ -785,11 +770,12 @@ func (s *StaffInvitationSuite) TestUpdateValidity_FailPath() {
 			invitationID: invitation.ID().String(),
 			request: staffhttp.UpdateInvitationValidityRequest{
 				ValidFrom:  nil,
-				ValidUntil: ptrToTime(time.Now().Add(-1 * time.Hour).Truncate(time.Second).UTC()),
+				ValidUntil: ptrToTime(time.Now().Add(1 * time.Hour).Truncate(time.Second).UTC()),
 			},
 			opts: []httpframework.RequestBuilderOptions{
 				httpframework.WithStaff(t, staffUser.User().ID()),
 			},
+			advance: 2 * time.Hour,
 			assert: func(t *testing.T, resp *httpframework.Response) {
 				resp.AssertStatus(http.StatusBadRequest).
 					AssertContainsMessage("time cannot be in the past")
@@ -829,6 +815,7 @@ func (s *StaffInvitationSuite) TestUpdateValidity_FailPath() {
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
+			s.advanceClock(t, tc.advance)
 			resp := s.HTTP.UpdateStaffInvitationValidity(t, tc.invitationID, tc.request, tc.opts...)
 			tc.assert(t, resp)
 		})
@@ -851,6 +838,7 @@ func (s *StaffInvitationSuite) TestDeleteInvitation_HappyPath() {
 		).AssertStatus(http.StatusOK)
 
 		s.DB.RequireStaffInvitationExists(t, invitation.ID()).AssertDeleted(true)
+		event.WaitFor(t, s.Event, 5*time.Second, deletedFor(invitation.ID()))
 	})
 
 	t.Run("delete invitation with validity period", func(t *testing.T) {
@@ -869,6 +857,7 @@ func (s *StaffInvitationSuite) TestDeleteInvitation_HappyPath() {
 		).AssertStatus(http.StatusOK)
 
 		s.DB.RequireStaffInvitationExists(t, invitation.ID()).AssertDeleted(true)
+		event.WaitFor(t, s.Event, 5*time.Second, deletedFor(invitation.ID()))
 	})
 
 	t.Run("delete already deleted invitation", func(t *testing.T) {
@@ -883,6 +872,7 @@ func (s *StaffInvitationSuite) TestDeleteInvitation_HappyPath() {
 		).AssertStatus(http.StatusOK)
 
 		s.DB.RequireStaffInvitationExists(t, invitation.ID()).AssertDeleted(true)
+		event.RequireNoEvent(t, s.Event, deletedFor(invitation.ID()))
 	})
 }
 
@@ -890,8 +880,6 @@ func (s *StaffInvitationSuite) TestDeleteInvitation_FailPath() {
 	t := s.T()
 
 	staffUser := s.SeedStaff(t, fixtures.TestStaff.Email)
-	groupID := s.SeedGroup(t)
-	studentUser := s.SeedStudent(t, fixtures.TestStudent.Email, groupID)
 
 	invitation := builders.NewStaffInvitationBuilder().
 		WithRecipientsEmail([]string{fixtures.ValidStaff2Email}).
@@ -904,24 +892,6 @@ func (s *StaffInvitationSuite) TestDeleteInvitation_FailPath() {
 		opts         []httpframework.RequestBuilderOptions
 		assert       func(t *testing.T, resp *httpframework.Response)
 	}{
-		{
-			name:         "unauthenticated",
-			invitationID: invitation.ID().String(),
-			opts:         []httpframework.RequestBuilderOptions{httpframework.WithAnon()},
-			assert: func(t *testing.T, resp *httpframework.Response) {
-				resp.AssertStatus(http.StatusUnauthorized)
-			},
-		},
-		{
-			name:         "forbidden for non-staff user",
-			invitationID: invitation.ID().String(),
-			opts: []httpframework.RequestBuilderOptions{
-				httpframework.WithStudent(t, studentUser.User().ID()),
-			},
-			assert: func(t *testing.T, resp *httpframework.Response) {
-				resp.AssertStatus(http.StatusForbidden)
-			},
-		},
 		{
 			name:         "invitation not found",
 			invitationID: uuid.NewString(),
@@ -938,10 +908,14 @@ func (s *StaffInvitationSuite) TestDeleteInvitation_FailPath() {
 		t.Run(tc.name, func(t *testing.T) {
 			resp := s.HTTP.DeleteStaffInvitation(t, tc.invitationID, tc.opts...)
 			tc.assert(t, resp)
+			event.RequireNoEvent(t, s.Event, deletedFor(invitation.ID()))
 		})
 	}
 }
 
+// acceptLinkText introduces the accept link in the invitation mail.
+const acceptLinkText = "Please use the following link to accept the invitation:"
+
 func parseCodeFromMailBody(t *testing.T, body string) string {
 	t.Helper()
 	// Example body: "Please use the following link to accept the invitation: <URL>/<CODE>?email=..."
@@ -954,6 +928,19 @@ func parseCodeFromMailBody(t *testing.T, body string) string {
 	return code
 }
 
+func deletedFor(id staffinvitation.ID) func(*staffinvitation.Deleted) bool {
+	return func(e *staffinvitation.Deleted) bool { return e.StaffInvitationID == id }
+}
+
+// advanceClock moves the application clock by d for the rest of the subtest.
+func (s *StaffInvitationSuite) advanceClock(t *testing.T, d time.Duration) {
+	if d == 0 {
+		return
+	}
+	s.Clock.Advance(d)
+	t.Cleanup(s.Clock.Reset)
+}
+
 func ptrToTime(t time.Time) *time.Time {
 	return &t
 }
@@ -0,0 +1,124 @@
+package staff
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/application/staff/staffquery"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	staffhttp "gitlab.com/ucmsv2/ucms-backend/internal/ports/http/staff"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/httpx"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/fixtures"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/framework"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/framework/event"
+	httpframework "gitlab.com/ucmsv2/ucms-backend/tests/integration/framework/http"
+)
+
+type StaffProfileSuite struct {
+	framework.IntegrationTestSuite
+}
+
+func TestStaffProfileSuite(t *testing.T) {
+	suite.Run(t, new(StaffProfileSuite))
+}
+
+func (s *StaffProfileSuite) TestUpdateProfile_HappyPath() {
+	t := s.T()
+
+	staffUser := s.SeedStaff(t, fixtures.TestStaff.Email)
+	department, position := "Dean's Office", "Methodologist"
+
+	s.HTTP.UpdateStaffProfile(t,
+		staffhttp.UpdateProfileRequest{Department: httpx.SetTo(department), Position: httpx.SetTo(position)},
+		httpframework.WithStaff(t, staffUser.User().ID()),
+	).RequireStatus(http.StatusOK)
+
+	s.DB.RequireStaffExists(t, staffUser.User().ID()).
+		AssertDepartment(t, department).
+		AssertPosition(t, position)
+	e := event.WaitFor(t, s.Event, 5*time.Second, func(e *user.StaffDepartmentAndPositionUpdated) bool {
+		return e.StaffID == staffUser.User().ID()
+	})
+	assert.Equal(t, department, e.Department)
+
+	t.Run("a left out field is kept", func(t *testing.T) {
+		s.HTTP.UpdateStaffProfile(t,
+			staffhttp.UpdateProfileRequest{Position: httpx.SetTo("")},
+			httpframework.WithStaff(t, staffUser.User().ID()),
+		).RequireStatus(http.StatusOK)
+
+		s.DB.RequireStaffExists(t, staffUser.User().ID()).
+			AssertDepartment(t, department).
+			AssertPosition(t, "")
+	})
+
+	t.Run("the profile shows the department", func(t *testing.T) {
+		var res struct {
+			Staff staffquery.GetStaffResponse `json:"staff"`
+		}
+		s.HTTP.GetStaffProfile(t, httpframework.WithStaff(t, staffUser.User().ID())).
+			RequireStatus(http.StatusOK).
+			RequireParseJSON(&res)
+		assert.Equal(t, department, res.Staff.Department)
+		assert.Empty(t, res.Staff.Position)
+	})
+}
+
+func (s *StaffProfileSuite) TestUpdateProfile_MergePatch() {
+	t := s.T()
+
+	staffUser := s.SeedStaff(t, fixtures.TestStaff.Email)
+	asStaff := httpframework.WithStaff(t, staffUser.User().ID())
+	patch := func(t *testing.T, body string) *httpframework.Response {
+		return s.HTTP.Anon().Patch("/v1/staffs/me").
+			WithHeader("Content-Type", "application/json").
+			WithBody(strings.NewReader(body)).
+			With(asStaff).Do(t)
+	}
+	patch(t, `{"department":"Dean's Office","position":"Methodologist"}`).RequireStatus(http.StatusOK)
+
+	t.Run("an explicit null clears the field", func(t *testing.T) {
+		patch(t, `{"position":null}`).RequireStatus(http.StatusOK)
+
+		s.DB.RequireStaffExists(t, staffUser.User().ID()).
+			AssertDepartment(t, "Dean's Office").
+			AssertPosition(t, "")
+	})
+
+	t.Run("an empty patch records no event", func(t *testing.T) {
+		s.Event.Reset()
+		patch(t, `{}`).RequireStatus(http.StatusOK)
+		patch(t, `{"department":"Dean's Office"}`).RequireStatus(http.StatusOK)
+
+		event.RequireNoEvent(t, s.Event, func(e *user.StaffDepartmentAndPositionUpdated) bool {
+			return e.StaffID == staffUser.User().ID()
+		})
+	})
+
+	t.Run("unknown fields are rejected", func(t *testing.T) {
+		patch(t, `{"department":"Registrar's Office","role":"staff"}`).
+			AssertStatus(http.StatusBadRequest).
+			AssertContainsMessage("is not a known field")
+
+		s.DB.RequireStaffExists(t, staffUser.User().ID()).AssertDepartment(t, "Dean's Office")
+	})
+}
+
+func (s *StaffProfileSuite) TestUpdateProfile_FailPath() {
+	t := s.T()
+
+	staffUser := s.SeedStaff(t, fixtures.TestStaff.Email)
+
+	t.Run("department too long", func(t *testing.T) {
+		long := strings.Repeat("a", user.MaxDepartmentLen+1)
+		s.HTTP.UpdateStaffProfile(t,
+			staffhttp.UpdateProfileRequest{Department: httpx.SetTo(long)},
+			httpframework.WithStaff(t, staffUser.User().ID()),
+		).AssertStatus(http.StatusBadRequest)
+	})
+}
@@ -0,0 +1,264 @@
+package staff
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/base64"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/application/search/searchquery"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/group"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/majors"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/pagination"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/builders"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/fixtures"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/framework"
+	httpframework "gitlab.com/ucmsv2/ucms-backend/tests/integration/framework/http"
+)
+
+type SearchSuite struct {
+	framework.IntegrationTestSuite
+}
+
+func TestSearchSuite(t *testing.T) {
+	suite.Run(t, new(SearchSuite))
+}
+
+type searchResponse struct {
+	Results searchquery.SearchResponse `json:"results"`
+}
+
+func (s *SearchSuite) TestSearch_Buckets() {
+	t := s.T()
+	staff := s.SeedStaff(t, fixtures.TestStaff.Email)
+	asStaff := httpframework.WithStaff(t, staff.User().ID())
+
+	groupID := group.NewID()
+	s.DB.SeedGroup(t, groupID, "ORION-1", "2024", majors.SE)
+	s.DB.SeedGroup(t, group.NewID(), "ORION", "2024", majors.IT)
+	s.DB.SeedGroup(t, group.NewID(), "SE-2401", "2024", majors.SE)
+
+	byName := builders.NewStudentBuilder().WithGroupID(groupID).WithName("Orion", "Belt").
+		WithUsername("stargazer").WithEmail("stargazer@astanait.edu.kz").Build()
+	exact := builders.NewStudentBuilder().WithGroupID(groupID).WithName("Maria", "Lee").
+		WithUsername("orion").WithEmail("mlee@astanait.edu.kz").Build()
+	other := builders.NewStudentBuilder().WithGroupID(groupID).WithName("John", "Doe").
+		WithUsername("jdoe").WithEmail("jdoe@astanait.edu.kz").Build()
+	s.DB.SeedStudent(t, byName)
+	s.DB.SeedStudent(t, exact)
+	s.DB.SeedStudent(t, other)
+
+	s.DB.SeedStaffInvitation(t, builders.NewStaffInvitationBuilder().
+		WithCreatorID(staff.User().ID()).
+		WithCode("orion-invite").
+		WithRecipientsEmail([]string{"new.staff@astanait.edu.kz"}).
+		Build())
+	s.DB.SeedStaffInvitation(t, builders.NewStaffInvitationBuilder().
+		WithCreatorID(staff.User().ID()).
+		WithCode("unrelated").
+		Build())
+
+	var res searchResponse
+	s.HTTP.Search(t, "orion", "", asStaff).RequireStatus(http.StatusOK).RequireParseJSON(&res)
+	assert.False(t, res.Results.Partial)
+
+	require.Len(t, res.Results.Users, 2)
+	assert.Equal(t, exact.User().ID().String(), res.Results.Users[0].ID, "the exact username match comes first")
+	assert.Equal(t, byName.User().ID().String(), res.Results.Users[1].ID)
+
+	require.Len(t, res.Results.Groups, 2)
+	assert.Equal(t, "ORION", res.Results.Groups[0].Name, "the exact name match comes first")
+	assert.Equal(t, "ORION-1", res.Results.Groups[1].Name)
+
+	require.Len(t, res.Results.Invitations, 1)
+	assert.Equal(t, "orion-invite", res.Results.Invitations[0].Code)
+
+	var groupsOnly searchResponse
+	s.HTTP.Search(t, "orion", "groups", asStaff).RequireStatus(http.StatusOK).RequireParseJSON(&groupsOnly)
+	assert.Len(t, groupsOnly.Results.Groups, 2)
+	assert.Nil(t, groupsOnly.Results.Users)
+	assert.Nil(t, groupsOnly.Results.Invitations)
+
+	var byBarcode searchResponse
+	s.HTTP.Search(t, other.User().Barcode().String(), "users", asStaff).RequireStatus(http.StatusOK).RequireParseJSON(&byBarcode)
+	require.NotEmpty(t, byBarcode.Results.Users)
+	assert.Equal(t, other.User().ID().String(), byBarcode.Results.Users[0].ID)
+}
+
+func (s *SearchSuite) TestSearch_Limit() {
+	t := s.T()
+	staff := s.SeedStaff(t, fixtures.TestStaff.Email)
+	asStaff := httpframework.WithStaff(t, staff.User().ID())
+	for _, name := range []string{"LYRA-1", "LYRA-2", "LYRA-3"} {
+		s.DB.SeedGroup(t, group.NewID(), name, "2024", majors.SE)
+	}
+
+	var res searchResponse
+	s.HTTP.Search(t, "lyra", "groups", asStaff, httpframework.WithRequestQuery("limit", "2")).
+		RequireStatus(http.StatusOK).
+		RequireParseJSON(&res)
+	assert.Len(t, res.Results.Groups, 2)
+}
+
+func (s *SearchSuite) TestSearch_InvalidQuery() {
+	t := s.T()
+	staff := s.SeedStaff(t, fixtures.TestStaff.Email)
+	asStaff := httpframework.WithStaff(t, staff.User().ID())
+
+	s.HTTP.Search(t, "", "", asStaff).AssertStatus(http.StatusBadRequest)
+	s.HTTP.Search(t, "a", "", asStaff).AssertStatus(http.StatusBadRequest)
+	s.HTTP.Search(t, "anna", "users,courses", asStaff).AssertStatus(http.StatusBadRequest)
+}
+
+type listUsersResponse struct {
+	Users []searchquery.UserHit `json:"users"`
+	Meta  pagination.Meta       `json:"meta"`
+}
+
+// seedListedUsers inserts n students whose email contains marker, many of
+// them sharing a name so that the ids break the ties of the sort key.
+func (s *SearchSuite) seedListedUsers(t *testing.T, marker string, n int) {
+	t.Helper()
+	s.DB.Exec(t, `
+        INSERT INTO users (id, barcode, username, role_id, first_name, last_name,
+                           avatar_source, avatar_external, avatar_s3_key, email, pass_hash)
+        SELECT gen_random_uuid(), 'LST' || i, $1 || i, gr.id, 'First' || (i % 7), 'Last' || (i % 50),
+               '', '', '', $1 || i || '@astanait.edu.kz', '\x00'
+        FROM generate_series(1, $2::int) AS i, global_roles gr
+        WHERE gr.name = 'student';
+    `, marker, n)
+}
+
+func (s *SearchSuite) TestListUsers_CursorWalk() {
+	t := s.T()
+	staff := s.SeedStaff(t, fixtures.TestStaff.Email)
+	asStaff := httpframework.WithStaff(t, staff.User().ID())
+	const seeded = 2500
+	s.seedListedUsers(t, "walker", seeded)
+
+	seen := make(map[string]bool, seeded)
+	var (
+		cursor string
+		last   searchquery.UserHit
+		pages  int
+	)
+	for {
+		var res listUsersResponse
+		s.HTTP.ListUsers(t, "walker", cursor, asStaff, httpframework.WithRequestQuery("page_size", "100")).
+			RequireStatus(http.StatusOK).
+			RequireParseJSON(&res)
+		pages++
+		for _, u := range res.Users {
+			require.False(t, seen[u.ID], "user %s listed twice", u.ID)
+			seen[u.ID] = true
+			if last.ID != "" {
+				require.Negative(t, compareListed(last, u), "%v is listed before %v", last, u)
+			}
+			last = u
+		}
+		if res.Meta.NextCursor == "" {
+			break
+		}
+		require.Len(t, res.Users, 100, "only the last page is short")
+		cursor = res.Meta.NextCursor
+	}
+
+	assert.Len(t, seen, seeded, "every user is listed")
+	assert.Equal(t, seeded/100, pages)
+}
+
+// compareListed compares two users in the order of the listing.
+func compareListed(a, b searchquery.UserHit) int {
+	return cmp.Or(cmp.Compare(a.LastName, b.LastName), cmp.Compare(a.FirstName, b.FirstName), cmp.Compare(a.ID, b.ID))
+}
+
+func (s *SearchSuite) TestListUsers_OffsetMode() {
+	t := s.T()
+	staff := s.SeedStaff(t, fixtures.TestStaff.Email)
+	asStaff := httpframework.WithStaff(t, staff.User().ID())
+	s.seedListedUsers(t, "pager", 5)
+
+	var first, second listUsersResponse
+	s.HTTP.ListUsers(t, "pager", "", asStaff, httpframework.WithRequestQuery("page_size", "3")).
+		RequireStatus(http.StatusOK).
+		RequireParseJSON(&first)
+	s.HTTP.ListUsers(t, "pager", "", asStaff,
+		httpframework.WithRequestQuery("page_size", "3"), httpframework.WithRequestQuery("page", "2")).
+		RequireStatus(http.StatusOK).
+		RequireParseJSON(&second)
+
+	require.Len(t, first.Users, 3)
+	assert.Equal(t, 1, first.Meta.Page)
+	assert.NotEmpty(t, first.Meta.NextCursor, "an offset page offers the cursor of the next one")
+	require.Len(t, second.Users, 2)
+	assert.Equal(t, 2, second.Meta.Page)
+	assert.Empty(t, second.Meta.NextCursor)
+
+	var resumed listUsersResponse
+	s.HTTP.ListUsers(t, "pager", first.Meta.NextCursor, asStaff, httpframework.WithRequestQuery("page_size", "3")).
+		RequireStatus(http.StatusOK).
+		RequireParseJSON(&resumed)
+	assert.Equal(t, second.Users, resumed.Users, "the cursor resumes where the offset page ended")
+}
+
+func (s *SearchSuite) TestListUsers_InvalidCursor() {
+	t := s.T()
+	staff := s.SeedStaff(t, fixtures.TestStaff.Email)
+	asStaff := httpframework.WithStaff(t, staff.User().ID())
+	s.seedListedUsers(t, "tamper", 3)
+
+	var res listUsersResponse
+	s.HTTP.ListUsers(t, "tamper", "", asStaff, httpframework.WithRequestQuery("page_size", "1")).
+		RequireStatus(http.StatusOK).
+		RequireParseJSON(&res)
+	require.NotEmpty(t, res.Meta.NextCursor)
+
+	payload, sig, ok := strings.Cut(res.Meta.NextCursor, ".")
+	require.True(t, ok)
+	raw, err := base64.RawURLEncoding.DecodeString(payload)
+	require.NoError(t, err)
+	forged := bytes.Replace(raw, []byte("Last"), []byte("Zast"), 1)
+	tampered := base64.RawURLEncoding.EncodeToString(forged) + "." + sig
+
+	s.HTTP.ListUsers(t, "tamper", tampered, asStaff).AssertStatus(http.StatusBadRequest)
+	s.HTTP.ListUsers(t, "tamper", "garbage", asStaff).AssertStatus(http.StatusBadRequest)
+	s.HTTP.ListUsers(t, "tamper", res.Meta.NextCursor, asStaff, httpframework.WithRequestQuery("page", "2")).
+		AssertStatus(http.StatusBadRequest)
+}
+
+func (s *SearchSuite) TestListUsers_Filters() {
+	t := s.T()
+	staff := s.SeedStaff(t, fixtures.TestStaff.Email)
+	asStaff := httpframework.WithStaff(t, staff.User().ID())
+	s.DB.Exec(t, "UPDATE staffs SET department = 'Registrar' WHERE user_id = $1", uuid.UUID(staff.User().ID()))
+
+	graduate := builders.NewStudentBuilder().WithUsername("graduate").WithEmail("graduate@astanait.edu.kz").
+		WithEnrollment(user.Graduated, nil, "").Build()
+	enrolled := builders.NewStudentBuilder().WithUsername("enrolled").WithEmail("enrolled@astanait.edu.kz").Build()
+	s.DB.SeedStudent(t, graduate)
+	s.DB.SeedStudent(t, enrolled)
+
+	var res listUsersResponse
+	s.HTTP.ListUsers(t, "", "", asStaff, httpframework.WithRequestQuery("status", "graduated")).
+		RequireStatus(http.StatusOK).
+		RequireParseJSON(&res)
+	require.Len(t, res.Users, 1)
+	assert.Equal(t, graduate.User().ID().String(), res.Users[0].ID)
+
+	s.HTTP.ListUsers(t, "", "", asStaff, httpframework.WithRequestQuery("department", "Registrar")).
+		RequireStatus(http.StatusOK).
+		RequireParseJSON(&res)
+	require.Len(t, res.Users, 1)
+	assert.Equal(t, staff.User().ID().String(), res.Users[0].ID)
+
+	s.HTTP.ListUsers(t, "", "", asStaff, httpframework.WithRequestQuery("status", "dropped")).
+		AssertStatus(http.StatusBadRequest)
+}
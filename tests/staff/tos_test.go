@@ -0,0 +1,110 @@
+package staff
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/application/tos/tosquery"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/staffinvitation"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	staffhttp "gitlab.com/ucmsv2/ucms-backend/internal/ports/http/staff"
+	toshttp "gitlab.com/ucmsv2/ucms-backend/internal/ports/http/tos"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/httpx"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/fixtures"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/framework"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/framework/event"
+	httpframework "gitlab.com/ucmsv2/ucms-backend/tests/integration/framework/http"
+)
+
+type TOSSuite struct {
+	framework.IntegrationTestSuite
+}
+
+func TestTOSSuite(t *testing.T) {
+	suite.Run(t, new(TOSSuite))
+}
+
+func (s *TOSSuite) TestReconsent() {
+	t := s.T()
+
+	admin := s.SeedStaff(t, fixtures.TestStaff.Email)
+	asAdmin := httpframework.WithStaff(t, admin.User().ID())
+
+	s.HTTP.PublishTOSVersion(t, toshttp.PublishVersionRequest{
+		Version:     "2026-01",
+		DocumentURL: "https://ucms.kz/legal/tos/2026-01",
+	}, asAdmin).RequireStatus(http.StatusCreated)
+
+	var current struct {
+		TOS tosquery.VersionResponse `json:"tos"`
+	}
+	s.HTTP.GetCurrentTOS(t).RequireStatus(http.StatusOK).RequireParseJSON(&current)
+	assert.Equal(t, "2026-01", current.TOS.Version)
+
+	email := randomEmail()
+	s.HTTP.CreateStaffInvitation(t,
+		staffhttp.CreateInvitationRequest{Recipients: []string{email}},
+		asAdmin,
+	).RequireStatus(http.StatusCreated)
+	created := event.WaitFor(t, s.Event, 5*time.Second, func(e *staffinvitation.Created) bool {
+		return len(e.RecipientsEmail) == 1 && e.RecipientsEmail[0] == email
+	})
+	token, err := staffhttp.SignInvitationJWTToken(
+		created.Code,
+		email,
+		fixtures.InvitationTokenAlg,
+		fixtures.InvitationTokenKey,
+		fixtures.InvitationTokenExp,
+	)
+	require.NoError(t, err)
+
+	req := staffhttp.AcceptInvitationRequest{
+		Token:     token,
+		Barcode:   fixtures.TestStaff2.Barcode.String(),
+		Username:  fixtures.TestStaff2.Username,
+		Password:  fixtures.TestStaff2.Password,
+		FirstName: fixtures.TestStaff2.FirstName,
+		LastName:  fixtures.TestStaff2.LastName,
+	}
+	s.HTTP.AcceptStaffInvitation(t, req).AssertStatus(http.StatusBadRequest)
+
+	req.AcceptTOS = true
+	s.HTTP.AcceptStaffInvitation(t, req).RequireStatus(http.StatusCreated)
+
+	staffID := s.DB.RequireStaffExistsByEmail(t, email).Staff().User().ID()
+	asStaff := httpframework.WithStaff(t, staffID)
+	department := "Dean's Office"
+	update := staffhttp.UpdateProfileRequest{Department: httpx.SetTo(department)}
+	s.HTTP.UpdateStaffProfile(t, update, asStaff).RequireStatus(http.StatusOK)
+
+	s.HTTP.PublishTOSVersion(t, toshttp.PublishVersionRequest{
+		Version:     "2026-02",
+		DocumentURL: "https://ucms.kz/legal/tos/2026-02",
+	}, asAdmin).RequireStatus(http.StatusCreated)
+
+	var gated struct {
+		Code errorx.Code `json:"code"`
+	}
+	s.HTTP.UpdateStaffProfile(t, update, asStaff).
+		RequireStatus(http.StatusPreconditionRequired).
+		RequireParseJSON(&gated)
+	assert.Equal(t, errorx.CodeTOSReconsentRequired, gated.Code)
+
+	t.Run("the own profile stays readable", func(t *testing.T) {
+		s.HTTP.GetStaffProfile(t, asStaff).RequireStatus(http.StatusOK)
+	})
+
+	s.HTTP.AcceptTOS(t, asStaff).RequireStatus(http.StatusOK)
+
+	e := event.WaitFor(t, s.Event, 5*time.Second, func(e *user.UserTOSAccepted) bool {
+		return e.UserID == staffID && e.Version == "2026-02"
+	})
+	assert.Equal(t, "2026-02", e.Version)
+	s.HTTP.UpdateStaffProfile(t, update, asStaff).RequireStatus(http.StatusOK)
+}
@@ -0,0 +1,155 @@
+package status
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/application/status/statusquery"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/incident"
+	statushttp "gitlab.com/ucmsv2/ucms-backend/internal/ports/http/status"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/healthx"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/fixtures"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/framework"
+	httpframework "gitlab.com/ucmsv2/ucms-backend/tests/integration/framework/http"
+)
+
+type StatusSuite struct {
+	framework.IntegrationTestSuite
+}
+
+func TestStatusSuite(t *testing.T) {
+	suite.Run(t, new(StatusSuite))
+}
+
+func (s *StatusSuite) getStatus(t *testing.T) statusquery.Status {
+	t.Helper()
+	var body struct {
+		Status statusquery.Status `json:"status"`
+	}
+	res := s.HTTP.GetStatus(t).RequireStatus(http.StatusOK)
+	assert.Equal(t, "public, max-age=30", res.Header().Get("Cache-Control"))
+	res.RequireParseJSON(&body)
+	return body.Status
+}
+
+func (s *StatusSuite) createIncident(t *testing.T, req statushttp.IncidentRequest, opts ...httpframework.RequestBuilderOptions) string {
+	t.Helper()
+	var body struct {
+		Incident struct {
+			ID string `json:"id"`
+		} `json:"incident"`
+	}
+	s.HTTP.CreateIncident(t, req, opts...).
+		RequireStatus(http.StatusCreated).
+		RequireParseJSON(&body)
+	require.NotEmpty(t, body.Incident.ID)
+	return body.Incident.ID
+}
+
+func (s *StatusSuite) TestIncidents() {
+	t := s.T()
+	admin := s.SeedStaff(t, fixtures.TestStaff.Email)
+	asStaff := httpframework.WithStaff(t, admin.User().ID())
+	now := time.Now().UTC()
+
+	older := now.Add(-3 * time.Hour)
+	olderID := s.createIncident(t, statushttp.IncidentRequest{
+		Title:     "Login slow",
+		Severity:  incident.SeverityMinor,
+		StartedAt: &older,
+	}, asStaff)
+	latestID := s.createIncident(t, statushttp.IncidentRequest{
+		Title:    "Avatar uploads failing",
+		Severity: incident.SeverityMajor,
+		Note:     "Investigating",
+	}, asStaff)
+
+	status := s.getStatus(t)
+	assert.Equal(t, healthx.Operational, status.State)
+	assert.NotEmpty(t, status.Components)
+	require.Len(t, status.Incidents, 2)
+	assert.Equal(t, latestID, status.Incidents[0].ID.String(), "the latest started first")
+	assert.Equal(t, olderID, status.Incidents[1].ID.String())
+	assert.Nil(t, status.Incidents[0].ResolvedAt)
+	require.Len(t, status.Incidents[0].Notes, 1)
+	assert.Equal(t, "Investigating", status.Incidents[0].Notes[0].Text)
+
+	resolved := time.Now().UTC()
+	s.HTTP.UpdateIncident(t, latestID, statushttp.IncidentRequest{
+		Title:      "Avatar uploads failing",
+		Severity:   incident.SeverityMajor,
+		ResolvedAt: &resolved,
+		Note:       "Storage is back",
+	}, asStaff).RequireStatus(http.StatusOK)
+
+	status = s.getStatus(t)
+	require.Len(t, status.Incidents, 2)
+	assert.Equal(t, latestID, status.Incidents[0].ID.String())
+	require.NotNil(t, status.Incidents[0].ResolvedAt)
+	assert.WithinDuration(t, resolved, status.Incidents[0].ResolvedAt.Time, time.Second)
+	assert.Len(t, status.Incidents[0].Notes, 2)
+
+	t.Run("old resolved incidents leave the page", func(t *testing.T) {
+		started, over := now.Add(-30*24*time.Hour), now.Add(-20*24*time.Hour)
+		s.createIncident(t, statushttp.IncidentRequest{
+			Title:      "Outage last month",
+			Severity:   incident.SeverityCritical,
+			StartedAt:  &started,
+			ResolvedAt: &over,
+		}, asStaff)
+		assert.Len(t, s.getStatus(t).Incidents, 2)
+	})
+}
+
+func (s *StatusSuite) TestIncidents_Validation() {
+	t := s.T()
+	admin := s.SeedStaff(t, fixtures.TestStaff.Email)
+	asStaff := httpframework.WithStaff(t, admin.User().ID())
+
+	s.HTTP.CreateIncident(t, statushttp.IncidentRequest{Title: "Outage", Severity: "apocalyptic"}, asStaff).
+		RequireStatus(http.StatusBadRequest)
+	s.HTTP.UpdateIncident(t, incident.NewID().String(), statushttp.IncidentRequest{
+		Title:    "Outage",
+		Severity: incident.SeverityMinor,
+	}, asStaff).RequireStatus(http.StatusNotFound)
+
+	started := time.Now().UTC()
+	before := started.Add(-time.Hour)
+	s.HTTP.CreateIncident(t, statushttp.IncidentRequest{
+		Title:      "Outage",
+		Severity:   incident.SeverityMinor,
+		StartedAt:  &started,
+		ResolvedAt: &before,
+	}, asStaff).RequireStatus(http.StatusBadRequest)
+}
+
+func (s *StatusSuite) TestIncidents_StaffOnly() {
+	t := s.T()
+	groupID := s.SeedGroup(t)
+	student := s.SeedStudent(t, fixtures.ValidStudentEmail, groupID)
+
+	s.HTTP.CreateIncident(t, statushttp.IncidentRequest{Title: "Outage", Severity: incident.SeverityMinor},
+		httpframework.WithStudent(t, student.User().ID())).
+		RequireStatus(http.StatusForbidden)
+	s.HTTP.CreateIncident(t, statushttp.IncidentRequest{Title: "Outage", Severity: incident.SeverityMinor}).
+		RequireStatus(http.StatusUnauthorized)
+}
+
+func (s *StatusSuite) TestComponents() {
+	t := s.T()
+	s.Health.ProbeOnce(t.Context())
+
+	status := s.getStatus(t)
+	names := make([]string, len(status.Components))
+	for i, c := range status.Components {
+		names[i] = c.Name
+		assert.Equal(t, healthx.Operational, c.State, c.Name)
+	}
+	assert.Equal(t, []string{"api", "database", "storage", "mail", "events"}, names)
+	assert.GreaterOrEqual(t, status.UptimeSeconds, int64(0))
+}
@@ -0,0 +1,78 @@
+package storage
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/suite"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/adapters/services/s3"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/framework"
+)
+
+type BucketSuite struct {
+	framework.IntegrationTestSuite
+}
+
+func TestBucketSuite(t *testing.T) {
+	suite.Run(t, &BucketSuite{IntegrationTestSuite: framework.IntegrationTestSuite{
+		Options: framework.SuiteOptions{Storage: true},
+	}})
+}
+
+func randomBucketName() string {
+	return "ucms-test-" + strings.ReplaceAll(uuid.NewString(), "-", "")[:20]
+}
+
+func (s *BucketSuite) TestEnsureBucket_CreatesBucketWithLifecycle() {
+	t := s.T()
+	client := s.NewS3Client(randomBucketName())
+
+	info, err := client.EnsureBucket(t.Context(), s3.EnsureBucketOptions{Bootstrap: true})
+	s.Require().NoError(err)
+	s.True(info.Created, "missing bucket should be created")
+	s.Equal(client.Bucket(), info.Name)
+	s.Equal("us-east-1", info.Region)
+	s.Equal(int32(1), info.TmpExpirationDays, "tmp/ objects should expire after a day")
+
+	// Running it again, e.g. on the next deploy, keeps everything in place.
+	info, err = client.EnsureBucket(t.Context(), s3.EnsureBucketOptions{Bootstrap: true})
+	s.Require().NoError(err)
+	s.False(info.Created)
+	s.Equal(int32(1), info.TmpExpirationDays)
+}
+
+func (s *BucketSuite) TestEnsureBucket_WithoutBootstrap() {
+	t := s.T()
+	client := s.NewS3Client(randomBucketName())
+
+	_, err := client.EnsureBucket(t.Context(), s3.EnsureBucketOptions{Bootstrap: false})
+	s.Require().Error(err, "missing bucket must not be created without bootstrap")
+
+	s.Require().NoError(client.CreateBucket(t.Context()))
+	info, err := client.EnsureBucket(t.Context(), s3.EnsureBucketOptions{Bootstrap: false})
+	s.Require().NoError(err)
+	s.False(info.Created)
+	s.Zero(info.TmpExpirationDays, "lifecycle is left alone without bootstrap")
+}
+
+func (s *BucketSuite) TestSelfTest_CleansUp() {
+	t := s.T()
+	client := s.NewS3Client(randomBucketName())
+	_, err := client.EnsureBucket(t.Context(), s3.EnsureBucketOptions{Bootstrap: true})
+	s.Require().NoError(err)
+
+	s.Require().NoError(client.SelfTest(t.Context()))
+
+	objects, _, err := client.ListObjects(t.Context(), "tmp/", "", 100)
+	s.Require().NoError(err)
+	s.Empty(objects, "self-test object should be removed")
+}
+
+func (s *BucketSuite) TestSelfTest_MissingBucketFails() {
+	t := s.T()
+	client := s.NewS3Client(randomBucketName())
+
+	s.Error(client.SelfTest(t.Context()))
+}
@@ -0,0 +1,173 @@
+package student
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/application/student/studentquery"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/group"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/registration"
+	registrationhttp "gitlab.com/ucmsv2/ucms-backend/internal/ports/http/registration"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/builders"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/fixtures"
+	httpframework "gitlab.com/ucmsv2/ucms-backend/tests/integration/framework/http"
+)
+
+type enrollmentClosedResponse struct {
+	Code    errorx.Code `json:"code"`
+	Details string      `json:"details"`
+}
+
+// seedWindowGroup seeds a group students may join from opensAt until
+// closesAt.
+func (s *GroupSuite) seedWindowGroup(t *testing.T, name string, opensAt, closesAt *time.Time) group.ID {
+	t.Helper()
+	g := builders.NewGroupBuilder().WithID(group.NewID()).WithName(name).WithEnrollmentWindow(opensAt, closesAt).Build()
+	s.DB.SeedBuiltGroup(t, g)
+	return g.ID()
+}
+
+// completeRegistration completes the registration of the student n, whose
+// email was verified now, in groupID.
+func (s *GroupSuite) completeRegistration(t *testing.T, n int, groupID group.ID) *httpframework.Response {
+	t.Helper()
+	email := fmt.Sprintf("window%d@astanait.edu.kz", n)
+	reg := builders.NewRegistrationBuilder().
+		WithClock(s.Clock).
+		WithEmail(email).
+		WithStatus(registration.StatusVerified).
+		Build()
+	s.DB.SeedRegistration(t, reg)
+
+	return s.HTTP.CompleteStudentRegistration(t, registrationhttp.CompleteStudentRegistrationRequest{
+		Email:            email,
+		VerificationCode: reg.VerificationCode(),
+		Password:         fixtures.TestStudent.Password,
+		Barcode:          fmt.Sprintf("2109%02d", n),
+		Username:         fmt.Sprintf("window%d", n),
+		FirstName:        fixtures.TestStudent.FirstName,
+		LastName:         fixtures.TestStudent.LastName,
+		GroupId:          uuid.UUID(groupID),
+	})
+}
+
+func (s *GroupSuite) requireEnrollmentClosed(t *testing.T, res *httpframework.Response, window ...time.Time) {
+	t.Helper()
+	var body enrollmentClosedResponse
+	res.RequireStatus(http.StatusUnprocessableEntity).RequireParseJSON(&body)
+	assert.Equal(t, errorx.CodeGroupEnrollmentClosed, body.Code)
+	for _, at := range window {
+		assert.Contains(t, body.Details, at.UTC().Format(time.RFC3339), "the details name the window")
+	}
+}
+
+func (s *GroupSuite) listedGroup(t *testing.T, id group.ID) studentquery.GroupListItem {
+	t.Helper()
+	var res struct {
+		Groups []studentquery.GroupListItem `json:"groups"`
+	}
+	s.HTTP.ListGroups(t).RequireStatus(http.StatusOK).RequireParseJSON(&res)
+	for _, g := range res.Groups {
+		if g.ID == id.String() {
+			return g
+		}
+	}
+	require.Failf(t, "group not listed", "group %s", id)
+	return studentquery.GroupListItem{}
+}
+
+func (s *GroupSuite) TestEnrollmentWindow_CompleteRegistration() {
+	t := s.T()
+	opensAt := s.Clock.Now().Add(time.Hour).Truncate(time.Second)
+	closesAt := opensAt.Add(time.Hour)
+	groupID := s.seedWindowGroup(t, "SE-2601", &opensAt, &closesAt)
+
+	s.requireEnrollmentClosed(t, s.completeRegistration(t, 1, groupID), opensAt, closesAt)
+	s.DB.RequireUserNotExists(t, "window1@astanait.edu.kz")
+	assert.False(t, s.listedGroup(t, groupID).EnrollmentOpen, "the group is not open yet")
+
+	s.Clock.Advance(90 * time.Minute)
+	s.completeRegistration(t, 2, groupID).RequireSuccess()
+	s.DB.RequireStudentExistsByEmail(t, "window2@astanait.edu.kz").AssertGroupID(t, groupID)
+	assert.True(t, s.listedGroup(t, groupID).EnrollmentOpen)
+
+	s.Clock.Advance(time.Hour)
+	s.requireEnrollmentClosed(t, s.completeRegistration(t, 3, groupID), opensAt, closesAt)
+	s.DB.RequireUserNotExists(t, "window3@astanait.edu.kz")
+	assert.False(t, s.listedGroup(t, groupID).EnrollmentOpen, "the group is closed again")
+}
+
+func (s *GroupSuite) TestEnrollmentWindow_TransferAndClear() {
+	t := s.T()
+	staff := s.SeedStaff(t, fixtures.ValidStaffEmail)
+	asStaff := httpframework.WithStaff(t, staff.User().ID())
+	from := s.SeedGroup(t)
+	student := s.SeedStudent(t, fixtures.ValidStudentEmail, from)
+	opensAt := s.Clock.Now().Add(-2 * time.Hour).Truncate(time.Second)
+	closesAt := opensAt.Add(time.Hour)
+	to := s.seedWindowGroup(t, "SE-2602", &opensAt, &closesAt)
+
+	s.requireEnrollmentClosed(t,
+		s.HTTP.TransferStudentGroup(t, student.User().Barcode().String(), uuid.UUID(to), asStaff), closesAt)
+	s.DB.RequireStudentExists(t, student.User().ID()).AssertGroupID(t, from)
+
+	s.HTTP.UpdateGroup(t, uuid.UUID(to), `{"enrollment_opens_at": null, "enrollment_closes_at": null}`, asStaff).
+		RequireStatus(http.StatusOK)
+	listed := s.listedGroup(t, to)
+	assert.True(t, listed.EnrollmentOpen, "a group without a window is always open")
+	assert.Nil(t, listed.EnrollmentOpensAt)
+	assert.Nil(t, listed.EnrollmentClosesAt)
+
+	s.HTTP.TransferStudentGroup(t, student.User().Barcode().String(), uuid.UUID(to), asStaff).
+		RequireStatus(http.StatusOK)
+	s.DB.RequireStudentExists(t, student.User().ID()).AssertGroupID(t, to)
+}
+
+func (s *GroupSuite) TestEnrollmentWindow_Update() {
+	t := s.T()
+	staff := s.SeedStaff(t, fixtures.ValidStaffEmail)
+	asStaff := httpframework.WithStaff(t, staff.User().ID())
+	groupID := s.SeedGroup(t)
+	now := s.Clock.Now()
+	window := func(opensAt, closesAt time.Time) string {
+		return fmt.Sprintf(`{"enrollment_opens_at": %q, "enrollment_closes_at": %q}`,
+			opensAt.UTC().Format(time.RFC3339), closesAt.UTC().Format(time.RFC3339))
+	}
+
+	for name, body := range map[string]string{
+		"opening in the past":    window(now.Add(-time.Hour), now.Add(time.Hour)),
+		"closing before opening": window(now.Add(2*time.Hour), now.Add(time.Hour)),
+		"window too short":       window(now.Add(time.Hour), now.Add(time.Hour+30*time.Second)),
+	} {
+		s.HTTP.UpdateGroup(t, uuid.UUID(groupID), body, asStaff).AssertStatus(http.StatusBadRequest)
+		assert.True(t, s.listedGroup(t, groupID).EnrollmentOpen, name)
+	}
+
+	opensAt := now.Add(time.Hour).Truncate(time.Second)
+	closesAt := opensAt.Add(7 * 24 * time.Hour)
+	s.HTTP.UpdateGroup(t, uuid.UUID(groupID), window(opensAt, closesAt), asStaff).RequireStatus(http.StatusOK)
+
+	var res struct {
+		Group studentquery.GetGroupResponse `json:"group"`
+	}
+	s.HTTP.GetGroup(t, uuid.UUID(groupID), asStaff).RequireStatus(http.StatusOK).RequireParseJSON(&res)
+	require.NotNil(t, res.Group.EnrollmentOpensAt)
+	require.NotNil(t, res.Group.EnrollmentClosesAt)
+	assert.True(t, opensAt.Equal(*res.Group.EnrollmentOpensAt))
+	assert.True(t, closesAt.Equal(*res.Group.EnrollmentClosesAt))
+	assert.False(t, s.listedGroup(t, groupID).EnrollmentOpen)
+
+	// The opening is past once the window is open, only the closing moves.
+	s.Clock.Advance(2 * time.Hour)
+	assert.True(t, s.listedGroup(t, groupID).EnrollmentOpen)
+	s.HTTP.UpdateGroup(t, uuid.UUID(groupID),
+		fmt.Sprintf(`{"enrollment_closes_at": %q}`, closesAt.Add(24*time.Hour).Format(time.RFC3339)), asStaff).
+		RequireStatus(http.StatusOK)
+}
@@ -0,0 +1,143 @@
+package student
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/application/student/studentquery"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/group"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/fixtures"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/framework"
+	httpframework "gitlab.com/ucmsv2/ucms-backend/tests/integration/framework/http"
+)
+
+type GroupSuite struct {
+	framework.IntegrationTestSuite
+}
+
+func TestGroupSuite(t *testing.T) {
+	suite.Run(t, new(GroupSuite))
+}
+
+// summarize counts the members of the groups into their summaries, like the
+// migration does for the groups that exist when it runs.
+func (s *GroupSuite) summarize(t *testing.T, groupIDs ...group.ID) {
+	t.Helper()
+	for _, id := range groupIDs {
+		s.DB.Exec(t, `
+            INSERT INTO group_summaries (group_id, member_count)
+            SELECT g.id, count(s.user_id)
+            FROM groups g LEFT JOIN students s ON s.group_id = g.id
+            WHERE g.id = $1
+            GROUP BY g.id;
+        `, id)
+	}
+}
+
+func (s *GroupSuite) memberCount(t *testing.T, groupID group.ID, opts ...httpframework.RequestBuilderOptions) int {
+	t.Helper()
+	var res struct {
+		Group studentquery.GetGroupResponse `json:"group"`
+	}
+	s.HTTP.GetGroup(t, uuid.UUID(groupID), opts...).RequireStatus(http.StatusOK).RequireParseJSON(&res)
+	return res.Group.MemberCount
+}
+
+func (s *GroupSuite) TestTransferUpdatesMemberCounts() {
+	t := s.T()
+	staff := s.SeedStaff(t, fixtures.ValidStaffEmail)
+	asStaff := httpframework.WithStaff(t, staff.User().ID())
+	from := s.SeedGroup(t)
+	to := group.NewID()
+	s.DB.SeedGroup(t, to, "SE-2402", fixtures.SEGroup.Year, fixtures.SEGroup.Major)
+	student := s.SeedStudent(t, fixtures.ValidStudentEmail, from)
+	s.SeedStudent(t, "classmate@astanait.edu.kz", from)
+	s.summarize(t, from, to)
+
+	// Both groups are cached before the transfer.
+	require.Equal(t, 2, s.memberCount(t, from, asStaff))
+	require.Equal(t, 0, s.memberCount(t, to, asStaff))
+
+	s.HTTP.TransferStudentGroup(t, student.User().Barcode().String(), uuid.UUID(to), asStaff).
+		RequireStatus(http.StatusOK)
+
+	assert.Eventually(t, func() bool {
+		return s.memberCount(t, from, asStaff) == 1 && s.memberCount(t, to, asStaff) == 1
+	}, 5*time.Second, 50*time.Millisecond, "both member counts follow the transfer")
+}
+
+func (s *GroupSuite) TestTransferFromDeletedGroup() {
+	t := s.T()
+	staff := s.SeedStaff(t, fixtures.ValidStaffEmail)
+	asStaff := httpframework.WithStaff(t, staff.User().ID())
+	from := s.SeedGroup(t)
+	to := group.NewID()
+	s.DB.SeedGroup(t, to, "SE-2402", fixtures.SEGroup.Year, fixtures.SEGroup.Major)
+	student := s.SeedStudent(t, fixtures.ValidStudentEmail, from)
+	s.summarize(t, to)
+
+	s.HTTP.TransferStudentGroup(t, student.User().Barcode().String(), uuid.UUID(to), asStaff).
+		RequireStatus(http.StatusOK)
+	// The group the student left is gone by the time the event is handled.
+	s.DB.Exec(t, `DELETE FROM groups WHERE id = $1;`, from)
+
+	assert.Eventually(t, func() bool {
+		return s.memberCount(t, to, asStaff) == 1
+	}, 5*time.Second, 50*time.Millisecond, "the summary of the new group is refreshed all the same")
+}
+
+func (s *GroupSuite) TestGetGroup_NotFound() {
+	t := s.T()
+	staff := s.SeedStaff(t, fixtures.ValidStaffEmail)
+
+	s.HTTP.GetGroup(t, uuid.New(), httpframework.WithStaff(t, staff.User().ID())).
+		AssertStatus(http.StatusNotFound)
+}
+
+func (s *GroupSuite) TestUpdateGroup() {
+	t := s.T()
+	staff := s.SeedStaff(t, fixtures.ValidStaffEmail)
+	asStaff := httpframework.WithStaff(t, staff.User().ID())
+	groupID := s.SeedGroup(t)
+	getGroup := func(t *testing.T) studentquery.GetGroupResponse {
+		var res struct {
+			Group studentquery.GetGroupResponse `json:"group"`
+		}
+		s.HTTP.GetGroup(t, uuid.UUID(groupID), asStaff).RequireStatus(http.StatusOK).RequireParseJSON(&res)
+		return res.Group
+	}
+	// The group is cached before the update.
+	require.Equal(t, fixtures.SEGroup.Name, getGroup(t).Name)
+
+	s.HTTP.UpdateGroup(t, uuid.UUID(groupID), `{"name":"SE-2502","year":"25"}`, asStaff).
+		RequireStatus(http.StatusOK)
+
+	got := getGroup(t)
+	assert.Equal(t, "SE-2502", got.Name)
+	assert.Equal(t, "25", got.Year)
+	assert.Equal(t, fixtures.SEGroup.Major.String(), got.Major, "a field left out is kept")
+
+	t.Run("an explicit null fails for a required field", func(t *testing.T) {
+		s.HTTP.UpdateGroup(t, uuid.UUID(groupID), `{"name":null}`, asStaff).
+			AssertStatus(http.StatusBadRequest)
+		assert.Equal(t, "SE-2502", getGroup(t).Name)
+	})
+
+	t.Run("unknown fields are rejected", func(t *testing.T) {
+		s.HTTP.UpdateGroup(t, uuid.UUID(groupID), `{"name":"SE-2503","member_count":3}`, asStaff).
+			AssertStatus(http.StatusBadRequest).
+			AssertContainsMessage("is not a known field")
+		assert.Equal(t, "SE-2502", getGroup(t).Name)
+	})
+
+	t.Run("unknown group", func(t *testing.T) {
+		s.HTTP.UpdateGroup(t, uuid.New(), `{"name":"SE-2503"}`, asStaff).
+			AssertStatus(http.StatusNotFound)
+	})
+}
@@ -0,0 +1,44 @@
+package student
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/builders"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/fixtures"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/framework"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/framework/goldenx"
+	httpframework "gitlab.com/ucmsv2/ucms-backend/tests/integration/framework/http"
+)
+
+type StudentProfileSuite struct {
+	framework.IntegrationTestSuite
+}
+
+func TestStudentProfileSuite(t *testing.T) {
+	suite.Run(t, new(StudentProfileSuite))
+}
+
+func (s *StudentProfileSuite) TestGetProfile_Shape() {
+	t := s.T()
+	s.DB.SeedGroup(t, fixtures.SEGroup.ID, fixtures.SEGroup.Name, fixtures.SEGroup.Year, fixtures.SEGroup.Major)
+	student := builders.NewStudentBuilder().
+		WithBarcode(fixtures.TestStudentBarcode).
+		WithEmail(fixtures.ValidStudentEmail).
+		WithGroupID(fixtures.SEGroup.ID).
+		Build()
+	s.DB.SeedStudent(t, student)
+
+	s.HTTP.GetStudentProfile(t, httpframework.WithStudent(t, student.User().ID())).
+		RequireStatus(http.StatusOK).
+		MatchGolden(t, "student_profile.json", goldenx.IgnoreFields("$.student.registered_at"))
+}
+
+func (s *StudentProfileSuite) TestGetProfile_Unauthenticated() {
+	t := s.T()
+
+	s.HTTP.GetStudentProfile(t, httpframework.WithAnon()).
+		AssertStatus(http.StatusUnauthorized)
+}
@@ -0,0 +1,144 @@
+package system
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/app"
+	"gitlab.com/ucmsv2/ucms-backend/internal/application/mail"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/env"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/builders"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/fixtures"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/framework"
+	"gitlab.com/ucmsv2/ucms-backend/tests/mocks"
+)
+
+// AppSuite boots the whole application the way cmd/api does, on the database
+// of the suite, with the infrastructure replaced by no-op fakes.
+type AppSuite struct {
+	framework.IntegrationTestSuite
+}
+
+func TestAppSuite(t *testing.T) {
+	suite.Run(t, new(AppSuite))
+}
+
+func (s *AppSuite) TestRun_ServesAndShutsDown() {
+	t := s.T()
+
+	cfg := framework.NewAppConfig()
+	cfg.Admin.Port = "0"
+
+	api, err := app.New(t.Context(), cfg,
+		app.WithPool(s.Pool()),
+		app.WithMailSender(mocks.NewMockMailSender()),
+		app.AllowMockMailSender(),
+		app.WithClock(clock.NewOffset()),
+		app.WithStorage(nil, fixtures.ValidS3BaseURL),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+	runErr := make(chan error, 1)
+	go func() { runErr <- api.Run(ctx) }()
+
+	select {
+	case <-api.Ready():
+	case err := <-runErr:
+		t.Fatalf("the app stopped before serving: %v", err)
+	case <-time.After(10 * time.Second):
+		t.Fatal("the app did not start serving")
+	}
+
+	res, err := http.Get("http://" + api.Addr().String() + "/health")
+	require.NoError(t, err)
+	body, _ := io.ReadAll(res.Body)
+	_ = res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "OK", string(body))
+
+	res, err = http.Get("http://" + api.AdminAddr().String() + "/debug/pprof/")
+	require.NoError(t, err)
+	_ = res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode, "pprof is served on the admin listener")
+
+	cancel()
+	require.NoError(t, <-runErr)
+
+	shutdownCtx, stop := context.WithTimeout(context.Background(), 10*time.Second)
+	defer stop()
+	require.NoError(t, api.Shutdown(shutdownCtx))
+
+	require.NoError(t, s.Pool().Ping(t.Context()), "the pool of WithPool stays open")
+}
+
+func (s *AppSuite) TestNew_RefusesMockMailSender() {
+	t := s.T()
+	cfg := framework.NewAppConfig()
+	cfg.Mode = env.Prod
+
+	_, err := app.New(t.Context(), cfg,
+		app.WithPool(s.Pool()),
+		app.WithStorage(nil, fixtures.ValidS3BaseURL),
+	)
+	require.ErrorIs(t, err, mail.ErrMockSender, "production has no mail sender to fall back on")
+
+	_, err = app.New(t.Context(), cfg,
+		app.WithPool(s.Pool()),
+		app.WithMailSender(mocks.NewMockMailSender()),
+		app.WithStorage(nil, fixtures.ValidS3BaseURL),
+	)
+	require.ErrorIs(t, err, mail.ErrMockSender)
+	require.NoError(t, s.Pool().Ping(t.Context()), "the pool of WithPool stays open")
+}
+
+func (s *AppSuite) TestNew_ModeGatesDevEndpoints() {
+	tests := []struct {
+		name     string
+		mode     env.Mode
+		wantCode int
+	}{
+		{name: "prod", mode: env.Prod, wantCode: http.StatusNotFound},
+		{name: "test", mode: env.Test, wantCode: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			t := s.T()
+			reg := builders.NewRegistrationBuilder().WithEmail(tt.name + "@test.com").Build()
+			s.DB.SeedRegistration(t, reg)
+
+			cfg := framework.NewAppConfig()
+			cfg.Mode = tt.mode
+			api, err := app.New(t.Context(), cfg,
+				app.WithPool(s.Pool()),
+				app.WithMailSender(mocks.NewMockMailSender()),
+				app.AllowMockMailSender(),
+				app.WithStorage(nil, fixtures.ValidS3BaseURL),
+			)
+			require.NoError(t, err)
+
+			rec := httptest.NewRecorder()
+			api.Router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet,
+				"/dev/registrations/verification-code/"+reg.Email().String(), nil))
+			assert.Equal(t, tt.wantCode, rec.Code, rec.Body.String())
+
+			req := httptest.NewRequest(http.MethodPost, "/v1/dev/clock", strings.NewReader(`{"advance":"1h"}`))
+			req.Header.Set("Content-Type", "application/json")
+			rec = httptest.NewRecorder()
+			api.Router.ServeHTTP(rec, req)
+			assert.Equal(t, tt.wantCode, rec.Code, rec.Body.String())
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package system
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	adminhttp "gitlab.com/ucmsv2/ucms-backend/internal/ports/http/admin"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/fixtures"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/framework"
+	httpframework "gitlab.com/ucmsv2/ucms-backend/tests/integration/framework/http"
+)
+
+type ErrorInboxSuite struct {
+	framework.IntegrationTestSuite
+}
+
+func TestErrorInboxSuite(t *testing.T) {
+	suite.Run(t, new(ErrorInboxSuite))
+}
+
+func (s *ErrorInboxSuite) SetupSuite() {
+	s.IntegrationTestSuite.SetupSuite()
+	s.Router().Get("/test/panic", func(w http.ResponseWriter, r *http.Request) {
+		panic("handler exploded")
+	})
+}
+
+type listErrorsResponse struct {
+	Errors []adminhttp.ErrorEventResponse `json:"errors"`
+}
+
+func (s *ErrorInboxSuite) TestPanic_AggregatedAndResolved() {
+	t := s.T()
+	staff := s.SeedStaff(t, fixtures.TestStaff.Email)
+	asStaff := httpframework.WithStaff(t, staff.User().ID())
+
+	for range 2 {
+		s.HTTP.Do(t, httpframework.NewRequest(http.MethodGet, "/test/panic").Build()).
+			AssertStatus(http.StatusInternalServerError)
+	}
+	require.NoError(t, s.ErrorRecorder.Flush(t.Context()))
+
+	var open listErrorsResponse
+	s.HTTP.ListSystemErrors(t, "", asStaff).RequireStatus(http.StatusOK).RequireParseJSON(&open)
+	require.Len(t, open.Errors, 1, "both panics should be aggregated")
+	e := open.Errors[0]
+	assert.Equal(t, int64(2), e.Count)
+	assert.Equal(t, "handler exploded", e.Message)
+	assert.Equal(t, "string", e.Type)
+	assert.Equal(t, "GET /test/panic", e.Route)
+	assert.Equal(t, "open", e.Status)
+	assert.NotEmpty(t, e.Frames)
+	assert.False(t, e.LastSeen.Before(e.FirstSeen.Time))
+
+	s.HTTP.ResolveSystemError(t, e.Signature, asStaff).RequireStatus(http.StatusOK)
+
+	var afterResolve listErrorsResponse
+	s.HTTP.ListSystemErrors(t, "", asStaff).RequireStatus(http.StatusOK).RequireParseJSON(&afterResolve)
+	assert.Empty(t, afterResolve.Errors, "resolved errors are hidden by default")
+
+	var resolved listErrorsResponse
+	s.HTTP.ListSystemErrors(t, "resolved", asStaff).RequireStatus(http.StatusOK).RequireParseJSON(&resolved)
+	require.Len(t, resolved.Errors, 1)
+	assert.Equal(t, e.Signature, resolved.Errors[0].Signature)
+}
+
+func (s *ErrorInboxSuite) TestListErrors_StaffOnly() {
+	t := s.T()
+	student := s.SeedStudent(t, fixtures.TestStudent.Email, s.SeedGroup(t))
+
+	s.HTTP.ListSystemErrors(t, "", httpframework.WithStudent(t, student.User().ID())).
+		AssertStatus(http.StatusForbidden)
+	s.HTTP.ResolveSystemError(t, "unknown", httpframework.WithStudent(t, student.User().ID())).
+		AssertStatus(http.StatusForbidden)
+}
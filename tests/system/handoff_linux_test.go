@@ -0,0 +1,144 @@
+//go:build linux
+
+package system
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/app"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/fixtures"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/framework"
+	"gitlab.com/ucmsv2/ucms-backend/tests/mocks"
+)
+
+// HandoffSuite restarts the application the way a rolling deploy does: the
+// new instance binds the port with SO_REUSEPORT before the old one drains.
+type HandoffSuite struct {
+	framework.IntegrationTestSuite
+}
+
+func TestHandoffSuite(t *testing.T) {
+	suite.Run(t, new(HandoffSuite))
+}
+
+// instance is a running application standing in for one process.
+type instance struct {
+	app    *app.App
+	cancel context.CancelFunc
+	done   chan error
+}
+
+func (s *HandoffSuite) startInstance(port string) *instance {
+	t := s.T()
+
+	cfg := framework.NewAppConfig()
+	cfg.Port = port
+	cfg.ReusePort = true
+
+	api, err := app.New(t.Context(), cfg,
+		app.WithPool(s.Pool()),
+		app.WithMailSender(mocks.NewMockMailSender()),
+		app.AllowMockMailSender(),
+		app.WithClock(clock.NewOffset()),
+		app.WithStorage(nil, fixtures.ValidS3BaseURL),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	in := &instance{app: api, cancel: cancel, done: make(chan error, 1)}
+	go func() { in.done <- api.Run(ctx) }()
+
+	select {
+	case <-api.Ready():
+	case err := <-in.done:
+		t.Fatalf("the instance stopped before serving: %v", err)
+	case <-time.After(10 * time.Second):
+		t.Fatal("the instance did not start serving")
+	}
+	return in
+}
+
+// stop runs the shutdown path of cmd/api: stop accepting, drain, close.
+func (s *HandoffSuite) stop(in *instance) {
+	in.cancel()
+	s.Require().NoError(<-in.done)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	s.Require().NoError(in.app.Shutdown(ctx))
+}
+
+func (s *HandoffSuite) TestRollingRestart_NoFailedRequests() {
+	t := s.T()
+
+	probe, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	_, port, err := net.SplitHostPort(probe.Addr().String())
+	require.NoError(t, err)
+	require.NoError(t, probe.Close())
+
+	old := s.startInstance(port)
+	url := "http://127.0.0.1:" + port + "/health"
+
+	ctx, stopClients := context.WithCancel(t.Context())
+	var (
+		wg        sync.WaitGroup
+		succeeded atomic.Int64
+		failures  = make(chan error, 100)
+	)
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			client := &http.Client{Timeout: 5 * time.Second}
+			for ctx.Err() == nil {
+				res, err := client.Get(url)
+				if err != nil {
+					select {
+					case failures <- err:
+					default:
+					}
+					continue
+				}
+				_, _ = io.Copy(io.Discard, res.Body)
+				_ = res.Body.Close()
+				if res.StatusCode != http.StatusOK {
+					select {
+					case failures <- assert.AnError:
+					default:
+					}
+					continue
+				}
+				succeeded.Add(1)
+			}
+		}()
+	}
+
+	time.Sleep(200 * time.Millisecond)
+	next := s.startInstance(port)
+	time.Sleep(200 * time.Millisecond)
+	s.stop(old)
+	time.Sleep(300 * time.Millisecond)
+
+	stopClients()
+	wg.Wait()
+	close(failures)
+	s.stop(next)
+
+	for err := range failures {
+		t.Errorf("request failed during the restart: %v", err)
+	}
+	assert.Positive(t, succeeded.Load())
+}
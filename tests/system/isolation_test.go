@@ -0,0 +1,64 @@
+package system
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/fixtures"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/framework"
+)
+
+// IsolationSuite seeds the same staff as its twin running in parallel. With
+// a database per suite neither sees the row of the other.
+type IsolationSuite struct {
+	framework.IntegrationTestSuite
+	seeded *sync.WaitGroup
+}
+
+func TestIsolationSuite(t *testing.T) {
+	var seeded sync.WaitGroup
+	seeded.Add(2)
+
+	for _, name := range []string{"first", "second"} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			suite.Run(t, &IsolationSuite{seeded: &seeded})
+		})
+	}
+}
+
+func (s *IsolationSuite) TestSameEmail_DoesNotInterfere() {
+	t := s.T()
+	s.SeedStaff(t, fixtures.TestStaff.Email)
+
+	s.seeded.Done()
+	waitSeeded(t, s.seeded)
+
+	var count int
+	err := s.Pool().QueryRow(t.Context(),
+		"SELECT COUNT(*) FROM users WHERE email = $1", fixtures.TestStaff.Email).Scan(&count)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count, "the suite should only see its own staff")
+}
+
+// waitSeeded waits for the twin suite, which never arrives when its setup
+// failed.
+func waitSeeded(t *testing.T, seeded *sync.WaitGroup) {
+	t.Helper()
+
+	done := make(chan struct{})
+	go func() {
+		seeded.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Minute):
+		t.Fatal("the twin suite did not seed its staff")
+	}
+}
@@ -0,0 +1,126 @@
+package system
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/adapters/repos/postgres"
+	adminhttp "gitlab.com/ucmsv2/ucms-backend/internal/ports/http/admin"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/fixtures"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/framework"
+	httpframework "gitlab.com/ucmsv2/ucms-backend/tests/integration/framework/http"
+)
+
+const weeklyReportJob = "weekly-report"
+
+type JobsSuite struct {
+	framework.IntegrationTestSuite
+	stopJobs context.CancelFunc
+	jobsDone chan struct{}
+}
+
+func TestJobsSuite(t *testing.T) {
+	suite.Run(t, new(JobsSuite))
+}
+
+func (s *JobsSuite) SetupSuite() {
+	s.IntegrationTestSuite.SetupSuite()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.stopJobs = cancel
+	s.jobsDone = make(chan struct{})
+	go func() {
+		defer close(s.jobsDone)
+		s.Jobs.Run(ctx)
+	}()
+}
+
+func (s *JobsSuite) TearDownSuite() {
+	s.stopJobs()
+	<-s.jobsDone
+	s.IntegrationTestSuite.TearDownSuite()
+}
+
+type listJobsResponse struct {
+	Jobs []adminhttp.JobResponse `json:"jobs"`
+}
+
+func (s *JobsSuite) job(t *testing.T, opts httpframework.RequestBuilderOptions) adminhttp.JobResponse {
+	t.Helper()
+	var res listJobsResponse
+	s.HTTP.ListJobs(t, opts).RequireStatus(http.StatusOK).RequireParseJSON(&res)
+	for _, j := range res.Jobs {
+		if j.Name == weeklyReportJob {
+			return j
+		}
+	}
+	require.Failf(t, "job not listed", "%s is not in %v", weeklyReportJob, res.Jobs)
+	return adminhttp.JobResponse{}
+}
+
+func (s *JobsSuite) TestRunJob() {
+	t := s.T()
+	staff := s.SeedStaff(t, fixtures.TestStaff.Email)
+	asStaff := httpframework.WithStaff(t, staff.User().ID())
+
+	// The job runs on start to catch up on the last week.
+	var before adminhttp.JobResponse
+	require.Eventually(t, func() bool {
+		before = s.job(t, asStaff)
+		return !before.Running && before.LastResult != ""
+	}, 10*time.Second, 50*time.Millisecond)
+	assert.Equal(t, "weekly on Monday at 06:00 UTC", before.Schedule)
+	assert.NotNil(t, before.NextRunAt)
+
+	s.HTTP.RunJob(t, weeklyReportJob, asStaff).RequireStatus(http.StatusAccepted)
+
+	require.Eventually(t, func() bool {
+		j := s.job(t, asStaff)
+		return !j.Running && j.Successes+j.Failures+j.Skips > before.Successes+before.Failures+before.Skips
+	}, 10*time.Second, 50*time.Millisecond)
+	after := s.job(t, asStaff)
+	assert.Equal(t, "success", after.LastResult, after.LastError)
+	assert.Equal(t, before.Successes+1, after.Successes)
+
+	s.HTTP.RunJob(t, "unknown", asStaff).AssertStatus(http.StatusNotFound)
+}
+
+func (s *JobsSuite) TestJobs_StaffOnly() {
+	t := s.T()
+	student := s.SeedStudent(t, fixtures.TestStudent.Email, s.SeedGroup(t))
+	asStudent := httpframework.WithStudent(t, student.User().ID())
+
+	s.HTTP.ListJobs(t, asStudent).AssertStatus(http.StatusForbidden)
+	s.HTTP.RunJob(t, weeklyReportJob, asStudent).AssertStatus(http.StatusForbidden)
+}
+
+func (s *JobsSuite) TestJobLocker_SingleHolder() {
+	t := s.T()
+	first := postgres.NewJobLocker(s.Pool(), nil)
+	second := postgres.NewJobLocker(s.Pool(), nil)
+
+	unlock, ok, err := first.TryLock(t.Context(), "locker-test")
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	_, ok, err = second.TryLock(t.Context(), "locker-test")
+	require.NoError(t, err)
+	assert.False(t, ok, "the lock is held by the first session")
+
+	otherUnlock, ok, err := second.TryLock(t.Context(), "another-job")
+	require.NoError(t, err)
+	assert.True(t, ok, "the jobs have their own locks")
+	otherUnlock()
+
+	unlock()
+	unlock, ok, err = second.TryLock(t.Context(), "locker-test")
+	require.NoError(t, err)
+	assert.True(t, ok, "the lock is released")
+	unlock()
+}
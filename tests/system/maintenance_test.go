@@ -0,0 +1,155 @@
+package system
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/app"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/event"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/registration"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/staffinvitation"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/roles"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/builders"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/fixtures"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/framework"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/framework/db"
+)
+
+// MaintenanceSuite runs the operations of the ucms-api maintenance commands
+// on the database of the suite.
+type MaintenanceSuite struct {
+	framework.IntegrationTestSuite
+}
+
+func TestMaintenanceSuite(t *testing.T) {
+	suite.Run(t, new(MaintenanceSuite))
+}
+
+func (s *MaintenanceSuite) newMaintenance() *app.Maintenance {
+	t := s.T()
+
+	m, err := app.NewMaintenance(t.Context(), framework.NewAppConfig(),
+		app.WithPool(s.Pool()),
+		app.WithMailSender(s.MockMailSender),
+		app.AllowMockMailSender(),
+		app.WithClock(s.Clock),
+	)
+	require.NoError(t, err)
+	t.Cleanup(m.Close)
+	return m
+}
+
+func (s *MaintenanceSuite) TestPromoteUser_StudentToStaff() {
+	t := s.T()
+	student := s.SeedStudent(t, fixtures.TestStudent.Email, s.SeedGroup(t))
+
+	res, err := s.newMaintenance().PromoteUser(t.Context(), student.User().Barcode(), roles.Staff)
+	require.NoError(t, err)
+	assert.Equal(t, roles.Student, res.FromRole)
+	assert.Equal(t, roles.Staff, res.ToRole)
+
+	s.DB.RequireUserExists(t, fixtures.TestStudent.Email).AssertRole(roles.Staff)
+	s.DB.RequireStaffExists(t, student.User().ID())
+}
+
+func (s *MaintenanceSuite) TestPromoteUser_Rejected() {
+	t := s.T()
+	student := s.SeedStudent(t, fixtures.TestStudent.Email, s.SeedGroup(t))
+	m := s.newMaintenance()
+
+	_, err := m.PromoteUser(t.Context(), student.User().Barcode(), roles.Global("admin"))
+	assert.True(t, errorx.IsCode(err, errorx.CodeInvalid), "unexpected error: %v", err)
+
+	_, err = m.PromoteUser(t.Context(), student.User().Barcode(), roles.Guest)
+	assert.True(t, errorx.IsConflict(err), "unexpected error: %v", err)
+
+	_, err = m.PromoteUser(t.Context(), user.Barcode("unknown"), roles.Staff)
+	assert.True(t, errorx.IsNotFound(err), "unexpected error: %v", err)
+
+	s.DB.RequireUserExists(t, fixtures.TestStudent.Email).AssertRole(roles.Student)
+}
+
+func (s *MaintenanceSuite) TestRevokeInvitation() {
+	t := s.T()
+	creator := s.SeedStaff(t, fixtures.TestStaff.Email)
+	invitation := builders.NewStaffInvitationBuilder().WithCreatorID(creator.User().ID()).Build()
+	s.DB.SeedStaffInvitation(t, invitation)
+	m := s.newMaintenance()
+
+	require.NoError(t, m.RevokeInvitation(t.Context(), invitation.ID()))
+	s.DB.RequireStaffInvitationExists(t, invitation.ID()).AssertDeleted(true)
+
+	require.NoError(t, m.RevokeInvitation(t.Context(), invitation.ID()), "revoking again changes nothing")
+
+	err := m.RevokeInvitation(t.Context(), staffinvitation.NewID())
+	assert.True(t, errorx.IsNotFound(err), "unexpected error: %v", err)
+}
+
+func (s *MaintenanceSuite) TestPurgeRegistrations() {
+	t := s.T()
+	now := s.Clock.Now()
+	stale := builders.NewRegistrationBuilder().WithEmail("stale@test.com").
+		WithCreatedAt(now.Add(-31 * 24 * time.Hour)).Build()
+	completed := builders.NewRegistrationBuilder().WithEmail("completed@test.com").
+		WithStatus(registration.StatusCompleted).WithCreatedAt(now.Add(-60 * 24 * time.Hour)).Build()
+	recent := builders.NewRegistrationBuilder().WithEmail("recent@test.com").
+		WithCreatedAt(now.Add(-time.Hour)).Build()
+	for _, reg := range []*registration.Registration{stale, completed, recent} {
+		s.DB.SeedRegistration(t, reg)
+	}
+
+	res, err := s.newMaintenance().PurgeRegistrations(t.Context(), 30*24*time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), res.Deleted)
+
+	s.DB.RequireRegistrationNotExists(t, stale.Email().String())
+	s.DB.RequireRegistrationExists(t, completed.Email().String())
+	s.DB.RequireRegistrationExists(t, recent.Email().String())
+}
+
+func (s *MaintenanceSuite) TestRequeueMailDeadLetters() {
+	t := s.T()
+	started := registration.RegistrationStarted{
+		Header:           event.NewEventHeader(),
+		RegistrationID:   registration.NewID(),
+		Email:            fixtures.ValidStudentEmail,
+		VerificationCode: "123456",
+	}
+	payload, err := json.Marshal(started)
+	require.NoError(t, err)
+
+	s.DB.SeedDeadLetter(t, db.DeadLetter{
+		Topic:   registration.EventStreamName,
+		Handler: "MailOnRegistrationStarted",
+		Reason:  "poison message: mail server rejected the sender",
+		Payload: payload,
+	})
+	s.DB.SeedDeadLetter(t, db.DeadLetter{
+		Topic:   registration.EventStreamName,
+		Handler: "MailOnRegistrationStarted",
+		Reason:  "poison message: decode",
+		Payload: []byte(`{"registration_id":`),
+	})
+	s.DB.SeedDeadLetter(t, db.DeadLetter{
+		Topic:   user.StudentEventStreamName,
+		Handler: "RegistrationOnStudentRegistered",
+		Reason:  "poison message: decode",
+		Payload: []byte(`{}`),
+	})
+
+	res, err := s.newMaintenance().RequeueMailDeadLetters(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, 1, res.Redelivered)
+	assert.Equal(t, 1, res.Failed)
+
+	assert.Len(t, s.MockMailSender.MailsTo(fixtures.ValidStudentEmail), 1)
+	s.DB.RequireDeadLetterCount(t, registration.EventStreamName, 1)
+	s.DB.RequireDeadLetterCount(t, user.StudentEventStreamName, 1)
+}
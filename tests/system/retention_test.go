@@ -0,0 +1,177 @@
+package system
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/adapters/repos/postgres"
+	adminhttp "gitlab.com/ucmsv2/ucms-backend/internal/ports/http/admin"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/clock"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/retention"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/fixtures"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/framework"
+	httpframework "gitlab.com/ucmsv2/ucms-backend/tests/integration/framework/http"
+)
+
+type RetentionSuite struct {
+	framework.IntegrationTestSuite
+}
+
+func TestRetentionSuite(t *testing.T) {
+	suite.Run(t, &RetentionSuite{IntegrationTestSuite: framework.IntegrationTestSuite{
+		Options: framework.SuiteOptions{Storage: true},
+	}})
+}
+
+type listArchivesResponse struct {
+	Archives []adminhttp.ArchiveResponse `json:"archives"`
+}
+
+func (s *RetentionSuite) newArchiver(t *testing.T, now time.Time, policies retention.Policies) *retention.Archiver {
+	t.Helper()
+	a, err := retention.NewArchiver(retention.ArchiverArgs{
+		Store:     postgres.NewRetentionRepo(s.Pool(), nil),
+		Storage:   s.S3Client,
+		Policies:  policies,
+		ChunkSize: 2,
+		TempDir:   t.TempDir(),
+		Clock:     clock.NewFake(now),
+	})
+	require.NoError(t, err)
+	return a
+}
+
+func (s *RetentionSuite) count(t *testing.T, table string) int {
+	t.Helper()
+	var n int
+	require.NoError(t, s.DB.QueryOne(t, "SELECT COUNT(*) FROM "+table).Scan(&n))
+	return n
+}
+
+func (s *RetentionSuite) seedImpersonation(t *testing.T, actorID, targetID uuid.UUID, startedAt time.Time) {
+	t.Helper()
+	s.DB.Exec(t, `
+		INSERT INTO impersonations (id, actor_id, target_id, target_role, started_at, expires_at)
+		VALUES ($1, $2, $3, 'student', $4, $4 + interval '1 hour')
+	`, uuid.New(), actorID, targetID, startedAt)
+}
+
+func (s *RetentionSuite) seedErrorEvent(t *testing.T, signature string, lastSeen time.Time) {
+	t.Helper()
+	s.DB.Exec(t, `
+		INSERT INTO error_events (signature, type, message, count, first_seen, last_seen)
+		VALUES ($1, 'string', 'exploded', 1, $2, $2)
+	`, signature, lastSeen)
+}
+
+func (s *RetentionSuite) TestArchive_ExpiredRowsOnly() {
+	t := s.T()
+	now := time.Now().UTC().Truncate(time.Second)
+	staff := s.SeedStaff(t, fixtures.TestStaff.Email)
+	student := s.SeedStudent(t, fixtures.TestStudent.Email, s.SeedGroup(t))
+	actorID, targetID := uuid.UUID(staff.User().ID()), uuid.UUID(student.User().ID())
+
+	for range 5 {
+		s.seedImpersonation(t, actorID, targetID, now.AddDate(0, 0, -120))
+	}
+	s.seedImpersonation(t, actorID, targetID, now.AddDate(0, 0, -10))
+	s.DB.Exec(t, `
+		INSERT INTO staff_bootstrap_audit (user_id, action, created_at)
+		VALUES ($1, 'created', $2), ($1, 'password_rotated', $3)
+	`, actorID, now.AddDate(0, 0, -120), now.AddDate(0, 0, -1))
+	s.seedErrorEvent(t, "old", now.AddDate(0, 0, -40))
+	s.seedErrorEvent(t, "recent", now.AddDate(0, 0, -1))
+
+	results, err := s.newArchiver(t, now, retention.Policies{
+		retention.CategoryAuthAudit:  {Days: 90, Mode: retention.ModeArchive},
+		retention.CategoryErrorInbox: {Days: 30},
+	}).Run(t.Context())
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+
+	assert.Equal(t, 1, s.count(t, "impersonations"), "only the expired impersonations are deleted")
+	assert.Equal(t, 1, s.count(t, "staff_bootstrap_audit"))
+	var signature string
+	require.NoError(t, s.DB.QueryOne(t, "SELECT signature FROM error_events").Scan(&signature))
+	assert.Equal(t, "recent", signature)
+
+	impersonations := results[0]
+	assert.Equal(t, int64(5), impersonations.Deleted)
+	require.NotNil(t, impersonations.Archive)
+	key := "archives/impersonations/" + now.Format(time.DateOnly) + ".ndjson.gz"
+	assert.Equal(t, key, impersonations.Archive.Key)
+	s.S3.RequireFile(t, key)
+
+	data, err := s.S3Client.GetObject(t.Context(), key)
+	require.NoError(t, err)
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	require.NoError(t, err)
+	var lines int
+	sc := bufio.NewScanner(gz)
+	for sc.Scan() {
+		assert.Contains(t, sc.Text(), `"actor_id"`, "a line is a row as JSON")
+		lines++
+	}
+	require.NoError(t, sc.Err())
+	assert.Equal(t, 5, lines)
+
+	var (
+		rows int64
+		size int64
+	)
+	require.NoError(t, s.DB.QueryOne(t,
+		"SELECT row_count, size_bytes FROM archive_manifests WHERE object_key = $1", key).Scan(&rows, &size))
+	assert.Equal(t, int64(5), rows)
+	assert.Equal(t, int64(len(data)), size)
+
+	assert.Nil(t, results[2].Archive, "the error inbox is deleted only")
+	assert.Equal(t, int64(1), results[2].Deleted)
+}
+
+func (s *RetentionSuite) TestListArchives() {
+	t := s.T()
+	now := time.Now().UTC().Truncate(time.Second)
+	staff := s.SeedStaff(t, fixtures.TestStaff.Email)
+	s.seedErrorEvent(t, "old", now.AddDate(0, 0, -40))
+
+	_, err := s.newArchiver(t, now, retention.Policies{
+		retention.CategoryErrorInbox: {Days: 30, Mode: retention.ModeArchive},
+	}).Run(t.Context())
+	require.NoError(t, err)
+
+	var res listArchivesResponse
+	s.HTTP.ListArchives(t, "error_events", httpframework.WithStaff(t, staff.User().ID())).
+		RequireStatus(http.StatusOK).
+		RequireParseJSON(&res)
+	require.Len(t, res.Archives, 1)
+	a := res.Archives[0]
+	assert.Equal(t, "error_inbox", a.Category)
+	assert.Equal(t, "error_events", a.Table)
+	assert.Equal(t, int64(1), a.Rows)
+	assert.Contains(t, a.DownloadURL, "X-Amz-Signature", "the archives are private")
+
+	s.HTTP.ListArchives(t, "impersonations", httpframework.WithStaff(t, staff.User().ID())).
+		RequireStatus(http.StatusOK).
+		RequireParseJSON(&res)
+	assert.Empty(t, res.Archives)
+
+	s.HTTP.ListArchives(t, "users", httpframework.WithStaff(t, staff.User().ID())).
+		AssertStatus(http.StatusBadRequest)
+}
+
+func (s *RetentionSuite) TestListArchives_StaffOnly() {
+	t := s.T()
+	student := s.SeedStudent(t, fixtures.TestStudent.Email, s.SeedGroup(t))
+
+	s.HTTP.ListArchives(t, "", httpframework.WithStudent(t, student.User().ID())).
+		AssertStatus(http.StatusForbidden)
+}
@@ -0,0 +1,98 @@
+package system
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"gitlab.com/ucmsv2/ucms-backend/pkg/schemaversion"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/framework"
+)
+
+type SchemaVersionSuite struct {
+	framework.IntegrationTestSuite
+}
+
+func TestSchemaVersionSuite(t *testing.T) {
+	suite.Run(t, new(SchemaVersionSuite))
+}
+
+type versionResponse struct {
+	Schema schemaversion.Versions `json:"schema"`
+}
+
+type readyResponse struct {
+	Ready  bool   `json:"ready"`
+	Reason string `json:"reason"`
+}
+
+// applyNewerMigration moves the schema one migration past the binary, as a
+// newer release of a rolling deploy would, and restores it after the test.
+func (s *SchemaVersionSuite) applyNewerMigration(t *testing.T, name string, breaking bool) uint {
+	t.Helper()
+	expected := s.Schema.Versions().Expected
+	newer := expected + 1
+
+	_, err := s.Pool().Exec(t.Context(), `UPDATE schema_migrations SET version = $1`, int64(newer))
+	require.NoError(t, err)
+	_, err = s.Pool().Exec(t.Context(),
+		`INSERT INTO schema_manifest (version, name, breaking) VALUES ($1, $2, $3)`, int64(newer), name, breaking)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		ctx := context.Background()
+		_, err := s.Pool().Exec(ctx, `UPDATE schema_migrations SET version = $1`, int64(expected))
+		assert.NoError(t, err)
+		_, err = s.Pool().Exec(ctx, `DELETE FROM schema_manifest WHERE version = $1`, int64(newer))
+		assert.NoError(t, err)
+		assert.NoError(t, s.Schema.Check(ctx))
+	})
+
+	require.NoError(t, s.Schema.Check(t.Context()))
+	return newer
+}
+
+func (s *SchemaVersionSuite) TestMatchingSchema() {
+	t := s.T()
+	require.NoError(t, s.Schema.Check(t.Context()))
+
+	var version versionResponse
+	s.HTTP.GetVersion(t).RequireStatus(http.StatusOK).RequireParseJSON(&version)
+	assert.NotZero(t, version.Schema.Expected)
+	assert.Equal(t, version.Schema.Expected, version.Schema.Applied)
+	assert.False(t, version.Schema.Dirty)
+
+	var ready readyResponse
+	s.HTTP.GetReady(t).RequireStatus(http.StatusOK).RequireParseJSON(&ready)
+	assert.True(t, ready.Ready)
+}
+
+func (s *SchemaVersionSuite) TestNewerCompatibleSchema_StaysReady() {
+	t := s.T()
+	newer := s.applyNewerMigration(t, "add_users_nickname", false)
+
+	var version versionResponse
+	s.HTTP.GetVersion(t).RequireStatus(http.StatusOK).RequireParseJSON(&version)
+	assert.Equal(t, newer, version.Schema.Applied)
+	assert.Equal(t, newer-1, version.Schema.Expected)
+
+	s.HTTP.GetReady(t).RequireStatus(http.StatusOK)
+}
+
+func (s *SchemaVersionSuite) TestBreakingSchema_NotReady() {
+	t := s.T()
+	newer := s.applyNewerMigration(t, "drop_users_phone"+schemaversion.BreakingSuffix, true)
+
+	var ready readyResponse
+	s.HTTP.GetReady(t).RequireStatus(http.StatusServiceUnavailable).RequireParseJSON(&ready)
+	assert.False(t, ready.Ready)
+	assert.Equal(t, schemaversion.ReasonVersionMismatch, ready.Reason)
+
+	var version versionResponse
+	s.HTTP.GetVersion(t).RequireStatus(http.StatusOK).RequireParseJSON(&version)
+	assert.Equal(t, newer, version.Schema.Applied)
+	assert.Equal(t, newer-1, version.Schema.Expected)
+}
@@ -0,0 +1,102 @@
+package system
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	adminhttp "gitlab.com/ucmsv2/ucms-backend/internal/ports/http/admin"
+	registrationhttp "gitlab.com/ucmsv2/ucms-backend/internal/ports/http/registration"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/fixtures"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/framework"
+	httpframework "gitlab.com/ucmsv2/ucms-backend/tests/integration/framework/http"
+)
+
+const completeRegistrationRoute = "POST /v1/registrations/students/complete"
+
+type ValidationStatsSuite struct {
+	framework.IntegrationTestSuite
+}
+
+func TestValidationStatsSuite(t *testing.T) {
+	suite.Run(t, new(ValidationStatsSuite))
+}
+
+type listValidationStatsResponse struct {
+	Stats []adminhttp.ValidationStatResponse `json:"stats"`
+	Days  int                                `json:"days"`
+}
+
+func (r listValidationStatsResponse) count(route, field string) int64 {
+	for _, s := range r.Stats {
+		if s.Route == route && s.Field == field {
+			return s.Count
+		}
+	}
+	return 0
+}
+
+func (s *ValidationStatsSuite) TestCompleteRegistration_FirstNameCounted() {
+	t := s.T()
+	staff := s.SeedStaff(t, fixtures.TestStaff.Email)
+	asStaff := httpframework.WithStaff(t, staff.User().ID())
+	const secretName = "Secret4821"
+
+	var before listValidationStatsResponse
+	s.HTTP.ListValidationStats(t, completeRegistrationRoute, 0, asStaff).
+		RequireStatus(http.StatusOK).
+		RequireParseJSON(&before)
+	assert.Equal(t, 7, before.Days)
+
+	for range 2 {
+		s.HTTP.CompleteStudentRegistration(t, registrationhttp.CompleteStudentRegistrationRequest{
+			Email:            "stats@test.com",
+			VerificationCode: "ABC123",
+			Password:         fixtures.TestStudent.Password,
+			Barcode:          "STU901",
+			Username:         "statsuser",
+			FirstName:        secretName,
+			LastName:         "Student",
+			GroupId:          uuid.UUID(fixtures.SEGroup.ID),
+		}).AssertBadRequest()
+	}
+	require.NoError(t, s.ValidationRecorder.Flush(t.Context()))
+
+	var after listValidationStatsResponse
+	s.HTTP.ListValidationStats(t, completeRegistrationRoute, 1, asStaff).
+		RequireStatus(http.StatusOK).
+		RequireParseJSON(&after)
+	assert.Equal(t, before.count(completeRegistrationRoute, "first_name")+2,
+		after.count(completeRegistrationRoute, "first_name"))
+	assert.Zero(t, after.count(completeRegistrationRoute, "last_name"), "valid fields are not counted")
+	for _, stat := range after.Stats {
+		assert.Equal(t, completeRegistrationRoute, stat.Route, "narrowed to the route")
+	}
+
+	var rows string
+	err := s.Pool().QueryRow(t.Context(),
+		"SELECT coalesce(string_agg(validation_failures::text, ' '), '') FROM validation_failures").Scan(&rows)
+	require.NoError(t, err)
+	assert.Contains(t, rows, "first_name")
+	assert.NotContains(t, rows, secretName, "submitted values are never stored")
+}
+
+func (s *ValidationStatsSuite) TestListValidationStats() {
+	s.T().Run("staff only", func(t *testing.T) {
+		student := s.SeedStudent(t, fixtures.TestStudent.Email, s.SeedGroup(t))
+
+		s.HTTP.ListValidationStats(t, "", 0, httpframework.WithStudent(t, student.User().ID())).
+			AssertStatus(http.StatusForbidden)
+	})
+
+	s.T().Run("days out of range", func(t *testing.T) {
+		staff := s.SeedStaff(t, fixtures.TestStaff.Email)
+
+		s.HTTP.ListValidationStats(t, "", 91, httpframework.WithStaff(t, staff.User().ID())).
+			AssertStatus(http.StatusBadRequest)
+	})
+}
@@ -0,0 +1,214 @@
+package term
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/application/report/reportquery"
+	"gitlab.com/ucmsv2/ucms-backend/internal/application/student/studentquery"
+	"gitlab.com/ucmsv2/ucms-backend/internal/application/term/termquery"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/registration"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/term"
+	studenthttp "gitlab.com/ucmsv2/ucms-backend/internal/ports/http/student"
+	termhttp "gitlab.com/ucmsv2/ucms-backend/internal/ports/http/term"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/errorx"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/builders"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/fixtures"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/framework"
+	httpframework "gitlab.com/ucmsv2/ucms-backend/tests/integration/framework/http"
+)
+
+type TermSuite struct {
+	framework.IntegrationTestSuite
+}
+
+func TestTermSuite(t *testing.T) {
+	suite.Run(t, new(TermSuite))
+}
+
+var (
+	fallStart   = time.Date(2025, time.September, 1, 0, 0, 0, 0, time.UTC)
+	fallEnd     = time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)
+	springStart = time.Date(2026, time.January, 15, 0, 0, 0, 0, time.UTC)
+	springEnd   = time.Date(2026, time.June, 1, 0, 0, 0, 0, time.UTC)
+)
+
+// createTerm creates the term and returns its id.
+func (s *TermSuite) createTerm(t *testing.T, code string, startsAt, endsAt time.Time, opts ...httpframework.RequestBuilderOptions) string {
+	t.Helper()
+	var res struct {
+		Term struct {
+			ID string `json:"id"`
+		} `json:"term"`
+	}
+	s.HTTP.CreateTerm(t, termhttp.CreateTermRequest{Code: code, Name: code, StartsAt: startsAt, EndsAt: endsAt}, opts...).
+		RequireStatus(http.StatusCreated).
+		RequireParseJSON(&res)
+	return res.Term.ID
+}
+
+func (s *TermSuite) currentTerm(t *testing.T, opts ...httpframework.RequestBuilderOptions) *httpframework.Response {
+	t.Helper()
+	return s.HTTP.GetCurrentTerm(t, opts...)
+}
+
+func (s *TermSuite) TestOverlapRejected() {
+	t := s.T()
+	staff := s.SeedStaff(t, fixtures.TestStaff.Email)
+	asStaff := httpframework.WithStaff(t, staff.User().ID())
+	s.createTerm(t, "2025-Fall", fallStart, fallEnd, asStaff)
+
+	var body struct {
+		Code    errorx.Code `json:"code"`
+		Message string      `json:"message"`
+	}
+	s.HTTP.CreateTerm(t, termhttp.CreateTermRequest{
+		Code:     "2025-Winter",
+		Name:     "Winter 2025",
+		StartsAt: fallEnd.Add(-24 * time.Hour),
+		EndsAt:   springStart,
+	}, asStaff).
+		RequireStatus(http.StatusUnprocessableEntity).
+		RequireParseJSON(&body)
+	assert.Equal(t, errorx.CodeTermOverlap, body.Code)
+	assert.Contains(t, body.Message, "2025-Fall", "the conflicting term is named")
+
+	t.Run("adjacent terms do not overlap", func(t *testing.T) {
+		s.createTerm(t, "2026-Winter", fallEnd, springStart, asStaff)
+	})
+
+	t.Run("update into an overlap", func(t *testing.T) {
+		id := s.createTerm(t, "2026-Spring", springStart, springEnd, asStaff)
+		s.HTTP.UpdateTerm(t, id, `{"starts_at": "2026-01-10T00:00:00Z"}`, asStaff).
+			AssertStatus(http.StatusUnprocessableEntity).
+			AssertContainsMessage("2026-Winter")
+	})
+
+	t.Run("staff only", func(t *testing.T) {
+		s.HTTP.ListTerms(t).AssertStatus(http.StatusUnauthorized)
+	})
+}
+
+func (s *TermSuite) TestCurrentTermBoundaries() {
+	t := s.T()
+	staff := s.SeedStaff(t, fixtures.TestStaff.Email)
+	asStaff := httpframework.WithStaff(t, staff.User().ID())
+	s.createTerm(t, "2025-Fall", fallStart, fallEnd, asStaff)
+
+	current := func(t *testing.T, at time.Time) *httpframework.Response {
+		t.Helper()
+		s.Clock.Set(at)
+		return s.currentTerm(t, asStaff)
+	}
+
+	t.Run("start is inclusive", func(t *testing.T) {
+		var res struct {
+			Term termquery.TermResponse `json:"term"`
+		}
+		current(t, fallStart).RequireStatus(http.StatusOK).RequireParseJSON(&res)
+		assert.Equal(t, "2025-Fall", res.Term.Code)
+		assert.True(t, res.Term.Current)
+	})
+
+	t.Run("before the start", func(t *testing.T) {
+		current(t, fallStart.Add(-time.Second)).AssertStatus(http.StatusNotFound)
+	})
+
+	t.Run("end is exclusive", func(t *testing.T) {
+		current(t, fallEnd).AssertStatus(http.StatusNotFound)
+	})
+}
+
+func (s *TermSuite) TestDashboardByTerm() {
+	t := s.T()
+	staff := s.SeedStaff(t, fixtures.TestStaff.Email)
+	asStaff := httpframework.WithStaff(t, staff.User().ID())
+	fallID := s.createTerm(t, "2025-Fall", fallStart, fallEnd, asStaff)
+	s.createTerm(t, "2026-Spring", springStart, springEnd, asStaff)
+
+	seedRegistration := func(email string, at time.Time) {
+		s.DB.SeedRegistration(t, builders.NewRegistrationBuilder().
+			WithEmail(email).
+			WithStatus(registration.StatusPending).
+			WithCreatedAt(at).
+			Build())
+	}
+	seedRegistration("fall-1@example.com", fallStart)
+	seedRegistration("fall-2@example.com", fallEnd.Add(-time.Hour))
+	seedRegistration("break@example.com", fallEnd.Add(time.Hour))
+	seedRegistration("spring@example.com", springStart.Add(time.Hour))
+	s.Clock.Set(springStart.Add(24 * time.Hour))
+
+	dashboard := func(res *httpframework.Response) reportquery.Dashboard {
+		t.Helper()
+		var body struct {
+			Dashboard reportquery.Dashboard `json:"dashboard"`
+		}
+		res.RequireStatus(http.StatusOK).RequireParseJSON(&body)
+		return body.Dashboard
+	}
+
+	d := dashboard(s.HTTP.GetDashboard(t, asStaff))
+	require.NotNil(t, d.Term, "the dashboard defaults to the current term")
+	assert.Equal(t, "2026-Spring", d.Term.Code)
+	assert.Equal(t, int64(1), d.Registrations.Total)
+
+	d = dashboard(s.HTTP.GetTermDashboard(t, fallID, asStaff))
+	require.NotNil(t, d.Term)
+	assert.Equal(t, "2025-Fall", d.Term.Code)
+	assert.Equal(t, int64(2), d.Registrations.Total)
+	assert.Equal(t, fallEnd, d.Registrations.Until.Time)
+
+	s.HTTP.GetTermDashboard(t, uuid.NewString(), asStaff).AssertStatus(http.StatusNotFound)
+	s.HTTP.GetTermDashboard(t, "fall", asStaff).AssertStatus(http.StatusBadRequest)
+}
+
+func (s *TermSuite) TestGroupTerm() {
+	t := s.T()
+	staff := s.SeedStaff(t, fixtures.TestStaff.Email)
+	asStaff := httpframework.WithStaff(t, staff.User().ID())
+	fallID := s.createTerm(t, "2025-Fall", fallStart, fallEnd, asStaff)
+	springID := s.createTerm(t, "2026-Spring", springStart, springEnd, asStaff)
+	fall, err := uuid.Parse(fallID)
+	require.NoError(t, err)
+	fallTerm := term.ID(fall)
+
+	var created struct {
+		Group struct {
+			ID uuid.UUID `json:"id"`
+		} `json:"group"`
+	}
+	s.HTTP.CreateGroup(t, studenthttp.CreateGroupRequest{
+		Name:   "SE-2501",
+		Year:   "25",
+		Major:  string(fixtures.SEGroup.Major),
+		TermID: &fallTerm,
+	}, asStaff).
+		RequireStatus(http.StatusCreated).
+		RequireParseJSON(&created)
+
+	termOf := func() *string {
+		var res struct {
+			Group studentquery.GetGroupResponse `json:"group"`
+		}
+		s.HTTP.GetGroup(t, created.Group.ID, asStaff).RequireStatus(http.StatusOK).RequireParseJSON(&res)
+		return res.Group.TermID
+	}
+	require.NotNil(t, termOf())
+	assert.Equal(t, fallID, *termOf())
+
+	s.HTTP.UpdateGroup(t, created.Group.ID, `{"term_id": "`+springID+`"}`, asStaff).RequireStatus(http.StatusOK)
+	require.NotNil(t, termOf())
+	assert.Equal(t, springID, *termOf(), "the group rolled over to the next term")
+
+	s.HTTP.UpdateGroup(t, created.Group.ID, `{"term_id": "`+uuid.NewString()+`"}`, asStaff).
+		AssertStatus(http.StatusNotFound)
+
+	s.HTTP.DeleteTerm(t, springID, asStaff).AssertStatus(http.StatusConflict)
+}
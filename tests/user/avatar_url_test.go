@@ -0,0 +1,60 @@
+package user
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/valueobject/avatars"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/storagex"
+)
+
+func (s *UpdateAvatarSuite) TestAvatarURL_SignedURLAuthorizesPrivateBucket() {
+	t := s.T()
+
+	key := user.AvatarKeyPrefix + user.NewID().String()
+	require.NoError(t, s.S3Client.UploadFile(t.Context(), key, strings.NewReader("private avatar"), "image/png"))
+
+	urls, err := storagex.NewURLBuilder(storagex.URLBuilderConfig{
+		Strategy:        storagex.URLStrategySigned,
+		Presigner:       s.S3Client,
+		SignedURLExpiry: time.Minute,
+	})
+	require.NoError(t, err)
+	builder := user.NewAvatarURLBuilder(urls)
+
+	signed, err := builder.Build(t.Context(), avatars.NewS3Avatar(key))
+	require.NoError(t, err)
+
+	u, err := url.Parse(signed)
+	require.NoError(t, err)
+	assert.True(t, strings.HasSuffix(u.Path, "/"+key), "signed url should point to the object, got %s", signed)
+	assert.NotEmpty(t, u.Query().Get("X-Amz-Signature"))
+	assert.Equal(t, "60", u.Query().Get("X-Amz-Expires"))
+
+	// The test bucket has no anonymous read policy, the bare URL must be rejected.
+	unsigned := *u
+	unsigned.RawQuery = ""
+	resp, err := http.Get(unsigned.String())
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode, "anonymous access should be denied")
+
+	resp, err = http.Get(signed)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "private avatar", string(body))
+
+	again, err := builder.Build(t.Context(), avatars.NewS3Avatar(key))
+	require.NoError(t, err)
+	assert.Equal(t, signed, again, "signed url should be cached")
+}
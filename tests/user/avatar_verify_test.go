@@ -0,0 +1,112 @@
+package user
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	postgresrepo "gitlab.com/ucmsv2/ucms-backend/internal/adapters/repos/postgres"
+	"gitlab.com/ucmsv2/ucms-backend/internal/application/student/studentquery"
+	usercmd "gitlab.com/ucmsv2/ucms-backend/internal/application/user/cmd"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/group"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/builders"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/framework"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/framework/event"
+	httpframework "gitlab.com/ucmsv2/ucms-backend/tests/integration/framework/http"
+)
+
+type AvatarVerifySuite struct {
+	framework.IntegrationTestSuite
+}
+
+func TestAvatarVerifySuite(t *testing.T) {
+	suite.Run(t, &AvatarVerifySuite{IntegrationTestSuite: framework.IntegrationTestSuite{
+		Options: framework.SuiteOptions{Storage: true, VerifyAvatars: true},
+	}})
+}
+
+// seedStudentWithAvatar seeds a student whose avatar object is uploaded, and
+// deleted again unless keep.
+func (s *AvatarVerifySuite) seedStudentWithAvatar(t *testing.T, email string, groupID group.ID, keep bool) (*user.Student, string) {
+	t.Helper()
+	key := user.AvatarKeyPrefix + user.NewID().String() + "/1"
+	b := builders.NewStudentBuilder().WithEmail(email).WithGroupID(groupID)
+	b.WithS3Avatar(key)
+	student := b.Build()
+	s.DB.SeedStudent(t, student)
+
+	require.NoError(t, s.S3Client.UploadFile(t.Context(), key, strings.NewReader("avatar"), "image/png"))
+	if !keep {
+		require.NoError(t, s.S3Client.DeleteFile(t.Context(), key))
+	}
+	return student, key
+}
+
+func (s *AvatarVerifySuite) avatarURL(t *testing.T, id user.ID) string {
+	t.Helper()
+	var res struct {
+		Student studentquery.GetStudentResponse `json:"student"`
+	}
+	s.HTTP.GetStudentProfile(t, httpframework.WithStudent(t, id)).
+		RequireStatus(http.StatusOK).
+		RequireParseJSON(&res)
+	return res.Student.AvatarURL
+}
+
+func (s *AvatarVerifySuite) avatarKey(t *testing.T, id user.ID) string {
+	t.Helper()
+	var key string
+	err := s.Pool().QueryRow(t.Context(), "SELECT avatar_s3_key FROM users WHERE id = $1", id).Scan(&key)
+	require.NoError(t, err)
+	return key
+}
+
+func (s *AvatarVerifySuite) TestProfile_MissingAvatarObject() {
+	t := s.T()
+	groupID := s.SeedGroup(t)
+	present, presentKey := s.seedStudentWithAvatar(t, "present@astanait.edu.kz", groupID, true)
+	gone, goneKey := s.seedStudentWithAvatar(t, "gone@astanait.edu.kz", groupID, false)
+
+	assert.True(t, strings.HasSuffix(s.avatarURL(t, present.User().ID()), "/"+presentKey))
+	assert.Empty(t, s.avatarURL(t, gone.User().ID()), "a missing object gets no url")
+
+	e := event.RequireEventuallyEvent[*user.UserAvatarMissing](t, s.Event, 5*time.Second)
+	assert.Equal(t, gone.User().ID(), e.UserID)
+	assert.Equal(t, goneKey, e.Avatar.S3Key)
+	require.Eventually(t, func() bool { return s.avatarKey(t, gone.User().ID()) == "" }, 5*time.Second, 50*time.Millisecond,
+		"the stale reference is cleared")
+	assert.Equal(t, presentKey, s.avatarKey(t, present.User().ID()))
+}
+
+func (s *AvatarVerifySuite) TestCollectOrphanedAvatars_RepairsMissingObjects() {
+	t := s.T()
+	groupID := s.SeedGroup(t)
+	read, _ := s.seedStudentWithAvatar(t, "read@astanait.edu.kz", groupID, false)
+	unread, _ := s.seedStudentWithAvatar(t, "unread@astanait.edu.kz", groupID, false)
+	kept, keptKey := s.seedStudentWithAvatar(t, "kept@astanait.edu.kz", groupID, true)
+
+	assert.Empty(t, s.avatarURL(t, read.User().ID()))
+
+	handler := usercmd.NewCollectOrphanedAvatarsHandler(usercmd.CollectOrphanedAvatarsHandlerArgs{
+		Storage:  s.S3Client,
+		UserRepo: postgresrepo.NewUserRepo(s.Pool(), nil, nil),
+	})
+	res, err := handler.Handle(t.Context(), usercmd.CollectOrphanedAvatars{})
+	require.NoError(t, err)
+	assert.Zero(t, res.Failed)
+	// The read may have cleared its reference before the pass ran.
+	assert.Contains(t, res.Dangling, unread.User().ID(), "a reference no profile read noticed is repaired")
+	assert.NotContains(t, res.Dangling, kept.User().ID())
+
+	assert.Empty(t, s.avatarKey(t, read.User().ID()))
+	assert.Empty(t, s.avatarKey(t, unread.User().ID()))
+	assert.Equal(t, keptKey, s.avatarKey(t, kept.User().ID()))
+	assert.Empty(t, s.avatarURL(t, unread.User().ID()))
+	s.S3.RequireFile(t, keptKey)
+}
@@ -0,0 +1,78 @@
+package user
+
+import (
+	"strings"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	postgresrepo "gitlab.com/ucmsv2/ucms-backend/internal/adapters/repos/postgres"
+	usercmd "gitlab.com/ucmsv2/ucms-backend/internal/application/user/cmd"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/builders"
+)
+
+func (s *UpdateAvatarSuite) TestCollectOrphanedAvatars() {
+	t := s.T()
+
+	referencedKey := user.AvatarKeyPrefix + user.NewID().String() + "/1"
+	u := builders.NewUserBuilder().WithS3Avatar(referencedKey).Build()
+	s.DB.SeedUser(t, u)
+	require.NoError(t, s.S3Client.UploadFile(t.Context(), referencedKey, strings.NewReader("referenced"), "image/png"))
+
+	orphanKey := user.AvatarKeyPrefix + user.NewID().String() + "/1"
+	require.NoError(t, s.S3Client.UploadFile(t.Context(), orphanKey, strings.NewReader("orphan"), "image/png"))
+
+	handler := usercmd.NewCollectOrphanedAvatarsHandler(usercmd.CollectOrphanedAvatarsHandlerArgs{
+		Storage:  s.S3Client,
+		UserRepo: postgresrepo.NewUserRepo(s.Pool(), nil, nil),
+		Now:      func() time.Time { return time.Now().Add(48 * time.Hour) },
+	})
+
+	res, err := handler.Handle(t.Context(), usercmd.CollectOrphanedAvatars{DryRun: true})
+	require.NoError(t, err)
+	assert.Contains(t, res.Orphaned, orphanKey)
+	assert.NotContains(t, res.Orphaned, referencedKey)
+	s.S3.RequireFile(t, orphanKey)
+
+	res, err = handler.Handle(t.Context(), usercmd.CollectOrphanedAvatars{})
+	require.NoError(t, err)
+	assert.Contains(t, res.Orphaned, orphanKey)
+	assert.Zero(t, res.Failed)
+
+	s.S3.RequireNoFile(t, orphanKey)
+	s.S3.RequireFile(t, referencedKey)
+}
+
+func (s *UpdateAvatarSuite) TestCollectOrphanedAvatars_KeepsPendingUploads() {
+	t := s.T()
+	u := builders.NewUserBuilder().Build()
+	s.DB.SeedUser(t, u)
+	repo := postgresrepo.NewUserRepo(s.Pool(), nil, nil)
+
+	pendingKey := user.AvatarKeyPrefix + user.NewID().String() + "/1"
+	require.NoError(t, s.S3Client.UploadFile(t.Context(), pendingKey, strings.NewReader("pending"), "image/png"))
+	token, err := repo.CreatePendingUpload(t.Context(), u.ID(), pendingKey)
+	require.NoError(t, err)
+
+	handler := usercmd.NewCollectOrphanedAvatarsHandler(usercmd.CollectOrphanedAvatarsHandlerArgs{
+		Storage:  s.S3Client,
+		UserRepo: repo,
+		Now:      func() time.Time { return time.Now().Add(48 * time.Hour) },
+	})
+
+	res, err := handler.Handle(t.Context(), usercmd.CollectOrphanedAvatars{})
+	require.NoError(t, err)
+	assert.NotContains(t, res.Orphaned, pendingKey)
+	s.S3.RequireFile(t, pendingKey)
+
+	require.NoError(t, repo.ConsumePendingUpload(t.Context(), token, u.ID(), pendingKey))
+	require.ErrorIs(t, repo.ConsumePendingUpload(t.Context(), token, u.ID(), pendingKey), user.ErrUploadExpired,
+		"a token is consumed once")
+
+	res, err = handler.Handle(t.Context(), usercmd.CollectOrphanedAvatars{})
+	require.NoError(t, err)
+	assert.Contains(t, res.Orphaned, pendingKey)
+	s.S3.RequireNoFile(t, pendingKey)
+}
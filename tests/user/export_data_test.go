@@ -0,0 +1,83 @@
+package user
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"path"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/application/user/userquery"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/storagex"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/builders"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/fixtures"
+	httpframework "gitlab.com/ucmsv2/ucms-backend/tests/integration/framework/http"
+)
+
+func (s *UpdateAvatarSuite) TestExportUserData_HappyPath() {
+	t := s.T()
+	u := builders.NewUserBuilder().Build()
+	s.DB.SeedUser(t, u)
+
+	avatar := fixtures.UniqueJPEGAvatar()
+	s.HTTP.UpdateUserAvatar(t, avatar, httpframework.WithStudent(t, u.ID())).
+		RequireStatus(http.StatusOK)
+	dbUser := s.DB.RequireUserExists(t, u.Email().String()).User()
+
+	resp := s.HTTP.ExportUserData(t, httpframework.WithStudent(t, u.ID())).
+		RequireStatus(http.StatusOK).
+		AssertHeader("Content-Type", "application/zip").
+		AssertHeaderContains("Content-Disposition", "ucms-export-"+u.ID().String()+".zip")
+
+	body := resp.Body.Bytes()
+	zr, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+	require.NoError(t, err)
+
+	var profile userquery.ExportedProfile
+	require.NoError(t, json.Unmarshal(readZIPEntry(t, zr, "profile.json"), &profile))
+	assert.Equal(t, u.ID().String(), profile.ID)
+	assert.Equal(t, u.Email().String(), profile.Email)
+
+	assert.Equal(t, avatar, readZIPEntry(t, zr, "avatar/"+path.Base(dbUser.Avatar().S3Key)))
+
+	var manifest storagex.ZIPManifest
+	require.NoError(t, json.Unmarshal(readZIPEntry(t, zr, storagex.ZIPManifestName), &manifest))
+	assert.Empty(t, manifest.Missing)
+	assert.Len(t, manifest.Files, 2)
+}
+
+func (s *UpdateAvatarSuite) TestExportUserData_MissingAvatarObject() {
+	t := s.T()
+	u := builders.NewUserBuilder().WithGeneratedS3Avatar().Build()
+	s.DB.SeedUser(t, u)
+
+	resp := s.HTTP.ExportUserData(t, httpframework.WithStudent(t, u.ID())).
+		RequireStatus(http.StatusOK)
+
+	body := resp.Body.Bytes()
+	zr, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+	require.NoError(t, err)
+
+	var manifest storagex.ZIPManifest
+	require.NoError(t, json.Unmarshal(readZIPEntry(t, zr, storagex.ZIPManifestName), &manifest))
+	assert.Equal(t, []string{u.Avatar().S3Key}, manifest.Missing)
+}
+
+func (s *UpdateAvatarSuite) TestExportUserData_Unauthorized() {
+	t := s.T()
+	s.HTTP.ExportUserData(t, httpframework.WithAnon()).
+		AssertStatus(http.StatusUnauthorized)
+}
+
+func readZIPEntry(t require.TestingT, zr *zip.Reader, name string) []byte {
+	rc, err := zr.Open(name)
+	require.NoError(t, err)
+	defer rc.Close()
+	content, err := io.ReadAll(rc)
+	require.NoError(t, err)
+	return content
+}
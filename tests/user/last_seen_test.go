@@ -0,0 +1,158 @@
+package user
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"gitlab.com/ucmsv2/ucms-backend/internal/application/search/searchquery"
+	"gitlab.com/ucmsv2/ucms-backend/internal/application/staff/staffquery"
+	"gitlab.com/ucmsv2/ucms-backend/internal/domain/user"
+	authhttp "gitlab.com/ucmsv2/ucms-backend/internal/ports/http/auth"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/lastseen"
+	"gitlab.com/ucmsv2/ucms-backend/pkg/pagination"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/builders"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/fixtures"
+	"gitlab.com/ucmsv2/ucms-backend/tests/integration/framework"
+	httpframework "gitlab.com/ucmsv2/ucms-backend/tests/integration/framework/http"
+)
+
+type LastSeenSuite struct {
+	framework.IntegrationTestSuite
+}
+
+func TestLastSeenSuite(t *testing.T) {
+	suite.Run(t, new(LastSeenSuite))
+}
+
+type listInactiveUsersResponse struct {
+	Users []searchquery.UserHit `json:"users"`
+	Meta  pagination.Meta       `json:"meta"`
+}
+
+func (r listInactiveUsersResponse) ids() []string {
+	ids := make([]string, len(r.Users))
+	for i, u := range r.Users {
+		ids[i] = u.ID
+	}
+	return ids
+}
+
+func (s *LastSeenSuite) lastSeenAt(t *testing.T, id user.ID) *time.Time {
+	t.Helper()
+	var at *time.Time
+	err := s.Pool().QueryRow(t.Context(), "SELECT last_seen_at FROM users WHERE id = $1", id).Scan(&at)
+	require.NoError(t, err)
+	return at
+}
+
+func (s *LastSeenSuite) login(t *testing.T, email string) httpframework.RequestBuilderOptions {
+	t.Helper()
+	res := s.HTTP.Login(t, email, fixtures.TestStudent.Password).RequireSuccess()
+	access := res.GetCookie(authhttp.AccessJWTCookie)
+	require.NotNil(t, access)
+	return httpframework.WithAccessTokenCookie(access.Value)
+}
+
+func (s *LastSeenSuite) TestLastSeen_WrittenOncePerInterval() {
+	t := s.T()
+	staff := s.SeedStaff(t, fixtures.TestStaff.Email)
+	assert.Nil(t, s.lastSeenAt(t, staff.User().ID()))
+	session := s.login(t, fixtures.TestStaff.Email)
+	writes := s.LastSeen.Writes()
+
+	s.HTTP.GetStaffProfile(t, session).RequireStatus(http.StatusOK)
+	require.NoError(t, s.LastSeen.Flush(t.Context()))
+	first := s.lastSeenAt(t, staff.User().ID())
+	require.NotNil(t, first, "the first request after the login is recorded")
+	assert.WithinDuration(t, s.Clock.Now(), *first, time.Minute)
+	assert.Equal(t, writes+1, s.LastSeen.Writes())
+
+	var profile struct {
+		Staff staffquery.GetStaffResponse `json:"staff"`
+	}
+	s.HTTP.GetStaffProfile(t, session).RequireStatus(http.StatusOK).RequireParseJSON(&profile)
+	require.NoError(t, s.LastSeen.Flush(t.Context()))
+	require.NotNil(t, profile.Staff.LastSeenAt)
+	assert.WithinDuration(t, *first, profile.Staff.LastSeenAt.Time, time.Second)
+	assert.Equal(t, writes+1, s.LastSeen.Writes(), "a request within the interval does not write")
+	assert.Equal(t, first.UTC(), s.lastSeenAt(t, staff.User().ID()).UTC())
+
+	s.Clock.Advance(lastseen.MinInterval + time.Minute)
+	s.HTTP.GetStaffProfile(t, session).RequireStatus(http.StatusOK)
+	require.NoError(t, s.LastSeen.Flush(t.Context()))
+	assert.Equal(t, writes+2, s.LastSeen.Writes())
+	assert.True(t, s.lastSeenAt(t, staff.User().ID()).After(*first))
+}
+
+func (s *LastSeenSuite) TestLastSeen_ImpersonationNotRecorded() {
+	t := s.T()
+	staff := s.SeedStaff(t, fixtures.TestStaff.Email)
+	student := s.SeedStudent(t, fixtures.TestStudent.Email, s.SeedGroup(t))
+
+	res := s.HTTP.Impersonate(t, student.User().Barcode().String(), httpframework.WithStaff(t, staff.User().ID())).
+		RequireStatus(http.StatusOK)
+	access := res.GetCookie(authhttp.AccessJWTCookie)
+	require.NotNil(t, access)
+	s.HTTP.GetStudentProfile(t, httpframework.WithAccessTokenCookie(access.Value)).RequireStatus(http.StatusOK)
+	require.NoError(t, s.LastSeen.Flush(t.Context()))
+
+	assert.Nil(t, s.lastSeenAt(t, student.User().ID()), "a staff member acting as the user is not the user")
+}
+
+func (s *LastSeenSuite) TestListInactiveUsers() {
+	t := s.T()
+	staff := s.SeedStaff(t, fixtures.TestStaff.Email)
+	asStaff := httpframework.WithStaff(t, staff.User().ID())
+	never := builders.NewUserBuilder().Build()
+	s.DB.SeedUser(t, never)
+	stale := builders.NewUserBuilder().Build()
+	s.DB.SeedUser(t, stale)
+	_, err := s.Pool().Exec(t.Context(), "UPDATE users SET last_seen_at = $2 WHERE id = $1",
+		stale.ID(), s.Clock.Now().AddDate(0, 0, -100))
+	require.NoError(t, err)
+	fresh := s.SeedStudent(t, fixtures.TestStudent.Email, s.SeedGroup(t))
+	s.HTTP.GetStudentProfile(t, s.login(t, fixtures.TestStudent.Email)).RequireStatus(http.StatusOK)
+
+	// The listing request touches the staff member as well.
+	s.HTTP.ListInactiveUsers(t, "", 1, asStaff).RequireStatus(http.StatusOK)
+	require.NoError(t, s.LastSeen.Flush(t.Context()))
+
+	var res listInactiveUsersResponse
+	s.HTTP.ListInactiveUsers(t, "90d", 1, asStaff).RequireStatus(http.StatusOK).RequireParseJSON(&res)
+	assert.Equal(t, []string{never.ID().String(), stale.ID().String()}, res.ids(), "the users never seen first")
+	assert.Nil(t, res.Users[0].LastSeenAt)
+	require.NotNil(t, res.Users[1].LastSeenAt)
+	assert.NotContains(t, res.ids(), fresh.User().ID().String())
+
+	s.HTTP.ListInactiveUsers(t, "120d", 1, asStaff).RequireStatus(http.StatusOK).RequireParseJSON(&res)
+	assert.Equal(t, []string{never.ID().String()}, res.ids())
+
+	s.HTTP.ListInactiveUsers(t, "90d", 2, asStaff).RequireStatus(http.StatusOK).RequireParseJSON(&res)
+	assert.Empty(t, res.Users)
+	assert.Equal(t, 2, res.Meta.Page)
+
+	var found struct {
+		Users []searchquery.UserHit `json:"users"`
+	}
+	s.HTTP.ListUsers(t, fresh.User().Username(), "", asStaff).RequireStatus(http.StatusOK).RequireParseJSON(&found)
+	require.Len(t, found.Users, 1)
+	assert.NotNil(t, found.Users[0].LastSeenAt, "the directory shows the last seen time")
+}
+
+func (s *LastSeenSuite) TestListInactiveUsers_FailPath() {
+	t := s.T()
+	student := s.SeedStudent(t, fixtures.TestStudent.Email, s.SeedGroup(t))
+	staff := s.SeedStaff(t, fixtures.TestStaff.Email)
+
+	s.HTTP.ListInactiveUsers(t, "90d", 1, httpframework.WithStudent(t, student.User().ID())).
+		AssertStatus(http.StatusForbidden)
+	for _, since := range []string{"90", "0d", "-5d", "3w", "10000d"} {
+		s.HTTP.ListInactiveUsers(t, since, 1, httpframework.WithStaff(t, staff.User().ID())).
+			AssertStatus(http.StatusBadRequest)
+	}
+}
@@ -1,6 +1,10 @@
 package user
 
 import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"net/http"
 	"testing"
 	"time"
@@ -23,7 +27,9 @@ type UpdateAvatarSuite struct {
 }
 
 func TestUserSuite(t *testing.T) {
-	suite.Run(t, new(UpdateAvatarSuite))
+	suite.Run(t, &UpdateAvatarSuite{IntegrationTestSuite: framework.IntegrationTestSuite{
+		Options: framework.SuiteOptions{Storage: true},
+	}})
 }
 
 func (s *UpdateAvatarSuite) TestUpdateUserAvatar_HappyPath() {
@@ -46,7 +52,7 @@ func (s *UpdateAvatarSuite) TestUpdateUserAvatar_HappyPath() {
 			s.HTTP.UpdateUserAvatar(t, tt.file, httpframework.WithStudent(t, u.ID())).
 				RequireStatus(http.StatusOK)
 
-			dbUser := s.DB.RequireUserExists(t, u.Email()).
+			dbUser := s.DB.RequireUserExists(t, u.Email().String()).
 				AssertUpdatedAtWithin(time.Now(), time.Minute).
 				AssertAvatarNotEmpty().
 				User()
@@ -84,7 +90,7 @@ func (s *UpdateAvatarSuite) TestUpdateUserAvatar_ValidFormats() {
 			)
 			resp.AssertStatus(http.StatusOK)
 
-			dbUser := s.DB.RequireUserExists(t, u.Email()).
+			dbUser := s.DB.RequireUserExists(t, u.Email().String()).
 				AssertUpdatedAtWithin(time.Now(), time.Minute).
 				AssertAvatarNotEmpty().
 				User()
@@ -181,7 +187,7 @@ func (s *UpdateAvatarSuite) TestUpdateUserAvatar_SizeValidation() {
 			resp.AssertStatus(tt.expectedStatus)
 
 			if tt.expectedStatus == http.StatusOK {
-				dbUser := s.DB.RequireUserExists(t, u.Email()).
+				dbUser := s.DB.RequireUserExists(t, u.Email().String()).
 					AssertUpdatedAtWithin(time.Now(), time.Minute).
 					AssertAvatarNotEmpty().
 					User()
@@ -346,7 +352,7 @@ func (s *UpdateAvatarSuite) TestUpdateUserAvatar_ExistingAvatarReplacement() {
 	)
 	resp.AssertStatus(http.StatusOK)
 
-	dbUser := s.DB.RequireUserExists(t, u.Email()).
+	dbUser := s.DB.RequireUserExists(t, u.Email().String()).
 		AssertUpdatedAtWithin(time.Now(), time.Minute).
 		AssertAvatarNotEmpty().
 		User()
@@ -374,21 +380,19 @@ func (s *UpdateAvatarSuite) TestDeleteUserAvatar_HappyPath() {
 	u := builders.NewUserBuilder().Build()
 	s.DB.SeedUser(t, u)
 
-	validAvatar := fixtures.GetAvatarByKey("valid_png")
-	require.NotNil(t, validAvatar)
-
+	// Avatars are shared by content, upload one nobody else references.
 	s.HTTP.UpdateUserAvatarWithFile(
 		t,
-		"new_avatar.png",
-		validAvatar.ContentType,
-		validAvatar.Data,
+		"new_avatar.jpg",
+		"image/jpeg",
+		fixtures.UniqueJPEGAvatar(),
 		httpframework.WithUserJWT(t, u.ID()),
 	).
 		RequireStatus(http.StatusOK)
 
 	s.HTTP.DeleteUserAvatar(t, httpframework.WithUserJWT(t, u.ID())).
 		RequireStatus(http.StatusOK)
-	s.DB.RequireUserExists(t, u.Email()).
+	s.DB.RequireUserExists(t, u.Email().String()).
 		AssertEmptyAvatar()
 
 	e := event.RequireEventuallyEvent[*user.UserAvatarUpdated](t, s.Event, 5*time.Second)
@@ -454,3 +458,130 @@ func (s *UpdateAvatarSuite) TestDeleteUserAvatar_NotFound() {
 	s.HTTP.DeleteUserAvatar(t, httpframework.WithUserJWT(t, u.ID())).
 		RequireStatus(http.StatusNotFound)
 }
+
+func (s *UpdateAvatarSuite) TestUpdateUserAvatar_DedupesIdenticalContent() {
+	t := s.T()
+	first := builders.NewUserBuilder().Build()
+	second := builders.NewUserBuilder().Build()
+	s.DB.SeedUser(t, first)
+	s.DB.SeedUser(t, second)
+
+	avatar := fixtures.UniqueJPEGAvatar()
+	sum := sha256.Sum256(avatar)
+	wantKey := user.AvatarKeyPrefix + hex.EncodeToString(sum[:])
+
+	s.HTTP.UpdateUserAvatar(t, avatar, httpframework.WithStudent(t, first.ID())).
+		RequireStatus(http.StatusOK)
+	s.HTTP.UpdateUserAvatar(t, avatar, httpframework.WithStudent(t, second.ID())).
+		RequireStatus(http.StatusOK)
+
+	firstDB := s.DB.RequireUserExists(t, first.Email().String()).User()
+	secondDB := s.DB.RequireUserExists(t, second.Email().String()).User()
+	assert.Equal(t, wantKey, firstDB.Avatar().S3Key, "avatar key should be the content hash")
+	assert.Equal(t, wantKey, secondDB.Avatar().S3Key, "users with the same photo should share one object")
+
+	objects, _, err := s.S3Client.ListObjects(t.Context(), wantKey, "", 10)
+	require.NoError(t, err)
+	assert.Len(t, objects, 1)
+}
+
+func (s *UpdateAvatarSuite) TestDeleteUserAvatar_SharedObjectSurvives() {
+	t := s.T()
+	first := builders.NewUserBuilder().Build()
+	second := builders.NewUserBuilder().Build()
+	s.DB.SeedUser(t, first)
+	s.DB.SeedUser(t, second)
+
+	avatar := fixtures.UniqueJPEGAvatar()
+	s.HTTP.UpdateUserAvatar(t, avatar, httpframework.WithStudent(t, first.ID())).
+		RequireStatus(http.StatusOK)
+	s.HTTP.UpdateUserAvatar(t, avatar, httpframework.WithStudent(t, second.ID())).
+		RequireStatus(http.StatusOK)
+	key := s.DB.RequireUserExists(t, first.Email().String()).User().Avatar().S3Key
+
+	s.HTTP.DeleteUserAvatar(t, httpframework.WithUserJWT(t, first.ID())).
+		RequireStatus(http.StatusOK)
+	e := event.RequireEventuallyEvent[*user.UserAvatarUpdated](t, s.Event, 5*time.Second)
+	require.Equal(t, key, e.OldAvatar.S3Key)
+	// Give the handler a moment, the object must still be there afterwards.
+	time.Sleep(time.Second)
+	s.S3.RequireFile(t, key)
+
+	s.HTTP.DeleteUserAvatar(t, httpframework.WithUserJWT(t, second.ID())).
+		RequireStatus(http.StatusOK)
+	s.S3.RequireEventuallyNoFile(t, key)
+}
+
+func (s *UpdateAvatarSuite) TestUpdateUserAvatar_ChecksumMismatch() {
+	t := s.T()
+	u := builders.NewUserBuilder().WithEmptyAvatar().Build()
+	s.DB.SeedUser(t, u)
+
+	avatar := fixtures.UniqueJPEGAvatar()
+	otherSum := md5.Sum([]byte("different content"))
+
+	s.HTTP.UpdateUserAvatar(t, avatar,
+		httpframework.WithStudent(t, u.ID()),
+		httpframework.WithRequestHeader("Content-MD5", base64.StdEncoding.EncodeToString(otherSum[:])),
+	).
+		RequireStatus(http.StatusUnprocessableEntity)
+
+	s.DB.RequireUserExists(t, u.Email().String()).AssertEmptyAvatar()
+	sum := sha256.Sum256(avatar)
+	s.S3.RequireNoFile(t, user.AvatarKeyPrefix+hex.EncodeToString(sum[:]))
+
+	matching := md5.Sum(avatar)
+	s.HTTP.UpdateUserAvatar(t, avatar,
+		httpframework.WithStudent(t, u.ID()),
+		httpframework.WithRequestHeader("Content-MD5", base64.StdEncoding.EncodeToString(matching[:])),
+	).
+		RequireStatus(http.StatusOK)
+}
+
+func (s *UpdateAvatarSuite) TestUpdateUserAvatar_RejectsUnsafeImages() {
+	t := s.T()
+	u := builders.NewUserBuilder().WithEmptyAvatar().Build()
+	s.DB.SeedUser(t, u)
+
+	tests := []struct {
+		name        string
+		contentType string
+		fileData    []byte
+		message     string
+	}{
+		{
+			name:        "animated_gif",
+			contentType: "image/gif",
+			fileData:    fixtures.AnimatedGIFAvatar,
+			message:     "Animated images are not supported",
+		},
+		{
+			name:        "decompression_bomb",
+			contentType: "image/png",
+			fileData:    fixtures.HugePNGAvatar,
+			message:     "must not exceed 8000x8000 pixels",
+		},
+		{
+			name:        "content_type_mismatch",
+			contentType: "image/png",
+			fileData:    fixtures.ValidJPEGAvatar,
+			message:     "not a valid image",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s.HTTP.UpdateUserAvatarWithFile(
+				t,
+				"avatar",
+				tt.contentType,
+				tt.fileData,
+				httpframework.WithStudent(t, u.ID()),
+			).
+				AssertStatus(http.StatusUnprocessableEntity).
+				AssertContainsMessage(tt.message)
+		})
+	}
+
+	s.DB.RequireUserExists(t, u.Email().String()).AssertEmptyAvatar()
+}